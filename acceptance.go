@@ -0,0 +1,329 @@
+package bpfschain
+
+import (
+	"fmt"
+	"runtime"
+	"sync"
+
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/qinglongcn/bpfschain/txscript"
+)
+
+// RejectCode 表示交易被内存池拒绝的原因。
+type RejectCode uint8
+
+const (
+	// RejectMalformed 表示交易的结构无效，例如没有输入或输出，或者是 coinbase 交易。
+	RejectMalformed RejectCode = iota
+
+	// RejectMissingInputs 表示无法获取交易花费的输出。
+	RejectMissingInputs
+
+	// RejectNonStandard 表示交易的输出脚本、签名脚本或见证不符合标准策略。
+	RejectNonStandard
+
+	// RejectTooManySigOps 表示交易的签名操作成本超过 txscript.MaxStandardTxSigOpsCost。
+	RejectTooManySigOps
+
+	// RejectTooCostly 表示交易的估计验证成本超过 TxAcceptanceChecker.MaxValidationCost。
+	RejectTooCostly
+
+	// RejectInvalidScript 表示输入的脚本验证失败。
+	RejectInvalidScript
+
+	// RejectInternal 表示本地环境或调用方的错误使输入无法被验证，例如被花费输出的获取器无法提供计算签名哈希所需的输出。
+	// 它不说明交易无效，不能据此惩罚转发交易的节点。
+	RejectInternal
+)
+
+// rejectCodeNames 包含每种拒绝原因的名称。
+var rejectCodeNames = []string{
+	RejectMalformed:     "RejectMalformed",
+	RejectMissingInputs: "RejectMissingInputs",
+	RejectNonStandard:   "RejectNonStandard",
+	RejectTooManySigOps: "RejectTooManySigOps",
+	RejectTooCostly:     "RejectTooCostly",
+	RejectInvalidScript: "RejectInvalidScript",
+	RejectInternal:      "RejectInternal",
+}
+
+// String 返回拒绝原因的名称。
+func (c RejectCode) String() string {
+	if int(c) >= len(rejectCodeNames) {
+		return fmt.Sprintf("RejectCode(%d)", uint8(c))
+	}
+	return rejectCodeNames[c]
+}
+
+// TxRejection 描述交易被拒绝的原因，由 AdmitTransaction 作为错误返回。
+type TxRejection struct {
+	// Code 是拒绝的原因。
+	Code RejectCode
+
+	// Input 是导致拒绝的输入的索引，与具体输入无关时为 -1。
+	Input int
+
+	// Output 是导致拒绝的输出的索引，与具体输出无关时为 -1。
+	Output int
+
+	// Reason 是拒绝原因的可读描述。
+	Reason string
+
+	// Err 是导致拒绝的底层错误，例如脚本引擎返回的 txscript.Error，可以为 nil。
+	Err error
+}
+
+// Error 实现 error 接口。
+func (r *TxRejection) Error() string {
+	switch {
+	case r.Input >= 0:
+		return fmt.Sprintf("%v: input %d: %s", r.Code, r.Input, r.Reason)
+	case r.Output >= 0:
+		return fmt.Sprintf("%v: output %d: %s", r.Code, r.Output, r.Reason)
+	}
+	return fmt.Sprintf("%v: %s", r.Code, r.Reason)
+}
+
+// Unwrap 返回导致拒绝的底层错误。
+func (r *TxRejection) Unwrap() error {
+	return r.Err
+}
+
+// InputVerifier 验证交易 tx 的输入 idx 是否被授权花费 prevOut，未通过时返回错误。
+type InputVerifier func(tx *wire.MsgTx, idx int, prevOut *wire.TxOut) error
+
+// TxAcceptanceChecker 在交易进入内存池之前检查交易的脚本，将输出脚本策略、签名脚本策略、签名操作成本、
+// 验证成本估计和输入脚本的并发验证组合为一次 AdmitTransaction 调用。
+//
+// 导出字段应在第一次调用 AdmitTransaction 之前设置。 AdmitTransaction 可以被多个协程同时调用，
+// 前提是 PrevOuts 是并发安全的。
+type TxAcceptanceChecker struct {
+	// PrevOuts 提供交易花费的输出。
+	PrevOuts txscript.PrevOutputFetcher
+
+	// Flags 是 AdmitTransaction 验证输入脚本使用的标志，为 0 时每个输入使用 txscript.DefaultVerifyFlags 为其花费的输出选择的标志。
+	Flags txscript.ScriptFlags
+
+	// VerifyInput 验证输入的签名，用于签名字段不是签名脚本的链，为 nil 时由脚本引擎执行输入的脚本。
+	// 设置时不执行脚本，也不按 txscript.CheckSigScriptStandard 检查签名脚本，它返回的错误都视为输入验证失败。
+	VerifyInput InputVerifier
+
+	// SigCache 是验证签名使用的签名缓存，可以为 nil。
+	SigCache *txscript.SigCache
+
+	// EngineOpts 是创建脚本引擎时使用的选项，例如 txscript.WithChainLimits。
+	EngineOpts []txscript.EngineOpt
+
+	// ChainLimits 是检查输出脚本和交易脚本的大小时使用的限制，零值时使用 txscript.DefaultChainLimits()。
+	// 脚本引擎使用的限制由 EngineOpts 指定。
+	ChainLimits txscript.ChainLimits
+
+	// MaxValidationCost 是交易允许的最大估计验证成本，为 0 时不限制。
+	MaxValidationCost uint64
+
+	// Workers 是同时验证输入脚本的协程数量，不大于零时使用 runtime.NumCPU()。
+	Workers int
+}
+
+// NewTxAcceptanceChecker 返回使用 prevOuts 获取被花费输出、使用 sigCache 缓存签名验证结果的检查器。
+func NewTxAcceptanceChecker(prevOuts txscript.PrevOutputFetcher,
+	sigCache *txscript.SigCache) *TxAcceptanceChecker {
+
+	return &TxAcceptanceChecker{
+		PrevOuts: prevOuts,
+		SigCache: sigCache,
+	}
+}
+
+// AdmitTransaction 检查 tx 是否可以进入内存池，可以时返回 nil，否则返回 *TxRejection。 检查按以下顺序进行，
+// 返回第一个失败的检查：
+//
+//   - 交易不是 coinbase 交易，并且至少有一个输入和一个输出
+//   - 每个输出脚本都是标准的
+//   - txscript.CheckTransactionScriptsSanity 没有发现违规，包括签名操作成本不超过 txscript.MaxStandardTxSigOpsCost
+//   - VerifyInput 为 nil 时，每个签名脚本都通过 txscript.CheckSigScriptStandard
+//   - MaxValidationCost 不为 0 时，估计验证成本不超过它
+//   - 所有输入的脚本验证通过，输入由 Workers 个协程并发验证，失败时报告索引最小的失败输入；
+//     脚本引擎因 txscript.IsInternalError 的错误而无法验证输入时报告 RejectInternal
+func (c *TxAcceptanceChecker) AdmitTransaction(tx *wire.MsgTx) error {
+	if len(tx.TxIn) == 0 {
+		return reject(RejectMalformed, -1, -1, nil, "transaction has no "+
+			"inputs")
+	}
+	if len(tx.TxOut) == 0 {
+		return reject(RejectMalformed, -1, -1, nil, "transaction has no "+
+			"outputs")
+	}
+	if len(tx.TxIn) == 1 &&
+		tx.TxIn[0].PreviousOutPoint.Index == wire.MaxPrevOutIndex &&
+		tx.TxIn[0].PreviousOutPoint.Hash == (chainhash.Hash{}) {
+
+		return reject(RejectMalformed, -1, -1, nil, "coinbase transaction "+
+			"is not accepted as an individual transaction")
+	}
+
+	limits := c.ChainLimits
+	if limits == (txscript.ChainLimits{}) {
+		limits = txscript.DefaultChainLimits()
+	}
+	for i, txOut := range tx.TxOut {
+		if err := checkOutputStandard(txOut.PkScript, &limits); err != nil {
+			return reject(RejectNonStandard, -1, i, err, "%v", err)
+		}
+	}
+
+	violations := limits.CheckTransactionScriptsSanity(tx, c.PrevOuts)
+	if len(violations) != 0 {
+		v := violations[0]
+		code := RejectNonStandard
+		switch v.Kind {
+		case txscript.TxScriptMissingPrevOut:
+			code = RejectMissingInputs
+		case txscript.TxScriptTooManySigOps:
+			code = RejectTooManySigOps
+		}
+		return reject(code, v.Input, -1, v, "%s", v.Description)
+	}
+
+	// Every previous output is known from here on, since the sanity checks
+	// above reject transactions spending unknown outputs.
+	prevOuts := make([]*wire.TxOut, len(tx.TxIn))
+	for i, txIn := range tx.TxIn {
+		prevOuts[i] = c.PrevOuts.FetchPrevOutput(txIn.PreviousOutPoint)
+		if c.VerifyInput != nil {
+			continue
+		}
+		violations := txscript.CheckSigScriptStandard(
+			txIn.SignatureScript, prevOuts[i].PkScript,
+		)
+		if len(violations) != 0 {
+			return reject(RejectNonStandard, i, -1, violations[0], "%v",
+				violations[0])
+		}
+	}
+
+	if c.MaxValidationCost != 0 {
+		estimator := txscript.NewValidationCostEstimator(c.PrevOuts)
+		cost, err := estimator.EstimateTx(tx)
+		if err != nil {
+			return reject(RejectNonStandard, -1, -1, err, "%v", err)
+		}
+		if cost.Cost > c.MaxValidationCost {
+			return reject(RejectTooCostly, -1, -1, nil, "estimated "+
+				"validation cost %d exceeds the max of %d", cost.Cost,
+				c.MaxValidationCost)
+		}
+	}
+
+	return c.validateInputs(tx, prevOuts, func(prevOut *wire.TxOut) txscript.ScriptFlags {
+		if c.Flags == 0 {
+			return txscript.DefaultVerifyFlags(prevOut.PkScript)
+		}
+		return c.Flags
+	})
+}
+
+// VerifyInputScripts 按 flags 执行 tx 所有输入的脚本，不检查交易结构和标准策略，用于按共识规则验证区块中的交易。
+// flags 应当只包含共识标志，为 0 时也不会替换为 txscript.DefaultVerifyFlags 的策略标志，Flags 字段不被使用。
+// 无法获取被花费的输出时返回 RejectMissingInputs 的 *TxRejection，脚本验证失败时返回 RejectInvalidScript 的 *TxRejection，
+// 本地环境的错误返回 RejectInternal 的 *TxRejection。
+func (c *TxAcceptanceChecker) VerifyInputScripts(tx *wire.MsgTx,
+	flags txscript.ScriptFlags) error {
+
+	prevOuts := make([]*wire.TxOut, len(tx.TxIn))
+	for i, txIn := range tx.TxIn {
+		prevOuts[i] = c.PrevOuts.FetchPrevOutput(txIn.PreviousOutPoint)
+		if prevOuts[i] == nil {
+			return reject(RejectMissingInputs, i, -1, nil, "previous "+
+				"output %v not found", txIn.PreviousOutPoint)
+		}
+	}
+
+	return c.validateInputs(tx, prevOuts, func(*wire.TxOut) txscript.ScriptFlags {
+		return flags
+	})
+}
+
+// validateInputs 并发执行 tx 所有输入的脚本，prevOuts 是各输入花费的输出，flagsFor 返回花费 prevOut 的输入使用的标志。
+func (c *TxAcceptanceChecker) validateInputs(tx *wire.MsgTx,
+	prevOuts []*wire.TxOut,
+	flagsFor func(prevOut *wire.TxOut) txscript.ScriptFlags) error {
+
+	workers := c.Workers
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+	if workers > len(tx.TxIn) {
+		workers = len(tx.TxIn)
+	}
+
+	var sigHashes *txscript.TxSigHashes
+	if c.VerifyInput == nil {
+		sigHashes = txscript.NewTxSigHashes(tx, c.PrevOuts)
+	}
+	errs := make([]error, len(tx.TxIn))
+	inputs := make(chan int)
+
+	var wg sync.WaitGroup
+	wg.Add(workers)
+	for w := 0; w < workers; w++ {
+		go func() {
+			defer wg.Done()
+			for i := range inputs {
+				flags := flagsFor(prevOuts[i])
+				errs[i] = c.validateInput(
+					tx, i, prevOuts[i], flags, sigHashes,
+				)
+			}
+		}()
+	}
+	for i := range tx.TxIn {
+		inputs <- i
+	}
+	close(inputs)
+	wg.Wait()
+
+	for i, err := range errs {
+		if err == nil {
+			continue
+		}
+		code := RejectInvalidScript
+		if c.VerifyInput == nil && txscript.IsInternalError(err) {
+			code = RejectInternal
+		}
+		return reject(code, i, -1, err, "%v", err)
+	}
+	return nil
+}
+
+// validateInput 按 flags 执行 tx 的输入 idx 的脚本，prevOut 是该输入花费的输出。 设置 VerifyInput 时改为调用它。
+func (c *TxAcceptanceChecker) validateInput(tx *wire.MsgTx, idx int,
+	prevOut *wire.TxOut, flags txscript.ScriptFlags,
+	sigHashes *txscript.TxSigHashes) error {
+
+	if c.VerifyInput != nil {
+		return c.VerifyInput(tx, idx, prevOut)
+	}
+	vm, err := txscript.NewEngine(
+		prevOut.PkScript, tx, idx, flags, c.SigCache, sigHashes,
+		prevOut.Value, c.PrevOuts, c.EngineOpts...,
+	)
+	if err != nil {
+		return err
+	}
+	return vm.Execute()
+}
+
+// reject 返回描述拒绝原因的 TxRejection。
+func reject(code RejectCode, input, output int, err error, format string,
+	args ...interface{}) *TxRejection {
+
+	return &TxRejection{
+		Code:   code,
+		Input:  input,
+		Output: output,
+		Reason: fmt.Sprintf(format, args...),
+		Err:    err,
+	}
+}
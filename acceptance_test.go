@@ -0,0 +1,306 @@
+package bpfschain
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/qinglongcn/bpfschain/txscript"
+	"github.com/stretchr/testify/require"
+)
+
+// acceptanceTestTx 返回花费两个 P2WPKH 输出的已签名交易，以及提供这两个输出的获取器。
+func acceptanceTestTx(t *testing.T) (*wire.MsgTx, *txscript.MultiPrevOutFetcher) {
+	t.Helper()
+
+	privKey, err := btcec.NewPrivateKey()
+	require.NoError(t, err)
+	pkScript, err := txscript.PayToWitnessProgramScript(
+		0, btcutil.Hash160(privKey.PubKey().SerializeCompressed()),
+	)
+	require.NoError(t, err)
+
+	const amt = 100000
+	fetcher := txscript.NewMultiPrevOutFetcher(nil)
+	tx := wire.NewMsgTx(2)
+	for i := 0; i < 2; i++ {
+		op := wire.OutPoint{Hash: chainhash.Hash{1}, Index: uint32(i)}
+		fetcher.AddPrevOut(op, wire.NewTxOut(amt, pkScript))
+		tx.AddTxIn(wire.NewTxIn(&op, nil, nil))
+	}
+	tx.AddTxOut(wire.NewTxOut(2*amt-1000, pkScript))
+
+	sigHashes := txscript.NewTxSigHashes(tx, fetcher)
+	for i := range tx.TxIn {
+		tx.TxIn[i].Witness, err = txscript.WitnessSignature(
+			tx, sigHashes, i, amt, pkScript, txscript.SigHashAll,
+			privKey, true,
+		)
+		require.NoError(t, err)
+	}
+
+	return tx, fetcher
+}
+
+// TestAdmitTransaction 测试 AdmitTransaction 接受有效交易，并为每种被拒绝的交易返回相应的拒绝原因和位置。
+func TestAdmitTransaction(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		mutate func(*wire.MsgTx, *TxAcceptanceChecker)
+		code   RejectCode
+		input  int
+		output int
+	}{{
+		name:   "valid",
+		mutate: func(*wire.MsgTx, *TxAcceptanceChecker) {},
+	}, {
+		name: "coinbase",
+		mutate: func(tx *wire.MsgTx, _ *TxAcceptanceChecker) {
+			tx.TxIn = tx.TxIn[:1]
+			tx.TxIn[0].PreviousOutPoint = wire.OutPoint{
+				Index: wire.MaxPrevOutIndex,
+			}
+		},
+		code:   RejectMalformed,
+		input:  -1,
+		output: -1,
+	}, {
+		name: "non-standard output",
+		mutate: func(tx *wire.MsgTx, _ *TxAcceptanceChecker) {
+			tx.TxOut[0].PkScript = []byte{txscript.OP_TRUE}
+		},
+		code:   RejectNonStandard,
+		input:  -1,
+		output: 0,
+	}, {
+		name: "missing input",
+		mutate: func(tx *wire.MsgTx, _ *TxAcceptanceChecker) {
+			tx.TxIn[1].PreviousOutPoint.Index = 7
+		},
+		code:   RejectMissingInputs,
+		input:  1,
+		output: -1,
+	}, {
+		name: "signature script on witness spend",
+		mutate: func(tx *wire.MsgTx, _ *TxAcceptanceChecker) {
+			tx.TxIn[0].SignatureScript = []byte{txscript.OP_1}
+		},
+		code:   RejectNonStandard,
+		input:  0,
+		output: -1,
+	}, {
+		name: "too costly",
+		mutate: func(_ *wire.MsgTx, c *TxAcceptanceChecker) {
+			c.MaxValidationCost = 1
+		},
+		code:   RejectTooCostly,
+		input:  -1,
+		output: -1,
+	}, {
+		name: "invalid signature",
+		mutate: func(tx *wire.MsgTx, _ *TxAcceptanceChecker) {
+			tx.TxIn[1].Witness[0][10] ^= 0x01
+		},
+		code:   RejectInvalidScript,
+		input:  1,
+		output: -1,
+	}}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			tx, fetcher := acceptanceTestTx(t)
+			checker := NewTxAcceptanceChecker(
+				fetcher, txscript.NewSigCache(10),
+			)
+			checker.Workers = 2
+			test.mutate(tx, checker)
+
+			err := checker.AdmitTransaction(tx)
+			if test.name == "valid" {
+				require.NoError(t, err)
+				return
+			}
+
+			var rejection *TxRejection
+			require.ErrorAs(t, err, &rejection)
+			require.Equal(t, test.code, rejection.Code, err.Error())
+			require.Equal(t, test.input, rejection.Input)
+			require.Equal(t, test.output, rejection.Output)
+		})
+	}
+}
+
+// TestAdmitTransactionScriptError 测试脚本验证失败时可以从拒绝中取得脚本引擎返回的错误。
+func TestAdmitTransactionScriptError(t *testing.T) {
+	t.Parallel()
+
+	tx, fetcher := acceptanceTestTx(t)
+	tx.TxIn[0].Witness[1] = tx.TxIn[0].Witness[1][:32]
+
+	err := NewTxAcceptanceChecker(fetcher, nil).AdmitTransaction(tx)
+	var scriptErr txscript.Error
+	require.True(t, errors.As(err, &scriptErr), "%v", err)
+	require.Equal(t, txscript.ErrEqualVerify, scriptErr.ErrorCode)
+}
+
+// TestTxAcceptanceCheckerVerifyInput 测试设置 VerifyInput 时由它代替脚本引擎验证输入，且签名脚本不按标准策略检查。
+func TestTxAcceptanceCheckerVerifyInput(t *testing.T) {
+	t.Parallel()
+
+	pkScript, err := txscript.NewScriptBuilder().AddOp(txscript.OP_DUP).
+		AddOp(txscript.OP_HASH160).AddData(make([]byte, 20)).
+		AddOp(txscript.OP_EQUALVERIFY).AddOp(txscript.OP_CHECKSIG).Script()
+	require.NoError(t, err)
+
+	// The signature scripts push data that is not a valid P2PKH spend.
+	fetcher := txscript.NewMultiPrevOutFetcher(nil)
+	tx := wire.NewMsgTx(2)
+	for i := 0; i < 2; i++ {
+		op := wire.OutPoint{Hash: chainhash.Hash{1}, Index: uint32(i)}
+		fetcher.AddPrevOut(op, wire.NewTxOut(100000, pkScript))
+		sigScript := []byte{txscript.OP_DATA_1, byte(i)}
+		tx.AddTxIn(wire.NewTxIn(&op, sigScript, nil))
+	}
+	tx.AddTxOut(wire.NewTxOut(199000, pkScript))
+	require.Error(t, NewTxAcceptanceChecker(fetcher, nil).AdmitTransaction(tx))
+
+	checker := NewTxAcceptanceChecker(fetcher, nil)
+	checker.VerifyInput = func(tx *wire.MsgTx, idx int, _ *wire.TxOut) error {
+		if tx.TxIn[idx].SignatureScript[1] != byte(idx) {
+			return errors.New("bad signature")
+		}
+		return nil
+	}
+	require.NoError(t, checker.AdmitTransaction(tx))
+	require.NoError(t, checker.VerifyInputScripts(tx, 0))
+
+	tx.TxIn[1].SignatureScript[1] = 0
+	err = checker.AdmitTransaction(tx)
+	var rejection *TxRejection
+	require.ErrorAs(t, err, &rejection)
+	require.Equal(t, RejectInvalidScript, rejection.Code)
+	require.Equal(t, 1, rejection.Input)
+}
+
+// TestVerifyInputScriptsFlags 测试 VerifyInputScripts 只使用给定的共识标志，并将本地环境的错误报告为 RejectInternal。
+func TestVerifyInputScriptsFlags(t *testing.T) {
+	t.Parallel()
+
+	tx, fetcher := acceptanceTestTx(t)
+	tx.TxIn[0].Witness[0][10] ^= 0x01
+	checker := NewTxAcceptanceChecker(fetcher, nil)
+
+	// Without the witness rules the witness is not examined, rather than
+	// being checked under the policy flags.
+	require.NoError(t, checker.VerifyInputScripts(tx, 0))
+
+	var rejection *TxRejection
+	err := checker.VerifyInputScripts(
+		tx, txscript.ScriptBip16|txscript.ScriptVerifyWitness,
+	)
+	require.ErrorAs(t, err, &rejection)
+	require.Equal(t, RejectInvalidScript, rejection.Code)
+	require.Equal(t, 0, rejection.Input)
+
+	err = checker.VerifyInputScripts(tx, txscript.ScriptVerifyCleanStack)
+	require.ErrorAs(t, err, &rejection)
+	require.Equal(t, RejectInternal, rejection.Code, err.Error())
+	require.True(t, txscript.IsInternalError(rejection.Err))
+}
+
+// TestOptionsTxAcceptanceChecker 测试 Options 中的脚本限制被传递给检查器创建的脚本引擎。
+func TestOptionsTxAcceptanceChecker(t *testing.T) {
+	t.Parallel()
+
+	opt := DefaultOptions()
+	require.NoError(t, opt.CheckAndSetOptions())
+
+	tx, fetcher := acceptanceTestTx(t)
+	checker := opt.NewTxAcceptanceChecker(fetcher, nil, 0)
+	require.NotZero(t, checker.Flags&txscript.ScriptVerifyWitness)
+	require.Zero(t, checker.Flags&txscript.ScriptVerifyCheckSigFromStack)
+	require.NoError(t, checker.AdmitTransaction(tx))
+
+	// A chain whose witness elements cannot hold a signature rejects the
+	// same transaction, both in the sanity checks and in the engine.
+	opt.ChainLimits.MaxWitnessElementSize = 32
+	require.NoError(t, opt.CheckAndSetOptions())
+	checker = opt.NewTxAcceptanceChecker(fetcher, nil, 0)
+	var rejection *TxRejection
+	err := checker.AdmitTransaction(tx)
+	require.ErrorAs(t, err, &rejection)
+	require.Equal(t, RejectNonStandard, rejection.Code)
+	require.Equal(t, 0, rejection.Input)
+
+	err = checker.VerifyInputScripts(tx, checker.Flags)
+	var scriptErr txscript.Error
+	require.True(t, errors.As(err, &scriptErr), "%v", err)
+	require.Equal(t, txscript.ErrElementTooBig, scriptErr.ErrorCode)
+
+	// The chain tag is part of the signature hash, so the transaction signed
+	// without a tag is rejected.
+	opt.ChainLimits = txscript.BitcoinChainLimits()
+	opt.ChainTag = "bpfschain-test"
+	err = opt.NewTxAcceptanceChecker(fetcher, nil, 0).AdmitTransaction(tx)
+	require.ErrorAs(t, err, &rejection)
+	require.Equal(t, RejectInvalidScript, rejection.Code)
+
+	opt.EnableExtensionOpcodes = true
+	require.NotZero(t, opt.ScriptFlagsAt(0, nil)&
+		txscript.ScriptVerifyCheckSigFromStack)
+}
+
+// TestTxAcceptanceCheckerChainLimits 测试输出脚本按检查器的限制而不是包级默认限制识别空数据脚本。
+func TestTxAcceptanceCheckerChainLimits(t *testing.T) {
+	t.Parallel()
+
+	tx, fetcher := acceptanceTestTx(t)
+	limits := txscript.BitcoinChainLimits()
+	limits.MaxDataCarrierSize = 256
+	nullData, err := limits.NullDataScript(make([]byte, 120))
+	require.NoError(t, err)
+	tx.AddTxOut(wire.NewTxOut(0, nullData))
+
+	checker := NewTxAcceptanceChecker(fetcher, nil)
+	var rejection *TxRejection
+	err = checker.AdmitTransaction(tx)
+	require.ErrorAs(t, err, &rejection)
+	require.Equal(t, RejectNonStandard, rejection.Code)
+	require.Equal(t, 1, rejection.Output)
+
+	// The signatures do not commit to the added output, so only the
+	// output standardness check is affected by the limits.
+	checker.ChainLimits = limits
+	checker.VerifyInput = func(*wire.MsgTx, int, *wire.TxOut) error {
+		return nil
+	}
+	require.NoError(t, checker.AdmitTransaction(tx))
+	require.False(t, txscript.IsNullData(nullData))
+}
+
+// TestCheckAndSetOptionsScriptRules 测试 CheckAndSetOptions 填充缺省的脚本规则并拒绝无效的脚本规则。
+func TestCheckAndSetOptionsScriptRules(t *testing.T) {
+	t.Parallel()
+
+	opt := &Options{}
+	require.NoError(t, opt.CheckAndSetOptions())
+	require.Equal(t, txscript.BitcoinChainLimits(), opt.ChainLimits)
+	require.NotNil(t, opt.Deployments)
+
+	opt = DefaultOptions()
+	opt.ChainLimits.MaxStackSize = -1
+	require.Error(t, opt.CheckAndSetOptions())
+
+	opt = DefaultOptions()
+	opt.Deployments.Deployments = append(opt.Deployments.Deployments,
+		opt.Deployments.Deployments[0])
+	require.ErrorIs(t, opt.CheckAndSetOptions(), txscript.ErrInvalidDeployment)
+}
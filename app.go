@@ -8,6 +8,7 @@ import (
 	"github.com/bpfs/dep2p"
 
 	"github.com/bpfs/dep2p/pubsub"
+	"github.com/qinglongcn/bpfschain/txscript"
 	"github.com/sirupsen/logrus"
 	"go.uber.org/fx"
 )
@@ -32,6 +33,10 @@ func Open(opt *Options, p2p *dep2p.DeP2P, pubsub *pubsub.DeP2PPubSub) (*BC, erro
 	if err := opt.CheckAndSetOptions(); err != nil {
 		return nil, err
 	}
+	// 1.1 脚本实现自检
+	if err := txscript.SelfTest(); err != nil {
+		return nil, err
+	}
 	// 2. 本地文件夹
 	if err := initDirectories(); err != nil {
 		return nil, err
@@ -170,7 +175,7 @@ func StartBlockchainNet(lc fx.Lifecycle, input StartBlockchainNetInput) {
 	go RequestVersionAndBlocks(input.Ctx, input.Opt, input.P2P, input.PubSub, input.Chain)
 
 	// 启用协程，处理网络节点事件
-	go HandleEvents(input.P2P, input.PubSub, input.Chain, input.Pool)
+	go HandleEvents(input.Opt, input.P2P, input.PubSub, input.Chain, input.Pool)
 }
 
 // UpdateBlockchainInstance 更新区块链实例
@@ -13,7 +13,9 @@ import (
 	"syscall"
 	"time"
 
+	"github.com/btcsuite/btcd/wire"
 	"github.com/dgraph-io/badger/v4"
+	"github.com/qinglongcn/bpfschain/txscript"
 	"github.com/sirupsen/logrus"
 )
 
@@ -189,11 +191,12 @@ func (chain *Blockchain) GetBlock(blockHash []byte) (*Block, error) {
 }
 
 // MineBlock 挖掘新的区块并将其添加到区块链中
-func (chain *Blockchain) MineBlock(transactions []*Transaction) (*Block, error) {
+func (chain *Blockchain) MineBlock(opt *Options, transactions []*Transaction) (*Block, error) {
 	// 验证交易的合法性
+	height := chain.GetBestHeight() + 1
 	for _, tx := range transactions {
-		if !chain.VerifyTransaction(tx) {
-			return nil, fmt.Errorf("无效的交易: %s", tx.ID)
+		if err := chain.VerifyTransaction(opt, tx, height); err != nil {
+			return nil, fmt.Errorf("无效的交易 %x: %w", tx.ID, err)
 		}
 	}
 
@@ -313,15 +316,59 @@ func (chain *Blockchain) SignTransaction(priv *ecdsa.PrivateKey, tx *Transaction
 	tx.Sign(priv, prevTxs)
 }
 
-// VerifyTransaction 验证交易的输入签名
-func (chain *Blockchain) VerifyTransaction(tx *Transaction) bool {
+// AdmitTransaction 按 opt 中的脚本规则和标准策略检查交易能否进入内存池，脚本规则取下一个区块高度生效的规则，
+// 输入的签名按链的签名格式验证。 交易被拒绝时返回 *TxRejection。
+func (chain *Blockchain) AdmitTransaction(opt *Options, tx *Transaction) error {
+	msgTx, prevTxs, prevOuts, err := chain.scriptTx(tx)
+	if err != nil {
+		return reject(RejectMalformed, -1, -1, err, "%v", err)
+	}
+
+	height := int32(chain.GetBestHeight() + 1)
+	checker := opt.NewTxAcceptanceChecker(prevOuts, nil, height)
+	checker.VerifyInput = signatureVerifier(tx, prevTxs)
+	return checker.AdmitTransaction(msgTx)
+}
+
+// VerifyTransaction 按 opt 中在高度 height 生效的共识规则验证交易所有输入的签名，不检查标准策略。
+// 验证失败时返回 *TxRejection。
+func (chain *Blockchain) VerifyTransaction(opt *Options, tx *Transaction, height int) error {
 	if tx.IsCoinbase() {
-		return true
+		return nil
+	}
+	msgTx, prevTxs, prevOuts, err := chain.scriptTx(tx)
+	if err != nil {
+		return reject(RejectMalformed, -1, -1, err, "%v", err)
+	}
+
+	checker := NewTxAcceptanceChecker(prevOuts, nil)
+	checker.EngineOpts = opt.ScriptEngineOpts()
+	checker.VerifyInput = signatureVerifier(tx, prevTxs)
+	return checker.VerifyInputScripts(msgTx, opt.ScriptFlagsAt(int32(height), nil))
+}
+
+// scriptTx 返回交易的 wire 形式、按十六进制 ID 索引的被引用交易，以及提供其输入所花费的输出的获取器。
+// 找不到的被引用交易不会导致错误，由检查器报告缺少输入。
+func (chain *Blockchain) scriptTx(tx *Transaction) (*wire.MsgTx, map[string]Transaction, txscript.PrevOutputFetcher, error) {
+	msgTx, err := tx.MsgTx()
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	prevTxs := make(map[string]Transaction)
+	for _, in := range tx.Vin {
+		prevTx, err := chain.FindTransaction(in.ID)
+		if err != nil {
+			continue
+		}
+		prevTxs[hex.EncodeToString(prevTx.ID)] = *prevTx
+	}
+	prevOuts, err := prevOutputFetcher(tx, prevTxs)
+	if err != nil {
+		return nil, nil, nil, err
 	}
-	prevTxs := chain.getTransaction(tx)
 
-	// 验证交易输入的签名
-	return tx.Verify(prevTxs)
+	return msgTx, prevTxs, prevOuts, nil
 }
 
 // getDatabasePath 获取数据库路径
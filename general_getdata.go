@@ -165,7 +165,7 @@ func HandleFullnodesGetDataTx(ctx context.Context, p2p *dep2p.DeP2P, pubsub *pub
 	} else {
 		// 如果发送者不是矿工，则直接通过网络将交易发送给其他节点。
 		// 向指定的矿工 peer 发送交易数据
-		if err := SendMiningTx(p2p, pubsub, pool, request.Message.Sender, &tx); err != nil {
+		if err := SendMiningTx(p2p, pubsub, request.Message.Sender, &tx); err != nil {
 			logrus.Errorf("[HandleFullnodesGetDataTx] 向指定的矿工 peer 发送交易数据失败:\t%v", err)
 			return
 		}
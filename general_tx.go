@@ -13,9 +13,12 @@ import (
 )
 
 // SendFullnodesTx 向指定的全节点 peer 发送交易数据
-func SendFullnodesTx(p2p *dep2p.DeP2P, pubsub *pubsub.DeP2PPubSub, pool *MemoryPool, transaction *Transaction) error {
-	// 向交易池中添加一个新的交易
-	pool.Add(transaction)
+func SendFullnodesTx(opt *Options, p2p *dep2p.DeP2P, pubsub *pubsub.DeP2PPubSub, chain *Blockchain, pool *MemoryPool, transaction *Transaction) error {
+	// 检查交易并添加到交易池中
+	if err := pool.Admit(opt, chain, transaction); err != nil {
+		logrus.Errorf("[SendFullnodesTx] 交易被拒绝:\t%v", err)
+		return err
+	}
 
 	payloadBytes, err := EncodeToBytes(transaction)
 	if err != nil {
@@ -65,20 +68,21 @@ func HandleFullnodesTx(ctx context.Context, opt *Options, p2p *dep2p.DeP2P, pubs
 	}
 
 	// 若是全节点，只负责验证交易，并将交易放到内存池中
-	if chain.VerifyTransaction(tx) {
-		// 如果tx来自本地节点，说明本地节点不是挖矿节点，也没有挖出它，就将它加入到Pending中，成为tx类型的inv，
-		// 在其它节点请求tx时候，将本地tx发给对方处理
-		pool.Add(tx)
+	// 如果tx来自本地节点，说明本地节点不是挖矿节点，也没有挖出它，就将它加入到Pending中，成为tx类型的inv，
+	// 在其它节点请求tx时候，将本地tx发给对方处理
+	if err := pool.Admit(opt, chain, tx); err != nil {
+		logrus.Errorf("[HandleFullnodesTx] 交易被拒绝:\t%v", err)
+		return
+	}
 
-		// if isState.IsMiner { // 当前节点为矿工节点
-		// 	// 将交易移到排队队列
-		// 	pool.Move(tx, "queued")
-		// 	logrus.Info("MINING")
+	// if isState.IsMiner { // 当前节点为矿工节点
+	// 	// 将交易移到排队队列
+	// 	pool.Move(tx, "queued")
+	// 	logrus.Info("MINING")
 
-		// 	// 立即挖出排队中的所有交易
-		// 	MineTx(p2p, pubsub, chain, pool, pool.Queued, BlockchainDbPath)
-		// }
-	}
+	// 	// 立即挖出排队中的所有交易
+	// 	MineTx(p2p, pubsub, chain, pool, pool.Queued, BlockchainDbPath)
+	// }
 }
 
 // SendTxFromPool 从交易池中获取交易并发送给指定的 peer(全节点)
@@ -116,10 +120,8 @@ func SendTxFromPool(p2p *dep2p.DeP2P, pubsub *pubsub.DeP2PPubSub, peerId string,
 }
 
 // SendMiningTx 向指定的矿工 peer 发送交易数据(全节点)
-func SendMiningTx(p2p *dep2p.DeP2P, pubsub *pubsub.DeP2PPubSub, pool *MemoryPool, peerId string, transaction *Transaction) error {
-	// 向交易池中添加一个新的交易
-	pool.Add(transaction)
-
+// 交易取自交易池的挂起队列，在进入交易池时已经过检查
+func SendMiningTx(p2p *dep2p.DeP2P, pubsub *pubsub.DeP2PPubSub, peerId string, transaction *Transaction) error {
 	payloadBytes, err := EncodeToBytes(transaction)
 	if err != nil {
 		logrus.Errorf("[SendMiningTx] 编码失败:\t%v", err)
@@ -171,16 +173,14 @@ func HandleMiningTx(ctx context.Context, opt *Options, p2p *dep2p.DeP2P, pubsub
 		return
 	}
 
-	// 验证交易的输入签名
-	if !chain.VerifyTransaction(tx) {
+	// 检查交易的脚本，如果tx来自本地节点，说明本地节点不是挖矿节点，也没有挖出它，就将它加入到Pending中，成为tx类型的inv，
+	// 在其它节点请求tx时候，将本地tx发给对方处理
+	if err := pool.Admit(opt, chain, tx); err != nil {
+		logrus.Errorf("[HandleMiningTx] 交易被拒绝:\t%v", err)
 		// pool.Wg.Done()
 		return
 	}
 
-	// 如果tx来自本地节点，说明本地节点不是挖矿节点，也没有挖出它，就将它加入到Pending中，成为tx类型的inv，
-	// 在其它节点请求tx时候，将本地tx发给对方处理
-	pool.Add(tx)
-
 	// 是否为矿工节点
 	if !opt.IsMinerNode {
 		// pool.Wg.Done()
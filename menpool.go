@@ -57,9 +57,14 @@ func (memo *MemoryPool) Move(tnx *Transaction, to string) {
 	}
 }
 
-// Add 添加新的交易到交易内存池
-func (memo *MemoryPool) Add(tnx *Transaction) {
+// Admit 按 opt 中的脚本规则和标准策略检查交易，通过时将其添加到挂起的交易队列，否则返回 *TxRejection 说明拒绝原因
+func (memo *MemoryPool) Admit(opt *Options, chain *Blockchain, tnx *Transaction) error {
+	if err := chain.AdmitTransaction(opt, tnx); err != nil {
+		return err
+	}
+
 	memo.Pending[hex.EncodeToString(tnx.ID)] = *tnx
+	return nil
 }
 
 // Remove从某个队列中删除交易
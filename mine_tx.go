@@ -12,13 +12,16 @@ import (
 func MineTx(opt *Options, p2p *dep2p.DeP2P, pubsub *pubsub.DeP2PPubSub, chain *Blockchain, pool *MemoryPool, memorypoolTxs map[string]Transaction, dir string) {
 	var txs []*Transaction
 
+	height := chain.GetBestHeight() + 1
 	for id := range memorypoolTxs {
 		logrus.Infof("tx: %s \n", memorypoolTxs[id].ID)
 		tx := memorypoolTxs[id]
 
-		if chain.VerifyTransaction(&tx) {
-			txs = append(txs, &tx)
+		if err := chain.VerifyTransaction(opt, &tx, height); err != nil {
+			logrus.Errorf("[MineTx] 无效的交易 %x:\t%v", tx.ID, err)
+			continue
 		}
+		txs = append(txs, &tx)
 	}
 
 	if len(txs) == 0 {
@@ -34,7 +37,7 @@ func MineTx(opt *Options, p2p *dep2p.DeP2P, pubsub *pubsub.DeP2PPubSub, chain *B
 	txs = append(txs, cbTx)
 
 	// 挖掘新的区块并将其添加到区块链中
-	newBlock, err := chain.MineBlock(txs)
+	newBlock, err := chain.MineBlock(opt, txs)
 	if err != nil {
 		// pool.ClearAll() // 清除内存池中的全部交易
 		// pool.Wg.Done()
@@ -5,6 +5,8 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+
+	"github.com/qinglongcn/bpfschain/txscript"
 )
 
 // Options 是用于创建文件存储对象的参数
@@ -30,6 +32,18 @@ type Options struct {
 	// 折扣，0折免费；9折乘0.9付费；10折原价；20要多出一倍的钱。多的销毁，少的系统贴
 	GasDiscount float64
 
+	// ScriptFlags 是在部署计划之外始终生效的脚本验证标志
+	ScriptFlags txscript.ScriptFlags
+	// ChainLimits 是节点创建的脚本引擎和交易检查使用的共识限制，零值时使用 txscript.BitcoinChainLimits()。
+	// 限制只通过参数传递，不修改 txscript 的包级默认限制，同一进程中的多个节点可以使用不同的限制
+	ChainLimits txscript.ChainLimits
+	// ChainTag 是注入签名哈希的链域分隔标签，空标签 txscript.BitcoinChainTag 表示比特币的签名哈希
+	ChainTag txscript.ChainTag
+	// Deployments 是脚本规则的软分叉部署计划，为 nil 时使用 txscript.DefaultDeploymentSchedule()
+	Deployments *txscript.DeploymentSchedule
+	// EnableExtensionOpcodes 是否启用 bpfschain 的扩展操作码，例如 tapscript 中的 OP_CHECKSIGFROMSTACK
+	EnableExtensionOpcodes bool
+
 	Priv *ecdsa.PrivateKey // 私钥
 	Pub  []byte            // 公钥
 	// pub  *ecdsa.PublicKey  // 公钥
@@ -48,6 +62,11 @@ func DefaultOptions() *Options {
 		Subsidy:                50,
 		GasPrice:               1,
 		GasDiscount:            0.9,
+		// 脚本规则
+		ChainLimits:            txscript.BitcoinChainLimits(),
+		ChainTag:               txscript.BitcoinChainTag,
+		Deployments:            txscript.DefaultDeploymentSchedule(),
+		EnableExtensionOpcodes: false,
 		// 初始化
 		IsFullNode:    false,
 		IsMinerNode:   false,
@@ -117,5 +136,65 @@ func (opt *Options) CheckAndSetOptions() error {
 		return fmt.Errorf("'%s' 区块链实例已打开", opt.InstanceId)
 	}
 
+	// 检查脚本规则
+	if opt.ChainLimits == (txscript.ChainLimits{}) {
+		opt.ChainLimits = txscript.BitcoinChainLimits()
+	}
+	if err := opt.ChainLimits.Validate(); err != nil {
+		return fmt.Errorf("脚本限制无效: %w", err)
+	}
+	if opt.Deployments == nil {
+		opt.Deployments = txscript.DefaultDeploymentSchedule()
+	}
+	if err := opt.Deployments.Validate(); err != nil {
+		return fmt.Errorf("部署计划无效: %w", err)
+	}
+	if _, err := txscript.FormatScriptFlags(opt.ScriptFlags); err != nil {
+		return fmt.Errorf("脚本验证标志无效: %w", err)
+	}
+
 	return nil
 }
+
+// ScriptFlagsAt 返回验证高度 height 的区块中的交易时生效的脚本验证标志。 states 是只通过版本位激活的部署的状态，可以为 nil。
+func (opt *Options) ScriptFlagsAt(height int32,
+	states txscript.DeploymentStates) txscript.ScriptFlags {
+
+	flags := opt.ScriptFlags
+	if opt.Deployments != nil {
+		flags |= opt.Deployments.FlagsAt(height, states)
+	}
+	if opt.EnableExtensionOpcodes {
+		flags |= txscript.ScriptVerifyCheckSigFromStack
+	}
+	return flags
+}
+
+// ScriptEngineOpts 返回节点创建脚本引擎时使用的引擎选项，包括选项中的脚本限制和链域分隔标签。
+func (opt *Options) ScriptEngineOpts() []txscript.EngineOpt {
+	limits := opt.scriptLimits()
+	return []txscript.EngineOpt{
+		txscript.WithChainLimits(limits),
+		txscript.WithChainTag(opt.ChainTag),
+	}
+}
+
+// scriptLimits 返回选项中的脚本限制，零值时返回 txscript.BitcoinChainLimits()。
+func (opt *Options) scriptLimits() txscript.ChainLimits {
+	if opt.ChainLimits == (txscript.ChainLimits{}) {
+		return txscript.BitcoinChainLimits()
+	}
+	return opt.ChainLimits
+}
+
+// NewTxAcceptanceChecker 返回按选项中的脚本规则检查交易能否进入内存池的检查器，height 是交易预计被打包的区块高度。
+// 除了在该高度生效的共识标志，检查器还使用 txscript.StandardVerifyFlags 中的策略标志。
+func (opt *Options) NewTxAcceptanceChecker(prevOuts txscript.PrevOutputFetcher,
+	sigCache *txscript.SigCache, height int32) *TxAcceptanceChecker {
+
+	checker := NewTxAcceptanceChecker(prevOuts, sigCache)
+	checker.Flags = opt.ScriptFlagsAt(height, nil) | txscript.StandardVerifyFlags
+	checker.ChainLimits = opt.scriptLimits()
+	checker.EngineOpts = opt.ScriptEngineOpts()
+	return checker
+}
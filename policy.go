@@ -4,6 +4,7 @@ import (
 	"fmt"
 
 	"github.com/btcsuite/btcd/txscript"
+	bpfstxscript "github.com/qinglongcn/bpfschain/txscript"
 )
 
 const (
@@ -43,3 +44,16 @@ func checkPkScriptStandard(pkScript []byte, scriptClass txscript.ScriptClass) er
 
 	return nil
 }
+
+// checkOutputStandard 根据公钥脚本的类别检查交易输出脚本是否是标准的，空数据脚本按 limits 中的 MaxDataCarrierSize 识别。
+func checkOutputStandard(pkScript []byte, limits *bpfstxscript.ChainLimits) error {
+	if limits.IsNullData(pkScript) {
+		return nil
+	}
+
+	scriptClass := txscript.GetScriptClass(pkScript)
+	if scriptClass == txscript.NullDataTy {
+		return fmt.Errorf("null data script with more than %d bytes of data", limits.MaxDataCarrierSize)
+	}
+	return checkPkScriptStandard(pkScript, scriptClass)
+}
@@ -68,7 +68,7 @@ func RequestVersionAndBlocks(ctx context.Context, opt *Options, p2p *dep2p.DeP2P
 }
 
 // HandleEvents 处理网络事件
-func HandleEvents(p2p *dep2p.DeP2P, pubsub *pubsub.DeP2PPubSub, chain *Blockchain, pool *MemoryPool) {
+func HandleEvents(opt *Options, p2p *dep2p.DeP2P, pubsub *pubsub.DeP2PPubSub, chain *Blockchain, pool *MemoryPool) {
 	for {
 		select {
 		case block := <-pool.Blocks: // 如果 Blocks 队列新增数据（block数据），全网广播
@@ -78,7 +78,7 @@ func HandleEvents(p2p *dep2p.DeP2P, pubsub *pubsub.DeP2PPubSub, chain *Blockchai
 			}
 		case tnx := <-pool.Transactions: // 如果 Transactions 队列新增数据（Transaction数据），全网广播
 			// 向指定的全节点 peer 发送交易数据
-			if err := SendFullnodesTx(p2p, pubsub, pool, tnx); err != nil {
+			if err := SendFullnodesTx(opt, p2p, pubsub, chain, pool, tnx); err != nil {
 				logrus.Errorf("[HandleEvents] 向指定的全节点 peer 发送交易数据失败:\t%v", err)
 			}
 		}
@@ -216,76 +216,84 @@ func (tx *Transaction) Verify(prevTXs map[string]Transaction) bool {
 		return true // 创始区块交易无需验证
 	}
 
-	txCopy := tx.TrimmedCopy() // 创建交易的修剪副本，用于验证
 	// 迭代每个输入
-	for inId, in := range tx.Vin {
-		prevTX, exists := prevTXs[hex.EncodeToString(in.ID)]
-		if !exists {
-			logrus.Fatal("ERROR: 引用的输出的交易（作为输入）不正确")
+	for inId := range tx.Vin {
+		if !tx.verifyInput(inId, prevTXs) {
+			return false
 		}
+	}
 
-		txCopy.Vin[inId].Signature = nil // 清空副本中的签名字段
-		txCopy.Vin[inId].PubKey = prevTX.Vout[in.Vout].PubKeyHash
+	return true // 所有输入都通过验证
+}
 
-		dataToVerify := txCopy.Hash() // 计算交易副本的哈希，作为验证的数据
+// verifyInput 验证交易的第 inId 个输入的签名，可以被多个协程同时调用
+func (tx *Transaction) verifyInput(inId int, prevTXs map[string]Transaction) bool {
+	txCopy := tx.TrimmedCopy() // 创建交易的修剪副本，用于验证
+	in := tx.Vin[inId]
+	prevTX, exists := prevTXs[hex.EncodeToString(in.ID)]
+	if !exists {
+		logrus.Fatal("ERROR: 引用的输出的交易（作为输入）不正确")
+	}
 
-		// 解析签名和公钥
-		var r, s, x, y big.Int
-		sigLen := len(in.Signature)
-		r.SetBytes(in.Signature[:(sigLen / 2)])
-		s.SetBytes(in.Signature[(sigLen / 2):])
+	txCopy.Vin[inId].Signature = nil // 清空副本中的签名字段
+	txCopy.Vin[inId].PubKey = prevTX.Vout[in.Vout].PubKeyHash
 
-		// 从脚本中提取公钥
-		scriptClass, addrs, _, err := txscript.ExtractPkScriptAddrs(
-			in.PubKey, &chaincfg.MainNetParams)
-		if err != nil {
-			return false
-		}
+	dataToVerify := txCopy.Hash() // 计算交易副本的哈希，作为验证的数据
 
-		if len(addrs) == 0 {
-			return false
-		}
+	// 解析签名和公钥
+	var r, s, x, y big.Int
+	sigLen := len(in.Signature)
+	r.SetBytes(in.Signature[:(sigLen / 2)])
+	s.SetBytes(in.Signature[(sigLen / 2):])
 
-		var rawPubKey ecdsa.PublicKey
-		switch scriptClass {
-		case txscript.PubKeyHashTy: // P2PKH
-			// 对于P2PKH，地址是公钥的RIPEMD160(SHA256(pubKey))散列
-
-			// 返回将地址插入 txout 脚本时要使用的地址的原始字节。
-			addresses := addrs[0].ScriptAddress()
-			// 对地址进行Base58解码
-			pubKeyHash := Base58Decode(addresses)
-			// 去除地址的版本和校验和部分，获取公钥哈希
-			pubKeyHash = pubKeyHash[1 : len(pubKeyHash)-checkSumlength] // 计算得到公钥哈希
-
-			// 从输入中直接取出公钥数组，解析为一对长度相同的坐标
-			keyLen := len(pubKeyHash)
-			x.SetBytes(pubKeyHash[:(keyLen / 2)])
-			y.SetBytes(pubKeyHash[(keyLen / 2):])
-
-			// 从解析的坐标创建一个rawPubKey（原生态公钥）
-			rawPubKey = ecdsa.PublicKey{Curve: elliptic.P256(), X: &x, Y: &y}
-
-		case txscript.ScriptHashTy: // P2SH
-			// 对于P2SH，需要额外的信息来恢复公钥
-			// 通常，P2SH地址用于多重签名或其他复杂脚本，需要完整的赎回脚本才能验证
-			// 因此在这里可能需要额外的逻辑来处理P2SH
-
-			return false // 暂时不支持P2SH，直接返回false
-		// 处理其他类型的scriptClass
-		default:
-			return false // 不支持的或未知的scriptClass
-		}
+	// 从脚本中提取公钥
+	scriptClass, addrs, _, err := txscript.ExtractPkScriptAddrs(
+		in.PubKey, &chaincfg.MainNetParams)
+	if err != nil {
+		return false
+	}
 
-		// 使用公钥验证签名
-		if !ecdsa.Verify(&rawPubKey, dataToVerify, &r, &s) {
-			return false // 签名验证失败
-		}
+	if len(addrs) == 0 {
+		return false
+	}
 
-		txCopy.Vin[inId].PubKey = nil
+	var rawPubKey ecdsa.PublicKey
+	switch scriptClass {
+	case txscript.PubKeyHashTy: // P2PKH
+		// 对于P2PKH，地址是公钥的RIPEMD160(SHA256(pubKey))散列
+
+		// 返回将地址插入 txout 脚本时要使用的地址的原始字节。
+		addresses := addrs[0].ScriptAddress()
+		// 对地址进行Base58解码
+		pubKeyHash := Base58Decode(addresses)
+		// 去除地址的版本和校验和部分，获取公钥哈希
+		pubKeyHash = pubKeyHash[1 : len(pubKeyHash)-checkSumlength] // 计算得到公钥哈希
+
+		// 从输入中直接取出公钥数组，解析为一对长度相同的坐标
+		keyLen := len(pubKeyHash)
+		x.SetBytes(pubKeyHash[:(keyLen / 2)])
+		y.SetBytes(pubKeyHash[(keyLen / 2):])
+
+		// 从解析的坐标创建一个rawPubKey（原生态公钥）
+		rawPubKey = ecdsa.PublicKey{Curve: elliptic.P256(), X: &x, Y: &y}
+
+	case txscript.ScriptHashTy: // P2SH
+		// 对于P2SH，需要额外的信息来恢复公钥
+		// 通常，P2SH地址用于多重签名或其他复杂脚本，需要完整的赎回脚本才能验证
+		// 因此在这里可能需要额外的逻辑来处理P2SH
+
+		return false // 暂时不支持P2SH，直接返回false
+	// 处理其他类型的scriptClass
+	default:
+		return false // 不支持的或未知的scriptClass
 	}
 
-	return true // 所有输入都通过验证
+	// 使用公钥验证签名
+	if !ecdsa.Verify(&rawPubKey, dataToVerify, &r, &s) {
+		return false // 签名验证失败
+	}
+
+	return true
 }
 
 // String 返回交易的可读表示形式，便于调试和日志记录
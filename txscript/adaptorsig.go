@@ -0,0 +1,277 @@
+// 包含与 BIP0340 Schnorr 签名兼容的适配器签名（adaptor signature），用于在 taproot 花费上实现无脚本原子交换和类 DLC 协议。
+
+package txscript
+
+import (
+	"bytes"
+	"crypto/rand"
+	"errors"
+	"fmt"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcec/v2/schnorr"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/wire"
+)
+
+// AdaptorSignatureSize 是序列化的适配器签名的字节数：33 字节的压缩 nonce 点加上 32 字节的预签名标量。
+const AdaptorSignatureSize = 65
+
+// ErrInvalidAdaptorSignature 在适配器签名格式错误、与适配器点不匹配或无法完成时返回。
+var ErrInvalidAdaptorSignature = errors.New("invalid adaptor signature")
+
+// AdaptorSignature 是针对适配器点 T = t*G 加密的 Schnorr 预签名。 预签名可以被任何人验证，
+// 但只有知道秘密 t 的一方才能通过 Adapt 将其转换为有效的 BIP0340 签名；反过来，
+// 任何同时看到预签名和最终签名的人都可以通过 Extract 恢复 t。
+//
+// 最终签名的 nonce 点为 R = R' + T，其中 R' 是签名者的 nonce 点。 由于 BIP0340 要求 R 的 y 坐标为偶数，
+// 当 R 的 y 坐标为奇数时，签名者对自己的 nonce 取反，最终签名的标量为 s' - t 而不是 s' + t。
+type AdaptorSignature struct {
+	// nonce 是最终签名的完整 nonce 点 R = R' + T，保留 y 坐标的奇偶性。
+	nonce btcec.PublicKey
+
+	// s 是预签名标量 s'。
+	s btcec.ModNScalar
+}
+
+// Serialize 返回适配器签名的 65 字节编码：压缩的 nonce 点 R 后接预签名标量 s'。
+func (a *AdaptorSignature) Serialize() []byte {
+	var b [AdaptorSignatureSize]byte
+	copy(b[:33], a.nonce.SerializeCompressed())
+	a.s.PutBytesUnchecked(b[33:])
+	return b[:]
+}
+
+// ParseAdaptorSignature 解析 Serialize 生成的适配器签名。
+func ParseAdaptorSignature(b []byte) (*AdaptorSignature, error) {
+	if len(b) != AdaptorSignatureSize {
+		return nil, fmt.Errorf("%w: length %d, want %d",
+			ErrInvalidAdaptorSignature, len(b), AdaptorSignatureSize)
+	}
+
+	nonce, err := btcec.ParsePubKey(b[:33])
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidAdaptorSignature, err)
+	}
+
+	var a AdaptorSignature
+	a.nonce = *nonce
+	if overflow := a.s.SetByteSlice(b[33:]); overflow {
+		return nil, fmt.Errorf("%w: scalar exceeds group order",
+			ErrInvalidAdaptorSignature)
+	}
+	return &a, nil
+}
+
+// nonceIsOdd 返回 nonce 点 R 的 y 坐标是否为奇数，即签名者是否对其 nonce 取反。
+func (a *AdaptorSignature) nonceIsOdd() bool {
+	return a.nonce.SerializeCompressed()[0] == 0x03
+}
+
+// adaptorChallenge 返回 BIP0340 挑战 e = tagged_hash("BIP0340/challenge", R.x || P || m) mod n。
+func adaptorChallenge(nonce *btcec.PublicKey, pubKey, hash []byte) btcec.ModNScalar {
+	commitment := chainhash.TaggedHash(
+		chainhash.TagBIP0340Challenge, schnorr.SerializePubKey(nonce),
+		pubKey, hash,
+	)
+
+	var e btcec.ModNScalar
+	e.SetBytes((*[32]byte)(commitment))
+	return e
+}
+
+// EncryptedSign 使用私钥对 32 字节的消息哈希创建针对适配器点的预签名。 nonce 按照 BIP0340 使用 crypto/rand 提供的辅助随机数派生，
+// 并额外承诺适配器点，因此对同一消息使用不同适配器点不会重用 nonce。
+func EncryptedSign(privKey *btcec.PrivateKey, hash []byte,
+	adaptor *btcec.PublicKey) (*AdaptorSignature, error) {
+
+	if len(hash) != 32 {
+		return nil, fmt.Errorf("%w: message hash must be 32 bytes, got %d",
+			ErrInvalidAdaptorSignature, len(hash))
+	}
+
+	// d = private key, negated if P = d*G has an odd y coordinate.
+	d := privKey.Key
+	if d.IsZero() {
+		return nil, fmt.Errorf("%w: private key is zero",
+			ErrInvalidAdaptorSignature)
+	}
+	pubKey := privKey.PubKey()
+	if pubKey.SerializeCompressed()[0] == 0x03 {
+		d.Negate()
+	}
+	pBytes := schnorr.SerializePubKey(pubKey)
+
+	// t = bytes(d) xor tagged_hash("BIP0340/aux", a)
+	var aux [32]byte
+	if _, err := rand.Read(aux[:]); err != nil {
+		return nil, err
+	}
+	var t [32]byte
+	d.PutBytes(&t)
+	auxHash := chainhash.TaggedHash(chainhash.TagBIP0340Aux, aux[:])
+	for i := range t {
+		t[i] ^= auxHash[i]
+	}
+
+	// k' = tagged_hash("BIP0340/nonce", t || T || P || m) mod n
+	nonceHash := chainhash.TaggedHash(
+		chainhash.TagBIP0340Nonce, t[:], adaptor.SerializeCompressed(),
+		pBytes, hash,
+	)
+	var k btcec.ModNScalar
+	k.SetBytes((*[32]byte)(nonceHash))
+	if k.IsZero() {
+		return nil, fmt.Errorf("%w: generated nonce is zero",
+			ErrInvalidAdaptorSignature)
+	}
+
+	// R = k'*G + T
+	var noncePoint, adaptorPoint, r btcec.JacobianPoint
+	btcec.ScalarBaseMultNonConst(&k, &noncePoint)
+	adaptor.AsJacobian(&adaptorPoint)
+	btcec.AddNonConst(&noncePoint, &adaptorPoint, &r)
+	if (r.X.IsZero() && r.Y.IsZero()) || r.Z.IsZero() {
+		return nil, fmt.Errorf("%w: nonce point is infinity",
+			ErrInvalidAdaptorSignature)
+	}
+	r.ToAffine()
+
+	// The final nonce is -R when R has an odd y coordinate, which is
+	// achieved by negating k' here and subtracting t when adapting.
+	if r.Y.IsOdd() {
+		k.Negate()
+	}
+
+	sig := AdaptorSignature{nonce: *btcec.NewPublicKey(&r.X, &r.Y)}
+	e := adaptorChallenge(&sig.nonce, pBytes, hash)
+
+	// s' = k + e*d
+	sig.s.Mul2(&e, &d).Add(&k)
+
+	return &sig, nil
+}
+
+// Verify 返回预签名是否为 pubKey 针对 hash 和适配器点 adaptor 创建的有效预签名。
+// 验证通过意味着一旦知道适配器点的离散对数，就能将其转换为有效的 BIP0340 签名。
+func (a *AdaptorSignature) Verify(hash []byte, pubKey,
+	adaptor *btcec.PublicKey) bool {
+
+	if len(hash) != 32 {
+		return false
+	}
+
+	// BIP0340 public keys always have an even y coordinate.
+	pBytes := schnorr.SerializePubKey(pubKey)
+	evenPubKey, err := schnorr.ParsePubKey(pBytes)
+	if err != nil {
+		return false
+	}
+	e := adaptorChallenge(&a.nonce, pBytes, hash)
+
+	// R' = R - T
+	var r, negAdaptor, expected btcec.JacobianPoint
+	a.nonce.AsJacobian(&r)
+	adaptor.AsJacobian(&negAdaptor)
+	negAdaptor.Y.Negate(1).Normalize()
+	btcec.AddNonConst(&r, &negAdaptor, &expected)
+	if expected.Z.IsZero() {
+		return false
+	}
+	expected.ToAffine()
+	if a.nonceIsOdd() {
+		expected.Y.Negate(1).Normalize()
+	}
+
+	// s'*G - e*P must equal R' (or -R' when the signer negated its nonce).
+	var sG, eP, got btcec.JacobianPoint
+	btcec.ScalarBaseMultNonConst(&a.s, &sG)
+	var p btcec.JacobianPoint
+	evenPubKey.AsJacobian(&p)
+	e.Negate()
+	btcec.ScalarMultNonConst(&e, &p, &eP)
+	btcec.AddNonConst(&sG, &eP, &got)
+	if got.Z.IsZero() {
+		return false
+	}
+	got.ToAffine()
+
+	return got.X.Equals(&expected.X) && got.Y.Equals(&expected.Y)
+}
+
+// Adapt 使用适配器点的秘密 t 将预签名转换为有效的 BIP0340 签名。 秘密错误时返回的签名无法通过验证。
+func Adapt(presig *AdaptorSignature, secret *btcec.PrivateKey) *schnorr.Signature {
+	t := secret.Key
+	if presig.nonceIsOdd() {
+		t.Negate()
+	}
+
+	s := presig.s
+	s.Add(&t)
+
+	var r btcec.JacobianPoint
+	presig.nonce.AsJacobian(&r)
+	return schnorr.NewSignature(&r.X, &s)
+}
+
+// Extract 根据预签名和由其生成的最终签名恢复适配器点的秘密 t。 如果最终签名不是由该预签名生成的，或者恢复的秘密与适配器点不符，则返回错误。
+func Extract(sig *schnorr.Signature, presig *AdaptorSignature,
+	adaptor *btcec.PublicKey) (*btcec.PrivateKey, error) {
+
+	sigBytes := sig.Serialize()
+	if !bytes.Equal(sigBytes[:32], schnorr.SerializePubKey(&presig.nonce)) {
+		return nil, fmt.Errorf("%w: signature nonce does not match "+
+			"pre-signature", ErrInvalidAdaptorSignature)
+	}
+
+	var s btcec.ModNScalar
+	s.SetByteSlice(sigBytes[32:])
+
+	// t = s - s', negated when the signer negated its nonce.
+	negPresig := presig.s
+	negPresig.Negate()
+	t := s
+	t.Add(&negPresig)
+	if presig.nonceIsOdd() {
+		t.Negate()
+	}
+
+	secret := btcec.PrivKeyFromScalar(&t)
+	if !secret.PubKey().IsEqual(adaptor) {
+		return nil, fmt.Errorf("%w: extracted secret does not match "+
+			"adaptor point", ErrInvalidAdaptorSignature)
+	}
+	return secret, nil
+}
+
+// RawTxInTapscriptAdaptorSignature 与 RawTxInTapscriptSignature 相同，计算 tapscript 叶子花费的签名哈希，
+// 但返回针对适配器点的预签名而不是最终签名。 接收方可以使用 CalcTapscriptSignaturehash 计算相同的签名哈希来验证预签名。
+func RawTxInTapscriptAdaptorSignature(tx *wire.MsgTx, sigHashes *TxSigHashes,
+	idx int, amt int64, pkScript []byte, tapLeaf TapLeaf,
+	hashType SigHashType, privKey *btcec.PrivateKey,
+	adaptor *btcec.PublicKey) (*AdaptorSignature, error) {
+
+	tapLeafHash := tapLeaf.TapHash()
+	sigHash, err := calcTaprootSignatureHashRaw(
+		sigHashes, hashType, tx, idx,
+		NewCannedPrevOutputFetcher(pkScript, amt),
+		WithBaseTapscriptVersion(blankCodeSepValue, tapLeafHash[:]),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return EncryptedSign(privKey, sigHash, adaptor)
+}
+
+// AdaptTapscriptSignature 使用秘密完成预签名，并返回可直接放入 tapscript 见证中的签名，
+// 非默认签名哈希类型会被追加到签名末尾。
+func AdaptTapscriptSignature(presig *AdaptorSignature,
+	secret *btcec.PrivateKey, hashType SigHashType) []byte {
+
+	sig := Adapt(presig, secret).Serialize()
+	if hashType != SigHashDefault {
+		sig = append(sig, byte(hashType))
+	}
+	return sig
+}
@@ -0,0 +1,124 @@
+package txscript
+
+import (
+	"crypto/sha256"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcec/v2/schnorr"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/stretchr/testify/require"
+)
+
+// TestAdaptorSignature 确保预签名可以被验证、完成为有效的 BIP0340 签名，并且可以从最终签名中恢复秘密。
+// 使用多个随机密钥以覆盖私钥和 nonce 点 y 坐标为奇数和偶数的情况。
+func TestAdaptorSignature(t *testing.T) {
+	t.Parallel()
+
+	for i := 0; i < 32; i++ {
+		privKey, err := btcec.NewPrivateKey()
+		require.NoError(t, err)
+		secret, err := btcec.NewPrivateKey()
+		require.NoError(t, err)
+		adaptor := secret.PubKey()
+		hash := sha256.Sum256([]byte{byte(i)})
+
+		presig, err := EncryptedSign(privKey, hash[:], adaptor)
+		require.NoError(t, err)
+		require.True(t, presig.Verify(hash[:], privKey.PubKey(), adaptor))
+
+		// 预签名本身不是有效签名，并且只对正确的消息、公钥和适配器点有效。
+		otherHash := sha256.Sum256([]byte("other"))
+		otherKey, err := btcec.NewPrivateKey()
+		require.NoError(t, err)
+		require.False(t, presig.Verify(otherHash[:], privKey.PubKey(), adaptor))
+		require.False(t, presig.Verify(hash[:], otherKey.PubKey(), adaptor))
+		require.False(t, presig.Verify(hash[:], privKey.PubKey(),
+			otherKey.PubKey()))
+
+		parsed, err := ParseAdaptorSignature(presig.Serialize())
+		require.NoError(t, err)
+		require.Equal(t, presig.Serialize(), parsed.Serialize())
+
+		sig := Adapt(parsed, secret)
+		require.True(t, sig.Verify(hash[:], privKey.PubKey()))
+
+		extracted, err := Extract(sig, presig, adaptor)
+		require.NoError(t, err)
+		require.Equal(t, secret.Serialize(), extracted.Serialize())
+
+		// 使用错误秘密完成的签名无效，并且无法从中提取秘密。
+		badSig := Adapt(presig, otherKey)
+		require.False(t, badSig.Verify(hash[:], privKey.PubKey()))
+		_, err = Extract(badSig, presig, adaptor)
+		require.ErrorIs(t, err, ErrInvalidAdaptorSignature)
+	}
+
+	_, err := ParseAdaptorSignature(make([]byte, AdaptorSignatureSize-1))
+	require.ErrorIs(t, err, ErrInvalidAdaptorSignature)
+	_, err = ParseAdaptorSignature(make([]byte, AdaptorSignatureSize))
+	require.ErrorIs(t, err, ErrInvalidAdaptorSignature)
+
+	privKey, err := btcec.NewPrivateKey()
+	require.NoError(t, err)
+	_, err = EncryptedSign(privKey, []byte{1, 2, 3}, privKey.PubKey())
+	require.ErrorIs(t, err, ErrInvalidAdaptorSignature)
+}
+
+// TestTapscriptAdaptorSignature 确保适配器签名可以用于 tapscript 叶子花费：对方验证预签名，
+// 秘密持有者完成签名并花费输出，随后对方从链上见证中提取秘密。
+func TestTapscriptAdaptorSignature(t *testing.T) {
+	t.Parallel()
+
+	signerKey, err := btcec.NewPrivateKey()
+	require.NoError(t, err)
+	internalKey, err := btcec.NewPrivateKey()
+	require.NoError(t, err)
+	secret, err := btcec.NewPrivateKey()
+	require.NoError(t, err)
+
+	leafScript, err := NewScriptBuilder().
+		AddData(schnorr.SerializePubKey(signerKey.PubKey())).
+		AddOp(OP_CHECKSIG).Script()
+	require.NoError(t, err)
+	leaf := NewBaseTapLeaf(leafScript)
+
+	tree := AssembleTaprootScriptTree(leaf)
+	rootHash := tree.RootNode.TapHash()
+	outputKey := ComputeTaprootOutputKey(internalKey.PubKey(), rootHash[:])
+	pkScript, err := PayToTaprootScript(outputKey)
+	require.NoError(t, err)
+	ctrl := tree.LeafMerkleProofs[0].ToControlBlock(internalKey.PubKey())
+	ctrlBytes, err := ctrl.ToBytes()
+	require.NoError(t, err)
+
+	const amt = 1e8
+	prevFetcher := NewCannedPrevOutputFetcher(pkScript, amt)
+	tx := createSpendingTx(nil, nil, pkScript, amt)
+	sigHashes := NewTxSigHashes(tx, prevFetcher)
+
+	for _, hashType := range []SigHashType{SigHashDefault, SigHashAll} {
+		presig, err := RawTxInTapscriptAdaptorSignature(tx, sigHashes, 0,
+			amt, pkScript, leaf, hashType, signerKey, secret.PubKey())
+		require.NoError(t, err)
+
+		sigHash, err := CalcTapscriptSignaturehash(sigHashes, hashType, tx,
+			0, prevFetcher, leaf)
+		require.NoError(t, err)
+		require.True(t, presig.Verify(sigHash, signerKey.PubKey(),
+			secret.PubKey()))
+
+		sig := AdaptTapscriptSignature(presig, secret, hashType)
+		tx.TxIn[0].Witness = wire.TxWitness{sig, leafScript, ctrlBytes}
+		vm, err := NewEngine(pkScript, tx, 0, StandardVerifyFlags, nil,
+			sigHashes, amt, prevFetcher)
+		require.NoError(t, err)
+		require.NoError(t, vm.Execute())
+
+		parsed, err := schnorr.ParseSignature(tx.TxIn[0].Witness[0][:64])
+		require.NoError(t, err)
+		extracted, err := Extract(parsed, presig, secret.PubKey())
+		require.NoError(t, err)
+		require.Equal(t, secret.Serialize(), extracted.Serialize())
+	}
+}
@@ -0,0 +1,216 @@
+// 包含地址索引构建器，从区块中提取地址到输出以及输出到花费交易的记录，并为这些记录提供适合键值存储（例如 Badger）
+// 的有序键编码，使浏览器和钱包对多重签名、taproot 等脚本以相同的规则推导地址。
+
+package txscript
+
+import (
+	"encoding/binary"
+	"fmt"
+
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/wire"
+)
+
+const (
+	// AddrIndexOutputPrefix 是地址到输出记录的键前缀。
+	AddrIndexOutputPrefix byte = 'a'
+
+	// AddrIndexSpendPrefix 是输出到花费交易记录的键前缀。
+	AddrIndexSpendPrefix byte = 's'
+
+	// outPointKeySize 是键中序列化输出点的长度：交易哈希加大端序的输出索引。
+	outPointKeySize = chainhash.HashSize + 4
+)
+
+// AddrOutputRecord 记录一个输出支付给一个地址。 支付给多个地址的输出（例如裸多重签名）为每个地址产生一条记录。
+type AddrOutputRecord struct {
+	// Address 是 EncodeAddress 编码的地址。 公钥地址（P2PK 和裸多重签名中的公钥）使用对应的 P2PKH 地址，
+	// 使同一密钥的各种输出归入同一地址。
+	Address string
+
+	// Class 是输出公钥脚本的类别，包括通过 RegisterScriptClass 注册的自定义类别。
+	Class ScriptClass
+
+	// OutPoint 是输出的位置。
+	OutPoint wire.OutPoint
+
+	// Value 是输出的金额。
+	Value int64
+
+	// Height 是包含该输出的区块的高度。
+	Height int32
+}
+
+// Key 返回记录的键：前缀、地址长度、地址和输出点。 同一地址的所有记录共享 AddrIndexOutputKeyPrefix 返回的前缀。
+func (r *AddrOutputRecord) Key() []byte {
+	key := AddrIndexOutputKeyPrefix(r.Address)
+	return appendOutPointKey(key, &r.OutPoint)
+}
+
+// ValueBytes 返回记录的值：大端序的高度、金额和脚本类别。
+func (r *AddrOutputRecord) ValueBytes() []byte {
+	value := make([]byte, 13)
+	binary.BigEndian.PutUint32(value[0:4], uint32(r.Height))
+	binary.BigEndian.PutUint64(value[4:12], uint64(r.Value))
+	value[12] = byte(r.Class)
+	return value
+}
+
+// SpendRecord 记录一个输出被某个交易的输入花费。
+type SpendRecord struct {
+	// OutPoint 是被花费的输出。
+	OutPoint wire.OutPoint
+
+	// SpendingTx 是花费交易的哈希。
+	SpendingTx chainhash.Hash
+
+	// InputIndex 是花费交易中花费该输出的输入的索引。
+	InputIndex uint32
+
+	// Height 是包含花费交易的区块的高度。
+	Height int32
+}
+
+// Key 返回记录的键：前缀和被花费的输出点。
+func (r *SpendRecord) Key() []byte {
+	key := make([]byte, 1, 1+outPointKeySize)
+	key[0] = AddrIndexSpendPrefix
+	return appendOutPointKey(key, &r.OutPoint)
+}
+
+// ValueBytes 返回记录的值：花费交易的哈希以及大端序的输入索引和高度。
+func (r *SpendRecord) ValueBytes() []byte {
+	value := make([]byte, chainhash.HashSize+8)
+	copy(value, r.SpendingTx[:])
+	binary.BigEndian.PutUint32(value[chainhash.HashSize:], r.InputIndex)
+	binary.BigEndian.PutUint32(value[chainhash.HashSize+4:],
+		uint32(r.Height))
+	return value
+}
+
+// AddrIndexOutputKeyPrefix 返回 address 的所有地址到输出记录共享的键前缀，可用于前缀迭代。 地址长度编码在地址之前，
+// 因此一个地址的前缀不会匹配以它开头的更长地址的记录。
+func AddrIndexOutputKeyPrefix(address string) []byte {
+	key := make([]byte, 0, 2+len(address)+outPointKeySize)
+	key = append(key, AddrIndexOutputPrefix, byte(len(address)))
+	return append(key, address...)
+}
+
+// appendOutPointKey 将 op 以交易哈希和大端序索引的形式追加到 key，使同一交易的输出按索引排序。
+func appendOutPointKey(key []byte, op *wire.OutPoint) []byte {
+	key = append(key, op.Hash[:]...)
+	return binary.BigEndian.AppendUint32(key, op.Index)
+}
+
+// AddrIndexBatch 是从一个区块或交易中提取的索引记录。
+type AddrIndexBatch struct {
+	// Outputs 是地址到输出的记录，按交易和输出的顺序排列。
+	Outputs []AddrOutputRecord
+
+	// Spends 是输出到花费交易的记录，按交易和输入的顺序排列。 coinbase 交易的输入不产生记录。
+	Spends []SpendRecord
+}
+
+// AddrIndexer 使用 ExtractPkScriptAddrs 从交易输出中提取地址，构建地址索引记录。
+type AddrIndexer struct {
+	chainParams *chaincfg.Params
+}
+
+// NewAddrIndexer 返回按 chainParams 编码地址的索引构建器。
+func NewAddrIndexer(chainParams *chaincfg.Params) *AddrIndexer {
+	return &AddrIndexer{chainParams: chainParams}
+}
+
+// OutputAddrs 返回 pkScript 在索引中对应的地址和脚本类别。 规则如下：
+//
+//   - P2PKH、P2SH、P2WPKH、P2WSH、taproot 和未知见证版本各对应一个地址，taproot 使用输出密钥
+//   - P2PK 和裸多重签名中每个有效的公钥对应其 P2PKH 地址，重复的公钥只出现一次
+//   - 已注册的自定义类别使用模板的 ExtractAddrs 返回的地址
+//   - 空数据和非标准脚本没有地址
+func (idx *AddrIndexer) OutputAddrs(pkScript []byte) (ScriptClass, []string) {
+	class, addrs, _, err := ExtractPkScriptAddrs(pkScript, idx.chainParams)
+	if err != nil || len(addrs) == 0 {
+		return class, nil
+	}
+
+	encoded := make([]string, 0, len(addrs))
+	seen := make(map[string]struct{}, len(addrs))
+	for _, addr := range addrs {
+		var str string
+		if pk, ok := addr.(*btcutil.AddressPubKey); ok {
+			str = pk.AddressPubKeyHash().EncodeAddress()
+		} else {
+			str = addr.EncodeAddress()
+		}
+		if _, ok := seen[str]; ok {
+			continue
+		}
+		seen[str] = struct{}{}
+		encoded = append(encoded, str)
+	}
+	return class, encoded
+}
+
+// IndexTx 返回高度为 height 的区块中的交易 tx 的索引记录。
+func (idx *AddrIndexer) IndexTx(tx *wire.MsgTx, height int32) (*AddrIndexBatch,
+	error) {
+
+	batch := &AddrIndexBatch{}
+	if err := idx.indexTx(batch, tx, height); err != nil {
+		return nil, err
+	}
+	return batch, nil
+}
+
+// IndexBlock 返回高度为 height 的区块 block 中所有交易的索引记录。 区块内花费同一区块中较早输出的交易同样产生花费记录。
+func (idx *AddrIndexer) IndexBlock(block *wire.MsgBlock,
+	height int32) (*AddrIndexBatch, error) {
+
+	batch := &AddrIndexBatch{}
+	for i, tx := range block.Transactions {
+		if err := idx.indexTx(batch, tx, height); err != nil {
+			return nil, fmt.Errorf("transaction %d: %w", i, err)
+		}
+	}
+	return batch, nil
+}
+
+// indexTx 将 tx 的索引记录追加到 batch。
+func (idx *AddrIndexer) indexTx(batch *AddrIndexBatch, tx *wire.MsgTx,
+	height int32) error {
+
+	if height < 0 {
+		return fmt.Errorf("negative block height %d", height)
+	}
+
+	txHash := tx.TxHash()
+	isCoinBase := len(tx.TxIn) == 1 &&
+		tx.TxIn[0].PreviousOutPoint.Index == wire.MaxPrevOutIndex &&
+		tx.TxIn[0].PreviousOutPoint.Hash == (chainhash.Hash{})
+	if !isCoinBase {
+		for i, txIn := range tx.TxIn {
+			batch.Spends = append(batch.Spends, SpendRecord{
+				OutPoint:   txIn.PreviousOutPoint,
+				SpendingTx: txHash,
+				InputIndex: uint32(i),
+				Height:     height,
+			})
+		}
+	}
+
+	for i, txOut := range tx.TxOut {
+		class, addrs := idx.OutputAddrs(txOut.PkScript)
+		for _, addr := range addrs {
+			batch.Outputs = append(batch.Outputs, AddrOutputRecord{
+				Address:  addr,
+				Class:    class,
+				OutPoint: wire.OutPoint{Hash: txHash, Index: uint32(i)},
+				Value:    txOut.Value,
+				Height:   height,
+			})
+		}
+	}
+	return nil
+}
@@ -0,0 +1,170 @@
+package txscript
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcec/v2/schnorr"
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/stretchr/testify/require"
+)
+
+// TestAddrIndexerIndexBlock 测试 IndexBlock 为各类输出推导地址，并为非 coinbase 输入产生花费记录。
+func TestAddrIndexerIndexBlock(t *testing.T) {
+	t.Parallel()
+
+	params := &chaincfg.MainNetParams
+	privKey, err := btcec.NewPrivateKey()
+	require.NoError(t, err)
+	pubKey := privKey.PubKey().SerializeCompressed()
+	pkHashAddr, err := btcutil.NewAddressPubKeyHash(
+		btcutil.Hash160(pubKey), params,
+	)
+	require.NoError(t, err)
+	p2pkh, err := PayToAddrScript(pkHashAddr)
+	require.NoError(t, err)
+
+	// A bare multisig that lists the same key twice is indexed once under
+	// the key's P2PKH address.
+	addrPubKey, err := btcutil.NewAddressPubKey(pubKey, params)
+	require.NoError(t, err)
+	multiSig, err := MultiSigScript(
+		[]*btcutil.AddressPubKey{addrPubKey, addrPubKey}, 1,
+	)
+	require.NoError(t, err)
+
+	outputKey := schnorr.SerializePubKey(privKey.PubKey())
+	trAddr, err := btcutil.NewAddressTaproot(outputKey, params)
+	require.NoError(t, err)
+	p2tr, err := PayToAddrScript(trAddr)
+	require.NoError(t, err)
+
+	nullData, err := NullDataScript([]byte("bpfschain"))
+	require.NoError(t, err)
+
+	coinbase := wire.NewMsgTx(1)
+	coinbase.AddTxIn(wire.NewTxIn(
+		wire.NewOutPoint(&chainhash.Hash{}, wire.MaxPrevOutIndex), nil,
+		nil,
+	))
+	coinbase.AddTxOut(wire.NewTxOut(5000, p2pkh))
+	coinbaseHash := coinbase.TxHash()
+
+	spend := wire.NewMsgTx(2)
+	spend.AddTxIn(wire.NewTxIn(wire.NewOutPoint(&coinbaseHash, 0), nil, nil))
+	spend.AddTxIn(wire.NewTxIn(
+		wire.NewOutPoint(&chainhash.Hash{9}, 3), nil, nil,
+	))
+	spend.AddTxOut(wire.NewTxOut(1000, multiSig))
+	spend.AddTxOut(wire.NewTxOut(2000, p2tr))
+	spend.AddTxOut(wire.NewTxOut(0, nullData))
+	spendHash := spend.TxHash()
+
+	block := &wire.MsgBlock{
+		Transactions: []*wire.MsgTx{coinbase, spend},
+	}
+	batch, err := NewAddrIndexer(params).IndexBlock(block, 7)
+	require.NoError(t, err)
+
+	require.Equal(t, []AddrOutputRecord{{
+		Address:  pkHashAddr.EncodeAddress(),
+		Class:    PubKeyHashTy,
+		OutPoint: wire.OutPoint{Hash: coinbaseHash, Index: 0},
+		Value:    5000,
+		Height:   7,
+	}, {
+		Address:  pkHashAddr.EncodeAddress(),
+		Class:    MultiSigTy,
+		OutPoint: wire.OutPoint{Hash: spendHash, Index: 0},
+		Value:    1000,
+		Height:   7,
+	}, {
+		Address:  trAddr.EncodeAddress(),
+		Class:    WitnessV1TaprootTy,
+		OutPoint: wire.OutPoint{Hash: spendHash, Index: 1},
+		Value:    2000,
+		Height:   7,
+	}}, batch.Outputs)
+
+	require.Equal(t, []SpendRecord{{
+		OutPoint:   wire.OutPoint{Hash: coinbaseHash, Index: 0},
+		SpendingTx: spendHash,
+		InputIndex: 0,
+		Height:     7,
+	}, {
+		OutPoint:   wire.OutPoint{Hash: chainhash.Hash{9}, Index: 3},
+		SpendingTx: spendHash,
+		InputIndex: 1,
+		Height:     7,
+	}}, batch.Spends)
+
+	_, err = NewAddrIndexer(params).IndexTx(spend, -1)
+	require.Error(t, err)
+}
+
+// TestAddrIndexerCustomClass 测试已注册的自定义脚本类别通过模板的 ExtractAddrs 进入索引。
+//
+// 注意：该测试修改全局注册表，因此不能调用 t.Parallel。
+func TestAddrIndexerCustomClass(t *testing.T) {
+	params := &chaincfg.MainNetParams
+	hash := bytes.Repeat([]byte{0x11}, 20)
+	owner, err := btcutil.NewAddressPubKeyHash(hash, params)
+	require.NoError(t, err)
+
+	// 质押脚本的形式为：OP_NOP10 <20 字节哈希> OP_DROP OP_TRUE。
+	script := append([]byte{OP_NOP10, OP_DATA_20}, hash...)
+	script = append(script, OP_DROP, OP_TRUE)
+
+	withCleanScriptClassRegistry(func() {
+		class, err := RegisterScriptClass(ScriptTemplate{
+			Name: "addrindex_stake",
+			Match: func(s []byte) bool {
+				return bytes.Equal(s, script)
+			},
+			ExtractAddrs: func([]byte,
+				*chaincfg.Params) ([]btcutil.Address, int) {
+
+				return []btcutil.Address{owner}, 1
+			},
+		})
+		require.NoError(t, err)
+
+		gotClass, addrs := NewAddrIndexer(params).OutputAddrs(script)
+		require.Equal(t, class, gotClass)
+		require.Equal(t, []string{owner.EncodeAddress()}, addrs)
+	})
+}
+
+// TestAddrIndexKeys 测试索引记录的键以地址前缀开头，并且不同地址的前缀互不匹配。
+func TestAddrIndexKeys(t *testing.T) {
+	t.Parallel()
+
+	rec := AddrOutputRecord{
+		Address:  "1abc",
+		OutPoint: wire.OutPoint{Hash: chainhash.Hash{1}, Index: 2},
+		Value:    500,
+		Height:   3,
+		Class:    PubKeyHashTy,
+	}
+	key := rec.Key()
+	require.True(t, bytes.HasPrefix(key, AddrIndexOutputKeyPrefix("1abc")))
+	require.False(t, bytes.HasPrefix(key, AddrIndexOutputKeyPrefix("1ab")))
+	require.Equal(t, []byte{0, 0, 0, 2}, key[len(key)-4:])
+	require.Equal(t, []byte{
+		0, 0, 0, 3, 0, 0, 0, 0, 0, 0, 1, 0xf4, byte(PubKeyHashTy),
+	}, rec.ValueBytes())
+
+	spend := SpendRecord{
+		OutPoint:   rec.OutPoint,
+		SpendingTx: chainhash.Hash{5},
+		InputIndex: 1,
+		Height:     4,
+	}
+	require.Equal(t, AddrIndexSpendPrefix, spend.Key()[0])
+	require.Equal(t, key[len(key)-outPointKeySize:], spend.Key()[1:])
+	require.Len(t, spend.ValueBytes(), chainhash.HashSize+8)
+}
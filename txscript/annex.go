@@ -0,0 +1,134 @@
+// 包含 taproot 见证附件（annex）的结构化 TLV 编码，使每个输入可以在附件中携带类型化的辅助数据，例如手续费代付信息。
+
+package txscript
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/btcsuite/btcd/wire"
+)
+
+// ErrMalformedAnnex 在附件不是有效的 TLV 记录流或记录无法编码时返回。
+var ErrMalformedAnnex = errors.New("malformed annex")
+
+// AnnexRecord 是附件中的一条类型化记录。
+type AnnexRecord struct {
+	// Type 是记录类型。 同一附件中的记录类型必须严格递增。
+	Type uint64
+
+	// Value 是记录的值，可以为空。
+	Value []byte
+}
+
+// EncodeAnnex 将记录编码为结构化附件：
+//
+//	0x50 || (type || length || value)*
+//
+// 其中 type 和 length 都是 CompactSize 整数。 记录按类型排序后编码，类型重复时返回 ErrMalformedAnnex。
+// 不含任何记录的附件只有标签字节。
+func EncodeAnnex(records []AnnexRecord) ([]byte, error) {
+	sorted := make([]AnnexRecord, len(records))
+	copy(sorted, records)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Type < sorted[j].Type
+	})
+
+	var buf bytes.Buffer
+	buf.WriteByte(TaprootAnnexTag)
+	for i, record := range sorted {
+		if i > 0 && sorted[i-1].Type == record.Type {
+			return nil, fmt.Errorf("%w: duplicate record type %d",
+				ErrMalformedAnnex, record.Type)
+		}
+
+		// Writes to a bytes.Buffer never fail.
+		_ = wire.WriteVarInt(&buf, 0, record.Type)
+		_ = wire.WriteVarBytes(&buf, 0, record.Value)
+	}
+	return buf.Bytes(), nil
+}
+
+// DecodeAnnex 解析 EncodeAnnex 编码的附件并按顺序返回其中的记录。 附件必须以 TaprootAnnexTag 开头，
+// 整数必须使用规范的 CompactSize 编码，记录类型必须严格递增，并且不能有多余的字节。 返回的值引用传入的附件而不是副本。
+func DecodeAnnex(annex []byte) ([]AnnexRecord, error) {
+	if len(annex) == 0 || annex[0] != TaprootAnnexTag {
+		return nil, fmt.Errorf("%w: missing annex tag", ErrMalformedAnnex)
+	}
+
+	var records []AnnexRecord
+	r := bytes.NewReader(annex[1:])
+	for r.Len() > 0 {
+		recordType, err := wire.ReadVarInt(r, 0)
+		if err != nil {
+			return nil, fmt.Errorf("%w: record %d type: %v",
+				ErrMalformedAnnex, len(records), err)
+		}
+		if n := len(records); n > 0 && records[n-1].Type >= recordType {
+			return nil, fmt.Errorf("%w: record type %d does not "+
+				"follow %d", ErrMalformedAnnex, recordType,
+				records[n-1].Type)
+		}
+
+		length, err := wire.ReadVarInt(r, 0)
+		if err != nil {
+			return nil, fmt.Errorf("%w: record %d length: %v",
+				ErrMalformedAnnex, len(records), err)
+		}
+		if length > uint64(r.Len()) {
+			return nil, fmt.Errorf("%w: record %d length %d exceeds "+
+				"remaining %d bytes", ErrMalformedAnnex, len(records),
+				length, r.Len())
+		}
+
+		start := len(annex) - r.Len()
+		value := annex[start : start+int(length) : start+int(length)]
+		if _, err := r.Seek(int64(length), io.SeekCurrent); err != nil {
+			return nil, err
+		}
+
+		records = append(records, AnnexRecord{
+			Type:  recordType,
+			Value: value,
+		})
+	}
+
+	return records, nil
+}
+
+// ValidateAnnex 返回附件是否可以被 DecodeAnnex 解析，不能时返回描述原因的错误。
+func ValidateAnnex(annex []byte) error {
+	_, err := DecodeAnnex(annex)
+	return err
+}
+
+// FindAnnexRecord 返回记录中给定类型的记录值，不存在时返回 false。
+func FindAnnexRecord(records []AnnexRecord, recordType uint64) ([]byte, bool) {
+	i := sort.Search(len(records), func(i int) bool {
+		return records[i].Type >= recordType
+	})
+	if i < len(records) && records[i].Type == recordType {
+		return records[i].Value, true
+	}
+	return nil, false
+}
+
+// Annex 返回当前 taproot 花费的原始附件，没有附件时返回 nil。
+func (vm *Engine) Annex() []byte {
+	if vm.taprootCtx == nil {
+		return nil
+	}
+	return vm.taprootCtx.annex
+}
+
+// AnnexRecords 返回当前 taproot 花费的附件中解析出的记录。 只有设置了 ScriptVerifyStructuredAnnex 时才会解析附件，
+// 否则或没有附件时返回 nil。 调用者不得修改返回的记录。
+func (vm *Engine) AnnexRecords() []AnnexRecord {
+	if vm.taprootCtx == nil {
+		return nil
+	}
+	return vm.taprootCtx.annexRecords
+}
@@ -0,0 +1,116 @@
+package txscript
+
+import (
+	"testing"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/stretchr/testify/require"
+)
+
+// TestAnnexEncoding 确保附件记录可以往返编码，并且格式错误的附件被拒绝。
+func TestAnnexEncoding(t *testing.T) {
+	t.Parallel()
+
+	records := []AnnexRecord{
+		{Type: 300, Value: make([]byte, 260)},
+		{Type: 0, Value: []byte{0xaa}},
+		{Type: 7},
+	}
+	annex, err := EncodeAnnex(records)
+	require.NoError(t, err)
+	require.Equal(t, byte(TaprootAnnexTag), annex[0])
+	require.Equal(t, []byte{0x00, 0x01, 0xaa, 0x07, 0x00, 0xfd, 0x2c, 0x01},
+		annex[1:9])
+
+	decoded, err := DecodeAnnex(annex)
+	require.NoError(t, err)
+	require.Len(t, decoded, 3)
+	require.Equal(t, []uint64{0, 7, 300}, []uint64{
+		decoded[0].Type, decoded[1].Type, decoded[2].Type,
+	})
+	require.Equal(t, records[0].Value, decoded[2].Value)
+	require.Empty(t, decoded[1].Value)
+	require.NoError(t, ValidateAnnex(annex))
+
+	value, ok := FindAnnexRecord(decoded, 0)
+	require.True(t, ok)
+	require.Equal(t, []byte{0xaa}, value)
+	_, ok = FindAnnexRecord(decoded, 8)
+	require.False(t, ok)
+
+	empty, err := EncodeAnnex(nil)
+	require.NoError(t, err)
+	require.Equal(t, []byte{TaprootAnnexTag}, empty)
+	decoded, err = DecodeAnnex(empty)
+	require.NoError(t, err)
+	require.Empty(t, decoded)
+
+	_, err = EncodeAnnex([]AnnexRecord{{Type: 1}, {Type: 1}})
+	require.ErrorIs(t, err, ErrMalformedAnnex)
+
+	invalid := map[string][]byte{
+		"empty":                nil,
+		"missing tag":          {0x51, 0x00, 0x00},
+		"truncated value":      {TaprootAnnexTag, 0x01, 0x02, 0xaa},
+		"missing length":       {TaprootAnnexTag, 0x01},
+		"non-canonical type":   {TaprootAnnexTag, 0xfd, 0x01, 0x00, 0x00},
+		"non-increasing types": {TaprootAnnexTag, 0x02, 0x00, 0x01, 0x00},
+		"duplicate types":      {TaprootAnnexTag, 0x02, 0x00, 0x02, 0x00},
+		"oversized length": {TaprootAnnexTag, 0x01, 0xff, 0xff, 0xff, 0xff,
+			0xff, 0xff, 0xff, 0xff, 0xff},
+		"truncated record type": {TaprootAnnexTag, 0xfd, 0x01},
+	}
+	for name, annex := range invalid {
+		require.ErrorIs(t, ValidateAnnex(annex), ErrMalformedAnnex, name)
+	}
+}
+
+// TestStructuredAnnexFlag 确保设置 ScriptVerifyStructuredAnnex 时格式错误的附件使花费失败，
+// 并且解析出的记录可以通过引擎获取。
+func TestStructuredAnnexFlag(t *testing.T) {
+	t.Parallel()
+
+	internalKey, err := btcec.NewPrivateKey()
+	require.NoError(t, err)
+	leaf := NewBaseTapLeaf([]byte{OP_TRUE})
+	tree := AssembleTaprootScriptTree(leaf)
+	rootHash := tree.RootNode.TapHash()
+	outputKey := ComputeTaprootOutputKey(internalKey.PubKey(), rootHash[:])
+	pkScript, err := PayToTaprootScript(outputKey)
+	require.NoError(t, err)
+	ctrl := tree.LeafMerkleProofs[0].ToControlBlock(internalKey.PubKey())
+	ctrlBytes, err := ctrl.ToBytes()
+	require.NoError(t, err)
+
+	const amt = 1e8
+	prevFetcher := NewCannedPrevOutputFetcher(pkScript, amt)
+	spend := func(annex []byte, flags ScriptFlags) (*Engine, error) {
+		witness := wire.TxWitness{leaf.Script, ctrlBytes, annex}
+		tx := createSpendingTx(witness, nil, pkScript, amt)
+		vm, err := NewEngine(pkScript, tx, 0, flags, nil, nil, amt,
+			prevFetcher)
+		require.NoError(t, err)
+		return vm, vm.Execute()
+	}
+
+	annex, err := EncodeAnnex([]AnnexRecord{{Type: 1, Value: []byte{2}}})
+	require.NoError(t, err)
+	malformed := []byte{TaprootAnnexTag, 0x01}
+
+	flags := StandardVerifyFlags | ScriptVerifyStructuredAnnex
+	vm, err := spend(annex, flags)
+	require.NoError(t, err)
+	require.Equal(t, annex, vm.Annex())
+	require.Equal(t, []AnnexRecord{{Type: 1, Value: []byte{2}}},
+		vm.AnnexRecords())
+
+	_, err = spend(malformed, flags)
+	require.True(t, IsErrorCode(err, ErrInvalidAnnex), err)
+
+	// 未设置标志时附件不被解析。
+	vm, err = spend(malformed, StandardVerifyFlags)
+	require.NoError(t, err)
+	require.Equal(t, malformed, vm.Annex())
+	require.Nil(t, vm.AnnexRecords())
+}
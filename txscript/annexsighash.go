@@ -0,0 +1,122 @@
+// 包含多方签名协调者检查附件承诺的辅助函数。 taproot 签名哈希提交到见证中是否存在附件以及附件的内容，
+// 参与者对附件的理解不一致时产生的签名在最终见证中全部无效。 协调者可以为每个输入同时计算带附件和不带附件的签名哈希，
+// 在汇总签名之前发现各方签署的是哪一种。
+
+package txscript
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcec/v2/schnorr"
+	"github.com/btcsuite/btcd/wire"
+)
+
+// ErrAnnexSigHashMismatch 在签名或签名哈希既不对应带附件的签名哈希也不对应不带附件的签名哈希时返回。
+var ErrAnnexSigHashMismatch = errors.New("signature does not match annex " +
+	"sighash")
+
+// WithSigningAnnex 使 taproot 和 tapscript 签名函数签署提交到 annex 的签名哈希。 annex 必须以 TaprootAnnexTag 开头，
+// 否则签名函数返回 ErrMalformedAnnex 错误。 TaprootWitnessSignature 会把附件附加到返回的见证末尾。
+func WithSigningAnnex(annex []byte) TaprootSignOption {
+	return func(o *taprootSignOptions) {
+		o.annex = annex
+	}
+}
+
+// WitnessAnnex 返回见证中的附件，即按 BIP0341 至少有两个元素时以 TaprootAnnexTag 开头的最后一个元素，没有附件时返回 nil。
+// 只有花费 taproot 输出的见证才会被按此规则解释。
+func WitnessAnnex(witness wire.TxWitness) []byte {
+	annex, err := extractAnnex(witness)
+	if err != nil {
+		return nil
+	}
+	return annex
+}
+
+// AnnexedInputs 返回见证中带有附件的输入的索引，按升序排列。 调用者需要自行确认这些输入花费的是 taproot 输出。
+func AnnexedInputs(tx *wire.MsgTx) []int {
+	var idxs []int
+	for i, txIn := range tx.TxIn {
+		if WitnessAnnex(txIn.Witness) != nil {
+			idxs = append(idxs, i)
+		}
+	}
+	return idxs
+}
+
+// TaprootAnnexSigHashes 是同一输入在带附件和不带附件时的 taproot 签名哈希。
+type TaprootAnnexSigHashes struct {
+	// Annex 是计算 WithAnnex 时使用的附件。
+	Annex []byte
+
+	// WithAnnex 是提交到 Annex 的签名哈希。
+	WithAnnex []byte
+
+	// WithoutAnnex 是见证中没有附件时的签名哈希。
+	WithoutAnnex []byte
+}
+
+// CalcTaprootAnnexSigHashes 计算输入 idx 在见证带有 annex 和不带附件时的 taproot 签名哈希。
+// opts 与 CalcTaprootSignatureHashPreimage 的选项相同，例如计算 tapscript 签名哈希时的 WithBaseTapscriptVersion，但不应包含 WithAnnex。
+// annex 必须以 TaprootAnnexTag 开头，否则返回 ErrMalformedAnnex 错误；调用者可以用 WitnessAnnex 取得见证中已有的附件。
+func CalcTaprootAnnexSigHashes(sigHashes *TxSigHashes, hType SigHashType,
+	tx *wire.MsgTx, idx int, prevOuts PrevOutputFetcher, annex []byte,
+	opts ...TaprootSigHashOption) (*TaprootAnnexSigHashes, error) {
+
+	if len(annex) == 0 || annex[0] != TaprootAnnexTag {
+		return nil, fmt.Errorf("%w: missing annex tag", ErrMalformedAnnex)
+	}
+
+	without, err := calcTaprootSignatureHashRaw(
+		sigHashes, hType, tx, idx, prevOuts, opts...,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	annexOpts := make([]TaprootSigHashOption, 0, len(opts)+1)
+	annexOpts = append(annexOpts, opts...)
+	annexOpts = append(annexOpts, WithAnnex(annex))
+	with, err := calcTaprootSignatureHashRaw(
+		sigHashes, hType, tx, idx, prevOuts, annexOpts...,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &TaprootAnnexSigHashes{
+		Annex:        annex,
+		WithAnnex:    with,
+		WithoutAnnex: without,
+	}, nil
+}
+
+// MatchSigHash 返回参与者报告的签名哈希是否提交到附件：等于 WithAnnex 时返回 true，等于 WithoutAnnex 时返回 false，
+// 两者都不等于时返回 ErrAnnexSigHashMismatch 错误。
+func (h *TaprootAnnexSigHashes) MatchSigHash(sigHash []byte) (bool, error) {
+	switch {
+	case bytes.Equal(sigHash, h.WithAnnex):
+		return true, nil
+	case bytes.Equal(sigHash, h.WithoutAnnex):
+		return false, nil
+	}
+	return false, fmt.Errorf("%w: sighash %x", ErrAnnexSigHashMismatch,
+		sigHash)
+}
+
+// MatchSignature 返回 pubKey 的签名 sig 签署的签名哈希是否提交到附件：对 WithAnnex 有效时返回 true，
+// 对 WithoutAnnex 有效时返回 false，两者都无效时返回 ErrAnnexSigHashMismatch 错误。 sig 是不带签名哈希类型字节的 64 字节签名。
+func (h *TaprootAnnexSigHashes) MatchSignature(sig *schnorr.Signature,
+	pubKey *btcec.PublicKey) (bool, error) {
+
+	switch {
+	case sig.Verify(h.WithAnnex, pubKey):
+		return true, nil
+	case sig.Verify(h.WithoutAnnex, pubKey):
+		return false, nil
+	}
+	return false, ErrAnnexSigHashMismatch
+}
@@ -0,0 +1,127 @@
+package txscript
+
+import (
+	"testing"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcec/v2/schnorr"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/stretchr/testify/require"
+)
+
+// TestTaprootAnnexSigHashes 确保带附件和不带附件的签名哈希与 CalcSigHash 一致，并且协调者可以分辨参与者签署的是哪一种。
+func TestTaprootAnnexSigHashes(t *testing.T) {
+	t.Parallel()
+
+	const amt = 50000
+	key, err := btcec.NewPrivateKey()
+	require.NoError(t, err)
+	outputKey := ComputeTaprootKeyNoScript(key.PubKey())
+	pkScript, err := PayToTaprootScript(outputKey)
+	require.NoError(t, err)
+
+	tx := createSpendingTx(nil, nil, pkScript, amt)
+	fetcher := NewCannedPrevOutputFetcher(pkScript, amt)
+	sigHashes := NewTxSigHashes(tx, fetcher)
+	annex, err := EncodeAnnex([]AnnexRecord{{Type: 1, Value: []byte{7}}})
+	require.NoError(t, err)
+
+	hashes, err := CalcTaprootAnnexSigHashes(sigHashes, SigHashDefault, tx,
+		0, fetcher, annex)
+	require.NoError(t, err)
+	with, err := CalcSigHash(tx, 0, SigHashDefault, SigVersionTaproot,
+		WithSigHashPrevOuts(fetcher), WithSigHashAnnex(annex))
+	require.NoError(t, err)
+	require.Equal(t, with, hashes.WithAnnex)
+	without, err := CalcTaprootSignatureHash(sigHashes, SigHashDefault, tx,
+		0, fetcher)
+	require.NoError(t, err)
+	require.Equal(t, without, hashes.WithoutAnnex)
+
+	annexed, err := hashes.MatchSigHash(with)
+	require.NoError(t, err)
+	require.True(t, annexed)
+	annexed, err = hashes.MatchSigHash(without)
+	require.NoError(t, err)
+	require.False(t, annexed)
+	_, err = hashes.MatchSigHash(make([]byte, 32))
+	require.ErrorIs(t, err, ErrAnnexSigHashMismatch)
+
+	_, err = CalcTaprootAnnexSigHashes(sigHashes, SigHashDefault, tx, 0,
+		fetcher, []byte{0x51})
+	require.ErrorIs(t, err, ErrMalformedAnnex)
+
+	// A signature made with WithSigningAnnex commits to the annex, is
+	// recognised by the coordinator and spends the output only with the
+	// annex in the witness.
+	witness, err := TaprootWitnessSignature(tx, sigHashes, 0, amt, pkScript,
+		SigHashDefault, key, WithSigningAnnex(annex))
+	require.NoError(t, err)
+	require.Len(t, witness, 2)
+	require.Equal(t, annex, WitnessAnnex(witness))
+
+	sig, err := schnorr.ParseSignature(witness[0])
+	require.NoError(t, err)
+	annexed, err = hashes.MatchSignature(sig, outputKey)
+	require.NoError(t, err)
+	require.True(t, annexed)
+	_, err = hashes.MatchSignature(sig, key.PubKey())
+	require.ErrorIs(t, err, ErrAnnexSigHashMismatch)
+
+	verify := func(witness wire.TxWitness) error {
+		tx.TxIn[0].Witness = witness
+		vm, err := NewEngine(pkScript, tx, 0, StandardVerifyFlags, nil,
+			sigHashes, amt, fetcher)
+		require.NoError(t, err)
+		return vm.Execute()
+	}
+	require.NoError(t, verify(witness))
+	require.Error(t, verify(witness[:1]))
+	require.Empty(t, AnnexedInputs(tx))
+
+	tx.TxIn[0].Witness = witness
+	require.Equal(t, []int{0}, AnnexedInputs(tx))
+
+	_, err = TaprootWitnessSignature(tx, sigHashes, 0, amt, pkScript,
+		SigHashDefault, key, WithSigningAnnex([]byte{}))
+	require.ErrorIs(t, err, ErrMalformedAnnex)
+}
+
+// TestTapscriptAnnexSigHashes 确保 tapscript 签名哈希同样可以区分附件，并且 WithSigningAnnex 签署的 tapscript 签名提交到附件。
+func TestTapscriptAnnexSigHashes(t *testing.T) {
+	t.Parallel()
+
+	const amt = 50000
+	key, err := btcec.NewPrivateKey()
+	require.NoError(t, err)
+	leaf := NewBaseTapLeaf([]byte{OP_TRUE})
+	pkScript := mustParseShortForm("OP_1 DATA_32 0x" +
+		"0000000000000000000000000000000000000000000000000000000000000001")
+	tx := createSpendingTx(nil, nil, pkScript, amt)
+	fetcher := NewCannedPrevOutputFetcher(pkScript, amt)
+	sigHashes := NewTxSigHashes(tx, fetcher)
+	annex := []byte{TaprootAnnexTag}
+
+	leafHash := leaf.TapHash()
+	hashes, err := CalcTaprootAnnexSigHashes(sigHashes, SigHashAll, tx, 0,
+		fetcher, annex,
+		WithBaseTapscriptVersion(blankCodeSepValue, leafHash[:]))
+	require.NoError(t, err)
+	with, err := CalcTapscriptSignaturehash(sigHashes, SigHashAll, tx, 0,
+		fetcher, leaf, WithAnnex(annex))
+	require.NoError(t, err)
+	require.Equal(t, with, hashes.WithAnnex)
+
+	for _, opts := range [][]TaprootSignOption{
+		{WithSigningAnnex(annex)}, nil,
+	} {
+		raw, err := RawTxInTapscriptSignature(tx, sigHashes, 0, amt,
+			pkScript, leaf, SigHashAll, key, opts...)
+		require.NoError(t, err)
+		sig, err := schnorr.ParseSignature(raw[:64])
+		require.NoError(t, err)
+		annexed, err := hashes.MatchSignature(sig, key.PubKey())
+		require.NoError(t, err)
+		require.Equal(t, opts != nil, annexed)
+	}
+}
@@ -0,0 +1,205 @@
+// 包含异步签名验证队列，允许引擎将 CHECKSIG 的密码学验证推迟到脚本逻辑执行完毕之后，由有界的工作协程池并行完成。
+
+package txscript
+
+import (
+	"runtime"
+	"sync"
+)
+
+// SigVerifyFuture 表示一个已提交但可能尚未完成的签名验证任务。
+type SigVerifyFuture struct {
+	verify func() bool
+	done   chan struct{}
+	valid  bool
+}
+
+// run 执行验证任务并通知等待者。
+func (f *SigVerifyFuture) run() {
+	f.valid = f.verify()
+	close(f.done)
+}
+
+// Done 返回一个在验证完成时关闭的通道。
+func (f *SigVerifyFuture) Done() <-chan struct{} {
+	return f.done
+}
+
+// Wait 阻塞直到验证完成，并返回签名是否有效。
+func (f *SigVerifyFuture) Wait() bool {
+	<-f.done
+	return f.valid
+}
+
+// AsyncSigVerifier 是一个由多个引擎共享的签名验证队列。 提交的任务由固定数量的工作协程执行，
+// 队列已满时提交会阻塞，从而限制未完成任务的数量。
+//
+// 与 Bitcoin Core 的 CScriptCheck 类似，引擎通过 WithAsyncSigVerifier 使用该队列时，
+// 只有在签名无效必然导致脚本失败（即启用了 ScriptVerifyNullFail 或处于 tapscript 执行中）
+// 且签名非空的情况下才会推迟验证，此时签名检查的结果可以预先假定为成功。
+// 签名哈希仍在执行操作码时同步计算，只有密码学验证被推迟。
+//
+// AsyncSigVerifier 是并发安全的。
+type AsyncSigVerifier struct {
+	jobs chan *SigVerifyFuture
+	quit chan struct{}
+	wg   sync.WaitGroup
+
+	// mtx 保护 stopped，并保证 Stop 返回后不会再有任务进入队列。
+	mtx      sync.RWMutex
+	stopped  bool
+	stopOnce sync.Once
+}
+
+// NewAsyncSigVerifier 返回一个使用 numWorkers 个工作协程、最多缓存 queueSize 个任务的签名验证队列。
+// numWorkers 不大于零时使用 runtime.NumCPU()，queueSize 小于零时视为零。
+func NewAsyncSigVerifier(numWorkers, queueSize int) *AsyncSigVerifier {
+	if numWorkers <= 0 {
+		numWorkers = runtime.NumCPU()
+	}
+	if queueSize < 0 {
+		queueSize = 0
+	}
+
+	a := &AsyncSigVerifier{
+		jobs: make(chan *SigVerifyFuture, queueSize),
+		quit: make(chan struct{}),
+	}
+	a.wg.Add(numWorkers)
+	for i := 0; i < numWorkers; i++ {
+		go a.worker()
+	}
+	return a
+}
+
+// worker 从队列中取出任务并执行，直到队列停止。
+func (a *AsyncSigVerifier) worker() {
+	defer a.wg.Done()
+
+	for {
+		select {
+		case f := <-a.jobs:
+			f.run()
+		case <-a.quit:
+			return
+		}
+	}
+}
+
+// Submit 将验证任务加入队列并返回对应的 future。 队列已满时阻塞。 如果队列已经停止，任务会在调用者的协程中同步执行。
+func (a *AsyncSigVerifier) Submit(verify func() bool) *SigVerifyFuture {
+	f := &SigVerifyFuture{
+		verify: verify,
+		done:   make(chan struct{}),
+	}
+
+	a.mtx.RLock()
+	if a.stopped {
+		a.mtx.RUnlock()
+		f.run()
+		return f
+	}
+	select {
+	case a.jobs <- f:
+		a.mtx.RUnlock()
+	case <-a.quit:
+		a.mtx.RUnlock()
+		f.run()
+	}
+	return f
+}
+
+// Stop 停止所有工作协程。 已经在队列中的任务会在返回前执行完毕，之后提交的任务将同步执行。
+func (a *AsyncSigVerifier) Stop() {
+	a.stopOnce.Do(func() {
+		close(a.quit)
+
+		a.mtx.Lock()
+		a.stopped = true
+		a.mtx.Unlock()
+
+		a.wg.Wait()
+
+		// No more jobs can be queued at this point, so run whatever the
+		// workers left behind.
+		for {
+			select {
+			case f := <-a.jobs:
+				f.run()
+			default:
+				return
+			}
+		}
+	})
+}
+
+// WithAsyncSigVerifier 使引擎在可能的情况下将签名验证推迟到传入的队列中。 被推迟的验证按提交顺序在 Execute 结束时等待，
+// 任何一个签名无效都会导致与同步验证相同的 ErrNullFail 错误。 手动调用 Step 的调用者需要在执行结束后调用 CheckDeferredSigs。
+func WithAsyncSigVerifier(a *AsyncSigVerifier) EngineOpt {
+	return func(cfg *engineConfig) {
+		cfg.asyncSigVerifier = a
+	}
+}
+
+// verifySignature 验证传入的签名。 如果引擎配置了 AsyncSigVerifier 且签名无效必然导致脚本失败，
+// 则只同步计算签名哈希，将密码学验证提交到队列并假定签名有效。
+func (vm *Engine) verifySignature(v signatureVerifier, fullSigBytes []byte) bool {
+	vm.recordSigOp()
+
+	valid := vm.dispatchSigVerify(v, fullSigBytes)
+	sigType := SigTypeECDSA
+	if vm.taprootCtx != nil {
+		sigType = SigTypeSchnorr
+	}
+	vm.recordSigCheck(sigType, valid)
+	return valid
+}
+
+// dispatchSigVerify 同步验证签名，或在可以推迟时将验证提交到 AsyncSigVerifier 并返回 true。
+func (vm *Engine) dispatchSigVerify(v signatureVerifier,
+	fullSigBytes []byte) bool {
+
+	// Deferring is only sound when a failed non-empty signature aborts
+	// execution, since only then is the result known ahead of time.
+	canDefer := vm.asyncSigVerifier != nil && len(fullSigBytes) > 0 &&
+		(vm.taprootCtx != nil || vm.hasFlag(ScriptVerifyNullFail))
+	if !canDefer {
+		return v.Verify()
+	}
+
+	dv, ok := v.(deferrableSigVerifier)
+	if !ok {
+		return v.Verify()
+	}
+	sigHash, ok := dv.calcSigHash()
+	if !ok {
+		return v.Verify()
+	}
+
+	future := vm.asyncSigVerifier.Submit(func() bool {
+		return dv.verifySig(sigHash)
+	})
+	vm.deferredSigs = append(vm.deferredSigs, future)
+	return true
+}
+
+// CheckDeferredSigs 等待所有被推迟的签名验证完成，并在有签名无效时返回 ErrNullFail 错误。
+// 未配置 WithAsyncSigVerifier 时总是返回 nil。
+func (vm *Engine) CheckDeferredSigs() error {
+	deferred := vm.deferredSigs
+	vm.deferredSigs = nil
+
+	// Wait for every future even after a failure so that no verification
+	// is still reading stack memory once this returns.
+	allValid := true
+	for _, future := range deferred {
+		if !future.Wait() {
+			allValid = false
+		}
+	}
+	if !allValid {
+		str := "signature not empty on failed checksig"
+		return scriptError(ErrNullFail, str)
+	}
+	return nil
+}
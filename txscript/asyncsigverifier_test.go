@@ -0,0 +1,149 @@
+package txscript
+
+import (
+	"sync/atomic"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcec/v2/schnorr"
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/stretchr/testify/require"
+)
+
+// TestAsyncSigVerifierQueue 确保队列执行所有提交的任务，并在停止后同步执行新任务。
+func TestAsyncSigVerifierQueue(t *testing.T) {
+	t.Parallel()
+
+	a := NewAsyncSigVerifier(4, 2)
+
+	var ran int32
+	futures := make([]*SigVerifyFuture, 100)
+	for i := range futures {
+		valid := i%3 != 0
+		futures[i] = a.Submit(func() bool {
+			atomic.AddInt32(&ran, 1)
+			return valid
+		})
+	}
+	for i, future := range futures {
+		require.Equal(t, i%3 != 0, future.Wait(), "future %d", i)
+		<-future.Done()
+	}
+	require.EqualValues(t, len(futures), atomic.LoadInt32(&ran))
+
+	a.Stop()
+	a.Stop()
+	future := a.Submit(func() bool { return true })
+	select {
+	case <-future.Done():
+	default:
+		t.Fatal("submit after stop did not run synchronously")
+	}
+	require.True(t, future.Wait())
+}
+
+// asyncSigTestTx 返回一个花费单个输出的交易。
+func asyncSigTestTx(amt int64) *wire.MsgTx {
+	tx := wire.NewMsgTx(2)
+	tx.AddTxIn(&wire.TxIn{PreviousOutPoint: wire.OutPoint{Index: 0}})
+	tx.AddTxOut(wire.NewTxOut(amt, nil))
+	return tx
+}
+
+// TestAsyncSigVerifierEngine 确保引擎推迟 P2PKH 和 tapscript 的签名验证，并在签名无效时返回 ErrNullFail。
+func TestAsyncSigVerifierEngine(t *testing.T) {
+	t.Parallel()
+
+	a := NewAsyncSigVerifier(2, 0)
+	defer a.Stop()
+
+	privKey, err := btcec.NewPrivateKey()
+	require.NoError(t, err)
+
+	// 传统 P2PKH 花费。
+	addr, err := btcutil.NewAddressPubKeyHash(
+		btcutil.Hash160(privKey.PubKey().SerializeCompressed()),
+		&chaincfg.MainNetParams,
+	)
+	require.NoError(t, err)
+	p2pkh, err := PayToAddrScript(addr)
+	require.NoError(t, err)
+
+	// 对输出金额不同的交易签名，得到解析成功但无效的签名。
+	const amt = 1e8
+	tx := asyncSigTestTx(amt - 1000)
+	validSig, err := SignatureScript(tx, 0, p2pkh, SigHashAll, privKey, true)
+	require.NoError(t, err)
+	invalidSig, err := SignatureScript(
+		asyncSigTestTx(amt-2000), 0, p2pkh, SigHashAll, privKey, true,
+	)
+	require.NoError(t, err)
+
+	tx.TxIn[0].SignatureScript = validSig
+	vm, err := NewEngine(p2pkh, tx, 0, StandardVerifyFlags, nil, nil, amt,
+		nil, WithAsyncSigVerifier(a))
+	require.NoError(t, err)
+
+	// 手动执行，确保签名检查确实被推迟。
+	for done := false; !done; {
+		done, err = vm.Step()
+		require.NoError(t, err)
+	}
+	require.Len(t, vm.deferredSigs, 1)
+	require.NoError(t, vm.CheckDeferredSigs())
+	require.NoError(t, vm.CheckErrorCondition(true))
+
+	tx.TxIn[0].SignatureScript = invalidSig
+	vm, err = NewEngine(p2pkh, tx, 0, StandardVerifyFlags, nil, nil, amt,
+		nil, WithAsyncSigVerifier(a))
+	require.NoError(t, err)
+	require.True(t, IsErrorCode(vm.Execute(), ErrNullFail))
+
+	// 未启用 NULLFAIL 时不能推迟，无效签名只会使 CHECKSIG 返回 false。
+	vm, err = NewEngine(p2pkh, tx, 0, ScriptBip16, nil, nil, amt, nil,
+		WithAsyncSigVerifier(a))
+	require.NoError(t, err)
+	require.True(t, IsErrorCode(vm.Execute(), ErrEvalFalse))
+	require.Empty(t, vm.deferredSigs)
+
+	// tapscript 花费。
+	leafScript, err := NewScriptBuilder().
+		AddData(schnorr.SerializePubKey(privKey.PubKey())).
+		AddOp(OP_CHECKSIG).Script()
+	require.NoError(t, err)
+	leaf := NewBaseTapLeaf(leafScript)
+	tree := AssembleTaprootScriptTree(leaf)
+	rootHash := tree.RootNode.TapHash()
+	outputKey := ComputeTaprootOutputKey(privKey.PubKey(), rootHash[:])
+	p2tr, err := PayToTaprootScript(outputKey)
+	require.NoError(t, err)
+	ctrl := tree.LeafMerkleProofs[0].ToControlBlock(privKey.PubKey())
+	ctrlBlock, err := ctrl.ToBytes()
+	require.NoError(t, err)
+
+	for _, valid := range []bool{true, false} {
+		tx := asyncSigTestTx(amt - 1000)
+		fetcher := NewCannedPrevOutputFetcher(p2tr, amt)
+		sigHashes := NewTxSigHashes(tx, fetcher)
+		sig, err := RawTxInTapscriptSignature(
+			tx, sigHashes, 0, amt, p2tr, leaf, SigHashDefault, privKey,
+		)
+		require.NoError(t, err)
+		if !valid {
+			sig[63] ^= 0x01
+		}
+
+		tx.TxIn[0].Witness = wire.TxWitness{sig, leafScript, ctrlBlock}
+		vm, err := NewEngine(p2tr, tx, 0, StandardVerifyFlags, nil,
+			sigHashes, amt, fetcher, WithAsyncSigVerifier(a))
+		require.NoError(t, err)
+		err = vm.Execute()
+		if valid {
+			require.NoError(t, err)
+		} else {
+			require.True(t, IsErrorCode(err, ErrNullFail), err)
+		}
+	}
+}
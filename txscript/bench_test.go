@@ -8,7 +8,9 @@ import (
 	"io/ioutil"
 	"testing"
 
+	"github.com/btcsuite/btcd/btcutil"
 	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
 	"github.com/btcsuite/btcd/wire"
 )
 
@@ -500,3 +502,192 @@ func BenchmarkExtractPkScriptAddrs(b *testing.B) {
 		}
 	}
 }
+
+// BenchmarkExecuteStackMemory 基准测试执行大量产生数字和哈希摘要的操作码时，启用和未启用堆栈内存池的分配情况。
+func BenchmarkExecuteStackMemory(b *testing.B) {
+	builder := NewScriptBuilder()
+	for i := 0; i < 50; i++ {
+		builder.AddInt64(int64(i + 1)).AddOp(OP_1ADD).AddOp(OP_SHA256).
+			AddOp(OP_DROP)
+	}
+	builder.AddOp(OP_TRUE)
+	script, err := builder.Script()
+	if err != nil {
+		b.Fatalf("failed to create benchmark script: %v", err)
+	}
+
+	benches := []struct {
+		name string
+		opts []EngineOpt
+	}{
+		{"heap", nil},
+		{"pooled", []EngineOpt{WithPooledStackMemory()}},
+	}
+	for _, bench := range benches {
+		bench := bench
+		b.Run(bench.name, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				vm, err := NewSimulationEngine(SimulationParams{
+					Script: script,
+				}, bench.opts...)
+				if err != nil {
+					b.Fatalf("unexpected err: %v", err)
+				}
+				if err := vm.Execute(); err != nil {
+					b.Fatalf("unexpected err: %v", err)
+				}
+			}
+		})
+	}
+}
+
+// newManyInputsTx 返回一个包含 numInputs 个带签名脚本的输入和两个输出的交易。
+func newManyInputsTx(numInputs int) *wire.MsgTx {
+	tx := wire.NewMsgTx(wire.TxVersion)
+	for i := 0; i < numInputs; i++ {
+		var prevHash chainhash.Hash
+		prevHash[0], prevHash[1] = byte(i), byte(i>>8)
+		txIn := wire.NewTxIn(wire.NewOutPoint(&prevHash, uint32(i)),
+			bytes.Repeat([]byte{OP_NOP}, 107), nil)
+		tx.AddTxIn(txIn)
+	}
+	tx.AddTxOut(wire.NewTxOut(1e8, prevOutScript))
+	tx.AddTxOut(wire.NewTxOut(2e8, prevOutScript))
+	return tx
+}
+
+// BenchmarkEngineManyInputs 基准测试为一个 500 输入交易的每个输入创建引擎并计算传统签名哈希所需的时间和内存，
+// 这是验证该交易时每个输入的 CHECKSIG 所需的工作。
+func BenchmarkEngineManyInputs(b *testing.B) {
+	tx := newManyInputsTx(500)
+	pkScript := mustParseShortForm("1")
+
+	b.ResetTimer()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		for idx := range tx.TxIn {
+			vm, err := NewEngine(pkScript, tx, idx, 0, nil, nil, 0,
+				nil)
+			if err != nil {
+				b.Fatalf("unexpected err: %v", err)
+			}
+			_ = calcSignatureHash(prevOutScript, SigHashAll, vm.tx, idx,
+				vm.chainTag)
+		}
+	}
+}
+
+// BenchmarkPickRoll 基准测试在深堆栈上反复执行 PICK 和 ROLL 的 tapscript，这是编译后的 miniscript 常见的模式，
+// 其中 ROLL 将栈底的元素移到栈顶。
+func BenchmarkPickRoll(b *testing.B) {
+	for _, depth := range []int{100, 500, 990} {
+		stk := make([][]byte, depth)
+		for i := range stk {
+			stk[i] = []byte{byte(i), 1}
+		}
+
+		builder := NewScriptBuilder()
+		for i := 0; i < depth; i++ {
+			builder.AddInt64(int64(depth - 1)).AddOp(OP_ROLL)
+			builder.AddInt64(int64(depth - 1)).AddOp(OP_PICK).
+				AddOp(OP_DROP)
+		}
+		builder.AddOp(OP_DEPTH).AddInt64(int64(depth)).
+			AddOp(OP_EQUALVERIFY).AddOp(OP_2DROP)
+		for i := 0; i < depth-3; i++ {
+			builder.AddOp(OP_DROP)
+		}
+		script, err := builder.Script()
+		if err != nil {
+			b.Fatalf("failed to create benchmark script: %v", err)
+		}
+
+		b.Run(fmt.Sprintf("depth=%d", depth), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				vm, err := NewSimulationEngine(SimulationParams{
+					Script:     script,
+					Stack:      stk,
+					SigVersion: SigVersionTapscript,
+				})
+				if err != nil {
+					b.Fatalf("unexpected err: %v", err)
+				}
+				if err := vm.Execute(); err != nil {
+					b.Fatalf("unexpected err: %v", err)
+				}
+			}
+		})
+	}
+}
+
+// BenchmarkStackRollN 基准测试直接在堆栈上将栈底元素 ROLL 到栈顶。
+func BenchmarkStackRollN(b *testing.B) {
+	for _, depth := range []int32{10, 100, 1000} {
+		b.Run(fmt.Sprintf("depth=%d", depth), func(b *testing.B) {
+			var s stack
+			for i := int32(0); i < depth; i++ {
+				s.PushByteArray([]byte{byte(i)})
+			}
+
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if err := s.RollN(depth - 1); err != nil {
+					b.Fatalf("unexpected err: %v", err)
+				}
+			}
+		})
+	}
+}
+
+// BenchmarkOpcodeDispatch 基准测试标准脚本中常见的操作码序列经由热路径分派和经由跳转表分派的开销。
+func BenchmarkOpcodeDispatch(b *testing.B) {
+	pubKey := make([]byte, 33)
+	pubKeyHash := btcutil.Hash160(pubKey)
+	type step struct {
+		op   *opcode
+		data []byte
+	}
+	steps := []step{
+		{&opcodeArray[OP_DATA_33], pubKey},
+		{&opcodeArray[OP_DUP], nil},
+		{&opcodeArray[OP_HASH160], nil},
+		{&opcodeArray[OP_DATA_20], pubKeyHash},
+		{&opcodeArray[OP_EQUALVERIFY], nil},
+		{&opcodeArray[OP_DROP], nil},
+	}
+
+	benches := []struct {
+		name     string
+		dispatch func(*opcode, []byte, *Engine) error
+	}{
+		{"hot", dispatchOpcode},
+		{"cold", func(op *opcode, data []byte, vm *Engine) error {
+			return opcodeJumpTable[op.value](op, data, vm)
+		}},
+	}
+	for _, bench := range benches {
+		bench := bench
+		b.Run(bench.name, func(b *testing.B) {
+			vm, err := NewSimulationEngine(SimulationParams{
+				Script: []byte{OP_TRUE},
+			})
+			if err != nil {
+				b.Fatalf("unexpected err: %v", err)
+			}
+
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				for _, s := range steps {
+					err := bench.dispatch(s.op, s.data, vm)
+					if err != nil {
+						b.Fatalf("unexpected err: %v", err)
+					}
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,49 @@
+package benchmarks
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// TestCases 确保每个用例都能通过验证，并且分配次数在预算之内。 分配次数与机器无关，因此总是检查；
+// 耗时预算只在设置了 BPFSCHAIN_CHECK_BUDGETS 时通过 CheckBudgets 检查。
+func TestCases(t *testing.T) {
+	cases, err := Cases()
+	require.NoError(t, err)
+	require.Len(t, cases, 5)
+
+	for i := range cases {
+		c := &cases[i]
+		require.NoError(t, c.Spend.Verify(), c.Name)
+
+		allocs := testing.AllocsPerRun(10, func() {
+			_ = c.Spend.Verify()
+		})
+		require.LessOrEqual(t, int64(allocs), c.Budget.AllocsPerOp,
+			c.Name)
+	}
+
+	// 用例是确定性的。
+	again, err := Cases()
+	require.NoError(t, err)
+	for i := range cases {
+		require.Equal(t, cases[i].Spend.Tx.TxIn[0].Witness,
+			again[i].Spend.Tx.TxIn[0].Witness, cases[i].Name)
+		require.Equal(t, cases[i].Spend.Tx.TxIn[0].SignatureScript,
+			again[i].Spend.Tx.TxIn[0].SignatureScript, cases[i].Name)
+	}
+
+	if os.Getenv("BPFSCHAIN_CHECK_BUDGETS") != "" {
+		CheckBudgets(t, 1)
+	}
+}
+
+func BenchmarkSpends(b *testing.B) {
+	cases, err := Cases()
+	require.NoError(b, err)
+	for i := range cases {
+		b.Run(cases[i].Name, cases[i].Benchmark)
+	}
+}
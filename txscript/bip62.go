@@ -0,0 +1,324 @@
+// 包含独立的 BIP0062 合规检查，在广播之前对交易的每个输入逐条检查延展性规则并给出每条规则的结果，
+// 不需要执行脚本，也不要求签名有效。
+
+package txscript
+
+import (
+	"fmt"
+
+	"github.com/btcsuite/btcd/wire"
+)
+
+// BIP62Rule 表示 CheckBIP62 检查的一条 BIP0062 规则。
+type BIP62Rule uint8
+
+const (
+	// BIP62SigPushOnly 要求签名脚本只包含数据推送。
+	BIP62SigPushOnly BIP62Rule = iota
+
+	// BIP62MinimalPush 要求签名脚本中的每个数据推送都使用最短的编码。
+	BIP62MinimalPush
+
+	// BIP62StrictDER 要求 ECDSA 签名是严格的 DER 编码。
+	BIP62StrictDER
+
+	// BIP62LowS 要求 ECDSA 签名的 S 值不大于曲线阶的一半。
+	BIP62LowS
+
+	// BIP62CleanStack 要求签名脚本和见证恰好提供花费公钥脚本所需的参数，执行结束后堆栈上没有多余的元素。
+	BIP62CleanStack
+
+	// BIP62NullDummy 要求 OP_CHECKMULTISIG 额外弹出的虚拟元素为空。
+	BIP62NullDummy
+
+	// numBIP62Rules 是规则的数量。
+	numBIP62Rules
+)
+
+// bip62RuleNames 包含每条规则的名称。
+var bip62RuleNames = []string{
+	BIP62SigPushOnly: "BIP62SigPushOnly",
+	BIP62MinimalPush: "BIP62MinimalPush",
+	BIP62StrictDER:   "BIP62StrictDER",
+	BIP62LowS:        "BIP62LowS",
+	BIP62CleanStack:  "BIP62CleanStack",
+	BIP62NullDummy:   "BIP62NullDummy",
+}
+
+// String 返回规则的名称。
+func (r BIP62Rule) String() string {
+	if int(r) >= len(bip62RuleNames) {
+		return fmt.Sprintf("BIP62Rule(%d)", uint8(r))
+	}
+	return bip62RuleNames[r]
+}
+
+// BIP62Status 是一条规则的检查结果。
+type BIP62Status uint8
+
+const (
+	// BIP62Pass 表示输入满足该规则。
+	BIP62Pass BIP62Status = iota
+
+	// BIP62Fail 表示输入违反该规则。
+	BIP62Fail
+
+	// BIP62Unchecked 表示无法确定输入是否满足该规则，例如无法识别花费的脚本所需的参数。
+	BIP62Unchecked
+)
+
+// String 返回检查结果的可读名称。
+func (s BIP62Status) String() string {
+	switch s {
+	case BIP62Pass:
+		return "pass"
+	case BIP62Fail:
+		return "fail"
+	case BIP62Unchecked:
+		return "unchecked"
+	default:
+		return fmt.Sprintf("BIP62Status(%d)", uint8(s))
+	}
+}
+
+// BIP62Result 是一个输入对一条规则的检查结果。
+type BIP62Result struct {
+	// Input 是输入的索引。
+	Input int
+
+	// Rule 是检查的规则。
+	Rule BIP62Rule
+
+	// Status 是检查结果。
+	Status BIP62Status
+
+	// Description 说明违反规则或无法检查的原因，通过时为空。
+	Description string
+}
+
+// String 返回检查结果的单行描述。
+func (r BIP62Result) String() string {
+	if r.Description == "" {
+		return fmt.Sprintf("input %d %v: %v", r.Input, r.Rule, r.Status)
+	}
+	return fmt.Sprintf("input %d %v: %v: %s", r.Input, r.Rule, r.Status,
+		r.Description)
+}
+
+// BIP62Report 是 CheckBIP62 对整个交易的检查报告。
+type BIP62Report struct {
+	// Results 按输入顺序包含每个输入对每条规则的检查结果。
+	Results []BIP62Result
+}
+
+// Passed 返回是否没有任何输入违反任何规则。 无法检查的规则不视为违反。
+func (r *BIP62Report) Passed() bool {
+	return len(r.Failures()) == 0
+}
+
+// Failures 返回所有违反规则的检查结果。
+func (r *BIP62Report) Failures() []BIP62Result {
+	var failures []BIP62Result
+	for _, result := range r.Results {
+		if result.Status == BIP62Fail {
+			failures = append(failures, result)
+		}
+	}
+	return failures
+}
+
+// RuleStatus 返回规则在所有输入上的汇总结果：任一输入违反时为 BIP62Fail，否则任一输入无法检查时为 BIP62Unchecked，
+// 其余情况为 BIP62Pass。
+func (r *BIP62Report) RuleStatus(rule BIP62Rule) BIP62Status {
+	status := BIP62Pass
+	for _, result := range r.Results {
+		if result.Rule != rule {
+			continue
+		}
+		switch result.Status {
+		case BIP62Fail:
+			return BIP62Fail
+		case BIP62Unchecked:
+			status = BIP62Unchecked
+		}
+	}
+	return status
+}
+
+// CheckBIP62 在广播之前检查 tx 的所有输入是否满足 BIP0062 的延展性规则，并返回每个输入对每条规则的结果：
+//
+//   - 签名脚本只包含数据推送，并且每个推送都使用最短的编码
+//   - ECDSA 签名是严格的 DER 编码并且 S 值较低
+//   - 签名脚本和见证恰好提供所需的参数（clean stack）
+//   - OP_CHECKMULTISIG 的虚拟元素为空
+//
+// 与 CheckMalleability 不同，检查不执行脚本，也不验证签名，因此可以用于尚未完全签名的交易。 签名和虚拟元素的位置以及所需的参数数量
+// 由 CalcInputRequirements 确定，无法识别花费的脚本时相关规则的结果为 BIP62Unchecked。 schnorr 签名没有这类延展性，
+// 总是满足签名编码规则。
+//
+// prevOutFetcher 必须能够返回交易所有输入花费的输出。
+func CheckBIP62(tx *wire.MsgTx,
+	prevOutFetcher PrevOutputFetcher) (*BIP62Report, error) {
+
+	report := &BIP62Report{
+		Results: make([]BIP62Result, 0, len(tx.TxIn)*int(numBIP62Rules)),
+	}
+	for i, txIn := range tx.TxIn {
+		prevOut := prevOutFetcher.FetchPrevOutput(txIn.PreviousOutPoint)
+		if prevOut == nil {
+			str := fmt.Sprintf("previous output %v of input %d is "+
+				"required", txIn.PreviousOutPoint, i)
+			return nil, internalError(str, nil)
+		}
+		results := checkInputBIP62(txIn, prevOut.PkScript)
+		for rule := range results {
+			results[rule].Input = i
+			results[rule].Rule = BIP62Rule(rule)
+		}
+		report.Results = append(report.Results, results[:]...)
+	}
+	return report, nil
+}
+
+// checkInputBIP62 检查花费 pkScript 的输入，返回按规则索引的结果。
+func checkInputBIP62(txIn *wire.TxIn,
+	pkScript []byte) [numBIP62Rules]BIP62Result {
+
+	var results [numBIP62Rules]BIP62Result
+	fail := func(rule BIP62Rule, format string, args ...interface{}) {
+		if results[rule].Status == BIP62Fail {
+			return
+		}
+		results[rule].Status = BIP62Fail
+		results[rule].Description = fmt.Sprintf(format, args...)
+	}
+
+	const scriptVersion = 0
+	var pushes [][]byte
+	tokenizer := MakeScriptTokenizer(scriptVersion, txIn.SignatureScript)
+	for offset := 0; tokenizer.Next(); offset = int(tokenizer.ByteIndex()) {
+		op := tokenizer.Opcode()
+		if op > OP_16 {
+			fail(BIP62SigPushOnly, "opcode %s at offset %d is not a "+
+				"data push", opcodeArray[op].name, offset)
+			continue
+		}
+		data := tokenizer.Data()
+		switch {
+		case op <= OP_PUSHDATA4:
+			err := checkMinimalDataPush(&opcodeArray[op], data)
+			if err != nil {
+				fail(BIP62MinimalPush, "offset %d: %v", offset, err)
+			}
+		case op == OP_1NEGATE:
+			data = ScriptNum(-1).Bytes()
+		case IsSmallInt(op):
+			data = ScriptNum(AsSmallInt(op)).Bytes()
+		}
+		pushes = append(pushes, data)
+	}
+	if err := tokenizer.Err(); err != nil {
+		fail(BIP62SigPushOnly, "unparseable signature script: %v", err)
+	}
+
+	// The arguments can only be located when the signature script is
+	// made up of pushes.
+	unchecked := func(format string,
+		args ...interface{}) [numBIP62Rules]BIP62Result {
+
+		for _, rule := range []BIP62Rule{BIP62StrictDER, BIP62LowS,
+			BIP62CleanStack, BIP62NullDummy} {
+
+			results[rule].Status = BIP62Unchecked
+			results[rule].Description = fmt.Sprintf(format, args...)
+		}
+		return results
+	}
+	if results[BIP62SigPushOnly].Status == BIP62Fail {
+		return unchecked("signature script is not push only")
+	}
+
+	witness := [][]byte(txIn.Witness)
+	taproot := isWitnessTaprootScript(pkScript)
+	if taproot && isAnnexedWitness(txIn.Witness) {
+		witness = witness[:len(witness)-1]
+	}
+
+	var script []byte
+	switch {
+	case isScriptHashScript(pkScript):
+		if len(pushes) == 0 {
+			return unchecked("no redeem script")
+		}
+		script = pushes[len(pushes)-1]
+		if isWitnessScriptHashScript(script) && len(witness) != 0 {
+			script = witness[len(witness)-1]
+		}
+
+	case isWitnessScriptHashScript(pkScript):
+		if len(witness) == 0 {
+			return unchecked("no witness script")
+		}
+		script = witness[len(witness)-1]
+
+	case taproot:
+		if len(witness) >= 2 {
+			script = witness[len(witness)-2]
+		}
+	}
+
+	reqs, err := CalcInputRequirements(pkScript, script)
+	if err != nil {
+		return unchecked("%v", err)
+	}
+
+	if len(pushes) != len(reqs.SigScript) {
+		fail(BIP62CleanStack, "signature script has %d items, %d "+
+			"expected", len(pushes), len(reqs.SigScript))
+	}
+	if len(witness) != len(reqs.Witness) {
+		fail(BIP62CleanStack, "witness has %d items, %d expected",
+			len(witness), len(reqs.Witness))
+	}
+
+	// Arguments are consumed from the top of the stack, so any extra
+	// items are below them and the kinds are matched from the end.
+	checkItems := func(name string, kinds []InputItemKind, items [][]byte) {
+		for j := range kinds {
+			idx := len(items) - len(kinds) + j
+			if idx < 0 {
+				continue
+			}
+			item := items[idx]
+			switch kinds[j] {
+			case InputItemDummy:
+				if len(item) != 0 {
+					fail(BIP62NullDummy, "%s item %d: multisig "+
+						"dummy is not empty", name, idx)
+				}
+
+			case InputItemSignature:
+				// Schnorr signatures are not malleable.
+				if taproot || len(item) == 0 {
+					continue
+				}
+				sig := item[:len(item)-1]
+				vm := Engine{flags: ScriptVerifyDERSignatures}
+				if err := vm.checkSignatureEncoding(sig); err != nil {
+					fail(BIP62StrictDER, "%s item %d: %v", name,
+						idx, err)
+					continue
+				}
+				vm.flags = ScriptVerifyLowS
+				if err := vm.checkSignatureEncoding(sig); err != nil {
+					fail(BIP62LowS, "%s item %d: %v", name, idx,
+						err)
+				}
+			}
+		}
+	}
+	checkItems("signature script", reqs.SigScript, pushes)
+	checkItems("witness", reqs.Witness, witness)
+
+	return results
+}
@@ -0,0 +1,126 @@
+package txscript
+
+import (
+	"crypto/sha256"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/stretchr/testify/require"
+)
+
+// TestCheckBIP62 确保每条 BIP0062 规则的违反都被单独报告，并且无法识别的脚本被标记为无法检查。
+func TestCheckBIP62(t *testing.T) {
+	t.Parallel()
+
+	privKey, err := btcec.NewPrivateKey()
+	require.NoError(t, err)
+	pubKey := privKey.PubKey().SerializeCompressed()
+	p2pkh, err := payToPubKeyHashScript(btcutil.Hash160(pubKey))
+	require.NoError(t, err)
+
+	tx := createSpendingTx(nil, nil, p2pkh, 0)
+	sig, err := RawTxInSignature(tx, 0, p2pkh, SigHashAll, privKey)
+	require.NoError(t, err)
+
+	sigScript := func(script string, sig []byte) []byte {
+		builder := NewScriptBuilder()
+		if script != "" {
+			builder.AddOps(mustParseShortForm(script))
+		}
+		s, err := builder.AddData(sig).AddData(pubKey).Script()
+		require.NoError(t, err)
+		return s
+	}
+	pushData1 := append([]byte{OP_PUSHDATA1, byte(len(sig))}, sig...)
+	pushData1 = append(pushData1, OP_DATA_33)
+	pushData1 = append(pushData1, pubKey...)
+
+	tests := []struct {
+		name      string
+		sigScript []byte
+		failed    []BIP62Rule
+	}{{
+		name:      "canonical",
+		sigScript: sigScript("", sig),
+	}, {
+		name:      "high s",
+		sigScript: sigScript("", reencodeSig(t, sig, false, true)),
+		failed:    []BIP62Rule{BIP62LowS},
+	}, {
+		name:      "padded r",
+		sigScript: sigScript("", reencodeSig(t, sig, true, false)),
+		failed:    []BIP62Rule{BIP62StrictDER},
+	}, {
+		name:      "non-minimal push",
+		sigScript: pushData1,
+		failed:    []BIP62Rule{BIP62MinimalPush},
+	}, {
+		name:      "extra stack item",
+		sigScript: sigScript("1", sig),
+		failed:    []BIP62Rule{BIP62CleanStack},
+	}, {
+		name:      "not push only",
+		sigScript: sigScript("NOP", sig),
+		failed:    []BIP62Rule{BIP62SigPushOnly},
+	}}
+
+	fetcher := NewCannedPrevOutputFetcher(p2pkh, 0)
+	for _, test := range tests {
+		tx.TxIn[0].SignatureScript = test.sigScript
+		report, err := CheckBIP62(tx, fetcher)
+		require.NoError(t, err, test.name)
+		require.Len(t, report.Results, int(numBIP62Rules), test.name)
+		require.Equal(t, len(test.failed) == 0, report.Passed(), test.name)
+
+		var failed []BIP62Rule
+		for _, result := range report.Failures() {
+			require.Equal(t, 0, result.Input, test.name)
+			require.NotEmpty(t, result.Description, test.name)
+			failed = append(failed, result.Rule)
+		}
+		require.Equal(t, test.failed, failed, test.name)
+	}
+
+	// The signature rules can't be checked without locating the
+	// arguments.
+	tx.TxIn[0].SignatureScript = sigScript("NOP", sig)
+	report, err := CheckBIP62(tx, fetcher)
+	require.NoError(t, err)
+	require.Equal(t, BIP62Fail, report.RuleStatus(BIP62SigPushOnly))
+	require.Equal(t, BIP62Unchecked, report.RuleStatus(BIP62LowS))
+	require.Equal(t, BIP62Pass, report.RuleStatus(BIP62MinimalPush))
+
+	// 多重签名的虚拟元素必须为空，签名不需要有效。
+	multisig, err := NewScriptBuilder().AddOp(OP_1).AddData(pubKey).
+		AddOp(OP_1).AddOp(OP_CHECKMULTISIG).Script()
+	require.NoError(t, err)
+	scriptHash := sha256.Sum256(multisig)
+	p2wsh, err := payToWitnessScriptHashScript(scriptHash[:])
+	require.NoError(t, err)
+	tx = createSpendingTx(wire.TxWitness{{0x01}, sig, multisig}, nil,
+		p2wsh, 0)
+	report, err = CheckBIP62(tx, NewCannedPrevOutputFetcher(p2wsh, 0))
+	require.NoError(t, err)
+	require.Len(t, report.Failures(), 1)
+	require.Equal(t, BIP62NullDummy, report.Failures()[0].Rule)
+
+	tx.TxIn[0].Witness[0] = nil
+	report, err = CheckBIP62(tx, NewCannedPrevOutputFetcher(p2wsh, 0))
+	require.NoError(t, err)
+	require.True(t, report.Passed())
+
+	// 无法识别的脚本。
+	pkScript := mustParseShortForm("DROP 1")
+	tx = createSpendingTx(nil, []byte{OP_1}, pkScript, 0)
+	report, err = CheckBIP62(tx, NewCannedPrevOutputFetcher(pkScript, 0))
+	require.NoError(t, err)
+	require.True(t, report.Passed())
+	require.Equal(t, BIP62Unchecked, report.RuleStatus(BIP62CleanStack))
+	require.Equal(t, BIP62Pass, report.RuleStatus(BIP62SigPushOnly))
+
+	// 缺少被花费的输出。
+	_, err = CheckBIP62(tx, NewMultiPrevOutFetcher(nil))
+	require.Error(t, err)
+}
@@ -0,0 +1,235 @@
+// 包含区块脚本验证器，使用多个工作协程并发验证区块中所有输入的脚本，并支持进度回调、通过 context 取消，
+// 以及在验证中止时报告已完成的部分结果，使同步流程能够显示进度并在链重组时放弃正在验证的区块。
+
+package txscript
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"sort"
+	"sync"
+
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/wire"
+)
+
+// BlockInputFailure 描述区块中一个验证失败的输入。
+type BlockInputFailure struct {
+	// TxIndex 是交易在区块中的索引。
+	TxIndex int
+
+	// InputIndex 是输入在交易中的索引。
+	InputIndex int
+
+	// Err 是验证失败的原因，通常是 Error。
+	Err error
+}
+
+// Error 实现 error 接口。
+func (f *BlockInputFailure) Error() string {
+	return fmt.Sprintf("transaction %d input %d: %v", f.TxIndex,
+		f.InputIndex, f.Err)
+}
+
+// Unwrap 返回验证失败的原因。
+func (f *BlockInputFailure) Unwrap() error {
+	return f.Err
+}
+
+// BlockValidationResult 是 BlockValidator.Validate 的结果。 验证被取消或因失败而中止时，它描述中止前已完成的部分。
+type BlockValidationResult struct {
+	// Total 是区块中需要验证的输入数，不包括 coinbase 交易的输入。
+	Total int
+
+	// Validated 是已经完成验证的输入数，包括验证失败的输入。
+	Validated int
+
+	// Failures 是已发现的验证失败，按交易和输入的顺序排列。 中止验证的第一个失败之后，其他工作协程上已经在执行的输入
+	// 仍然可能报告失败。
+	Failures []BlockInputFailure
+}
+
+// Complete 返回是否所有输入都已完成验证。
+func (r *BlockValidationResult) Complete() bool {
+	return r.Validated == r.Total
+}
+
+// BlockValidator 使用 EngineFactory 并发验证区块中所有输入的脚本。
+//
+// 导出字段应在第一次调用 Validate 之前设置。 Validate 可以被多个协程同时调用，前提是 Progress 是并发安全的。
+type BlockValidator struct {
+	// Workers 是同时验证输入的协程数量，不大于零时使用 runtime.NumCPU()。
+	Workers int
+
+	// Progress 不为 nil 时在每个输入完成验证后被调用，validated 是已完成的输入数，total 是需要验证的输入总数。
+	// 对同一次 Validate 的调用是串行的，并且 validated 严格递增。 它在工作协程中被调用，应当尽快返回。
+	Progress func(validated, total int)
+
+	factory *EngineFactory
+}
+
+// NewBlockValidator 返回使用 factory 创建脚本引擎的区块验证器。 工厂的标志、签名缓存和选项用于验证所有输入。
+func NewBlockValidator(factory *EngineFactory) *BlockValidator {
+	return &BlockValidator{factory: factory}
+}
+
+// blockInputJob 是一个待验证的输入。
+type blockInputJob struct {
+	txIndex   int
+	tx        *wire.MsgTx
+	input     int
+	prevOut   *wire.TxOut
+	prevOuts  PrevOutputFetcher
+	sigHashes *TxSigHashes
+}
+
+// Validate 验证 block 中除 coinbase 交易以外所有输入的脚本，prevOuts 必须能够提供区块花费的所有输出，
+// 包括区块内较早交易创建的输出。
+//
+// 所有输入验证通过时返回 nil 错误。 任何输入验证失败时，其余工作协程停止领取新的输入，返回的错误是 Failures 中的第一个
+// *BlockInputFailure。 ctx 被取消时工作协程在当前输入完成后立即停止，返回 ctx.Err()。 在所有情况下返回的结果都描述
+// 已经完成的部分。
+func (v *BlockValidator) Validate(ctx context.Context, block *wire.MsgBlock,
+	prevOuts PrevOutputFetcher) (*BlockValidationResult, error) {
+
+	result := &BlockValidationResult{}
+	for i, tx := range block.Transactions {
+		if !isBlockCoinBase(i, tx) {
+			result.Total += len(tx.TxIn)
+		}
+	}
+	if result.Total == 0 {
+		return result, ctx.Err()
+	}
+
+	workers := v.Workers
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+	if workers > result.Total {
+		workers = result.Total
+	}
+
+	// abortCtx is canceled on the first failure, so both the producer and
+	// the workers stop taking new inputs.
+	abortCtx, abort := context.WithCancel(ctx)
+	defer abort()
+
+	var mtx sync.Mutex
+	report := func(txIndex, input int, err error) {
+		mtx.Lock()
+		defer mtx.Unlock()
+
+		result.Validated++
+		if err != nil {
+			result.Failures = append(result.Failures, BlockInputFailure{
+				TxIndex:    txIndex,
+				InputIndex: input,
+				Err:        err,
+			})
+			abort()
+		}
+		if v.Progress != nil {
+			v.Progress(result.Validated, result.Total)
+		}
+	}
+
+	jobs := make(chan blockInputJob)
+	var wg sync.WaitGroup
+	wg.Add(workers)
+	for w := 0; w < workers; w++ {
+		go func() {
+			defer wg.Done()
+			for job := range jobs {
+				// Drain the channel without executing once
+				// validation has been aborted.
+				if abortCtx.Err() != nil {
+					continue
+				}
+				report(job.txIndex, job.input, v.validateInput(job))
+			}
+		}()
+	}
+
+	v.produce(abortCtx, block, prevOuts, jobs, report)
+	close(jobs)
+	wg.Wait()
+
+	sort.Slice(result.Failures, func(i, j int) bool {
+		a, b := &result.Failures[i], &result.Failures[j]
+		if a.TxIndex != b.TxIndex {
+			return a.TxIndex < b.TxIndex
+		}
+		return a.InputIndex < b.InputIndex
+	})
+	switch {
+	case len(result.Failures) != 0:
+		return result, &result.Failures[0]
+	case ctx.Err() != nil:
+		return result, ctx.Err()
+	}
+	return result, nil
+}
+
+// produce 将 block 中需要验证的输入依次发送到 jobs，直到所有输入都已发送或 ctx 被取消。 花费未知输出的输入直接通过
+// report 报告为失败。
+func (v *BlockValidator) produce(ctx context.Context, block *wire.MsgBlock,
+	prevOuts PrevOutputFetcher, jobs chan<- blockInputJob,
+	report func(txIndex, input int, err error)) {
+
+	for i, tx := range block.Transactions {
+		if isBlockCoinBase(i, tx) {
+			continue
+		}
+
+		// The signature hash midstate reads every spent output, so a
+		// transaction with an unknown input can't be executed at all.
+		txPrevOuts := make([]*wire.TxOut, len(tx.TxIn))
+		for j, txIn := range tx.TxIn {
+			txPrevOuts[j] = prevOuts.FetchPrevOutput(txIn.PreviousOutPoint)
+			if txPrevOuts[j] == nil {
+				str := fmt.Sprintf("unable to find previous output %v",
+					txIn.PreviousOutPoint)
+				report(i, j, scriptError(ErrInvalidIndex, str))
+				return
+			}
+		}
+
+		sigHashes := NewTxSigHashes(tx, prevOuts)
+		for j := range tx.TxIn {
+			job := blockInputJob{
+				txIndex:   i,
+				tx:        tx,
+				input:     j,
+				prevOut:   txPrevOuts[j],
+				prevOuts:  prevOuts,
+				sigHashes: sigHashes,
+			}
+			select {
+			case jobs <- job:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}
+}
+
+// validateInput 创建并执行验证 job 的脚本引擎。
+func (v *BlockValidator) validateInput(job blockInputJob) error {
+	vm, err := v.factory.NewEngine(
+		job.prevOut.PkScript, job.tx, job.input, job.sigHashes,
+		job.prevOut.Value, job.prevOuts,
+	)
+	if err != nil {
+		return err
+	}
+	return vm.Execute()
+}
+
+// isBlockCoinBase 返回区块中索引为 txIndex 的交易 tx 是否是 coinbase 交易。
+func isBlockCoinBase(txIndex int, tx *wire.MsgTx) bool {
+	return txIndex == 0 && len(tx.TxIn) == 1 &&
+		tx.TxIn[0].PreviousOutPoint.Index == wire.MaxPrevOutIndex &&
+		tx.TxIn[0].PreviousOutPoint.Hash == (chainhash.Hash{})
+}
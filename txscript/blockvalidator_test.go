@@ -0,0 +1,162 @@
+package txscript
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/stretchr/testify/require"
+)
+
+// blockValidatorTestBlock 返回包含 coinbase 交易和两笔各花费 numInputs 个 P2WPKH 输出的已签名交易的区块，
+// 以及提供被花费输出的获取器。
+func blockValidatorTestBlock(t *testing.T,
+	numInputs int) (*wire.MsgBlock, *MultiPrevOutFetcher) {
+
+	t.Helper()
+
+	privKey, err := btcec.NewPrivateKey()
+	require.NoError(t, err)
+	pkScript, err := PayToWitnessProgramScript(
+		0, btcutil.Hash160(privKey.PubKey().SerializeCompressed()),
+	)
+	require.NoError(t, err)
+
+	coinbase := wire.NewMsgTx(1)
+	coinbase.AddTxIn(wire.NewTxIn(
+		wire.NewOutPoint(&chainhash.Hash{}, wire.MaxPrevOutIndex), nil,
+		nil,
+	))
+	coinbase.AddTxOut(wire.NewTxOut(5000, pkScript))
+	block := &wire.MsgBlock{Transactions: []*wire.MsgTx{coinbase}}
+
+	const amt = 1000
+	fetcher := NewMultiPrevOutFetcher(nil)
+	for i := 0; i < 2; i++ {
+		tx := wire.NewMsgTx(2)
+		for j := 0; j < numInputs; j++ {
+			op := wire.OutPoint{
+				Hash:  chainhash.Hash{byte(i + 1)},
+				Index: uint32(j),
+			}
+			fetcher.AddPrevOut(op, wire.NewTxOut(amt, pkScript))
+			tx.AddTxIn(wire.NewTxIn(&op, nil, nil))
+		}
+		tx.AddTxOut(wire.NewTxOut(int64(numInputs*amt-100), pkScript))
+
+		sigHashes := NewTxSigHashes(tx, fetcher)
+		for j := range tx.TxIn {
+			tx.TxIn[j].Witness, err = WitnessSignature(
+				tx, sigHashes, j, amt, pkScript, SigHashAll, privKey,
+				true,
+			)
+			require.NoError(t, err)
+		}
+		block.Transactions = append(block.Transactions, tx)
+	}
+
+	return block, fetcher
+}
+
+// newTestBlockValidator 返回使用标准标志和 workers 个工作协程的区块验证器。
+func newTestBlockValidator(workers int) *BlockValidator {
+	v := NewBlockValidator(NewEngineFactory(StandardVerifyFlags, nil))
+	v.Workers = workers
+	return v
+}
+
+// TestBlockValidatorValid 测试有效区块的所有非 coinbase 输入都被验证，并且进度回调按顺序报告每个输入。
+func TestBlockValidatorValid(t *testing.T) {
+	t.Parallel()
+
+	block, fetcher := blockValidatorTestBlock(t, 3)
+	v := newTestBlockValidator(4)
+
+	var progress []int
+	v.Progress = func(validated, total int) {
+		require.Equal(t, 6, total)
+		progress = append(progress, validated)
+	}
+
+	result, err := v.Validate(context.Background(), block, fetcher)
+	require.NoError(t, err)
+	require.True(t, result.Complete())
+	require.Equal(t, 6, result.Total)
+	require.Empty(t, result.Failures)
+	require.Equal(t, []int{1, 2, 3, 4, 5, 6}, progress)
+}
+
+// TestBlockValidatorFailure 测试输入验证失败时返回该输入的位置和脚本错误。
+func TestBlockValidatorFailure(t *testing.T) {
+	t.Parallel()
+
+	block, fetcher := blockValidatorTestBlock(t, 3)
+	block.Transactions[2].TxIn[1].Witness[0][10] ^= 0x01
+
+	result, err := newTestBlockValidator(1).Validate(
+		context.Background(), block, fetcher,
+	)
+	var failure *BlockInputFailure
+	require.ErrorAs(t, err, &failure)
+	require.Equal(t, 2, failure.TxIndex)
+	require.Equal(t, 1, failure.InputIndex)
+
+	var scriptErr Error
+	require.True(t, errors.As(err, &scriptErr), "%v", err)
+
+	// With a single worker nothing after the failing input is executed.
+	require.Equal(t, 5, result.Validated)
+	require.False(t, result.Complete())
+	require.Len(t, result.Failures, 1)
+}
+
+// TestBlockValidatorMissingPrevOut 测试花费未知输出的输入被报告为失败，而不是使验证器崩溃。
+func TestBlockValidatorMissingPrevOut(t *testing.T) {
+	t.Parallel()
+
+	block, fetcher := blockValidatorTestBlock(t, 2)
+	block.Transactions[1].TxIn[1].PreviousOutPoint.Index = 9
+
+	result, err := newTestBlockValidator(2).Validate(
+		context.Background(), block, fetcher,
+	)
+	var scriptErr Error
+	require.True(t, errors.As(err, &scriptErr), "%v", err)
+	require.Equal(t, ErrInvalidIndex, scriptErr.ErrorCode)
+	require.Equal(t, 1, result.Validated)
+	require.Equal(t, 1, result.Failures[0].InputIndex)
+}
+
+// TestBlockValidatorCancel 测试 context 被取消后工作协程停止验证，并返回已完成部分的结果。
+func TestBlockValidatorCancel(t *testing.T) {
+	t.Parallel()
+
+	block, fetcher := blockValidatorTestBlock(t, 3)
+
+	// A context canceled up front executes nothing.
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	result, err := newTestBlockValidator(2).Validate(ctx, block, fetcher)
+	require.ErrorIs(t, err, context.Canceled)
+	require.Zero(t, result.Validated)
+	require.Equal(t, 6, result.Total)
+
+	// Canceling from the progress callback stops a single worker right
+	// after the input it reported.
+	ctx, cancel = context.WithCancel(context.Background())
+	defer cancel()
+	v := newTestBlockValidator(1)
+	v.Progress = func(validated, _ int) {
+		if validated == 2 {
+			cancel()
+		}
+	}
+	result, err = v.Validate(ctx, block, fetcher)
+	require.ErrorIs(t, err, context.Canceled)
+	require.Equal(t, 2, result.Validated)
+	require.Empty(t, result.Failures)
+}
@@ -0,0 +1,198 @@
+// Package btcdcompat 以 btcd v0.23 的 txscript 包完全相同的签名导出本分支的 txscript，
+// 使针对 btcd 编写的代码只需把导入改为
+//
+//	txscript "github.com/qinglongcn/bpfschain/txscript/btcdcompat"
+//
+// 即可编译并保持原有行为，之后再逐步迁移到 txscript 包中带选项的 API。
+//
+// 类型都是 txscript 中对应类型的别名，因此两个包的值可以混用。 签名与 btcd 相同的函数直接引用 txscript 中的函数；
+// 本分支为其增加了选项参数的函数由包装函数提供，taproot 签名函数按 btcd 的行为使用 RFC6979 派生的确定性 nonce。
+// 已有更完整替代的函数带有 Deprecated 注释，指向应当迁移到的 API。
+//
+// 操作码常量与 btcd 相同，但 OpcodeByName 使用本分支的操作码名称，例如 0xc1 是 OP_CHECKSIGFROMSTACK 而不是 OP_UNKNOWN193。
+package btcdcompat
+
+import (
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/qinglongcn/bpfschain/txscript"
+)
+
+// 以下类型是 txscript 中同名类型的别名。
+type (
+	AtomicSwapDataPushes    = txscript.AtomicSwapDataPushes
+	CannedPrevOutputFetcher = txscript.CannedPrevOutputFetcher
+	ControlBlock            = txscript.ControlBlock
+	Engine                  = txscript.Engine
+	ErrScriptNotCanonical   = txscript.ErrScriptNotCanonical
+	Error                   = txscript.Error
+	ErrorCode               = txscript.ErrorCode
+	HashCache               = txscript.HashCache
+	IndexedTapScriptTree    = txscript.IndexedTapScriptTree
+	KeyClosure              = txscript.KeyClosure
+	KeyDB                   = txscript.KeyDB
+	MultiPrevOutFetcher     = txscript.MultiPrevOutFetcher
+	PkScript                = txscript.PkScript
+	PrevOutputFetcher       = txscript.PrevOutputFetcher
+	ScriptBuilder           = txscript.ScriptBuilder
+	ScriptClass             = txscript.ScriptClass
+	ScriptClosure           = txscript.ScriptClosure
+	ScriptDB                = txscript.ScriptDB
+	ScriptFlags             = txscript.ScriptFlags
+	ScriptInfo              = txscript.ScriptInfo
+	ScriptTokenizer         = txscript.ScriptTokenizer
+	SegwitSigHashMidstate   = txscript.SegwitSigHashMidstate
+	SigCache                = txscript.SigCache
+	SigHashType             = txscript.SigHashType
+	TapBranch               = txscript.TapBranch
+	TapLeaf                 = txscript.TapLeaf
+	TapNode                 = txscript.TapNode
+	TaprootSigHashMidState  = txscript.TaprootSigHashMidState
+	TaprootSigHashOption    = txscript.TaprootSigHashOption
+	TapscriptLeafVersion    = txscript.TapscriptLeafVersion
+	TapscriptProof          = txscript.TapscriptProof
+	TxSigHashes             = txscript.TxSigHashes
+)
+
+// 以下函数与 txscript 中的同名函数签名和行为都相同。
+var (
+	AssembleTaprootScriptTree   = txscript.AssembleTaprootScriptTree
+	CalcMultiSigStats           = txscript.CalcMultiSigStats
+	CalcScriptInfo              = txscript.CalcScriptInfo
+	ComputePkScript             = txscript.ComputePkScript
+	ComputeTaprootKeyNoScript   = txscript.ComputeTaprootKeyNoScript
+	ComputeTaprootOutputKey     = txscript.ComputeTaprootOutputKey
+	DisableLog                  = txscript.DisableLog
+	DisasmString                = txscript.DisasmString
+	ExtractAtomicSwapDataPushes = txscript.ExtractAtomicSwapDataPushes
+	ExtractPkScriptAddrs        = txscript.ExtractPkScriptAddrs
+	ExtractWitnessProgramInfo   = txscript.ExtractWitnessProgramInfo
+	GetPreciseSigOpCount        = txscript.GetPreciseSigOpCount
+	GetScriptClass              = txscript.GetScriptClass
+	GetSigOpCount               = txscript.GetSigOpCount
+	GetWitnessSigOpCount        = txscript.GetWitnessSigOpCount
+	IsErrorCode                 = txscript.IsErrorCode
+	IsMultisigScript            = txscript.IsMultisigScript
+	IsMultisigSigScript         = txscript.IsMultisigSigScript
+	IsNullData                  = txscript.IsNullData
+	IsPayToPubKey               = txscript.IsPayToPubKey
+	IsPayToPubKeyHash           = txscript.IsPayToPubKeyHash
+	IsPayToScriptHash           = txscript.IsPayToScriptHash
+	IsPayToTaproot              = txscript.IsPayToTaproot
+	IsPayToWitnessPubKeyHash    = txscript.IsPayToWitnessPubKeyHash
+	IsPayToWitnessScriptHash    = txscript.IsPayToWitnessScriptHash
+	IsPushOnlyScript            = txscript.IsPushOnlyScript
+	IsUnspendable               = txscript.IsUnspendable
+	IsWitnessProgram            = txscript.IsWitnessProgram
+	MakeScriptTokenizer         = txscript.MakeScriptTokenizer
+	MultiSigScript              = txscript.MultiSigScript
+	NewBaseTapLeaf              = txscript.NewBaseTapLeaf
+	NewCannedPrevOutputFetcher  = txscript.NewCannedPrevOutputFetcher
+	NewHashCache                = txscript.NewHashCache
+	NewIndexedTapScriptTree     = txscript.NewIndexedTapScriptTree
+	NewMultiPrevOutFetcher      = txscript.NewMultiPrevOutFetcher
+	NewScriptClass              = txscript.NewScriptClass
+	NewSigCache                 = txscript.NewSigCache
+	NewTapBranch                = txscript.NewTapBranch
+	NewTapLeaf                  = txscript.NewTapLeaf
+	NewTxSigHashes              = txscript.NewTxSigHashes
+	NullDataScript              = txscript.NullDataScript
+	ParseControlBlock           = txscript.ParseControlBlock
+	ParsePkScript               = txscript.ParsePkScript
+	PayToAddrScript             = txscript.PayToAddrScript
+	PushedData                  = txscript.PushedData
+	RawTxInSignature            = txscript.RawTxInSignature
+	RawTxInWitnessSignature     = txscript.RawTxInWitnessSignature
+	ScriptHasOpSuccess          = txscript.ScriptHasOpSuccess
+	SignTxOutput                = txscript.SignTxOutput
+	SignatureScript             = txscript.SignatureScript
+	TweakTaprootPrivKey         = txscript.TweakTaprootPrivKey
+	VerifyTaprootKeySpend       = txscript.VerifyTaprootKeySpend
+	VerifyTaprootLeafCommitment = txscript.VerifyTaprootLeafCommitment
+	WithAnnex                   = txscript.WithAnnex
+	WithBaseTapscriptVersion    = txscript.WithBaseTapscriptVersion
+	WitnessSignature            = txscript.WitnessSignature
+)
+
+// 以下签名哈希函数与 txscript 中的同名函数相同，新代码应改用统一的 txscript.CalcSigHash。
+var (
+	// Deprecated: 使用 txscript.CalcSigHash 和 SigVersionBase。
+	CalcSignatureHash = txscript.CalcSignatureHash
+
+	// Deprecated: 使用 txscript.CalcSigHash 和 SigVersionTaproot，它还支持附件和链域分隔标签。
+	CalcTaprootSignatureHash = txscript.CalcTaprootSignatureHash
+
+	// Deprecated: 使用 txscript.CalcSigHash 和 SigVersionTapscript。
+	CalcTapscriptSignaturehash = txscript.CalcTapscriptSignaturehash
+
+	// Deprecated: 使用 txscript.CalcSigHash 和 SigVersionWitnessV0。
+	CalcWitnessSigHash = txscript.CalcWitnessSigHash
+)
+
+// 以下变量是 txscript 中同名变量的值。
+var (
+	Bip16Activation          = txscript.Bip16Activation
+	ErrUnsupportedScriptType = txscript.ErrUnsupportedScriptType
+	OpcodeByName             = txscript.OpcodeByName
+)
+
+// NewEngine 返回新的脚本引擎，与 txscript.NewEngine 不使用任何选项时相同。
+//
+// Deprecated: 使用 txscript.NewEngine，它接受 WithChainTag 等 EngineOpt 选项。
+func NewEngine(scriptPubKey []byte, tx *wire.MsgTx, txIdx int,
+	flags ScriptFlags, sigCache *SigCache, hashCache *TxSigHashes,
+	inputAmount int64, prevOutFetcher PrevOutputFetcher) (*Engine, error) {
+
+	return txscript.NewEngine(
+		scriptPubKey, tx, txIdx, flags, sigCache, hashCache,
+		inputAmount, prevOutFetcher,
+	)
+}
+
+// NewScriptBuilder 返回使用默认初始容量的脚本构建器。
+func NewScriptBuilder() *ScriptBuilder {
+	return txscript.NewScriptBuilder()
+}
+
+// RawTxInTaprootSignature 与 btcd 相同，使用 RFC6979 派生的确定性 nonce 签名，相同的输入总是得到相同的签名。
+// hashType 不是 SigHashDefault 时签名末尾附加签名哈希类型字节；btcd v0.23 在这种情况下遗漏该字节，产生的签名无法通过验证。
+//
+// Deprecated: 使用 txscript.RawTxInTaprootSignature，它默认按 BIP0340 使用辅助随机数，
+// 需要可复现的签名时可以传入 txscript.WithAuxRand。
+func RawTxInTaprootSignature(tx *wire.MsgTx, sigHashes *TxSigHashes, idx int,
+	amt int64, pkScript []byte, tapScriptRootHash []byte,
+	hashType SigHashType, key *btcec.PrivateKey) ([]byte, error) {
+
+	return txscript.RawTxInTaprootSignature(
+		tx, sigHashes, idx, amt, pkScript, tapScriptRootHash, hashType,
+		key, txscript.WithRFC6979Nonce(),
+	)
+}
+
+// RawTxInTapscriptSignature 与 btcd 相同，使用 RFC6979 派生的确定性 nonce 签名。
+//
+// Deprecated: 使用 txscript.RawTxInTapscriptSignature，它默认按 BIP0340 使用辅助随机数。
+func RawTxInTapscriptSignature(tx *wire.MsgTx, sigHashes *TxSigHashes,
+	idx int, amt int64, pkScript []byte, tapLeaf TapLeaf,
+	hashType SigHashType, privKey *btcec.PrivateKey) ([]byte, error) {
+
+	return txscript.RawTxInTapscriptSignature(
+		tx, sigHashes, idx, amt, pkScript, tapLeaf, hashType, privKey,
+		txscript.WithRFC6979Nonce(),
+	)
+}
+
+// TaprootWitnessSignature 与 btcd 相同，使用 RFC6979 派生的确定性 nonce 签名。
+// 签名哈希类型字节的处理与 RawTxInTaprootSignature 相同。
+//
+// Deprecated: 使用 txscript.TaprootWitnessSignature，它默认按 BIP0340 使用辅助随机数，
+// 并支持 WithSigningAnnex 等签名选项。
+func TaprootWitnessSignature(tx *wire.MsgTx, sigHashes *TxSigHashes, idx int,
+	amt int64, pkScript []byte, hashType SigHashType,
+	key *btcec.PrivateKey) (wire.TxWitness, error) {
+
+	return txscript.TaprootWitnessSignature(
+		tx, sigHashes, idx, amt, pkScript, hashType, key,
+		txscript.WithRFC6979Nonce(),
+	)
+}
@@ -0,0 +1,728 @@
+package btcdcompat
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcec/v2/schnorr"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	btcd "github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/stretchr/testify/require"
+)
+
+// TestFunctionSignatures 确保每个函数的签名与 btcd 的同名函数完全相同。 两个包都名为 txscript，
+// 因此签名中的类型名也相同。
+func TestFunctionSignatures(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		btcd, shim interface{}
+	}{
+		{"AssembleTaprootScriptTree", btcd.AssembleTaprootScriptTree, AssembleTaprootScriptTree},
+		{"CalcMultiSigStats", btcd.CalcMultiSigStats, CalcMultiSigStats},
+		{"CalcScriptInfo", btcd.CalcScriptInfo, CalcScriptInfo},
+		{"CalcSignatureHash", btcd.CalcSignatureHash, CalcSignatureHash},
+		{"CalcTaprootSignatureHash", btcd.CalcTaprootSignatureHash, CalcTaprootSignatureHash},
+		{"CalcTapscriptSignaturehash", btcd.CalcTapscriptSignaturehash, CalcTapscriptSignaturehash},
+		{"CalcWitnessSigHash", btcd.CalcWitnessSigHash, CalcWitnessSigHash},
+		{"ComputePkScript", btcd.ComputePkScript, ComputePkScript},
+		{"ComputeTaprootKeyNoScript", btcd.ComputeTaprootKeyNoScript, ComputeTaprootKeyNoScript},
+		{"ComputeTaprootOutputKey", btcd.ComputeTaprootOutputKey, ComputeTaprootOutputKey},
+		{"DisableLog", btcd.DisableLog, DisableLog},
+		{"DisasmString", btcd.DisasmString, DisasmString},
+		{"ExtractAtomicSwapDataPushes", btcd.ExtractAtomicSwapDataPushes, ExtractAtomicSwapDataPushes},
+		{"ExtractPkScriptAddrs", btcd.ExtractPkScriptAddrs, ExtractPkScriptAddrs},
+		{"ExtractWitnessProgramInfo", btcd.ExtractWitnessProgramInfo, ExtractWitnessProgramInfo},
+		{"GetPreciseSigOpCount", btcd.GetPreciseSigOpCount, GetPreciseSigOpCount},
+		{"GetScriptClass", btcd.GetScriptClass, GetScriptClass},
+		{"GetSigOpCount", btcd.GetSigOpCount, GetSigOpCount},
+		{"GetWitnessSigOpCount", btcd.GetWitnessSigOpCount, GetWitnessSigOpCount},
+		{"IsErrorCode", btcd.IsErrorCode, IsErrorCode},
+		{"IsMultisigScript", btcd.IsMultisigScript, IsMultisigScript},
+		{"IsMultisigSigScript", btcd.IsMultisigSigScript, IsMultisigSigScript},
+		{"IsNullData", btcd.IsNullData, IsNullData},
+		{"IsPayToPubKey", btcd.IsPayToPubKey, IsPayToPubKey},
+		{"IsPayToPubKeyHash", btcd.IsPayToPubKeyHash, IsPayToPubKeyHash},
+		{"IsPayToScriptHash", btcd.IsPayToScriptHash, IsPayToScriptHash},
+		{"IsPayToTaproot", btcd.IsPayToTaproot, IsPayToTaproot},
+		{"IsPayToWitnessPubKeyHash", btcd.IsPayToWitnessPubKeyHash, IsPayToWitnessPubKeyHash},
+		{"IsPayToWitnessScriptHash", btcd.IsPayToWitnessScriptHash, IsPayToWitnessScriptHash},
+		{"IsPushOnlyScript", btcd.IsPushOnlyScript, IsPushOnlyScript},
+		{"IsUnspendable", btcd.IsUnspendable, IsUnspendable},
+		{"IsWitnessProgram", btcd.IsWitnessProgram, IsWitnessProgram},
+		{"MakeScriptTokenizer", btcd.MakeScriptTokenizer, MakeScriptTokenizer},
+		{"MultiSigScript", btcd.MultiSigScript, MultiSigScript},
+		{"NewBaseTapLeaf", btcd.NewBaseTapLeaf, NewBaseTapLeaf},
+		{"NewCannedPrevOutputFetcher", btcd.NewCannedPrevOutputFetcher, NewCannedPrevOutputFetcher},
+		{"NewEngine", btcd.NewEngine, NewEngine},
+		{"NewHashCache", btcd.NewHashCache, NewHashCache},
+		{"NewIndexedTapScriptTree", btcd.NewIndexedTapScriptTree, NewIndexedTapScriptTree},
+		{"NewMultiPrevOutFetcher", btcd.NewMultiPrevOutFetcher, NewMultiPrevOutFetcher},
+		{"NewScriptBuilder", btcd.NewScriptBuilder, NewScriptBuilder},
+		{"NewScriptClass", btcd.NewScriptClass, NewScriptClass},
+		{"NewSigCache", btcd.NewSigCache, NewSigCache},
+		{"NewTapBranch", btcd.NewTapBranch, NewTapBranch},
+		{"NewTapLeaf", btcd.NewTapLeaf, NewTapLeaf},
+		{"NewTxSigHashes", btcd.NewTxSigHashes, NewTxSigHashes},
+		{"NullDataScript", btcd.NullDataScript, NullDataScript},
+		{"ParseControlBlock", btcd.ParseControlBlock, ParseControlBlock},
+		{"ParsePkScript", btcd.ParsePkScript, ParsePkScript},
+		{"PayToAddrScript", btcd.PayToAddrScript, PayToAddrScript},
+		{"PushedData", btcd.PushedData, PushedData},
+		{"RawTxInSignature", btcd.RawTxInSignature, RawTxInSignature},
+		{"RawTxInTaprootSignature", btcd.RawTxInTaprootSignature, RawTxInTaprootSignature},
+		{"RawTxInTapscriptSignature", btcd.RawTxInTapscriptSignature, RawTxInTapscriptSignature},
+		{"RawTxInWitnessSignature", btcd.RawTxInWitnessSignature, RawTxInWitnessSignature},
+		{"ScriptHasOpSuccess", btcd.ScriptHasOpSuccess, ScriptHasOpSuccess},
+		{"SignTxOutput", btcd.SignTxOutput, SignTxOutput},
+		{"SignatureScript", btcd.SignatureScript, SignatureScript},
+		{"TaprootWitnessSignature", btcd.TaprootWitnessSignature, TaprootWitnessSignature},
+		{"TweakTaprootPrivKey", btcd.TweakTaprootPrivKey, TweakTaprootPrivKey},
+		{"UseLogger", btcd.UseLogger, UseLogger},
+		{"VerifyTaprootKeySpend", btcd.VerifyTaprootKeySpend, VerifyTaprootKeySpend},
+		{"VerifyTaprootLeafCommitment", btcd.VerifyTaprootLeafCommitment, VerifyTaprootLeafCommitment},
+		{"WithAnnex", btcd.WithAnnex, WithAnnex},
+		{"WithBaseTapscriptVersion", btcd.WithBaseTapscriptVersion, WithBaseTapscriptVersion},
+		{"WitnessSignature", btcd.WitnessSignature, WitnessSignature},
+	}
+	for _, test := range tests {
+		require.Equal(t, reflect.TypeOf(test.btcd).String(),
+			reflect.TypeOf(test.shim).String(), test.name)
+	}
+}
+
+// TestTypes 确保每个类型的种类、导出字段和方法都与 btcd 的同名类型兼容：btcd 类型的每个导出字段和方法在别名的类型上都存在且类型相同。
+func TestTypes(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		btcd, shim reflect.Type
+	}{
+		{"AtomicSwapDataPushes", reflect.TypeOf((*btcd.AtomicSwapDataPushes)(nil)).Elem(),
+			reflect.TypeOf((*AtomicSwapDataPushes)(nil)).Elem()},
+		{"CannedPrevOutputFetcher", reflect.TypeOf((*btcd.CannedPrevOutputFetcher)(nil)).Elem(),
+			reflect.TypeOf((*CannedPrevOutputFetcher)(nil)).Elem()},
+		{"ControlBlock", reflect.TypeOf((*btcd.ControlBlock)(nil)).Elem(),
+			reflect.TypeOf((*ControlBlock)(nil)).Elem()},
+		{"Engine", reflect.TypeOf((*btcd.Engine)(nil)).Elem(),
+			reflect.TypeOf((*Engine)(nil)).Elem()},
+		{"ErrScriptNotCanonical", reflect.TypeOf((*btcd.ErrScriptNotCanonical)(nil)).Elem(),
+			reflect.TypeOf((*ErrScriptNotCanonical)(nil)).Elem()},
+		{"Error", reflect.TypeOf((*btcd.Error)(nil)).Elem(),
+			reflect.TypeOf((*Error)(nil)).Elem()},
+		{"ErrorCode", reflect.TypeOf((*btcd.ErrorCode)(nil)).Elem(),
+			reflect.TypeOf((*ErrorCode)(nil)).Elem()},
+		{"HashCache", reflect.TypeOf((*btcd.HashCache)(nil)).Elem(),
+			reflect.TypeOf((*HashCache)(nil)).Elem()},
+		{"IndexedTapScriptTree", reflect.TypeOf((*btcd.IndexedTapScriptTree)(nil)).Elem(),
+			reflect.TypeOf((*IndexedTapScriptTree)(nil)).Elem()},
+		{"KeyClosure", reflect.TypeOf((*btcd.KeyClosure)(nil)).Elem(),
+			reflect.TypeOf((*KeyClosure)(nil)).Elem()},
+		{"KeyDB", reflect.TypeOf((*btcd.KeyDB)(nil)).Elem(),
+			reflect.TypeOf((*KeyDB)(nil)).Elem()},
+		{"MultiPrevOutFetcher", reflect.TypeOf((*btcd.MultiPrevOutFetcher)(nil)).Elem(),
+			reflect.TypeOf((*MultiPrevOutFetcher)(nil)).Elem()},
+		{"PkScript", reflect.TypeOf((*btcd.PkScript)(nil)).Elem(),
+			reflect.TypeOf((*PkScript)(nil)).Elem()},
+		{"PrevOutputFetcher", reflect.TypeOf((*btcd.PrevOutputFetcher)(nil)).Elem(),
+			reflect.TypeOf((*PrevOutputFetcher)(nil)).Elem()},
+		{"ScriptBuilder", reflect.TypeOf((*btcd.ScriptBuilder)(nil)).Elem(),
+			reflect.TypeOf((*ScriptBuilder)(nil)).Elem()},
+		{"ScriptClass", reflect.TypeOf((*btcd.ScriptClass)(nil)).Elem(),
+			reflect.TypeOf((*ScriptClass)(nil)).Elem()},
+		{"ScriptClosure", reflect.TypeOf((*btcd.ScriptClosure)(nil)).Elem(),
+			reflect.TypeOf((*ScriptClosure)(nil)).Elem()},
+		{"ScriptDB", reflect.TypeOf((*btcd.ScriptDB)(nil)).Elem(),
+			reflect.TypeOf((*ScriptDB)(nil)).Elem()},
+		{"ScriptFlags", reflect.TypeOf((*btcd.ScriptFlags)(nil)).Elem(),
+			reflect.TypeOf((*ScriptFlags)(nil)).Elem()},
+		{"ScriptInfo", reflect.TypeOf((*btcd.ScriptInfo)(nil)).Elem(),
+			reflect.TypeOf((*ScriptInfo)(nil)).Elem()},
+		{"ScriptTokenizer", reflect.TypeOf((*btcd.ScriptTokenizer)(nil)).Elem(),
+			reflect.TypeOf((*ScriptTokenizer)(nil)).Elem()},
+		{"SegwitSigHashMidstate", reflect.TypeOf((*btcd.SegwitSigHashMidstate)(nil)).Elem(),
+			reflect.TypeOf((*SegwitSigHashMidstate)(nil)).Elem()},
+		{"SigCache", reflect.TypeOf((*btcd.SigCache)(nil)).Elem(),
+			reflect.TypeOf((*SigCache)(nil)).Elem()},
+		{"SigHashType", reflect.TypeOf((*btcd.SigHashType)(nil)).Elem(),
+			reflect.TypeOf((*SigHashType)(nil)).Elem()},
+		{"TapBranch", reflect.TypeOf((*btcd.TapBranch)(nil)).Elem(),
+			reflect.TypeOf((*TapBranch)(nil)).Elem()},
+		{"TapLeaf", reflect.TypeOf((*btcd.TapLeaf)(nil)).Elem(),
+			reflect.TypeOf((*TapLeaf)(nil)).Elem()},
+		{"TapNode", reflect.TypeOf((*btcd.TapNode)(nil)).Elem(),
+			reflect.TypeOf((*TapNode)(nil)).Elem()},
+		{"TaprootSigHashMidState", reflect.TypeOf((*btcd.TaprootSigHashMidState)(nil)).Elem(),
+			reflect.TypeOf((*TaprootSigHashMidState)(nil)).Elem()},
+		{"TaprootSigHashOption", reflect.TypeOf((*btcd.TaprootSigHashOption)(nil)).Elem(),
+			reflect.TypeOf((*TaprootSigHashOption)(nil)).Elem()},
+		{"TapscriptLeafVersion", reflect.TypeOf((*btcd.TapscriptLeafVersion)(nil)).Elem(),
+			reflect.TypeOf((*TapscriptLeafVersion)(nil)).Elem()},
+		{"TapscriptProof", reflect.TypeOf((*btcd.TapscriptProof)(nil)).Elem(),
+			reflect.TypeOf((*TapscriptProof)(nil)).Elem()},
+		{"TxSigHashes", reflect.TypeOf((*btcd.TxSigHashes)(nil)).Elem(),
+			reflect.TypeOf((*TxSigHashes)(nil)).Elem()},
+	}
+	for _, test := range tests {
+		require.Equal(t, test.btcd.Kind(), test.shim.Kind(), test.name)
+
+		if test.btcd.Kind() == reflect.Struct {
+			for i := 0; i < test.btcd.NumField(); i++ {
+				want := test.btcd.Field(i)
+				if !want.IsExported() {
+					continue
+				}
+				got, ok := test.shim.FieldByName(want.Name)
+				require.True(t, ok, "%s.%s", test.name, want.Name)
+				require.Equal(t, want.Type.String(), got.Type.String(),
+					"%s.%s", test.name, want.Name)
+			}
+		}
+
+		// Interfaces list their methods on the type itself, everything
+		// else is checked through the pointer method set.
+		btcdType, shimType := test.btcd, test.shim
+		if btcdType.Kind() != reflect.Interface {
+			btcdType, shimType = reflect.PtrTo(btcdType),
+				reflect.PtrTo(shimType)
+		}
+		for i := 0; i < btcdType.NumMethod(); i++ {
+			want := btcdType.Method(i)
+			got, ok := shimType.MethodByName(want.Name)
+			require.True(t, ok, "%s.%s", test.name, want.Name)
+			require.Equal(t, want.Type.String(), got.Type.String(),
+				"%s.%s", test.name, want.Name)
+		}
+		if test.btcd.Kind() == reflect.Interface {
+			require.Equal(t, test.btcd.NumMethod(), test.shim.NumMethod(),
+				"interface %s must not require extra methods", test.name)
+		}
+	}
+}
+
+// TestConstants 确保常量、操作码和变量的值与 btcd 相同。
+func TestConstants(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		btcd, shim interface{}
+	}{
+		{"BaseLeafVersion", btcd.BaseLeafVersion, BaseLeafVersion},
+		{"BaseSegwitWitnessVersion", btcd.BaseSegwitWitnessVersion, BaseSegwitWitnessVersion},
+		{"ControlBlockBaseSize", btcd.ControlBlockBaseSize, ControlBlockBaseSize},
+		{"ControlBlockMaxNodeCount", btcd.ControlBlockMaxNodeCount, ControlBlockMaxNodeCount},
+		{"ControlBlockMaxSize", btcd.ControlBlockMaxSize, ControlBlockMaxSize},
+		{"ControlBlockNodeSize", btcd.ControlBlockNodeSize, ControlBlockNodeSize},
+		{"ErrCheckMultiSigVerify", btcd.ErrCheckMultiSigVerify, ErrCheckMultiSigVerify},
+		{"ErrCheckSigVerify", btcd.ErrCheckSigVerify, ErrCheckSigVerify},
+		{"ErrCleanStack", btcd.ErrCleanStack, ErrCleanStack},
+		{"ErrControlBlockInvalidLength", btcd.ErrControlBlockInvalidLength, ErrControlBlockInvalidLength},
+		{"ErrControlBlockTooLarge", btcd.ErrControlBlockTooLarge, ErrControlBlockTooLarge},
+		{"ErrControlBlockTooSmall", btcd.ErrControlBlockTooSmall, ErrControlBlockTooSmall},
+		{"ErrDisabledOpcode", btcd.ErrDisabledOpcode, ErrDisabledOpcode},
+		{"ErrDiscourageOpSuccess", btcd.ErrDiscourageOpSuccess, ErrDiscourageOpSuccess},
+		{"ErrDiscourageUpgradableNOPs", btcd.ErrDiscourageUpgradableNOPs, ErrDiscourageUpgradableNOPs},
+		{"ErrDiscourageUpgradableWitnessProgram", btcd.ErrDiscourageUpgradableWitnessProgram, ErrDiscourageUpgradableWitnessProgram},
+		{"ErrDiscourageUpgradeablePubKeyType", btcd.ErrDiscourageUpgradeablePubKeyType, ErrDiscourageUpgradeablePubKeyType},
+		{"ErrDiscourageUpgradeableTaprootVersion", btcd.ErrDiscourageUpgradeableTaprootVersion, ErrDiscourageUpgradeableTaprootVersion},
+		{"ErrEarlyReturn", btcd.ErrEarlyReturn, ErrEarlyReturn},
+		{"ErrElementTooBig", btcd.ErrElementTooBig, ErrElementTooBig},
+		{"ErrEmptyStack", btcd.ErrEmptyStack, ErrEmptyStack},
+		{"ErrEqualVerify", btcd.ErrEqualVerify, ErrEqualVerify},
+		{"ErrEvalFalse", btcd.ErrEvalFalse, ErrEvalFalse},
+		{"ErrInternal", btcd.ErrInternal, ErrInternal},
+		{"ErrInvalidFlags", btcd.ErrInvalidFlags, ErrInvalidFlags},
+		{"ErrInvalidIndex", btcd.ErrInvalidIndex, ErrInvalidIndex},
+		{"ErrInvalidProgramCounter", btcd.ErrInvalidProgramCounter, ErrInvalidProgramCounter},
+		{"ErrInvalidPubKeyCount", btcd.ErrInvalidPubKeyCount, ErrInvalidPubKeyCount},
+		{"ErrInvalidSigHashType", btcd.ErrInvalidSigHashType, ErrInvalidSigHashType},
+		{"ErrInvalidSignatureCount", btcd.ErrInvalidSignatureCount, ErrInvalidSignatureCount},
+		{"ErrInvalidStackOperation", btcd.ErrInvalidStackOperation, ErrInvalidStackOperation},
+		{"ErrInvalidTaprootSigLen", btcd.ErrInvalidTaprootSigLen, ErrInvalidTaprootSigLen},
+		{"ErrMalformedPush", btcd.ErrMalformedPush, ErrMalformedPush},
+		{"ErrMinimalData", btcd.ErrMinimalData, ErrMinimalData},
+		{"ErrMinimalIf", btcd.ErrMinimalIf, ErrMinimalIf},
+		{"ErrNegativeLockTime", btcd.ErrNegativeLockTime, ErrNegativeLockTime},
+		{"ErrNotMultisigScript", btcd.ErrNotMultisigScript, ErrNotMultisigScript},
+		{"ErrNotPushOnly", btcd.ErrNotPushOnly, ErrNotPushOnly},
+		{"ErrNullFail", btcd.ErrNullFail, ErrNullFail},
+		{"ErrNumEqualVerify", btcd.ErrNumEqualVerify, ErrNumEqualVerify},
+		{"ErrNumberTooBig", btcd.ErrNumberTooBig, ErrNumberTooBig},
+		{"ErrPubKeyType", btcd.ErrPubKeyType, ErrPubKeyType},
+		{"ErrReservedOpcode", btcd.ErrReservedOpcode, ErrReservedOpcode},
+		{"ErrScriptTooBig", btcd.ErrScriptTooBig, ErrScriptTooBig},
+		{"ErrScriptUnfinished", btcd.ErrScriptUnfinished, ErrScriptUnfinished},
+		{"ErrSigHighS", btcd.ErrSigHighS, ErrSigHighS},
+		{"ErrSigInvalidDataLen", btcd.ErrSigInvalidDataLen, ErrSigInvalidDataLen},
+		{"ErrSigInvalidRIntID", btcd.ErrSigInvalidRIntID, ErrSigInvalidRIntID},
+		{"ErrSigInvalidSIntID", btcd.ErrSigInvalidSIntID, ErrSigInvalidSIntID},
+		{"ErrSigInvalidSLen", btcd.ErrSigInvalidSLen, ErrSigInvalidSLen},
+		{"ErrSigInvalidSeqID", btcd.ErrSigInvalidSeqID, ErrSigInvalidSeqID},
+		{"ErrSigMissingSLen", btcd.ErrSigMissingSLen, ErrSigMissingSLen},
+		{"ErrSigMissingSTypeID", btcd.ErrSigMissingSTypeID, ErrSigMissingSTypeID},
+		{"ErrSigNegativeR", btcd.ErrSigNegativeR, ErrSigNegativeR},
+		{"ErrSigNegativeS", btcd.ErrSigNegativeS, ErrSigNegativeS},
+		{"ErrSigNullDummy", btcd.ErrSigNullDummy, ErrSigNullDummy},
+		{"ErrSigTooLong", btcd.ErrSigTooLong, ErrSigTooLong},
+		{"ErrSigTooMuchRPadding", btcd.ErrSigTooMuchRPadding, ErrSigTooMuchRPadding},
+		{"ErrSigTooMuchSPadding", btcd.ErrSigTooMuchSPadding, ErrSigTooMuchSPadding},
+		{"ErrSigTooShort", btcd.ErrSigTooShort, ErrSigTooShort},
+		{"ErrSigZeroRLen", btcd.ErrSigZeroRLen, ErrSigZeroRLen},
+		{"ErrSigZeroSLen", btcd.ErrSigZeroSLen, ErrSigZeroSLen},
+		{"ErrStackOverflow", btcd.ErrStackOverflow, ErrStackOverflow},
+		{"ErrTaprootMaxSigOps", btcd.ErrTaprootMaxSigOps, ErrTaprootMaxSigOps},
+		{"ErrTaprootMerkleProofInvalid", btcd.ErrTaprootMerkleProofInvalid, ErrTaprootMerkleProofInvalid},
+		{"ErrTaprootOutputKeyParityMismatch", btcd.ErrTaprootOutputKeyParityMismatch, ErrTaprootOutputKeyParityMismatch},
+		{"ErrTaprootPubkeyIsEmpty", btcd.ErrTaprootPubkeyIsEmpty, ErrTaprootPubkeyIsEmpty},
+		{"ErrTaprootSigInvalid", btcd.ErrTaprootSigInvalid, ErrTaprootSigInvalid},
+		{"ErrTapscriptCheckMultisig", btcd.ErrTapscriptCheckMultisig, ErrTapscriptCheckMultisig},
+		{"ErrTooManyOperations", btcd.ErrTooManyOperations, ErrTooManyOperations},
+		{"ErrTooManyRequiredSigs", btcd.ErrTooManyRequiredSigs, ErrTooManyRequiredSigs},
+		{"ErrTooMuchNullData", btcd.ErrTooMuchNullData, ErrTooMuchNullData},
+		{"ErrUnbalancedConditional", btcd.ErrUnbalancedConditional, ErrUnbalancedConditional},
+		{"ErrUnsatisfiedLockTime", btcd.ErrUnsatisfiedLockTime, ErrUnsatisfiedLockTime},
+		{"ErrUnsupportedAddress", btcd.ErrUnsupportedAddress, ErrUnsupportedAddress},
+		{"ErrUnsupportedScriptVersion", btcd.ErrUnsupportedScriptVersion, ErrUnsupportedScriptVersion},
+		{"ErrVerify", btcd.ErrVerify, ErrVerify},
+		{"ErrWitnessHasNoAnnex", btcd.ErrWitnessHasNoAnnex, ErrWitnessHasNoAnnex},
+		{"ErrWitnessMalleated", btcd.ErrWitnessMalleated, ErrWitnessMalleated},
+		{"ErrWitnessMalleatedP2SH", btcd.ErrWitnessMalleatedP2SH, ErrWitnessMalleatedP2SH},
+		{"ErrWitnessProgramEmpty", btcd.ErrWitnessProgramEmpty, ErrWitnessProgramEmpty},
+		{"ErrWitnessProgramMismatch", btcd.ErrWitnessProgramMismatch, ErrWitnessProgramMismatch},
+		{"ErrWitnessProgramWrongLength", btcd.ErrWitnessProgramWrongLength, ErrWitnessProgramWrongLength},
+		{"ErrWitnessPubKeyType", btcd.ErrWitnessPubKeyType, ErrWitnessPubKeyType},
+		{"ErrWitnessUnexpected", btcd.ErrWitnessUnexpected, ErrWitnessUnexpected},
+		{"LockTimeThreshold", btcd.LockTimeThreshold, LockTimeThreshold},
+		{"MaxDataCarrierSize", btcd.MaxDataCarrierSize, MaxDataCarrierSize},
+		{"MaxOpsPerScript", btcd.MaxOpsPerScript, MaxOpsPerScript},
+		{"MaxPubKeysPerMultiSig", btcd.MaxPubKeysPerMultiSig, MaxPubKeysPerMultiSig},
+		{"MaxScriptElementSize", btcd.MaxScriptElementSize, MaxScriptElementSize},
+		{"MaxScriptSize", btcd.MaxScriptSize, MaxScriptSize},
+		{"MaxStackSize", btcd.MaxStackSize, MaxStackSize},
+		{"MultiSigTy", btcd.MultiSigTy, MultiSigTy},
+		{"NonStandardTy", btcd.NonStandardTy, NonStandardTy},
+		{"NullDataTy", btcd.NullDataTy, NullDataTy},
+		{"OpCondFalse", btcd.OpCondFalse, OpCondFalse},
+		{"OpCondSkip", btcd.OpCondSkip, OpCondSkip},
+		{"OpCondTrue", btcd.OpCondTrue, OpCondTrue},
+		{"PubKeyHashTy", btcd.PubKeyHashTy, PubKeyHashTy},
+		{"PubKeyTy", btcd.PubKeyTy, PubKeyTy},
+		{"ScriptBip16", btcd.ScriptBip16, ScriptBip16},
+		{"ScriptDiscourageUpgradableNops", btcd.ScriptDiscourageUpgradableNops, ScriptDiscourageUpgradableNops},
+		{"ScriptHashTy", btcd.ScriptHashTy, ScriptHashTy},
+		{"ScriptStrictMultiSig", btcd.ScriptStrictMultiSig, ScriptStrictMultiSig},
+		{"ScriptVerifyCheckLockTimeVerify", btcd.ScriptVerifyCheckLockTimeVerify, ScriptVerifyCheckLockTimeVerify},
+		{"ScriptVerifyCheckSequenceVerify", btcd.ScriptVerifyCheckSequenceVerify, ScriptVerifyCheckSequenceVerify},
+		{"ScriptVerifyCleanStack", btcd.ScriptVerifyCleanStack, ScriptVerifyCleanStack},
+		{"ScriptVerifyDERSignatures", btcd.ScriptVerifyDERSignatures, ScriptVerifyDERSignatures},
+		{"ScriptVerifyDiscourageOpSuccess", btcd.ScriptVerifyDiscourageOpSuccess, ScriptVerifyDiscourageOpSuccess},
+		{"ScriptVerifyDiscourageUpgradeablePubkeyType", btcd.ScriptVerifyDiscourageUpgradeablePubkeyType, ScriptVerifyDiscourageUpgradeablePubkeyType},
+		{"ScriptVerifyDiscourageUpgradeableTaprootVersion", btcd.ScriptVerifyDiscourageUpgradeableTaprootVersion, ScriptVerifyDiscourageUpgradeableTaprootVersion},
+		{"ScriptVerifyDiscourageUpgradeableWitnessProgram", btcd.ScriptVerifyDiscourageUpgradeableWitnessProgram, ScriptVerifyDiscourageUpgradeableWitnessProgram},
+		{"ScriptVerifyLowS", btcd.ScriptVerifyLowS, ScriptVerifyLowS},
+		{"ScriptVerifyMinimalData", btcd.ScriptVerifyMinimalData, ScriptVerifyMinimalData},
+		{"ScriptVerifyMinimalIf", btcd.ScriptVerifyMinimalIf, ScriptVerifyMinimalIf},
+		{"ScriptVerifyNullFail", btcd.ScriptVerifyNullFail, ScriptVerifyNullFail},
+		{"ScriptVerifySigPushOnly", btcd.ScriptVerifySigPushOnly, ScriptVerifySigPushOnly},
+		{"ScriptVerifyStrictEncoding", btcd.ScriptVerifyStrictEncoding, ScriptVerifyStrictEncoding},
+		{"ScriptVerifyTaproot", btcd.ScriptVerifyTaproot, ScriptVerifyTaproot},
+		{"ScriptVerifyWitness", btcd.ScriptVerifyWitness, ScriptVerifyWitness},
+		{"ScriptVerifyWitnessPubKeyType", btcd.ScriptVerifyWitnessPubKeyType, ScriptVerifyWitnessPubKeyType},
+		{"SigHashAll", btcd.SigHashAll, SigHashAll},
+		{"SigHashAnyOneCanPay", btcd.SigHashAnyOneCanPay, SigHashAnyOneCanPay},
+		{"SigHashDefault", btcd.SigHashDefault, SigHashDefault},
+		{"SigHashNone", btcd.SigHashNone, SigHashNone},
+		{"SigHashOld", btcd.SigHashOld, SigHashOld},
+		{"SigHashSingle", btcd.SigHashSingle, SigHashSingle},
+		{"StandardVerifyFlags", btcd.StandardVerifyFlags, StandardVerifyFlags},
+		{"TaprootAnnexTag", btcd.TaprootAnnexTag, TaprootAnnexTag},
+		{"TaprootLeafMask", btcd.TaprootLeafMask, TaprootLeafMask},
+		{"TaprootWitnessVersion", btcd.TaprootWitnessVersion, TaprootWitnessVersion},
+		{"WitnessUnknownTy", btcd.WitnessUnknownTy, WitnessUnknownTy},
+		{"WitnessV0PubKeyHashTy", btcd.WitnessV0PubKeyHashTy, WitnessV0PubKeyHashTy},
+		{"WitnessV0ScriptHashTy", btcd.WitnessV0ScriptHashTy, WitnessV0ScriptHashTy},
+		{"WitnessV1TaprootTy", btcd.WitnessV1TaprootTy, WitnessV1TaprootTy},
+		{"OP_0", btcd.OP_0, OP_0},
+		{"OP_0NOTEQUAL", btcd.OP_0NOTEQUAL, OP_0NOTEQUAL},
+		{"OP_1", btcd.OP_1, OP_1},
+		{"OP_10", btcd.OP_10, OP_10},
+		{"OP_11", btcd.OP_11, OP_11},
+		{"OP_12", btcd.OP_12, OP_12},
+		{"OP_13", btcd.OP_13, OP_13},
+		{"OP_14", btcd.OP_14, OP_14},
+		{"OP_15", btcd.OP_15, OP_15},
+		{"OP_16", btcd.OP_16, OP_16},
+		{"OP_1ADD", btcd.OP_1ADD, OP_1ADD},
+		{"OP_1NEGATE", btcd.OP_1NEGATE, OP_1NEGATE},
+		{"OP_1SUB", btcd.OP_1SUB, OP_1SUB},
+		{"OP_2", btcd.OP_2, OP_2},
+		{"OP_2DIV", btcd.OP_2DIV, OP_2DIV},
+		{"OP_2DROP", btcd.OP_2DROP, OP_2DROP},
+		{"OP_2DUP", btcd.OP_2DUP, OP_2DUP},
+		{"OP_2MUL", btcd.OP_2MUL, OP_2MUL},
+		{"OP_2OVER", btcd.OP_2OVER, OP_2OVER},
+		{"OP_2ROT", btcd.OP_2ROT, OP_2ROT},
+		{"OP_2SWAP", btcd.OP_2SWAP, OP_2SWAP},
+		{"OP_3", btcd.OP_3, OP_3},
+		{"OP_3DUP", btcd.OP_3DUP, OP_3DUP},
+		{"OP_4", btcd.OP_4, OP_4},
+		{"OP_5", btcd.OP_5, OP_5},
+		{"OP_6", btcd.OP_6, OP_6},
+		{"OP_7", btcd.OP_7, OP_7},
+		{"OP_8", btcd.OP_8, OP_8},
+		{"OP_9", btcd.OP_9, OP_9},
+		{"OP_ABS", btcd.OP_ABS, OP_ABS},
+		{"OP_ADD", btcd.OP_ADD, OP_ADD},
+		{"OP_AND", btcd.OP_AND, OP_AND},
+		{"OP_BOOLAND", btcd.OP_BOOLAND, OP_BOOLAND},
+		{"OP_BOOLOR", btcd.OP_BOOLOR, OP_BOOLOR},
+		{"OP_CAT", btcd.OP_CAT, OP_CAT},
+		{"OP_CHECKLOCKTIMEVERIFY", btcd.OP_CHECKLOCKTIMEVERIFY, OP_CHECKLOCKTIMEVERIFY},
+		{"OP_CHECKMULTISIG", btcd.OP_CHECKMULTISIG, OP_CHECKMULTISIG},
+		{"OP_CHECKMULTISIGVERIFY", btcd.OP_CHECKMULTISIGVERIFY, OP_CHECKMULTISIGVERIFY},
+		{"OP_CHECKSEQUENCEVERIFY", btcd.OP_CHECKSEQUENCEVERIFY, OP_CHECKSEQUENCEVERIFY},
+		{"OP_CHECKSIG", btcd.OP_CHECKSIG, OP_CHECKSIG},
+		{"OP_CHECKSIGADD", btcd.OP_CHECKSIGADD, OP_CHECKSIGADD},
+		{"OP_CHECKSIGVERIFY", btcd.OP_CHECKSIGVERIFY, OP_CHECKSIGVERIFY},
+		{"OP_CODESEPARATOR", btcd.OP_CODESEPARATOR, OP_CODESEPARATOR},
+		{"OP_DATA_1", btcd.OP_DATA_1, OP_DATA_1},
+		{"OP_DATA_10", btcd.OP_DATA_10, OP_DATA_10},
+		{"OP_DATA_11", btcd.OP_DATA_11, OP_DATA_11},
+		{"OP_DATA_12", btcd.OP_DATA_12, OP_DATA_12},
+		{"OP_DATA_13", btcd.OP_DATA_13, OP_DATA_13},
+		{"OP_DATA_14", btcd.OP_DATA_14, OP_DATA_14},
+		{"OP_DATA_15", btcd.OP_DATA_15, OP_DATA_15},
+		{"OP_DATA_16", btcd.OP_DATA_16, OP_DATA_16},
+		{"OP_DATA_17", btcd.OP_DATA_17, OP_DATA_17},
+		{"OP_DATA_18", btcd.OP_DATA_18, OP_DATA_18},
+		{"OP_DATA_19", btcd.OP_DATA_19, OP_DATA_19},
+		{"OP_DATA_2", btcd.OP_DATA_2, OP_DATA_2},
+		{"OP_DATA_20", btcd.OP_DATA_20, OP_DATA_20},
+		{"OP_DATA_21", btcd.OP_DATA_21, OP_DATA_21},
+		{"OP_DATA_22", btcd.OP_DATA_22, OP_DATA_22},
+		{"OP_DATA_23", btcd.OP_DATA_23, OP_DATA_23},
+		{"OP_DATA_24", btcd.OP_DATA_24, OP_DATA_24},
+		{"OP_DATA_25", btcd.OP_DATA_25, OP_DATA_25},
+		{"OP_DATA_26", btcd.OP_DATA_26, OP_DATA_26},
+		{"OP_DATA_27", btcd.OP_DATA_27, OP_DATA_27},
+		{"OP_DATA_28", btcd.OP_DATA_28, OP_DATA_28},
+		{"OP_DATA_29", btcd.OP_DATA_29, OP_DATA_29},
+		{"OP_DATA_3", btcd.OP_DATA_3, OP_DATA_3},
+		{"OP_DATA_30", btcd.OP_DATA_30, OP_DATA_30},
+		{"OP_DATA_31", btcd.OP_DATA_31, OP_DATA_31},
+		{"OP_DATA_32", btcd.OP_DATA_32, OP_DATA_32},
+		{"OP_DATA_33", btcd.OP_DATA_33, OP_DATA_33},
+		{"OP_DATA_34", btcd.OP_DATA_34, OP_DATA_34},
+		{"OP_DATA_35", btcd.OP_DATA_35, OP_DATA_35},
+		{"OP_DATA_36", btcd.OP_DATA_36, OP_DATA_36},
+		{"OP_DATA_37", btcd.OP_DATA_37, OP_DATA_37},
+		{"OP_DATA_38", btcd.OP_DATA_38, OP_DATA_38},
+		{"OP_DATA_39", btcd.OP_DATA_39, OP_DATA_39},
+		{"OP_DATA_4", btcd.OP_DATA_4, OP_DATA_4},
+		{"OP_DATA_40", btcd.OP_DATA_40, OP_DATA_40},
+		{"OP_DATA_41", btcd.OP_DATA_41, OP_DATA_41},
+		{"OP_DATA_42", btcd.OP_DATA_42, OP_DATA_42},
+		{"OP_DATA_43", btcd.OP_DATA_43, OP_DATA_43},
+		{"OP_DATA_44", btcd.OP_DATA_44, OP_DATA_44},
+		{"OP_DATA_45", btcd.OP_DATA_45, OP_DATA_45},
+		{"OP_DATA_46", btcd.OP_DATA_46, OP_DATA_46},
+		{"OP_DATA_47", btcd.OP_DATA_47, OP_DATA_47},
+		{"OP_DATA_48", btcd.OP_DATA_48, OP_DATA_48},
+		{"OP_DATA_49", btcd.OP_DATA_49, OP_DATA_49},
+		{"OP_DATA_5", btcd.OP_DATA_5, OP_DATA_5},
+		{"OP_DATA_50", btcd.OP_DATA_50, OP_DATA_50},
+		{"OP_DATA_51", btcd.OP_DATA_51, OP_DATA_51},
+		{"OP_DATA_52", btcd.OP_DATA_52, OP_DATA_52},
+		{"OP_DATA_53", btcd.OP_DATA_53, OP_DATA_53},
+		{"OP_DATA_54", btcd.OP_DATA_54, OP_DATA_54},
+		{"OP_DATA_55", btcd.OP_DATA_55, OP_DATA_55},
+		{"OP_DATA_56", btcd.OP_DATA_56, OP_DATA_56},
+		{"OP_DATA_57", btcd.OP_DATA_57, OP_DATA_57},
+		{"OP_DATA_58", btcd.OP_DATA_58, OP_DATA_58},
+		{"OP_DATA_59", btcd.OP_DATA_59, OP_DATA_59},
+		{"OP_DATA_6", btcd.OP_DATA_6, OP_DATA_6},
+		{"OP_DATA_60", btcd.OP_DATA_60, OP_DATA_60},
+		{"OP_DATA_61", btcd.OP_DATA_61, OP_DATA_61},
+		{"OP_DATA_62", btcd.OP_DATA_62, OP_DATA_62},
+		{"OP_DATA_63", btcd.OP_DATA_63, OP_DATA_63},
+		{"OP_DATA_64", btcd.OP_DATA_64, OP_DATA_64},
+		{"OP_DATA_65", btcd.OP_DATA_65, OP_DATA_65},
+		{"OP_DATA_66", btcd.OP_DATA_66, OP_DATA_66},
+		{"OP_DATA_67", btcd.OP_DATA_67, OP_DATA_67},
+		{"OP_DATA_68", btcd.OP_DATA_68, OP_DATA_68},
+		{"OP_DATA_69", btcd.OP_DATA_69, OP_DATA_69},
+		{"OP_DATA_7", btcd.OP_DATA_7, OP_DATA_7},
+		{"OP_DATA_70", btcd.OP_DATA_70, OP_DATA_70},
+		{"OP_DATA_71", btcd.OP_DATA_71, OP_DATA_71},
+		{"OP_DATA_72", btcd.OP_DATA_72, OP_DATA_72},
+		{"OP_DATA_73", btcd.OP_DATA_73, OP_DATA_73},
+		{"OP_DATA_74", btcd.OP_DATA_74, OP_DATA_74},
+		{"OP_DATA_75", btcd.OP_DATA_75, OP_DATA_75},
+		{"OP_DATA_8", btcd.OP_DATA_8, OP_DATA_8},
+		{"OP_DATA_9", btcd.OP_DATA_9, OP_DATA_9},
+		{"OP_DEPTH", btcd.OP_DEPTH, OP_DEPTH},
+		{"OP_DIV", btcd.OP_DIV, OP_DIV},
+		{"OP_DROP", btcd.OP_DROP, OP_DROP},
+		{"OP_DUP", btcd.OP_DUP, OP_DUP},
+		{"OP_ELSE", btcd.OP_ELSE, OP_ELSE},
+		{"OP_ENDIF", btcd.OP_ENDIF, OP_ENDIF},
+		{"OP_EQUAL", btcd.OP_EQUAL, OP_EQUAL},
+		{"OP_EQUALVERIFY", btcd.OP_EQUALVERIFY, OP_EQUALVERIFY},
+		{"OP_FALSE", btcd.OP_FALSE, OP_FALSE},
+		{"OP_FROMALTSTACK", btcd.OP_FROMALTSTACK, OP_FROMALTSTACK},
+		{"OP_GREATERTHAN", btcd.OP_GREATERTHAN, OP_GREATERTHAN},
+		{"OP_GREATERTHANOREQUAL", btcd.OP_GREATERTHANOREQUAL, OP_GREATERTHANOREQUAL},
+		{"OP_HASH160", btcd.OP_HASH160, OP_HASH160},
+		{"OP_HASH256", btcd.OP_HASH256, OP_HASH256},
+		{"OP_IF", btcd.OP_IF, OP_IF},
+		{"OP_IFDUP", btcd.OP_IFDUP, OP_IFDUP},
+		{"OP_INVALIDOPCODE", btcd.OP_INVALIDOPCODE, OP_INVALIDOPCODE},
+		{"OP_INVERT", btcd.OP_INVERT, OP_INVERT},
+		{"OP_LEFT", btcd.OP_LEFT, OP_LEFT},
+		{"OP_LESSTHAN", btcd.OP_LESSTHAN, OP_LESSTHAN},
+		{"OP_LESSTHANOREQUAL", btcd.OP_LESSTHANOREQUAL, OP_LESSTHANOREQUAL},
+		{"OP_LSHIFT", btcd.OP_LSHIFT, OP_LSHIFT},
+		{"OP_MAX", btcd.OP_MAX, OP_MAX},
+		{"OP_MIN", btcd.OP_MIN, OP_MIN},
+		{"OP_MOD", btcd.OP_MOD, OP_MOD},
+		{"OP_MUL", btcd.OP_MUL, OP_MUL},
+		{"OP_NEGATE", btcd.OP_NEGATE, OP_NEGATE},
+		{"OP_NIP", btcd.OP_NIP, OP_NIP},
+		{"OP_NOP", btcd.OP_NOP, OP_NOP},
+		{"OP_NOP1", btcd.OP_NOP1, OP_NOP1},
+		{"OP_NOP10", btcd.OP_NOP10, OP_NOP10},
+		{"OP_NOP2", btcd.OP_NOP2, OP_NOP2},
+		{"OP_NOP3", btcd.OP_NOP3, OP_NOP3},
+		{"OP_NOP4", btcd.OP_NOP4, OP_NOP4},
+		{"OP_NOP5", btcd.OP_NOP5, OP_NOP5},
+		{"OP_NOP6", btcd.OP_NOP6, OP_NOP6},
+		{"OP_NOP7", btcd.OP_NOP7, OP_NOP7},
+		{"OP_NOP8", btcd.OP_NOP8, OP_NOP8},
+		{"OP_NOP9", btcd.OP_NOP9, OP_NOP9},
+		{"OP_NOT", btcd.OP_NOT, OP_NOT},
+		{"OP_NOTIF", btcd.OP_NOTIF, OP_NOTIF},
+		{"OP_NUMEQUAL", btcd.OP_NUMEQUAL, OP_NUMEQUAL},
+		{"OP_NUMEQUALVERIFY", btcd.OP_NUMEQUALVERIFY, OP_NUMEQUALVERIFY},
+		{"OP_NUMNOTEQUAL", btcd.OP_NUMNOTEQUAL, OP_NUMNOTEQUAL},
+		{"OP_OR", btcd.OP_OR, OP_OR},
+		{"OP_OVER", btcd.OP_OVER, OP_OVER},
+		{"OP_PICK", btcd.OP_PICK, OP_PICK},
+		{"OP_PUBKEY", btcd.OP_PUBKEY, OP_PUBKEY},
+		{"OP_PUBKEYHASH", btcd.OP_PUBKEYHASH, OP_PUBKEYHASH},
+		{"OP_PUBKEYS", btcd.OP_PUBKEYS, OP_PUBKEYS},
+		{"OP_PUSHDATA1", btcd.OP_PUSHDATA1, OP_PUSHDATA1},
+		{"OP_PUSHDATA2", btcd.OP_PUSHDATA2, OP_PUSHDATA2},
+		{"OP_PUSHDATA4", btcd.OP_PUSHDATA4, OP_PUSHDATA4},
+		{"OP_RESERVED", btcd.OP_RESERVED, OP_RESERVED},
+		{"OP_RESERVED1", btcd.OP_RESERVED1, OP_RESERVED1},
+		{"OP_RESERVED2", btcd.OP_RESERVED2, OP_RESERVED2},
+		{"OP_RETURN", btcd.OP_RETURN, OP_RETURN},
+		{"OP_RIGHT", btcd.OP_RIGHT, OP_RIGHT},
+		{"OP_RIPEMD160", btcd.OP_RIPEMD160, OP_RIPEMD160},
+		{"OP_ROLL", btcd.OP_ROLL, OP_ROLL},
+		{"OP_ROT", btcd.OP_ROT, OP_ROT},
+		{"OP_RSHIFT", btcd.OP_RSHIFT, OP_RSHIFT},
+		{"OP_SHA1", btcd.OP_SHA1, OP_SHA1},
+		{"OP_SHA256", btcd.OP_SHA256, OP_SHA256},
+		{"OP_SIZE", btcd.OP_SIZE, OP_SIZE},
+		{"OP_SMALLINTEGER", btcd.OP_SMALLINTEGER, OP_SMALLINTEGER},
+		{"OP_SUB", btcd.OP_SUB, OP_SUB},
+		{"OP_SUBSTR", btcd.OP_SUBSTR, OP_SUBSTR},
+		{"OP_SWAP", btcd.OP_SWAP, OP_SWAP},
+		{"OP_TOALTSTACK", btcd.OP_TOALTSTACK, OP_TOALTSTACK},
+		{"OP_TRUE", btcd.OP_TRUE, OP_TRUE},
+		{"OP_TUCK", btcd.OP_TUCK, OP_TUCK},
+		{"OP_UNKNOWN187", btcd.OP_UNKNOWN187, OP_UNKNOWN187},
+		{"OP_UNKNOWN188", btcd.OP_UNKNOWN188, OP_UNKNOWN188},
+		{"OP_UNKNOWN189", btcd.OP_UNKNOWN189, OP_UNKNOWN189},
+		{"OP_UNKNOWN190", btcd.OP_UNKNOWN190, OP_UNKNOWN190},
+		{"OP_UNKNOWN191", btcd.OP_UNKNOWN191, OP_UNKNOWN191},
+		{"OP_UNKNOWN192", btcd.OP_UNKNOWN192, OP_UNKNOWN192},
+		{"OP_UNKNOWN193", btcd.OP_UNKNOWN193, OP_UNKNOWN193},
+		{"OP_UNKNOWN194", btcd.OP_UNKNOWN194, OP_UNKNOWN194},
+		{"OP_UNKNOWN195", btcd.OP_UNKNOWN195, OP_UNKNOWN195},
+		{"OP_UNKNOWN196", btcd.OP_UNKNOWN196, OP_UNKNOWN196},
+		{"OP_UNKNOWN197", btcd.OP_UNKNOWN197, OP_UNKNOWN197},
+		{"OP_UNKNOWN198", btcd.OP_UNKNOWN198, OP_UNKNOWN198},
+		{"OP_UNKNOWN199", btcd.OP_UNKNOWN199, OP_UNKNOWN199},
+		{"OP_UNKNOWN200", btcd.OP_UNKNOWN200, OP_UNKNOWN200},
+		{"OP_UNKNOWN201", btcd.OP_UNKNOWN201, OP_UNKNOWN201},
+		{"OP_UNKNOWN202", btcd.OP_UNKNOWN202, OP_UNKNOWN202},
+		{"OP_UNKNOWN203", btcd.OP_UNKNOWN203, OP_UNKNOWN203},
+		{"OP_UNKNOWN204", btcd.OP_UNKNOWN204, OP_UNKNOWN204},
+		{"OP_UNKNOWN205", btcd.OP_UNKNOWN205, OP_UNKNOWN205},
+		{"OP_UNKNOWN206", btcd.OP_UNKNOWN206, OP_UNKNOWN206},
+		{"OP_UNKNOWN207", btcd.OP_UNKNOWN207, OP_UNKNOWN207},
+		{"OP_UNKNOWN208", btcd.OP_UNKNOWN208, OP_UNKNOWN208},
+		{"OP_UNKNOWN209", btcd.OP_UNKNOWN209, OP_UNKNOWN209},
+		{"OP_UNKNOWN210", btcd.OP_UNKNOWN210, OP_UNKNOWN210},
+		{"OP_UNKNOWN211", btcd.OP_UNKNOWN211, OP_UNKNOWN211},
+		{"OP_UNKNOWN212", btcd.OP_UNKNOWN212, OP_UNKNOWN212},
+		{"OP_UNKNOWN213", btcd.OP_UNKNOWN213, OP_UNKNOWN213},
+		{"OP_UNKNOWN214", btcd.OP_UNKNOWN214, OP_UNKNOWN214},
+		{"OP_UNKNOWN215", btcd.OP_UNKNOWN215, OP_UNKNOWN215},
+		{"OP_UNKNOWN216", btcd.OP_UNKNOWN216, OP_UNKNOWN216},
+		{"OP_UNKNOWN217", btcd.OP_UNKNOWN217, OP_UNKNOWN217},
+		{"OP_UNKNOWN218", btcd.OP_UNKNOWN218, OP_UNKNOWN218},
+		{"OP_UNKNOWN219", btcd.OP_UNKNOWN219, OP_UNKNOWN219},
+		{"OP_UNKNOWN220", btcd.OP_UNKNOWN220, OP_UNKNOWN220},
+		{"OP_UNKNOWN221", btcd.OP_UNKNOWN221, OP_UNKNOWN221},
+		{"OP_UNKNOWN222", btcd.OP_UNKNOWN222, OP_UNKNOWN222},
+		{"OP_UNKNOWN223", btcd.OP_UNKNOWN223, OP_UNKNOWN223},
+		{"OP_UNKNOWN224", btcd.OP_UNKNOWN224, OP_UNKNOWN224},
+		{"OP_UNKNOWN225", btcd.OP_UNKNOWN225, OP_UNKNOWN225},
+		{"OP_UNKNOWN226", btcd.OP_UNKNOWN226, OP_UNKNOWN226},
+		{"OP_UNKNOWN227", btcd.OP_UNKNOWN227, OP_UNKNOWN227},
+		{"OP_UNKNOWN228", btcd.OP_UNKNOWN228, OP_UNKNOWN228},
+		{"OP_UNKNOWN229", btcd.OP_UNKNOWN229, OP_UNKNOWN229},
+		{"OP_UNKNOWN230", btcd.OP_UNKNOWN230, OP_UNKNOWN230},
+		{"OP_UNKNOWN231", btcd.OP_UNKNOWN231, OP_UNKNOWN231},
+		{"OP_UNKNOWN232", btcd.OP_UNKNOWN232, OP_UNKNOWN232},
+		{"OP_UNKNOWN233", btcd.OP_UNKNOWN233, OP_UNKNOWN233},
+		{"OP_UNKNOWN234", btcd.OP_UNKNOWN234, OP_UNKNOWN234},
+		{"OP_UNKNOWN235", btcd.OP_UNKNOWN235, OP_UNKNOWN235},
+		{"OP_UNKNOWN236", btcd.OP_UNKNOWN236, OP_UNKNOWN236},
+		{"OP_UNKNOWN237", btcd.OP_UNKNOWN237, OP_UNKNOWN237},
+		{"OP_UNKNOWN238", btcd.OP_UNKNOWN238, OP_UNKNOWN238},
+		{"OP_UNKNOWN239", btcd.OP_UNKNOWN239, OP_UNKNOWN239},
+		{"OP_UNKNOWN240", btcd.OP_UNKNOWN240, OP_UNKNOWN240},
+		{"OP_UNKNOWN241", btcd.OP_UNKNOWN241, OP_UNKNOWN241},
+		{"OP_UNKNOWN242", btcd.OP_UNKNOWN242, OP_UNKNOWN242},
+		{"OP_UNKNOWN243", btcd.OP_UNKNOWN243, OP_UNKNOWN243},
+		{"OP_UNKNOWN244", btcd.OP_UNKNOWN244, OP_UNKNOWN244},
+		{"OP_UNKNOWN245", btcd.OP_UNKNOWN245, OP_UNKNOWN245},
+		{"OP_UNKNOWN246", btcd.OP_UNKNOWN246, OP_UNKNOWN246},
+		{"OP_UNKNOWN247", btcd.OP_UNKNOWN247, OP_UNKNOWN247},
+		{"OP_UNKNOWN248", btcd.OP_UNKNOWN248, OP_UNKNOWN248},
+		{"OP_UNKNOWN249", btcd.OP_UNKNOWN249, OP_UNKNOWN249},
+		{"OP_UNKNOWN252", btcd.OP_UNKNOWN252, OP_UNKNOWN252},
+		{"OP_VER", btcd.OP_VER, OP_VER},
+		{"OP_VERIF", btcd.OP_VERIF, OP_VERIF},
+		{"OP_VERIFY", btcd.OP_VERIFY, OP_VERIFY},
+		{"OP_VERNOTIF", btcd.OP_VERNOTIF, OP_VERNOTIF},
+		{"OP_WITHIN", btcd.OP_WITHIN, OP_WITHIN},
+		{"OP_XOR", btcd.OP_XOR, OP_XOR},
+		{"Bip16Activation", btcd.Bip16Activation, Bip16Activation},
+	}
+	for _, test := range tests {
+		require.Equal(t, constValue(test.btcd), constValue(test.shim),
+			test.name)
+	}
+
+	// The fork names the opcodes it assigns, so only their btcd names are
+	// missing from OpcodeByName.
+	renamed := map[string]bool{"OP_UNKNOWN193": true, "OP_UNKNOWN194": true}
+	for name, op := range btcd.OpcodeByName {
+		if renamed[name] {
+			_, ok := OpcodeByName[name]
+			require.False(t, ok, name)
+			continue
+		}
+		require.Equal(t, op, OpcodeByName[name], name)
+	}
+	require.Equal(t, btcd.ErrUnsupportedScriptType.Error(),
+		ErrUnsupportedScriptType.Error())
+}
+
+// constValue 返回常量的底层值的文本形式。 本分支为部分常量类型增加了 String 方法，因此不能直接格式化。
+func constValue(v interface{}) string {
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32,
+		reflect.Int64:
+
+		return fmt.Sprint(rv.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32,
+		reflect.Uint64:
+
+		return fmt.Sprint(rv.Uint())
+	}
+	return fmt.Sprint(v)
+}
+
+// TestTaprootSignaturesMatchBtcd 确保 taproot 签名包装函数与 btcd 一样是确定性的并且逐字节相同，并且签名可以被引擎验证。
+func TestTaprootSignaturesMatchBtcd(t *testing.T) {
+	t.Parallel()
+
+	key, _ := btcec.PrivKeyFromBytes(bytes32(0x11))
+	const amt = 100000
+	outputKey := ComputeTaprootKeyNoScript(key.PubKey())
+	pkScript, err := NewScriptBuilder().AddOp(OP_1).
+		AddData(schnorr.SerializePubKey(outputKey)).Script()
+	require.NoError(t, err)
+
+	tx := wire.NewMsgTx(2)
+	tx.AddTxIn(wire.NewTxIn(
+		wire.NewOutPoint(&chainhash.Hash{1}, 0), nil, nil,
+	))
+	tx.AddTxOut(wire.NewTxOut(amt-1000, pkScript))
+
+	fetcher := NewCannedPrevOutputFetcher(pkScript, amt)
+	sigHashes := NewTxSigHashes(tx, fetcher)
+	btcdHashes := btcd.NewTxSigHashes(
+		tx, btcd.NewCannedPrevOutputFetcher(pkScript, amt),
+	)
+	leaf := NewBaseTapLeaf([]byte{OP_TRUE})
+	btcdLeaf := btcd.NewBaseTapLeaf([]byte{btcd.OP_TRUE})
+
+	for _, hashType := range []SigHashType{SigHashDefault, SigHashAll} {
+		want, err := btcd.RawTxInTaprootSignature(tx, btcdHashes, 0, amt,
+			pkScript, nil, btcd.SigHashType(hashType), key)
+		require.NoError(t, err)
+		got, err := RawTxInTaprootSignature(tx, sigHashes, 0, amt, pkScript,
+			nil, hashType, key)
+		require.NoError(t, err)
+		requireSameTaprootSig(t, want, got, hashType)
+
+		wantWitness, err := btcd.TaprootWitnessSignature(tx, btcdHashes, 0,
+			amt, pkScript, btcd.SigHashType(hashType), key)
+		require.NoError(t, err)
+		gotWitness, err := TaprootWitnessSignature(tx, sigHashes, 0, amt,
+			pkScript, hashType, key)
+		require.NoError(t, err)
+		require.Len(t, gotWitness, len(wantWitness))
+		requireSameTaprootSig(t, wantWitness[0], gotWitness[0], hashType)
+
+		want, err = btcd.RawTxInTapscriptSignature(tx, btcdHashes, 0, amt,
+			pkScript, btcdLeaf, btcd.SigHashType(hashType), key)
+		require.NoError(t, err)
+		got, err = RawTxInTapscriptSignature(tx, sigHashes, 0, amt,
+			pkScript, leaf, hashType, key)
+		require.NoError(t, err)
+		require.Equal(t, want, got)
+
+		tx.TxIn[0].Witness = gotWitness
+		vm, err := NewEngine(pkScript, tx, 0, StandardVerifyFlags, nil,
+			sigHashes, amt, fetcher)
+		require.NoError(t, err)
+		require.NoError(t, vm.Execute())
+	}
+}
+
+// requireSameTaprootSig 确保 got 与 btcd 的签名 want 相同。 btcd v0.23 的密钥路径签名从不附加签名哈希类型字节，
+// 因此非 SigHashDefault 时只比较 64 字节的 schnorr 签名，并检查 got 附加了正确的字节。
+func requireSameTaprootSig(t *testing.T, want, got []byte,
+	hashType SigHashType) {
+
+	if hashType == SigHashDefault {
+		require.Equal(t, want, got)
+		return
+	}
+	require.Equal(t, want[:schnorr.SignatureSize], got[:schnorr.SignatureSize])
+	require.Equal(t, []byte{byte(hashType)}, got[schnorr.SignatureSize:])
+}
+
+// bytes32 返回每个字节都是 b 的 32 字节切片。
+func bytes32(b byte) []byte {
+	buf := make([]byte, 32)
+	for i := range buf {
+		buf[i] = b
+	}
+	return buf
+}
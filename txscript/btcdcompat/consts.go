@@ -0,0 +1,142 @@
+package btcdcompat
+
+import "github.com/qinglongcn/bpfschain/txscript"
+
+// 以下常量与 txscript 中的同名常量相同，包括脚本标志、签名哈希类型、脚本类别、错误码和各项限制。
+const (
+	BaseLeafVersion                                 = txscript.BaseLeafVersion
+	BaseSegwitWitnessVersion                        = txscript.BaseSegwitWitnessVersion
+	ControlBlockBaseSize                            = txscript.ControlBlockBaseSize
+	ControlBlockMaxNodeCount                        = txscript.ControlBlockMaxNodeCount
+	ControlBlockMaxSize                             = txscript.ControlBlockMaxSize
+	ControlBlockNodeSize                            = txscript.ControlBlockNodeSize
+	ErrCheckMultiSigVerify                          = txscript.ErrCheckMultiSigVerify
+	ErrCheckSigVerify                               = txscript.ErrCheckSigVerify
+	ErrCleanStack                                   = txscript.ErrCleanStack
+	ErrControlBlockInvalidLength                    = txscript.ErrControlBlockInvalidLength
+	ErrControlBlockTooLarge                         = txscript.ErrControlBlockTooLarge
+	ErrControlBlockTooSmall                         = txscript.ErrControlBlockTooSmall
+	ErrDisabledOpcode                               = txscript.ErrDisabledOpcode
+	ErrDiscourageOpSuccess                          = txscript.ErrDiscourageOpSuccess
+	ErrDiscourageUpgradableNOPs                     = txscript.ErrDiscourageUpgradableNOPs
+	ErrDiscourageUpgradableWitnessProgram           = txscript.ErrDiscourageUpgradableWitnessProgram
+	ErrDiscourageUpgradeablePubKeyType              = txscript.ErrDiscourageUpgradeablePubKeyType
+	ErrDiscourageUpgradeableTaprootVersion          = txscript.ErrDiscourageUpgradeableTaprootVersion
+	ErrEarlyReturn                                  = txscript.ErrEarlyReturn
+	ErrElementTooBig                                = txscript.ErrElementTooBig
+	ErrEmptyStack                                   = txscript.ErrEmptyStack
+	ErrEqualVerify                                  = txscript.ErrEqualVerify
+	ErrEvalFalse                                    = txscript.ErrEvalFalse
+	ErrInternal                                     = txscript.ErrInternal
+	ErrInvalidFlags                                 = txscript.ErrInvalidFlags
+	ErrInvalidIndex                                 = txscript.ErrInvalidIndex
+	ErrInvalidProgramCounter                        = txscript.ErrInvalidProgramCounter
+	ErrInvalidPubKeyCount                           = txscript.ErrInvalidPubKeyCount
+	ErrInvalidSigHashType                           = txscript.ErrInvalidSigHashType
+	ErrInvalidSignatureCount                        = txscript.ErrInvalidSignatureCount
+	ErrInvalidStackOperation                        = txscript.ErrInvalidStackOperation
+	ErrInvalidTaprootSigLen                         = txscript.ErrInvalidTaprootSigLen
+	ErrMalformedPush                                = txscript.ErrMalformedPush
+	ErrMinimalData                                  = txscript.ErrMinimalData
+	ErrMinimalIf                                    = txscript.ErrMinimalIf
+	ErrNegativeLockTime                             = txscript.ErrNegativeLockTime
+	ErrNotMultisigScript                            = txscript.ErrNotMultisigScript
+	ErrNotPushOnly                                  = txscript.ErrNotPushOnly
+	ErrNullFail                                     = txscript.ErrNullFail
+	ErrNumEqualVerify                               = txscript.ErrNumEqualVerify
+	ErrNumberTooBig                                 = txscript.ErrNumberTooBig
+	ErrPubKeyType                                   = txscript.ErrPubKeyType
+	ErrReservedOpcode                               = txscript.ErrReservedOpcode
+	ErrScriptTooBig                                 = txscript.ErrScriptTooBig
+	ErrScriptUnfinished                             = txscript.ErrScriptUnfinished
+	ErrSigHighS                                     = txscript.ErrSigHighS
+	ErrSigInvalidDataLen                            = txscript.ErrSigInvalidDataLen
+	ErrSigInvalidRIntID                             = txscript.ErrSigInvalidRIntID
+	ErrSigInvalidSIntID                             = txscript.ErrSigInvalidSIntID
+	ErrSigInvalidSLen                               = txscript.ErrSigInvalidSLen
+	ErrSigInvalidSeqID                              = txscript.ErrSigInvalidSeqID
+	ErrSigMissingSLen                               = txscript.ErrSigMissingSLen
+	ErrSigMissingSTypeID                            = txscript.ErrSigMissingSTypeID
+	ErrSigNegativeR                                 = txscript.ErrSigNegativeR
+	ErrSigNegativeS                                 = txscript.ErrSigNegativeS
+	ErrSigNullDummy                                 = txscript.ErrSigNullDummy
+	ErrSigTooLong                                   = txscript.ErrSigTooLong
+	ErrSigTooMuchRPadding                           = txscript.ErrSigTooMuchRPadding
+	ErrSigTooMuchSPadding                           = txscript.ErrSigTooMuchSPadding
+	ErrSigTooShort                                  = txscript.ErrSigTooShort
+	ErrSigZeroRLen                                  = txscript.ErrSigZeroRLen
+	ErrSigZeroSLen                                  = txscript.ErrSigZeroSLen
+	ErrStackOverflow                                = txscript.ErrStackOverflow
+	ErrTaprootMaxSigOps                             = txscript.ErrTaprootMaxSigOps
+	ErrTaprootMerkleProofInvalid                    = txscript.ErrTaprootMerkleProofInvalid
+	ErrTaprootOutputKeyParityMismatch               = txscript.ErrTaprootOutputKeyParityMismatch
+	ErrTaprootPubkeyIsEmpty                         = txscript.ErrTaprootPubkeyIsEmpty
+	ErrTaprootSigInvalid                            = txscript.ErrTaprootSigInvalid
+	ErrTapscriptCheckMultisig                       = txscript.ErrTapscriptCheckMultisig
+	ErrTooManyOperations                            = txscript.ErrTooManyOperations
+	ErrTooManyRequiredSigs                          = txscript.ErrTooManyRequiredSigs
+	ErrTooMuchNullData                              = txscript.ErrTooMuchNullData
+	ErrUnbalancedConditional                        = txscript.ErrUnbalancedConditional
+	ErrUnsatisfiedLockTime                          = txscript.ErrUnsatisfiedLockTime
+	ErrUnsupportedAddress                           = txscript.ErrUnsupportedAddress
+	ErrUnsupportedScriptVersion                     = txscript.ErrUnsupportedScriptVersion
+	ErrVerify                                       = txscript.ErrVerify
+	ErrWitnessHasNoAnnex                            = txscript.ErrWitnessHasNoAnnex
+	ErrWitnessMalleated                             = txscript.ErrWitnessMalleated
+	ErrWitnessMalleatedP2SH                         = txscript.ErrWitnessMalleatedP2SH
+	ErrWitnessProgramEmpty                          = txscript.ErrWitnessProgramEmpty
+	ErrWitnessProgramMismatch                       = txscript.ErrWitnessProgramMismatch
+	ErrWitnessProgramWrongLength                    = txscript.ErrWitnessProgramWrongLength
+	ErrWitnessPubKeyType                            = txscript.ErrWitnessPubKeyType
+	ErrWitnessUnexpected                            = txscript.ErrWitnessUnexpected
+	LockTimeThreshold                               = txscript.LockTimeThreshold
+	MaxDataCarrierSize                              = txscript.MaxDataCarrierSize
+	MaxOpsPerScript                                 = txscript.MaxOpsPerScript
+	MaxPubKeysPerMultiSig                           = txscript.MaxPubKeysPerMultiSig
+	MaxScriptElementSize                            = txscript.MaxScriptElementSize
+	MaxScriptSize                                   = txscript.MaxScriptSize
+	MaxStackSize                                    = txscript.MaxStackSize
+	MultiSigTy                                      = txscript.MultiSigTy
+	NonStandardTy                                   = txscript.NonStandardTy
+	NullDataTy                                      = txscript.NullDataTy
+	OpCondFalse                                     = txscript.OpCondFalse
+	OpCondSkip                                      = txscript.OpCondSkip
+	OpCondTrue                                      = txscript.OpCondTrue
+	PubKeyHashTy                                    = txscript.PubKeyHashTy
+	PubKeyTy                                        = txscript.PubKeyTy
+	ScriptBip16                                     = txscript.ScriptBip16
+	ScriptDiscourageUpgradableNops                  = txscript.ScriptDiscourageUpgradableNops
+	ScriptHashTy                                    = txscript.ScriptHashTy
+	ScriptStrictMultiSig                            = txscript.ScriptStrictMultiSig
+	ScriptVerifyCheckLockTimeVerify                 = txscript.ScriptVerifyCheckLockTimeVerify
+	ScriptVerifyCheckSequenceVerify                 = txscript.ScriptVerifyCheckSequenceVerify
+	ScriptVerifyCleanStack                          = txscript.ScriptVerifyCleanStack
+	ScriptVerifyDERSignatures                       = txscript.ScriptVerifyDERSignatures
+	ScriptVerifyDiscourageOpSuccess                 = txscript.ScriptVerifyDiscourageOpSuccess
+	ScriptVerifyDiscourageUpgradeablePubkeyType     = txscript.ScriptVerifyDiscourageUpgradeablePubkeyType
+	ScriptVerifyDiscourageUpgradeableTaprootVersion = txscript.ScriptVerifyDiscourageUpgradeableTaprootVersion
+	ScriptVerifyDiscourageUpgradeableWitnessProgram = txscript.ScriptVerifyDiscourageUpgradeableWitnessProgram
+	ScriptVerifyLowS                                = txscript.ScriptVerifyLowS
+	ScriptVerifyMinimalData                         = txscript.ScriptVerifyMinimalData
+	ScriptVerifyMinimalIf                           = txscript.ScriptVerifyMinimalIf
+	ScriptVerifyNullFail                            = txscript.ScriptVerifyNullFail
+	ScriptVerifySigPushOnly                         = txscript.ScriptVerifySigPushOnly
+	ScriptVerifyStrictEncoding                      = txscript.ScriptVerifyStrictEncoding
+	ScriptVerifyTaproot                             = txscript.ScriptVerifyTaproot
+	ScriptVerifyWitness                             = txscript.ScriptVerifyWitness
+	ScriptVerifyWitnessPubKeyType                   = txscript.ScriptVerifyWitnessPubKeyType
+	SigHashAll                                      = txscript.SigHashAll
+	SigHashAnyOneCanPay                             = txscript.SigHashAnyOneCanPay
+	SigHashDefault                                  = txscript.SigHashDefault
+	SigHashNone                                     = txscript.SigHashNone
+	SigHashOld                                      = txscript.SigHashOld
+	SigHashSingle                                   = txscript.SigHashSingle
+	StandardVerifyFlags                             = txscript.StandardVerifyFlags
+	TaprootAnnexTag                                 = txscript.TaprootAnnexTag
+	TaprootLeafMask                                 = txscript.TaprootLeafMask
+	TaprootWitnessVersion                           = txscript.TaprootWitnessVersion
+	WitnessUnknownTy                                = txscript.WitnessUnknownTy
+	WitnessV0PubKeyHashTy                           = txscript.WitnessV0PubKeyHashTy
+	WitnessV0ScriptHashTy                           = txscript.WitnessV0ScriptHashTy
+	WitnessV1TaprootTy                              = txscript.WitnessV1TaprootTy
+)
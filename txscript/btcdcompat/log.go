@@ -0,0 +1,10 @@
+// 导出完整构建中 txscript 的日志设置函数。
+
+//go:build !txscriptlite
+
+package btcdcompat
+
+import "github.com/qinglongcn/bpfschain/txscript"
+
+// UseLogger 与 txscript.UseLogger 相同。
+var UseLogger = txscript.UseLogger
@@ -0,0 +1,10 @@
+// 定义精简构建（txscriptlite 构建标签）中的日志设置函数。 精简构建的 txscript 没有日志输出，也没有 UseLogger。
+
+//go:build txscriptlite
+
+package btcdcompat
+
+import "github.com/btcsuite/btclog"
+
+// UseLogger 在精简构建中什么也不做，仅为与 btcd 的签名保持源代码兼容而保留。
+func UseLogger(logger btclog.Logger) {}
@@ -0,0 +1,267 @@
+package btcdcompat
+
+import "github.com/qinglongcn/bpfschain/txscript"
+
+// 以下操作码常量与 txscript 中的同名常量相同。
+const (
+	OP_0                   = txscript.OP_0
+	OP_0NOTEQUAL           = txscript.OP_0NOTEQUAL
+	OP_1                   = txscript.OP_1
+	OP_10                  = txscript.OP_10
+	OP_11                  = txscript.OP_11
+	OP_12                  = txscript.OP_12
+	OP_13                  = txscript.OP_13
+	OP_14                  = txscript.OP_14
+	OP_15                  = txscript.OP_15
+	OP_16                  = txscript.OP_16
+	OP_1ADD                = txscript.OP_1ADD
+	OP_1NEGATE             = txscript.OP_1NEGATE
+	OP_1SUB                = txscript.OP_1SUB
+	OP_2                   = txscript.OP_2
+	OP_2DIV                = txscript.OP_2DIV
+	OP_2DROP               = txscript.OP_2DROP
+	OP_2DUP                = txscript.OP_2DUP
+	OP_2MUL                = txscript.OP_2MUL
+	OP_2OVER               = txscript.OP_2OVER
+	OP_2ROT                = txscript.OP_2ROT
+	OP_2SWAP               = txscript.OP_2SWAP
+	OP_3                   = txscript.OP_3
+	OP_3DUP                = txscript.OP_3DUP
+	OP_4                   = txscript.OP_4
+	OP_5                   = txscript.OP_5
+	OP_6                   = txscript.OP_6
+	OP_7                   = txscript.OP_7
+	OP_8                   = txscript.OP_8
+	OP_9                   = txscript.OP_9
+	OP_ABS                 = txscript.OP_ABS
+	OP_ADD                 = txscript.OP_ADD
+	OP_AND                 = txscript.OP_AND
+	OP_BOOLAND             = txscript.OP_BOOLAND
+	OP_BOOLOR              = txscript.OP_BOOLOR
+	OP_CAT                 = txscript.OP_CAT
+	OP_CHECKLOCKTIMEVERIFY = txscript.OP_CHECKLOCKTIMEVERIFY
+	OP_CHECKMULTISIG       = txscript.OP_CHECKMULTISIG
+	OP_CHECKMULTISIGVERIFY = txscript.OP_CHECKMULTISIGVERIFY
+	OP_CHECKSEQUENCEVERIFY = txscript.OP_CHECKSEQUENCEVERIFY
+	OP_CHECKSIG            = txscript.OP_CHECKSIG
+	OP_CHECKSIGADD         = txscript.OP_CHECKSIGADD
+	OP_CHECKSIGVERIFY      = txscript.OP_CHECKSIGVERIFY
+	OP_CODESEPARATOR       = txscript.OP_CODESEPARATOR
+	OP_DATA_1              = txscript.OP_DATA_1
+	OP_DATA_10             = txscript.OP_DATA_10
+	OP_DATA_11             = txscript.OP_DATA_11
+	OP_DATA_12             = txscript.OP_DATA_12
+	OP_DATA_13             = txscript.OP_DATA_13
+	OP_DATA_14             = txscript.OP_DATA_14
+	OP_DATA_15             = txscript.OP_DATA_15
+	OP_DATA_16             = txscript.OP_DATA_16
+	OP_DATA_17             = txscript.OP_DATA_17
+	OP_DATA_18             = txscript.OP_DATA_18
+	OP_DATA_19             = txscript.OP_DATA_19
+	OP_DATA_2              = txscript.OP_DATA_2
+	OP_DATA_20             = txscript.OP_DATA_20
+	OP_DATA_21             = txscript.OP_DATA_21
+	OP_DATA_22             = txscript.OP_DATA_22
+	OP_DATA_23             = txscript.OP_DATA_23
+	OP_DATA_24             = txscript.OP_DATA_24
+	OP_DATA_25             = txscript.OP_DATA_25
+	OP_DATA_26             = txscript.OP_DATA_26
+	OP_DATA_27             = txscript.OP_DATA_27
+	OP_DATA_28             = txscript.OP_DATA_28
+	OP_DATA_29             = txscript.OP_DATA_29
+	OP_DATA_3              = txscript.OP_DATA_3
+	OP_DATA_30             = txscript.OP_DATA_30
+	OP_DATA_31             = txscript.OP_DATA_31
+	OP_DATA_32             = txscript.OP_DATA_32
+	OP_DATA_33             = txscript.OP_DATA_33
+	OP_DATA_34             = txscript.OP_DATA_34
+	OP_DATA_35             = txscript.OP_DATA_35
+	OP_DATA_36             = txscript.OP_DATA_36
+	OP_DATA_37             = txscript.OP_DATA_37
+	OP_DATA_38             = txscript.OP_DATA_38
+	OP_DATA_39             = txscript.OP_DATA_39
+	OP_DATA_4              = txscript.OP_DATA_4
+	OP_DATA_40             = txscript.OP_DATA_40
+	OP_DATA_41             = txscript.OP_DATA_41
+	OP_DATA_42             = txscript.OP_DATA_42
+	OP_DATA_43             = txscript.OP_DATA_43
+	OP_DATA_44             = txscript.OP_DATA_44
+	OP_DATA_45             = txscript.OP_DATA_45
+	OP_DATA_46             = txscript.OP_DATA_46
+	OP_DATA_47             = txscript.OP_DATA_47
+	OP_DATA_48             = txscript.OP_DATA_48
+	OP_DATA_49             = txscript.OP_DATA_49
+	OP_DATA_5              = txscript.OP_DATA_5
+	OP_DATA_50             = txscript.OP_DATA_50
+	OP_DATA_51             = txscript.OP_DATA_51
+	OP_DATA_52             = txscript.OP_DATA_52
+	OP_DATA_53             = txscript.OP_DATA_53
+	OP_DATA_54             = txscript.OP_DATA_54
+	OP_DATA_55             = txscript.OP_DATA_55
+	OP_DATA_56             = txscript.OP_DATA_56
+	OP_DATA_57             = txscript.OP_DATA_57
+	OP_DATA_58             = txscript.OP_DATA_58
+	OP_DATA_59             = txscript.OP_DATA_59
+	OP_DATA_6              = txscript.OP_DATA_6
+	OP_DATA_60             = txscript.OP_DATA_60
+	OP_DATA_61             = txscript.OP_DATA_61
+	OP_DATA_62             = txscript.OP_DATA_62
+	OP_DATA_63             = txscript.OP_DATA_63
+	OP_DATA_64             = txscript.OP_DATA_64
+	OP_DATA_65             = txscript.OP_DATA_65
+	OP_DATA_66             = txscript.OP_DATA_66
+	OP_DATA_67             = txscript.OP_DATA_67
+	OP_DATA_68             = txscript.OP_DATA_68
+	OP_DATA_69             = txscript.OP_DATA_69
+	OP_DATA_7              = txscript.OP_DATA_7
+	OP_DATA_70             = txscript.OP_DATA_70
+	OP_DATA_71             = txscript.OP_DATA_71
+	OP_DATA_72             = txscript.OP_DATA_72
+	OP_DATA_73             = txscript.OP_DATA_73
+	OP_DATA_74             = txscript.OP_DATA_74
+	OP_DATA_75             = txscript.OP_DATA_75
+	OP_DATA_8              = txscript.OP_DATA_8
+	OP_DATA_9              = txscript.OP_DATA_9
+	OP_DEPTH               = txscript.OP_DEPTH
+	OP_DIV                 = txscript.OP_DIV
+	OP_DROP                = txscript.OP_DROP
+	OP_DUP                 = txscript.OP_DUP
+	OP_ELSE                = txscript.OP_ELSE
+	OP_ENDIF               = txscript.OP_ENDIF
+	OP_EQUAL               = txscript.OP_EQUAL
+	OP_EQUALVERIFY         = txscript.OP_EQUALVERIFY
+	OP_FALSE               = txscript.OP_FALSE
+	OP_FROMALTSTACK        = txscript.OP_FROMALTSTACK
+	OP_GREATERTHAN         = txscript.OP_GREATERTHAN
+	OP_GREATERTHANOREQUAL  = txscript.OP_GREATERTHANOREQUAL
+	OP_HASH160             = txscript.OP_HASH160
+	OP_HASH256             = txscript.OP_HASH256
+	OP_IF                  = txscript.OP_IF
+	OP_IFDUP               = txscript.OP_IFDUP
+	OP_INVALIDOPCODE       = txscript.OP_INVALIDOPCODE
+	OP_INVERT              = txscript.OP_INVERT
+	OP_LEFT                = txscript.OP_LEFT
+	OP_LESSTHAN            = txscript.OP_LESSTHAN
+	OP_LESSTHANOREQUAL     = txscript.OP_LESSTHANOREQUAL
+	OP_LSHIFT              = txscript.OP_LSHIFT
+	OP_MAX                 = txscript.OP_MAX
+	OP_MIN                 = txscript.OP_MIN
+	OP_MOD                 = txscript.OP_MOD
+	OP_MUL                 = txscript.OP_MUL
+	OP_NEGATE              = txscript.OP_NEGATE
+	OP_NIP                 = txscript.OP_NIP
+	OP_NOP                 = txscript.OP_NOP
+	OP_NOP1                = txscript.OP_NOP1
+	OP_NOP10               = txscript.OP_NOP10
+	OP_NOP2                = txscript.OP_NOP2
+	OP_NOP3                = txscript.OP_NOP3
+	OP_NOP4                = txscript.OP_NOP4
+	OP_NOP5                = txscript.OP_NOP5
+	OP_NOP6                = txscript.OP_NOP6
+	OP_NOP7                = txscript.OP_NOP7
+	OP_NOP8                = txscript.OP_NOP8
+	OP_NOP9                = txscript.OP_NOP9
+	OP_NOT                 = txscript.OP_NOT
+	OP_NOTIF               = txscript.OP_NOTIF
+	OP_NUMEQUAL            = txscript.OP_NUMEQUAL
+	OP_NUMEQUALVERIFY      = txscript.OP_NUMEQUALVERIFY
+	OP_NUMNOTEQUAL         = txscript.OP_NUMNOTEQUAL
+	OP_OR                  = txscript.OP_OR
+	OP_OVER                = txscript.OP_OVER
+	OP_PICK                = txscript.OP_PICK
+	OP_PUBKEY              = txscript.OP_PUBKEY
+	OP_PUBKEYHASH          = txscript.OP_PUBKEYHASH
+	OP_PUBKEYS             = txscript.OP_PUBKEYS
+	OP_PUSHDATA1           = txscript.OP_PUSHDATA1
+	OP_PUSHDATA2           = txscript.OP_PUSHDATA2
+	OP_PUSHDATA4           = txscript.OP_PUSHDATA4
+	OP_RESERVED            = txscript.OP_RESERVED
+	OP_RESERVED1           = txscript.OP_RESERVED1
+	OP_RESERVED2           = txscript.OP_RESERVED2
+	OP_RETURN              = txscript.OP_RETURN
+	OP_RIGHT               = txscript.OP_RIGHT
+	OP_RIPEMD160           = txscript.OP_RIPEMD160
+	OP_ROLL                = txscript.OP_ROLL
+	OP_ROT                 = txscript.OP_ROT
+	OP_RSHIFT              = txscript.OP_RSHIFT
+	OP_SHA1                = txscript.OP_SHA1
+	OP_SHA256              = txscript.OP_SHA256
+	OP_SIZE                = txscript.OP_SIZE
+	OP_SMALLINTEGER        = txscript.OP_SMALLINTEGER
+	OP_SUB                 = txscript.OP_SUB
+	OP_SUBSTR              = txscript.OP_SUBSTR
+	OP_SWAP                = txscript.OP_SWAP
+	OP_TOALTSTACK          = txscript.OP_TOALTSTACK
+	OP_TRUE                = txscript.OP_TRUE
+	OP_TUCK                = txscript.OP_TUCK
+	OP_UNKNOWN187          = txscript.OP_UNKNOWN187
+	OP_UNKNOWN188          = txscript.OP_UNKNOWN188
+	OP_UNKNOWN189          = txscript.OP_UNKNOWN189
+	OP_UNKNOWN190          = txscript.OP_UNKNOWN190
+	OP_UNKNOWN191          = txscript.OP_UNKNOWN191
+	OP_UNKNOWN192          = txscript.OP_UNKNOWN192
+	OP_UNKNOWN193          = txscript.OP_UNKNOWN193
+	OP_UNKNOWN194          = txscript.OP_UNKNOWN194
+	OP_UNKNOWN195          = txscript.OP_UNKNOWN195
+	OP_UNKNOWN196          = txscript.OP_UNKNOWN196
+	OP_UNKNOWN197          = txscript.OP_UNKNOWN197
+	OP_UNKNOWN198          = txscript.OP_UNKNOWN198
+	OP_UNKNOWN199          = txscript.OP_UNKNOWN199
+	OP_UNKNOWN200          = txscript.OP_UNKNOWN200
+	OP_UNKNOWN201          = txscript.OP_UNKNOWN201
+	OP_UNKNOWN202          = txscript.OP_UNKNOWN202
+	OP_UNKNOWN203          = txscript.OP_UNKNOWN203
+	OP_UNKNOWN204          = txscript.OP_UNKNOWN204
+	OP_UNKNOWN205          = txscript.OP_UNKNOWN205
+	OP_UNKNOWN206          = txscript.OP_UNKNOWN206
+	OP_UNKNOWN207          = txscript.OP_UNKNOWN207
+	OP_UNKNOWN208          = txscript.OP_UNKNOWN208
+	OP_UNKNOWN209          = txscript.OP_UNKNOWN209
+	OP_UNKNOWN210          = txscript.OP_UNKNOWN210
+	OP_UNKNOWN211          = txscript.OP_UNKNOWN211
+	OP_UNKNOWN212          = txscript.OP_UNKNOWN212
+	OP_UNKNOWN213          = txscript.OP_UNKNOWN213
+	OP_UNKNOWN214          = txscript.OP_UNKNOWN214
+	OP_UNKNOWN215          = txscript.OP_UNKNOWN215
+	OP_UNKNOWN216          = txscript.OP_UNKNOWN216
+	OP_UNKNOWN217          = txscript.OP_UNKNOWN217
+	OP_UNKNOWN218          = txscript.OP_UNKNOWN218
+	OP_UNKNOWN219          = txscript.OP_UNKNOWN219
+	OP_UNKNOWN220          = txscript.OP_UNKNOWN220
+	OP_UNKNOWN221          = txscript.OP_UNKNOWN221
+	OP_UNKNOWN222          = txscript.OP_UNKNOWN222
+	OP_UNKNOWN223          = txscript.OP_UNKNOWN223
+	OP_UNKNOWN224          = txscript.OP_UNKNOWN224
+	OP_UNKNOWN225          = txscript.OP_UNKNOWN225
+	OP_UNKNOWN226          = txscript.OP_UNKNOWN226
+	OP_UNKNOWN227          = txscript.OP_UNKNOWN227
+	OP_UNKNOWN228          = txscript.OP_UNKNOWN228
+	OP_UNKNOWN229          = txscript.OP_UNKNOWN229
+	OP_UNKNOWN230          = txscript.OP_UNKNOWN230
+	OP_UNKNOWN231          = txscript.OP_UNKNOWN231
+	OP_UNKNOWN232          = txscript.OP_UNKNOWN232
+	OP_UNKNOWN233          = txscript.OP_UNKNOWN233
+	OP_UNKNOWN234          = txscript.OP_UNKNOWN234
+	OP_UNKNOWN235          = txscript.OP_UNKNOWN235
+	OP_UNKNOWN236          = txscript.OP_UNKNOWN236
+	OP_UNKNOWN237          = txscript.OP_UNKNOWN237
+	OP_UNKNOWN238          = txscript.OP_UNKNOWN238
+	OP_UNKNOWN239          = txscript.OP_UNKNOWN239
+	OP_UNKNOWN240          = txscript.OP_UNKNOWN240
+	OP_UNKNOWN241          = txscript.OP_UNKNOWN241
+	OP_UNKNOWN242          = txscript.OP_UNKNOWN242
+	OP_UNKNOWN243          = txscript.OP_UNKNOWN243
+	OP_UNKNOWN244          = txscript.OP_UNKNOWN244
+	OP_UNKNOWN245          = txscript.OP_UNKNOWN245
+	OP_UNKNOWN246          = txscript.OP_UNKNOWN246
+	OP_UNKNOWN247          = txscript.OP_UNKNOWN247
+	OP_UNKNOWN248          = txscript.OP_UNKNOWN248
+	OP_UNKNOWN249          = txscript.OP_UNKNOWN249
+	OP_UNKNOWN252          = txscript.OP_UNKNOWN252
+	OP_VER                 = txscript.OP_VER
+	OP_VERIF               = txscript.OP_VERIF
+	OP_VERIFY              = txscript.OP_VERIFY
+	OP_VERNOTIF            = txscript.OP_VERNOTIF
+	OP_WITHIN              = txscript.OP_WITHIN
+	OP_XOR                 = txscript.OP_XOR
+)
@@ -0,0 +1,87 @@
+// 包含签名哈希的链域分隔标签。 设置标签后，传统、版本 0 见证和 taproot 签名哈希都提交到该标签，
+// 因此一条链上的签名不会在比特币或使用其他标签的链上有效，反之亦然。
+
+package txscript
+
+import (
+	"crypto/sha256"
+	"hash"
+
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+)
+
+// chainTagSigHashDomain 是传统和版本 0 见证签名哈希前缀所哈希的域名，其后接标签本身。
+const chainTagSigHashDomain = "bpfschain/sighash/"
+
+// ChainTag 是注入签名哈希的链特定域分隔标签。 空标签（BitcoinChainTag）表示比特币的签名哈希，不做任何修改。
+//
+// 设置标签后：
+//
+//   - 传统和版本 0 见证签名哈希为 SHA256(SHA256(p || p || m))，其中 m 是未修改的签名消息（CalcSigHashPreimage 的返回值），
+//     p = SHA256("bpfschain/sighash/" || tag)。 SigHashSingle 没有对应输出时的常量 1 同样作为 m 被哈希。
+//   - taproot 和 tapscript 签名哈希使用 BIP0340 标签 "TapSighash/" || tag 代替 "TapSighash"。
+//
+// 验证通过 WithChainTag 设置标签；签名时 taproot 签名函数接受 WithSigningChainTag，
+// ECDSA 签名可以用 CalcSigHash 和 WithSigHashChainTag 计算签名哈希后签名。
+type ChainTag string
+
+// BitcoinChainTag 是比特币使用的空标签。
+const BitcoinChainTag ChainTag = ""
+
+// newSigHasher 返回用于计算传统和版本 0 见证签名哈希第一轮 SHA256 的哈希，设置了标签时已写入标签前缀。
+func (t ChainTag) newSigHasher() hash.Hash {
+	h := sha256.New()
+	if t != BitcoinChainTag {
+		prefix := sha256.Sum256([]byte(chainTagSigHashDomain + string(t)))
+		h.Write(prefix[:])
+		h.Write(prefix[:])
+	}
+	return h
+}
+
+// tapSighashTag 返回 taproot 签名哈希使用的 BIP0340 标签。
+func (t ChainTag) tapSighashTag() []byte {
+	if t == BitcoinChainTag {
+		return chainhash.TagTapSighash
+	}
+	tag := make([]byte, 0, len(chainhash.TagTapSighash)+1+len(t))
+	tag = append(tag, chainhash.TagTapSighash...)
+	tag = append(tag, '/')
+	return append(tag, t...)
+}
+
+// validationKey 返回区分标签的验证缓存键，使不同标签下的验证结果不会互相命中。
+func (t ChainTag) validationKey(key chainhash.Hash) chainhash.Hash {
+	if t == BitcoinChainTag {
+		return key
+	}
+	return chainhash.HashH(append(key[:], t...))
+}
+
+// WithChainTag 使引擎使用带有 tag 的签名哈希验证签名。
+func WithChainTag(tag ChainTag) EngineOpt {
+	return func(cfg *engineConfig) {
+		cfg.chainTag = tag
+	}
+}
+
+// WithSigHashChainTag 使 CalcSigHash 计算带有 tag 的签名哈希。 CalcSigHashPreimage 返回的签名消息不受标签影响。
+func WithSigHashChainTag(tag ChainTag) SigHashOption {
+	return func(cfg *sigHashConfig) {
+		cfg.chainTag = tag
+	}
+}
+
+// WithTaprootChainTag 使 taproot 和 tapscript 签名哈希使用带有 tag 的 BIP0340 标签。
+func WithTaprootChainTag(tag ChainTag) TaprootSigHashOption {
+	return func(o *taprootSigHashOptions) {
+		o.chainTag = tag
+	}
+}
+
+// WithSigningChainTag 使 taproot 和 tapscript 签名函数签署带有 tag 的签名哈希。
+func WithSigningChainTag(tag ChainTag) TaprootSignOption {
+	return func(o *taprootSignOptions) {
+		o.chainTag = tag
+	}
+}
@@ -0,0 +1,204 @@
+package txscript
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcec/v2/ecdsa"
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/stretchr/testify/require"
+)
+
+// TestChainTagSigHashVectors 确保带标签的签名哈希与 ChainTag 描述的构造一致并与固定的测试向量相同，
+// 空标签的签名哈希与比特币相同。
+func TestChainTagSigHashVectors(t *testing.T) {
+	t.Parallel()
+
+	script := mustParseShortForm("DUP HASH160 DATA_20 0x" +
+		"433ec2ac1ffa1b7b7d027f564529c57197f9ae88 EQUALVERIFY CHECKSIG")
+	const amt = 50000
+	tx := createSpendingTx(nil, nil, script, amt)
+	fetcher := NewCannedPrevOutputFetcher(script, amt)
+	leaf := NewBaseTapLeaf([]byte{OP_TRUE})
+	const tag ChainTag = "bpfschain"
+
+	tests := []struct {
+		sigVersion SigVersion
+		hashType   SigHashType
+		opts       []SigHashOption
+		digest     string
+	}{{
+		sigVersion: SigVersionBase,
+		hashType:   SigHashAll,
+		opts:       []SigHashOption{WithSigHashScript(script)},
+		digest:     "5347879c5ac464089434546d60677f40e1b87709e5bd8e6c73054f1136171091",
+	}, {
+		sigVersion: SigVersionWitnessV0,
+		hashType:   SigHashAll,
+		opts: []SigHashOption{
+			WithSigHashScript(script), WithSigHashAmount(amt),
+		},
+		digest: "a5688e55f4fa9be7149f21b4145d90a4f2512e7bd9e51c747dfea57c4e67f83c",
+	}, {
+		sigVersion: SigVersionTaproot,
+		hashType:   SigHashDefault,
+		opts:       []SigHashOption{WithSigHashPrevOuts(fetcher)},
+		digest:     "ea3bcdc48cb76eeffd95d80585ef7e6d125486da9d754fc0884d7a524adbdf7b",
+	}, {
+		sigVersion: SigVersionTapscript,
+		hashType:   SigHashAll,
+		opts: []SigHashOption{
+			WithSigHashPrevOuts(fetcher), WithSigHashTapLeaf(leaf),
+		},
+		digest: "1e1a142ecd7b97fc6ad1b5243cfc58e2acc9a0eb6c9fddf37e9581d0b6b94a62",
+	}}
+	prefix := sha256.Sum256([]byte("bpfschain/sighash/bpfschain"))
+	for _, test := range tests {
+		bitcoin, err := CalcSigHash(tx, 0, test.hashType, test.sigVersion,
+			test.opts...)
+		require.NoError(t, err)
+		untagged, err := CalcSigHash(tx, 0, test.hashType,
+			test.sigVersion, append(test.opts,
+				WithSigHashChainTag(BitcoinChainTag))...)
+		require.NoError(t, err)
+		require.Equal(t, bitcoin, untagged)
+
+		tagged, err := CalcSigHash(tx, 0, test.hashType, test.sigVersion,
+			append(test.opts, WithSigHashChainTag(tag))...)
+		require.NoError(t, err)
+		require.NotEqual(t, bitcoin, tagged)
+
+		preimage, err := CalcSigHashPreimage(tx, 0, test.hashType,
+			test.sigVersion, append(test.opts,
+				WithSigHashChainTag(tag))...)
+		require.NoError(t, err)
+		var want []byte
+		switch test.sigVersion {
+		case SigVersionBase, SigVersionWitnessV0:
+			msg := append(append(prefix[:], prefix[:]...), preimage...)
+			first := sha256.Sum256(msg)
+			hash := sha256.Sum256(first[:])
+			want = hash[:]
+		default:
+			hash := chainhash.TaggedHash(
+				[]byte("TapSighash/bpfschain"), preimage,
+			)
+			want = hash[:]
+		}
+		require.Equal(t, want, tagged, test.sigVersion)
+		require.Equal(t, test.digest, hex.EncodeToString(tagged),
+			test.sigVersion)
+	}
+
+	// SigHashSingle 没有对应输出时的常量同样被分隔。
+	tx.AddTxIn(&wire.TxIn{})
+	one, err := CalcSigHash(tx, 1, SigHashSingle, SigVersionBase,
+		WithSigHashScript(script))
+	require.NoError(t, err)
+	require.Equal(t, byte(1), one[0])
+	tagged, err := CalcSigHash(tx, 1, SigHashSingle, SigVersionBase,
+		WithSigHashScript(script), WithSigHashChainTag(tag))
+	require.NoError(t, err)
+	require.NotEqual(t, one, tagged)
+}
+
+// TestChainTagEngine 确保带标签签署的传统、版本 0 见证和 taproot 花费只在使用相同标签的引擎中有效，
+// 而比特币的签名在带标签的引擎中无效。
+func TestChainTagEngine(t *testing.T) {
+	t.Parallel()
+
+	const (
+		tag   ChainTag = "bpfschain"
+		other ChainTag = "other"
+		amt            = 50000
+	)
+	key, err := btcec.NewPrivateKey()
+	require.NoError(t, err)
+	pubKey := key.PubKey().SerializeCompressed()
+	keyHash := btcutil.Hash160(pubKey)
+	p2pkh, err := payToPubKeyHashScript(keyHash)
+	require.NoError(t, err)
+	p2wpkh, err := payToWitnessPubKeyHashScript(keyHash)
+	require.NoError(t, err)
+	p2tr, err := PayToTaprootScript(ComputeTaprootKeyNoScript(key.PubKey()))
+	require.NoError(t, err)
+
+	// verify 返回使用 opts 的引擎执行花费 pkScript 的 tx 的结果。
+	verify := func(tx *wire.MsgTx, pkScript []byte,
+		opts ...EngineOpt) error {
+
+		fetcher := NewCannedPrevOutputFetcher(pkScript, amt)
+		vm, err := NewEngine(pkScript, tx, 0, StandardVerifyFlags, nil,
+			NewTxSigHashes(tx, fetcher), amt, fetcher, opts...)
+		if err != nil {
+			return err
+		}
+		return vm.Execute()
+	}
+
+	// ecdsaSig 返回按 sigVersion 以 tag 签署 tx 的签名。
+	ecdsaSig := func(tx *wire.MsgTx, sigVersion SigVersion,
+		tag ChainTag) []byte {
+
+		hash, err := CalcSigHash(tx, 0, SigHashAll, sigVersion,
+			WithSigHashScript(p2pkh), WithSigHashAmount(amt),
+			WithSigHashChainTag(tag))
+		require.NoError(t, err)
+		sig := ecdsa.Sign(key, hash).Serialize()
+		return append(sig, byte(SigHashAll))
+	}
+
+	tests := []struct {
+		name     string
+		pkScript []byte
+		sign     func(tx *wire.MsgTx, tag ChainTag)
+	}{{
+		name:     "p2pkh",
+		pkScript: p2pkh,
+		sign: func(tx *wire.MsgTx, tag ChainTag) {
+			sigScript, err := NewScriptBuilder().
+				AddData(ecdsaSig(tx, SigVersionBase, tag)).
+				AddData(pubKey).Script()
+			require.NoError(t, err)
+			tx.TxIn[0].SignatureScript = sigScript
+		},
+	}, {
+		name:     "p2wpkh",
+		pkScript: p2wpkh,
+		sign: func(tx *wire.MsgTx, tag ChainTag) {
+			tx.TxIn[0].Witness = wire.TxWitness{
+				ecdsaSig(tx, SigVersionWitnessV0, tag), pubKey,
+			}
+		},
+	}, {
+		name:     "p2tr",
+		pkScript: p2tr,
+		sign: func(tx *wire.MsgTx, tag ChainTag) {
+			fetcher := NewCannedPrevOutputFetcher(p2tr, amt)
+			witness, err := TaprootWitnessSignature(tx,
+				NewTxSigHashes(tx, fetcher), 0, amt, p2tr,
+				SigHashDefault, key, WithSigningChainTag(tag))
+			require.NoError(t, err)
+			tx.TxIn[0].Witness = witness
+		},
+	}}
+	for _, test := range tests {
+		tx := createSpendingTx(nil, nil, test.pkScript, amt)
+		test.sign(tx, tag)
+		require.NoError(t, verify(tx, test.pkScript, WithChainTag(tag)),
+			test.name)
+		require.Error(t, verify(tx, test.pkScript), test.name)
+		require.Error(t, verify(tx, test.pkScript, WithChainTag(other)),
+			test.name)
+
+		tx = createSpendingTx(nil, nil, test.pkScript, amt)
+		test.sign(tx, BitcoinChainTag)
+		require.NoError(t, verify(tx, test.pkScript), test.name)
+		require.Error(t, verify(tx, test.pkScript, WithChainTag(tag)),
+			test.name)
+	}
+}
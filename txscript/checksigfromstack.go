@@ -0,0 +1,208 @@
+// 包含 bpfschain 的 OP_CHECKSIGFROMSTACK 和 OP_CHECKSIGFROMSTACKVERIFY 扩展操作码，用于验证对堆栈上任意消息的签名，
+// 例如预言机签名的数据。 操作码占用未使用的 0xc1 和 0xc2，只有设置了 ScriptVerifyCheckSigFromStack 时才可在 tapscript 中执行。
+//
+// 与 BIP0348 一样，操作码只在 tapscript 中定义，由 OP_SUCCESSx 软分叉升级而来，其签名计入每个输入的签名操作预算。
+// 公钥长度选择签名形式：32 字节 x-only 公钥对应 BIP0340 schnorr 签名，33 字节压缩公钥对应严格 DER 编码的低 S 值 ECDSA 签名。
+// 在版本 0 脚本和见证版本 0 脚本中它们仍是无效操作码，因为在这些脚本中赋予语义是硬分叉，并且静态的签名操作计数不统计它们。
+
+package txscript
+
+import (
+	"crypto/sha256"
+	"fmt"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcec/v2/ecdsa"
+	"github.com/btcsuite/btcd/btcec/v2/schnorr"
+)
+
+// bpfschain 扩展操作码。
+const (
+	// OP_CHECKSIGFROMSTACK 验证对堆栈上任意消息的签名，占用 OP_UNKNOWN193。
+	OP_CHECKSIGFROMSTACK = OP_UNKNOWN193 // 193
+
+	// OP_CHECKSIGFROMSTACKVERIFY 是 OP_CHECKSIGFROMSTACK 和 OP_VERIFY 的组合，占用 OP_UNKNOWN194。
+	OP_CHECKSIGFROMSTACKVERIFY = OP_UNKNOWN194 // 194
+)
+
+// isOpSuccess 返回在给定标志下 op 是否是 tapscript 的 OP_SUCCESSx 操作码。 设置 ScriptVerifyCheckSigFromStack 后，
+// OP_CHECKSIGFROMSTACK 和 OP_CHECKSIGFROMSTACKVERIFY 被赋予语义，不再是 OP_SUCCESSx。
+func isOpSuccess(op byte, flags ScriptFlags) bool {
+	if _, ok := successOpcodes[op]; !ok {
+		return false
+	}
+	switch op {
+	case OP_CHECKSIGFROMSTACK, OP_CHECKSIGFROMSTACKVERIFY:
+		return flags&ScriptVerifyCheckSigFromStack == 0
+	}
+	return true
+}
+
+// scriptHasOpSuccess 与 ScriptHasOpSuccess 相同，但考虑引擎的标志为 OP_SUCCESSx 操作码赋予的语义。
+func (vm *Engine) scriptHasOpSuccess(script []byte) bool {
+	tokenizer := MakeScriptTokenizer(0, script)
+	for tokenizer.Next() {
+		if isOpSuccess(tokenizer.Opcode(), vm.flags) {
+			return true
+		}
+	}
+	return false
+}
+
+// CheckSigFromStackMessageHash 返回 OP_CHECKSIGFROMSTACK 验证签名时使用的消息摘要，即消息的 SHA256 哈希。
+// 为堆栈消息签名时应对该摘要签名。
+func CheckSigFromStackMessageHash(message []byte) []byte {
+	hash := sha256.Sum256(message)
+	return hash[:]
+}
+
+// opcodeCheckSigFromStack 验证对堆栈上任意消息的签名，并将结果推送到堆栈上。 签名针对消息的 SHA256 摘要且不带签名哈希类型字节：
+// 32 字节公钥要求 64 字节 BIP0340 schnorr 签名，33 字节压缩公钥要求严格 DER 编码的低 S 值 ECDSA 签名。 其他公钥与 OP_CHECKSIG
+// 一样被视为未知公钥类型。 两种形式的非空签名都计入签名操作预算，非空签名无效时执行失败。
+//
+// 未设置 ScriptVerifyCheckSigFromStack 时，或者在 tapscript 以外的脚本中，该操作码与其他未定义的操作码一样无效。
+//
+// Stack transformation: [... signature message pubkey] -> [... bool]
+func opcodeCheckSigFromStack(op *opcode, data []byte, vm *Engine) error {
+	if !vm.hasFlag(ScriptVerifyCheckSigFromStack) || vm.taprootCtx == nil {
+		return opcodeInvalid(op, data, vm)
+	}
+
+	pkBytes, err := vm.dstack.PopByteArray()
+	if err != nil {
+		return err
+	}
+	message, err := vm.dstack.PopByteArray()
+	if err != nil {
+		return err
+	}
+	sigBytes, err := vm.dstack.PopByteArray()
+	if err != nil {
+		return err
+	}
+
+	valid, err := vm.verifySigFromStack(pkBytes, message, sigBytes)
+	if err != nil {
+		return err
+	}
+	if !valid && len(sigBytes) != 0 {
+		str := "signature not empty on failed checksigfromstack"
+		return scriptError(ErrNullFail, str)
+	}
+
+	vm.dstack.PushBool(valid)
+	return nil
+}
+
+// opcodeCheckSigFromStackVerify 是 opcodeCheckSigFromStack 和 opcodeVerify 的组合。
+//
+// Stack transformation: [... signature message pubkey] -> [... bool] -> [...]
+func opcodeCheckSigFromStackVerify(op *opcode, data []byte, vm *Engine) error {
+	err := opcodeCheckSigFromStack(op, data, vm)
+	if err == nil {
+		err = abstractVerify(op, vm, ErrCheckSigFromStackVerify)
+	}
+	return err
+}
+
+// verifySigFromStack 按 tapscript 的规则验证对 message 的签名，并根据公钥长度选择 BIP0340 或 ECDSA 形式。
+func (vm *Engine) verifySigFromStack(pkBytes, message,
+	sigBytes []byte) (bool, error) {
+
+	// Only non-empty signatures count towards the sig op budget, as with
+	// OP_CHECKSIG.
+	if len(sigBytes) != 0 {
+		if err := vm.taprootCtx.tallysigOp(); err != nil {
+			return false, err
+		}
+	}
+	if len(pkBytes) == 0 {
+		return false, scriptError(ErrTaprootPubkeyIsEmpty, "")
+	}
+	if len(sigBytes) == 0 {
+		return false, nil
+	}
+
+	switch {
+	case len(pkBytes) == schnorr.PubKeyBytesLen:
+		return vm.verifySchnorrFromStack(pkBytes, message, sigBytes)
+
+	case btcec.IsCompressedPubKey(pkBytes):
+		return vm.verifyECDSAFromStack(pkBytes, message, sigBytes)
+	}
+
+	// Unknown public key types are reserved for upgrades.
+	if vm.hasFlag(ScriptVerifyDiscourageUpgradeablePubkeyType) {
+		return false, upgradeablePubKeyTypeError(pkBytes)
+	}
+	return true, nil
+}
+
+// verifySchnorrFromStack 验证 32 字节公钥对 message 的 BIP0340 签名，签名非空并且已计入签名操作预算。
+func (vm *Engine) verifySchnorrFromStack(pkBytes, message,
+	sigBytes []byte) (bool, error) {
+
+	pubKey, err := schnorr.ParsePubKey(pkBytes)
+	if err != nil {
+		return false, err
+	}
+	if len(sigBytes) != schnorr.SignatureSize {
+		str := fmt.Sprintf("invalid sig len: %v", len(sigBytes))
+		return false, scriptError(ErrInvalidTaprootSigLen, str)
+	}
+	sig, err := schnorr.ParseSignature(sigBytes)
+	if err != nil {
+		return false, nil
+	}
+
+	vm.recordSigOp()
+	hash := CheckSigFromStackMessageHash(message)
+	valid := vm.verifier().VerifySchnorr(sig, hash, pubKey)
+	vm.recordSigCheck(SigTypeSchnorr, valid)
+	return valid, nil
+}
+
+// verifyECDSAFromStack 验证 33 字节压缩公钥对 message 的 ECDSA 签名，签名非空并且已计入签名操作预算。 与脚本标志无关，
+// 签名必须是严格 DER 编码并且 S 值较低，否则同一签名的其他编码会让见证可被延展。
+func (vm *Engine) verifyECDSAFromStack(pkBytes, message,
+	sigBytes []byte) (bool, error) {
+
+	if err := checkDERSignatureEncoding(sigBytes, true); err != nil {
+		return false, err
+	}
+	pubKey, err := btcec.ParsePubKey(pkBytes)
+	if err != nil {
+		return false, nil
+	}
+	sig, err := ecdsa.ParseDERSignature(sigBytes)
+	if err != nil {
+		return false, nil
+	}
+
+	vm.recordSigOp()
+	hash := CheckSigFromStackMessageHash(message)
+	valid := vm.verifier().VerifyECDSA(sig, hash, pubKey)
+	vm.recordSigCheck(SigTypeECDSA, valid)
+	return valid, nil
+}
+
+// CheckSigFromStackSchnorrSignature 返回私钥 key 对 message 的 64 字节 BIP0340 签名，可用于 tapscript 中的 OP_CHECKSIGFROMSTACK，
+// 对应的公钥是 key 的 x-only 公钥。
+func CheckSigFromStackSchnorrSignature(message []byte,
+	key *btcec.PrivateKey) ([]byte, error) {
+
+	hash := CheckSigFromStackMessageHash(message)
+	sig, err := schnorr.Sign(key, hash)
+	if err != nil {
+		return nil, err
+	}
+	return sig.Serialize(), nil
+}
+
+// CheckSigFromStackECDSASignature 返回私钥 key 对 message 的严格 DER 编码的低 S 值 ECDSA 签名，可用于 tapscript 中的
+// OP_CHECKSIGFROMSTACK，对应的公钥是 key 的 33 字节压缩公钥。
+func CheckSigFromStackECDSASignature(message []byte,
+	key *btcec.PrivateKey) []byte {
+
+	return ecdsa.Sign(key, CheckSigFromStackMessageHash(message)).Serialize()
+}
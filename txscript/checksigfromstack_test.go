@@ -0,0 +1,243 @@
+package txscript
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"math/big"
+	"os"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcec/v2/schnorr"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/stretchr/testify/require"
+)
+
+// TestCheckSigFromStackVectors 确保 checksigfromstack_tests.json 中的所有测试都按照预期结果执行。
+func TestCheckSigFromStackVectors(t *testing.T) {
+	file, err := os.ReadFile("data/checksigfromstack_tests.json")
+	require.NoError(t, err)
+
+	var tests [][]interface{}
+	require.NoError(t, json.Unmarshal(file, &tests))
+
+	testScripts(t, tests, true)
+	testScripts(t, tests, false)
+}
+
+// TestCheckSigFromStackSignatures 确保签名辅助函数生成的 ECDSA 签名与测试向量一致，生成的 BIP0340 签名能通过消息摘要验证。
+func TestCheckSigFromStackSignatures(t *testing.T) {
+	t.Parallel()
+
+	keyBytes := sha256.Sum256([]byte("bpfschain checksigfromstack oracle"))
+	key, pubKey := btcec.PrivKeyFromBytes(keyBytes[:])
+
+	sig := CheckSigFromStackECDSASignature([]byte("rain"), key)
+	require.Equal(t, hexToBytes("3045022100dff870c1bdf3c01c5e96c4370f56ad"+
+		"1e04ae1e7bcfe7b9e1222b078d1f0abd7c022065ffecd4ff2b0ada083a2c59dd"+
+		"ce0ab4ad8cb1b78df61bd6c625e991322d293a"), sig)
+
+	schnorrSig, err := CheckSigFromStackSchnorrSignature([]byte("rain"), key)
+	require.NoError(t, err)
+	parsed, err := schnorr.ParseSignature(schnorrSig)
+	require.NoError(t, err)
+	require.True(t, parsed.Verify(
+		CheckSigFromStackMessageHash([]byte("rain")), pubKey,
+	))
+}
+
+// checkSigFromStackLeafSpend 返回一个函数，它使用给定的见证栈元素和引擎选项通过脚本路径花费只包含 leafScript 的 taproot 输出，
+// 并返回执行结果。
+func checkSigFromStackLeafSpend(t *testing.T,
+	leafScript []byte) func(wire.TxWitness, ScriptFlags, ...EngineOpt) error {
+
+	leaf := NewBaseTapLeaf(leafScript)
+	tree := AssembleTaprootScriptTree(leaf)
+	ctrlBlock := tree.LeafMerkleProofs[0].ToControlBlock(NUMSKey())
+	ctrlBlockBytes, err := ctrlBlock.ToBytes()
+	require.NoError(t, err)
+	rootHash := tree.RootNode.TapHash()
+	pkScript, err := PayToTaprootScript(
+		ComputeTaprootOutputKey(NUMSKey(), rootHash[:]),
+	)
+	require.NoError(t, err)
+
+	const amt = 1e8
+	prevFetcher := NewCannedPrevOutputFetcher(pkScript, amt)
+	return func(items wire.TxWitness, flags ScriptFlags,
+		opts ...EngineOpt) error {
+
+		tx := htlcSpendingTx(0)
+		tx.TxIn[0].Witness = append(
+			append(wire.TxWitness{}, items...), leafScript, ctrlBlockBytes,
+		)
+		vm, err := NewEngine(pkScript, tx, 0, flags, nil,
+			NewTxSigHashes(tx, prevFetcher), amt, prevFetcher, opts...)
+		if err != nil {
+			return err
+		}
+		return vm.Execute()
+	}
+}
+
+// TestCheckSigFromStackTapscript 确保 tapscript 中的 OP_CHECKSIGFROMSTACK 验证 BIP0340 签名，
+// 无效的非空签名导致 NULLFAIL，并且未设置标志时该操作码仍是 OP_SUCCESSx。
+func TestCheckSigFromStackTapscript(t *testing.T) {
+	t.Parallel()
+
+	oracleKey, err := btcec.NewPrivateKey()
+	require.NoError(t, err)
+	leafScript, err := NewScriptBuilder().
+		AddData(schnorr.SerializePubKey(oracleKey.PubKey())).
+		AddOp(OP_CHECKSIGFROMSTACK).Script()
+	require.NoError(t, err)
+	spend := checkSigFromStackLeafSpend(t, leafScript)
+	execute := func(sig, message []byte, flags ScriptFlags) error {
+		return spend(wire.TxWitness{sig, message}, flags)
+	}
+
+	sig, err := CheckSigFromStackSchnorrSignature([]byte("rain"), oracleKey)
+	require.NoError(t, err)
+
+	flags := StandardVerifyFlags | ScriptVerifyCheckSigFromStack
+	require.NoError(t, execute(sig, []byte("rain"), flags))
+
+	err = execute(sig, []byte("sun"), flags)
+	require.True(t, IsErrorCode(err, ErrNullFail), "got %v", err)
+
+	err = execute(nil, []byte("rain"), flags)
+	require.True(t, IsErrorCode(err, ErrEvalFalse), "got %v", err)
+
+	err = execute(sig[:63], []byte("rain"), flags)
+	require.True(t, IsErrorCode(err, ErrInvalidTaprootSigLen), "got %v", err)
+
+	// 未设置标志时，任何见证都能通过 OP_SUCCESSx 叶子花费，除非不鼓励使用 OP_SUCCESSx。
+	require.NoError(t, execute(nil, []byte("sun"),
+		StandardVerifyFlags&^ScriptVerifyDiscourageOpSuccess))
+	err = execute(sig, []byte("rain"), StandardVerifyFlags)
+	require.True(t, IsErrorCode(err, ErrDiscourageOpSuccess), "got %v", err)
+
+	// OP_CHECKSIGFROMSTACKVERIFY 在签名无效时同样导致 NULLFAIL。
+	verifyScript, err := NewScriptBuilder().
+		AddData(schnorr.SerializePubKey(oracleKey.PubKey())).
+		AddOp(OP_CHECKSIGFROMSTACKVERIFY).AddOp(OP_TRUE).Script()
+	require.NoError(t, err)
+	spendVerify := checkSigFromStackLeafSpend(t, verifyScript)
+	require.NoError(t, spendVerify(wire.TxWitness{sig, []byte("rain")}, flags))
+	err = spendVerify(wire.TxWitness{sig, []byte("sun")}, flags)
+	require.True(t, IsErrorCode(err, ErrNullFail), "got %v", err)
+}
+
+// highSSignature 返回与 DER 编码的 ECDSA 签名 sig 等价但使用高 S 值的 DER 编码签名。
+func highSSignature(sig []byte) []byte {
+	rLen := int(sig[3])
+	r := sig[4 : 4+rLen]
+	sValue := new(big.Int).SetBytes(sig[6+rLen:])
+	highS := append([]byte{0x00}, new(big.Int).Sub(btcec.S256().N, sValue).Bytes()...)
+
+	encoded := []byte{0x30, byte(4 + len(r) + len(highS)), 0x02, byte(len(r))}
+	encoded = append(encoded, r...)
+	encoded = append(encoded, 0x02, byte(len(highS)))
+	return append(encoded, highS...)
+}
+
+// TestCheckSigFromStackECDSA 确保 tapscript 中的 OP_CHECKSIGFROMSTACK 对 33 字节压缩公钥验证 ECDSA 签名，
+// 并且不论脚本标志如何都要求严格 DER 编码和低 S 值。
+func TestCheckSigFromStackECDSA(t *testing.T) {
+	t.Parallel()
+
+	keyBytes := sha256.Sum256([]byte("bpfschain checksigfromstack oracle"))
+	oracleKey, _ := btcec.PrivKeyFromBytes(keyBytes[:])
+	leafScript, err := NewScriptBuilder().
+		AddData(oracleKey.PubKey().SerializeCompressed()).
+		AddOp(OP_CHECKSIGFROMSTACK).Script()
+	require.NoError(t, err)
+	spend := checkSigFromStackLeafSpend(t, leafScript)
+	execute := func(sig, message []byte, flags ScriptFlags) error {
+		return spend(wire.TxWitness{sig, message}, flags)
+	}
+
+	sig := CheckSigFromStackECDSASignature([]byte("rain"), oracleKey)
+	flags := StandardVerifyFlags | ScriptVerifyCheckSigFromStack
+	require.NoError(t, execute(sig, []byte("rain"), flags))
+
+	err = execute(sig, []byte("sun"), flags)
+	require.True(t, IsErrorCode(err, ErrNullFail), "got %v", err)
+
+	err = execute(nil, []byte("rain"), flags)
+	require.True(t, IsErrorCode(err, ErrEvalFalse), "got %v", err)
+
+	// 编码规则是共识规则，即使只设置了 ScriptVerifyCheckSigFromStack 也会执行。
+	consensusFlags := ScriptBip16 | ScriptVerifyWitness |
+		ScriptVerifyTaproot | ScriptVerifyCheckSigFromStack
+	err = execute(highSSignature(sig), []byte("rain"), consensusFlags)
+	require.True(t, IsErrorCode(err, ErrSigHighS), "got %v", err)
+
+	err = execute(append(sig, 0x00), []byte("rain"), consensusFlags)
+	require.True(t, IsErrorCode(err, ErrSigInvalidDataLen), "got %v", err)
+
+	// 非压缩公钥不是 ECDSA 形式，与其他未知公钥类型一样保留给升级。
+	uncompressedScript, err := NewScriptBuilder().
+		AddData(oracleKey.PubKey().SerializeUncompressed()).
+		AddOp(OP_CHECKSIGFROMSTACK).Script()
+	require.NoError(t, err)
+	spendUncompressed := checkSigFromStackLeafSpend(t, uncompressedScript)
+	require.NoError(t, spendUncompressed(
+		wire.TxWitness{sig, []byte("sun")}, consensusFlags,
+	))
+	err = spendUncompressed(wire.TxWitness{sig, []byte("sun")}, flags)
+	require.True(t, IsErrorCode(err, ErrDiscourageUpgradeablePubKeyType),
+		"got %v", err)
+}
+
+// TestCheckSigFromStackSigOpBudget 确保 tapscript 中每个非空签名的 OP_CHECKSIGFROMSTACK 不论是 BIP0340 还是 ECDSA 形式，
+// 都与 OP_CHECKSIG 一样消耗输入的签名操作预算，空签名不消耗预算。
+func TestCheckSigFromStackSigOpBudget(t *testing.T) {
+	t.Parallel()
+
+	oracleKey, err := btcec.NewPrivateKey()
+	require.NoError(t, err)
+	schnorrSig, err := CheckSigFromStackSchnorrSignature([]byte("rain"),
+		oracleKey)
+	require.NoError(t, err)
+
+	// Each repetition checks the signature again, the budget of a witness
+	// this small only covers a few of them.
+	builder := NewScriptBuilder()
+	for i := 0; i < 8; i++ {
+		builder.AddOp(OP_3DUP).AddOp(OP_CHECKSIGFROMSTACKVERIFY)
+	}
+	leafScript, err := builder.AddOp(OP_2DROP).AddOp(OP_DROP).
+		AddOp(OP_TRUE).Script()
+	require.NoError(t, err)
+	spend := checkSigFromStackLeafSpend(t, leafScript)
+
+	flags := StandardVerifyFlags | ScriptVerifyCheckSigFromStack
+	tests := []struct {
+		name    string
+		witness wire.TxWitness
+	}{{
+		name: "schnorr",
+		witness: wire.TxWitness{
+			schnorrSig, []byte("rain"),
+			schnorr.SerializePubKey(oracleKey.PubKey()),
+		},
+	}, {
+		name: "ecdsa",
+		witness: wire.TxWitness{
+			CheckSigFromStackECDSASignature([]byte("rain"), oracleKey),
+			[]byte("rain"), oracleKey.PubKey().SerializeCompressed(),
+		},
+	}}
+	for _, test := range tests {
+		err = spend(test.witness, flags)
+		require.True(t, IsErrorCode(err, ErrTaprootMaxSigOps),
+			"%s: got %v", test.name, err)
+
+		// 空签名使 OP_CHECKSIGFROMSTACKVERIFY 失败，但在此之前不消耗预算。
+		test.witness[0] = nil
+		err = spend(test.witness, flags)
+		require.True(t, IsErrorCode(err, ErrCheckSigFromStackVerify),
+			"%s: got %v", test.name, err)
+	}
+}
@@ -0,0 +1,179 @@
+// 包含脚本执行成本模型，用于在执行之前估计脚本的最大执行成本，以及在执行期间可选地强制执行成本上限，供中继层对开销较大的脚本进行限流。
+
+package txscript
+
+import (
+	"fmt"
+
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/wire"
+)
+
+// CostModel 为脚本执行的各类操作指定成本。 成本的单位是任意的，DefaultCostModel 大致以哈希一个字节的开销为单位。
+type CostModel struct {
+	// OpCost 是执行一个非数据推送操作码的成本。
+	OpCost uint64
+
+	// PushCost 是执行一个数据推送操作码的成本。
+	PushCost uint64
+
+	// HashByteCost 是哈希操作码每输入一个字节到哈希函数的成本。
+	HashByteCost uint64
+
+	// StackByteCost 是 OP_DUP、OP_PICK 等操作码每复制一个堆栈字节的成本。
+	StackByteCost uint64
+
+	// SigOpCost 是一次签名验证的成本，包括计算签名哈希。
+	SigOpCost uint64
+}
+
+// DefaultCostModel 返回默认的成本模型。 一次签名验证的开销约为哈希两万个字节，操作码的分派和堆栈操作相对便宜。
+func DefaultCostModel() CostModel {
+	return CostModel{
+		OpCost:        8,
+		PushCost:      2,
+		HashByteCost:  1,
+		StackByteCost: 1,
+		SigOpCost:     20000,
+	}
+}
+
+// stackCopyItems 是复制堆栈元素的操作码执行后，位于栈顶的副本元素数量。
+var stackCopyItems = map[byte]int{
+	OP_DUP:   1,
+	OP_IFDUP: 1,
+	OP_2DUP:  2,
+	OP_3DUP:  3,
+	OP_OVER:  1,
+	OP_2OVER: 2,
+	OP_PICK:  1,
+	OP_TUCK:  1,
+}
+
+// hashExtraBytes 是哈希操作码在输入数据之外额外哈希的字节数，即两轮哈希中第二轮的输入。
+var hashExtraBytes = map[byte]int{
+	OP_RIPEMD160: 0,
+	OP_SHA1:      0,
+	OP_SHA256:    0,
+	OP_HASH160:   chainhash.HashSize,
+	OP_HASH256:   chainhash.HashSize,
+}
+
+// ScoreScript 返回以 witness 作为初始堆栈执行 script 的最大成本。 对于见证脚本和 tapscript，witness 是去掉脚本
+// （以及 taproot 控制块和附件）之后的见证堆栈；对于其他脚本可以为 nil。
+//
+// 脚本中没有循环，每个操作码最多执行一次，因此估计是一个上界：所有分支都被视为执行，每个哈希和复制操作的输入按
+// witness 和脚本中最大的元素计算，每个多重签名操作按其公钥数进行签名验证。 脚本无法解析时返回错误。
+func (m *CostModel) ScoreScript(script []byte,
+	witness wire.TxWitness) (uint64, error) {
+
+	// Stack elements are either pushed by the script, taken from the
+	// witness or produced by opcodes, the largest of which are the 32-byte
+	// hash results.
+	maxElem := chainhash.HashSize
+	for _, item := range witness {
+		if len(item) > maxElem {
+			maxElem = len(item)
+		}
+	}
+
+	const scriptVersion = 0
+	tokenizer := MakeScriptTokenizer(scriptVersion, script)
+	for tokenizer.Next() {
+		if len(tokenizer.Data()) > maxElem {
+			maxElem = len(tokenizer.Data())
+		}
+	}
+	if err := tokenizer.Err(); err != nil {
+		return 0, err
+	}
+
+	var cost uint64
+	prevOp := byte(OP_INVALIDOPCODE)
+	tokenizer = MakeScriptTokenizer(scriptVersion, script)
+	for tokenizer.Next() {
+		op := tokenizer.Opcode()
+		cost += m.opcodeCost(op)
+
+		if extra, ok := hashExtraBytes[op]; ok {
+			cost += m.HashByteCost * uint64(maxElem+extra)
+		}
+		if n, ok := stackCopyItems[op]; ok {
+			cost += m.StackByteCost * uint64(n*maxElem)
+		}
+
+		switch op {
+		case OP_CHECKSIG, OP_CHECKSIGVERIFY, OP_CHECKSIGADD:
+			cost += m.SigOpCost
+
+		case OP_CHECKMULTISIG, OP_CHECKMULTISIGVERIFY:
+			numPubKeys := MaxPubKeysPerMultiSig
+			if prevOp >= OP_1 && prevOp <= OP_16 {
+				numPubKeys = AsSmallInt(prevOp)
+			}
+			cost += m.SigOpCost * uint64(numPubKeys)
+		}
+
+		prevOp = op
+	}
+
+	return cost, nil
+}
+
+// opcodeCost 返回执行操作码本身的成本。
+func (m *CostModel) opcodeCost(op byte) uint64 {
+	if op <= OP_16 {
+		return m.PushCost
+	}
+	return m.OpCost
+}
+
+// ScoreScript 使用 DefaultCostModel 返回执行脚本的最大成本，详见 CostModel.ScoreScript。
+func ScoreScript(script []byte, witness wire.TxWitness) (uint64, error) {
+	model := DefaultCostModel()
+	return model.ScoreScript(script, witness)
+}
+
+// WithCostLimit 使引擎按成本模型累计执行成本，并在成本超过 limit 时以 ErrScriptCostExceeded 失败。
+// limit 为 0 时只累计成本而不强制上限，累计的成本可以通过 Engine.Cost 获取。
+func WithCostLimit(model CostModel, limit uint64) EngineOpt {
+	return func(cfg *engineConfig) {
+		cfg.costModel = &model
+		cfg.costLimit = limit
+	}
+}
+
+// Cost 返回引擎按 WithCostLimit 指定的成本模型累计的执行成本，没有指定成本模型时返回 0。
+func (vm *Engine) Cost() uint64 {
+	return vm.cost
+}
+
+// chargeStep 累计一次成功执行的步骤的成本，并检查是否超过成本上限。 哈希和签名验证的成本在执行时已经累计。
+// executing 表示执行前该操作码是否位于执行分支中。
+func (vm *Engine) chargeStep(op byte, executing bool) error {
+	if !executing {
+		return nil
+	}
+
+	model := vm.costModel
+	vm.cost += model.opcodeCost(op)
+	if n, ok := stackCopyItems[op]; ok {
+		// The copies are the topmost items once the opcode executed. This
+		// overcharges OP_IFDUP when the top item is false and therefore not
+		// duplicated, which is fine for a ceiling.
+		for i := int32(0); i < int32(n) && i < vm.dstack.Depth(); i++ {
+			item, err := vm.dstack.PeekByteArray(i)
+			if err != nil {
+				return err
+			}
+			vm.cost += model.StackByteCost * uint64(len(item))
+		}
+	}
+
+	if vm.costLimit != 0 && vm.cost > vm.costLimit {
+		str := fmt.Sprintf("script execution cost %d exceeds limit %d",
+			vm.cost, vm.costLimit)
+		return scriptError(ErrScriptCostExceeded, str)
+	}
+	return nil
+}
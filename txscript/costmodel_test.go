@@ -0,0 +1,141 @@
+package txscript
+
+import (
+	"testing"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/stretchr/testify/require"
+)
+
+// TestScoreScript 确保静态估计的成本按模型计算并覆盖所有分支。
+func TestScoreScript(t *testing.T) {
+	t.Parallel()
+
+	model := CostModel{
+		OpCost:        10,
+		PushCost:      1,
+		HashByteCost:  2,
+		StackByteCost: 3,
+		SigOpCost:     1000,
+	}
+
+	tests := []struct {
+		name    string
+		script  string
+		witness wire.TxWitness
+		cost    uint64
+	}{{
+		name:   "empty",
+		script: "",
+	}, {
+		name:   "pushes",
+		script: "1 DATA_2 0x0102",
+		cost:   2,
+	}, {
+		// 哈希输入按最小元素 32 字节计算，第二轮哈希额外 32 字节。
+		name:   "hash160",
+		script: "HASH160",
+		cost:   10 + 2*(32+32),
+	}, {
+		name:    "dup uses largest witness item",
+		script:  "2DUP",
+		witness: wire.TxWitness{make([]byte, 40), make([]byte, 10)},
+		cost:    10 + 3*2*40,
+	}, {
+		name:   "both branches counted",
+		script: "IF CHECKSIG ELSE CHECKSIGVERIFY ENDIF",
+		cost:   5*10 + 2*1000,
+	}, {
+		name:   "multisig uses key count",
+		script: "2 DATA_1 0x01 DATA_1 0x02 DATA_1 0x03 3 CHECKMULTISIG",
+		cost:   5 + 10 + 3*1000,
+	}, {
+		name:   "multisig without key count",
+		script: "CHECKMULTISIGVERIFY",
+		cost:   10 + MaxPubKeysPerMultiSig*1000,
+	}}
+
+	for _, test := range tests {
+		cost, err := model.ScoreScript(
+			mustParseShortForm(test.script), test.witness,
+		)
+		require.NoError(t, err, test.name)
+		require.Equal(t, test.cost, cost, test.name)
+	}
+
+	_, err := ScoreScript(mustParseShortForm("CHECKSIG DATA_2 0x01"), nil)
+	require.True(t, IsErrorCode(err, ErrMalformedPush))
+}
+
+// TestEngineCostLimit 确保引擎按成本模型累计执行成本，静态估计不低于实际成本，并且超过上限时执行失败。
+func TestEngineCostLimit(t *testing.T) {
+	t.Parallel()
+
+	model := DefaultCostModel()
+
+	// 未执行的分支不计入成本：0、IF、ENDIF 和 1。
+	script := mustParseShortForm("0 IF DATA_1 0x01 SHA256 DROP ENDIF 1")
+	tx := createSpendingTx(nil, nil, script, 0)
+	vm, err := NewEngine(script, tx, 0, 0, nil, nil, 0, nil,
+		WithCostLimit(model, 0))
+	require.NoError(t, err)
+	require.NoError(t, vm.Execute())
+	require.Equal(t, 2*model.PushCost+2*model.OpCost, vm.Cost())
+
+	// 传统 P2PKH 花费。
+	privKey, err := btcec.NewPrivateKey()
+	require.NoError(t, err)
+	pubKey := privKey.PubKey().SerializeCompressed()
+	addr, err := btcutil.NewAddressPubKeyHash(
+		btcutil.Hash160(pubKey), &chaincfg.MainNetParams,
+	)
+	require.NoError(t, err)
+	p2pkh, err := PayToAddrScript(addr)
+	require.NoError(t, err)
+
+	tx = createSpendingTx(nil, nil, p2pkh, 0)
+	sigScript, err := SignatureScript(tx, 0, p2pkh, SigHashAll, privKey, true)
+	require.NoError(t, err)
+	tx.TxIn[0].SignatureScript = sigScript
+
+	// 两个签名脚本推送、OP_DUP 复制公钥、OP_HASH160 哈希公钥及其 SHA256 结果、推送公钥哈希、
+	// OP_EQUALVERIFY 和 OP_CHECKSIG 的一次签名验证。
+	wantCost := 3*model.PushCost + 4*model.OpCost +
+		uint64(len(pubKey))*model.StackByteCost +
+		uint64(len(pubKey)+32)*model.HashByteCost + model.SigOpCost
+
+	vm, err = NewEngine(p2pkh, tx, 0, StandardVerifyFlags, nil, nil, 0,
+		nil, WithCostLimit(model, 0))
+	require.NoError(t, err)
+	require.NoError(t, vm.Execute())
+	require.Equal(t, wantCost, vm.Cost())
+
+	pushes, err := PushedData(sigScript)
+	require.NoError(t, err)
+	sigScriptCost, err := model.ScoreScript(sigScript, nil)
+	require.NoError(t, err)
+	pkScriptCost, err := model.ScoreScript(p2pkh, pushes)
+	require.NoError(t, err)
+	require.GreaterOrEqual(t, sigScriptCost+pkScriptCost, wantCost)
+
+	// 成本恰好等于上限时允许执行，超过上限时失败。
+	vm, err = NewEngine(p2pkh, tx, 0, StandardVerifyFlags, nil, nil, 0,
+		nil, WithCostLimit(model, wantCost))
+	require.NoError(t, err)
+	require.NoError(t, vm.Execute())
+
+	vm, err = NewEngine(p2pkh, tx, 0, StandardVerifyFlags, nil, nil, 0,
+		nil, WithCostLimit(model, wantCost-1))
+	require.NoError(t, err)
+	require.True(t, IsErrorCode(vm.Execute(), ErrScriptCostExceeded))
+
+	// 没有指定成本模型时不累计成本。
+	vm, err = NewEngine(p2pkh, tx, 0, StandardVerifyFlags, nil, nil, 0,
+		nil)
+	require.NoError(t, err)
+	require.NoError(t, vm.Execute())
+	require.Zero(t, vm.Cost())
+}
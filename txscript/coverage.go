@@ -0,0 +1,263 @@
+// 包含脚本执行覆盖率收集器，用于在测试套件中检查复杂合约脚本的每条花费路径是否都被执行过。
+
+package txscript
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+)
+
+// CoverageBranch 表示条件操作码的一个分支。
+type CoverageBranch uint8
+
+const (
+	// CoverageBranchThen 是 OP_IF/OP_NOTIF 之后到 OP_ELSE 或 OP_ENDIF 之前的分支。
+	CoverageBranchThen CoverageBranch = iota
+
+	// CoverageBranchElse 是条件不成立时执行的分支（可能是 OP_ELSE 之后的部分，也可能为空）。
+	CoverageBranchElse
+)
+
+// String 返回分支的可读名称。
+func (b CoverageBranch) String() string {
+	if b == CoverageBranchThen {
+		return "then"
+	}
+	return "else"
+}
+
+// UncoveredBranch 描述一个从未被执行过的条件分支。
+type UncoveredBranch struct {
+	// Script 是包含该条件操作码的脚本。
+	Script []byte
+
+	// OpcodeIndex 是条件操作码在脚本中的操作码序号（不是字节偏移）。
+	OpcodeIndex int
+
+	// Branch 是未被执行的分支。
+	Branch CoverageBranch
+}
+
+// String 返回未覆盖分支的可读描述。
+func (u UncoveredBranch) String() string {
+	return fmt.Sprintf("%s:%04d %v", chainhash.HashH(u.Script), u.OpcodeIndex,
+		u.Branch)
+}
+
+// scriptCoverage 记录单个脚本的覆盖情况。
+type scriptCoverage struct {
+	script []byte
+
+	// executed 按操作码序号记录该操作码是否在执行分支中被成功执行过。
+	executed []bool
+
+	// branches 按条件操作码序号记录每个分支是否被执行过。 只记录位于执行分支中的 OP_IF/OP_NOTIF。
+	branches map[int]*[2]bool
+
+	// conditionals 是脚本中所有 OP_IF/OP_NOTIF 的操作码序号。
+	conditionals []int
+}
+
+// newScriptCoverage 为传入的脚本创建一个空的覆盖记录。
+func newScriptCoverage(script []byte) *scriptCoverage {
+	const scriptVersion = 0
+	cov := &scriptCoverage{
+		script:   script,
+		branches: make(map[int]*[2]bool),
+	}
+
+	var numOpcodes int
+	tokenizer := MakeScriptTokenizer(scriptVersion, script)
+	for tokenizer.Next() {
+		switch tokenizer.Opcode() {
+		case OP_IF, OP_NOTIF:
+			cov.conditionals = append(cov.conditionals, numOpcodes)
+		}
+		numOpcodes++
+	}
+	cov.executed = make([]bool, numOpcodes)
+
+	return cov
+}
+
+// ScriptCoverage 收集一个或多个引擎执行脚本时的操作码和分支覆盖情况。
+// 通过 WithCoverage 将同一个收集器传给多个引擎，即可汇总一组测试花费的覆盖率。
+//
+// ScriptCoverage 是并发安全的。
+type ScriptCoverage struct {
+	mtx     sync.Mutex
+	scripts map[chainhash.Hash]*scriptCoverage
+	order   []chainhash.Hash
+}
+
+// NewScriptCoverage 返回一个新的空覆盖率收集器。
+func NewScriptCoverage() *ScriptCoverage {
+	return &ScriptCoverage{
+		scripts: make(map[chainhash.Hash]*scriptCoverage),
+	}
+}
+
+// WithCoverage 使引擎把每一步的执行情况记录到传入的收集器中。
+func WithCoverage(c *ScriptCoverage) EngineOpt {
+	return func(cfg *engineConfig) {
+		cfg.coverage = c
+	}
+}
+
+// lookup 返回脚本的覆盖记录，不存在时创建。 调用者必须持有 mtx。
+func (c *ScriptCoverage) lookup(script []byte) *scriptCoverage {
+	hash := chainhash.HashH(script)
+	cov, ok := c.scripts[hash]
+	if !ok {
+		cov = newScriptCoverage(script)
+		c.scripts[hash] = cov
+		c.order = append(c.order, hash)
+	}
+	return cov
+}
+
+// AddScript 将脚本加入收集器而不记录任何执行，使从未被执行过的脚本也会出现在报告中。
+func (c *ScriptCoverage) AddScript(script []byte) {
+	c.mtx.Lock()
+	c.lookup(script)
+	c.mtx.Unlock()
+}
+
+// isOpcodeExecuting 返回传入的操作码在当前位置是否会真正生效。 OP_ELSE 和 OP_ENDIF 即使位于未执行的分支中也会改变条件状态，
+// 因此只要与之匹配的 OP_IF/OP_NOTIF 本身被执行过，它们就视为已执行。
+func (vm *Engine) isOpcodeExecuting(op byte) bool {
+	switch op {
+	case OP_ELSE, OP_ENDIF:
+		return len(vm.condStack) > 0 &&
+			vm.condStack[len(vm.condStack)-1] != OpCondSkip
+	}
+	return vm.isBranchExecuting()
+}
+
+// recordStep 记录一次操作码执行。 executing 表示执行前该操作码是否位于执行分支中，
+// branchTaken 仅对条件操作码有意义，表示之后的 then 分支是否会被执行。
+func (c *ScriptCoverage) recordStep(script []byte, opcodeIdx int, op byte,
+	executing, branchTaken bool) {
+
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	cov := c.lookup(script)
+	if !executing || opcodeIdx >= len(cov.executed) {
+		return
+	}
+	cov.executed[opcodeIdx] = true
+
+	if op != OP_IF && op != OP_NOTIF {
+		return
+	}
+	branches, ok := cov.branches[opcodeIdx]
+	if !ok {
+		branches = new([2]bool)
+		cov.branches[opcodeIdx] = branches
+	}
+	if branchTaken {
+		branches[CoverageBranchThen] = true
+	} else {
+		branches[CoverageBranchElse] = true
+	}
+}
+
+// OpcodeCoverage 返回脚本中被执行过的操作码数以及操作码总数。
+func (c *ScriptCoverage) OpcodeCoverage(script []byte) (int, int) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	cov, ok := c.scripts[chainhash.HashH(script)]
+	if !ok {
+		return 0, 0
+	}
+	var executed int
+	for _, e := range cov.executed {
+		if e {
+			executed++
+		}
+	}
+	return executed, len(cov.executed)
+}
+
+// UnexecutedOpcodes 返回脚本中从未被执行过的操作码序号。
+func (c *ScriptCoverage) UnexecutedOpcodes(script []byte) []int {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	cov, ok := c.scripts[chainhash.HashH(script)]
+	if !ok {
+		return nil
+	}
+	var unexecuted []int
+	for i, e := range cov.executed {
+		if !e {
+			unexecuted = append(unexecuted, i)
+		}
+	}
+	return unexecuted
+}
+
+// UncoveredBranches 返回所有已记录脚本中从未被执行过的 OP_IF/OP_NOTIF 分支，按脚本加入顺序和操作码序号排序。
+// 位于从未执行过的分支内部的条件操作码，其两个分支都会被报告。
+func (c *ScriptCoverage) UncoveredBranches() []UncoveredBranch {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	var uncovered []UncoveredBranch
+	for _, hash := range c.order {
+		cov := c.scripts[hash]
+		for _, idx := range cov.conditionals {
+			var taken [2]bool
+			if branches, ok := cov.branches[idx]; ok {
+				taken = *branches
+			}
+			for _, branch := range []CoverageBranch{
+				CoverageBranchThen, CoverageBranchElse,
+			} {
+				if taken[branch] {
+					continue
+				}
+				uncovered = append(uncovered, UncoveredBranch{
+					Script:      cov.script,
+					OpcodeIndex: idx,
+					Branch:      branch,
+				})
+			}
+		}
+	}
+	return uncovered
+}
+
+// Report 返回所有已记录脚本的覆盖率摘要，包括反汇编的脚本、操作码覆盖率和未覆盖的分支。
+func (c *ScriptCoverage) Report() string {
+	c.mtx.Lock()
+	hashes := append([]chainhash.Hash(nil), c.order...)
+	c.mtx.Unlock()
+
+	uncovered := c.UncoveredBranches()
+
+	var b strings.Builder
+	for _, hash := range hashes {
+		c.mtx.Lock()
+		script := c.scripts[hash].script
+		c.mtx.Unlock()
+
+		executed, total := c.OpcodeCoverage(script)
+		disasm, _ := DisasmString(script)
+		fmt.Fprintf(&b, "%s: %d/%d opcodes executed\n  %s\n", hash,
+			executed, total, disasm)
+		for _, u := range uncovered {
+			if chainhash.HashH(u.Script) != hash {
+				continue
+			}
+			fmt.Fprintf(&b, "  uncovered %v branch of conditional "+
+				"at opcode %d\n", u.Branch, u.OpcodeIndex)
+		}
+	}
+	return b.String()
+}
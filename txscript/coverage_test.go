@@ -0,0 +1,50 @@
+package txscript
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// TestScriptCoverage 确保覆盖率收集器能够跨多次执行汇总操作码和分支覆盖情况。
+func TestScriptCoverage(t *testing.T) {
+	t.Parallel()
+
+	// 一个简单的双路径合约：栈顶为真时要求 2，否则要求 3。
+	pkScript := mustParseShortForm("IF 2 ELSE 3 ENDIF EQUAL")
+	unused := mustParseShortForm("IF 1 ENDIF")
+
+	coverage := NewScriptCoverage()
+	coverage.AddScript(unused)
+	execute := func(sigScript string) {
+		t.Helper()
+		tx := createSpendingTx(nil, mustParseShortForm(sigScript),
+			pkScript, 0)
+		vm, err := NewEngine(pkScript, tx, 0, 0, nil, nil, 0, nil,
+			WithCoverage(coverage))
+		require.NoError(t, err)
+		require.NoError(t, vm.Execute())
+	}
+
+	execute("2 1")
+	uncovered := coverage.UncoveredBranches()
+	require.Len(t, uncovered, 3)
+
+	// 未执行的脚本先加入，因此其两个分支排在前面。
+	require.Equal(t, pkScript, uncovered[2].Script)
+	require.Equal(t, 0, uncovered[2].OpcodeIndex)
+	require.Equal(t, CoverageBranchElse, uncovered[2].Branch)
+	require.Equal(t, []int{3}, coverage.UnexecutedOpcodes(pkScript))
+	executed, total := coverage.OpcodeCoverage(pkScript)
+	require.Equal(t, 5, executed)
+	require.Equal(t, 6, total)
+
+	execute("3 0")
+	uncovered = coverage.UncoveredBranches()
+	require.Len(t, uncovered, 2)
+	for _, u := range uncovered {
+		require.Equal(t, unused, u.Script)
+	}
+	require.Empty(t, coverage.UnexecutedOpcodes(pkScript))
+	require.Contains(t, coverage.Report(), "uncovered then branch")
+}
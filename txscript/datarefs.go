@@ -0,0 +1,72 @@
+// 包含签名哈希安全审计使用的数据引用查找。 传统签名哈希（BIP0143 之前）在计算前会从子脚本中删除所有包含签名的规范数据推送，
+// 即 Bitcoin Core 中的 FindAndDelete，因此在脚本中嵌入签名的合约实际签署的子脚本与执行的脚本不同。
+// FindDataReferences 报告脚本中每个包含给定字节的推送以及删除是否会发生，使脚本作者能够在部署前发现这类共识上的边缘情况。
+
+package txscript
+
+import "bytes"
+
+// DataReference 描述脚本中一个推送的数据包含给定字节的操作码。
+type DataReference struct {
+	// OpcodeIndex 是该操作码在脚本中的序号，从 0 开始，与 DisasmPC 输出的操作码序号相同。
+	OpcodeIndex int
+
+	// ByteOffset 是该操作码在脚本中的字节偏移。
+	ByteOffset int
+
+	// Opcode 是推送数据的操作码。
+	Opcode byte
+
+	// Exact 表示推送的数据与给定字节完全相等，否则给定字节只是数据的一部分。
+	Exact bool
+
+	// Canonical 表示该推送使用了最小的推送操作码，只有规范推送会被传统签名哈希删除。
+	Canonical bool
+
+	// Stripped 表示传统签名哈希会从子脚本中删除整个推送，此时签署的子脚本与执行的脚本不同。
+	// 给定字节只是数据的一部分时，删除的内容还包括推送中的其它数据。
+	Stripped bool
+}
+
+// FindDataReferences 返回脚本中每个推送的数据包含 data 的操作码，按在脚本中出现的顺序排列，
+// 并按 OP_CHECKSIG 和 OP_CHECKMULTISIG 在传统签名哈希计算中删除签名的规则标记每个推送是否会被删除，
+// 与 RemoveDataPushes 的结果完全一致。 data 通常是带有签名哈希类型字节的签名。
+//
+// 以下情况值得脚本作者注意：
+//
+//   - Stripped 为 true：签名所在的推送不会被签名提交，在签名哈希计算时等同于不存在。
+//   - Stripped 为 true 且 Exact 为 false：推送中与签名无关的其它数据也一同被删除。
+//   - Canonical 为 false：非规范推送不会被删除，与同一数据的规范推送行为不同，并且违反 ScriptVerifyMinimalData。
+//
+// 删除只发生在传统（SigVersionBase）脚本中，见证版本 0 和 tapscript 的签名哈希不修改脚本。
+// data 为空时没有推送被视为引用，返回空结果。 如果脚本解析失败，则返回解析错误。
+//
+// 注意：该函数仅对0版本脚本有效。 由于该函数不接受脚本版本，因此其他脚本版本的结果未定义。
+func FindDataReferences(script, data []byte) ([]DataReference, error) {
+	const scriptVersion = 0
+	if err := checkScriptParses(scriptVersion, script); err != nil {
+		return nil, err
+	}
+	if len(data) == 0 {
+		return nil, nil
+	}
+
+	var refs []DataReference
+	var prevOffset int32
+	tokenizer := MakeScriptTokenizer(scriptVersion, script)
+	for tokenizer.Next() {
+		op, pushed := tokenizer.Opcode(), tokenizer.Data()
+		if op <= OP_PUSHDATA4 && bytes.Contains(pushed, data) {
+			refs = append(refs, DataReference{
+				OpcodeIndex: int(tokenizer.OpcodePosition()),
+				ByteOffset:  int(prevOffset),
+				Opcode:      op,
+				Exact:       len(pushed) == len(data),
+				Canonical:   isCanonicalPush(op, pushed),
+				Stripped:    strippedBySigHash(op, pushed, data),
+			})
+		}
+		prevOffset = tokenizer.ByteIndex()
+	}
+	return refs, nil
+}
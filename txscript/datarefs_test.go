@@ -0,0 +1,92 @@
+package txscript
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// TestFindDataReferences 确保 FindDataReferences 报告每个包含数据的推送，并且其 Stripped 标记与 RemoveDataPushes 删除的推送完全一致。
+func TestFindDataReferences(t *testing.T) {
+	t.Parallel()
+
+	data := []byte{1, 2, 3}
+	tests := []struct {
+		name   string
+		script string
+		refs   []DataReference
+		err    ErrorCode
+	}{{
+		name:   "no references",
+		script: "DUP HASH160 DATA_2 0x0102 EQUAL",
+	}, {
+		name:   "exact canonical push",
+		script: "NOP DATA_3 0x010203 DROP TRUE",
+		refs: []DataReference{{
+			OpcodeIndex: 1, ByteOffset: 1, Opcode: OP_DATA_3,
+			Exact: true, Canonical: true, Stripped: true,
+		}},
+	}, {
+		name:   "partial push removes extra data",
+		script: "DATA_5 0x0001020304 DROP DATA_3 0x010203",
+		refs: []DataReference{{
+			OpcodeIndex: 0, ByteOffset: 0, Opcode: OP_DATA_5,
+			Canonical: true, Stripped: true,
+		}, {
+			OpcodeIndex: 2, ByteOffset: 7, Opcode: OP_DATA_3,
+			Exact: true, Canonical: true, Stripped: true,
+		}},
+	}, {
+		name:   "noncanonical push kept",
+		script: "PUSHDATA1 0x03 0x010203 DATA_3 0x010203",
+		refs: []DataReference{{
+			OpcodeIndex: 0, ByteOffset: 0, Opcode: OP_PUSHDATA1,
+			Exact: true,
+		}, {
+			OpcodeIndex: 1, ByteOffset: 5, Opcode: OP_DATA_3,
+			Exact: true, Canonical: true, Stripped: true,
+		}},
+	}, {
+		name:   "opcode bytes are not references",
+		script: "NOP2 NOP3 NOP4",
+	}, {
+		name:   "malformed script",
+		script: "PUSHDATA1 0xff 0xfe",
+		err:    ErrMalformedPush,
+	}}
+
+	for _, test := range tests {
+		script := mustParseShortForm(test.script)
+		refs, err := FindDataReferences(script, data)
+		if test.err != 0 {
+			require.True(t, IsErrorCode(err, test.err), test.name)
+			continue
+		}
+		require.NoError(t, err, test.name)
+		require.Equal(t, test.refs, refs, test.name)
+
+		// Removing exactly the stripped pushes must reproduce the legacy
+		// sighash subscript.
+		var kept []byte
+		stripped := make(map[int]bool)
+		for _, ref := range refs {
+			stripped[ref.OpcodeIndex] = ref.Stripped
+		}
+		tokenizer := MakeScriptTokenizer(0, script)
+		var prevOffset int32
+		for tokenizer.Next() {
+			idx := int(tokenizer.OpcodePosition())
+			if !stripped[idx] {
+				kept = append(kept, script[prevOffset:tokenizer.ByteIndex()]...)
+			}
+			prevOffset = tokenizer.ByteIndex()
+		}
+		want, err := RemoveDataPushes(script, data)
+		require.NoError(t, err, test.name)
+		require.Equal(t, want, append([]byte{}, kept...), test.name)
+	}
+
+	refs, err := FindDataReferences(mustParseShortForm("DATA_1 0x01"), nil)
+	require.NoError(t, err)
+	require.Empty(t, refs)
+}
@@ -0,0 +1,314 @@
+// 包含与 Bitcoin Core 的 decodescript 和 decoderawtransaction RPC 输出格式相同的脚本和交易解码，
+// 使 RPC 层可以直接序列化结果，而不必用底层函数重新拼装。
+
+package txscript
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcec/v2/ecdsa"
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/wire"
+)
+
+// DecodedScript 是解码后的公钥脚本，字段与 Bitcoin Core 的 decodescript 结果相同。
+type DecodedScript struct {
+	// Asm 是脚本的反汇编。 脚本无法解析时以 "[error]" 结尾。
+	Asm string `json:"asm"`
+
+	// Hex 是脚本的十六进制编码。 DecodeScript 的顶层结果不包含它，因为它就是传入的脚本。
+	Hex string `json:"hex,omitempty"`
+
+	// Type 是脚本类别的名称，例如 "pubkeyhash" 或 "witness_v0_scripthash"。
+	Type string `json:"type"`
+
+	// ReqSigs 和 Addresses 是花费所需的签名数和脚本支付的地址。
+	ReqSigs   int      `json:"reqSigs,omitempty"`
+	Addresses []string `json:"addresses,omitempty"`
+
+	// P2SH 是以脚本作为兑换脚本的 P2SH 地址，脚本不能被 P2SH 包装时为空。
+	P2SH string `json:"p2sh,omitempty"`
+
+	// Segwit 是脚本对应的见证版本 0 程序：P2PK 和 P2PKH 脚本对应 P2WPKH，其他脚本对应以它为见证脚本的 P2WSH。
+	// 脚本不能用于见证版本 0 时为 nil。
+	Segwit *DecodedScript `json:"segwit,omitempty"`
+
+	// P2SHSegwit 是包装 Segwit 程序的 P2SH 地址，只在 Segwit 中设置。
+	P2SHSegwit string `json:"p2sh-segwit,omitempty"`
+}
+
+// DecodedSigScript 是解码后的签名脚本。
+type DecodedSigScript struct {
+	// Asm 是签名脚本的反汇编，其中的签名以 "<签名>[ALL]" 的形式显示哈希类型。
+	Asm string `json:"asm"`
+
+	// Hex 是签名脚本的十六进制编码。
+	Hex string `json:"hex"`
+}
+
+// DecodedPrevOut 是输入花费的输出。
+type DecodedPrevOut struct {
+	// Value 是以 BTC 为单位的金额。
+	Value float64 `json:"value"`
+
+	// ScriptPubKey 是输出的公钥脚本。
+	ScriptPubKey DecodedScript `json:"scriptPubKey"`
+}
+
+// DecodedTxIn 是解码后的交易输入。 币基输入只设置 Coinbase 和 Sequence。
+type DecodedTxIn struct {
+	Coinbase  string            `json:"coinbase,omitempty"`
+	Txid      string            `json:"txid,omitempty"`
+	Vout      *uint32           `json:"vout,omitempty"`
+	ScriptSig *DecodedSigScript `json:"scriptSig,omitempty"`
+	Witness   []string          `json:"txinwitness,omitempty"`
+
+	// Prevout 是输入花费的输出，只在向 DecodeTxScripts 提供了该输出时设置。
+	Prevout *DecodedPrevOut `json:"prevout,omitempty"`
+
+	Sequence uint32 `json:"sequence"`
+}
+
+// DecodedTxOut 是解码后的交易输出。
+type DecodedTxOut struct {
+	// Value 是以 BTC 为单位的金额。
+	Value        float64       `json:"value"`
+	N            uint32        `json:"n"`
+	ScriptPubKey DecodedScript `json:"scriptPubKey"`
+}
+
+// DecodedTx 是解码后的交易，字段与 Bitcoin Core 的 decoderawtransaction 结果相同。
+type DecodedTx struct {
+	Txid     string         `json:"txid"`
+	Hash     string         `json:"hash"`
+	Version  int32          `json:"version"`
+	Size     int            `json:"size"`
+	Vsize    int            `json:"vsize"`
+	Weight   int            `json:"weight"`
+	LockTime uint32         `json:"locktime"`
+	Vin      []DecodedTxIn  `json:"vin"`
+	Vout     []DecodedTxOut `json:"vout"`
+}
+
+// DecodeScript 返回 script 作为公钥脚本的解码结果，包括可以包装它的 P2SH 地址和见证版本 0 程序，
+// 与 Bitcoin Core 的 decodescript 相同。 无法解析的脚本不会导致错误，其反汇编以 "[error]" 结尾，类别为 "nonstandard"。
+func DecodeScript(script []byte, params *chaincfg.Params) *DecodedScript {
+	decoded, class, pubKeys := decodePkScript(script, params)
+	decoded.Hex = ""
+	if !canWrapP2SH(script, class) {
+		return decoded
+	}
+	if addr, err := btcutil.NewAddressScriptHash(script, params); err == nil {
+		decoded.P2SH = addr.EncodeAddress()
+	}
+
+	// Witness scripts can't use uncompressed public keys, and witness
+	// programs can't be wrapped again.
+	var segwitScript []byte
+	var err error
+	switch class {
+	case PubKeyTy:
+		if len(pubKeys[0]) != btcec.PubKeyBytesLenCompressed {
+			return decoded
+		}
+		segwitScript, err = payToWitnessPubKeyHashScript(hash160(pubKeys[0]))
+
+	case PubKeyHashTy:
+		segwitScript, err = payToWitnessPubKeyHashScript(
+			extractPubKeyHash(script),
+		)
+
+	case MultiSigTy:
+		for _, pubKey := range pubKeys {
+			if len(pubKey) != btcec.PubKeyBytesLenCompressed {
+				return decoded
+			}
+		}
+		fallthrough
+
+	default:
+		if IsWitnessProgram(script) {
+			return decoded
+		}
+		scriptHash := sha256.Sum256(script)
+		segwitScript, err = payToWitnessScriptHashScript(scriptHash[:])
+	}
+	if err != nil {
+		return decoded
+	}
+
+	decoded.Segwit, _, _ = decodePkScript(segwitScript, params)
+	addr, err := btcutil.NewAddressScriptHash(segwitScript, params)
+	if err == nil {
+		decoded.Segwit.P2SHSegwit = addr.EncodeAddress()
+	}
+	return decoded
+}
+
+// decodePkScript 返回公钥脚本的反汇编、十六进制编码、类别和地址，以及 P2PK 和多重签名脚本中的公钥。
+func decodePkScript(script []byte,
+	params *chaincfg.Params) (*DecodedScript, ScriptClass, [][]byte) {
+
+	asm, _ := DisasmString(script)
+	decoded := &DecodedScript{
+		Asm: asm,
+		Hex: hex.EncodeToString(script),
+	}
+
+	// Unparsable scripts are nonstandard and pay no addresses.
+	class, addrs, reqSigs, _ := ExtractPkScriptAddrs(script, params)
+	decoded.Type = class.String()
+	decoded.ReqSigs = reqSigs
+	for _, addr := range addrs {
+		decoded.Addresses = append(decoded.Addresses, addr.EncodeAddress())
+	}
+
+	var pubKeys [][]byte
+	switch class {
+	case PubKeyTy:
+		pubKeys = [][]byte{extractPubKey(script)}
+	case MultiSigTy:
+		const scriptVersion = 0
+		pubKeys = extractMultisigScriptDetails(
+			scriptVersion, script, true,
+		).pubKeys
+	}
+	return decoded, class, pubKeys
+}
+
+// canWrapP2SH 返回脚本能否作为 P2SH 兑换脚本，规则与 Bitcoin Core 相同：P2SH、taproot、未知版本的见证程序、
+// 空数据脚本和包含 OP_CHECKSIGADD 的非标准脚本不能被包装。
+func canWrapP2SH(script []byte, class ScriptClass) bool {
+	switch class {
+	case ScriptHashTy, WitnessV1TaprootTy, WitnessUnknownTy, NullDataTy:
+		return false
+
+	case NonStandardTy:
+		const scriptVersion = 0
+		tokenizer := MakeScriptTokenizer(scriptVersion, script)
+		for tokenizer.Next() {
+			if tokenizer.Opcode() == OP_CHECKSIGADD {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// sigHashTypeNames 是签名脚本反汇编中显示的哈希类型名称，与 Bitcoin Core 相同。
+var sigHashTypeNames = map[SigHashType]string{
+	SigHashAll:                          "ALL",
+	SigHashNone:                         "NONE",
+	SigHashSingle:                       "SINGLE",
+	SigHashAll | SigHashAnyOneCanPay:    "ALL|ANYONECANPAY",
+	SigHashNone | SigHashAnyOneCanPay:   "NONE|ANYONECANPAY",
+	SigHashSingle | SigHashAnyOneCanPay: "SINGLE|ANYONECANPAY",
+}
+
+// disasmSigScript 返回签名脚本的单行反汇编。 与 DisasmString 不同，严格 DER 编码且哈希类型有效的签名
+// 显示为去掉哈希类型字节的签名后接方括号中的哈希类型名称。
+func disasmSigScript(script []byte) string {
+	const scriptVersion = 0
+
+	var disbuf strings.Builder
+	tokenizer := MakeScriptTokenizer(scriptVersion, script)
+	for tokenizer.Next() {
+		if disbuf.Len() != 0 {
+			disbuf.WriteByte(' ')
+		}
+
+		// Core only attempts to decode pushes longer than a script
+		// number as signatures.
+		data := tokenizer.Data()
+		if len(data) > 4 {
+			hashType := SigHashType(data[len(data)-1])
+			name, ok := sigHashTypeNames[hashType]
+			if ok {
+				_, err := ecdsa.ParseDERSignature(data[:len(data)-1])
+				ok = err == nil
+			}
+			if ok {
+				disbuf.WriteString(hex.EncodeToString(data[:len(data)-1]))
+				disbuf.WriteString("[" + name + "]")
+				continue
+			}
+		}
+		disasmOpcode(&disbuf, tokenizer.op, data, true)
+	}
+	if tokenizer.Err() != nil {
+		if tokenizer.ByteIndex() != 0 {
+			disbuf.WriteByte(' ')
+		}
+		disbuf.WriteString("[error]")
+	}
+	return disbuf.String()
+}
+
+// DecodeTxScripts 返回交易的解码结果，与 Bitcoin Core 的 decoderawtransaction 相同。 prevOuts 不为 nil 时，
+// 能够获取的被花费输出会作为输入的 Prevout 一同解码。
+func DecodeTxScripts(tx *wire.MsgTx, prevOuts PrevOutputFetcher,
+	params *chaincfg.Params) *DecodedTx {
+
+	weight := tx.SerializeSizeStripped()*3 + tx.SerializeSize()
+	decoded := &DecodedTx{
+		Txid:     tx.TxHash().String(),
+		Hash:     tx.WitnessHash().String(),
+		Version:  tx.Version,
+		Size:     tx.SerializeSize(),
+		Vsize:    (weight + 3) / 4,
+		Weight:   weight,
+		LockTime: tx.LockTime,
+		Vin:      make([]DecodedTxIn, 0, len(tx.TxIn)),
+		Vout:     make([]DecodedTxOut, 0, len(tx.TxOut)),
+	}
+
+	isCoinBase := len(tx.TxIn) == 1 &&
+		tx.TxIn[0].PreviousOutPoint.Index == wire.MaxPrevOutIndex &&
+		tx.TxIn[0].PreviousOutPoint.Hash == (chainhash.Hash{})
+	for _, txIn := range tx.TxIn {
+		vin := DecodedTxIn{Sequence: txIn.Sequence}
+		for _, item := range txIn.Witness {
+			vin.Witness = append(vin.Witness, hex.EncodeToString(item))
+		}
+		if isCoinBase {
+			vin.Coinbase = hex.EncodeToString(txIn.SignatureScript)
+			decoded.Vin = append(decoded.Vin, vin)
+			continue
+		}
+
+		vout := txIn.PreviousOutPoint.Index
+		vin.Txid = txIn.PreviousOutPoint.Hash.String()
+		vin.Vout = &vout
+		vin.ScriptSig = &DecodedSigScript{
+			Asm: disasmSigScript(txIn.SignatureScript),
+			Hex: hex.EncodeToString(txIn.SignatureScript),
+		}
+		if prevOuts != nil {
+			prevOut := prevOuts.FetchPrevOutput(txIn.PreviousOutPoint)
+			if prevOut != nil {
+				scriptPubKey, _, _ := decodePkScript(prevOut.PkScript, params)
+				vin.Prevout = &DecodedPrevOut{
+					Value:        btcutil.Amount(prevOut.Value).ToBTC(),
+					ScriptPubKey: *scriptPubKey,
+				}
+			}
+		}
+		decoded.Vin = append(decoded.Vin, vin)
+	}
+
+	for i, txOut := range tx.TxOut {
+		scriptPubKey, _, _ := decodePkScript(txOut.PkScript, params)
+		decoded.Vout = append(decoded.Vout, DecodedTxOut{
+			Value:        btcutil.Amount(txOut.Value).ToBTC(),
+			N:            uint32(i),
+			ScriptPubKey: *scriptPubKey,
+		})
+	}
+
+	return decoded
+}
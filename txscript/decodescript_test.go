@@ -0,0 +1,167 @@
+package txscript
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/stretchr/testify/require"
+)
+
+// TestDecodeScript 确保脚本解码结果的类别、地址以及 P2SH 和见证版本 0 包装与 Bitcoin Core 的 decodescript 一致。
+func TestDecodeScript(t *testing.T) {
+	t.Parallel()
+
+	params := &chaincfg.MainNetParams
+
+	p2pkh := hexToBytes("76a91455ae51684c43435da751ac8d2173b2652eb6410588ac")
+	pkhAddr, err := btcutil.NewAddressPubKeyHash(p2pkh[3:23], params)
+	require.NoError(t, err)
+	p2shAddr, err := btcutil.NewAddressScriptHash(p2pkh, params)
+	require.NoError(t, err)
+	decoded := DecodeScript(p2pkh, params)
+	require.Equal(t, "OP_DUP OP_HASH160 55ae51684c43435da751ac8d2173b2652eb64105 "+
+		"OP_EQUALVERIFY OP_CHECKSIG", decoded.Asm)
+	require.Empty(t, decoded.Hex)
+	require.Equal(t, "pubkeyhash", decoded.Type)
+	require.Equal(t, 1, decoded.ReqSigs)
+	require.Equal(t, []string{pkhAddr.EncodeAddress()}, decoded.Addresses)
+	require.Equal(t, p2shAddr.EncodeAddress(), decoded.P2SH)
+	require.NotNil(t, decoded.Segwit)
+	require.Equal(t, "witness_v0_keyhash", decoded.Segwit.Type)
+	require.Equal(t, "001455ae51684c43435da751ac8d2173b2652eb64105",
+		decoded.Segwit.Hex)
+	require.NotEmpty(t, decoded.Segwit.P2SHSegwit)
+
+	// 多重签名脚本被包装为 P2WSH。
+	var multiSig []byte
+	var addrKeys []*btcutil.AddressPubKey
+	for i := 0; i < 2; i++ {
+		priv, err := btcec.NewPrivateKey()
+		require.NoError(t, err)
+		addrKey, err := btcutil.NewAddressPubKey(
+			priv.PubKey().SerializeCompressed(), params,
+		)
+		require.NoError(t, err)
+		addrKeys = append(addrKeys, addrKey)
+	}
+	multiSig, err = MultiSigScript(addrKeys, 1)
+	require.NoError(t, err)
+	decoded = DecodeScript(multiSig, params)
+	require.Equal(t, "multisig", decoded.Type)
+	require.Len(t, decoded.Addresses, 2)
+	require.Equal(t, "witness_v0_scripthash", decoded.Segwit.Type)
+
+	// 使用未压缩公钥的脚本不能用于见证版本 0。
+	uncompressed, err := btcutil.NewAddressPubKey(
+		addrKeys[0].PubKey().SerializeUncompressed(), params,
+	)
+	require.NoError(t, err)
+	p2pk, err := PayToAddrScript(uncompressed)
+	require.NoError(t, err)
+	decoded = DecodeScript(p2pk, params)
+	require.Equal(t, "pubkey", decoded.Type)
+	require.NotEmpty(t, decoded.P2SH)
+	require.Nil(t, decoded.Segwit)
+
+	// P2SH、taproot 和空数据脚本不能被包装，见证程序只能被 P2SH 包装。
+	p2sh, err := hex.DecodeString("a914" + strings.Repeat("00", 20) + "87")
+	require.NoError(t, err)
+	p2tr := append([]byte{OP_1, OP_DATA_32}, make([]byte, 32)...)
+	nullData, err := NullDataScript([]byte("data"))
+	require.NoError(t, err)
+	for _, script := range [][]byte{p2sh, p2tr, nullData} {
+		decoded = DecodeScript(script, params)
+		require.Empty(t, decoded.P2SH)
+		require.Nil(t, decoded.Segwit)
+	}
+	p2wpkh, err := payToWitnessPubKeyHashScript(make([]byte, 20))
+	require.NoError(t, err)
+	decoded = DecodeScript(p2wpkh, params)
+	require.NotEmpty(t, decoded.P2SH)
+	require.Nil(t, decoded.Segwit)
+
+	// 无法解析的脚本仍然返回结果。
+	decoded = DecodeScript([]byte{OP_DATA_2, 0x01}, params)
+	require.Equal(t, "nonstandard", decoded.Type)
+	require.True(t, strings.HasSuffix(decoded.Asm, "[error]"))
+}
+
+// TestDecodeTxScripts 确保交易解码结果包含签名的哈希类型、见证、被花费的输出以及与 decoderawtransaction 相同的 JSON 字段。
+func TestDecodeTxScripts(t *testing.T) {
+	t.Parallel()
+
+	params := &chaincfg.MainNetParams
+	privKey, err := btcec.NewPrivateKey()
+	require.NoError(t, err)
+	pubKeyHash := hash160(privKey.PubKey().SerializeCompressed())
+	p2pkh, err := payToPubKeyHashScript(pubKeyHash)
+	require.NoError(t, err)
+	p2wpkh, err := payToWitnessPubKeyHashScript(pubKeyHash)
+	require.NoError(t, err)
+
+	tx := wire.NewMsgTx(2)
+	tx.AddTxIn(&wire.TxIn{
+		PreviousOutPoint: wire.OutPoint{Index: 1},
+		Sequence:         wire.MaxTxInSequenceNum,
+	})
+	tx.AddTxIn(&wire.TxIn{
+		PreviousOutPoint: wire.OutPoint{Index: 2},
+		Witness:          wire.TxWitness{{0x01}, {0x02, 0x03}},
+	})
+	tx.AddTxOut(wire.NewTxOut(150000000, p2wpkh))
+	tx.TxIn[0].SignatureScript, err = SignatureScript(tx, 0, p2pkh,
+		SigHashAll|SigHashAnyOneCanPay, privKey, true)
+	require.NoError(t, err)
+
+	prevOuts := NewMultiPrevOutFetcher(map[wire.OutPoint]*wire.TxOut{
+		{Index: 1}: wire.NewTxOut(200000000, p2pkh),
+	})
+	decoded := DecodeTxScripts(tx, prevOuts, params)
+	require.Equal(t, tx.TxHash().String(), decoded.Txid)
+	require.Equal(t, tx.WitnessHash().String(), decoded.Hash)
+	require.Equal(t, tx.SerializeSize(), decoded.Size)
+	require.Less(t, decoded.Vsize, decoded.Size)
+	require.Len(t, decoded.Vin, 2)
+
+	vin := decoded.Vin[0]
+	require.Equal(t, uint32(1), *vin.Vout)
+	require.Contains(t, vin.ScriptSig.Asm, "[ALL|ANYONECANPAY] ")
+	require.Equal(t, hex.EncodeToString(tx.TxIn[0].SignatureScript),
+		vin.ScriptSig.Hex)
+	require.Equal(t, 2.0, vin.Prevout.Value)
+	require.Equal(t, "pubkeyhash", vin.Prevout.ScriptPubKey.Type)
+	require.Equal(t, []string{"01", "0203"}, decoded.Vin[1].Witness)
+	require.Nil(t, decoded.Vin[1].Prevout)
+
+	vout := decoded.Vout[0]
+	require.Equal(t, 1.5, vout.Value)
+	require.Equal(t, "witness_v0_keyhash", vout.ScriptPubKey.Type)
+	require.Equal(t, hex.EncodeToString(p2wpkh), vout.ScriptPubKey.Hex)
+
+	encoded, err := json.Marshal(decoded)
+	require.NoError(t, err)
+	for _, field := range []string{
+		`"txid":`, `"vsize":`, `"scriptSig":`, `"txinwitness":`,
+		`"prevout":`, `"scriptPubKey":`, `"n":0`,
+	} {
+		require.Contains(t, string(encoded), field)
+	}
+
+	// 币基输入只包含币基脚本和序列号。
+	coinbase := wire.NewMsgTx(1)
+	coinbase.AddTxIn(&wire.TxIn{
+		PreviousOutPoint: wire.OutPoint{Index: wire.MaxPrevOutIndex},
+		SignatureScript:  []byte{0x51, 0x52},
+	})
+	coinbase.AddTxOut(wire.NewTxOut(5000000000, p2wpkh))
+	decoded = DecodeTxScripts(coinbase, nil, params)
+	require.Equal(t, "5152", decoded.Vin[0].Coinbase)
+	require.Nil(t, decoded.Vin[0].Vout)
+	require.Nil(t, decoded.Vin[0].ScriptSig)
+}
@@ -0,0 +1,454 @@
+// 包含软分叉部署到脚本验证标志的映射，使节点层可以按区块高度和版本位状态获取生效的标志，而不必手动组合位掩码。
+// 部署计划可以序列化为 JSON，用于私有链的创世配置。
+
+package txscript
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// ErrInvalidDeployment 在部署计划不一致或无法解析时返回。
+var ErrInvalidDeployment = errors.New("invalid deployment schedule")
+
+// 已知软分叉部署的名称。
+const (
+	// DeploymentP2SH 是 BIP0016 支付脚本哈希。
+	DeploymentP2SH = "p2sh"
+
+	// DeploymentCLTV 是 BIP0065 OP_CHECKLOCKTIMEVERIFY。
+	DeploymentCLTV = "cltv"
+
+	// DeploymentDERSig 是 BIP0066 严格 DER 签名。
+	DeploymentDERSig = "dersig"
+
+	// DeploymentCSV 是 BIP0112 OP_CHECKSEQUENCEVERIFY。
+	DeploymentCSV = "csv"
+
+	// DeploymentSegwit 是 BIP0141 隔离见证，包括 BIP0147 NULLDUMMY。
+	DeploymentSegwit = "segwit"
+
+	// DeploymentTaproot 是 BIP0341 和 BIP0342 taproot。
+	DeploymentTaproot = "taproot"
+
+	// DeploymentWitnessVersions 是 bpfschain 在共识层面拒绝花费未知见证版本输出的软分叉。
+	DeploymentWitnessVersions = "witnessversions"
+
+	// DeploymentScriptVersions 是 bpfschain 拒绝执行未注册脚本版本的软分叉。
+	DeploymentScriptVersions = "scriptversions"
+
+	// DeploymentStructuredAnnex 是 bpfschain 要求 taproot 附件为 TLV 记录流的软分叉。
+	DeploymentStructuredAnnex = "structuredannex"
+)
+
+const (
+	// DeploymentNoHeight 是只通过版本位激活的部署的 Height。
+	DeploymentNoHeight int32 = -1
+
+	// VersionBitsNumBits 是区块版本中可用于部署信号的位数。
+	VersionBitsNumBits = 29
+)
+
+// DeploymentState 是部署的版本位阈值状态，与 BIP0009 相同。
+type DeploymentState byte
+
+const (
+	// DeploymentDefined 是部署开始前的状态。
+	DeploymentDefined DeploymentState = iota
+
+	// DeploymentStarted 是矿工可以发出信号的状态。
+	DeploymentStarted
+
+	// DeploymentLockedIn 是部署已达到阈值、等待生效的状态。
+	DeploymentLockedIn
+
+	// DeploymentActive 是部署规则生效的状态。
+	DeploymentActive
+
+	// DeploymentFailed 是部署超时未达到阈值的状态。
+	DeploymentFailed
+)
+
+// deploymentStateStrings 是 DeploymentState 值到可读字符串的映射。
+var deploymentStateStrings = map[DeploymentState]string{
+	DeploymentDefined:  "defined",
+	DeploymentStarted:  "started",
+	DeploymentLockedIn: "lockedin",
+	DeploymentActive:   "active",
+	DeploymentFailed:   "failed",
+}
+
+// String 以人类可读的形式返回 DeploymentState。
+func (s DeploymentState) String() string {
+	if str, ok := deploymentStateStrings[s]; ok {
+		return str
+	}
+	return fmt.Sprintf("Unknown DeploymentState (%d)", byte(s))
+}
+
+// DeploymentStates 是节点层按部署名称计算出的版本位状态。 未出现的部署视为 DeploymentDefined。
+type DeploymentStates map[string]DeploymentState
+
+// Deployment 是启用一组脚本验证标志的软分叉。
+type Deployment struct {
+	// Name 是部署的唯一名称。
+	Name string
+
+	// Flags 是部署生效后启用的脚本验证标志。
+	Flags ScriptFlags
+
+	// Height 是部署生效的第一个区块高度，0 表示从创世区块起生效。 为 DeploymentNoHeight 时部署只在版本位状态为
+	// DeploymentActive 时生效。
+	Height int32
+
+	// Bit 是部署通过版本位激活时在区块版本中发出信号的位。 只有 Height 为 DeploymentNoHeight 时使用。
+	Bit uint8
+
+	// Time 不为 0 时，部署只对时间戳不早于 Time 的区块生效，与 BIP0016 按区块时间切换规则相同。 它只由 ActiveAt 检查。
+	Time int64
+}
+
+// Active 返回部署在高度 height 是否生效。 state 是部署的版本位状态，对指定了 Height 的部署没有影响。
+func (d *Deployment) Active(height int32, state DeploymentState) bool {
+	if d.Height != DeploymentNoHeight {
+		return height >= d.Height
+	}
+	return state == DeploymentActive
+}
+
+// ActiveAt 返回部署在高度为 height、时间戳为 blockTime 的区块中是否生效。 与 Active 不同，它同时检查 Time。
+func (d *Deployment) ActiveAt(height int32, blockTime int64,
+	state DeploymentState) bool {
+
+	if d.Time != 0 && blockTime < d.Time {
+		return false
+	}
+	return d.Active(height, state)
+}
+
+// jsonDeployment 是 Deployment 的 JSON 表示，标志使用参考测试中的标志名称。
+type jsonDeployment struct {
+	Name   string `json:"name"`
+	Flags  string `json:"flags"`
+	Height *int32 `json:"height,omitempty"`
+	Bit    *uint8 `json:"bit,omitempty"`
+	Time   int64  `json:"time,omitempty"`
+}
+
+// MarshalJSON 实现 json.Marshaler 接口。 标志编码为逗号分隔的标志名称，例如 "WITNESS,NULLDUMMY"，
+// 并根据部署的激活方式只编码 height 或 bit 之一。
+func (d Deployment) MarshalJSON() ([]byte, error) {
+	flags, err := FormatScriptFlags(d.Flags)
+	if err != nil {
+		return nil, fmt.Errorf("%w: deployment %q: %v",
+			ErrInvalidDeployment, d.Name, err)
+	}
+
+	jd := jsonDeployment{Name: d.Name, Flags: flags, Time: d.Time}
+	if d.Height != DeploymentNoHeight {
+		height := d.Height
+		jd.Height = &height
+	} else {
+		bit := d.Bit
+		jd.Bit = &bit
+	}
+	return json.Marshal(jd)
+}
+
+// UnmarshalJSON 实现 json.Unmarshaler 接口。 必须且只能指定 height 或 bit 之一。
+func (d *Deployment) UnmarshalJSON(data []byte) error {
+	var jd jsonDeployment
+	if err := json.Unmarshal(data, &jd); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidDeployment, err)
+	}
+	flags, err := ParseScriptFlags(jd.Flags)
+	if err != nil {
+		return fmt.Errorf("%w: deployment %q: %v", ErrInvalidDeployment,
+			jd.Name, err)
+	}
+
+	*d = Deployment{Name: jd.Name, Flags: flags, Time: jd.Time}
+	switch {
+	case jd.Height != nil && jd.Bit == nil:
+		d.Height = *jd.Height
+	case jd.Height == nil && jd.Bit != nil:
+		d.Height = DeploymentNoHeight
+		d.Bit = *jd.Bit
+	default:
+		return fmt.Errorf("%w: deployment %q must specify exactly one of "+
+			"height or bit", ErrInvalidDeployment, jd.Name)
+	}
+	return nil
+}
+
+// DeploymentSchedule 是链的软分叉部署计划。
+type DeploymentSchedule struct {
+	// BaseFlags 是不依赖任何部署、从创世区块起生效的标志。
+	BaseFlags ScriptFlags
+
+	// Deployments 是链的软分叉部署。
+	Deployments []Deployment
+}
+
+// DefaultDeploymentSchedule 返回私有链默认的部署计划：所有已知部署都从创世区块起生效。
+func DefaultDeploymentSchedule() *DeploymentSchedule {
+	return &DeploymentSchedule{
+		Deployments: []Deployment{
+			{Name: DeploymentP2SH, Flags: ScriptBip16},
+			{Name: DeploymentCLTV, Flags: ScriptVerifyCheckLockTimeVerify},
+			{Name: DeploymentDERSig, Flags: ScriptVerifyDERSignatures},
+			{Name: DeploymentCSV, Flags: ScriptVerifyCheckSequenceVerify},
+			{
+				Name:  DeploymentSegwit,
+				Flags: ScriptVerifyWitness | ScriptStrictMultiSig,
+			},
+			{Name: DeploymentTaproot, Flags: ScriptVerifyTaproot},
+			{
+				Name:  DeploymentWitnessVersions,
+				Flags: ScriptVerifyRejectUnknownWitnessVersion,
+			},
+			{
+				Name:  DeploymentScriptVersions,
+				Flags: ScriptVerifyRejectUnknownScriptVersion,
+			},
+			{
+				Name:  DeploymentStructuredAnnex,
+				Flags: ScriptVerifyStructuredAnnex,
+			},
+		},
+	}
+}
+
+// Validate 检查部署计划是否一致：部署名称非空且唯一，标志都是已知标志，高度和时间不为负，
+// 并且只通过版本位激活的部署使用不同的有效信号位。
+func (s *DeploymentSchedule) Validate() error {
+	if _, err := FormatScriptFlags(s.BaseFlags); err != nil {
+		return fmt.Errorf("%w: base flags: %v", ErrInvalidDeployment, err)
+	}
+
+	names := make(map[string]struct{}, len(s.Deployments))
+	bits := make(map[uint8]string)
+	for i := range s.Deployments {
+		d := &s.Deployments[i]
+		if d.Name == "" {
+			return fmt.Errorf("%w: deployment %d has no name",
+				ErrInvalidDeployment, i)
+		}
+		if _, ok := names[d.Name]; ok {
+			return fmt.Errorf("%w: duplicate deployment %q",
+				ErrInvalidDeployment, d.Name)
+		}
+		names[d.Name] = struct{}{}
+
+		if _, err := FormatScriptFlags(d.Flags); err != nil {
+			return fmt.Errorf("%w: deployment %q: %v",
+				ErrInvalidDeployment, d.Name, err)
+		}
+
+		switch {
+		case d.Height == DeploymentNoHeight:
+			if d.Bit >= VersionBitsNumBits {
+				return fmt.Errorf("%w: deployment %q bit %d out of "+
+					"range", ErrInvalidDeployment, d.Name, d.Bit)
+			}
+			if other, ok := bits[d.Bit]; ok {
+				return fmt.Errorf("%w: deployments %q and %q share "+
+					"bit %d", ErrInvalidDeployment, other, d.Name,
+					d.Bit)
+			}
+			bits[d.Bit] = d.Name
+
+		case d.Height < 0:
+			return fmt.Errorf("%w: deployment %q has negative height %d",
+				ErrInvalidDeployment, d.Name, d.Height)
+		}
+		if d.Time < 0 {
+			return fmt.Errorf("%w: deployment %q has negative time %d",
+				ErrInvalidDeployment, d.Name, d.Time)
+		}
+	}
+	return nil
+}
+
+// Lookup 返回名为 name 的部署。 没有该部署时返回 false。
+func (s *DeploymentSchedule) Lookup(name string) (*Deployment, bool) {
+	for i := range s.Deployments {
+		if s.Deployments[i].Name == name {
+			return &s.Deployments[i], true
+		}
+	}
+	return nil, false
+}
+
+// FlagsAt 返回验证高度 height 的区块中的交易时生效的共识脚本验证标志。 states 是只通过版本位激活的部署在该区块的状态，
+// 可以为 nil。 FlagsAt 不检查部署的 Time，重新验证历史区块时使用 FlagHistory.EvaluateAt。
+func (s *DeploymentSchedule) FlagsAt(height int32,
+	states DeploymentStates) ScriptFlags {
+
+	flags := s.BaseFlags
+	for i := range s.Deployments {
+		d := &s.Deployments[i]
+		if d.Active(height, states[d.Name]) {
+			flags |= d.Flags
+		}
+	}
+	return flags
+}
+
+// ActiveDeployments 返回在高度 height 生效的部署名称，顺序与 Deployments 相同。
+func (s *DeploymentSchedule) ActiveDeployments(height int32,
+	states DeploymentStates) []string {
+
+	var active []string
+	for i := range s.Deployments {
+		d := &s.Deployments[i]
+		if d.Active(height, states[d.Name]) {
+			active = append(active, d.Name)
+		}
+	}
+	return active
+}
+
+// jsonDeploymentSchedule 是 DeploymentSchedule 的 JSON 表示。
+type jsonDeploymentSchedule struct {
+	BaseFlags   string       `json:"base_flags"`
+	Deployments []Deployment `json:"deployments"`
+}
+
+// MarshalJSON 实现 json.Marshaler 接口。 只有通过 Validate 检查的部署计划才能序列化。
+func (s DeploymentSchedule) MarshalJSON() ([]byte, error) {
+	if err := s.Validate(); err != nil {
+		return nil, err
+	}
+	baseFlags, err := FormatScriptFlags(s.BaseFlags)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(jsonDeploymentSchedule{
+		BaseFlags:   baseFlags,
+		Deployments: s.Deployments,
+	})
+}
+
+// UnmarshalJSON 实现 json.Unmarshaler 接口。 解析后的部署计划必须通过 Validate 检查。
+func (s *DeploymentSchedule) UnmarshalJSON(data []byte) error {
+	var js jsonDeploymentSchedule
+	if err := json.Unmarshal(data, &js); err != nil {
+		if errors.Is(err, ErrInvalidDeployment) {
+			return err
+		}
+		return fmt.Errorf("%w: %v", ErrInvalidDeployment, err)
+	}
+	baseFlags, err := ParseScriptFlags(js.BaseFlags)
+	if err != nil {
+		return fmt.Errorf("%w: base flags: %v", ErrInvalidDeployment, err)
+	}
+
+	schedule := DeploymentSchedule{
+		BaseFlags:   baseFlags,
+		Deployments: js.Deployments,
+	}
+	if err := schedule.Validate(); err != nil {
+		return err
+	}
+	*s = schedule
+	return nil
+}
+
+// FlagHistory 记录部署计划中只通过版本位激活的部署实际生效的高度，使节点在重组后重新验证历史区块时，
+// 可以得到每个高度当时生效的标志，而不必自己维护标志的历史。
+//
+// 部署生效之前，它的标志在历史区块中不生效。 例如 taproot 生效之前，见证版本 1 的 32 字节程序在共识层面被视为任何人都可以花费，
+// 因此重新验证这些区块时不能使用当前的标志。 FlagHistory 是并发安全的。
+type FlagHistory struct {
+	schedule *DeploymentSchedule
+
+	mtx         sync.RWMutex
+	activations map[string]int32
+}
+
+// NewFlagHistory 返回部署计划 schedule 的标志历史，部署计划必须通过 Validate 检查。 调用者此后不得修改 schedule。
+func NewFlagHistory(schedule *DeploymentSchedule) (*FlagHistory, error) {
+	if err := schedule.Validate(); err != nil {
+		return nil, err
+	}
+	return &FlagHistory{
+		schedule:    schedule,
+		activations: make(map[string]int32),
+	}, nil
+}
+
+// RecordActivation 记录只通过版本位激活的部署 name 从高度 height 起生效，节点应在部署的版本位状态变为
+// DeploymentActive 时调用它。 重复记录相同的高度没有影响。 部署不存在、不是通过版本位激活，或已记录了不同的高度时返回错误，
+// 重组改变激活高度时应先调用 Rewind。
+func (h *FlagHistory) RecordActivation(name string, height int32) error {
+	d, ok := h.schedule.Lookup(name)
+	if !ok {
+		return fmt.Errorf("%w: unknown deployment %q",
+			ErrInvalidDeployment, name)
+	}
+	if d.Height != DeploymentNoHeight {
+		return fmt.Errorf("%w: deployment %q activates at fixed height %d",
+			ErrInvalidDeployment, name, d.Height)
+	}
+	if height < 0 {
+		return fmt.Errorf("%w: deployment %q activation at negative "+
+			"height %d", ErrInvalidDeployment, name, height)
+	}
+
+	h.mtx.Lock()
+	defer h.mtx.Unlock()
+	if existing, ok := h.activations[name]; ok && existing != height {
+		return fmt.Errorf("%w: deployment %q already active at height %d",
+			ErrInvalidDeployment, name, existing)
+	}
+	h.activations[name] = height
+	return nil
+}
+
+// Rewind 忘记在高度 height 之后生效的激活记录。 重组断开区块后，以分叉点的高度调用它，
+// 新分支上的激活随后通过 RecordActivation 重新记录。
+func (h *FlagHistory) Rewind(height int32) {
+	h.mtx.Lock()
+	defer h.mtx.Unlock()
+	for name, activation := range h.activations {
+		if activation > height {
+			delete(h.activations, name)
+		}
+	}
+}
+
+// ActivationHeight 返回只通过版本位激活的部署 name 记录的生效高度，没有记录时返回 false。
+func (h *FlagHistory) ActivationHeight(name string) (int32, bool) {
+	h.mtx.RLock()
+	defer h.mtx.RUnlock()
+	height, ok := h.activations[name]
+	return height, ok
+}
+
+// EvaluateAt 返回验证高度为 height、时间戳为 blockTime 的区块中的交易时生效的共识脚本验证标志。
+// 指定了 Height 的部署按高度判断，只通过版本位激活的部署按记录的激活高度判断，指定了 Time 的部署还要求区块时间不早于 Time。
+func (h *FlagHistory) EvaluateAt(height int32, blockTime int64) ScriptFlags {
+	h.mtx.RLock()
+	defer h.mtx.RUnlock()
+
+	flags := h.schedule.BaseFlags
+	for i := range h.schedule.Deployments {
+		d := &h.schedule.Deployments[i]
+		if d.ActiveAt(height, blockTime, h.stateAt(d, height)) {
+			flags |= d.Flags
+		}
+	}
+	return flags
+}
+
+// stateAt 返回部署在高度 height 的版本位状态，只区分已生效和未生效。 调用者必须持有读锁。
+func (h *FlagHistory) stateAt(d *Deployment, height int32) DeploymentState {
+	activation, ok := h.activations[d.Name]
+	if ok && height >= activation {
+		return DeploymentActive
+	}
+	return DeploymentDefined
+}
@@ -0,0 +1,256 @@
+package txscript
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/btcsuite/btcd/wire"
+	"github.com/stretchr/testify/require"
+)
+
+// TestDeploymentScheduleFlags 确保部署计划按高度和版本位状态返回生效的标志。
+func TestDeploymentScheduleFlags(t *testing.T) {
+	t.Parallel()
+
+	schedule := &DeploymentSchedule{
+		BaseFlags: ScriptBip16,
+		Deployments: []Deployment{
+			{Name: DeploymentCSV, Flags: ScriptVerifyCheckSequenceVerify,
+				Height: 100},
+			{Name: DeploymentSegwit,
+				Flags:  ScriptVerifyWitness | ScriptStrictMultiSig,
+				Height: 200},
+			{Name: DeploymentTaproot, Flags: ScriptVerifyTaproot,
+				Height: DeploymentNoHeight, Bit: 2},
+		},
+	}
+	require.NoError(t, schedule.Validate())
+
+	require.Equal(t, ScriptBip16, schedule.FlagsAt(99, nil))
+	require.Equal(t, ScriptBip16|ScriptVerifyCheckSequenceVerify,
+		schedule.FlagsAt(100, nil))
+	require.Equal(t, ScriptBip16|ScriptVerifyCheckSequenceVerify|
+		ScriptVerifyWitness|ScriptStrictMultiSig,
+		schedule.FlagsAt(200, nil))
+
+	// 版本位状态只影响没有指定高度的部署。
+	states := DeploymentStates{
+		DeploymentCSV:     DeploymentFailed,
+		DeploymentTaproot: DeploymentLockedIn,
+	}
+	require.Equal(t, []string{DeploymentCSV},
+		schedule.ActiveDeployments(150, states))
+	states[DeploymentTaproot] = DeploymentActive
+	require.Equal(t, []string{DeploymentCSV, DeploymentTaproot},
+		schedule.ActiveDeployments(150, states))
+	require.Equal(t, ScriptBip16|ScriptVerifyCheckSequenceVerify|
+		ScriptVerifyTaproot, schedule.FlagsAt(150, states))
+
+	d, ok := schedule.Lookup(DeploymentTaproot)
+	require.True(t, ok)
+	require.Equal(t, uint8(2), d.Bit)
+	_, ok = schedule.Lookup(DeploymentStructuredAnnex)
+	require.False(t, ok)
+
+	// 默认计划从创世区块起启用所有共识标志。
+	defaults := DefaultDeploymentSchedule()
+	require.NoError(t, defaults.Validate())
+	flags := defaults.FlagsAt(0, nil)
+	for _, flag := range []ScriptFlags{
+		ScriptBip16, ScriptVerifyCheckLockTimeVerify,
+		ScriptVerifyDERSignatures, ScriptVerifyCheckSequenceVerify,
+		ScriptVerifyWitness, ScriptStrictMultiSig, ScriptVerifyTaproot,
+		ScriptVerifyRejectUnknownWitnessVersion,
+		ScriptVerifyRejectUnknownScriptVersion,
+		ScriptVerifyStructuredAnnex,
+	} {
+		require.NotZero(t, flags&flag, "missing flag 0x%x", uint32(flag))
+	}
+	require.Zero(t, flags&ScriptDiscourageUpgradableNops)
+
+	require.Equal(t, "lockedin", DeploymentLockedIn.String())
+	require.Equal(t, "Unknown DeploymentState (9)",
+		DeploymentState(9).String())
+}
+
+// TestDeploymentScheduleValidate 确保不一致的部署计划被拒绝。
+func TestDeploymentScheduleValidate(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		deployments []Deployment
+	}{{
+		name:        "missing name",
+		deployments: []Deployment{{Flags: ScriptBip16}},
+	}, {
+		name: "duplicate name",
+		deployments: []Deployment{
+			{Name: "a", Flags: ScriptBip16},
+			{Name: "a", Flags: ScriptVerifyWitness},
+		},
+	}, {
+		name: "unknown flag",
+		deployments: []Deployment{
+			{Name: "a", Flags: 1 << 31},
+		},
+	}, {
+		name: "negative height",
+		deployments: []Deployment{
+			{Name: "a", Flags: ScriptBip16, Height: -2},
+		},
+	}, {
+		name: "bit out of range",
+		deployments: []Deployment{
+			{Name: "a", Flags: ScriptBip16, Height: DeploymentNoHeight,
+				Bit: VersionBitsNumBits},
+		},
+	}, {
+		name: "shared bit",
+		deployments: []Deployment{
+			{Name: "a", Flags: ScriptBip16, Height: DeploymentNoHeight,
+				Bit: 1},
+			{Name: "b", Flags: ScriptBip16, Height: DeploymentNoHeight,
+				Bit: 1},
+		},
+	}}
+	for _, test := range tests {
+		schedule := DeploymentSchedule{Deployments: test.deployments}
+		require.ErrorIs(t, schedule.Validate(), ErrInvalidDeployment,
+			test.name)
+		_, err := json.Marshal(schedule)
+		require.ErrorIs(t, err, ErrInvalidDeployment, test.name)
+	}
+
+	// 指定了高度的部署可以共享信号位，因为信号位不被使用。
+	schedule := DeploymentSchedule{Deployments: []Deployment{
+		{Name: "a", Flags: ScriptBip16, Bit: 1},
+		{Name: "b", Flags: ScriptBip16, Bit: 1},
+	}}
+	require.NoError(t, schedule.Validate())
+}
+
+// TestDeploymentScheduleJSON 确保部署计划可以序列化为创世配置并解析回来。
+func TestDeploymentScheduleJSON(t *testing.T) {
+	t.Parallel()
+
+	schedule := DefaultDeploymentSchedule()
+	schedule.BaseFlags = ScriptVerifyLowS
+	schedule.Deployments = append(schedule.Deployments, Deployment{
+		Name:   "future",
+		Flags:  ScriptVerifyMinimalIf | ScriptVerifyNullFail,
+		Height: DeploymentNoHeight,
+		Bit:    0,
+	})
+	schedule.Deployments[3].Height = 1000
+
+	data, err := json.Marshal(schedule)
+	require.NoError(t, err)
+
+	var decoded DeploymentSchedule
+	require.NoError(t, json.Unmarshal(data, &decoded))
+	require.Equal(t, *schedule, decoded)
+
+	const genesisConfig = `{
+		"base_flags": "NONE",
+		"deployments": [
+			{"name": "segwit", "flags": "WITNESS,NULLDUMMY", "height": 0},
+			{"name": "taproot", "flags": "TAPROOT", "bit": 2}
+		]
+	}`
+	require.NoError(t, json.Unmarshal([]byte(genesisConfig), &decoded))
+	require.Equal(t, DeploymentSchedule{
+		Deployments: []Deployment{
+			{Name: DeploymentSegwit,
+				Flags: ScriptVerifyWitness | ScriptStrictMultiSig},
+			{Name: DeploymentTaproot, Flags: ScriptVerifyTaproot,
+				Height: DeploymentNoHeight, Bit: 2},
+		},
+	}, decoded)
+
+	invalid := []string{
+		`{"base_flags": "BOGUS", "deployments": []}`,
+		`{"deployments": [{"name": "a", "flags": "BOGUS", "height": 0}]}`,
+		`{"deployments": [{"name": "a", "flags": "P2SH"}]}`,
+		`{"deployments": [{"name": "a", "flags": "P2SH", "height": 0,
+			"bit": 1}]}`,
+		`{"deployments": [{"name": "a", "flags": "P2SH", "bit": 40}]}`,
+		`{"deployments": [{"name": "a", "flags": "P2SH", "height": 0},
+			{"name": "a", "flags": "TAPROOT", "height": 0}]}`,
+		`{"deployments": 1}`,
+	}
+	for _, config := range invalid {
+		err := json.Unmarshal([]byte(config), &decoded)
+		require.ErrorIs(t, err, ErrInvalidDeployment, config)
+	}
+}
+
+// TestFlagHistoryEvaluateAt 确保历史标志按高度、区块时间和记录的激活高度计算，重组后可以回退激活记录，
+// 并且 taproot 生效之前见证版本 1 的输出被视为任何人都可以花费。
+func TestFlagHistoryEvaluateAt(t *testing.T) {
+	t.Parallel()
+
+	const p2shTime = 1333238400
+	schedule := &DeploymentSchedule{
+		Deployments: []Deployment{
+			{Name: DeploymentP2SH, Flags: ScriptBip16, Time: p2shTime},
+			{Name: DeploymentSegwit,
+				Flags:  ScriptVerifyWitness | ScriptStrictMultiSig,
+				Height: 100},
+			{Name: DeploymentTaproot, Flags: ScriptVerifyTaproot,
+				Height: DeploymentNoHeight, Bit: 2},
+		},
+	}
+	history, err := NewFlagHistory(schedule)
+	require.NoError(t, err)
+
+	require.Equal(t, ScriptFlags(0), history.EvaluateAt(99, p2shTime-1))
+	require.Equal(t, ScriptBip16, history.EvaluateAt(99, p2shTime))
+	segwitFlags := ScriptBip16 | ScriptVerifyWitness | ScriptStrictMultiSig
+	require.Equal(t, segwitFlags&^ScriptBip16,
+		history.EvaluateAt(150, p2shTime-1))
+	require.Equal(t, segwitFlags, history.EvaluateAt(150, p2shTime))
+
+	// 只有版本位部署的激活高度可以被记录。
+	require.Error(t, history.RecordActivation(DeploymentSegwit, 10))
+	require.Error(t, history.RecordActivation("unknown", 10))
+	require.NoError(t, history.RecordActivation(DeploymentTaproot, 200))
+	require.NoError(t, history.RecordActivation(DeploymentTaproot, 200))
+	require.Error(t, history.RecordActivation(DeploymentTaproot, 300))
+	require.Equal(t, segwitFlags, history.EvaluateAt(199, p2shTime))
+	require.Equal(t, segwitFlags|ScriptVerifyTaproot,
+		history.EvaluateAt(200, p2shTime))
+
+	// taproot 生效之前，花费见证版本 1 输出的任意见证都有效。
+	p2tr := append([]byte{OP_1, OP_DATA_32}, bytes.Repeat([]byte{0x01}, 32)...)
+	tx := createSpendingTx(wire.TxWitness{{0x01}}, nil, p2tr, 1000)
+	fetcher := NewCannedPrevOutputFetcher(p2tr, 1000)
+	execute := func(flags ScriptFlags) error {
+		vm, err := NewEngine(p2tr, tx, 0, flags, nil,
+			NewTxSigHashes(tx, fetcher), 1000, fetcher)
+		require.NoError(t, err)
+		return vm.Execute()
+	}
+	require.NoError(t, execute(history.EvaluateAt(199, p2shTime)))
+	require.Error(t, execute(history.EvaluateAt(200, p2shTime)))
+
+	// 重组回退到激活之前后，可以在新分支上记录不同的激活高度。
+	history.Rewind(199)
+	_, ok := history.ActivationHeight(DeploymentTaproot)
+	require.False(t, ok)
+	require.Equal(t, segwitFlags, history.EvaluateAt(250, p2shTime))
+	require.NoError(t, history.RecordActivation(DeploymentTaproot, 300))
+	height, ok := history.ActivationHeight(DeploymentTaproot)
+	require.True(t, ok)
+	require.Equal(t, int32(300), height)
+
+	// 部署时间可以序列化，负时间无效。
+	data, err := json.Marshal(schedule)
+	require.NoError(t, err)
+	var decoded DeploymentSchedule
+	require.NoError(t, json.Unmarshal(data, &decoded))
+	require.Equal(t, *schedule, decoded)
+	schedule.Deployments[0].Time = -1
+	require.ErrorIs(t, schedule.Validate(), ErrInvalidDeployment)
+}
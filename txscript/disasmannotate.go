@@ -0,0 +1,203 @@
+// 包含带注释的脚本反汇编，使用 standard.go 中的提取函数识别标准模板，在反汇编中标注公钥、哈希和地址，
+// 并说明 CHECKLOCKTIMEVERIFY 和 CHECKSEQUENCEVERIFY 的锁定时间含义，便于人工审阅脚本。
+
+package txscript
+
+import (
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/wire"
+)
+
+// DisasmStringAnnotated 与 DisasmString 一样反汇编脚本，但会标注识别出的结构：
+//
+//   - 标准模板在第一行以注释给出，例如 "# P2PKH template"，开头带有锁定时间前缀的标准模板标注为
+//     "# timelocked P2PKH template"。
+//   - 模板中的公钥、哈希和见证程序以 "<pubkey hash: 1A1zP1…>" 的形式显示，chainParams 不为 nil 时
+//     哈希和见证程序显示为对应网络的地址，否则显示为十六进制。
+//   - CHECKLOCKTIMEVERIFY 和 CHECKSEQUENCEVERIFY 之后附加锁定时间的解释并换行，例如
+//     "# CLTV locktime=650000 (block height)"。
+//
+// 脚本解析失败时的行为与 DisasmString 相同。
+//
+// 注意：该函数仅对0版本脚本有效。
+func DisasmStringAnnotated(script []byte, chainParams *chaincfg.Params) (string, error) {
+	const scriptVersion = 0
+
+	var disbuf strings.Builder
+	header, labels := templateLabels(script, chainParams)
+	if header != "" {
+		disbuf.WriteString("# ")
+		disbuf.WriteString(header)
+		disbuf.WriteString(" template\n")
+	}
+
+	lineStart := true
+	var prevOp *opcode
+	var prevData []byte
+	tokenizer := MakeScriptTokenizer(scriptVersion, script)
+	for {
+		offset := tokenizer.ByteIndex()
+		if !tokenizer.Next() {
+			break
+		}
+		if !lineStart {
+			disbuf.WriteByte(' ')
+		}
+		lineStart = false
+
+		op, data := tokenizer.op, tokenizer.Data()
+		if label, ok := labels[offset]; ok {
+			disbuf.WriteString(label)
+		} else {
+			disasmOpcode(&disbuf, op, data, true)
+		}
+
+		if note := lockTimeNote(prevOp, prevData, op.value); note != "" {
+			disbuf.WriteString(" # ")
+			disbuf.WriteString(note)
+			disbuf.WriteByte('\n')
+			lineStart = true
+		}
+		prevOp, prevData = op, data
+	}
+	if tokenizer.Err() != nil {
+		if !lineStart {
+			disbuf.WriteByte(' ')
+		}
+		disbuf.WriteString("[error]")
+	}
+	return strings.TrimSuffix(disbuf.String(), "\n"), tokenizer.Err()
+}
+
+// templateLabels 识别脚本的标准模板，返回模板名称以及按数据推送在脚本中的偏移索引的标注。
+// 脚本不是标准模板时，会尝试去掉锁定时间前缀后再识别。
+func templateLabels(script []byte, chainParams *chaincfg.Params) (string, map[int32]string) {
+	prefix := ""
+	body := script
+	class := GetScriptClass(body)
+	if class == NonStandardTy {
+		body = stripTimeLockPrefix(script)
+		if len(body) == len(script) {
+			return "", nil
+		}
+		class = GetScriptClass(body)
+		if class == NonStandardTy {
+			return "", nil
+		}
+		prefix = "timelocked "
+	}
+
+	var name string
+	var kinds []string
+	switch class {
+	case PubKeyTy:
+		name, kinds = "P2PK", []string{"pubkey"}
+	case PubKeyHashTy:
+		name, kinds = "P2PKH", []string{"pubkey hash"}
+	case ScriptHashTy:
+		name, kinds = "P2SH", []string{"script hash"}
+	case WitnessV0PubKeyHashTy:
+		name, kinds = "P2WPKH", []string{"witness pubkey hash"}
+	case WitnessV0ScriptHashTy:
+		name, kinds = "P2WSH", []string{"witness script hash"}
+	case WitnessV1TaprootTy:
+		name, kinds = "P2TR", []string{"taproot output key"}
+	case WitnessUnknownTy:
+		version, _, _ := ExtractWitnessProgramInfo(body)
+		name = fmt.Sprintf("witness v%d program", version)
+		kinds = []string{"witness program"}
+	case MultiSigTy:
+		const scriptVersion = 0
+		details := extractMultisigScriptDetails(scriptVersion, body, false)
+		name = fmt.Sprintf("%d-of-%d multisig", details.requiredSigs,
+			details.numPubKeys)
+		kinds = make([]string, details.numPubKeys)
+		for i := range kinds {
+			kinds[i] = "pubkey"
+		}
+	case NullDataTy:
+		return prefix + "OP_RETURN data", nil
+	default:
+		return prefix + class.String(), nil
+	}
+
+	// Hashes and witness programs are shown as addresses when the
+	// network is known, keys are always shown in hex.
+	var addrs []string
+	if chainParams != nil && class != PubKeyTy && class != MultiSigTy {
+		_, addresses, _, err := ExtractPkScriptAddrs(body, chainParams)
+		if err == nil && len(addresses) == 1 {
+			addrs = []string{addresses[0].EncodeAddress()}
+		}
+	}
+
+	const scriptVersion = 0
+	labels := make(map[int32]string, len(kinds))
+	base := int32(len(script) - len(body))
+	tokenizer := MakeScriptTokenizer(scriptVersion, body)
+	for i := 0; i < len(kinds); {
+		offset := tokenizer.ByteIndex()
+		if !tokenizer.Next() {
+			break
+		}
+		data := tokenizer.Data()
+		if tokenizer.Opcode() > OP_PUSHDATA4 || len(data) == 0 {
+			continue
+		}
+		value := hex.EncodeToString(data)
+		if i < len(addrs) {
+			value = addrs[i]
+		}
+		labels[base+offset] = fmt.Sprintf("<%s: %s>", kinds[i], value)
+		i++
+	}
+	return prefix + name, labels
+}
+
+// lockTimeNote 在 CHECKLOCKTIMEVERIFY 或 CHECKSEQUENCEVERIFY 紧跟在数字推送之后时返回对该数字的解释，否则返回空字符串。
+func lockTimeNote(prevOp *opcode, prevData []byte, op byte) string {
+	if prevOp == nil || (op != OP_CHECKLOCKTIMEVERIFY &&
+		op != OP_CHECKSEQUENCEVERIFY) {
+
+		return ""
+	}
+
+	var n int64
+	switch {
+	case IsSmallInt(prevOp.value):
+		n = int64(AsSmallInt(prevOp.value))
+	case prevOp.value <= OP_PUSHDATA4:
+		var err error
+		n, err = DecodeLockTimeNum(prevData, false)
+		if err != nil {
+			return ""
+		}
+	default:
+		return ""
+	}
+
+	if op == OP_CHECKLOCKTIMEVERIFY {
+		if n < LockTimeThreshold {
+			return fmt.Sprintf("CLTV locktime=%d (block height)", n)
+		}
+		return fmt.Sprintf("CLTV locktime=%d (%s)", n,
+			time.Unix(n, 0).UTC().Format(time.RFC3339))
+	}
+
+	switch {
+	case n&wire.SequenceLockTimeDisabled != 0:
+		return fmt.Sprintf("CSV sequence=%d (disabled)", n)
+	case n&wire.SequenceLockTimeIsSeconds != 0:
+		seconds := (n & wire.SequenceLockTimeMask) <<
+			wire.SequenceLockTimeGranularity
+		return fmt.Sprintf("CSV sequence=%d (%d seconds)", n, seconds)
+	default:
+		return fmt.Sprintf("CSV sequence=%d (%d blocks)", n,
+			n&wire.SequenceLockTimeMask)
+	}
+}
@@ -0,0 +1,83 @@
+package txscript
+
+import (
+	"testing"
+
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/stretchr/testify/require"
+)
+
+// TestDisasmStringAnnotated 确保标准模板、模板中的数据推送以及锁定时间被正确标注。
+func TestDisasmStringAnnotated(t *testing.T) {
+	t.Parallel()
+
+	pubKey := "02192d74d0cb94344c9569c2e77901573d8d7903c3ebec3a957724895dca52c6b4"
+	tests := []struct {
+		name   string
+		script string
+		params *chaincfg.Params
+		want   string
+	}{{
+		name: "p2pkh with address",
+		script: "DUP HASH160 DATA_20 0x62e907b15cbf27d5425399ebf6f0fb50ebb88f18 " +
+			"EQUALVERIFY CHECKSIG",
+		params: &chaincfg.MainNetParams,
+		want: "# P2PKH template\nOP_DUP OP_HASH160 " +
+			"<pubkey hash: 1A1zP1eP5QGefi2DMPTfTL5SLmv7DivfNa> " +
+			"OP_EQUALVERIFY OP_CHECKSIG",
+	}, {
+		name: "p2pkh without params",
+		script: "DUP HASH160 DATA_20 0x62e907b15cbf27d5425399ebf6f0fb50ebb88f18 " +
+			"EQUALVERIFY CHECKSIG",
+		want: "# P2PKH template\nOP_DUP OP_HASH160 " +
+			"<pubkey hash: 62e907b15cbf27d5425399ebf6f0fb50ebb88f18> " +
+			"OP_EQUALVERIFY OP_CHECKSIG",
+	}, {
+		name:   "multisig",
+		script: "1 DATA_33 0x" + pubKey + " 1 CHECKMULTISIG",
+		want: "# 1-of-1 multisig template\n1 <pubkey: " + pubKey +
+			"> 1 OP_CHECKMULTISIG",
+	}, {
+		name: "timelocked p2pk",
+		script: "DATA_3 0x10eb09 CHECKLOCKTIMEVERIFY DROP DATA_33 0x" +
+			pubKey + " CHECKSIG",
+		want: "# timelocked P2PK template\n10eb09 OP_CHECKLOCKTIMEVERIFY " +
+			"# CLTV locktime=650000 (block height)\nOP_DROP <pubkey: " +
+			pubKey + "> OP_CHECKSIG",
+	}, {
+		name:   "cltv unix time",
+		script: "DATA_4 0x0065cd1d CHECKLOCKTIMEVERIFY",
+		want: "0065cd1d OP_CHECKLOCKTIMEVERIFY # CLTV locktime=500000000 " +
+			"(1985-11-05T00:53:20Z)",
+	}, {
+		name:   "csv blocks",
+		script: "DATA_2 0x9000 CHECKSEQUENCEVERIFY",
+		want:   "9000 OP_CHECKSEQUENCEVERIFY # CSV sequence=144 (144 blocks)",
+	}, {
+		name:   "csv small int",
+		script: "16 CHECKSEQUENCEVERIFY DROP 1",
+		want: "16 OP_CHECKSEQUENCEVERIFY # CSV sequence=16 (16 blocks)\n" +
+			"OP_DROP 1",
+	}, {
+		name:   "csv seconds",
+		script: "DATA_3 0x020040 CHECKSEQUENCEVERIFY",
+		want: "020040 OP_CHECKSEQUENCEVERIFY # CSV sequence=4194306 " +
+			"(1024 seconds)",
+	}, {
+		name:   "null data",
+		script: "RETURN DATA_2 0x0102",
+		want:   "# OP_RETURN data template\nOP_RETURN 0102",
+	}}
+
+	for _, test := range tests {
+		script := mustParseShortForm(test.script)
+		got, err := DisasmStringAnnotated(script, test.params)
+		require.NoError(t, err, test.name)
+		require.Equal(t, test.want, got, test.name)
+	}
+
+	// A parse failure keeps the disassembly up to the failure.
+	got, err := DisasmStringAnnotated(mustParseShortForm("1 DATA_2 0x01"), nil)
+	require.Error(t, err)
+	require.Equal(t, "1 [error]", got)
+}
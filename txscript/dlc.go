@@ -0,0 +1,348 @@
+// 包含构建、识别和花费离散对数合约（DLC）tapscript 树的函数。 每个预言机结果对应一个叶子，叶子中的公钥是收款方公钥
+// 加上预言机对该结果的签名点，只有预言机公布该结果的签名后收款方才能花费；另有一个锁定时间到期后的退款叶子。
+
+package txscript
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcec/v2/schnorr"
+	"github.com/btcsuite/btcd/wire"
+)
+
+// ErrInvalidDLC 在 DLC 参数、叶子或预言机签名不符合要求时返回。
+var ErrInvalidDLC = errors.New("invalid dlc")
+
+// DLCLeafType 表示 DLC tapscript 叶子的花费路径。
+type DLCLeafType uint8
+
+const (
+	// DLCLeafUnknown 表示脚本不是 DLC 叶子。
+	DLCLeafUnknown DLCLeafType = iota
+
+	// DLCLeafOutcome 表示预言机公布结果后由收款方花费的结果叶子。
+	DLCLeafOutcome
+
+	// DLCLeafRefund 表示锁定时间到期后的退款叶子。
+	DLCLeafRefund
+)
+
+// String 返回叶子类型的可读名称。
+func (t DLCLeafType) String() string {
+	switch t {
+	case DLCLeafOutcome:
+		return "outcome"
+	case DLCLeafRefund:
+		return "refund"
+	default:
+		return "unknown"
+	}
+}
+
+// DLCOutcome 是合约的一个可能结果。
+type DLCOutcome struct {
+	// Message 是预言机对该结果签名的消息，预言机签名的是它的 SHA256 哈希。
+	Message []byte
+
+	// PayoutKey 是该结果的收款方公钥。
+	PayoutKey *btcec.PublicKey
+}
+
+// DLCContract 描述一个由单个预言机裁决的合约。
+type DLCContract struct {
+	// OracleKey 是预言机的 BIP0340 公钥。
+	OracleKey *btcec.PublicKey
+
+	// OracleNonce 是预言机事先公布的本次事件的 nonce 点 R，预言机必须使用它对结果签名。
+	OracleNonce *btcec.PublicKey
+
+	// Outcomes 是所有可能的结果，每个结果对应一个叶子。
+	Outcomes []DLCOutcome
+
+	// RefundKey 是退款叶子的公钥，通常是双方的聚合公钥。
+	RefundKey *btcec.PublicKey
+
+	// RefundLockTime 是退款叶子的绝对锁定时间，应当晚于预言机预期公布结果的时间。
+	RefundLockTime int64
+}
+
+// OracleAttestationPoint 返回预言机使用公钥 oracleKey 和 nonce 点 nonce 对结果 message 签名时签名标量对应的点
+// S = R + e*P，其中 e 是 BIP0340 挑战。 预言机公布签名 (R, s) 后，s 就是 S 的离散对数，因此 S 可以作为适配器点或公钥调整使用。
+func OracleAttestationPoint(oracleKey, nonce *btcec.PublicKey,
+	message []byte) (*btcec.PublicKey, error) {
+
+	// BIP0340 keys and nonces always have an even y coordinate.
+	pBytes := schnorr.SerializePubKey(oracleKey)
+	evenKey, err := schnorr.ParsePubKey(pBytes)
+	if err != nil {
+		return nil, fmt.Errorf("%w: oracle key: %v", ErrInvalidDLC, err)
+	}
+	evenNonce, err := schnorr.ParsePubKey(schnorr.SerializePubKey(nonce))
+	if err != nil {
+		return nil, fmt.Errorf("%w: oracle nonce: %v", ErrInvalidDLC, err)
+	}
+
+	hash := sha256.Sum256(message)
+	e := adaptorChallenge(evenNonce, pBytes, hash[:])
+
+	var r, p, eP, s btcec.JacobianPoint
+	evenNonce.AsJacobian(&r)
+	evenKey.AsJacobian(&p)
+	btcec.ScalarMultNonConst(&e, &p, &eP)
+	btcec.AddNonConst(&r, &eP, &s)
+	if (s.X.IsZero() && s.Y.IsZero()) || s.Z.IsZero() {
+		return nil, fmt.Errorf("%w: attestation point is infinity",
+			ErrInvalidDLC)
+	}
+	s.ToAffine()
+
+	return btcec.NewPublicKey(&s.X, &s.Y), nil
+}
+
+// DLCOutcomeKey 返回结果叶子使用的公钥，即收款方公钥与预言机对该结果的签名点之和。
+func DLCOutcomeKey(payoutKey, oracleKey, nonce *btcec.PublicKey,
+	message []byte) (*btcec.PublicKey, error) {
+
+	attestation, err := OracleAttestationPoint(oracleKey, nonce, message)
+	if err != nil {
+		return nil, err
+	}
+
+	var p, s, sum btcec.JacobianPoint
+	payoutKey.AsJacobian(&p)
+	attestation.AsJacobian(&s)
+	btcec.AddNonConst(&p, &s, &sum)
+	if (sum.X.IsZero() && sum.Y.IsZero()) || sum.Z.IsZero() {
+		return nil, fmt.Errorf("%w: outcome key is infinity",
+			ErrInvalidDLC)
+	}
+	sum.ToAffine()
+
+	return btcec.NewPublicKey(&sum.X, &sum.Y), nil
+}
+
+// DLCOutcomePrivKey 在预言机公布结果 message 的签名 attestation 后返回结果叶子公钥的私钥，
+// 即收款方私钥 payoutKey 与签名标量之和。 签名不是预言机使用约定的 nonce 对该结果的有效签名时返回错误。
+func DLCOutcomePrivKey(payoutKey *btcec.PrivateKey, oracleKey,
+	nonce *btcec.PublicKey, message []byte,
+	attestation *schnorr.Signature) (*btcec.PrivateKey, error) {
+
+	sigBytes := attestation.Serialize()
+	if !bytes.Equal(sigBytes[:32], schnorr.SerializePubKey(nonce)) {
+		return nil, fmt.Errorf("%w: attestation does not use the "+
+			"announced nonce", ErrInvalidDLC)
+	}
+	point, err := OracleAttestationPoint(oracleKey, nonce, message)
+	if err != nil {
+		return nil, err
+	}
+
+	var s btcec.ModNScalar
+	if overflow := s.SetByteSlice(sigBytes[32:]); overflow {
+		return nil, fmt.Errorf("%w: attestation scalar exceeds group "+
+			"order", ErrInvalidDLC)
+	}
+	if !btcec.PrivKeyFromScalar(&s).PubKey().IsEqual(point) {
+		return nil, fmt.Errorf("%w: attestation is not a signature of "+
+			"the outcome", ErrInvalidDLC)
+	}
+
+	d := payoutKey.Key
+	d.Add(&s)
+	if d.IsZero() {
+		return nil, fmt.Errorf("%w: outcome private key is zero",
+			ErrInvalidDLC)
+	}
+	return btcec.PrivKeyFromScalar(&d), nil
+}
+
+// BuildTapscriptDLCLeaves 返回合约的结果叶子和退款叶子：
+//
+//	outcome: <payoutKey + S_i> CHECKSIG
+//	refund:  <refundLockTime> CHECKLOCKTIMEVERIFY DROP <refundKey> CHECKSIG
+//
+// 结果叶子与 contract.Outcomes 的顺序相同，S_i 是预言机对第 i 个结果的签名点。
+func BuildTapscriptDLCLeaves(contract *DLCContract) ([]TapLeaf, TapLeaf, error) {
+	if len(contract.Outcomes) == 0 {
+		return nil, TapLeaf{}, fmt.Errorf("%w: no outcomes", ErrInvalidDLC)
+	}
+	if contract.RefundLockTime <= 0 ||
+		contract.RefundLockTime > maxHTLCLockTime {
+
+		return nil, TapLeaf{}, fmt.Errorf("%w: refund lock time %d is "+
+			"not in range [1, %d]", ErrInvalidDLC,
+			contract.RefundLockTime, maxHTLCLockTime)
+	}
+
+	outcomes := make([]TapLeaf, 0, len(contract.Outcomes))
+	seen := make(map[string]struct{}, len(contract.Outcomes))
+	for i, outcome := range contract.Outcomes {
+		if _, ok := seen[string(outcome.Message)]; ok {
+			return nil, TapLeaf{}, fmt.Errorf("%w: duplicate outcome "+
+				"%d", ErrInvalidDLC, i)
+		}
+		seen[string(outcome.Message)] = struct{}{}
+
+		key, err := DLCOutcomeKey(
+			outcome.PayoutKey, contract.OracleKey,
+			contract.OracleNonce, outcome.Message,
+		)
+		if err != nil {
+			return nil, TapLeaf{}, err
+		}
+		script, err := NewScriptBuilder().
+			AddData(schnorr.SerializePubKey(key)).AddOp(OP_CHECKSIG).
+			Script()
+		if err != nil {
+			return nil, TapLeaf{}, err
+		}
+		outcomes = append(outcomes, NewBaseTapLeaf(script))
+	}
+
+	refund, err := NewScriptBuilder().
+		AddInt64(contract.RefundLockTime).
+		AddOp(OP_CHECKLOCKTIMEVERIFY).AddOp(OP_DROP).
+		AddData(schnorr.SerializePubKey(contract.RefundKey)).
+		AddOp(OP_CHECKSIG).Script()
+	if err != nil {
+		return nil, TapLeaf{}, err
+	}
+
+	return outcomes, NewBaseTapLeaf(refund), nil
+}
+
+// ClassifyTapscriptDLCLeaf 返回传入的 tapscript 叶子脚本属于哪条 DLC 花费路径，对于退款叶子还返回其锁定时间。
+// 结果叶子与普通的单密钥叶子形式相同，因此任何 "<key> CHECKSIG" 叶子都被识别为结果叶子，需要确定具体结果时应使用
+// DLCTapTree.ClassifyLeaf。
+func ClassifyTapscriptDLCLeaf(script []byte) (DLCLeafType, int64) {
+	const scriptVersion = 0
+	var ops []byte
+	var datas [][]byte
+	tokenizer := MakeScriptTokenizer(scriptVersion, script)
+	for tokenizer.Next() {
+		ops = append(ops, tokenizer.Opcode())
+		datas = append(datas, tokenizer.Data())
+	}
+	if tokenizer.Err() != nil {
+		return DLCLeafUnknown, 0
+	}
+
+	if bytes.Equal(ops, []byte{OP_DATA_32, OP_CHECKSIG}) {
+		return DLCLeafOutcome, 0
+	}
+
+	if len(ops) == 5 && ops[1] == OP_CHECKLOCKTIMEVERIFY &&
+		ops[2] == OP_DROP && ops[3] == OP_DATA_32 &&
+		ops[4] == OP_CHECKSIG {
+
+		switch {
+		case ops[0] >= OP_1 && ops[0] <= OP_16:
+			return DLCLeafRefund, int64(AsSmallInt(ops[0]))
+		case datas[0] != nil:
+			lockTime, err := MakeScriptNum(
+				datas[0], true, cltvMaxScriptNumLen,
+			)
+			if err == nil && lockTime > 0 {
+				return DLCLeafRefund, int64(lockTime)
+			}
+		}
+	}
+
+	return DLCLeafUnknown, 0
+}
+
+// DLCTapTree 是由合约的全部叶子组装成的 taproot 输出。
+type DLCTapTree struct {
+	// InternalKey 是 taproot 输出的内部密钥。
+	InternalKey *btcec.PublicKey
+
+	// OutcomeLeaves 是各结果的叶子，与合约的结果顺序相同。
+	OutcomeLeaves []TapLeaf
+
+	// RefundLeaf 是退款叶子。
+	RefundLeaf TapLeaf
+
+	// Tree 是组装后的脚本树，结果叶子在前，退款叶子在最后。
+	Tree *IndexedTapScriptTree
+}
+
+// NewDLCTapTree 构建合约的全部叶子并组装成以 internalKey 为内部密钥的 taproot 输出。
+// internalKey 通常是双方的聚合公钥，双方协商一致时可以通过密钥路径花费；不需要密钥路径时可以使用 NUMSKey。
+func NewDLCTapTree(contract *DLCContract,
+	internalKey *btcec.PublicKey) (*DLCTapTree, error) {
+
+	outcomes, refund, err := BuildTapscriptDLCLeaves(contract)
+	if err != nil {
+		return nil, err
+	}
+
+	leaves := make([]TapLeaf, 0, len(outcomes)+1)
+	leaves = append(leaves, outcomes...)
+	leaves = append(leaves, refund)
+
+	return &DLCTapTree{
+		InternalKey:   internalKey,
+		OutcomeLeaves: outcomes,
+		RefundLeaf:    refund,
+		Tree:          AssembleTaprootScriptTree(leaves...),
+	}, nil
+}
+
+// OutputKey 返回 taproot 输出密钥。
+func (t *DLCTapTree) OutputKey() *btcec.PublicKey {
+	rootHash := t.Tree.RootNode.TapHash()
+	return ComputeTaprootOutputKey(t.InternalKey, rootHash[:])
+}
+
+// PkScript 返回支付到该 taproot 输出的公钥脚本。
+func (t *DLCTapTree) PkScript() ([]byte, error) {
+	return PayToTaprootScript(t.OutputKey())
+}
+
+// controlBlock 返回叶子的序列化控制块。
+func (t *DLCTapTree) controlBlock(leaf TapLeaf) ([]byte, error) {
+	idx, ok := t.Tree.LeafProofIndex[leaf.TapHash()]
+	if !ok {
+		return nil, fmt.Errorf("%w: leaf is not in the tree",
+			ErrInvalidDLC)
+	}
+	ctrlBlock := t.Tree.LeafMerkleProofs[idx].ToControlBlock(t.InternalKey)
+	return ctrlBlock.ToBytes()
+}
+
+// OutcomeControlBlock 返回第 i 个结果叶子的序列化控制块。
+func (t *DLCTapTree) OutcomeControlBlock(i int) ([]byte, error) {
+	if i < 0 || i >= len(t.OutcomeLeaves) {
+		return nil, fmt.Errorf("%w: outcome index %d out of range",
+			ErrInvalidDLC, i)
+	}
+	return t.controlBlock(t.OutcomeLeaves[i])
+}
+
+// RefundControlBlock 返回退款叶子的序列化控制块。
+func (t *DLCTapTree) RefundControlBlock() ([]byte, error) {
+	return t.controlBlock(t.RefundLeaf)
+}
+
+// ClassifyLeaf 返回叶子脚本在树中的花费路径，对于结果叶子还返回结果的索引，其他情况下索引为 -1。
+func (t *DLCTapTree) ClassifyLeaf(script []byte) (DLCLeafType, int) {
+	if bytes.Equal(script, t.RefundLeaf.Script) {
+		return DLCLeafRefund, -1
+	}
+	for i, leaf := range t.OutcomeLeaves {
+		if bytes.Equal(script, leaf.Script) {
+			return DLCLeafOutcome, i
+		}
+	}
+	return DLCLeafUnknown, -1
+}
+
+// DLCLeafWitness 返回通过结果叶子或退款叶子花费 DLC 输出的见证。 sig 是叶子公钥的 schnorr 签名，
+// 对于结果叶子由 DLCOutcomePrivKey 返回的私钥生成；controlBlock 是该叶子的序列化控制块。
+func DLCLeafWitness(sig, leafScript, controlBlock []byte) wire.TxWitness {
+	return wire.TxWitness{sig, leafScript, controlBlock}
+}
@@ -0,0 +1,134 @@
+package txscript
+
+import (
+	"crypto/sha256"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcec/v2/schnorr"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/stretchr/testify/require"
+)
+
+// oracleAttest 返回预言机使用私钥 oracleKey 和事先公布的 nonce 私钥 nonceKey 对 message 的 BIP0340 签名。
+func oracleAttest(oracleKey, nonceKey *btcec.PrivateKey,
+	message []byte) *schnorr.Signature {
+
+	x, k := oracleKey.Key, nonceKey.Key
+	if oracleKey.PubKey().SerializeCompressed()[0] == 0x03 {
+		x.Negate()
+	}
+	nonce := nonceKey.PubKey()
+	if nonce.SerializeCompressed()[0] == 0x03 {
+		k.Negate()
+	}
+
+	hash := sha256.Sum256(message)
+	e := adaptorChallenge(
+		nonce, schnorr.SerializePubKey(oracleKey.PubKey()), hash[:],
+	)
+	var s btcec.ModNScalar
+	s.Mul2(&e, &x).Add(&k)
+
+	var r btcec.JacobianPoint
+	nonce.AsJacobian(&r)
+	return schnorr.NewSignature(&r.X, &s)
+}
+
+// TestDLCTapscript 确保预言机公布结果后收款方能够通过对应的结果叶子花费，错误结果的签名无法使用，
+// 并且退款叶子在锁定时间到期后可以花费。
+func TestDLCTapscript(t *testing.T) {
+	t.Parallel()
+
+	const lockTime = 100
+	newKey := func() *btcec.PrivateKey {
+		key, err := btcec.NewPrivateKey()
+		require.NoError(t, err)
+		return key
+	}
+	oracleKey, nonceKey := newKey(), newKey()
+	alice, bob, refundKey := newKey(), newKey(), newKey()
+
+	contract := &DLCContract{
+		OracleKey:   oracleKey.PubKey(),
+		OracleNonce: nonceKey.PubKey(),
+		Outcomes: []DLCOutcome{
+			{Message: []byte("rain"), PayoutKey: alice.PubKey()},
+			{Message: []byte("sun"), PayoutKey: bob.PubKey()},
+		},
+		RefundKey:      refundKey.PubKey(),
+		RefundLockTime: lockTime,
+	}
+	tree, err := NewDLCTapTree(contract, NUMSKey())
+	require.NoError(t, err)
+	require.Len(t, tree.OutcomeLeaves, 2)
+
+	for i, leaf := range tree.OutcomeLeaves {
+		leafType, _ := ClassifyTapscriptDLCLeaf(leaf.Script)
+		require.Equal(t, DLCLeafOutcome, leafType)
+		leafType, idx := tree.ClassifyLeaf(leaf.Script)
+		require.Equal(t, DLCLeafOutcome, leafType)
+		require.Equal(t, i, idx)
+	}
+	leafType, refundLockTime := ClassifyTapscriptDLCLeaf(tree.RefundLeaf.Script)
+	require.Equal(t, DLCLeafRefund, leafType)
+	require.Equal(t, int64(lockTime), refundLockTime)
+	leafType, _ = ClassifyTapscriptDLCLeaf([]byte{OP_TRUE})
+	require.Equal(t, DLCLeafUnknown, leafType)
+
+	pkScript, err := tree.PkScript()
+	require.NoError(t, err)
+	const amt = 1e8
+	prevFetcher := NewCannedPrevOutputFetcher(pkScript, amt)
+
+	spend := func(tx *wire.MsgTx, leaf TapLeaf, ctrlBlock []byte,
+		key *btcec.PrivateKey) error {
+
+		sigHashes := NewTxSigHashes(tx, prevFetcher)
+		sig, err := RawTxInTapscriptSignature(tx, sigHashes, 0, amt,
+			pkScript, leaf, SigHashDefault, key)
+		require.NoError(t, err)
+
+		tx.TxIn[0].Witness = DLCLeafWitness(sig, leaf.Script, ctrlBlock)
+		vm, err := NewEngine(pkScript, tx, 0, StandardVerifyFlags, nil,
+			sigHashes, amt, prevFetcher)
+		if err != nil {
+			return err
+		}
+		return vm.Execute()
+	}
+
+	// 预言机公布 "sun"，bob 使用第二个结果叶子花费。
+	attestation := oracleAttest(oracleKey, nonceKey, []byte("sun"))
+	outcomeKey, err := DLCOutcomePrivKey(bob, oracleKey.PubKey(),
+		nonceKey.PubKey(), []byte("sun"), attestation)
+	require.NoError(t, err)
+	ctrlBlock, err := tree.OutcomeControlBlock(1)
+	require.NoError(t, err)
+	err = spend(htlcSpendingTx(0), tree.OutcomeLeaves[1], ctrlBlock,
+		outcomeKey)
+	require.NoError(t, err)
+
+	// 该签名不能用于另一个结果。
+	_, err = DLCOutcomePrivKey(alice, oracleKey.PubKey(),
+		nonceKey.PubKey(), []byte("rain"), attestation)
+	require.ErrorIs(t, err, ErrInvalidDLC)
+
+	_, err = tree.OutcomeControlBlock(2)
+	require.ErrorIs(t, err, ErrInvalidDLC)
+
+	// 退款路径。
+	ctrlBlock, err = tree.RefundControlBlock()
+	require.NoError(t, err)
+	err = spend(htlcSpendingTx(lockTime), tree.RefundLeaf, ctrlBlock,
+		refundKey)
+	require.NoError(t, err)
+	err = spend(htlcSpendingTx(lockTime-1), tree.RefundLeaf, ctrlBlock,
+		refundKey)
+	require.True(t, IsErrorCode(err, ErrUnsatisfiedLockTime))
+
+	// 重复的结果会被拒绝。
+	contract.Outcomes[1].Message = []byte("rain")
+	_, err = NewDLCTapTree(contract, NUMSKey())
+	require.ErrorIs(t, err, ErrInvalidDLC)
+}
@@ -26,6 +26,15 @@ txscript 包实现了比特币交易脚本语言。
 这允许调用者通过检查断言的 txscript.Error 类型的 ErrorCode 字段以编程方式确定特定错误，同时仍然提供带有上下文信息的丰富错误消息。
 还提供了一个名为 IsErrorCode 的便捷函数，允许调用者轻松检查特定的错误代码。
 有关完整列表，请参阅包文档中的 ErrorCode。
+
+# 精简构建
+
+使用 txscriptlite 构建标签编译时，包不依赖任何日志库，执行引擎的跟踪日志在编译时被移除，适用于编译到 WASM（GOOS=js GOARCH=wasm）
+或嵌入式验证器。 精简构建中没有 UseLogger，LiteBuild 常量为 true。
+
+精简构建中脚本错误只携带错误代码，不保留描述，但错误路径上的描述仍会先被格式化再丢弃，因此标准库的 fmt 和 reflect 仍是依赖。
+精简构建减少的是日志依赖和成功路径上的跟踪开销，而不是格式化代码的体积。
+需要确定的内存使用时，可以通过 WithChainLimits 限制堆栈和脚本大小，并通过 WithPooledStackMemory 复用堆栈元素的内存。
 */
 package txscript
 
@@ -12,7 +12,6 @@ import (
 	"github.com/btcsuite/btcd/btcec/v2"
 	"github.com/btcsuite/btcd/chaincfg/chainhash"
 	"github.com/btcsuite/btcd/wire"
-	"github.com/sirupsen/logrus"
 )
 
 // ScriptFlags 是一个位掩码，定义执行脚本对时将完成的附加操作或测试。
@@ -87,6 +86,23 @@ const (
 
 	// ScriptVerifyDiscourageUpgradeablePubkeyType 定义未知的公钥版本（在 Tapscript 执行期间）是否是非标准的。
 	ScriptVerifyDiscourageUpgradeablePubkeyType
+
+	// ScriptVerifyRejectUnknownWitnessVersion 定义是否在共识层面拒绝花费未注册处理程序的见证版本（2 到 16）的输出。
+	// 未设置时，这些输出与比特币一样被视为任何人都可以花费。
+	ScriptVerifyRejectUnknownWitnessVersion
+
+	// ScriptVerifyRejectUnknownScriptVersion 定义是否拒绝执行未通过 RegisterScriptVersion 注册的脚本版本（非 0）。
+	// 未设置时，这些脚本不被执行即视为成功，即任何人都可以花费。
+	ScriptVerifyRejectUnknownScriptVersion
+
+	// ScriptVerifyStructuredAnnex 定义 taproot 花费中的附件是否必须是 DecodeAnnex 可以解析的 TLV 记录流。
+	// 设置时，解析出的记录可以在 tapscript 执行期间通过 Engine.AnnexRecords 获取。
+	ScriptVerifyStructuredAnnex
+
+	// ScriptVerifyCheckSigFromStack 定义是否启用 bpfschain 的 OP_CHECKSIGFROMSTACK 和 OP_CHECKSIGFROMSTACKVERIFY 扩展操作码。
+	// 操作码只在 tapscript 中定义。 未设置时它们与其他未定义的操作码相同：在 tapscript 中是 OP_SUCCESSx，
+	// 在其他脚本中无论是否设置都在执行时失败。
+	ScriptVerifyCheckSigFromStack
 )
 
 const (
@@ -123,6 +139,9 @@ var halfOrder = new(big.Int).Rsh(btcec.S256().N, 1)
 type taprootExecutionCtx struct {
 	annex []byte
 
+	// annexRecords 是设置了 ScriptVerifyStructuredAnnex 时从附件中解析出的记录。
+	annexRecords []AnnexRecord
+
 	codeSepPos uint32
 
 	tapLeafHash chainhash.Hash
@@ -161,7 +180,7 @@ type Engine struct {
 	//
 	// flags 指定修改引擎执行行为的附加标志。
 	//
-	// tx 标识包含输入的交易，该输入又包含正在执行的签名脚本。
+	// tx 标识包含输入的交易，该输入又包含正在执行的签名脚本。 它是调用者交易的引用，引擎将其视为只读。
 	//
 	// txIdx 标识包含正在执行的签名脚本的交易中的输入索引。
 	//
@@ -175,9 +194,10 @@ type Engine struct {
 	//
 	// prevOutFetcher 用于查找主根交易的所有先前输出，因为该信息被散列到此类输入的ighash 摘要中。
 	flags          ScriptFlags
-	tx             wire.MsgTx
+	tx             *wire.MsgTx
 	txIdx          int
 	version        uint16
+	scriptVersion  *scriptVersionDef
 	bip16          bool
 	sigCache       *SigCache
 	hashCache      *TxSigHashes
@@ -218,6 +238,42 @@ type Engine struct {
 	witnessProgram  []byte
 	inputAmount     int64
 	taprootCtx      *taprootExecutionCtx
+
+	// limits 指定该引擎执行期间所使用的脚本大小、堆栈深度等限制。
+	limits ChainLimits
+
+	// coverage 在非 nil 时记录每一步执行的操作码和条件分支。
+	coverage *ScriptCoverage
+
+	// asyncSigVerifier 在非 nil 时用于推迟签名验证，deferredSigs 按提交顺序保存尚未确认的验证结果。
+	asyncSigVerifier *AsyncSigVerifier
+	deferredSigs     []*SigVerifyFuture
+
+	// stats 在非 nil 时记录执行期间的资源使用情况。
+	stats *ExecutionStats
+
+	// validationCache 在非 nil 时用于跳过已经验证通过的输入，validationKey 是该输入的缓存键。
+	validationCache *ValidationCache
+	validationKey   chainhash.Hash
+
+	// costModel 在非 nil 时用于累计执行成本 cost，costLimit 不为 0 时是成本上限。
+	costModel *CostModel
+	costLimit uint64
+	cost      uint64
+
+	// verifierBackend 在非 nil 时用于签名的密码学验证，否则使用 BtcecVerifier。
+	verifierBackend VerifierBackend
+
+	// replayLog 在非 nil 时记录执行的操作码、条件分支和签名验证结果。
+	replayLog *ReplayLog
+
+	// chainTag 是签名哈希提交的链域分隔标签。
+	chainTag ChainTag
+
+	// extOpCounts 记录当前脚本中每个扩展操作码的执行次数，extOutputBytes 记录当前脚本中扩展操作码推入数据堆栈的总字节数，
+	// 只在设置了对应的 ChainLimits 限制时统计。
+	extOpCounts    map[byte]int
+	extOutputBytes int
 }
 
 // hasFlag 返回脚本引擎实例是否设置了传递的标志。
@@ -349,14 +405,19 @@ func checkMinimalDataPush(op *opcode, data []byte) error {
 // 执行操作码对传递的操作码执行执行。
 // 它考虑到它是否被条件隐藏，但在这种情况下仍然必须测试一些规则。
 func (vm *Engine) executeOpcode(op *opcode, data []byte) error {
+	// Opcodes redefined by a registered script version replace the version
+	// 0 semantics, including the disabled and always-illegal rules.
+	overridden := vm.scriptVersion != nil &&
+		vm.scriptVersion.overridden[op.value]
+
 	// Disabled opcodes are fail on program counter.
-	if isOpcodeDisabled(op.value) {
+	if !overridden && isOpcodeDisabled(op.value) {
 		str := fmt.Sprintf("attempt to execute disabled opcode %s", op.name)
 		return scriptError(ErrDisabledOpcode, str)
 	}
 
 	// Always-illegal opcodes are fail on program counter.
-	if isOpcodeAlwaysIllegal(op.value) {
+	if !overridden && isOpcodeAlwaysIllegal(op.value) {
 		str := fmt.Sprintf("attempt to execute reserved opcode %s", op.name)
 		return scriptError(ErrReservedOpcode, str)
 	}
@@ -364,15 +425,15 @@ func (vm *Engine) executeOpcode(op *opcode, data []byte) error {
 	// Note that this includes OP_RESERVED which counts as a push operation.
 	if vm.taprootCtx == nil && op.value > OP_16 {
 		vm.numOps++
-		if vm.numOps > MaxOpsPerScript {
+		if vm.numOps > vm.limits.MaxOpsPerScript {
 			str := fmt.Sprintf("exceeded max operation limit of %d",
-				MaxOpsPerScript)
+				vm.limits.MaxOpsPerScript)
 			return scriptError(ErrTooManyOperations, str)
 		}
 
-	} else if len(data) > MaxScriptElementSize {
+	} else if len(data) > vm.limits.MaxScriptElementSize {
 		str := fmt.Sprintf("element size %d exceeds max allowed size %d",
-			len(data), MaxScriptElementSize)
+			len(data), vm.limits.MaxScriptElementSize)
 		return scriptError(ErrElementTooBig, str)
 	}
 
@@ -392,7 +453,63 @@ func (vm *Engine) executeOpcode(op *opcode, data []byte) error {
 		}
 	}
 
-	return op.opfunc(op, data, vm)
+	if vm.isExtensionOpcode(op.value, overridden) {
+		return vm.executeExtensionOpcode(op, data, overridden)
+	}
+	if overridden {
+		return vm.scriptVersion.handlers[op.value](op, data, vm)
+	}
+	return dispatchOpcode(op, data, vm)
+}
+
+// isExtensionOpcode 返回 op 是否是受 ChainLimits 扩展操作码限制约束的操作码，即由脚本版本重新定义的操作码，
+// 或设置了 ScriptVerifyCheckSigFromStack 时 tapscript 中的 OP_CHECKSIGFROMSTACK 和 OP_CHECKSIGFROMSTACKVERIFY。
+func (vm *Engine) isExtensionOpcode(op byte, overridden bool) bool {
+	if overridden {
+		return true
+	}
+	return (op == OP_CHECKSIGFROMSTACK || op == OP_CHECKSIGFROMSTACKVERIFY) &&
+		vm.hasFlag(ScriptVerifyCheckSigFromStack) && vm.taprootCtx != nil
+}
+
+// executeExtensionOpcode 执行扩展操作码，并按 ChainLimits 的 MaxExtensionOpExecutions 和 MaxExtensionOutputBytes
+// 统计它在当前脚本中的执行次数和推入数据堆栈的字节数。
+func (vm *Engine) executeExtensionOpcode(op *opcode, data []byte,
+	overridden bool) error {
+
+	if limit := vm.limits.MaxExtensionOpExecutions; limit > 0 {
+		if vm.extOpCounts == nil {
+			vm.extOpCounts = make(map[byte]int)
+		}
+		vm.extOpCounts[op.value]++
+		if vm.extOpCounts[op.value] > limit {
+			str := fmt.Sprintf("exceeded max executions of %s per "+
+				"script of %d", op.name, limit)
+			return scriptError(ErrTooManyExtensionOps, str)
+		}
+	}
+
+	pushedBytes := vm.dstack.pushedBytes
+	var err error
+	if overridden {
+		err = vm.scriptVersion.handlers[op.value](op, data, vm)
+	} else {
+		err = dispatchOpcode(op, data, vm)
+	}
+	if err != nil {
+		return err
+	}
+
+	if limit := vm.limits.MaxExtensionOutputBytes; limit > 0 {
+		vm.extOutputBytes += vm.dstack.pushedBytes - pushedBytes
+		if vm.extOutputBytes > limit {
+			str := fmt.Sprintf("extension opcodes produced %d bytes "+
+				"which exceeds the max of %d per script",
+				vm.extOutputBytes, limit)
+			return scriptError(ErrExtensionOutputTooBig, str)
+		}
+	}
+	return nil
 }
 
 // 如果当前脚本位置对于执行无效，则 checkValidPC 返回错误。
@@ -412,6 +529,7 @@ func (vm *Engine) isWitnessVersionActive(version uint) bool {
 
 // verifyWitnessProgram 使用传递的见证作为输入来验证存储的见证程序。
 func (vm *Engine) verifyWitnessProgram(witness wire.TxWitness) error {
+	handler := lookupWitnessVersionHandler(vm.witnessVersion)
 	switch {
 
 	// We're attempting to verify a base (witness version 0) segwit output,
@@ -458,10 +576,10 @@ func (vm *Engine) verifyWitnessProgram(witness wire.TxWitness) error {
 			// element in the passed stack. The size of the script
 			// MUST NOT exceed the max script size.
 			witnessScript := witness[len(witness)-1]
-			if len(witnessScript) > MaxScriptSize {
+			if len(witnessScript) > vm.limits.MaxScriptSize {
 				str := fmt.Sprintf("witnessScript size %d "+
 					"is larger than max allowed size %d",
-					len(witnessScript), MaxScriptSize)
+					len(witnessScript), vm.limits.MaxScriptSize)
 				return scriptError(ErrScriptTooBig, str)
 			}
 
@@ -528,6 +646,14 @@ func (vm *Engine) verifyWitnessProgram(witness wire.TxWitness) error {
 		if isAnnexedWitness(witness) {
 			vm.taprootCtx.annex, _ = extractAnnex(witness)
 
+			if vm.hasFlag(ScriptVerifyStructuredAnnex) {
+				records, err := DecodeAnnex(vm.taprootCtx.annex)
+				if err != nil {
+					return scriptError(ErrInvalidAnnex, err.Error())
+				}
+				vm.taprootCtx.annexRecords = records
+			}
+
 			// Snip the annex off the end of the witness stack.
 			witness = witness[:len(witness)-1]
 		}
@@ -543,10 +669,16 @@ func (vm *Engine) verifyWitnessProgram(witness wire.TxWitness) error {
 			// removing the annex), we'll do normal taproot
 			// keyspend validation.
 			rawSig := witness[0]
-			err := VerifyTaprootKeySpend(
-				vm.witnessProgram, rawSig, &vm.tx, vm.txIdx,
+			err := verifyTaprootKeySpend(
+				vm.witnessProgram, rawSig, vm.tx, vm.txIdx,
 				vm.prevOutFetcher, vm.hashCache, vm.sigCache,
+				vm.verifier(), vm.chainTag,
 			)
+			if vm.replayLog != nil && (err == nil ||
+				IsErrorCode(err, ErrTaprootSigInvalid)) {
+
+				vm.replayLog.recordSigCheck(SigTypeSchnorr, err == nil)
+			}
 			if err != nil {
 				// TODO(roasbeef): proper error
 				return err
@@ -585,7 +717,7 @@ func (vm *Engine) verifyWitnessProgram(witness wire.TxWitness) error {
 			// check to see if OP_SUCCESS op codes are found in the
 			// script. If so, then we'll return here early as we
 			// skip proper validation.
-			if ScriptHasOpSuccess(witnessScript) {
+			if vm.scriptHasOpSuccess(witnessScript) {
 				// An op success op code has been found, however if
 				// the policy flag forbidding them is active, then
 				// we'll return an error.
@@ -647,6 +779,17 @@ func (vm *Engine) verifyWitnessProgram(witness wire.TxWitness) error {
 			vm.SetStack(witness[:len(witness)-2])
 		}
 
+	// A handler has been registered for this (future) witness version, so
+	// delegate verification to it.
+	case handler != nil:
+		return vm.verifyRegisteredWitnessProgram(handler, witness)
+
+	case vm.hasFlag(ScriptVerifyRejectUnknownWitnessVersion):
+		errStr := fmt.Sprintf("witness program version %d has no "+
+			"registered handler", vm.witnessVersion)
+
+		return scriptError(ErrUnknownWitnessVersion, errStr)
+
 	case vm.hasFlag(ScriptVerifyDiscourageUpgradeableWitnessProgram):
 		errStr := fmt.Sprintf("new witness program versions "+
 			"invalid: %v", vm.witnessProgram)
@@ -661,34 +804,33 @@ func (vm *Engine) verifyWitnessProgram(witness wire.TxWitness) error {
 	}
 
 	// TODO(roasbeef): other sanity checks here
-	switch {
+	if vm.isWitnessVersionActive(TaprootWitnessVersion) ||
+		vm.isWitnessVersionActive(BaseSegwitWitnessVersion) {
 
-	// In addition to the normal script element size limits, taproot also
-	// enforces a limit on the max _starting_ stack size.
-	case vm.isWitnessVersionActive(TaprootWitnessVersion):
-		if vm.dstack.Depth() > MaxStackSize {
-			str := fmt.Sprintf("tapscript stack size %d > max allowed %d",
-				vm.dstack.Depth(), MaxStackSize)
-			return scriptError(ErrStackOverflow, str)
-		}
+		return vm.checkWitnessStack(vm.GetStack())
+	}
 
-		fallthrough
-	case vm.isWitnessVersionActive(BaseSegwitWitnessVersion):
-		// All elements within the witness stack must not be greater
-		// than the maximum bytes which are allowed to be pushed onto
-		// the stack.
-		for _, witElement := range vm.GetStack() {
-			if len(witElement) > MaxScriptElementSize {
-				str := fmt.Sprintf("element size %d exceeds "+
-					"max allowed size %d", len(witElement),
-					MaxScriptElementSize)
-				return scriptError(ErrElementTooBig, str)
-			}
-		}
+	return nil
+}
 
-		return nil
+// checkWitnessStack 确保见证版本 0 或 tapscript 花费的初始堆栈满足 ChainLimits 中见证元素数量和大小的限制。
+func (vm *Engine) checkWitnessStack(stack [][]byte) error {
+	if len(stack) > vm.limits.MaxWitnessStackItems {
+		str := fmt.Sprintf("witness stack size %d > max allowed %d",
+			len(stack), vm.limits.MaxWitnessStackItems)
+		return scriptError(ErrStackOverflow, str)
+	}
+
+	// All elements within the witness stack must not be greater than the
+	// maximum witness element size.
+	for _, witElement := range stack {
+		if len(witElement) > vm.limits.MaxWitnessElementSize {
+			str := fmt.Sprintf("witness element size %d exceeds max "+
+				"allowed size %d", len(witElement),
+				vm.limits.MaxWitnessElementSize)
+			return scriptError(ErrElementTooBig, str)
+		}
 	}
-
 	return nil
 }
 
@@ -792,7 +934,7 @@ func (vm *Engine) CheckErrorCondition(finalScript bool) error {
 	}
 	if !v {
 		// 记录有趣的数据。
-		logrus.Tracef("%v", newLogClosure(func() string {
+		logTrace(func() string {
 			var buf strings.Builder
 			buf.WriteString("scripts failed:\n")
 			for i := range vm.scripts {
@@ -801,7 +943,7 @@ func (vm *Engine) CheckErrorCondition(finalScript bool) error {
 				buf.WriteString(dis)
 			}
 			return buf.String()
-		}))
+		})
 		return scriptError(ErrEvalFalse,
 			"false stack entry at end of script execution")
 	}
@@ -819,6 +961,7 @@ func (vm *Engine) Step() (done bool, err error) {
 	}
 
 	// Attempt to parse the next opcode from the current script.
+	opcodeOffset := vm.tokenizer.ByteIndex()
 	if !vm.tokenizer.Next() {
 		// Note that due to the fact that all scripts are checked for parse
 		// failures before this code ever runs, there should never be an error
@@ -837,18 +980,52 @@ func (vm *Engine) Step() (done bool, err error) {
 	// Execute the opcode while taking into account several things such as
 	// disabled opcodes, illegal opcodes, maximum allowed operations per script,
 	// maximum script element sizes, and conditionals.
+	executing := vm.isOpcodeExecuting(vm.tokenizer.Opcode())
+	if vm.replayLog != nil {
+		vm.replayLog.recordStep(vm.scriptIdx, vm.opcodeIdx,
+			vm.tokenizer.Opcode(), executing)
+	}
 	err = vm.executeOpcode(vm.tokenizer.op, vm.tokenizer.Data())
 	if err != nil {
-		return true, err
+		return true, vm.locateFailure(err, opcodeOffset)
+	}
+
+	// Record which way a conditional went for replay.
+	if vm.replayLog != nil && executing {
+		switch vm.tokenizer.Opcode() {
+		case OP_IF, OP_NOTIF:
+			vm.replayLog.recordBranch(vm.isBranchExecuting())
+		}
+	}
+
+	// Record the step for coverage reporting when requested.
+	if vm.coverage != nil {
+		vm.coverage.recordStep(
+			vm.scripts[vm.scriptIdx], vm.opcodeIdx,
+			vm.tokenizer.Opcode(), executing, vm.isBranchExecuting(),
+		)
+	}
+
+	if vm.stats != nil {
+		vm.stats.recordStep(vm, vm.tokenizer.Opcode(), executing)
+	}
+
+	if vm.costModel != nil {
+		err := vm.chargeStep(vm.tokenizer.Opcode(), executing)
+		if err != nil {
+			return true, vm.locateFailure(err, opcodeOffset)
+		}
 	}
 
 	// The number of elements in the combination of the data and alt stacks
 	// must not exceed the maximum number of stack elements allowed.
 	combinedStackSize := vm.dstack.Depth() + vm.astack.Depth()
-	if combinedStackSize > MaxStackSize {
+	if int(combinedStackSize) > vm.limits.MaxStackSize {
 		str := fmt.Sprintf("combined stack size %d > max allowed %d",
-			combinedStackSize, MaxStackSize)
-		return false, scriptError(ErrStackOverflow, str)
+			combinedStackSize, vm.limits.MaxStackSize)
+		return false, vm.locateFailure(
+			scriptError(ErrStackOverflow, str), opcodeOffset,
+		)
 	}
 
 	// Prepare for next instruction.
@@ -863,8 +1040,11 @@ func (vm *Engine) Step() (done bool, err error) {
 		// Alt stack doesn't persist between scripts.
 		_ = vm.astack.DropN(vm.astack.Depth())
 
-		// The number of operations is per script.
+		// The number of operations is per script, and so is the
+		// accounting of extension opcodes.
 		vm.numOps = 0
+		vm.extOpCounts = nil
+		vm.extOutputBytes = 0
 
 		// Reset the opcode index for the next script.
 		vm.opcodeIdx = 0
@@ -932,27 +1112,53 @@ func (vm *Engine) Step() (done bool, err error) {
 
 // 执行将执行脚本引擎中的所有脚本，如果验证成功则返回 nil，如果发生则返回错误。
 func (vm *Engine) Execute() (err error) {
-	// 目前除 0 之外的所有脚本版本都可以正常执行，从而使任何人都可以支付所有输出。
-	// 将来这将允许添加新的脚本语言。
-	if vm.version != 0 {
+	defer vm.releaseStackMemory()
+
+	if vm.replayLog != nil {
+		defer func() {
+			vm.replayLog.recordResult(err)
+		}()
+	}
+
+	// 已经以相同标志验证通过的输入无需再次执行。
+	if vm.validationCache != nil {
+		if vm.validationCache.Exists(vm.validationKey) {
+			return nil
+		}
+		defer func() {
+			if err == nil {
+				vm.validationCache.Add(vm.validationKey)
+			}
+		}()
+	}
+
+	// 未注册的非 0 脚本版本不被执行即视为成功，从而使任何人都可以花费这些输出。
+	// 设置了 ScriptVerifyRejectUnknownScriptVersion 时，NewEngine 已经拒绝了这种情况。
+	if vm.version != 0 && vm.scriptVersion == nil {
 		return nil
 	}
 
-	done := false
+	// 模拟执行的引擎在没有可执行脚本时程序计数器已经位于最后一个脚本之后。
+	done := vm.scriptIdx >= len(vm.scripts)
 	for !done {
-		logrus.Tracef("%v", newLogClosure(func() string {
+		logTrace(func() string {
 			dis, err := vm.DisasmPC()
 			if err != nil {
 				return fmt.Sprintf("stepping - failed to disasm pc: %v", err)
 			}
 			return fmt.Sprintf("stepping %v", dis)
-		}))
+		})
 
 		done, err = vm.Step()
 		if err != nil {
+			// A deferred signature failure takes precedence, as the
+			// remaining execution assumed it to be valid.
+			if sigErr := vm.CheckDeferredSigs(); sigErr != nil {
+				return sigErr
+			}
 			return err
 		}
-		logrus.Tracef("%v", newLogClosure(func() string {
+		logTrace(func() string {
 			var dstr, astr string
 
 			// 跟踪时记录非空堆栈。
@@ -964,9 +1170,12 @@ func (vm *Engine) Execute() (err error) {
 			}
 
 			return dstr + astr
-		}))
+		})
 	}
 
+	if err := vm.CheckDeferredSigs(); err != nil {
+		return err
+	}
 	return vm.CheckErrorCondition(true)
 }
 
@@ -981,8 +1190,7 @@ func (vm *Engine) checkHashTypeEncoding(hashType SigHashType) error {
 		return nil
 	}
 
-	sigHashType := hashType & ^SigHashAnyOneCanPay
-	if sigHashType < SigHashAll || sigHashType > SigHashSingle {
+	if !isValidLegacySigHash(hashType) {
 		str := fmt.Sprintf("invalid hash type 0x%x", hashType)
 		return scriptError(ErrInvalidSigHashType, str)
 	}
@@ -1044,6 +1252,12 @@ func (vm *Engine) checkSignatureEncoding(sig []byte) error {
 		return nil
 	}
 
+	return checkDERSignatureEncoding(sig, vm.hasFlag(ScriptVerifyLowS))
+}
+
+// checkDERSignatureEncoding 按严格 DER 规则检查签名编码，requireLowS 为 true 时还要求 S 值不超过曲线阶的一半。
+// 它不依赖脚本标志，供必须始终执行严格编码的调用方（如 OP_CHECKSIGFROMSTACK 的 ECDSA 形式）直接使用。
+func checkDERSignatureEncoding(sig []byte, requireLowS bool) error {
 	// The format of a DER encoded signature is as follows:
 	//
 	// 0x30 <total length> 0x02 <length of R> <R> 0x02 <length of S> <S>
@@ -1221,7 +1435,7 @@ func (vm *Engine) checkSignatureEncoding(sig []byte) error {
 	// transaction with the complement while still being a valid signature that
 	// verifies.  This would result in changing the transaction hash and thus is
 	// a source of malleability.
-	if vm.hasFlag(ScriptVerifyLowS) {
+	if requireLowS {
 		sValue := new(big.Int).SetBytes(sig[sOffset : sOffset+sLen])
 		if sValue.Cmp(halfOrder) > 0 {
 			return scriptError(ErrSigHighS, "signature is not canonical due "+
@@ -1252,6 +1466,43 @@ func setStack(stack *stack, data [][]byte) {
 	}
 }
 
+// WithPooledStackMemory 使引擎从共享的内存池中为执行期间计算产生的堆栈元素（数字、布尔值和哈希摘要）分配内存，
+// 并在 Execute 返回时将内存归还到池中，从而在验证大量脚本时减少小对象分配和垃圾回收压力。
+//
+// Execute 返回前，剩余的堆栈内容会被复制到堆上，因此之后调用 GetStack 和 GetAltStack 是安全的。
+// 但是在执行期间通过 GetStack 等方法获取的元素只在 Execute 返回前有效。 手动调用 Step 的调用者不会释放内存池，
+// 其内存由垃圾回收器正常回收。
+func WithPooledStackMemory() EngineOpt {
+	return func(cfg *engineConfig) {
+		cfg.pooledStack = true
+	}
+}
+
+// usePooledStackMemory 使数据堆栈和备用堆栈从同一个内存池中分配元素。
+func (vm *Engine) usePooledStackMemory() {
+	arena := getStackArena()
+	vm.dstack.arena = arena
+	vm.astack.arena = arena
+}
+
+// releaseStackMemory 在启用 WithPooledStackMemory 时将剩余的堆栈内容复制到堆上，并将内存池归还以供其他引擎复用。
+func (vm *Engine) releaseStackMemory() {
+	arena := vm.dstack.arena
+	if arena == nil {
+		return
+	}
+
+	// Deferred signature checks may still reference stack elements.
+	for _, future := range vm.deferredSigs {
+		future.Wait()
+	}
+
+	vm.dstack.detach()
+	vm.astack.detach()
+	vm.savedFirstStack = nil
+	arena.release()
+}
+
 // GetStack 以数组形式返回主堆栈的内容。 其中数组中的最后一项是堆栈的顶部。
 func (vm *Engine) GetStack() [][]byte {
 	return getStack(&vm.dstack)
@@ -1272,12 +1523,120 @@ func (vm *Engine) SetAltStack(data [][]byte) {
 	setStack(&vm.astack, data)
 }
 
+// checkSigHashInputs 在需要由引擎计算签名哈希缓存时，确保见证花费计算签名哈希所需的先前输出都可以获取，并计算该缓存。
+// 否则签名检查会因本地数据缺失而失败，并被误报为签名无效，因此这种情况返回 InternalError 而不是脚本错误。
+//
+// taproot 签名哈希承诺所有输入的先前输出，版本 0 见证计算缓存时也需要它们。 调用方提供的缓存已经使用这些先前输出计算，
+// 因此这里不再检查，避免为交易的每个输入重复遍历所有输入。
+func (vm *Engine) checkSigHashInputs() error {
+	if vm.hashCache != nil {
+		return nil
+	}
+	taproot := vm.isWitnessVersionActive(TaprootWitnessVersion) &&
+		vm.hasFlag(ScriptVerifyTaproot)
+	witnessV0 := vm.isWitnessVersionActive(BaseSegwitWitnessVersion)
+	if !taproot && !witnessV0 {
+		return nil
+	}
+
+	if vm.prevOutFetcher == nil {
+		return internalError("previous output fetcher required to "+
+			"compute witness signature hashes", nil)
+	}
+	PrefetchPrevOutputs(vm.prevOutFetcher, vm.tx)
+	for _, txIn := range vm.tx.TxIn {
+		outpoint := txIn.PreviousOutPoint
+		if outpoint.Index == wire.MaxPrevOutIndex &&
+			outpoint.Hash == (chainhash.Hash{}) {
+
+			continue
+		}
+		if vm.prevOutFetcher.FetchPrevOutput(outpoint) == nil {
+			str := fmt.Sprintf("previous output %v not found", outpoint)
+			return internalError(str, nil)
+		}
+	}
+
+	// Compute the cache once here so the signature checks of this input
+	// don't each rebuild it.
+	vm.hashCache = NewTxSigHashes(vm.tx, vm.prevOutFetcher)
+	return nil
+}
+
+// engineConfig 包含可通过 EngineOpt 修改的引擎构造参数。
+type engineConfig struct {
+	limits           ChainLimits
+	coverage         *ScriptCoverage
+	asyncSigVerifier *AsyncSigVerifier
+	stats            *ExecutionStats
+	pooledStack      bool
+	scriptVersion    uint16
+	validationCache  *ValidationCache
+	costModel        *CostModel
+	costLimit        uint64
+	pkScriptInfo     *pkScriptInfo
+	verifierBackend  VerifierBackend
+	replayLog        *ReplayLog
+	chainTag         ChainTag
+}
+
+// defaultEngineConfig 返回默认的引擎构造参数。
+func defaultEngineConfig() *engineConfig {
+	return &engineConfig{
+		limits: DefaultChainLimits(),
+	}
+}
+
+// EngineOpt 是用于修改 NewEngine 所创建引擎的函数选项。
+type EngineOpt func(*engineConfig)
+
+// WithChainLimits 指定引擎执行期间使用的限制，而不是包级默认限制。
+func WithChainLimits(limits ChainLimits) EngineOpt {
+	return func(cfg *engineConfig) {
+		cfg.limits = limits
+	}
+}
+
 // NewEngine 为提供的公钥脚本、交易和输入索引返回一个新的脚本引擎。 标志根据每个标志提供的描述修改脚本引擎的行为。
+// 可选的 EngineOpt 用于调整限制等高级行为，未指定时使用包级默认值。
+//
+// 引擎保存对 tx 的引用而不是副本，因此验证同一交易的多个输入时不会为每个输入复制交易。
+// 引擎从不修改 tx，调用者在引擎使用期间也不得修改它。
 func NewEngine(scriptPubKey []byte, tx *wire.MsgTx, txIdx int, flags ScriptFlags,
 	sigCache *SigCache, hashCache *TxSigHashes, inputAmount int64,
-	prevOutFetcher PrevOutputFetcher) (*Engine, error) {
+	prevOutFetcher PrevOutputFetcher, opts ...EngineOpt) (*Engine, error) {
+
+	cfg := defaultEngineConfig()
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	// 一旦有引擎被创建，链特定的脚本类别注册表即不可再修改。
+	freezeScriptClassRegistry()
+	freezeWitnessHandlerRegistry()
+	freezeScriptVersionRegistry()
+
+	// 已注册的脚本版本可以指定自己的限制；未注册的非 0 版本只有在未设置严格标志时才被接受。
+	scriptVersion := cfg.scriptVersion
+	versionDef := lookupScriptVersion(scriptVersion)
+	switch {
+	case versionDef != nil && versionDef.limits != nil:
+		cfg.limits = *versionDef.limits
+
+	case scriptVersion != 0 && versionDef == nil &&
+		flags&ScriptVerifyRejectUnknownScriptVersion != 0:
 
-	const scriptVersion = 0
+		str := fmt.Sprintf("script version %d is not registered",
+			scriptVersion)
+		return nil, scriptError(ErrUnsupportedScriptVersion, str)
+	}
+	if err := cfg.limits.Validate(); err != nil {
+		return nil, err
+	}
+
+	if tx == nil {
+		return nil, internalError("nil transaction", nil)
+	}
 
 	// 提供的交易输入索引必须引用有效的输入。
 	if txIdx < 0 || txIdx >= len(tx.TxIn) {
@@ -1305,7 +1664,26 @@ func NewEngine(scriptPubKey []byte, tx *wire.MsgTx, txIdx int, flags ScriptFlags
 		hashCache:      hashCache,
 		inputAmount:    inputAmount,
 		prevOutFetcher: prevOutFetcher,
+		limits:         cfg.limits,
+		coverage:       cfg.coverage,
+		version:        scriptVersion,
+		scriptVersion:  versionDef,
+
+		asyncSigVerifier: cfg.asyncSigVerifier,
+		stats:            cfg.stats,
+		costModel:        cfg.costModel,
+		costLimit:        cfg.costLimit,
+		verifierBackend:  cfg.verifierBackend,
+		replayLog:        cfg.replayLog,
+		chainTag:         cfg.chainTag,
+	}
+	// The checks of the public key script don't depend on the transaction,
+	// so an engine factory may supply them for scripts it already analyzed.
+	pkInfo := cfg.pkScriptInfo
+	if pkInfo == nil {
+		pkInfo = newPkScriptInfo(scriptPubKey)
 	}
+
 	if vm.hasFlag(ScriptVerifyCleanStack) && (!vm.hasFlag(ScriptBip16) &&
 		!vm.hasFlag(ScriptVerifyWitness)) {
 		return nil, scriptError(ErrInvalidFlags,
@@ -1319,7 +1697,7 @@ func NewEngine(scriptPubKey []byte, tx *wire.MsgTx, txIdx int, flags ScriptFlags
 	}
 
 	// 签名脚本必须只包含PS2H的数据推送，这是根据公钥脚本的形式确定的。
-	if vm.hasFlag(ScriptBip16) && isScriptHashScript(scriptPubKey) {
+	if vm.hasFlag(ScriptBip16) && pkInfo.scriptHash {
 		// 仅接受为 P2SH 推送数据的输入脚本。
 		// 请注意，当上面设置了验证签名脚本仅推送的标志时，仅推送检查已经完成，因此避免再次检查。
 		alreadyChecked := vm.hasFlag(ScriptVerifySigPushOnly)
@@ -1332,16 +1710,20 @@ func NewEngine(scriptPubKey []byte, tx *wire.MsgTx, txIdx int, flags ScriptFlags
 
 	// 引擎使用切片来存储脚本。 这允许按顺序执行多个脚本。 例如，对于支付脚本哈希交易，最终将需要执行第三个脚本。
 	scripts := [][]byte{scriptSig, scriptPubKey}
-	for _, scr := range scripts {
-		if len(scr) > MaxScriptSize {
+	for i, scr := range scripts {
+		if len(scr) > vm.limits.MaxScriptSize {
 			str := fmt.Sprintf("script size %d is larger than max allowed "+
-				"size %d", len(scr), MaxScriptSize)
+				"size %d", len(scr), vm.limits.MaxScriptSize)
 			return nil, scriptError(ErrScriptTooBig, str)
 		}
 
 		const scriptVersion = 0
-		if err := checkScriptParses(scriptVersion, scr); err != nil {
-			return nil, err
+		parseErr := pkInfo.parseErr
+		if i == 0 {
+			parseErr = checkScriptParses(scriptVersion, scr)
+		}
+		if parseErr != nil {
+			return nil, parseErr
 		}
 	}
 	vm.scripts = scripts
@@ -1368,7 +1750,7 @@ func NewEngine(scriptPubKey []byte, tx *wire.MsgTx, txIdx int, flags ScriptFlags
 		var witProgram []byte
 
 		switch {
-		case IsWitnessProgram(vm.scripts[1]):
+		case pkInfo.witnessProgram:
 			// 对于所有本机见证程序来说，scriptSig 必须为*空*，否则我们会引入延展性。
 			if len(scriptSig) != 0 {
 				errStr := "native witness program cannot " +
@@ -1379,13 +1761,8 @@ func NewEngine(scriptPubKey []byte, tx *wire.MsgTx, txIdx int, flags ScriptFlags
 			witProgram = scriptPubKey
 		case len(tx.TxIn[txIdx].Witness) != 0 && vm.bip16:
 			// sigScript 必须“准确”是见证程序的单个规范数据推送，否则我们将重新引入可延展性。
-			sigPops := vm.scripts[0]
-			if len(sigPops) > 2 &&
-				isCanonicalPush(sigPops[0], sigPops[1:]) &&
-				IsWitnessProgram(sigPops[1:]) {
-
-				witProgram = sigPops[1:]
-			} else {
+			witProgram = nestedWitnessProgram(vm.scripts[0])
+			if witProgram == nil {
 				errStr := "signature script for witness " +
 					"nested p2sh is not canonical"
 				return nil, scriptError(ErrWitnessMalleatedP2SH, errStr)
@@ -1394,9 +1771,14 @@ func NewEngine(scriptPubKey []byte, tx *wire.MsgTx, txIdx int, flags ScriptFlags
 
 		if witProgram != nil {
 			var err error
-			vm.witnessVersion, vm.witnessProgram, err = ExtractWitnessProgramInfo(
-				witProgram,
-			)
+			if pkInfo.witnessProgram {
+				vm.witnessVersion, vm.witnessProgram, err =
+					pkInfo.witnessVersion, pkInfo.program,
+					pkInfo.programErr
+			} else {
+				vm.witnessVersion, vm.witnessProgram, err =
+					ExtractWitnessProgramInfo(witProgram)
+			}
 			if err != nil {
 				return nil, err
 			}
@@ -1410,11 +1792,27 @@ func NewEngine(scriptPubKey []byte, tx *wire.MsgTx, txIdx int, flags ScriptFlags
 
 	}
 
+	if cfg.pooledStack {
+		vm.usePooledStackMemory()
+	}
+
 	// 设置当前分词器，用于通过与程序计数器关联的脚本一次解析一个操作码。
 	vm.tokenizer = MakeScriptTokenizer(scriptVersion, scripts[vm.scriptIdx])
 
-	vm.tx = *tx
+	vm.tx = tx
 	vm.txIdx = txIdx
 
+	if err := vm.checkSigHashInputs(); err != nil {
+		return nil, err
+	}
+
+	if cfg.validationCache != nil {
+		vm.validationCache = cfg.validationCache
+		vm.validationKey = cfg.chainTag.validationKey(
+			cfg.validationCache.Key(tx, txIdx, scriptPubKey,
+				inputAmount, scriptVersion, flags),
+		)
+	}
+
 	return &vm, nil
 }
@@ -0,0 +1,154 @@
+// 包含脚本引擎工厂，在验证花费同一公钥脚本的多个输入时共享公钥脚本的解析检查、类别和签名操作数，
+// 避免同一地址收到的大量输出在一个区块中被花费时反复解析相同的脚本。
+
+package txscript
+
+import (
+	"sync"
+
+	"github.com/btcsuite/btcd/wire"
+)
+
+// pkScriptInfo 是 NewEngine 对公钥脚本进行的与交易无关的检查结果。
+type pkScriptInfo struct {
+	parseErr       error
+	scriptHash     bool
+	witnessProgram bool
+	witnessVersion int
+	program        []byte
+	programErr     error
+}
+
+// newPkScriptInfo 解析并检查公钥脚本。
+func newPkScriptInfo(pkScript []byte) *pkScriptInfo {
+	const scriptVersion = 0
+	info := &pkScriptInfo{
+		parseErr:       checkScriptParses(scriptVersion, pkScript),
+		scriptHash:     isScriptHashScript(pkScript),
+		witnessProgram: IsWitnessProgram(pkScript),
+	}
+	if info.witnessProgram {
+		info.witnessVersion, info.program, info.programErr =
+			ExtractWitnessProgramInfo(pkScript)
+	}
+	return info
+}
+
+// withPkScriptInfo 使引擎使用已有的公钥脚本检查结果，而不是重新解析公钥脚本。 info 必须是对传给 NewEngine 的公钥脚本计算的。
+func withPkScriptInfo(info *pkScriptInfo) EngineOpt {
+	return func(cfg *engineConfig) {
+		cfg.pkScriptInfo = info
+	}
+}
+
+// PkScriptAnalysis 是公钥脚本与交易无关的分析结果，由 EngineFactory 在花费同一公钥脚本的所有输入之间共享。
+type PkScriptAnalysis struct {
+	// Class 是公钥脚本的类别。
+	Class ScriptClass
+
+	// SigOps 是公钥脚本按传统规则计算的签名操作数，与 GetSigOpCount 相同。
+	SigOps int
+
+	info *pkScriptInfo
+}
+
+// EngineFactoryStats 是 EngineFactory 共享分析结果的计数快照。
+type EngineFactoryStats struct {
+	// Scripts 是已分析的不同公钥脚本数。
+	Scripts int
+
+	// Hits 是复用已有分析结果的次数，Misses 是分析新公钥脚本的次数。
+	Hits   uint64
+	Misses uint64
+}
+
+// EngineFactory 使用相同的标志、签名缓存和引擎选项创建脚本引擎，并按公钥脚本的内容缓存解析检查、
+// 类别和签名操作数。 一个地址收到的许多输出在同一区块中被花费时，每个公钥脚本只被分析一次。
+//
+// 缓存没有容量限制，工厂应当只在一个区块或一批交易的验证期间使用。 EngineFactory 是并发安全的，
+// 它创建的每个引擎仍然只能在一个 goroutine 中使用。
+type EngineFactory struct {
+	flags    ScriptFlags
+	sigCache *SigCache
+	opts     []EngineOpt
+
+	mtx      sync.RWMutex
+	analyses map[string]*PkScriptAnalysis
+	hits     uint64
+	misses   uint64
+}
+
+// NewEngineFactory 返回使用 flags、sigCache 和 opts 创建引擎的工厂。 参数的含义与 NewEngine 相同。
+func NewEngineFactory(flags ScriptFlags, sigCache *SigCache,
+	opts ...EngineOpt) *EngineFactory {
+
+	return &EngineFactory{
+		flags:    flags,
+		sigCache: sigCache,
+		opts:     opts,
+		analyses: make(map[string]*PkScriptAnalysis),
+	}
+}
+
+// Analyze 返回 pkScript 的分析结果，已分析过相同内容的公钥脚本时返回共享的结果。 调用者不得修改返回值。
+func (f *EngineFactory) Analyze(pkScript []byte) *PkScriptAnalysis {
+	f.mtx.RLock()
+	analysis, ok := f.analyses[string(pkScript)]
+	f.mtx.RUnlock()
+	if ok {
+		f.mtx.Lock()
+		f.hits++
+		f.mtx.Unlock()
+		return analysis
+	}
+
+	// Analyze outside of the lock, a concurrent analysis of the same
+	// script yields an identical result. The shared witness program
+	// must not alias the caller's buffer.
+	script := append([]byte(nil), pkScript...)
+	analysis = &PkScriptAnalysis{
+		Class:  GetScriptClass(script),
+		SigOps: GetSigOpCount(script),
+		info:   newPkScriptInfo(script),
+	}
+
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	if existing, ok := f.analyses[string(pkScript)]; ok {
+		f.hits++
+		return existing
+	}
+	f.analyses[string(pkScript)] = analysis
+	f.misses++
+	return analysis
+}
+
+// NewEngine 返回验证 tx 的输入 txIdx 的脚本引擎，该输入花费公钥脚本为 scriptPubKey、金额为 inputAmount 的输出。
+// 引擎使用工厂的标志、签名缓存和选项，opts 在工厂的选项之后应用，可以为单个引擎指定执行统计等选项。
+// 公钥脚本的分析结果在花费相同公钥脚本的引擎之间共享，其他行为与 NewEngine 相同。
+func (f *EngineFactory) NewEngine(scriptPubKey []byte, tx *wire.MsgTx,
+	txIdx int, hashCache *TxSigHashes, inputAmount int64,
+	prevOutFetcher PrevOutputFetcher, opts ...EngineOpt) (*Engine, error) {
+
+	analysis := f.Analyze(scriptPubKey)
+	engineOpts := make([]EngineOpt, 0, len(f.opts)+len(opts)+1)
+	engineOpts = append(engineOpts, f.opts...)
+	engineOpts = append(engineOpts, opts...)
+	engineOpts = append(engineOpts, withPkScriptInfo(analysis.info))
+
+	return NewEngine(
+		scriptPubKey, tx, txIdx, f.flags, f.sigCache, hashCache,
+		inputAmount, prevOutFetcher, engineOpts...,
+	)
+}
+
+// Stats 返回工厂当前的计数快照。
+func (f *EngineFactory) Stats() EngineFactoryStats {
+	f.mtx.RLock()
+	defer f.mtx.RUnlock()
+	return EngineFactoryStats{
+		Scripts: len(f.analyses),
+		Hits:    f.hits,
+		Misses:  f.misses,
+	}
+}
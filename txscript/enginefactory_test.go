@@ -0,0 +1,86 @@
+package txscript
+
+import (
+	"testing"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/stretchr/testify/require"
+)
+
+// TestEngineFactory 确保工厂创建的引擎能验证花费同一公钥脚本的多个输入，每个公钥脚本只被分析一次，
+// 并且无效公钥脚本的错误与 NewEngine 相同。
+func TestEngineFactory(t *testing.T) {
+	t.Parallel()
+
+	privKey, err := btcec.NewPrivateKey()
+	require.NoError(t, err)
+	pubKeyHash := hash160(privKey.PubKey().SerializeCompressed())
+	p2pkh, err := payToPubKeyHashScript(pubKeyHash)
+	require.NoError(t, err)
+	p2wpkh, err := payToWitnessPubKeyHashScript(pubKeyHash)
+	require.NoError(t, err)
+
+	// 同一地址收到的多个输出在一个交易中被花费。
+	const amount = 10000
+	pkScripts := [][]byte{p2pkh, p2wpkh, p2pkh, p2wpkh, p2pkh, p2wpkh}
+	prevOuts := make(map[wire.OutPoint]*wire.TxOut)
+	tx := wire.NewMsgTx(2)
+	for i, pkScript := range pkScripts {
+		outPoint := wire.OutPoint{Index: uint32(i)}
+		prevOuts[outPoint] = wire.NewTxOut(amount, pkScript)
+		tx.AddTxIn(wire.NewTxIn(&outPoint, nil, nil))
+	}
+	tx.AddTxOut(wire.NewTxOut(amount, p2wpkh))
+
+	fetcher := NewMultiPrevOutFetcher(prevOuts)
+	hashCache := NewTxSigHashes(tx, fetcher)
+	for i, pkScript := range pkScripts {
+		if IsPayToWitnessPubKeyHash(pkScript) {
+			tx.TxIn[i].Witness, err = WitnessSignature(tx, hashCache, i,
+				amount, pkScript, SigHashAll, privKey, true)
+		} else {
+			tx.TxIn[i].SignatureScript, err = SignatureScript(tx, i,
+				pkScript, SigHashAll, privKey, true)
+		}
+		require.NoError(t, err)
+	}
+
+	factory := NewEngineFactory(StandardVerifyFlags, nil)
+	for i, pkScript := range pkScripts {
+		// 每个输入使用独立的公钥脚本副本，分析结果按内容共享。
+		pkScript = append([]byte(nil), pkScript...)
+		vm, err := factory.NewEngine(pkScript, tx, i, hashCache, amount,
+			fetcher)
+		require.NoError(t, err)
+		require.NoError(t, vm.Execute(), "input %d", i)
+	}
+	require.Equal(t, EngineFactoryStats{
+		Scripts: 2,
+		Hits:    4,
+		Misses:  2,
+	}, factory.Stats())
+
+	analysis := factory.Analyze(p2pkh)
+	require.Equal(t, PubKeyHashTy, analysis.Class)
+	require.Equal(t, 1, analysis.SigOps)
+	require.Equal(t, WitnessV0PubKeyHashTy, factory.Analyze(p2wpkh).Class)
+
+	// 共享的分析结果不能绕过签名检查。
+	tx.TxIn[1].Witness, tx.TxIn[3].Witness = tx.TxIn[3].Witness,
+		tx.TxIn[1].Witness
+	vm, err := factory.NewEngine(p2wpkh, tx, 1, hashCache, amount, fetcher)
+	require.NoError(t, err)
+	require.Error(t, vm.Execute())
+
+	// 无法解析的公钥脚本返回与 NewEngine 相同的错误。
+	malformed := []byte{OP_DATA_2, 0x01}
+	_, wantErr := NewEngine(malformed, tx, 0, StandardVerifyFlags, nil,
+		hashCache, amount, fetcher)
+	require.True(t, IsErrorCode(wantErr, ErrMalformedPush))
+	for i := 0; i < 2; i++ {
+		_, err = factory.NewEngine(malformed, tx, 0, hashCache, amount,
+			fetcher)
+		require.Equal(t, wantErr, err)
+	}
+}
@@ -3,6 +3,7 @@
 package txscript
 
 import (
+	"errors"
 	"fmt"
 )
 
@@ -405,6 +406,51 @@ const (
 	// is exceeded during taproot execution.
 	ErrTaprootMaxSigOps
 
+	// ErrInvalidChainLimits is returned when the provided chain limits are
+	// non-positive or inconsistent with each other.
+	ErrInvalidChainLimits
+
+	// ErrUnknownWitnessVersion is returned when ScriptVerifyRejectUnknownWitnessVersion
+	// is set and a witness program with a version that has no registered
+	// handler is spent.
+	ErrUnknownWitnessVersion
+
+	// ErrInvalidAnnex is returned when ScriptVerifyStructuredAnnex is set
+	// and a taproot spend carries an annex that is not a valid TLV record
+	// stream.
+	ErrInvalidAnnex
+
+	// ErrScriptCostExceeded is returned when the engine was created with a
+	// cost limit and the accumulated execution cost exceeds it.
+	ErrScriptCostExceeded
+
+	// ErrCheckSigFromStackVerify is returned when OP_CHECKSIGFROMSTACKVERIFY
+	// is encountered in a script and the top item on the data stack does
+	// not evaluate to true.
+	ErrCheckSigFromStackVerify
+
+	// ErrSchnorrSigRTooBig is returned when the R value of a BIP340
+	// signature is not less than the field prime.
+	ErrSchnorrSigRTooBig
+
+	// ErrSchnorrSigSTooBig is returned when the s value of a BIP340
+	// signature is not less than the group order.
+	ErrSchnorrSigSTooBig
+
+	// ErrXOnlyPubKeyNotOnCurve is returned when a 32-byte x-only public key
+	// is not the x coordinate of a point on the curve.
+	ErrXOnlyPubKeyNotOnCurve
+
+	// ErrTooManyExtensionOps is returned when an extension opcode is
+	// executed more times in a single script than allowed by
+	// ChainLimits.MaxExtensionOpExecutions.
+	ErrTooManyExtensionOps
+
+	// ErrExtensionOutputTooBig is returned when the extension opcodes of a
+	// single script push more bytes onto the data stack than allowed by
+	// ChainLimits.MaxExtensionOutputBytes.
+	ErrExtensionOutputTooBig
+
 	// numErrorCodes is the maximum error code number used in tests.  This
 	// entry MUST be the last entry in the enum.
 	numErrorCodes
@@ -491,6 +537,16 @@ var errorCodeStrings = map[ErrorCode]string{
 	ErrInvalidTaprootSigLen:                "ErrInvalidTaprootSigLen",
 	ErrTaprootPubkeyIsEmpty:                "ErrTaprootPubkeyIsEmpty",
 	ErrTaprootMaxSigOps:                    "ErrTaprootMaxSigOps",
+	ErrInvalidChainLimits:                  "ErrInvalidChainLimits",
+	ErrUnknownWitnessVersion:               "ErrUnknownWitnessVersion",
+	ErrInvalidAnnex:                        "ErrInvalidAnnex",
+	ErrScriptCostExceeded:                  "ErrScriptCostExceeded",
+	ErrCheckSigFromStackVerify:             "ErrCheckSigFromStackVerify",
+	ErrSchnorrSigRTooBig:                   "ErrSchnorrSigRTooBig",
+	ErrSchnorrSigSTooBig:                   "ErrSchnorrSigSTooBig",
+	ErrXOnlyPubKeyNotOnCurve:               "ErrXOnlyPubKeyNotOnCurve",
+	ErrTooManyExtensionOps:                 "ErrTooManyExtensionOps",
+	ErrExtensionOutputTooBig:               "ErrExtensionOutputTooBig",
 }
 
 // String returns the ErrorCode as a human-readable name.
@@ -502,7 +558,8 @@ func (e ErrorCode) String() string {
 }
 
 // Error identifies a script-related error.  It is used to indicate three
-// classes of errors:
+// classes of errors, of which only the first is a consensus failure as reported
+// by IsConsensusError:
 //  1. Script execution failures due to violating one of the many requirements
 //     imposed by the script engine or evaluating to false
 //  2. Improper API usage by callers
@@ -512,18 +569,33 @@ func (e ErrorCode) String() string {
 // ErrorCode field to ascertain the specific reason for the error.  As an
 // additional convenience, the caller may make use of the IsErrorCode function
 // to check for a specific error code.
+//
+// Errors caused by executing an opcode additionally carry the location of the
+// failure which is available via the Location method.
 type Error struct {
 	ErrorCode   ErrorCode
 	Description string
+
+	location *FailureLocation
 }
 
 // Error satisfies the error interface and prints human-readable errors.
 func (e Error) Error() string {
-	return e.Description
+	desc := e.Description
+	if desc == "" {
+		desc = e.ErrorCode.String()
+	}
+	if e.location != nil {
+		return fmt.Sprintf("%s (%v)", desc, e.location)
+	}
+	return desc
 }
 
-// scriptError 在给定一组参数的情况下创建一个错误。
+// scriptError 在给定一组参数的情况下创建一个错误。 精简构建中描述被丢弃，错误只携带错误代码；调用方传入的描述此时已经格式化。
 func scriptError(c ErrorCode, desc string) Error {
+	if LiteBuild {
+		desc = ""
+	}
 	return Error{ErrorCode: c, Description: desc}
 }
 
@@ -533,3 +605,68 @@ func IsErrorCode(err error, c ErrorCode) bool {
 	serr, ok := err.(Error)
 	return ok && serr.ErrorCode == c
 }
+
+// InternalError identifies a failure of the local environment rather than of
+// the script being validated, such as a nil transaction or a previous output
+// fetcher that cannot provide an output required to compute a signature hash.
+// Such failures say nothing about the validity of the spend, so callers must
+// not treat them as a consensus rejection of the transaction or of the peer
+// that relayed it.
+type InternalError struct {
+	Description string
+	Err         error
+}
+
+// Error satisfies the error interface and prints human-readable errors.
+func (e InternalError) Error() string {
+	if e.Err != nil {
+		return e.Description + ": " + e.Err.Error()
+	}
+	return e.Description
+}
+
+// Unwrap returns the underlying error, if any.
+func (e InternalError) Unwrap() error {
+	return e.Err
+}
+
+// internalError creates an InternalError given a set of arguments.
+func internalError(desc string, err error) InternalError {
+	return InternalError{Description: desc, Err: err}
+}
+
+// apiErrorCodes are the error codes that indicate improper API usage or
+// inconsistent configuration rather than an invalid script.
+var apiErrorCodes = map[ErrorCode]struct{}{
+	ErrInternal:            {},
+	ErrInvalidFlags:        {},
+	ErrInvalidIndex:        {},
+	ErrUnsupportedAddress:  {},
+	ErrNotMultisigScript:   {},
+	ErrTooManyRequiredSigs: {},
+	ErrTooMuchNullData:     {},
+	ErrInvalidChainLimits:  {},
+}
+
+// IsConsensusError returns whether the provided error means the script, and
+// therefore the transaction spending it, is invalid.  Only these errors may be
+// used to reject a transaction or penalize the peer that relayed it.
+//
+// Errors returned when an unregistered script version is rejected by
+// ScriptVerifyRejectUnknownScriptVersion are consensus errors, as the version
+// is chosen by the output being spent.
+func IsConsensusError(err error) bool {
+	var serr Error
+	if !errors.As(err, &serr) {
+		return false
+	}
+	_, isAPI := apiErrorCodes[serr.ErrorCode]
+	return !isAPI
+}
+
+// IsInternalError returns whether the provided error is a failure of the local
+// environment or of the caller, rather than a judgement on the script.  It is
+// the complement of IsConsensusError for any non-nil error.
+func IsInternalError(err error) bool {
+	return err != nil && !IsConsensusError(err)
+}
@@ -2,7 +2,11 @@
 package txscript
 
 import (
+	"errors"
+	"fmt"
 	"testing"
+
+	"github.com/btcsuite/btcd/wire"
 )
 
 // TestErrorCodeStringer 测试 ErrorCode 类型的字符串化输出。
@@ -92,6 +96,16 @@ func TestErrorCodeStringer(t *testing.T) {
 		{ErrInvalidTaprootSigLen, "ErrInvalidTaprootSigLen"},
 		{ErrTaprootPubkeyIsEmpty, "ErrTaprootPubkeyIsEmpty"},
 		{ErrTaprootMaxSigOps, "ErrTaprootMaxSigOps"},
+		{ErrInvalidChainLimits, "ErrInvalidChainLimits"},
+		{ErrUnknownWitnessVersion, "ErrUnknownWitnessVersion"},
+		{ErrInvalidAnnex, "ErrInvalidAnnex"},
+		{ErrScriptCostExceeded, "ErrScriptCostExceeded"},
+		{ErrCheckSigFromStackVerify, "ErrCheckSigFromStackVerify"},
+		{ErrSchnorrSigRTooBig, "ErrSchnorrSigRTooBig"},
+		{ErrSchnorrSigSTooBig, "ErrSchnorrSigSTooBig"},
+		{ErrXOnlyPubKeyNotOnCurve, "ErrXOnlyPubKeyNotOnCurve"},
+		{ErrTooManyExtensionOps, "ErrTooManyExtensionOps"},
+		{ErrExtensionOutputTooBig, "ErrExtensionOutputTooBig"},
 		{0xffff, "Unknown ErrorCode (65535)"},
 	}
 
@@ -140,3 +154,90 @@ func TestError(t *testing.T) {
 		}
 	}
 }
+
+// TestErrorClassification 确保脚本无效的错误被归类为共识错误，而 API 误用和本地环境错误被归类为内部错误。
+func TestErrorClassification(t *testing.T) {
+	t.Parallel()
+
+	wrapped := fmt.Errorf("context: %w", scriptError(ErrEvalFalse, "false"))
+	tests := []struct {
+		name      string
+		err       error
+		consensus bool
+	}{
+		{"eval false", scriptError(ErrEvalFalse, ""), true},
+		{"wrapped eval false", wrapped, true},
+		{"unknown witness version",
+			scriptError(ErrUnknownWitnessVersion, ""), true},
+		{"invalid index", scriptError(ErrInvalidIndex, ""), false},
+		{"invalid flags", scriptError(ErrInvalidFlags, ""), false},
+		{"internal", scriptError(ErrInternal, ""), false},
+		{"environment", internalError("missing", nil), false},
+		{"plain error", errors.New("boom"), false},
+	}
+
+	for _, test := range tests {
+		if got := IsConsensusError(test.err); got != test.consensus {
+			t.Errorf("%s: IsConsensusError = %v, want %v", test.name,
+				got, test.consensus)
+		}
+		if got := IsInternalError(test.err); got == test.consensus {
+			t.Errorf("%s: IsInternalError = %v, want %v", test.name,
+				got, !test.consensus)
+		}
+	}
+	if IsInternalError(nil) || IsConsensusError(nil) {
+		t.Errorf("nil error must not be classified")
+	}
+
+	// 本地数据缺失导致的引擎构造失败是内部错误，而不是签名无效。
+	_, err := NewEngine([]byte{OP_TRUE}, nil, 0, 0, nil, nil, 0, nil)
+	var ierr InternalError
+	if !errors.As(err, &ierr) {
+		t.Fatalf("nil transaction: got %v, want InternalError", err)
+	}
+
+	pkScripts := [][]byte{
+		mustParseShortForm("0 DATA_20 0x" +
+			"0000000000000000000000000000000000000000"),
+		mustParseShortForm("1 DATA_32 0x" +
+			"79be667ef9dcbbac55a06295ce870b07029bfcdb2dce28d959f2815b16f81798"),
+	}
+	for _, pkScript := range pkScripts {
+		tx := createSpendingTx(wire.TxWitness{{0x01}}, nil, pkScript, 0)
+		fetcher := NewMultiPrevOutFetcher(nil)
+		_, err := NewEngine(pkScript, tx, 0, StandardVerifyFlags, nil, nil,
+			0, fetcher)
+		if !errors.As(err, &ierr) || !IsInternalError(err) {
+			t.Errorf("missing prevout: got %v, want InternalError", err)
+		}
+
+		fetcher.AddPrevOut(tx.TxIn[0].PreviousOutPoint,
+			wire.NewTxOut(0, pkScript))
+		_, err = NewEngine(pkScript, tx, 0, StandardVerifyFlags, nil, nil,
+			0, fetcher)
+		if err != nil {
+			t.Errorf("unexpected error with prevout available: %v", err)
+		}
+
+		// 调用方提供的签名哈希缓存已经承诺了其他输入的先前输出，引擎不再为每个输入重复查找它们。
+		other := wire.OutPoint{Index: 1}
+		tx.AddTxIn(wire.NewTxIn(&other, nil, nil))
+		fullFetcher := NewMultiPrevOutFetcher(map[wire.OutPoint]*wire.TxOut{
+			tx.TxIn[0].PreviousOutPoint: wire.NewTxOut(0, pkScript),
+			other:                       wire.NewTxOut(0, pkScript),
+		})
+		hashCache := NewTxSigHashes(tx, fullFetcher)
+		_, err = NewEngine(pkScript, tx, 0, StandardVerifyFlags, nil,
+			hashCache, 0, fetcher)
+		if err != nil {
+			t.Errorf("unexpected error with hash cache: %v", err)
+		}
+		_, err = NewEngine(pkScript, tx, 0, StandardVerifyFlags, nil, nil,
+			0, fetcher)
+		if !IsInternalError(err) {
+			t.Errorf("missing other prevout: got %v, want InternalError",
+				err)
+		}
+	}
+}
@@ -0,0 +1,118 @@
+// 包含脚本执行失败的定位信息，使 Execute 返回的错误可以指出失败的脚本、操作码及其上下文，便于运营者调试被拒绝的交易。
+
+package txscript
+
+import (
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+const (
+	// failureWindowRadius 是失败位置的反汇编窗口中失败操作码前后各显示的操作码数。
+	failureWindowRadius = 2
+
+	// failureWindowMaxData 是反汇编窗口中完整显示的最大数据推送字节数，更长的数据只显示开头部分。
+	failureWindowMaxData = 32
+)
+
+// FailureLocation 描述脚本执行失败的位置。
+type FailureLocation struct {
+	// ScriptIndex 是失败脚本在引擎执行的脚本序列中的索引：0 为签名脚本，1 为公钥脚本，
+	// 之后为 P2SH 兑换脚本或见证脚本（包括 tapscript 叶子）。
+	ScriptIndex int
+
+	// OpcodeIndex 是失败操作码在脚本中的序号，数据推送及其数据只计为一个操作码。
+	OpcodeIndex int
+
+	// Opcode 是失败操作码的值。
+	Opcode byte
+
+	// ByteOffset 是失败操作码在脚本中的字节偏移量。
+	ByteOffset int
+
+	// Disasm 是失败操作码前后若干操作码的单行反汇编，失败的操作码用方括号标出，例如
+	// "... OP_HASH160 <公钥哈希> [OP_EQUALVERIFY] OP_CHECKSIG"。
+	Disasm string
+}
+
+// OpcodeName 返回失败操作码的名称，例如 "OP_EQUALVERIFY"。
+func (l *FailureLocation) OpcodeName() string {
+	return opcodeArray[l.Opcode].name
+}
+
+// String 返回失败位置的单行描述。
+func (l *FailureLocation) String() string {
+	return fmt.Sprintf("script %d, opcode %d (%s) at offset %d: %s",
+		l.ScriptIndex, l.OpcodeIndex, l.OpcodeName(), l.ByteOffset,
+		l.Disasm)
+}
+
+// Location 返回错误的失败位置。 只有 Execute 或 Step 执行操作码失败时返回的错误带有失败位置，其他错误返回 nil。
+func (e Error) Location() *FailureLocation {
+	return e.location
+}
+
+// locateFailure 为执行当前操作码时产生的脚本错误附加失败位置，其他错误原样返回。 offset 是当前操作码的字节偏移量。
+func (vm *Engine) locateFailure(err error, offset int32) error {
+	serr, ok := err.(Error)
+	if !ok || serr.location != nil {
+		return err
+	}
+
+	script := vm.scripts[vm.scriptIdx]
+	opcodeIdx := vm.tokenizer.OpcodePosition()
+	serr.location = &FailureLocation{
+		ScriptIndex: vm.scriptIdx,
+		OpcodeIndex: int(opcodeIdx),
+		Opcode:      vm.tokenizer.Opcode(),
+		ByteOffset:  int(offset),
+		Disasm: disasmWindow(vm.version, script, opcodeIdx,
+			failureWindowRadius),
+	}
+	return serr
+}
+
+// disasmWindow 返回脚本中第 opcodeIdx 个操作码前后各 radius 个操作码的单行反汇编，目标操作码用方括号标出。
+// 窗口之外还有操作码时以省略号表示。
+func disasmWindow(version uint16, script []byte, opcodeIdx int32,
+	radius int32) string {
+
+	var buf strings.Builder
+	tokenizer := MakeScriptTokenizer(version, script)
+	for tokenizer.Next() {
+		pos := tokenizer.OpcodePosition()
+		switch {
+		case pos < opcodeIdx-radius:
+			if pos == 0 {
+				buf.WriteString("... ")
+			}
+			continue
+
+		case pos > opcodeIdx+radius:
+			buf.WriteString(" ...")
+			return buf.String()
+
+		case pos > opcodeIdx-radius && pos > 0:
+			buf.WriteByte(' ')
+		}
+
+		if pos == opcodeIdx {
+			buf.WriteByte('[')
+		}
+		op := &opcodeArray[tokenizer.Opcode()]
+		if data := tokenizer.Data(); len(data) > failureWindowMaxData {
+			buf.WriteString(hex.EncodeToString(
+				data[:failureWindowMaxData/2],
+			))
+			buf.WriteString("...")
+		} else {
+			disasmOpcode(&buf, op, data, true)
+		}
+		if pos == opcodeIdx {
+			buf.WriteByte(']')
+		}
+	}
+
+	return buf.String()
+}
@@ -0,0 +1,74 @@
+package txscript
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// TestFailureLocation 确保执行操作码失败的错误带有失败位置，而其他错误没有。
+func TestFailureLocation(t *testing.T) {
+	t.Parallel()
+
+	// 公钥哈希与推送的公钥不匹配的 P2PKH 花费在 OP_EQUALVERIFY 处失败。
+	pkScript := mustParseShortForm("DUP HASH160 DATA_20 " +
+		"0x0000000000000000000000000000000000000000 EQUALVERIFY CHECKSIG")
+	sigScript := mustParseShortForm("DATA_1 0x01 DATA_1 0x02")
+	tx := createSpendingTx(nil, sigScript, pkScript, 0)
+
+	vm, err := NewEngine(pkScript, tx, 0, 0, nil, nil, 0, nil)
+	require.NoError(t, err)
+	err = vm.Execute()
+	require.True(t, IsErrorCode(err, ErrEqualVerify))
+
+	loc := err.(Error).Location()
+	require.NotNil(t, loc)
+	require.Equal(t, 1, loc.ScriptIndex)
+	require.Equal(t, 3, loc.OpcodeIndex)
+	require.Equal(t, byte(OP_EQUALVERIFY), loc.Opcode)
+	require.Equal(t, "OP_EQUALVERIFY", loc.OpcodeName())
+	require.Equal(t, 23, loc.ByteOffset)
+	require.Equal(t, "... OP_HASH160 "+
+		"0000000000000000000000000000000000000000 "+
+		"[OP_EQUALVERIFY] OP_CHECKSIG", loc.Disasm)
+	require.Contains(t, err.Error(), "script 1, opcode 3 (OP_EQUALVERIFY) "+
+		"at offset 23")
+
+	// 脚本执行结束时堆栈顶部为假不是某个操作码的失败。
+	pkScript = mustParseShortForm("DROP 0")
+	tx = createSpendingTx(nil, sigScript, pkScript, 0)
+	vm, err = NewEngine(pkScript, tx, 0, 0, nil, nil, 0, nil)
+	require.NoError(t, err)
+	err = vm.Execute()
+	require.True(t, IsErrorCode(err, ErrEvalFalse))
+	require.Nil(t, err.(Error).Location())
+	if !LiteBuild {
+		require.Equal(t, err.(Error).Description, err.Error())
+	}
+}
+
+// TestDisasmWindow 确保反汇编窗口只包含失败操作码附近的操作码，并截断较长的数据推送。
+func TestDisasmWindow(t *testing.T) {
+	t.Parallel()
+
+	script := mustParseShortForm("1 2 3 4 5 6 7")
+	tests := []struct {
+		opcodeIdx int32
+		want      string
+	}{
+		{0, "[1] 2 3 ..."},
+		{1, "1 [2] 3 4 ..."},
+		{3, "... 2 3 [4] 5 6 ..."},
+		{6, "... 5 6 [7]"},
+	}
+	for _, test := range tests {
+		got := disasmWindow(0, script, test.opcodeIdx, failureWindowRadius)
+		require.Equal(t, test.want, got)
+	}
+
+	longPush := mustParseShortForm("DATA_40 0x" + strings.Repeat("ab", 40) +
+		" DROP")
+	require.Equal(t, strings.Repeat("ab", 16)+"... [OP_DROP]",
+		disasmWindow(0, longPush, 1, failureWindowRadius))
+}
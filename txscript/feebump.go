@@ -0,0 +1,367 @@
+// 包含手续费追加的估算，比较通过 RBF 替换交易和通过 CPFP 子交易达到目标费率的成本，并生成未签名的替换交易或子交易。
+// 估算使用已签名交易中每个输入的实际签名脚本和见证大小，而不是钱包层常用的按输入类型的近似值。
+
+package txscript
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/wire"
+)
+
+// DefaultIncrementalRelayFee 是未指定时使用的增量中继费率，以每 1000 虚拟字节的聪数表示。 BIP0125 要求替换交易额外支付的手续费
+// 至少覆盖按该费率计算的自身大小。
+const DefaultIncrementalRelayFee btcutil.Amount = 1000
+
+// maxDERSigLen 是附加了 sighash 类型的 DER 编码 ECDSA 签名的最大长度。
+const maxDERSigLen = 73
+
+var (
+	// ErrFeeBumpNotNeeded 在交易的费率已经达到目标费率时返回。
+	ErrFeeBumpNotNeeded = errors.New("transaction already meets the " +
+		"target fee rate")
+
+	// ErrNoFeeBump 在 RBF 和 CPFP 都无法达到目标费率时返回，例如找零输出或子交易花费的输出金额不足以支付手续费。
+	ErrNoFeeBump = errors.New("no viable fee bump")
+)
+
+// FeeBumpMethod 表示追加手续费的方式。
+type FeeBumpMethod uint8
+
+const (
+	// FeeBumpRBF 表示以更高的手续费替换原交易（BIP0125），额外的手续费从找零输出中扣除。
+	FeeBumpRBF FeeBumpMethod = iota
+
+	// FeeBumpCPFP 表示创建一个花费原交易输出的子交易，使父子交易的整体费率达到目标。
+	FeeBumpCPFP
+)
+
+// String 返回追加方式的名称。
+func (m FeeBumpMethod) String() string {
+	switch m {
+	case FeeBumpRBF:
+		return "RBF"
+	case FeeBumpCPFP:
+		return "CPFP"
+	}
+	return fmt.Sprintf("FeeBumpMethod(%d)", uint8(m))
+}
+
+// FeeBumpParams 描述一次手续费追加。
+type FeeBumpParams struct {
+	// Tx 是需要追加手续费的已签名交易。
+	Tx *wire.MsgTx
+
+	// PrevOutFetcher 提供 Tx 的所有输入花费的输出，用于计算 Tx 当前的手续费。
+	PrevOutFetcher PrevOutputFetcher
+
+	// FeeRate 是目标费率，以每 1000 虚拟字节的聪数表示。
+	FeeRate btcutil.Amount
+
+	// IncrementalRelayFee 是增量中继费率，同时用作子交易的最低费率和判断粉尘输出的费率。 为 0 时使用 DefaultIncrementalRelayFee。
+	IncrementalRelayFee btcutil.Amount
+
+	// ChangeIndex 是 RBF 替换交易中用于扣除额外手续费的找零输出索引，为 -1 时不考虑 RBF。
+	ChangeIndex int
+
+	// CPFPIndex 是子交易花费的 Tx 输出索引，为 -1 时不考虑 CPFP。 该输出必须是 P2PK、P2PKH、P2WPKH 或 P2TR（密钥路径）输出，
+	// 以便精确估算花费它的输入大小。
+	CPFPIndex int
+
+	// ChildPkScript 是子交易唯一输出的公钥脚本。
+	ChildPkScript []byte
+}
+
+// FeeBumpOption 是一种可行的手续费追加方案。
+type FeeBumpOption struct {
+	// Method 是追加方式。
+	Method FeeBumpMethod
+
+	// Tx 是未签名的替换交易或子交易，签名脚本和见证为空。
+	Tx *wire.MsgTx
+
+	// VSize 是 Tx 签名后的估算虚拟大小，ECDSA 签名按最大长度计算，因此实际大小不会超过该值。
+	VSize int64
+
+	// Fee 是 Tx 支付的手续费。
+	Fee btcutil.Amount
+
+	// ExtraFee 是与当前相比需要额外支付的手续费：对于 RBF 是替换交易与原交易手续费之差，对于 CPFP 是子交易的手续费。
+	ExtraFee btcutil.Amount
+}
+
+// feeForVSize 返回按 feeRate（每 1000 虚拟字节的聪数）为 vsize 虚拟字节支付的手续费，向上取整。
+func feeForVSize(vsize int64, feeRate btcutil.Amount) btcutil.Amount {
+	return (btcutil.Amount(vsize)*feeRate + 999) / 1000
+}
+
+// txWeight 返回交易按当前签名脚本和见证计算的权重。
+func txWeight(tx *wire.MsgTx) int64 {
+	// Weight only fails when filling in unsigned inputs.
+	weight, _ := Weight(tx, nil)
+	return weight
+}
+
+// weightToVSize 将权重转换为虚拟大小，向上取整。
+func weightToVSize(weight int64) int64 {
+	return (weight + witnessScaleFactor - 1) / witnessScaleFactor
+}
+
+// isDERSigPush 返回数据是否像附加了 sighash 类型的 DER 编码 ECDSA 签名。
+func isDERSigPush(data []byte) bool {
+	return len(data) >= 9 && len(data) <= maxDERSigLen &&
+		data[0] == 0x30 && int(data[1]) == len(data)-3
+}
+
+// maxSignedWeight 返回以相同脚本重新签名 tx 后的最大权重。 ECDSA 签名的长度在 71 到 73 字节之间变化，
+// 因此每个签名按最大长度计算；schnorr 签名的长度固定。
+func maxSignedWeight(tx *wire.MsgTx) int64 {
+	const scriptVersion = 0
+	weight := txWeight(tx)
+	for _, txIn := range tx.TxIn {
+		var sigScriptGrowth int
+		tokenizer := MakeScriptTokenizer(scriptVersion, txIn.SignatureScript)
+		for tokenizer.Next() {
+			if data := tokenizer.Data(); isDERSigPush(data) {
+				sigScriptGrowth += maxDERSigLen - len(data)
+			}
+		}
+		if sigScriptGrowth > 0 {
+			oldLen := len(txIn.SignatureScript)
+			newLen := oldLen + sigScriptGrowth
+			growth := sigScriptGrowth +
+				wire.VarIntSerializeSize(uint64(newLen)) -
+				wire.VarIntSerializeSize(uint64(oldLen))
+			weight += int64(growth) * witnessScaleFactor
+		}
+
+		for _, item := range txIn.Witness {
+			if isDERSigPush(item) {
+				weight += int64(maxDERSigLen - len(item))
+			}
+		}
+	}
+	return weight
+}
+
+// spendPlaceholder 返回花费 pkScript 时签名脚本和见证的最大占位数据。 只支持可以精确估算的单签名输出。
+func spendPlaceholder(pkScript []byte) ([]byte, wire.TxWitness, error) {
+	sig := make([]byte, maxDERSigLen)
+	pubKey := make([]byte, 33)
+	switch GetScriptClass(pkScript) {
+	case PubKeyTy:
+		sigScript, err := NewScriptBuilder().AddData(sig).Script()
+		return sigScript, nil, err
+
+	case PubKeyHashTy:
+		sigScript, err := NewScriptBuilder().AddData(sig).AddData(pubKey).
+			Script()
+		return sigScript, nil, err
+
+	case WitnessV0PubKeyHashTy:
+		return nil, wire.TxWitness{sig, pubKey}, nil
+
+	case WitnessV1TaprootTy:
+		// A key path spend with SigHashDefault.
+		return nil, wire.TxWitness{make([]byte, 64)}, nil
+	}
+	return nil, nil, fmt.Errorf("cannot estimate the size of spending a "+
+		"%v output", GetScriptClass(pkScript))
+}
+
+// isDustOutput 返回按 relayFee 花费该输出的成本是否超过其金额的三分之一，与 mempool 的粉尘规则相同。
+func isDustOutput(txOut *wire.TxOut, relayFee btcutil.Amount) bool {
+	// The size of a typical input spending the output: 41 bytes of
+	// outpoint, sequence and script length plus a 107 byte signature script,
+	// discounted for witness programs.
+	totalSize := int64(txOut.SerializeSize() + 41)
+	if IsWitnessProgram(txOut.PkScript) {
+		totalSize += 107 / witnessScaleFactor
+	} else {
+		totalSize += 107
+	}
+	return txOut.Value*1000/(3*totalSize) < int64(relayFee)
+}
+
+// PlanFeeBump 计算使 params.Tx 达到目标费率的 RBF 和 CPFP 方案，按额外手续费从低到高返回可行的方案，额外手续费相同时 RBF 在前。
+//
+// RBF 要求原交易至少有一个输入按 BIP0125 发出可替换信号。 替换交易保留原交易的输入和输出，只减少找零输出的金额，
+// 其手续费不低于按目标费率计算的手续费，并且按 BIP0125 至少比原交易多支付按增量中继费率计算的自身大小的手续费。
+// CPFP 子交易花费 CPFPIndex 指定的输出，使父子交易的整体费率达到目标，并且自身费率不低于增量中继费率。
+// 找零或子交易的输出在扣除手续费后会成为粉尘时，对应的方案不可行。
+//
+// 交易已经达到目标费率时返回 ErrFeeBumpNotNeeded，两种方案都不可行时返回 ErrNoFeeBump。
+func PlanFeeBump(params *FeeBumpParams) ([]FeeBumpOption, error) {
+	tx := params.Tx
+	if params.FeeRate <= 0 {
+		return nil, fmt.Errorf("invalid fee rate %v", params.FeeRate)
+	}
+	relayFee := params.IncrementalRelayFee
+	if relayFee == 0 {
+		relayFee = DefaultIncrementalRelayFee
+	}
+
+	var inputValue, outputValue int64
+	for i, txIn := range tx.TxIn {
+		prevOut := params.PrevOutFetcher.FetchPrevOutput(
+			txIn.PreviousOutPoint,
+		)
+		if prevOut == nil {
+			return nil, fmt.Errorf("previous output of input %d not "+
+				"found", i)
+		}
+		inputValue += prevOut.Value
+	}
+	for _, txOut := range tx.TxOut {
+		outputValue += txOut.Value
+	}
+	fee := btcutil.Amount(inputValue - outputValue)
+	if fee < 0 {
+		return nil, fmt.Errorf("transaction outputs exceed its inputs "+
+			"by %v", -fee)
+	}
+
+	parentVSize := weightToVSize(txWeight(tx))
+	if fee >= feeForVSize(parentVSize, params.FeeRate) {
+		return nil, ErrFeeBumpNotNeeded
+	}
+
+	var options []FeeBumpOption
+	var errs []error
+
+	if params.ChangeIndex >= 0 {
+		option, err := planRBF(params, fee, relayFee)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("RBF: %w", err))
+		} else {
+			options = append(options, *option)
+		}
+	}
+	if params.CPFPIndex >= 0 {
+		option, err := planCPFP(params, fee, parentVSize, relayFee)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("CPFP: %w", err))
+		} else {
+			options = append(options, *option)
+		}
+	}
+
+	if len(options) == 0 {
+		if len(errs) == 0 {
+			return nil, fmt.Errorf("%w: no change or CPFP output given",
+				ErrNoFeeBump)
+		}
+		return nil, fmt.Errorf("%w: %v", ErrNoFeeBump, errors.Join(errs...))
+	}
+
+	sort.SliceStable(options, func(i, j int) bool {
+		return options[i].ExtraFee < options[j].ExtraFee
+	})
+	return options, nil
+}
+
+// planRBF 返回 RBF 替换交易方案。
+func planRBF(params *FeeBumpParams, fee,
+	relayFee btcutil.Amount) (*FeeBumpOption, error) {
+
+	tx := params.Tx
+	if params.ChangeIndex >= len(tx.TxOut) {
+		return nil, fmt.Errorf("change index %d out of range",
+			params.ChangeIndex)
+	}
+
+	// BIP0125 only allows replacing transactions that signal it.
+	var signals bool
+	for _, txIn := range tx.TxIn {
+		if txIn.Sequence < wire.MaxTxInSequenceNum-1 {
+			signals = true
+			break
+		}
+	}
+	if !signals {
+		return nil, errors.New("transaction does not signal " +
+			"replaceability")
+	}
+
+	vsize := weightToVSize(maxSignedWeight(tx))
+	newFee := feeForVSize(vsize, params.FeeRate)
+	if minFee := fee + feeForVSize(vsize, relayFee); newFee < minFee {
+		newFee = minFee
+	}
+	extra := newFee - fee
+
+	replacement := tx.Copy()
+	for _, txIn := range replacement.TxIn {
+		txIn.SignatureScript = nil
+		txIn.Witness = nil
+	}
+	change := replacement.TxOut[params.ChangeIndex]
+	change.Value -= int64(extra)
+	if change.Value < 0 || isDustOutput(change, relayFee) {
+		return nil, fmt.Errorf("change output of %v cannot pay the "+
+			"extra fee of %v", btcutil.Amount(change.Value+int64(extra)),
+			extra)
+	}
+
+	return &FeeBumpOption{
+		Method:   FeeBumpRBF,
+		Tx:       replacement,
+		VSize:    vsize,
+		Fee:      newFee,
+		ExtraFee: extra,
+	}, nil
+}
+
+// planCPFP 返回 CPFP 子交易方案。
+func planCPFP(params *FeeBumpParams, parentFee btcutil.Amount,
+	parentVSize int64, relayFee btcutil.Amount) (*FeeBumpOption, error) {
+
+	tx := params.Tx
+	if params.CPFPIndex >= len(tx.TxOut) {
+		return nil, fmt.Errorf("CPFP index %d out of range",
+			params.CPFPIndex)
+	}
+	parentOut := tx.TxOut[params.CPFPIndex]
+	sigScript, witness, err := spendPlaceholder(parentOut.PkScript)
+	if err != nil {
+		return nil, err
+	}
+
+	child := wire.NewMsgTx(tx.Version)
+	child.AddTxIn(&wire.TxIn{
+		PreviousOutPoint: wire.OutPoint{
+			Hash:  tx.TxHash(),
+			Index: uint32(params.CPFPIndex),
+		},
+		SignatureScript: sigScript,
+		Witness:         witness,
+		Sequence:        wire.MaxTxInSequenceNum,
+	})
+	child.AddTxOut(wire.NewTxOut(0, params.ChildPkScript))
+	vsize := weightToVSize(txWeight(child))
+
+	childFee := feeForVSize(parentVSize+vsize, params.FeeRate) - parentFee
+	if minFee := feeForVSize(vsize, relayFee); childFee < minFee {
+		childFee = minFee
+	}
+
+	child.TxIn[0].SignatureScript = nil
+	child.TxIn[0].Witness = nil
+	childOut := child.TxOut[0]
+	childOut.Value = parentOut.Value - int64(childFee)
+	if childOut.Value < 0 || isDustOutput(childOut, relayFee) {
+		return nil, fmt.Errorf("output of %v cannot pay the child fee "+
+			"of %v", btcutil.Amount(parentOut.Value), childFee)
+	}
+
+	return &FeeBumpOption{
+		Method:   FeeBumpCPFP,
+		Tx:       child,
+		VSize:    vsize,
+		Fee:      childFee,
+		ExtraFee: childFee,
+	}, nil
+}
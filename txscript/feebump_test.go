@@ -0,0 +1,159 @@
+package txscript
+
+import (
+	"testing"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/stretchr/testify/require"
+)
+
+// TestPlanFeeBump 确保 RBF 和 CPFP 方案在签名后达到目标费率，估算的大小不小于实际大小，并且不可行的方案被排除。
+func TestPlanFeeBump(t *testing.T) {
+	t.Parallel()
+
+	key, err := btcec.NewPrivateKey()
+	require.NoError(t, err)
+	p2wpkh, err := payToWitnessPubKeyHashScript(
+		btcutil.Hash160(key.PubKey().SerializeCompressed()),
+	)
+	require.NoError(t, err)
+
+	// signP2WPKH 签署 tx 中花费 prevOuts 的所有输入。
+	signP2WPKH := func(tx *wire.MsgTx, prevOuts map[wire.OutPoint]*wire.TxOut) {
+		fetcher := NewMultiPrevOutFetcher(prevOuts)
+		sigHashes := NewTxSigHashes(tx, fetcher)
+		for i, txIn := range tx.TxIn {
+			prevOut := prevOuts[txIn.PreviousOutPoint]
+			witness, err := WitnessSignature(tx, sigHashes, i,
+				prevOut.Value, prevOut.PkScript, SigHashAll, key, true)
+			require.NoError(t, err)
+			txIn.Witness = witness
+		}
+	}
+
+	const inputValue = 100000
+	newParent := func(change int64, sequence uint32) (*wire.MsgTx,
+		map[wire.OutPoint]*wire.TxOut) {
+
+		outpoint := wire.OutPoint{Hash: chainhash.Hash{1}}
+		prevOuts := map[wire.OutPoint]*wire.TxOut{
+			outpoint: wire.NewTxOut(inputValue, p2wpkh),
+		}
+		tx := wire.NewMsgTx(2)
+		tx.AddTxIn(&wire.TxIn{
+			PreviousOutPoint: outpoint,
+			Sequence:         sequence,
+		})
+		tx.AddTxOut(wire.NewTxOut(inputValue-change-200, p2wpkh))
+		tx.AddTxOut(wire.NewTxOut(change, p2wpkh))
+		signP2WPKH(tx, prevOuts)
+		return tx, prevOuts
+	}
+
+	const feeRate = 10000
+	parent, prevOuts := newParent(40000, wire.MaxTxInSequenceNum-2)
+	params := &FeeBumpParams{
+		Tx:             parent,
+		PrevOutFetcher: NewMultiPrevOutFetcher(prevOuts),
+		FeeRate:        feeRate,
+		ChangeIndex:    1,
+		CPFPIndex:      1,
+		ChildPkScript:  p2wpkh,
+	}
+	options, err := PlanFeeBump(params)
+	require.NoError(t, err)
+	require.Len(t, options, 2)
+	require.LessOrEqual(t, options[0].ExtraFee, options[1].ExtraFee)
+
+	for _, option := range options {
+		require.NotEmpty(t, option.Method.String())
+		for _, txIn := range option.Tx.TxIn {
+			require.Nil(t, txIn.Witness)
+			require.Nil(t, txIn.SignatureScript)
+		}
+
+		switch option.Method {
+		case FeeBumpRBF:
+			replacement := option.Tx
+			require.Equal(t, int64(40000)-int64(option.ExtraFee),
+				replacement.TxOut[1].Value)
+			signP2WPKH(replacement, prevOuts)
+
+			vsize := weightToVSize(txWeight(replacement))
+			require.LessOrEqual(t, vsize, option.VSize)
+			require.GreaterOrEqual(t, option.Fee,
+				feeForVSize(vsize, feeRate))
+			require.Equal(t, btcutil.Amount(200)+option.ExtraFee,
+				option.Fee)
+
+		case FeeBumpCPFP:
+			child := option.Tx
+			require.Equal(t, parent.TxHash(),
+				child.TxIn[0].PreviousOutPoint.Hash)
+			signP2WPKH(child, map[wire.OutPoint]*wire.TxOut{
+				child.TxIn[0].PreviousOutPoint: parent.TxOut[1],
+			})
+
+			vsize := weightToVSize(txWeight(child))
+			require.LessOrEqual(t, vsize, option.VSize)
+			packageVSize := vsize + weightToVSize(txWeight(parent))
+			require.GreaterOrEqual(t, 200+option.Fee,
+				feeForVSize(packageVSize, feeRate))
+		}
+	}
+
+	// 不发出替换信号的交易只能使用 CPFP。
+	parent, prevOuts = newParent(40000, wire.MaxTxInSequenceNum)
+	params.Tx = parent
+	params.PrevOutFetcher = NewMultiPrevOutFetcher(prevOuts)
+	options, err = PlanFeeBump(params)
+	require.NoError(t, err)
+	require.Len(t, options, 1)
+	require.Equal(t, FeeBumpCPFP, options[0].Method)
+
+	// 已经达到目标费率。
+	params.FeeRate = 1000
+	_, err = PlanFeeBump(params)
+	require.ErrorIs(t, err, ErrFeeBumpNotNeeded)
+
+	// 找零不足以支付额外的手续费。
+	parent, prevOuts = newParent(1000, wire.MaxTxInSequenceNum-2)
+	params = &FeeBumpParams{
+		Tx:             parent,
+		PrevOutFetcher: NewMultiPrevOutFetcher(prevOuts),
+		FeeRate:        feeRate,
+		ChangeIndex:    1,
+		CPFPIndex:      1,
+		ChildPkScript:  p2wpkh,
+	}
+	_, err = PlanFeeBump(params)
+	require.ErrorIs(t, err, ErrNoFeeBump)
+
+	// 无法估算花费大小的输出不能用于 CPFP。
+	params.CPFPIndex = 0
+	params.Tx.TxOut[0].PkScript = []byte{OP_TRUE}
+	_, err = PlanFeeBump(params)
+	require.ErrorIs(t, err, ErrNoFeeBump)
+}
+
+// TestMaxSignedWeight 确保较短的 ECDSA 签名按最大长度计算。
+func TestMaxSignedWeight(t *testing.T) {
+	t.Parallel()
+
+	sig := make([]byte, 71)
+	sig[0], sig[1] = 0x30, 68
+	sigScript, err := NewScriptBuilder().AddData(sig).
+		AddData(make([]byte, 33)).Script()
+	require.NoError(t, err)
+
+	tx := wire.NewMsgTx(2)
+	tx.AddTxIn(&wire.TxIn{SignatureScript: sigScript})
+	tx.AddTxIn(&wire.TxIn{Witness: wire.TxWitness{sig, make([]byte, 33)}})
+	tx.AddTxOut(wire.NewTxOut(0, nil))
+
+	require.Equal(t, txWeight(tx)+2*witnessScaleFactor+2,
+		maxSignedWeight(tx))
+}
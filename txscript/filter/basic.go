@@ -0,0 +1,106 @@
+// 包含 BIP0158 基本过滤器的构建：过滤器元素取自区块的输出脚本以及区块中各输入所花费输出的公钥脚本。
+
+package filter
+
+import (
+	"fmt"
+
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/qinglongcn/bpfschain/txscript"
+)
+
+// BlockKey 返回区块过滤器使用的 SipHash 密钥，即区块哈希（内部字节序）的前 16 个字节。
+func BlockKey(blockHash *chainhash.Hash) [KeySize]byte {
+	var key [KeySize]byte
+	copy(key[:], blockHash[:KeySize])
+	return key
+}
+
+// isFilterScript 返回公钥脚本是否应加入基本过滤器。 空脚本和以 OP_RETURN 开头的脚本无法被花费，
+// 钱包不会监视它们，因此被排除在外。
+func isFilterScript(pkScript []byte) bool {
+	return len(pkScript) != 0 && pkScript[0] != txscript.OP_RETURN
+}
+
+// BasicFilterElements 返回区块基本过滤器的元素：每个交易输出的公钥脚本，以及除 coinbase 外每个输入所花费输出的公钥脚本。
+// prevOuts 用于查找被花费的输出，找不到任何被花费的输出时返回错误。
+func BasicFilterElements(block *wire.MsgBlock,
+	prevOuts txscript.PrevOutputFetcher) ([][]byte, error) {
+
+	var elements [][]byte
+	for i, tx := range block.Transactions {
+		for _, txOut := range tx.TxOut {
+			if isFilterScript(txOut.PkScript) {
+				elements = append(elements, txOut.PkScript)
+			}
+		}
+
+		// The coinbase does not spend any outputs.
+		if i == 0 {
+			continue
+		}
+		for _, txIn := range tx.TxIn {
+			prevOut := prevOuts.FetchPrevOutput(txIn.PreviousOutPoint)
+			if prevOut == nil {
+				return nil, fmt.Errorf("%w: missing previous output %v",
+					ErrInvalidFilter, txIn.PreviousOutPoint)
+			}
+			if isFilterScript(prevOut.PkScript) {
+				elements = append(elements, prevOut.PkScript)
+			}
+		}
+	}
+	return elements, nil
+}
+
+// BuildBasicFilter 为区块构建 BIP0158 基本过滤器。 prevOuts 必须能够返回区块中每个非 coinbase 输入所花费的输出，
+// 包括被同一区块中较早交易创建的输出。
+func BuildBasicFilter(block *wire.MsgBlock,
+	prevOuts txscript.PrevOutputFetcher) (*Filter, error) {
+
+	elements, err := BasicFilterElements(block, prevOuts)
+	if err != nil {
+		return nil, err
+	}
+
+	blockHash := block.BlockHash()
+	return New(BlockKey(&blockHash), DefaultP, DefaultM, elements)
+}
+
+// ParseBasicFilter 解析以 CompactSize 元素数为前缀的序列化基本过滤器。
+func ParseBasicFilter(b []byte) (*Filter, error) {
+	return FromNBytes(DefaultP, DefaultM, b)
+}
+
+// MakeHeader 返回过滤器头，即过滤器哈希与前一个过滤器头连接后的双 SHA256 哈希。 创世区块的前一个过滤器头为全零。
+func MakeHeader(f *Filter, prevHeader *chainhash.Hash) chainhash.Hash {
+	filterHash := f.Hash()
+	var buf [2 * chainhash.HashSize]byte
+	copy(buf[:chainhash.HashSize], filterHash[:])
+	copy(buf[chainhash.HashSize:], prevHeader[:])
+	return chainhash.DoubleHashH(buf[:])
+}
+
+// MatchScripts 返回区块的基本过滤器是否可能包含任意一个公钥脚本。
+func MatchScripts(f *Filter, blockHash *chainhash.Hash,
+	pkScripts [][]byte) (bool, error) {
+
+	return f.MatchAny(BlockKey(blockHash), pkScripts)
+}
+
+// MatchAddresses 返回区块的基本过滤器是否可能包含支付到任意一个地址的输出或对这些输出的花费。
+func MatchAddresses(f *Filter, blockHash *chainhash.Hash,
+	addrs []btcutil.Address) (bool, error) {
+
+	pkScripts := make([][]byte, 0, len(addrs))
+	for _, addr := range addrs {
+		pkScript, err := txscript.PayToAddrScript(addr)
+		if err != nil {
+			return false, err
+		}
+		pkScripts = append(pkScripts, pkScript)
+	}
+	return MatchScripts(f, blockHash, pkScripts)
+}
@@ -0,0 +1,106 @@
+package filter
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/qinglongcn/bpfschain/txscript"
+	"github.com/stretchr/testify/require"
+)
+
+// TestBuildBasicFilterGenesis 确保测试网创世区块的基本过滤器和过滤器头与 BIP0158 测试向量一致。
+func TestBuildBasicFilterGenesis(t *testing.T) {
+	t.Parallel()
+
+	block := chaincfg.TestNet3Params.GenesisBlock
+	f, err := BuildBasicFilter(block, txscript.NewMultiPrevOutFetcher(nil))
+	require.NoError(t, err)
+	require.Equal(t, "019dfca8", hex.EncodeToString(f.NBytes()))
+
+	header := MakeHeader(f, &chainhash.Hash{})
+	require.Equal(t, "21584579b7eb08997773e5aeff3a7f932700042d0ed2a6129012b7d7ae81b750",
+		header.String())
+
+	blockHash := block.BlockHash()
+	match, err := MatchScripts(f, &blockHash,
+		[][]byte{block.Transactions[0].TxOut[0].PkScript})
+	require.NoError(t, err)
+	require.True(t, match)
+}
+
+// TestBuildBasicFilter 确保基本过滤器包含输出脚本和被花费输出的脚本，并排除 OP_RETURN 输出。
+func TestBuildBasicFilter(t *testing.T) {
+	t.Parallel()
+
+	params := &chaincfg.RegressionNetParams
+	newAddr := func(b byte) btcutil.Address {
+		var hash [20]byte
+		hash[0] = b
+		addr, err := btcutil.NewAddressWitnessPubKeyHash(hash[:], params)
+		require.NoError(t, err)
+		return addr
+	}
+	payTo := func(addr btcutil.Address) []byte {
+		pkScript, err := txscript.PayToAddrScript(addr)
+		require.NoError(t, err)
+		return pkScript
+	}
+	received, spent, unrelated := newAddr(1), newAddr(2), newAddr(3)
+
+	nullData, err := txscript.NullDataScript([]byte("anchor"))
+	require.NoError(t, err)
+
+	coinbase := wire.NewMsgTx(wire.TxVersion)
+	coinbase.AddTxIn(wire.NewTxIn(
+		wire.NewOutPoint(&chainhash.Hash{}, wire.MaxPrevOutIndex),
+		[]byte{txscript.OP_0, txscript.OP_0}, nil,
+	))
+	coinbase.AddTxOut(wire.NewTxOut(1e8, payTo(received)))
+	coinbase.AddTxOut(wire.NewTxOut(0, nullData))
+
+	prevOutPoint := wire.OutPoint{Hash: chainhash.Hash{9}}
+	spend := wire.NewMsgTx(wire.TxVersion)
+	spend.AddTxIn(wire.NewTxIn(&prevOutPoint, nil, nil))
+	spend.AddTxOut(wire.NewTxOut(1e8, nil))
+
+	block := wire.NewMsgBlock(&wire.BlockHeader{})
+	require.NoError(t, block.AddTransaction(coinbase))
+	require.NoError(t, block.AddTransaction(spend))
+
+	_, err = BuildBasicFilter(block, txscript.NewMultiPrevOutFetcher(nil))
+	require.ErrorIs(t, err, ErrInvalidFilter)
+
+	prevOuts := txscript.NewMultiPrevOutFetcher(map[wire.OutPoint]*wire.TxOut{
+		prevOutPoint: wire.NewTxOut(1e8, payTo(spent)),
+	})
+	elements, err := BasicFilterElements(block, prevOuts)
+	require.NoError(t, err)
+	require.Equal(t, [][]byte{payTo(received), payTo(spent)}, elements)
+
+	f, err := BuildBasicFilter(block, prevOuts)
+	require.NoError(t, err)
+	require.Equal(t, uint32(2), f.N())
+
+	parsed, err := ParseBasicFilter(f.NBytes())
+	require.NoError(t, err)
+
+	blockHash := block.BlockHash()
+	for _, addr := range []btcutil.Address{received, spent} {
+		match, err := MatchAddresses(parsed, &blockHash,
+			[]btcutil.Address{addr})
+		require.NoError(t, err)
+		require.True(t, match, addr)
+	}
+	match, err := MatchAddresses(parsed, &blockHash,
+		[]btcutil.Address{unrelated})
+	require.NoError(t, err)
+	require.False(t, match)
+
+	match, err = MatchScripts(parsed, &blockHash, [][]byte{nullData})
+	require.NoError(t, err)
+	require.False(t, match)
+}
@@ -0,0 +1,311 @@
+// Package filter 实现 BIP0158 紧凑区块过滤器（Golomb 编码集合，GCS）的构建和匹配。
+//
+// 服务端使用 BuildBasicFilter 从区块的输出脚本和被花费输出的公钥脚本生成过滤器，
+// 轻客户端使用 Filter.Match、Filter.MatchAny 或 MatchAddresses 判断区块是否可能包含与其相关的交易。
+package filter
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math/bits"
+	"sort"
+
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/wire"
+)
+
+const (
+	// KeySize 是 SipHash 密钥的字节数。
+	KeySize = 16
+
+	// DefaultP 是基本过滤器的 Golomb-Rice 参数，即每个元素余数的位数。
+	DefaultP = 19
+
+	// DefaultM 是基本过滤器的哈希范围倍数，误报率约为 1/M。
+	DefaultM uint64 = 784931
+
+	// MaxElements 是单个过滤器允许的最大元素数。
+	MaxElements = 1<<32 - 1
+)
+
+// ErrInvalidFilter 在过滤器参数无效或序列化的过滤器格式错误时返回。
+var ErrInvalidFilter = errors.New("invalid filter")
+
+// Filter 是一个不可变的 Golomb 编码集合。 元素在构建时被哈希到 [0, N*M) 范围内，排序后以 Golomb-Rice 编码存储相邻值的差。
+type Filter struct {
+	n    uint32
+	p    uint8
+	m    uint64
+	data []byte
+}
+
+// New 使用传入的密钥和参数为元素集合构建过滤器。 重复的元素只计入一次。
+func New(key [KeySize]byte, p uint8, m uint64, elements [][]byte) (*Filter, error) {
+	if p == 0 || p > 32 || m == 0 {
+		return nil, fmt.Errorf("%w: p must be in [1, 32] and m must be "+
+			"positive, got p=%d m=%d", ErrInvalidFilter, p, m)
+	}
+
+	unique := make(map[string]struct{}, len(elements))
+	for _, e := range elements {
+		unique[string(e)] = struct{}{}
+	}
+	if uint64(len(unique)) > MaxElements {
+		return nil, fmt.Errorf("%w: %d elements exceeds maximum of %d",
+			ErrInvalidFilter, len(unique), MaxElements)
+	}
+
+	f := &Filter{n: uint32(len(unique)), p: p, m: m}
+	k0, k1 := splitKey(key)
+	values := make([]uint64, 0, len(unique))
+	for e := range unique {
+		values = append(values, f.hashToRange(k0, k1, []byte(e)))
+	}
+	sort.Slice(values, func(i, j int) bool { return values[i] < values[j] })
+
+	var w bitWriter
+	var last uint64
+	for _, v := range values {
+		delta := v - last
+		last = v
+
+		// The quotient is written in unary followed by the p-bit
+		// remainder.
+		for q := delta >> p; q > 0; q-- {
+			w.writeBit(true)
+		}
+		w.writeBit(false)
+		w.writeBits(delta, p)
+	}
+	f.data = w.bytes
+
+	return f, nil
+}
+
+// FromBytes 使用元素数、参数和 Golomb-Rice 编码的数据重建过滤器。
+func FromBytes(n uint32, p uint8, m uint64, data []byte) (*Filter, error) {
+	if p == 0 || p > 32 || m == 0 {
+		return nil, fmt.Errorf("%w: p must be in [1, 32] and m must be "+
+			"positive, got p=%d m=%d", ErrInvalidFilter, p, m)
+	}
+	return &Filter{n: n, p: p, m: m, data: append([]byte(nil), data...)}, nil
+}
+
+// FromNBytes 解析以 CompactSize 元素数为前缀的过滤器，即 BIP0158 在 P2P 消息中使用的格式。
+func FromNBytes(p uint8, m uint64, b []byte) (*Filter, error) {
+	r := bytes.NewReader(b)
+	n, err := wire.ReadVarInt(r, 0)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidFilter, err)
+	}
+	if n > MaxElements {
+		return nil, fmt.Errorf("%w: %d elements exceeds maximum of %d",
+			ErrInvalidFilter, n, MaxElements)
+	}
+	return FromBytes(uint32(n), p, m, b[len(b)-r.Len():])
+}
+
+// N 返回过滤器中的元素数。
+func (f *Filter) N() uint32 {
+	return f.n
+}
+
+// P 返回过滤器的 Golomb-Rice 参数。
+func (f *Filter) P() uint8 {
+	return f.p
+}
+
+// Bytes 返回不含元素数前缀的 Golomb-Rice 编码数据。
+func (f *Filter) Bytes() []byte {
+	return append([]byte(nil), f.data...)
+}
+
+// NBytes 返回以 CompactSize 元素数为前缀的序列化过滤器。
+func (f *Filter) NBytes() []byte {
+	var buf bytes.Buffer
+	buf.Grow(wire.VarIntSerializeSize(uint64(f.n)) + len(f.data))
+	_ = wire.WriteVarInt(&buf, 0, uint64(f.n))
+	buf.Write(f.data)
+	return buf.Bytes()
+}
+
+// Hash 返回序列化过滤器的双 SHA256 哈希，用于计算过滤器头。
+func (f *Filter) Hash() chainhash.Hash {
+	return chainhash.DoubleHashH(f.NBytes())
+}
+
+// Match 返回元素是否可能在过滤器中。 返回 false 时元素一定不在集合中；返回 true 时有约 1/M 的概率是误报。
+func (f *Filter) Match(key [KeySize]byte, element []byte) (bool, error) {
+	return f.MatchAny(key, [][]byte{element})
+}
+
+// MatchAny 返回任意一个元素是否可能在过滤器中。 与逐个调用 Match 相比，过滤器只被解码一次。
+func (f *Filter) MatchAny(key [KeySize]byte, elements [][]byte) (bool, error) {
+	if f.n == 0 || len(elements) == 0 {
+		return false, nil
+	}
+
+	k0, k1 := splitKey(key)
+	queries := make([]uint64, len(elements))
+	for i, e := range elements {
+		queries[i] = f.hashToRange(k0, k1, e)
+	}
+	sort.Slice(queries, func(i, j int) bool { return queries[i] < queries[j] })
+
+	r := bitReader{bytes: f.data}
+	var value uint64
+	qi := 0
+	for i := uint32(0); i < f.n; i++ {
+		delta, err := r.readGolombRice(f.p)
+		if err != nil {
+			return false, err
+		}
+		value += delta
+
+		for qi < len(queries) && queries[qi] < value {
+			qi++
+		}
+		if qi == len(queries) {
+			return false, nil
+		}
+		if queries[qi] == value {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// hashToRange 将元素均匀映射到 [0, N*M) 范围内。
+func (f *Filter) hashToRange(k0, k1 uint64, element []byte) uint64 {
+	hi, _ := bits.Mul64(sipHash(k0, k1, element), uint64(f.n)*f.m)
+	return hi
+}
+
+// splitKey 将 16 字节密钥拆分为两个小端序的 SipHash 密钥字。
+func splitKey(key [KeySize]byte) (uint64, uint64) {
+	return binary.LittleEndian.Uint64(key[:8]),
+		binary.LittleEndian.Uint64(key[8:])
+}
+
+// sipHash 计算 SipHash-2-4。
+func sipHash(k0, k1 uint64, p []byte) uint64 {
+	v0 := k0 ^ 0x736f6d6570736575
+	v1 := k1 ^ 0x646f72616e646f6d
+	v2 := k0 ^ 0x6c7967656e657261
+	v3 := k1 ^ 0x7465646279746573
+
+	round := func() {
+		v0 += v1
+		v1 = bits.RotateLeft64(v1, 13)
+		v1 ^= v0
+		v0 = bits.RotateLeft64(v0, 32)
+		v2 += v3
+		v3 = bits.RotateLeft64(v3, 16)
+		v3 ^= v2
+		v0 += v3
+		v3 = bits.RotateLeft64(v3, 21)
+		v3 ^= v0
+		v2 += v1
+		v1 = bits.RotateLeft64(v1, 17)
+		v1 ^= v2
+		v2 = bits.RotateLeft64(v2, 32)
+	}
+
+	last := uint64(len(p)) << 56
+	for len(p) >= 8 {
+		m := binary.LittleEndian.Uint64(p)
+		v3 ^= m
+		round()
+		round()
+		v0 ^= m
+		p = p[8:]
+	}
+	for i, b := range p {
+		last |= uint64(b) << (8 * i)
+	}
+	v3 ^= last
+	round()
+	round()
+	v0 ^= last
+
+	v2 ^= 0xff
+	round()
+	round()
+	round()
+	round()
+
+	return v0 ^ v1 ^ v2 ^ v3
+}
+
+// bitWriter 按从高位到低位的顺序写入位。
+type bitWriter struct {
+	bytes []byte
+	n     uint8
+}
+
+// writeBit 写入单个位。
+func (w *bitWriter) writeBit(bit bool) {
+	if w.n == 0 {
+		w.bytes = append(w.bytes, 0)
+		w.n = 8
+	}
+	w.n--
+	if bit {
+		w.bytes[len(w.bytes)-1] |= 1 << w.n
+	}
+}
+
+// writeBits 写入 v 的低 n 位。
+func (w *bitWriter) writeBits(v uint64, n uint8) {
+	for i := int(n) - 1; i >= 0; i-- {
+		w.writeBit(v>>uint(i)&1 == 1)
+	}
+}
+
+// bitReader 按从高位到低位的顺序读取位。
+type bitReader struct {
+	bytes []byte
+	pos   int
+}
+
+// readBit 读取单个位。
+func (r *bitReader) readBit() (bool, error) {
+	if r.pos >= len(r.bytes)*8 {
+		return false, fmt.Errorf("%w: %v", ErrInvalidFilter,
+			io.ErrUnexpectedEOF)
+	}
+	bit := r.bytes[r.pos/8]>>(7-uint(r.pos%8))&1 == 1
+	r.pos++
+	return bit, nil
+}
+
+// readGolombRice 读取一个参数为 p 的 Golomb-Rice 编码值。
+func (r *bitReader) readGolombRice(p uint8) (uint64, error) {
+	var q uint64
+	for {
+		bit, err := r.readBit()
+		if err != nil {
+			return 0, err
+		}
+		if !bit {
+			break
+		}
+		q++
+	}
+
+	var rem uint64
+	for i := uint8(0); i < p; i++ {
+		bit, err := r.readBit()
+		if err != nil {
+			return 0, err
+		}
+		rem <<= 1
+		if bit {
+			rem |= 1
+		}
+	}
+	return q<<p | rem, nil
+}
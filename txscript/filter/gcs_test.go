@@ -0,0 +1,94 @@
+package filter
+
+import (
+	"encoding/binary"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// TestSipHash 确保 SipHash-2-4 的实现与参考实现的测试向量一致。
+func TestSipHash(t *testing.T) {
+	t.Parallel()
+
+	var key [KeySize]byte
+	for i := range key {
+		key[i] = byte(i)
+	}
+	k0, k1 := splitKey(key)
+
+	msg := make([]byte, 15)
+	for i := range msg {
+		msg[i] = byte(i)
+	}
+
+	require.Equal(t, uint64(0x726fdb47dd0e0e31), sipHash(k0, k1, nil))
+	require.Equal(t, uint64(0xa129ca6149be45e5), sipHash(k0, k1, msg))
+}
+
+// TestFilterMatch 确保过滤器包含所有构建时的元素，序列化后可以重建，并且对不在集合中的元素误报率接近 1/M。
+func TestFilterMatch(t *testing.T) {
+	t.Parallel()
+
+	var key [KeySize]byte
+	copy(key[:], "bpfschain filter")
+
+	elements := make([][]byte, 500)
+	for i := range elements {
+		elements[i] = []byte(fmt.Sprintf("element %d", i))
+	}
+	// 重复的元素只计入一次。
+	f, err := New(key, DefaultP, DefaultM, append(elements, elements[0]))
+	require.NoError(t, err)
+	require.Equal(t, uint32(len(elements)), f.N())
+
+	parsed, err := FromNBytes(DefaultP, DefaultM, f.NBytes())
+	require.NoError(t, err)
+	require.Equal(t, f.Bytes(), parsed.Bytes())
+	require.Equal(t, f.Hash(), parsed.Hash())
+
+	for _, e := range elements {
+		match, err := parsed.Match(key, e)
+		require.NoError(t, err)
+		require.True(t, match)
+	}
+
+	var falsePositives int
+	for i := 0; i < 10000; i++ {
+		var e [8]byte
+		binary.LittleEndian.PutUint64(e[:], uint64(i))
+		match, err := f.Match(key, e[:])
+		require.NoError(t, err)
+		if match {
+			falsePositives++
+		}
+	}
+	require.LessOrEqual(t, falsePositives, 2)
+
+	match, err := f.MatchAny(key, [][]byte{[]byte("missing"), elements[42]})
+	require.NoError(t, err)
+	require.True(t, match)
+
+	// 使用不同的密钥时元素无法匹配。
+	var otherKey [KeySize]byte
+	match, err = f.Match(otherKey, elements[0])
+	require.NoError(t, err)
+	require.False(t, match)
+
+	// 截断的过滤器在解码时返回错误。
+	truncated, err := FromBytes(f.N(), DefaultP, DefaultM, f.Bytes()[:10])
+	require.NoError(t, err)
+	_, err = truncated.Match(key, []byte("missing"))
+	require.ErrorIs(t, err, ErrInvalidFilter)
+
+	empty, err := New(key, DefaultP, DefaultM, nil)
+	require.NoError(t, err)
+	require.Equal(t, []byte{0}, empty.NBytes())
+	match, err = empty.Match(key, elements[0])
+	require.NoError(t, err)
+	require.False(t, match)
+
+	_, err = New(key, 0, DefaultM, elements)
+	require.ErrorIs(t, err, ErrInvalidFilter)
+}
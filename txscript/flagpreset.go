@@ -0,0 +1,204 @@
+// 包含命名的脚本验证标志预设，使运营者可以在配置文件中以文本形式指定标志，而不必在代码中组合位掩码。
+
+package txscript
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/btcsuite/btcd/chaincfg"
+)
+
+// 脚本验证标志预设。
+const (
+	// ConsensusFlags 是私有链从创世区块起生效的共识标志，与 DefaultDeploymentSchedule 在高度 0 的标志相同。
+	ConsensusFlags = ScriptBip16 |
+		ScriptVerifyCheckLockTimeVerify |
+		ScriptVerifyDERSignatures |
+		ScriptVerifyCheckSequenceVerify |
+		ScriptVerifyWitness |
+		ScriptStrictMultiSig |
+		ScriptVerifyTaproot |
+		ScriptVerifyRejectUnknownWitnessVersion |
+		ScriptVerifyRejectUnknownScriptVersion |
+		ScriptVerifyStructuredAnnex
+
+	// MempoolStandardFlags 是内存池接受交易时使用的标志，即 StandardVerifyFlags 加上共识标志。 策略规则必须比共识规则更严格，
+	// 否则内存池会接受无法打包进区块的交易。
+	MempoolStandardFlags = StandardVerifyFlags | ConsensusFlags
+
+	// discourageUpgradeFlags 是为将来的软分叉保留操作码、见证版本、叶子版本和公钥类型的策略标志。
+	discourageUpgradeFlags = ScriptDiscourageUpgradableNops |
+		ScriptVerifyDiscourageUpgradeableWitnessProgram |
+		ScriptVerifyDiscourageUpgradeableTaprootVersion |
+		ScriptVerifyDiscourageOpSuccess |
+		ScriptVerifyDiscourageUpgradeablePubkeyType
+
+	// RelaxedPrivateChainFlags 是去掉升级保留规则的 MempoolStandardFlags，适用于在私有链上试验新操作码和脚本版本。
+	// 防止延展性的规则仍然生效。
+	RelaxedPrivateChainFlags = MempoolStandardFlags &^ discourageUpgradeFlags
+)
+
+// 内置标志预设的名称。
+const (
+	// FlagPresetConsensus 是 ConsensusFlags 预设的名称。
+	FlagPresetConsensus = "consensus"
+
+	// FlagPresetMempoolStandard 是 MempoolStandardFlags 预设的名称。
+	FlagPresetMempoolStandard = "standard"
+
+	// FlagPresetRelaxedPrivateChain 是 RelaxedPrivateChainFlags 预设的名称。
+	FlagPresetRelaxedPrivateChain = "relaxed"
+)
+
+var (
+	// ErrUnknownFlagPreset 在请求的标志预设不存在时返回。
+	ErrUnknownFlagPreset = errors.New("unknown flag preset")
+
+	// ErrInvalidFlagPreset 在注册的标志预设无效或名称已被使用时返回。
+	ErrInvalidFlagPreset = errors.New("invalid flag preset")
+)
+
+// FlagPreset 是命名的脚本验证标志集合。
+type FlagPreset struct {
+	// Name 是预设的名称，由小写字母、数字、连字符和下划线组成，与大写的标志名称区分。
+	Name string
+
+	// Flags 是预设的标志。
+	Flags ScriptFlags
+
+	// Description 是预设用途的简短描述。
+	Description string
+}
+
+// String 返回预设的文本形式，例如 "standard: P2SH,STRICTENC,..."。
+func (p FlagPreset) String() string {
+	return p.Name + ": " + p.Flags.String()
+}
+
+var (
+	// flagPresetsMtx 保护 flagPresets。
+	flagPresetsMtx sync.RWMutex
+
+	// flagPresets 保存内置和已注册的标志预设。
+	flagPresets = map[string]FlagPreset{
+		FlagPresetConsensus: {
+			Name:        FlagPresetConsensus,
+			Flags:       ConsensusFlags,
+			Description: "consensus rules active since genesis",
+		},
+		FlagPresetMempoolStandard: {
+			Name:        FlagPresetMempoolStandard,
+			Flags:       MempoolStandardFlags,
+			Description: "standardness rules used for mempool acceptance",
+		},
+		FlagPresetRelaxedPrivateChain: {
+			Name:  FlagPresetRelaxedPrivateChain,
+			Flags: RelaxedPrivateChainFlags,
+			Description: "standardness rules without the upgrade " +
+				"discouragement rules",
+		},
+	}
+)
+
+// isValidPresetName 返回名称是否只由小写字母、数字、连字符和下划线组成。
+func isValidPresetName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for _, c := range name {
+		switch {
+		case c >= 'a' && c <= 'z', c >= '0' && c <= '9', c == '-', c == '_':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// RegisterFlagPreset 注册自定义的标志预设，使其可以通过 GetFlagPreset 和 ResolveScriptFlags 按名称获取。
+// 名称无效、已被使用或标志包含未知的标志位时返回 ErrInvalidFlagPreset。
+func RegisterFlagPreset(preset FlagPreset) error {
+	if !isValidPresetName(preset.Name) {
+		return fmt.Errorf("%w: invalid name %q", ErrInvalidFlagPreset,
+			preset.Name)
+	}
+	if _, err := FormatScriptFlags(preset.Flags); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidFlagPreset, err)
+	}
+
+	flagPresetsMtx.Lock()
+	defer flagPresetsMtx.Unlock()
+
+	if _, ok := flagPresets[preset.Name]; ok {
+		return fmt.Errorf("%w: preset %q already exists",
+			ErrInvalidFlagPreset, preset.Name)
+	}
+	flagPresets[preset.Name] = preset
+	return nil
+}
+
+// GetFlagPreset 返回名为 name 的标志预设，不存在时返回 ErrUnknownFlagPreset。
+func GetFlagPreset(name string) (FlagPreset, error) {
+	flagPresetsMtx.RLock()
+	defer flagPresetsMtx.RUnlock()
+
+	preset, ok := flagPresets[name]
+	if !ok {
+		return FlagPreset{}, fmt.Errorf("%w: %q", ErrUnknownFlagPreset,
+			name)
+	}
+	return preset, nil
+}
+
+// FlagPresets 返回所有内置和已注册的标志预设，按名称排序。
+func FlagPresets() []FlagPreset {
+	flagPresetsMtx.RLock()
+	defer flagPresetsMtx.RUnlock()
+
+	presets := make([]FlagPreset, 0, len(flagPresets))
+	for _, preset := range flagPresets {
+		presets = append(presets, preset)
+	}
+	sort.Slice(presets, func(i, j int) bool {
+		return presets[i].Name < presets[j].Name
+	})
+	return presets
+}
+
+// ResolveScriptFlags 解析配置文件中的标志值，值可以是预设名称，也可以是 ParseScriptFlags 接受的以逗号分隔的标志名称。
+// 预设名称之后可以用逗号附加额外的标志，例如 "relaxed,DISCOURAGE_OP_SUCCESS"。
+func ResolveScriptFlags(value string) (ScriptFlags, error) {
+	value = strings.TrimSpace(value)
+	name, rest, _ := strings.Cut(value, ",")
+	name = strings.TrimSpace(name)
+	if !isValidPresetName(name) {
+		return ParseScriptFlags(value)
+	}
+
+	preset, err := GetFlagPreset(name)
+	if err != nil {
+		return 0, err
+	}
+	extra, err := ParseScriptFlags(rest)
+	if err != nil {
+		return 0, err
+	}
+	return preset.Flags | extra, nil
+}
+
+// NetworkFlagPreset 返回网络默认使用的标志预设：回归测试和模拟测试网络使用 FlagPresetRelaxedPrivateChain，
+// 其他网络使用 FlagPresetMempoolStandard。
+func NetworkFlagPreset(params *chaincfg.Params) FlagPreset {
+	name := FlagPresetMempoolStandard
+	switch params.Net {
+	case chaincfg.RegressionNetParams.Net, chaincfg.SimNetParams.Net:
+		name = FlagPresetRelaxedPrivateChain
+	}
+
+	preset, _ := GetFlagPreset(name)
+	return preset
+}
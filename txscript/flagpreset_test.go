@@ -0,0 +1,112 @@
+package txscript
+
+import (
+	"testing"
+
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/stretchr/testify/require"
+)
+
+// TestFlagPresets 确保内置预设可以按名称获取，并且标志之间的关系符合预期。
+func TestFlagPresets(t *testing.T) {
+	t.Parallel()
+
+	require.Equal(t, DefaultDeploymentSchedule().FlagsAt(0, nil),
+		ConsensusFlags)
+	require.Equal(t, ConsensusFlags, MempoolStandardFlags&ConsensusFlags)
+	require.Equal(t, ConsensusFlags, RelaxedPrivateChainFlags&ConsensusFlags)
+	require.Zero(t, RelaxedPrivateChainFlags&ScriptVerifyDiscourageOpSuccess)
+	require.NotZero(t, RelaxedPrivateChainFlags&ScriptVerifyLowS)
+
+	for name, flags := range map[string]ScriptFlags{
+		FlagPresetConsensus:           ConsensusFlags,
+		FlagPresetMempoolStandard:     MempoolStandardFlags,
+		FlagPresetRelaxedPrivateChain: RelaxedPrivateChainFlags,
+	} {
+		preset, err := GetFlagPreset(name)
+		require.NoError(t, err)
+		require.Equal(t, flags, preset.Flags)
+		require.NotEmpty(t, preset.Description)
+	}
+	_, err := GetFlagPreset("bogus")
+	require.ErrorIs(t, err, ErrUnknownFlagPreset)
+
+	preset, err := GetFlagPreset(FlagPresetConsensus)
+	require.NoError(t, err)
+	require.Equal(t, "consensus: P2SH,DERSIG,NULLDUMMY,CHECKLOCKTIMEVERIFY,"+
+		"CHECKSEQUENCEVERIFY,WITNESS,TAPROOT,REJECT_UNKNOWN_WITNESS_VERSION,"+
+		"REJECT_UNKNOWN_SCRIPT_VERSION,STRUCTURED_ANNEX", preset.String())
+
+	require.Equal(t, FlagPresetMempoolStandard,
+		NetworkFlagPreset(&chaincfg.MainNetParams).Name)
+	require.Equal(t, FlagPresetRelaxedPrivateChain,
+		NetworkFlagPreset(&chaincfg.RegressionNetParams).Name)
+}
+
+// TestRegisterFlagPreset 确保可以注册自定义预设，并拒绝无效或重复的预设。
+func TestRegisterFlagPreset(t *testing.T) {
+	t.Parallel()
+
+	custom := FlagPreset{
+		Name:  "test-preset_1",
+		Flags: ConsensusFlags | ScriptVerifyNullFail,
+	}
+	require.NoError(t, RegisterFlagPreset(custom))
+	preset, err := GetFlagPreset(custom.Name)
+	require.NoError(t, err)
+	require.Equal(t, custom, preset)
+	require.Contains(t, FlagPresets(), custom)
+
+	presets := FlagPresets()
+	for i := 1; i < len(presets); i++ {
+		require.Less(t, presets[i-1].Name, presets[i].Name)
+	}
+
+	for _, invalid := range []FlagPreset{
+		custom,
+		{Name: FlagPresetConsensus},
+		{Name: ""},
+		{Name: "Upper"},
+		{Name: "with space"},
+		{Name: "unknown-flags", Flags: 1 << 31},
+	} {
+		require.ErrorIs(t, RegisterFlagPreset(invalid),
+			ErrInvalidFlagPreset, invalid.Name)
+	}
+}
+
+// TestResolveScriptFlags 确保配置值可以使用预设名称、标志名称或二者的组合。
+func TestResolveScriptFlags(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		value string
+		flags ScriptFlags
+	}{
+		{"consensus", ConsensusFlags},
+		{" relaxed , DISCOURAGE_OP_SUCCESS", RelaxedPrivateChainFlags |
+			ScriptVerifyDiscourageOpSuccess},
+		{"P2SH, WITNESS", ScriptBip16 | ScriptVerifyWitness},
+		{"NONE", 0},
+		{"", 0},
+	}
+	for _, test := range tests {
+		flags, err := ResolveScriptFlags(test.value)
+		require.NoError(t, err, test.value)
+		require.Equal(t, test.flags, flags, test.value)
+	}
+
+	_, err := ResolveScriptFlags("unknown")
+	require.ErrorIs(t, err, ErrUnknownFlagPreset)
+	_, err = ResolveScriptFlags("standard,BOGUS")
+	require.ErrorIs(t, err, ErrUnknownScriptFlag)
+	_, err = ResolveScriptFlags("P2SH,BOGUS")
+	require.ErrorIs(t, err, ErrUnknownScriptFlag)
+
+	// 格式化的标志可以解析回来。
+	str, err := FormatScriptFlags(MempoolStandardFlags)
+	require.NoError(t, err)
+	flags, err := ParseScriptFlags(str)
+	require.NoError(t, err)
+	require.Equal(t, MempoolStandardFlags, flags)
+}
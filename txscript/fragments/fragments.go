@@ -0,0 +1,48 @@
+// Package fragments 提供经过验证的可复用脚本片段：时间锁守卫、哈希锁守卫、公钥检查和阈值签名累加器。
+// 每个片段都有构建脚本的 Script、构造见证元素的满足器以及字节大小常量，并实现 txscript.ScriptFragment，
+// 因此可以用 ScriptBuilder.AddFragment 与其它片段或操作码组合。
+//
+// 所有片段都是守卫形式：片段从栈顶消耗自己的见证元素，检查失败时脚本立即失败，成功时不在栈上留下任何元素。
+// 因此片段可以按任意顺序直接拼接，拼接后的脚本需要以留下真值的操作码结束，Compose 为此在末尾追加 OP_TRUE。
+//
+// 片段按顺序执行，第一个片段消耗栈顶的见证元素，因此组合脚本的见证应当用 Witness 按片段的顺序组装。
+package fragments
+
+import (
+	"errors"
+
+	"github.com/btcsuite/btcd/wire"
+	"github.com/qinglongcn/bpfschain/txscript"
+)
+
+var (
+	// ErrInvalidFragment 在片段的参数无效时返回。
+	ErrInvalidFragment = errors.New("invalid script fragment")
+
+	// ErrUnsatisfiable 在满足器无法用给定的数据满足片段时返回。
+	ErrUnsatisfiable = errors.New("fragment cannot be satisfied")
+)
+
+// Compose 返回依次执行 frags 后以 OP_TRUE 结束的脚本，任一片段无效时返回该片段的错误。
+func Compose(frags ...txscript.ScriptFragment) ([]byte, error) {
+	builder := txscript.NewScriptBuilder()
+	for _, frag := range frags {
+		builder.AddFragment(frag)
+	}
+	return builder.AddOp(txscript.OP_TRUE).Script()
+}
+
+// Witness 将各片段满足器返回的见证元素组装为组合脚本的见证，items[i] 是第 i 个片段的元素。
+// 先执行的片段的元素位于栈顶，即见证的末尾。 调用者需要在末尾追加见证脚本，或者 tapscript 的叶子脚本和控制块。
+func Witness(items ...[][]byte) wire.TxWitness {
+	var witness wire.TxWitness
+	for i := len(items) - 1; i >= 0; i-- {
+		witness = append(witness, items[i]...)
+	}
+	return witness
+}
+
+// pushSize 返回规范推送 data 占用的字节数。
+func pushSize(data []byte) int {
+	return len(txscript.NewScriptBuilder().AddData(data).MustScript())
+}
@@ -0,0 +1,185 @@
+package fragments
+
+import (
+	"crypto/sha256"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcec/v2/schnorr"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/qinglongcn/bpfschain/txscript"
+	"github.com/stretchr/testify/require"
+)
+
+// spendAmount 是测试中被花费的输出的金额。
+const spendAmount = 100000
+
+// spend 是花费组合脚本的见证版本 0 或 tapscript 输入。
+type spend struct {
+	t         *testing.T
+	tapscript bool
+	script    []byte
+	pkScript  []byte
+	leaf      txscript.TapLeaf
+	ctrlBlock []byte
+	tx        *wire.MsgTx
+	fetcher   txscript.PrevOutputFetcher
+}
+
+// newSpend 返回花费以 script 为见证脚本（tapscript 为 true 时为叶子脚本）的输出的交易。
+func newSpend(t *testing.T, script []byte, tapscript bool) *spend {
+	s := &spend{t: t, tapscript: tapscript, script: script}
+	if tapscript {
+		internalKey, err := btcec.NewPrivateKey()
+		require.NoError(t, err)
+		s.leaf = txscript.NewBaseTapLeaf(script)
+		tree := txscript.AssembleTaprootScriptTree(s.leaf)
+		rootHash := tree.RootNode.TapHash()
+		outputKey := txscript.ComputeTaprootOutputKey(
+			internalKey.PubKey(), rootHash[:],
+		)
+		s.pkScript, err = txscript.PayToTaprootScript(outputKey)
+		require.NoError(t, err)
+		ctrlBlock := tree.LeafMerkleProofs[0].ToControlBlock(
+			internalKey.PubKey(),
+		)
+		s.ctrlBlock, err = ctrlBlock.ToBytes()
+		require.NoError(t, err)
+	} else {
+		scriptHash := sha256.Sum256(script)
+		s.pkScript = txscript.NewScriptBuilder().AddOp(txscript.OP_0).
+			AddData(scriptHash[:]).MustScript()
+	}
+
+	prevTx := wire.NewMsgTx(wire.TxVersion)
+	prevTx.AddTxIn(wire.NewTxIn(
+		wire.NewOutPoint(&chainhash.Hash{}, ^uint32(0)), nil, nil,
+	))
+	prevTx.AddTxOut(wire.NewTxOut(spendAmount, s.pkScript))
+	prevHash := prevTx.TxHash()
+
+	s.tx = wire.NewMsgTx(wire.TxVersion)
+	s.tx.AddTxIn(wire.NewTxIn(wire.NewOutPoint(&prevHash, 0), nil, nil))
+	s.tx.AddTxOut(wire.NewTxOut(spendAmount-1000, nil))
+	s.fetcher = txscript.NewCannedPrevOutputFetcher(s.pkScript, spendAmount)
+	return s
+}
+
+// sign 返回 key 对交易当前状态的签名。
+func (s *spend) sign(key *btcec.PrivateKey) []byte {
+	sigHashes := txscript.NewTxSigHashes(s.tx, s.fetcher)
+	var (
+		sig []byte
+		err error
+	)
+	if s.tapscript {
+		sig, err = txscript.RawTxInTapscriptSignature(s.tx, sigHashes, 0,
+			spendAmount, s.pkScript, s.leaf, txscript.SigHashDefault, key)
+	} else {
+		sig, err = txscript.RawTxInWitnessSignature(s.tx, sigHashes, 0,
+			spendAmount, s.script, txscript.SigHashAll, key)
+	}
+	require.NoError(s.t, err)
+	return sig
+}
+
+// pubKey 返回 key 在脚本中使用的编码。
+func (s *spend) pubKey(key *btcec.PrivateKey) []byte {
+	if s.tapscript {
+		return schnorr.SerializePubKey(key.PubKey())
+	}
+	return key.PubKey().SerializeCompressed()
+}
+
+// execute 以 items 为各片段的见证元素执行花费。
+func (s *spend) execute(items ...[][]byte) error {
+	witness := Witness(items...)
+	witness = append(witness, s.script)
+	if s.tapscript {
+		witness = append(witness, s.ctrlBlock)
+	}
+	s.tx.TxIn[0].Witness = witness
+
+	sigHashes := txscript.NewTxSigHashes(s.tx, s.fetcher)
+	vm, err := txscript.NewEngine(s.pkScript, s.tx, 0,
+		txscript.StandardVerifyFlags, nil, sigHashes, spendAmount,
+		s.fetcher)
+	require.NoError(s.t, err)
+	return vm.Execute()
+}
+
+// newKeys 返回 n 个随机私钥。
+func newKeys(t *testing.T, n int) []*btcec.PrivateKey {
+	keys := make([]*btcec.PrivateKey, n)
+	for i := range keys {
+		key, err := btcec.NewPrivateKey()
+		require.NoError(t, err)
+		keys[i] = key
+	}
+	return keys
+}
+
+// TestCompose 确保组合所有片段的脚本在两种脚本版本中都能用 Witness 组装的见证花费，并且顺序错误的见证失败。
+func TestCompose(t *testing.T) {
+	t.Parallel()
+
+	preimage := sha256.Sum256([]byte("preimage"))
+	hash := sha256.Sum256(preimage[:])
+	hashlock := &HashlockGuard{Lock: txscript.HashLock{
+		Type: txscript.HashLockSHA256, Hash: hash[:],
+	}}
+	timelock := &TimelockGuard{LockTime: 10, Relative: true}
+
+	for _, tapscript := range []bool{false, true} {
+		keys := newKeys(t, 3)
+		pubKeys := func(s *spend) [][]byte {
+			return [][]byte{
+				s.pubKey(keys[0]), s.pubKey(keys[1]), s.pubKey(keys[2]),
+			}
+		}
+
+		// The keys depend on the script version, so build a throwaway
+		// spend to encode them before the real script exists.
+		encoding := &spend{tapscript: tapscript}
+		keyCheck := &KeyCheck{PubKey: encoding.pubKey(keys[0])}
+		threshold := &ThresholdAccumulator{
+			Threshold: 2, PubKeys: pubKeys(encoding),
+		}
+		script, err := Compose(timelock, hashlock, keyCheck, threshold)
+		require.NoError(t, err)
+		require.Len(t, script, timelock.Size()+hashlock.Size()+
+			keyCheck.Size()+threshold.Size()+1)
+
+		s := newSpend(t, script, tapscript)
+		timelockItems, err := timelock.Satisfy(s.tx, 0)
+		require.NoError(t, err)
+		hashlockItems, err := hashlock.Satisfy(preimage[:])
+		require.NoError(t, err)
+		keyItems, err := keyCheck.Satisfy(s.sign(keys[0]))
+		require.NoError(t, err)
+		thresholdItems, err := threshold.Satisfy([][]byte{
+			nil, s.sign(keys[1]), s.sign(keys[2]),
+		})
+		require.NoError(t, err)
+
+		require.NoError(t, s.execute(
+			timelockItems, hashlockItems, keyItems, thresholdItems,
+		), "tapscript=%v", tapscript)
+		require.Error(t, s.execute(
+			timelockItems, keyItems, hashlockItems, thresholdItems,
+		), "tapscript=%v", tapscript)
+	}
+
+	_, err := Compose(&KeyCheck{PubKey: []byte{1, 2, 3}})
+	require.ErrorIs(t, err, ErrInvalidFragment)
+}
+
+// TestWitness 确保先执行的片段的见证元素位于见证末尾。
+func TestWitness(t *testing.T) {
+	t.Parallel()
+
+	witness := Witness([][]byte{{1}}, nil, [][]byte{{2}, {3}})
+	require.Equal(t, wire.TxWitness{{2}, {3}, {1}}, witness)
+	require.Empty(t, Witness())
+}
@@ -0,0 +1,77 @@
+package fragments
+
+import (
+	"fmt"
+
+	"github.com/qinglongcn/bpfschain/txscript"
+)
+
+const (
+	// HashlockPreimageSize 是哈希锁守卫要求的原像字节数。
+	HashlockPreimageSize = 32
+
+	// HashlockGuardSHA256Size 是使用 SHA256 或 HASH256 的哈希锁守卫的字节数。
+	HashlockGuardSHA256Size = 39
+
+	// HashlockGuardHash160Size 是使用 HASH160、RIPEMD160 或 SHA1 的哈希锁守卫的字节数。
+	HashlockGuardHash160Size = 27
+)
+
+// HashlockGuard 是要求提供哈希原像的守卫：
+//
+//	SIZE 32 EQUALVERIFY <hash op> <hash> EQUALVERIFY
+//
+// 原像的长度固定为 HashlockPreimageSize 字节。 省略长度检查是常见的错误：对方可以使用过长的原像使另一条链上的合约无法兑现，
+// 或在原子交换中利用两条链对元素大小的不同限制。 片段从栈顶消耗一个见证元素，即原像。
+type HashlockGuard struct {
+	// Lock 是原像必须匹配的哈希锁。
+	Lock txscript.HashLock
+}
+
+// opcode 检查哈希锁并返回其哈希操作码。
+func (g *HashlockGuard) opcode() (byte, error) {
+	op, ok := g.Lock.Type.Opcode()
+	if !ok {
+		return 0, fmt.Errorf("%w: hashlock guard: unknown hash type %v",
+			ErrInvalidFragment, g.Lock.Type)
+	}
+	if size := len(g.Lock.Type.Digest(nil)); len(g.Lock.Hash) != size {
+		return 0, fmt.Errorf("%w: hashlock guard: %v hash must be %d "+
+			"bytes, got %d", ErrInvalidFragment, g.Lock.Type, size,
+			len(g.Lock.Hash))
+	}
+	return op, nil
+}
+
+// Script 返回守卫的脚本。
+func (g *HashlockGuard) Script() ([]byte, error) {
+	op, err := g.opcode()
+	if err != nil {
+		return nil, err
+	}
+	return txscript.NewScriptBuilder().AddOp(txscript.OP_SIZE).
+		AddInt64(HashlockPreimageSize).AddOp(txscript.OP_EQUALVERIFY).
+		AddOp(op).AddData(g.Lock.Hash).AddOp(txscript.OP_EQUALVERIFY).
+		Script()
+}
+
+// Size 返回守卫的字节数，SHA256 和 HASH256 为 HashlockGuardSHA256Size，其余 20 字节的哈希为 HashlockGuardHash160Size。
+func (g *HashlockGuard) Size() int {
+	return 6 + pushSize(g.Lock.Hash)
+}
+
+// Satisfy 返回满足守卫的见证元素 {preimage}。 原像的长度不是 HashlockPreimageSize 或哈希不匹配时返回 ErrUnsatisfiable 错误。
+func (g *HashlockGuard) Satisfy(preimage []byte) ([][]byte, error) {
+	if _, err := g.opcode(); err != nil {
+		return nil, err
+	}
+	if len(preimage) != HashlockPreimageSize {
+		return nil, fmt.Errorf("%w: preimage must be %d bytes, got %d",
+			ErrUnsatisfiable, HashlockPreimageSize, len(preimage))
+	}
+	if !g.Lock.Matches(preimage) {
+		return nil, fmt.Errorf("%w: preimage does not match %v hash %x",
+			ErrUnsatisfiable, g.Lock.Type, g.Lock.Hash)
+	}
+	return [][]byte{preimage}, nil
+}
@@ -0,0 +1,81 @@
+package fragments
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/qinglongcn/bpfschain/txscript"
+	"github.com/stretchr/testify/require"
+)
+
+// TestHashlockGuard 确保每种哈希类型的哈希锁守卫大小与常量一致，正确的原像在两种脚本版本中都能花费，
+// 而错误或长度不对的原像既被 Satisfy 拒绝也无法通过脚本。
+func TestHashlockGuard(t *testing.T) {
+	t.Parallel()
+
+	preimage := bytes.Repeat([]byte{0x42}, HashlockPreimageSize)
+	sizes := map[txscript.HashLockType]int{
+		txscript.HashLockSHA256:    HashlockGuardSHA256Size,
+		txscript.HashLockHash256:   HashlockGuardSHA256Size,
+		txscript.HashLockHash160:   HashlockGuardHash160Size,
+		txscript.HashLockRipemd160: HashlockGuardHash160Size,
+		txscript.HashLockSHA1:      HashlockGuardHash160Size,
+	}
+	for lockType, size := range sizes {
+		g := &HashlockGuard{Lock: txscript.HashLock{
+			Type: lockType, Hash: lockType.Digest(preimage),
+		}}
+		script, err := g.Script()
+		require.NoError(t, err, lockType)
+		require.Len(t, script, size, lockType)
+		require.Equal(t, size, g.Size(), lockType)
+
+		items, err := g.Satisfy(preimage)
+		require.NoError(t, err, lockType)
+		require.Equal(t, [][]byte{preimage}, items)
+
+		wrong := bytes.Repeat([]byte{0x43}, HashlockPreimageSize)
+		_, err = g.Satisfy(wrong)
+		require.ErrorIs(t, err, ErrUnsatisfiable)
+
+		// A preimage of the wrong size is rejected even when it matches
+		// the hash.
+		short := preimage[:HashlockPreimageSize-1]
+		shortGuard := &HashlockGuard{Lock: txscript.HashLock{
+			Type: lockType, Hash: lockType.Digest(short),
+		}}
+		_, err = shortGuard.Satisfy(short)
+		require.ErrorIs(t, err, ErrUnsatisfiable)
+
+		script, err = Compose(g)
+		require.NoError(t, err)
+		shortScript, err := Compose(shortGuard)
+		require.NoError(t, err)
+		for _, tapscript := range []bool{false, true} {
+			s := newSpend(t, script, tapscript)
+			require.NoError(t, s.execute(items), lockType)
+			require.Error(t, s.execute([][]byte{wrong}), lockType)
+			require.Error(t, s.execute(), lockType)
+
+			s = newSpend(t, shortScript, tapscript)
+			require.Error(t, s.execute([][]byte{short}), lockType)
+		}
+	}
+}
+
+// TestHashlockGuardInvalid 确保未知的哈希类型和长度错误的哈希被拒绝。
+func TestHashlockGuardInvalid(t *testing.T) {
+	t.Parallel()
+
+	for _, lock := range []txscript.HashLock{
+		{Type: txscript.HashLockSHA256, Hash: make([]byte, 20)},
+		{Type: txscript.HashLockHash160, Hash: make([]byte, 32)},
+		{Type: 200, Hash: make([]byte, 32)},
+	} {
+		g := &HashlockGuard{Lock: lock}
+		_, err := g.Script()
+		require.ErrorIs(t, err, ErrInvalidFragment)
+		_, err = g.Satisfy(make([]byte, HashlockPreimageSize))
+		require.ErrorIs(t, err, ErrInvalidFragment)
+	}
+}
@@ -0,0 +1,69 @@
+package fragments
+
+import (
+	"fmt"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/qinglongcn/bpfschain/txscript"
+)
+
+const (
+	// KeyCheckSize 是见证版本 0 脚本中使用 33 字节压缩公钥的公钥检查的字节数。
+	KeyCheckSize = 35
+
+	// KeyCheckTapscriptSize 是 tapscript 中使用 32 字节 x-only 公钥的公钥检查的字节数。
+	KeyCheckTapscriptSize = 34
+)
+
+// checkPubKey 检查公钥的编码：33 字节的压缩公钥用于见证版本 0 脚本，32 字节的 x-only 公钥用于 tapscript。
+// 其它编码被拒绝：未压缩公钥在见证脚本中不标准，而 tapscript 中的 33 字节公钥按共识是任何人都能满足的未知公钥类型。
+func checkPubKey(pubKey []byte) error {
+	switch len(pubKey) {
+	case btcec.PubKeyBytesLenCompressed:
+		if _, err := btcec.ParsePubKey(pubKey); err != nil {
+			return err
+		}
+		return nil
+
+	case 32:
+		_, err := txscript.ParseXOnlyPubKey(pubKey)
+		return err
+
+	default:
+		return fmt.Errorf("public key must be 33 or 32 bytes, got %d",
+			len(pubKey))
+	}
+}
+
+// KeyCheck 是要求公钥签名的守卫：
+//
+//	<PubKey> CHECKSIGVERIFY
+//
+// 片段从栈顶消耗一个见证元素，即签名。
+type KeyCheck struct {
+	// PubKey 是见证版本 0 脚本中 33 字节的压缩公钥，或 tapscript 中 32 字节的 x-only 公钥。
+	PubKey []byte
+}
+
+// Script 返回守卫的脚本。
+func (k *KeyCheck) Script() ([]byte, error) {
+	if err := checkPubKey(k.PubKey); err != nil {
+		return nil, fmt.Errorf("%w: key check: %v", ErrInvalidFragment, err)
+	}
+	return txscript.NewScriptBuilder().AddData(k.PubKey).
+		AddOp(txscript.OP_CHECKSIGVERIFY).Script()
+}
+
+// Size 返回守卫的字节数，即 KeyCheckSize 或 KeyCheckTapscriptSize。
+func (k *KeyCheck) Size() int {
+	return pushSize(k.PubKey) + 1
+}
+
+// Satisfy 返回满足守卫的见证元素 {sig}。 sig 是带签名哈希类型字节的签名，为空时返回 ErrUnsatisfiable 错误，
+// 因为空签名总是使 OP_CHECKSIGVERIFY 失败。
+func (k *KeyCheck) Satisfy(sig []byte) ([][]byte, error) {
+	if len(sig) == 0 {
+		return nil, fmt.Errorf("%w: missing signature", ErrUnsatisfiable)
+	}
+	return [][]byte{sig}, nil
+}
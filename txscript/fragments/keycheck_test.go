@@ -0,0 +1,60 @@
+package fragments
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// TestKeyCheck 确保公钥检查的大小与常量一致，正确的签名在两种脚本版本中都能花费，而其它公钥的签名或空签名失败。
+func TestKeyCheck(t *testing.T) {
+	t.Parallel()
+
+	keys := newKeys(t, 2)
+	for _, tapscript := range []bool{false, true} {
+		encoding := &spend{tapscript: tapscript}
+		k := &KeyCheck{PubKey: encoding.pubKey(keys[0])}
+		script, err := k.Script()
+		require.NoError(t, err)
+		size := KeyCheckSize
+		if tapscript {
+			size = KeyCheckTapscriptSize
+		}
+		require.Len(t, script, size)
+		require.Equal(t, size, k.Size())
+
+		script, err = Compose(k)
+		require.NoError(t, err)
+		s := newSpend(t, script, tapscript)
+		items, err := k.Satisfy(s.sign(keys[0]))
+		require.NoError(t, err)
+		require.NoError(t, s.execute(items), "tapscript=%v", tapscript)
+
+		items, err = k.Satisfy(s.sign(keys[1]))
+		require.NoError(t, err)
+		require.Error(t, s.execute(items), "tapscript=%v", tapscript)
+		require.Error(t, s.execute([][]byte{{}}), "tapscript=%v",
+			tapscript)
+	}
+
+	_, err := (&KeyCheck{PubKey: make([]byte, 33)}).Satisfy(nil)
+	require.ErrorIs(t, err, ErrUnsatisfiable)
+}
+
+// TestKeyCheckInvalid 确保未压缩公钥、不在曲线上的公钥和其它长度的公钥被拒绝。
+func TestKeyCheckInvalid(t *testing.T) {
+	t.Parallel()
+
+	key := newKeys(t, 1)[0]
+	notOnCurve := make([]byte, 32)
+	notOnCurve[31] = 5
+	for _, pubKey := range [][]byte{
+		nil,
+		key.PubKey().SerializeUncompressed(),
+		make([]byte, 33),
+		notOnCurve,
+	} {
+		_, err := (&KeyCheck{PubKey: pubKey}).Script()
+		require.ErrorIs(t, err, ErrInvalidFragment, "%x", pubKey)
+	}
+}
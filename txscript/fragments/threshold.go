@@ -0,0 +1,145 @@
+package fragments
+
+import (
+	"fmt"
+
+	"github.com/qinglongcn/bpfschain/txscript"
+)
+
+const (
+	// ThresholdAccumulatorKeySize 是见证版本 0 的阈值累加器中第一个公钥以外每个公钥占用的字节数。
+	ThresholdAccumulatorKeySize = 37
+
+	// ThresholdAccumulatorTapscriptKeySize 是 tapscript 的阈值累加器中每个公钥占用的字节数。
+	ThresholdAccumulatorTapscriptKeySize = 34
+
+	// MaxThresholdKeys 是见证版本 0 的阈值累加器允许的最大公钥数，与 OP_CHECKMULTISIG 相同。
+	MaxThresholdKeys = txscript.MaxPubKeysPerMultiSig
+)
+
+// ThresholdAccumulator 是要求 PubKeys 中恰好 Threshold 个公钥签名的守卫。 tapscript 中使用 32 字节的 x-only 公钥：
+//
+//	<key 1> CHECKSIG <key 2> CHECKSIGADD ... <key n> CHECKSIGADD <Threshold> NUMEQUALVERIFY
+//
+// 见证版本 0 脚本中使用 33 字节的压缩公钥，OP_CHECKSIGADD 不可用，改为交换后相加：
+//
+//	<key 1> CHECKSIG SWAP <key 2> CHECKSIG ADD ... SWAP <key n> CHECKSIG ADD <Threshold> NUMEQUALVERIFY
+//
+// 片段从栈顶消耗 n 个见证元素，第 i 个公钥的签名位于从栈顶数起第 i 个，不签名的公钥对应空元素。
+// 由于比较使用 NUMEQUALVERIFY，多于 Threshold 个有效签名同样会失败，Satisfy 因此只使用前 Threshold 个签名。
+type ThresholdAccumulator struct {
+	// Threshold 是需要的签名数。
+	Threshold int
+
+	// PubKeys 是参与的公钥，必须全部是 33 字节的压缩公钥或全部是 32 字节的 x-only 公钥，并且不能重复。
+	PubKeys [][]byte
+}
+
+// tapscript 返回累加器是否使用 x-only 公钥。
+func (a *ThresholdAccumulator) tapscript() bool {
+	return len(a.PubKeys) > 0 && len(a.PubKeys[0]) == 32
+}
+
+// validate 检查阈值和公钥。 重复的公钥会被同一个签名满足多次，使实际需要的签名者少于阈值。
+func (a *ThresholdAccumulator) validate() error {
+	n := len(a.PubKeys)
+	maxKeys := MaxThresholdKeys
+	if a.tapscript() {
+		maxKeys = txscript.MaxPubKeysPerMultiA
+	}
+	if n == 0 || n > maxKeys {
+		return fmt.Errorf("%w: threshold accumulator: %d keys, must be "+
+			"between 1 and %d", ErrInvalidFragment, n, maxKeys)
+	}
+	if a.Threshold < 1 || a.Threshold > n {
+		return fmt.Errorf("%w: threshold accumulator: threshold %d, "+
+			"must be between 1 and %d", ErrInvalidFragment, a.Threshold, n)
+	}
+
+	seen := make(map[string]struct{}, n)
+	for i, key := range a.PubKeys {
+		if len(key) != len(a.PubKeys[0]) {
+			return fmt.Errorf("%w: threshold accumulator: key %d is %d "+
+				"bytes, key 0 is %d bytes", ErrInvalidFragment, i,
+				len(key), len(a.PubKeys[0]))
+		}
+		if err := checkPubKey(key); err != nil {
+			return fmt.Errorf("%w: threshold accumulator: key %d: %v",
+				ErrInvalidFragment, i, err)
+		}
+		if _, ok := seen[string(key)]; ok {
+			return fmt.Errorf("%w: threshold accumulator: duplicate "+
+				"key %x", ErrInvalidFragment, key)
+		}
+		seen[string(key)] = struct{}{}
+	}
+	return nil
+}
+
+// Script 返回守卫的脚本。
+func (a *ThresholdAccumulator) Script() ([]byte, error) {
+	if err := a.validate(); err != nil {
+		return nil, err
+	}
+
+	tapscript := a.tapscript()
+	builder := txscript.NewScriptBuilder()
+	for i, key := range a.PubKeys {
+		switch {
+		case i == 0:
+			builder.AddData(key).AddOp(txscript.OP_CHECKSIG)
+		case tapscript:
+			builder.AddData(key).AddOp(txscript.OP_CHECKSIGADD)
+		default:
+			builder.AddOp(txscript.OP_SWAP).AddData(key).
+				AddOp(txscript.OP_CHECKSIG).AddOp(txscript.OP_ADD)
+		}
+	}
+	return builder.AddInt64(int64(a.Threshold)).
+		AddOp(txscript.OP_NUMEQUALVERIFY).Script()
+}
+
+// Size 返回守卫的字节数。
+func (a *ThresholdAccumulator) Size() int {
+	n := len(a.PubKeys)
+	if n == 0 {
+		return 0
+	}
+	thresholdSize := len(txscript.NewScriptBuilder().
+		AddInt64(int64(a.Threshold)).MustScript())
+	if a.tapscript() {
+		return n*ThresholdAccumulatorTapscriptKeySize + thresholdSize + 1
+	}
+	return KeyCheckSize + (n-1)*ThresholdAccumulatorKeySize +
+		thresholdSize + 1
+}
+
+// Satisfy 返回满足守卫的见证元素。 sigs[i] 是 PubKeys[i] 的带签名哈希类型字节的签名，为空表示该公钥不签名。
+// 只有前 Threshold 个非空签名被使用，其余被替换为空元素。 sigs 的长度与公钥数不同或非空签名少于 Threshold 个时返回 ErrUnsatisfiable 错误。
+func (a *ThresholdAccumulator) Satisfy(sigs [][]byte) ([][]byte, error) {
+	if err := a.validate(); err != nil {
+		return nil, err
+	}
+	if len(sigs) != len(a.PubKeys) {
+		return nil, fmt.Errorf("%w: got %d signatures for %d keys",
+			ErrUnsatisfiable, len(sigs), len(a.PubKeys))
+	}
+
+	// The first key's signature is consumed first, so it goes on top of
+	// the stack, which is the end of the returned items.
+	items := make([][]byte, len(sigs))
+	var used int
+	for i, sig := range sigs {
+		item := []byte{}
+		if len(sig) != 0 && used < a.Threshold {
+			item = sig
+			used++
+		}
+		items[len(sigs)-1-i] = item
+	}
+	if used < a.Threshold {
+		return nil, fmt.Errorf("%w: got %d of %d signatures",
+			ErrUnsatisfiable, used, a.Threshold)
+	}
+	return items, nil
+}
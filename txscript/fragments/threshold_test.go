@@ -0,0 +1,121 @@
+package fragments
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// TestThresholdAccumulator 对每种阈值和签名者组合确保阈值累加器在两种脚本版本中恰好在签名数达到阈值时可以花费，
+// 并且大小与脚本一致。
+func TestThresholdAccumulator(t *testing.T) {
+	t.Parallel()
+
+	const n = 3
+	keys := newKeys(t, n)
+	for _, tapscript := range []bool{false, true} {
+		encoding := &spend{tapscript: tapscript}
+		var pubKeys [][]byte
+		for _, key := range keys {
+			pubKeys = append(pubKeys, encoding.pubKey(key))
+		}
+
+		for threshold := 1; threshold <= n; threshold++ {
+			a := &ThresholdAccumulator{
+				Threshold: threshold, PubKeys: pubKeys,
+			}
+			script, err := a.Script()
+			require.NoError(t, err)
+			require.Len(t, script, a.Size())
+
+			script, err = Compose(a)
+			require.NoError(t, err)
+			s := newSpend(t, script, tapscript)
+			sigs := make([][]byte, n)
+			for i, key := range keys {
+				sigs[i] = s.sign(key)
+			}
+
+			// Try every subset of signers.
+			for mask := 0; mask < 1<<n; mask++ {
+				subset := make([][]byte, n)
+				var count int
+				for i := range keys {
+					if mask&(1<<i) != 0 {
+						subset[i] = sigs[i]
+						count++
+					}
+				}
+				items, err := a.Satisfy(subset)
+				if count < threshold {
+					require.ErrorIs(t, err, ErrUnsatisfiable)
+					continue
+				}
+				require.NoError(t, err)
+				require.NoError(t, s.execute(items),
+					"tapscript=%v threshold=%d mask=%b", tapscript,
+					threshold, mask)
+			}
+
+			// Signatures in the wrong order fail.
+			if threshold == n {
+				items, err := a.Satisfy(sigs)
+				require.NoError(t, err)
+				items[0], items[1] = items[1], items[0]
+				require.Error(t, s.execute(items))
+			}
+		}
+	}
+}
+
+// TestThresholdAccumulatorSize 确保大小与每个公钥的大小常量一致。
+func TestThresholdAccumulatorSize(t *testing.T) {
+	t.Parallel()
+
+	keys := newKeys(t, 2)
+	v0 := &ThresholdAccumulator{Threshold: 1, PubKeys: [][]byte{
+		keys[0].PubKey().SerializeCompressed(),
+		keys[1].PubKey().SerializeCompressed(),
+	}}
+	require.Equal(t, KeyCheckSize+ThresholdAccumulatorKeySize+2, v0.Size())
+
+	encoding := &spend{tapscript: true}
+	tapscript := &ThresholdAccumulator{Threshold: 1, PubKeys: [][]byte{
+		encoding.pubKey(keys[0]), encoding.pubKey(keys[1]),
+	}}
+	require.Equal(t, 2*ThresholdAccumulatorTapscriptKeySize+2,
+		tapscript.Size())
+}
+
+// TestThresholdAccumulatorInvalid 确保无效的阈值、重复或混合编码的公钥以及过多的公钥被拒绝。
+func TestThresholdAccumulatorInvalid(t *testing.T) {
+	t.Parallel()
+
+	keys := newKeys(t, MaxThresholdKeys+1)
+	compressed := func(n int) [][]byte {
+		var pubKeys [][]byte
+		for _, key := range keys[:n] {
+			pubKeys = append(pubKeys, key.PubKey().SerializeCompressed())
+		}
+		return pubKeys
+	}
+	xOnly := (&spend{tapscript: true}).pubKey(keys[1])
+
+	for _, a := range []*ThresholdAccumulator{
+		{Threshold: 1},
+		{Threshold: 0, PubKeys: compressed(2)},
+		{Threshold: 3, PubKeys: compressed(2)},
+		{Threshold: 1, PubKeys: append(compressed(1), compressed(1)...)},
+		{Threshold: 1, PubKeys: [][]byte{compressed(1)[0], xOnly}},
+		{Threshold: 1, PubKeys: compressed(MaxThresholdKeys + 1)},
+	} {
+		_, err := a.Script()
+		require.ErrorIs(t, err, ErrInvalidFragment)
+		_, err = a.Satisfy(make([][]byte, len(a.PubKeys)))
+		require.ErrorIs(t, err, ErrInvalidFragment)
+	}
+
+	a := &ThresholdAccumulator{Threshold: 1, PubKeys: compressed(2)}
+	_, err := a.Satisfy(make([][]byte, 1))
+	require.ErrorIs(t, err, ErrUnsatisfiable)
+}
@@ -0,0 +1,126 @@
+package fragments
+
+import (
+	"fmt"
+
+	"github.com/btcsuite/btcd/wire"
+	"github.com/qinglongcn/bpfschain/txscript"
+)
+
+const (
+	// TimelockGuardMinSize 是时间锁守卫的最小字节数，锁定时间为 0 到 16 时由单字节操作码推送。
+	TimelockGuardMinSize = 3
+
+	// TimelockGuardMaxSize 是时间锁守卫的最大字节数，锁定时间需要 5 字节的脚本数字时达到。
+	TimelockGuardMaxSize = 8
+)
+
+// relativeLockTimeMask 是相对时间锁中有意义的位：类型标志和锁定值。
+const relativeLockTimeMask = wire.SequenceLockTimeIsSeconds |
+	wire.SequenceLockTimeMask
+
+// TimelockGuard 是要求交易满足时间锁的守卫：
+//
+//	<LockTime> CHECKLOCKTIMEVERIFY DROP
+//	<LockTime> CHECKSEQUENCEVERIFY DROP
+//
+// 片段不消耗见证元素，由交易的锁定时间或输入的序列号满足。
+type TimelockGuard struct {
+	// LockTime 是绝对锁定时间（区块高度或 Unix 时间），或者 Relative 为 true 时 BIP0068 编码的相对锁定时间。
+	LockTime int64
+
+	// Relative 表示使用 OP_CHECKSEQUENCEVERIFY 的相对时间锁。
+	Relative bool
+}
+
+// validate 检查锁定时间。 相对时间锁不能设置禁用标志，否则 OP_CHECKSEQUENCEVERIFY 不做任何检查，
+// 也不能设置 BIP0068 未定义的位，因为这些位不参与比较，容易让人误以为锁定值更大。
+func (g *TimelockGuard) validate() error {
+	if _, err := txscript.EncodeLockTimeNum(g.LockTime); err != nil {
+		return fmt.Errorf("%w: timelock guard: %v", ErrInvalidFragment, err)
+	}
+	if !g.Relative {
+		return nil
+	}
+	if g.LockTime&int64(wire.SequenceLockTimeDisabled) != 0 {
+		return fmt.Errorf("%w: timelock guard: relative lock time %#x "+
+			"has the disable flag set", ErrInvalidFragment, g.LockTime)
+	}
+	if g.LockTime&^int64(relativeLockTimeMask) != 0 {
+		return fmt.Errorf("%w: timelock guard: relative lock time %#x "+
+			"sets undefined bits", ErrInvalidFragment, g.LockTime)
+	}
+	return nil
+}
+
+// Script 返回守卫的脚本。
+func (g *TimelockGuard) Script() ([]byte, error) {
+	if err := g.validate(); err != nil {
+		return nil, err
+	}
+	op := byte(txscript.OP_CHECKLOCKTIMEVERIFY)
+	if g.Relative {
+		op = txscript.OP_CHECKSEQUENCEVERIFY
+	}
+	return txscript.NewScriptBuilder().AddLockTime(g.LockTime).AddOp(op).
+		AddOp(txscript.OP_DROP).Script()
+}
+
+// Size 返回守卫的字节数，介于 TimelockGuardMinSize 和 TimelockGuardMaxSize 之间。
+func (g *TimelockGuard) Size() int {
+	num, _ := txscript.EncodeLockTimeNum(g.LockTime)
+	return pushSize(num) + 2
+}
+
+// Satisfy 修改 tx，使输入 idx 满足守卫。 绝对时间锁设置交易的锁定时间并使输入的序列号不是最大值，
+// 相对时间锁将交易版本提高到 2 并设置输入的序列号。 tx 已经满足守卫时不做修改，
+// 与已有的锁定时间类型冲突时返回 ErrUnsatisfiable 错误。 守卫不需要见证元素，返回 nil。
+func (g *TimelockGuard) Satisfy(tx *wire.MsgTx, idx int) ([][]byte, error) {
+	if err := g.validate(); err != nil {
+		return nil, err
+	}
+	if idx < 0 || idx >= len(tx.TxIn) {
+		return nil, fmt.Errorf("%w: input index %d out of range",
+			ErrUnsatisfiable, idx)
+	}
+	txIn := tx.TxIn[idx]
+
+	if !g.Relative {
+		lockTime := uint32(g.LockTime)
+		isHeight := lockTime < txscript.LockTimeThreshold
+		if tx.LockTime != 0 &&
+			(tx.LockTime < txscript.LockTimeThreshold) != isHeight {
+
+			return nil, fmt.Errorf("%w: transaction lock time %d and "+
+				"guard lock time %d are of different types",
+				ErrUnsatisfiable, tx.LockTime, lockTime)
+		}
+		if tx.LockTime < lockTime {
+			tx.LockTime = lockTime
+		}
+		if txIn.Sequence == wire.MaxTxInSequenceNum {
+			txIn.Sequence = wire.MaxTxInSequenceNum - 1
+		}
+		return nil, nil
+	}
+
+	sequence := uint32(g.LockTime)
+	if tx.Version < 2 {
+		tx.Version = 2
+	}
+	if txIn.Sequence&wire.SequenceLockTimeDisabled == 0 {
+		current := txIn.Sequence & relativeLockTimeMask
+		if current&wire.SequenceLockTimeIsSeconds !=
+			sequence&wire.SequenceLockTimeIsSeconds {
+
+			return nil, fmt.Errorf("%w: input sequence %#x and guard "+
+				"lock time %#x are of different types",
+				ErrUnsatisfiable, txIn.Sequence, sequence)
+		}
+		if current >= sequence {
+			return nil, nil
+		}
+	}
+	txIn.Sequence = sequence
+	return nil, nil
+}
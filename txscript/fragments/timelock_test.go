@@ -0,0 +1,108 @@
+package fragments
+
+import (
+	"testing"
+
+	"github.com/btcsuite/btcd/wire"
+	"github.com/qinglongcn/bpfschain/txscript"
+	"github.com/stretchr/testify/require"
+)
+
+// TestTimelockGuardSize 确保时间锁守卫的大小与脚本一致并且位于大小常量之间。
+func TestTimelockGuardSize(t *testing.T) {
+	t.Parallel()
+
+	for _, lockTime := range []int64{
+		0, 1, 16, 17, 127, 128, 0x7fff, 0x8000, 0x7fffff, 0x800000,
+		txscript.LockTimeThreshold, 0x7fffffff, 0x80000000, 0xffffffff,
+	} {
+		g := &TimelockGuard{LockTime: lockTime}
+		script, err := g.Script()
+		require.NoError(t, err, lockTime)
+		require.Len(t, script, g.Size(), lockTime)
+		require.GreaterOrEqual(t, g.Size(), TimelockGuardMinSize)
+		require.LessOrEqual(t, g.Size(), TimelockGuardMaxSize)
+	}
+	require.Equal(t, TimelockGuardMinSize,
+		(&TimelockGuard{LockTime: 16}).Size())
+	require.Equal(t, TimelockGuardMaxSize,
+		(&TimelockGuard{LockTime: 0xffffffff}).Size())
+}
+
+// TestTimelockGuardInvalid 确保无法满足或不做检查的锁定时间被拒绝。
+func TestTimelockGuardInvalid(t *testing.T) {
+	t.Parallel()
+
+	for _, g := range []*TimelockGuard{
+		{LockTime: -1},
+		{LockTime: 0x100000000},
+		{LockTime: int64(wire.SequenceLockTimeDisabled), Relative: true},
+		{LockTime: 1 << 16, Relative: true},
+	} {
+		_, err := g.Script()
+		require.ErrorIs(t, err, ErrInvalidFragment, "%+v", *g)
+		_, err = g.Satisfy(wire.NewMsgTx(2), 0)
+		require.ErrorIs(t, err, ErrInvalidFragment, "%+v", *g)
+	}
+}
+
+// TestTimelockGuardSpend 确保 Satisfy 修改的交易在两种脚本版本中都满足绝对和相对时间锁，而未满足的交易失败。
+func TestTimelockGuardSpend(t *testing.T) {
+	t.Parallel()
+
+	seconds := int64(wire.SequenceLockTimeIsSeconds | 5)
+	for _, tapscript := range []bool{false, true} {
+		for _, g := range []*TimelockGuard{
+			{LockTime: 0},
+			{LockTime: 500},
+			{LockTime: txscript.LockTimeThreshold + 1},
+			{LockTime: 0xffffffff},
+			{LockTime: 10, Relative: true},
+			{LockTime: seconds, Relative: true},
+		} {
+			script, err := Compose(g)
+			require.NoError(t, err)
+
+			s := newSpend(t, script, tapscript)
+			if g.LockTime != 0 {
+				require.Error(t, s.execute(), "%+v", *g)
+			}
+			items, err := g.Satisfy(s.tx, 0)
+			require.NoError(t, err)
+			require.Empty(t, items)
+			require.NoError(t, s.execute(items), "%+v", *g)
+		}
+	}
+}
+
+// TestTimelockGuardSatisfy 确保 Satisfy 保留已经足够的锁定时间，并拒绝类型冲突的锁定时间。
+func TestTimelockGuardSatisfy(t *testing.T) {
+	t.Parallel()
+
+	tx := wire.NewMsgTx(1)
+	tx.AddTxIn(&wire.TxIn{Sequence: wire.MaxTxInSequenceNum})
+	tx.LockTime = 1000
+	_, err := (&TimelockGuard{LockTime: 500}).Satisfy(tx, 0)
+	require.NoError(t, err)
+	require.EqualValues(t, 1000, tx.LockTime)
+	require.EqualValues(t, wire.MaxTxInSequenceNum-1, tx.TxIn[0].Sequence)
+
+	_, err = (&TimelockGuard{LockTime: 600000000}).Satisfy(tx, 0)
+	require.ErrorIs(t, err, ErrUnsatisfiable)
+
+	_, err = (&TimelockGuard{LockTime: 20, Relative: true}).Satisfy(tx, 0)
+	require.NoError(t, err)
+	require.EqualValues(t, 2, tx.Version)
+	require.EqualValues(t, 20, tx.TxIn[0].Sequence)
+	_, err = (&TimelockGuard{LockTime: 10, Relative: true}).Satisfy(tx, 0)
+	require.NoError(t, err)
+	require.EqualValues(t, 20, tx.TxIn[0].Sequence)
+
+	seconds := int64(wire.SequenceLockTimeIsSeconds | 1)
+	_, err = (&TimelockGuard{LockTime: seconds, Relative: true}).
+		Satisfy(tx, 0)
+	require.ErrorIs(t, err, ErrUnsatisfiable)
+
+	_, err = (&TimelockGuard{LockTime: 1}).Satisfy(tx, 1)
+	require.ErrorIs(t, err, ErrUnsatisfiable)
+}
@@ -76,6 +76,52 @@ type PrevOutputFetcher interface {
 	FetchPrevOutput(wire.OutPoint) *wire.TxOut
 }
 
+// PrevOutputPrefetcher is an optional interface that can be implemented by a
+// PrevOutputFetcher backed by a database. Before fetching the previous outputs
+// of a transaction one at a time, the signature hash cache and the script
+// engine hint the outpoints they're about to request, allowing the
+// implementation to load them with a single batched read.
+type PrevOutputPrefetcher interface {
+	PrevOutputFetcher
+
+	// Prefetch is a hint that the previous outputs referenced by the
+	// passed outpoints are about to be fetched. It may be called several
+	// times with overlapping outpoints, so implementations should make
+	// loading already cached outpoints cheap. Failures should be deferred
+	// to FetchPrevOutput, which reports a missing output by returning nil.
+	Prefetch([]wire.OutPoint)
+}
+
+// PrefetchPrevOutputs hints the previous outputs spent by the non-coinbase
+// inputs of the passed transactions to the fetcher in a single batch if it
+// implements PrevOutputPrefetcher, and does nothing otherwise. Callers that
+// validate a whole block can use it to load every spent output at once.
+func PrefetchPrevOutputs(fetcher PrevOutputFetcher, txs ...*wire.MsgTx) {
+	prefetcher, ok := fetcher.(PrevOutputPrefetcher)
+	if !ok {
+		return
+	}
+
+	var (
+		outpoints []wire.OutPoint
+		zeroHash  chainhash.Hash
+	)
+	for _, tx := range txs {
+		for _, txIn := range tx.TxIn {
+			outpoint := txIn.PreviousOutPoint
+			if outpoint.Index == math.MaxUint32 &&
+				outpoint.Hash == zeroHash {
+
+				continue
+			}
+			outpoints = append(outpoints, outpoint)
+		}
+	}
+	if len(outpoints) > 0 {
+		prefetcher.Prefetch(outpoints)
+	}
+}
+
 // CannedPrevOutputFetcher is an implementation of PrevOutputFetcher that only
 // is able to return information for a single previous output.
 type CannedPrevOutputFetcher struct {
@@ -203,28 +249,26 @@ type TxSigHashes struct {
 	SegwitSigHashMidstate
 
 	TaprootSigHashMidState
+
+	// hasV0Inputs and hasV1Inputs record which midstates were computed,
+	// so an update only recomputes what the transaction actually needs.
+	hasV0Inputs bool
+	hasV1Inputs bool
+
+	// dirty tracks the midstates that have been invalidated since they
+	// were last computed.
+	dirty SigHashMidstate
 }
 
-// NewTxSigHashes computes, and returns the cached sighashes of the given
-// transaction.
-func NewTxSigHashes(tx *wire.MsgTx,
-	inputFetcher PrevOutputFetcher) *TxSigHashes {
+// inputWitnessVersions returns whether the passed transaction has inputs that
+// need the segwit v0 midstate and inputs that need the taproot midstate.
+func inputWitnessVersions(tx *wire.MsgTx,
+	inputFetcher PrevOutputFetcher) (bool, bool) {
 
 	var (
-		sigHashes TxSigHashes
-		zeroHash  chainhash.Hash
+		hasV0Inputs, hasV1Inputs bool
+		zeroHash                 chainhash.Hash
 	)
-
-	// Base segwit (witness version v0), and taproot (witness version v1)
-	// differ in how the set of pre-computed cached sighash midstate is
-	// computed. For taproot, the prevouts, sequence, and outputs are
-	// computed as normal, but a single sha256 hash invocation is used. In
-	// addition, the hashes of all the previous input amounts and scripts
-	// are included as well.
-	//
-	// Based on the above distinction, we'll run through all the referenced
-	// inputs to determine what we need to compute.
-	var hasV0Inputs, hasV1Inputs bool
 	for _, txIn := range tx.TxIn {
 		// If this is a coinbase input, then we know that we only need
 		// the v0 midstate (though it won't be used) in this instance.
@@ -252,6 +296,31 @@ func NewTxSigHashes(tx *wire.MsgTx,
 		}
 	}
 
+	return hasV0Inputs, hasV1Inputs
+}
+
+// NewTxSigHashes computes, and returns the cached sighashes of the given
+// transaction.
+func NewTxSigHashes(tx *wire.MsgTx,
+	inputFetcher PrevOutputFetcher) *TxSigHashes {
+
+	var sigHashes TxSigHashes
+
+	// Base segwit (witness version v0), and taproot (witness version v1)
+	// differ in how the set of pre-computed cached sighash midstate is
+	// computed. For taproot, the prevouts, sequence, and outputs are
+	// computed as normal, but a single sha256 hash invocation is used. In
+	// addition, the hashes of all the previous input amounts and scripts
+	// are included as well.
+	//
+	// Based on the above distinction, we'll run through all the referenced
+	// inputs to determine what we need to compute, letting the fetcher
+	// load them in a single batch first.
+	PrefetchPrevOutputs(inputFetcher, tx)
+	hasV0Inputs, hasV1Inputs := inputWitnessVersions(tx, inputFetcher)
+	sigHashes.hasV0Inputs = hasV0Inputs
+	sigHashes.hasV1Inputs = hasV1Inputs
+
 	// Now that we know which cached midstate we need to calculate, we can
 	// go ahead and do so.
 	//
@@ -260,10 +329,12 @@ func NewTxSigHashes(tx *wire.MsgTx,
 	// difference is that this is a single instead of a double hash.
 	//
 	// Both v0 and v1 share this base data computed using a sha256 single
-	// hash.
-	sigHashes.HashPrevOutsV1 = calcHashPrevOuts(tx)
-	sigHashes.HashSequenceV1 = calcHashSequence(tx)
-	sigHashes.HashOutputsV1 = calcHashOutputs(tx)
+	// hash, a transaction without inputs needs none of it.
+	if hasV0Inputs || hasV1Inputs {
+		sigHashes.HashPrevOutsV1 = calcHashPrevOuts(tx)
+		sigHashes.HashSequenceV1 = calcHashSequence(tx)
+		sigHashes.HashOutputsV1 = calcHashOutputs(tx)
+	}
 
 	// The v0 data is the same as the v1 (newer data) but it uses a double
 	// hash instead.
@@ -292,6 +363,155 @@ func NewTxSigHashes(tx *wire.MsgTx,
 	return &sigHashes
 }
 
+// SigHashMidstate is a bit set identifying the individual midstates cached
+// within TxSigHashes.
+type SigHashMidstate uint8
+
+const (
+	// MidstatePrevOuts identifies the hash of all previous outpoints.
+	MidstatePrevOuts SigHashMidstate = 1 << iota
+
+	// MidstateSequences identifies the hash of all input sequence numbers.
+	MidstateSequences
+
+	// MidstateOutputs identifies the hash of all transaction outputs.
+	MidstateOutputs
+
+	// MidstateInputAmounts identifies the taproot hash of all previous
+	// output amounts.
+	MidstateInputAmounts
+
+	// MidstateInputScripts identifies the taproot hash of all previous
+	// output scripts.
+	MidstateInputScripts
+
+	// MidstateInputs identifies every midstate that depends on the
+	// transaction inputs.
+	MidstateInputs = MidstatePrevOuts | MidstateSequences |
+		MidstateInputAmounts | MidstateInputScripts
+
+	// MidstateAll identifies every cached midstate.
+	MidstateAll = MidstateInputs | MidstateOutputs
+)
+
+// Invalidate marks the passed midstates as stale. They'll be recomputed by the
+// next call to Update. Until then the TxSigHashes must not be used to compute
+// signature hashes.
+func (h *TxSigHashes) Invalidate(midstates SigHashMidstate) {
+	h.dirty |= midstates & MidstateAll
+}
+
+// InvalidateInputs marks every midstate derived from the inputs as stale. This
+// should be called after an input is added, removed or modified, or after the
+// previous output it spends changes.
+func (h *TxSigHashes) InvalidateInputs() {
+	h.Invalidate(MidstateInputs)
+}
+
+// InvalidateOutputs marks the outputs midstate as stale. This should be called
+// after an output is added, removed or modified.
+func (h *TxSigHashes) InvalidateOutputs() {
+	h.Invalidate(MidstateOutputs)
+}
+
+// Dirty returns the set of midstates that have been invalidated and not yet
+// recomputed.
+func (h *TxSigHashes) Dirty() SigHashMidstate {
+	return h.dirty
+}
+
+// Update recomputes the invalidated midstates for the passed transaction,
+// leaving the rest untouched. The result is identical to calling
+// NewTxSigHashes on the mutated transaction, as long as every mutation was
+// reported through Invalidate.
+func (h *TxSigHashes) Update(tx *wire.MsgTx, inputFetcher PrevOutputFetcher) {
+	dirty := h.dirty
+	if dirty == 0 {
+		return
+	}
+
+	// The set of midstates the transaction needs can only change when its
+	// inputs or the outputs they spend change.
+	hasV0Inputs, hasV1Inputs := h.hasV0Inputs, h.hasV1Inputs
+	inputDeps := MidstatePrevOuts | MidstateInputAmounts |
+		MidstateInputScripts
+	if dirty&inputDeps != 0 {
+		PrefetchPrevOutputs(inputFetcher, tx)
+		hasV0Inputs, hasV1Inputs = inputWitnessVersions(tx, inputFetcher)
+	}
+
+	// The base data is only computed when the transaction has inputs, so it
+	// needs to be derived in full once it gains one.
+	switch {
+	case !hasV0Inputs && !hasV1Inputs:
+		h.HashPrevOutsV1 = chainhash.Hash{}
+		h.HashSequenceV1 = chainhash.Hash{}
+		h.HashOutputsV1 = chainhash.Hash{}
+
+	case !h.hasV0Inputs && !h.hasV1Inputs:
+		dirty |= MidstatePrevOuts | MidstateSequences | MidstateOutputs
+		fallthrough
+
+	default:
+		if dirty&MidstatePrevOuts != 0 {
+			h.HashPrevOutsV1 = calcHashPrevOuts(tx)
+		}
+		if dirty&MidstateSequences != 0 {
+			h.HashSequenceV1 = calcHashSequence(tx)
+		}
+		if dirty&MidstateOutputs != 0 {
+			h.HashOutputsV1 = calcHashOutputs(tx)
+		}
+	}
+
+	// The v0 midstate is a second hash of the v1 data, so it needs to be
+	// refreshed whenever the v1 data changed or wasn't derived before.
+	switch {
+	case !hasV0Inputs:
+		h.SegwitSigHashMidstate = SegwitSigHashMidstate{}
+
+	case !h.hasV0Inputs:
+		dirty |= MidstatePrevOuts | MidstateSequences | MidstateOutputs
+		fallthrough
+
+	default:
+		if dirty&MidstatePrevOuts != 0 {
+			h.HashPrevOutsV0 = chainhash.HashH(h.HashPrevOutsV1[:])
+		}
+		if dirty&MidstateSequences != 0 {
+			h.HashSequenceV0 = chainhash.HashH(h.HashSequenceV1[:])
+		}
+		if dirty&MidstateOutputs != 0 {
+			h.HashOutputsV0 = chainhash.HashH(h.HashOutputsV1[:])
+		}
+	}
+
+	switch {
+	case !hasV1Inputs:
+		h.HashInputAmountsV1 = chainhash.Hash{}
+		h.HashInputScriptsV1 = chainhash.Hash{}
+
+	case !h.hasV1Inputs:
+		dirty |= MidstateInputAmounts | MidstateInputScripts
+		fallthrough
+
+	default:
+		if dirty&MidstateInputAmounts != 0 {
+			h.HashInputAmountsV1 = calcHashInputAmounts(
+				tx, inputFetcher,
+			)
+		}
+		if dirty&MidstateInputScripts != 0 {
+			h.HashInputScriptsV1 = calcHashInputScripts(
+				tx, inputFetcher,
+			)
+		}
+	}
+
+	h.hasV0Inputs, h.hasV1Inputs = hasV0Inputs, hasV1Inputs
+	h.dirty = 0
+}
+
 // HashCache houses a set of partial sighashes keyed by txid. The set of partial
 // sighashes are those introduced within BIP0143 by the new more efficient
 // sighash digest calculation algorithm. Using this threadsafe shared cache,
@@ -4,6 +4,7 @@ package txscript
 
 import (
 	"math/rand"
+	"reflect"
 	"testing"
 	"time"
 
@@ -181,3 +182,222 @@ func TestHashCachePurge(t *testing.T) {
 		}
 	}
 }
+
+// TestTxSigHashesUpdate 确保在交易被修改并标记相应的中间状态失效后，增量更新的结果与从头计算的结果一致。
+func TestTxSigHashesUpdate(t *testing.T) {
+	t.Parallel()
+
+	tx, prevOuts, err := genTestTx()
+	if err != nil {
+		t.Fatalf("unable to generate test tx: %v", err)
+	}
+	sigHashes := NewTxSigHashes(tx, prevOuts)
+
+	p2tr := append([]byte{OP_1, OP_DATA_32}, make([]byte, 32)...)
+	mutations := []struct {
+		name   string
+		mutate func()
+		dirty  SigHashMidstate
+	}{{
+		name: "change output",
+		mutate: func() {
+			tx.TxOut[0].Value++
+			sigHashes.InvalidateOutputs()
+		},
+		dirty: MidstateOutputs,
+	}, {
+		name: "change sequence",
+		mutate: func() {
+			tx.TxIn[0].Sequence++
+			sigHashes.Invalidate(MidstateSequences)
+		},
+		dirty: MidstateSequences,
+	}, {
+		name: "add taproot input",
+		mutate: func() {
+			txIn := &wire.TxIn{PreviousOutPoint: wire.OutPoint{Index: 7}}
+			tx.AddTxIn(txIn)
+			prevOuts.AddPrevOut(txIn.PreviousOutPoint, &wire.TxOut{
+				Value:    1000,
+				PkScript: p2tr,
+			})
+			sigHashes.InvalidateInputs()
+		},
+		dirty: MidstateInputs,
+	}, {
+		name: "add output",
+		mutate: func() {
+			tx.AddTxOut(wire.NewTxOut(5, []byte{OP_TRUE}))
+			sigHashes.InvalidateOutputs()
+		},
+		dirty: MidstateOutputs,
+	}, {
+		name: "remove non-taproot inputs",
+		mutate: func() {
+			tx.TxIn = tx.TxIn[len(tx.TxIn)-1:]
+			sigHashes.InvalidateInputs()
+		},
+		dirty: MidstateInputs,
+	}}
+
+	for _, m := range mutations {
+		m.mutate()
+		if sigHashes.Dirty() != m.dirty {
+			t.Fatalf("%s: dirty midstates %b, want %b", m.name,
+				sigHashes.Dirty(), m.dirty)
+		}
+
+		sigHashes.Update(tx, prevOuts)
+		if sigHashes.Dirty() != 0 {
+			t.Fatalf("%s: midstates still dirty after update", m.name)
+		}
+		want := NewTxSigHashes(tx, prevOuts)
+		if *sigHashes != *want {
+			t.Fatalf("%s: incremental sighashes mismatch: got %v, "+
+				"want %v", m.name, spew.Sdump(sigHashes),
+				spew.Sdump(want))
+		}
+	}
+}
+
+// TestTxSigHashesUpdateLegacyOnly 确保只花费传统输出的交易在增量更新后与从头计算的结果一致，
+// 包括移除全部输入后不再包含任何中间状态，以及重新加入输入后完整地计算中间状态。
+func TestTxSigHashesUpdateLegacyOnly(t *testing.T) {
+	t.Parallel()
+
+	tx, prevOuts, err := genTestTx()
+	if err != nil {
+		t.Fatalf("unable to generate test tx: %v", err)
+	}
+	p2pkh := append([]byte{OP_DUP, OP_HASH160, OP_DATA_20},
+		make([]byte, 20)...)
+	p2pkh = append(p2pkh, OP_EQUALVERIFY, OP_CHECKSIG)
+	for _, txIn := range tx.TxIn {
+		prevOuts.AddPrevOut(txIn.PreviousOutPoint, &wire.TxOut{
+			Value:    1000,
+			PkScript: p2pkh,
+		})
+	}
+	sigHashes := NewTxSigHashes(tx, prevOuts)
+	txIns := tx.TxIn
+
+	mutations := []struct {
+		name   string
+		mutate func()
+	}{{
+		name: "change output",
+		mutate: func() {
+			tx.TxOut[0].Value++
+			sigHashes.InvalidateOutputs()
+		},
+	}, {
+		name: "change sequence",
+		mutate: func() {
+			tx.TxIn[0].Sequence++
+			sigHashes.Invalidate(MidstateSequences)
+		},
+	}, {
+		name: "remove all inputs",
+		mutate: func() {
+			tx.TxIn = nil
+			sigHashes.InvalidateInputs()
+		},
+	}, {
+		name: "change output without inputs",
+		mutate: func() {
+			tx.TxOut[0].Value++
+			sigHashes.InvalidateOutputs()
+		},
+	}, {
+		name: "restore inputs",
+		mutate: func() {
+			tx.TxIn = txIns
+			sigHashes.InvalidateInputs()
+		},
+	}}
+
+	for _, m := range mutations {
+		m.mutate()
+		sigHashes.Update(tx, prevOuts)
+		want := NewTxSigHashes(tx, prevOuts)
+		if *sigHashes != *want {
+			t.Fatalf("%s: incremental sighashes mismatch: got %v, "+
+				"want %v", m.name, spew.Sdump(sigHashes),
+				spew.Sdump(want))
+		}
+	}
+}
+
+// prefetchingFetcher 记录 Prefetch 收到的外点的 PrevOutputPrefetcher。
+type prefetchingFetcher struct {
+	*MultiPrevOutFetcher
+	batches [][]wire.OutPoint
+}
+
+func (f *prefetchingFetcher) Prefetch(outpoints []wire.OutPoint) {
+	f.batches = append(f.batches, outpoints)
+}
+
+// TestPrefetchPrevOutputs 确保实现 PrevOutputPrefetcher 的获取器在签名哈希缓存和脚本引擎逐个获取先前输出之前，
+// 一次性收到交易所有非 coinbase 输入的外点。
+func TestPrefetchPrevOutputs(t *testing.T) {
+	t.Parallel()
+
+	tx, prevOuts, err := genTestTx()
+	if err != nil {
+		t.Fatalf("unable to generate test tx: %v", err)
+	}
+	var outpoints []wire.OutPoint
+	for _, txIn := range tx.TxIn {
+		outpoints = append(outpoints, txIn.PreviousOutPoint)
+	}
+	fetcher := &prefetchingFetcher{MultiPrevOutFetcher: prevOuts}
+
+	NewTxSigHashes(tx, fetcher)
+	if len(fetcher.batches) != 1 {
+		t.Fatalf("got %d prefetch batches, want 1", len(fetcher.batches))
+	}
+	if !reflect.DeepEqual(fetcher.batches[0], outpoints) {
+		t.Fatalf("prefetched %v, want %v", fetcher.batches[0], outpoints)
+	}
+
+	// coinbase 输入不引用先前输出，多个交易的外点合并为一批。
+	coinbase := wire.NewMsgTx(1)
+	coinbase.AddTxIn(&wire.TxIn{
+		PreviousOutPoint: wire.OutPoint{Index: wire.MaxPrevOutIndex},
+	})
+	fetcher.batches = nil
+	PrefetchPrevOutputs(fetcher, coinbase, tx, tx)
+	if len(fetcher.batches) != 1 ||
+		len(fetcher.batches[0]) != 2*len(outpoints) {
+
+		t.Fatalf("unexpected prefetch batches %v", fetcher.batches)
+	}
+	fetcher.batches = nil
+	PrefetchPrevOutputs(fetcher, coinbase)
+	if len(fetcher.batches) != 0 {
+		t.Fatalf("unexpected prefetch for coinbase: %v", fetcher.batches)
+	}
+
+	// 不实现 PrevOutputPrefetcher 的获取器不受影响。
+	PrefetchPrevOutputs(prevOuts, tx)
+
+	// 脚本引擎在检查见证花费所需的先前输出之前预取它们。
+	pkScript := []byte{OP_0, OP_DATA_20}
+	pkScript = append(pkScript, make([]byte, 20)...)
+	prevOuts.AddPrevOut(outpoints[0], wire.NewTxOut(1000, pkScript))
+	tx.TxIn[0].SignatureScript = nil
+	tx.TxIn[0].Witness = wire.TxWitness{{0x01}, make([]byte, 33)}
+	fetcher.batches = nil
+	vm, err := NewEngine(
+		pkScript, tx, 0, ScriptBip16|ScriptVerifyWitness, nil, nil,
+		1000, fetcher,
+	)
+	if err != nil {
+		t.Fatalf("unable to create engine: %v", err)
+	}
+	_ = vm.Execute()
+	if len(fetcher.batches) == 0 {
+		t.Fatalf("engine didn't prefetch previous outputs")
+	}
+}
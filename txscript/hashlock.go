@@ -0,0 +1,309 @@
+// 包含哈希锁脚本的构建和识别，以及从花费交易的见证和签名脚本中提取公开的原像，供不依赖完整原子交换模板的交换监控使用。
+
+package txscript
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/wire"
+	"golang.org/x/crypto/ripemd160"
+)
+
+// ErrInvalidHashLock 在哈希锁参数不符合要求或脚本不是哈希锁脚本时返回。
+var ErrInvalidHashLock = errors.New("invalid hash lock")
+
+// hashLockOpcodes 将哈希锁类型映射到对应的哈希操作码，与 hashLockOps 相反。
+var hashLockOpcodes = map[HashLockType]byte{
+	HashLockSHA256:    OP_SHA256,
+	HashLockHash256:   OP_HASH256,
+	HashLockHash160:   OP_HASH160,
+	HashLockRipemd160: OP_RIPEMD160,
+	HashLockSHA1:      OP_SHA1,
+}
+
+// Opcode 返回哈希锁类型对应的哈希操作码。 类型未知时返回 false。
+func (t HashLockType) Opcode() (byte, bool) {
+	op, ok := hashLockOpcodes[t]
+	return op, ok
+}
+
+// Digest 返回 data 在哈希锁类型对应的哈希操作码下的结果。 类型未知时返回 nil。
+func (t HashLockType) Digest(data []byte) []byte {
+	switch t {
+	case HashLockSHA256:
+		hash := sha256.Sum256(data)
+		return hash[:]
+	case HashLockHash256:
+		return chainhash.DoubleHashB(data)
+	case HashLockHash160:
+		return hash160(data)
+	case HashLockRipemd160:
+		return calcHash(data, ripemd160.New())
+	case HashLockSHA1:
+		hash := sha1.Sum(data)
+		return hash[:]
+	}
+	return nil
+}
+
+// Matches 返回 preimage 的哈希是否等于哈希锁的哈希。
+func (l HashLock) Matches(preimage []byte) bool {
+	digest := l.Type.Digest(preimage)
+	return digest != nil && bytes.Equal(digest, l.Hash)
+}
+
+// HashLockScript 描述 BuildHashLockScript 或 BuildHashLockKeyScript 生成的脚本：
+//
+//	[SIZE <PreimageSize> EQUALVERIFY] <hash op> <hash> EQUAL
+//	[SIZE <PreimageSize> EQUALVERIFY] <hash op> <hash> EQUALVERIFY <PubKey> CHECKSIG
+type HashLockScript struct {
+	// Lock 是脚本的哈希锁。
+	Lock HashLock
+
+	// PreimageSize 是脚本要求的原像字节数，为 0 时脚本不限制原像的长度。
+	PreimageSize int
+
+	// PubKey 是花费时需要签名的公钥，为 nil 时只需要原像。
+	PubKey []byte
+}
+
+// Script 返回描述的脚本。
+func (s *HashLockScript) Script() ([]byte, error) {
+	op, ok := hashLockOpcodes[s.Lock.Type]
+	if !ok {
+		return nil, fmt.Errorf("%w: unknown type %v", ErrInvalidHashLock,
+			s.Lock.Type)
+	}
+	if size := hashLockOps[op].size; len(s.Lock.Hash) != size {
+		return nil, fmt.Errorf("%w: %v hash must be %d bytes, got %d",
+			ErrInvalidHashLock, s.Lock.Type, size, len(s.Lock.Hash))
+	}
+	if s.PreimageSize < 0 || s.PreimageSize > MaxScriptElementSize {
+		return nil, fmt.Errorf("%w: preimage size %d out of range",
+			ErrInvalidHashLock, s.PreimageSize)
+	}
+	if s.PubKey != nil && len(s.PubKey) != 32 && len(s.PubKey) != 33 {
+		return nil, fmt.Errorf("%w: public key must be 32 or 33 bytes, "+
+			"got %d", ErrInvalidHashLock, len(s.PubKey))
+	}
+
+	builder := NewScriptBuilder()
+	if s.PreimageSize != 0 {
+		builder.AddOp(OP_SIZE).AddInt64(int64(s.PreimageSize)).
+			AddOp(OP_EQUALVERIFY)
+	}
+	builder.AddOp(op).AddData(s.Lock.Hash)
+	if s.PubKey == nil {
+		return builder.AddOp(OP_EQUAL).Script()
+	}
+	return builder.AddOp(OP_EQUALVERIFY).AddData(s.PubKey).
+		AddOp(OP_CHECKSIG).Script()
+}
+
+// Matches 返回 preimage 是否满足脚本的哈希锁，包括长度要求。
+func (s *HashLockScript) Matches(preimage []byte) bool {
+	if s.PreimageSize != 0 && len(preimage) != s.PreimageSize {
+		return false
+	}
+	return s.Lock.Matches(preimage)
+}
+
+// BuildHashLockScript 返回任何知道原像的人都可以花费的哈希锁脚本，preimageSize 为 0 时不限制原像的长度。
+// 花费时只需提供原像。 由于原像一经公开任何人都可以花费，该脚本通常只作为更大合约的一部分或用于测试。
+func BuildHashLockScript(lock HashLock, preimageSize int) ([]byte, error) {
+	s := HashLockScript{Lock: lock, PreimageSize: preimageSize}
+	return s.Script()
+}
+
+// BuildHashLockKeyScript 返回需要同时提供原像和 pubKey 签名的哈希锁脚本，preimageSize 为 0 时不限制原像的长度。
+// pubKey 在 P2WSH 见证脚本中为 33 字节的压缩公钥，在 tapscript 中为 32 字节的 x-only 公钥。 见证为 {sig, preimage}。
+func BuildHashLockKeyScript(lock HashLock, preimageSize int,
+	pubKey []byte) ([]byte, error) {
+
+	if pubKey == nil {
+		return nil, fmt.Errorf("%w: missing public key", ErrInvalidHashLock)
+	}
+	s := HashLockScript{Lock: lock, PreimageSize: preimageSize, PubKey: pubKey}
+	return s.Script()
+}
+
+// ParseHashLockScript 解析 BuildHashLockScript 或 BuildHashLockKeyScript 生成的脚本。 脚本不是这两种形式时返回 ErrInvalidHashLock。
+// 返回的哈希和公钥引用传入的脚本而不是副本。
+func ParseHashLockScript(script []byte) (*HashLockScript, error) {
+	const scriptVersion = 0
+	var tokens []requirementToken
+	tokenizer := MakeScriptTokenizer(scriptVersion, script)
+	for tokenizer.Next() {
+		tokens = append(tokens, requirementToken{
+			op:   tokenizer.Opcode(),
+			data: tokenizer.Data(),
+		})
+	}
+	if err := tokenizer.Err(); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidHashLock, err)
+	}
+
+	var s HashLockScript
+	if len(tokens) > 3 && tokens[0].op == OP_SIZE &&
+		tokens[2].op == OP_EQUALVERIFY {
+
+		size, ok := requirementNum(tokens, 1)
+		if !ok || size <= 0 {
+			return nil, fmt.Errorf("%w: invalid preimage size",
+				ErrInvalidHashLock)
+		}
+		s.PreimageSize = int(size)
+		tokens = tokens[3:]
+	}
+
+	if len(tokens) < 3 {
+		return nil, fmt.Errorf("%w: script too short", ErrInvalidHashLock)
+	}
+	lockOp, ok := hashLockOps[tokens[0].op]
+	if !ok {
+		return nil, fmt.Errorf("%w: missing hash opcode", ErrInvalidHashLock)
+	}
+	s.Lock = HashLock{Type: lockOp.lockType, Hash: tokens[1].data}
+
+	switch {
+	case len(tokens) == 3 && tokens[2].op == OP_EQUAL:
+	case len(tokens) == 5 && tokens[2].op == OP_EQUALVERIFY &&
+		tokens[4].op == OP_CHECKSIG:
+
+		s.PubKey = tokens[3].data
+	default:
+		return nil, fmt.Errorf("%w: unexpected script form",
+			ErrInvalidHashLock)
+	}
+
+	// Rebuilding the script validates the hash and key sizes and ensures
+	// the script uses the canonical encoding.
+	expected, err := s.Script()
+	if err != nil {
+		return nil, err
+	}
+	if !bytes.Equal(expected, script) {
+		return nil, fmt.Errorf("%w: non-canonical script encoding",
+			ErrInvalidHashLock)
+	}
+	return &s, nil
+}
+
+// IsHashLockScript 返回脚本是否是 BuildHashLockScript 或 BuildHashLockKeyScript 生成的哈希锁脚本。
+func IsHashLockScript(script []byte) bool {
+	_, err := ParseHashLockScript(script)
+	return err == nil
+}
+
+// RevealedPreimage 是花费交易中公开的一个原像。
+type RevealedPreimage struct {
+	// InputIndex 是公开原像的输入的索引。
+	InputIndex int
+
+	// Lock 是原像满足的哈希锁。
+	Lock HashLock
+
+	// Preimage 是公开的原像。
+	Preimage []byte
+}
+
+// inputStackItems 返回输入的签名脚本推送和见证中的所有元素。 签名脚本无法解析时忽略签名脚本。
+func inputStackItems(txIn *wire.TxIn) [][]byte {
+	items, err := PushedData(txIn.SignatureScript)
+	if err != nil {
+		items = nil
+	}
+	return append(items, txIn.Witness...)
+}
+
+// revealedScripts 返回输入公开的可能包含哈希锁的脚本：P2SH 兑换脚本、P2WSH 见证脚本或 tapscript 叶子。
+// 由于不知道被花费的输出，所有可能的候选都会返回。
+func revealedScripts(txIn *wire.TxIn) [][]byte {
+	var scripts [][]byte
+	pushes, err := PushedData(txIn.SignatureScript)
+	if err == nil && len(pushes) != 0 {
+		scripts = append(scripts, pushes[len(pushes)-1])
+	}
+
+	witness := txIn.Witness
+	if isAnnexedWitness(witness) {
+		witness = witness[:len(witness)-1]
+	}
+	if len(witness) != 0 {
+		scripts = append(scripts, witness[len(witness)-1])
+	}
+	if len(witness) >= 2 {
+		if _, err := ParseControlBlock(witness[len(witness)-1]); err == nil {
+			scripts = append(scripts, witness[len(witness)-2])
+		}
+	}
+	return scripts
+}
+
+// ExtractPreimages 返回 tx 的输入中公开的所有原像。 每个输入公开的兑换脚本、见证脚本或 tapscript 叶子中由
+// ExtractRequirements 识别的哈希锁，都会与该输入的签名脚本推送和见证元素进行比对，因此 HTLC、哈希锁脚本以及其他包含
+// <hash op> <hash> EQUAL 或 EQUALVERIFY 形式的合约都可以被识别。 prevOutFetcher 不为 nil 时，被花费的裸公钥脚本中的哈希锁也会被比对。
+//
+// 同一输入中同一哈希锁的原像只返回一次。 需要监控已知哈希而不关心脚本形式时使用 FindPreimage。
+func ExtractPreimages(tx *wire.MsgTx,
+	prevOutFetcher PrevOutputFetcher) []RevealedPreimage {
+
+	var revealed []RevealedPreimage
+	for idx, txIn := range tx.TxIn {
+		scripts := revealedScripts(txIn)
+		if prevOutFetcher != nil {
+			prevOut := prevOutFetcher.FetchPrevOutput(txIn.PreviousOutPoint)
+			if prevOut != nil {
+				scripts = append(scripts, prevOut.PkScript)
+			}
+		}
+
+		var reqs ScriptRequirements
+		for _, script := range scripts {
+			scriptReqs, err := ExtractRequirements(script)
+			if err != nil {
+				continue
+			}
+			for _, lock := range scriptReqs.HashLocks {
+				reqs.addHashLock(lock)
+			}
+		}
+		if len(reqs.HashLocks) == 0 {
+			continue
+		}
+
+		items := inputStackItems(txIn)
+		for _, lock := range reqs.HashLocks {
+			for _, item := range items {
+				if lock.Matches(item) {
+					revealed = append(revealed, RevealedPreimage{
+						InputIndex: idx,
+						Lock:       lock,
+						Preimage:   item,
+					})
+					break
+				}
+			}
+		}
+	}
+
+	return revealed
+}
+
+// FindPreimage 在 tx 所有输入的签名脚本推送和见证元素中查找满足哈希锁的原像，返回原像及其所在输入的索引。
+// 与 ExtractPreimages 不同，它不需要识别脚本的形式，因此适用于任何公开原像的合约。 没有找到时返回 false。
+func FindPreimage(tx *wire.MsgTx, lock HashLock) ([]byte, int, bool) {
+	for idx, txIn := range tx.TxIn {
+		for _, item := range inputStackItems(txIn) {
+			if lock.Matches(item) {
+				return item, idx, true
+			}
+		}
+	}
+	return nil, 0, false
+}
@@ -0,0 +1,246 @@
+package txscript
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/stretchr/testify/require"
+)
+
+// TestHashLockScript 确保哈希锁脚本可以构建、解析和识别，并拒绝无效的参数。
+func TestHashLockScript(t *testing.T) {
+	t.Parallel()
+
+	preimage := bytes.Repeat([]byte{0x42}, 32)
+	sha := HashLock{Type: HashLockSHA256, Hash: HashLockSHA256.Digest(preimage)}
+	h160 := HashLock{Type: HashLockHash160, Hash: btcutil.Hash160(preimage)}
+	pubKey := bytes.Repeat([]byte{0x02}, 33)
+
+	tests := []HashLockScript{
+		{Lock: sha},
+		{Lock: sha, PreimageSize: 32},
+		{Lock: h160, PreimageSize: 32, PubKey: pubKey},
+		{Lock: h160, PubKey: pubKey[1:]},
+		{Lock: HashLock{
+			Type: HashLockHash256,
+			Hash: HashLockHash256.Digest(preimage),
+		}},
+	}
+	for _, test := range tests {
+		script, err := test.Script()
+		require.NoError(t, err)
+		require.True(t, IsHashLockScript(script))
+
+		parsed, err := ParseHashLockScript(script)
+		require.NoError(t, err)
+		require.Equal(t, test, *parsed)
+		require.True(t, parsed.Matches(preimage))
+		require.False(t, parsed.Matches(preimage[1:]))
+	}
+
+	script, err := BuildHashLockScript(sha, 16)
+	require.NoError(t, err)
+	require.Equal(t, mustParseShortForm("SIZE 16 EQUALVERIFY SHA256 DATA_32 "+
+		"0x"+hex.EncodeToString(sha.Hash)+" EQUAL"), script)
+
+	// 无效的参数。
+	_, err = BuildHashLockScript(HashLock{Type: HashLockSHA256,
+		Hash: h160.Hash}, 0)
+	require.ErrorIs(t, err, ErrInvalidHashLock)
+	_, err = BuildHashLockScript(HashLock{Type: 0xff, Hash: sha.Hash}, 0)
+	require.ErrorIs(t, err, ErrInvalidHashLock)
+	_, err = BuildHashLockScript(sha, MaxScriptElementSize+1)
+	require.ErrorIs(t, err, ErrInvalidHashLock)
+	_, err = BuildHashLockKeyScript(sha, 0, nil)
+	require.ErrorIs(t, err, ErrInvalidHashLock)
+	_, err = BuildHashLockKeyScript(sha, 0, pubKey[:20])
+	require.ErrorIs(t, err, ErrInvalidHashLock)
+
+	// 其他脚本不是哈希锁脚本。
+	var recipientHash, refundHash [20]byte
+	htlc, err := BuildHTLCScript(recipientHash, refundHash,
+		sha256.Sum256(preimage), 100)
+	require.NoError(t, err)
+	for _, script := range [][]byte{
+		nil,
+		htlc,
+		mustParseShortForm("DUP HASH160 DATA_20 0x" + hex.EncodeToString(h160.Hash) +
+			" EQUALVERIFY CHECKSIG"),
+		mustParseShortForm("SHA256 DATA_32 0x" + hex.EncodeToString(sha.Hash) +
+			" EQUAL 1"),
+		mustParseShortForm("SIZE 0 EQUALVERIFY SHA256 DATA_32 0x" +
+			hex.EncodeToString(sha.Hash) + " EQUAL"),
+		mustParseShortForm("SIZE DATA_1 0x10 EQUALVERIFY SHA256 " +
+			"DATA_32 0x" + hex.EncodeToString(sha.Hash) + " EQUAL"),
+	} {
+		require.False(t, IsHashLockScript(script), "%x", script)
+		_, err := ParseHashLockScript(script)
+		require.ErrorIs(t, err, ErrInvalidHashLock)
+	}
+}
+
+// TestHashLockKeyScriptSpend 确保 P2WSH 哈希锁签名脚本可以使用原像和签名花费，错误的原像会失败。
+func TestHashLockKeyScriptSpend(t *testing.T) {
+	t.Parallel()
+
+	key, err := btcec.NewPrivateKey()
+	require.NoError(t, err)
+	preimage := bytes.Repeat([]byte{0x11}, 32)
+	lock := HashLock{
+		Type: HashLockSHA256,
+		Hash: HashLockSHA256.Digest(preimage),
+	}
+	script, err := BuildHashLockKeyScript(
+		lock, len(preimage), key.PubKey().SerializeCompressed(),
+	)
+	require.NoError(t, err)
+
+	scriptHash := sha256.Sum256(script)
+	pkScript, err := payToWitnessScriptHashScript(scriptHash[:])
+	require.NoError(t, err)
+	const amt = 1e6
+	prevFetcher := NewCannedPrevOutputFetcher(pkScript, amt)
+
+	tx := htlcSpendingTx(0)
+	sigHashes := NewTxSigHashes(tx, prevFetcher)
+	sig, err := RawTxInWitnessSignature(
+		tx, sigHashes, 0, amt, script, SigHashAll, key,
+	)
+	require.NoError(t, err)
+
+	for _, test := range []struct {
+		preimage []byte
+		valid    bool
+	}{
+		{preimage, true},
+		{bytes.Repeat([]byte{0x12}, 32), false},
+		{preimage[1:], false},
+	} {
+		tx.TxIn[0].Witness = wire.TxWitness{sig, test.preimage, script}
+		vm, err := NewEngine(pkScript, tx, 0, StandardVerifyFlags, nil,
+			sigHashes, amt, prevFetcher)
+		require.NoError(t, err)
+		if test.valid {
+			require.NoError(t, vm.Execute())
+		} else {
+			require.True(t, IsErrorCode(vm.Execute(), ErrEqualVerify))
+		}
+	}
+}
+
+// TestExtractPreimages 确保能够从各种花费形式中提取公开的原像。
+func TestExtractPreimages(t *testing.T) {
+	t.Parallel()
+
+	newPreimage := func(b byte) []byte {
+		return bytes.Repeat([]byte{b}, 32)
+	}
+	dummySig := bytes.Repeat([]byte{0x30}, 71)
+	dummyPubKey := bytes.Repeat([]byte{0x02}, 33)
+	tx := wire.NewMsgTx(2)
+	fetcher := NewMultiPrevOutFetcher(nil)
+	addInput := func(sigScript []byte, witness wire.TxWitness,
+		pkScript []byte) {
+
+		op := wire.OutPoint{Index: uint32(len(tx.TxIn))}
+		tx.AddTxIn(&wire.TxIn{
+			PreviousOutPoint: op,
+			SignatureScript:  sigScript,
+			Witness:          witness,
+		})
+		fetcher.AddPrevOut(op, wire.NewTxOut(1e6, pkScript))
+	}
+
+	// 0: P2WSH HTLC 赎回。
+	htlcPreimage := newPreimage(1)
+	var recipientHash, refundHash [20]byte
+	htlc, err := BuildHTLCScript(recipientHash, refundHash,
+		sha256.Sum256(htlcPreimage), 100)
+	require.NoError(t, err)
+	witness, err := RedeemHTLCWitness(htlcPreimage, dummySig, dummyPubKey,
+		htlc)
+	require.NoError(t, err)
+	addInput(nil, witness, nil)
+
+	// 1: tapscript HTLC 赎回，附带附件。
+	tapPreimage := newPreimage(2)
+	recipientKey, err := btcec.NewPrivateKey()
+	require.NoError(t, err)
+	redeemLeaf, refundLeaf, err := BuildTapscriptHTLCLeaves(
+		recipientKey.PubKey(), recipientKey.PubKey(),
+		sha256.Sum256(tapPreimage), 100,
+	)
+	require.NoError(t, err)
+	tree := AssembleTaprootScriptTree(redeemLeaf, refundLeaf)
+	idx := tree.LeafProofIndex[redeemLeaf.TapHash()]
+	ctrlBlock := tree.LeafMerkleProofs[idx].ToControlBlock(
+		recipientKey.PubKey(),
+	)
+	ctrlBytes, err := ctrlBlock.ToBytes()
+	require.NoError(t, err)
+	witness, err = RedeemTapscriptHTLCWitness(tapPreimage, dummySig[:64],
+		redeemLeaf.Script, ctrlBytes)
+	require.NoError(t, err)
+	addInput(nil, append(witness, []byte{TaprootAnnexTag}), nil)
+
+	// 2: P2SH HASH160 哈希锁。
+	p2shPreimage := []byte("short preimage")
+	p2shLock := HashLock{
+		Type: HashLockHash160,
+		Hash: HashLockHash160.Digest(p2shPreimage),
+	}
+	redeemScript, err := BuildHashLockScript(p2shLock, 0)
+	require.NoError(t, err)
+	sigScript, err := NewScriptBuilder().AddData(p2shPreimage).
+		AddData(redeemScript).Script()
+	require.NoError(t, err)
+	addInput(sigScript, nil, nil)
+
+	// 3: 裸哈希锁公钥脚本，只有提供前一输出时才能识别。
+	barePreimage := newPreimage(3)
+	bareLock := HashLock{
+		Type: HashLockSHA256,
+		Hash: HashLockSHA256.Digest(barePreimage),
+	}
+	bareScript, err := BuildHashLockScript(bareLock, 32)
+	require.NoError(t, err)
+	sigScript, err = NewScriptBuilder().AddData(barePreimage).Script()
+	require.NoError(t, err)
+	addInput(sigScript, nil, bareScript)
+
+	// 4: 不包含哈希锁的 P2WPKH 花费。
+	addInput(nil, wire.TxWitness{dummySig, dummyPubKey}, nil)
+
+	revealed := ExtractPreimages(tx, nil)
+	require.Len(t, revealed, 3)
+	require.Equal(t, 0, revealed[0].InputIndex)
+	require.Equal(t, htlcPreimage, revealed[0].Preimage)
+	require.Equal(t, HashLockSHA256, revealed[0].Lock.Type)
+	require.Equal(t, 1, revealed[1].InputIndex)
+	require.Equal(t, tapPreimage, revealed[1].Preimage)
+	require.Equal(t, 2, revealed[2].InputIndex)
+	require.Equal(t, p2shPreimage, revealed[2].Preimage)
+	require.Equal(t, p2shLock, revealed[2].Lock)
+
+	revealed = ExtractPreimages(tx, fetcher)
+	require.Len(t, revealed, 4)
+	require.Equal(t, 3, revealed[3].InputIndex)
+	require.Equal(t, barePreimage, revealed[3].Preimage)
+
+	// FindPreimage 不需要识别脚本。
+	preimage, inputIdx, ok := FindPreimage(tx, bareLock)
+	require.True(t, ok)
+	require.Equal(t, 3, inputIdx)
+	require.Equal(t, barePreimage, preimage)
+
+	_, _, ok = FindPreimage(tx, HashLock{
+		Type: HashLockSHA256,
+		Hash: HashLockSHA256.Digest(newPreimage(4)),
+	})
+	require.False(t, ok)
+}
@@ -0,0 +1,378 @@
+// 包含由 BIP0032 扩展密钥和描述符风格的派生模板支持的 KeyDB 和 ScriptDB 实现，
+// 使 SignTxOutput 可以按地址找到密钥，而无需调用方预先计算每个地址的映射。
+
+package txscript
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcec/v2/schnorr"
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/btcutil/hdkeychain"
+	"github.com/btcsuite/btcd/chaincfg"
+)
+
+// DefaultGapLimit 是 HDKeyDB 默认的间隙限制，即在最后一个被使用的地址之后继续派生的地址数。
+const DefaultGapLimit = 20
+
+var (
+	// ErrInvalidDerivationTemplate 在派生模板无法解析时返回。
+	ErrInvalidDerivationTemplate = errors.New("invalid derivation template")
+
+	// ErrHDKeyNotFound 在地址不在任何模板已派生的范围（包括间隙限制）内时返回。
+	ErrHDKeyNotFound = errors.New("address not found in extended key")
+
+	// ErrHDKeyPublicOnly 在由扩展公钥支持的 HDKeyDB 被请求私钥时返回。
+	ErrHDKeyPublicOnly = errors.New("extended key is public only")
+)
+
+// hdScriptType 是派生模板生成的输出类型。
+type hdScriptType uint8
+
+const (
+	hdPubKeyHash hdScriptType = iota
+	hdWitnessPubKeyHash
+	hdNestedWitnessPubKeyHash
+	hdTaproot
+)
+
+// hdTemplate 是已解析的派生模板。
+type hdTemplate struct {
+	desc       string
+	scriptType hdScriptType
+
+	// base 是模板中通配符之前的路径派生出的扩展密钥。
+	base *hdkeychain.ExtendedKey
+
+	// hardenedWildcard 表示通配符索引使用强化派生。
+	hardenedWildcard bool
+
+	// addrs 按索引保存已派生的地址，无效的子密钥索引为 nil。
+	addrs []btcutil.Address
+
+	// used 是已被查找到的最大索引加一。
+	used uint32
+}
+
+// hdEntry 是已派生的地址。
+type hdEntry struct {
+	template *hdTemplate
+	index    uint32
+
+	// redeemScript 是嵌套见证地址的兑换脚本，其他地址为 nil。
+	redeemScript []byte
+}
+
+// HDKeyDB 是由 BIP0032 扩展密钥支持的 KeyDB 和 ScriptDB。 地址按派生模板生成，每个模板初始派生间隙限制数量的地址；
+// 每当查找命中某个索引，就继续派生到该索引之后间隙限制数量的地址，与钱包恢复时的间隙扫描规则相同。 派生结果被缓存。
+//
+// 派生模板使用描述符风格的语法，路径相对于扩展密钥，最后一个元素必须是通配符：
+//
+//	pkh(0/*)                P2PKH
+//	wpkh(84h/0h/0h/0/*)     P2WPKH
+//	sh(wpkh(49'/0'/0'/0/*)) P2SH 嵌套的 P2WPKH
+//	tr(86h/0h/0h/0/*)       BIP0086 P2TR 密钥路径
+//
+// 强化步骤可以用 h 或 ' 表示，并且要求扩展私钥。 由扩展公钥支持时，GetKey 返回 ErrHDKeyPublicOnly，GetScript 仍然可用。
+//
+// 对于 P2TR 地址，GetKey 返回未调整的内部私钥，可以直接传递给 TaprootWitnessSignature。
+// HDKeyDB 可以被并发使用。
+type HDKeyDB struct {
+	mtx sync.Mutex
+
+	params    *chaincfg.Params
+	gapLimit  uint32
+	private   bool
+	templates []*hdTemplate
+	entries   map[string]*hdEntry
+	keys      map[string]*btcec.PrivateKey
+}
+
+// 确保 HDKeyDB 同时实现 KeyDB 和 ScriptDB。
+var (
+	_ KeyDB    = (*HDKeyDB)(nil)
+	_ ScriptDB = (*HDKeyDB)(nil)
+)
+
+// NewHDKeyDB 返回由扩展密钥和派生模板支持的 HDKeyDB，使用 DefaultGapLimit。
+func NewHDKeyDB(key *hdkeychain.ExtendedKey, params *chaincfg.Params,
+	templates ...string) (*HDKeyDB, error) {
+
+	return NewHDKeyDBWithGapLimit(key, params, DefaultGapLimit, templates...)
+}
+
+// NewHDKeyDBWithGapLimit 与 NewHDKeyDB 相同，但使用指定的间隙限制。
+func NewHDKeyDBWithGapLimit(key *hdkeychain.ExtendedKey,
+	params *chaincfg.Params, gapLimit uint32,
+	templates ...string) (*HDKeyDB, error) {
+
+	if gapLimit == 0 {
+		return nil, fmt.Errorf("%w: gap limit must be positive",
+			ErrInvalidDerivationTemplate)
+	}
+	if len(templates) == 0 {
+		return nil, fmt.Errorf("%w: at least one template is required",
+			ErrInvalidDerivationTemplate)
+	}
+
+	db := &HDKeyDB{
+		params:   params,
+		gapLimit: gapLimit,
+		private:  key.IsPrivate(),
+		entries:  make(map[string]*hdEntry),
+		keys:     make(map[string]*btcec.PrivateKey),
+	}
+	for _, desc := range templates {
+		tmpl, err := parseHDTemplate(key, desc)
+		if err != nil {
+			return nil, err
+		}
+		db.templates = append(db.templates, tmpl)
+		if err := db.deriveUpTo(tmpl, gapLimit); err != nil {
+			return nil, err
+		}
+	}
+	return db, nil
+}
+
+// parseHDTemplate 解析派生模板并派生通配符之前的路径。
+func parseHDTemplate(key *hdkeychain.ExtendedKey,
+	desc string) (*hdTemplate, error) {
+
+	invalid := func(format string, args ...interface{}) error {
+		return fmt.Errorf("%w: %q: %s", ErrInvalidDerivationTemplate,
+			desc, fmt.Sprintf(format, args...))
+	}
+
+	tmpl := &hdTemplate{desc: desc}
+	path := strings.TrimSpace(desc)
+	unwrap := func(fn string) bool {
+		if strings.HasPrefix(path, fn+"(") && strings.HasSuffix(path, ")") {
+			path = path[len(fn)+1 : len(path)-1]
+			return true
+		}
+		return false
+	}
+	switch {
+	case unwrap("pkh"):
+		tmpl.scriptType = hdPubKeyHash
+	case unwrap("wpkh"):
+		tmpl.scriptType = hdWitnessPubKeyHash
+	case unwrap("tr"):
+		tmpl.scriptType = hdTaproot
+	case unwrap("sh"):
+		if !unwrap("wpkh") {
+			return nil, invalid("sh() only supports wpkh()")
+		}
+		tmpl.scriptType = hdNestedWitnessPubKeyHash
+	default:
+		return nil, invalid("unsupported script type")
+	}
+
+	path = strings.TrimPrefix(path, "m/")
+	steps := strings.Split(path, "/")
+	wildcard := steps[len(steps)-1]
+	switch wildcard {
+	case "*":
+	case "*h", "*'":
+		tmpl.hardenedWildcard = true
+	default:
+		return nil, invalid("path must end with a wildcard")
+	}
+
+	base := key
+	for _, step := range steps[:len(steps)-1] {
+		hardened := strings.HasSuffix(step, "h") ||
+			strings.HasSuffix(step, "'")
+		if hardened {
+			step = step[:len(step)-1]
+		}
+		index, err := strconv.ParseUint(step, 10, 31)
+		if err != nil {
+			return nil, invalid("invalid path element %q", step)
+		}
+		if hardened {
+			index += hdkeychain.HardenedKeyStart
+		}
+
+		base, err = base.Derive(uint32(index))
+		if err != nil {
+			return nil, invalid("%v", err)
+		}
+	}
+	if tmpl.hardenedWildcard && !key.IsPrivate() {
+		return nil, invalid("hardened wildcard requires a private key")
+	}
+	tmpl.base = base
+
+	return tmpl, nil
+}
+
+// deriveChild 派生模板中指定索引的子密钥。
+func (t *hdTemplate) deriveChild(index uint32) (*hdkeychain.ExtendedKey, error) {
+	if t.hardenedWildcard {
+		index += hdkeychain.HardenedKeyStart
+	}
+	return t.base.Derive(index)
+}
+
+// deriveUpTo 派生模板中索引小于 n 的所有地址。
+func (db *HDKeyDB) deriveUpTo(t *hdTemplate, n uint32) error {
+	for index := uint32(len(t.addrs)); index < n; index++ {
+		child, err := t.deriveChild(index)
+		if err != nil {
+			// Roughly 1 in 2^127 indexes is invalid and must be
+			// skipped, as specified by BIP0032.
+			if errors.Is(err, hdkeychain.ErrInvalidChild) {
+				t.addrs = append(t.addrs, nil)
+				continue
+			}
+			return err
+		}
+		pubKey, err := child.ECPubKey()
+		if err != nil {
+			return err
+		}
+
+		entry := &hdEntry{template: t, index: index}
+		pkHash := btcutil.Hash160(pubKey.SerializeCompressed())
+
+		var addr btcutil.Address
+		switch t.scriptType {
+		case hdPubKeyHash:
+			addr, err = btcutil.NewAddressPubKeyHash(pkHash, db.params)
+
+		case hdWitnessPubKeyHash:
+			addr, err = btcutil.NewAddressWitnessPubKeyHash(
+				pkHash, db.params,
+			)
+
+		case hdNestedWitnessPubKeyHash:
+			// The inner witness address is indexed as well, so the
+			// key can be found when signing the nested program.
+			var inner btcutil.Address
+			inner, err = btcutil.NewAddressWitnessPubKeyHash(
+				pkHash, db.params,
+			)
+			if err != nil {
+				return err
+			}
+			db.entries[inner.EncodeAddress()] = entry
+
+			entry.redeemScript, err = PayToAddrScript(inner)
+			if err != nil {
+				return err
+			}
+			addr, err = btcutil.NewAddressScriptHash(
+				entry.redeemScript, db.params,
+			)
+
+		case hdTaproot:
+			addr, err = btcutil.NewAddressTaproot(
+				schnorr.SerializePubKey(ComputeTaprootKeyNoScript(pubKey)),
+				db.params,
+			)
+		}
+		if err != nil {
+			return err
+		}
+		db.entries[addr.EncodeAddress()] = entry
+		t.addrs = append(t.addrs, addr)
+	}
+	return nil
+}
+
+// lookup 返回地址对应的已派生条目，并将其标记为已使用，必要时按间隙限制继续派生。
+func (db *HDKeyDB) lookup(addr btcutil.Address) (*hdEntry, error) {
+	entry, ok := db.entries[addr.EncodeAddress()]
+	if !ok {
+		return nil, fmt.Errorf("%w: %v", ErrHDKeyNotFound,
+			addr.EncodeAddress())
+	}
+
+	t := entry.template
+	if entry.index >= t.used {
+		t.used = entry.index + 1
+		if err := db.deriveUpTo(t, t.used+db.gapLimit); err != nil {
+			return nil, err
+		}
+	}
+	return entry, nil
+}
+
+// GetKey 实现 KeyDB 接口，返回地址对应的私钥。 派生的公钥总是压缩的。
+func (db *HDKeyDB) GetKey(addr btcutil.Address) (*btcec.PrivateKey, bool, error) {
+	db.mtx.Lock()
+	defer db.mtx.Unlock()
+
+	encoded := addr.EncodeAddress()
+	if key, ok := db.keys[encoded]; ok {
+		return key, true, nil
+	}
+
+	entry, err := db.lookup(addr)
+	if err != nil {
+		return nil, false, err
+	}
+	if !db.private {
+		return nil, false, ErrHDKeyPublicOnly
+	}
+
+	child, err := entry.template.deriveChild(entry.index)
+	if err != nil {
+		return nil, false, err
+	}
+	key, err := child.ECPrivKey()
+	if err != nil {
+		return nil, false, err
+	}
+	db.keys[encoded] = key
+
+	return key, true, nil
+}
+
+// GetScript 实现 ScriptDB 接口，返回 P2SH 嵌套见证地址的兑换脚本。
+func (db *HDKeyDB) GetScript(addr btcutil.Address) ([]byte, error) {
+	db.mtx.Lock()
+	defer db.mtx.Unlock()
+
+	entry, err := db.lookup(addr)
+	if err != nil {
+		return nil, err
+	}
+	if entry.redeemScript == nil {
+		return nil, fmt.Errorf("%w: %v is not a script hash address",
+			ErrHDKeyNotFound, addr.EncodeAddress())
+	}
+	return entry.redeemScript, nil
+}
+
+// Address 返回第 i 个模板中指定索引的地址，并将其标记为已使用，必要时按间隙限制继续派生。 钱包可以用它生成新的收款地址。
+func (db *HDKeyDB) Address(template int, index uint32) (btcutil.Address, error) {
+	db.mtx.Lock()
+	defer db.mtx.Unlock()
+
+	if template < 0 || template >= len(db.templates) {
+		return nil, fmt.Errorf("%w: template %d out of range",
+			ErrInvalidDerivationTemplate, template)
+	}
+	t := db.templates[template]
+	if err := db.deriveUpTo(t, index+1); err != nil {
+		return nil, err
+	}
+
+	addr := t.addrs[index]
+	if addr == nil {
+		return nil, fmt.Errorf("%w: index %d of template %q is invalid",
+			ErrHDKeyNotFound, index, t.desc)
+	}
+	if _, err := db.lookup(addr); err != nil {
+		return nil, err
+	}
+	return addr, nil
+}
@@ -0,0 +1,155 @@
+package txscript
+
+import (
+	"crypto/sha512"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/btcutil/hdkeychain"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/pbkdf2"
+)
+
+// testHDMasterKey 返回 BIP0039 测试助记词 "abandon ... about" 对应的主网主密钥，
+// BIP0044、BIP0049、BIP0084 和 BIP0086 都使用它给出测试向量。
+func testHDMasterKey(t *testing.T) *hdkeychain.ExtendedKey {
+	mnemonic := "abandon abandon abandon abandon abandon abandon " +
+		"abandon abandon abandon abandon abandon about"
+	seed := pbkdf2.Key([]byte(mnemonic), []byte("mnemonic"), 2048, 64,
+		sha512.New)
+	master, err := hdkeychain.NewMaster(seed, &chaincfg.MainNetParams)
+	require.NoError(t, err)
+	return master
+}
+
+// TestHDKeyDBAddresses 确保每种模板派生的第一个地址与对应 BIP 的测试向量一致，并且可以通过地址找到密钥和脚本。
+func TestHDKeyDBAddresses(t *testing.T) {
+	t.Parallel()
+
+	params := &chaincfg.MainNetParams
+	db, err := NewHDKeyDB(testHDMasterKey(t), params,
+		"pkh(44h/0h/0h/0/*)",
+		"sh(wpkh(49'/0'/0'/0/*))",
+		"wpkh(m/84h/0h/0h/0/*)",
+		"tr(86h/0h/0h/0/*)",
+	)
+	require.NoError(t, err)
+
+	want := []string{
+		"1LqBGSKuX5yYUonjxT5qGfpUsXKYYWeabA",
+		"37VucYSaXLCAsxYyAPfbSi9eh4iEcbShgf",
+		"bc1qcr8te4kr609gcawutmrza0j4xv80jy8z306fyu",
+		"bc1p5cyxnuxmeuwuvkwfem96lqzszd02n6xdcjrs20cac6yqjjwudpxqkedrcr",
+	}
+	for i, encoded := range want {
+		addr, err := db.Address(i, 0)
+		require.NoError(t, err)
+		require.Equal(t, encoded, addr.EncodeAddress())
+
+		if i == 1 {
+			redeemScript, err := db.GetScript(addr)
+			require.NoError(t, err)
+			version, _, err := ExtractWitnessProgramInfo(redeemScript)
+			require.NoError(t, err)
+			require.Zero(t, version)
+			continue
+		}
+
+		key, compressed, err := db.GetKey(addr)
+		require.NoError(t, err)
+		require.True(t, compressed)
+		if i == 3 {
+			outputKey := ComputeTaprootKeyNoScript(key.PubKey())
+			require.Equal(t, addr.ScriptAddress(),
+				outputKey.SerializeCompressed()[1:])
+		}
+
+		_, err = db.GetScript(addr)
+		require.ErrorIs(t, err, ErrHDKeyNotFound)
+	}
+
+	// SignTxOutput 可以直接使用 HDKeyDB 查找密钥。
+	addr, err := db.Address(0, 3)
+	require.NoError(t, err)
+	pkScript, err := PayToAddrScript(addr)
+	require.NoError(t, err)
+	tx := createSpendingTx(nil, nil, pkScript, 0)
+	sigScript, err := SignTxOutput(params, tx, 0, pkScript, SigHashAll, db,
+		db, nil)
+	require.NoError(t, err)
+	tx.TxIn[0].SignatureScript = sigScript
+	vm, err := NewEngine(pkScript, tx, 0, StandardVerifyFlags, nil, nil, 0,
+		nil)
+	require.NoError(t, err)
+	require.NoError(t, vm.Execute())
+}
+
+// TestHDKeyDBGapLimit 确保只有间隙限制内的地址可以被找到，并且查找命中后继续派生。
+func TestHDKeyDBGapLimit(t *testing.T) {
+	t.Parallel()
+
+	master := testHDMasterKey(t)
+	params := &chaincfg.MainNetParams
+	const tmpl = "wpkh(84h/0h/0h/0/*)"
+
+	reference, err := NewHDKeyDBWithGapLimit(master, params, 5, tmpl)
+	require.NoError(t, err)
+	var addrs []btcutil.Address
+	for i := uint32(0); i < 12; i++ {
+		addr, err := reference.Address(0, i)
+		require.NoError(t, err)
+		addrs = append(addrs, addr)
+	}
+
+	db, err := NewHDKeyDBWithGapLimit(master, params, 5, tmpl)
+	require.NoError(t, err)
+	_, _, err = db.GetKey(addrs[7])
+	require.ErrorIs(t, err, ErrHDKeyNotFound)
+
+	// 找到索引 4 的地址后，派生范围扩展到索引 9。
+	_, _, err = db.GetKey(addrs[4])
+	require.NoError(t, err)
+	_, _, err = db.GetKey(addrs[7])
+	require.NoError(t, err)
+	_, _, err = db.GetKey(addrs[11])
+	require.NoError(t, err)
+
+	// 扩展公钥只能派生非强化路径，并且不能提供私钥。
+	account := master
+	for _, i := range []uint32{84, 0, 0} {
+		account, err = account.Derive(hdkeychain.HardenedKeyStart + i)
+		require.NoError(t, err)
+	}
+	accountPub, err := account.Neuter()
+	require.NoError(t, err)
+
+	watchOnly, err := NewHDKeyDB(accountPub, params, "wpkh(0/*)")
+	require.NoError(t, err)
+	addr, err := watchOnly.Address(0, 0)
+	require.NoError(t, err)
+	require.Equal(t, addrs[0], addr)
+	_, _, err = watchOnly.GetKey(addr)
+	require.ErrorIs(t, err, ErrHDKeyPublicOnly)
+
+	unknown, err := btcutil.NewAddressWitnessPubKeyHash(make([]byte, 20),
+		params)
+	require.NoError(t, err)
+	_, _, err = watchOnly.GetKey(unknown)
+	require.ErrorIs(t, err, ErrHDKeyNotFound)
+
+	invalid := []string{
+		"wpkh(84h/0h/0h/0/1)",
+		"wsh(0/*)",
+		"sh(pkh(0/*))",
+		"pkh(x/*)",
+	}
+	for _, tmpl := range invalid {
+		_, err := NewHDKeyDB(master, params, tmpl)
+		require.ErrorIs(t, err, ErrInvalidDerivationTemplate, tmpl)
+	}
+	_, err = NewHDKeyDB(accountPub, params, "wpkh(0h/*)")
+	require.ErrorIs(t, err, ErrInvalidDerivationTemplate)
+	_, err = NewHDKeyDB(master, params)
+	require.ErrorIs(t, err, ErrInvalidDerivationTemplate)
+}
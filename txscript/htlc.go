@@ -0,0 +1,229 @@
+// 包含构建、花费和识别哈希时间锁定合约（HTLC）脚本的函数，用于跨链原子交换。
+
+package txscript
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcec/v2/schnorr"
+	"github.com/btcsuite/btcd/wire"
+)
+
+const (
+	// HTLCSecretSize 是 HTLC 原像所需的字节数。
+	HTLCSecretSize = 32
+
+	// maxHTLCLockTime 是能够被 CHECKLOCKTIMEVERIFY 解释的最大锁定时间。
+	maxHTLCLockTime = 1<<(8*cltvMaxScriptNumLen-1) - 1
+)
+
+// ErrInvalidHTLC 在 HTLC 参数或脚本不符合要求时返回。
+var ErrInvalidHTLC = errors.New("invalid htlc")
+
+// HTLCLeafType 表示 tapscript HTLC 叶子的花费路径。
+type HTLCLeafType uint8
+
+const (
+	// HTLCLeafUnknown 表示脚本不是 HTLC 叶子。
+	HTLCLeafUnknown HTLCLeafType = iota
+
+	// HTLCLeafRedeem 表示收款方出示原像的赎回叶子。
+	HTLCLeafRedeem
+
+	// HTLCLeafRefund 表示锁定时间到期后退款方的退款叶子。
+	HTLCLeafRefund
+)
+
+// String 返回叶子类型的可读名称。
+func (t HTLCLeafType) String() string {
+	switch t {
+	case HTLCLeafRedeem:
+		return "redeem"
+	case HTLCLeafRefund:
+		return "refund"
+	default:
+		return "unknown"
+	}
+}
+
+// checkHTLCLockTime 确保锁定时间可以被 CHECKLOCKTIMEVERIFY 使用。
+func checkHTLCLockTime(lockTime int64) error {
+	if lockTime <= 0 || lockTime > maxHTLCLockTime {
+		return fmt.Errorf("%w: lock time %d is not in range [1, %d]",
+			ErrInvalidHTLC, lockTime, maxHTLCLockTime)
+	}
+	return nil
+}
+
+// BuildHTLCScript 返回一个 HTLC 脚本，其格式与 ExtractAtomicSwapDataPushes 识别的原子交换合约相同：
+//
+//	IF
+//	 SIZE 32 EQUALVERIFY SHA256 <secretHash> EQUALVERIFY DUP HASH160 <recipientHash>
+//	ELSE
+//	 <lockTime> CHECKLOCKTIMEVERIFY DROP DUP HASH160 <refundHash>
+//	ENDIF
+//	EQUALVERIFY CHECKSIG
+//
+// 返回的脚本应当作为 P2SH 的赎回脚本或 P2WSH 的见证脚本使用。
+func BuildHTLCScript(recipientHash, refundHash [20]byte, secretHash [32]byte,
+	lockTime int64) ([]byte, error) {
+
+	if err := checkHTLCLockTime(lockTime); err != nil {
+		return nil, err
+	}
+
+	return NewScriptBuilder().
+		AddOp(OP_IF).
+		AddOp(OP_SIZE).AddInt64(HTLCSecretSize).AddOp(OP_EQUALVERIFY).
+		AddOp(OP_SHA256).AddData(secretHash[:]).AddOp(OP_EQUALVERIFY).
+		AddOp(OP_DUP).AddOp(OP_HASH160).AddData(recipientHash[:]).
+		AddOp(OP_ELSE).
+		AddInt64(lockTime).AddOp(OP_CHECKLOCKTIMEVERIFY).AddOp(OP_DROP).
+		AddOp(OP_DUP).AddOp(OP_HASH160).AddData(refundHash[:]).
+		AddOp(OP_ENDIF).
+		AddOp(OP_EQUALVERIFY).AddOp(OP_CHECKSIG).
+		Script()
+}
+
+// IsHTLCScript 返回传入的脚本是否是 BuildHTLCScript 生成的 HTLC 脚本。
+func IsHTLCScript(script []byte) bool {
+	const scriptVersion = 0
+	pushes, err := ExtractAtomicSwapDataPushes(scriptVersion, script)
+	return err == nil && pushes != nil && pushes.SecretSize == HTLCSecretSize
+}
+
+// checkHTLCPreimage 确保原像的长度符合 HTLC 的要求。
+func checkHTLCPreimage(preimage []byte) error {
+	if len(preimage) != HTLCSecretSize {
+		return fmt.Errorf("%w: preimage must be %d bytes, got %d",
+			ErrInvalidHTLC, HTLCSecretSize, len(preimage))
+	}
+	return nil
+}
+
+// RedeemHTLCWitness 返回使用原像花费 P2WSH HTLC 输出的见证。 sig 是附加了 sighash 类型的收款方签名，pubKey 是其序列化公钥。
+func RedeemHTLCWitness(preimage, sig, pubKey,
+	witnessScript []byte) (wire.TxWitness, error) {
+
+	if err := checkHTLCPreimage(preimage); err != nil {
+		return nil, err
+	}
+	return wire.TxWitness{sig, pubKey, preimage, {1}, witnessScript}, nil
+}
+
+// RefundHTLCWitness 返回在锁定时间到期后退款花费 P2WSH HTLC 输出的见证。 sig 是附加了 sighash 类型的退款方签名，pubKey 是其序列化公钥。
+func RefundHTLCWitness(sig, pubKey, witnessScript []byte) wire.TxWitness {
+	return wire.TxWitness{sig, pubKey, nil, witnessScript}
+}
+
+// RedeemHTLCSigScript 返回使用原像花费 P2SH HTLC 输出的签名脚本。
+func RedeemHTLCSigScript(preimage, sig, pubKey,
+	redeemScript []byte) ([]byte, error) {
+
+	if err := checkHTLCPreimage(preimage); err != nil {
+		return nil, err
+	}
+	return NewScriptBuilder().AddData(sig).AddData(pubKey).
+		AddData(preimage).AddInt64(1).AddData(redeemScript).Script()
+}
+
+// RefundHTLCSigScript 返回在锁定时间到期后退款花费 P2SH HTLC 输出的签名脚本。
+func RefundHTLCSigScript(sig, pubKey, redeemScript []byte) ([]byte, error) {
+	return NewScriptBuilder().AddData(sig).AddData(pubKey).
+		AddOp(OP_0).AddData(redeemScript).Script()
+}
+
+// BuildTapscriptHTLCLeaves 返回 HTLC 的两个 tapscript 叶子：
+//
+//	redeem: SIZE 32 EQUALVERIFY SHA256 <secretHash> EQUALVERIFY <recipientKey> CHECKSIG
+//	refund: <lockTime> CHECKLOCKTIMEVERIFY DROP <refundKey> CHECKSIG
+//
+// 两个叶子通常与一个不可花费的内部密钥一起组装成 taproot 输出。
+func BuildTapscriptHTLCLeaves(recipientKey, refundKey *btcec.PublicKey,
+	secretHash [32]byte, lockTime int64) (TapLeaf, TapLeaf, error) {
+
+	if err := checkHTLCLockTime(lockTime); err != nil {
+		return TapLeaf{}, TapLeaf{}, err
+	}
+
+	redeem, err := NewScriptBuilder().
+		AddOp(OP_SIZE).AddInt64(HTLCSecretSize).AddOp(OP_EQUALVERIFY).
+		AddOp(OP_SHA256).AddData(secretHash[:]).AddOp(OP_EQUALVERIFY).
+		AddData(schnorr.SerializePubKey(recipientKey)).AddOp(OP_CHECKSIG).
+		Script()
+	if err != nil {
+		return TapLeaf{}, TapLeaf{}, err
+	}
+
+	refund, err := NewScriptBuilder().
+		AddInt64(lockTime).AddOp(OP_CHECKLOCKTIMEVERIFY).AddOp(OP_DROP).
+		AddData(schnorr.SerializePubKey(refundKey)).AddOp(OP_CHECKSIG).
+		Script()
+	if err != nil {
+		return TapLeaf{}, TapLeaf{}, err
+	}
+
+	return NewBaseTapLeaf(redeem), NewBaseTapLeaf(refund), nil
+}
+
+// ClassifyTapscriptHTLCLeaf 返回传入的 tapscript 叶子脚本属于哪条 HTLC 花费路径。
+func ClassifyTapscriptHTLCLeaf(script []byte) HTLCLeafType {
+	const scriptVersion = 0
+	var ops []byte
+	var datas [][]byte
+	tokenizer := MakeScriptTokenizer(scriptVersion, script)
+	for tokenizer.Next() {
+		ops = append(ops, tokenizer.Opcode())
+		datas = append(datas, tokenizer.Data())
+	}
+	if tokenizer.Err() != nil {
+		return HTLCLeafUnknown
+	}
+
+	redeemOps := []byte{OP_SIZE, OP_DATA_1, OP_EQUALVERIFY, OP_SHA256,
+		OP_DATA_32, OP_EQUALVERIFY, OP_DATA_32, OP_CHECKSIG}
+	if bytes.Equal(ops, redeemOps) &&
+		bytes.Equal(datas[1], []byte{HTLCSecretSize}) {
+
+		return HTLCLeafRedeem
+	}
+
+	if len(ops) == 5 && ops[1] == OP_CHECKLOCKTIMEVERIFY &&
+		ops[2] == OP_DROP && ops[3] == OP_DATA_32 &&
+		ops[4] == OP_CHECKSIG {
+
+		switch {
+		case ops[0] >= OP_1 && ops[0] <= OP_16:
+			return HTLCLeafRefund
+		case datas[0] != nil:
+			lockTime, err := MakeScriptNum(
+				datas[0], true, cltvMaxScriptNumLen,
+			)
+			if err == nil && lockTime > 0 {
+				return HTLCLeafRefund
+			}
+		}
+	}
+
+	return HTLCLeafUnknown
+}
+
+// RedeemTapscriptHTLCWitness 返回通过赎回叶子花费 taproot HTLC 输出的见证。 sig 是收款方的 schnorr 签名，controlBlock 是赎回叶子的序列化控制块。
+func RedeemTapscriptHTLCWitness(preimage, sig, leafScript,
+	controlBlock []byte) (wire.TxWitness, error) {
+
+	if err := checkHTLCPreimage(preimage); err != nil {
+		return nil, err
+	}
+	return wire.TxWitness{sig, preimage, leafScript, controlBlock}, nil
+}
+
+// RefundTapscriptHTLCWitness 返回通过退款叶子花费 taproot HTLC 输出的见证。 sig 是退款方的 schnorr 签名，controlBlock 是退款叶子的序列化控制块。
+func RefundTapscriptHTLCWitness(sig, leafScript,
+	controlBlock []byte) wire.TxWitness {
+
+	return wire.TxWitness{sig, leafScript, controlBlock}
+}
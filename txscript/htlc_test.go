@@ -0,0 +1,191 @@
+package txscript
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/stretchr/testify/require"
+)
+
+// htlcSpendingTx 返回一个花费单个输出的交易，锁定时间和序列号设置为允许 CHECKLOCKTIMEVERIFY 通过。
+func htlcSpendingTx(lockTime uint32) *wire.MsgTx {
+	tx := wire.NewMsgTx(2)
+	tx.LockTime = lockTime
+	tx.AddTxIn(&wire.TxIn{
+		PreviousOutPoint: wire.OutPoint{Index: 0},
+		Sequence:         wire.MaxTxInSequenceNum - 1,
+	})
+	tx.AddTxOut(wire.NewTxOut(1e8-1000, nil))
+	return tx
+}
+
+// TestHTLCScriptP2WSH 确保 P2WSH HTLC 能够通过赎回路径和退款路径花费，并且错误的原像或过早的退款会失败。
+func TestHTLCScriptP2WSH(t *testing.T) {
+	t.Parallel()
+
+	const lockTime = 500
+	recipientKey, err := btcec.NewPrivateKey()
+	require.NoError(t, err)
+	refundKey, err := btcec.NewPrivateKey()
+	require.NoError(t, err)
+
+	preimage := bytes.Repeat([]byte{0x07}, HTLCSecretSize)
+	secretHash := sha256.Sum256(preimage)
+	var recipientHash, refundHash [20]byte
+	copy(recipientHash[:], btcutil.Hash160(
+		recipientKey.PubKey().SerializeCompressed()))
+	copy(refundHash[:], btcutil.Hash160(
+		refundKey.PubKey().SerializeCompressed()))
+
+	script, err := BuildHTLCScript(recipientHash, refundHash, secretHash,
+		lockTime)
+	require.NoError(t, err)
+	require.True(t, IsHTLCScript(script))
+	require.False(t, IsHTLCScript(script[1:]))
+
+	pushes, err := ExtractAtomicSwapDataPushes(0, script)
+	require.NoError(t, err)
+	require.Equal(t, int64(lockTime), pushes.LockTime)
+	require.Equal(t, secretHash, pushes.SecretHash)
+
+	scriptHash := sha256.Sum256(script)
+	pkScript, err := payToWitnessScriptHashScript(scriptHash[:])
+	require.NoError(t, err)
+	const amt = 1e8
+	prevFetcher := NewCannedPrevOutputFetcher(pkScript, amt)
+
+	spend := func(tx *wire.MsgTx, witness wire.TxWitness) error {
+		tx.TxIn[0].Witness = witness
+		vm, err := NewEngine(pkScript, tx, 0, StandardVerifyFlags, nil,
+			NewTxSigHashes(tx, prevFetcher), amt, prevFetcher)
+		if err != nil {
+			return err
+		}
+		return vm.Execute()
+	}
+	signWith := func(tx *wire.MsgTx, key *btcec.PrivateKey) []byte {
+		sig, err := RawTxInWitnessSignature(tx,
+			NewTxSigHashes(tx, prevFetcher), 0, amt, script,
+			SigHashAll, key)
+		require.NoError(t, err)
+		return sig
+	}
+
+	// 赎回路径。
+	tx := htlcSpendingTx(0)
+	witness, err := RedeemHTLCWitness(preimage, signWith(tx, recipientKey),
+		recipientKey.PubKey().SerializeCompressed(), script)
+	require.NoError(t, err)
+	require.NoError(t, spend(tx, witness))
+
+	// 错误的原像。
+	badPreimage := bytes.Repeat([]byte{0x08}, HTLCSecretSize)
+	witness, err = RedeemHTLCWitness(badPreimage,
+		signWith(tx, recipientKey),
+		recipientKey.PubKey().SerializeCompressed(), script)
+	require.NoError(t, err)
+	require.True(t, IsErrorCode(spend(tx, witness), ErrEqualVerify))
+
+	_, err = RedeemHTLCWitness(preimage[1:], nil, nil, script)
+	require.ErrorIs(t, err, ErrInvalidHTLC)
+
+	// 退款路径。
+	tx = htlcSpendingTx(lockTime)
+	witness = RefundHTLCWitness(signWith(tx, refundKey),
+		refundKey.PubKey().SerializeCompressed(), script)
+	require.NoError(t, spend(tx, witness))
+
+	// 锁定时间到期之前退款。
+	tx = htlcSpendingTx(lockTime - 1)
+	witness = RefundHTLCWitness(signWith(tx, refundKey),
+		refundKey.PubKey().SerializeCompressed(), script)
+	require.True(t, IsErrorCode(spend(tx, witness), ErrUnsatisfiedLockTime))
+
+	_, err = BuildHTLCScript(recipientHash, refundHash, secretHash, 0)
+	require.ErrorIs(t, err, ErrInvalidHTLC)
+}
+
+// TestHTLCTapscript 确保 tapscript HTLC 叶子能够被识别，并且两条花费路径都能通过验证。
+func TestHTLCTapscript(t *testing.T) {
+	t.Parallel()
+
+	const lockTime = 10
+	recipientKey, err := btcec.NewPrivateKey()
+	require.NoError(t, err)
+	refundKey, err := btcec.NewPrivateKey()
+	require.NoError(t, err)
+	internalKey, err := btcec.NewPrivateKey()
+	require.NoError(t, err)
+
+	preimage := bytes.Repeat([]byte{0x09}, HTLCSecretSize)
+	secretHash := sha256.Sum256(preimage)
+
+	redeemLeaf, refundLeaf, err := BuildTapscriptHTLCLeaves(
+		recipientKey.PubKey(), refundKey.PubKey(), secretHash, lockTime,
+	)
+	require.NoError(t, err)
+	require.Equal(t, HTLCLeafRedeem, ClassifyTapscriptHTLCLeaf(redeemLeaf.Script))
+	require.Equal(t, HTLCLeafRefund, ClassifyTapscriptHTLCLeaf(refundLeaf.Script))
+	require.Equal(t, HTLCLeafUnknown, ClassifyTapscriptHTLCLeaf([]byte{OP_TRUE}))
+
+	tree := AssembleTaprootScriptTree(redeemLeaf, refundLeaf)
+	rootHash := tree.RootNode.TapHash()
+	outputKey := ComputeTaprootOutputKey(internalKey.PubKey(), rootHash[:])
+	pkScript, err := PayToTaprootScript(outputKey)
+	require.NoError(t, err)
+	const amt = 1e8
+	prevFetcher := NewCannedPrevOutputFetcher(pkScript, amt)
+
+	controlBlockFor := func(leaf TapLeaf) []byte {
+		idx := tree.LeafProofIndex[leaf.TapHash()]
+		ctrl := tree.LeafMerkleProofs[idx].ToControlBlock(
+			internalKey.PubKey(),
+		)
+		ctrlBytes, err := ctrl.ToBytes()
+		require.NoError(t, err)
+		return ctrlBytes
+	}
+	spend := func(tx *wire.MsgTx, leaf TapLeaf, key *btcec.PrivateKey,
+		makeWitness func(sig []byte) wire.TxWitness) error {
+
+		sigHashes := NewTxSigHashes(tx, prevFetcher)
+		sig, err := RawTxInTapscriptSignature(tx, sigHashes, 0, amt,
+			pkScript, leaf, SigHashDefault, key)
+		require.NoError(t, err)
+
+		tx.TxIn[0].Witness = makeWitness(sig)
+		vm, err := NewEngine(pkScript, tx, 0, StandardVerifyFlags, nil,
+			sigHashes, amt, prevFetcher)
+		if err != nil {
+			return err
+		}
+		return vm.Execute()
+	}
+
+	err = spend(htlcSpendingTx(0), redeemLeaf, recipientKey,
+		func(sig []byte) wire.TxWitness {
+			witness, err := RedeemTapscriptHTLCWitness(preimage, sig,
+				redeemLeaf.Script, controlBlockFor(redeemLeaf))
+			require.NoError(t, err)
+			return witness
+		})
+	require.NoError(t, err)
+
+	err = spend(htlcSpendingTx(lockTime), refundLeaf, refundKey,
+		func(sig []byte) wire.TxWitness {
+			return RefundTapscriptHTLCWitness(sig, refundLeaf.Script,
+				controlBlockFor(refundLeaf))
+		})
+	require.NoError(t, err)
+
+	err = spend(htlcSpendingTx(lockTime-1), refundLeaf, refundKey,
+		func(sig []byte) wire.TxWitness {
+			return RefundTapscriptHTLCWitness(sig, refundLeaf.Script,
+				controlBlockFor(refundLeaf))
+		})
+	require.True(t, IsErrorCode(err, ErrUnsatisfiedLockTime))
+}
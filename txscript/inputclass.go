@@ -0,0 +1,119 @@
+// 包含输入花费方式的分类，在不构造脚本引擎的情况下识别 P2SH 嵌套的见证程序，返回实际执行的脚本类别、
+// 见证程序以及嵌套方式是否规范，规则与 NewEngine 相同。
+
+package txscript
+
+import (
+	"github.com/btcsuite/btcd/wire"
+)
+
+// InputClass 描述一个输入如何花费它的公钥脚本。
+type InputClass struct {
+	// PkScriptClass 是被花费的公钥脚本的类别。
+	PkScriptClass ScriptClass
+
+	// Class 是实际执行的最内层脚本的类别：P2SH 花费为兑换脚本的类别，P2WSH 花费为见证脚本的类别，
+	// 其他见证程序为见证程序的类别，其余为 PkScriptClass。 兑换脚本或见证脚本缺失时为 NonStandardTy。
+	Class ScriptClass
+
+	// Nested 表示见证程序嵌套在 P2SH 中，由签名脚本推送。
+	Nested bool
+
+	// WitnessVersion 和 WitnessProgram 是花费的见证程序的版本和程序数据。 不是见证花费时 WitnessVersion 为 -1。
+	WitnessVersion int
+	WitnessProgram []byte
+
+	// RedeemScript 是 P2SH 花费的兑换脚本，嵌套见证程序时为见证程序本身。
+	RedeemScript []byte
+
+	// WitnessScript 是 P2WSH 花费的见证脚本。
+	WitnessScript []byte
+
+	// Canonical 表示签名脚本和见证的形式满足 NewEngine 在启用 P2SH 和见证验证时的构造检查：
+	// 原生见证程序的签名脚本为空，嵌套见证程序的签名脚本恰好是见证程序的一次规范推送，P2SH 的签名脚本只包含数据推送，
+	// 并且不是见证花费的输入没有见证。
+	Canonical bool
+}
+
+// ClassifyInput 对签名脚本为 scriptSig、见证为 witness、花费 pkScript 的输入进行分类，识别原生和嵌套在 P2SH 中的
+// P2WPKH、P2WSH 和 taproot 花费。 内存池可以用它在构造引擎之前过滤输入，不规范的嵌套方式会被 NewEngine 以
+// ErrWitnessMalleated、ErrWitnessMalleatedP2SH、ErrNotPushOnly 或 ErrWitnessUnexpected 拒绝。
+func ClassifyInput(scriptSig, pkScript []byte, witness wire.TxWitness) *InputClass {
+	c := &InputClass{
+		PkScriptClass:  GetScriptClass(pkScript),
+		WitnessVersion: -1,
+		Canonical:      true,
+	}
+
+	program := pkScript
+	switch {
+	case IsWitnessProgram(pkScript):
+		c.Canonical = len(scriptSig) == 0
+
+	case isScriptHashScript(pkScript):
+		if !IsPushOnlyScript(scriptSig) {
+			c.Canonical = false
+			c.Class = NonStandardTy
+			return c
+		}
+		pushes, _ := PushedData(scriptSig)
+		if len(pushes) == 0 {
+			c.Class = NonStandardTy
+			return c
+		}
+		c.RedeemScript = pushes[len(pushes)-1]
+
+		// Only a witness program pushed on its own is treated as a
+		// nested witness spend, anything else is executed as a plain
+		// redeem script.
+		if nested := nestedWitnessProgram(scriptSig); nested != nil {
+			c.Nested = true
+			program = nested
+			break
+		}
+		if IsWitnessProgram(c.RedeemScript) && len(witness) != 0 {
+			c.Nested = true
+			c.Canonical = false
+			program = c.RedeemScript
+			break
+		}
+
+		const scriptVersion = 0
+		c.Class = typeOfScript(scriptVersion, c.RedeemScript)
+		c.Canonical = len(witness) == 0
+		return c
+
+	default:
+		c.Class = c.PkScriptClass
+		c.Canonical = len(witness) == 0
+		return c
+	}
+
+	version, data, err := ExtractWitnessProgramInfo(program)
+	if err != nil {
+		c.Class = NonStandardTy
+		return c
+	}
+	c.WitnessVersion = version
+	c.WitnessProgram = data
+	c.Class = GetScriptClass(program)
+	if c.Class == WitnessV0ScriptHashTy {
+		c.Class = NonStandardTy
+		if len(witness) != 0 {
+			const scriptVersion = 0
+			c.WitnessScript = witness[len(witness)-1]
+			c.Class = typeOfScript(scriptVersion, c.WitnessScript)
+		}
+	}
+	return c
+}
+
+// nestedWitnessProgram 返回签名脚本推送的见证程序。 签名脚本必须恰好是见证程序的一次规范推送，否则返回 nil。
+func nestedWitnessProgram(sigScript []byte) []byte {
+	if len(sigScript) > 2 && isCanonicalPush(sigScript[0], sigScript[1:]) &&
+		IsWitnessProgram(sigScript[1:]) {
+
+		return sigScript[1:]
+	}
+	return nil
+}
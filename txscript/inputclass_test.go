@@ -0,0 +1,127 @@
+package txscript
+
+import (
+	"crypto/sha256"
+	"testing"
+
+	"github.com/btcsuite/btcd/wire"
+	"github.com/stretchr/testify/require"
+)
+
+// TestClassifyInput 确保原生和嵌套的见证花费、P2SH 花费以及不规范的嵌套方式被正确分类。
+func TestClassifyInput(t *testing.T) {
+	t.Parallel()
+
+	pubKey := hexToBytes("02192d74d0cb94344c9569c2e77901573d8d7903c3ebec3a" +
+		"957724895dca52c6b4")
+	sig := make([]byte, 71)
+	p2wpkh, err := payToWitnessPubKeyHashScript(hash160(pubKey))
+	require.NoError(t, err)
+	p2pk, err := payToPubKeyScript(pubKey)
+	require.NoError(t, err)
+	scriptHash := sha256.Sum256(p2pk)
+	p2wsh, err := payToWitnessScriptHashScript(scriptHash[:])
+	require.NoError(t, err)
+	p2sh := func(script []byte) []byte {
+		pkScript, err := payToScriptHashScript(hash160(script))
+		require.NoError(t, err)
+		return pkScript
+	}
+	push := func(data ...[]byte) []byte {
+		builder := NewScriptBuilder()
+		for _, d := range data {
+			builder.AddData(d)
+		}
+		script, err := builder.Script()
+		require.NoError(t, err)
+		return script
+	}
+
+	tests := []struct {
+		name      string
+		sigScript []byte
+		pkScript  []byte
+		witness   wire.TxWitness
+		class     ScriptClass
+		nested    bool
+		version   int
+		canonical bool
+	}{{
+		name:      "p2wpkh",
+		pkScript:  p2wpkh,
+		witness:   wire.TxWitness{sig, pubKey},
+		class:     WitnessV0PubKeyHashTy,
+		canonical: true,
+	}, {
+		name:      "p2wpkh with signature script",
+		sigScript: push(sig),
+		pkScript:  p2wpkh,
+		witness:   wire.TxWitness{sig, pubKey},
+		class:     WitnessV0PubKeyHashTy,
+	}, {
+		name:      "p2sh-p2wpkh",
+		sigScript: push(p2wpkh),
+		pkScript:  p2sh(p2wpkh),
+		witness:   wire.TxWitness{sig, pubKey},
+		class:     WitnessV0PubKeyHashTy,
+		nested:    true,
+		canonical: true,
+	}, {
+		name:      "p2sh-p2wsh",
+		sigScript: push(p2wsh),
+		pkScript:  p2sh(p2wsh),
+		witness:   wire.TxWitness{sig, p2pk},
+		class:     PubKeyTy,
+		nested:    true,
+		canonical: true,
+	}, {
+		name:      "p2sh-p2wpkh with extra push",
+		sigScript: push(sig, p2wpkh),
+		pkScript:  p2sh(p2wpkh),
+		witness:   wire.TxWitness{sig, pubKey},
+		class:     WitnessV0PubKeyHashTy,
+		nested:    true,
+	}, {
+		name:      "p2sh p2pk",
+		sigScript: push(sig, p2pk),
+		pkScript:  p2sh(p2pk),
+		class:     PubKeyTy,
+		version:   -1,
+		canonical: true,
+	}, {
+		name:      "p2sh p2pk with witness",
+		sigScript: push(sig, p2pk),
+		pkScript:  p2sh(p2pk),
+		witness:   wire.TxWitness{sig},
+		class:     PubKeyTy,
+		version:   -1,
+	}, {
+		name:      "p2sh not push only",
+		sigScript: []byte{OP_NOP},
+		pkScript:  p2sh(p2pk),
+		class:     NonStandardTy,
+		version:   -1,
+	}, {
+		name:      "p2pk",
+		sigScript: push(sig),
+		pkScript:  p2pk,
+		class:     PubKeyTy,
+		version:   -1,
+		canonical: true,
+	}}
+
+	for _, test := range tests {
+		c := ClassifyInput(test.sigScript, test.pkScript, test.witness)
+		require.Equal(t, test.class, c.Class, test.name)
+		require.Equal(t, test.nested, c.Nested, test.name)
+		require.Equal(t, test.version, c.WitnessVersion, test.name)
+		require.Equal(t, test.canonical, c.Canonical, test.name)
+	}
+
+	// 嵌套的见证程序和见证脚本被返回。
+	c := ClassifyInput(push(p2wsh), p2sh(p2wsh), wire.TxWitness{sig, p2pk})
+	require.Equal(t, ScriptHashTy, c.PkScriptClass)
+	require.Equal(t, scriptHash[:], c.WitnessProgram)
+	require.Equal(t, p2wsh, c.RedeemScript)
+	require.Equal(t, p2pk, c.WitnessScript)
+}
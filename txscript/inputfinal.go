@@ -0,0 +1,193 @@
+// 包含输入完成度检查，判断输入的签名脚本和见证是否已经完整并能通过脚本引擎验证，不能通过时列出还缺少的签名、脚本和控制块，
+// 供钱包界面在广播之前提示用户。
+
+package txscript
+
+import (
+	"fmt"
+
+	"github.com/btcsuite/btcd/wire"
+)
+
+// InputFinality 是 IsInputFinal 的结果，描述输入是否已经可以广播，以及不能广播时缺少什么。
+type InputFinality struct {
+	// Final 表示输入的签名脚本和见证通过了脚本引擎的验证。
+	Final bool
+
+	// Requirements 是花费被花费输出所需的参数。 缺少兑换脚本、见证脚本或控制块，或者脚本无法被 CalcInputRequirements
+	// 识别时为 nil，此时 Missing 只能报告缺少的脚本或控制块。
+	Requirements *InputRequirements
+
+	// Signatures 是签名脚本和见证中已经提供的非空签名数。
+	Signatures int
+
+	// Missing 是还需要提供的参数，缺少的签名排在最前面，其余按它们在签名脚本和见证中的顺序排列。
+	// 对于 multi_a 叶子，只有已提供的签名数低于阈值时才报告缺少签名。
+	Missing []InputItemKind
+
+	// Err 是脚本引擎验证失败的原因，Final 为 true 时为 nil。 Missing 为空而 Err 不为 nil 表示参数已经齐全但无效，
+	// 例如签名错误或脚本与输出承诺的哈希不匹配。
+	Err error
+}
+
+// MissingSigs 返回还需要提供的签名数。
+func (f *InputFinality) MissingSigs() int {
+	var n int
+	for _, kind := range f.Missing {
+		if kind == InputItemSignature {
+			n++
+		}
+	}
+	return n
+}
+
+// IsMissing 返回是否还需要提供种类为 kind 的参数。
+func (f *InputFinality) IsMissing(kind InputItemKind) bool {
+	for _, missing := range f.Missing {
+		if missing == kind {
+			return true
+		}
+	}
+	return false
+}
+
+// IsInputFinal 检查 tx 的输入 idx 的签名脚本和见证是否完整并能通过验证，该输入花费 prevOut。 验证使用
+// DefaultVerifyFlags 为 prevOut 选择的标志，hashCache 的要求与 NewEngineFromPrevOut 相同：花费 P2TR 输出的多输入交易
+// 必须提供使用所有被花费输出计算的 hashCache。
+//
+// 验证失败时，IsInputFinal 根据 CalcInputRequirements 将已有的参数与所需的参数逐个对应，空的参数视为缺失，
+// 并在结果中列出缺少的签名、脚本和控制块。 P2SH 和 P2WSH 花费的兑换脚本和见证脚本取自最后一个推送或见证元素；
+// 按 BIP0341，P2TR 花费的见证去掉附件后只有一个元素时被视为密钥路径花费，至少有两个元素时最后一个元素必须是控制块。
+//
+// idx 超出范围、prevOut 为 nil 或无法创建脚本引擎时返回错误。
+func IsInputFinal(tx *wire.MsgTx, idx int, prevOut *wire.TxOut,
+	hashCache *TxSigHashes) (*InputFinality, error) {
+
+	if idx < 0 || idx >= len(tx.TxIn) {
+		return nil, fmt.Errorf("input index %d out of range for %d inputs",
+			idx, len(tx.TxIn))
+	}
+	if prevOut == nil {
+		return nil, internalError("previous output is required", nil)
+	}
+
+	f := &InputFinality{}
+	vm, err := NewEngineFromPrevOut(prevOut, tx, idx, 0, nil, hashCache)
+	switch {
+	// Script errors such as a non push only signature script describe the
+	// input, everything else is a problem with the arguments.
+	case err != nil && IsErrorCode(err, ErrInternal):
+		return nil, err
+	case err != nil:
+		f.Err = err
+	default:
+		f.Err = vm.Execute()
+	}
+	f.Final = f.Err == nil
+
+	f.analyze(prevOut.PkScript, tx.TxIn[idx])
+	return f, nil
+}
+
+// analyze 将输入 txIn 已有的参数与花费 pkScript 所需的参数对应，并记录已有的签名数和缺少的参数。
+func (f *InputFinality) analyze(pkScript []byte, txIn *wire.TxIn) {
+	sigItems, err := sigScriptItems(txIn.SignatureScript)
+	if err != nil {
+		return
+	}
+	witness := [][]byte(txIn.Witness)
+
+	var script []byte
+	switch class := GetScriptClass(pkScript); class {
+	case ScriptHashTy:
+		if len(sigItems) == 0 {
+			f.Missing = []InputItemKind{InputItemScript}
+			return
+		}
+		script = sigItems[len(sigItems)-1]
+		if IsPayToWitnessScriptHash(script) {
+			if len(witness) == 0 {
+				f.Missing = []InputItemKind{InputItemScript}
+				return
+			}
+			script = witness[len(witness)-1]
+		}
+
+	case WitnessV0ScriptHashTy:
+		if len(witness) == 0 {
+			f.Missing = []InputItemKind{InputItemScript}
+			return
+		}
+		script = witness[len(witness)-1]
+
+	case WitnessV1TaprootTy:
+		if isAnnexedWitness(witness) {
+			witness = witness[:len(witness)-1]
+		}
+		if len(witness) < 2 {
+			break
+		}
+		if _, err := ParseControlBlock(witness[len(witness)-1]); err != nil {
+			f.Missing = []InputItemKind{InputItemControlBlock}
+			return
+		}
+		script = witness[len(witness)-2]
+	}
+
+	reqs, err := CalcInputRequirements(pkScript, script)
+	if err != nil {
+		return
+	}
+	f.Requirements = reqs
+
+	var missing []InputItemKind
+	missing = f.matchItems(reqs.SigScript, sigItems, missing)
+	missing = f.matchItems(reqs.Witness, witness, missing)
+	for i := f.Signatures; i < reqs.RequiredSigs; i++ {
+		f.Missing = append(f.Missing, InputItemSignature)
+	}
+	f.Missing = append(f.Missing, missing...)
+}
+
+// matchItems 将所需的参数 want 与已有的参数 have 按位置对应，累计已有的签名数，并将缺少的其他参数追加到 missing。
+func (f *InputFinality) matchItems(want []InputItemKind, have [][]byte,
+	missing []InputItemKind) []InputItemKind {
+
+	for i, kind := range want {
+		// The multisig dummy must be present but empty.
+		present := i < len(have) &&
+			(len(have[i]) != 0 || kind == InputItemDummy)
+
+		switch {
+		case kind == InputItemSignature:
+			if present {
+				f.Signatures++
+			}
+		case !present:
+			missing = append(missing, kind)
+		}
+	}
+	return missing
+}
+
+// sigScriptItems 返回只包含推送操作码的签名脚本推送到栈上的元素，OP_0 推送空元素。
+func sigScriptItems(sigScript []byte) ([][]byte, error) {
+	var items [][]byte
+
+	const scriptVersion = 0
+	tokenizer := MakeScriptTokenizer(scriptVersion, sigScript)
+	for tokenizer.Next() {
+		switch op := tokenizer.Opcode(); {
+		case op <= OP_PUSHDATA4:
+			items = append(items, tokenizer.Data())
+		case op == OP_1NEGATE:
+			items = append(items, ScriptNum(-1).Bytes())
+		case op >= OP_1 && op <= OP_16:
+			items = append(items, ScriptNum(AsSmallInt(op)).Bytes())
+		default:
+			return nil, scriptError(ErrNotPushOnly,
+				"signature script is not push only")
+		}
+	}
+	return items, tokenizer.Err()
+}
@@ -0,0 +1,186 @@
+package txscript
+
+import (
+	"crypto/sha256"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcec/v2/schnorr"
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/stretchr/testify/require"
+)
+
+// TestIsInputFinalWitnessPubKeyHash 测试 P2WPKH 输入在未签名、已签名和签名无效时的完成度报告。
+func TestIsInputFinalWitnessPubKeyHash(t *testing.T) {
+	t.Parallel()
+
+	privKey, err := btcec.NewPrivateKey()
+	require.NoError(t, err)
+	pubKey := privKey.PubKey().SerializeCompressed()
+	pkScript, err := payToWitnessPubKeyHashScript(hash160(pubKey))
+	require.NoError(t, err)
+	prevOut := wire.NewTxOut(1e5, pkScript)
+
+	tx := wire.NewMsgTx(2)
+	tx.AddTxIn(&wire.TxIn{})
+	tx.AddTxOut(wire.NewTxOut(1e4, pkScript))
+
+	f, err := IsInputFinal(tx, 0, prevOut, nil)
+	require.NoError(t, err)
+	require.False(t, f.Final)
+	require.Error(t, f.Err)
+	require.Equal(t, []InputItemKind{
+		InputItemSignature, InputItemPubKey,
+	}, f.Missing)
+	require.Equal(t, 1, f.MissingSigs())
+
+	sigHashes := NewTxSigHashes(
+		tx, NewCannedPrevOutputFetcher(pkScript, prevOut.Value),
+	)
+	tx.TxIn[0].Witness, err = WitnessSignature(
+		tx, sigHashes, 0, prevOut.Value, pkScript, SigHashAll, privKey, true,
+	)
+	require.NoError(t, err)
+
+	// 只有签名时还缺少公钥。
+	witness := tx.TxIn[0].Witness
+	tx.TxIn[0].Witness = witness[:1]
+	f, err = IsInputFinal(tx, 0, prevOut, nil)
+	require.NoError(t, err)
+	require.Equal(t, []InputItemKind{InputItemPubKey}, f.Missing)
+	require.Equal(t, 1, f.Signatures)
+
+	tx.TxIn[0].Witness = witness
+	f, err = IsInputFinal(tx, 0, prevOut, nil)
+	require.NoError(t, err)
+	require.True(t, f.Final)
+	require.NoError(t, f.Err)
+	require.Empty(t, f.Missing)
+
+	// 参数齐全但签名无效时不缺少任何参数，Err 说明失败原因。
+	witness[0][10] ^= 0x01
+	f, err = IsInputFinal(tx, 0, prevOut, nil)
+	require.NoError(t, err)
+	require.False(t, f.Final)
+	require.Error(t, f.Err)
+	require.Empty(t, f.Missing)
+
+	_, err = IsInputFinal(tx, 1, prevOut, nil)
+	require.Error(t, err)
+	_, err = IsInputFinal(tx, 0, nil, nil)
+	require.Error(t, err)
+}
+
+// TestIsInputFinalMultiSig 测试部分签名的 P2WSH 和 P2SH 多重签名输入报告缺少的签名数和脚本。
+func TestIsInputFinalMultiSig(t *testing.T) {
+	t.Parallel()
+
+	var privKeys []*btcec.PrivateKey
+	var addrKeys []*btcutil.AddressPubKey
+	for i := 0; i < 3; i++ {
+		privKey, err := btcec.NewPrivateKey()
+		require.NoError(t, err)
+		privKeys = append(privKeys, privKey)
+		addrKey, err := btcutil.NewAddressPubKey(
+			privKey.PubKey().SerializeCompressed(), &chaincfg.MainNetParams,
+		)
+		require.NoError(t, err)
+		addrKeys = append(addrKeys, addrKey)
+	}
+	multiSig, err := MultiSigScript(addrKeys, 2)
+	require.NoError(t, err)
+	scriptHash := sha256.Sum256(multiSig)
+	p2wsh, err := payToWitnessScriptHashScript(scriptHash[:])
+	require.NoError(t, err)
+	prevOut := wire.NewTxOut(1e5, p2wsh)
+
+	tx := wire.NewMsgTx(2)
+	tx.AddTxIn(&wire.TxIn{})
+	tx.AddTxOut(wire.NewTxOut(1e4, p2wsh))
+
+	// 没有见证脚本时无法确定其他参数。
+	f, err := IsInputFinal(tx, 0, prevOut, nil)
+	require.NoError(t, err)
+	require.Nil(t, f.Requirements)
+	require.Equal(t, []InputItemKind{InputItemScript}, f.Missing)
+
+	sigHashes := NewTxSigHashes(
+		tx, NewCannedPrevOutputFetcher(p2wsh, prevOut.Value),
+	)
+	sig := func(i int) []byte {
+		sig, err := RawTxInWitnessSignature(
+			tx, sigHashes, 0, prevOut.Value, multiSig, SigHashAll,
+			privKeys[i],
+		)
+		require.NoError(t, err)
+		return sig
+	}
+
+	// 一个签名和一个空的占位元素。
+	tx.TxIn[0].Witness = wire.TxWitness{nil, sig(0), nil, multiSig}
+	f, err = IsInputFinal(tx, 0, prevOut, nil)
+	require.NoError(t, err)
+	require.False(t, f.Final)
+	require.NotNil(t, f.Requirements)
+	require.Equal(t, 1, f.Signatures)
+	require.Equal(t, []InputItemKind{InputItemSignature}, f.Missing)
+
+	tx.TxIn[0].Witness = wire.TxWitness{nil, sig(0), sig(2), multiSig}
+	f, err = IsInputFinal(tx, 0, prevOut, nil)
+	require.NoError(t, err)
+	require.True(t, f.Final, "%v", f.Err)
+	require.Equal(t, 2, f.Signatures)
+	require.Empty(t, f.Missing)
+
+	// P2SH 多重签名的兑换脚本缺失。
+	p2sh, err := payToScriptHashScript(hash160(multiSig))
+	require.NoError(t, err)
+	tx.TxIn[0].Witness = nil
+	f, err = IsInputFinal(tx, 0, wire.NewTxOut(1e5, p2sh), nil)
+	require.NoError(t, err)
+	require.True(t, f.IsMissing(InputItemScript))
+}
+
+// TestIsInputFinalTaproot 测试 taproot 输入在缺少密钥路径签名和控制块时的报告。
+func TestIsInputFinalTaproot(t *testing.T) {
+	t.Parallel()
+
+	privKey, err := btcec.NewPrivateKey()
+	require.NoError(t, err)
+	outputKey := ComputeTaprootKeyNoScript(privKey.PubKey())
+	pkScript, err := payToWitnessTaprootScript(
+		schnorr.SerializePubKey(outputKey),
+	)
+	require.NoError(t, err)
+	prevOut := wire.NewTxOut(1e5, pkScript)
+
+	tx := wire.NewMsgTx(2)
+	tx.AddTxIn(&wire.TxIn{})
+	tx.AddTxOut(wire.NewTxOut(1e4, pkScript))
+
+	f, err := IsInputFinal(tx, 0, prevOut, nil)
+	require.NoError(t, err)
+	require.False(t, f.Final)
+	require.Equal(t, []InputItemKind{InputItemSignature}, f.Missing)
+
+	// 最后一个元素不是控制块的脚本路径花费。
+	tx.TxIn[0].Witness = wire.TxWitness{{OP_TRUE}, {0x01, 0x02}}
+	f, err = IsInputFinal(tx, 0, prevOut, nil)
+	require.NoError(t, err)
+	require.False(t, f.Final)
+	require.Equal(t, []InputItemKind{InputItemControlBlock}, f.Missing)
+
+	sigHashes := NewTxSigHashes(
+		tx, NewCannedPrevOutputFetcher(pkScript, prevOut.Value),
+	)
+	tx.TxIn[0].Witness, err = TaprootWitnessSignature(
+		tx, sigHashes, 0, prevOut.Value, pkScript, SigHashDefault, privKey,
+	)
+	require.NoError(t, err)
+	f, err = IsInputFinal(tx, 0, prevOut, nil)
+	require.NoError(t, err)
+	require.True(t, f.Final, "%v", f.Err)
+	require.Equal(t, 1, f.Signatures)
+}
@@ -0,0 +1,312 @@
+// 包含花费脚本所需输入参数的分析，返回签名脚本和见证中每个参数的数量和种类，支持传统脚本、P2SH、见证版本 0 和 taproot 脚本路径。
+
+package txscript
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+)
+
+// ErrScriptHashMismatch 在传入的兑换脚本或见证脚本与公钥脚本承诺的哈希不匹配时返回。
+var ErrScriptHashMismatch = errors.New("script does not match script hash")
+
+// InputItemKind 表示花费输出时必须提供的参数的种类。
+type InputItemKind uint8
+
+const (
+	// InputItemSignature 是签名。 对于 multi_a 叶子，未签名的公钥对应的签名项为空。
+	InputItemSignature InputItemKind = iota
+
+	// InputItemPubKey 是公钥，例如 P2PKH 和 P2WPKH 花费中出示的公钥。
+	InputItemPubKey
+
+	// InputItemPreimage 是哈希锁要求的原像。
+	InputItemPreimage
+
+	// InputItemScript 是兑换脚本、见证脚本或 tapscript 叶子脚本本身。
+	InputItemScript
+
+	// InputItemControlBlock 是 taproot 脚本路径花费的控制块。
+	InputItemControlBlock
+
+	// InputItemDummy 是 OP_CHECKMULTISIG 额外弹出的虚拟元素，必须为空。
+	InputItemDummy
+
+	// InputItemData 是含义由已注册的自定义脚本类别定义的参数。
+	InputItemData
+)
+
+// String 返回参数种类的可读名称。
+func (k InputItemKind) String() string {
+	switch k {
+	case InputItemSignature:
+		return "signature"
+	case InputItemPubKey:
+		return "pubkey"
+	case InputItemPreimage:
+		return "preimage"
+	case InputItemScript:
+		return "script"
+	case InputItemControlBlock:
+		return "controlblock"
+	case InputItemDummy:
+		return "dummy"
+	case InputItemData:
+		return "data"
+	default:
+		return "unknown"
+	}
+}
+
+// InputRequirements 描述花费一个输出时签名脚本和见证必须提供的参数。
+type InputRequirements struct {
+	// PkScriptClass 是被花费的公钥脚本的类别。
+	PkScriptClass ScriptClass
+
+	// SigScript 是签名脚本必须推送的参数，按推送顺序排列。
+	SigScript []InputItemKind
+
+	// Witness 是见证必须包含的参数，按见证中的顺序排列。
+	Witness []InputItemKind
+
+	// RequiredSigs 是必须提供的有效签名数。 对于 multi_a 叶子，它小于见证中签名项的数量。
+	RequiredSigs int
+}
+
+// NumItems 返回签名脚本和见证中的参数总数。
+func (r *InputRequirements) NumItems() int {
+	return len(r.SigScript) + len(r.Witness)
+}
+
+// CalcInputRequirements 返回花费 pkScript 时必须提供的参数的数量和种类。 与 CalcScriptInfo 的 ExpectedInputs 不同，
+// 它区分签名、公钥、原像和脚本本身，并且区分签名脚本和见证。
+//
+// script 是兑换或花费路径脚本：P2SH 的兑换脚本，P2WSH 的见证脚本，或 taproot 脚本路径花费的叶子脚本。
+// 对于嵌套在 P2SH 中的 P2WSH，script 是见证脚本，见证程序由其推导。 P2TR 的 script 为 nil 时表示密钥路径花费，
+// 其他不需要脚本的类别忽略 script。
+//
+// 脚本与 pkScript 承诺的哈希不匹配时返回 ErrScriptHashMismatch，无法确定所需参数时返回 ErrUnsupportedScriptType。
+// 识别的内层脚本包括标准 P2PK、P2PKH 和多重签名脚本，BuildHashLockScript 和 BuildHashLockKeyScript 生成的哈希锁脚本，
+// 单密钥和 multi_a tapscript 叶子，以及以 <locktime> CHECKLOCKTIMEVERIFY 或 CHECKSEQUENCEVERIFY DROP 开头的上述脚本。
+func CalcInputRequirements(pkScript, script []byte) (*InputRequirements, error) {
+	reqs := &InputRequirements{PkScriptClass: GetScriptClass(pkScript)}
+
+	switch reqs.PkScriptClass {
+	case WitnessV0PubKeyHashTy:
+		reqs.Witness = []InputItemKind{InputItemSignature, InputItemPubKey}
+		reqs.RequiredSigs = 1
+
+	case WitnessV0ScriptHashTy:
+		hash := sha256.Sum256(script)
+		if !bytes.Equal(hash[:], extractWitnessV0ScriptHash(pkScript)) {
+			return nil, fmt.Errorf("%w: witness program %x",
+				ErrScriptHashMismatch, extractWitnessV0ScriptHash(pkScript))
+		}
+		items, sigs, err := subScriptInputs(script)
+		if err != nil {
+			return nil, err
+		}
+		reqs.Witness = append(items, InputItemScript)
+		reqs.RequiredSigs = sigs
+
+	case WitnessV1TaprootTy:
+		if script == nil {
+			reqs.Witness = []InputItemKind{InputItemSignature}
+			reqs.RequiredSigs = 1
+			break
+		}
+		items, sigs, err := tapscriptLeafInputs(script)
+		if err != nil {
+			return nil, err
+		}
+		reqs.Witness = append(items, InputItemScript,
+			InputItemControlBlock)
+		reqs.RequiredSigs = sigs
+
+	case ScriptHashTy:
+		scriptHash := extractScriptHash(pkScript)
+		if bytes.Equal(hash160(script), scriptHash) {
+			return nestedScriptInputs(reqs, script)
+		}
+
+		// The script may instead be the witness script of a P2WSH
+		// program nested in the P2SH output.
+		hash := sha256.Sum256(script)
+		program, err := payToWitnessScriptHashScript(hash[:])
+		if err != nil {
+			return nil, err
+		}
+		if !bytes.Equal(hash160(program), scriptHash) {
+			return nil, fmt.Errorf("%w: script hash %x", ErrScriptHashMismatch,
+				scriptHash)
+		}
+		items, sigs, err := subScriptInputs(script)
+		if err != nil {
+			return nil, err
+		}
+		reqs.SigScript = []InputItemKind{InputItemScript}
+		reqs.Witness = append(items, InputItemScript)
+		reqs.RequiredSigs = sigs
+
+	default:
+		items, sigs, err := legacyScriptInputs(pkScript, reqs.PkScriptClass)
+		if err != nil {
+			return nil, err
+		}
+		reqs.SigScript = items
+		reqs.RequiredSigs = sigs
+	}
+
+	return reqs, nil
+}
+
+// nestedScriptInputs 填充通过兑换脚本 redeemScript 花费 P2SH 输出所需的参数，兑换脚本可以是 P2WPKH 见证程序。
+func nestedScriptInputs(reqs *InputRequirements,
+	redeemScript []byte) (*InputRequirements, error) {
+
+	const scriptVersion = 0
+	switch class := typeOfScript(scriptVersion, redeemScript); class {
+	case WitnessV0PubKeyHashTy:
+		reqs.SigScript = []InputItemKind{InputItemScript}
+		reqs.Witness = []InputItemKind{InputItemSignature, InputItemPubKey}
+		reqs.RequiredSigs = 1
+
+	case WitnessV0ScriptHashTy:
+		return nil, fmt.Errorf("%w: the witness script of a nested P2WSH "+
+			"output is required", ErrUnsupportedScriptType)
+
+	case ScriptHashTy, WitnessV1TaprootTy, WitnessUnknownTy:
+		return nil, fmt.Errorf("%w: %v redeem script", ErrUnsupportedScriptType,
+			class)
+
+	default:
+		items, sigs, err := subScriptInputs(redeemScript)
+		if err != nil {
+			return nil, err
+		}
+		reqs.SigScript = append(items, InputItemScript)
+		reqs.RequiredSigs = sigs
+	}
+
+	return reqs, nil
+}
+
+// legacyScriptInputs 返回花费指定类别的版本 0 脚本所需的参数及签名数。
+func legacyScriptInputs(script []byte,
+	class ScriptClass) ([]InputItemKind, int, error) {
+
+	switch class {
+	case PubKeyTy:
+		return []InputItemKind{InputItemSignature}, 1, nil
+
+	case PubKeyHashTy:
+		return []InputItemKind{InputItemSignature, InputItemPubKey}, 1, nil
+
+	case MultiSigTy:
+		// OP_CHECKMULTISIG pops an additional item from the stack due to
+		// the original bitcoind bug, so a dummy element comes first.
+		numSigs := AsSmallInt(script[0])
+		items := make([]InputItemKind, 0, numSigs+1)
+		items = append(items, InputItemDummy)
+		for i := 0; i < numSigs; i++ {
+			items = append(items, InputItemSignature)
+		}
+		return items, numSigs, nil
+	}
+
+	// Custom templates only report the number of items, not their kind.
+	if custom := lookupCustomScriptClass(class); custom != nil &&
+		custom.ExpectedInputs != nil {
+
+		if n := custom.ExpectedInputs(script); n >= 0 {
+			items := make([]InputItemKind, n)
+			for i := range items {
+				items[i] = InputItemData
+			}
+			return items, 0, nil
+		}
+	}
+
+	return nil, 0, fmt.Errorf("%w: %v", ErrUnsupportedScriptType, class)
+}
+
+// subScriptInputs 返回执行兑换脚本或 P2WSH 见证脚本所需的参数及签名数，不包括脚本本身。
+func subScriptInputs(script []byte) ([]InputItemKind, int, error) {
+	script = stripTimeLockPrefix(script)
+
+	const scriptVersion = 0
+	switch class := typeOfScript(scriptVersion, script); class {
+	case PubKeyTy, PubKeyHashTy, MultiSigTy:
+		return legacyScriptInputs(script, class)
+	}
+
+	if lock, err := ParseHashLockScript(script); err == nil {
+		return hashLockInputs(lock)
+	}
+
+	return nil, 0, fmt.Errorf("%w: unrecognized script",
+		ErrUnsupportedScriptType)
+}
+
+// tapscriptLeafInputs 返回执行 tapscript 叶子所需的参数及签名数，不包括叶子脚本和控制块。
+func tapscriptLeafInputs(script []byte) ([]InputItemKind, int, error) {
+	script = stripTimeLockPrefix(script)
+
+	// A single key leaf: <x-only key> CHECKSIG.
+	if len(script) == 34 && script[0] == OP_DATA_32 &&
+		script[33] == OP_CHECKSIG {
+
+		return []InputItemKind{InputItemSignature}, 1, nil
+	}
+
+	// Every key of a multi_a leaf consumes a signature item, which is
+	// empty for the keys that don't sign.
+	if d, err := ParseMultiALeaf(script); err == nil {
+		items := make([]InputItemKind, len(d.PubKeys))
+		for i := range items {
+			items[i] = InputItemSignature
+		}
+		return items, d.Threshold, nil
+	}
+
+	if lock, err := ParseHashLockScript(script); err == nil {
+		return hashLockInputs(lock)
+	}
+
+	return nil, 0, fmt.Errorf("%w: unrecognized tapscript leaf",
+		ErrUnsupportedScriptType)
+}
+
+// hashLockInputs 返回花费哈希锁脚本所需的参数及签名数。
+func hashLockInputs(lock *HashLockScript) ([]InputItemKind, int, error) {
+	if lock.PubKey == nil {
+		return []InputItemKind{InputItemPreimage}, 0, nil
+	}
+	return []InputItemKind{InputItemSignature, InputItemPreimage}, 1, nil
+}
+
+// stripTimeLockPrefix 去掉脚本开头的 <locktime> CHECKLOCKTIMEVERIFY DROP 或 <sequence> CHECKSEQUENCEVERIFY DROP，
+// 这些前缀只约束花费交易，不消耗任何参数。
+func stripTimeLockPrefix(script []byte) []byte {
+	const scriptVersion = 0
+	tokenizer := MakeScriptTokenizer(scriptVersion, script)
+	if !tokenizer.Next() {
+		return script
+	}
+	if op := tokenizer.Opcode(); !(op >= OP_1 && op <= OP_16) &&
+		(op > OP_PUSHDATA4 || len(tokenizer.Data()) == 0) {
+
+		return script
+	}
+	if !tokenizer.Next() || (tokenizer.Opcode() != OP_CHECKLOCKTIMEVERIFY &&
+		tokenizer.Opcode() != OP_CHECKSEQUENCEVERIFY) {
+
+		return script
+	}
+	if !tokenizer.Next() || tokenizer.Opcode() != OP_DROP {
+		return script
+	}
+	return script[tokenizer.ByteIndex():]
+}
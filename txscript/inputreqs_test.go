@@ -0,0 +1,189 @@
+package txscript
+
+import (
+	"crypto/sha256"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcec/v2/schnorr"
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/stretchr/testify/require"
+)
+
+// TestCalcInputRequirements 确保传统、P2SH、见证版本 0 和 taproot 输出所需的参数被正确识别，包括嵌套的见证程序和脚本路径叶子。
+func TestCalcInputRequirements(t *testing.T) {
+	t.Parallel()
+
+	var keys []*btcec.PublicKey
+	var addrKeys []*btcutil.AddressPubKey
+	for i := 0; i < 3; i++ {
+		priv, err := btcec.NewPrivateKey()
+		require.NoError(t, err)
+		keys = append(keys, priv.PubKey())
+		addrKey, err := btcutil.NewAddressPubKey(
+			priv.PubKey().SerializeCompressed(), &chaincfg.MainNetParams,
+		)
+		require.NoError(t, err)
+		addrKeys = append(addrKeys, addrKey)
+	}
+	pubKey := keys[0].SerializeCompressed()
+
+	multiSig, err := MultiSigScript(addrKeys, 2)
+	require.NoError(t, err)
+	secretHash := sha256.Sum256([]byte("secret"))
+	hashLock, err := BuildHashLockKeyScript(
+		HashLock{Type: HashLockSHA256, Hash: secretHash[:]}, 32, pubKey,
+	)
+	require.NoError(t, err)
+	csvHashLock, err := NewScriptBuilder().AddInt64(144).
+		AddOp(OP_CHECKSEQUENCEVERIFY).AddOp(OP_DROP).
+		AddOps(hashLock).Script()
+	require.NoError(t, err)
+
+	p2pkh, err := payToPubKeyHashScript(hash160(pubKey))
+	require.NoError(t, err)
+	p2wpkh, err := payToWitnessPubKeyHashScript(hash160(pubKey))
+	require.NoError(t, err)
+	p2sh := func(script []byte) []byte {
+		pkScript, err := payToScriptHashScript(hash160(script))
+		require.NoError(t, err)
+		return pkScript
+	}
+	p2wsh := func(script []byte) []byte {
+		hash := sha256.Sum256(script)
+		pkScript, err := payToWitnessScriptHashScript(hash[:])
+		require.NoError(t, err)
+		return pkScript
+	}
+	p2tr, err := payToWitnessTaprootScript(schnorr.SerializePubKey(keys[0]))
+	require.NoError(t, err)
+
+	multiA, err := NewMultiADescriptor(2, keys, false)
+	require.NoError(t, err)
+	multiALeaf, err := multiA.Script()
+	require.NoError(t, err)
+	redeemLeaf, refundLeaf, err := BuildTapscriptHTLCLeaves(keys[0], keys[1],
+		secretHash, 600000)
+	require.NoError(t, err)
+
+	const (
+		sig      = InputItemSignature
+		key      = InputItemPubKey
+		preimage = InputItemPreimage
+		script   = InputItemScript
+		control  = InputItemControlBlock
+		dummy    = InputItemDummy
+	)
+	tests := []struct {
+		name      string
+		pkScript  []byte
+		script    []byte
+		class     ScriptClass
+		sigScript []InputItemKind
+		witness   []InputItemKind
+		sigs      int
+	}{{
+		name:      "p2pkh",
+		pkScript:  p2pkh,
+		class:     PubKeyHashTy,
+		sigScript: []InputItemKind{sig, key},
+		sigs:      1,
+	}, {
+		name:      "bare multisig",
+		pkScript:  multiSig,
+		class:     MultiSigTy,
+		sigScript: []InputItemKind{dummy, sig, sig},
+		sigs:      2,
+	}, {
+		name:     "p2wpkh",
+		pkScript: p2wpkh,
+		class:    WitnessV0PubKeyHashTy,
+		witness:  []InputItemKind{sig, key},
+		sigs:     1,
+	}, {
+		name:      "p2sh multisig",
+		pkScript:  p2sh(multiSig),
+		script:    multiSig,
+		class:     ScriptHashTy,
+		sigScript: []InputItemKind{dummy, sig, sig, script},
+		sigs:      2,
+	}, {
+		name:      "p2sh-p2wpkh",
+		pkScript:  p2sh(p2wpkh),
+		script:    p2wpkh,
+		class:     ScriptHashTy,
+		sigScript: []InputItemKind{script},
+		witness:   []InputItemKind{sig, key},
+		sigs:      1,
+	}, {
+		name:     "p2wsh hash lock with relative timelock",
+		pkScript: p2wsh(csvHashLock),
+		script:   csvHashLock,
+		class:    WitnessV0ScriptHashTy,
+		witness:  []InputItemKind{sig, preimage, script},
+		sigs:     1,
+	}, {
+		name:      "p2sh-p2wsh multisig",
+		pkScript:  p2sh(p2wsh(multiSig)),
+		script:    multiSig,
+		class:     ScriptHashTy,
+		sigScript: []InputItemKind{script},
+		witness:   []InputItemKind{dummy, sig, sig, script},
+		sigs:      2,
+	}, {
+		name:     "p2tr key path",
+		pkScript: p2tr,
+		class:    WitnessV1TaprootTy,
+		witness:  []InputItemKind{sig},
+		sigs:     1,
+	}, {
+		name:     "p2tr multi_a leaf",
+		pkScript: p2tr,
+		script:   multiALeaf,
+		class:    WitnessV1TaprootTy,
+		witness:  []InputItemKind{sig, sig, sig, script, control},
+		sigs:     2,
+	}, {
+		name:     "p2tr htlc redeem leaf",
+		pkScript: p2tr,
+		script:   redeemLeaf.Script,
+		class:    WitnessV1TaprootTy,
+		witness:  []InputItemKind{sig, preimage, script, control},
+		sigs:     1,
+	}, {
+		name:     "p2tr htlc refund leaf",
+		pkScript: p2tr,
+		script:   refundLeaf.Script,
+		class:    WitnessV1TaprootTy,
+		witness:  []InputItemKind{sig, script, control},
+		sigs:     1,
+	}}
+
+	for _, test := range tests {
+		reqs, err := CalcInputRequirements(test.pkScript, test.script)
+		require.NoError(t, err, test.name)
+		require.Equal(t, test.class, reqs.PkScriptClass, test.name)
+		require.Equal(t, test.sigScript, reqs.SigScript, test.name)
+		require.Equal(t, test.witness, reqs.Witness, test.name)
+		require.Equal(t, test.sigs, reqs.RequiredSigs, test.name)
+		require.Equal(t, len(test.sigScript)+len(test.witness),
+			reqs.NumItems(), test.name)
+	}
+
+	// 脚本与输出承诺的哈希不匹配。
+	_, err = CalcInputRequirements(p2wsh(multiSig), hashLock)
+	require.ErrorIs(t, err, ErrScriptHashMismatch)
+	_, err = CalcInputRequirements(p2sh(multiSig), hashLock)
+	require.ErrorIs(t, err, ErrScriptHashMismatch)
+
+	// 所需参数取决于执行路径的脚本无法分析。
+	htlc, err := BuildHTLCScript([20]byte{1}, [20]byte{2}, secretHash, 600000)
+	require.NoError(t, err)
+	_, err = CalcInputRequirements(p2wsh(htlc), htlc)
+	require.ErrorIs(t, err, ErrUnsupportedScriptType)
+	_, err = CalcInputRequirements(p2sh(p2wsh(multiSig)), p2wsh(multiSig))
+	require.ErrorIs(t, err, ErrUnsupportedScriptType)
+	_, err = CalcInputRequirements([]byte{OP_RETURN}, nil)
+	require.ErrorIs(t, err, ErrUnsupportedScriptType)
+}
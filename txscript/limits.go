@@ -0,0 +1,153 @@
+// 包含可配置的共识限制，使私有链能够在不修改引擎代码的情况下调整脚本大小、堆栈深度等限制。
+
+package txscript
+
+import (
+	"fmt"
+	"sync/atomic"
+)
+
+// ChainLimits 包含脚本引擎和标准脚本识别所使用的各项限制。 bpfschain 自行管理共识，因此这些限制可以与比特币不同，例如允许更大的数据推送以便在链上存放元数据。
+type ChainLimits struct {
+	// MaxScriptSize 是原始脚本允许的最大长度。
+	MaxScriptSize int
+
+	// MaxScriptElementSize 是可推入堆栈的最大字节数。
+	MaxScriptElementSize int
+
+	// MaxOpsPerScript 是每个脚本允许的最大非推送操作数。
+	MaxOpsPerScript int
+
+	// MaxPubKeysPerMultiSig 是多重签名中允许的最大公钥数。 签名操作计数把每个多重签名操作码按常量 MaxPubKeysPerMultiSig
+	// 计算，因此它不能超过该常量，否则执行的签名检查会多于计数的签名操作。
+	MaxPubKeysPerMultiSig int
+
+	// MaxStackSize 是执行期间堆栈和替代堆栈的最大组合高度。
+	MaxStackSize int
+
+	// MaxDataCarrierSize 是标准空数据脚本中允许推送的最大字节数。
+	MaxDataCarrierSize int
+
+	// MaxWitnessStackItems 是见证版本 0 和 tapscript 花费开始执行时初始堆栈的最大元素数，不包括见证脚本、控制块和附件。
+	// 它不能超过 MaxStackSize，否则执行第一个操作码时堆栈就会溢出。
+	MaxWitnessStackItems int
+
+	// MaxWitnessElementSize 是见证版本 0 和 tapscript 花费的初始堆栈中每个元素的最大字节数。 它可以大于
+	// MaxScriptElementSize，使见证能够携带比脚本推送更大的数据。
+	MaxWitnessElementSize int
+
+	// MaxExtensionOpExecutions 是每个脚本中同一个扩展操作码允许执行的最大次数，0 表示不限制。 扩展操作码是由注册的脚本版本
+	// 重新定义的操作码，以及设置 ScriptVerifyCheckSigFromStack 时 tapscript 中的 OP_CHECKSIGFROMSTACK 和
+	// OP_CHECKSIGFROMSTACKVERIFY。
+	MaxExtensionOpExecutions int
+
+	// MaxExtensionOutputBytes 是每个脚本中扩展操作码推入数据堆栈的总字节数上限，0 表示不限制。 例如它限制了反复执行
+	// OP_CAT 拼接能够产生的数据总量，而 MaxScriptElementSize 只限制单个元素。
+	MaxExtensionOutputBytes int
+}
+
+// BitcoinChainLimits 返回与比特币共识一致的限制，即本包中各个同名常量的值。 见证的限制与比特币一样分别等于 MaxStackSize
+// 和 MaxScriptElementSize。
+func BitcoinChainLimits() ChainLimits {
+	return ChainLimits{
+		MaxScriptSize:         MaxScriptSize,
+		MaxScriptElementSize:  MaxScriptElementSize,
+		MaxOpsPerScript:       MaxOpsPerScript,
+		MaxPubKeysPerMultiSig: MaxPubKeysPerMultiSig,
+		MaxStackSize:          MaxStackSize,
+		MaxDataCarrierSize:    MaxDataCarrierSize,
+		MaxWitnessStackItems:  MaxStackSize,
+		MaxWitnessElementSize: MaxScriptElementSize,
+	}
+}
+
+// Validate 如果限制之间不一致或包含非正值，则返回错误。
+func (l *ChainLimits) Validate() error {
+	fields := []struct {
+		name  string
+		value int
+	}{
+		{"MaxScriptSize", l.MaxScriptSize},
+		{"MaxScriptElementSize", l.MaxScriptElementSize},
+		{"MaxOpsPerScript", l.MaxOpsPerScript},
+		{"MaxPubKeysPerMultiSig", l.MaxPubKeysPerMultiSig},
+		{"MaxStackSize", l.MaxStackSize},
+		{"MaxDataCarrierSize", l.MaxDataCarrierSize},
+		{"MaxWitnessStackItems", l.MaxWitnessStackItems},
+		{"MaxWitnessElementSize", l.MaxWitnessElementSize},
+	}
+	for _, field := range fields {
+		if field.value <= 0 {
+			str := fmt.Sprintf("chain limit %s must be positive, got %d",
+				field.name, field.value)
+			return scriptError(ErrInvalidChainLimits, str)
+		}
+	}
+
+	// The extension limits are optional and disabled when zero.
+	optional := []struct {
+		name  string
+		value int
+	}{
+		{"MaxExtensionOpExecutions", l.MaxExtensionOpExecutions},
+		{"MaxExtensionOutputBytes", l.MaxExtensionOutputBytes},
+	}
+	for _, field := range optional {
+		if field.value < 0 {
+			str := fmt.Sprintf("chain limit %s must not be negative, "+
+				"got %d", field.name, field.value)
+			return scriptError(ErrInvalidChainLimits, str)
+		}
+	}
+
+	if l.MaxScriptElementSize > l.MaxScriptSize {
+		str := fmt.Sprintf("max element size %d exceeds max script "+
+			"size %d", l.MaxScriptElementSize, l.MaxScriptSize)
+		return scriptError(ErrInvalidChainLimits, str)
+	}
+	if l.MaxDataCarrierSize > l.MaxScriptElementSize {
+		str := fmt.Sprintf("max data carrier size %d exceeds max "+
+			"element size %d", l.MaxDataCarrierSize,
+			l.MaxScriptElementSize)
+		return scriptError(ErrInvalidChainLimits, str)
+	}
+	if l.MaxPubKeysPerMultiSig > MaxPubKeysPerMultiSig {
+		str := fmt.Sprintf("max pubkeys per multisig %d exceeds the %d "+
+			"charged by sigop counting", l.MaxPubKeysPerMultiSig,
+			MaxPubKeysPerMultiSig)
+		return scriptError(ErrInvalidChainLimits, str)
+	}
+	if l.MaxWitnessStackItems > l.MaxStackSize {
+		str := fmt.Sprintf("max witness stack items %d exceeds max "+
+			"stack size %d", l.MaxWitnessStackItems, l.MaxStackSize)
+		return scriptError(ErrInvalidChainLimits, str)
+	}
+
+	return nil
+}
+
+// defaultChainLimits 是未显式指定限制的引擎以及 standard.go 中的标准脚本识别函数所使用的限制。
+var defaultChainLimits atomic.Pointer[ChainLimits]
+
+func init() {
+	limits := BitcoinChainLimits()
+	defaultChainLimits.Store(&limits)
+}
+
+// DefaultChainLimits 返回当前的包级默认限制。 除非调用了 SetDefaultChainLimits，否则它与 BitcoinChainLimits 相同。
+func DefaultChainLimits() ChainLimits {
+	return *defaultChainLimits.Load()
+}
+
+// SetDefaultChainLimits 设置包级默认限制。 它会影响之后创建的所有未通过 WithChainLimits 指定限制的引擎，
+// 以及 NullDataScript、IsUnspendable、ScriptBuilder 等不接受引擎参数的函数。
+//
+// 该函数应当在进程启动期间、创建任何引擎之前调用一次。 同一进程中的多个节点使用不同的限制时不应调用它，
+// 而是通过 WithChainLimits、WithBuilderChainLimits 和 ChainLimits 的同名方法显式传递限制。
+func SetDefaultChainLimits(limits ChainLimits) error {
+	if err := limits.Validate(); err != nil {
+		return err
+	}
+	defaultChainLimits.Store(&limits)
+	return nil
+}
@@ -0,0 +1,433 @@
+package txscript
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"testing"
+
+	"github.com/btcsuite/btcd/wire"
+)
+
+// TestChainLimitsValidate 确保不一致或非正的限制会被拒绝。
+func TestChainLimitsValidate(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		modify func(*ChainLimits)
+		valid  bool
+	}{{
+		name:   "bitcoin defaults",
+		modify: func(*ChainLimits) {},
+		valid:  true,
+	}, {
+		name:   "larger elements",
+		modify: func(l *ChainLimits) { l.MaxScriptElementSize = 4096 },
+		valid:  true,
+	}, {
+		name:   "zero script size",
+		modify: func(l *ChainLimits) { l.MaxScriptSize = 0 },
+	}, {
+		name:   "negative stack size",
+		modify: func(l *ChainLimits) { l.MaxStackSize = -1 },
+	}, {
+		name: "element larger than script",
+		modify: func(l *ChainLimits) {
+			l.MaxScriptElementSize = l.MaxScriptSize + 1
+		},
+	}, {
+		name: "data carrier larger than element",
+		modify: func(l *ChainLimits) {
+			l.MaxDataCarrierSize = l.MaxScriptElementSize + 1
+		},
+	}, {
+		name: "witness elements larger than script elements",
+		modify: func(l *ChainLimits) {
+			l.MaxWitnessElementSize = l.MaxScriptElementSize * 8
+		},
+		valid: true,
+	}, {
+		name:   "zero witness element size",
+		modify: func(l *ChainLimits) { l.MaxWitnessElementSize = 0 },
+	}, {
+		name: "witness items exceed stack size",
+		modify: func(l *ChainLimits) {
+			l.MaxWitnessStackItems = l.MaxStackSize + 1
+		},
+	}, {
+		name:   "fewer multisig pubkeys",
+		modify: func(l *ChainLimits) { l.MaxPubKeysPerMultiSig = 15 },
+		valid:  true,
+	}, {
+		name: "multisig pubkeys exceed sigop charge",
+		modify: func(l *ChainLimits) {
+			l.MaxPubKeysPerMultiSig = MaxPubKeysPerMultiSig + 1
+		},
+	}, {
+		name: "extension limits",
+		modify: func(l *ChainLimits) {
+			l.MaxExtensionOpExecutions = 4
+			l.MaxExtensionOutputBytes = 1024
+		},
+		valid: true,
+	}, {
+		name:   "negative extension executions",
+		modify: func(l *ChainLimits) { l.MaxExtensionOpExecutions = -1 },
+	}, {
+		name:   "negative extension output",
+		modify: func(l *ChainLimits) { l.MaxExtensionOutputBytes = -1 },
+	}}
+
+	for _, test := range tests {
+		limits := BitcoinChainLimits()
+		test.modify(&limits)
+		err := limits.Validate()
+		if test.valid {
+			if err != nil {
+				t.Errorf("%s: unexpected error: %v", test.name, err)
+			}
+			continue
+		}
+		if !IsErrorCode(err, ErrInvalidChainLimits) {
+			t.Errorf("%s: expected ErrInvalidChainLimits, got %v",
+				test.name, err)
+		}
+	}
+}
+
+// TestEngineChainLimits 确保通过 WithChainLimits 指定的限制会在执行期间生效。
+func TestEngineChainLimits(t *testing.T) {
+	t.Parallel()
+
+	// 推送一个超过比特币元素大小限制的数据项，然后将其丢弃。
+	pkScript, err := NewScriptBuilder().
+		AddFullData(bytes.Repeat([]byte{0x01}, 600)).
+		AddOp(OP_DROP).AddOp(OP_TRUE).Script()
+	if err != nil {
+		t.Fatalf("unable to build script: %v", err)
+	}
+	tx := createSpendingTx(nil, nil, pkScript, 0)
+
+	vm, err := NewEngine(pkScript, tx, 0, 0, nil, nil, 0, nil)
+	if err != nil {
+		t.Fatalf("unable to create engine: %v", err)
+	}
+	err = vm.Execute()
+	if !IsErrorCode(err, ErrElementTooBig) {
+		t.Fatalf("expected ErrElementTooBig, got %v", err)
+	}
+
+	limits := BitcoinChainLimits()
+	limits.MaxScriptElementSize = 1024
+	vm, err = NewEngine(pkScript, tx, 0, 0, nil, nil, 0, nil,
+		WithChainLimits(limits))
+	if err != nil {
+		t.Fatalf("unable to create engine: %v", err)
+	}
+	if err := vm.Execute(); err != nil {
+		t.Fatalf("unexpected execution failure: %v", err)
+	}
+
+	// 无效的限制必须在创建引擎时被拒绝。
+	limits.MaxOpsPerScript = 0
+	_, err = NewEngine(pkScript, tx, 0, 0, nil, nil, 0, nil,
+		WithChainLimits(limits))
+	if !IsErrorCode(err, ErrInvalidChainLimits) {
+		t.Fatalf("expected ErrInvalidChainLimits, got %v", err)
+	}
+}
+
+// TestEngineExtensionLimits 确保扩展操作码的执行次数和输出字节数限制在边界处生效，只统计实际执行的操作码，
+// 并且按脚本分别计算。
+//
+// 注意：该测试修改全局注册表，因此不能调用 t.Parallel。
+func TestEngineExtensionLimits(t *testing.T) {
+	opCat := func(vm *Engine, data []byte) error {
+		b, err := vm.PopStack()
+		if err != nil {
+			return err
+		}
+		a, err := vm.PopStack()
+		if err != nil {
+			return err
+		}
+		vm.PushStack(append(append([]byte{}, a...), b...))
+		return nil
+	}
+
+	limits := BitcoinChainLimits()
+	limits.MaxExtensionOpExecutions = 2
+	limits.MaxExtensionOutputBytes = 8
+
+	execute := func(sigScript, pkScript string, flags ScriptFlags,
+		opts ...EngineOpt) error {
+
+		pk := mustParseShortForm(pkScript)
+		tx := createSpendingTx(nil, mustParseShortForm(sigScript), pk, 0)
+		vm, err := NewEngine(pk, tx, 0, flags, nil, nil, 0, nil, opts...)
+		if err != nil {
+			return err
+		}
+		return vm.Execute()
+	}
+
+	withCleanScriptVersionRegistry(func() {
+		err := RegisterScriptVersion(1, ScriptVersionDefinition{
+			Opcodes: map[byte]OpcodeFunc{OP_CAT: opCat},
+			Limits:  &limits,
+		})
+		if err != nil {
+			t.Fatalf("unable to register script version: %v", err)
+		}
+		version := WithScriptVersion(1)
+
+		tests := []struct {
+			name      string
+			sigScript string
+			pkScript  string
+			code      ErrorCode
+			fails     bool
+		}{{
+			name:     "max executions",
+			pkScript: "'a' 'b' CAT 'c' CAT DROP 1",
+		}, {
+			name:     "too many executions",
+			pkScript: "'a' 'b' CAT 'c' CAT 'd' CAT DROP 1",
+			code:     ErrTooManyExtensionOps,
+			fails:    true,
+		}, {
+			name:     "max output",
+			pkScript: "'ab' 'cd' CAT 'ef' 'gh' CAT 2DROP 1",
+		}, {
+			name:     "output too big",
+			pkScript: "'ab' 'cd' CAT 'ef' 'ghi' CAT 2DROP 1",
+			code:     ErrExtensionOutputTooBig,
+			fails:    true,
+		}, {
+			name:     "unexecuted branch",
+			pkScript: "0 IF CAT CAT CAT ENDIF 1",
+		}, {
+			name:      "per script",
+			sigScript: "'a' 'b' CAT 'c' CAT",
+			pkScript:  "DROP 'd' 'e' CAT 'f' CAT DROP 1",
+		}}
+		for _, test := range tests {
+			err := execute(test.sigScript, test.pkScript, 0, version)
+			if !test.fails {
+				if err != nil {
+					t.Errorf("%s: unexpected error: %v", test.name, err)
+				}
+				continue
+			}
+			if !IsErrorCode(err, test.code) {
+				t.Errorf("%s: expected %v, got %v", test.name,
+					test.code, err)
+			}
+		}
+	})
+
+	// OP_CHECKSIGFROMSTACK is an extension opcode once enabled in
+	// tapscript. An empty signature makes it push false without checking
+	// the key.
+	limits = BitcoinChainLimits()
+	limits.MaxExtensionOpExecutions = 1
+	csfs := mustParseShortForm("0 'msg' 'key' CHECKSIGFROMSTACK DROP")
+	flags := StandardVerifyFlags | ScriptVerifyCheckSigFromStack
+	once := checkSigFromStackLeafSpend(t, append(
+		append([]byte{}, csfs...), OP_TRUE,
+	))
+	if err := once(nil, flags, WithChainLimits(limits)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	twice := checkSigFromStackLeafSpend(t, append(
+		append(append([]byte{}, csfs...), csfs...), OP_TRUE,
+	))
+	err := twice(nil, flags, WithChainLimits(limits))
+	if !IsErrorCode(err, ErrTooManyExtensionOps) {
+		t.Fatalf("expected ErrTooManyExtensionOps, got %v", err)
+	}
+}
+
+// TestEngineWitnessLimits 确保见证元素数量和大小的限制对见证版本 0 和 tapscript 花费同样生效，
+// 并且见证元素的大小限制与脚本推送的大小限制相互独立。
+func TestEngineWitnessLimits(t *testing.T) {
+	t.Parallel()
+
+	const flags = ScriptBip16 | ScriptVerifyWitness | ScriptVerifyTaproot
+	witnessScript := []byte{OP_DROP, OP_TRUE}
+	scriptHash := sha256.Sum256(witnessScript)
+	p2wsh, err := payToWitnessScriptHashScript(scriptHash[:])
+	if err != nil {
+		t.Fatalf("unable to build p2wsh script: %v", err)
+	}
+
+	leaf := NewBaseTapLeaf(witnessScript)
+	tree := AssembleTaprootScriptTree(leaf)
+	rootHash := tree.RootNode.TapHash()
+	p2tr, err := PayToTaprootScript(
+		ComputeTaprootOutputKey(NUMSKey(), rootHash[:]),
+	)
+	if err != nil {
+		t.Fatalf("unable to build p2tr script: %v", err)
+	}
+	ctrlBlock := tree.LeafMerkleProofs[0].ToControlBlock(NUMSKey())
+	ctrlBytes, err := ctrlBlock.ToBytes()
+	if err != nil {
+		t.Fatalf("unable to serialize control block: %v", err)
+	}
+
+	execute := func(pkScript []byte, witness wire.TxWitness,
+		limits ChainLimits) error {
+
+		tx := createSpendingTx(witness, nil, pkScript, 0)
+		prevOuts := NewCannedPrevOutputFetcher(pkScript, 0)
+		vm, err := NewEngine(pkScript, tx, 0, flags, nil,
+			NewTxSigHashes(tx, prevOuts), 0, prevOuts,
+			WithChainLimits(limits))
+		if err != nil {
+			return err
+		}
+		return vm.Execute()
+	}
+
+	big := bytes.Repeat([]byte{0x01}, 600)
+	spends := []struct {
+		name     string
+		pkScript []byte
+		witness  wire.TxWitness
+	}{
+		{"p2wsh", p2wsh, wire.TxWitness{big, witnessScript}},
+		{"tapscript", p2tr, wire.TxWitness{big, witnessScript, ctrlBytes}},
+	}
+	for _, spend := range spends {
+		limits := BitcoinChainLimits()
+		err := execute(spend.pkScript, spend.witness, limits)
+		if !IsErrorCode(err, ErrElementTooBig) {
+			t.Fatalf("%s: expected ErrElementTooBig, got %v",
+				spend.name, err)
+		}
+
+		limits.MaxWitnessElementSize = 1024
+		err = execute(spend.pkScript, spend.witness, limits)
+		if err != nil {
+			t.Fatalf("%s: unexpected execution failure: %v",
+				spend.name, err)
+		}
+
+		limits.MaxWitnessStackItems = 0
+		err = execute(spend.pkScript, spend.witness, limits)
+		if !IsErrorCode(err, ErrInvalidChainLimits) {
+			t.Fatalf("%s: expected ErrInvalidChainLimits, got %v",
+				spend.name, err)
+		}
+
+		// The witness script and control block don't count towards the
+		// stack items.
+		extra := append(wire.TxWitness{{0x02}}, spend.witness...)
+		limits.MaxWitnessStackItems = 1
+		err = execute(spend.pkScript, spend.witness, limits)
+		if err != nil {
+			t.Fatalf("%s: unexpected execution failure: %v",
+				spend.name, err)
+		}
+		err = execute(spend.pkScript, extra, limits)
+		if !IsErrorCode(err, ErrStackOverflow) {
+			t.Fatalf("%s: expected ErrStackOverflow, got %v",
+				spend.name, err)
+		}
+	}
+}
+
+// TestSetDefaultChainLimits 确保包级默认限制会影响标准脚本函数，并且无效的限制会被拒绝。
+//
+// 注意：该测试修改包级状态，因此不能并行运行。
+func TestSetDefaultChainLimits(t *testing.T) {
+	saved := DefaultChainLimits()
+	defer func() {
+		if err := SetDefaultChainLimits(saved); err != nil {
+			t.Fatalf("unable to restore limits: %v", err)
+		}
+	}()
+
+	data := bytes.Repeat([]byte{0x02}, 120)
+	if _, err := NullDataScript(data); !IsErrorCode(err, ErrTooMuchNullData) {
+		t.Fatalf("expected ErrTooMuchNullData, got %v", err)
+	}
+
+	limits := BitcoinChainLimits()
+	limits.MaxDataCarrierSize = 256
+	if err := SetDefaultChainLimits(limits); err != nil {
+		t.Fatalf("unable to set limits: %v", err)
+	}
+	script, err := NullDataScript(data)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !IsNullData(script) {
+		t.Fatalf("script not recognized as null data: %x", script)
+	}
+
+	limits.MaxScriptSize = 0
+	err = SetDefaultChainLimits(limits)
+	if !IsErrorCode(err, ErrInvalidChainLimits) {
+		t.Fatalf("expected ErrInvalidChainLimits, got %v", err)
+	}
+	if got := DefaultChainLimits().MaxDataCarrierSize; got != 256 {
+		t.Fatalf("invalid limits were applied: carrier size %d", got)
+	}
+}
+
+// TestChainLimitsExplicit 确保 ChainLimits 的方法和 WithBuilderChainLimits 使用显式传递的限制，而不读取或修改包级默认限制。
+func TestChainLimitsExplicit(t *testing.T) {
+	t.Parallel()
+
+	limits := BitcoinChainLimits()
+	limits.MaxDataCarrierSize = 256
+	limits.MaxScriptSize = 200
+
+	data := bytes.Repeat([]byte{0x02}, 120)
+	script, err := limits.NullDataScript(data)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !limits.IsNullData(script) {
+		t.Fatalf("script not recognized as null data: %x", script)
+	}
+	if class := limits.GetScriptClass(script); class != NullDataTy {
+		t.Fatalf("unexpected class %v", class)
+	}
+	if IsNullData(script) || GetScriptClass(script) != NonStandardTy {
+		t.Fatalf("package default limits changed")
+	}
+	if _, err := NullDataScript(data); !IsErrorCode(err, ErrTooMuchNullData) {
+		t.Fatalf("expected ErrTooMuchNullData, got %v", err)
+	}
+
+	// A script within the package default size is unspendable under the
+	// smaller explicit limit.
+	large := bytes.Repeat([]byte{OP_NOP}, 201)
+	if !limits.IsUnspendable(large) || IsUnspendable(large) {
+		t.Fatalf("unexpected unspendable result for %d byte script",
+			len(large))
+	}
+	_, err = NewScriptBuilder(WithBuilderChainLimits(limits)).AddOps(large).
+		Script()
+	if _, ok := err.(ErrScriptNotCanonical); !ok {
+		t.Fatalf("expected ErrScriptNotCanonical, got %v", err)
+	}
+
+	// The sanity checks apply the explicit size limit to the spent script.
+	tx := wire.NewMsgTx(2)
+	op := wire.OutPoint{Index: 0}
+	tx.AddTxIn(wire.NewTxIn(&op, nil, nil))
+	fetcher := NewCannedPrevOutputFetcher(large, 1000)
+	violations := limits.CheckTransactionScriptsSanity(tx, fetcher)
+	if len(violations) != 1 || violations[0].Kind != TxScriptTooLarge {
+		t.Fatalf("unexpected violations %v", violations)
+	}
+	for _, v := range CheckTransactionScriptsSanity(tx, fetcher) {
+		if v.Kind == TxScriptTooLarge {
+			t.Fatalf("package default limits changed: %v", v)
+		}
+	}
+}
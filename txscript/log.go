@@ -1,8 +1,17 @@
 // 定义了日志记录的相关功能，可能用于调试和跟踪脚本执行。
 
+//go:build !txscriptlite
+
 package txscript
 
-import "github.com/btcsuite/btclog"
+import (
+	"github.com/btcsuite/btclog"
+	"github.com/sirupsen/logrus"
+)
+
+// LiteBuild 表示包是否使用 txscriptlite 构建标签编译。 精简构建不包含日志依赖，脚本错误只携带错误代码而没有描述，
+// 适用于编译到 WASM 或嵌入式验证器。
+const LiteBuild = false
 
 // log is a logger that is initialized with no output filters.  This
 // means the package will not perform any logging by default until the caller
@@ -37,3 +46,9 @@ func (c logClosure) String() string {
 func newLogClosure(c func() string) logClosure {
 	return logClosure(c)
 }
+
+// logTrace logs the string returned by c at the trace level. The closure is
+// only invoked if trace logging is enabled.
+func logTrace(c func() string) {
+	logrus.Tracef("%v", newLogClosure(c))
+}
@@ -0,0 +1,16 @@
+// 定义精简构建（txscriptlite 构建标签）中的日志功能。 精简构建不依赖任何日志库，跟踪日志在编译时被移除，
+// 使引擎可以编译到 WASM 或嵌入式验证器，并且单步执行时不会为日志分配闭包。
+
+//go:build txscriptlite
+
+package txscript
+
+// LiteBuild 表示包是否使用 txscriptlite 构建标签编译。 精简构建不包含日志依赖，脚本错误只携带错误代码而没有描述，
+// 适用于编译到 WASM 或嵌入式验证器。
+const LiteBuild = true
+
+// DisableLog 禁用包的日志输出。 精简构建没有日志输出，因此它什么也不做，仅为与完整构建保持源代码兼容而保留。
+func DisableLog() {}
+
+// logTrace 在精简构建中什么也不做，调用会被内联消除，因此跟踪日志的闭包既不会被创建也不会被调用。
+func logTrace(func() string) {}
@@ -0,0 +1,25 @@
+//go:build txscriptlite
+
+package txscript
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// TestLiteBuildErrors 确保精简构建中的脚本错误只携带错误代码，并以错误代码的名称作为错误字符串。
+func TestLiteBuildErrors(t *testing.T) {
+	t.Parallel()
+
+	require.True(t, LiteBuild)
+
+	pkScript := mustParseShortForm("0")
+	tx := createSpendingTx(nil, nil, pkScript, 0)
+	vm, err := NewEngine(pkScript, tx, 0, 0, nil, nil, 0, nil)
+	require.NoError(t, err)
+	err = vm.Execute()
+	require.True(t, IsErrorCode(err, ErrEvalFalse))
+	require.Empty(t, err.(Error).Description)
+	require.Equal(t, "ErrEvalFalse", err.Error())
+}
@@ -0,0 +1,235 @@
+// 包含已签名输入的延展性分析，报告第三方可以在不使签名失效的情况下修改签名脚本或见证的方式，
+// 供接受零确认交易的服务在依赖交易 ID 之前检查。
+
+package txscript
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/btcsuite/btcd/wire"
+)
+
+// MalleabilityKind 表示一种延展性来源。
+type MalleabilityKind uint8
+
+const (
+	// MalleabilityNotPushOnly 表示签名脚本包含数据推送以外的操作码，第三方可以添加不影响结果的操作码。
+	MalleabilityNotPushOnly MalleabilityKind = iota
+
+	// MalleabilityNonMinimalPush 表示数据推送或作为数字使用的堆栈元素没有使用最短的编码，第三方可以改用其他编码。
+	MalleabilityNonMinimalPush
+
+	// MalleabilityNonCanonicalDER 表示 ECDSA 签名不是严格的 DER 编码，第三方可以重新编码签名。
+	MalleabilityNonCanonicalDER
+
+	// MalleabilityHighS 表示 ECDSA 签名的 S 值大于曲线阶的一半，第三方可以将 S 替换为 N-S。
+	MalleabilityHighS
+
+	// MalleabilityExtraStackItems 表示执行结束后堆栈上留有多余的元素，第三方可以添加或修改这些元素。
+	MalleabilityExtraStackItems
+
+	// MalleabilityNonMinimalIf 表示 OP_IF 或 OP_NOTIF 的参数不是空向量或 [0x01]，第三方可以替换为其他等价值。
+	MalleabilityNonMinimalIf
+
+	// MalleabilityNonNullFail 表示失败的签名检查使用了非空签名，第三方可以将其替换为任意数据。
+	MalleabilityNonNullFail
+
+	// MalleabilityCodeSeparator 表示脚本中的 OP_CODESEPARATOR 之后没有签名检查，该操作码不起任何作用。
+	MalleabilityCodeSeparator
+
+	// MalleabilityAnnex 表示 taproot 附件没有被任何签名承诺或不是结构化的 TLV 记录流。
+	MalleabilityAnnex
+)
+
+// malleabilityKindNames 包含每种延展性来源的名称。
+var malleabilityKindNames = []string{
+	MalleabilityNotPushOnly:     "MalleabilityNotPushOnly",
+	MalleabilityNonMinimalPush:  "MalleabilityNonMinimalPush",
+	MalleabilityNonCanonicalDER: "MalleabilityNonCanonicalDER",
+	MalleabilityHighS:           "MalleabilityHighS",
+	MalleabilityExtraStackItems: "MalleabilityExtraStackItems",
+	MalleabilityNonMinimalIf:    "MalleabilityNonMinimalIf",
+	MalleabilityNonNullFail:     "MalleabilityNonNullFail",
+	MalleabilityCodeSeparator:   "MalleabilityCodeSeparator",
+	MalleabilityAnnex:           "MalleabilityAnnex",
+}
+
+// String 返回延展性来源的名称。
+func (k MalleabilityKind) String() string {
+	if int(k) >= len(malleabilityKindNames) {
+		return fmt.Sprintf("MalleabilityKind(%d)", uint8(k))
+	}
+	return malleabilityKindNames[k]
+}
+
+// MalleabilityVector 描述输入的一处延展性来源。
+type MalleabilityVector struct {
+	// Kind 是延展性来源的种类。
+	Kind MalleabilityKind
+
+	// Location 是相关操作码的位置，脚本索引与引擎执行的脚本序列相同。 与具体操作码无关时为 nil。
+	Location *FailureLocation
+
+	// Description 是延展性来源的可读描述。
+	Description string
+}
+
+// String 返回延展性来源的单行描述。
+func (v MalleabilityVector) String() string {
+	if v.Location == nil {
+		return fmt.Sprintf("%v: %s", v.Kind, v.Description)
+	}
+	return fmt.Sprintf("%v at %v: %s", v.Kind, v.Location, v.Description)
+}
+
+// malleabilityChecks 是通过引擎标志检测的延展性来源。 每个标志单独加到基础标志上执行一次，执行失败即说明存在对应的延展性。
+// code 不为 0 时只有该错误码才计入，用于区分同时受多个标志影响的检查。
+var malleabilityChecks = []struct {
+	kind MalleabilityKind
+	flag ScriptFlags
+	code ErrorCode
+}{
+	{MalleabilityNotPushOnly, ScriptVerifySigPushOnly, 0},
+	{MalleabilityNonMinimalPush, ScriptVerifyMinimalData, 0},
+	{MalleabilityNonCanonicalDER, ScriptVerifyDERSignatures, 0},
+
+	// ScriptVerifyLowS also requires strict DER encoding, so only the high
+	// S failure itself is attributed to it.
+	{MalleabilityHighS, ScriptVerifyLowS, ErrSigHighS},
+	{MalleabilityExtraStackItems, ScriptVerifyCleanStack, 0},
+	{MalleabilityNonMinimalIf, ScriptVerifyMinimalIf, 0},
+	{MalleabilityNonNullFail, ScriptVerifyNullFail, 0},
+}
+
+// malleabilityFlags 是从基础标志中去掉的标志，包括 malleabilityChecks 中的标志以及同样要求严格签名编码的 ScriptVerifyStrictEncoding。
+const malleabilityFlags = ScriptVerifySigPushOnly | ScriptVerifyMinimalData |
+	ScriptVerifyDERSignatures | ScriptVerifyLowS | ScriptVerifyCleanStack |
+	ScriptVerifyMinimalIf | ScriptVerifyNullFail | ScriptVerifyStrictEncoding
+
+// CheckMalleability 分析 tx 中已完全签名的输入 txIdx，并返回发现的所有延展性来源，没有时返回 nil。 检查的来源包括：
+//
+//   - 签名脚本包含数据推送以外的操作码
+//   - 没有使用最短编码的数据推送和数字
+//   - 不是严格 DER 编码或 S 值过高的 ECDSA 签名
+//   - 执行结束后堆栈上多余的元素
+//   - 不是最短形式的 OP_IF 参数以及失败签名检查中的非空签名
+//   - 之后没有签名检查的 OP_CODESEPARATOR
+//   - 没有被签名承诺或不是结构化记录的 taproot 附件
+//
+// 签名脚本的修改会改变交易 ID，见证的修改只会改变见证交易 ID 和交易的权重。 每种通过引擎检测的来源只报告第一处。
+//
+// 输入在去掉上述规则的 DefaultVerifyFlags 下必须能够通过验证，否则返回验证错误。 prevOutFetcher 必须能够返回交易所有输入花费的输出。
+func CheckMalleability(tx *wire.MsgTx, txIdx int,
+	prevOutFetcher PrevOutputFetcher) ([]MalleabilityVector, error) {
+
+	if txIdx < 0 || txIdx >= len(tx.TxIn) {
+		str := fmt.Sprintf("transaction input index %d is negative or "+
+			">= %d", txIdx, len(tx.TxIn))
+		return nil, scriptError(ErrInvalidIndex, str)
+	}
+	prevOut := prevOutFetcher.FetchPrevOutput(tx.TxIn[txIdx].PreviousOutPoint)
+	if prevOut == nil {
+		return nil, internalError("previous output is required", nil)
+	}
+
+	sigHashes := NewTxSigHashes(tx, prevOutFetcher)
+	baseFlags := DefaultVerifyFlags(prevOut.PkScript) &^ malleabilityFlags
+	execute := func(flags ScriptFlags,
+		opts ...EngineOpt) (*Engine, error) {
+
+		vm, err := NewEngine(
+			prevOut.PkScript, tx, txIdx, flags, nil, sigHashes,
+			prevOut.Value, prevOutFetcher, opts...,
+		)
+		if err != nil {
+			return nil, err
+		}
+		return vm, vm.Execute()
+	}
+
+	var stats ExecutionStats
+	vm, err := execute(baseFlags, WithExecutionStats(&stats))
+	if err != nil {
+		return nil, err
+	}
+
+	var vectors []MalleabilityVector
+	for _, check := range malleabilityChecks {
+		_, err := execute(baseFlags | check.flag)
+		var serr Error
+		if err == nil || !errors.As(err, &serr) ||
+			(check.code != 0 && serr.ErrorCode != check.code) {
+
+			continue
+		}
+		vectors = append(vectors, MalleabilityVector{
+			Kind:        check.kind,
+			Location:    serr.Location(),
+			Description: serr.Description,
+		})
+	}
+
+	vectors = append(vectors, codeSeparatorVectors(vm.scripts)...)
+
+	if vm.taprootCtx != nil && vm.taprootCtx.annex != nil {
+		annex := vm.taprootCtx.annex
+		if _, err := DecodeAnnex(annex); err != nil {
+			vectors = append(vectors, MalleabilityVector{
+				Kind: MalleabilityAnnex,
+				Description: "annex is not a structured record " +
+					"stream: " + err.Error(),
+			})
+		}
+
+		// Key path spends always commit to the annex, while a script path
+		// spend only does when its leaf checks a signature.
+		if !vm.taprootCtx.mustSucceed && stats.SigOps == 0 {
+			vectors = append(vectors, MalleabilityVector{
+				Kind: MalleabilityAnnex,
+				Description: "annex is not committed to by any " +
+					"signature",
+			})
+		}
+	}
+
+	return vectors, nil
+}
+
+// codeSeparatorVectors 返回脚本中之后没有签名检查操作码的 OP_CODESEPARATOR。 脚本索引与传入的切片相同。
+func codeSeparatorVectors(scripts [][]byte) []MalleabilityVector {
+	const scriptVersion = 0
+	var vectors []MalleabilityVector
+	for scriptIdx, script := range scripts {
+		var pending []*FailureLocation
+		tokenizer := MakeScriptTokenizer(scriptVersion, script)
+		for offset := 0; tokenizer.Next(); offset = int(tokenizer.ByteIndex()) {
+			switch tokenizer.Opcode() {
+			case OP_CODESEPARATOR:
+				pending = append(pending, &FailureLocation{
+					ScriptIndex: scriptIdx,
+					OpcodeIndex: int(tokenizer.OpcodePosition()),
+					Opcode:      OP_CODESEPARATOR,
+					ByteOffset:  offset,
+				})
+
+			case OP_CHECKSIG, OP_CHECKSIGVERIFY, OP_CHECKMULTISIG,
+				OP_CHECKMULTISIGVERIFY, OP_CHECKSIGADD:
+
+				pending = nil
+			}
+		}
+
+		for _, loc := range pending {
+			loc.Disasm = disasmWindow(scriptVersion, script,
+				int32(loc.OpcodeIndex), failureWindowRadius)
+			vectors = append(vectors, MalleabilityVector{
+				Kind:     MalleabilityCodeSeparator,
+				Location: loc,
+				Description: "OP_CODESEPARATOR is not followed by a " +
+					"signature check",
+			})
+		}
+	}
+	return vectors
+}
@@ -0,0 +1,222 @@
+package txscript
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/stretchr/testify/require"
+)
+
+// reencodeSig 将 DER 签名的 R 和 S 重新编码为新的签名。 padR 为 true 时在 R 前添加多余的零字节，highS 为 true 时将 S 替换为 N-S。
+func reencodeSig(t *testing.T, sig []byte, padR, highS bool) []byte {
+	t.Helper()
+
+	hashType := sig[len(sig)-1]
+	rLen := int(sig[3])
+	r := sig[4 : 4+rLen]
+	s := sig[6+rLen : len(sig)-1]
+
+	if padR {
+		r = append([]byte{0x00}, r...)
+	}
+	if highS {
+		n := btcec.S256().N
+		s = new(big.Int).Sub(n, new(big.Int).SetBytes(s)).Bytes()
+		if s[0]&0x80 != 0 {
+			s = append([]byte{0x00}, s...)
+		}
+	}
+
+	der := []byte{0x30, byte(4 + len(r) + len(s)), 0x02, byte(len(r))}
+	der = append(der, r...)
+	der = append(der, 0x02, byte(len(s)))
+	der = append(der, s...)
+	return append(der, hashType)
+}
+
+// TestCheckMalleability 确保延展性分析报告已签名输入中的各种延展性来源。
+func TestCheckMalleability(t *testing.T) {
+	t.Parallel()
+
+	privKey, err := btcec.NewPrivateKey()
+	require.NoError(t, err)
+	pubKey := privKey.PubKey().SerializeCompressed()
+	addr, err := btcutil.NewAddressPubKeyHash(
+		btcutil.Hash160(pubKey), &chaincfg.MainNetParams,
+	)
+	require.NoError(t, err)
+	p2pkh, err := PayToAddrScript(addr)
+	require.NoError(t, err)
+
+	tx := createSpendingTx(nil, nil, p2pkh, 0)
+	sig, err := RawTxInSignature(tx, 0, p2pkh, SigHashAll, privKey)
+	require.NoError(t, err)
+	fetcher := NewCannedPrevOutputFetcher(p2pkh, 0)
+
+	sigScript := func(script string, sig []byte) []byte {
+		builder := NewScriptBuilder()
+		if script != "" {
+			builder.AddOps(mustParseShortForm(script))
+		}
+		s, err := builder.AddData(sig).AddData(pubKey).Script()
+		require.NoError(t, err)
+		return s
+	}
+	pushData1 := append([]byte{OP_PUSHDATA1, byte(len(sig))}, sig...)
+	pushData1 = append(pushData1, OP_DATA_33)
+	pushData1 = append(pushData1, pubKey...)
+
+	tests := []struct {
+		name      string
+		sigScript []byte
+		kinds     []MalleabilityKind
+	}{{
+		name:      "canonical",
+		sigScript: sigScript("", sig),
+	}, {
+		name:      "high s",
+		sigScript: sigScript("", reencodeSig(t, sig, false, true)),
+		kinds:     []MalleabilityKind{MalleabilityHighS},
+	}, {
+		name:      "padded r",
+		sigScript: sigScript("", reencodeSig(t, sig, true, false)),
+		kinds:     []MalleabilityKind{MalleabilityNonCanonicalDER},
+	}, {
+		name:      "non-minimal push",
+		sigScript: pushData1,
+		kinds:     []MalleabilityKind{MalleabilityNonMinimalPush},
+	}, {
+		name:      "extra stack item",
+		sigScript: sigScript("1", sig),
+		kinds:     []MalleabilityKind{MalleabilityExtraStackItems},
+	}, {
+		name:      "not push only",
+		sigScript: sigScript("NOP", sig),
+		kinds:     []MalleabilityKind{MalleabilityNotPushOnly},
+	}, {
+		name:      "unnecessary code separator in signature script",
+		sigScript: sigScript("CODESEPARATOR", sig),
+		kinds: []MalleabilityKind{
+			MalleabilityNotPushOnly, MalleabilityCodeSeparator,
+		},
+	}}
+
+	for _, test := range tests {
+		tx.TxIn[0].SignatureScript = test.sigScript
+		vectors, err := CheckMalleability(tx, 0, fetcher)
+		require.NoError(t, err, test.name)
+
+		var kinds []MalleabilityKind
+		for _, v := range vectors {
+			kinds = append(kinds, v.Kind)
+		}
+		require.Equal(t, test.kinds, kinds, test.name)
+	}
+
+	// 检测到的来源带有失败位置。
+	tx.TxIn[0].SignatureScript = sigScript("CODESEPARATOR", sig)
+	vectors, err := CheckMalleability(tx, 0, fetcher)
+	require.NoError(t, err)
+	require.Len(t, vectors, 2)
+	loc := vectors[1].Location
+	require.NotNil(t, loc)
+	require.Equal(t, 0, loc.ScriptIndex)
+	require.Equal(t, 0, loc.OpcodeIndex)
+	require.Contains(t, vectors[1].String(), "[OP_CODESEPARATOR]")
+
+	// 无效的输入返回验证错误。
+	tx.TxIn[0].SignatureScript = sigScript("", sig[1:])
+	_, err = CheckMalleability(tx, 0, fetcher)
+	require.Error(t, err)
+
+	_, err = CheckMalleability(tx, 1, fetcher)
+	require.True(t, IsErrorCode(err, ErrInvalidIndex))
+	_, err = CheckMalleability(tx, 0, NewMultiPrevOutFetcher(nil))
+	require.ErrorAs(t, err, &InternalError{})
+}
+
+// TestCheckMalleabilityScripts 确保延展性分析报告脚本中多余的 OP_CODESEPARATOR 和未被承诺的附件。
+func TestCheckMalleabilityScripts(t *testing.T) {
+	t.Parallel()
+
+	// 裸脚本中之后有签名检查的 OP_CODESEPARATOR 不被报告。
+	pkScript := mustParseShortForm("CODESEPARATOR 1 CODESEPARATOR DROP " +
+		"CODESEPARATOR 1")
+	tx := createSpendingTx(nil, nil, pkScript, 0)
+	vectors, err := CheckMalleability(
+		tx, 0, NewCannedPrevOutputFetcher(pkScript, 0),
+	)
+	require.NoError(t, err)
+	require.Len(t, vectors, 3)
+	for i, v := range vectors {
+		require.Equal(t, MalleabilityCodeSeparator, v.Kind)
+		require.Equal(t, 1, v.Location.ScriptIndex)
+		require.Equal(t, []int{0, 2, 4}[i], v.Location.OpcodeIndex)
+	}
+
+	pkScript = mustParseShortForm("CODESEPARATOR 0 0 0 CHECKMULTISIG")
+	tx = createSpendingTx(nil, nil, pkScript, 0)
+	vectors, err = CheckMalleability(
+		tx, 0, NewCannedPrevOutputFetcher(pkScript, 0),
+	)
+	require.NoError(t, err)
+	require.Empty(t, vectors)
+
+	// 不检查签名的 tapscript 叶子不承诺附件。
+	internalKey, err := btcec.NewPrivateKey()
+	require.NoError(t, err)
+	leaf := NewBaseTapLeaf(mustParseShortForm("1"))
+	tree := AssembleTaprootScriptTree(leaf)
+	ctrlBlock := tree.LeafMerkleProofs[0].ToControlBlock(
+		internalKey.PubKey(),
+	)
+	ctrlBytes, err := ctrlBlock.ToBytes()
+	require.NoError(t, err)
+	rootHash := tree.RootNode.TapHash()
+	outputKey := ComputeTaprootOutputKey(internalKey.PubKey(), rootHash[:])
+	p2tr, err := PayToTaprootScript(outputKey)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name  string
+		annex []byte
+		kinds []MalleabilityKind
+	}{{
+		name: "no annex",
+	}, {
+		name:  "structured annex",
+		annex: []byte{TaprootAnnexTag},
+		kinds: []MalleabilityKind{MalleabilityAnnex},
+	}, {
+		name:  "unstructured annex",
+		annex: []byte{TaprootAnnexTag, 0xff},
+		kinds: []MalleabilityKind{
+			MalleabilityAnnex, MalleabilityAnnex,
+		},
+	}}
+	for _, test := range tests {
+		witness := wire.TxWitness{leaf.Script, ctrlBytes}
+		if test.annex != nil {
+			witness = append(witness, test.annex)
+		}
+		tx := createSpendingTx(witness, nil, p2tr, 0)
+		vectors, err := CheckMalleability(
+			tx, 0, NewCannedPrevOutputFetcher(p2tr, 0),
+		)
+		require.NoError(t, err, test.name)
+
+		var kinds []MalleabilityKind
+		for _, v := range vectors {
+			require.Nil(t, v.Location, test.name)
+			kinds = append(kinds, v.Kind)
+		}
+		require.Equal(t, test.kinds, kinds, test.name)
+	}
+
+	require.Equal(t, "MalleabilityHighS", MalleabilityHighS.String())
+	require.Equal(t, "MalleabilityKind(200)", MalleabilityKind(200).String())
+}
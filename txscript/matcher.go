@@ -0,0 +1,286 @@
+// 包含只读钱包使用的脚本匹配器。 匹配器预先计算被监视的脚本、地址和公钥在各种输出类型中出现的哈希，
+// 使重新扫描区块时每个公钥脚本或见证只需几次哈希表查询，而不必与每个被监视项逐字节比较。
+
+package txscript
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"sync"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcec/v2/schnorr"
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/wire"
+)
+
+// Matcher 判断公钥脚本、输入或交易是否涉及被监视的脚本、地址或公钥。
+//
+// 被监视的项在加入时被展开为哈希集合：公钥匹配 P2PK、P2PKH、P2WPKH、P2SH-P2WPKH 以及 BIP0086 密钥路径 P2TR 输出；
+// 赎回脚本匹配 P2SH、P2WSH 和 P2SH-P2WSH 输出；x-only 公钥匹配以其为输出密钥的 P2TR 输出，
+// 以及以其为内部密钥或在叶子脚本中使用它的 taproot 脚本路径花费；tapscript 叶子匹配揭示该叶子的脚本路径花费。
+//
+// Matcher 是并发安全的。
+type Matcher struct {
+	sync.RWMutex
+
+	// pkScripts 是被精确匹配的公钥脚本。
+	pkScripts map[string]struct{}
+
+	// pubKeys 是 P2PK 输出中的序列化公钥。
+	pubKeys map[string]struct{}
+
+	// pubKeyHashes 是 P2PKH 和 P2WPKH 输出中的公钥 HASH160。
+	pubKeyHashes map[[20]byte]struct{}
+
+	// scriptHashes 是 P2SH 输出中的赎回脚本 HASH160。
+	scriptHashes map[[20]byte]struct{}
+
+	// witnessScriptHashes 是 P2WSH 输出中的见证脚本 SHA256。
+	witnessScriptHashes map[[32]byte]struct{}
+
+	// xOnlyKeys 是 taproot 输出密钥、内部密钥和叶子脚本中的 x-only 公钥。
+	xOnlyKeys map[[32]byte]struct{}
+
+	// tapLeaves 是被监视的 tapscript 叶子哈希。
+	tapLeaves map[chainhash.Hash]struct{}
+}
+
+// NewMatcher 返回没有监视任何项的匹配器。
+func NewMatcher() *Matcher {
+	return &Matcher{
+		pkScripts:           make(map[string]struct{}),
+		pubKeys:             make(map[string]struct{}),
+		pubKeyHashes:        make(map[[20]byte]struct{}),
+		scriptHashes:        make(map[[20]byte]struct{}),
+		witnessScriptHashes: make(map[[32]byte]struct{}),
+		xOnlyKeys:           make(map[[32]byte]struct{}),
+		tapLeaves:           make(map[chainhash.Hash]struct{}),
+	}
+}
+
+// hash160Key 返回 data 的 HASH160，用作哈希集合的键。
+func hash160Key(data []byte) [20]byte {
+	return *(*[20]byte)(hash160(data))
+}
+
+// AddScript 监视与 pkScript 完全相同的公钥脚本。
+func (m *Matcher) AddScript(pkScript []byte) {
+	m.Lock()
+	m.pkScripts[string(pkScript)] = struct{}{}
+	m.Unlock()
+}
+
+// AddAddress 监视支付到地址的公钥脚本。
+func (m *Matcher) AddAddress(addr btcutil.Address) error {
+	pkScript, err := PayToAddrScript(addr)
+	if err != nil {
+		return err
+	}
+	m.AddScript(pkScript)
+	return nil
+}
+
+// AddPubKey 监视公钥的压缩和未压缩编码所在的 P2PK、P2PKH、P2WPKH 和 P2SH-P2WPKH 输出，
+// 以及以公钥为内部密钥的 BIP0086 密钥路径 P2TR 输出和公钥的 x-only 编码。
+func (m *Matcher) AddPubKey(pubKey *btcec.PublicKey) {
+	compressed := pubKey.SerializeCompressed()
+	uncompressed := pubKey.SerializeUncompressed()
+	compressedHash := hash160Key(compressed)
+	nestedScript, _ := payToWitnessPubKeyHashScript(compressedHash[:])
+
+	var xOnly, outputKey [32]byte
+	copy(xOnly[:], schnorr.SerializePubKey(pubKey))
+	copy(outputKey[:], schnorr.SerializePubKey(
+		ComputeTaprootKeyNoScript(pubKey),
+	))
+
+	m.Lock()
+	defer m.Unlock()
+
+	m.pubKeys[string(compressed)] = struct{}{}
+	m.pubKeys[string(uncompressed)] = struct{}{}
+	m.pubKeyHashes[compressedHash] = struct{}{}
+	m.pubKeyHashes[hash160Key(uncompressed)] = struct{}{}
+	m.scriptHashes[hash160Key(nestedScript)] = struct{}{}
+	m.xOnlyKeys[xOnly] = struct{}{}
+	m.xOnlyKeys[outputKey] = struct{}{}
+}
+
+// AddRedeemScript 监视以 script 为赎回脚本的 P2SH 输出、以其为见证脚本的 P2WSH 输出，以及嵌套在 P2SH 中的 P2WSH 输出。
+func (m *Matcher) AddRedeemScript(script []byte) {
+	witnessScriptHash := sha256.Sum256(script)
+	nestedScript, _ := payToWitnessScriptHashScript(witnessScriptHash[:])
+
+	m.Lock()
+	defer m.Unlock()
+
+	m.scriptHashes[hash160Key(script)] = struct{}{}
+	m.scriptHashes[hash160Key(nestedScript)] = struct{}{}
+	m.witnessScriptHashes[witnessScriptHash] = struct{}{}
+}
+
+// AddXOnlyKey 监视 32 字节的 x-only 公钥，它匹配以其为输出密钥的 P2TR 输出，
+// 以及以其为内部密钥或在叶子脚本中推送它的脚本路径花费。
+func (m *Matcher) AddXOnlyKey(key []byte) error {
+	if len(key) != schnorr.PubKeyBytesLen {
+		return fmt.Errorf("x-only key must be %d bytes, got %d",
+			schnorr.PubKeyBytesLen, len(key))
+	}
+
+	var xOnly [32]byte
+	copy(xOnly[:], key)
+
+	m.Lock()
+	m.xOnlyKeys[xOnly] = struct{}{}
+	m.Unlock()
+	return nil
+}
+
+// AddTapLeaf 监视揭示叶子的 taproot 脚本路径花费。
+func (m *Matcher) AddTapLeaf(leaf TapLeaf) {
+	leafHash := leaf.TapHash()
+
+	m.Lock()
+	m.tapLeaves[leafHash] = struct{}{}
+	m.Unlock()
+}
+
+// MatchPkScript 返回公钥脚本是否匹配任何被监视的项。
+func (m *Matcher) MatchPkScript(pkScript []byte) bool {
+	m.RLock()
+	defer m.RUnlock()
+
+	return m.matchPkScript(pkScript)
+}
+
+// matchPkScript 是 MatchPkScript 的实现，调用者必须持有读锁。
+func (m *Matcher) matchPkScript(pkScript []byte) bool {
+	if _, ok := m.pkScripts[string(pkScript)]; ok {
+		return true
+	}
+
+	if pubKey := extractPubKey(pkScript); pubKey != nil {
+		_, ok := m.pubKeys[string(pubKey)]
+		return ok
+	}
+	if hash := extractPubKeyHash(pkScript); hash != nil {
+		_, ok := m.pubKeyHashes[*(*[20]byte)(hash)]
+		return ok
+	}
+	if hash := extractWitnessPubKeyHash(pkScript); hash != nil {
+		_, ok := m.pubKeyHashes[*(*[20]byte)(hash)]
+		return ok
+	}
+	if hash := extractScriptHash(pkScript); hash != nil {
+		_, ok := m.scriptHashes[*(*[20]byte)(hash)]
+		return ok
+	}
+	if hash := extractWitnessV0ScriptHash(pkScript); hash != nil {
+		_, ok := m.witnessScriptHashes[*(*[32]byte)(hash)]
+		return ok
+	}
+	if key := extractWitnessV1KeyBytes(pkScript); key != nil {
+		_, ok := m.xOnlyKeys[*(*[32]byte)(key)]
+		return ok
+	}
+	return false
+}
+
+// MatchInput 返回签名脚本和见证是否花费了被监视的输出，适用于被花费的公钥脚本未知的情况。
+//
+// 签名脚本的最后一个推送被视为 P2SH 赎回脚本或 P2PKH 公钥。 版本 0 见证的最后一个元素被视为见证脚本或 P2WPKH 公钥；
+// 最后一个元素（不计附言）是有效控制块的见证被视为 taproot 脚本路径花费，匹配揭示的叶子、内部密钥以及叶子脚本推送的 x-only 公钥。
+func (m *Matcher) MatchInput(sigScript []byte, witness wire.TxWitness) bool {
+	m.RLock()
+	defer m.RUnlock()
+
+	return m.matchInput(sigScript, witness)
+}
+
+// matchInput 是 MatchInput 的实现，调用者必须持有读锁。
+func (m *Matcher) matchInput(sigScript []byte, witness wire.TxWitness) bool {
+	if len(sigScript) > 0 {
+		pushes, err := PushedData(sigScript)
+		if err == nil && len(pushes) > 0 {
+			last := pushes[len(pushes)-1]
+			if _, ok := m.scriptHashes[hash160Key(last)]; ok {
+				return true
+			}
+			if _, ok := m.pubKeys[string(last)]; ok {
+				return true
+			}
+		}
+	}
+
+	if len(witness) == 0 {
+		return false
+	}
+	last := witness[len(witness)-1]
+	if _, ok := m.witnessScriptHashes[sha256.Sum256(last)]; ok {
+		return true
+	}
+	if len(last) == btcec.PubKeyBytesLenCompressed {
+		if _, ok := m.pubKeyHashes[hash160Key(last)]; ok {
+			return true
+		}
+	}
+
+	return m.matchTapscriptSpend(witness)
+}
+
+// matchTapscriptSpend 返回 taproot 脚本路径花费的见证是否揭示了被监视的叶子、内部密钥或叶子脚本中的公钥。
+func (m *Matcher) matchTapscriptSpend(witness wire.TxWitness) bool {
+	if isAnnexedWitness(witness) {
+		witness = witness[:len(witness)-1]
+	}
+	if len(witness) < 2 {
+		return false
+	}
+	ctrlBlock, err := ParseControlBlock(witness[len(witness)-1])
+	if err != nil {
+		return false
+	}
+	script := witness[len(witness)-2]
+
+	leafHash := TapLeafHash(ctrlBlock.LeafVersion, script)
+	if _, ok := m.tapLeaves[leafHash]; ok {
+		return true
+	}
+	internalKey := schnorr.SerializePubKey(ctrlBlock.InternalKey)
+	if _, ok := m.xOnlyKeys[*(*[32]byte)(internalKey)]; ok {
+		return true
+	}
+
+	const scriptVersion = 0
+	tokenizer := MakeScriptTokenizer(scriptVersion, script)
+	for tokenizer.Next() {
+		data := tokenizer.Data()
+		if len(data) != schnorr.PubKeyBytesLen {
+			continue
+		}
+		if _, ok := m.xOnlyKeys[*(*[32]byte)(data)]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// MatchTx 返回交易是否有任何输出的公钥脚本匹配被监视的项，或者有任何输入按 MatchInput 的规则花费被监视的输出。
+func (m *Matcher) MatchTx(tx *wire.MsgTx) bool {
+	m.RLock()
+	defer m.RUnlock()
+
+	for _, txOut := range tx.TxOut {
+		if m.matchPkScript(txOut.PkScript) {
+			return true
+		}
+	}
+	for _, txIn := range tx.TxIn {
+		if m.matchInput(txIn.SignatureScript, txIn.Witness) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,180 @@
+package txscript
+
+import (
+	"crypto/sha256"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcec/v2/schnorr"
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/stretchr/testify/require"
+)
+
+// TestMatcherPkScripts 确保被监视的项匹配所有对应类型的公钥脚本，并且不匹配无关的脚本。
+func TestMatcherPkScripts(t *testing.T) {
+	t.Parallel()
+
+	privKey, err := btcec.NewPrivateKey()
+	require.NoError(t, err)
+	pubKey := privKey.PubKey()
+	otherKey, err := btcec.NewPrivateKey()
+	require.NoError(t, err)
+
+	redeemScript := mustParseShortForm("1 DROP 1")
+	xOnlyKey := schnorr.SerializePubKey(otherKey.PubKey())
+	addr, err := btcutil.NewAddressPubKeyHash(
+		make([]byte, 20), &chaincfg.MainNetParams,
+	)
+	require.NoError(t, err)
+
+	m := NewMatcher()
+	m.AddPubKey(pubKey)
+	m.AddRedeemScript(redeemScript)
+	require.NoError(t, m.AddXOnlyKey(xOnlyKey))
+	require.Error(t, m.AddXOnlyKey(xOnlyKey[1:]))
+	require.NoError(t, m.AddAddress(addr))
+	m.AddScript([]byte{OP_RETURN, OP_DATA_1, 0x01})
+
+	mustScript := func(script []byte, err error) []byte {
+		require.NoError(t, err)
+		return script
+	}
+	pubKeyHash := btcutil.Hash160(pubKey.SerializeCompressed())
+	witnessScriptHash := sha256.Sum256(redeemScript)
+	p2wpkh := mustScript(payToWitnessPubKeyHashScript(pubKeyHash))
+	p2wsh := mustScript(payToWitnessScriptHashScript(witnessScriptHash[:]))
+
+	matches := [][]byte{
+		mustScript(payToPubKeyScript(pubKey.SerializeCompressed())),
+		mustScript(payToPubKeyScript(pubKey.SerializeUncompressed())),
+		mustScript(payToPubKeyHashScript(pubKeyHash)),
+		mustScript(payToPubKeyHashScript(
+			btcutil.Hash160(pubKey.SerializeUncompressed()),
+		)),
+		p2wpkh,
+		mustScript(payToScriptHashScript(btcutil.Hash160(p2wpkh))),
+		mustScript(PayToTaprootScript(ComputeTaprootKeyNoScript(pubKey))),
+		mustScript(payToScriptHashScript(btcutil.Hash160(redeemScript))),
+		p2wsh,
+		mustScript(payToScriptHashScript(btcutil.Hash160(p2wsh))),
+		mustScript(payToWitnessTaprootScript(xOnlyKey)),
+		mustScript(PayToAddrScript(addr)),
+		{OP_RETURN, OP_DATA_1, 0x01},
+	}
+	for i, pkScript := range matches {
+		require.True(t, m.MatchPkScript(pkScript), "script %d", i)
+	}
+
+	otherHash := btcutil.Hash160(otherKey.PubKey().SerializeCompressed())
+	misses := [][]byte{
+		nil,
+		{OP_RETURN, OP_DATA_1, 0x02},
+		mustScript(payToPubKeyHashScript(otherHash)),
+		mustScript(payToWitnessPubKeyHashScript(otherHash)),
+		mustScript(payToScriptHashScript(otherHash)),
+		mustScript(PayToTaprootScript(
+			ComputeTaprootKeyNoScript(otherKey.PubKey()),
+		)),
+	}
+	for i, pkScript := range misses {
+		require.False(t, m.MatchPkScript(pkScript), "script %d", i)
+	}
+}
+
+// TestMatcherInputs 确保匹配器在被花费的公钥脚本未知时，从签名脚本和见证中识别花费被监视输出的输入。
+func TestMatcherInputs(t *testing.T) {
+	t.Parallel()
+
+	privKey, err := btcec.NewPrivateKey()
+	require.NoError(t, err)
+	pubKey := privKey.PubKey().SerializeCompressed()
+	redeemScript := mustParseShortForm("1 DROP 1")
+	sig := make([]byte, 71)
+
+	m := NewMatcher()
+	require.False(t, m.MatchInput(nil, nil))
+
+	// P2PKH 和 P2WPKH 花费揭示公钥。
+	p2pkhSigScript, err := NewScriptBuilder().AddData(sig).AddData(pubKey).
+		Script()
+	require.NoError(t, err)
+	p2wpkhWitness := wire.TxWitness{sig, pubKey}
+	require.False(t, m.MatchInput(p2pkhSigScript, nil))
+	require.False(t, m.MatchInput(nil, p2wpkhWitness))
+	m.AddPubKey(privKey.PubKey())
+	require.True(t, m.MatchInput(p2pkhSigScript, nil))
+	require.True(t, m.MatchInput(nil, p2wpkhWitness))
+
+	// P2SH 和 P2WSH 花费揭示赎回脚本。
+	p2shSigScript, err := NewScriptBuilder().AddData(redeemScript).Script()
+	require.NoError(t, err)
+	p2wshWitness := wire.TxWitness{redeemScript}
+	require.False(t, m.MatchInput(p2shSigScript, nil))
+	require.False(t, m.MatchInput(nil, p2wshWitness))
+	m.AddRedeemScript(redeemScript)
+	require.True(t, m.MatchInput(p2shSigScript, nil))
+	require.True(t, m.MatchInput(nil, p2wshWitness))
+}
+
+// TestMatcherTapscriptSpend 确保 taproot 脚本路径花费按揭示的叶子、内部密钥和叶子脚本中的公钥匹配。
+func TestMatcherTapscriptSpend(t *testing.T) {
+	t.Parallel()
+
+	internalKey, err := btcec.NewPrivateKey()
+	require.NoError(t, err)
+	leafKey, err := btcec.NewPrivateKey()
+	require.NoError(t, err)
+	leafKeyBytes := schnorr.SerializePubKey(leafKey.PubKey())
+
+	checkSigLeaf := NewBaseTapLeaf(append(
+		append([]byte{OP_DATA_32}, leafKeyBytes...), OP_CHECKSIG,
+	))
+	trueLeaf := NewBaseTapLeaf([]byte{OP_TRUE})
+	tree := AssembleTaprootScriptTree(checkSigLeaf, trueLeaf)
+
+	spendWitness := func(i int) wire.TxWitness {
+		proof := tree.LeafMerkleProofs[i]
+		ctrlBlock := proof.ToControlBlock(internalKey.PubKey())
+		ctrlBlockBytes, err := ctrlBlock.ToBytes()
+		require.NoError(t, err)
+		return wire.TxWitness{make([]byte, 64), proof.Script, ctrlBlockBytes}
+	}
+	checkSigSpend := spendWitness(0)
+	trueSpend := spendWitness(1)
+
+	m := NewMatcher()
+	require.False(t, m.MatchInput(nil, checkSigSpend))
+	require.False(t, m.MatchInput(nil, trueSpend))
+
+	// 叶子脚本推送的公钥。
+	require.NoError(t, m.AddXOnlyKey(leafKeyBytes))
+	require.True(t, m.MatchInput(nil, checkSigSpend))
+	require.False(t, m.MatchInput(nil, trueSpend))
+
+	// 被监视的叶子，附言不影响匹配。
+	m.AddTapLeaf(trueLeaf)
+	require.True(t, m.MatchInput(nil, trueSpend))
+	annexed := append(wire.TxWitness{}, trueSpend...)
+	annexed = append(annexed, []byte{TaprootAnnexTag, 0x01})
+	require.True(t, m.MatchInput(nil, annexed))
+
+	// 内部密钥匹配树中的所有叶子。
+	m = NewMatcher()
+	m.AddPubKey(internalKey.PubKey())
+	require.True(t, m.MatchInput(nil, trueSpend))
+
+	// MatchTx 检查所有输入和输出。
+	tx := wire.NewMsgTx(2)
+	tx.AddTxIn(&wire.TxIn{Witness: trueSpend})
+	tx.AddTxOut(wire.NewTxOut(1000, []byte{OP_TRUE}))
+	require.True(t, m.MatchTx(tx))
+	require.False(t, NewMatcher().MatchTx(tx))
+
+	pkScript, err := PayToTaprootKeySpendOnlyScript(internalKey.PubKey())
+	require.NoError(t, err)
+	tx.TxIn[0].Witness = nil
+	tx.TxOut[0].PkScript = pkScript
+	require.True(t, m.MatchTx(tx))
+}
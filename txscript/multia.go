@@ -0,0 +1,395 @@
+// 包含 tapscript 多重签名描述符 multi_a 和 sortedmulti_a（BIP0387），用于生成基于 OP_CHECKSIGADD 的叶子及其地址，
+// 并将现有的叶子解析回描述符形式。
+
+package txscript
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcec/v2/schnorr"
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/chaincfg"
+)
+
+// MaxPubKeysPerMultiA 是 multi_a 描述符允许的最大公钥数。
+const MaxPubKeysPerMultiA = 999
+
+// ErrInvalidMultiA 在 multi_a 描述符或叶子无效时返回。
+var ErrInvalidMultiA = errors.New("invalid multi_a descriptor")
+
+// MultiADescriptor 是 multi_a 或 sortedmulti_a 描述符，对应的 tapscript 叶子为：
+//
+//	<key 1> CHECKSIG <key 2> CHECKSIGADD ... <key n> CHECKSIGADD <threshold> NUMEQUAL
+//
+// 对于 sortedmulti_a，叶子中的公钥按字节序排序。
+type MultiADescriptor struct {
+	// Threshold 是花费所需的签名数。
+	Threshold int
+
+	// PubKeys 是 32 字节的 x-only 公钥，顺序与描述符相同。
+	PubKeys [][]byte
+
+	// Sorted 表示描述符是 sortedmulti_a。
+	Sorted bool
+}
+
+// NewMultiADescriptor 返回使用给定公钥的 multi_a 描述符，sorted 为 true 时返回 sortedmulti_a 描述符。
+func NewMultiADescriptor(threshold int, pubKeys []*btcec.PublicKey,
+	sorted bool) (*MultiADescriptor, error) {
+
+	keys := make([][]byte, 0, len(pubKeys))
+	for _, pubKey := range pubKeys {
+		keys = append(keys, schnorr.SerializePubKey(pubKey))
+	}
+	d := &MultiADescriptor{Threshold: threshold, PubKeys: keys, Sorted: sorted}
+	if err := d.validate(); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+// validate 检查阈值和公钥是否有效。
+func (d *MultiADescriptor) validate() error {
+	n := len(d.PubKeys)
+	if n == 0 || n > MaxPubKeysPerMultiA {
+		return fmt.Errorf("%w: %d keys, must be between 1 and %d",
+			ErrInvalidMultiA, n, MaxPubKeysPerMultiA)
+	}
+	if d.Threshold < 1 || d.Threshold > n {
+		return fmt.Errorf("%w: threshold %d, must be between 1 and %d",
+			ErrInvalidMultiA, d.Threshold, n)
+	}
+
+	seen := make(map[string]struct{}, n)
+	for _, key := range d.PubKeys {
+		if _, err := schnorr.ParsePubKey(key); err != nil {
+			return fmt.Errorf("%w: invalid key %x: %v",
+				ErrInvalidMultiA, key, err)
+		}
+		if _, ok := seen[string(key)]; ok {
+			return fmt.Errorf("%w: duplicate key %x", ErrInvalidMultiA,
+				key)
+		}
+		seen[string(key)] = struct{}{}
+	}
+	return nil
+}
+
+// scriptKeys 返回叶子中公钥的顺序。
+func (d *MultiADescriptor) scriptKeys() [][]byte {
+	if !d.Sorted {
+		return d.PubKeys
+	}
+	keys := make([][]byte, len(d.PubKeys))
+	copy(keys, d.PubKeys)
+	sortPubKeys(keys)
+	return keys
+}
+
+// Script 返回描述符对应的 tapscript 叶子脚本。
+func (d *MultiADescriptor) Script() ([]byte, error) {
+	if err := d.validate(); err != nil {
+		return nil, err
+	}
+
+	builder := NewScriptBuilder()
+	for i, key := range d.scriptKeys() {
+		builder.AddData(key)
+		if i == 0 {
+			builder.AddOp(OP_CHECKSIG)
+		} else {
+			builder.AddOp(OP_CHECKSIGADD)
+		}
+	}
+	return builder.AddInt64(int64(d.Threshold)).AddOp(OP_NUMEQUAL).Script()
+}
+
+// Leaf 返回描述符对应的 tapscript 叶子。
+func (d *MultiADescriptor) Leaf() (TapLeaf, error) {
+	script, err := d.Script()
+	if err != nil {
+		return TapLeaf{}, err
+	}
+	return NewBaseTapLeaf(script), nil
+}
+
+// OutputKey 返回内部密钥为 internalKey、只有描述符叶子的 taproot 输出密钥。
+func (d *MultiADescriptor) OutputKey(
+	internalKey *btcec.PublicKey) (*btcec.PublicKey, error) {
+
+	leaf, err := d.Leaf()
+	if err != nil {
+		return nil, err
+	}
+	tapHash := leaf.TapHash()
+	return ComputeTaprootOutputKey(internalKey, tapHash[:]), nil
+}
+
+// Address 返回描述符 tr(internalKey, d) 的地址。
+func (d *MultiADescriptor) Address(internalKey *btcec.PublicKey,
+	params *chaincfg.Params) (*btcutil.AddressTaproot, error) {
+
+	outputKey, err := d.OutputKey(internalKey)
+	if err != nil {
+		return nil, err
+	}
+	return btcutil.NewAddressTaproot(
+		schnorr.SerializePubKey(outputKey), params,
+	)
+}
+
+// String 返回描述符片段，例如 "multi_a(2,<key 1>,<key 2>)"，公钥以十六进制编码。
+func (d *MultiADescriptor) String() string {
+	var buf strings.Builder
+	if d.Sorted {
+		buf.WriteString("sortedmulti_a(")
+	} else {
+		buf.WriteString("multi_a(")
+	}
+	buf.WriteString(strconv.Itoa(d.Threshold))
+	for _, key := range d.PubKeys {
+		buf.WriteByte(',')
+		buf.WriteString(hex.EncodeToString(key))
+	}
+	buf.WriteByte(')')
+	return buf.String()
+}
+
+// TaprootDescriptor 返回附加校验和的完整描述符 tr(internalKey, d)#checksum。
+func (d *MultiADescriptor) TaprootDescriptor(
+	internalKey *btcec.PublicKey) (string, error) {
+
+	if err := d.validate(); err != nil {
+		return "", err
+	}
+	desc := fmt.Sprintf("tr(%x,%v)", schnorr.SerializePubKey(internalKey),
+		d)
+	checksum, err := descriptorChecksum(desc)
+	if err != nil {
+		return "", err
+	}
+	return desc + "#" + checksum, nil
+}
+
+// parseDescriptorKey 解析十六进制编码的公钥，可以是 32 字节的 x-only 公钥或 33 字节的压缩公钥。
+func parseDescriptorKey(s string) (*btcec.PublicKey, error) {
+	key, err := hex.DecodeString(s)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid key %q", ErrInvalidMultiA, s)
+	}
+	var pubKey *btcec.PublicKey
+	switch len(key) {
+	case schnorr.PubKeyBytesLen:
+		pubKey, err = schnorr.ParsePubKey(key)
+	case btcec.PubKeyBytesLenCompressed:
+		pubKey, err = btcec.ParsePubKey(key)
+	default:
+		return nil, fmt.Errorf("%w: key %q must be 32 or 33 bytes",
+			ErrInvalidMultiA, s)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid key %q: %v", ErrInvalidMultiA,
+			s, err)
+	}
+	return pubKey, nil
+}
+
+// ParseMultiADescriptor 解析 multi_a 或 sortedmulti_a 描述符片段。 公钥可以是十六进制编码的 x-only 公钥或压缩公钥，
+// 压缩公钥会被转换为 x-only 公钥。
+func ParseMultiADescriptor(desc string) (*MultiADescriptor, error) {
+	var d MultiADescriptor
+	var args string
+	switch {
+	case strings.HasPrefix(desc, "multi_a(") && strings.HasSuffix(desc, ")"):
+		args = desc[len("multi_a(") : len(desc)-1]
+	case strings.HasPrefix(desc, "sortedmulti_a(") &&
+		strings.HasSuffix(desc, ")"):
+
+		args = desc[len("sortedmulti_a(") : len(desc)-1]
+		d.Sorted = true
+	default:
+		return nil, fmt.Errorf("%w: %q is not a multi_a or sortedmulti_a "+
+			"expression", ErrInvalidMultiA, desc)
+	}
+
+	fields := strings.Split(args, ",")
+	threshold, err := strconv.Atoi(fields[0])
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid threshold %q",
+			ErrInvalidMultiA, fields[0])
+	}
+	d.Threshold = threshold
+	for _, field := range fields[1:] {
+		pubKey, err := parseDescriptorKey(field)
+		if err != nil {
+			return nil, err
+		}
+		d.PubKeys = append(d.PubKeys, schnorr.SerializePubKey(pubKey))
+	}
+
+	if err := d.validate(); err != nil {
+		return nil, err
+	}
+	return &d, nil
+}
+
+// ParseTaprootMultiADescriptor 解析只有一个 multi_a 或 sortedmulti_a 叶子的描述符 tr(KEY,SCRIPT)，并返回内部密钥和叶子的描述符。
+// 描述符带有校验和时会验证校验和。
+func ParseTaprootMultiADescriptor(
+	desc string) (*btcec.PublicKey, *MultiADescriptor, error) {
+
+	if idx := strings.IndexByte(desc, '#'); idx >= 0 {
+		checksum, err := descriptorChecksum(desc[:idx])
+		if err != nil {
+			return nil, nil, err
+		}
+		if desc[idx+1:] != checksum {
+			return nil, nil, fmt.Errorf("%w: checksum mismatch, "+
+				"expected %s", ErrInvalidMultiA, checksum)
+		}
+		desc = desc[:idx]
+	}
+
+	if !strings.HasPrefix(desc, "tr(") || !strings.HasSuffix(desc, ")") {
+		return nil, nil, fmt.Errorf("%w: %q is not a tr expression",
+			ErrInvalidMultiA, desc)
+	}
+	args := desc[len("tr(") : len(desc)-1]
+	sep := strings.IndexByte(args, ',')
+	if sep < 0 {
+		return nil, nil, fmt.Errorf("%w: tr expression has no script",
+			ErrInvalidMultiA)
+	}
+
+	internalKey, err := parseDescriptorKey(args[:sep])
+	if err != nil {
+		return nil, nil, err
+	}
+	d, err := ParseMultiADescriptor(args[sep+1:])
+	if err != nil {
+		return nil, nil, err
+	}
+	return internalKey, d, nil
+}
+
+// ParseMultiALeaf 将 multi_a 形式的 tapscript 叶子脚本解析回描述符。 返回的描述符是 multi_a，公钥顺序与叶子相同；
+// 叶子中的公钥已排序时，它也等价于使用相同公钥的 sortedmulti_a 描述符。
+func ParseMultiALeaf(script []byte) (*MultiADescriptor, error) {
+	const scriptVersion = 0
+	var tokens []requirementToken
+	tokenizer := MakeScriptTokenizer(scriptVersion, script)
+	for tokenizer.Next() {
+		tokens = append(tokens, requirementToken{
+			op:   tokenizer.Opcode(),
+			data: tokenizer.Data(),
+		})
+	}
+	if err := tokenizer.Err(); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidMultiA, err)
+	}
+	if len(tokens) < 4 || len(tokens)%2 != 0 ||
+		tokens[len(tokens)-1].op != OP_NUMEQUAL {
+
+		return nil, fmt.Errorf("%w: not a multi_a leaf", ErrInvalidMultiA)
+	}
+
+	var d MultiADescriptor
+	for i := 0; i < len(tokens)-2; i += 2 {
+		checkOp := byte(OP_CHECKSIGADD)
+		if i == 0 {
+			checkOp = OP_CHECKSIG
+		}
+		if tokens[i].op != OP_DATA_32 || tokens[i+1].op != checkOp {
+			return nil, fmt.Errorf("%w: not a multi_a leaf",
+				ErrInvalidMultiA)
+		}
+		d.PubKeys = append(d.PubKeys, tokens[i].data)
+	}
+	threshold, ok := requirementNum(tokens, len(tokens)-2)
+	if !ok || threshold > MaxPubKeysPerMultiA {
+		return nil, fmt.Errorf("%w: invalid threshold", ErrInvalidMultiA)
+	}
+	d.Threshold = int(threshold)
+
+	// Rebuilding the script validates the threshold and keys and ensures
+	// the leaf uses the canonical encoding.
+	expected, err := d.Script()
+	if err != nil {
+		return nil, err
+	}
+	if !bytes.Equal(expected, script) {
+		return nil, fmt.Errorf("%w: non-canonical leaf encoding",
+			ErrInvalidMultiA)
+	}
+	return &d, nil
+}
+
+const (
+	// descriptorInputCharset 是描述符校验和使用的输入字符集（BIP0380）。
+	descriptorInputCharset = "0123456789()[],'/*abcdefgh@:$%{}" +
+		"IJKLMNOPQRSTUVWXYZ&+-.;<=>?!^_|~" +
+		"ijklmnopqrstuvwxyzABCDEFGH`#\"\\ "
+
+	// descriptorChecksumCharset 是描述符校验和的输出字符集。
+	descriptorChecksumCharset = "qpzry9x8gf2tvdw0s3jn54khce6mua7l"
+)
+
+// descriptorPolyMod 是描述符校验和使用的 BCH 码的一步计算。
+func descriptorPolyMod(c uint64, val int) uint64 {
+	c0 := c >> 35
+	c = ((c & 0x7ffffffff) << 5) ^ uint64(val)
+	if c0&1 != 0 {
+		c ^= 0xf5dee51989
+	}
+	if c0&2 != 0 {
+		c ^= 0xa9fdca3312
+	}
+	if c0&4 != 0 {
+		c ^= 0x1bab10e32d
+	}
+	if c0&8 != 0 {
+		c ^= 0x3706b1677a
+	}
+	if c0&16 != 0 {
+		c ^= 0x644d626ffd
+	}
+	return c
+}
+
+// descriptorChecksum 返回不含校验和的描述符的 8 字符校验和（BIP0380）。
+func descriptorChecksum(desc string) (string, error) {
+	c := uint64(1)
+	cls, clsCount := 0, 0
+	for _, ch := range desc {
+		pos := strings.IndexRune(descriptorInputCharset, ch)
+		if pos < 0 {
+			return "", fmt.Errorf("%w: invalid character %q",
+				ErrInvalidMultiA, ch)
+		}
+		c = descriptorPolyMod(c, pos&31)
+		cls = cls*3 + pos>>5
+		if clsCount++; clsCount == 3 {
+			c = descriptorPolyMod(c, cls)
+			cls, clsCount = 0, 0
+		}
+	}
+	if clsCount > 0 {
+		c = descriptorPolyMod(c, cls)
+	}
+	for i := 0; i < 8; i++ {
+		c = descriptorPolyMod(c, 0)
+	}
+	c ^= 1
+
+	checksum := make([]byte, 8)
+	for i := range checksum {
+		checksum[i] = descriptorChecksumCharset[(c>>(5*(7-i)))&31]
+	}
+	return string(checksum), nil
+}
@@ -0,0 +1,235 @@
+package txscript
+
+import (
+	"bytes"
+	"encoding/hex"
+	"strings"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcec/v2/schnorr"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/stretchr/testify/require"
+)
+
+// TestDescriptorChecksum 确保描述符校验和与 BIP0380 的测试向量一致。
+func TestDescriptorChecksum(t *testing.T) {
+	t.Parallel()
+
+	checksum, err := descriptorChecksum("raw(deadbeef)")
+	require.NoError(t, err)
+	require.Equal(t, "89f8spxm", checksum)
+
+	checksum, err = descriptorChecksum(
+		"addr(mkmZxiEcEd8ZqjQWVZuC6so5dFMKEFpN2j)",
+	)
+	require.NoError(t, err)
+	require.Equal(t, "02wpgw69", checksum)
+
+	_, err = descriptorChecksum("raw(\n)")
+	require.ErrorIs(t, err, ErrInvalidMultiA)
+}
+
+// TestMultiADescriptor 确保 multi_a 描述符可以生成叶子、地址和描述符字符串，并且可以从这些形式解析回来。
+func TestMultiADescriptor(t *testing.T) {
+	t.Parallel()
+
+	var pubKeys []*btcec.PublicKey
+	for i := 0; i < 3; i++ {
+		privKey, err := btcec.NewPrivateKey()
+		require.NoError(t, err)
+		pubKeys = append(pubKeys, privKey.PubKey())
+	}
+	xOnly := func(i int) string {
+		return hex.EncodeToString(schnorr.SerializePubKey(pubKeys[i]))
+	}
+
+	desc, err := NewMultiADescriptor(2, pubKeys, false)
+	require.NoError(t, err)
+	require.Equal(t, "multi_a(2,"+xOnly(0)+","+xOnly(1)+","+xOnly(2)+")",
+		desc.String())
+
+	script, err := desc.Script()
+	require.NoError(t, err)
+	require.Equal(t, mustParseShortForm(
+		"DATA_32 0x"+xOnly(0)+" CHECKSIG "+
+			"DATA_32 0x"+xOnly(1)+" CHECKSIGADD "+
+			"DATA_32 0x"+xOnly(2)+" CHECKSIGADD 2 NUMEQUAL",
+	), script)
+
+	parsed, err := ParseMultiALeaf(script)
+	require.NoError(t, err)
+	require.Equal(t, desc, parsed)
+
+	// sortedmulti_a 按字节序排列叶子中的公钥，但描述符保留原始顺序。
+	sortedDesc, err := NewMultiADescriptor(2, pubKeys, true)
+	require.NoError(t, err)
+	require.True(t, strings.HasPrefix(sortedDesc.String(), "sortedmulti_a(2,"+
+		xOnly(0)))
+	sortedScript, err := sortedDesc.Script()
+	require.NoError(t, err)
+	parsed, err = ParseMultiALeaf(sortedScript)
+	require.NoError(t, err)
+	require.False(t, parsed.Sorted)
+	for i := 1; i < len(parsed.PubKeys); i++ {
+		require.Negative(t, bytes.Compare(
+			parsed.PubKeys[i-1], parsed.PubKeys[i],
+		))
+	}
+
+	// 地址与使用单叶子树计算的地址相同。
+	internalKey := pubKeys[0]
+	leaf, err := desc.Leaf()
+	require.NoError(t, err)
+	tree := AssembleTaprootScriptTree(leaf)
+	rootHash := tree.RootNode.TapHash()
+	outputKey := ComputeTaprootOutputKey(internalKey, rootHash[:])
+	addr, err := desc.Address(internalKey, &chaincfg.MainNetParams)
+	require.NoError(t, err)
+	require.Equal(t, schnorr.SerializePubKey(outputKey), addr.ScriptAddress())
+
+	// 完整的描述符可以解析回来，压缩公钥被转换为 x-only 公钥。
+	full, err := desc.TaprootDescriptor(internalKey)
+	require.NoError(t, err)
+	require.Regexp(t, `^tr\([0-9a-f]{64},multi_a\(.*\)\)#[a-z0-9]{8}$`, full)
+	gotKey, gotDesc, err := ParseTaprootMultiADescriptor(full)
+	require.NoError(t, err)
+	require.Equal(t, xOnlyBytes(internalKey), xOnlyBytes(gotKey))
+	require.Equal(t, desc, gotDesc)
+
+	compressed := hex.EncodeToString(pubKeys[1].SerializeCompressed())
+	gotKey, gotDesc, err = ParseTaprootMultiADescriptor(
+		"tr(" + xOnly(0) + ",sortedmulti_a(1," + compressed + "))",
+	)
+	require.NoError(t, err)
+	require.Equal(t, xOnlyBytes(internalKey), xOnlyBytes(gotKey))
+	require.Equal(t, &MultiADescriptor{
+		Threshold: 1,
+		PubKeys:   [][]byte{xOnlyBytes(pubKeys[1])},
+		Sorted:    true,
+	}, gotDesc)
+
+	// 无效的描述符和叶子。
+	k0, k1 := xOnly(0), xOnly(1)
+	invalid := []string{
+		"multi_a(0," + k0 + ")",
+		"multi_a(2," + k0 + ")",
+		"multi_a(1," + k0 + "," + k0 + ")",
+		"multi_a(1)",
+		"multi_a(x," + k0 + ")",
+		"multi_a(1," + k0[2:] + ")",
+		"multi_a(1,zz)",
+		"multi(1," + k0 + ")",
+		"sortedmulti_a(1," + k0 + "," + k1,
+	}
+	for _, d := range invalid {
+		_, err := ParseMultiADescriptor(d)
+		require.ErrorIs(t, err, ErrInvalidMultiA, d)
+	}
+	// Replace the last checksum character with a different one, the
+	// checksum of the random keys may already end in 'q'.
+	badChecksum := full[:len(full)-1] + "q"
+	if badChecksum == full {
+		badChecksum = full[:len(full)-1] + "p"
+	}
+	for _, d := range []string{
+		badChecksum,
+		"tr(" + k0 + ")",
+		"wsh(multi_a(1," + k0 + "))",
+	} {
+		_, _, err := ParseTaprootMultiADescriptor(d)
+		require.ErrorIs(t, err, ErrInvalidMultiA, d)
+	}
+	for _, s := range []string{
+		"DATA_32 0x" + k0 + " CHECKSIG 1 NUMEQUALVERIFY",
+		"DATA_32 0x" + k0 + " CHECKSIGADD 1 NUMEQUAL",
+		"DATA_32 0x" + k0 + " CHECKSIG 2 NUMEQUAL",
+		"DATA_32 0x" + k0 + " CHECKSIG DATA_1 0x01 NUMEQUAL",
+		"DATA_32 0x" + k0 + " CHECKSIG DATA_32 0x" + k0 +
+			" CHECKSIGADD 1 NUMEQUAL",
+	} {
+		_, err := ParseMultiALeaf(mustParseShortForm(s))
+		require.ErrorIs(t, err, ErrInvalidMultiA, s)
+	}
+}
+
+// xOnlyBytes 返回公钥的 x-only 序列化。
+func xOnlyBytes(pubKey *btcec.PublicKey) []byte {
+	return schnorr.SerializePubKey(pubKey)
+}
+
+// TestMultiALeafSpend 确保 multi_a 叶子可以使用达到阈值的签名通过脚本路径花费。
+func TestMultiALeafSpend(t *testing.T) {
+	t.Parallel()
+
+	var privKeys []*btcec.PrivateKey
+	var pubKeys []*btcec.PublicKey
+	for i := 0; i < 3; i++ {
+		privKey, err := btcec.NewPrivateKey()
+		require.NoError(t, err)
+		privKeys = append(privKeys, privKey)
+		pubKeys = append(pubKeys, privKey.PubKey())
+	}
+	internalKey, err := btcec.NewPrivateKey()
+	require.NoError(t, err)
+
+	desc, err := NewMultiADescriptor(2, pubKeys, false)
+	require.NoError(t, err)
+	leaf, err := desc.Leaf()
+	require.NoError(t, err)
+	outputKey, err := desc.OutputKey(internalKey.PubKey())
+	require.NoError(t, err)
+	pkScript, err := PayToTaprootScript(outputKey)
+	require.NoError(t, err)
+
+	tree := AssembleTaprootScriptTree(leaf)
+	ctrlBlock := tree.LeafMerkleProofs[0].ToControlBlock(
+		internalKey.PubKey(),
+	)
+	ctrlBytes, err := ctrlBlock.ToBytes()
+	require.NoError(t, err)
+
+	const amt = 1e6
+	prevFetcher := NewCannedPrevOutputFetcher(pkScript, amt)
+	tx := createSpendingTx(nil, nil, pkScript, amt)
+	sigHashes := NewTxSigHashes(tx, prevFetcher)
+	sign := func(i int) []byte {
+		sig, err := RawTxInTapscriptSignature(
+			tx, sigHashes, 0, amt, pkScript, leaf, SigHashDefault,
+			privKeys[i],
+		)
+		require.NoError(t, err)
+		return sig
+	}
+
+	// 第一个公钥的签名位于栈顶，因此见证中签名的顺序与公钥相反。
+	for _, test := range []struct {
+		signers []bool
+		valid   bool
+	}{
+		{[]bool{true, false, true}, true},
+		{[]bool{true, true, true}, false},
+		{[]bool{false, true, false}, false},
+	} {
+		var witness wire.TxWitness
+		for i := len(privKeys) - 1; i >= 0; i-- {
+			if test.signers[i] {
+				witness = append(witness, sign(i))
+			} else {
+				witness = append(witness, nil)
+			}
+		}
+		tx.TxIn[0].Witness = append(witness, leaf.Script, ctrlBytes)
+
+		vm, err := NewEngine(pkScript, tx, 0, StandardVerifyFlags, nil,
+			sigHashes, amt, prevFetcher)
+		require.NoError(t, err)
+		err = vm.Execute()
+		if test.valid {
+			require.NoError(t, err)
+		} else {
+			require.Error(t, err)
+		}
+	}
+}
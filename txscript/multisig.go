@@ -0,0 +1,345 @@
+// 包含多重签名签名会话，用于跨多个设备交互式地收集传统和 P2WSH 多重签名的部分签名。
+
+package txscript
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcec/v2/ecdsa"
+	"github.com/btcsuite/btcd/wire"
+)
+
+var (
+	// ErrUnknownMultisigKey 在部分签名所属的公钥不在多重签名脚本中时返回。
+	ErrUnknownMultisigKey = errors.New("public key is not part of the " +
+		"multisig script")
+
+	// ErrInvalidMultisigSignature 在部分签名无法解析或无法针对对应的签名哈希通过验证时返回。
+	ErrInvalidMultisigSignature = errors.New("invalid multisig partial " +
+		"signature")
+
+	// ErrMultisigIncomplete 在收集到的签名数量尚未达到阈值时请求最终脚本返回。
+	ErrMultisigIncomplete = errors.New("multisig signature threshold not " +
+		"met")
+
+	// ErrMultisigWitnessMismatch 在待合并的见证不是花费给定多重签名脚本的 P2WSH 见证时返回。
+	ErrMultisigWitnessMismatch = errors.New("witness does not spend the " +
+		"multisig script")
+)
+
+// MultisigSpendType 表示多重签名脚本被花费时的封装方式。
+type MultisigSpendType uint8
+
+const (
+	// MultisigBare 表示多重签名脚本直接作为公钥脚本。
+	MultisigBare MultisigSpendType = iota
+
+	// MultisigP2SH 表示多重签名脚本作为 P2SH 的赎回脚本。
+	MultisigP2SH
+
+	// MultisigP2WSH 表示多重签名脚本作为 P2WSH 的见证脚本。
+	MultisigP2WSH
+)
+
+// MultisigSession 跟踪一个多重签名输入的签名进度。 每个部分签名在加入时都会针对正确的签名哈希进行验证，
+// 并在达到阈值后按照脚本中的公钥顺序生成最终的签名脚本或见证。
+//
+// MultisigSession 不是并发安全的。
+type MultisigSession struct {
+	tx        *wire.MsgTx
+	idx       int
+	script    []byte
+	spendType MultisigSpendType
+	sigHashes *TxSigHashes
+	amount    int64
+
+	pubKeys  [][]byte
+	required int
+
+	// sigs 按公钥在脚本中的位置保存已验证的签名（附加了 sighash 类型），未签名的位置为 nil。
+	sigs [][]byte
+}
+
+// NewMultisigSession 为花费 tx 的输入 idx 创建一个新的签名会话。 script 是标准多重签名脚本本身（裸脚本、赎回脚本或见证脚本）。
+// 对于 MultisigP2WSH，sigHashes 和 amount 用于计算 BIP0143 签名哈希；其他类型会忽略它们。
+func NewMultisigSession(tx *wire.MsgTx, idx int, script []byte,
+	spendType MultisigSpendType, sigHashes *TxSigHashes,
+	amount int64) (*MultisigSession, error) {
+
+	if idx < 0 || idx >= len(tx.TxIn) {
+		return nil, fmt.Errorf("transaction input index %d is out of "+
+			"range", idx)
+	}
+
+	const scriptVersion = 0
+	details := extractMultisigScriptDetails(scriptVersion, script, true)
+	if !details.valid {
+		return nil, scriptError(ErrNotMultisigScript,
+			"script is not a standard multisig script")
+	}
+
+	if spendType == MultisigP2WSH && sigHashes == nil {
+		sigHashes = NewTxSigHashes(tx, NewCannedPrevOutputFetcher(
+			nil, amount,
+		))
+	}
+
+	return &MultisigSession{
+		tx:        tx,
+		idx:       idx,
+		script:    script,
+		spendType: spendType,
+		sigHashes: sigHashes,
+		amount:    amount,
+		pubKeys:   details.pubKeys,
+		required:  details.requiredSigs,
+		sigs:      make([][]byte, len(details.pubKeys)),
+	}, nil
+}
+
+// SigHash 返回签名者使用 hashType 对该输入签名时需要签署的哈希。
+func (s *MultisigSession) SigHash(hashType SigHashType) ([]byte, error) {
+	if s.spendType == MultisigP2WSH {
+		return calcWitnessSignatureHashRaw(
+			s.script, s.sigHashes, hashType, s.tx, s.idx, s.amount,
+			BitcoinChainTag,
+		)
+	}
+	return CalcSignatureHash(s.script, hashType, s.tx, s.idx)
+}
+
+// AddSignature 验证 pubKey 的部分签名并将其加入会话。 sig 必须是附加了 sighash 类型的 DER 签名。
+// 如果该公钥已经签名，新签名将替换旧签名。
+func (s *MultisigSession) AddSignature(pubKey, sig []byte) error {
+	keyIdx := -1
+	for i, key := range s.pubKeys {
+		if bytes.Equal(key, pubKey) {
+			keyIdx = i
+			break
+		}
+	}
+	if keyIdx == -1 {
+		return ErrUnknownMultisigKey
+	}
+
+	if len(sig) < 1 {
+		return fmt.Errorf("%w: empty signature",
+			ErrInvalidMultisigSignature)
+	}
+	hashType := SigHashType(sig[len(sig)-1])
+	parsedSig, err := ecdsa.ParseDERSignature(sig[:len(sig)-1])
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidMultisigSignature, err)
+	}
+	parsedKey, err := btcec.ParsePubKey(pubKey)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidMultisigSignature, err)
+	}
+
+	hash, err := s.SigHash(hashType)
+	if err != nil {
+		return err
+	}
+	if !parsedSig.Verify(hash, parsedKey) {
+		return fmt.Errorf("%w: signature does not verify for key %x",
+			ErrInvalidMultisigSignature, pubKey)
+	}
+
+	s.sigs[keyIdx] = append([]byte(nil), sig...)
+	return nil
+}
+
+// PubKeys 返回脚本中的公钥，顺序与脚本一致。
+func (s *MultisigSession) PubKeys() [][]byte {
+	return s.pubKeys
+}
+
+// RequiredSigs 返回脚本要求的签名数。
+func (s *MultisigSession) RequiredSigs() int {
+	return s.required
+}
+
+// HasSigned 返回 pubKey 是否已经提供了有效的签名。
+func (s *MultisigSession) HasSigned(pubKey []byte) bool {
+	for i, key := range s.pubKeys {
+		if bytes.Equal(key, pubKey) {
+			return s.sigs[i] != nil
+		}
+	}
+	return false
+}
+
+// NumSigned 返回已经提供有效签名的公钥数。
+func (s *MultisigSession) NumSigned() int {
+	var n int
+	for _, sig := range s.sigs {
+		if sig != nil {
+			n++
+		}
+	}
+	return n
+}
+
+// Complete 返回收集到的签名是否已经达到阈值。
+func (s *MultisigSession) Complete() bool {
+	return s.NumSigned() >= s.required
+}
+
+// orderedSigs 按脚本中的公钥顺序返回前 required 个签名。
+func (s *MultisigSession) orderedSigs() ([][]byte, error) {
+	if !s.Complete() {
+		return nil, fmt.Errorf("%w: have %d of %d signatures",
+			ErrMultisigIncomplete, s.NumSigned(), s.required)
+	}
+
+	sigs := make([][]byte, 0, s.required)
+	for _, sig := range s.sigs {
+		if sig == nil {
+			continue
+		}
+		sigs = append(sigs, sig)
+		if len(sigs) == s.required {
+			break
+		}
+	}
+	return sigs, nil
+}
+
+// SignatureScript 返回最终的签名脚本。 对于 MultisigP2SH，赎回脚本会作为最后一个推送附加；对于 MultisigP2WSH，返回空脚本。
+func (s *MultisigSession) SignatureScript() ([]byte, error) {
+	sigs, err := s.orderedSigs()
+	if err != nil {
+		return nil, err
+	}
+	if s.spendType == MultisigP2WSH {
+		return nil, nil
+	}
+
+	// The extra OP_FALSE works around the off-by-one bug in the reference
+	// implementation of OP_CHECKMULTISIG.
+	builder := NewScriptBuilder().AddOp(OP_FALSE)
+	for _, sig := range sigs {
+		builder.AddData(sig)
+	}
+	if s.spendType == MultisigP2SH {
+		builder.AddData(s.script)
+	}
+	return builder.Script()
+}
+
+// Witness 返回最终的见证。 对于非 MultisigP2WSH 的会话，返回 nil 见证。
+func (s *MultisigSession) Witness() (wire.TxWitness, error) {
+	sigs, err := s.orderedSigs()
+	if err != nil {
+		return nil, err
+	}
+	if s.spendType != MultisigP2WSH {
+		return nil, nil
+	}
+
+	witness := make(wire.TxWitness, 0, len(sigs)+2)
+	witness = append(witness, nil)
+	witness = append(witness, sigs...)
+	witness = append(witness, s.script)
+	return witness, nil
+}
+
+// MergeWitnesses 合并花费 P2WSH 多重签名输出的两个部分签名的见证，是传统签名脚本合并的见证版本 0 对应物。
+// script 可以是多重签名见证脚本本身，也可以是 P2WSH 公钥脚本，后者从见证中取出见证脚本并检查其哈希。
+// sigHashes 和 amount 用于计算 BIP0143 签名哈希。
+//
+// 两个见证中的每个签名都针对脚本中的每个公钥和签名自身的 sighash 类型进行验证，无法解析或无法通过验证的签名被丢弃，
+// 同一公钥的重复签名只保留一个。 合并后的见证按公钥在脚本中的顺序排列签名，最多保留所需数量的签名，
+// 不足时以空元素补齐，格式为 [空元素, 签名..., 见证脚本]，达到阈值后即可直接用于花费。
+// 两个见证都为空时返回 nil。 见证的最后一个元素与见证脚本不符时返回 ErrMultisigWitnessMismatch。
+func MergeWitnesses(tx *wire.MsgTx, idx int, script []byte,
+	sigHashes *TxSigHashes, amount int64, existing,
+	incoming wire.TxWitness) (wire.TxWitness, error) {
+
+	if len(existing) == 0 && len(incoming) == 0 {
+		return nil, nil
+	}
+
+	// When given the P2WSH output script, the witness script is revealed
+	// by the witnesses themselves.
+	if isWitnessScriptHashScript(script) {
+		scriptHash := extractWitnessV0ScriptHash(script)
+		witness := existing
+		if len(witness) == 0 {
+			witness = incoming
+		}
+		witnessScript := witness[len(witness)-1]
+		hash := sha256.Sum256(witnessScript)
+		if !bytes.Equal(hash[:], scriptHash) {
+			return nil, fmt.Errorf("%w: witness script hash %x does "+
+				"not match %x", ErrMultisigWitnessMismatch, hash[:],
+				scriptHash)
+		}
+		script = witnessScript
+	}
+
+	session, err := NewMultisigSession(tx, idx, script, MultisigP2WSH,
+		sigHashes, amount)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, witness := range []wire.TxWitness{existing, incoming} {
+		if len(witness) == 0 {
+			continue
+		}
+		if !bytes.Equal(witness[len(witness)-1], script) {
+			return nil, fmt.Errorf("%w: last witness item is not "+
+				"the witness script", ErrMultisigWitnessMismatch)
+		}
+
+		// The first item is the dummy element consumed by
+		// OP_CHECKMULTISIG, and empty items are placeholders for
+		// missing signatures.
+		for _, sig := range witness[:len(witness)-1] {
+			if len(sig) != 0 {
+				session.addMatchingSignature(sig)
+			}
+		}
+	}
+
+	return session.partialWitness(), nil
+}
+
+// addMatchingSignature 将 sig 加入第一个尚未签名且签名能够通过验证的公钥，没有这样的公钥时丢弃该签名。
+func (s *MultisigSession) addMatchingSignature(sig []byte) {
+	for i, pubKey := range s.pubKeys {
+		if s.sigs[i] != nil {
+			if bytes.Equal(s.sigs[i], sig) {
+				return
+			}
+			continue
+		}
+		if s.AddSignature(pubKey, sig) == nil {
+			return
+		}
+	}
+}
+
+// partialWitness 返回当前签名的见证，签名不足时以空元素补齐到所需数量。
+func (s *MultisigSession) partialWitness() wire.TxWitness {
+	witness := make(wire.TxWitness, 0, s.required+2)
+	witness = append(witness, nil)
+	for _, sig := range s.sigs {
+		if sig == nil {
+			continue
+		}
+		witness = append(witness, sig)
+		if len(witness) == s.required+1 {
+			break
+		}
+	}
+	for len(witness) < s.required+1 {
+		witness = append(witness, nil)
+	}
+	return append(witness, s.script)
+}
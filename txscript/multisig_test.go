@@ -0,0 +1,196 @@
+package txscript
+
+import (
+	"crypto/sha256"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcec/v2/ecdsa"
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/stretchr/testify/require"
+)
+
+// TestMultisigSession 确保签名会话能够乱序收集签名、拒绝无效签名，并为 P2SH 和 P2WSH 生成可通过验证的脚本。
+func TestMultisigSession(t *testing.T) {
+	t.Parallel()
+
+	keys := make([]*btcec.PrivateKey, 3)
+	addrs := make([]*btcutil.AddressPubKey, 3)
+	for i := range keys {
+		key, err := btcec.NewPrivateKey()
+		require.NoError(t, err)
+		addr, err := btcutil.NewAddressPubKey(
+			key.PubKey().SerializeCompressed(), &chaincfg.MainNetParams,
+		)
+		require.NoError(t, err)
+		keys[i], addrs[i] = key, addr
+	}
+	msScript, err := MultiSigScript(addrs, 2)
+	require.NoError(t, err)
+
+	const amt = 1e8
+	scriptHash := sha256.Sum256(msScript)
+	p2wsh, err := payToWitnessScriptHashScript(scriptHash[:])
+	require.NoError(t, err)
+	p2sh, err := payToScriptHashScript(btcutil.Hash160(msScript))
+	require.NoError(t, err)
+
+	tests := []struct {
+		name      string
+		spendType MultisigSpendType
+		pkScript  []byte
+	}{
+		{"p2sh", MultisigP2SH, p2sh},
+		{"p2wsh", MultisigP2WSH, p2wsh},
+	}
+	for _, test := range tests {
+		tx := wire.NewMsgTx(2)
+		tx.AddTxIn(&wire.TxIn{PreviousOutPoint: wire.OutPoint{Index: 0}})
+		tx.AddTxOut(wire.NewTxOut(amt-1000, nil))
+		prevFetcher := NewCannedPrevOutputFetcher(test.pkScript, amt)
+		sigHashes := NewTxSigHashes(tx, prevFetcher)
+
+		session, err := NewMultisigSession(tx, 0, msScript,
+			test.spendType, sigHashes, amt)
+		require.NoError(t, err, test.name)
+		require.Equal(t, 2, session.RequiredSigs())
+
+		signWith := func(key *btcec.PrivateKey) []byte {
+			hash, err := session.SigHash(SigHashAll)
+			require.NoError(t, err)
+			sig := ecdsa.Sign(key, hash).Serialize()
+			return append(sig, byte(SigHashAll))
+		}
+
+		// 签名来自错误的密钥时必须被拒绝。
+		err = session.AddSignature(
+			keys[0].PubKey().SerializeCompressed(), signWith(keys[1]),
+		)
+		require.ErrorIs(t, err, ErrInvalidMultisigSignature, test.name)
+
+		other, err := btcec.NewPrivateKey()
+		require.NoError(t, err)
+		err = session.AddSignature(
+			other.PubKey().SerializeCompressed(), signWith(other),
+		)
+		require.ErrorIs(t, err, ErrUnknownMultisigKey, test.name)
+
+		// 乱序加入签名。
+		require.NoError(t, session.AddSignature(
+			keys[2].PubKey().SerializeCompressed(), signWith(keys[2]),
+		))
+		_, err = session.SignatureScript()
+		require.ErrorIs(t, err, ErrMultisigIncomplete, test.name)
+		require.NoError(t, session.AddSignature(
+			keys[0].PubKey().SerializeCompressed(), signWith(keys[0]),
+		))
+		require.True(t, session.Complete())
+		require.False(t, session.HasSigned(
+			keys[1].PubKey().SerializeCompressed(),
+		))
+
+		sigScript, err := session.SignatureScript()
+		require.NoError(t, err)
+		witness, err := session.Witness()
+		require.NoError(t, err)
+		tx.TxIn[0].SignatureScript = sigScript
+		tx.TxIn[0].Witness = witness
+
+		vm, err := NewEngine(test.pkScript, tx, 0, StandardVerifyFlags,
+			nil, sigHashes, amt, prevFetcher)
+		require.NoError(t, err, test.name)
+		require.NoError(t, vm.Execute(), test.name)
+	}
+
+	_, err = NewMultisigSession(wire.NewMsgTx(2), 0, msScript,
+		MultisigBare, nil, 0)
+	require.Error(t, err)
+	tx := wire.NewMsgTx(2)
+	tx.AddTxIn(&wire.TxIn{})
+	_, err = NewMultisigSession(tx, 0, []byte{OP_TRUE}, MultisigBare, nil, 0)
+	require.True(t, IsErrorCode(err, ErrNotMultisigScript))
+}
+
+// TestMergeWitnesses 确保部分签名的 P2WSH 多重签名见证按公钥顺序合并、去除重复和无效的签名，并且合并结果可以通过验证。
+func TestMergeWitnesses(t *testing.T) {
+	t.Parallel()
+
+	keys := make([]*btcec.PrivateKey, 3)
+	addrs := make([]*btcutil.AddressPubKey, 3)
+	for i := range keys {
+		key, err := btcec.NewPrivateKey()
+		require.NoError(t, err)
+		addr, err := btcutil.NewAddressPubKey(
+			key.PubKey().SerializeCompressed(), &chaincfg.MainNetParams,
+		)
+		require.NoError(t, err)
+		keys[i], addrs[i] = key, addr
+	}
+	msScript, err := MultiSigScript(addrs, 2)
+	require.NoError(t, err)
+	scriptHash := sha256.Sum256(msScript)
+	p2wsh, err := payToWitnessScriptHashScript(scriptHash[:])
+	require.NoError(t, err)
+
+	const amt = 1e8
+	tx := wire.NewMsgTx(2)
+	tx.AddTxIn(&wire.TxIn{PreviousOutPoint: wire.OutPoint{Index: 0}})
+	tx.AddTxOut(wire.NewTxOut(amt-1000, nil))
+	prevFetcher := NewCannedPrevOutputFetcher(p2wsh, amt)
+	sigHashes := NewTxSigHashes(tx, prevFetcher)
+
+	sign := func(key *btcec.PrivateKey, hashType SigHashType) []byte {
+		sig, err := RawTxInWitnessSignature(tx, sigHashes, 0, amt,
+			msScript, hashType, key)
+		require.NoError(t, err)
+		return sig
+	}
+	partial := func(sig []byte) wire.TxWitness {
+		return wire.TxWitness{nil, sig, nil, msScript}
+	}
+	sig0 := sign(keys[0], SigHashAll)
+	sig2 := sign(keys[2], SigHashSingle)
+
+	// 合并顺序不影响结果，签名按公钥顺序排列。
+	for _, script := range [][]byte{msScript, p2wsh} {
+		merged, err := MergeWitnesses(tx, 0, script, sigHashes, amt,
+			partial(sig2), partial(sig0))
+		require.NoError(t, err)
+		require.Equal(t, wire.TxWitness{nil, sig0, sig2, msScript}, merged)
+
+		tx.TxIn[0].Witness = merged
+		vm, err := NewEngine(p2wsh, tx, 0, StandardVerifyFlags, nil,
+			sigHashes, amt, prevFetcher)
+		require.NoError(t, err)
+		require.NoError(t, vm.Execute())
+		tx.TxIn[0].Witness = nil
+	}
+
+	// 重复的签名只保留一个，无效的签名被丢弃。
+	other, err := btcec.NewPrivateKey()
+	require.NoError(t, err)
+	merged, err := MergeWitnesses(tx, 0, msScript, sigHashes, amt,
+		partial(sig0), wire.TxWitness{nil, sig0, sign(other, SigHashAll),
+			msScript})
+	require.NoError(t, err)
+	require.Equal(t, partial(sig0), merged)
+
+	merged, err = MergeWitnesses(tx, 0, msScript, sigHashes, amt, nil,
+		partial(sig2))
+	require.NoError(t, err)
+	require.Equal(t, partial(sig2), merged)
+
+	merged, err = MergeWitnesses(tx, 0, p2wsh, sigHashes, amt, nil, nil)
+	require.NoError(t, err)
+	require.Nil(t, merged)
+
+	// 见证脚本与脚本不符。
+	_, err = MergeWitnesses(tx, 0, msScript, sigHashes, amt,
+		partial(sig0), wire.TxWitness{nil, sig2, nil, p2wsh})
+	require.ErrorIs(t, err, ErrMultisigWitnessMismatch)
+	_, err = MergeWitnesses(tx, 0, p2wsh, sigHashes, amt,
+		wire.TxWitness{nil, sig2, nil, p2wsh}, nil)
+	require.ErrorIs(t, err, ErrMultisigWitnessMismatch)
+}
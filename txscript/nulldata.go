@@ -0,0 +1,144 @@
+// 包含 OP_RETURN 元数据协议的注册表。 应用程序按数据前缀注册协议及其解析函数，DecodeNullData 将空数据输出分派给对应的解析函数，
+// 使链上的多个元数据协议不必各自扫描和解析所有 OP_RETURN 输出。
+
+package txscript
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/btcsuite/btcd/wire"
+)
+
+var (
+	// ErrInvalidNullDataProtocol 在注册的协议缺少必需字段，或其名称或前缀与已注册的协议冲突时返回。
+	ErrInvalidNullDataProtocol = errors.New("invalid null data protocol")
+
+	// ErrNotNullData 在输出的公钥脚本不是标准空数据脚本时返回。
+	ErrNotNullData = errors.New("output is not a null data script")
+
+	// ErrUnknownNullDataProtocol 在空数据输出的数据不以任何已注册协议的前缀开头时返回。
+	ErrUnknownNullDataProtocol = errors.New("unknown null data protocol")
+)
+
+// NullDataProtocol 描述一个通过 OP_RETURN 输出携带元数据的协议。
+type NullDataProtocol struct {
+	// Name 是协议的名称，在注册表中必须唯一。
+	Name string
+
+	// Prefix 是协议数据开头的标识字节，不能为空。 一个协议的前缀不能是另一个协议前缀的前缀，因此每个输出最多属于一个协议。
+	Prefix []byte
+
+	// Parse 解析去掉前缀后的数据，返回协议定义的类型化载荷。 它必须是无副作用的，并且可以被并发调用。
+	Parse func(payload []byte) (interface{}, error)
+}
+
+// NullDataPayload 是 DecodeNullData 解码的空数据输出。
+type NullDataPayload struct {
+	// Protocol 是数据所属协议的名称。
+	Protocol string
+
+	// Data 是去掉协议前缀后的原始数据。
+	Data []byte
+
+	// Value 是协议的 Parse 函数返回的载荷。
+	Value interface{}
+}
+
+var (
+	// nullDataProtocolsMtx 保护 nullDataProtocols。
+	nullDataProtocolsMtx sync.RWMutex
+
+	// nullDataProtocols 保存已注册的协议。
+	nullDataProtocols []NullDataProtocol
+)
+
+// RegisterNullDataProtocol 注册一个 OP_RETURN 元数据协议。 缺少名称、前缀或解析函数，或者名称已被使用，
+// 或者前缀与已注册的前缀互为前缀时返回 ErrInvalidNullDataProtocol。
+func RegisterNullDataProtocol(proto NullDataProtocol) error {
+	if proto.Name == "" || len(proto.Prefix) == 0 || proto.Parse == nil {
+		return fmt.Errorf("%w: name, prefix and parse function are "+
+			"required", ErrInvalidNullDataProtocol)
+	}
+	if len(proto.Prefix) > DefaultChainLimits().MaxDataCarrierSize {
+		return fmt.Errorf("%w: prefix of %d bytes exceeds the data "+
+			"carrier size", ErrInvalidNullDataProtocol, len(proto.Prefix))
+	}
+
+	nullDataProtocolsMtx.Lock()
+	defer nullDataProtocolsMtx.Unlock()
+
+	for _, registered := range nullDataProtocols {
+		if registered.Name == proto.Name {
+			return fmt.Errorf("%w: protocol %q already registered",
+				ErrInvalidNullDataProtocol, proto.Name)
+		}
+		if bytes.HasPrefix(registered.Prefix, proto.Prefix) ||
+			bytes.HasPrefix(proto.Prefix, registered.Prefix) {
+
+			return fmt.Errorf("%w: prefix %x of %q overlaps prefix %x "+
+				"of %q", ErrInvalidNullDataProtocol, proto.Prefix,
+				proto.Name, registered.Prefix, registered.Name)
+		}
+	}
+
+	// Keep a private copy of the prefix so callers can't change which
+	// outputs the protocol claims after registration.
+	proto.Prefix = append([]byte(nil), proto.Prefix...)
+	nullDataProtocols = append(nullDataProtocols, proto)
+	return nil
+}
+
+// NullDataProtocols 返回所有已注册的协议，按名称排序。
+func NullDataProtocols() []NullDataProtocol {
+	nullDataProtocolsMtx.RLock()
+	defer nullDataProtocolsMtx.RUnlock()
+
+	protos := make([]NullDataProtocol, len(nullDataProtocols))
+	copy(protos, nullDataProtocols)
+	sort.Slice(protos, func(i, j int) bool {
+		return protos[i].Name < protos[j].Name
+	})
+	return protos
+}
+
+// lookupNullDataProtocol 返回前缀与 data 匹配的已注册协议。
+func lookupNullDataProtocol(data []byte) (NullDataProtocol, bool) {
+	nullDataProtocolsMtx.RLock()
+	defer nullDataProtocolsMtx.RUnlock()
+
+	for _, proto := range nullDataProtocols {
+		if bytes.HasPrefix(data, proto.Prefix) {
+			return proto, true
+		}
+	}
+	return NullDataProtocol{}, false
+}
+
+// DecodeNullData 解码空数据输出，将其数据分派给前缀匹配的已注册协议。 输出不是标准空数据脚本时返回 ErrNotNullData，
+// 没有协议匹配时返回 ErrUnknownNullDataProtocol；协议解析失败时返回包含协议名称的解析错误。
+func DecodeNullData(txOut *wire.TxOut) (*NullDataPayload, error) {
+	data := ExtractStandardData(txOut.PkScript)
+	if data.Class != NullDataTy {
+		return nil, ErrNotNullData
+	}
+
+	proto, ok := lookupNullDataProtocol(data.NullData)
+	if !ok {
+		return nil, ErrUnknownNullDataProtocol
+	}
+	payload := data.NullData[len(proto.Prefix):]
+	value, err := proto.Parse(payload)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", proto.Name, err)
+	}
+
+	return &NullDataPayload{
+		Protocol: proto.Name,
+		Data:     payload,
+		Value:    value,
+	}, nil
+}
@@ -0,0 +1,102 @@
+package txscript
+
+import (
+	"encoding/binary"
+	"errors"
+	"testing"
+
+	"github.com/btcsuite/btcd/wire"
+	"github.com/stretchr/testify/require"
+)
+
+// testAnchor 是测试协议的载荷：一个区块高度和一个哈希。
+type testAnchor struct {
+	height uint32
+	hash   []byte
+}
+
+// TestNullDataProtocols 确保空数据输出被分派给前缀匹配的协议，并且无效或冲突的协议被拒绝。
+func TestNullDataProtocols(t *testing.T) {
+	t.Parallel()
+
+	errShort := errors.New("short anchor")
+	anchor := NullDataProtocol{
+		Name:   "test-anchor",
+		Prefix: []byte("TANC"),
+		Parse: func(payload []byte) (interface{}, error) {
+			if len(payload) < 4 {
+				return nil, errShort
+			}
+			return testAnchor{
+				height: binary.BigEndian.Uint32(payload),
+				hash:   payload[4:],
+			}, nil
+		},
+	}
+	require.NoError(t, RegisterNullDataProtocol(anchor))
+	require.Contains(t, protocolNames(NullDataProtocols()), anchor.Name)
+
+	// 注册后修改前缀不影响注册表。
+	tag := NullDataProtocol{
+		Name:   "test-tag",
+		Prefix: []byte("TTAG"),
+		Parse: func(payload []byte) (interface{}, error) {
+			return string(payload), nil
+		},
+	}
+	require.NoError(t, RegisterNullDataProtocol(tag))
+	tag.Prefix[0] = 'X'
+
+	nullData := func(data []byte) *wire.TxOut {
+		pkScript, err := NullDataScript(data)
+		require.NoError(t, err)
+		return wire.NewTxOut(0, pkScript)
+	}
+
+	payload, err := DecodeNullData(nullData([]byte("TANC\x00\x00\x01\x00\xab")))
+	require.NoError(t, err)
+	require.Equal(t, "test-anchor", payload.Protocol)
+	require.Equal(t, []byte{0, 0, 1, 0, 0xab}, payload.Data)
+	require.Equal(t, testAnchor{height: 256, hash: []byte{0xab}},
+		payload.Value)
+
+	payload, err = DecodeNullData(nullData([]byte("TTAGhello")))
+	require.NoError(t, err)
+	require.Equal(t, "test-tag", payload.Protocol)
+	require.Equal(t, "hello", payload.Value)
+
+	_, err = DecodeNullData(nullData([]byte("TANC\x01")))
+	require.ErrorIs(t, err, errShort)
+	require.Contains(t, err.Error(), "test-anchor")
+
+	_, err = DecodeNullData(nullData([]byte("XTAGhello")))
+	require.ErrorIs(t, err, ErrUnknownNullDataProtocol)
+	_, err = DecodeNullData(wire.NewTxOut(0, []byte{OP_RETURN}))
+	require.ErrorIs(t, err, ErrUnknownNullDataProtocol)
+	_, err = DecodeNullData(wire.NewTxOut(0, []byte{OP_TRUE}))
+	require.ErrorIs(t, err, ErrNotNullData)
+
+	parse := anchor.Parse
+	for _, invalid := range []NullDataProtocol{
+		{Name: "", Prefix: []byte("TNEW"), Parse: parse},
+		{Name: "test-no-prefix", Parse: parse},
+		{Name: "test-no-parse", Prefix: []byte("TNEW")},
+		{Name: "test-anchor", Prefix: []byte("TNEW"), Parse: parse},
+		{Name: "test-shorter", Prefix: []byte("TAN"), Parse: parse},
+		{Name: "test-longer", Prefix: []byte("TTAGS"), Parse: parse},
+		{Name: "test-huge", Prefix: make([]byte, MaxDataCarrierSize+1),
+			Parse: parse},
+	} {
+		require.ErrorIs(t, RegisterNullDataProtocol(invalid),
+			ErrInvalidNullDataProtocol, invalid.Name)
+	}
+}
+
+// protocolNames 返回协议的名称。
+func protocolNames(protos []NullDataProtocol) []string {
+	names := make([]string, 0, len(protos))
+	for _, proto := range protos {
+		names = append(names, proto.Name)
+	}
+	return names
+}
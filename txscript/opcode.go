@@ -21,15 +21,15 @@ import (
 )
 
 // 操作码定义与 txscript 操作码相关的信息。
-// opfunc（如果存在）是调用以在脚本上执行操作码的函数。
-// 当前脚本作为切片传入，第一个成员是操作码本身。
 type opcode struct {
 	value  byte
 	name   string
 	length int
-	opfunc func(*opcode, []byte, *Engine) error
 }
 
+// opcodeHandler 是在脚本上执行操作码的函数。 data 是操作码携带的数据，非推送操作码为 nil。
+type opcodeHandler func(op *opcode, data []byte, vm *Engine) error
+
 // 这些常量是 btc wiki、比特币核心以及大多数（如果不是所有）其他与处理 BTC 脚本相关的参考资料和软件中使用的官方操作码的值。
 const (
 	OP_0            = 0x00 // 0 - 表示数字0
@@ -311,284 +311,564 @@ const (
 	OpCondSkip  = 2
 )
 
-// opcodeArray 保存有关所有可能的操作码的详细信息，例如操作码和任何关联数据应占用多少字节、其人类可读的名称以及处理程序函数。
+// opcodeArray 保存有关所有可能的操作码的详细信息，例如操作码和任何关联数据应占用多少字节以及其人类可读的名称。 处理函数在 opcodeJumpTable 中。
 var opcodeArray = [256]opcode{
 	// 数据推送操作码。
-	OP_FALSE:     {OP_FALSE, "OP_0", 1, opcodeFalse},
-	OP_DATA_1:    {OP_DATA_1, "OP_DATA_1", 2, opcodePushData},
-	OP_DATA_2:    {OP_DATA_2, "OP_DATA_2", 3, opcodePushData},
-	OP_DATA_3:    {OP_DATA_3, "OP_DATA_3", 4, opcodePushData},
-	OP_DATA_4:    {OP_DATA_4, "OP_DATA_4", 5, opcodePushData},
-	OP_DATA_5:    {OP_DATA_5, "OP_DATA_5", 6, opcodePushData},
-	OP_DATA_6:    {OP_DATA_6, "OP_DATA_6", 7, opcodePushData},
-	OP_DATA_7:    {OP_DATA_7, "OP_DATA_7", 8, opcodePushData},
-	OP_DATA_8:    {OP_DATA_8, "OP_DATA_8", 9, opcodePushData},
-	OP_DATA_9:    {OP_DATA_9, "OP_DATA_9", 10, opcodePushData},
-	OP_DATA_10:   {OP_DATA_10, "OP_DATA_10", 11, opcodePushData},
-	OP_DATA_11:   {OP_DATA_11, "OP_DATA_11", 12, opcodePushData},
-	OP_DATA_12:   {OP_DATA_12, "OP_DATA_12", 13, opcodePushData},
-	OP_DATA_13:   {OP_DATA_13, "OP_DATA_13", 14, opcodePushData},
-	OP_DATA_14:   {OP_DATA_14, "OP_DATA_14", 15, opcodePushData},
-	OP_DATA_15:   {OP_DATA_15, "OP_DATA_15", 16, opcodePushData},
-	OP_DATA_16:   {OP_DATA_16, "OP_DATA_16", 17, opcodePushData},
-	OP_DATA_17:   {OP_DATA_17, "OP_DATA_17", 18, opcodePushData},
-	OP_DATA_18:   {OP_DATA_18, "OP_DATA_18", 19, opcodePushData},
-	OP_DATA_19:   {OP_DATA_19, "OP_DATA_19", 20, opcodePushData},
-	OP_DATA_20:   {OP_DATA_20, "OP_DATA_20", 21, opcodePushData},
-	OP_DATA_21:   {OP_DATA_21, "OP_DATA_21", 22, opcodePushData},
-	OP_DATA_22:   {OP_DATA_22, "OP_DATA_22", 23, opcodePushData},
-	OP_DATA_23:   {OP_DATA_23, "OP_DATA_23", 24, opcodePushData},
-	OP_DATA_24:   {OP_DATA_24, "OP_DATA_24", 25, opcodePushData},
-	OP_DATA_25:   {OP_DATA_25, "OP_DATA_25", 26, opcodePushData},
-	OP_DATA_26:   {OP_DATA_26, "OP_DATA_26", 27, opcodePushData},
-	OP_DATA_27:   {OP_DATA_27, "OP_DATA_27", 28, opcodePushData},
-	OP_DATA_28:   {OP_DATA_28, "OP_DATA_28", 29, opcodePushData},
-	OP_DATA_29:   {OP_DATA_29, "OP_DATA_29", 30, opcodePushData},
-	OP_DATA_30:   {OP_DATA_30, "OP_DATA_30", 31, opcodePushData},
-	OP_DATA_31:   {OP_DATA_31, "OP_DATA_31", 32, opcodePushData},
-	OP_DATA_32:   {OP_DATA_32, "OP_DATA_32", 33, opcodePushData},
-	OP_DATA_33:   {OP_DATA_33, "OP_DATA_33", 34, opcodePushData},
-	OP_DATA_34:   {OP_DATA_34, "OP_DATA_34", 35, opcodePushData},
-	OP_DATA_35:   {OP_DATA_35, "OP_DATA_35", 36, opcodePushData},
-	OP_DATA_36:   {OP_DATA_36, "OP_DATA_36", 37, opcodePushData},
-	OP_DATA_37:   {OP_DATA_37, "OP_DATA_37", 38, opcodePushData},
-	OP_DATA_38:   {OP_DATA_38, "OP_DATA_38", 39, opcodePushData},
-	OP_DATA_39:   {OP_DATA_39, "OP_DATA_39", 40, opcodePushData},
-	OP_DATA_40:   {OP_DATA_40, "OP_DATA_40", 41, opcodePushData},
-	OP_DATA_41:   {OP_DATA_41, "OP_DATA_41", 42, opcodePushData},
-	OP_DATA_42:   {OP_DATA_42, "OP_DATA_42", 43, opcodePushData},
-	OP_DATA_43:   {OP_DATA_43, "OP_DATA_43", 44, opcodePushData},
-	OP_DATA_44:   {OP_DATA_44, "OP_DATA_44", 45, opcodePushData},
-	OP_DATA_45:   {OP_DATA_45, "OP_DATA_45", 46, opcodePushData},
-	OP_DATA_46:   {OP_DATA_46, "OP_DATA_46", 47, opcodePushData},
-	OP_DATA_47:   {OP_DATA_47, "OP_DATA_47", 48, opcodePushData},
-	OP_DATA_48:   {OP_DATA_48, "OP_DATA_48", 49, opcodePushData},
-	OP_DATA_49:   {OP_DATA_49, "OP_DATA_49", 50, opcodePushData},
-	OP_DATA_50:   {OP_DATA_50, "OP_DATA_50", 51, opcodePushData},
-	OP_DATA_51:   {OP_DATA_51, "OP_DATA_51", 52, opcodePushData},
-	OP_DATA_52:   {OP_DATA_52, "OP_DATA_52", 53, opcodePushData},
-	OP_DATA_53:   {OP_DATA_53, "OP_DATA_53", 54, opcodePushData},
-	OP_DATA_54:   {OP_DATA_54, "OP_DATA_54", 55, opcodePushData},
-	OP_DATA_55:   {OP_DATA_55, "OP_DATA_55", 56, opcodePushData},
-	OP_DATA_56:   {OP_DATA_56, "OP_DATA_56", 57, opcodePushData},
-	OP_DATA_57:   {OP_DATA_57, "OP_DATA_57", 58, opcodePushData},
-	OP_DATA_58:   {OP_DATA_58, "OP_DATA_58", 59, opcodePushData},
-	OP_DATA_59:   {OP_DATA_59, "OP_DATA_59", 60, opcodePushData},
-	OP_DATA_60:   {OP_DATA_60, "OP_DATA_60", 61, opcodePushData},
-	OP_DATA_61:   {OP_DATA_61, "OP_DATA_61", 62, opcodePushData},
-	OP_DATA_62:   {OP_DATA_62, "OP_DATA_62", 63, opcodePushData},
-	OP_DATA_63:   {OP_DATA_63, "OP_DATA_63", 64, opcodePushData},
-	OP_DATA_64:   {OP_DATA_64, "OP_DATA_64", 65, opcodePushData},
-	OP_DATA_65:   {OP_DATA_65, "OP_DATA_65", 66, opcodePushData},
-	OP_DATA_66:   {OP_DATA_66, "OP_DATA_66", 67, opcodePushData},
-	OP_DATA_67:   {OP_DATA_67, "OP_DATA_67", 68, opcodePushData},
-	OP_DATA_68:   {OP_DATA_68, "OP_DATA_68", 69, opcodePushData},
-	OP_DATA_69:   {OP_DATA_69, "OP_DATA_69", 70, opcodePushData},
-	OP_DATA_70:   {OP_DATA_70, "OP_DATA_70", 71, opcodePushData},
-	OP_DATA_71:   {OP_DATA_71, "OP_DATA_71", 72, opcodePushData},
-	OP_DATA_72:   {OP_DATA_72, "OP_DATA_72", 73, opcodePushData},
-	OP_DATA_73:   {OP_DATA_73, "OP_DATA_73", 74, opcodePushData},
-	OP_DATA_74:   {OP_DATA_74, "OP_DATA_74", 75, opcodePushData},
-	OP_DATA_75:   {OP_DATA_75, "OP_DATA_75", 76, opcodePushData},
-	OP_PUSHDATA1: {OP_PUSHDATA1, "OP_PUSHDATA1", -1, opcodePushData},
-	OP_PUSHDATA2: {OP_PUSHDATA2, "OP_PUSHDATA2", -2, opcodePushData},
-	OP_PUSHDATA4: {OP_PUSHDATA4, "OP_PUSHDATA4", -4, opcodePushData},
-	OP_1NEGATE:   {OP_1NEGATE, "OP_1NEGATE", 1, opcode1Negate},
-	OP_RESERVED:  {OP_RESERVED, "OP_RESERVED", 1, opcodeReserved},
-	OP_TRUE:      {OP_TRUE, "OP_1", 1, opcodeN},
-	OP_2:         {OP_2, "OP_2", 1, opcodeN},
-	OP_3:         {OP_3, "OP_3", 1, opcodeN},
-	OP_4:         {OP_4, "OP_4", 1, opcodeN},
-	OP_5:         {OP_5, "OP_5", 1, opcodeN},
-	OP_6:         {OP_6, "OP_6", 1, opcodeN},
-	OP_7:         {OP_7, "OP_7", 1, opcodeN},
-	OP_8:         {OP_8, "OP_8", 1, opcodeN},
-	OP_9:         {OP_9, "OP_9", 1, opcodeN},
-	OP_10:        {OP_10, "OP_10", 1, opcodeN},
-	OP_11:        {OP_11, "OP_11", 1, opcodeN},
-	OP_12:        {OP_12, "OP_12", 1, opcodeN},
-	OP_13:        {OP_13, "OP_13", 1, opcodeN},
-	OP_14:        {OP_14, "OP_14", 1, opcodeN},
-	OP_15:        {OP_15, "OP_15", 1, opcodeN},
-	OP_16:        {OP_16, "OP_16", 1, opcodeN},
+	OP_FALSE:     {OP_FALSE, "OP_0", 1},
+	OP_DATA_1:    {OP_DATA_1, "OP_DATA_1", 2},
+	OP_DATA_2:    {OP_DATA_2, "OP_DATA_2", 3},
+	OP_DATA_3:    {OP_DATA_3, "OP_DATA_3", 4},
+	OP_DATA_4:    {OP_DATA_4, "OP_DATA_4", 5},
+	OP_DATA_5:    {OP_DATA_5, "OP_DATA_5", 6},
+	OP_DATA_6:    {OP_DATA_6, "OP_DATA_6", 7},
+	OP_DATA_7:    {OP_DATA_7, "OP_DATA_7", 8},
+	OP_DATA_8:    {OP_DATA_8, "OP_DATA_8", 9},
+	OP_DATA_9:    {OP_DATA_9, "OP_DATA_9", 10},
+	OP_DATA_10:   {OP_DATA_10, "OP_DATA_10", 11},
+	OP_DATA_11:   {OP_DATA_11, "OP_DATA_11", 12},
+	OP_DATA_12:   {OP_DATA_12, "OP_DATA_12", 13},
+	OP_DATA_13:   {OP_DATA_13, "OP_DATA_13", 14},
+	OP_DATA_14:   {OP_DATA_14, "OP_DATA_14", 15},
+	OP_DATA_15:   {OP_DATA_15, "OP_DATA_15", 16},
+	OP_DATA_16:   {OP_DATA_16, "OP_DATA_16", 17},
+	OP_DATA_17:   {OP_DATA_17, "OP_DATA_17", 18},
+	OP_DATA_18:   {OP_DATA_18, "OP_DATA_18", 19},
+	OP_DATA_19:   {OP_DATA_19, "OP_DATA_19", 20},
+	OP_DATA_20:   {OP_DATA_20, "OP_DATA_20", 21},
+	OP_DATA_21:   {OP_DATA_21, "OP_DATA_21", 22},
+	OP_DATA_22:   {OP_DATA_22, "OP_DATA_22", 23},
+	OP_DATA_23:   {OP_DATA_23, "OP_DATA_23", 24},
+	OP_DATA_24:   {OP_DATA_24, "OP_DATA_24", 25},
+	OP_DATA_25:   {OP_DATA_25, "OP_DATA_25", 26},
+	OP_DATA_26:   {OP_DATA_26, "OP_DATA_26", 27},
+	OP_DATA_27:   {OP_DATA_27, "OP_DATA_27", 28},
+	OP_DATA_28:   {OP_DATA_28, "OP_DATA_28", 29},
+	OP_DATA_29:   {OP_DATA_29, "OP_DATA_29", 30},
+	OP_DATA_30:   {OP_DATA_30, "OP_DATA_30", 31},
+	OP_DATA_31:   {OP_DATA_31, "OP_DATA_31", 32},
+	OP_DATA_32:   {OP_DATA_32, "OP_DATA_32", 33},
+	OP_DATA_33:   {OP_DATA_33, "OP_DATA_33", 34},
+	OP_DATA_34:   {OP_DATA_34, "OP_DATA_34", 35},
+	OP_DATA_35:   {OP_DATA_35, "OP_DATA_35", 36},
+	OP_DATA_36:   {OP_DATA_36, "OP_DATA_36", 37},
+	OP_DATA_37:   {OP_DATA_37, "OP_DATA_37", 38},
+	OP_DATA_38:   {OP_DATA_38, "OP_DATA_38", 39},
+	OP_DATA_39:   {OP_DATA_39, "OP_DATA_39", 40},
+	OP_DATA_40:   {OP_DATA_40, "OP_DATA_40", 41},
+	OP_DATA_41:   {OP_DATA_41, "OP_DATA_41", 42},
+	OP_DATA_42:   {OP_DATA_42, "OP_DATA_42", 43},
+	OP_DATA_43:   {OP_DATA_43, "OP_DATA_43", 44},
+	OP_DATA_44:   {OP_DATA_44, "OP_DATA_44", 45},
+	OP_DATA_45:   {OP_DATA_45, "OP_DATA_45", 46},
+	OP_DATA_46:   {OP_DATA_46, "OP_DATA_46", 47},
+	OP_DATA_47:   {OP_DATA_47, "OP_DATA_47", 48},
+	OP_DATA_48:   {OP_DATA_48, "OP_DATA_48", 49},
+	OP_DATA_49:   {OP_DATA_49, "OP_DATA_49", 50},
+	OP_DATA_50:   {OP_DATA_50, "OP_DATA_50", 51},
+	OP_DATA_51:   {OP_DATA_51, "OP_DATA_51", 52},
+	OP_DATA_52:   {OP_DATA_52, "OP_DATA_52", 53},
+	OP_DATA_53:   {OP_DATA_53, "OP_DATA_53", 54},
+	OP_DATA_54:   {OP_DATA_54, "OP_DATA_54", 55},
+	OP_DATA_55:   {OP_DATA_55, "OP_DATA_55", 56},
+	OP_DATA_56:   {OP_DATA_56, "OP_DATA_56", 57},
+	OP_DATA_57:   {OP_DATA_57, "OP_DATA_57", 58},
+	OP_DATA_58:   {OP_DATA_58, "OP_DATA_58", 59},
+	OP_DATA_59:   {OP_DATA_59, "OP_DATA_59", 60},
+	OP_DATA_60:   {OP_DATA_60, "OP_DATA_60", 61},
+	OP_DATA_61:   {OP_DATA_61, "OP_DATA_61", 62},
+	OP_DATA_62:   {OP_DATA_62, "OP_DATA_62", 63},
+	OP_DATA_63:   {OP_DATA_63, "OP_DATA_63", 64},
+	OP_DATA_64:   {OP_DATA_64, "OP_DATA_64", 65},
+	OP_DATA_65:   {OP_DATA_65, "OP_DATA_65", 66},
+	OP_DATA_66:   {OP_DATA_66, "OP_DATA_66", 67},
+	OP_DATA_67:   {OP_DATA_67, "OP_DATA_67", 68},
+	OP_DATA_68:   {OP_DATA_68, "OP_DATA_68", 69},
+	OP_DATA_69:   {OP_DATA_69, "OP_DATA_69", 70},
+	OP_DATA_70:   {OP_DATA_70, "OP_DATA_70", 71},
+	OP_DATA_71:   {OP_DATA_71, "OP_DATA_71", 72},
+	OP_DATA_72:   {OP_DATA_72, "OP_DATA_72", 73},
+	OP_DATA_73:   {OP_DATA_73, "OP_DATA_73", 74},
+	OP_DATA_74:   {OP_DATA_74, "OP_DATA_74", 75},
+	OP_DATA_75:   {OP_DATA_75, "OP_DATA_75", 76},
+	OP_PUSHDATA1: {OP_PUSHDATA1, "OP_PUSHDATA1", -1},
+	OP_PUSHDATA2: {OP_PUSHDATA2, "OP_PUSHDATA2", -2},
+	OP_PUSHDATA4: {OP_PUSHDATA4, "OP_PUSHDATA4", -4},
+	OP_1NEGATE:   {OP_1NEGATE, "OP_1NEGATE", 1},
+	OP_RESERVED:  {OP_RESERVED, "OP_RESERVED", 1},
+	OP_TRUE:      {OP_TRUE, "OP_1", 1},
+	OP_2:         {OP_2, "OP_2", 1},
+	OP_3:         {OP_3, "OP_3", 1},
+	OP_4:         {OP_4, "OP_4", 1},
+	OP_5:         {OP_5, "OP_5", 1},
+	OP_6:         {OP_6, "OP_6", 1},
+	OP_7:         {OP_7, "OP_7", 1},
+	OP_8:         {OP_8, "OP_8", 1},
+	OP_9:         {OP_9, "OP_9", 1},
+	OP_10:        {OP_10, "OP_10", 1},
+	OP_11:        {OP_11, "OP_11", 1},
+	OP_12:        {OP_12, "OP_12", 1},
+	OP_13:        {OP_13, "OP_13", 1},
+	OP_14:        {OP_14, "OP_14", 1},
+	OP_15:        {OP_15, "OP_15", 1},
+	OP_16:        {OP_16, "OP_16", 1},
 
 	// 控制操作码。
-	OP_NOP:                 {OP_NOP, "OP_NOP", 1, opcodeNop},
-	OP_VER:                 {OP_VER, "OP_VER", 1, opcodeReserved},
-	OP_IF:                  {OP_IF, "OP_IF", 1, opcodeIf},
-	OP_NOTIF:               {OP_NOTIF, "OP_NOTIF", 1, opcodeNotIf},
-	OP_VERIF:               {OP_VERIF, "OP_VERIF", 1, opcodeReserved},
-	OP_VERNOTIF:            {OP_VERNOTIF, "OP_VERNOTIF", 1, opcodeReserved},
-	OP_ELSE:                {OP_ELSE, "OP_ELSE", 1, opcodeElse},
-	OP_ENDIF:               {OP_ENDIF, "OP_ENDIF", 1, opcodeEndif},
-	OP_VERIFY:              {OP_VERIFY, "OP_VERIFY", 1, opcodeVerify},
-	OP_RETURN:              {OP_RETURN, "OP_RETURN", 1, opcodeReturn},
-	OP_CHECKLOCKTIMEVERIFY: {OP_CHECKLOCKTIMEVERIFY, "OP_CHECKLOCKTIMEVERIFY", 1, opcodeCheckLockTimeVerify},
-	OP_CHECKSEQUENCEVERIFY: {OP_CHECKSEQUENCEVERIFY, "OP_CHECKSEQUENCEVERIFY", 1, opcodeCheckSequenceVerify},
+	OP_NOP:                 {OP_NOP, "OP_NOP", 1},
+	OP_VER:                 {OP_VER, "OP_VER", 1},
+	OP_IF:                  {OP_IF, "OP_IF", 1},
+	OP_NOTIF:               {OP_NOTIF, "OP_NOTIF", 1},
+	OP_VERIF:               {OP_VERIF, "OP_VERIF", 1},
+	OP_VERNOTIF:            {OP_VERNOTIF, "OP_VERNOTIF", 1},
+	OP_ELSE:                {OP_ELSE, "OP_ELSE", 1},
+	OP_ENDIF:               {OP_ENDIF, "OP_ENDIF", 1},
+	OP_VERIFY:              {OP_VERIFY, "OP_VERIFY", 1},
+	OP_RETURN:              {OP_RETURN, "OP_RETURN", 1},
+	OP_CHECKLOCKTIMEVERIFY: {OP_CHECKLOCKTIMEVERIFY, "OP_CHECKLOCKTIMEVERIFY", 1},
+	OP_CHECKSEQUENCEVERIFY: {OP_CHECKSEQUENCEVERIFY, "OP_CHECKSEQUENCEVERIFY", 1},
 
 	// 堆栈操作码。
-	OP_TOALTSTACK:   {OP_TOALTSTACK, "OP_TOALTSTACK", 1, opcodeToAltStack},
-	OP_FROMALTSTACK: {OP_FROMALTSTACK, "OP_FROMALTSTACK", 1, opcodeFromAltStack},
-	OP_2DROP:        {OP_2DROP, "OP_2DROP", 1, opcode2Drop},
-	OP_2DUP:         {OP_2DUP, "OP_2DUP", 1, opcode2Dup},
-	OP_3DUP:         {OP_3DUP, "OP_3DUP", 1, opcode3Dup},
-	OP_2OVER:        {OP_2OVER, "OP_2OVER", 1, opcode2Over},
-	OP_2ROT:         {OP_2ROT, "OP_2ROT", 1, opcode2Rot},
-	OP_2SWAP:        {OP_2SWAP, "OP_2SWAP", 1, opcode2Swap},
-	OP_IFDUP:        {OP_IFDUP, "OP_IFDUP", 1, opcodeIfDup},
-	OP_DEPTH:        {OP_DEPTH, "OP_DEPTH", 1, opcodeDepth},
-	OP_DROP:         {OP_DROP, "OP_DROP", 1, opcodeDrop},
-	OP_DUP:          {OP_DUP, "OP_DUP", 1, opcodeDup},
-	OP_NIP:          {OP_NIP, "OP_NIP", 1, opcodeNip},
-	OP_OVER:         {OP_OVER, "OP_OVER", 1, opcodeOver},
-	OP_PICK:         {OP_PICK, "OP_PICK", 1, opcodePick},
-	OP_ROLL:         {OP_ROLL, "OP_ROLL", 1, opcodeRoll},
-	OP_ROT:          {OP_ROT, "OP_ROT", 1, opcodeRot},
-	OP_SWAP:         {OP_SWAP, "OP_SWAP", 1, opcodeSwap},
-	OP_TUCK:         {OP_TUCK, "OP_TUCK", 1, opcodeTuck},
+	OP_TOALTSTACK:   {OP_TOALTSTACK, "OP_TOALTSTACK", 1},
+	OP_FROMALTSTACK: {OP_FROMALTSTACK, "OP_FROMALTSTACK", 1},
+	OP_2DROP:        {OP_2DROP, "OP_2DROP", 1},
+	OP_2DUP:         {OP_2DUP, "OP_2DUP", 1},
+	OP_3DUP:         {OP_3DUP, "OP_3DUP", 1},
+	OP_2OVER:        {OP_2OVER, "OP_2OVER", 1},
+	OP_2ROT:         {OP_2ROT, "OP_2ROT", 1},
+	OP_2SWAP:        {OP_2SWAP, "OP_2SWAP", 1},
+	OP_IFDUP:        {OP_IFDUP, "OP_IFDUP", 1},
+	OP_DEPTH:        {OP_DEPTH, "OP_DEPTH", 1},
+	OP_DROP:         {OP_DROP, "OP_DROP", 1},
+	OP_DUP:          {OP_DUP, "OP_DUP", 1},
+	OP_NIP:          {OP_NIP, "OP_NIP", 1},
+	OP_OVER:         {OP_OVER, "OP_OVER", 1},
+	OP_PICK:         {OP_PICK, "OP_PICK", 1},
+	OP_ROLL:         {OP_ROLL, "OP_ROLL", 1},
+	OP_ROT:          {OP_ROT, "OP_ROT", 1},
+	OP_SWAP:         {OP_SWAP, "OP_SWAP", 1},
+	OP_TUCK:         {OP_TUCK, "OP_TUCK", 1},
 
 	// 拼接操作码。
-	OP_CAT:    {OP_CAT, "OP_CAT", 1, opcodeDisabled},
-	OP_SUBSTR: {OP_SUBSTR, "OP_SUBSTR", 1, opcodeDisabled},
-	OP_LEFT:   {OP_LEFT, "OP_LEFT", 1, opcodeDisabled},
-	OP_RIGHT:  {OP_RIGHT, "OP_RIGHT", 1, opcodeDisabled},
-	OP_SIZE:   {OP_SIZE, "OP_SIZE", 1, opcodeSize},
+	OP_CAT:    {OP_CAT, "OP_CAT", 1},
+	OP_SUBSTR: {OP_SUBSTR, "OP_SUBSTR", 1},
+	OP_LEFT:   {OP_LEFT, "OP_LEFT", 1},
+	OP_RIGHT:  {OP_RIGHT, "OP_RIGHT", 1},
+	OP_SIZE:   {OP_SIZE, "OP_SIZE", 1},
 
 	// 按位逻辑操作码。
-	OP_INVERT:      {OP_INVERT, "OP_INVERT", 1, opcodeDisabled},
-	OP_AND:         {OP_AND, "OP_AND", 1, opcodeDisabled},
-	OP_OR:          {OP_OR, "OP_OR", 1, opcodeDisabled},
-	OP_XOR:         {OP_XOR, "OP_XOR", 1, opcodeDisabled},
-	OP_EQUAL:       {OP_EQUAL, "OP_EQUAL", 1, opcodeEqual},
-	OP_EQUALVERIFY: {OP_EQUALVERIFY, "OP_EQUALVERIFY", 1, opcodeEqualVerify},
-	OP_RESERVED1:   {OP_RESERVED1, "OP_RESERVED1", 1, opcodeReserved},
-	OP_RESERVED2:   {OP_RESERVED2, "OP_RESERVED2", 1, opcodeReserved},
+	OP_INVERT:      {OP_INVERT, "OP_INVERT", 1},
+	OP_AND:         {OP_AND, "OP_AND", 1},
+	OP_OR:          {OP_OR, "OP_OR", 1},
+	OP_XOR:         {OP_XOR, "OP_XOR", 1},
+	OP_EQUAL:       {OP_EQUAL, "OP_EQUAL", 1},
+	OP_EQUALVERIFY: {OP_EQUALVERIFY, "OP_EQUALVERIFY", 1},
+	OP_RESERVED1:   {OP_RESERVED1, "OP_RESERVED1", 1},
+	OP_RESERVED2:   {OP_RESERVED2, "OP_RESERVED2", 1},
 
 	// 数字相关的操作码。
-	OP_1ADD:               {OP_1ADD, "OP_1ADD", 1, opcode1Add},
-	OP_1SUB:               {OP_1SUB, "OP_1SUB", 1, opcode1Sub},
-	OP_2MUL:               {OP_2MUL, "OP_2MUL", 1, opcodeDisabled},
-	OP_2DIV:               {OP_2DIV, "OP_2DIV", 1, opcodeDisabled},
-	OP_NEGATE:             {OP_NEGATE, "OP_NEGATE", 1, opcodeNegate},
-	OP_ABS:                {OP_ABS, "OP_ABS", 1, opcodeAbs},
-	OP_NOT:                {OP_NOT, "OP_NOT", 1, opcodeNot},
-	OP_0NOTEQUAL:          {OP_0NOTEQUAL, "OP_0NOTEQUAL", 1, opcode0NotEqual},
-	OP_ADD:                {OP_ADD, "OP_ADD", 1, opcodeAdd},
-	OP_SUB:                {OP_SUB, "OP_SUB", 1, opcodeSub},
-	OP_MUL:                {OP_MUL, "OP_MUL", 1, opcodeDisabled},
-	OP_DIV:                {OP_DIV, "OP_DIV", 1, opcodeDisabled},
-	OP_MOD:                {OP_MOD, "OP_MOD", 1, opcodeDisabled},
-	OP_LSHIFT:             {OP_LSHIFT, "OP_LSHIFT", 1, opcodeDisabled},
-	OP_RSHIFT:             {OP_RSHIFT, "OP_RSHIFT", 1, opcodeDisabled},
-	OP_BOOLAND:            {OP_BOOLAND, "OP_BOOLAND", 1, opcodeBoolAnd},
-	OP_BOOLOR:             {OP_BOOLOR, "OP_BOOLOR", 1, opcodeBoolOr},
-	OP_NUMEQUAL:           {OP_NUMEQUAL, "OP_NUMEQUAL", 1, opcodeNumEqual},
-	OP_NUMEQUALVERIFY:     {OP_NUMEQUALVERIFY, "OP_NUMEQUALVERIFY", 1, opcodeNumEqualVerify},
-	OP_NUMNOTEQUAL:        {OP_NUMNOTEQUAL, "OP_NUMNOTEQUAL", 1, opcodeNumNotEqual},
-	OP_LESSTHAN:           {OP_LESSTHAN, "OP_LESSTHAN", 1, opcodeLessThan},
-	OP_GREATERTHAN:        {OP_GREATERTHAN, "OP_GREATERTHAN", 1, opcodeGreaterThan},
-	OP_LESSTHANOREQUAL:    {OP_LESSTHANOREQUAL, "OP_LESSTHANOREQUAL", 1, opcodeLessThanOrEqual},
-	OP_GREATERTHANOREQUAL: {OP_GREATERTHANOREQUAL, "OP_GREATERTHANOREQUAL", 1, opcodeGreaterThanOrEqual},
-	OP_MIN:                {OP_MIN, "OP_MIN", 1, opcodeMin},
-	OP_MAX:                {OP_MAX, "OP_MAX", 1, opcodeMax},
-	OP_WITHIN:             {OP_WITHIN, "OP_WITHIN", 1, opcodeWithin},
+	OP_1ADD:               {OP_1ADD, "OP_1ADD", 1},
+	OP_1SUB:               {OP_1SUB, "OP_1SUB", 1},
+	OP_2MUL:               {OP_2MUL, "OP_2MUL", 1},
+	OP_2DIV:               {OP_2DIV, "OP_2DIV", 1},
+	OP_NEGATE:             {OP_NEGATE, "OP_NEGATE", 1},
+	OP_ABS:                {OP_ABS, "OP_ABS", 1},
+	OP_NOT:                {OP_NOT, "OP_NOT", 1},
+	OP_0NOTEQUAL:          {OP_0NOTEQUAL, "OP_0NOTEQUAL", 1},
+	OP_ADD:                {OP_ADD, "OP_ADD", 1},
+	OP_SUB:                {OP_SUB, "OP_SUB", 1},
+	OP_MUL:                {OP_MUL, "OP_MUL", 1},
+	OP_DIV:                {OP_DIV, "OP_DIV", 1},
+	OP_MOD:                {OP_MOD, "OP_MOD", 1},
+	OP_LSHIFT:             {OP_LSHIFT, "OP_LSHIFT", 1},
+	OP_RSHIFT:             {OP_RSHIFT, "OP_RSHIFT", 1},
+	OP_BOOLAND:            {OP_BOOLAND, "OP_BOOLAND", 1},
+	OP_BOOLOR:             {OP_BOOLOR, "OP_BOOLOR", 1},
+	OP_NUMEQUAL:           {OP_NUMEQUAL, "OP_NUMEQUAL", 1},
+	OP_NUMEQUALVERIFY:     {OP_NUMEQUALVERIFY, "OP_NUMEQUALVERIFY", 1},
+	OP_NUMNOTEQUAL:        {OP_NUMNOTEQUAL, "OP_NUMNOTEQUAL", 1},
+	OP_LESSTHAN:           {OP_LESSTHAN, "OP_LESSTHAN", 1},
+	OP_GREATERTHAN:        {OP_GREATERTHAN, "OP_GREATERTHAN", 1},
+	OP_LESSTHANOREQUAL:    {OP_LESSTHANOREQUAL, "OP_LESSTHANOREQUAL", 1},
+	OP_GREATERTHANOREQUAL: {OP_GREATERTHANOREQUAL, "OP_GREATERTHANOREQUAL", 1},
+	OP_MIN:                {OP_MIN, "OP_MIN", 1},
+	OP_MAX:                {OP_MAX, "OP_MAX", 1},
+	OP_WITHIN:             {OP_WITHIN, "OP_WITHIN", 1},
 
 	// 加密操作码。
-	OP_RIPEMD160:           {OP_RIPEMD160, "OP_RIPEMD160", 1, opcodeRipemd160},
-	OP_SHA1:                {OP_SHA1, "OP_SHA1", 1, opcodeSha1},
-	OP_SHA256:              {OP_SHA256, "OP_SHA256", 1, opcodeSha256},
-	OP_HASH160:             {OP_HASH160, "OP_HASH160", 1, opcodeHash160},
-	OP_HASH256:             {OP_HASH256, "OP_HASH256", 1, opcodeHash256},
-	OP_CODESEPARATOR:       {OP_CODESEPARATOR, "OP_CODESEPARATOR", 1, opcodeCodeSeparator},
-	OP_CHECKSIG:            {OP_CHECKSIG, "OP_CHECKSIG", 1, opcodeCheckSig},
-	OP_CHECKSIGVERIFY:      {OP_CHECKSIGVERIFY, "OP_CHECKSIGVERIFY", 1, opcodeCheckSigVerify},
-	OP_CHECKMULTISIG:       {OP_CHECKMULTISIG, "OP_CHECKMULTISIG", 1, opcodeCheckMultiSig},
-	OP_CHECKMULTISIGVERIFY: {OP_CHECKMULTISIGVERIFY, "OP_CHECKMULTISIGVERIFY", 1, opcodeCheckMultiSigVerify},
-	OP_CHECKSIGADD:         {OP_CHECKSIGADD, "OP_CHECKSIGADD", 1, opcodeCheckSigAdd},
+	OP_RIPEMD160:           {OP_RIPEMD160, "OP_RIPEMD160", 1},
+	OP_SHA1:                {OP_SHA1, "OP_SHA1", 1},
+	OP_SHA256:              {OP_SHA256, "OP_SHA256", 1},
+	OP_HASH160:             {OP_HASH160, "OP_HASH160", 1},
+	OP_HASH256:             {OP_HASH256, "OP_HASH256", 1},
+	OP_CODESEPARATOR:       {OP_CODESEPARATOR, "OP_CODESEPARATOR", 1},
+	OP_CHECKSIG:            {OP_CHECKSIG, "OP_CHECKSIG", 1},
+	OP_CHECKSIGVERIFY:      {OP_CHECKSIGVERIFY, "OP_CHECKSIGVERIFY", 1},
+	OP_CHECKMULTISIG:       {OP_CHECKMULTISIG, "OP_CHECKMULTISIG", 1},
+	OP_CHECKMULTISIGVERIFY: {OP_CHECKMULTISIGVERIFY, "OP_CHECKMULTISIGVERIFY", 1},
+	OP_CHECKSIGADD:         {OP_CHECKSIGADD, "OP_CHECKSIGADD", 1},
 
 	// 保留的操作码。
-	OP_NOP1:  {OP_NOP1, "OP_NOP1", 1, opcodeNop},
-	OP_NOP4:  {OP_NOP4, "OP_NOP4", 1, opcodeNop},
-	OP_NOP5:  {OP_NOP5, "OP_NOP5", 1, opcodeNop},
-	OP_NOP6:  {OP_NOP6, "OP_NOP6", 1, opcodeNop},
-	OP_NOP7:  {OP_NOP7, "OP_NOP7", 1, opcodeNop},
-	OP_NOP8:  {OP_NOP8, "OP_NOP8", 1, opcodeNop},
-	OP_NOP9:  {OP_NOP9, "OP_NOP9", 1, opcodeNop},
-	OP_NOP10: {OP_NOP10, "OP_NOP10", 1, opcodeNop},
+	OP_NOP1:  {OP_NOP1, "OP_NOP1", 1},
+	OP_NOP4:  {OP_NOP4, "OP_NOP4", 1},
+	OP_NOP5:  {OP_NOP5, "OP_NOP5", 1},
+	OP_NOP6:  {OP_NOP6, "OP_NOP6", 1},
+	OP_NOP7:  {OP_NOP7, "OP_NOP7", 1},
+	OP_NOP8:  {OP_NOP8, "OP_NOP8", 1},
+	OP_NOP9:  {OP_NOP9, "OP_NOP9", 1},
+	OP_NOP10: {OP_NOP10, "OP_NOP10", 1},
 
 	// 未定义的操作码。
-	OP_UNKNOWN187: {OP_UNKNOWN187, "OP_UNKNOWN187", 1, opcodeInvalid},
-	OP_UNKNOWN188: {OP_UNKNOWN188, "OP_UNKNOWN188", 1, opcodeInvalid},
-	OP_UNKNOWN189: {OP_UNKNOWN189, "OP_UNKNOWN189", 1, opcodeInvalid},
-	OP_UNKNOWN190: {OP_UNKNOWN190, "OP_UNKNOWN190", 1, opcodeInvalid},
-	OP_UNKNOWN191: {OP_UNKNOWN191, "OP_UNKNOWN191", 1, opcodeInvalid},
-	OP_UNKNOWN192: {OP_UNKNOWN192, "OP_UNKNOWN192", 1, opcodeInvalid},
-	OP_UNKNOWN193: {OP_UNKNOWN193, "OP_UNKNOWN193", 1, opcodeInvalid},
-	OP_UNKNOWN194: {OP_UNKNOWN194, "OP_UNKNOWN194", 1, opcodeInvalid},
-	OP_UNKNOWN195: {OP_UNKNOWN195, "OP_UNKNOWN195", 1, opcodeInvalid},
-	OP_UNKNOWN196: {OP_UNKNOWN196, "OP_UNKNOWN196", 1, opcodeInvalid},
-	OP_UNKNOWN197: {OP_UNKNOWN197, "OP_UNKNOWN197", 1, opcodeInvalid},
-	OP_UNKNOWN198: {OP_UNKNOWN198, "OP_UNKNOWN198", 1, opcodeInvalid},
-	OP_UNKNOWN199: {OP_UNKNOWN199, "OP_UNKNOWN199", 1, opcodeInvalid},
-	OP_UNKNOWN200: {OP_UNKNOWN200, "OP_UNKNOWN200", 1, opcodeInvalid},
-	OP_UNKNOWN201: {OP_UNKNOWN201, "OP_UNKNOWN201", 1, opcodeInvalid},
-	OP_UNKNOWN202: {OP_UNKNOWN202, "OP_UNKNOWN202", 1, opcodeInvalid},
-	OP_UNKNOWN203: {OP_UNKNOWN203, "OP_UNKNOWN203", 1, opcodeInvalid},
-	OP_UNKNOWN204: {OP_UNKNOWN204, "OP_UNKNOWN204", 1, opcodeInvalid},
-	OP_UNKNOWN205: {OP_UNKNOWN205, "OP_UNKNOWN205", 1, opcodeInvalid},
-	OP_UNKNOWN206: {OP_UNKNOWN206, "OP_UNKNOWN206", 1, opcodeInvalid},
-	OP_UNKNOWN207: {OP_UNKNOWN207, "OP_UNKNOWN207", 1, opcodeInvalid},
-	OP_UNKNOWN208: {OP_UNKNOWN208, "OP_UNKNOWN208", 1, opcodeInvalid},
-	OP_UNKNOWN209: {OP_UNKNOWN209, "OP_UNKNOWN209", 1, opcodeInvalid},
-	OP_UNKNOWN210: {OP_UNKNOWN210, "OP_UNKNOWN210", 1, opcodeInvalid},
-	OP_UNKNOWN211: {OP_UNKNOWN211, "OP_UNKNOWN211", 1, opcodeInvalid},
-	OP_UNKNOWN212: {OP_UNKNOWN212, "OP_UNKNOWN212", 1, opcodeInvalid},
-	OP_UNKNOWN213: {OP_UNKNOWN213, "OP_UNKNOWN213", 1, opcodeInvalid},
-	OP_UNKNOWN214: {OP_UNKNOWN214, "OP_UNKNOWN214", 1, opcodeInvalid},
-	OP_UNKNOWN215: {OP_UNKNOWN215, "OP_UNKNOWN215", 1, opcodeInvalid},
-	OP_UNKNOWN216: {OP_UNKNOWN216, "OP_UNKNOWN216", 1, opcodeInvalid},
-	OP_UNKNOWN217: {OP_UNKNOWN217, "OP_UNKNOWN217", 1, opcodeInvalid},
-	OP_UNKNOWN218: {OP_UNKNOWN218, "OP_UNKNOWN218", 1, opcodeInvalid},
-	OP_UNKNOWN219: {OP_UNKNOWN219, "OP_UNKNOWN219", 1, opcodeInvalid},
-	OP_UNKNOWN220: {OP_UNKNOWN220, "OP_UNKNOWN220", 1, opcodeInvalid},
-	OP_UNKNOWN221: {OP_UNKNOWN221, "OP_UNKNOWN221", 1, opcodeInvalid},
-	OP_UNKNOWN222: {OP_UNKNOWN222, "OP_UNKNOWN222", 1, opcodeInvalid},
-	OP_UNKNOWN223: {OP_UNKNOWN223, "OP_UNKNOWN223", 1, opcodeInvalid},
-	OP_UNKNOWN224: {OP_UNKNOWN224, "OP_UNKNOWN224", 1, opcodeInvalid},
-	OP_UNKNOWN225: {OP_UNKNOWN225, "OP_UNKNOWN225", 1, opcodeInvalid},
-	OP_UNKNOWN226: {OP_UNKNOWN226, "OP_UNKNOWN226", 1, opcodeInvalid},
-	OP_UNKNOWN227: {OP_UNKNOWN227, "OP_UNKNOWN227", 1, opcodeInvalid},
-	OP_UNKNOWN228: {OP_UNKNOWN228, "OP_UNKNOWN228", 1, opcodeInvalid},
-	OP_UNKNOWN229: {OP_UNKNOWN229, "OP_UNKNOWN229", 1, opcodeInvalid},
-	OP_UNKNOWN230: {OP_UNKNOWN230, "OP_UNKNOWN230", 1, opcodeInvalid},
-	OP_UNKNOWN231: {OP_UNKNOWN231, "OP_UNKNOWN231", 1, opcodeInvalid},
-	OP_UNKNOWN232: {OP_UNKNOWN232, "OP_UNKNOWN232", 1, opcodeInvalid},
-	OP_UNKNOWN233: {OP_UNKNOWN233, "OP_UNKNOWN233", 1, opcodeInvalid},
-	OP_UNKNOWN234: {OP_UNKNOWN234, "OP_UNKNOWN234", 1, opcodeInvalid},
-	OP_UNKNOWN235: {OP_UNKNOWN235, "OP_UNKNOWN235", 1, opcodeInvalid},
-	OP_UNKNOWN236: {OP_UNKNOWN236, "OP_UNKNOWN236", 1, opcodeInvalid},
-	OP_UNKNOWN237: {OP_UNKNOWN237, "OP_UNKNOWN237", 1, opcodeInvalid},
-	OP_UNKNOWN238: {OP_UNKNOWN238, "OP_UNKNOWN238", 1, opcodeInvalid},
-	OP_UNKNOWN239: {OP_UNKNOWN239, "OP_UNKNOWN239", 1, opcodeInvalid},
-	OP_UNKNOWN240: {OP_UNKNOWN240, "OP_UNKNOWN240", 1, opcodeInvalid},
-	OP_UNKNOWN241: {OP_UNKNOWN241, "OP_UNKNOWN241", 1, opcodeInvalid},
-	OP_UNKNOWN242: {OP_UNKNOWN242, "OP_UNKNOWN242", 1, opcodeInvalid},
-	OP_UNKNOWN243: {OP_UNKNOWN243, "OP_UNKNOWN243", 1, opcodeInvalid},
-	OP_UNKNOWN244: {OP_UNKNOWN244, "OP_UNKNOWN244", 1, opcodeInvalid},
-	OP_UNKNOWN245: {OP_UNKNOWN245, "OP_UNKNOWN245", 1, opcodeInvalid},
-	OP_UNKNOWN246: {OP_UNKNOWN246, "OP_UNKNOWN246", 1, opcodeInvalid},
-	OP_UNKNOWN247: {OP_UNKNOWN247, "OP_UNKNOWN247", 1, opcodeInvalid},
-	OP_UNKNOWN248: {OP_UNKNOWN248, "OP_UNKNOWN248", 1, opcodeInvalid},
-	OP_UNKNOWN249: {OP_UNKNOWN249, "OP_UNKNOWN249", 1, opcodeInvalid},
+	OP_UNKNOWN187: {OP_UNKNOWN187, "OP_UNKNOWN187", 1},
+	OP_UNKNOWN188: {OP_UNKNOWN188, "OP_UNKNOWN188", 1},
+	OP_UNKNOWN189: {OP_UNKNOWN189, "OP_UNKNOWN189", 1},
+	OP_UNKNOWN190: {OP_UNKNOWN190, "OP_UNKNOWN190", 1},
+	OP_UNKNOWN191: {OP_UNKNOWN191, "OP_UNKNOWN191", 1},
+	OP_UNKNOWN192: {OP_UNKNOWN192, "OP_UNKNOWN192", 1},
+	OP_UNKNOWN193: {OP_CHECKSIGFROMSTACK, "OP_CHECKSIGFROMSTACK", 1},
+	OP_UNKNOWN194: {OP_CHECKSIGFROMSTACKVERIFY, "OP_CHECKSIGFROMSTACKVERIFY", 1},
+	OP_UNKNOWN195: {OP_UNKNOWN195, "OP_UNKNOWN195", 1},
+	OP_UNKNOWN196: {OP_UNKNOWN196, "OP_UNKNOWN196", 1},
+	OP_UNKNOWN197: {OP_UNKNOWN197, "OP_UNKNOWN197", 1},
+	OP_UNKNOWN198: {OP_UNKNOWN198, "OP_UNKNOWN198", 1},
+	OP_UNKNOWN199: {OP_UNKNOWN199, "OP_UNKNOWN199", 1},
+	OP_UNKNOWN200: {OP_UNKNOWN200, "OP_UNKNOWN200", 1},
+	OP_UNKNOWN201: {OP_UNKNOWN201, "OP_UNKNOWN201", 1},
+	OP_UNKNOWN202: {OP_UNKNOWN202, "OP_UNKNOWN202", 1},
+	OP_UNKNOWN203: {OP_UNKNOWN203, "OP_UNKNOWN203", 1},
+	OP_UNKNOWN204: {OP_UNKNOWN204, "OP_UNKNOWN204", 1},
+	OP_UNKNOWN205: {OP_UNKNOWN205, "OP_UNKNOWN205", 1},
+	OP_UNKNOWN206: {OP_UNKNOWN206, "OP_UNKNOWN206", 1},
+	OP_UNKNOWN207: {OP_UNKNOWN207, "OP_UNKNOWN207", 1},
+	OP_UNKNOWN208: {OP_UNKNOWN208, "OP_UNKNOWN208", 1},
+	OP_UNKNOWN209: {OP_UNKNOWN209, "OP_UNKNOWN209", 1},
+	OP_UNKNOWN210: {OP_UNKNOWN210, "OP_UNKNOWN210", 1},
+	OP_UNKNOWN211: {OP_UNKNOWN211, "OP_UNKNOWN211", 1},
+	OP_UNKNOWN212: {OP_UNKNOWN212, "OP_UNKNOWN212", 1},
+	OP_UNKNOWN213: {OP_UNKNOWN213, "OP_UNKNOWN213", 1},
+	OP_UNKNOWN214: {OP_UNKNOWN214, "OP_UNKNOWN214", 1},
+	OP_UNKNOWN215: {OP_UNKNOWN215, "OP_UNKNOWN215", 1},
+	OP_UNKNOWN216: {OP_UNKNOWN216, "OP_UNKNOWN216", 1},
+	OP_UNKNOWN217: {OP_UNKNOWN217, "OP_UNKNOWN217", 1},
+	OP_UNKNOWN218: {OP_UNKNOWN218, "OP_UNKNOWN218", 1},
+	OP_UNKNOWN219: {OP_UNKNOWN219, "OP_UNKNOWN219", 1},
+	OP_UNKNOWN220: {OP_UNKNOWN220, "OP_UNKNOWN220", 1},
+	OP_UNKNOWN221: {OP_UNKNOWN221, "OP_UNKNOWN221", 1},
+	OP_UNKNOWN222: {OP_UNKNOWN222, "OP_UNKNOWN222", 1},
+	OP_UNKNOWN223: {OP_UNKNOWN223, "OP_UNKNOWN223", 1},
+	OP_UNKNOWN224: {OP_UNKNOWN224, "OP_UNKNOWN224", 1},
+	OP_UNKNOWN225: {OP_UNKNOWN225, "OP_UNKNOWN225", 1},
+	OP_UNKNOWN226: {OP_UNKNOWN226, "OP_UNKNOWN226", 1},
+	OP_UNKNOWN227: {OP_UNKNOWN227, "OP_UNKNOWN227", 1},
+	OP_UNKNOWN228: {OP_UNKNOWN228, "OP_UNKNOWN228", 1},
+	OP_UNKNOWN229: {OP_UNKNOWN229, "OP_UNKNOWN229", 1},
+	OP_UNKNOWN230: {OP_UNKNOWN230, "OP_UNKNOWN230", 1},
+	OP_UNKNOWN231: {OP_UNKNOWN231, "OP_UNKNOWN231", 1},
+	OP_UNKNOWN232: {OP_UNKNOWN232, "OP_UNKNOWN232", 1},
+	OP_UNKNOWN233: {OP_UNKNOWN233, "OP_UNKNOWN233", 1},
+	OP_UNKNOWN234: {OP_UNKNOWN234, "OP_UNKNOWN234", 1},
+	OP_UNKNOWN235: {OP_UNKNOWN235, "OP_UNKNOWN235", 1},
+	OP_UNKNOWN236: {OP_UNKNOWN236, "OP_UNKNOWN236", 1},
+	OP_UNKNOWN237: {OP_UNKNOWN237, "OP_UNKNOWN237", 1},
+	OP_UNKNOWN238: {OP_UNKNOWN238, "OP_UNKNOWN238", 1},
+	OP_UNKNOWN239: {OP_UNKNOWN239, "OP_UNKNOWN239", 1},
+	OP_UNKNOWN240: {OP_UNKNOWN240, "OP_UNKNOWN240", 1},
+	OP_UNKNOWN241: {OP_UNKNOWN241, "OP_UNKNOWN241", 1},
+	OP_UNKNOWN242: {OP_UNKNOWN242, "OP_UNKNOWN242", 1},
+	OP_UNKNOWN243: {OP_UNKNOWN243, "OP_UNKNOWN243", 1},
+	OP_UNKNOWN244: {OP_UNKNOWN244, "OP_UNKNOWN244", 1},
+	OP_UNKNOWN245: {OP_UNKNOWN245, "OP_UNKNOWN245", 1},
+	OP_UNKNOWN246: {OP_UNKNOWN246, "OP_UNKNOWN246", 1},
+	OP_UNKNOWN247: {OP_UNKNOWN247, "OP_UNKNOWN247", 1},
+	OP_UNKNOWN248: {OP_UNKNOWN248, "OP_UNKNOWN248", 1},
+	OP_UNKNOWN249: {OP_UNKNOWN249, "OP_UNKNOWN249", 1},
 
 	// 比特币核心内部使用操作码。 此处定义是为了完整性。
-	OP_SMALLINTEGER: {OP_SMALLINTEGER, "OP_SMALLINTEGER", 1, opcodeInvalid},
-	OP_PUBKEYS:      {OP_PUBKEYS, "OP_PUBKEYS", 1, opcodeInvalid},
-	OP_UNKNOWN252:   {OP_UNKNOWN252, "OP_UNKNOWN252", 1, opcodeInvalid},
-	OP_PUBKEYHASH:   {OP_PUBKEYHASH, "OP_PUBKEYHASH", 1, opcodeInvalid},
-	OP_PUBKEY:       {OP_PUBKEY, "OP_PUBKEY", 1, opcodeInvalid},
+	OP_SMALLINTEGER: {OP_SMALLINTEGER, "OP_SMALLINTEGER", 1},
+	OP_PUBKEYS:      {OP_PUBKEYS, "OP_PUBKEYS", 1},
+	OP_UNKNOWN252:   {OP_UNKNOWN252, "OP_UNKNOWN252", 1},
+	OP_PUBKEYHASH:   {OP_PUBKEYHASH, "OP_PUBKEYHASH", 1},
+	OP_PUBKEY:       {OP_PUBKEY, "OP_PUBKEY", 1},
 
-	OP_INVALIDOPCODE: {OP_INVALIDOPCODE, "OP_INVALIDOPCODE", 1, opcodeInvalid},
+	OP_INVALIDOPCODE: {OP_INVALIDOPCODE, "OP_INVALIDOPCODE", 1},
+}
+
+// opcodeJumpTable 是以操作码的值为索引的版本 0 处理函数跳转表。 分派时直接按值索引，而不是经由每个操作码结构中的函数指针。
+var opcodeJumpTable = [256]opcodeHandler{
+	// 数据推送操作码。
+	OP_FALSE:     opcodeFalse,
+	OP_DATA_1:    opcodePushData,
+	OP_DATA_2:    opcodePushData,
+	OP_DATA_3:    opcodePushData,
+	OP_DATA_4:    opcodePushData,
+	OP_DATA_5:    opcodePushData,
+	OP_DATA_6:    opcodePushData,
+	OP_DATA_7:    opcodePushData,
+	OP_DATA_8:    opcodePushData,
+	OP_DATA_9:    opcodePushData,
+	OP_DATA_10:   opcodePushData,
+	OP_DATA_11:   opcodePushData,
+	OP_DATA_12:   opcodePushData,
+	OP_DATA_13:   opcodePushData,
+	OP_DATA_14:   opcodePushData,
+	OP_DATA_15:   opcodePushData,
+	OP_DATA_16:   opcodePushData,
+	OP_DATA_17:   opcodePushData,
+	OP_DATA_18:   opcodePushData,
+	OP_DATA_19:   opcodePushData,
+	OP_DATA_20:   opcodePushData,
+	OP_DATA_21:   opcodePushData,
+	OP_DATA_22:   opcodePushData,
+	OP_DATA_23:   opcodePushData,
+	OP_DATA_24:   opcodePushData,
+	OP_DATA_25:   opcodePushData,
+	OP_DATA_26:   opcodePushData,
+	OP_DATA_27:   opcodePushData,
+	OP_DATA_28:   opcodePushData,
+	OP_DATA_29:   opcodePushData,
+	OP_DATA_30:   opcodePushData,
+	OP_DATA_31:   opcodePushData,
+	OP_DATA_32:   opcodePushData,
+	OP_DATA_33:   opcodePushData,
+	OP_DATA_34:   opcodePushData,
+	OP_DATA_35:   opcodePushData,
+	OP_DATA_36:   opcodePushData,
+	OP_DATA_37:   opcodePushData,
+	OP_DATA_38:   opcodePushData,
+	OP_DATA_39:   opcodePushData,
+	OP_DATA_40:   opcodePushData,
+	OP_DATA_41:   opcodePushData,
+	OP_DATA_42:   opcodePushData,
+	OP_DATA_43:   opcodePushData,
+	OP_DATA_44:   opcodePushData,
+	OP_DATA_45:   opcodePushData,
+	OP_DATA_46:   opcodePushData,
+	OP_DATA_47:   opcodePushData,
+	OP_DATA_48:   opcodePushData,
+	OP_DATA_49:   opcodePushData,
+	OP_DATA_50:   opcodePushData,
+	OP_DATA_51:   opcodePushData,
+	OP_DATA_52:   opcodePushData,
+	OP_DATA_53:   opcodePushData,
+	OP_DATA_54:   opcodePushData,
+	OP_DATA_55:   opcodePushData,
+	OP_DATA_56:   opcodePushData,
+	OP_DATA_57:   opcodePushData,
+	OP_DATA_58:   opcodePushData,
+	OP_DATA_59:   opcodePushData,
+	OP_DATA_60:   opcodePushData,
+	OP_DATA_61:   opcodePushData,
+	OP_DATA_62:   opcodePushData,
+	OP_DATA_63:   opcodePushData,
+	OP_DATA_64:   opcodePushData,
+	OP_DATA_65:   opcodePushData,
+	OP_DATA_66:   opcodePushData,
+	OP_DATA_67:   opcodePushData,
+	OP_DATA_68:   opcodePushData,
+	OP_DATA_69:   opcodePushData,
+	OP_DATA_70:   opcodePushData,
+	OP_DATA_71:   opcodePushData,
+	OP_DATA_72:   opcodePushData,
+	OP_DATA_73:   opcodePushData,
+	OP_DATA_74:   opcodePushData,
+	OP_DATA_75:   opcodePushData,
+	OP_PUSHDATA1: opcodePushData,
+	OP_PUSHDATA2: opcodePushData,
+	OP_PUSHDATA4: opcodePushData,
+	OP_1NEGATE:   opcode1Negate,
+	OP_RESERVED:  opcodeReserved,
+	OP_TRUE:      opcodeN,
+	OP_2:         opcodeN,
+	OP_3:         opcodeN,
+	OP_4:         opcodeN,
+	OP_5:         opcodeN,
+	OP_6:         opcodeN,
+	OP_7:         opcodeN,
+	OP_8:         opcodeN,
+	OP_9:         opcodeN,
+	OP_10:        opcodeN,
+	OP_11:        opcodeN,
+	OP_12:        opcodeN,
+	OP_13:        opcodeN,
+	OP_14:        opcodeN,
+	OP_15:        opcodeN,
+	OP_16:        opcodeN,
+
+	// 控制操作码。
+	OP_NOP:                 opcodeNop,
+	OP_VER:                 opcodeReserved,
+	OP_IF:                  opcodeIf,
+	OP_NOTIF:               opcodeNotIf,
+	OP_VERIF:               opcodeReserved,
+	OP_VERNOTIF:            opcodeReserved,
+	OP_ELSE:                opcodeElse,
+	OP_ENDIF:               opcodeEndif,
+	OP_VERIFY:              opcodeVerify,
+	OP_RETURN:              opcodeReturn,
+	OP_CHECKLOCKTIMEVERIFY: opcodeCheckLockTimeVerify,
+	OP_CHECKSEQUENCEVERIFY: opcodeCheckSequenceVerify,
+
+	// 堆栈操作码。
+	OP_TOALTSTACK:   opcodeToAltStack,
+	OP_FROMALTSTACK: opcodeFromAltStack,
+	OP_2DROP:        opcode2Drop,
+	OP_2DUP:         opcode2Dup,
+	OP_3DUP:         opcode3Dup,
+	OP_2OVER:        opcode2Over,
+	OP_2ROT:         opcode2Rot,
+	OP_2SWAP:        opcode2Swap,
+	OP_IFDUP:        opcodeIfDup,
+	OP_DEPTH:        opcodeDepth,
+	OP_DROP:         opcodeDrop,
+	OP_DUP:          opcodeDup,
+	OP_NIP:          opcodeNip,
+	OP_OVER:         opcodeOver,
+	OP_PICK:         opcodePick,
+	OP_ROLL:         opcodeRoll,
+	OP_ROT:          opcodeRot,
+	OP_SWAP:         opcodeSwap,
+	OP_TUCK:         opcodeTuck,
+
+	// 拼接操作码。
+	OP_CAT:    opcodeDisabled,
+	OP_SUBSTR: opcodeDisabled,
+	OP_LEFT:   opcodeDisabled,
+	OP_RIGHT:  opcodeDisabled,
+	OP_SIZE:   opcodeSize,
+
+	// 按位逻辑操作码。
+	OP_INVERT:      opcodeDisabled,
+	OP_AND:         opcodeDisabled,
+	OP_OR:          opcodeDisabled,
+	OP_XOR:         opcodeDisabled,
+	OP_EQUAL:       opcodeEqual,
+	OP_EQUALVERIFY: opcodeEqualVerify,
+	OP_RESERVED1:   opcodeReserved,
+	OP_RESERVED2:   opcodeReserved,
+
+	// 数字相关的操作码。
+	OP_1ADD:               opcode1Add,
+	OP_1SUB:               opcode1Sub,
+	OP_2MUL:               opcodeDisabled,
+	OP_2DIV:               opcodeDisabled,
+	OP_NEGATE:             opcodeNegate,
+	OP_ABS:                opcodeAbs,
+	OP_NOT:                opcodeNot,
+	OP_0NOTEQUAL:          opcode0NotEqual,
+	OP_ADD:                opcodeAdd,
+	OP_SUB:                opcodeSub,
+	OP_MUL:                opcodeDisabled,
+	OP_DIV:                opcodeDisabled,
+	OP_MOD:                opcodeDisabled,
+	OP_LSHIFT:             opcodeDisabled,
+	OP_RSHIFT:             opcodeDisabled,
+	OP_BOOLAND:            opcodeBoolAnd,
+	OP_BOOLOR:             opcodeBoolOr,
+	OP_NUMEQUAL:           opcodeNumEqual,
+	OP_NUMEQUALVERIFY:     opcodeNumEqualVerify,
+	OP_NUMNOTEQUAL:        opcodeNumNotEqual,
+	OP_LESSTHAN:           opcodeLessThan,
+	OP_GREATERTHAN:        opcodeGreaterThan,
+	OP_LESSTHANOREQUAL:    opcodeLessThanOrEqual,
+	OP_GREATERTHANOREQUAL: opcodeGreaterThanOrEqual,
+	OP_MIN:                opcodeMin,
+	OP_MAX:                opcodeMax,
+	OP_WITHIN:             opcodeWithin,
+
+	// 加密操作码。
+	OP_RIPEMD160:           opcodeRipemd160,
+	OP_SHA1:                opcodeSha1,
+	OP_SHA256:              opcodeSha256,
+	OP_HASH160:             opcodeHash160,
+	OP_HASH256:             opcodeHash256,
+	OP_CODESEPARATOR:       opcodeCodeSeparator,
+	OP_CHECKSIG:            opcodeCheckSig,
+	OP_CHECKSIGVERIFY:      opcodeCheckSigVerify,
+	OP_CHECKMULTISIG:       opcodeCheckMultiSig,
+	OP_CHECKMULTISIGVERIFY: opcodeCheckMultiSigVerify,
+	OP_CHECKSIGADD:         opcodeCheckSigAdd,
+
+	// 保留的操作码。
+	OP_NOP1:  opcodeNop,
+	OP_NOP4:  opcodeNop,
+	OP_NOP5:  opcodeNop,
+	OP_NOP6:  opcodeNop,
+	OP_NOP7:  opcodeNop,
+	OP_NOP8:  opcodeNop,
+	OP_NOP9:  opcodeNop,
+	OP_NOP10: opcodeNop,
+
+	// 未定义的操作码。
+	OP_UNKNOWN187: opcodeInvalid,
+	OP_UNKNOWN188: opcodeInvalid,
+	OP_UNKNOWN189: opcodeInvalid,
+	OP_UNKNOWN190: opcodeInvalid,
+	OP_UNKNOWN191: opcodeInvalid,
+	OP_UNKNOWN192: opcodeInvalid,
+	OP_UNKNOWN193: opcodeCheckSigFromStack,
+	OP_UNKNOWN194: opcodeCheckSigFromStackVerify,
+	OP_UNKNOWN195: opcodeInvalid,
+	OP_UNKNOWN196: opcodeInvalid,
+	OP_UNKNOWN197: opcodeInvalid,
+	OP_UNKNOWN198: opcodeInvalid,
+	OP_UNKNOWN199: opcodeInvalid,
+	OP_UNKNOWN200: opcodeInvalid,
+	OP_UNKNOWN201: opcodeInvalid,
+	OP_UNKNOWN202: opcodeInvalid,
+	OP_UNKNOWN203: opcodeInvalid,
+	OP_UNKNOWN204: opcodeInvalid,
+	OP_UNKNOWN205: opcodeInvalid,
+	OP_UNKNOWN206: opcodeInvalid,
+	OP_UNKNOWN207: opcodeInvalid,
+	OP_UNKNOWN208: opcodeInvalid,
+	OP_UNKNOWN209: opcodeInvalid,
+	OP_UNKNOWN210: opcodeInvalid,
+	OP_UNKNOWN211: opcodeInvalid,
+	OP_UNKNOWN212: opcodeInvalid,
+	OP_UNKNOWN213: opcodeInvalid,
+	OP_UNKNOWN214: opcodeInvalid,
+	OP_UNKNOWN215: opcodeInvalid,
+	OP_UNKNOWN216: opcodeInvalid,
+	OP_UNKNOWN217: opcodeInvalid,
+	OP_UNKNOWN218: opcodeInvalid,
+	OP_UNKNOWN219: opcodeInvalid,
+	OP_UNKNOWN220: opcodeInvalid,
+	OP_UNKNOWN221: opcodeInvalid,
+	OP_UNKNOWN222: opcodeInvalid,
+	OP_UNKNOWN223: opcodeInvalid,
+	OP_UNKNOWN224: opcodeInvalid,
+	OP_UNKNOWN225: opcodeInvalid,
+	OP_UNKNOWN226: opcodeInvalid,
+	OP_UNKNOWN227: opcodeInvalid,
+	OP_UNKNOWN228: opcodeInvalid,
+	OP_UNKNOWN229: opcodeInvalid,
+	OP_UNKNOWN230: opcodeInvalid,
+	OP_UNKNOWN231: opcodeInvalid,
+	OP_UNKNOWN232: opcodeInvalid,
+	OP_UNKNOWN233: opcodeInvalid,
+	OP_UNKNOWN234: opcodeInvalid,
+	OP_UNKNOWN235: opcodeInvalid,
+	OP_UNKNOWN236: opcodeInvalid,
+	OP_UNKNOWN237: opcodeInvalid,
+	OP_UNKNOWN238: opcodeInvalid,
+	OP_UNKNOWN239: opcodeInvalid,
+	OP_UNKNOWN240: opcodeInvalid,
+	OP_UNKNOWN241: opcodeInvalid,
+	OP_UNKNOWN242: opcodeInvalid,
+	OP_UNKNOWN243: opcodeInvalid,
+	OP_UNKNOWN244: opcodeInvalid,
+	OP_UNKNOWN245: opcodeInvalid,
+	OP_UNKNOWN246: opcodeInvalid,
+	OP_UNKNOWN247: opcodeInvalid,
+	OP_UNKNOWN248: opcodeInvalid,
+	OP_UNKNOWN249: opcodeInvalid,
+
+	// 比特币核心内部使用操作码。 此处定义是为了完整性。
+	OP_SMALLINTEGER: opcodeInvalid,
+	OP_PUBKEYS:      opcodeInvalid,
+	OP_UNKNOWN252:   opcodeInvalid,
+	OP_PUBKEYHASH:   opcodeInvalid,
+	OP_PUBKEY:       opcodeInvalid,
+
+	OP_INVALIDOPCODE: opcodeInvalid,
 }
 
 // opcodeOnelineRepls 定义在进行单行反汇编时被替换的操作码名称。 这样做是为了匹配参考实现的输出，同时不更改更好的完整反汇编中的操作码名称。
@@ -789,7 +1069,7 @@ func opcodePushData(op *opcode, data []byte, vm *Engine) error {
 
 // opcode1Negate 将编码为数字的 -1 推送到数据堆栈。
 func opcode1Negate(op *opcode, data []byte, vm *Engine) error {
-	vm.dstack.PushInt(scriptNum(-1))
+	vm.dstack.PushInt(ScriptNum(-1))
 	return nil
 }
 
@@ -797,7 +1077,7 @@ func opcode1Negate(op *opcode, data []byte, vm *Engine) error {
 func opcodeN(op *opcode, data []byte, vm *Engine) error {
 	// The opcodes are all defined consecutively, so the numeric value is
 	// the difference.
-	vm.dstack.PushInt(scriptNum((op.value - (OP_1 - 1))))
+	vm.dstack.PushInt(ScriptNum((op.value - (OP_1 - 1))))
 	return nil
 }
 
@@ -1029,8 +1309,8 @@ func opcodeCheckLockTimeVerify(op *opcode, data []byte, vm *Engine) error {
 
 	// The current transaction locktime is a uint32 resulting in a maximum
 	// locktime of 2^32-1 (the year 2106).  However, scriptNums are signed
-	// and therefore a standard 4-byte scriptNum would only support up to a
-	// maximum of 2^31-1 (the year 2038).  Thus, a 5-byte scriptNum is used
+	// and therefore a standard 4-byte ScriptNum would only support up to a
+	// maximum of 2^31-1 (the year 2038).  Thus, a 5-byte ScriptNum is used
 	// here since it will support up to 2^39-1 which allows dates beyond the
 	// current locktime limit.
 	//
@@ -1100,8 +1380,8 @@ func opcodeCheckSequenceVerify(op *opcode, data []byte, vm *Engine) error {
 
 	// The current transaction sequence is a uint32 resulting in a maximum
 	// sequence of 2^32-1.  However, scriptNums are signed and therefore a
-	// standard 4-byte scriptNum would only support up to a maximum of
-	// 2^31-1.  Thus, a 5-byte scriptNum is used here since it will support
+	// standard 4-byte ScriptNum would only support up to a maximum of
+	// 2^31-1.  Thus, a 5-byte ScriptNum is used here since it will support
 	// up to 2^39-1 which allows sequences beyond the current sequence
 	// limit.
 	//
@@ -1253,7 +1533,7 @@ func opcodeIfDup(op *opcode, data []byte, vm *Engine) error {
 // Example with 2 items: [x1 x2] -> [x1 x2 2]
 // Example with 3 items: [x1 x2 x3] -> [x1 x2 x3 3]
 func opcodeDepth(op *opcode, data []byte, vm *Engine) error {
-	vm.dstack.PushInt(scriptNum(vm.dstack.Depth()))
+	vm.dstack.PushInt(ScriptNum(vm.dstack.Depth()))
 	return nil
 }
 
@@ -1343,7 +1623,7 @@ func opcodeSize(op *opcode, data []byte, vm *Engine) error {
 		return err
 	}
 
-	vm.dstack.PushInt(scriptNum(len(so)))
+	vm.dstack.PushInt(ScriptNum(len(so)))
 	return nil
 }
 
@@ -1446,9 +1726,9 @@ func opcodeNot(op *opcode, data []byte, vm *Engine) error {
 	}
 
 	if m == 0 {
-		vm.dstack.PushInt(scriptNum(1))
+		vm.dstack.PushInt(ScriptNum(1))
 	} else {
-		vm.dstack.PushInt(scriptNum(0))
+		vm.dstack.PushInt(ScriptNum(0))
 	}
 	return nil
 }
@@ -1525,9 +1805,9 @@ func opcodeBoolAnd(op *opcode, data []byte, vm *Engine) error {
 	}
 
 	if v0 != 0 && v1 != 0 {
-		vm.dstack.PushInt(scriptNum(1))
+		vm.dstack.PushInt(ScriptNum(1))
 	} else {
-		vm.dstack.PushInt(scriptNum(0))
+		vm.dstack.PushInt(ScriptNum(0))
 	}
 
 	return nil
@@ -1551,9 +1831,9 @@ func opcodeBoolOr(op *opcode, data []byte, vm *Engine) error {
 	}
 
 	if v0 != 0 || v1 != 0 {
-		vm.dstack.PushInt(scriptNum(1))
+		vm.dstack.PushInt(ScriptNum(1))
 	} else {
-		vm.dstack.PushInt(scriptNum(0))
+		vm.dstack.PushInt(ScriptNum(0))
 	}
 
 	return nil
@@ -1575,9 +1855,9 @@ func opcodeNumEqual(op *opcode, data []byte, vm *Engine) error {
 	}
 
 	if v0 == v1 {
-		vm.dstack.PushInt(scriptNum(1))
+		vm.dstack.PushInt(ScriptNum(1))
 	} else {
-		vm.dstack.PushInt(scriptNum(0))
+		vm.dstack.PushInt(ScriptNum(0))
 	}
 
 	return nil
@@ -1614,9 +1894,9 @@ func opcodeNumNotEqual(op *opcode, data []byte, vm *Engine) error {
 	}
 
 	if v0 != v1 {
-		vm.dstack.PushInt(scriptNum(1))
+		vm.dstack.PushInt(ScriptNum(1))
 	} else {
-		vm.dstack.PushInt(scriptNum(0))
+		vm.dstack.PushInt(ScriptNum(0))
 	}
 
 	return nil
@@ -1637,9 +1917,9 @@ func opcodeLessThan(op *opcode, data []byte, vm *Engine) error {
 	}
 
 	if v1 < v0 {
-		vm.dstack.PushInt(scriptNum(1))
+		vm.dstack.PushInt(ScriptNum(1))
 	} else {
-		vm.dstack.PushInt(scriptNum(0))
+		vm.dstack.PushInt(ScriptNum(0))
 	}
 
 	return nil
@@ -1661,9 +1941,9 @@ func opcodeGreaterThan(op *opcode, data []byte, vm *Engine) error {
 	}
 
 	if v1 > v0 {
-		vm.dstack.PushInt(scriptNum(1))
+		vm.dstack.PushInt(ScriptNum(1))
 	} else {
-		vm.dstack.PushInt(scriptNum(0))
+		vm.dstack.PushInt(ScriptNum(0))
 	}
 	return nil
 }
@@ -1684,9 +1964,9 @@ func opcodeLessThanOrEqual(op *opcode, data []byte, vm *Engine) error {
 	}
 
 	if v1 <= v0 {
-		vm.dstack.PushInt(scriptNum(1))
+		vm.dstack.PushInt(ScriptNum(1))
 	} else {
-		vm.dstack.PushInt(scriptNum(0))
+		vm.dstack.PushInt(ScriptNum(0))
 	}
 	return nil
 }
@@ -1707,9 +1987,9 @@ func opcodeGreaterThanOrEqual(op *opcode, data []byte, vm *Engine) error {
 	}
 
 	if v1 >= v0 {
-		vm.dstack.PushInt(scriptNum(1))
+		vm.dstack.PushInt(ScriptNum(1))
 	} else {
-		vm.dstack.PushInt(scriptNum(0))
+		vm.dstack.PushInt(ScriptNum(0))
 	}
 
 	return nil
@@ -1783,9 +2063,9 @@ func opcodeWithin(op *opcode, data []byte, vm *Engine) error {
 	}
 
 	if x >= minVal && x < maxVal {
-		vm.dstack.PushInt(scriptNum(1))
+		vm.dstack.PushInt(ScriptNum(1))
 	} else {
-		vm.dstack.PushInt(scriptNum(0))
+		vm.dstack.PushInt(ScriptNum(0))
 	}
 	return nil
 }
@@ -1805,6 +2085,7 @@ func opcodeRipemd160(op *opcode, data []byte, vm *Engine) error {
 		return err
 	}
 
+	vm.recordHashed(len(buf))
 	vm.dstack.PushByteArray(calcHash(buf, ripemd160.New()))
 	return nil
 }
@@ -1818,8 +2099,9 @@ func opcodeSha1(op *opcode, data []byte, vm *Engine) error {
 		return err
 	}
 
+	vm.recordHashed(len(buf))
 	hash := sha1.Sum(buf)
-	vm.dstack.PushByteArray(hash[:])
+	vm.dstack.pushCopy(hash[:])
 	return nil
 }
 
@@ -1832,8 +2114,9 @@ func opcodeSha256(op *opcode, data []byte, vm *Engine) error {
 		return err
 	}
 
+	vm.recordHashed(len(buf))
 	hash := sha256.Sum256(buf)
-	vm.dstack.PushByteArray(hash[:])
+	vm.dstack.pushCopy(hash[:])
 	return nil
 }
 
@@ -1846,6 +2129,7 @@ func opcodeHash160(op *opcode, data []byte, vm *Engine) error {
 		return err
 	}
 
+	vm.recordHashed(len(buf) + sha256.Size)
 	hash := sha256.Sum256(buf)
 	vm.dstack.PushByteArray(calcHash(hash[:], ripemd160.New()))
 	return nil
@@ -1860,7 +2144,9 @@ func opcodeHash256(op *opcode, data []byte, vm *Engine) error {
 		return err
 	}
 
-	vm.dstack.PushByteArray(chainhash.DoubleHashB(buf))
+	vm.recordHashed(len(buf) + chainhash.HashSize)
+	hash := chainhash.DoubleHashH(buf)
+	vm.dstack.pushCopy(hash[:])
 	return nil
 }
 
@@ -1985,7 +2271,7 @@ func opcodeCheckSig(op *opcode, data []byte, vm *Engine) error {
 		// TODO(roasbeef): return an error?
 	}
 
-	valid := sigVerifier.Verify()
+	valid := vm.verifySignature(sigVerifier, fullSigBytes)
 
 	switch {
 	// For tapscript, and prior execution with null fail active, if the
@@ -2073,7 +2359,7 @@ func opcodeCheckSigAdd(op *opcode, data []byte, vm *Engine) error {
 		return err
 	}
 
-	valid := sigVerifier.Verify()
+	valid := vm.verifySignature(sigVerifier, sigBytes)
 
 	// If the signature is invalid, this we fail execution, as it should
 	// have been an empty signature.
@@ -2126,15 +2412,15 @@ func opcodeCheckMultiSig(op *opcode, data []byte, vm *Engine) error {
 			numPubKeys)
 		return scriptError(ErrInvalidPubKeyCount, str)
 	}
-	if numPubKeys > MaxPubKeysPerMultiSig {
+	if numPubKeys > vm.limits.MaxPubKeysPerMultiSig {
 		str := fmt.Sprintf("too many pubkeys: %d > %d",
-			numPubKeys, MaxPubKeysPerMultiSig)
+			numPubKeys, vm.limits.MaxPubKeysPerMultiSig)
 		return scriptError(ErrInvalidPubKeyCount, str)
 	}
 	vm.numOps += numPubKeys
-	if vm.numOps > MaxOpsPerScript {
+	if vm.numOps > vm.limits.MaxOpsPerScript {
 		str := fmt.Sprintf("exceeded max operation limit of %d",
-			MaxOpsPerScript)
+			vm.limits.MaxOpsPerScript)
 		return scriptError(ErrTooManyOperations, str)
 	}
 
@@ -2287,33 +2573,42 @@ func opcodeCheckMultiSig(op *opcode, data []byte, vm *Engine) error {
 				sigHashes = vm.hashCache
 			} else {
 				sigHashes = NewTxSigHashes(
-					&vm.tx, vm.prevOutFetcher,
+					vm.tx, vm.prevOutFetcher,
 				)
 			}
 
 			hash, err = calcWitnessSignatureHashRaw(script, sigHashes, hashType,
-				&vm.tx, vm.txIdx, vm.inputAmount)
+				vm.tx, vm.txIdx, vm.inputAmount, vm.chainTag)
 			if err != nil {
 				return err
 			}
 		} else {
-			hash = calcSignatureHash(script, hashType, &vm.tx, vm.txIdx)
+			hash = calcSignatureHash(
+				script, hashType, vm.tx, vm.txIdx, vm.chainTag,
+			)
 		}
 
+		vm.recordSigOp()
+
 		var valid bool
 		if vm.sigCache != nil {
 			var sigHash chainhash.Hash
 			copy(sigHash[:], hash)
 
 			valid = vm.sigCache.Exists(sigHash, signature, pubKey)
-			if !valid && parsedSig.Verify(hash, parsedPubKey) {
+			if !valid && vm.verifier().VerifyECDSA(
+				parsedSig, hash, parsedPubKey) {
+
 				vm.sigCache.Add(sigHash, signature, pubKey)
 				valid = true
 			}
 		} else {
-			valid = parsedSig.Verify(hash, parsedPubKey)
+			valid = vm.verifier().VerifyECDSA(
+				parsedSig, hash, parsedPubKey,
+			)
 		}
 
+		vm.recordSigCheck(SigTypeECDSA, valid)
 		if valid {
 			// PubKey verified, move on to the next signature.
 			signatureIdx++
@@ -117,7 +117,15 @@ func TestOpcodeDisasm(t *testing.T) {
 
 		// OP_UNKNOWN#.
 		case opcodeVal >= 0xbb && opcodeVal <= 0xf9 || opcodeVal == 0xfc:
-			expectedStr = "OP_UNKNOWN" + strconv.Itoa(opcodeVal)
+			switch opcodeVal {
+			// OP_UNKNOWN193 和 OP_UNKNOWN194 现在是 OP_CHECKSIGFROMSTACK 和 OP_CHECKSIGFROMSTACKVERIFY。
+			case 0xc1:
+				expectedStr = "OP_CHECKSIGFROMSTACK"
+			case 0xc2:
+				expectedStr = "OP_CHECKSIGFROMSTACKVERIFY"
+			default:
+				expectedStr = "OP_UNKNOWN" + strconv.Itoa(opcodeVal)
+			}
 		}
 
 		var buf strings.Builder
@@ -187,6 +195,10 @@ func TestOpcodeDisasm(t *testing.T) {
 			// OP_UNKNOWN186 又名 0xba 现在是 OP_CHECKSIGADD。
 			case 0xba:
 				expectedStr = "OP_CHECKSIGADD"
+			case 0xc1:
+				expectedStr = "OP_CHECKSIGFROMSTACK"
+			case 0xc2:
+				expectedStr = "OP_CHECKSIGFROMSTACKVERIFY"
 			default:
 				expectedStr = "OP_UNKNOWN" + strconv.Itoa(opcodeVal)
 			}
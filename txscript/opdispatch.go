@@ -0,0 +1,64 @@
+// 包含操作码分派。 区块中绝大多数是标准脚本，执行的操作码集中在数据推送、OP_DUP、OP_HASH160、OP_EQUALVERIFY
+// 和 OP_CHECKSIG 等少数几个上，对这些操作码直接调用其处理函数可以避免间接调用，并使编译器能够内联较小的处理函数（热路径）。
+// 其余操作码按值索引 opcodeJumpTable 分派（冷路径），脚本版本重新定义的操作码使用该版本的处理函数表。
+
+package txscript
+
+// dispatchOpcode 执行未被脚本版本重新定义的操作码。 热路径上的操作码直接调用与 opcodeJumpTable 中相同的处理函数，
+// 因此两条路径的行为完全一致。
+func dispatchOpcode(op *opcode, data []byte, vm *Engine) error {
+	switch op.value {
+	case OP_0:
+		return opcodeFalse(op, data, vm)
+
+	case OP_DATA_1, OP_DATA_2, OP_DATA_3, OP_DATA_4, OP_DATA_5, OP_DATA_6,
+		OP_DATA_7, OP_DATA_8, OP_DATA_9, OP_DATA_10, OP_DATA_11,
+		OP_DATA_12, OP_DATA_13, OP_DATA_14, OP_DATA_15, OP_DATA_16,
+		OP_DATA_17, OP_DATA_18, OP_DATA_19, OP_DATA_20, OP_DATA_21,
+		OP_DATA_22, OP_DATA_23, OP_DATA_24, OP_DATA_25, OP_DATA_26,
+		OP_DATA_27, OP_DATA_28, OP_DATA_29, OP_DATA_30, OP_DATA_31,
+		OP_DATA_32, OP_DATA_33, OP_DATA_34, OP_DATA_35, OP_DATA_36,
+		OP_DATA_37, OP_DATA_38, OP_DATA_39, OP_DATA_40, OP_DATA_41,
+		OP_DATA_42, OP_DATA_43, OP_DATA_44, OP_DATA_45, OP_DATA_46,
+		OP_DATA_47, OP_DATA_48, OP_DATA_49, OP_DATA_50, OP_DATA_51,
+		OP_DATA_52, OP_DATA_53, OP_DATA_54, OP_DATA_55, OP_DATA_56,
+		OP_DATA_57, OP_DATA_58, OP_DATA_59, OP_DATA_60, OP_DATA_61,
+		OP_DATA_62, OP_DATA_63, OP_DATA_64, OP_DATA_65, OP_DATA_66,
+		OP_DATA_67, OP_DATA_68, OP_DATA_69, OP_DATA_70, OP_DATA_71,
+		OP_DATA_72, OP_DATA_73, OP_DATA_74, OP_DATA_75, OP_PUSHDATA1,
+		OP_PUSHDATA2, OP_PUSHDATA4:
+
+		return opcodePushData(op, data, vm)
+
+	case OP_1, OP_2, OP_3, OP_4, OP_5, OP_6, OP_7, OP_8, OP_9, OP_10,
+		OP_11, OP_12, OP_13, OP_14, OP_15, OP_16:
+
+		return opcodeN(op, data, vm)
+
+	case OP_VERIFY:
+		return opcodeVerify(op, data, vm)
+
+	case OP_DROP:
+		return opcodeDrop(op, data, vm)
+
+	case OP_DUP:
+		return opcodeDup(op, data, vm)
+
+	case OP_EQUAL:
+		return opcodeEqual(op, data, vm)
+
+	case OP_EQUALVERIFY:
+		return opcodeEqualVerify(op, data, vm)
+
+	case OP_HASH160:
+		return opcodeHash160(op, data, vm)
+
+	case OP_CHECKSIG:
+		return opcodeCheckSig(op, data, vm)
+
+	case OP_CHECKSIGVERIFY:
+		return opcodeCheckSigVerify(op, data, vm)
+	}
+
+	return opcodeJumpTable[op.value](op, data, vm)
+}
@@ -0,0 +1,166 @@
+package txscript
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// TestDispatchOpcodeDifferential 确保对每个操作码，热路径分派与经由跳转表的分派在各种堆栈上产生相同的错误和引擎状态。
+func TestDispatchOpcodeDifferential(t *testing.T) {
+	t.Parallel()
+
+	pubKey := hexToBytes("0279be667ef9dcbbac55a06295ce870b07029bfcdb2dce28" +
+		"d959f2815b16f81798")
+	stacks := [][][]byte{
+		nil,
+		{{}},
+		{{1}},
+		{{1}, {1}},
+		{{1}, {2}, {3}},
+		{{}, {0x81}, {0xff, 0xff}, {1, 2, 3, 4}},
+		{{0x80}, {0x00}, {2}, {2}, {1}},
+		{make([]byte, 71), pubKey},
+		{{}, pubKey[1:]},
+		{{1}, pubKey},
+	}
+	sigVersions := []SigVersion{
+		SigVersionBase, SigVersionWitnessV0, SigVersionTapscript,
+	}
+
+	for value := 0; value < len(opcodeArray); value++ {
+		op := &opcodeArray[value]
+		var data []byte
+		switch {
+		case op.length > 1:
+			data = make([]byte, op.length-1)
+		case op.length < 0:
+			data = []byte{0x01, 0x02}
+		}
+
+		for _, sigVersion := range sigVersions {
+			for i, stk := range stacks {
+				newEngine := func() *Engine {
+					vm, err := NewSimulationEngine(SimulationParams{
+						Script:     []byte{OP_TRUE},
+						Stack:      stk,
+						SigVersion: sigVersion,
+					})
+					require.NoError(t, err)
+					return vm
+				}
+				hot, cold := newEngine(), newEngine()
+
+				desc := fmt.Sprintf("%s, sig version %v, stack %d",
+					op.name, sigVersion, i)
+				hotErr := dispatchOpcode(op, data, hot)
+				coldErr := opcodeJumpTable[value](op, data, cold)
+				require.Equal(t, coldErr, hotErr, desc)
+				require.Equal(t, cold.GetStack(), hot.GetStack(), desc)
+				require.Equal(t, cold.GetAltStack(), hot.GetAltStack(),
+					desc)
+				require.Equal(t, cold.condStack, hot.condStack, desc)
+			}
+		}
+	}
+}
+
+// TestDispatchOpcodeRecordedResults 确保通过引擎单步执行每个操作码的结果与 data/opdispatch_results.json 一致。
+// 该文件由引入热路径和跳转表之前、经由每个操作码结构中的函数指针分派的实现生成，因此分派方式的改变不会改变任何操作码的行为。
+func TestDispatchOpcodeRecordedResults(t *testing.T) {
+	t.Parallel()
+
+	file, err := os.ReadFile("data/opdispatch_results.json")
+	require.NoError(t, err)
+	var recorded map[string]string
+	require.NoError(t, json.Unmarshal(file, &recorded))
+
+	got := opcodeDispatchDigests(t)
+	for value := 0; value < len(opcodeArray); value++ {
+		key := fmt.Sprintf("%#02x", value)
+		require.Equal(t, recorded[key], got[key], opcodeArray[value].name)
+	}
+}
+
+// opcodeDispatchDigests 对每个操作码，在各种签名版本、脚本标志和初始堆栈上通过引擎单步执行它，
+// 并返回以操作码值（如 "0x76"）为键、所有执行结果的 SHA256 摘要为值的映射。 结果包括错误代码以及执行后的数据堆栈、
+// 备用堆栈和条件堆栈。
+func opcodeDispatchDigests(t *testing.T) map[string]string {
+	pubKey := hexToBytes("0279be667ef9dcbbac55a06295ce870b07029bfcdb2dce28" +
+		"d959f2815b16f81798")
+	stacks := [][][]byte{
+		nil,
+		{{}},
+		{{1}},
+		{{1}, {1}},
+		{{1}, {2}, {3}},
+		{{}, {0x81}, {0xff, 0xff}, {1, 2, 3, 4}},
+		{{0x80}, {0x00}, {2}, {2}, {1}},
+		{make([]byte, 71), pubKey},
+		{{}, pubKey[1:]},
+		{{1}, pubKey},
+		{{2}, {1}, {3}, {4}, {2}, {5}, {6}, {7}},
+	}
+	sigVersions := []SigVersion{
+		SigVersionBase, SigVersionWitnessV0, SigVersionTapscript,
+	}
+	flagSets := []ScriptFlags{0, StandardVerifyFlags}
+
+	digests := make(map[string]string, len(opcodeArray))
+	for value := 0; value < len(opcodeArray); value++ {
+		op := &opcodeArray[value]
+		script := []byte{op.value}
+		switch {
+		case op.length > 1:
+			script = append(script, make([]byte, op.length-1)...)
+		case op.value == OP_PUSHDATA1:
+			script = append(script, 0x02, 0x01, 0x02)
+		case op.value == OP_PUSHDATA2:
+			script = append(script, 0x02, 0x00, 0x01, 0x02)
+		case op.value == OP_PUSHDATA4:
+			script = append(script, 0x02, 0x00, 0x00, 0x00, 0x01, 0x02)
+		}
+
+		hash := sha256.New()
+		for _, sigVersion := range sigVersions {
+			for fi, flags := range flagSets {
+				for si, stk := range stacks {
+					fmt.Fprintf(hash, "%v/%d/%d:", sigVersion, fi, si)
+					vm, err := NewSimulationEngine(SimulationParams{
+						Script:     script,
+						Stack:      stk,
+						SigVersion: sigVersion,
+						Flags:      flags,
+					})
+					if err == nil {
+						var done bool
+						done, err = vm.Step()
+						fmt.Fprintf(hash, " done=%v", done)
+					}
+					switch e := err.(type) {
+					case nil:
+					case Error:
+						fmt.Fprintf(hash, " err=%v", e.ErrorCode)
+					default:
+						fmt.Fprintf(hash, " err=other")
+					}
+					if vm != nil {
+						fmt.Fprintf(hash, " stack=%x alt=%x cond=%v",
+							vm.GetStack(), vm.GetAltStack(),
+							vm.condStack)
+					}
+					fmt.Fprintln(hash)
+				}
+			}
+		}
+		key := fmt.Sprintf("%#02x", value)
+		digests[key] = hex.EncodeToString(hash.Sum(nil))
+	}
+	require.Len(t, digests, len(opcodeArray))
+	return digests
+}
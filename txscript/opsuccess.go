@@ -0,0 +1,135 @@
+// 包含 tapscript OP_SUCCESS 分析，用于精确报告叶子脚本依赖哪些未来升级的操作码，供内存池策略和钱包提示使用。
+
+package txscript
+
+import "fmt"
+
+// OpSuccessLocation 描述 tapscript 中一个 OP_SUCCESSx 操作码的位置。
+type OpSuccessLocation struct {
+	// Opcode 是操作码的值，例如 OP_CAT 为 126。
+	Opcode byte
+
+	// Offset 是操作码在脚本中的字节偏移量。
+	Offset int
+
+	// Position 是操作码在脚本中的序号，数据推送及其数据只计为一个操作码。
+	Position int
+}
+
+// String 返回位置的可读描述，例如 "OP_CAT (0x7e) at offset 3"。
+func (l OpSuccessLocation) String() string {
+	return fmt.Sprintf("%s (0x%02x) at offset %d", opcodeArray[l.Opcode].name,
+		l.Opcode, l.Offset)
+}
+
+// FindOpSuccess 按出现顺序返回 tapscript 中所有 OP_SUCCESSx 操作码的位置。
+//
+// 与 BIP0342 对脚本的预扫描一致，扫描在第一个解析错误处停止：解析错误之前出现的 OP_SUCCESSx 会使脚本无条件成功，
+// 之后的字节不被视为操作码。 返回的错误是扫描停止时遇到的解析错误，没有错误时为 nil。
+func FindOpSuccess(script []byte) ([]OpSuccessLocation, error) {
+	var locations []OpSuccessLocation
+
+	const scriptVersion = 0
+	tokenizer := MakeScriptTokenizer(scriptVersion, script)
+	for tokenizer.Next() {
+		op := tokenizer.Opcode()
+		if _, ok := successOpcodes[op]; !ok {
+			continue
+		}
+
+		// OP_SUCCESSx opcodes never carry data, so the opcode is the
+		// single byte before the tokenizer's current offset.
+		locations = append(locations, OpSuccessLocation{
+			Opcode:   op,
+			Offset:   int(tokenizer.ByteIndex()) - 1,
+			Position: int(tokenizer.OpcodePosition()),
+		})
+	}
+
+	return locations, tokenizer.Err()
+}
+
+// TapLeafPolicyClass 是 AnalyzeTapLeaf 对 tapscript 叶子的策略分类。
+type TapLeafPolicyClass uint8
+
+const (
+	// TapLeafStandard 表示叶子使用基本叶子版本、可以解析且不包含 OP_SUCCESSx，其语义完全由当前共识规则定义。
+	TapLeafStandard TapLeafPolicyClass = iota
+
+	// TapLeafUpgradeReliant 表示叶子包含 OP_SUCCESSx。 按当前共识规则，任何人都可以通过该叶子花费输出，
+	// 只有在未来软分叉为这些操作码赋予语义后叶子才具有约束力。
+	TapLeafUpgradeReliant
+
+	// TapLeafUnknownVersion 表示叶子使用未定义的叶子版本，按当前共识规则其脚本不被执行，任何人都可以通过该叶子花费输出。
+	TapLeafUnknownVersion
+
+	// TapLeafUnparseable 表示叶子不包含 OP_SUCCESSx 但脚本无法解析，通过该叶子的花费总是失败。
+	TapLeafUnparseable
+)
+
+// tapLeafPolicyClassNames 包含每个分类的名称。
+var tapLeafPolicyClassNames = []string{
+	TapLeafStandard:       "standard",
+	TapLeafUpgradeReliant: "upgrade-reliant",
+	TapLeafUnknownVersion: "unknown-leaf-version",
+	TapLeafUnparseable:    "unparseable",
+}
+
+// String 返回分类的名称。
+func (c TapLeafPolicyClass) String() string {
+	if int(c) >= len(tapLeafPolicyClassNames) {
+		return fmt.Sprintf("TapLeafPolicyClass(%d)", uint8(c))
+	}
+	return tapLeafPolicyClassNames[c]
+}
+
+// TapLeafAnalysis 是 AnalyzeTapLeaf 的结果。
+type TapLeafAnalysis struct {
+	// Class 是叶子的策略分类。
+	Class TapLeafPolicyClass
+
+	// OpSuccess 是叶子脚本中所有 OP_SUCCESSx 的位置。 只有 Class 为 TapLeafUpgradeReliant 时非空。
+	OpSuccess []OpSuccessLocation
+
+	// ParseErr 是扫描脚本时遇到的解析错误。 对于依赖升级的叶子，解析错误之前已经出现了 OP_SUCCESSx，因此不影响分类。
+	ParseErr error
+}
+
+// IsUpgradeReliant 返回叶子是否依赖未来的共识升级才具有约束力，即包含 OP_SUCCESSx 或使用未知叶子版本。
+// 钱包可以在向这样的叶子付款前警告用户，内存池可以据此拒绝创建或花费这类输出。
+func (a *TapLeafAnalysis) IsUpgradeReliant() bool {
+	return a.Class == TapLeafUpgradeReliant || a.Class == TapLeafUnknownVersion
+}
+
+// UsesOpcode 返回叶子是否包含给定的 OP_SUCCESSx 操作码，便于只允许部分已知升级的策略做出判断。
+func (a *TapLeafAnalysis) UsesOpcode(op byte) bool {
+	for _, loc := range a.OpSuccess {
+		if loc.Opcode == op {
+			return true
+		}
+	}
+	return false
+}
+
+// AnalyzeTapLeaf 分析 tapscript 叶子，报告其中所有的 OP_SUCCESSx 及其位置，并对叶子进行策略分类。
+// 与引擎在验证时只区分接受和 ScriptVerifyDiscourageOpSuccess 拒绝不同，调用者可以根据具体的操作码和位置做出决定。
+func AnalyzeTapLeaf(leaf TapLeaf) TapLeafAnalysis {
+	if leaf.LeafVersion != BaseLeafVersion {
+		return TapLeafAnalysis{Class: TapLeafUnknownVersion}
+	}
+
+	locations, err := FindOpSuccess(leaf.Script)
+	switch {
+	case len(locations) != 0:
+		return TapLeafAnalysis{
+			Class:     TapLeafUpgradeReliant,
+			OpSuccess: locations,
+			ParseErr:  err,
+		}
+
+	case err != nil:
+		return TapLeafAnalysis{Class: TapLeafUnparseable, ParseErr: err}
+	}
+
+	return TapLeafAnalysis{Class: TapLeafStandard}
+}
@@ -0,0 +1,87 @@
+package txscript
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// TestFindOpSuccess 确保 FindOpSuccess 报告每个 OP_SUCCESSx 的操作码、字节偏移和序号，并在第一个解析错误处停止。
+func TestFindOpSuccess(t *testing.T) {
+	t.Parallel()
+
+	script := mustParseShortForm("DATA_2 0x0102 CAT 1 0x50 DROP 0xbb")
+	locations, err := FindOpSuccess(script)
+	require.NoError(t, err)
+	require.Equal(t, []OpSuccessLocation{
+		{Opcode: OP_CAT, Offset: 3, Position: 1},
+		{Opcode: OP_RESERVED, Offset: 5, Position: 3},
+		{Opcode: 0xbb, Offset: 7, Position: 5},
+	}, locations)
+	require.Equal(t, "OP_CAT (0x7e) at offset 3", locations[0].String())
+
+	// 截断的数据推送之后的字节不被视为操作码。
+	script = append(mustParseShortForm("CAT"), OP_PUSHDATA1, 0x05, OP_CAT)
+	locations, err = FindOpSuccess(script)
+	require.Error(t, err)
+	require.Len(t, locations, 1)
+
+	locations, err = FindOpSuccess(mustParseShortForm("1 DROP 1"))
+	require.NoError(t, err)
+	require.Empty(t, locations)
+}
+
+// TestAnalyzeTapLeaf 确保叶子被正确分类，并且分类与引擎对 OP_SUCCESS 和未知叶子版本的处理一致。
+func TestAnalyzeTapLeaf(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		leaf     TapLeaf
+		class    TapLeafPolicyClass
+		reliant  bool
+		parseErr bool
+	}{{
+		name:  "standard",
+		leaf:  NewBaseTapLeaf(mustParseShortForm("1")),
+		class: TapLeafStandard,
+	}, {
+		name:    "op_success",
+		leaf:    NewBaseTapLeaf(mustParseShortForm("1 VERIFY 0 CAT")),
+		class:   TapLeafUpgradeReliant,
+		reliant: true,
+	}, {
+		name: "op_success before parse error",
+		leaf: NewBaseTapLeaf(append(mustParseShortForm("CAT"),
+			OP_PUSHDATA1)),
+		class:    TapLeafUpgradeReliant,
+		reliant:  true,
+		parseErr: true,
+	}, {
+		name:     "unparseable",
+		leaf:     NewBaseTapLeaf([]byte{OP_DATA_2, 0x01}),
+		class:    TapLeafUnparseable,
+		parseErr: true,
+	}, {
+		name:    "unknown leaf version",
+		leaf:    NewTapLeaf(0xc2, mustParseShortForm("0")),
+		class:   TapLeafUnknownVersion,
+		reliant: true,
+	}}
+
+	for _, test := range tests {
+		analysis := AnalyzeTapLeaf(test.leaf)
+		require.Equal(t, test.class, analysis.Class, test.name)
+		require.Equal(t, test.reliant, analysis.IsUpgradeReliant(), test.name)
+		require.Equal(t, test.parseErr, analysis.ParseErr != nil, test.name)
+		require.Equal(t, test.class == TapLeafUpgradeReliant,
+			analysis.UsesOpcode(OP_CAT), test.name)
+
+		// 只有包含 OP_SUCCESSx 的叶子会被 ScriptHasOpSuccess 识别。
+		require.Equal(t, test.class == TapLeafUpgradeReliant,
+			ScriptHasOpSuccess(test.leaf.Script), test.name)
+	}
+
+	require.Equal(t, "upgrade-reliant", TapLeafUpgradeReliant.String())
+	require.Equal(t, "TapLeafPolicyClass(9)", TapLeafPolicyClass(9).String())
+}
@@ -0,0 +1,309 @@
+// 包含脚本大小优化器，用于在描述符或 miniscript 等工具生成的脚本不够紧凑时将其重写为更小的等价形式，
+// 并为揭示脚本选择 P2WSH 或 tapscript 叶子中见证重量更小的一种编码。
+
+package txscript
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	"github.com/btcsuite/btcd/wire"
+)
+
+// ErrNoScriptEncoding 在优化目标中的所有候选编码都无法承载脚本时返回。
+var ErrNoScriptEncoding = errors.New("no candidate encoding can carry the " +
+	"script")
+
+// ScriptEncoding 表示揭示脚本在链上的承载方式。
+type ScriptEncoding uint8
+
+const (
+	// EncodingP2WSH 表示脚本作为 P2WSH 见证脚本被花费。
+	EncodingP2WSH ScriptEncoding = iota
+
+	// EncodingTapscript 表示脚本作为 taproot 脚本树中的 tapscript 叶子被花费。
+	EncodingTapscript
+)
+
+// String 返回编码的可读名称。
+func (e ScriptEncoding) String() string {
+	switch e {
+	case EncodingP2WSH:
+		return "p2wsh"
+	case EncodingTapscript:
+		return "tapscript"
+	default:
+		return fmt.Sprintf("unknown encoding %d", uint8(e))
+	}
+}
+
+// OptimizeTarget 描述优化后的脚本可以使用的编码。
+type OptimizeTarget struct {
+	// Encodings 是候选编码。 为空时使用 EncodingP2WSH 和 EncodingTapscript。
+	Encodings []ScriptEncoding
+
+	// TapTreeDepth 是叶子在 taproot 脚本树中的深度，决定控制块中默克尔证明的长度。
+	TapTreeDepth int
+}
+
+// OptimizedScript 是优化的结果。
+type OptimizedScript struct {
+	// Script 是优化后的脚本。
+	Script []byte
+
+	// Encoding 是花费 Script 时见证重量最小的编码。
+	Encoding ScriptEncoding
+
+	// Rewrites 是应用的重写次数。
+	Rewrites int
+
+	// OriginalWeight 是使用候选编码中成本最低的一种花费原始脚本时揭示脚本所需的见证重量。
+	OriginalWeight int
+
+	// OptimizedWeight 是使用 Encoding 花费 Script 时揭示脚本所需的见证重量。
+	OptimizedWeight int
+}
+
+// WeightSavings 返回优化节省的估计见证重量。
+func (o *OptimizedScript) WeightSavings() int {
+	return o.OriginalWeight - o.OptimizedWeight
+}
+
+// optimizeToken 是被优化脚本中的一个操作码及其数据。
+type optimizeToken struct {
+	op     byte
+	data   []byte
+	isPush bool
+}
+
+// OptimizeScript 将脚本重写为更小的等价形式并选择揭示成本最低的编码。 应用的重写包括：
+//
+//   - 将非最小编码的数据推送替换为最小推送，包括小整数操作码
+//   - 删除相邻的 OP_DUP OP_DROP 以及数据推送后紧跟的 OP_DROP
+//   - 将 OP_EQUAL、OP_NUMEQUAL、OP_CHECKSIG 和 OP_CHECKMULTISIG 后紧跟的 OP_VERIFY 合并为对应的 VERIFY 操作码
+//
+// 对于能够成功执行的脚本，这些重写不改变执行结果，但被删除的操作码不再计入操作码和堆栈限制，
+// 并且在 tapscript 中会改变 OP_CODESEPARATOR 的位置，因此必须在签名之前进行优化。
+func OptimizeScript(script []byte, target OptimizeTarget) (*OptimizedScript, error) {
+	encodings := target.Encodings
+	if len(encodings) == 0 {
+		encodings = []ScriptEncoding{EncodingP2WSH, EncodingTapscript}
+	}
+	if target.TapTreeDepth < 0 ||
+		target.TapTreeDepth > ControlBlockMaxNodeCount {
+		return nil, fmt.Errorf("invalid taproot tree depth %d",
+			target.TapTreeDepth)
+	}
+
+	tokens, err := optimizeTokens(script)
+	if err != nil {
+		return nil, err
+	}
+
+	var rewrites int
+	out := make([]optimizeToken, 0, len(tokens))
+	for _, token := range tokens {
+		var last *optimizeToken
+		if len(out) > 0 {
+			last = &out[len(out)-1]
+		}
+
+		switch {
+		case token.op == OP_DROP && last != nil &&
+			(last.op == OP_DUP || last.isPush):
+
+			out = out[:len(out)-1]
+			rewrites++
+			continue
+
+		case token.op == OP_VERIFY && last != nil &&
+			verifyVariant(last.op) != 0:
+
+			last.op = verifyVariant(last.op)
+			rewrites++
+			continue
+		}
+
+		out = append(out, token)
+	}
+
+	optimized := make([]byte, 0, len(script))
+	var pushRewrites int
+	for _, token := range out {
+		if !token.isPush {
+			optimized = append(optimized, token.op)
+			continue
+		}
+
+		// Only count the push as rewritten when it wasn't already
+		// minimally encoded.
+		minimal := appendMinimalPush(nil, token.data)
+		if minimal[0] != token.op {
+			pushRewrites++
+		}
+		optimized = append(optimized, minimal...)
+	}
+	rewrites += pushRewrites
+
+	_, originalWeight, err := cheapestEncoding(
+		script, encodings, target.TapTreeDepth,
+	)
+	if err != nil {
+		return nil, err
+	}
+	encoding, weight, err := cheapestEncoding(
+		optimized, encodings, target.TapTreeDepth,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &OptimizedScript{
+		Script:          optimized,
+		Encoding:        encoding,
+		Rewrites:        rewrites,
+		OriginalWeight:  originalWeight,
+		OptimizedWeight: weight,
+	}
+	return result, nil
+}
+
+// cheapestEncoding 返回候选编码中揭示脚本成本最低的编码及其见证重量。
+func cheapestEncoding(script []byte, encodings []ScriptEncoding,
+	tapTreeDepth int) (ScriptEncoding, int, error) {
+
+	var (
+		best       ScriptEncoding
+		bestWeight int
+		found      bool
+	)
+	for _, encoding := range encodings {
+		weight, err := scriptRevealWeight(script, encoding, tapTreeDepth)
+		if err != nil {
+			continue
+		}
+		if !found || weight < bestWeight {
+			best, bestWeight, found = encoding, weight, true
+		}
+	}
+	if !found {
+		return 0, 0, fmt.Errorf("%w: candidates %v",
+			ErrNoScriptEncoding, encodings)
+	}
+	return best, bestWeight, nil
+}
+
+// optimizeTokens 将脚本解析为操作码序列，数据推送（包括小整数操作码）会记录其推送的数据。
+func optimizeTokens(script []byte) ([]optimizeToken, error) {
+	const scriptVersion = 0
+
+	var tokens []optimizeToken
+	tokenizer := MakeScriptTokenizer(scriptVersion, script)
+	for tokenizer.Next() {
+		op := tokenizer.Opcode()
+		token := optimizeToken{op: op}
+		switch {
+		case op <= OP_PUSHDATA4:
+			token.data = tokenizer.Data()
+			token.isPush = true
+		case op == OP_1NEGATE:
+			token.data = []byte{0x81}
+			token.isPush = true
+		case op >= OP_1 && op <= OP_16:
+			token.data = []byte{op - (OP_1 - 1)}
+			token.isPush = true
+		}
+		tokens = append(tokens, token)
+	}
+	if err := tokenizer.Err(); err != nil {
+		return nil, err
+	}
+	return tokens, nil
+}
+
+// verifyVariant 返回与 op 后接 OP_VERIFY 等价的单个操作码，不存在时返回 0。
+func verifyVariant(op byte) byte {
+	switch op {
+	case OP_EQUAL:
+		return OP_EQUALVERIFY
+	case OP_NUMEQUAL:
+		return OP_NUMEQUALVERIFY
+	case OP_CHECKSIG:
+		return OP_CHECKSIGVERIFY
+	case OP_CHECKMULTISIG:
+		return OP_CHECKMULTISIGVERIFY
+	}
+	return 0
+}
+
+// appendMinimalPush 将推送 data 的最小编码附加到 script。 与 ScriptBuilder.AddData 不同，单个零字节不会被编码为
+// OP_0，因为 OP_0 推送的是空字节数组。
+func appendMinimalPush(script, data []byte) []byte {
+	dataLen := len(data)
+	switch {
+	case dataLen == 0:
+		return append(script, OP_0)
+	case dataLen == 1 && data[0] >= 1 && data[0] <= 16:
+		return append(script, (OP_1-1)+data[0])
+	case dataLen == 1 && data[0] == 0x81:
+		return append(script, OP_1NEGATE)
+	case dataLen < OP_PUSHDATA1:
+		script = append(script, byte((OP_DATA_1-1)+dataLen))
+	case dataLen <= 0xff:
+		script = append(script, OP_PUSHDATA1, byte(dataLen))
+	case dataLen <= 0xffff:
+		var buf [2]byte
+		binary.LittleEndian.PutUint16(buf[:], uint16(dataLen))
+		script = append(script, OP_PUSHDATA2)
+		script = append(script, buf[:]...)
+	default:
+		var buf [4]byte
+		binary.LittleEndian.PutUint32(buf[:], uint32(dataLen))
+		script = append(script, OP_PUSHDATA4)
+		script = append(script, buf[:]...)
+	}
+	return append(script, data...)
+}
+
+// scriptRevealWeight 返回使用传入编码花费脚本时揭示脚本所需的见证重量，即脚本本身以及 tapscript 控制块的见证字节数。
+// 当脚本无法使用该编码时返回错误。
+func scriptRevealWeight(script []byte, encoding ScriptEncoding,
+	tapTreeDepth int) (int, error) {
+
+	witnessItemSize := func(n int) int {
+		return wire.VarIntSerializeSize(uint64(n)) + n
+	}
+
+	switch encoding {
+	case EncodingP2WSH:
+		maxScriptSize := DefaultChainLimits().MaxScriptSize
+		if len(script) > maxScriptSize {
+			return 0, fmt.Errorf("script size %d exceeds the p2wsh "+
+				"limit of %d", len(script), maxScriptSize)
+		}
+		return witnessItemSize(len(script)), nil
+
+	case EncodingTapscript:
+		// Legacy multisig opcodes are disabled in tapscript.
+		tokenizer := MakeScriptTokenizer(0, script)
+		for tokenizer.Next() {
+			switch tokenizer.Opcode() {
+			case OP_CHECKMULTISIG, OP_CHECKMULTISIGVERIFY:
+				return 0, fmt.Errorf("%v is disabled in tapscript",
+					opcodeArray[tokenizer.Opcode()].name)
+			}
+		}
+		if err := tokenizer.Err(); err != nil {
+			return 0, err
+		}
+
+		ctrlBlockSize := ControlBlockBaseSize +
+			ControlBlockNodeSize*tapTreeDepth
+		return witnessItemSize(len(script)) +
+			witnessItemSize(ctrlBlockSize), nil
+
+	default:
+		return 0, fmt.Errorf("unknown script encoding %v", encoding)
+	}
+}
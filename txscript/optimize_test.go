@@ -0,0 +1,113 @@
+package txscript
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// TestOptimizeScript 确保优化器应用预期的重写，并且优化后的脚本与原始脚本的执行结果一致。
+func TestOptimizeScript(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		script   string
+		want     string
+		rewrites int
+	}{{
+		name:     "small int pushes",
+		script:   "DATA_1 0x05 PUSHDATA1 0x01 0x10 DATA_1 0x81 NUMEQUAL",
+		want:     "5 16 -1 NUMEQUAL",
+		rewrites: 3,
+	}, {
+		name:     "non-minimal pushdata",
+		script:   "PUSHDATA2 0x0200 0x0102 SIZE",
+		want:     "DATA_2 0x0102 SIZE",
+		rewrites: 1,
+	}, {
+		name:     "zero byte is not OP_0",
+		script:   "DATA_1 0x00 DATA_1 0x00 EQUAL",
+		want:     "DATA_1 0x00 DATA_1 0x00 EQUAL",
+		rewrites: 0,
+	}, {
+		name:     "nested dup drop",
+		script:   "1 DUP DUP DROP DROP",
+		want:     "1",
+		rewrites: 2,
+	}, {
+		name:     "push drop",
+		script:   "1 DATA_2 0x0102 DROP",
+		want:     "1",
+		rewrites: 1,
+	}, {
+		name:     "verify fusion",
+		script:   "1 1 EQUAL VERIFY 2 2 NUMEQUAL VERIFY 1",
+		want:     "1 1 EQUALVERIFY 2 2 NUMEQUALVERIFY 1",
+		rewrites: 2,
+	}}
+
+	for _, test := range tests {
+		script := mustParseShortForm(test.script)
+		result, err := OptimizeScript(script, OptimizeTarget{})
+		require.NoError(t, err, test.name)
+		require.Equal(t, mustParseShortForm(test.want), result.Script,
+			test.name)
+		require.Equal(t, test.rewrites, result.Rewrites, test.name)
+		require.Equal(t, EncodingP2WSH, result.Encoding, test.name)
+		require.Equal(t, len(script)-len(result.Script),
+			result.WeightSavings(), test.name)
+
+		// 两个脚本的执行结果必须一致。
+		run := func(pkScript []byte) error {
+			tx := createSpendingTx(nil, nil, pkScript, 0)
+			vm, err := NewEngine(pkScript, tx, 0, 0, nil, nil, 0, nil)
+			require.NoError(t, err)
+			return vm.Execute()
+		}
+		require.Equal(t, run(script) == nil, run(result.Script) == nil,
+			test.name)
+	}
+}
+
+// TestOptimizeScriptEncoding 确保优化器根据目标选择编码，并拒绝无法承载脚本的目标。
+func TestOptimizeScriptEncoding(t *testing.T) {
+	t.Parallel()
+
+	script := mustParseShortForm("DATA_32 0x" +
+		"0000000000000000000000000000000000000000000000000000000000000001 " +
+		"CHECKSIG")
+
+	// 默认目标中 P2WSH 没有控制块开销，因此更小。
+	result, err := OptimizeScript(script, OptimizeTarget{TapTreeDepth: 2})
+	require.NoError(t, err)
+	require.Equal(t, EncodingP2WSH, result.Encoding)
+	require.Equal(t, 1+len(script), result.OptimizedWeight)
+
+	// 只允许 tapscript 时，重量需要计入控制块。
+	result, err = OptimizeScript(script, OptimizeTarget{
+		Encodings:    []ScriptEncoding{EncodingTapscript},
+		TapTreeDepth: 2,
+	})
+	require.NoError(t, err)
+	require.Equal(t, EncodingTapscript, result.Encoding)
+	require.Equal(t, 1+len(script)+1+ControlBlockBaseSize+
+		2*ControlBlockNodeSize, result.OptimizedWeight)
+
+	// 多重签名操作码在 tapscript 中被禁用。
+	multisig := mustParseShortForm("1 DATA_33 0x02" +
+		"0000000000000000000000000000000000000000000000000000000000000001 " +
+		"1 CHECKMULTISIG")
+	_, err = OptimizeScript(multisig, OptimizeTarget{
+		Encodings: []ScriptEncoding{EncodingTapscript},
+	})
+	require.ErrorIs(t, err, ErrNoScriptEncoding)
+	result, err = OptimizeScript(multisig, OptimizeTarget{})
+	require.NoError(t, err)
+	require.Equal(t, EncodingP2WSH, result.Encoding)
+
+	_, err = OptimizeScript(script, OptimizeTarget{TapTreeDepth: -1})
+	require.Error(t, err)
+	_, err = OptimizeScript([]byte{OP_DATA_2, 0x01}, OptimizeTarget{})
+	require.Error(t, err)
+}
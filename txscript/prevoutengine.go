@@ -0,0 +1,59 @@
+// 包含从被花费的输出直接构造脚本引擎的便捷函数，避免调用者向 NewEngine 传入彼此不一致的公钥脚本和金额。
+
+package txscript
+
+import (
+	"github.com/btcsuite/btcd/wire"
+)
+
+// taprootOnlyFlags 是只影响 taproot 花费的验证标志。
+const taprootOnlyFlags = ScriptVerifyTaproot |
+	ScriptVerifyDiscourageUpgradeableTaprootVersion |
+	ScriptVerifyDiscourageOpSuccess |
+	ScriptVerifyDiscourageUpgradeablePubkeyType
+
+// DefaultVerifyFlags 根据公钥脚本的类别返回验证花费该脚本的输入时使用的默认标志。
+//
+// P2TR 输出使用 StandardVerifyFlags。 其他输出使用去掉 taproot 专用标志的 StandardVerifyFlags，
+// 这些标志对不是 taproot 的花费没有影响，去掉它们使返回的标志准确反映实际生效的规则。
+func DefaultVerifyFlags(pkScript []byte) ScriptFlags {
+	if GetScriptClass(pkScript) == WitnessV1TaprootTy {
+		return StandardVerifyFlags
+	}
+	return StandardVerifyFlags &^ taprootOnlyFlags
+}
+
+// NewEngineFromPrevOut 返回验证 tx 的输入 txIdx 的脚本引擎，公钥脚本和金额都取自该输入花费的输出 prevOut。
+// flags 为 0 时使用 DefaultVerifyFlags 为 prevOut 选择的标志。 其他参数与 NewEngine 相同。
+//
+// taproot 签名哈希承诺了交易所有输入花费的输出，而 prevOut 只描述其中一个。 因此花费 P2TR 输出的多输入交易必须提供
+// 使用所有被花费输出计算的 hashCache，否则返回错误；需要完整的前一输出查找时使用 NewEngine。
+func NewEngineFromPrevOut(prevOut *wire.TxOut, tx *wire.MsgTx, txIdx int,
+	flags ScriptFlags, sigCache *SigCache, hashCache *TxSigHashes,
+	opts ...EngineOpt) (*Engine, error) {
+
+	if prevOut == nil {
+		return nil, internalError("previous output is required", nil)
+	}
+	if flags == 0 {
+		flags = DefaultVerifyFlags(prevOut.PkScript)
+	}
+	if hashCache == nil && len(tx.TxIn) > 1 &&
+		flags&ScriptVerifyTaproot != 0 && IsPayToTaproot(prevOut.PkScript) {
+
+		return nil, internalError("signature hash cache required to "+
+			"verify a taproot spend of a multi-input transaction", nil)
+	}
+
+	// The canned fetcher returns prevOut for every outpoint. Only the entry
+	// for the spent output matters: segwit v0 signature hashes don't commit
+	// to the other spent outputs, and taproot spends of multi-input
+	// transactions were required to supply the full cache above.
+	prevOutFetcher := NewCannedPrevOutputFetcher(
+		prevOut.PkScript, prevOut.Value,
+	)
+	return NewEngine(
+		prevOut.PkScript, tx, txIdx, flags, sigCache, hashCache,
+		prevOut.Value, prevOutFetcher, opts...,
+	)
+}
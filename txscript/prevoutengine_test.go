@@ -0,0 +1,110 @@
+package txscript
+
+import (
+	"testing"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcec/v2/schnorr"
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/stretchr/testify/require"
+)
+
+// TestNewEngineFromPrevOut 确保从被花费的输出构造的引擎可以验证各类输入，并且拒绝无法正确计算签名哈希的 taproot 花费。
+func TestNewEngineFromPrevOut(t *testing.T) {
+	t.Parallel()
+
+	params := &chaincfg.TestNet3Params
+	privKey, err := btcec.NewPrivateKey()
+	require.NoError(t, err)
+	pubKey := privKey.PubKey().SerializeCompressed()
+
+	p2pkhAddr, err := btcutil.NewAddressPubKeyHash(
+		btcutil.Hash160(pubKey), params,
+	)
+	require.NoError(t, err)
+	p2wpkhAddr, err := btcutil.NewAddressWitnessPubKeyHash(
+		btcutil.Hash160(pubKey), params,
+	)
+	require.NoError(t, err)
+	trAddr, err := btcutil.NewAddressTaproot(
+		schnorr.SerializePubKey(ComputeTaprootKeyNoScript(privKey.PubKey())),
+		params,
+	)
+	require.NoError(t, err)
+
+	keys := make(map[string]addressToKey)
+	var prevOuts []*wire.TxOut
+	for i, addr := range []btcutil.Address{p2pkhAddr, p2wpkhAddr, trAddr} {
+		keys[addr.EncodeAddress()] = addressToKey{privKey, true}
+		pkScript, err := PayToAddrScript(addr)
+		require.NoError(t, err)
+		prevOuts = append(prevOuts, wire.NewTxOut(int64(i+1)*1e5, pkScript))
+	}
+
+	// 每种输出都由单输入交易花费。
+	for _, prevOut := range prevOuts {
+		tx := wire.NewMsgTx(2)
+		tx.AddTxIn(&wire.TxIn{})
+		tx.AddTxOut(wire.NewTxOut(1e4, prevOut.PkScript))
+		err := SignAllInputs(
+			params, tx,
+			NewCannedPrevOutputFetcher(prevOut.PkScript, prevOut.Value),
+			mkGetKey(keys), mkGetScript(nil), SigHashAll,
+		)
+		require.NoError(t, err)
+
+		vm, err := NewEngineFromPrevOut(prevOut, tx, 0, 0, nil, nil)
+		require.NoError(t, err)
+		require.NoError(t, vm.Execute())
+
+		// 金额与签名承诺的金额不一致时，见证输入的验证必须失败。
+		if IsWitnessProgram(prevOut.PkScript) {
+			wrongAmount := wire.NewTxOut(prevOut.Value+1, prevOut.PkScript)
+			vm, err := NewEngineFromPrevOut(wrongAmount, tx, 0, 0, nil, nil)
+			require.NoError(t, err)
+			require.Error(t, vm.Execute())
+		}
+	}
+
+	// 多输入交易的 taproot 花费需要完整的签名哈希缓存。
+	tx := wire.NewMsgTx(2)
+	fetcher := NewMultiPrevOutFetcher(nil)
+	for i, prevOut := range prevOuts {
+		op := wire.OutPoint{Index: uint32(i)}
+		tx.AddTxIn(&wire.TxIn{PreviousOutPoint: op})
+		fetcher.AddPrevOut(op, prevOut)
+	}
+	tx.AddTxOut(wire.NewTxOut(1e4, prevOuts[0].PkScript))
+	err = SignAllInputs(
+		params, tx, fetcher, mkGetKey(keys), mkGetScript(nil), SigHashAll,
+	)
+	require.NoError(t, err)
+
+	_, err = NewEngineFromPrevOut(prevOuts[2], tx, 2, 0, nil, nil)
+	require.ErrorAs(t, err, &InternalError{})
+
+	sigHashes := NewTxSigHashes(tx, fetcher)
+	for i, prevOut := range prevOuts {
+		vm, err := NewEngineFromPrevOut(prevOut, tx, i, 0, nil, sigHashes)
+		require.NoError(t, err)
+		require.NoError(t, vm.Execute(), "input %d", i)
+	}
+
+	_, err = NewEngineFromPrevOut(nil, tx, 0, 0, nil, nil)
+	require.ErrorAs(t, err, &InternalError{})
+}
+
+// TestDefaultVerifyFlags 确保只有 taproot 输出使用 taproot 专用标志。
+func TestDefaultVerifyFlags(t *testing.T) {
+	t.Parallel()
+
+	p2tr := append([]byte{OP_1, OP_DATA_32}, make([]byte, 32)...)
+	p2wpkh := append([]byte{OP_0, OP_DATA_20}, make([]byte, 20)...)
+
+	require.Equal(t, StandardVerifyFlags, DefaultVerifyFlags(p2tr))
+	require.Zero(t, DefaultVerifyFlags(p2wpkh)&ScriptVerifyTaproot)
+	require.NotZero(t, DefaultVerifyFlags(p2wpkh)&ScriptVerifyWitness)
+	require.NotZero(t, DefaultVerifyFlags(nil)&ScriptBip16)
+}
@@ -10,8 +10,6 @@ import (
 	"io/fs"
 	"io/ioutil"
 	"path/filepath"
-	"strconv"
-	"strings"
 	"testing"
 
 	"github.com/btcsuite/btcd/btcutil"
@@ -43,14 +41,6 @@ func scriptTestName(test []interface{}) (string, error) {
 	return name, nil
 }
 
-// 将十六进制字符串解析为 [] 字节。
-func parseHex(tok string) ([]byte, error) {
-	if !strings.HasPrefix(tok, "0x") {
-		return nil, fmt.Errorf("not a hex number")
-	}
-	return hex.DecodeString(tok[2:])
-}
-
 // parseWitnessStack 将编码为十六进制的见证项的 json 数组解析为见证元素的切片。
 func parseWitnessStack(elements []interface{}) ([][]byte, error) {
 	witness := make([][]byte, len(elements))
@@ -66,123 +56,6 @@ func parseWitnessStack(elements []interface{}) ([][]byte, error) {
 	return witness, nil
 }
 
-// shortFormOps 保存操作码名称到值的映射，以供短格式解析使用。 它在这里声明，因此只需要创建一次。
-var shortFormOps map[string]byte
-
-// parseShortForm 将比特币核心参考测试中使用的字符串解析为它来自的脚本。
-//
-// 如果是临时的，用于这些测试的格式非常简单：
-//   - 除推送操作码和未知操作码以外的操作码以 OP_NAME 或仅 NAME 的形式出现
-//   - 普通数字被制成推送操作
-//   - 以 0x 开头的数字按原样插入到 []byte 中（因此 0x14 是 OP_DATA_20）
-//   - 单引号字符串作为数据推送
-//   - 其他任何内容都是错误
-func parseShortForm(script string) ([]byte, error) {
-	// 仅创建一次简短形式的操作码映射。
-	if shortFormOps == nil {
-		ops := make(map[string]byte)
-		for opcodeName, opcodeValue := range OpcodeByName {
-			if strings.Contains(opcodeName, "OP_UNKNOWN") {
-				continue
-			}
-			ops[opcodeName] = opcodeValue
-
-			// 名为 OP_# 的操作码不能去掉 OP_ 前缀，否则它们会与普通数字冲突。
-			// 此外，由于 OP_FALSE 和 OP_TRUE 分别是 OP_0 和 OP_1 的别名，因此它们具有相同的值，因此请按名称检测它们并允许它们。
-			if (opcodeName == "OP_FALSE" || opcodeName == "OP_TRUE") ||
-				(opcodeValue != OP_0 && (opcodeValue < OP_1 ||
-					opcodeValue > OP_16)) {
-
-				ops[strings.TrimPrefix(opcodeName, "OP_")] = opcodeValue
-			}
-		}
-		shortFormOps = ops
-	}
-
-	// Split 只做一个分隔符，因此将所有 \n 和制表符转换为空格。
-	script = strings.Replace(script, "\n", " ", -1)
-	script = strings.Replace(script, "\t", " ", -1)
-	tokens := strings.Split(script, " ")
-	builder := NewScriptBuilder()
-
-	for _, tok := range tokens {
-		if len(tok) == 0 {
-			continue
-		}
-		// if 解析为普通数字
-		if num, err := strconv.ParseInt(tok, 10, 64); err == nil {
-			builder.AddInt64(num)
-			continue
-		} else if bts, err := parseHex(tok); err == nil {
-			// 手动连接字节，因为测试代码故意创建太大的脚本，否则会导致构建器出错。
-			if builder.err == nil {
-				builder.script = append(builder.script, bts...)
-			}
-		} else if len(tok) >= 2 &&
-			tok[0] == '\'' && tok[len(tok)-1] == '\'' {
-			builder.AddFullData([]byte(tok[1 : len(tok)-1]))
-		} else if opcode, ok := shortFormOps[tok]; ok {
-			builder.AddOp(opcode)
-		} else {
-			return nil, fmt.Errorf("bad token %q", tok)
-		}
-
-	}
-	return builder.Script()
-}
-
-// parseScriptFlags 将提供的标志字符串从参考测试中使用的格式解析为适合在脚本引擎中使用的 ScriptFlags。
-func parseScriptFlags(flagStr string) (ScriptFlags, error) {
-	var flags ScriptFlags
-
-	sFlags := strings.Split(flagStr, ",")
-	for _, flag := range sFlags {
-		switch flag {
-		case "":
-			// Nothing.
-		case "CHECKLOCKTIMEVERIFY":
-			flags |= ScriptVerifyCheckLockTimeVerify
-		case "CHECKSEQUENCEVERIFY":
-			flags |= ScriptVerifyCheckSequenceVerify
-		case "CLEANSTACK":
-			flags |= ScriptVerifyCleanStack
-		case "DERSIG":
-			flags |= ScriptVerifyDERSignatures
-		case "DISCOURAGE_UPGRADABLE_NOPS":
-			flags |= ScriptDiscourageUpgradableNops
-		case "LOW_S":
-			flags |= ScriptVerifyLowS
-		case "MINIMALDATA":
-			flags |= ScriptVerifyMinimalData
-		case "NONE":
-			// Nothing.
-		case "NULLDUMMY":
-			flags |= ScriptStrictMultiSig
-		case "NULLFAIL":
-			flags |= ScriptVerifyNullFail
-		case "P2SH":
-			flags |= ScriptBip16
-		case "SIGPUSHONLY":
-			flags |= ScriptVerifySigPushOnly
-		case "STRICTENC":
-			flags |= ScriptVerifyStrictEncoding
-		case "WITNESS":
-			flags |= ScriptVerifyWitness
-		case "DISCOURAGE_UPGRADABLE_WITNESS_PROGRAM":
-			flags |= ScriptVerifyDiscourageUpgradeableWitnessProgram
-		case "MINIMALIF":
-			flags |= ScriptVerifyMinimalIf
-		case "WITNESS_PUBKEYTYPE":
-			flags |= ScriptVerifyWitnessPubKeyType
-		case "TAPROOT":
-			flags |= ScriptVerifyTaproot
-		default:
-			return flags, fmt.Errorf("invalid flag: %s", flag)
-		}
-	}
-	return flags, nil
-}
-
 // parseExpectedResult 将提供的预期结果字符串解析为允许的脚本错误代码。
 // 如果不支持预期的结果字符串，则会返回错误。
 func parseExpectedResult(expected string) ([]ErrorCode, error) {
@@ -226,6 +99,8 @@ func parseExpectedResult(expected string) ([]ErrorCode, error) {
 		return []ErrorCode{ErrEarlyReturn}, nil
 	case "VERIFY":
 		return []ErrorCode{ErrVerify}, nil
+	case "CHECKSIGFROMSTACKVERIFY":
+		return []ErrorCode{ErrCheckSigFromStackVerify}, nil
 	case "INVALID_STACK_OPERATION", "INVALID_ALTSTACK_OPERATION":
 		return []ErrorCode{ErrInvalidStackOperation}, nil
 	case "DISABLED_OPCODE":
@@ -388,7 +263,7 @@ func testScripts(t *testing.T, tests [][]interface{}, useSigCache bool) {
 			t.Errorf("%s: flags field is not a string", name)
 			continue
 		}
-		flags, err := parseScriptFlags(flagsStr)
+		flags, err := ParseScriptFlags(flagsStr)
 		if err != nil {
 			t.Errorf("%s: %v", name, err)
 			continue
@@ -531,7 +406,7 @@ testloop:
 			continue
 		}
 
-		flags, err := parseScriptFlags(verifyFlags)
+		flags, err := ParseScriptFlags(verifyFlags)
 		if err != nil {
 			t.Errorf("bad test %d: %v", i, err)
 			continue
@@ -686,7 +561,7 @@ testloop:
 			continue
 		}
 
-		flags, err := parseScriptFlags(verifyFlags)
+		flags, err := ParseScriptFlags(verifyFlags)
 		if err != nil {
 			t.Errorf("bad test %d: %v", i, err)
 			continue
@@ -901,7 +776,7 @@ func executeTaprootRefTest(t *testing.T, testCase taprootJsonTest) {
 		}
 	}
 
-	flags, err := parseScriptFlags(testCase.Flags)
+	flags, err := ParseScriptFlags(testCase.Flags)
 	if err != nil {
 		t.Fatalf("unable to parse flags: %v", err)
 	}
@@ -0,0 +1,280 @@
+// 包含通过外部签名设备（硬件钱包、HSM 等）创建交易签名的函数，使节点无需持有原始私钥。
+
+package txscript
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcec/v2/ecdsa"
+	"github.com/btcsuite/btcd/btcec/v2/schnorr"
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/wire"
+)
+
+var (
+	// ErrRemoteSignerUnsupported 当远程签名器不支持所请求的签名类型时返回。
+	ErrRemoteSignerUnsupported = errors.New("remote signer does not " +
+		"support the requested signature")
+
+	// ErrInvalidRemoteSignature 当远程签名器返回的签名无法解析或无法通过验证时返回。
+	ErrInvalidRemoteSignature = errors.New("invalid signature returned by " +
+		"remote signer")
+)
+
+// RemoteSigType 表示请求远程签名器生成的签名类型。
+type RemoteSigType uint8
+
+const (
+	// RemoteSigECDSA 请求对哈希进行 ECDSA 签名，签名器应返回 DER 编码的签名（不附加 sighash 类型）。
+	RemoteSigECDSA RemoteSigType = iota
+
+	// RemoteSigSchnorr 请求使用未调整的密钥对哈希进行 BIP-340 schnorr 签名，用于 tapscript 叶子花费。
+	RemoteSigSchnorr
+
+	// RemoteSigTaprootKeySpend 请求使用按 BIP-86 调整（不提交任何脚本树）的密钥进行 schnorr 签名，用于 taproot 密钥路径花费。
+	RemoteSigTaprootKeySpend
+)
+
+// String 返回签名类型的可读名称。
+func (t RemoteSigType) String() string {
+	switch t {
+	case RemoteSigECDSA:
+		return "ecdsa"
+	case RemoteSigSchnorr:
+		return "schnorr"
+	case RemoteSigTaprootKeySpend:
+		return "taproot-keyspend"
+	default:
+		return fmt.Sprintf("unknown(%d)", uint8(t))
+	}
+}
+
+// RemoteSigner 是外部签名设备的接口。 实现只会收到待签名的哈希以及密钥的派生路径，永远不会向调用方暴露私钥。
+//
+// SignHash 可能需要等待用户在设备上确认，因此实现应当遵守 ctx 的取消和截止时间。
+type RemoteSigner interface {
+	SignHash(ctx context.Context, hash []byte, derivationPath []uint32,
+		sigType RemoteSigType) ([]byte, error)
+}
+
+// RemoteTaprootSigner 是可选接口，由能够使用提交了任意脚本树根的调整密钥进行签名的远程签名器实现。
+// 仅在 taproot 密钥路径花费的输出提交了非空脚本树时才需要。
+type RemoteTaprootSigner interface {
+	RemoteSigner
+
+	SignTaprootHash(ctx context.Context, hash []byte,
+		derivationPath []uint32, tapScriptRootHash []byte) ([]byte, error)
+}
+
+// RemoteKeyDB 是提供给 SignTxOutputRemote 的接口，用于查找地址对应的公钥及其在签名设备中的派生路径。
+type RemoteKeyDB interface {
+	GetKeyPath(ctx context.Context, addr btcutil.Address) (*btcec.PublicKey,
+		[]uint32, bool, error)
+}
+
+// RemoteKeyClosure 使用闭包实现 RemoteKeyDB。
+type RemoteKeyClosure func(context.Context, btcutil.Address) (*btcec.PublicKey,
+	[]uint32, bool, error)
+
+// GetKeyPath 通过调用闭包实现 RemoteKeyDB。
+func (kc RemoteKeyClosure) GetKeyPath(ctx context.Context,
+	addr btcutil.Address) (*btcec.PublicKey, []uint32, bool, error) {
+
+	return kc(ctx, addr)
+}
+
+// signHashRemote 在 ctx 仍然有效时请求远程签名器对 hash 签名。
+func signHashRemote(ctx context.Context, signer RemoteSigner, hash []byte,
+	derivationPath []uint32, sigType RemoteSigType) ([]byte, error) {
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	sig, err := signer.SignHash(ctx, hash, derivationPath, sigType)
+	if err != nil {
+		return nil, fmt.Errorf("remote %v signing failed: %w", sigType, err)
+	}
+	return sig, nil
+}
+
+// remoteECDSASignature 请求远程 ECDSA 签名，检查其编码，并附加 hashType。
+func remoteECDSASignature(ctx context.Context, signer RemoteSigner,
+	hash []byte, derivationPath []uint32, hashType SigHashType) ([]byte, error) {
+
+	sig, err := signHashRemote(ctx, signer, hash, derivationPath,
+		RemoteSigECDSA)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := ecdsa.ParseDERSignature(sig); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidRemoteSignature, err)
+	}
+
+	return append(sig[:len(sig):len(sig)], byte(hashType)), nil
+}
+
+// finalizeSchnorrSig 检查远程 schnorr 签名的编码，并在 hashType 不是 SigHashDefault 时附加它。
+func finalizeSchnorrSig(sig []byte, hashType SigHashType) ([]byte, error) {
+	if _, err := schnorr.ParseSignature(sig); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidRemoteSignature, err)
+	}
+	if hashType == SigHashDefault {
+		return sig, nil
+	}
+	return append(sig[:len(sig):len(sig)], byte(hashType)), nil
+}
+
+// RawTxInSignatureRemote 与 RawTxInSignature 相同，但签名由 derivationPath 指定的远程密钥生成。
+func RawTxInSignatureRemote(ctx context.Context, tx *wire.MsgTx, idx int,
+	subScript []byte, hashType SigHashType, signer RemoteSigner,
+	derivationPath []uint32) ([]byte, error) {
+
+	hash, err := CalcSignatureHash(subScript, hashType, tx, idx)
+	if err != nil {
+		return nil, err
+	}
+	return remoteECDSASignature(ctx, signer, hash, derivationPath, hashType)
+}
+
+// RawTxInWitnessSignatureRemote 与 RawTxInWitnessSignature 相同，但签名由 derivationPath 指定的远程密钥生成。
+func RawTxInWitnessSignatureRemote(ctx context.Context, tx *wire.MsgTx,
+	sigHashes *TxSigHashes, idx int, amt int64, subScript []byte,
+	hashType SigHashType, signer RemoteSigner,
+	derivationPath []uint32) ([]byte, error) {
+
+	hash, err := calcWitnessSignatureHashRaw(subScript, sigHashes, hashType,
+		tx, idx, amt, BitcoinChainTag)
+	if err != nil {
+		return nil, err
+	}
+	return remoteECDSASignature(ctx, signer, hash, derivationPath, hashType)
+}
+
+// RawTxInTaprootSignatureRemote 与 RawTxInTaprootSignature 相同，但签名由 derivationPath 指定的远程密钥生成。
+// 如果 tapScriptRootHash 为空，则签名器按 BIP-86 调整密钥；否则签名器必须实现 RemoteTaprootSigner。
+func RawTxInTaprootSignatureRemote(ctx context.Context, tx *wire.MsgTx,
+	sigHashes *TxSigHashes, idx int, amt int64, pkScript []byte,
+	tapScriptRootHash []byte, hashType SigHashType, signer RemoteSigner,
+	derivationPath []uint32) ([]byte, error) {
+
+	sigHash, err := calcTaprootSignatureHashRaw(
+		sigHashes, hashType, tx, idx,
+		NewCannedPrevOutputFetcher(pkScript, amt),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	var sig []byte
+	if len(tapScriptRootHash) == 0 {
+		sig, err = signHashRemote(ctx, signer, sigHash, derivationPath,
+			RemoteSigTaprootKeySpend)
+	} else {
+		tapSigner, ok := signer.(RemoteTaprootSigner)
+		if !ok {
+			return nil, fmt.Errorf("%w: key spend committing to a "+
+				"script tree", ErrRemoteSignerUnsupported)
+		}
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		sig, err = tapSigner.SignTaprootHash(
+			ctx, sigHash, derivationPath, tapScriptRootHash,
+		)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return finalizeSchnorrSig(sig, hashType)
+}
+
+// RawTxInTapscriptSignatureRemote 与 RawTxInTapscriptSignature 相同，但签名由 derivationPath 指定的远程密钥生成。
+func RawTxInTapscriptSignatureRemote(ctx context.Context, tx *wire.MsgTx,
+	sigHashes *TxSigHashes, idx int, amt int64, pkScript []byte,
+	tapLeaf TapLeaf, hashType SigHashType, signer RemoteSigner,
+	derivationPath []uint32) ([]byte, error) {
+
+	tapLeafHash := tapLeaf.TapHash()
+	sigHash, err := calcTaprootSignatureHashRaw(
+		sigHashes, hashType, tx, idx,
+		NewCannedPrevOutputFetcher(pkScript, amt),
+		WithBaseTapscriptVersion(blankCodeSepValue, tapLeafHash[:]),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	sig, err := signHashRemote(ctx, signer, sigHash, derivationPath,
+		RemoteSigSchnorr)
+	if err != nil {
+		return nil, err
+	}
+
+	return finalizeSchnorrSig(sig, hashType)
+}
+
+// remoteKeyDBSigner 使用 RemoteSigner 和 RemoteKeyDB 实现 txInSigner。
+type remoteKeyDBSigner struct {
+	ctx    context.Context
+	signer RemoteSigner
+	kdb    RemoteKeyDB
+}
+
+// signTxIn 实现 txInSigner 接口。
+func (s remoteKeyDBSigner) signTxIn(tx *wire.MsgTx, idx int, subScript []byte,
+	hashType SigHashType, addr btcutil.Address) ([]byte, []byte, error) {
+
+	pubKey, path, compressed, err := s.kdb.GetKeyPath(s.ctx, addr)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	hash, err := CalcSignatureHash(subScript, hashType, tx, idx)
+	if err != nil {
+		return nil, nil, err
+	}
+	sig, err := remoteECDSASignature(s.ctx, s.signer, hash, path, hashType)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// 确保设备使用了与地址对应的密钥签名，否则生成的脚本将无法通过验证。
+	parsed, _ := ecdsa.ParseDERSignature(sig[:len(sig)-1])
+	if !parsed.Verify(hash, pubKey) {
+		return nil, nil, fmt.Errorf("%w: signature does not match key "+
+			"for %v", ErrInvalidRemoteSignature, addr)
+	}
+
+	if compressed {
+		return sig, pubKey.SerializeCompressed(), nil
+	}
+	return sig, pubKey.SerializeUncompressed(), nil
+}
+
+// SignTxOutputRemote 与 SignTxOutput 相同，但签名由远程签名器生成，密钥通过 kdb 以公钥和派生路径的形式查找。
+// ctx 会传递给每一次对签名器和 kdb 的调用；如果 ctx 被取消，签名将中止并返回 ctx 的错误。
+//
+// 注意：该函数仅对0版本脚本有效。 由于该函数不接受脚本版本，因此其他脚本版本的结果未定义。
+func SignTxOutputRemote(ctx context.Context, chainParams *chaincfg.Params,
+	tx *wire.MsgTx, idx int, pkScript []byte, hashType SigHashType,
+	signer RemoteSigner, kdb RemoteKeyDB, sdb ScriptDB,
+	previousScript []byte) ([]byte, error) {
+
+	script, err := signTxOutput(chainParams, tx, idx, pkScript, hashType,
+		remoteKeyDBSigner{ctx: ctx, signer: signer, kdb: kdb}, sdb,
+		previousScript)
+	if err != nil {
+		return nil, err
+	}
+
+	// 多重签名会静默跳过失败的签名，因此在此处报告取消，而不是返回不完整的脚本。
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return script, nil
+}
@@ -0,0 +1,179 @@
+package txscript
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcec/v2/ecdsa"
+	"github.com/btcsuite/btcd/btcec/v2/schnorr"
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/stretchr/testify/require"
+)
+
+// mockRemoteSigner 模拟一个按派生路径保存私钥的签名设备。
+type mockRemoteSigner struct {
+	keys map[string]*btcec.PrivateKey
+}
+
+func (m *mockRemoteSigner) SignHash(ctx context.Context, hash []byte,
+	path []uint32, sigType RemoteSigType) ([]byte, error) {
+
+	key, ok := m.keys[fmt.Sprint(path)]
+	if !ok {
+		return nil, fmt.Errorf("unknown path %v", path)
+	}
+
+	switch sigType {
+	case RemoteSigECDSA:
+		return ecdsa.Sign(key, hash).Serialize(), nil
+	case RemoteSigSchnorr:
+		sig, err := schnorr.Sign(key, hash)
+		if err != nil {
+			return nil, err
+		}
+		return sig.Serialize(), nil
+	case RemoteSigTaprootKeySpend:
+		sig, err := schnorr.Sign(TweakTaprootPrivKey(*key, nil), hash)
+		if err != nil {
+			return nil, err
+		}
+		return sig.Serialize(), nil
+	}
+	return nil, ErrRemoteSignerUnsupported
+}
+
+// TestSignTxOutputRemote 确保通过远程签名器生成的 P2PKH 和多重签名脚本能够通过验证，并且取消的上下文会中止签名。
+func TestSignTxOutputRemote(t *testing.T) {
+	t.Parallel()
+
+	path1 := []uint32{44 + 0x80000000, 0x80000000, 0}
+	path2 := []uint32{44 + 0x80000000, 0x80000000, 1}
+	key1, err := btcec.NewPrivateKey()
+	require.NoError(t, err)
+	key2, err := btcec.NewPrivateKey()
+	require.NoError(t, err)
+
+	signer := &mockRemoteSigner{keys: map[string]*btcec.PrivateKey{
+		fmt.Sprint(path1): key1,
+		fmt.Sprint(path2): key2,
+	}}
+
+	params := &chaincfg.TestNet3Params
+	pkHash := btcutil.Hash160(key1.PubKey().SerializeCompressed())
+	addr1, err := btcutil.NewAddressPubKeyHash(pkHash, params)
+	require.NoError(t, err)
+	pubAddr1, err := btcutil.NewAddressPubKey(
+		key1.PubKey().SerializeCompressed(), params,
+	)
+	require.NoError(t, err)
+	pubAddr2, err := btcutil.NewAddressPubKey(
+		key2.PubKey().SerializeCompressed(), params,
+	)
+	require.NoError(t, err)
+
+	kdb := RemoteKeyClosure(func(_ context.Context,
+		addr btcutil.Address) (*btcec.PublicKey, []uint32, bool, error) {
+
+		switch addr.EncodeAddress() {
+		case addr1.EncodeAddress(), pubAddr1.EncodeAddress():
+			return key1.PubKey(), path1, true, nil
+		case pubAddr2.EncodeAddress():
+			return key2.PubKey(), path2, true, nil
+		}
+		return nil, nil, false, fmt.Errorf("nope")
+	})
+
+	p2pkh, err := PayToAddrScript(addr1)
+	require.NoError(t, err)
+	multiSig, err := MultiSigScript(
+		[]*btcutil.AddressPubKey{pubAddr1, pubAddr2}, 2,
+	)
+	require.NoError(t, err)
+
+	tx := wire.NewMsgTx(1)
+	tx.AddTxIn(&wire.TxIn{PreviousOutPoint: wire.OutPoint{Index: 0}})
+	tx.AddTxOut(wire.NewTxOut(1, nil))
+
+	for _, pkScript := range [][]byte{p2pkh, multiSig} {
+		sigScript, err := SignTxOutputRemote(
+			context.Background(), params, tx, 0, pkScript, SigHashAll,
+			signer, kdb, mkGetScript(nil), nil,
+		)
+		require.NoError(t, err)
+		require.NoError(t, checkScripts("remote", tx, 0, 1, sigScript,
+			pkScript))
+	}
+
+	// 签名设备使用了错误的密钥时必须报错。
+	wrongKDB := RemoteKeyClosure(func(context.Context,
+		btcutil.Address) (*btcec.PublicKey, []uint32, bool, error) {
+
+		return key1.PubKey(), path2, true, nil
+	})
+	_, err = SignTxOutputRemote(
+		context.Background(), params, tx, 0, p2pkh, SigHashAll, signer,
+		wrongKDB, mkGetScript(nil), nil,
+	)
+	require.ErrorIs(t, err, ErrInvalidRemoteSignature)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	for _, pkScript := range [][]byte{p2pkh, multiSig} {
+		_, err = SignTxOutputRemote(
+			ctx, params, tx, 0, pkScript, SigHashAll, signer, kdb,
+			mkGetScript(nil), nil,
+		)
+		require.ErrorIs(t, err, context.Canceled)
+	}
+}
+
+// TestRawTxInTaprootSignatureRemote 确保远程 taproot 密钥路径签名能够通过验证，并且不支持脚本树的签名器会被拒绝。
+func TestRawTxInTaprootSignatureRemote(t *testing.T) {
+	t.Parallel()
+
+	path := []uint32{86 + 0x80000000, 0x80000000, 0}
+	privKey, err := btcec.NewPrivateKey()
+	require.NoError(t, err)
+	signer := &mockRemoteSigner{keys: map[string]*btcec.PrivateKey{
+		fmt.Sprint(path): privKey,
+	}}
+
+	pkScript, err := PayToTaprootScript(
+		ComputeTaprootKeyNoScript(privKey.PubKey()),
+	)
+	require.NoError(t, err)
+
+	tx := wire.NewMsgTx(2)
+	tx.AddTxIn(&wire.TxIn{PreviousOutPoint: wire.OutPoint{Index: 1}})
+	tx.AddTxOut(&wire.TxOut{Value: 1e8, PkScript: pkScript})
+
+	prevFetcher := NewCannedPrevOutputFetcher(pkScript, 1e8)
+	sigHashes := NewTxSigHashes(tx, prevFetcher)
+
+	for _, hashType := range []SigHashType{SigHashDefault, SigHashAll} {
+		sig, err := RawTxInTaprootSignatureRemote(
+			context.Background(), tx, sigHashes, 0, 1e8, pkScript, nil,
+			hashType, signer, path,
+		)
+		require.NoError(t, err)
+
+		txCopy := tx.Copy()
+		txCopy.TxIn[0].Witness = wire.TxWitness{sig}
+		vm, err := NewEngine(
+			pkScript, txCopy, 0, StandardVerifyFlags, nil, sigHashes,
+			1e8, prevFetcher,
+		)
+		require.NoError(t, err)
+		require.NoError(t, vm.Execute())
+	}
+
+	_, err = RawTxInTaprootSignatureRemote(
+		context.Background(), tx, sigHashes, 0, 1e8, pkScript,
+		make([]byte, 32), SigHashDefault, signer, path,
+	)
+	require.ErrorIs(t, err, ErrRemoteSignerUnsupported)
+}
@@ -0,0 +1,427 @@
+// 包含确定性的执行重放日志，用于排查不同架构的 bpfschain 节点之间罕见的共识分歧。 日志以紧凑的二进制格式记录执行的操作码、
+// 条件分支的走向和签名验证的结果，所有整数都按固定的字节序或变长整数编码，因此可以在节点之间直接比较。
+
+package txscript
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash"
+
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+)
+
+// DefaultReplayLogSize 是 NewReplayLog 未指定大小时日志的最大字节数。
+const DefaultReplayLogSize = 64 * 1024
+
+// replayLogMagic 是序列化日志的前缀，后跟一个字节的格式版本。
+var replayLogMagic = []byte{'b', 'p', 'r', 'l', 1}
+
+// ErrInvalidReplayLog 在无法解码序列化的重放日志时返回。
+var ErrInvalidReplayLog = errors.New("invalid replay log")
+
+// ReplayEventKind 表示重放日志中事件的种类。
+type ReplayEventKind uint8
+
+const (
+	// ReplayEventStep 表示引擎即将执行一个操作码，包括位于未执行分支中的操作码。
+	ReplayEventStep ReplayEventKind = iota + 1
+
+	// ReplayEventBranch 表示执行分支中的 OP_IF 或 OP_NOTIF 决定了之后的 then 分支是否被执行。
+	ReplayEventBranch
+
+	// ReplayEventSigCheck 表示一次签名验证及其结果。
+	ReplayEventSigCheck
+
+	// ReplayEventResult 是日志的最后一个事件，记录执行的结果。
+	ReplayEventResult
+)
+
+// replayEventNames 包含每种事件的名称。
+var replayEventNames = map[ReplayEventKind]string{
+	ReplayEventStep:     "ReplayEventStep",
+	ReplayEventBranch:   "ReplayEventBranch",
+	ReplayEventSigCheck: "ReplayEventSigCheck",
+	ReplayEventResult:   "ReplayEventResult",
+}
+
+// String 返回事件种类的名称。
+func (k ReplayEventKind) String() string {
+	if name, ok := replayEventNames[k]; ok {
+		return name
+	}
+	return fmt.Sprintf("ReplayEventKind(%d)", uint8(k))
+}
+
+// ReplayEvent 是重放日志中的一个事件。 各字段只在对应的事件种类中有意义。
+type ReplayEvent struct {
+	// Kind 是事件的种类。
+	Kind ReplayEventKind
+
+	// ScriptIdx 和 OpcodeIdx 是 ReplayEventStep 中操作码所在的脚本序号和操作码序号。
+	ScriptIdx int
+	OpcodeIdx int
+
+	// Opcode 是 ReplayEventStep 中的操作码值。
+	Opcode byte
+
+	// Executing 表示 ReplayEventStep 中的操作码是否位于执行分支中。
+	Executing bool
+
+	// BranchTaken 表示 ReplayEventBranch 之后的 then 分支是否被执行。
+	BranchTaken bool
+
+	// SigType 和 SigValid 是 ReplayEventSigCheck 中签名的类型和验证结果。
+	SigType  SigType
+	SigValid bool
+
+	// Success 和 ErrorCode 是 ReplayEventResult 中执行的结果，执行失败时 ErrorCode 是错误代码，
+	// 不是 Error 类型的错误记为 ErrInternal。
+	Success   bool
+	ErrorCode ErrorCode
+
+	// Truncated 表示 ReplayEventResult 之前的事件是否因日志大小限制被截断。
+	Truncated bool
+}
+
+// String 返回事件的可读描述。
+func (e ReplayEvent) String() string {
+	switch e.Kind {
+	case ReplayEventStep:
+		return fmt.Sprintf("step %02x:%04d %s executing=%v", e.ScriptIdx,
+			e.OpcodeIdx, opcodeArray[e.Opcode].name, e.Executing)
+	case ReplayEventBranch:
+		return fmt.Sprintf("branch taken=%v", e.BranchTaken)
+	case ReplayEventSigCheck:
+		return fmt.Sprintf("sig check %v valid=%v", e.SigType, e.SigValid)
+	case ReplayEventResult:
+		if e.Success {
+			return "result success"
+		}
+		return fmt.Sprintf("result %v", e.ErrorCode)
+	}
+	return e.Kind.String()
+}
+
+// ReplayLog 记录一次脚本执行的重放日志。 通过 WithReplayLog 传给 NewEngine 后，引擎在执行期间向其追加事件。
+//
+// 日志的大小受 NewReplayLog 指定的上限约束，超出上限的事件不再保存，但启用哈希时仍计入哈希，
+// 因此两个节点即使日志被截断也可以通过比较哈希判断执行是否完全相同。 ReplayLog 不是并发安全的，每个日志只能记录一次执行。
+type ReplayLog struct {
+	buf       []byte
+	maxBytes  int
+	truncated bool
+	done      bool
+	hasher    hash.Hash
+	scratch   [2*binary.MaxVarintLen64 + 4]byte
+}
+
+// NewReplayLog 返回一个最多保存 maxBytes 字节事件的空日志，maxBytes 不大于 0 时使用 DefaultReplayLogSize。
+// withHash 为 true 时，日志同时计算所有事件的 SHA256 哈希。
+func NewReplayLog(maxBytes int, withHash bool) *ReplayLog {
+	if maxBytes <= 0 {
+		maxBytes = DefaultReplayLogSize
+	}
+	l := &ReplayLog{maxBytes: maxBytes}
+	if withHash {
+		l.hasher = sha256.New()
+	}
+	return l
+}
+
+// WithReplayLog 使引擎把执行过程记录到传入的日志中。
+func WithReplayLog(log *ReplayLog) EngineOpt {
+	return func(cfg *engineConfig) {
+		cfg.replayLog = log
+	}
+}
+
+// append 追加一个编码后的事件，超出大小上限时只计入哈希。 结果事件由 recordResult 直接保存，因此截断的日志仍然以结果结束。
+func (l *ReplayLog) append(record []byte) {
+	if l.done {
+		return
+	}
+	if l.hasher != nil {
+		l.hasher.Write(record)
+	}
+	// Once an event is dropped all later ones are too, so that the
+	// stored events are always a prefix of the execution.
+	if l.truncated || len(l.buf)+len(record) > l.maxBytes {
+		l.truncated = true
+		return
+	}
+	l.buf = append(l.buf, record...)
+}
+
+// recordStep 记录即将执行的操作码。
+func (l *ReplayLog) recordStep(scriptIdx, opcodeIdx int, op byte,
+	executing bool) {
+
+	record := l.scratch[:0]
+	record = append(record, byte(ReplayEventStep))
+	record = binary.AppendUvarint(record, uint64(scriptIdx))
+	record = binary.AppendUvarint(record, uint64(opcodeIdx))
+	record = append(record, op, boolByte(executing))
+	l.append(record)
+}
+
+// recordBranch 记录条件操作码的分支走向。
+func (l *ReplayLog) recordBranch(taken bool) {
+	l.append(append(l.scratch[:0], byte(ReplayEventBranch),
+		boolByte(taken)))
+}
+
+// recordSigCheck 记录一次签名验证的结果。
+func (l *ReplayLog) recordSigCheck(sigType SigType, valid bool) {
+	l.append(append(l.scratch[:0], byte(ReplayEventSigCheck),
+		byte(sigType), boolByte(valid)))
+}
+
+// recordSigCheck 在启用重放日志时记录一次签名验证的结果。 被推迟到 AsyncSigVerifier 的验证记为有效，
+// 其真实结果体现在执行结果中。
+func (vm *Engine) recordSigCheck(sigType SigType, valid bool) {
+	if vm.replayLog != nil {
+		vm.replayLog.recordSigCheck(sigType, valid)
+	}
+}
+
+// recordResult 记录执行结果并结束日志。
+func (l *ReplayLog) recordResult(err error) {
+	if l.done {
+		return
+	}
+	record := append(l.scratch[:0], byte(ReplayEventResult))
+	if err == nil {
+		record = binary.AppendUvarint(record, 0)
+	} else {
+		code := ErrInternal
+		var scriptErr Error
+		if errors.As(err, &scriptErr) {
+			code = scriptErr.ErrorCode
+		}
+		record = binary.AppendUvarint(record, uint64(code)+1)
+	}
+
+	// The truncation flag isn't hashed so that logs of different sizes
+	// of the same execution hash identically.
+	if l.hasher != nil {
+		l.hasher.Write(record)
+	}
+	record = append(record, boolByte(l.truncated))
+	l.buf = append(l.buf, record...)
+	l.done = true
+}
+
+// boolByte 将布尔值编码为一个字节。
+func boolByte(b bool) byte {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// Bytes 返回序列化的日志。 执行结束前返回的日志没有结果事件，不能用于 Replay。
+func (l *ReplayLog) Bytes() []byte {
+	b := make([]byte, 0, len(replayLogMagic)+len(l.buf))
+	b = append(b, replayLogMagic...)
+	return append(b, l.buf...)
+}
+
+// Truncated 返回是否有事件因大小限制未被保存。
+func (l *ReplayLog) Truncated() bool {
+	return l.truncated
+}
+
+// Hash 返回所有事件（包括被截断的事件）的 SHA256 哈希。 创建日志时未启用哈希则返回 false。
+func (l *ReplayLog) Hash() (chainhash.Hash, bool) {
+	if l.hasher == nil {
+		return chainhash.Hash{}, false
+	}
+	var h chainhash.Hash
+	copy(h[:], l.hasher.Sum(nil))
+	return h, true
+}
+
+// DecodeReplayLog 解码 ReplayLog.Bytes 返回的序列化日志。
+func DecodeReplayLog(serialized []byte) ([]ReplayEvent, error) {
+	if !bytes.HasPrefix(serialized, replayLogMagic) {
+		return nil, fmt.Errorf("%w: bad magic", ErrInvalidReplayLog)
+	}
+	r := bytes.NewReader(serialized[len(replayLogMagic):])
+
+	readByte := func() (byte, error) {
+		b, err := r.ReadByte()
+		if err != nil {
+			return 0, fmt.Errorf("%w: unexpected end", ErrInvalidReplayLog)
+		}
+		return b, nil
+	}
+	readUvarint := func() (uint64, error) {
+		v, err := binary.ReadUvarint(r)
+		if err != nil {
+			return 0, fmt.Errorf("%w: bad varint", ErrInvalidReplayLog)
+		}
+		return v, nil
+	}
+	readBool := func() (bool, error) {
+		b, err := readByte()
+		if err != nil {
+			return false, err
+		}
+		if b > 1 {
+			return false, fmt.Errorf("%w: bad bool %d",
+				ErrInvalidReplayLog, b)
+		}
+		return b == 1, nil
+	}
+
+	var events []ReplayEvent
+	for r.Len() > 0 {
+		if n := len(events); n > 0 && events[n-1].Kind == ReplayEventResult {
+			return nil, fmt.Errorf("%w: data after result",
+				ErrInvalidReplayLog)
+		}
+
+		kind, _ := readByte()
+		event := ReplayEvent{Kind: ReplayEventKind(kind)}
+		var err error
+		switch event.Kind {
+		case ReplayEventStep:
+			var scriptIdx, opcodeIdx uint64
+			if scriptIdx, err = readUvarint(); err != nil {
+				return nil, err
+			}
+			if opcodeIdx, err = readUvarint(); err != nil {
+				return nil, err
+			}
+			event.ScriptIdx = int(scriptIdx)
+			event.OpcodeIdx = int(opcodeIdx)
+			if event.Opcode, err = readByte(); err != nil {
+				return nil, err
+			}
+			event.Executing, err = readBool()
+
+		case ReplayEventBranch:
+			event.BranchTaken, err = readBool()
+
+		case ReplayEventSigCheck:
+			var sigType byte
+			if sigType, err = readByte(); err != nil {
+				return nil, err
+			}
+			event.SigType = SigType(sigType)
+			event.SigValid, err = readBool()
+
+		case ReplayEventResult:
+			var code uint64
+			if code, err = readUvarint(); err != nil {
+				return nil, err
+			}
+			event.Success = code == 0
+			if code != 0 {
+				event.ErrorCode = ErrorCode(code - 1)
+			}
+			event.Truncated, err = readBool()
+
+		default:
+			return nil, fmt.Errorf("%w: unknown event kind %d",
+				ErrInvalidReplayLog, kind)
+		}
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, event)
+	}
+
+	if len(events) == 0 || events[len(events)-1].Kind != ReplayEventResult {
+		return nil, fmt.Errorf("%w: missing result", ErrInvalidReplayLog)
+	}
+	return events, nil
+}
+
+// ReplayDivergence 描述重放与记录的日志第一次不一致的位置。
+type ReplayDivergence struct {
+	// Index 是第一个不一致的事件序号。
+	Index int
+
+	// Recorded 和 Replayed 分别是记录的日志和重放产生的事件，某一方在该位置已经没有事件时为 nil。
+	Recorded *ReplayEvent
+	Replayed *ReplayEvent
+}
+
+// String 返回分歧的可读描述。
+func (d *ReplayDivergence) String() string {
+	describe := func(e *ReplayEvent) string {
+		if e == nil {
+			return "<none>"
+		}
+		return e.String()
+	}
+	return fmt.Sprintf("event %d: recorded %s, replayed %s", d.Index,
+		describe(d.Recorded), describe(d.Replayed))
+}
+
+// Replay 执行引擎并将执行过程与 recorded 中序列化的日志比较，返回第一个分歧，执行完全一致时返回 nil。
+// 脚本执行失败本身不是错误，而是作为结果事件参与比较。 recorded 无法解码时返回 ErrInvalidReplayLog。
+//
+// 如果记录的日志被截断，只比较截断之前的事件和最终结果。 引擎必须以与记录时相同的参数构造，并且不应使用 ValidationCache，
+// 否则命中缓存的执行不会产生任何步骤。 与 Execute 一样，Replay 只能调用一次。
+func (vm *Engine) Replay(recorded []byte) (*ReplayDivergence, error) {
+	want, err := DecodeReplayLog(recorded)
+	if err != nil {
+		return nil, err
+	}
+
+	// The replay itself is never truncated so that every recorded event
+	// has a counterpart to compare against.
+	log := &ReplayLog{maxBytes: int(^uint(0) >> 1)}
+	vm.replayLog = log
+	_ = vm.Execute()
+
+	got, err := DecodeReplayLog(log.Bytes())
+	if err != nil {
+		return nil, err
+	}
+	return diffReplayEvents(want, got), nil
+}
+
+// diffReplayEvents 比较两个解码后的日志，返回第一个分歧。 两者都以结果事件结束。
+func diffReplayEvents(want, got []ReplayEvent) *ReplayDivergence {
+	wantResult, gotResult := want[len(want)-1], got[len(got)-1]
+	want, got = want[:len(want)-1], got[:len(got)-1]
+
+	for i := 0; i < len(want) || i < len(got); i++ {
+		switch {
+		case i >= len(want):
+			if wantResult.Truncated {
+				return diffReplayResults(len(want), wantResult, gotResult)
+			}
+			return &ReplayDivergence{Index: i, Replayed: &got[i]}
+
+		case i >= len(got):
+			return &ReplayDivergence{Index: i, Recorded: &want[i]}
+		}
+
+		if want[i] != got[i] {
+			return &ReplayDivergence{
+				Index:    i,
+				Recorded: &want[i],
+				Replayed: &got[i],
+			}
+		}
+	}
+
+	return diffReplayResults(len(want), wantResult, gotResult)
+}
+
+// diffReplayResults 比较两个结果事件，忽略截断标志。
+func diffReplayResults(index int, want, got ReplayEvent) *ReplayDivergence {
+	want.Truncated, got.Truncated = false, false
+	if want == got {
+		return nil
+	}
+	return &ReplayDivergence{Index: index, Recorded: &want, Replayed: &got}
+}
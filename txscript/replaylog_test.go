@@ -0,0 +1,155 @@
+package txscript
+
+import (
+	"testing"
+
+	"github.com/btcsuite/btcd/wire"
+	"github.com/stretchr/testify/require"
+)
+
+// TestReplayLog 确保重放日志记录操作码、分支和签名验证，重放相同的执行没有分歧，
+// 而验证结果不同的重放报告第一个分歧的事件。
+func TestReplayLog(t *testing.T) {
+	t.Parallel()
+
+	tx, pkScript, amt := multisigSpend(t, 2, 3)
+	prevFetcher := NewCannedPrevOutputFetcher(pkScript, amt)
+	newEngine := func(opts ...EngineOpt) *Engine {
+		vm, err := NewEngine(pkScript, tx, 0, StandardVerifyFlags, nil,
+			NewTxSigHashes(tx, prevFetcher), amt, prevFetcher, opts...)
+		require.NoError(t, err)
+		return vm
+	}
+
+	log := NewReplayLog(0, true)
+	require.NoError(t, newEngine(WithReplayLog(log)).Execute())
+	require.False(t, log.Truncated())
+
+	events, err := DecodeReplayLog(log.Bytes())
+	require.NoError(t, err)
+	var steps, sigChecks int
+	for _, event := range events {
+		require.NotEmpty(t, event.String())
+		switch event.Kind {
+		case ReplayEventStep:
+			steps++
+		case ReplayEventSigCheck:
+			sigChecks++
+			require.Equal(t, SigTypeECDSA, event.SigType)
+		}
+	}
+	require.Equal(t, 8, steps)
+	require.Equal(t, 3, sigChecks)
+	require.Equal(t, ReplayEvent{Kind: ReplayEventResult, Success: true},
+		events[len(events)-1])
+
+	divergence, err := newEngine().Replay(log.Bytes())
+	require.NoError(t, err)
+	require.Nil(t, divergence)
+
+	// 截断的日志保留结果，哈希与完整日志相同，并且仍然可以重放。
+	small := NewReplayLog(8, true)
+	require.NoError(t, newEngine(WithReplayLog(small)).Execute())
+	require.True(t, small.Truncated())
+	require.Less(t, len(small.Bytes()), len(log.Bytes()))
+	hash, ok := log.Hash()
+	require.True(t, ok)
+	smallHash, ok := small.Hash()
+	require.True(t, ok)
+	require.Equal(t, hash, smallHash)
+
+	divergence, err = newEngine().Replay(small.Bytes())
+	require.NoError(t, err)
+	require.Nil(t, divergence)
+
+	_, ok = NewReplayLog(0, false).Hash()
+	require.False(t, ok)
+
+	// 拒绝所有签名的后端在第一次签名验证处产生分歧。
+	divergence, err = newEngine(
+		WithVerifierBackend(&countingVerifier{reject: true}),
+	).Replay(log.Bytes())
+	require.NoError(t, err)
+	require.NotNil(t, divergence)
+	require.Equal(t, ReplayEventSigCheck, divergence.Recorded.Kind)
+	require.True(t, divergence.Recorded.SigValid)
+	require.False(t, divergence.Replayed.SigValid)
+	require.NotEmpty(t, divergence.String())
+}
+
+// TestReplayLogBranches 确保条件操作码的分支走向和失败的执行结果被记录。
+func TestReplayLogBranches(t *testing.T) {
+	t.Parallel()
+
+	pkScript := mustParseShortForm("IF 2 ELSE 3 ENDIF 3 EQUAL")
+	execute := func(sigScript []byte) *ReplayLog {
+		tx := createSpendingTx(nil, sigScript, pkScript, 0)
+		log := NewReplayLog(0, false)
+		vm, err := NewEngine(pkScript, tx, 0, 0, nil, nil, 0,
+			NewCannedPrevOutputFetcher(pkScript, 0), WithReplayLog(log))
+		require.NoError(t, err)
+		_ = vm.Execute()
+		return log
+	}
+
+	log := execute(mustParseShortForm("0"))
+	events, err := DecodeReplayLog(log.Bytes())
+	require.NoError(t, err)
+	require.Contains(t, events, ReplayEvent{
+		Kind: ReplayEventBranch, BranchTaken: false,
+	})
+	require.True(t, events[len(events)-1].Success)
+
+	log = execute(mustParseShortForm("1"))
+	events, err = DecodeReplayLog(log.Bytes())
+	require.NoError(t, err)
+	require.Contains(t, events, ReplayEvent{
+		Kind: ReplayEventBranch, BranchTaken: true,
+	})
+	require.Equal(t, ReplayEvent{
+		Kind: ReplayEventResult, ErrorCode: ErrEvalFalse,
+	}, events[len(events)-1])
+
+	// 跳过的 OP_ELSE 分支中的操作码被记录为未执行。
+	require.Contains(t, events, ReplayEvent{
+		Kind: ReplayEventStep, ScriptIdx: 1, OpcodeIdx: 3, Opcode: OP_3,
+	})
+
+	tx := createSpendingTx(nil, mustParseShortForm("0"), pkScript, 0)
+	vm, err := NewEngine(pkScript, tx, 0, 0, nil, nil, 0,
+		NewCannedPrevOutputFetcher(pkScript, 0))
+	require.NoError(t, err)
+	divergence, err := vm.Replay(log.Bytes())
+	require.NoError(t, err)
+	require.NotNil(t, divergence)
+	require.Zero(t, divergence.Index)
+	require.Equal(t, byte(OP_1), divergence.Recorded.Opcode)
+	require.Equal(t, byte(OP_0), divergence.Replayed.Opcode)
+}
+
+// TestDecodeReplayLogErrors 确保格式错误的日志被拒绝。
+func TestDecodeReplayLogErrors(t *testing.T) {
+	t.Parallel()
+
+	tests := [][]byte{
+		nil,
+		[]byte("bad"),
+		replayLogMagic,
+		append(append([]byte{}, replayLogMagic...), 0x09),
+		append(append([]byte{}, replayLogMagic...), 0x02, 0x02),
+		append(append([]byte{}, replayLogMagic...), 0x01, 0x00),
+		append(append([]byte{}, replayLogMagic...), 0x04, 0x00, 0x00,
+			0x02, 0x01),
+	}
+	for _, test := range tests {
+		_, err := DecodeReplayLog(test)
+		require.ErrorIs(t, err, ErrInvalidReplayLog, "%x", test)
+	}
+
+	var tx wire.MsgTx
+	tx.AddTxIn(&wire.TxIn{})
+	vm, err := NewEngine([]byte{OP_TRUE}, &tx, 0, 0, nil, nil, 0, nil)
+	require.NoError(t, err)
+	_, err = vm.Replay([]byte("bad"))
+	require.ErrorIs(t, err, ErrInvalidReplayLog)
+}
@@ -0,0 +1,263 @@
+// 包含脚本依赖提取，用于在不要求脚本匹配标准模板的情况下，找出花费脚本所需的公钥、哈希原像和时间锁。
+
+package txscript
+
+import (
+	"bytes"
+
+	"github.com/btcsuite/btcd/wire"
+)
+
+// HashLockType 表示哈希锁使用的哈希操作码。
+type HashLockType uint8
+
+const (
+	// HashLockSHA256 表示 OP_SHA256 哈希锁。
+	HashLockSHA256 HashLockType = iota
+
+	// HashLockHash256 表示 OP_HASH256（双 SHA256）哈希锁。
+	HashLockHash256
+
+	// HashLockHash160 表示 OP_HASH160 哈希锁。
+	HashLockHash160
+
+	// HashLockRipemd160 表示 OP_RIPEMD160 哈希锁。
+	HashLockRipemd160
+
+	// HashLockSHA1 表示 OP_SHA1 哈希锁。
+	HashLockSHA1
+)
+
+// String 返回哈希锁类型的可读名称。
+func (t HashLockType) String() string {
+	switch t {
+	case HashLockSHA256:
+		return "sha256"
+	case HashLockHash256:
+		return "hash256"
+	case HashLockHash160:
+		return "hash160"
+	case HashLockRipemd160:
+		return "ripemd160"
+	case HashLockSHA1:
+		return "sha1"
+	default:
+		return "unknown"
+	}
+}
+
+// hashLockOps 将哈希操作码映射到对应的哈希锁类型及摘要长度。
+var hashLockOps = map[byte]struct {
+	lockType HashLockType
+	size     int
+}{
+	OP_SHA256:    {HashLockSHA256, 32},
+	OP_HASH256:   {HashLockHash256, 32},
+	OP_HASH160:   {HashLockHash160, 20},
+	OP_RIPEMD160: {HashLockRipemd160, 20},
+	OP_SHA1:      {HashLockSHA1, 20},
+}
+
+// HashLock 是脚本要求出示原像的哈希值。
+type HashLock struct {
+	// Type 是计算哈希使用的操作码类型。
+	Type HashLockType
+
+	// Hash 是原像必须匹配的哈希值。
+	Hash []byte
+}
+
+// TimeLock 是脚本通过 OP_CHECKLOCKTIMEVERIFY 或 OP_CHECKSEQUENCEVERIFY 施加的时间锁约束。
+type TimeLock struct {
+	// Relative 表示该约束来自 OP_CHECKSEQUENCEVERIFY，否则来自 OP_CHECKLOCKTIMEVERIFY。
+	Relative bool
+
+	// Value 是推送到堆栈上的锁定值。 对于绝对时间锁，它与交易的锁定时间比较；对于相对时间锁，它与输入的序列号比较。
+	Value int64
+}
+
+// IsTimeBased 返回时间锁是以秒（时间戳）而不是区块高度计量的。
+func (l TimeLock) IsTimeBased() bool {
+	if l.Relative {
+		return l.Value&int64(wire.SequenceLockTimeIsSeconds) != 0
+	}
+	return l.Value >= LockTimeThreshold
+}
+
+// ScriptRequirements 描述花费一个脚本可能需要满足的条件。 每个列表按首次出现的顺序排列且不含重复项。
+type ScriptRequirements struct {
+	// PubKeys 是脚本引用的公钥，包括压缩、未压缩和混合编码的 ECDSA 公钥，
+	// 以及作为签名检查操作码输入的 32 字节 x-only 公钥。 P2TR 输出的输出密钥也包含在内。
+	PubKeys [][]byte
+
+	// PubKeyHashes 是脚本以 DUP HASH160 <hash> 形式引用的公钥哈希，
+	// 以及 P2WPKH 输出的见证程序。
+	PubKeyHashes [][]byte
+
+	// HashLocks 是脚本以 <hash op> <hash> EQUAL 或 EQUALVERIFY 形式要求出示原像的哈希值。
+	HashLocks []HashLock
+
+	// TimeLocks 是脚本施加的绝对和相对时间锁约束。
+	TimeLocks []TimeLock
+}
+
+// addPubKey 在公钥尚未记录时添加该公钥。
+func (r *ScriptRequirements) addPubKey(pubKey []byte) {
+	for _, existing := range r.PubKeys {
+		if bytes.Equal(existing, pubKey) {
+			return
+		}
+	}
+	r.PubKeys = append(r.PubKeys, pubKey)
+}
+
+// addPubKeyHash 在公钥哈希尚未记录时添加该哈希。
+func (r *ScriptRequirements) addPubKeyHash(hash []byte) {
+	for _, existing := range r.PubKeyHashes {
+		if bytes.Equal(existing, hash) {
+			return
+		}
+	}
+	r.PubKeyHashes = append(r.PubKeyHashes, hash)
+}
+
+// addHashLock 在哈希锁尚未记录时添加该哈希锁。
+func (r *ScriptRequirements) addHashLock(lock HashLock) {
+	for _, existing := range r.HashLocks {
+		if existing.Type == lock.Type && bytes.Equal(existing.Hash, lock.Hash) {
+			return
+		}
+	}
+	r.HashLocks = append(r.HashLocks, lock)
+}
+
+// addTimeLock 在时间锁尚未记录时添加该时间锁。
+func (r *ScriptRequirements) addTimeLock(lock TimeLock) {
+	for _, existing := range r.TimeLocks {
+		if existing == lock {
+			return
+		}
+	}
+	r.TimeLocks = append(r.TimeLocks, lock)
+}
+
+// requirementToken 是 ExtractRequirements 扫描时使用的已解析操作码。
+type requirementToken struct {
+	op   byte
+	data []byte
+}
+
+// isCheckSigOp 返回操作码是否从堆栈顶部消耗单个公钥进行签名检查。
+func isCheckSigOp(op byte) bool {
+	switch op {
+	case OP_CHECKSIG, OP_CHECKSIGVERIFY, OP_CHECKSIGADD:
+		return true
+	}
+	return false
+}
+
+// ExtractRequirements 遍历脚本中的所有操作码，并返回其引用的公钥、公钥哈希、哈希锁和时间锁。
+// 与 ExtractPkScriptAddrs 不同，脚本不需要匹配任何标准模板，因此可以用于非标准脚本、兑换脚本、见证脚本和 tapscript 叶子。
+//
+// 识别基于操作码模式而不是执行，因此不会考虑条件分支是否可达，也无法识别通过计算得到的值。 脚本无法解析时返回错误。
+func ExtractRequirements(script []byte) (*ScriptRequirements, error) {
+	var reqs ScriptRequirements
+
+	// 见证程序不包含任何操作码形式的约束，但其程序本身就是花费所需的公钥或公钥哈希。
+	if hash := extractWitnessPubKeyHash(script); hash != nil {
+		reqs.addPubKeyHash(hash)
+		return &reqs, nil
+	}
+	if key := extractWitnessV1KeyBytes(script); key != nil {
+		reqs.addPubKey(key)
+		return &reqs, nil
+	}
+
+	const scriptVersion = 0
+	var tokens []requirementToken
+	tokenizer := MakeScriptTokenizer(scriptVersion, script)
+	for tokenizer.Next() {
+		tokens = append(tokens, requirementToken{
+			op:   tokenizer.Opcode(),
+			data: tokenizer.Data(),
+		})
+	}
+	if err := tokenizer.Err(); err != nil {
+		return nil, err
+	}
+
+	opAt := func(i int) byte {
+		if i < 0 || i >= len(tokens) {
+			return OP_INVALIDOPCODE
+		}
+		return tokens[i].op
+	}
+
+	for i, token := range tokens {
+		switch {
+		// 任何严格编码的 ECDSA 公钥推送都被视为公钥，无论随后如何使用。
+		case isStrictPubKeyEncoding(token.data):
+			reqs.addPubKey(token.data)
+
+		// 32 字节的推送可能是 x-only 公钥或哈希值，因此只在紧接着签名检查操作码时才视为公钥。
+		case len(token.data) == 32 && isCheckSigOp(opAt(i+1)):
+			reqs.addPubKey(token.data)
+
+		case token.op == OP_CHECKLOCKTIMEVERIFY,
+			token.op == OP_CHECKSEQUENCEVERIFY:
+
+			value, ok := requirementNum(tokens, i-1)
+			if !ok {
+				continue
+			}
+			reqs.addTimeLock(TimeLock{
+				Relative: token.op == OP_CHECKSEQUENCEVERIFY,
+				Value:    value,
+			})
+		}
+
+		// 公钥哈希以 DUP HASH160 <hash> 的形式出现，即对堆栈上的公钥副本进行哈希，
+		// 与之比较的操作码可能位于条件分支之后，例如 HTLC 的两个分支共用 EQUALVERIFY CHECKSIG。
+		lockOp, ok := hashLockOps[token.op]
+		if !ok || i+1 >= len(tokens) ||
+			len(tokens[i+1].data) != lockOp.size {
+
+			continue
+		}
+		hash := tokens[i+1].data
+		if token.op == OP_HASH160 && opAt(i-1) == OP_DUP {
+			reqs.addPubKeyHash(hash)
+			continue
+		}
+
+		// 其他哈希值只有在与原像哈希直接比较时才被视为哈希锁。
+		if op := opAt(i + 2); op != OP_EQUAL && op != OP_EQUALVERIFY {
+			continue
+		}
+		reqs.addHashLock(HashLock{Type: lockOp.lockType, Hash: hash})
+	}
+
+	return &reqs, nil
+}
+
+// requirementNum 返回指定位置的操作码推送的数值。 该位置不是数值推送时，第二个返回值为 false。
+func requirementNum(tokens []requirementToken, i int) (int64, bool) {
+	if i < 0 {
+		return 0, false
+	}
+
+	token := tokens[i]
+	if IsSmallInt(token.op) {
+		return int64(AsSmallInt(token.op)), true
+	}
+	if token.op > OP_PUSHDATA4 || len(token.data) == 0 {
+		return 0, false
+	}
+
+	// 锁定时间最多使用 5 个字节编码，与 CHECKLOCKTIMEVERIFY 和 CHECKSEQUENCEVERIFY 的限制相同。
+	num, err := MakeScriptNum(token.data, false, 5)
+	if err != nil {
+		return 0, false
+	}
+	return int64(num), true
+}
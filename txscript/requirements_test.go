@@ -0,0 +1,115 @@
+package txscript
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcec/v2/schnorr"
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/stretchr/testify/require"
+)
+
+// TestExtractRequirementsHTLC 确保从传统和 tapscript HTLC 中提取出公钥、公钥哈希、哈希锁和绝对时间锁。
+func TestExtractRequirementsHTLC(t *testing.T) {
+	t.Parallel()
+
+	recipientHash := [20]byte{0x01}
+	refundHash := [20]byte{0x02}
+	secretHash := [32]byte{0x03}
+	const lockTime = 600000
+
+	script, err := BuildHTLCScript(recipientHash, refundHash, secretHash,
+		lockTime)
+	require.NoError(t, err)
+
+	reqs, err := ExtractRequirements(script)
+	require.NoError(t, err)
+	require.Empty(t, reqs.PubKeys)
+	require.Equal(t, [][]byte{recipientHash[:], refundHash[:]},
+		reqs.PubKeyHashes)
+	require.Equal(t, []HashLock{{Type: HashLockSHA256, Hash: secretHash[:]}},
+		reqs.HashLocks)
+	require.Equal(t, []TimeLock{{Value: lockTime}}, reqs.TimeLocks)
+	require.False(t, reqs.TimeLocks[0].IsTimeBased())
+
+	recipientKey, err := btcec.NewPrivateKey()
+	require.NoError(t, err)
+	refundKey, err := btcec.NewPrivateKey()
+	require.NoError(t, err)
+	redeem, refund, err := BuildTapscriptHTLCLeaves(recipientKey.PubKey(),
+		refundKey.PubKey(), secretHash, lockTime)
+	require.NoError(t, err)
+
+	reqs, err = ExtractRequirements(redeem.Script)
+	require.NoError(t, err)
+	require.Equal(t, [][]byte{schnorr.SerializePubKey(recipientKey.PubKey())},
+		reqs.PubKeys)
+	require.Len(t, reqs.HashLocks, 1)
+	require.Empty(t, reqs.TimeLocks)
+
+	reqs, err = ExtractRequirements(refund.Script)
+	require.NoError(t, err)
+	require.Equal(t, [][]byte{schnorr.SerializePubKey(refundKey.PubKey())},
+		reqs.PubKeys)
+	require.Empty(t, reqs.HashLocks)
+	require.Equal(t, []TimeLock{{Value: lockTime}}, reqs.TimeLocks)
+}
+
+// TestExtractRequirementsNonStandard 确保提取不依赖标准模板，并正确处理重复项、相对时间锁和见证程序。
+func TestExtractRequirementsNonStandard(t *testing.T) {
+	t.Parallel()
+
+	privKey, err := btcec.NewPrivateKey()
+	require.NoError(t, err)
+	pubKey := privKey.PubKey().SerializeCompressed()
+	keyHash := btcutil.Hash160(pubKey)
+	image := bytes.Repeat([]byte{0xaa}, 20)
+
+	// 同一个公钥出现两次，HASH160 哈希锁之后没有签名检查，相对时间锁以秒计量。
+	script, err := NewScriptBuilder().
+		AddOp(OP_IF).
+		AddData(pubKey).AddOp(OP_CHECKSIGVERIFY).
+		AddOp(OP_HASH160).AddData(image).AddOp(OP_EQUAL).
+		AddOp(OP_ELSE).
+		AddInt64(4194305).AddOp(OP_CHECKSEQUENCEVERIFY).AddOp(OP_DROP).
+		AddOp(OP_16).AddOp(OP_CHECKSEQUENCEVERIFY).AddOp(OP_DROP).
+		AddData(pubKey).AddOp(OP_CHECKSIG).
+		AddOp(OP_ENDIF).
+		Script()
+	require.NoError(t, err)
+
+	reqs, err := ExtractRequirements(script)
+	require.NoError(t, err)
+	require.Equal(t, [][]byte{pubKey}, reqs.PubKeys)
+	require.Empty(t, reqs.PubKeyHashes)
+	require.Equal(t, []HashLock{{Type: HashLockHash160, Hash: image}},
+		reqs.HashLocks)
+	require.Equal(t, []TimeLock{
+		{Relative: true, Value: 4194305},
+		{Relative: true, Value: 16},
+	}, reqs.TimeLocks)
+	require.True(t, reqs.TimeLocks[0].IsTimeBased())
+	require.False(t, reqs.TimeLocks[1].IsTimeBased())
+
+	// 32 字节推送在没有签名检查时不被视为公钥。
+	reqs, err = ExtractRequirements(mustParseShortForm("DATA_32 0x" +
+		"0101010101010101010101010101010101010101010101010101010101010101"))
+	require.NoError(t, err)
+	require.Empty(t, reqs.PubKeys)
+
+	// 见证程序本身就是花费所需的公钥哈希或输出密钥。
+	p2wpkh := append([]byte{OP_0, OP_DATA_20}, keyHash...)
+	reqs, err = ExtractRequirements(p2wpkh)
+	require.NoError(t, err)
+	require.Equal(t, [][]byte{keyHash}, reqs.PubKeyHashes)
+
+	outputKey := schnorr.SerializePubKey(privKey.PubKey())
+	p2tr := append([]byte{OP_1, OP_DATA_32}, outputKey...)
+	reqs, err = ExtractRequirements(p2tr)
+	require.NoError(t, err)
+	require.Equal(t, [][]byte{outputKey}, reqs.PubKeys)
+
+	_, err = ExtractRequirements([]byte{OP_DATA_2, 0x01})
+	require.True(t, IsErrorCode(err, ErrMalformedPush))
+}
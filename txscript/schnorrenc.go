@@ -0,0 +1,78 @@
+// 包含 BIP0340 签名编码的检查，在不执行脚本引擎的情况下按 BIP0340 和 BIP0341 的规则检查签名的长度、R 和 s 的取值范围
+// 以及 taproot 签名哈希字节，使接收用户提供的签名的接口能够尽早拒绝格式错误的数据，并返回精确的错误码。
+
+package txscript
+
+import (
+	"fmt"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcec/v2/schnorr"
+)
+
+// CheckSchnorrSignatureEncoding 检查 sig 是否是编码有效的 64 字节 BIP0340 签名：长度错误返回 ErrInvalidTaprootSigLen 错误，
+// R 不小于域的素数返回 ErrSchnorrSigRTooBig 错误，s 不小于群的阶返回 ErrSchnorrSigSTooBig 错误。
+//
+// BIP0340 签名只编码随机数点 R 的 x 坐标，其 Y 坐标隐含为偶数，因此签名编码不携带也不检查随机数的奇偶性，
+// R 是否是曲线上某点的 x 坐标同样只在验证时才能确定。 通过检查的签名仍然需要针对消息和公钥进行验证。
+func CheckSchnorrSignatureEncoding(sig []byte) error {
+	if len(sig) != schnorr.SignatureSize {
+		str := fmt.Sprintf("schnorr signature must be %d bytes, got %d",
+			schnorr.SignatureSize, len(sig))
+		return scriptError(ErrInvalidTaprootSigLen, str)
+	}
+
+	var r btcec.FieldVal
+	if overflow := r.SetByteSlice(sig[:32]); overflow {
+		return scriptError(ErrSchnorrSigRTooBig,
+			"signature R value is not less than the field prime")
+	}
+	var s btcec.ModNScalar
+	if overflow := s.SetByteSlice(sig[32:]); overflow {
+		return scriptError(ErrSchnorrSigSTooBig,
+			"signature s value is not less than the group order")
+	}
+	return nil
+}
+
+// CheckTaprootSignatureEncoding 检查 sig 是否是编码有效的 taproot 签名，并返回其签名哈希类型。
+//
+// 按 BIP0341，64 字节的签名使用 SigHashDefault；65 字节的签名以签名哈希类型结尾，该字节不能为 0x00，
+// 并且必须是 taproot 定义的类型之一，否则返回 ErrInvalidSigHashType 错误。 其他长度返回 ErrInvalidTaprootSigLen 错误。
+// 签名本身按 CheckSchnorrSignatureEncoding 检查。
+//
+// 注意：脚本引擎对以 0x00 结尾的 65 字节签名报告 ErrInvalidTaprootSigLen，这里报告更精确的 ErrInvalidSigHashType。
+func CheckTaprootSignatureEncoding(sig []byte) (SigHashType, error) {
+	switch len(sig) {
+	case schnorr.SignatureSize:
+		if err := CheckSchnorrSignatureEncoding(sig); err != nil {
+			return 0, err
+		}
+		return SigHashDefault, nil
+
+	case schnorr.SignatureSize + 1:
+		hashType := SigHashType(sig[schnorr.SignatureSize])
+		switch {
+		case hashType == SigHashDefault:
+			return 0, scriptError(ErrInvalidSigHashType,
+				"SIGHASH_DEFAULT must be implied by a 64-byte "+
+					"signature rather than an explicit byte")
+
+		case !isValidTaprootSigHash(hashType):
+			str := fmt.Sprintf("invalid taproot sighash type 0x%02x",
+				uint8(hashType))
+			return 0, scriptError(ErrInvalidSigHashType, str)
+		}
+		err := CheckSchnorrSignatureEncoding(sig[:schnorr.SignatureSize])
+		if err != nil {
+			return 0, err
+		}
+		return hashType, nil
+
+	default:
+		str := fmt.Sprintf("taproot signature must be %d or %d bytes, "+
+			"got %d", schnorr.SignatureSize, schnorr.SignatureSize+1,
+			len(sig))
+		return 0, scriptError(ErrInvalidTaprootSigLen, str)
+	}
+}
@@ -0,0 +1,150 @@
+package txscript
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcec/v2/schnorr"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/stretchr/testify/require"
+)
+
+// TestCheckTaprootSignatureEncoding 测试签名长度、R 和 s 的取值范围以及签名哈希字节的检查，并确认随机数的奇偶性不影响编码的有效性。
+func TestCheckTaprootSignatureEncoding(t *testing.T) {
+	t.Parallel()
+
+	privKey, err := btcec.NewPrivateKey()
+	require.NoError(t, err)
+	msg := chainhash.HashB([]byte("bpfschain"))
+	signature, err := schnorr.Sign(privKey, msg)
+	require.NoError(t, err)
+	sig := signature.Serialize()
+
+	withByte := func(sig []byte, b byte) []byte {
+		return append(append([]byte(nil), sig...), b)
+	}
+	withR := func(r []byte) []byte {
+		return append(append([]byte(nil), r...), sig[32:]...)
+	}
+	overflow := bytes.Repeat([]byte{0xff}, 32)
+
+	// An R value that is not the x coordinate of any point still has a
+	// valid encoding.
+	var notOnCurve []byte
+	for x := byte(1); notOnCurve == nil; x++ {
+		candidate := make([]byte, 32)
+		candidate[31] = x
+		if _, err := schnorr.ParsePubKey(candidate); err != nil {
+			notOnCurve = candidate
+		}
+	}
+
+	// The x coordinate of a point with an odd Y coordinate is as valid an R
+	// value as one with an even Y coordinate.
+	var oddKey *btcec.PublicKey
+	for oddKey == nil {
+		key, err := btcec.NewPrivateKey()
+		require.NoError(t, err)
+		if key.PubKey().SerializeCompressed()[0] == 0x03 {
+			oddKey = key.PubKey()
+		}
+	}
+
+	tests := []struct {
+		name     string
+		sig      []byte
+		hashType SigHashType
+		code     ErrorCode
+		fails    bool
+	}{
+		{name: "default", sig: sig, hashType: SigHashDefault},
+		{name: "all", sig: withByte(sig, 0x01), hashType: SigHashAll},
+		{
+			name:     "single anyonecanpay",
+			sig:      withByte(sig, 0x83),
+			hashType: SigHashSingle | SigHashAnyOneCanPay,
+		},
+		{
+			name:  "explicit default",
+			sig:   withByte(sig, 0x00),
+			code:  ErrInvalidSigHashType,
+			fails: true,
+		},
+		{
+			name:  "undefined sighash",
+			sig:   withByte(sig, 0x04),
+			code:  ErrInvalidSigHashType,
+			fails: true,
+		},
+		{
+			name:  "too short",
+			sig:   sig[:63],
+			code:  ErrInvalidTaprootSigLen,
+			fails: true,
+		},
+		{
+			name:  "too long",
+			sig:   withByte(withByte(sig, 0x01), 0x01),
+			code:  ErrInvalidTaprootSigLen,
+			fails: true,
+		},
+		{
+			name:  "r too big",
+			sig:   withR(overflow),
+			code:  ErrSchnorrSigRTooBig,
+			fails: true,
+		},
+		{
+			name:  "s too big",
+			sig:   append(append([]byte(nil), sig[:32]...), overflow...),
+			code:  ErrSchnorrSigSTooBig,
+			fails: true,
+		},
+		{
+			name:     "r not on curve",
+			sig:      withR(notOnCurve),
+			hashType: SigHashDefault,
+		},
+		{
+			name:     "r with odd y",
+			sig:      withR(schnorr.SerializePubKey(oddKey)),
+			hashType: SigHashDefault,
+		},
+	}
+
+	for _, test := range tests {
+		hashType, err := CheckTaprootSignatureEncoding(test.sig)
+		if test.fails {
+			require.True(t, IsErrorCode(err, test.code), "%s: %v",
+				test.name, err)
+			continue
+		}
+		require.NoError(t, err, test.name)
+		require.Equal(t, test.hashType, hashType, test.name)
+	}
+
+	require.NoError(t, CheckSchnorrSignatureEncoding(sig))
+	err = CheckSchnorrSignatureEncoding(withByte(sig, 0x01))
+	require.True(t, IsErrorCode(err, ErrInvalidTaprootSigLen))
+}
+
+// TestCheckXOnlyPubKeyEncoding 测试 x-only 公钥编码检查的错误码。
+func TestCheckXOnlyPubKeyEncoding(t *testing.T) {
+	t.Parallel()
+
+	privKey, err := btcec.NewPrivateKey()
+	require.NoError(t, err)
+	pubKey := privKey.PubKey()
+
+	require.NoError(t, CheckXOnlyPubKeyEncoding(schnorr.SerializePubKey(pubKey)))
+
+	err = CheckXOnlyPubKeyEncoding(nil)
+	require.True(t, IsErrorCode(err, ErrTaprootPubkeyIsEmpty))
+
+	err = CheckXOnlyPubKeyEncoding(pubKey.SerializeCompressed())
+	require.True(t, IsErrorCode(err, ErrPubKeyType))
+
+	err = CheckXOnlyPubKeyEncoding(bytes.Repeat([]byte{0xff}, 32))
+	require.True(t, IsErrorCode(err, ErrXOnlyPubKeyNotOnCurve))
+}
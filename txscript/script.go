@@ -83,6 +83,12 @@ func IsNullData(script []byte) bool {
 	return isNullDataScript(scriptVersion, script)
 }
 
+// IsNullData 与包级函数 IsNullData 相同，但空数据脚本推送的数据不能超过 l.MaxDataCarrierSize 字节。
+func (l *ChainLimits) IsNullData(script []byte) bool {
+	const scriptVersion = 0
+	return isNullDataScriptSize(scriptVersion, script, l.MaxDataCarrierSize)
+}
+
 // ExtractWitnessProgramInfo 尝试从传递的脚本中提取见证程序版本以及见证程序本身。
 func ExtractWitnessProgramInfo(script []byte) (int, []byte, error) {
 	// If at this point, the scripts doesn't resemble a witness program,
@@ -118,18 +124,50 @@ func IsPushOnlyScript(script []byte) bool {
 
 // DisasmString 将反汇编脚本格式化为一行打印。 当脚本解析失败时，返回的字符串将包含失败发生点之前的反汇编脚本，并附加字符串'[error]'。 此外，如果调用者想要有关失败的更多信息，则会返回脚本解析失败的原因。
 //
-// 注意：该函数仅对0版本脚本有效。 由于该函数不接受脚本版本，因此其他脚本版本的结果未定义。
+// 注意：该函数仅对0版本脚本有效。 其他脚本版本请使用 DisasmStringVersion，tapscript 叶子脚本请使用 DisasmTapscript。
 func DisasmString(script []byte) (string, error) {
-	const scriptVersion = 0
+	return disasmScript(0, script, false)
+}
 
+// DisasmStringVersion 与 DisasmString 相同，但按给定的脚本版本解析脚本。 版本必须是 0 或通过 RegisterScriptVersion
+// 注册的版本，否则返回 ErrUnsupportedScriptVersion 错误，返回的字符串只包含 '[error]'。
+func DisasmStringVersion(scriptVersion uint16, script []byte) (string, error) {
+	return disasmScript(scriptVersion, script, false)
+}
+
+// DisasmTapscript 按 BIP0342 的 tapscript 语义反汇编基本叶子版本的叶子脚本。 与 DisasmString 不同，在 tapscript
+// 中被重新定义为 OP_SUCCESSx 的操作码显示为 "OP_SUCCESS<n>"，例如 OP_CAT 显示为 OP_SUCCESS126，以免被误认为
+// 会执行原有的语义。 OP_CHECKSIGADD 只在 tapscript 中有效，它始终以该名称显示。
+//
+// 解析失败时的行为与 DisasmString 相同。
+func DisasmTapscript(script []byte) (string, error) {
+	return disasmScript(0, script, true)
+}
+
+// DisasmTapLeaf 反汇编 tapscript 叶子。 基本叶子版本的脚本按 DisasmTapscript 反汇编。 其他叶子版本的脚本语义未定义，
+// 返回 ErrDiscourageUpgradeableTaprootVersion 错误和空字符串。
+func DisasmTapLeaf(leaf TapLeaf) (string, error) {
+	if leaf.LeafVersion != BaseLeafVersion {
+		str := fmt.Sprintf("unknown tapscript leaf version %#x",
+			uint8(leaf.LeafVersion))
+		return "", scriptError(ErrDiscourageUpgradeableTaprootVersion, str)
+	}
+	return DisasmTapscript(leaf.Script)
+}
+
+// disasmScript 是 DisasmString 及其变体的实现。 tapscript 为 true 时，OP_SUCCESSx 操作码以 tapscript 中的名称显示。
+func disasmScript(scriptVersion uint16, script []byte, tapscript bool) (string, error) {
 	var disbuf strings.Builder
 	tokenizer := MakeScriptTokenizer(scriptVersion, script)
-	if tokenizer.Next() {
-		disasmOpcode(&disbuf, tokenizer.op, tokenizer.Data(), true)
-	}
 	for tokenizer.Next() {
-		disbuf.WriteByte(' ')
-		disasmOpcode(&disbuf, tokenizer.op, tokenizer.Data(), true)
+		if tokenizer.OpcodePosition() != 0 {
+			disbuf.WriteByte(' ')
+		}
+		op := tokenizer.op
+		if tapscript {
+			op = tapscriptOpcode(op)
+		}
+		disasmOpcode(&disbuf, op, tokenizer.Data(), true)
 	}
 	if tokenizer.Err() != nil {
 		if tokenizer.ByteIndex() != 0 {
@@ -140,6 +178,17 @@ func DisasmString(script []byte) (string, error) {
 	return disbuf.String(), tokenizer.Err()
 }
 
+// tapscriptOpcode 返回用于在 tapscript 中显示 op 的操作码。 OP_SUCCESSx 操作码返回名称为 "OP_SUCCESS<n>" 的副本，
+// 其他操作码原样返回。
+func tapscriptOpcode(op *opcode) *opcode {
+	if _, ok := successOpcodes[op.value]; !ok {
+		return op
+	}
+	success := *op
+	success.name = fmt.Sprintf("OP_SUCCESS%d", op.value)
+	return &success
+}
+
 // removeOpcodeRaw 将在删除与'opcode'匹配的任何操作码后返回脚本。 如果操作码没有出现在脚本中，则原始脚本将不加修改地返回。 否则，将分配一个新脚本来包含过滤后的脚本。 此方法假设脚本解析成功。
 //
 // 注意：该函数仅对0版本脚本有效。 由于该函数不接受脚本版本，因此其他脚本版本的结果未定义。
@@ -195,6 +244,11 @@ func isCanonicalPush(opcode byte, data []byte) bool {
 	return true
 }
 
+// strippedBySigHash 返回传统签名哈希计算是否会删除操作码 op 及其推送的数据 data，即 op 是包含 dataToRemove 的规范数据推送。
+func strippedBySigHash(op byte, data, dataToRemove []byte) bool {
+	return isCanonicalPush(op, data) && bytes.Contains(data, dataToRemove)
+}
+
 // removeOpcodeByData 将返回减去执行规范数据推送（包含要删除的传递数据）的任何操作码的脚本。 此函数假设提供了版本 0 脚本，因为任何未来版本的脚本都应避免此功能，因为由于签名脚本不是无见证交易哈希的一部分，因此它是不必要的。
 //
 // 警告：这将返回未修改的传递脚本，除非需要修改，在这种情况下将返回修改后的脚本。 这意味着调用者可能不依赖于能够安全地改变传递或返回的脚本而不可能修改相同的数据。
@@ -221,7 +275,7 @@ func removeOpcodeByData(script []byte, dataToRemove []byte) []byte {
 		// Thus, as an optimization, avoid allocating a new script unless there
 		// is actually a match that needs to be removed.
 		op, data := tokenizer.Opcode(), tokenizer.Data()
-		if isCanonicalPush(op, data) && bytes.Contains(data, dataToRemove) {
+		if strippedBySigHash(op, data, dataToRemove) {
 			if result == nil {
 				fullPushLen := tokenizer.ByteIndex() - prevOffset
 				result = make([]byte, 0, int32(len(script))-fullPushLen)
@@ -239,6 +293,40 @@ func removeOpcodeByData(script []byte, dataToRemove []byte) []byte {
 	return result
 }
 
+// RemoveOpcode 返回删除了所有 op 操作码之后的脚本，语义与传统签名哈希计算中剥离 OP_CODESEPARATOR 的逻辑完全一致。
+// 仅删除实际作为操作码出现的 op，恰好出现在数据推送内部的相同字节不受影响。
+//
+// 与内部实现不同，返回的脚本总是新分配的，调用者可以安全地修改它而不影响传入的脚本。 如果脚本解析失败，则返回解析错误。
+//
+// 注意：该函数仅对0版本脚本有效。 由于该函数不接受脚本版本，因此其他脚本版本的结果未定义。
+func RemoveOpcode(script []byte, op byte) ([]byte, error) {
+	const scriptVersion = 0
+	if err := checkScriptParses(scriptVersion, script); err != nil {
+		return nil, err
+	}
+
+	result := removeOpcodeRaw(script, op)
+	return append([]byte(nil), result...), nil
+}
+
+// RemoveDataPushes 返回删除了所有包含 data 的规范数据推送之后的脚本，语义与引擎在 OP_CHECKSIG 和 OP_CHECKMULTISIG 的传统签名哈希计算中删除签名的逻辑完全一致。
+// 只要规范推送的数据中包含 data（而不必完全相等），整个推送操作码连同其数据都会被删除；非规范推送则保持不变。
+//
+// 外部签名者应使用此函数来构造传统签名哈希的子脚本，而不是自行实现，因为细微的差异会导致签名无效。
+//
+// 与内部实现不同，返回的脚本总是新分配的，调用者可以安全地修改它而不影响传入的脚本。 如果脚本解析失败，则返回解析错误。
+//
+// 注意：该函数仅对0版本脚本有效。 由于该函数不接受脚本版本，因此其他脚本版本的结果未定义。
+func RemoveDataPushes(script, data []byte) ([]byte, error) {
+	const scriptVersion = 0
+	if err := checkScriptParses(scriptVersion, script); err != nil {
+		return nil, err
+	}
+
+	result := removeOpcodeByData(script, data)
+	return append([]byte(nil), result...), nil
+}
+
 // AsSmallInt 以整数形式返回传递的操作码，根据 IsSmallInt()，该操作码必须为 true。
 func AsSmallInt(op byte) int {
 	if op == OP_0 {
@@ -255,43 +343,10 @@ func AsSmallInt(op byte) int {
 func countSigOpsV0(script []byte, precise bool) int {
 	const scriptVersion = 0
 
-	numSigOps := 0
-	tokenizer := MakeScriptTokenizer(scriptVersion, script)
-	prevOp := byte(OP_INVALIDOPCODE)
-	for tokenizer.Next() {
-		switch tokenizer.Opcode() {
-		case OP_CHECKSIG, OP_CHECKSIGVERIFY:
-			numSigOps++
-
-		case OP_CHECKMULTISIG, OP_CHECKMULTISIGVERIFY:
-			// Note that OP_0 is treated as the max number of sigops here in
-			// precise mode despite it being a valid small integer in order to
-			// highly discourage multisigs with zero pubkeys.
-			//
-			// Also, even though this is referred to as "precise" counting, it's
-			// not really precise at all due to the small int opcodes only
-			// covering 1 through 16 pubkeys, which means this will count any
-			// more than that value (e.g. 17, 18 19) as the maximum number of
-			// allowed pubkeys. This is, unfortunately, now part of
-			// the Bitcoin consensus rules, due to historical
-			// reasons. This could be made more correct with a new
-			// script version, however, ideally all multisignaure
-			// operations in new script versions should move to
-			// aggregated schemes such as Schnorr instead.
-			if precise && prevOp >= OP_1 && prevOp <= OP_16 {
-				numSigOps += AsSmallInt(prevOp)
-			} else {
-				numSigOps += MaxPubKeysPerMultiSig
-			}
-
-		default:
-			// Not a sigop.
-		}
-
-		prevOp = tokenizer.Opcode()
-	}
-
-	return numSigOps
+	// Parse failures are intentionally ignored since the counts up to the
+	// failure point are what consensus expects.
+	counts, _ := CountSigOpsDetailed(script, scriptVersion)
+	return counts.total(precise)
 }
 
 // GetSigOpCount 提供脚本中签名操作数量的快速计数。 CHECKSIG 操作计数为 1，CHECK_MULTISIG 计数为 20。
@@ -428,13 +483,19 @@ func checkScriptParses(scriptVersion uint16, script []byte) error {
 //
 // 注意：该函数仅对0版本脚本有效。 由于该函数不接受脚本版本，因此其他脚本版本的结果未定义。
 func IsUnspendable(pkScript []byte) bool {
+	limits := DefaultChainLimits()
+	return limits.IsUnspendable(pkScript)
+}
+
+// IsUnspendable 与包级函数 IsUnspendable 相同，但按 l 而不是包级默认限制判断脚本是否过大。
+func (l *ChainLimits) IsUnspendable(pkScript []byte) bool {
 	// The script is unspendable if starts with OP_RETURN or is guaranteed
 	// to fail at execution due to being larger than the max allowed script
 	// size.
 	switch {
 	case len(pkScript) > 0 && pkScript[0] == OP_RETURN:
 		return true
-	case len(pkScript) > MaxScriptSize:
+	case len(pkScript) > l.MaxScriptSize:
 		return true
 	}
 
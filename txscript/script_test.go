@@ -8,6 +8,7 @@ import (
 	"testing"
 
 	"github.com/btcsuite/btcd/wire"
+	"github.com/stretchr/testify/require"
 )
 
 // TestPushedData 确保 PushedData 函数从各种脚本中提取预期数据。
@@ -470,6 +471,101 @@ func TestRemoveOpcodeByData(t *testing.T) {
 	}
 }
 
+// TestRemoveOpcodePublicAPI 确保公开的 RemoveOpcode 和 RemoveDataPushes 函数与内部实现行为一致，
+// 会拒绝无法解析的脚本，并且返回的脚本永远不会与传入的脚本共享底层存储。
+func TestRemoveOpcodePublicAPI(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		before string
+		op     byte
+		data   []byte
+		err    error
+		after  string
+	}{
+		{
+			name:   "codeseparator removed",
+			before: "NOP CODESEPARATOR TRUE CODESEPARATOR",
+			op:     OP_CODESEPARATOR,
+			after:  "NOP TRUE",
+		},
+		{
+			name:   "codeseparator byte inside push kept",
+			before: "DATA_1 0xab CODESEPARATOR",
+			op:     OP_CODESEPARATOR,
+			after:  "DATA_1 0xab",
+		},
+		{
+			name:   "push containing data removed",
+			before: "NOP DATA_5 0x0001020304 TRUE",
+			data:   []byte{1, 2, 3},
+			after:  "NOP TRUE",
+		},
+		{
+			name:   "push merely sharing prefix kept",
+			before: "DATA_2 0x0102 TRUE",
+			data:   []byte{1, 2, 3},
+			after:  "DATA_2 0x0102 TRUE",
+		},
+		{
+			name:   "noncanonical push containing data kept",
+			before: "PUSHDATA1 0x03 0x010203",
+			data:   []byte{1, 2, 3},
+			after:  "PUSHDATA1 0x03 0x010203",
+		},
+		{
+			name:   "malformed script",
+			before: "PUSHDATA1 0xff 0xfe",
+			data:   []byte{1, 2, 3},
+			err:    scriptError(ErrMalformedPush, ""),
+		},
+		{
+			name:   "malformed script (opcode)",
+			before: "PUSHDATA2",
+			op:     OP_CODESEPARATOR,
+			err:    scriptError(ErrMalformedPush, ""),
+		},
+	}
+
+	for _, test := range tests {
+		before := mustParseShortForm(test.before)
+		var (
+			result []byte
+			err    error
+		)
+		if test.data != nil {
+			result, err = RemoveDataPushes(before, test.data)
+		} else {
+			result, err = RemoveOpcode(before, test.op)
+		}
+		if e := tstCheckScriptError(err, test.err); e != nil {
+			t.Errorf("%s: %v", test.name, e)
+			continue
+		}
+		if test.err != nil {
+			continue
+		}
+
+		after := mustParseShortForm(test.after)
+		if !bytes.Equal(after, result) {
+			t.Errorf("%s: value does not equal expected: exp: %x "+
+				"got: %x", test.name, after, result)
+			continue
+		}
+
+		// 修改结果不得影响原始脚本。
+		if len(result) > 0 {
+			orig := append([]byte(nil), before...)
+			result[0] ^= 0xff
+			if !bytes.Equal(orig, before) {
+				t.Errorf("%s: result aliases the passed script",
+					test.name)
+			}
+		}
+	}
+}
+
 // TestIsPayToScriptHash 确保 IsPayToScriptHash 函数返回 scriptClassTests 中所有脚本的预期结果。
 func TestIsPayToScriptHash(t *testing.T) {
 	t.Parallel()
@@ -634,3 +730,74 @@ func TestIsUnspendable(t *testing.T) {
 		}
 	}
 }
+
+// TestDisasmTapscript 确保 tapscript 反汇编将 OP_SUCCESSx 显示为 tapscript 中的名称，而 DisasmString 保持原有名称。
+func TestDisasmTapscript(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		script    string
+		legacy    string
+		tapscript string
+	}{{
+		name:      "checksigadd multisig",
+		script:    "DATA_1 0x01 CHECKSIG DATA_1 0x02 CHECKSIGADD 2 NUMEQUAL",
+		legacy:    "01 OP_CHECKSIG 02 OP_CHECKSIGADD 2 OP_NUMEQUAL",
+		tapscript: "01 OP_CHECKSIG 02 OP_CHECKSIGADD 2 OP_NUMEQUAL",
+	}, {
+		name:      "success opcodes",
+		script:    "1 1 CAT RESERVED VER 0xbb 0xfe",
+		legacy:    "1 1 OP_CAT OP_RESERVED OP_VER OP_UNKNOWN187 OP_PUBKEY",
+		tapscript: "1 1 OP_SUCCESS126 OP_SUCCESS80 OP_SUCCESS98 OP_SUCCESS187 OP_SUCCESS254",
+	}, {
+		name:      "parse error after success",
+		script:    "CAT 0x4c",
+		legacy:    "OP_CAT [error]",
+		tapscript: "OP_SUCCESS126 [error]",
+	}}
+
+	for _, test := range tests {
+		script := mustParseShortForm(test.script)
+
+		legacy, legacyErr := DisasmString(script)
+		require.Equal(t, test.legacy, legacy, test.name)
+
+		tapscript, err := DisasmTapscript(script)
+		require.Equal(t, test.tapscript, tapscript, test.name)
+		require.Equal(t, legacyErr, err, test.name)
+
+		leaf, err := DisasmTapLeaf(NewBaseTapLeaf(script))
+		require.Equal(t, test.tapscript, leaf, test.name)
+		require.Equal(t, legacyErr, err, test.name)
+	}
+
+	_, err := DisasmTapLeaf(NewTapLeaf(0xc2, []byte{OP_TRUE}))
+	require.True(t, IsErrorCode(err, ErrDiscourageUpgradeableTaprootVersion))
+}
+
+// TestDisasmStringVersion 确保 DisasmStringVersion 接受已注册的脚本版本，并拒绝未注册的版本。
+//
+// 注意：该测试修改全局注册表，因此不能调用 t.Parallel。
+func TestDisasmStringVersion(t *testing.T) {
+	script := mustParseShortForm("DUP HASH160 DATA_1 0x01 EQUALVERIFY")
+	want := "OP_DUP OP_HASH160 01 OP_EQUALVERIFY"
+
+	got, err := DisasmStringVersion(0, script)
+	require.NoError(t, err)
+	require.Equal(t, want, got)
+
+	withCleanScriptVersionRegistry(func() {
+		got, err := DisasmStringVersion(1, script)
+		require.True(t, IsErrorCode(err, ErrUnsupportedScriptVersion))
+		require.Equal(t, "[error]", got)
+
+		require.NoError(t, RegisterScriptVersion(1, ScriptVersionDefinition{}))
+		got, err = DisasmStringVersion(1, script)
+		require.NoError(t, err)
+		require.Equal(t, want, got)
+
+		tokenizer := MakeScriptTokenizer(1, script)
+		require.Equal(t, uint16(1), tokenizer.Version())
+	})
+}
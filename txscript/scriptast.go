@@ -0,0 +1,294 @@
+// 包含脚本的语法树解析，将 OP_IF/OP_NOTIF/OP_ELSE/OP_ENDIF 转换为嵌套的分支节点，供满足器、覆盖率和优化器等分析工具使用，
+// 而不必各自从扁平的操作码序列中重新推导分支结构。
+
+package txscript
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ScriptNode 是脚本语法树中的节点，为 *OpcodeNode 或 *BranchNode。
+type ScriptNode interface {
+	// scriptNode 限制只有本包中的类型可以作为节点。
+	scriptNode()
+}
+
+// OpcodeNode 是条件操作码以外的单个操作码，包括数据推送。
+type OpcodeNode struct {
+	// Opcode 是操作码的值。
+	Opcode byte
+
+	// Data 是操作码推送的数据，非推送操作码为 nil。
+	Data []byte
+
+	// Index 是操作码在脚本中的序号，数据推送及其数据只计为一个操作码。
+	Index int
+
+	// Offset 是操作码在脚本中的字节偏移量。
+	Offset int
+
+	// Raw 是操作码在脚本中的原始编码，引用解析的脚本。
+	Raw []byte
+}
+
+func (*OpcodeNode) scriptNode() {}
+
+// Name 返回操作码的名称，例如 "OP_CHECKSIG"。
+func (n *OpcodeNode) Name() string {
+	return opcodeArray[n.Opcode].name
+}
+
+// BranchNode 是从 OP_IF 或 OP_NOTIF 到匹配的 OP_ENDIF 的条件结构。
+//
+// 与其他实现一样，OP_ELSE 可以出现多次，每次都会翻转执行状态，因此结构被划分为若干部分：Arms[0] 是条件操作码之后的部分，
+// Arms[i] 是第 i 个 OP_ELSE 之后的部分。 条件成立时执行偶数索引的部分，否则执行奇数索引的部分。
+type BranchNode struct {
+	// If 是开始结构的 OP_IF 或 OP_NOTIF。
+	If *OpcodeNode
+
+	// Arms 是被 OP_ELSE 分隔的各个部分，至少包含一个（可能为空的）部分。
+	Arms [][]ScriptNode
+
+	// Elses 是分隔各部分的 OP_ELSE，比 Arms 少一个。
+	Elses []*OpcodeNode
+
+	// EndIf 是结束结构的 OP_ENDIF。
+	EndIf *OpcodeNode
+}
+
+func (*BranchNode) scriptNode() {}
+
+// Then 返回条件成立时首先执行的部分，即 Arms[0]。
+func (n *BranchNode) Then() []ScriptNode {
+	return n.Arms[0]
+}
+
+// Else 返回条件不成立时执行的第一个部分，没有 OP_ELSE 时返回 nil。
+func (n *BranchNode) Else() []ScriptNode {
+	if len(n.Arms) < 2 {
+		return nil
+	}
+	return n.Arms[1]
+}
+
+// ScriptAST 是脚本的语法树。
+type ScriptAST struct {
+	// Version 是解析脚本使用的脚本版本。
+	Version uint16
+
+	// Nodes 是脚本顶层的节点。
+	Nodes []ScriptNode
+}
+
+// ParseScriptAST 将脚本解析为语法树。 脚本无法解析时返回 ErrMalformedPush，条件操作码不平衡时返回 ErrUnbalancedConditional。
+//
+// 返回的节点中的数据和原始编码引用传入的脚本而不是副本。
+func ParseScriptAST(version uint16, script []byte) (*ScriptAST, error) {
+	// stack holds the branches that are still open, innermost last.
+	var stack []*BranchNode
+	var top []ScriptNode
+	appendNode := func(node ScriptNode) {
+		if len(stack) == 0 {
+			top = append(top, node)
+			return
+		}
+		b := stack[len(stack)-1]
+		b.Arms[len(b.Arms)-1] = append(b.Arms[len(b.Arms)-1], node)
+	}
+
+	tokenizer := MakeScriptTokenizer(version, script)
+	for offset := 0; tokenizer.Next(); offset = int(tokenizer.ByteIndex()) {
+		node := &OpcodeNode{
+			Opcode: tokenizer.Opcode(),
+			Data:   tokenizer.Data(),
+			Index:  int(tokenizer.OpcodePosition()),
+			Offset: offset,
+			Raw:    script[offset:tokenizer.ByteIndex()],
+		}
+
+		switch node.Opcode {
+		case OP_IF, OP_NOTIF:
+			b := &BranchNode{If: node, Arms: make([][]ScriptNode, 1)}
+			appendNode(b)
+			stack = append(stack, b)
+
+		case OP_ELSE:
+			if len(stack) == 0 {
+				str := fmt.Sprintf("OP_ELSE at offset %d has no "+
+					"matching OP_IF", offset)
+				return nil, scriptError(ErrUnbalancedConditional, str)
+			}
+			b := stack[len(stack)-1]
+			b.Elses = append(b.Elses, node)
+			b.Arms = append(b.Arms, nil)
+
+		case OP_ENDIF:
+			if len(stack) == 0 {
+				str := fmt.Sprintf("OP_ENDIF at offset %d has no "+
+					"matching OP_IF", offset)
+				return nil, scriptError(ErrUnbalancedConditional, str)
+			}
+			stack[len(stack)-1].EndIf = node
+			stack = stack[:len(stack)-1]
+
+		default:
+			appendNode(node)
+		}
+	}
+	if err := tokenizer.Err(); err != nil {
+		return nil, err
+	}
+	if len(stack) != 0 {
+		str := fmt.Sprintf("%s at offset %d has no matching OP_ENDIF",
+			stack[0].If.Name(), stack[0].If.Offset)
+		return nil, scriptError(ErrUnbalancedConditional, str)
+	}
+
+	return &ScriptAST{Version: version, Nodes: top}, nil
+}
+
+// ScriptVisitor 在 ScriptAST.Walk 遍历语法树时接收节点。 任何方法返回错误都会停止遍历并返回该错误。
+type ScriptVisitor interface {
+	// VisitOpcode 对每个条件操作码以外的操作码调用。
+	VisitOpcode(node *OpcodeNode, depth int) error
+
+	// EnterBranch 在进入条件结构时调用，返回 false 时跳过该结构的所有部分，并且不会为其调用 LeaveBranch。
+	EnterBranch(node *BranchNode, depth int) (bool, error)
+
+	// EnterArm 在遍历条件结构的第 arm 个部分之前调用。
+	EnterArm(node *BranchNode, arm int, depth int) error
+
+	// LeaveBranch 在条件结构的所有部分遍历完成后调用。
+	LeaveBranch(node *BranchNode, depth int) error
+}
+
+// Walk 按脚本顺序深度优先遍历语法树。 顶层节点的深度为 0，条件结构各部分中节点的深度比结构本身大 1。
+func (a *ScriptAST) Walk(v ScriptVisitor) error {
+	return walkScriptNodes(a.Nodes, v, 0)
+}
+
+// walkScriptNodes 遍历节点列表。
+func walkScriptNodes(nodes []ScriptNode, v ScriptVisitor, depth int) error {
+	for _, node := range nodes {
+		switch n := node.(type) {
+		case *OpcodeNode:
+			if err := v.VisitOpcode(n, depth); err != nil {
+				return err
+			}
+
+		case *BranchNode:
+			enter, err := v.EnterBranch(n, depth)
+			if err != nil {
+				return err
+			}
+			if !enter {
+				continue
+			}
+			for i, arm := range n.Arms {
+				if err := v.EnterArm(n, i, depth); err != nil {
+					return err
+				}
+				if err := walkScriptNodes(arm, v, depth+1); err != nil {
+					return err
+				}
+			}
+			if err := v.LeaveBranch(n, depth); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Inspect 按脚本顺序深度优先遍历语法树，对每个节点调用 fn。 fn 对分支节点返回 false 时跳过其所有部分。
+func (a *ScriptAST) Inspect(fn func(node ScriptNode, depth int) bool) {
+	inspectScriptNodes(a.Nodes, fn, 0)
+}
+
+// inspectScriptNodes 对节点列表调用 fn。
+func inspectScriptNodes(nodes []ScriptNode, fn func(ScriptNode, int) bool,
+	depth int) {
+
+	for _, node := range nodes {
+		if !fn(node, depth) {
+			continue
+		}
+		if b, ok := node.(*BranchNode); ok {
+			for _, arm := range b.Arms {
+				inspectScriptNodes(arm, fn, depth+1)
+			}
+		}
+	}
+}
+
+// MaxDepth 返回条件结构的最大嵌套深度，没有条件结构时返回 0。
+func (a *ScriptAST) MaxDepth() int {
+	var maxDepth int
+	a.Inspect(func(node ScriptNode, depth int) bool {
+		if _, ok := node.(*BranchNode); ok && depth+1 > maxDepth {
+			maxDepth = depth + 1
+		}
+		return true
+	})
+	return maxDepth
+}
+
+// Script 返回语法树对应的脚本，与解析的脚本逐字节相同。
+func (a *ScriptAST) Script() []byte {
+	return appendScriptNodes(nil, a.Nodes)
+}
+
+// appendScriptNodes 将节点的原始编码按脚本顺序附加到 script。
+func appendScriptNodes(script []byte, nodes []ScriptNode) []byte {
+	for _, node := range nodes {
+		switch n := node.(type) {
+		case *OpcodeNode:
+			script = append(script, n.Raw...)
+
+		case *BranchNode:
+			script = append(script, n.If.Raw...)
+			for i, arm := range n.Arms {
+				if i > 0 {
+					script = append(script, n.Elses[i-1].Raw...)
+				}
+				script = appendScriptNodes(script, arm)
+			}
+			script = append(script, n.EndIf.Raw...)
+		}
+	}
+	return script
+}
+
+// String 返回语法树的缩进文本形式，每行一个操作码，条件结构中的操作码按嵌套深度缩进。
+func (a *ScriptAST) String() string {
+	var buf strings.Builder
+	writeScriptNodes(&buf, a.Nodes, 0)
+	return buf.String()
+}
+
+// writeScriptNodes 将节点的文本形式写入 buf。
+func writeScriptNodes(buf *strings.Builder, nodes []ScriptNode, depth int) {
+	writeLine := func(n *OpcodeNode, depth int) {
+		buf.WriteString(strings.Repeat("  ", depth))
+		disasmOpcode(buf, &opcodeArray[n.Opcode], n.Data, true)
+		buf.WriteByte('\n')
+	}
+
+	for _, node := range nodes {
+		switch n := node.(type) {
+		case *OpcodeNode:
+			writeLine(n, depth)
+
+		case *BranchNode:
+			writeLine(n.If, depth)
+			for i, arm := range n.Arms {
+				if i > 0 {
+					writeLine(n.Elses[i-1], depth)
+				}
+				writeScriptNodes(buf, arm, depth+1)
+			}
+			writeLine(n.EndIf, depth)
+		}
+	}
+}
@@ -0,0 +1,152 @@
+package txscript
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// recordingVisitor 记录 Walk 的调用顺序。
+type recordingVisitor struct {
+	events   []string
+	skipIf   int
+	failAtOp byte
+}
+
+func (v *recordingVisitor) VisitOpcode(node *OpcodeNode, depth int) error {
+	if v.failAtOp != 0 && node.Opcode == v.failAtOp {
+		return errors.New("stop")
+	}
+	v.events = append(v.events, node.Name())
+	return nil
+}
+
+func (v *recordingVisitor) EnterBranch(node *BranchNode,
+	depth int) (bool, error) {
+
+	v.events = append(v.events, "enter")
+	return node.If.Index != v.skipIf, nil
+}
+
+func (v *recordingVisitor) EnterArm(node *BranchNode, arm int,
+	depth int) error {
+
+	v.events = append(v.events, []string{"then", "else"}[arm%2])
+	return nil
+}
+
+func (v *recordingVisitor) LeaveBranch(node *BranchNode, depth int) error {
+	v.events = append(v.events, "leave")
+	return nil
+}
+
+// TestParseScriptAST 确保条件结构被解析为嵌套的分支节点，并且语法树可以还原为原始脚本。
+func TestParseScriptAST(t *testing.T) {
+	t.Parallel()
+
+	script := mustParseShortForm("DUP IF 1 NOTIF 2 ELSE 3 ENDIF " +
+		"ELSE DATA_2 0x0102 ENDIF IF ENDIF 4")
+	ast, err := ParseScriptAST(0, script)
+	require.NoError(t, err)
+	require.Equal(t, script, ast.Script())
+	require.Equal(t, 2, ast.MaxDepth())
+	require.Len(t, ast.Nodes, 4)
+
+	outer, ok := ast.Nodes[1].(*BranchNode)
+	require.True(t, ok)
+	require.Equal(t, byte(OP_IF), outer.If.Opcode)
+	require.Equal(t, 1, outer.If.Index)
+	require.Len(t, outer.Arms, 2)
+	require.Len(t, outer.Then(), 2)
+	require.Len(t, outer.Else(), 1)
+	push := outer.Else()[0].(*OpcodeNode)
+	require.Equal(t, []byte{0x01, 0x02}, push.Data)
+	require.Equal(t, []byte{OP_DATA_2, 0x01, 0x02}, push.Raw)
+	require.Equal(t, 9, push.Index)
+
+	inner := outer.Then()[1].(*BranchNode)
+	require.Equal(t, byte(OP_NOTIF), inner.If.Opcode)
+	require.Len(t, inner.Elses, 1)
+	require.Equal(t, 7, inner.EndIf.Index)
+
+	empty := ast.Nodes[2].(*BranchNode)
+	require.Empty(t, empty.Then())
+	require.Nil(t, empty.Else())
+
+	require.Equal(t, "OP_DUP\nOP_IF\n  1\n  OP_NOTIF\n    2\n  OP_ELSE\n"+
+		"    3\n  OP_ENDIF\nOP_ELSE\n  0102\nOP_ENDIF\nOP_IF\nOP_ENDIF\n4\n",
+		ast.String())
+
+	// 多个 OP_ELSE 将结构划分为多个部分。
+	ast, err = ParseScriptAST(0, mustParseShortForm("IF 1 ELSE 2 ELSE 3 ENDIF"))
+	require.NoError(t, err)
+	b := ast.Nodes[0].(*BranchNode)
+	require.Len(t, b.Arms, 3)
+	require.Len(t, b.Elses, 2)
+
+	// 空脚本没有节点。
+	ast, err = ParseScriptAST(0, nil)
+	require.NoError(t, err)
+	require.Empty(t, ast.Nodes)
+	require.Empty(t, ast.Script())
+}
+
+// TestParseScriptASTErrors 确保不平衡的条件结构和无法解析的脚本被拒绝。
+func TestParseScriptASTErrors(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		script string
+		code   ErrorCode
+	}{
+		{"ELSE", ErrUnbalancedConditional},
+		{"1 ENDIF", ErrUnbalancedConditional},
+		{"IF 1 ELSE 2", ErrUnbalancedConditional},
+		{"IF IF ENDIF", ErrUnbalancedConditional},
+		{"IF ENDIF ENDIF", ErrUnbalancedConditional},
+		{"IF DATA_2 0x01", ErrMalformedPush},
+	}
+	for _, test := range tests {
+		_, err := ParseScriptAST(0, mustParseShortForm(test.script))
+		require.True(t, IsErrorCode(err, test.code), "%s: %v",
+			test.script, err)
+	}
+}
+
+// TestScriptASTWalk 确保访问者按脚本顺序接收节点，并且可以跳过分支或停止遍历。
+func TestScriptASTWalk(t *testing.T) {
+	t.Parallel()
+
+	ast, err := ParseScriptAST(0, mustParseShortForm(
+		"1 IF 2 IF 3 ENDIF ELSE 4 ENDIF 5",
+	))
+	require.NoError(t, err)
+
+	v := &recordingVisitor{skipIf: -1}
+	require.NoError(t, ast.Walk(v))
+	require.Equal(t, []string{
+		"OP_1", "enter", "then", "OP_2", "enter", "then", "OP_3",
+		"leave", "else", "OP_4", "leave", "OP_5",
+	}, v.events)
+
+	v = &recordingVisitor{skipIf: 3}
+	require.NoError(t, ast.Walk(v))
+	require.Equal(t, []string{
+		"OP_1", "enter", "then", "OP_2", "enter", "else", "OP_4",
+		"leave", "OP_5",
+	}, v.events)
+
+	v = &recordingVisitor{skipIf: -1, failAtOp: OP_4}
+	require.EqualError(t, ast.Walk(v), "stop")
+
+	// Inspect 报告每个节点的深度。
+	depths := map[int]int{}
+	ast.Inspect(func(node ScriptNode, depth int) bool {
+		if n, ok := node.(*OpcodeNode); ok {
+			depths[n.Index] = depth
+		}
+		return true
+	})
+	require.Equal(t, map[int]int{0: 0, 2: 1, 4: 2, 7: 1, 9: 0}, depths)
+}
@@ -4,6 +4,7 @@ package txscript
 
 import (
 	"encoding/binary"
+	"errors"
 	"fmt"
 )
 
@@ -18,12 +19,16 @@ const (
 type scriptBuilderConfig struct {
 	// allocSize 指定脚本构建器的支持数组的初始大小。
 	allocSize int
+
+	// limits 是构建器检查脚本大小和元素大小使用的限制。
+	limits ChainLimits
 }
 
 // defaultScriptBuilderConfig 返回一个带有默认值设置的新 scriptBuilderConfig。
 func defaultScriptBuilderConfig() *scriptBuilderConfig {
 	return &scriptBuilderConfig{
 		allocSize: defaultScriptAlloc,
+		limits:    DefaultChainLimits(),
 	}
 }
 
@@ -37,6 +42,13 @@ func WithScriptAllocSize(size int) ScriptBuilderOpt {
 	}
 }
 
+// WithBuilderChainLimits 指定脚本生成器检查脚本大小和元素大小使用的限制，未指定时使用包级默认限制。
+func WithBuilderChainLimits(limits ChainLimits) ScriptBuilderOpt {
+	return func(cfg *scriptBuilderConfig) {
+		cfg.limits = limits
+	}
+}
+
 // ErrScriptNotCanonical 标识非规范脚本。 调用者可以使用类型断言来检测此错误类型。
 type ErrScriptNotCanonical string
 
@@ -64,6 +76,24 @@ func (e ErrScriptNotCanonical) Error() string {
 type ScriptBuilder struct {
 	script []byte
 	err    error
+
+	// numOps 是已经添加到脚本的操作码（包括数据推送）的数量，用作错误信息中失败调用的操作码索引。
+	numOps int
+
+	// limits 是检查脚本大小和元素大小使用的限制。
+	limits ChainLimits
+}
+
+// fail 记录 call 失败的错误，错误信息包含失败的调用、操作码索引和尝试推送的数据长度（dataLen 小于 0 时省略）。
+func (b *ScriptBuilder) fail(call string, dataLen int, format string,
+	args ...interface{}) {
+
+	context := fmt.Sprintf("%s failed at op index %d", call, b.numOps)
+	if dataLen >= 0 {
+		context += fmt.Sprintf(" (data length %d)", dataLen)
+	}
+	b.err = ErrScriptNotCanonical(context + ": " +
+		fmt.Sprintf(format, args...))
 }
 
 // AddOp 将传递的操作码推送到脚本末尾。 如果推送操作码会导致脚本超出允许的最大脚本引擎大小，则不会修改脚本。
@@ -74,14 +104,16 @@ func (b *ScriptBuilder) AddOp(opcode byte) *ScriptBuilder {
 
 	// Pushes that would cause the script to exceed the largest allowed
 	// script size would result in a non-canonical script.
-	if len(b.script)+1 > MaxScriptSize {
-		str := fmt.Sprintf("adding an opcode would exceed the maximum "+
-			"allowed canonical script length of %d", MaxScriptSize)
-		b.err = ErrScriptNotCanonical(str)
+	maxScriptSize := b.limits.MaxScriptSize
+	if len(b.script)+1 > maxScriptSize {
+		b.fail("AddOp("+opcodeArray[opcode].name+")", -1,
+			"adding an opcode would exceed the maximum allowed "+
+				"canonical script length of %d", maxScriptSize)
 		return b
 	}
 
 	b.script = append(b.script, opcode)
+	b.numOps++
 	return b
 }
 
@@ -93,14 +125,62 @@ func (b *ScriptBuilder) AddOps(opcodes []byte) *ScriptBuilder {
 
 	// Pushes that would cause the script to exceed the largest allowed
 	// script size would result in a non-canonical script.
-	if len(b.script)+len(opcodes) > MaxScriptSize {
-		str := fmt.Sprintf("adding opcodes would exceed the maximum "+
-			"allowed canonical script length of %d", MaxScriptSize)
-		b.err = ErrScriptNotCanonical(str)
+	maxScriptSize := b.limits.MaxScriptSize
+	if len(b.script)+len(opcodes) > maxScriptSize {
+		b.fail("AddOps", -1, "adding %d opcodes would exceed the "+
+			"maximum allowed canonical script length of %d",
+			len(opcodes), maxScriptSize)
 		return b
 	}
 
 	b.script = append(b.script, opcodes...)
+	b.numOps += len(opcodes)
+	return b
+}
+
+// ScriptFragment 是可以由 AddFragment 追加到脚本的脚本片段，例如 fragments 包中经过验证的片段。
+type ScriptFragment interface {
+	// Script 返回片段的脚本，片段的参数无效时返回错误。
+	Script() ([]byte, error)
+}
+
+// AddFragment 将片段的脚本追加到脚本末尾。 片段的参数无效、脚本无法解析或追加后会超出允许的最大脚本引擎大小时，
+// 不会修改脚本，错误由 Script 返回。
+func (b *ScriptBuilder) AddFragment(f ScriptFragment) *ScriptBuilder {
+	if b.err != nil {
+		return b
+	}
+
+	script, err := f.Script()
+	if err != nil {
+		// Wrap the error so callers can still match the fragment's error.
+		b.err = fmt.Errorf("AddFragment failed at op index %d: %w",
+			b.numOps, err)
+		return b
+	}
+
+	// Count the opcodes so later failures report the right op index.
+	const scriptVersion = 0
+	var numOps int
+	tokenizer := MakeScriptTokenizer(scriptVersion, script)
+	for tokenizer.Next() {
+		numOps++
+	}
+	if err := tokenizer.Err(); err != nil {
+		b.fail("AddFragment", -1, "%v", err)
+		return b
+	}
+
+	maxScriptSize := b.limits.MaxScriptSize
+	if len(b.script)+len(script) > maxScriptSize {
+		b.fail("AddFragment", -1, "adding a fragment of %d bytes would "+
+			"exceed the maximum allowed canonical script length of %d",
+			len(script), maxScriptSize)
+		return b
+	}
+
+	b.script = append(b.script, script...)
+	b.numOps += numOps
 	return b
 }
 
@@ -134,6 +214,7 @@ func canonicalDataSize(data []byte) int {
 // 它根据数据的长度自动选择规范操作码。
 // 零长度缓冲区将导致将空数据推入堆栈（OP_0）。 此功能不强制执行数据限制。
 func (b *ScriptBuilder) addData(data []byte) *ScriptBuilder {
+	b.numOps++
 	dataLen := len(data)
 
 	// When the data consists of a single number that can be represented
@@ -193,29 +274,33 @@ func (b *ScriptBuilder) AddFullData(data []byte) *ScriptBuilder {
 // 零长度缓冲区将导致将空数据推送到堆栈 (OP_0)，并且任何大于 MaxScriptElementSize 的数据推送都不会修改脚本，因为脚本引擎不允许这样做。
 // 此外，如果推送数据会导致脚本超出脚本引擎允许的最大大小，则不会修改脚本。
 func (b *ScriptBuilder) AddData(data []byte) *ScriptBuilder {
+	return b.addCheckedData("AddData", data)
+}
+
+// addCheckedData 在检查脚本大小和元素大小限制后推送数据，call 是用于错误信息的调用名称。
+func (b *ScriptBuilder) addCheckedData(call string, data []byte) *ScriptBuilder {
 	if b.err != nil {
 		return b
 	}
 
 	// Pushes that would cause the script to exceed the largest allowed
 	// script size would result in a non-canonical script.
+	limits := &b.limits
+	dataLen := len(data)
 	dataSize := canonicalDataSize(data)
-	if len(b.script)+dataSize > MaxScriptSize {
-		str := fmt.Sprintf("adding %d bytes of data would exceed the "+
-			"maximum allowed canonical script length of %d",
-			dataSize, MaxScriptSize)
-		b.err = ErrScriptNotCanonical(str)
+	if len(b.script)+dataSize > limits.MaxScriptSize {
+		b.fail(call, dataLen, "adding %d bytes of data would exceed "+
+			"the maximum allowed canonical script length of %d",
+			dataSize, limits.MaxScriptSize)
 		return b
 	}
 
 	// Pushes larger than the max script element size would result in a
 	// script that is not canonical.
-	dataLen := len(data)
-	if dataLen > MaxScriptElementSize {
-		str := fmt.Sprintf("adding a data element of %d bytes would "+
-			"exceed the maximum allowed script element size of %d",
-			dataLen, MaxScriptElementSize)
-		b.err = ErrScriptNotCanonical(str)
+	if dataLen > limits.MaxScriptElementSize {
+		b.fail(call, dataLen, "adding a data element of %d bytes "+
+			"would exceed the maximum allowed script element size "+
+			"of %d", dataLen, limits.MaxScriptElementSize)
 		return b
 	}
 
@@ -225,37 +310,63 @@ func (b *ScriptBuilder) AddData(data []byte) *ScriptBuilder {
 // AddInt64 将传递的整数推送到脚本末尾。
 // 如果推送数据会导致脚本超出脚本引擎允许的最大大小，则不会修改脚本。
 func (b *ScriptBuilder) AddInt64(val int64) *ScriptBuilder {
+	return b.addInt64("AddInt64", val)
+}
+
+// addInt64 实现 AddInt64，call 是用于错误信息的调用名称。
+func (b *ScriptBuilder) addInt64(call string, val int64) *ScriptBuilder {
 	if b.err != nil {
 		return b
 	}
 
 	// Pushes that would cause the script to exceed the largest allowed
 	// script size would result in a non-canonical script.
-	if len(b.script)+1 > MaxScriptSize {
-		str := fmt.Sprintf("adding an integer would exceed the "+
-			"maximum allow canonical script length of %d",
-			MaxScriptSize)
-		b.err = ErrScriptNotCanonical(str)
+	maxScriptSize := b.limits.MaxScriptSize
+	if len(b.script)+1 > maxScriptSize {
+		b.fail(call, -1, "adding an integer would exceed the maximum "+
+			"allow canonical script length of %d", maxScriptSize)
 		return b
 	}
 
 	// Fast path for small integers and OP_1NEGATE.
 	if val == 0 {
 		b.script = append(b.script, OP_0)
+		b.numOps++
 		return b
 	}
 	if val == -1 || (val >= 1 && val <= 16) {
 		b.script = append(b.script, byte((OP_1-1)+val))
+		b.numOps++
 		return b
 	}
 
-	return b.AddData(scriptNum(val).Bytes())
+	return b.addCheckedData(call, ScriptNum(val).Bytes())
+}
+
+// AddLockTime 将锁定时间或序列号作为 CHECKLOCKTIMEVERIFY 或 CHECKSEQUENCEVERIFY 的参数推送到脚本末尾。
+// 与 AddInt64 不同，它拒绝这两个操作码无法接受或永远无法满足的值，规则见 EncodeLockTimeNum。
+func (b *ScriptBuilder) AddLockTime(lockTime int64) *ScriptBuilder {
+	if b.err != nil {
+		return b
+	}
+	if _, err := EncodeLockTimeNum(lockTime); err != nil {
+		// Keep the error code so callers can still match it.
+		context := fmt.Sprintf("AddLockTime failed at op index %d: ",
+			b.numOps)
+		if serr, ok := err.(Error); ok {
+			err = scriptError(serr.ErrorCode, context+serr.Description)
+		}
+		b.err = err
+		return b
+	}
+	return b.addInt64("AddLockTime", lockTime)
 }
 
 // Reset 重置脚本，使其没有内容。
 func (b *ScriptBuilder) Reset() *ScriptBuilder {
 	b.script = b.script[0:0]
 	b.err = nil
+	b.numOps = 0
 	return b
 }
 
@@ -265,6 +376,123 @@ func (b *ScriptBuilder) Script() ([]byte, error) {
 	return b.script, b.err
 }
 
+// MustScript 返回当前构建的脚本，构建时发生任何错误时 panic。 它只应用于由常量构建、不可能出错的脚本，例如测试和包级变量的初始化。
+func (b *ScriptBuilder) MustScript() []byte {
+	if b.err != nil {
+		panic(fmt.Sprintf("txscript: %v", b.err))
+	}
+	return b.script
+}
+
+// ErrScriptRoleViolation 在构建的脚本不满足 Validate 指定的目标用途时返回。
+var ErrScriptRoleViolation = errors.New("script violates target role")
+
+// ScriptRole 表示构建的脚本的目标用途，Validate 按该用途的共识和标准性规则检查脚本。
+type ScriptRole uint8
+
+const (
+	// ScriptRolePkScript 表示标准的输出公钥脚本，脚本必须属于可识别的标准类别。
+	ScriptRolePkScript ScriptRole = iota
+
+	// ScriptRoleSigScript 表示标准的签名脚本，脚本必须只包含数据推送并且不超过 MaxStandardSigScriptSize 字节。
+	ScriptRoleSigScript
+
+	// ScriptRoleRedeemScript 表示标准的 P2SH 兑换脚本，脚本不能超过最大元素大小，并且签名操作数不超过 MaxStandardP2SHSigOps。
+	ScriptRoleRedeemScript
+
+	// ScriptRoleWitnessScript 表示标准的 P2WSH 见证脚本，脚本不能超过 MaxStandardWitnessScriptSize 字节。
+	ScriptRoleWitnessScript
+
+	// ScriptRoleTapscript 表示基础版本的 tapscript 叶子，脚本不能使用在 tapscript 中被禁用的 OP_CHECKMULTISIG 和 OP_CHECKMULTISIGVERIFY。
+	ScriptRoleTapscript
+)
+
+// String 返回目标用途的可读名称。
+func (r ScriptRole) String() string {
+	switch r {
+	case ScriptRolePkScript:
+		return "public key script"
+	case ScriptRoleSigScript:
+		return "signature script"
+	case ScriptRoleRedeemScript:
+		return "P2SH redeem script"
+	case ScriptRoleWitnessScript:
+		return "P2WSH witness script"
+	case ScriptRoleTapscript:
+		return "tapscript leaf"
+	default:
+		return fmt.Sprintf("ScriptRole(%d)", uint8(r))
+	}
+}
+
+// Validate 检查当前构建的脚本是否可以用于 role 指定的用途，例如仍然是不超过 3600 字节的标准 P2WSH 见证脚本。
+// 构建时发生过错误时返回该错误，否则脚本无法解析或不满足目标用途的规则时返回包装 ErrScriptRoleViolation 的错误。
+func (b *ScriptBuilder) Validate(role ScriptRole) error {
+	if b.err != nil {
+		return b.err
+	}
+
+	script := b.script
+	const scriptVersion = 0
+	if err := checkScriptParses(scriptVersion, script); err != nil {
+		return fmt.Errorf("%w: %v: %v", ErrScriptRoleViolation, role, err)
+	}
+	violation := func(format string, args ...interface{}) error {
+		return fmt.Errorf("%w: %v: %s", ErrScriptRoleViolation, role,
+			fmt.Sprintf(format, args...))
+	}
+
+	switch role {
+	case ScriptRolePkScript:
+		if b.limits.GetScriptClass(script) == NonStandardTy {
+			return violation("script is not a standard output script")
+		}
+
+	case ScriptRoleSigScript:
+		if !IsPushOnlyScript(script) {
+			return violation("script is not push only")
+		}
+		if len(script) > MaxStandardSigScriptSize {
+			return violation("script size %d exceeds the max of %d",
+				len(script), MaxStandardSigScriptSize)
+		}
+
+	case ScriptRoleRedeemScript:
+		maxSize := b.limits.MaxScriptElementSize
+		if len(script) > maxSize {
+			return violation("script size %d exceeds the max of %d",
+				len(script), maxSize)
+		}
+		sigOps := countSigOpsV0(script, true)
+		if sigOps > MaxStandardP2SHSigOps {
+			return violation("script has %d signature operations, the "+
+				"max is %d", sigOps, MaxStandardP2SHSigOps)
+		}
+
+	case ScriptRoleWitnessScript:
+		if len(script) > MaxStandardWitnessScriptSize {
+			return violation("script size %d exceeds the max of %d",
+				len(script), MaxStandardWitnessScriptSize)
+		}
+
+	case ScriptRoleTapscript:
+		tokenizer := MakeScriptTokenizer(scriptVersion, script)
+		for tokenizer.Next() {
+			switch op := tokenizer.Opcode(); op {
+			case OP_CHECKMULTISIG, OP_CHECKMULTISIGVERIFY:
+				return violation("%s at op index %d is disabled in "+
+					"tapscript", opcodeArray[op].name,
+					tokenizer.OpcodePosition())
+			}
+		}
+
+	default:
+		return violation("unknown role")
+	}
+
+	return nil
+}
+
 // NewScriptBuilder 返回脚本生成器的新实例。
 // 有关详细信息，请参阅 ScriptBuilder。
 func NewScriptBuilder(opts ...ScriptBuilderOpt) *ScriptBuilder {
@@ -275,5 +503,6 @@ func NewScriptBuilder(opts ...ScriptBuilderOpt) *ScriptBuilder {
 
 	return &ScriptBuilder{
 		script: make([]byte, 0, cfg.allocSize),
+		limits: cfg.limits,
 	}
 }
@@ -4,6 +4,8 @@ package txscript
 
 import (
 	"bytes"
+	"errors"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/require"
@@ -414,3 +416,203 @@ func TestErroredScript(t *testing.T) {
 		t.Fatal("ErrScriptNotCanonical.Error does not have any text")
 	}
 }
+
+// TestScriptBuilderErrorContext 确保构建器的错误说明失败的调用、操作码索引和尝试推送的数据长度，并且错误类型保持不变。
+func TestScriptBuilderErrorContext(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name  string
+		build func(b *ScriptBuilder) *ScriptBuilder
+		want  []string
+	}{{
+		name: "oversized data element",
+		build: func(b *ScriptBuilder) *ScriptBuilder {
+			return b.AddOp(OP_DUP).AddOp(OP_HASH160).
+				AddData(make([]byte, MaxScriptElementSize+1))
+		},
+		want: []string{"AddData failed at op index 2",
+			"data length 521"},
+	}, {
+		name: "opcode past max script size",
+		build: func(b *ScriptBuilder) *ScriptBuilder {
+			return b.AddFullData(make([]byte, MaxScriptSize-3)).
+				AddOp(OP_CHECKSIG)
+		},
+		want: []string{"AddOp(OP_CHECKSIG) failed at op index 1"},
+	}, {
+		name: "integer past max script size",
+		build: func(b *ScriptBuilder) *ScriptBuilder {
+			return b.AddFullData(make([]byte, MaxScriptSize-5)).
+				AddInt64(1000)
+		},
+		want: []string{"AddInt64 failed at op index 1",
+			"data length 2"},
+	}}
+
+	for _, test := range tests {
+		_, err := test.build(NewScriptBuilder()).Script()
+		if _, ok := err.(ErrScriptNotCanonical); !ok {
+			t.Fatalf("%s: unexpected error type %T", test.name, err)
+		}
+		for _, want := range test.want {
+			if !strings.Contains(err.Error(), want) {
+				t.Fatalf("%s: error %q does not contain %q",
+					test.name, err, want)
+			}
+		}
+	}
+
+	// 锁定时间错误保留错误代码。 精简构建中脚本错误没有描述，因此只检查错误代码。
+	_, err := NewScriptBuilder().AddOp(OP_IF).AddLockTime(-1).Script()
+	if !IsErrorCode(err, ErrNegativeLockTime) {
+		t.Fatalf("unexpected lock time error: %v", err)
+	}
+	if !LiteBuild &&
+		!strings.Contains(err.Error(), "AddLockTime failed at op index 1") {
+
+		t.Fatalf("unexpected lock time error: %v", err)
+	}
+
+	// Reset 也会重置操作码索引。
+	builder := NewScriptBuilder().AddOp(OP_1).AddOp(OP_2)
+	_, err = builder.Reset().AddData(make([]byte, MaxScriptElementSize+1)).
+		Script()
+	if err == nil || !strings.Contains(err.Error(), "op index 0") {
+		t.Fatalf("unexpected error after reset: %v", err)
+	}
+}
+
+// TestScriptBuilderMustScript 确保 MustScript 返回构建的脚本并在构建出错时 panic。
+func TestScriptBuilderMustScript(t *testing.T) {
+	t.Parallel()
+
+	script := NewScriptBuilder().AddOp(OP_TRUE).MustScript()
+	if !bytes.Equal(script, []byte{OP_TRUE}) {
+		t.Fatalf("unexpected script %x", script)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Fatal("MustScript did not panic on an errored builder")
+		}
+	}()
+	NewScriptBuilder().AddData(make([]byte, MaxScriptElementSize+1)).
+		MustScript()
+}
+
+// TestScriptBuilderValidate 确保 Validate 按目标用途检查构建的脚本。
+func TestScriptBuilderValidate(t *testing.T) {
+	t.Parallel()
+
+	pubKey := hexToBytes("02f9308a019258c31049344f85f89d5229b531c845" +
+		"836f99b08601f113bce036f9")
+	multisig := func(n int) *ScriptBuilder {
+		b := NewScriptBuilder().AddInt64(1)
+		for i := 0; i < n; i++ {
+			b.AddData(pubKey)
+		}
+		return b.AddInt64(int64(n)).AddOp(OP_CHECKMULTISIG)
+	}
+	// A witness script that is too big for P2WSH standardness but
+	// still within the consensus script size limit.
+	bigWitnessScript := NewScriptBuilder()
+	for i := 0; i < 8; i++ {
+		bigWitnessScript.AddData(make([]byte, 500)).AddOp(OP_DROP)
+	}
+	bigWitnessScript.AddOp(OP_TRUE)
+
+	tests := []struct {
+		name    string
+		builder *ScriptBuilder
+		role    ScriptRole
+		valid   bool
+	}{
+		{"p2pkh", NewScriptBuilder().AddOp(OP_DUP).AddOp(OP_HASH160).
+			AddData(make([]byte, 20)).AddOp(OP_EQUALVERIFY).
+			AddOp(OP_CHECKSIG), ScriptRolePkScript, true},
+		{"nonstandard output", NewScriptBuilder().AddOp(OP_TRUE),
+			ScriptRolePkScript, false},
+		{"push only sig script", NewScriptBuilder().AddData(pubKey),
+			ScriptRoleSigScript, true},
+		{"sig script with opcode", NewScriptBuilder().AddOp(OP_NOP),
+			ScriptRoleSigScript, false},
+		{"3-key redeem script", multisig(3), ScriptRoleRedeemScript,
+			true},
+		{"16-key redeem script", multisig(16), ScriptRoleRedeemScript,
+			false},
+		{"witness script", multisig(3), ScriptRoleWitnessScript, true},
+		{"oversized witness script", bigWitnessScript,
+			ScriptRoleWitnessScript, false},
+		{"tapscript", NewScriptBuilder().AddData(pubKey[1:]).
+			AddOp(OP_CHECKSIG), ScriptRoleTapscript, true},
+		{"tapscript multisig", multisig(2), ScriptRoleTapscript, false},
+	}
+	for _, test := range tests {
+		err := test.builder.Validate(test.role)
+		if test.valid && err != nil {
+			t.Fatalf("%s: unexpected error: %v", test.name, err)
+		}
+		if !test.valid && !errors.Is(err, ErrScriptRoleViolation) {
+			t.Fatalf("%s: unexpected error: %v", test.name, err)
+		}
+	}
+
+	// 构建错误优先返回。
+	builder := NewScriptBuilder().AddData(make([]byte, MaxScriptElementSize+1))
+	err := builder.Validate(ScriptRoleWitnessScript)
+	if _, ok := err.(ErrScriptNotCanonical); !ok {
+		t.Fatal("Validate did not return the builder error")
+	}
+}
+
+// rawFragment 是返回固定脚本或错误的 ScriptFragment。
+type rawFragment struct {
+	script []byte
+	err    error
+}
+
+// Script 实现 ScriptFragment 接口。
+func (f rawFragment) Script() ([]byte, error) {
+	return f.script, f.err
+}
+
+// TestScriptBuilderAddFragment 确保 AddFragment 追加片段的脚本并计入其操作码，保留片段的错误，并拒绝无法解析或过大的片段。
+func TestScriptBuilderAddFragment(t *testing.T) {
+	t.Parallel()
+
+	fragment := rawFragment{script: []byte{OP_DATA_1, 0x05, OP_DROP}}
+	script, err := NewScriptBuilder().AddOp(OP_NOP).AddFragment(fragment).
+		AddOp(OP_TRUE).Script()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []byte{OP_NOP, OP_DATA_1, 0x05, OP_DROP, OP_TRUE}
+	if !bytes.Equal(script, want) {
+		t.Fatalf("unexpected script %x, want %x", script, want)
+	}
+
+	// The op index of a later failure counts the fragment's opcodes.
+	_, err = NewScriptBuilder().AddFragment(fragment).
+		AddData(make([]byte, MaxScriptElementSize+1)).Script()
+	if err == nil || !strings.Contains(err.Error(), "op index 2") {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	errFragment := errors.New("bad fragment")
+	_, err = NewScriptBuilder().AddFragment(rawFragment{err: errFragment}).
+		Script()
+	if !errors.Is(err, errFragment) {
+		t.Fatalf("fragment error not preserved: %v", err)
+	}
+
+	for _, f := range []rawFragment{
+		{script: []byte{OP_PUSHDATA1}},
+		{script: make([]byte, DefaultChainLimits().MaxScriptSize+1)},
+	} {
+		_, err = NewScriptBuilder().AddFragment(f).Script()
+		if _, ok := err.(ErrScriptNotCanonical); !ok {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+}
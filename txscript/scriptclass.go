@@ -0,0 +1,139 @@
+// 包含链特定标准脚本模板的注册逻辑，使 bpfschain 能够在不修改 standard.go 的情况下识别自定义输出类型。
+
+package txscript
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/chaincfg"
+)
+
+// firstCustomScriptClass 是分配给第一个已注册自定义模板的脚本类别。 所有内置类别的值都小于它。
+const firstCustomScriptClass = WitnessUnknownTy + 1
+
+var (
+	// ErrScriptClassRegistryFrozen 在脚本引擎已经被使用之后尝试注册新的脚本模板时返回。
+	ErrScriptClassRegistryFrozen = fmt.Errorf("script class registry is " +
+		"frozen")
+
+	// ErrInvalidScriptTemplate 在注册的脚本模板缺少必需字段或名称与已有类别冲突时返回。
+	ErrInvalidScriptTemplate = fmt.Errorf("invalid script template")
+)
+
+// ScriptTemplate 描述一个链特定的标准脚本模板，例如数据锚定脚本或质押脚本。
+type ScriptTemplate struct {
+	// Name 是该类别的名称，由 ScriptClass.String 返回，并可通过 NewScriptClass 解析。 它不能与任何已有类别重名。
+	Name string
+
+	// Match 返回传入的版本 0 脚本是否符合该模板。 它必须是无副作用的，并且可以被并发调用。
+	Match func(script []byte) bool
+
+	// ExpectedInputs 返回花费该脚本时签名脚本需要提供的参数个数，未知时返回 -1。 如果为 nil，则视为未知。
+	ExpectedInputs func(script []byte) int
+
+	// ExtractAddrs 可选地返回与脚本关联的地址以及所需的签名数。 如果为 nil，则 ExtractPkScriptAddrs 不返回任何地址且所需签名数为 0。
+	ExtractAddrs func(script []byte,
+		chainParams *chaincfg.Params) ([]btcutil.Address, int)
+}
+
+// customScriptClass 是已注册的模板及其分配的脚本类别。
+type customScriptClass struct {
+	class ScriptClass
+	ScriptTemplate
+}
+
+var (
+	// customClassesMtx 用于串行化注册操作。 读取方通过 customClasses 原子地获取不可变的快照，因此无需加锁。
+	customClassesMtx sync.Mutex
+
+	// customClasses 保存所有已注册模板的快照。 每次注册都会替换为一个新的切片，旧切片永远不会被修改。
+	customClasses atomic.Pointer[[]customScriptClass]
+
+	// customClassesFrozen 在第一个脚本引擎被创建时设置，此后不再接受新的注册，以避免与正在执行的脚本产生竞争。
+	customClassesFrozen atomic.Bool
+)
+
+// RegisterScriptClass 注册一个链特定的标准脚本模板，并返回分配给它的脚本类别。
+// 注册后，typeOfScript、GetScriptClass 和 ExtractPkScriptAddrs 会在所有内置模板都不匹配时依次尝试已注册的模板。
+//
+// 注册应当在程序初始化期间完成。 一旦创建了第一个脚本引擎，注册表即被冻结，之后的调用将返回 ErrScriptClassRegistryFrozen。
+func RegisterScriptClass(tmpl ScriptTemplate) (ScriptClass, error) {
+	if tmpl.Name == "" || tmpl.Match == nil {
+		return NonStandardTy, fmt.Errorf("%w: name and match function "+
+			"are required", ErrInvalidScriptTemplate)
+	}
+
+	customClassesMtx.Lock()
+	defer customClassesMtx.Unlock()
+
+	if customClassesFrozen.Load() {
+		return NonStandardTy, ErrScriptClassRegistryFrozen
+	}
+
+	if _, err := NewScriptClass(tmpl.Name); err == nil {
+		return NonStandardTy, fmt.Errorf("%w: class name %q already "+
+			"registered", ErrInvalidScriptTemplate, tmpl.Name)
+	}
+
+	var current []customScriptClass
+	if p := customClasses.Load(); p != nil {
+		current = *p
+	}
+
+	class := firstCustomScriptClass + ScriptClass(len(current))
+	if class < firstCustomScriptClass {
+		return NonStandardTy, fmt.Errorf("%w: too many registered "+
+			"script classes", ErrInvalidScriptTemplate)
+	}
+
+	updated := make([]customScriptClass, len(current), len(current)+1)
+	copy(updated, current)
+	updated = append(updated, customScriptClass{
+		class:          class,
+		ScriptTemplate: tmpl,
+	})
+	customClasses.Store(&updated)
+
+	return class, nil
+}
+
+// freezeScriptClassRegistry 冻结脚本类别注册表，之后的注册都会失败。
+func freezeScriptClassRegistry() {
+	customClassesFrozen.Store(true)
+}
+
+// registeredScriptClasses 返回当前已注册模板的不可变快照。
+func registeredScriptClasses() []customScriptClass {
+	if p := customClasses.Load(); p != nil {
+		return *p
+	}
+	return nil
+}
+
+// lookupCustomScriptClass 返回分配了传入脚本类别的已注册模板（如果有）。
+func lookupCustomScriptClass(class ScriptClass) *customScriptClass {
+	if class < firstCustomScriptClass {
+		return nil
+	}
+
+	classes := registeredScriptClasses()
+	idx := int(class - firstCustomScriptClass)
+	if idx >= len(classes) {
+		return nil
+	}
+	return &classes[idx]
+}
+
+// matchCustomScriptClass 按注册顺序返回第一个与传入脚本匹配的已注册模板，没有匹配时返回 nil。
+func matchCustomScriptClass(script []byte) *customScriptClass {
+	classes := registeredScriptClasses()
+	for i := range classes {
+		if classes[i].Match(script) {
+			return &classes[i]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,213 @@
+package txscript
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/chaincfg"
+)
+
+// withCleanScriptClassRegistry 在一个空的、未冻结的注册表中运行 f，并在结束后恢复原来的注册表状态。
+//
+// 注意：使用它的测试不能调用 t.Parallel，否则会与其他测试竞争全局注册表。
+func withCleanScriptClassRegistry(f func()) {
+	customClassesMtx.Lock()
+	saved := customClasses.Load()
+	wasFrozen := customClassesFrozen.Load()
+	customClasses.Store(nil)
+	customClassesFrozen.Store(false)
+	customClassesMtx.Unlock()
+
+	defer func() {
+		customClassesMtx.Lock()
+		customClasses.Store(saved)
+		customClassesFrozen.Store(wasFrozen)
+		customClassesMtx.Unlock()
+	}()
+
+	f()
+}
+
+// TestRegisterScriptClass 确保注册的链特定模板能被 GetScriptClass、ExtractPkScriptAddrs 和 expectedInputs 识别，
+// 并且内置模板始终优先。
+func TestRegisterScriptClass(t *testing.T) {
+	// 数据锚定脚本的形式为：OP_NOP10 <32 字节哈希> OP_DROP OP_TRUE。
+	anchorScript := append([]byte{OP_NOP10, OP_DATA_32},
+		bytes.Repeat([]byte{0xaa}, 32)...)
+	anchorScript = append(anchorScript, OP_DROP, OP_TRUE)
+	isAnchor := func(script []byte) bool {
+		return len(script) == 36 && script[0] == OP_NOP10 &&
+			script[1] == OP_DATA_32 && script[34] == OP_DROP &&
+			script[35] == OP_TRUE
+	}
+
+	withCleanScriptClassRegistry(func() {
+		anchorClass, err := RegisterScriptClass(ScriptTemplate{
+			Name:           "data_anchor",
+			Match:          isAnchor,
+			ExpectedInputs: func([]byte) int { return 0 },
+		})
+		if err != nil {
+			t.Fatalf("unable to register template: %v", err)
+		}
+		if anchorClass != firstCustomScriptClass {
+			t.Fatalf("unexpected class: got %d, want %d", anchorClass,
+				firstCustomScriptClass)
+		}
+
+		// 与内置类别重名的模板必须被拒绝。
+		_, err = RegisterScriptClass(ScriptTemplate{
+			Name:  PubKeyHashTy.String(),
+			Match: isAnchor,
+		})
+		if !errors.Is(err, ErrInvalidScriptTemplate) {
+			t.Fatalf("expected ErrInvalidScriptTemplate, got %v", err)
+		}
+
+		// 匹配所有脚本的模板不得覆盖内置模板。
+		catchAll, err := RegisterScriptClass(ScriptTemplate{
+			Name:  "catch_all",
+			Match: func([]byte) bool { return true },
+			ExtractAddrs: func(script []byte,
+				params *chaincfg.Params) ([]btcutil.Address, int) {
+
+				addr, _ := btcutil.NewAddressScriptHash(script, params)
+				return []btcutil.Address{addr}, 1
+			},
+		})
+		if err != nil {
+			t.Fatalf("unable to register template: %v", err)
+		}
+
+		if got := GetScriptClass(anchorScript); got != anchorClass {
+			t.Fatalf("GetScriptClass: got %v, want %v", got, anchorClass)
+		}
+		if got := anchorClass.String(); got != "data_anchor" {
+			t.Fatalf("String: got %q, want %q", got, "data_anchor")
+		}
+		parsed, err := NewScriptClass("data_anchor")
+		if err != nil || *parsed != anchorClass {
+			t.Fatalf("NewScriptClass: got %v (err %v), want %v",
+				parsed, err, anchorClass)
+		}
+		if got := expectedInputs(anchorScript, anchorClass); got != 0 {
+			t.Fatalf("expectedInputs: got %d, want 0", got)
+		}
+		if got := expectedInputs(nil, catchAll); got != -1 {
+			t.Fatalf("expectedInputs: got %d, want -1", got)
+		}
+
+		p2pkh := mustParseShortForm("DUP HASH160 DATA_20 0x" +
+			"433ec2ac1ffa1b7b7d027f564529c57197f9ae88 EQUALVERIFY " +
+			"CHECKSIG")
+		if got := GetScriptClass(p2pkh); got != PubKeyHashTy {
+			t.Fatalf("builtin class overridden: got %v", got)
+		}
+
+		class, addrs, reqSigs, err := ExtractPkScriptAddrs(
+			anchorScript, &chaincfg.MainNetParams,
+		)
+		if err != nil {
+			t.Fatalf("ExtractPkScriptAddrs: %v", err)
+		}
+		if class != anchorClass || len(addrs) != 0 || reqSigs != 0 {
+			t.Fatalf("ExtractPkScriptAddrs: got (%v, %v, %d)", class,
+				addrs, reqSigs)
+		}
+
+		other := []byte{OP_NOP, OP_TRUE}
+		class, addrs, reqSigs, err = ExtractPkScriptAddrs(
+			other, &chaincfg.MainNetParams,
+		)
+		if err != nil {
+			t.Fatalf("ExtractPkScriptAddrs: %v", err)
+		}
+		if class != catchAll || len(addrs) != 1 || reqSigs != 1 {
+			t.Fatalf("ExtractPkScriptAddrs: got (%v, %v, %d)", class,
+				addrs, reqSigs)
+		}
+
+		// 一旦创建了引擎，注册表就必须被冻结。
+		tx := createSpendingTx(nil, nil, anchorScript, 0)
+		_, err = NewEngine(anchorScript, tx, 0, 0, nil, nil, 0, nil)
+		if err != nil {
+			t.Fatalf("unable to create engine: %v", err)
+		}
+		_, err = RegisterScriptClass(ScriptTemplate{
+			Name:  "too_late",
+			Match: isAnchor,
+		})
+		if !errors.Is(err, ErrScriptClassRegistryFrozen) {
+			t.Fatalf("expected ErrScriptClassRegistryFrozen, got %v",
+				err)
+		}
+	})
+
+	// 恢复后的注册表中不得残留测试注册的模板。
+	if got := GetScriptClass(anchorScript); got != NonStandardTy {
+		t.Fatalf("registry not restored: got %v", got)
+	}
+}
+
+// TestScriptClassPrecedence 确保 GetScriptClass、ExtractStandardData 和 ExtractPkScriptAddrs 使用相同的优先级：
+// 内置模板优先于已注册的模板，已注册的模板优先于版本未知的见证程序。
+func TestScriptClassPrecedence(t *testing.T) {
+	program := func(version byte, fill byte) []byte {
+		return append([]byte{version, OP_DATA_32},
+			bytes.Repeat([]byte{fill}, 32)...)
+	}
+	witnessV2 := program(OP_2, 0xbb)
+	witnessV3 := program(OP_3, 0xcc)
+	taproot := program(OP_1, 0xdd)
+
+	withCleanScriptClassRegistry(func() {
+		futureClass, err := RegisterScriptClass(ScriptTemplate{
+			Name: "future_witness",
+			Match: func(script []byte) bool {
+				return bytes.Equal(script, witnessV2)
+			},
+		})
+		if err != nil {
+			t.Fatalf("unable to register template: %v", err)
+		}
+		_, err = RegisterScriptClass(ScriptTemplate{
+			Name: "shadow_taproot",
+			Match: func(script []byte) bool {
+				return bytes.Equal(script, taproot)
+			},
+		})
+		if err != nil {
+			t.Fatalf("unable to register template: %v", err)
+		}
+
+		tests := []struct {
+			name   string
+			script []byte
+			want   ScriptClass
+		}{
+			{"registered future witness", witnessV2, futureClass},
+			{"unregistered future witness", witnessV3, WitnessUnknownTy},
+			{"taproot", taproot, WitnessV1TaprootTy},
+		}
+		for _, test := range tests {
+			if got := GetScriptClass(test.script); got != test.want {
+				t.Errorf("%s: GetScriptClass: got %v, want %v",
+					test.name, got, test.want)
+			}
+			got := ExtractStandardData(test.script).Class
+			if got != test.want {
+				t.Errorf("%s: ExtractStandardData: got %v, want %v",
+					test.name, got, test.want)
+			}
+			class, _, _, err := ExtractPkScriptAddrs(
+				test.script, &chaincfg.MainNetParams,
+			)
+			if err != nil || class != test.want {
+				t.Errorf("%s: ExtractPkScriptAddrs: got %v (err %v), "+
+					"want %v", test.name, class, err, test.want)
+			}
+		}
+	})
+}
@@ -0,0 +1,373 @@
+// Package scriptgen 生成语法有效的随机脚本，以及附带满足输入的随机脚本，用于对脚本引擎和下游内存池策略进行基于属性的测试。
+//
+// 所有生成都由种子决定，因此失败的用例可以通过相同的种子重现。
+package scriptgen
+
+import (
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"math/rand"
+
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/qinglongcn/bpfschain/txscript"
+	"golang.org/x/crypto/ripemd160"
+)
+
+// ErrInvalidConfig 在生成器配置无效时返回。
+var ErrInvalidConfig = errors.New("invalid script generator config")
+
+// Config 控制生成脚本的形状。
+type Config struct {
+	// OpcodeWeights 是非推送、非条件操作码的相对权重。 为 nil 时使用 DefaultOpcodeWeights。
+	OpcodeWeights map[byte]int
+
+	// PushWeight 是生成一个数据推送的相对权重。
+	PushWeight int
+
+	// ConditionalWeight 是生成一个 OP_IF/OP_NOTIF 块的相对权重。 嵌套深度达到 MaxNestingDepth 后不再生成条件块。
+	ConditionalWeight int
+
+	// MinPushSize 和 MaxPushSize 是数据推送的字节数范围（包含两端）。
+	MinPushSize int
+	MaxPushSize int
+
+	// MaxNestingDepth 是条件块允许的最大嵌套深度。
+	MaxNestingDepth int
+
+	// MaxElements 是每个块（包括顶层）中最多生成的元素数。
+	MaxElements int
+}
+
+// DefaultOpcodeWeights 返回默认的操作码权重：所有未禁用、非保留、非推送且非条件的版本 0 操作码权重均为 1。
+func DefaultOpcodeWeights() map[byte]int {
+	weights := make(map[byte]int)
+	for op := txscript.OP_NOP; op <= txscript.OP_NOP10; op++ {
+		switch op {
+		case txscript.OP_IF, txscript.OP_NOTIF, txscript.OP_ELSE,
+			txscript.OP_ENDIF, txscript.OP_VERIF, txscript.OP_VERNOTIF,
+			txscript.OP_RETURN, txscript.OP_RESERVED1,
+			txscript.OP_RESERVED2, txscript.OP_VER,
+
+			// Disabled opcodes.
+			txscript.OP_CAT, txscript.OP_SUBSTR, txscript.OP_LEFT,
+			txscript.OP_RIGHT, txscript.OP_INVERT, txscript.OP_AND,
+			txscript.OP_OR, txscript.OP_XOR, txscript.OP_2MUL,
+			txscript.OP_2DIV, txscript.OP_MUL, txscript.OP_DIV,
+			txscript.OP_MOD, txscript.OP_LSHIFT, txscript.OP_RSHIFT:
+
+			continue
+		}
+		weights[byte(op)] = 1
+	}
+	return weights
+}
+
+// DefaultConfig 返回一个生成中等大小脚本的配置。
+func DefaultConfig() Config {
+	return Config{
+		OpcodeWeights:     DefaultOpcodeWeights(),
+		PushWeight:        10,
+		ConditionalWeight: 3,
+		MinPushSize:       0,
+		MaxPushSize:       75,
+		MaxNestingDepth:   3,
+		MaxElements:       12,
+	}
+}
+
+// validate 检查配置是否能够生成脚本。
+func (c *Config) validate() error {
+	switch {
+	case c.MinPushSize < 0 || c.MaxPushSize < c.MinPushSize:
+		return fmt.Errorf("%w: push size range [%d, %d]",
+			ErrInvalidConfig, c.MinPushSize, c.MaxPushSize)
+	case c.MaxPushSize > txscript.MaxScriptElementSize:
+		return fmt.Errorf("%w: max push size %d exceeds %d",
+			ErrInvalidConfig, c.MaxPushSize,
+			txscript.MaxScriptElementSize)
+	case c.MaxNestingDepth < 0:
+		return fmt.Errorf("%w: negative nesting depth", ErrInvalidConfig)
+	case c.MaxElements <= 0:
+		return fmt.Errorf("%w: max elements must be positive",
+			ErrInvalidConfig)
+	case c.PushWeight < 0 || c.ConditionalWeight < 0:
+		return fmt.Errorf("%w: negative weight", ErrInvalidConfig)
+	}
+	for op, weight := range c.OpcodeWeights {
+		if weight < 0 {
+			return fmt.Errorf("%w: negative weight for opcode 0x%02x",
+				ErrInvalidConfig, op)
+		}
+		if op <= txscript.OP_PUSHDATA4 {
+			return fmt.Errorf("%w: opcode 0x%02x is a data push, use "+
+				"PushWeight instead", ErrInvalidConfig, op)
+		}
+	}
+	return nil
+}
+
+// weightedOpcode 是累积权重表中的一项。
+type weightedOpcode struct {
+	op         byte
+	cumulative int
+}
+
+// Generator 根据配置生成随机脚本。 Generator 不是并发安全的。
+type Generator struct {
+	cfg     Config
+	rng     *rand.Rand
+	opcodes []weightedOpcode
+	total   int
+}
+
+// New 返回一个使用传入配置和种子的生成器。
+func New(cfg Config, seed int64) (*Generator, error) {
+	if cfg.OpcodeWeights == nil {
+		cfg.OpcodeWeights = DefaultOpcodeWeights()
+	}
+	if err := cfg.validate(); err != nil {
+		return nil, err
+	}
+
+	g := &Generator{
+		cfg: cfg,
+		rng: rand.New(rand.NewSource(seed)),
+	}
+
+	// Build the cumulative weight table in opcode order so generation is
+	// deterministic for a given seed regardless of map iteration order.
+	for op := 0; op < 256; op++ {
+		weight := cfg.OpcodeWeights[byte(op)]
+		if weight == 0 {
+			continue
+		}
+		g.total += weight
+		g.opcodes = append(g.opcodes, weightedOpcode{
+			op:         byte(op),
+			cumulative: g.total,
+		})
+	}
+	if g.total+cfg.PushWeight+cfg.ConditionalWeight == 0 {
+		return nil, fmt.Errorf("%w: all weights are zero",
+			ErrInvalidConfig)
+	}
+
+	return g, nil
+}
+
+// Script 生成一个语法有效的随机脚本：所有数据推送都是规范的，且条件块都是平衡的。 该脚本不保证能够执行成功。
+func (g *Generator) Script() []byte {
+	builder := txscript.NewScriptBuilder()
+	g.addBlock(builder, 0)
+
+	// The builder only fails when the script exceeds the maximum size, in
+	// which case the partial script is still syntactically valid.
+	script, _ := builder.Script()
+	return script
+}
+
+// addBlock 向 builder 添加一个随机长度的元素序列。
+func (g *Generator) addBlock(builder *txscript.ScriptBuilder, depth int) {
+	numElements := g.rng.Intn(g.cfg.MaxElements) + 1
+	for i := 0; i < numElements; i++ {
+		g.addElement(builder, depth)
+	}
+}
+
+// addElement 按权重向 builder 添加一个操作码、数据推送或条件块。
+func (g *Generator) addElement(builder *txscript.ScriptBuilder, depth int) {
+	condWeight := g.cfg.ConditionalWeight
+	if depth >= g.cfg.MaxNestingDepth {
+		condWeight = 0
+	}
+	total := g.total + g.cfg.PushWeight + condWeight
+	if total == 0 {
+		return
+	}
+
+	n := g.rng.Intn(total)
+	switch {
+	case n < g.cfg.PushWeight:
+		builder.AddData(g.randomBytes())
+
+	case n < g.cfg.PushWeight+condWeight:
+		if g.rng.Intn(2) == 0 {
+			builder.AddOp(txscript.OP_IF)
+		} else {
+			builder.AddOp(txscript.OP_NOTIF)
+		}
+		g.addBlock(builder, depth+1)
+		if g.rng.Intn(2) == 0 {
+			builder.AddOp(txscript.OP_ELSE)
+			g.addBlock(builder, depth+1)
+		}
+		builder.AddOp(txscript.OP_ENDIF)
+
+	default:
+		n -= g.cfg.PushWeight + condWeight
+		for _, entry := range g.opcodes {
+			if n < entry.cumulative {
+				builder.AddOp(entry.op)
+				break
+			}
+		}
+	}
+}
+
+// randomBytes 返回长度在配置范围内的随机字节。
+func (g *Generator) randomBytes() []byte {
+	size := g.cfg.MinPushSize
+	if g.cfg.MaxPushSize > g.cfg.MinPushSize {
+		size += g.rng.Intn(g.cfg.MaxPushSize - g.cfg.MinPushSize + 1)
+	}
+	data := make([]byte, size)
+	g.rng.Read(data)
+	return data
+}
+
+// Satisfied 是一个随机生成的脚本以及能够使其执行成功的输入。
+type Satisfied struct {
+	// Script 是生成的脚本。
+	Script []byte
+
+	// Inputs 是执行 Script 之前的初始堆栈，按从栈底到栈顶的顺序排列。
+	Inputs [][]byte
+}
+
+// SigScript 返回按顺序推送 Inputs 的签名脚本，用于将 Script 作为公钥脚本直接花费。
+func (s *Satisfied) SigScript() ([]byte, error) {
+	builder := txscript.NewScriptBuilder()
+	for _, input := range s.Inputs {
+		builder.AddData(input)
+	}
+	return builder.Script()
+}
+
+// Witness 返回将 Script 作为 P2WSH 见证脚本花费时的见证。
+func (s *Satisfied) Witness() [][]byte {
+	witness := make([][]byte, 0, len(s.Inputs)+1)
+	witness = append(witness, s.Inputs...)
+	return append(witness, s.Script)
+}
+
+// SatisfiedScript 生成一个由若干“谜题”组成的随机脚本，以及能够使其执行成功的输入。
+// 谜题包括哈希锁、长度检查、算术检查，以及在嵌套深度允许时选择其中一个分支的条件块。
+// 脚本最终在堆栈上只留下一个真值，因此也满足 clean stack 规则。
+func (g *Generator) SatisfiedScript() *Satisfied {
+	builder := txscript.NewScriptBuilder()
+
+	// consumed 按脚本消耗的顺序记录输入，即第一个元素必须位于栈顶。
+	var consumed [][]byte
+	numPuzzles := g.rng.Intn(g.cfg.MaxElements) + 1
+	for i := 0; i < numPuzzles; i++ {
+		consumed = append(consumed, g.addPuzzle(builder, 0)...)
+	}
+	builder.AddOp(txscript.OP_TRUE)
+
+	script, _ := builder.Script()
+	inputs := make([][]byte, len(consumed))
+	for i, input := range consumed {
+		inputs[len(consumed)-1-i] = input
+	}
+	return &Satisfied{Script: script, Inputs: inputs}
+}
+
+// addPuzzle 向 builder 添加一个谜题并返回解开它所需的输入（按消耗顺序）。 每个谜题执行后堆栈恢复原状。
+func (g *Generator) addPuzzle(builder *txscript.ScriptBuilder,
+	depth int) [][]byte {
+
+	numKinds := 4
+	if depth >= g.cfg.MaxNestingDepth {
+		numKinds = 3
+	}
+
+	switch g.rng.Intn(numKinds) {
+	// Hash lock: <hash op> <digest> EQUALVERIFY.
+	case 0:
+		preimage := g.randomBytes()
+		var hashOp byte
+		var digest []byte
+		switch g.rng.Intn(4) {
+		case 0:
+			hashOp = txscript.OP_SHA256
+			h := sha256.Sum256(preimage)
+			digest = h[:]
+		case 1:
+			hashOp = txscript.OP_HASH160
+			digest = btcutil.Hash160(preimage)
+		case 2:
+			hashOp = txscript.OP_HASH256
+			digest = chainhash.DoubleHashB(preimage)
+		default:
+			hashOp = txscript.OP_RIPEMD160
+			digest = ripemd160Hash(preimage)
+		}
+		builder.AddOp(hashOp).AddData(digest).
+			AddOp(txscript.OP_EQUALVERIFY)
+		return [][]byte{preimage}
+
+	// Size check: SIZE <len> EQUALVERIFY DROP.
+	case 1:
+		data := g.randomBytes()
+		builder.AddOp(txscript.OP_SIZE).AddInt64(int64(len(data))).
+			AddOp(txscript.OP_EQUALVERIFY).AddOp(txscript.OP_DROP)
+		return [][]byte{data}
+
+	// Arithmetic: <b> ADD <a+b> NUMEQUALVERIFY.
+	case 2:
+		a := g.rng.Int63n(1<<20) - 1<<19
+		b := g.rng.Int63n(1<<20) - 1<<19
+		builder.AddInt64(b).AddOp(txscript.OP_ADD).AddInt64(a + b).
+			AddOp(txscript.OP_NUMEQUALVERIFY)
+		return [][]byte{encodeScriptNum(a)}
+
+	// Conditional: IF <puzzle> ELSE <puzzle> ENDIF, choosing one branch.
+	default:
+		builder.AddOp(txscript.OP_IF)
+		thenInputs := g.addPuzzle(builder, depth+1)
+		builder.AddOp(txscript.OP_ELSE)
+		elseInputs := g.addPuzzle(builder, depth+1)
+		builder.AddOp(txscript.OP_ENDIF)
+
+		if g.rng.Intn(2) == 0 {
+			return append([][]byte{{1}}, thenInputs...)
+		}
+		return append([][]byte{nil}, elseInputs...)
+	}
+}
+
+// ripemd160Hash 返回 data 的 RIPEMD160 哈希。
+func ripemd160Hash(data []byte) []byte {
+	h := ripemd160.New()
+	h.Write(data)
+	return h.Sum(nil)
+}
+
+// encodeScriptNum 返回 n 的最小脚本数字编码。
+func encodeScriptNum(n int64) []byte {
+	if n == 0 {
+		return nil
+	}
+
+	negative := n < 0
+	if negative {
+		n = -n
+	}
+	var result []byte
+	for n > 0 {
+		result = append(result, byte(n&0xff))
+		n >>= 8
+	}
+	if result[len(result)-1]&0x80 != 0 {
+		extra := byte(0x00)
+		if negative {
+			extra = 0x80
+		}
+		result = append(result, extra)
+	} else if negative {
+		result[len(result)-1] |= 0x80
+	}
+	return result
+}
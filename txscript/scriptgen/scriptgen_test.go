@@ -0,0 +1,135 @@
+package scriptgen
+
+import (
+	"crypto/sha256"
+	"testing"
+
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/qinglongcn/bpfschain/txscript"
+	"github.com/stretchr/testify/require"
+)
+
+// spendingTx 返回花费 pkScript 的交易，并使用传入的签名脚本和见证。
+func spendingTx(pkScript, sigScript []byte, witness wire.TxWitness) *wire.MsgTx {
+	coinbaseTx := wire.NewMsgTx(wire.TxVersion)
+	outPoint := wire.NewOutPoint(&chainhash.Hash{}, ^uint32(0))
+	coinbaseTx.AddTxIn(wire.NewTxIn(outPoint, []byte{txscript.OP_0,
+		txscript.OP_0}, nil))
+	coinbaseTx.AddTxOut(wire.NewTxOut(0, pkScript))
+
+	spendTx := wire.NewMsgTx(wire.TxVersion)
+	coinbaseHash := coinbaseTx.TxHash()
+	outPoint = wire.NewOutPoint(&coinbaseHash, 0)
+	spendTx.AddTxIn(wire.NewTxIn(outPoint, sigScript, witness))
+	spendTx.AddTxOut(wire.NewTxOut(0, nil))
+	return spendTx
+}
+
+// TestScriptDeterministic 确保相同的种子生成相同的脚本，且生成的脚本都能被正确解析。
+func TestScriptDeterministic(t *testing.T) {
+	t.Parallel()
+
+	g1, err := New(DefaultConfig(), 42)
+	require.NoError(t, err)
+	g2, err := New(DefaultConfig(), 42)
+	require.NoError(t, err)
+
+	for i := 0; i < 200; i++ {
+		script := g1.Script()
+		require.Equal(t, script, g2.Script())
+
+		var depth int
+		tokenizer := txscript.MakeScriptTokenizer(0, script)
+		for tokenizer.Next() {
+			switch tokenizer.Opcode() {
+			case txscript.OP_IF, txscript.OP_NOTIF:
+				depth++
+				require.LessOrEqual(t, depth, DefaultConfig().MaxNestingDepth)
+			case txscript.OP_ENDIF:
+				depth--
+			}
+			require.GreaterOrEqual(t, depth, 0)
+		}
+		require.NoError(t, tokenizer.Err())
+		require.Zero(t, depth)
+	}
+}
+
+// TestConfigValidation 确保无效的配置被拒绝。
+func TestConfigValidation(t *testing.T) {
+	t.Parallel()
+
+	tests := []func(*Config){
+		func(c *Config) { c.MinPushSize = -1 },
+		func(c *Config) { c.MaxPushSize = c.MinPushSize - 1 },
+		func(c *Config) { c.MaxPushSize = txscript.MaxScriptElementSize + 1 },
+		func(c *Config) { c.MaxNestingDepth = -1 },
+		func(c *Config) { c.MaxElements = 0 },
+		func(c *Config) { c.PushWeight = -1 },
+		func(c *Config) { c.OpcodeWeights = map[byte]int{txscript.OP_DATA_1: 1} },
+		func(c *Config) {
+			c.OpcodeWeights = map[byte]int{}
+			c.PushWeight = 0
+			c.ConditionalWeight = 0
+		},
+	}
+	for i, mutate := range tests {
+		cfg := DefaultConfig()
+		mutate(&cfg)
+		_, err := New(cfg, 1)
+		require.ErrorIs(t, err, ErrInvalidConfig, "test %d", i)
+	}
+}
+
+// TestOpcodeWeights 确保只有权重非零的操作码会被生成。
+func TestOpcodeWeights(t *testing.T) {
+	t.Parallel()
+
+	cfg := DefaultConfig()
+	cfg.OpcodeWeights = map[byte]int{txscript.OP_DUP: 1}
+	cfg.PushWeight = 0
+	cfg.ConditionalWeight = 0
+	g, err := New(cfg, 7)
+	require.NoError(t, err)
+
+	for i := 0; i < 50; i++ {
+		script := g.Script()
+		require.NotEmpty(t, script)
+		for _, op := range script {
+			require.Equal(t, byte(txscript.OP_DUP), op)
+		}
+	}
+}
+
+// TestSatisfiedScript 确保生成的满足输入能够让脚本分别以裸脚本和 P2WSH 的形式在引擎中执行成功。
+func TestSatisfiedScript(t *testing.T) {
+	t.Parallel()
+
+	g, err := New(DefaultConfig(), 1337)
+	require.NoError(t, err)
+
+	const flags = txscript.StandardVerifyFlags
+	for i := 0; i < 200; i++ {
+		s := g.SatisfiedScript()
+
+		sigScript, err := s.SigScript()
+		require.NoError(t, err)
+		tx := spendingTx(s.Script, sigScript, nil)
+		vm, err := txscript.NewEngine(s.Script, tx, 0,
+			flags&^txscript.ScriptVerifyCleanStack, nil, nil, 0, nil)
+		require.NoError(t, err)
+		require.NoError(t, vm.Execute(), "bare script %x", s.Script)
+
+		scriptHash := sha256.Sum256(s.Script)
+		pkScript, err := txscript.NewScriptBuilder().
+			AddOp(txscript.OP_0).AddData(scriptHash[:]).Script()
+		require.NoError(t, err)
+		tx = spendingTx(pkScript, nil, s.Witness())
+		fetcher := txscript.NewCannedPrevOutputFetcher(pkScript, 0)
+		vm, err = txscript.NewEngine(pkScript, tx, 0, flags, nil,
+			txscript.NewTxSigHashes(tx, fetcher), 0, fetcher)
+		require.NoError(t, err)
+		require.NoError(t, vm.Execute(), "witness script %x", s.Script)
+	}
+}
@@ -4,6 +4,7 @@ package txscript
 
 import (
 	"fmt"
+	"math"
 )
 
 const (
@@ -16,12 +17,12 @@ const (
 	// cltvMaxScriptNumLen 是被解释为整数的最大字节数数据，可以用于由 CHECKLOCKTIMEVERIFY 解释的按时间和按高度锁定。
 	//
 	// 该值来自以下事实：当前事务锁定时间是 uint32，导致最大锁定时间为 2^32-1（2106 年）。
-	// 然而，scriptNum 是有符号的，因此标准的 4 字节 scriptNum 最多只能支持 2^31-1（2038 年）。
-	// 因此，需要 5 字节的 scriptNum，因为它将支持最多 2^39-1，这允许日期超出当前锁定时间限制。
+	// 然而，ScriptNum 是有符号的，因此标准的 4 字节 ScriptNum 最多只能支持 2^31-1（2038 年）。
+	// 因此，需要 5 字节的 ScriptNum，因为它将支持最多 2^39-1，这允许日期超出当前锁定时间限制。
 	cltvMaxScriptNumLen = 5
 )
 
-// scriptNum 表示脚本引擎中使用的数值，经过特殊处理以处理共识所需的微妙语义。
+// ScriptNum 表示脚本引擎中使用的数值，经过特殊处理以处理共识所需的微妙语义。
 //
 // 所有数字都存储在数据和备用堆栈上，编码为带有符号位的小端字节序。
 // 所有数字操作码（例如 OP_ADD、OP_SUB 和 OP_MUL）仅允许对 [-2^31 + 1, 2^31 - 1] 范围内的 4 字节整数进行操作，但是数字操作的结果可能会溢出并保留
@@ -35,7 +36,7 @@ const (
 //
 // 然后，每当数据被解释为整数时，都会使用 MakeScriptNum 函数将其转换为这种类型，如果数字超出范围或未根据参数进行最小编码，该函数将返回错误。
 // 由于所有数字操作码都涉及从堆栈中提取数据并将其解释为整数，因此它提供了所需的行为。
-type scriptNum int64
+type ScriptNum int64
 
 // checkMinimalDataEncoding 返回传递的字节数组是否符合最小编码要求。
 func checkMinimalDataEncoding(v []byte) error {
@@ -81,12 +82,23 @@ func checkMinimalDataEncoding(v []byte) error {
 //	-32767 -> [0xff 0xff]
 //	 32768 -> [0x00 0x80 0x00]
 //	-32768 -> [0x00 0x80 0x80]
-func (n scriptNum) Bytes() []byte {
+func (n ScriptNum) Bytes() []byte {
 	// Zero encodes as an empty byte slice.
 	if n == 0 {
 		return nil
 	}
 
+	// The maximum number of encoded bytes is 9 (8 bytes for max int64 plus
+	// a potential byte for sign extension).
+	return n.appendBytes(make([]byte, 0, 9))
+}
+
+// appendBytes 将数字的编码追加到 dst 并返回结果，编码规则与 Bytes 相同。 零不追加任何字节。
+func (n ScriptNum) appendBytes(dst []byte) []byte {
+	if n == 0 {
+		return dst
+	}
+
 	// Take the absolute value and keep track of whether it was originally
 	// negative.
 	isNegative := n < 0
@@ -94,9 +106,8 @@ func (n scriptNum) Bytes() []byte {
 		n = -n
 	}
 
-	// Encode to little endian.  The maximum number of encoded bytes is 9
-	// (8 bytes for max int64 plus a potential byte for sign extension).
-	result := make([]byte, 0, 9)
+	// Encode to little endian.
+	result := dst
 	for n > 0 {
 		result = append(result, byte(n&0xff))
 		n >>= 8
@@ -129,7 +140,7 @@ func (n scriptNum) Bytes() []byte {
 //
 // 实际上，对于大多数操作码，数字永远不应该超出范围，因为它是通过 MakeScriptNum 使用 defaultScriptLen 值创建的，该值会拒绝它们。
 // 万一将来最终根据某些算术的结果调用此函数（在被重新解释为整数之前允许超出范围），这将提供正确的行为。
-func (n scriptNum) Int32() int32 {
+func (n ScriptNum) Int32() int32 {
 	if n > maxInt32 {
 		return maxInt32
 	}
@@ -153,7 +164,7 @@ func (n scriptNum) Int32() int32 {
 // 警告：如果传递大于 maxScriptNumLen 的值，应格外小心，这可能导致加法和乘法溢出。
 //
 // 有关示例编码，请参阅 Bytes 函数文档。
-func MakeScriptNum(v []byte, requireMinimal bool, scriptNumLen int) (scriptNum, error) {
+func MakeScriptNum(v []byte, requireMinimal bool, scriptNumLen int) (ScriptNum, error) {
 	// Interpreting data requires that it is not larger than
 	// the passed scriptNumLen value.
 	if len(v) > scriptNumLen {
@@ -189,8 +200,116 @@ func MakeScriptNum(v []byte, requireMinimal bool, scriptNumLen int) (scriptNum,
 		// above, so uint8 is enough to cover the max possible shift
 		// value of 24.
 		result &= ^(int64(0x80) << uint8(8*(len(v)-1)))
-		return scriptNum(-result), nil
+		return ScriptNum(-result), nil
+	}
+
+	return ScriptNum(result), nil
+}
+
+const (
+	// MaxScriptNumLen 是大多数数字操作码接受的脚本数字的最大字节数。
+	MaxScriptNumLen = maxScriptNumLen
+
+	// LockTimeScriptNumLen 是 CHECKLOCKTIMEVERIFY 和 CHECKSEQUENCEVERIFY 接受的脚本数字的最大字节数。
+	LockTimeScriptNumLen = cltvMaxScriptNumLen
+
+	// maxInt64ScriptNumLen 是能够解码为 int64 的脚本数字的最大字节数。
+	maxInt64ScriptNumLen = 8
+)
+
+// ScriptNumError 描述超出长度限制或不是最小编码的脚本数字。 ErrorCode 为 ErrNumberTooBig 或 ErrMinimalData，
+// Unwrap 返回具有相同错误代码的 Error。
+type ScriptNumError struct {
+	// ErrorCode 是错误的原因。
+	ErrorCode ErrorCode
+
+	// Data 是数字的编码。
+	Data []byte
+
+	// MaxLen 是允许的最大字节数。
+	MaxLen int
+}
+
+// Error 实现 error 接口。
+func (e *ScriptNumError) Error() string {
+	if e.ErrorCode == ErrMinimalData {
+		return fmt.Sprintf("numeric value encoded as %x is not minimally "+
+			"encoded", e.Data)
 	}
+	return fmt.Sprintf("numeric value encoded as %x is %d bytes which "+
+		"exceeds the max allowed of %d", e.Data, len(e.Data), e.MaxLen)
+}
 
-	return scriptNum(result), nil
+// Unwrap 返回与 MakeScriptNum 相同的 Error，使 errors.As 可以取出错误代码。
+func (e *ScriptNumError) Unwrap() error {
+	return scriptError(e.ErrorCode, e.Error())
+}
+
+// Encode 返回数字的最小编码，编码超过 maxLen 字节时返回 ErrorCode 为 ErrNumberTooBig 的 *ScriptNumError。
+// 与 ScriptBuilder.AddInt64 不同，它使构建脚本的调用者在推送数字之前就能发现执行时会因数字过长而失败的脚本。
+func (n ScriptNum) Encode(maxLen int) ([]byte, error) {
+	data := n.Bytes()
+	if len(data) > maxLen {
+		return nil, &ScriptNumError{
+			ErrorCode: ErrNumberTooBig,
+			Data:      data,
+			MaxLen:    maxLen,
+		}
+	}
+	return data, nil
+}
+
+// DecodeScriptNum 将 data 解码为脚本数字，规则与 MakeScriptNum 相同，但错误是描述原因的 *ScriptNumError。
+// maxLen 大于 8 时按 8 处理，因为更长的数字无法表示为 int64。
+func DecodeScriptNum(data []byte, requireMinimal bool,
+	maxLen int) (ScriptNum, error) {
+
+	if maxLen > maxInt64ScriptNumLen {
+		maxLen = maxInt64ScriptNumLen
+	}
+	if len(data) > maxLen {
+		return 0, &ScriptNumError{
+			ErrorCode: ErrNumberTooBig,
+			Data:      data,
+			MaxLen:    maxLen,
+		}
+	}
+	if requireMinimal && checkMinimalDataEncoding(data) != nil {
+		return 0, &ScriptNumError{
+			ErrorCode: ErrMinimalData,
+			Data:      data,
+			MaxLen:    maxLen,
+		}
+	}
+	return MakeScriptNum(data, false, maxLen)
+}
+
+// EncodeLockTimeNum 返回 CHECKLOCKTIMEVERIFY 或 CHECKSEQUENCEVERIFY 使用的锁定时间或序列号的最小编码，
+// 最多 LockTimeScriptNumLen 字节。 lockTime 为负时返回 ErrNegativeLockTime，大于 math.MaxUint32 时返回
+// ErrNumberTooBig，因为这样的锁定时间永远无法被交易满足。
+func EncodeLockTimeNum(lockTime int64) ([]byte, error) {
+	if lockTime < 0 {
+		str := fmt.Sprintf("negative lock time: %d", lockTime)
+		return nil, scriptError(ErrNegativeLockTime, str)
+	}
+	if lockTime > math.MaxUint32 {
+		str := fmt.Sprintf("lock time %d exceeds the max of %d", lockTime,
+			uint32(math.MaxUint32))
+		return nil, scriptError(ErrNumberTooBig, str)
+	}
+	return ScriptNum(lockTime).Encode(LockTimeScriptNumLen)
+}
+
+// DecodeLockTimeNum 按 CHECKLOCKTIMEVERIFY 和 CHECKSEQUENCEVERIFY 的规则解码锁定时间或序列号：最多
+// LockTimeScriptNumLen 字节，并且不能为负。 requireMinimal 的含义与 DecodeScriptNum 相同。
+func DecodeLockTimeNum(data []byte, requireMinimal bool) (int64, error) {
+	n, err := DecodeScriptNum(data, requireMinimal, LockTimeScriptNumLen)
+	if err != nil {
+		return 0, err
+	}
+	if n < 0 {
+		str := fmt.Sprintf("negative lock time: %d", n)
+		return 0, scriptError(ErrNegativeLockTime, str)
+	}
+	return int64(n), nil
 }
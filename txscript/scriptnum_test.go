@@ -5,6 +5,7 @@ package txscript
 import (
 	"bytes"
 	"encoding/hex"
+	"errors"
 	"testing"
 )
 
@@ -24,7 +25,7 @@ func TestScriptNumBytes(t *testing.T) {
 	t.Parallel()
 
 	tests := []struct {
-		num        scriptNum
+		num        ScriptNum
 		serialized []byte
 	}{
 		{0, nil},
@@ -91,7 +92,7 @@ func TestMakeScriptNum(t *testing.T) {
 
 	tests := []struct {
 		serialized      []byte
-		num             scriptNum
+		num             ScriptNum
 		numLen          int
 		minimalEncoding bool
 		err             error
@@ -206,7 +207,7 @@ func TestScriptNumInt32(t *testing.T) {
 	t.Parallel()
 
 	tests := []struct {
-		in   scriptNum
+		in   ScriptNum
 		want int32
 	}{
 		// 有效 int32 范围内的值只是转换为 int32 的值本身。
@@ -259,3 +260,122 @@ func TestScriptNumInt32(t *testing.T) {
 		}
 	}
 }
+
+// TestScriptNumEncodeDecode 确保显式编码和解码遵守长度限制和最小编码要求，并返回描述原因的错误。
+func TestScriptNumEncodeDecode(t *testing.T) {
+	t.Parallel()
+
+	encodeTests := []struct {
+		num    ScriptNum
+		maxLen int
+		want   []byte
+		code   ErrorCode
+		fails  bool
+	}{
+		{num: 0, maxLen: 0, want: nil},
+		{num: 127, maxLen: 1, want: hexToBytes("7f")},
+		{num: 128, maxLen: 1, fails: true, code: ErrNumberTooBig},
+		{num: 2147483647, maxLen: 4, want: hexToBytes("ffffff7f")},
+		{num: 2147483648, maxLen: 4, fails: true, code: ErrNumberTooBig},
+		{num: 4294967295, maxLen: 5, want: hexToBytes("ffffffff00")},
+	}
+	for i, test := range encodeTests {
+		got, err := test.num.Encode(test.maxLen)
+		if test.fails {
+			var numErr *ScriptNumError
+			if !errors.As(err, &numErr) || numErr.ErrorCode != test.code {
+				t.Errorf("Encode #%d: got %v, want code %v", i, err,
+					test.code)
+			}
+			continue
+		}
+		if err != nil || !bytes.Equal(got, test.want) {
+			t.Errorf("Encode #%d: got %x (%v), want %x", i, got, err,
+				test.want)
+		}
+	}
+
+	decodeTests := []struct {
+		data           []byte
+		requireMinimal bool
+		maxLen         int
+		want           ScriptNum
+		code           ErrorCode
+		fails          bool
+	}{
+		{data: hexToBytes("ffffffff00"), maxLen: 5, want: 4294967295},
+		{data: hexToBytes("ffffffff00"), maxLen: 4, fails: true,
+			code: ErrNumberTooBig},
+		{data: hexToBytes("0100"), maxLen: 4, want: 1},
+		{data: hexToBytes("0100"), requireMinimal: true, maxLen: 4,
+			fails: true, code: ErrMinimalData},
+		{data: hexToBytes("80"), requireMinimal: true, maxLen: 4,
+			fails: true, code: ErrMinimalData},
+		{data: hexToBytes("ffffffffffffff7f"), maxLen: 9,
+			want: 9223372036854775807},
+		{data: hexToBytes("ffffffffffffff7f00"), maxLen: 9, fails: true,
+			code: ErrNumberTooBig},
+	}
+	for i, test := range decodeTests {
+		got, err := DecodeScriptNum(test.data, test.requireMinimal,
+			test.maxLen)
+		if test.fails {
+			var numErr *ScriptNumError
+			if !errors.As(err, &numErr) || numErr.ErrorCode != test.code {
+				t.Errorf("DecodeScriptNum #%d: got %v, want code %v", i,
+					err, test.code)
+			}
+			var scriptErr Error
+			if !errors.As(err, &scriptErr) ||
+				scriptErr.ErrorCode != test.code {
+
+				t.Errorf("DecodeScriptNum #%d: %v does not unwrap to "+
+					"code %v", i, err, test.code)
+			}
+			continue
+		}
+		if err != nil || got != test.want {
+			t.Errorf("DecodeScriptNum #%d: got %d (%v), want %d", i, got,
+				err, test.want)
+		}
+	}
+}
+
+// TestLockTimeNum 确保锁定时间的编码和解码使用 5 字节限制并拒绝负值和无法满足的值，且 AddLockTime 推送相同的编码。
+func TestLockTimeNum(t *testing.T) {
+	t.Parallel()
+
+	data, err := EncodeLockTimeNum(4294967295)
+	if err != nil || !bytes.Equal(data, hexToBytes("ffffffff00")) {
+		t.Fatalf("EncodeLockTimeNum: got %x (%v)", data, err)
+	}
+	lockTime, err := DecodeLockTimeNum(data, true)
+	if err != nil || lockTime != 4294967295 {
+		t.Fatalf("DecodeLockTimeNum: got %d (%v)", lockTime, err)
+	}
+
+	if _, err := EncodeLockTimeNum(-1); !IsErrorCode(err, ErrNegativeLockTime) {
+		t.Fatalf("EncodeLockTimeNum(-1): got %v", err)
+	}
+	if _, err := EncodeLockTimeNum(1 << 32); !IsErrorCode(err, ErrNumberTooBig) {
+		t.Fatalf("EncodeLockTimeNum(2^32): got %v", err)
+	}
+	_, err = DecodeLockTimeNum(hexToBytes("81"), true)
+	if !IsErrorCode(err, ErrNegativeLockTime) {
+		t.Fatalf("DecodeLockTimeNum(-1): got %v", err)
+	}
+
+	script, err := NewScriptBuilder().AddLockTime(4294967295).
+		AddOp(OP_CHECKLOCKTIMEVERIFY).Script()
+	if err != nil || !bytes.Equal(script, hexToBytes("05ffffffff00b1")) {
+		t.Fatalf("AddLockTime: got %x (%v)", script, err)
+	}
+	script, err = NewScriptBuilder().AddLockTime(16).Script()
+	if err != nil || !bytes.Equal(script, []byte{OP_16}) {
+		t.Fatalf("AddLockTime(16): got %x (%v)", script, err)
+	}
+	_, err = NewScriptBuilder().AddLockTime(1 << 40).Script()
+	if !IsErrorCode(err, ErrNumberTooBig) {
+		t.Fatalf("AddLockTime(2^40): got %v", err)
+	}
+}
@@ -0,0 +1,151 @@
+// 包含版本 1 及以上脚本语言的注册逻辑，使新的脚本版本可以定义自己的操作码语义和限制，而不是被视为任何人都可以花费。
+
+package txscript
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+)
+
+var (
+	// ErrScriptVersionRegistryFrozen 在脚本引擎已经被使用之后尝试注册脚本版本时返回。
+	ErrScriptVersionRegistryFrozen = fmt.Errorf("script version registry " +
+		"is frozen")
+
+	// ErrInvalidScriptVersion 在脚本版本定义无效、版本为 0 或该版本已注册时返回。
+	ErrInvalidScriptVersion = fmt.Errorf("invalid script version definition")
+)
+
+// OpcodeFunc 实现某个脚本版本中一个操作码的语义。 data 是操作码携带的数据，非推送操作码为 nil。
+// 实现通过 Engine 的 PopStack 和 PushStack 等方法操作堆栈，返回错误表示脚本执行失败。
+type OpcodeFunc func(vm *Engine, data []byte) error
+
+// ScriptVersionDefinition 描述一个版本 1 及以上的脚本语言。 脚本的编码与版本 0 相同，
+// 因此数据推送和条件操作码的语义不能被修改；其他操作码可以被重新定义、启用或禁用。
+type ScriptVersionDefinition struct {
+	// Opcodes 覆盖版本 0 操作码表中的操作码。 映射到 nil 的操作码在该版本中执行时失败，
+	// 映射到函数的操作码即使在版本 0 中被禁用（如 OP_CAT）也会执行该函数。 未出现的操作码保持版本 0 的语义。
+	Opcodes map[byte]OpcodeFunc
+
+	// Limits 可选地指定执行该版本脚本时使用的限制，为 nil 时使用引擎的限制。
+	Limits *ChainLimits
+}
+
+// scriptVersionDef 是已注册脚本版本的编译形式。
+type scriptVersionDef struct {
+	// handlers 保存被定义覆盖的操作码的处理函数，未覆盖的操作码使用 opcodeJumpTable。
+	handlers [256]opcodeHandler
+
+	// overridden 标记被定义覆盖的操作码，这些操作码不受版本 0 禁用和保留规则的约束。
+	overridden [256]bool
+
+	// limits 是该版本的限制，为 nil 时使用引擎的限制。
+	limits *ChainLimits
+}
+
+var (
+	// scriptVersionsMtx 用于串行化注册操作。
+	scriptVersionsMtx sync.Mutex
+
+	// scriptVersions 保存已注册脚本版本的不可变快照。
+	scriptVersions atomic.Pointer[map[uint16]*scriptVersionDef]
+
+	// scriptVersionsFrozen 在第一个脚本引擎被创建时设置，此后不再接受新的注册。
+	scriptVersionsFrozen atomic.Bool
+)
+
+// RegisterScriptVersion 注册一个版本 1 及以上的脚本语言。 通过 WithScriptVersion 以该版本创建的引擎会使用定义中的操作码表和限制执行脚本，
+// 未注册的版本仍按 ScriptVerifyRejectUnknownScriptVersion 标志处理。
+//
+// 注册应当在程序初始化期间完成。 一旦创建了第一个脚本引擎，注册表即被冻结，之后的调用将返回 ErrScriptVersionRegistryFrozen。
+func RegisterScriptVersion(version uint16, def ScriptVersionDefinition) error {
+	if version == 0 {
+		return fmt.Errorf("%w: version 0 is built in", ErrInvalidScriptVersion)
+	}
+	if def.Limits != nil {
+		if err := def.Limits.Validate(); err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidScriptVersion, err)
+		}
+	}
+
+	compiled := &scriptVersionDef{}
+	if def.Limits != nil {
+		limits := *def.Limits
+		compiled.limits = &limits
+	}
+	for value, fn := range def.Opcodes {
+		// 数据推送由分词器解析，条件操作码决定分支的执行，二者的语义必须在所有版本中保持一致。
+		if value <= OP_16 || isOpcodeConditional(value) {
+			return fmt.Errorf("%w: opcode %s cannot be redefined",
+				ErrInvalidScriptVersion, opcodeArray[value].name)
+		}
+
+		compiled.overridden[value] = true
+		if fn == nil {
+			compiled.handlers[value] = opcodeReserved
+			continue
+		}
+		fn := fn
+		compiled.handlers[value] = func(_ *opcode, data []byte,
+			vm *Engine) error {
+
+			return fn(vm, data)
+		}
+	}
+
+	scriptVersionsMtx.Lock()
+	defer scriptVersionsMtx.Unlock()
+
+	if scriptVersionsFrozen.Load() {
+		return ErrScriptVersionRegistryFrozen
+	}
+
+	current := scriptVersions.Load()
+	updated := make(map[uint16]*scriptVersionDef)
+	if current != nil {
+		if _, ok := (*current)[version]; ok {
+			return fmt.Errorf("%w: version %d already registered",
+				ErrInvalidScriptVersion, version)
+		}
+		for v, d := range *current {
+			updated[v] = d
+		}
+	}
+	updated[version] = compiled
+	scriptVersions.Store(&updated)
+
+	return nil
+}
+
+// freezeScriptVersionRegistry 冻结脚本版本注册表，之后的注册都会失败。
+func freezeScriptVersionRegistry() {
+	scriptVersionsFrozen.Store(true)
+}
+
+// lookupScriptVersion 返回为传入版本注册的定义，没有时返回 nil。
+func lookupScriptVersion(version uint16) *scriptVersionDef {
+	p := scriptVersions.Load()
+	if p == nil {
+		return nil
+	}
+	return (*p)[version]
+}
+
+// WithScriptVersion 指定公钥脚本的版本。 版本 0 是默认的比特币脚本语言；其他版本必须已通过 RegisterScriptVersion 注册，
+// 否则在设置了 ScriptVerifyRejectUnknownScriptVersion 时引擎创建失败，未设置时脚本不被执行即视为成功。
+func WithScriptVersion(version uint16) EngineOpt {
+	return func(cfg *engineConfig) {
+		cfg.scriptVersion = version
+	}
+}
+
+// PopStack 弹出并返回数据堆栈顶部的元素，供 OpcodeFunc 实现使用。
+func (vm *Engine) PopStack() ([]byte, error) {
+	return vm.dstack.PopByteArray()
+}
+
+// PushStack 将元素压入数据堆栈顶部，供 OpcodeFunc 实现使用。
+func (vm *Engine) PushStack(data []byte) {
+	vm.dstack.PushByteArray(data)
+}
@@ -0,0 +1,129 @@
+package txscript
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// withCleanScriptVersionRegistry 在一个空的、未冻结的脚本版本注册表中运行 f，并在结束后恢复原来的注册表状态。
+//
+// 注意：使用它的测试不能调用 t.Parallel，否则会与其他测试竞争全局注册表。
+func withCleanScriptVersionRegistry(f func()) {
+	scriptVersionsMtx.Lock()
+	saved := scriptVersions.Load()
+	wasFrozen := scriptVersionsFrozen.Load()
+	scriptVersions.Store(nil)
+	scriptVersionsFrozen.Store(false)
+	scriptVersionsMtx.Unlock()
+
+	defer func() {
+		scriptVersionsMtx.Lock()
+		scriptVersions.Store(saved)
+		scriptVersionsFrozen.Store(wasFrozen)
+		scriptVersionsMtx.Unlock()
+	}()
+
+	f()
+}
+
+// TestScriptVersions 确保已注册的脚本版本使用自己的操作码表和限制执行，并且未注册的版本按严格标志处理。
+func TestScriptVersions(t *testing.T) {
+	// 版本 1 启用 OP_CAT，禁用 OP_SHA1，并将最大元素大小限制为 8 字节。
+	opCat := func(vm *Engine, data []byte) error {
+		b, err := vm.PopStack()
+		if err != nil {
+			return err
+		}
+		a, err := vm.PopStack()
+		if err != nil {
+			return err
+		}
+		vm.PushStack(append(append([]byte{}, a...), b...))
+		return nil
+	}
+	limits := DefaultChainLimits()
+	limits.MaxScriptElementSize = 8
+	limits.MaxDataCarrierSize = 8
+
+	execute := func(sigScript, pkScript []byte, flags ScriptFlags,
+		version uint16) error {
+
+		tx := createSpendingTx(nil, sigScript, pkScript, 0)
+		vm, err := NewEngine(pkScript, tx, 0, flags, nil, nil, 0, nil,
+			WithScriptVersion(version))
+		if err != nil {
+			return err
+		}
+		return vm.Execute()
+	}
+
+	withCleanScriptVersionRegistry(func() {
+		invalid := []struct {
+			version uint16
+			def     ScriptVersionDefinition
+		}{
+			{0, ScriptVersionDefinition{}},
+			{1, ScriptVersionDefinition{
+				Opcodes: map[byte]OpcodeFunc{OP_DATA_1: opCat},
+			}},
+			{1, ScriptVersionDefinition{
+				Opcodes: map[byte]OpcodeFunc{OP_IF: opCat},
+			}},
+			{1, ScriptVersionDefinition{Limits: &ChainLimits{}}},
+		}
+		for _, test := range invalid {
+			err := RegisterScriptVersion(test.version, test.def)
+			require.ErrorIs(t, err, ErrInvalidScriptVersion)
+		}
+
+		err := RegisterScriptVersion(1, ScriptVersionDefinition{
+			Opcodes: map[byte]OpcodeFunc{
+				OP_CAT:  opCat,
+				OP_SHA1: nil,
+			},
+			Limits: &limits,
+		})
+		require.NoError(t, err)
+		err = RegisterScriptVersion(1, ScriptVersionDefinition{})
+		require.ErrorIs(t, err, ErrInvalidScriptVersion)
+
+		catScript := mustParseShortForm("CAT 'abcd' EQUAL")
+		sigScript := mustParseShortForm("'ab' 'cd'")
+
+		// 版本 0 中 OP_CAT 仍然被禁用。
+		err = execute(sigScript, catScript, 0, 0)
+		require.True(t, IsErrorCode(err, ErrDisabledOpcode))
+
+		require.NoError(t, execute(sigScript, catScript, 0, 1))
+		err = execute(mustParseShortForm("'ab' 'ce'"), catScript, 0, 1)
+		require.True(t, IsErrorCode(err, ErrEvalFalse))
+
+		// 版本 1 中 OP_SHA1 不可用。
+		err = execute(mustParseShortForm("'ab'"),
+			mustParseShortForm("SHA1 DROP 1"), 0, 1)
+		require.True(t, IsErrorCode(err, ErrReservedOpcode))
+
+		// 版本 1 使用自己的元素大小限制。
+		big := bytes.Repeat([]byte{0x01}, 9)
+		pkScript, err := NewScriptBuilder().AddData(big).AddOp(OP_DROP).
+			AddOp(OP_TRUE).Script()
+		require.NoError(t, err)
+		require.NoError(t, execute(nil, pkScript, 0, 0))
+		err = execute(nil, pkScript, 0, 1)
+		require.True(t, IsErrorCode(err, ErrElementTooBig))
+
+		// 未注册的版本默认不被执行即成功，设置严格标志时被拒绝。
+		failScript := mustParseShortForm("RETURN")
+		require.NoError(t, execute(nil, failScript, 0, 2))
+		err = execute(nil, failScript,
+			ScriptVerifyRejectUnknownScriptVersion, 2)
+		require.True(t, IsErrorCode(err, ErrUnsupportedScriptVersion))
+
+		// 创建引擎后注册表被冻结。
+		err = RegisterScriptVersion(3, ScriptVersionDefinition{})
+		require.True(t, errors.Is(err, ErrScriptVersionRegistryFrozen))
+	})
+}
@@ -0,0 +1,200 @@
+// 包含与链参数无关的 bech32/bech32m 地址编码器，用于在见证程序公钥脚本和使用任意人类可读部分（HRP）的地址字符串之间直接转换。
+
+package txscript
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/btcsuite/btcd/btcutil/bech32"
+	"github.com/btcsuite/btcd/chaincfg"
+)
+
+// ErrInvalidSegwitAddress 在地址字符串或公钥脚本不是有效的见证程序编码时返回。
+var ErrInvalidSegwitAddress = errors.New("invalid segwit address")
+
+// validateWitnessProgram 检查见证版本和程序长度是否符合 BIP0141 的规定：
+// 版本 0 的程序必须为 20 或 32 字节，所有版本的程序都必须为 2 到 40 字节。
+func validateWitnessProgram(version int, program []byte) error {
+	if version < 0 || version > 16 {
+		return fmt.Errorf("%w: witness version %d out of range",
+			ErrInvalidSegwitAddress, version)
+	}
+	if len(program) < 2 || len(program) > 40 {
+		return fmt.Errorf("%w: witness program length %d out of range",
+			ErrInvalidSegwitAddress, len(program))
+	}
+	if version == 0 && len(program) != payToWitnessPubKeyHashDataSize &&
+		len(program) != payToWitnessScriptHashDataSize {
+
+		return fmt.Errorf("%w: invalid v0 witness program length %d",
+			ErrInvalidSegwitAddress, len(program))
+	}
+	return nil
+}
+
+// EncodeSegwitAddress 将见证版本和程序编码为使用 hrp 的地址字符串。 版本 0 使用 bech32 编码，版本 1 及以上使用 BIP0350 的 bech32m 编码。
+func EncodeSegwitAddress(hrp string, version int, program []byte) (string, error) {
+	if err := validateWitnessProgram(version, program); err != nil {
+		return "", err
+	}
+
+	converted, err := bech32.ConvertBits(program, 8, 5, true)
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrInvalidSegwitAddress, err)
+	}
+	data := append([]byte{byte(version)}, converted...)
+
+	var addr string
+	if version == 0 {
+		addr, err = bech32.Encode(hrp, data)
+	} else {
+		addr, err = bech32.EncodeM(hrp, data)
+	}
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrInvalidSegwitAddress, err)
+	}
+	return addr, nil
+}
+
+// DecodeSegwitAddress 解码使用 hrp 的地址字符串并返回见证版本和程序。 HRP 的比较不区分大小写。
+// 版本 0 的地址必须使用 bech32 编码，版本 1 及以上的地址必须使用 bech32m 编码。
+func DecodeSegwitAddress(hrp, addr string) (int, []byte, error) {
+	decodedHRP, data, encoding, err := bech32.DecodeGeneric(addr)
+	if err != nil {
+		return 0, nil, fmt.Errorf("%w: %v", ErrInvalidSegwitAddress, err)
+	}
+	if decodedHRP != strings.ToLower(hrp) {
+		return 0, nil, fmt.Errorf("%w: hrp %q does not match %q",
+			ErrInvalidSegwitAddress, decodedHRP, hrp)
+	}
+	if len(data) < 1 {
+		return 0, nil, fmt.Errorf("%w: missing witness version",
+			ErrInvalidSegwitAddress)
+	}
+
+	version := int(data[0])
+	program, err := bech32.ConvertBits(data[1:], 5, 8, false)
+	if err != nil {
+		return 0, nil, fmt.Errorf("%w: %v", ErrInvalidSegwitAddress, err)
+	}
+	if err := validateWitnessProgram(version, program); err != nil {
+		return 0, nil, err
+	}
+
+	switch {
+	case version == 0 && encoding != bech32.Version0:
+		return 0, nil, fmt.Errorf("%w: v0 address must use bech32",
+			ErrInvalidSegwitAddress)
+	case version != 0 && encoding != bech32.VersionM:
+		return 0, nil, fmt.Errorf("%w: v%d address must use bech32m",
+			ErrInvalidSegwitAddress, version)
+	}
+
+	return version, program, nil
+}
+
+// PkScriptToSegwitAddress 将见证程序公钥脚本（例如 P2WPKH、P2WSH 或 P2TR）编码为使用 hrp 的地址字符串。
+// 与 ExtractPkScriptAddrs 不同，它不依赖 btcutil 的地址类型或比特币的链参数。
+func PkScriptToSegwitAddress(pkScript []byte, hrp string) (string, error) {
+	version, program, valid := extractWitnessProgramInfo(pkScript)
+	if !valid {
+		return "", fmt.Errorf("%w: script is not a witness program",
+			ErrInvalidSegwitAddress)
+	}
+	return EncodeSegwitAddress(hrp, version, program)
+}
+
+// SegwitAddressToPkScript 解码使用 hrp 的地址字符串并返回对应的见证程序公钥脚本。
+func SegwitAddressToPkScript(addr, hrp string) ([]byte, error) {
+	version, program, err := DecodeSegwitAddress(hrp, addr)
+	if err != nil {
+		return nil, err
+	}
+	return PayToWitnessProgramScript(version, program)
+}
+
+// PayToWitnessProgramScript 返回向任意版本的见证程序付款的公钥脚本，包括尚未定义花费规则的版本 2 到 16。
+func PayToWitnessProgramScript(version int, program []byte) ([]byte, error) {
+	if err := validateWitnessProgram(version, program); err != nil {
+		return nil, err
+	}
+
+	versionOp := byte(OP_0)
+	if version > 0 {
+		versionOp = byte(OP_1 - 1 + version)
+	}
+	return NewScriptBuilder().AddOp(versionOp).AddData(program).Script()
+}
+
+// WitnessAddress 是任意版本见证程序的地址。 btcutil 只为版本 0 和 taproot 提供地址类型，
+// 该类型使向未来见证版本付款的输出也可以通过 PayToAddrScript 构建，并由 ExtractPkScriptAddrs 返回。
+type WitnessAddress struct {
+	hrp     string
+	version int
+	program []byte
+}
+
+// NewWitnessAddress 返回使用 params 的 bech32 人类可读部分的见证地址。
+func NewWitnessAddress(version int, program []byte,
+	params *chaincfg.Params) (*WitnessAddress, error) {
+
+	if err := validateWitnessProgram(version, program); err != nil {
+		return nil, err
+	}
+	return &WitnessAddress{
+		hrp:     params.Bech32HRPSegwit,
+		version: version,
+		program: append([]byte(nil), program...),
+	}, nil
+}
+
+// DecodeWitnessAddress 解码 params 网络的见证地址字符串，接受任何见证版本。
+func DecodeWitnessAddress(addr string,
+	params *chaincfg.Params) (*WitnessAddress, error) {
+
+	version, program, err := DecodeSegwitAddress(params.Bech32HRPSegwit, addr)
+	if err != nil {
+		return nil, err
+	}
+	return &WitnessAddress{
+		hrp:     params.Bech32HRPSegwit,
+		version: version,
+		program: program,
+	}, nil
+}
+
+// Version 返回地址的见证版本。
+func (a *WitnessAddress) Version() int {
+	return a.version
+}
+
+// Program 返回地址的见证程序。
+func (a *WitnessAddress) Program() []byte {
+	return a.program
+}
+
+// EncodeAddress 返回地址的 bech32 或 bech32m 字符串编码。 它是 btcutil.Address 接口的一部分。
+func (a *WitnessAddress) EncodeAddress() string {
+	addr, err := EncodeSegwitAddress(a.hrp, a.version, a.program)
+	if err != nil {
+		return ""
+	}
+	return addr
+}
+
+// ScriptAddress 返回见证程序。 它是 btcutil.Address 接口的一部分。
+func (a *WitnessAddress) ScriptAddress() []byte {
+	return a.program
+}
+
+// IsForNet 返回地址是否属于 params 网络。 它是 btcutil.Address 接口的一部分。
+func (a *WitnessAddress) IsForNet(params *chaincfg.Params) bool {
+	return a.hrp == params.Bech32HRPSegwit
+}
+
+// String 返回地址的字符串编码。 它是 btcutil.Address 接口的一部分。
+func (a *WitnessAddress) String() string {
+	return a.EncodeAddress()
+}
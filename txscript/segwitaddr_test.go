@@ -0,0 +1,160 @@
+package txscript
+
+import (
+	"encoding/hex"
+	"strings"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/stretchr/testify/require"
+)
+
+// TestSegwitAddressVectors 使用 BIP0350 的测试向量确保地址和公钥脚本之间能够正确转换，并且拒绝使用错误校验和变体的地址。
+func TestSegwitAddressVectors(t *testing.T) {
+	t.Parallel()
+
+	valid := []struct {
+		hrp      string
+		addr     string
+		pkScript string
+	}{
+		{"bc", "BC1QW508D6QEJXTDG4Y5R3ZARVARY0C5XW7KV8F3T4",
+			"0014751e76e8199196d454941c45d1b3a323f1433bd6"},
+		{"bc", "bc1pw508d6qejxtdg4y5r3zarvary0c5xw7kw508d6qejxtdg4y5r3" +
+			"zarvary0c5xw7kt5nd6y",
+			"5128751e76e8199196d454941c45d1b3a323f1433bd6751e76e8199196" +
+				"d454941c45d1b3a323f1433bd6"},
+		{"bc", "BC1SW50QGDZ25J", "6002751e"},
+		{"bc", "bc1zw508d6qejxtdg4y5r3zarvaryvaxxpcs",
+			"5210751e76e8199196d454941c45d1b3a323"},
+		{"tb", "tb1pqqqqp399et2xygdj5xreqhjjvcmzhxw4aywxecjdzew6hylgvse" +
+			"sf3hn0c",
+			"5120000000c4a5cad46221b2a187905e5266362b99d5e91c6ce24d165d" +
+				"ab93e86433"},
+		{"bc", "bc1p0xlxvlhemja6c4dqv22uapctqupfhlxm9h8z3k2e72q4k9hcz7v" +
+			"qzk5jj0",
+			"512079be667ef9dcbbac55a06295ce870b07029bfcdb2dce28d959f281" +
+				"5b16f81798"},
+	}
+	for _, test := range valid {
+		want, err := hex.DecodeString(test.pkScript)
+		require.NoError(t, err)
+
+		pkScript, err := SegwitAddressToPkScript(test.addr, test.hrp)
+		require.NoError(t, err, test.addr)
+		require.Equal(t, want, pkScript, test.addr)
+
+		addr, err := PkScriptToSegwitAddress(pkScript, test.hrp)
+		require.NoError(t, err, test.addr)
+		require.Equal(t, strings.ToLower(test.addr), addr)
+	}
+
+	invalid := []struct {
+		hrp  string
+		addr string
+	}{
+		// 版本 1 使用了 bech32 校验和。
+		{"bc", "bc1p0xlxvlhemja6c4dqv22uapctqupfhlxm9h8z3k2e72q4k9hcz7v" +
+			"qh2y7hd"},
+		// 版本 16 使用了 bech32 校验和。
+		{"bc", "BC1S0XLXVLHEMJA6C4DQV22UAPCTQUPFHLXM9H8Z3K2E72Q4K9HCZ7V" +
+			"Q54WELL"},
+		// 版本 0 使用了 bech32m 校验和。
+		{"bc", "bc1qw508d6qejxtdg4y5r3zarvary0c5xw7kemeawh"},
+		{"tb", "tb1q0xlxvlhemja6c4dqv22uapctqupfhlxm9h8z3k2e72q4k9hcz7v" +
+			"q24jc47"},
+		// 版本 0 的程序长度无效。
+		{"bc", "BC1QR508D6QEJXTDG4Y5R3ZARVARYV98GJ9P"},
+		// HRP 不匹配。
+		{"tb", "BC1QW508D6QEJXTDG4Y5R3ZARVARY0C5XW7KV8F3T4"},
+	}
+	for _, test := range invalid {
+		_, err := SegwitAddressToPkScript(test.addr, test.hrp)
+		require.ErrorIs(t, err, ErrInvalidSegwitAddress, test.addr)
+	}
+}
+
+// TestSegwitAddressCustomHRP 确保编码器可以使用非比特币的 HRP，并拒绝非见证程序脚本。
+func TestSegwitAddressCustomHRP(t *testing.T) {
+	t.Parallel()
+
+	const hrp = "bpfs"
+	for _, pkScript := range [][]byte{
+		append([]byte{OP_0, OP_DATA_20}, make([]byte, 20)...),
+		append([]byte{OP_0, OP_DATA_32}, make([]byte, 32)...),
+		append([]byte{OP_1, OP_DATA_32}, make([]byte, 32)...),
+	} {
+		addr, err := PkScriptToSegwitAddress(pkScript, hrp)
+		require.NoError(t, err)
+		require.True(t, strings.HasPrefix(addr, hrp+"1"))
+
+		decoded, err := SegwitAddressToPkScript(addr, strings.ToUpper(hrp))
+		require.NoError(t, err)
+		require.Equal(t, pkScript, decoded)
+	}
+
+	p2pkh := mustParseShortForm("DUP HASH160 DATA_20 0x" +
+		"433ec2ac1ffa1b7b7d027f564529c57197f9ae88 EQUALVERIFY CHECKSIG")
+	_, err := PkScriptToSegwitAddress(p2pkh, hrp)
+	require.ErrorIs(t, err, ErrInvalidSegwitAddress)
+
+	_, err = EncodeSegwitAddress(hrp, 0, make([]byte, 25))
+	require.ErrorIs(t, err, ErrInvalidSegwitAddress)
+	_, err = EncodeSegwitAddress(hrp, 17, make([]byte, 32))
+	require.ErrorIs(t, err, ErrInvalidSegwitAddress)
+}
+
+// TestWitnessAddressFutureVersions 确保未来见证版本的输出可以通过 WitnessAddress 构建，并被分类为 WitnessUnknownTy 且提取出版本和程序。
+func TestWitnessAddressFutureVersions(t *testing.T) {
+	t.Parallel()
+
+	params := &chaincfg.MainNetParams
+	program := []byte{0x75, 0x1e}
+	addr, err := NewWitnessAddress(16, program, params)
+	require.NoError(t, err)
+	require.Equal(t, "bc1sw50qgdz25j", addr.EncodeAddress())
+	require.Equal(t, addr.EncodeAddress(), addr.String())
+	require.True(t, addr.IsForNet(params))
+	require.False(t, addr.IsForNet(&chaincfg.TestNet3Params))
+
+	pkScript, err := PayToAddrScript(addr)
+	require.NoError(t, err)
+	require.Equal(t, []byte{OP_16, OP_DATA_2, 0x75, 0x1e}, pkScript)
+	require.Equal(t, WitnessUnknownTy, GetScriptClass(pkScript))
+
+	class, addrs, reqSigs, err := ExtractPkScriptAddrs(pkScript, params)
+	require.NoError(t, err)
+	require.Equal(t, WitnessUnknownTy, class)
+	require.Equal(t, 0, reqSigs)
+	require.Len(t, addrs, 1)
+	extracted, ok := addrs[0].(*WitnessAddress)
+	require.True(t, ok)
+	require.Equal(t, 16, extracted.Version())
+	require.Equal(t, program, extracted.Program())
+
+	decoded, err := DecodeWitnessAddress("BC1SW50QGDZ25J", params)
+	require.NoError(t, err)
+	require.Equal(t, addr, decoded)
+
+	// 非 32 字节的版本 1 程序也属于未知版本，而 taproot 和版本 0 程序保持原有类别。
+	v1Short, err := PayToWitnessProgramScript(1, program)
+	require.NoError(t, err)
+	require.Equal(t, WitnessUnknownTy, GetScriptClass(v1Short))
+
+	var key [32]byte
+	taproot, err := PayToWitnessProgramScript(1, key[:])
+	require.NoError(t, err)
+	require.Equal(t, WitnessV1TaprootTy, GetScriptClass(taproot))
+	_, addrs, _, err = ExtractPkScriptAddrs(taproot, params)
+	require.NoError(t, err)
+	require.IsType(t, &btcutil.AddressTaproot{}, addrs[0])
+
+	invalidV0 := []byte{OP_0, OP_DATA_2, 0x75, 0x1e}
+	require.Equal(t, NonStandardTy, GetScriptClass(invalidV0))
+
+	_, err = NewWitnessAddress(17, program, params)
+	require.ErrorIs(t, err, ErrInvalidSegwitAddress)
+	_, err = PayToWitnessProgramScript(0, program)
+	require.ErrorIs(t, err, ErrInvalidSegwitAddress)
+}
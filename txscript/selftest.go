@@ -0,0 +1,242 @@
+// 包含启动自检，使用嵌入的共识关键测试向量（脚本数字编码、签名哈希、taproot 调整和哈希操作码）检查本包的实现，
+// 使节点在加入共识之前发现错误编译或平台差异导致的分歧。
+
+package txscript
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/btcsuite/btcd/btcec/v2/schnorr"
+	"github.com/btcsuite/btcd/wire"
+)
+
+// ErrSelfTestFailed 在 SelfTest 的某个测试向量的结果与预期不符时返回。
+var ErrSelfTestFailed = errors.New("script self-test failed")
+
+// selfTestVectors 是 SelfTest 使用的测试向量。 签名哈希向量取自 Bitcoin Core 的 sighash.json 和 BIP0143，
+// taproot 调整向量取自 BIP0341，哈希摘要由独立的实现计算。 所有摘要都按字节顺序而不是显示顺序编码。
+//
+//go:embed data/selftest.json
+var selfTestVectors []byte
+
+// selfTestScriptNum 是脚本数字的解码和编码向量。 Error 不为空时，Hex 必须以该错误码被拒绝。
+type selfTestScriptNum struct {
+	Hex    string `json:"hex"`
+	Value  int64  `json:"value"`
+	MaxLen int    `json:"max_len"`
+	Error  string `json:"error"`
+}
+
+// selfTestSigHash 是签名哈希向量，Amount 只用于见证版本 0 签名哈希。
+type selfTestSigHash struct {
+	Tx       string `json:"tx"`
+	Script   string `json:"script"`
+	Input    int    `json:"input"`
+	Amount   int64  `json:"amount"`
+	HashType uint32 `json:"hash_type"`
+	SigHash  string `json:"sighash"`
+}
+
+// selfTestTaprootTweak 是 taproot 输出密钥调整向量，MerkleRoot 为空表示没有脚本树。
+type selfTestTaprootTweak struct {
+	InternalKey string `json:"internal_key"`
+	MerkleRoot  string `json:"merkle_root"`
+	OutputKey   string `json:"output_key"`
+}
+
+// selfTestHash 是哈希操作码向量。
+type selfTestHash struct {
+	Op     string `json:"op"`
+	Input  string `json:"input"`
+	Digest string `json:"digest"`
+}
+
+// selfTestFile 是嵌入的测试向量文件的结构。
+type selfTestFile struct {
+	ScriptNum        []selfTestScriptNum    `json:"scriptnum"`
+	LegacySigHash    []selfTestSigHash      `json:"legacy_sighash"`
+	WitnessV0SigHash []selfTestSigHash      `json:"witness_v0_sighash"`
+	TaprootTweak     []selfTestTaprootTweak `json:"taproot_tweak"`
+	Hashes           []selfTestHash         `json:"hashes"`
+}
+
+// SelfTest 执行嵌入的共识关键测试向量，任何结果与预期不符时返回包装了 ErrSelfTestFailed 的错误，描述第一个失败的向量。
+// 节点应当在启动时、验证任何区块之前调用它。
+func SelfTest() error {
+	var vectors selfTestFile
+	if err := json.Unmarshal(selfTestVectors, &vectors); err != nil {
+		return fmt.Errorf("%w: unable to decode vectors: %v",
+			ErrSelfTestFailed, err)
+	}
+
+	checks := []struct {
+		name  string
+		count int
+		check func(int) error
+	}{
+		{"scriptnum", len(vectors.ScriptNum), func(i int) error {
+			return selfTestCheckScriptNum(&vectors.ScriptNum[i])
+		}},
+		{"legacy sighash", len(vectors.LegacySigHash), func(i int) error {
+			return selfTestCheckSigHash(&vectors.LegacySigHash[i], false)
+		}},
+		{"witness v0 sighash", len(vectors.WitnessV0SigHash), func(i int) error {
+			return selfTestCheckSigHash(&vectors.WitnessV0SigHash[i], true)
+		}},
+		{"taproot tweak", len(vectors.TaprootTweak), func(i int) error {
+			return selfTestCheckTaprootTweak(&vectors.TaprootTweak[i])
+		}},
+		{"hash", len(vectors.Hashes), func(i int) error {
+			return selfTestCheckHash(&vectors.Hashes[i])
+		}},
+	}
+	for _, c := range checks {
+		if c.count == 0 {
+			return fmt.Errorf("%w: no %s vectors", ErrSelfTestFailed,
+				c.name)
+		}
+		for i := 0; i < c.count; i++ {
+			if err := c.check(i); err != nil {
+				return fmt.Errorf("%w: %s vector %d: %v",
+					ErrSelfTestFailed, c.name, i, err)
+			}
+		}
+	}
+	return nil
+}
+
+// selfTestCheckScriptNum 检查脚本数字向量的最小编码解码结果，以及成功解码的值能否编码回相同的字节。
+func selfTestCheckScriptNum(v *selfTestScriptNum) error {
+	encoded, err := hex.DecodeString(v.Hex)
+	if err != nil {
+		return err
+	}
+
+	n, err := MakeScriptNum(encoded, true, v.MaxLen)
+	if v.Error != "" {
+		var scriptErr Error
+		if !errors.As(err, &scriptErr) ||
+			scriptErr.ErrorCode.String() != v.Error {
+
+			return fmt.Errorf("decoding %x: got error %v, want %s",
+				encoded, err, v.Error)
+		}
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("decoding %x: %v", encoded, err)
+	}
+	if int64(n) != v.Value {
+		return fmt.Errorf("decoding %x: got %d, want %d", encoded,
+			int64(n), v.Value)
+	}
+	if got := ScriptNum(v.Value).Bytes(); !bytes.Equal(got, encoded) {
+		return fmt.Errorf("encoding %d: got %x, want %x", v.Value, got,
+			encoded)
+	}
+	return nil
+}
+
+// selfTestCheckSigHash 检查传统或见证版本 0 签名哈希向量。
+func selfTestCheckSigHash(v *selfTestSigHash, witness bool) error {
+	rawTx, err := hex.DecodeString(v.Tx)
+	if err != nil {
+		return err
+	}
+	var tx wire.MsgTx
+	if err := tx.Deserialize(bytes.NewReader(rawTx)); err != nil {
+		return err
+	}
+	script, err := hex.DecodeString(v.Script)
+	if err != nil {
+		return err
+	}
+	want, err := hex.DecodeString(v.SigHash)
+	if err != nil {
+		return err
+	}
+
+	var got []byte
+	hashType := SigHashType(v.HashType)
+	if witness {
+		sigHashes := NewTxSigHashes(&tx, NewCannedPrevOutputFetcher(nil, 0))
+		got, err = CalcWitnessSigHash(
+			script, sigHashes, hashType, &tx, v.Input, v.Amount,
+		)
+	} else {
+		got, err = CalcSignatureHash(script, hashType, &tx, v.Input)
+	}
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(got, want) {
+		return fmt.Errorf("got sighash %x, want %x", got, want)
+	}
+	return nil
+}
+
+// selfTestCheckTaprootTweak 检查 taproot 输出密钥调整向量。
+func selfTestCheckTaprootTweak(v *selfTestTaprootTweak) error {
+	rawKey, err := hex.DecodeString(v.InternalKey)
+	if err != nil {
+		return err
+	}
+	internalKey, err := schnorr.ParsePubKey(rawKey)
+	if err != nil {
+		return err
+	}
+	merkleRoot, err := hex.DecodeString(v.MerkleRoot)
+	if err != nil {
+		return err
+	}
+	want, err := hex.DecodeString(v.OutputKey)
+	if err != nil {
+		return err
+	}
+
+	outputKey := ComputeTaprootOutputKey(internalKey, merkleRoot)
+	if got := schnorr.SerializePubKey(outputKey); !bytes.Equal(got, want) {
+		return fmt.Errorf("got output key %x, want %x", got, want)
+	}
+	return nil
+}
+
+// selfTestCheckHash 通过脚本引擎执行 <input> <op> <digest> OP_EQUAL，检查哈希操作码向量。
+func selfTestCheckHash(v *selfTestHash) error {
+	op, ok := OpcodeByName[v.Op]
+	if !ok {
+		return fmt.Errorf("unknown opcode %s", v.Op)
+	}
+	input, err := hex.DecodeString(v.Input)
+	if err != nil {
+		return err
+	}
+	digest, err := hex.DecodeString(v.Digest)
+	if err != nil {
+		return err
+	}
+
+	script, err := NewScriptBuilder().AddData(input).AddOp(op).
+		AddData(digest).AddOp(OP_EQUAL).Script()
+	if err != nil {
+		return err
+	}
+
+	tx := wire.NewMsgTx(1)
+	tx.AddTxIn(wire.NewTxIn(&wire.OutPoint{}, nil, nil))
+	vm, err := NewEngine(
+		script, tx, 0, 0, nil, nil, 0, NewCannedPrevOutputFetcher(nil, 0),
+	)
+	if err != nil {
+		return err
+	}
+	if err := vm.Execute(); err != nil {
+		return fmt.Errorf("%s of %x: %v", v.Op, input, err)
+	}
+	return nil
+}
@@ -0,0 +1,78 @@
+package txscript
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+// TestSelfTest 确保嵌入的测试向量全部通过。
+func TestSelfTest(t *testing.T) {
+	t.Parallel()
+
+	if err := SelfTest(); err != nil {
+		t.Fatalf("self-test failed: %v", err)
+	}
+}
+
+// TestSelfTestDetectsDivergence 确保任何一类测试向量与实现不符时 SelfTest 都会失败。
+func TestSelfTestDetectsDivergence(t *testing.T) {
+	var vectors selfTestFile
+	if err := json.Unmarshal(selfTestVectors, &vectors); err != nil {
+		t.Fatalf("unable to decode vectors: %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		mutate func(*selfTestFile)
+	}{
+		{"scriptnum value", func(v *selfTestFile) {
+			v.ScriptNum[1].Value++
+		}},
+		{"scriptnum error", func(v *selfTestFile) {
+			v.ScriptNum[len(v.ScriptNum)-1].Error = "ErrMinimalData"
+		}},
+		{"legacy sighash", func(v *selfTestFile) {
+			v.LegacySigHash[0].HashType = uint32(SigHashAll)
+		}},
+		{"witness v0 sighash", func(v *selfTestFile) {
+			v.WitnessV0SigHash[0].Amount++
+		}},
+		{"taproot tweak", func(v *selfTestFile) {
+			v.TaprootTweak[0].MerkleRoot = v.TaprootTweak[1].MerkleRoot
+		}},
+		{"hash", func(v *selfTestFile) {
+			v.Hashes[0].Op = "OP_HASH256"
+		}},
+		{"missing category", func(v *selfTestFile) {
+			v.Hashes = nil
+		}},
+	}
+
+	// The tests swap the package-level vectors, so they can't run in
+	// parallel with TestSelfTest or each other.
+	saved := selfTestVectors
+	defer func() { selfTestVectors = saved }()
+	for _, test := range tests {
+		mutated := vectors
+		mutated.ScriptNum = append([]selfTestScriptNum(nil),
+			vectors.ScriptNum...)
+		mutated.LegacySigHash = append([]selfTestSigHash(nil),
+			vectors.LegacySigHash...)
+		mutated.WitnessV0SigHash = append([]selfTestSigHash(nil),
+			vectors.WitnessV0SigHash...)
+		mutated.TaprootTweak = append([]selfTestTaprootTweak(nil),
+			vectors.TaprootTweak...)
+		mutated.Hashes = append([]selfTestHash(nil), vectors.Hashes...)
+		test.mutate(&mutated)
+
+		data, err := json.Marshal(mutated)
+		if err != nil {
+			t.Fatalf("%s: unable to encode vectors: %v", test.name, err)
+		}
+		selfTestVectors = data
+		if err := SelfTest(); !errors.Is(err, ErrSelfTestFailed) {
+			t.Errorf("%s: got %v, want ErrSelfTestFailed", test.name, err)
+		}
+	}
+}
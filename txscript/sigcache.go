@@ -4,20 +4,91 @@ package txscript
 
 import (
 	"bytes"
+	"crypto/rand"
+	"encoding/binary"
+	"math/bits"
 	"sync"
+	"sync/atomic"
 
 	"github.com/btcsuite/btcd/chaincfg/chainhash"
 )
 
+// SigType identifies the signature scheme of a SigCache entry. Entries of
+// different types live in separate namespaces, so an ECDSA verification can
+// never satisfy a lookup for a Schnorr signature and vice versa.
+type SigType uint8
+
+const (
+	// SigTypeECDSA is an ECDSA signature checked by the legacy and segwit
+	// v0 signature opcodes.
+	SigTypeECDSA SigType = iota
+
+	// SigTypeSchnorr is a BIP 340 Schnorr signature checked by a taproot
+	// key spend or a tapscript signature opcode.
+	SigTypeSchnorr
+
+	// numSigTypes is the number of signature types.
+	numSigTypes
+)
+
+// String returns the name of the signature type.
+func (t SigType) String() string {
+	switch t {
+	case SigTypeECDSA:
+		return "ecdsa"
+	case SigTypeSchnorr:
+		return "schnorr"
+	default:
+		return "unknown"
+	}
+}
+
 // sigCacheEntry represents an entry in the SigCache. Entries within the
-// SigCache are keyed according to the sigHash of the signature. In the
-// scenario of a cache-hit (according to the sigHash), an additional comparison
-// of the signature, and public key will be executed in order to ensure a complete
-// match. In the occasion that two sigHashes collide, the newer sigHash will
-// simply overwrite the existing entry.
+// SigCache are keyed by a salted SipHash of the signature type, sigHash,
+// signature and public key. In the scenario of a cache-hit, the full entry is
+// compared to ensure a complete match, so an entry is only ever shadowed by
+// another one whose key collides under the secret salt, which an attacker
+// can't predict.
 type sigCacheEntry struct {
-	sig    []byte
-	pubKey []byte
+	sigType SigType
+	sigHash chainhash.Hash
+	sig     []byte
+	pubKey  []byte
+}
+
+// matches returns whether the entry was added for the passed values.
+func (e *sigCacheEntry) matches(sigType SigType, sigHash *chainhash.Hash,
+	sig, pubKey []byte) bool {
+
+	return e.sigType == sigType && e.sigHash == *sigHash &&
+		bytes.Equal(e.sig, sig) && bytes.Equal(e.pubKey, pubKey)
+}
+
+// SigCacheStats is a snapshot of the counters of a single signature type
+// namespace within the SigCache.
+type SigCacheStats struct {
+	// Entries is the number of entries of the type currently cached.
+	Entries int
+
+	// Hits and Misses count the lookups that found or didn't find a
+	// matching entry.
+	Hits   uint64
+	Misses uint64
+
+	// Adds counts the entries added, and Evictions the entries of the type
+	// that were evicted to make room for new ones.
+	Adds      uint64
+	Evictions uint64
+}
+
+// sigCacheCounters holds the counters of a signature type namespace. Lookups
+// happen under the read lock, so the hit and miss counters are atomic.
+type sigCacheCounters struct {
+	entries   int
+	hits      atomic.Uint64
+	misses    atomic.Uint64
+	adds      uint64
+	evictions uint64
 }
 
 // SigCache implements an Schnorr+ECDSA signature verification cache with a
@@ -32,11 +103,19 @@ type sigCacheEntry struct {
 // optimization which speeds up the validation of transactions within a block,
 // if they've already been seen and verified within the mempool.
 //
+// Entries are keyed by a SipHash-2-4 of the complete (type, sigHash,
+// signature, public key) tuple under a key chosen randomly when the cache is
+// created. Keying on the whole tuple lets distinct signatures over the same
+// sigHash, such as those of a multisig input, coexist, and the secret key
+// prevents an attacker from crafting entries that evict each other.
+//
 // TODO(roasbeef): use type params here after Go 1.18
 type SigCache struct {
 	sync.RWMutex
-	validSigs  map[chainhash.Hash]sigCacheEntry
+	k0, k1     uint64
+	validSigs  map[uint64]sigCacheEntry
 	maxEntries uint
+	counters   [numSigTypes]sigCacheCounters
 }
 
 // NewSigCache creates and initializes a new instance of SigCache. Its sole
@@ -45,33 +124,100 @@ type SigCache struct {
 // to make room for new entries that would cause the number of entries in the
 // cache to exceed the max.
 func NewSigCache(maxEntries uint) *SigCache {
-	return &SigCache{
-		validSigs:  make(map[chainhash.Hash]sigCacheEntry, maxEntries),
+	s := &SigCache{
+		validSigs:  make(map[uint64]sigCacheEntry, maxEntries),
 		maxEntries: maxEntries,
 	}
+
+	// A predictable key only weakens the cache against crafted collisions,
+	// and a full entry comparison guards every hit, so a failing random
+	// source is not fatal.
+	var key [16]byte
+	_, _ = rand.Read(key[:])
+	s.k0 = binary.LittleEndian.Uint64(key[:8])
+	s.k1 = binary.LittleEndian.Uint64(key[8:])
+	return s
+}
+
+// key returns the cache key of the passed entry values.
+func (s *SigCache) key(sigType SigType, sigHash *chainhash.Hash, sig,
+	pubKey []byte) uint64 {
+
+	// The signature length is included so that the boundary between the
+	// signature and the public key is unambiguous. A fixed buffer avoids
+	// an allocation for every standard signature and key.
+	var buf [1 + chainhash.HashSize + 1 + 80 + 65]byte
+	msg := append(buf[:0], byte(sigType))
+	msg = append(msg, sigHash[:]...)
+	msg = append(msg, byte(len(sig)))
+	msg = append(msg, sig...)
+	msg = append(msg, pubKey...)
+	return sipHash24(s.k0, s.k1, msg)
 }
 
-// Exists returns true if an existing entry of 'sig' over 'sigHash' for public
-// key 'pubKey' is found within the SigCache. Otherwise, false is returned.
+// Exists returns true if an existing entry of the ECDSA signature 'sig' over
+// 'sigHash' for public key 'pubKey' is found within the SigCache. Otherwise,
+// false is returned.
 //
 // NOTE: This function is safe for concurrent access. Readers won't be blocked
 // unless there exists a writer, adding an entry to the SigCache.
 func (s *SigCache) Exists(sigHash chainhash.Hash, sig []byte, pubKey []byte) bool {
+	return s.ExistsWithType(SigTypeECDSA, sigHash, sig, pubKey)
+}
+
+// ExistsWithType returns true if an existing entry of the signature 'sig' of
+// the passed type over 'sigHash' for public key 'pubKey' is found within the
+// SigCache. Otherwise, false is returned.
+//
+// NOTE: This function is safe for concurrent access. Readers won't be blocked
+// unless there exists a writer, adding an entry to the SigCache.
+func (s *SigCache) ExistsWithType(sigType SigType, sigHash chainhash.Hash,
+	sig []byte, pubKey []byte) bool {
+
+	if sigType >= numSigTypes {
+		return false
+	}
+	key := s.key(sigType, &sigHash, sig, pubKey)
+
 	s.RLock()
-	entry, ok := s.validSigs[sigHash]
+	entry, ok := s.validSigs[key]
 	s.RUnlock()
 
-	return ok && bytes.Equal(entry.pubKey, pubKey) && bytes.Equal(entry.sig, sig)
+	found := ok && entry.matches(sigType, &sigHash, sig, pubKey)
+	if found {
+		s.counters[sigType].hits.Add(1)
+	} else {
+		s.counters[sigType].misses.Add(1)
+	}
+	return found
 }
 
-// Add adds an entry for a signature over 'sigHash' under public key 'pubKey'
-// to the signature cache. In the event that the SigCache is 'full', an
-// existing entry is randomly chosen to be evicted in order to make space for
-// the new entry.
+// Add adds an entry for an ECDSA signature over 'sigHash' under public key
+// 'pubKey' to the signature cache. In the event that the SigCache is 'full',
+// an existing entry is randomly chosen to be evicted in order to make space
+// for the new entry.
 //
 // NOTE: This function is safe for concurrent access. Writers will block
 // simultaneous readers until function execution has concluded.
 func (s *SigCache) Add(sigHash chainhash.Hash, sig []byte, pubKey []byte) {
+	s.AddWithType(SigTypeECDSA, sigHash, sig, pubKey)
+}
+
+// AddWithType adds an entry for a signature of the passed type over 'sigHash'
+// under public key 'pubKey' to the signature cache. In the event that the
+// SigCache is 'full', an existing entry is randomly chosen to be evicted in
+// order to make space for the new entry.
+//
+// NOTE: This function is safe for concurrent access. Writers will block
+// simultaneous readers until function execution has concluded.
+func (s *SigCache) AddWithType(sigType SigType, sigHash chainhash.Hash,
+	sig []byte, pubKey []byte) {
+
+	if sigType >= numSigTypes {
+		return
+	}
+	key := s.key(sigType, &sigHash, sig, pubKey)
+
 	s.Lock()
 	defer s.Unlock()
 
@@ -79,22 +225,110 @@ func (s *SigCache) Add(sigHash chainhash.Hash, sig []byte, pubKey []byte) {
 		return
 	}
 
-	// If adding this new entry will put us over the max number of allowed
-	// entries, then evict an entry.
-	if uint(len(s.validSigs)+1) > s.maxEntries {
+	// Replacing an entry under the same key doesn't grow the cache.
+	if old, ok := s.validSigs[key]; ok {
+		s.counters[old.sigType].entries--
+	} else if uint(len(s.validSigs)+1) > s.maxEntries {
+		// If adding this new entry will put us over the max number of
+		// allowed entries, then evict an entry.
+		//
 		// Remove a random entry from the map. Relying on the random
 		// starting point of Go's map iteration. It's worth noting that
 		// the random iteration starting point is not 100% guaranteed
 		// by the spec, however most Go compilers support it.
 		// Ultimately, the iteration order isn't important here because
 		// in order to manipulate which items are evicted, an adversary
-		// would need to be able to execute preimage attacks on the
-		// hashing function in order to start eviction at a specific
-		// entry.
-		for sigEntry := range s.validSigs {
-			delete(s.validSigs, sigEntry)
+		// would need to be able to predict the keyed hash of the
+		// entries.
+		for evictKey, evicted := range s.validSigs {
+			delete(s.validSigs, evictKey)
+			s.counters[evicted.sigType].entries--
+			s.counters[evicted.sigType].evictions++
 			break
 		}
 	}
-	s.validSigs[sigHash] = sigCacheEntry{sig, pubKey}
+
+	// Copy the signature and public key since they may refer to script or
+	// stack memory which is reused once script execution completes.
+	s.validSigs[key] = sigCacheEntry{
+		sigType: sigType,
+		sigHash: sigHash,
+		sig:     append([]byte(nil), sig...),
+		pubKey:  append([]byte(nil), pubKey...),
+	}
+	s.counters[sigType].entries++
+	s.counters[sigType].adds++
+}
+
+// Stats returns a snapshot of the counters of the passed signature type.
+func (s *SigCache) Stats(sigType SigType) SigCacheStats {
+	if sigType >= numSigTypes {
+		return SigCacheStats{}
+	}
+
+	s.RLock()
+	defer s.RUnlock()
+
+	c := &s.counters[sigType]
+	return SigCacheStats{
+		Entries:   c.entries,
+		Hits:      c.hits.Load(),
+		Misses:    c.misses.Load(),
+		Adds:      c.adds,
+		Evictions: c.evictions,
+	}
+}
+
+// sipHash24 returns the SipHash-2-4 of the passed message under the 128-bit
+// key (k0, k1).
+func sipHash24(k0, k1 uint64, msg []byte) uint64 {
+	v0 := k0 ^ 0x736f6d6570736575
+	v1 := k1 ^ 0x646f72616e646f6d
+	v2 := k0 ^ 0x6c7967656e657261
+	v3 := k1 ^ 0x7465646279746573
+
+	round := func() {
+		v0 += v1
+		v1 = bits.RotateLeft64(v1, 13)
+		v1 ^= v0
+		v0 = bits.RotateLeft64(v0, 32)
+		v2 += v3
+		v3 = bits.RotateLeft64(v3, 16)
+		v3 ^= v2
+		v0 += v3
+		v3 = bits.RotateLeft64(v3, 21)
+		v3 ^= v0
+		v2 += v1
+		v1 = bits.RotateLeft64(v1, 17)
+		v1 ^= v2
+		v2 = bits.RotateLeft64(v2, 32)
+	}
+
+	// Compress every full 8-byte block of the message.
+	length := len(msg)
+	for ; len(msg) >= 8; msg = msg[8:] {
+		m := binary.LittleEndian.Uint64(msg)
+		v3 ^= m
+		round()
+		round()
+		v0 ^= m
+	}
+
+	// The final block holds the remaining bytes and the message length
+	// modulo 256 in its most significant byte.
+	var last [8]byte
+	copy(last[:], msg)
+	last[7] = byte(length)
+	m := binary.LittleEndian.Uint64(last[:])
+	v3 ^= m
+	round()
+	round()
+	v0 ^= m
+
+	v2 ^= 0xff
+	round()
+	round()
+	round()
+	round()
+	return v0 ^ v1 ^ v2 ^ v3
 }
@@ -9,6 +9,7 @@ import (
 	"github.com/btcsuite/btcd/btcec/v2"
 	"github.com/btcsuite/btcd/btcec/v2/ecdsa"
 	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/wire"
 )
 
 // genRandomSig 返回一条随机消息，该消息在公钥和公钥下的签名。 该函数用于生成随机测试数据。
@@ -133,3 +134,139 @@ func TestSigCacheAddMaxEntriesZeroOrNegative(t *testing.T) {
 			"been added", len(sigCache.validSigs))
 	}
 }
+
+// TestSipHash24 确保 SipHash-2-4 的实现与参考实现的测试向量一致。
+func TestSipHash24(t *testing.T) {
+	const k0, k1 = 0x0706050403020100, 0x0f0e0d0c0b0a0908
+	msg := make([]byte, 15)
+	for i := range msg {
+		msg[i] = byte(i)
+	}
+
+	tests := []struct {
+		msgLen int
+		want   uint64
+	}{
+		{0, 0x726fdb47dd0e0e31},
+		{8, 0x93f5f5799a932462},
+		{15, 0xa129ca6149be45e5},
+	}
+	for _, test := range tests {
+		got := sipHash24(k0, k1, msg[:test.msgLen])
+		if got != test.want {
+			t.Errorf("siphash of %d bytes: got %x, want %x",
+				test.msgLen, got, test.want)
+		}
+	}
+}
+
+// TestSigCacheNamespaces 确保同一签名哈希下的不同签名可以共存，不同签名类型的条目互不影响，并且每种类型分别统计。
+func TestSigCacheNamespaces(t *testing.T) {
+	sigCache := NewSigCache(10)
+
+	msg, sig1, key1, err := genRandomSig()
+	if err != nil {
+		t.Fatalf("unable to generate random signature test data")
+	}
+	_, sig2, key2, err := genRandomSig()
+	if err != nil {
+		t.Fatalf("unable to generate random signature test data")
+	}
+
+	// 多重签名输入的所有签名都使用同一个签名哈希。
+	sigCache.Add(*msg, sig1.Serialize(), key1.SerializeCompressed())
+	sigCache.Add(*msg, sig2.Serialize(), key2.SerializeCompressed())
+	if !sigCache.Exists(*msg, sig1.Serialize(), key1.SerializeCompressed()) ||
+		!sigCache.Exists(*msg, sig2.Serialize(), key2.SerializeCompressed()) {
+
+		t.Fatalf("signatures over the same sighash evicted each other")
+	}
+	if sigCache.Exists(*msg, sig1.Serialize(), key2.SerializeCompressed()) {
+		t.Fatalf("mismatched signature and key found in cache")
+	}
+
+	// ECDSA 条目不满足 Schnorr 查询。
+	if sigCache.ExistsWithType(SigTypeSchnorr, *msg, sig1.Serialize(),
+		key1.SerializeCompressed()) {
+
+		t.Fatalf("ECDSA entry found in Schnorr namespace")
+	}
+	sigCache.AddWithType(SigTypeSchnorr, *msg, sig1.Serialize(),
+		key1.SerializeCompressed())
+	if !sigCache.ExistsWithType(SigTypeSchnorr, *msg, sig1.Serialize(),
+		key1.SerializeCompressed()) {
+
+		t.Fatalf("Schnorr entry not found")
+	}
+
+	// 再次添加相同的条目不会增加条目数。
+	sigCache.Add(*msg, sig1.Serialize(), key1.SerializeCompressed())
+
+	want := map[SigType]SigCacheStats{
+		SigTypeECDSA:   {Entries: 2, Hits: 2, Misses: 1, Adds: 3},
+		SigTypeSchnorr: {Entries: 1, Hits: 1, Misses: 1, Adds: 1},
+	}
+	for sigType, stats := range want {
+		if got := sigCache.Stats(sigType); got != stats {
+			t.Errorf("%v stats: got %+v, want %+v", sigType, got,
+				stats)
+		}
+	}
+
+	// 淘汰计入被淘汰条目的类型。
+	small := NewSigCache(1)
+	small.AddWithType(SigTypeSchnorr, *msg, sig1.Serialize(),
+		key1.SerializeCompressed())
+	small.Add(*msg, sig1.Serialize(), key1.SerializeCompressed())
+	if got := small.Stats(SigTypeSchnorr); got.Entries != 0 ||
+		got.Evictions != 1 {
+
+		t.Errorf("unexpected Schnorr stats after eviction: %+v", got)
+	}
+	if got := small.Stats(SigTypeECDSA); got.Entries != 1 ||
+		got.Evictions != 0 {
+
+		t.Errorf("unexpected ECDSA stats after eviction: %+v", got)
+	}
+}
+
+// TestSigCacheTaprootKeySpend 确保 taproot 密钥路径花费的验证结果被缓存在 Schnorr 命名空间中。
+func TestSigCacheTaprootKeySpend(t *testing.T) {
+	privKey, err := btcec.NewPrivateKey()
+	if err != nil {
+		t.Fatalf("unable to generate key: %v", err)
+	}
+	pkScript, err := PayToTaprootKeySpendOnlyScript(privKey.PubKey())
+	if err != nil {
+		t.Fatalf("unable to create pkScript: %v", err)
+	}
+
+	tx := createSpendingTx(nil, nil, pkScript, 1000)
+	prevOuts := NewCannedPrevOutputFetcher(pkScript, 1000)
+	sigHashes := NewTxSigHashes(tx, prevOuts)
+	sig, err := RawTxInTaprootSignature(
+		tx, sigHashes, 0, 1000, pkScript, nil, SigHashDefault, privKey,
+	)
+	if err != nil {
+		t.Fatalf("unable to sign: %v", err)
+	}
+	tx.TxIn[0].Witness = wire.TxWitness{sig}
+
+	sigCache := NewSigCache(10)
+	for i := 0; i < 2; i++ {
+		err := VerifyTaprootKeySpend(
+			pkScript[2:], sig, tx, 0, prevOuts, sigHashes, sigCache,
+		)
+		if err != nil {
+			t.Fatalf("unable to verify key spend: %v", err)
+		}
+	}
+
+	want := SigCacheStats{Entries: 1, Hits: 1, Misses: 1, Adds: 1}
+	if got := sigCache.Stats(SigTypeSchnorr); got != want {
+		t.Fatalf("Schnorr stats: got %+v, want %+v", got, want)
+	}
+	if got := sigCache.Stats(SigTypeECDSA); got != (SigCacheStats{}) {
+		t.Fatalf("unexpected ECDSA stats %+v", got)
+	}
+}
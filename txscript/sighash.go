@@ -37,32 +37,105 @@ const (
 	blankCodeSepValue = math.MaxUint32
 )
 
-// shallowCopyTx creates a shallow copy of the transaction for use when
-// calculating the signature hash.  It is used over the Copy method on the
-// transaction itself since that is a deep copy and therefore does more work and
-// allocates much more space than needed.
-func shallowCopyTx(tx *wire.MsgTx) wire.MsgTx {
-	// As an additional memory optimization, use contiguous backing arrays
-	// for the copied inputs and outputs and point the final slice of
-	// pointers into the contiguous arrays.  This avoids a lot of small
-	// allocations.
-	txCopy := wire.MsgTx{
-		Version:  tx.Version,
-		TxIn:     make([]*wire.TxIn, len(tx.TxIn)),
-		TxOut:    make([]*wire.TxOut, len(tx.TxOut)),
-		LockTime: tx.LockTime,
-	}
-	txIns := make([]wire.TxIn, len(tx.TxIn))
-	for i, oldTxIn := range tx.TxIn {
-		txIns[i] = *oldTxIn
-		txCopy.TxIn[i] = &txIns[i]
-	}
-	txOuts := make([]wire.TxOut, len(tx.TxOut))
-	for i, oldTxOut := range tx.TxOut {
-		txOuts[i] = *oldTxOut
-		txCopy.TxOut[i] = &txOuts[i]
-	}
-	return txCopy
+// writeLegacySigHashPreimage writes the serialization of the transaction as
+// modified by the legacy signature hash algorithm for the given hash type to w.
+// The modifications are applied while writing instead of on a copy of the
+// transaction, so the cost does not include allocating a copy of every input
+// and output for every signature checked.
+func writeLegacySigHashPreimage(w io.Writer, sigScript []byte,
+	hashType SigHashType, tx *wire.MsgTx, idx int) error {
+
+	var scratch [8]byte
+
+	binary.LittleEndian.PutUint32(scratch[:4], uint32(tx.Version))
+	if _, err := w.Write(scratch[:4]); err != nil {
+		return err
+	}
+
+	// Only the input being signed is committed to when the
+	// SigHashAnyOneCanPay flag is set. The sequence of all other inputs is
+	// zeroed out for SigHashNone and SigHashSingle, and the signature
+	// script of all other inputs is always empty.
+	anyOneCanPay := hashType&SigHashAnyOneCanPay != 0
+	baseType := hashType & sigHashMask
+	zeroSequences := baseType == SigHashNone || baseType == SigHashSingle
+
+	writeTxIn := func(i int) error {
+		txIn := tx.TxIn[i]
+		if err := wire.WriteOutPoint(w, 0, 0, &txIn.PreviousOutPoint); err != nil {
+			return err
+		}
+
+		script, sequence := sigScript, txIn.Sequence
+		if i != idx {
+			script = nil
+			if zeroSequences {
+				sequence = 0
+			}
+		}
+		if err := wire.WriteVarBytes(w, 0, script); err != nil {
+			return err
+		}
+
+		binary.LittleEndian.PutUint32(scratch[:4], sequence)
+		_, err := w.Write(scratch[:4])
+		return err
+	}
+	if anyOneCanPay {
+		if err := wire.WriteVarInt(w, 0, 1); err != nil {
+			return err
+		}
+		if err := writeTxIn(idx); err != nil {
+			return err
+		}
+	} else {
+		if err := wire.WriteVarInt(w, 0, uint64(len(tx.TxIn))); err != nil {
+			return err
+		}
+		for i := range tx.TxIn {
+			if err := writeTxIn(i); err != nil {
+				return err
+			}
+		}
+	}
+
+	// No outputs are committed to for SigHashNone, and only the outputs up
+	// to and including the one at the input's index are committed to for
+	// SigHashSingle, with all but the last one blanked out.
+	numOutputs := len(tx.TxOut)
+	switch baseType {
+	case SigHashNone:
+		numOutputs = 0
+	case SigHashSingle:
+		numOutputs = idx + 1
+	}
+	if err := wire.WriteVarInt(w, 0, uint64(numOutputs)); err != nil {
+		return err
+	}
+	for i := 0; i < numOutputs; i++ {
+		value, pkScript := tx.TxOut[i].Value, tx.TxOut[i].PkScript
+		if baseType == SigHashSingle && i != idx {
+			value, pkScript = -1, nil
+		}
+
+		binary.LittleEndian.PutUint64(scratch[:], uint64(value))
+		if _, err := w.Write(scratch[:]); err != nil {
+			return err
+		}
+		if err := wire.WriteVarBytes(w, 0, pkScript); err != nil {
+			return err
+		}
+	}
+
+	binary.LittleEndian.PutUint32(scratch[:4], tx.LockTime)
+	if _, err := w.Write(scratch[:4]); err != nil {
+		return err
+	}
+
+	// The hash type is appended as a 4-byte little-endian value.
+	binary.LittleEndian.PutUint32(scratch[:4], uint32(hashType))
+	_, err := w.Write(scratch[:4])
+	return err
 }
 
 // 给定当前脚本引擎实例的脚本和哈希类型，CalcSignatureHash 将计算用于签名和验证的签名哈希。
@@ -74,11 +147,42 @@ func CalcSignatureHash(script []byte, hashType SigHashType, tx *wire.MsgTx, idx
 		return nil, err
 	}
 
-	return calcSignatureHash(script, hashType, tx, idx), nil
+	return calcSignatureHash(script, hashType, tx, idx, BitcoinChainTag), nil
+}
+
+// CalcSignatureHashPreimage 返回 CalcSignatureHash 计算的传统签名消息，即按哈希类型修改后的交易序列化加上 4 字节的哈希类型，
+// 签名哈希是它的双 SHA256。 SigHashSingle 的输入没有对应输出时，由于共识漏洞签名哈希是常量 1 而不是任何原像的哈希，此时返回错误。
+//
+// 注意：该函数仅对0版本脚本有效。 由于该函数不接受脚本版本，因此其他脚本版本的结果未定义。
+func CalcSignatureHashPreimage(script []byte, hashType SigHashType,
+	tx *wire.MsgTx, idx int) ([]byte, error) {
+
+	const scriptVersion = 0
+	if err := checkScriptParses(scriptVersion, script); err != nil {
+		return nil, err
+	}
+	if idx < 0 || idx >= len(tx.TxIn) {
+		return nil, fmt.Errorf("%w: input index %d out of range for "+
+			"transaction with %d inputs", ErrInvalidSigHashParams, idx,
+			len(tx.TxIn))
+	}
+	if hashType&sigHashMask == SigHashSingle && idx >= len(tx.TxOut) {
+		return nil, fmt.Errorf("%w: no preimage for SigHashSingle input "+
+			"%d without a matching output", ErrInvalidSigHashParams, idx)
+	}
+
+	var b bytes.Buffer
+	script = removeOpcodeRaw(script, OP_CODESEPARATOR)
+	if err := writeLegacySigHashPreimage(&b, script, hashType, tx, idx); err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
 }
 
-// calcSignatureHash 计算观察所需签名哈希类型的目标交易的指定输入的签名哈希。
-func calcSignatureHash(sigScript []byte, hashType SigHashType, tx *wire.MsgTx, idx int) []byte {
+// calcSignatureHash 计算观察所需签名哈希类型的目标交易的指定输入的签名哈希，签名哈希提交到 chainTag。
+func calcSignatureHash(sigScript []byte, hashType SigHashType, tx *wire.MsgTx,
+	idx int, chainTag ChainTag) []byte {
+
 	// The SigHashSingle signature type signs only the corresponding input
 	// and output (the output with the same index number as the input).
 	//
@@ -102,69 +206,29 @@ func calcSignatureHash(sigScript []byte, hashType SigHashType, tx *wire.MsgTx, i
 	if hashType&sigHashMask == SigHashSingle && idx >= len(tx.TxOut) {
 		var hash chainhash.Hash
 		hash[0] = 0x01
-		return hash[:]
+		if chainTag == BitcoinChainTag {
+			return hash[:]
+		}
+
+		// A tagged chain must not share the constant with Bitcoin, so
+		// it is hashed like any other signature message.
+		h := chainTag.newSigHasher()
+		h.Write(hash[:])
+		tagged := sha256.Sum256(h.Sum(nil))
+		return tagged[:]
 	}
 
 	// Remove all instances of OP_CODESEPARATOR from the script.
 	sigScript = removeOpcodeRaw(sigScript, OP_CODESEPARATOR)
 
-	// Make a shallow copy of the transaction, zeroing out the script for
-	// all inputs that are not currently being processed.
-	txCopy := shallowCopyTx(tx)
-	for i := range txCopy.TxIn {
-		if i == idx {
-			txCopy.TxIn[idx].SignatureScript = sigScript
-		} else {
-			txCopy.TxIn[i].SignatureScript = nil
-		}
-	}
-
-	switch hashType & sigHashMask {
-	case SigHashNone:
-		txCopy.TxOut = txCopy.TxOut[0:0] // Empty slice.
-		for i := range txCopy.TxIn {
-			if i != idx {
-				txCopy.TxIn[i].Sequence = 0
-			}
-		}
-
-	case SigHashSingle:
-		// Resize output array to up to and including requested index.
-		txCopy.TxOut = txCopy.TxOut[:idx+1]
-
-		// All but current output get zeroed out.
-		for i := 0; i < idx; i++ {
-			txCopy.TxOut[i].Value = -1
-			txCopy.TxOut[i].PkScript = nil
-		}
-
-		// Sequence on all other inputs is 0, too.
-		for i := range txCopy.TxIn {
-			if i != idx {
-				txCopy.TxIn[i].Sequence = 0
-			}
-		}
-
-	default:
-		// Consensus treats undefined hashtypes like normal SigHashAll
-		// for purposes of hash generation.
-		fallthrough
-	case SigHashOld:
-		fallthrough
-	case SigHashAll:
-		// Nothing special here.
-	}
-	if hashType&SigHashAnyOneCanPay != 0 {
-		txCopy.TxIn = txCopy.TxIn[idx : idx+1]
-	}
-
 	// The final hash is the double sha256 of both the serialized modified
 	// transaction and the hash type (encoded as a 4-byte little-endian
-	// value) appended.
-	wbuf := bytes.NewBuffer(make([]byte, 0, txCopy.SerializeSizeStripped()+4))
-	txCopy.SerializeNoWitness(wbuf)
-	binary.Write(wbuf, binary.LittleEndian, hashType)
-	return chainhash.DoubleHashB(wbuf.Bytes())
+	// value) appended.  Writing to a hash never fails.
+	h := chainTag.newSigHasher()
+	_ = writeLegacySigHashPreimage(h, sigScript, hashType, tx, idx)
+	first := h.Sum(nil)
+	hash := sha256.Sum256(first)
+	return hash[:]
 }
 
 // calcWitnessSignatureHashRaw computes the sighash digest of a transaction's
@@ -179,7 +243,8 @@ func calcSignatureHash(sigScript []byte, hashType SigHashType, tx *wire.MsgTx, i
 // wallet if fed an invalid input amount, the real sighash will differ causing
 // the produced signature to be invalid.
 func calcWitnessSignatureHashRaw(subScript []byte, sigHashes *TxSigHashes,
-	hashType SigHashType, tx *wire.MsgTx, idx int, amt int64) ([]byte, error) {
+	hashType SigHashType, tx *wire.MsgTx, idx int, amt int64,
+	chainTag ChainTag) ([]byte, error) {
 
 	// As a sanity check, ensure the passed input index for the transaction
 	// is valid.
@@ -189,99 +254,127 @@ func calcWitnessSignatureHashRaw(subScript []byte, sigHashes *TxSigHashes,
 		return nil, fmt.Errorf("idx %d but %d txins", idx, len(tx.TxIn))
 	}
 
-	// We'll utilize this buffer throughout to incrementally calculate
-	// the signature hash for this transaction.
-	var sigHash bytes.Buffer
+	// The digest is the double sha256 of the preimage. Writing to a hash
+	// never fails.
+	h := chainTag.newSigHasher()
+	_ = writeWitnessV0SigHashPreimage(h, subScript, sigHashes, hashType, tx,
+		idx, amt)
+	first := h.Sum(nil)
+	hash := sha256.Sum256(first)
+	return hash[:], nil
+}
+
+// writeWitnessV0SigHashPreimage writes the BIP0143 signature message of the
+// given input to w. The caller must ensure the input index is valid.
+func writeWitnessV0SigHashPreimage(w io.Writer, subScript []byte,
+	sigHashes *TxSigHashes, hashType SigHashType, tx *wire.MsgTx, idx int,
+	amt int64) error {
+
+	var scratch [8]byte
+	var zeroHash chainhash.Hash
 
 	// First write out, then encode the transaction's version number.
-	var bVersion [4]byte
-	binary.LittleEndian.PutUint32(bVersion[:], uint32(tx.Version))
-	sigHash.Write(bVersion[:])
+	binary.LittleEndian.PutUint32(scratch[:4], uint32(tx.Version))
+	if _, err := w.Write(scratch[:4]); err != nil {
+		return err
+	}
 
 	// Next write out the possibly pre-calculated hashes for the sequence
 	// numbers of all inputs, and the hashes of the previous outs for all
 	// outputs.
-	var zeroHash chainhash.Hash
-
+	//
 	// If anyone can pay isn't active, then we can use the cached
 	// hashPrevOuts, otherwise we just write zeroes for the prev outs.
+	hashPrevOuts := zeroHash[:]
 	if hashType&SigHashAnyOneCanPay == 0 {
-		sigHash.Write(sigHashes.HashPrevOutsV0[:])
-	} else {
-		sigHash.Write(zeroHash[:])
+		hashPrevOuts = sigHashes.HashPrevOutsV0[:]
+	}
+	if _, err := w.Write(hashPrevOuts); err != nil {
+		return err
 	}
 
 	// If the sighash isn't anyone can pay, single, or none, the use the
 	// cached hash sequences, otherwise write all zeroes for the
 	// hashSequence.
+	hashSequence := zeroHash[:]
 	if hashType&SigHashAnyOneCanPay == 0 &&
 		hashType&sigHashMask != SigHashSingle &&
 		hashType&sigHashMask != SigHashNone {
-		sigHash.Write(sigHashes.HashSequenceV0[:])
-	} else {
-		sigHash.Write(zeroHash[:])
-	}
 
-	txIn := tx.TxIn[idx]
+		hashSequence = sigHashes.HashSequenceV0[:]
+	}
+	if _, err := w.Write(hashSequence); err != nil {
+		return err
+	}
 
 	// Next, write the outpoint being spent.
-	sigHash.Write(txIn.PreviousOutPoint.Hash[:])
-	var bIndex [4]byte
-	binary.LittleEndian.PutUint32(bIndex[:], txIn.PreviousOutPoint.Index)
-	sigHash.Write(bIndex[:])
+	txIn := tx.TxIn[idx]
+	if err := wire.WriteOutPoint(w, 0, 0, &txIn.PreviousOutPoint); err != nil {
+		return err
+	}
 
 	if isWitnessPubKeyHashScript(subScript) {
 		// The script code for a p2wkh is a length prefix varint for
 		// the next 25 bytes, followed by a re-creation of the original
 		// p2pkh pk script.
-		sigHash.Write([]byte{0x19})
-		sigHash.Write([]byte{OP_DUP})
-		sigHash.Write([]byte{OP_HASH160})
-		sigHash.Write([]byte{OP_DATA_20})
-		sigHash.Write(extractWitnessPubKeyHash(subScript))
-		sigHash.Write([]byte{OP_EQUALVERIFY})
-		sigHash.Write([]byte{OP_CHECKSIG})
+		scriptCode := make([]byte, 0, 26)
+		scriptCode = append(scriptCode, 0x19, OP_DUP, OP_HASH160,
+			OP_DATA_20)
+		scriptCode = append(scriptCode,
+			extractWitnessPubKeyHash(subScript)...)
+		scriptCode = append(scriptCode, OP_EQUALVERIFY, OP_CHECKSIG)
+		if _, err := w.Write(scriptCode); err != nil {
+			return err
+		}
 	} else {
 		// For p2wsh outputs, and future outputs, the script code is
 		// the original script, with all code separators removed,
 		// serialized with a var int length prefix.
-		wire.WriteVarBytes(&sigHash, 0, subScript)
+		if err := wire.WriteVarBytes(w, 0, subScript); err != nil {
+			return err
+		}
 	}
 
 	// Next, add the input amount, and sequence number of the input being
 	// signed.
-	var bAmount [8]byte
-	binary.LittleEndian.PutUint64(bAmount[:], uint64(amt))
-	sigHash.Write(bAmount[:])
-	var bSequence [4]byte
-	binary.LittleEndian.PutUint32(bSequence[:], txIn.Sequence)
-	sigHash.Write(bSequence[:])
+	binary.LittleEndian.PutUint64(scratch[:], uint64(amt))
+	if _, err := w.Write(scratch[:]); err != nil {
+		return err
+	}
+	binary.LittleEndian.PutUint32(scratch[:4], txIn.Sequence)
+	if _, err := w.Write(scratch[:4]); err != nil {
+		return err
+	}
 
 	// If the current signature mode isn't single, or none, then we can
 	// re-use the pre-generated hashoutputs sighash fragment. Otherwise,
 	// we'll serialize and add only the target output index to the signature
 	// pre-image.
+	hashOutputs := zeroHash[:]
 	if hashType&sigHashMask != SigHashSingle &&
 		hashType&sigHashMask != SigHashNone {
-		sigHash.Write(sigHashes.HashOutputsV0[:])
+
+		hashOutputs = sigHashes.HashOutputsV0[:]
 	} else if hashType&sigHashMask == SigHashSingle && idx < len(tx.TxOut) {
 		var b bytes.Buffer
-		wire.WriteTxOut(&b, 0, 0, tx.TxOut[idx])
-		sigHash.Write(chainhash.DoubleHashB(b.Bytes()))
-	} else {
-		sigHash.Write(zeroHash[:])
+		if err := wire.WriteTxOut(&b, 0, 0, tx.TxOut[idx]); err != nil {
+			return err
+		}
+		hashOutputs = chainhash.DoubleHashB(b.Bytes())
+	}
+	if _, err := w.Write(hashOutputs); err != nil {
+		return err
 	}
 
 	// Finally, write out the transaction's locktime, and the sig hash
 	// type.
-	var bLockTime [4]byte
-	binary.LittleEndian.PutUint32(bLockTime[:], tx.LockTime)
-	sigHash.Write(bLockTime[:])
-	var bHashType [4]byte
-	binary.LittleEndian.PutUint32(bHashType[:], uint32(hashType))
-	sigHash.Write(bHashType[:])
-
-	return chainhash.DoubleHashB(sigHash.Bytes()), nil
+	binary.LittleEndian.PutUint32(scratch[:4], tx.LockTime)
+	if _, err := w.Write(scratch[:4]); err != nil {
+		return err
+	}
+	binary.LittleEndian.PutUint32(scratch[:4], uint32(hashType))
+	_, err := w.Write(scratch[:4])
+	return err
 }
 
 // CalcWitnessSigHash computes the sighash digest for the specified input of
@@ -294,7 +387,30 @@ func CalcWitnessSigHash(script []byte, sigHashes *TxSigHashes, hType SigHashType
 		return nil, err
 	}
 
-	return calcWitnessSignatureHashRaw(script, sigHashes, hType, tx, idx, amt)
+	return calcWitnessSignatureHashRaw(
+		script, sigHashes, hType, tx, idx, amt, BitcoinChainTag,
+	)
+}
+
+// CalcWitnessSigHashPreimage 返回 CalcWitnessSigHash 计算的 BIP0143 签名消息，签名哈希是它的双 SHA256。
+func CalcWitnessSigHashPreimage(script []byte, sigHashes *TxSigHashes,
+	hType SigHashType, tx *wire.MsgTx, idx int, amt int64) ([]byte, error) {
+
+	const scriptVersion = 0
+	if err := checkScriptParses(scriptVersion, script); err != nil {
+		return nil, err
+	}
+	if idx < 0 || idx >= len(tx.TxIn) {
+		return nil, fmt.Errorf("idx %d but %d txins", idx, len(tx.TxIn))
+	}
+
+	var b bytes.Buffer
+	err := writeWitnessV0SigHashPreimage(&b, script, sigHashes, hType, tx,
+		idx, amt)
+	if err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
 }
 
 // sigHashExtFlag represents the sig hash extension flag as defined in BIP 341.
@@ -338,6 +454,9 @@ type taprootSigHashOptions struct {
 	// codeSepPos is the op code position of the last code separator. This
 	// is used for the BIP 342 sighash message extension.
 	codeSepPos uint32
+
+	// chainTag selects the tagged hash of the final digest.
+	chainTag ChainTag
 }
 
 // writeDigestExtensions writes out the sighah mesage extensiosn defined by the
@@ -426,6 +545,33 @@ func calcTaprootSignatureHashRaw(sigHashes *TxSigHashes, hType SigHashType,
 	prevOutFetcher PrevOutputFetcher,
 	sigHashOpts ...TaprootSigHashOption) ([]byte, error) {
 
+	// The final sighash is computed as: hash_TagSigHash(0x00 || sigMsg).
+	// The preimage includes the 0x00 epoch so we don't need to append
+	// here and incur extra allocations.
+	var sigMsg bytes.Buffer
+	err := writeTaprootSigHashPreimage(&sigMsg, sigHashes, hType, tx, idx,
+		prevOutFetcher, sigHashOpts...)
+	if err != nil {
+		return nil, err
+	}
+	opts := defaultTaprootSighashOptions()
+	for _, sigHashOpt := range sigHashOpts {
+		sigHashOpt(opts)
+	}
+	sigHash := chainhash.TaggedHash(
+		opts.chainTag.tapSighashTag(), sigMsg.Bytes(),
+	)
+	return sigHash[:], nil
+}
+
+// writeTaprootSigHashPreimage writes the sighash epoch followed by the BIP
+// 341 signature message of the given input to w. If an invalid sighash type
+// is passed in, an error is returned.
+func writeTaprootSigHashPreimage(w io.Writer, sigHashes *TxSigHashes,
+	hType SigHashType, tx *wire.MsgTx, idx int,
+	prevOutFetcher PrevOutputFetcher,
+	sigHashOpts ...TaprootSigHashOption) error {
+
 	opts := defaultTaprootSighashOptions()
 	for _, sigHashOpt := range sigHashOpts {
 		sigHashOpt(opts)
@@ -434,46 +580,49 @@ func calcTaprootSignatureHashRaw(sigHashes *TxSigHashes, hType SigHashType,
 	// If a valid sighash type isn't passed in, then we'll exit early.
 	if !isValidTaprootSigHash(hType) {
 		// TODO(roasbeef): use actual errr here
-		return nil, fmt.Errorf("invalid taproot sighash type: %v", hType)
+		return fmt.Errorf("invalid taproot sighash type: %v", hType)
 	}
 
 	// As a sanity check, ensure the passed input index for the transaction
 	// is valid.
 	if idx > len(tx.TxIn)-1 {
-		return nil, fmt.Errorf("idx %d but %d txins", idx, len(tx.TxIn))
+		return fmt.Errorf("idx %d but %d txins", idx, len(tx.TxIn))
 	}
 
-	// We'll utilize this buffer throughout to incrementally calculate
-	// the signature hash for this transaction.
-	var sigMsg bytes.Buffer
+	// Finally, if this is sighash single, then the output for this given
+	// input must exist, otherwise this is an invalid sighash type for
+	// this input. This is checked upfront so nothing is written to w.
+	if hType&sigHashMask == SigHashSingle && idx >= len(tx.TxOut) {
+		// TODO(roasbeef): real error here
+		return fmt.Errorf("invalid sighash type for input")
+	}
 
 	// The final sighash always has a value of 0x00 prepended to it, which
-	// is called the sighash epoch.
-	sigMsg.WriteByte(0x00)
-
-	// First, we write the hash type encoded as a single byte.
-	if err := sigMsg.WriteByte(byte(hType)); err != nil {
-		return nil, err
+	// is called the sighash epoch. It's followed by the hash type encoded
+	// as a single byte.
+	if _, err := w.Write([]byte{0x00, byte(hType)}); err != nil {
+		return err
 	}
 
 	// Next we'll write out the transaction specific data which binds the
 	// outer context of the sighash.
-	err := binary.Write(&sigMsg, binary.LittleEndian, tx.Version)
+	err := binary.Write(w, binary.LittleEndian, tx.Version)
 	if err != nil {
-		return nil, err
+		return err
 	}
-	err = binary.Write(&sigMsg, binary.LittleEndian, tx.LockTime)
+	err = binary.Write(w, binary.LittleEndian, tx.LockTime)
 	if err != nil {
-		return nil, err
+		return err
 	}
 
 	// If sighash isn't anyone can pay, then we'll include all the
 	// pre-computed midstate digests in the sighash.
+	var midstates [][]byte
 	if hType&SigHashAnyOneCanPay != SigHashAnyOneCanPay {
-		sigMsg.Write(sigHashes.HashPrevOutsV1[:])
-		sigMsg.Write(sigHashes.HashInputAmountsV1[:])
-		sigMsg.Write(sigHashes.HashInputScriptsV1[:])
-		sigMsg.Write(sigHashes.HashSequenceV1[:])
+		midstates = append(midstates, sigHashes.HashPrevOutsV1[:],
+			sigHashes.HashInputAmountsV1[:],
+			sigHashes.HashInputScriptsV1[:],
+			sigHashes.HashSequenceV1[:])
 	}
 
 	// If this is sighash all, or its taproot alias (sighash default),
@@ -482,7 +631,12 @@ func calcTaprootSignatureHashRaw(sigHashes *TxSigHashes, hType SigHashType,
 	if hType&SigHashSingle != SigHashSingle &&
 		hType&SigHashSingle != SigHashNone {
 
-		sigMsg.Write(sigHashes.HashOutputsV1[:])
+		midstates = append(midstates, sigHashes.HashOutputsV1[:])
+	}
+	for _, midstate := range midstates {
+		if _, err := w.Write(midstate); err != nil {
+			return err
+		}
 	}
 
 	// Next, we'll write out the relevant information for this specific
@@ -498,8 +652,8 @@ func calcTaprootSignatureHashRaw(sigHashes *TxSigHashes, hType SigHashType,
 		spendType += 1
 	}
 
-	if err := sigMsg.WriteByte(spendType); err != nil {
-		return nil, err
+	if _, err := w.Write([]byte{spendType}); err != nil {
+		return err
 	}
 
 	// If anyone can pay is active, then we'll write out just the specific
@@ -508,74 +662,59 @@ func calcTaprootSignatureHashRaw(sigHashes *TxSigHashes, hType SigHashType,
 	if hType&SigHashAnyOneCanPay == SigHashAnyOneCanPay {
 		// We'll start out with writing this input specific information by
 		// first writing the entire previous output.
-		err = wire.WriteOutPoint(&sigMsg, 0, 0, &input.PreviousOutPoint)
+		err = wire.WriteOutPoint(w, 0, 0, &input.PreviousOutPoint)
 		if err != nil {
-			return nil, err
+			return err
 		}
 
 		// Next, we'll write out the previous output (amt+script) being
 		// spent itself.
 		prevOut := prevOutFetcher.FetchPrevOutput(input.PreviousOutPoint)
-		if err := wire.WriteTxOut(&sigMsg, 0, 0, prevOut); err != nil {
-			return nil, err
+		if err := wire.WriteTxOut(w, 0, 0, prevOut); err != nil {
+			return err
 		}
 
 		// Finally, we'll write out the input sequence itself.
-		err = binary.Write(&sigMsg, binary.LittleEndian, input.Sequence)
+		err = binary.Write(w, binary.LittleEndian, input.Sequence)
 		if err != nil {
-			return nil, err
+			return err
 		}
 	} else {
-		err := binary.Write(&sigMsg, binary.LittleEndian, uint32(idx))
+		err := binary.Write(w, binary.LittleEndian, uint32(idx))
 		if err != nil {
-			return nil, err
+			return err
 		}
 	}
 
 	// Now that we have the input specific information written, we'll
 	// include the anex, if we have it.
 	if witnessHasAnnex {
-		sigMsg.Write(opts.annexHash)
+		if _, err := w.Write(opts.annexHash); err != nil {
+			return err
+		}
 	}
 
 	// Finally, if this is sighash single, then we'll write out the
 	// information for this given output.
 	if hType&sigHashMask == SigHashSingle {
-		// If this output doesn't exist, then we'll return with an error
-		// here as this is an invalid sighash type for this input.
-		if idx >= len(tx.TxOut) {
-			// TODO(roasbeef): real error here
-			return nil, fmt.Errorf("invalid sighash type for input")
-		}
-
-		// Now that we know this is a valid sighash input combination,
-		// we'll write out the information specific to this input.
 		// We'll write the wire serialization of the output and compute
 		// the sha256 in a single step.
 		shaWriter := sha256.New()
 		txOut := tx.TxOut[idx]
 		if err := wire.WriteTxOut(shaWriter, 0, 0, txOut); err != nil {
-			return nil, err
+			return err
 		}
 
 		// With the digest obtained, we'll write this out into our
 		// signature message.
-		if _, err := sigMsg.Write(shaWriter.Sum(nil)); err != nil {
-			return nil, err
+		if _, err := w.Write(shaWriter.Sum(nil)); err != nil {
+			return err
 		}
 	}
 
 	// Now that we've written out all the base information, we'll write any
 	// message extensions (if they exist).
-	if err := opts.writeDigestExtensions(&sigMsg); err != nil {
-		return nil, err
-	}
-
-	// The final sighash is computed as: hash_TagSigHash(0x00 || sigMsg).
-	// We wrote the 0x00 above so we don't need to append here and incur
-	// extra allocations.
-	sigHash := chainhash.TaggedHash(chainhash.TagTapSighash, sigMsg.Bytes())
-	return sigHash[:], nil
+	return opts.writeDigestExtensions(w)
 }
 
 // CalcTaprootSignatureHash computes the sighash digest of a transaction's
@@ -593,6 +732,22 @@ func CalcTaprootSignatureHash(sigHashes *TxSigHashes, hType SigHashType,
 	)
 }
 
+// CalcTaprootSignatureHashPreimage 返回 CalcTaprootSignatureHash 计算的 BIP 341 签名消息，包括开头的 0x00 签名哈希纪元，
+// 签名哈希是它的 TapSighash 标签哈希。 tapscript 花费可以通过 WithBaseTapscriptVersion 选项得到对应的签名消息。
+func CalcTaprootSignatureHashPreimage(sigHashes *TxSigHashes,
+	hType SigHashType, tx *wire.MsgTx, idx int,
+	prevOutFetcher PrevOutputFetcher,
+	sigHashOpts ...TaprootSigHashOption) ([]byte, error) {
+
+	var b bytes.Buffer
+	err := writeTaprootSigHashPreimage(&b, sigHashes, hType, tx, idx,
+		prevOutFetcher, sigHashOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
+
 // CalcTaprootSignatureHash is similar to CalcTaprootSignatureHash but for
 // _tapscript_ spends instead. A proper TapLeaf instance (the script leaf being
 // signed) must be passed in. The functional options can be used to specify an
@@ -618,3 +773,257 @@ func CalcTapscriptSignaturehash(sigHashes *TxSigHashes, hType SigHashType,
 		sigHashes, hType, tx, idx, prevOutFetcher, opts...,
 	)
 }
+
+// SigVersion 表示计算签名哈希时使用的算法版本。
+type SigVersion uint8
+
+const (
+	// SigVersionBase 是传统（非见证）签名哈希算法。
+	SigVersionBase SigVersion = iota
+
+	// SigVersionWitnessV0 是 BIP0143 定义的版本 0 见证签名哈希算法。
+	SigVersionWitnessV0
+
+	// SigVersionTaproot 是 BIP0341 定义的 taproot 密钥路径签名哈希算法。
+	SigVersionTaproot
+
+	// SigVersionTapscript 是 BIP0342 定义的 tapscript 脚本路径签名哈希算法。
+	SigVersionTapscript
+)
+
+// String 返回签名哈希版本的可读名称。
+func (v SigVersion) String() string {
+	switch v {
+	case SigVersionBase:
+		return "base"
+	case SigVersionWitnessV0:
+		return "witness_v0"
+	case SigVersionTaproot:
+		return "taproot"
+	case SigVersionTapscript:
+		return "tapscript"
+	default:
+		return fmt.Sprintf("unknown(%d)", uint8(v))
+	}
+}
+
+// ErrInvalidSigHashParams 在传递给 CalcSigHash 的参数不足以计算所请求版本的签名哈希时返回。
+var ErrInvalidSigHashParams = fmt.Errorf("invalid sighash parameters")
+
+// sigHashConfig 包含可通过 SigHashOption 指定的 CalcSigHash 参数。
+type sigHashConfig struct {
+	script         []byte
+	amount         int64
+	sigHashes      *TxSigHashes
+	prevOutFetcher PrevOutputFetcher
+	annex          []byte
+	tapLeafHash    []byte
+	codeSepPos     uint32
+	chainTag       ChainTag
+
+	// taprootOpts are the options derived from the above for the taproot
+	// and tapscript sighash algorithms.
+	taprootOpts []TaprootSigHashOption
+}
+
+// SigHashOption 是用于向 CalcSigHash 提供各版本所需参数的函数选项。
+type SigHashOption func(*sigHashConfig)
+
+// WithSigHashScript 指定传统和版本 0 见证签名哈希所签署的子脚本。
+func WithSigHashScript(script []byte) SigHashOption {
+	return func(cfg *sigHashConfig) {
+		cfg.script = script
+	}
+}
+
+// WithSigHashAmount 指定版本 0 见证签名哈希所提交的被花费输出金额。
+func WithSigHashAmount(amount int64) SigHashOption {
+	return func(cfg *sigHashConfig) {
+		cfg.amount = amount
+	}
+}
+
+// WithSigHashMidstate 指定预先计算的签名哈希中间状态。 未指定时，将根据交易和前序输出即时计算。
+func WithSigHashMidstate(sigHashes *TxSigHashes) SigHashOption {
+	return func(cfg *sigHashConfig) {
+		cfg.sigHashes = sigHashes
+	}
+}
+
+// WithSigHashPrevOuts 指定用于获取所有被花费输出的 PrevOutputFetcher，taproot 和 tapscript 签名哈希需要它。
+func WithSigHashPrevOuts(fetcher PrevOutputFetcher) SigHashOption {
+	return func(cfg *sigHashConfig) {
+		cfg.prevOutFetcher = fetcher
+	}
+}
+
+// WithSigHashAnnex 指定 taproot 或 tapscript 花费的见证中包含的附件。
+func WithSigHashAnnex(annex []byte) SigHashOption {
+	return func(cfg *sigHashConfig) {
+		cfg.annex = annex
+	}
+}
+
+// WithSigHashTapLeaf 指定 tapscript 签名哈希所提交的叶子。
+func WithSigHashTapLeaf(leaf TapLeaf) SigHashOption {
+	return func(cfg *sigHashConfig) {
+		leafHash := leaf.TapHash()
+		cfg.tapLeafHash = leafHash[:]
+	}
+}
+
+// WithSigHashTapLeafHash 与 WithSigHashTapLeaf 相同，但直接接受叶子哈希。
+func WithSigHashTapLeafHash(leafHash chainhash.Hash) SigHashOption {
+	return func(cfg *sigHashConfig) {
+		cfg.tapLeafHash = leafHash[:]
+	}
+}
+
+// WithSigHashCodeSepPos 指定 tapscript 中最后执行的 OP_CODESEPARATOR 的操作码位置。 未指定时，表示没有执行过 OP_CODESEPARATOR。
+func WithSigHashCodeSepPos(pos uint32) SigHashOption {
+	return func(cfg *sigHashConfig) {
+		cfg.codeSepPos = pos
+	}
+}
+
+// CalcSigHash 是计算所有签名哈希版本的统一入口。 各版本所需的参数通过选项提供：
+//
+//   - SigVersionBase：WithSigHashScript
+//   - SigVersionWitnessV0：WithSigHashScript、WithSigHashAmount，可选 WithSigHashMidstate
+//   - SigVersionTaproot：WithSigHashPrevOuts，可选 WithSigHashMidstate、WithSigHashAnnex
+//   - SigVersionTapscript：WithSigHashPrevOuts、WithSigHashTapLeaf（或 WithSigHashTapLeafHash），
+//     可选 WithSigHashMidstate、WithSigHashAnnex、WithSigHashCodeSepPos
+//
+// 缺少必需参数时返回 ErrInvalidSigHashParams。
+func CalcSigHash(tx *wire.MsgTx, idx int, hashType SigHashType,
+	sigVersion SigVersion, opts ...SigHashOption) ([]byte, error) {
+
+	cfg, err := newSigHashConfig(tx, idx, sigVersion, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	const scriptVersion = 0
+	switch sigVersion {
+	case SigVersionBase:
+		if err := checkScriptParses(scriptVersion, cfg.script); err != nil {
+			return nil, err
+		}
+		return calcSignatureHash(
+			cfg.script, hashType, tx, idx, cfg.chainTag,
+		), nil
+
+	case SigVersionWitnessV0:
+		if err := checkScriptParses(scriptVersion, cfg.script); err != nil {
+			return nil, err
+		}
+		return calcWitnessSignatureHashRaw(
+			cfg.script, cfg.sigHashes, hashType, tx, idx, cfg.amount,
+			cfg.chainTag,
+		)
+
+	default:
+		return calcTaprootSignatureHashRaw(
+			cfg.sigHashes, hashType, tx, idx, cfg.prevOutFetcher,
+			cfg.taprootOpts...,
+		)
+	}
+}
+
+// CalcSigHashPreimage 返回 CalcSigHash 签名哈希的原像，即被哈希的序列化签名消息，参数与 CalcSigHash 相同。
+// 审计工具和硬件钱包可以用它显示和独立验证被签名的内容：传统和版本 0 见证签名哈希是原像的双 SHA256，
+// taproot 和 tapscript 签名哈希是原像的 TapSighash 标签哈希。
+func CalcSigHashPreimage(tx *wire.MsgTx, idx int, hashType SigHashType,
+	sigVersion SigVersion, opts ...SigHashOption) ([]byte, error) {
+
+	cfg, err := newSigHashConfig(tx, idx, sigVersion, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	switch sigVersion {
+	case SigVersionBase:
+		return CalcSignatureHashPreimage(cfg.script, hashType, tx, idx)
+
+	case SigVersionWitnessV0:
+		return CalcWitnessSigHashPreimage(
+			cfg.script, cfg.sigHashes, hashType, tx, idx, cfg.amount,
+		)
+
+	default:
+		return CalcTaprootSignatureHashPreimage(
+			cfg.sigHashes, hashType, tx, idx, cfg.prevOutFetcher,
+			cfg.taprootOpts...,
+		)
+	}
+}
+
+// newSigHashConfig 应用选项并检查 sigVersion 所需的参数是否齐全，缺少的签名哈希中间状态会被即时计算。
+func newSigHashConfig(tx *wire.MsgTx, idx int, sigVersion SigVersion,
+	opts []SigHashOption) (*sigHashConfig, error) {
+
+	cfg := &sigHashConfig{
+		codeSepPos: blankCodeSepValue,
+	}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	if idx < 0 || idx >= len(tx.TxIn) {
+		return nil, fmt.Errorf("%w: input index %d out of range for "+
+			"transaction with %d inputs", ErrInvalidSigHashParams, idx,
+			len(tx.TxIn))
+	}
+
+	switch sigVersion {
+	case SigVersionBase:
+		if cfg.script == nil {
+			return nil, fmt.Errorf("%w: script required for %v sighash",
+				ErrInvalidSigHashParams, sigVersion)
+		}
+
+	case SigVersionWitnessV0:
+		if cfg.script == nil {
+			return nil, fmt.Errorf("%w: script required for %v sighash",
+				ErrInvalidSigHashParams, sigVersion)
+		}
+		if cfg.sigHashes == nil {
+			fetcher := cfg.prevOutFetcher
+			if fetcher == nil {
+				fetcher = NewCannedPrevOutputFetcher(nil, cfg.amount)
+			}
+			cfg.sigHashes = NewTxSigHashes(tx, fetcher)
+		}
+
+	case SigVersionTaproot, SigVersionTapscript:
+		if cfg.prevOutFetcher == nil {
+			return nil, fmt.Errorf("%w: prevout fetcher required for "+
+				"%v sighash", ErrInvalidSigHashParams, sigVersion)
+		}
+		if cfg.sigHashes == nil {
+			cfg.sigHashes = NewTxSigHashes(tx, cfg.prevOutFetcher)
+		}
+
+		if cfg.annex != nil {
+			cfg.taprootOpts = append(cfg.taprootOpts, WithAnnex(cfg.annex))
+		}
+		if cfg.chainTag != BitcoinChainTag {
+			cfg.taprootOpts = append(cfg.taprootOpts,
+				WithTaprootChainTag(cfg.chainTag))
+		}
+		if sigVersion == SigVersionTapscript {
+			if len(cfg.tapLeafHash) != chainhash.HashSize {
+				return nil, fmt.Errorf("%w: tap leaf required for %v "+
+					"sighash", ErrInvalidSigHashParams, sigVersion)
+			}
+			cfg.taprootOpts = append(cfg.taprootOpts,
+				WithBaseTapscriptVersion(cfg.codeSepPos, cfg.tapLeafHash))
+		}
+
+	default:
+		return nil, fmt.Errorf("%w: unknown sighash version %v",
+			ErrInvalidSigHashParams, sigVersion)
+	}
+
+	return cfg, nil
+}
@@ -0,0 +1,205 @@
+package txscript
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcec/v2/schnorr"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/stretchr/testify/require"
+)
+
+// TestCalcSigHash 确保统一的 CalcSigHash 与各版本专用函数的结果一致，并在缺少参数时返回错误。
+func TestCalcSigHash(t *testing.T) {
+	t.Parallel()
+
+	script := mustParseShortForm("DUP HASH160 DATA_20 0x" +
+		"433ec2ac1ffa1b7b7d027f564529c57197f9ae88 EQUALVERIFY CHECKSIG")
+	const amt = 50000
+	tx := createSpendingTx(nil, nil, script, amt)
+	fetcher := NewCannedPrevOutputFetcher(script, amt)
+	sigHashes := NewTxSigHashes(tx, fetcher)
+	leaf := NewBaseTapLeaf([]byte{OP_TRUE})
+	annex := []byte{TaprootAnnexTag, 0x01}
+
+	legacy, err := CalcSignatureHash(script, SigHashAll, tx, 0)
+	require.NoError(t, err)
+	got, err := CalcSigHash(tx, 0, SigHashAll, SigVersionBase,
+		WithSigHashScript(script))
+	require.NoError(t, err)
+	require.Equal(t, legacy, got)
+
+	witnessV0, err := CalcWitnessSigHash(script, sigHashes, SigHashAll, tx,
+		0, amt)
+	require.NoError(t, err)
+	got, err = CalcSigHash(tx, 0, SigHashAll, SigVersionWitnessV0,
+		WithSigHashScript(script), WithSigHashAmount(amt))
+	require.NoError(t, err)
+	require.Equal(t, witnessV0, got)
+
+	taproot, err := calcTaprootSignatureHashRaw(sigHashes, SigHashDefault,
+		tx, 0, fetcher, WithAnnex(annex))
+	require.NoError(t, err)
+	got, err = CalcSigHash(tx, 0, SigHashDefault, SigVersionTaproot,
+		WithSigHashPrevOuts(fetcher), WithSigHashAnnex(annex))
+	require.NoError(t, err)
+	require.Equal(t, taproot, got)
+
+	tapscript, err := CalcTapscriptSignaturehash(sigHashes, SigHashDefault,
+		tx, 0, fetcher, leaf)
+	require.NoError(t, err)
+	got, err = CalcSigHash(tx, 0, SigHashDefault, SigVersionTapscript,
+		WithSigHashPrevOuts(fetcher), WithSigHashMidstate(sigHashes),
+		WithSigHashTapLeaf(leaf))
+	require.NoError(t, err)
+	require.Equal(t, tapscript, got)
+
+	// 不同的 OP_CODESEPARATOR 位置必须产生不同的签名哈希。
+	withCodeSep, err := CalcSigHash(tx, 0, SigHashDefault,
+		SigVersionTapscript, WithSigHashPrevOuts(fetcher),
+		WithSigHashTapLeafHash(leaf.TapHash()), WithSigHashCodeSepPos(0))
+	require.NoError(t, err)
+	require.False(t, bytes.Equal(tapscript, withCodeSep))
+
+	invalid := []struct {
+		name    string
+		idx     int
+		version SigVersion
+		opts    []SigHashOption
+	}{
+		{"base without script", 0, SigVersionBase, nil},
+		{"v0 without script", 0, SigVersionWitnessV0, nil},
+		{"taproot without prevouts", 0, SigVersionTaproot, nil},
+		{"tapscript without leaf", 0, SigVersionTapscript,
+			[]SigHashOption{WithSigHashPrevOuts(fetcher)}},
+		{"bad index", 1, SigVersionBase,
+			[]SigHashOption{WithSigHashScript(script)}},
+		{"unknown version", 0, SigVersion(99), nil},
+	}
+	for _, test := range invalid {
+		_, err := CalcSigHash(tx, test.idx, SigHashAll, test.version,
+			test.opts...)
+		require.ErrorIs(t, err, ErrInvalidSigHashParams, test.name)
+	}
+}
+
+// TestCalcSigHashCodeSeparator 确保使用 CalcSigHash 计算的带有 OP_CODESEPARATOR 位置的 tapscript 签名能够通过验证。
+func TestCalcSigHashCodeSeparator(t *testing.T) {
+	t.Parallel()
+
+	privKey, err := btcec.NewPrivateKey()
+	require.NoError(t, err)
+
+	leafScript, err := NewScriptBuilder().AddOp(OP_CODESEPARATOR).
+		AddData(schnorr.SerializePubKey(privKey.PubKey())).
+		AddOp(OP_CHECKSIG).Script()
+	require.NoError(t, err)
+	leaf := NewBaseTapLeaf(leafScript)
+	tree := AssembleTaprootScriptTree(leaf)
+	rootHash := tree.RootNode.TapHash()
+	outputKey := ComputeTaprootOutputKey(privKey.PubKey(), rootHash[:])
+	pkScript, err := PayToTaprootScript(outputKey)
+	require.NoError(t, err)
+	ctrl := tree.LeafMerkleProofs[0].ToControlBlock(privKey.PubKey())
+	ctrlBlock, err := ctrl.ToBytes()
+	require.NoError(t, err)
+
+	const amt = 1e8
+	tx := wire.NewMsgTx(2)
+	tx.AddTxIn(&wire.TxIn{PreviousOutPoint: wire.OutPoint{Index: 0}})
+	tx.AddTxOut(wire.NewTxOut(amt-1000, nil))
+	fetcher := NewCannedPrevOutputFetcher(pkScript, amt)
+
+	sigHash, err := CalcSigHash(tx, 0, SigHashDefault, SigVersionTapscript,
+		WithSigHashPrevOuts(fetcher), WithSigHashTapLeaf(leaf),
+		WithSigHashCodeSepPos(0))
+	require.NoError(t, err)
+	sig, err := schnorr.Sign(privKey, sigHash)
+	require.NoError(t, err)
+
+	tx.TxIn[0].Witness = wire.TxWitness{sig.Serialize(), leafScript,
+		ctrlBlock}
+	vm, err := NewEngine(pkScript, tx, 0, StandardVerifyFlags, nil,
+		NewTxSigHashes(tx, fetcher), amt, fetcher)
+	require.NoError(t, err)
+	require.NoError(t, vm.Execute())
+}
+
+// TestCalcSigHashPreimage 确保每种签名哈希版本和哈希类型的原像按对应的哈希算法哈希后等于 CalcSigHash 的结果。
+func TestCalcSigHashPreimage(t *testing.T) {
+	t.Parallel()
+
+	script := mustParseShortForm("CODESEPARATOR DUP HASH160 DATA_20 0x" +
+		"433ec2ac1ffa1b7b7d027f564529c57197f9ae88 EQUALVERIFY CHECKSIG")
+	const amt = 50000
+	tx := createSpendingTx(nil, nil, script, amt)
+	tx.AddTxIn(&wire.TxIn{PreviousOutPoint: wire.OutPoint{Index: 1}})
+	fetcher := NewCannedPrevOutputFetcher(script, amt)
+	leaf := NewBaseTapLeaf([]byte{OP_TRUE})
+	annex := []byte{TaprootAnnexTag, 0x01}
+
+	doubleSHA256 := func(b []byte) []byte {
+		return chainhash.DoubleHashB(b)
+	}
+	tapSigHash := func(b []byte) []byte {
+		hash := chainhash.TaggedHash(chainhash.TagTapSighash, b)
+		return hash[:]
+	}
+	tests := []struct {
+		version SigVersion
+		opts    []SigHashOption
+		hash    func([]byte) []byte
+	}{
+		{SigVersionBase, []SigHashOption{WithSigHashScript(script)},
+			doubleSHA256},
+		{SigVersionWitnessV0, []SigHashOption{WithSigHashScript(script),
+			WithSigHashAmount(amt)}, doubleSHA256},
+		{SigVersionTaproot, []SigHashOption{WithSigHashPrevOuts(fetcher),
+			WithSigHashAnnex(annex)}, tapSigHash},
+		{SigVersionTapscript, []SigHashOption{WithSigHashPrevOuts(fetcher),
+			WithSigHashTapLeaf(leaf), WithSigHashCodeSepPos(0)},
+			tapSigHash},
+	}
+	hashTypes := []SigHashType{
+		SigHashAll, SigHashNone, SigHashSingle,
+		SigHashAll | SigHashAnyOneCanPay,
+		SigHashSingle | SigHashAnyOneCanPay,
+	}
+	for _, test := range tests {
+		for _, hashType := range hashTypes {
+			sigHash, err := CalcSigHash(tx, 0, hashType, test.version,
+				test.opts...)
+			require.NoError(t, err)
+			preimage, err := CalcSigHashPreimage(tx, 0, hashType,
+				test.version, test.opts...)
+			require.NoError(t, err)
+			require.Equal(t, sigHash, test.hash(preimage), "%v %v",
+				test.version, hashType)
+		}
+	}
+
+	// 传统签名消息以交易版本开头并以 4 字节哈希类型结尾，taproot 签名消息以签名哈希纪元和哈希类型开头。
+	preimage, err := CalcSigHashPreimage(tx, 0, SigHashAll, SigVersionBase,
+		WithSigHashScript(script))
+	require.NoError(t, err)
+	require.Equal(t, []byte{1, 0, 0, 0}, preimage[:4])
+	require.Equal(t, []byte{byte(SigHashAll), 0, 0, 0},
+		preimage[len(preimage)-4:])
+	preimage, err = CalcSigHashPreimage(tx, 0, SigHashDefault,
+		SigVersionTaproot, WithSigHashPrevOuts(fetcher))
+	require.NoError(t, err)
+	require.Equal(t, []byte{0x00, byte(SigHashDefault)}, preimage[:2])
+
+	// 没有对应输出的 SigHashSingle 输入的签名哈希是常量 1，没有原像。
+	_, err = CalcSigHashPreimage(tx, 1, SigHashSingle, SigVersionBase,
+		WithSigHashScript(script))
+	require.ErrorIs(t, err, ErrInvalidSigHashParams)
+	_, err = CalcSigHashPreimage(tx, 1, SigHashSingle, SigVersionTaproot,
+		WithSigHashPrevOuts(fetcher))
+	require.Error(t, err)
+	_, err = CalcSigHashPreimage(tx, 2, SigHashAll, SigVersionWitnessV0,
+		WithSigHashScript(script))
+	require.ErrorIs(t, err, ErrInvalidSigHashParams)
+}
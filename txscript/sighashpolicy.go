@@ -0,0 +1,123 @@
+// 包含签名哈希类型的策略检查，使钱包和内存池可以在签名或转发之前限制可接受的签名哈希类型，例如禁止 SIGHASH_NONE。
+
+package txscript
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	// ErrInvalidSigHashEncoding 在签名的哈希类型编码无效时返回，即引擎在严格编码或 taproot 规则下会拒绝的签名。
+	ErrInvalidSigHashEncoding = errors.New("invalid signature hash type " +
+		"encoding")
+
+	// ErrSigHashTypeNotAllowed 在签名的哈希类型编码有效但不被策略允许时返回。
+	ErrSigHashTypeNotAllowed = errors.New("signature hash type not allowed")
+)
+
+// SigHashPolicy 是策略允许的签名哈希类型列表。 nil 策略允许所有编码有效的类型。
+//
+// 由于 SigHashDefault 与 SigHashAll 承诺的交易数据相同，允许 SigHashAll 的策略也允许 SigHashDefault，
+// 反之则不然：只允许 SigHashDefault 的策略要求 taproot 签名省略哈希类型字节。
+type SigHashPolicy []SigHashType
+
+// AllSigHashTypes 是允许所有有效签名哈希类型的策略。
+var AllSigHashTypes = SigHashPolicy{
+	SigHashDefault,
+	SigHashAll,
+	SigHashNone,
+	SigHashSingle,
+	SigHashAll | SigHashAnyOneCanPay,
+	SigHashNone | SigHashAnyOneCanPay,
+	SigHashSingle | SigHashAnyOneCanPay,
+}
+
+// Allows 返回策略是否允许给定的签名哈希类型。
+func (p SigHashPolicy) Allows(hashType SigHashType) bool {
+	if p == nil {
+		return true
+	}
+	for _, allowed := range p {
+		if allowed == hashType ||
+			(hashType == SigHashDefault && allowed == SigHashAll) {
+
+			return true
+		}
+	}
+	return false
+}
+
+// Without 返回从策略中移除给定类型后的新策略，例如 AllSigHashTypes.Without(SigHashNone, SigHashNone|SigHashAnyOneCanPay)。
+// 对 nil 策略调用时从 AllSigHashTypes 开始移除。
+func (p SigHashPolicy) Without(hashTypes ...SigHashType) SigHashPolicy {
+	if p == nil {
+		p = AllSigHashTypes
+	}
+
+	result := make(SigHashPolicy, 0, len(p))
+	for _, allowed := range p {
+		removed := false
+		for _, hashType := range hashTypes {
+			if allowed == hashType {
+				removed = true
+				break
+			}
+		}
+		if !removed {
+			result = append(result, allowed)
+		}
+	}
+	return result
+}
+
+// isValidLegacySigHash 返回哈希类型在严格编码规则下是否有效，规则与 Engine.checkHashTypeEncoding 相同。
+func isValidLegacySigHash(hashType SigHashType) bool {
+	sigHashType := hashType & ^SigHashAnyOneCanPay
+	return sigHashType >= SigHashAll && sigHashType <= SigHashSingle
+}
+
+// ValidateHashType 从序列化的签名中取出签名哈希类型，检查其编码，并确保它被策略允许。
+//
+// taproot 为 false 时，sig 是附加了哈希类型字节的 DER 签名，哈希类型必须是严格编码规则下的有效类型。
+// taproot 为 true 时，sig 是 BIP0340 签名：64 字节的签名隐含 SigHashDefault，65 字节的签名的最后一个字节是哈希类型，
+// 并且不能显式编码为 SigHashDefault。
+//
+// 编码无效时返回 ErrInvalidSigHashEncoding，不被策略允许时返回 ErrSigHashTypeNotAllowed。 编码有效时总是返回签名的哈希类型。
+func ValidateHashType(sig []byte, allowed SigHashPolicy,
+	taproot bool) (SigHashType, error) {
+
+	var hashType SigHashType
+	switch {
+	case taproot && len(sig) == 64:
+		hashType = SigHashDefault
+
+	case taproot && len(sig) == 65:
+		hashType = SigHashType(sig[64])
+		if hashType == SigHashDefault || !isValidTaprootSigHash(hashType) {
+			return hashType, fmt.Errorf("%w: taproot hash type %#x",
+				ErrInvalidSigHashEncoding, uint8(hashType))
+		}
+
+	case taproot:
+		return 0, fmt.Errorf("%w: taproot signature length %d",
+			ErrInvalidSigHashEncoding, len(sig))
+
+	case len(sig) == 0:
+		return 0, fmt.Errorf("%w: empty signature",
+			ErrInvalidSigHashEncoding)
+
+	default:
+		hashType = SigHashType(sig[len(sig)-1])
+		if !isValidLegacySigHash(hashType) {
+			return hashType, fmt.Errorf("%w: hash type %#x",
+				ErrInvalidSigHashEncoding, uint8(hashType))
+		}
+	}
+
+	if !allowed.Allows(hashType) {
+		return hashType, fmt.Errorf("%w: %#x", ErrSigHashTypeNotAllowed,
+			uint8(hashType))
+	}
+	return hashType, nil
+}
@@ -0,0 +1,77 @@
+package txscript
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// TestValidateHashType 确保 ValidateHashType 正确处理传统签名和 taproot 签名的哈希类型编码，并应用策略。
+func TestValidateHashType(t *testing.T) {
+	t.Parallel()
+
+	der := bytes.Repeat([]byte{0x30}, 70)
+	legacySig := func(hashType SigHashType) []byte {
+		return append(append([]byte(nil), der...), byte(hashType))
+	}
+	schnorrSig := make([]byte, 64)
+	taprootSig := func(hashType SigHashType) []byte {
+		return append(append([]byte(nil), schnorrSig...), byte(hashType))
+	}
+	noNone := AllSigHashTypes.Without(SigHashNone,
+		SigHashNone|SigHashAnyOneCanPay)
+
+	tests := []struct {
+		name     string
+		sig      []byte
+		policy   SigHashPolicy
+		taproot  bool
+		hashType SigHashType
+		err      error
+	}{
+		{"legacy all", legacySig(SigHashAll), noNone, false, SigHashAll, nil},
+		{"legacy single|acp", legacySig(0x83), nil, false, 0x83, nil},
+		{"legacy none forbidden", legacySig(SigHashNone), noNone, false,
+			SigHashNone, ErrSigHashTypeNotAllowed},
+		{"legacy none|acp forbidden", legacySig(0x82), noNone, false,
+			0x82, ErrSigHashTypeNotAllowed},
+		{"legacy default invalid", legacySig(SigHashDefault), nil, false,
+			SigHashDefault, ErrInvalidSigHashEncoding},
+		{"legacy undefined type", legacySig(0x04), nil, false, 0x04,
+			ErrInvalidSigHashEncoding},
+		{"legacy empty", nil, nil, false, 0, ErrInvalidSigHashEncoding},
+		{"taproot implicit default", schnorrSig, noNone, true,
+			SigHashDefault, nil},
+		{"taproot explicit all", taprootSig(SigHashAll), noNone, true,
+			SigHashAll, nil},
+		{"taproot explicit default", taprootSig(SigHashDefault), nil, true,
+			SigHashDefault, ErrInvalidSigHashEncoding},
+		{"taproot undefined type", taprootSig(0x84), nil, true, 0x84,
+			ErrInvalidSigHashEncoding},
+		{"taproot bad length", schnorrSig[:63], nil, true, 0,
+			ErrInvalidSigHashEncoding},
+		{"taproot none forbidden", taprootSig(SigHashNone), noNone, true,
+			SigHashNone, ErrSigHashTypeNotAllowed},
+		{"default allowed by all", schnorrSig, SigHashPolicy{SigHashAll},
+			true, SigHashDefault, nil},
+		{"all not allowed by default", taprootSig(SigHashAll),
+			SigHashPolicy{SigHashDefault}, true, SigHashAll,
+			ErrSigHashTypeNotAllowed},
+	}
+
+	for _, test := range tests {
+		hashType, err := ValidateHashType(test.sig, test.policy, test.taproot)
+		require.Equal(t, test.hashType, hashType, test.name)
+		if test.err == nil {
+			require.NoError(t, err, test.name)
+		} else {
+			require.ErrorIs(t, err, test.err, test.name)
+		}
+	}
+
+	require.Len(t, noNone, len(AllSigHashTypes)-2)
+	require.Equal(t, AllSigHashTypes, SigHashPolicy(nil).Without())
+	require.True(t, SigHashPolicy(nil).Allows(SigHashNone))
+	require.False(t, SigHashPolicy{}.Allows(SigHashAll))
+}
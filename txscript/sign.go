@@ -6,7 +6,6 @@ import (
 	"fmt"
 
 	"github.com/btcsuite/btcd/btcec/v2"
-	"github.com/btcsuite/btcd/btcec/v2/schnorr"
 	"github.com/btcsuite/btcd/btcutil"
 
 	"github.com/btcsuite/btcd/btcec/v2/ecdsa"
@@ -23,7 +22,7 @@ func RawTxInWitnessSignature(tx *wire.MsgTx, sigHashes *TxSigHashes, idx int,
 	key *btcec.PrivateKey) ([]byte, error) {
 
 	hash, err := calcWitnessSignatureHashRaw(subScript, sigHashes, hashType, tx,
-		idx, amt)
+		idx, amt, BitcoinChainTag)
 	if err != nil {
 		return nil, err
 	}
@@ -64,15 +63,22 @@ func WitnessSignature(tx *wire.MsgTx, sigHashes *TxSigHashes, idx int, amt int64
 // RawTxInTaprootSignature returns a valid schnorr signature required to
 // perform a taproot key-spend of the specified input. If SigHashDefault was
 // specified, then the returned signature is 64-byte in length, as it omits the
-// additional byte to denote the sighash type.
+// additional byte to denote the sighash type. The nonce is derived as
+// specified in BIP 340 using fresh auxiliary randomness unless overridden by
+// opts, WithSigningChainTag selects a chain tagged sighash and
+// WithSigningAnnex commits the sighash to an annex.
 func RawTxInTaprootSignature(tx *wire.MsgTx, sigHashes *TxSigHashes, idx int,
 	amt int64, pkScript []byte, tapScriptRootHash []byte, hashType SigHashType,
-	key *btcec.PrivateKey) ([]byte, error) {
+	key *btcec.PrivateKey, opts ...TaprootSignOption) ([]byte, error) {
 
 	// First, we'll start by compute the top-level taproot sighash.
+	sigHashOpts, err := signingSigHashOptions(opts)
+	if err != nil {
+		return nil, err
+	}
 	sigHash, err := calcTaprootSignatureHashRaw(
 		sigHashes, hashType, tx, idx,
-		NewCannedPrevOutputFetcher(pkScript, amt),
+		NewCannedPrevOutputFetcher(pkScript, amt), sigHashOpts...,
 	)
 	if err != nil {
 		return nil, err
@@ -84,7 +90,7 @@ func RawTxInTaprootSignature(tx *wire.MsgTx, sigHashes *TxSigHashes, idx int,
 
 	// With the sighash constructed, we can sign it with the specified
 	// private key.
-	signature, err := schnorr.Sign(privKeyTweak, sigHash)
+	signature, err := schnorrSign(privKeyTweak, sigHash, opts)
 	if err != nil {
 		return nil, err
 	}
@@ -106,12 +112,11 @@ func RawTxInTaprootSignature(tx *wire.MsgTx, sigHashes *TxSigHashes, idx int,
 // 86. This method assumes that the public key included in pkScript was
 // generated using ComputeTaprootKeyNoScript that commits to a fake root
 // tapscript hash. If not, then RawTxInTaprootSignature should be used with the
-// actual committed contents.
-//
-// TODO(roasbeef): add support for annex even tho it's non-standard?
+// actual committed contents. When WithSigningAnnex is given, the annex is
+// appended to the returned witness.
 func TaprootWitnessSignature(tx *wire.MsgTx, sigHashes *TxSigHashes, idx int,
 	amt int64, pkScript []byte, hashType SigHashType,
-	key *btcec.PrivateKey) (wire.TxWitness, error) {
+	key *btcec.PrivateKey, opts ...TaprootSignOption) (wire.TxWitness, error) {
 
 	// As we're assuming this was a BIP 86 key, we use an empty root hash
 	// which means output key commits to just the public key.
@@ -119,7 +124,7 @@ func TaprootWitnessSignature(tx *wire.MsgTx, sigHashes *TxSigHashes, idx int,
 
 	sig, err := RawTxInTaprootSignature(
 		tx, sigHashes, idx, amt, pkScript, fakeTapscriptRootHash,
-		hashType, key,
+		hashType, key, opts...,
 	)
 	if err != nil {
 		return nil, err
@@ -127,27 +132,39 @@ func TaprootWitnessSignature(tx *wire.MsgTx, sigHashes *TxSigHashes, idx int,
 
 	// The witness script to spend a taproot input using the key-spend path
 	// is just the signature itself, given the public key is
-	// embedded in the previous output script.
+	// embedded in the previous output script, followed by the annex the
+	// signature commits to, if any.
+	if annex := signingAnnex(opts); annex != nil {
+		return wire.TxWitness{sig, annex}, nil
+	}
 	return wire.TxWitness{sig}, nil
 }
 
 // RawTxInTapscriptSignature computes a raw schnorr signature for a signature
 // generated from a tapscript leaf. This differs from the
 // RawTxInTaprootSignature which is used to generate signatures for top-level
-// taproot key spends.
+// taproot key spends. The nonce is derived in the same way as
+// RawTxInTaprootSignature.
 //
 // TODO(roasbeef): actually add code-sep to interface? not really used
 // anywhere....
 func RawTxInTapscriptSignature(tx *wire.MsgTx, sigHashes *TxSigHashes, idx int,
 	amt int64, pkScript []byte, tapLeaf TapLeaf, hashType SigHashType,
-	privKey *btcec.PrivateKey) ([]byte, error) {
+	privKey *btcec.PrivateKey, opts ...TaprootSignOption) ([]byte, error) {
 
 	// First, we'll start by compute the top-level taproot sighash.
 	tapLeafHash := tapLeaf.TapHash()
+	sigHashOpts, err := signingSigHashOptions(opts)
+	if err != nil {
+		return nil, err
+	}
+	sigHashOpts = append(
+		sigHashOpts,
+		WithBaseTapscriptVersion(blankCodeSepValue, tapLeafHash[:]),
+	)
 	sigHash, err := calcTaprootSignatureHashRaw(
 		sigHashes, hashType, tx, idx,
-		NewCannedPrevOutputFetcher(pkScript, amt),
-		WithBaseTapscriptVersion(blankCodeSepValue, tapLeafHash[:]),
+		NewCannedPrevOutputFetcher(pkScript, amt), sigHashOpts...,
 	)
 	if err != nil {
 		return nil, err
@@ -155,7 +172,7 @@ func RawTxInTapscriptSignature(tx *wire.MsgTx, sigHashes *TxSigHashes, idx int,
 
 	// With the sighash constructed, we can sign it with the specified
 	// private key.
-	signature, err := schnorr.Sign(privKey, sigHash)
+	signature, err := schnorrSign(privKey, sigHash, opts)
 	if err != nil {
 		return nil, err
 	}
@@ -208,13 +225,35 @@ func SignatureScript(tx *wire.MsgTx, idx int, subscript []byte, hashType SigHash
 	return NewScriptBuilder().AddData(sig).AddData(pkData).Script()
 }
 
-func p2pkSignatureScript(tx *wire.MsgTx, idx int, subScript []byte, hashType SigHashType, privKey *btcec.PrivateKey) ([]byte, error) {
-	sig, err := RawTxInSignature(tx, idx, subScript, hashType, privKey)
+// txInSigner 抽象了为单个地址生成输入签名所需的操作，使本地私钥和远程签名器可以共用 sign 中的脚本构造逻辑。
+type txInSigner interface {
+	// signTxIn 返回为 addr 生成的附加了 hashType 的签名以及该地址对应的序列化公钥。
+	signTxIn(tx *wire.MsgTx, idx int, subScript []byte, hashType SigHashType,
+		addr btcutil.Address) ([]byte, []byte, error)
+}
+
+// keyDBSigner 使用 KeyDB 中的私钥实现 txInSigner。
+type keyDBSigner struct {
+	kdb KeyDB
+}
+
+// signTxIn 实现 txInSigner 接口。
+func (s keyDBSigner) signTxIn(tx *wire.MsgTx, idx int, subScript []byte,
+	hashType SigHashType, addr btcutil.Address) ([]byte, []byte, error) {
+
+	key, compressed, err := s.kdb.GetKey(addr)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
+	}
+	sig, err := RawTxInSignature(tx, idx, subScript, hashType, key)
+	if err != nil {
+		return nil, nil, err
 	}
 
-	return NewScriptBuilder().AddData(sig).Script()
+	if compressed {
+		return sig, key.PubKey().SerializeCompressed(), nil
+	}
+	return sig, key.PubKey().SerializeUncompressed(), nil
 }
 
 // signMultiSig signs as many of the outputs in the provided multisig script as
@@ -222,18 +261,14 @@ func p2pkSignatureScript(tx *wire.MsgTx, idx int, subScript []byte, hashType Sig
 // the contract (i.e. nrequired signatures are provided).  Since it is arguably
 // legal to not be able to sign any of the outputs, no error is returned.
 func signMultiSig(tx *wire.MsgTx, idx int, subScript []byte, hashType SigHashType,
-	addresses []btcutil.Address, nRequired int, kdb KeyDB) ([]byte, bool) {
+	addresses []btcutil.Address, nRequired int, signer txInSigner) ([]byte, bool) {
 	// We start with a single OP_FALSE to work around the (now standard)
 	// but in the reference implementation that causes a spurious pop at
 	// the end of OP_CHECKMULTISIG.
 	builder := NewScriptBuilder().AddOp(OP_FALSE)
 	signed := 0
 	for _, addr := range addresses {
-		key, _, err := kdb.GetKey(addr)
-		if err != nil {
-			continue
-		}
-		sig, err := RawTxInSignature(tx, idx, subScript, hashType, key)
+		sig, _, err := signer.signTxIn(tx, idx, subScript, hashType, addr)
 		if err != nil {
 			continue
 		}
@@ -251,8 +286,8 @@ func signMultiSig(tx *wire.MsgTx, idx int, subScript []byte, hashType SigHashTyp
 }
 
 func sign(chainParams *chaincfg.Params, tx *wire.MsgTx, idx int,
-	subScript []byte, hashType SigHashType, kdb KeyDB, sdb ScriptDB) ([]byte,
-	ScriptClass, []btcutil.Address, int, error) {
+	subScript []byte, hashType SigHashType, signer txInSigner,
+	sdb ScriptDB) ([]byte, ScriptClass, []btcutil.Address, int, error) {
 
 	class, addresses, nrequired, err := ExtractPkScriptAddrs(subScript,
 		chainParams)
@@ -262,28 +297,29 @@ func sign(chainParams *chaincfg.Params, tx *wire.MsgTx, idx int,
 
 	switch class {
 	case PubKeyTy:
-		// 查找地址的键
-		key, _, err := kdb.GetKey(addresses[0])
+		// 使用地址的键签名
+		sig, _, err := signer.signTxIn(tx, idx, subScript, hashType,
+			addresses[0])
 		if err != nil {
 			return nil, class, nil, 0, err
 		}
 
-		script, err := p2pkSignatureScript(tx, idx, subScript, hashType,
-			key)
+		script, err := NewScriptBuilder().AddData(sig).Script()
 		if err != nil {
 			return nil, class, nil, 0, err
 		}
 
 		return script, class, addresses, nrequired, nil
 	case PubKeyHashTy:
-		// 查找地址的键
-		key, compressed, err := kdb.GetKey(addresses[0])
+		// 使用地址的键签名
+		sig, pkData, err := signer.signTxIn(tx, idx, subScript, hashType,
+			addresses[0])
 		if err != nil {
 			return nil, class, nil, 0, err
 		}
 
-		script, err := SignatureScript(tx, idx, subScript, hashType,
-			key, compressed)
+		script, err := NewScriptBuilder().AddData(sig).AddData(pkData).
+			Script()
 		if err != nil {
 			return nil, class, nil, 0, err
 		}
@@ -298,14 +334,14 @@ func sign(chainParams *chaincfg.Params, tx *wire.MsgTx, idx int,
 		return script, class, addresses, nrequired, nil
 	case MultiSigTy:
 		script, _ := signMultiSig(tx, idx, subScript, hashType,
-			addresses, nrequired, kdb)
+			addresses, nrequired, signer)
 		return script, class, addresses, nrequired, nil
 	case NullDataTy:
-		return nil, class, nil, 0,
-			fmt.Errorf("can't sign NULLDATA transactions")
+		return nil, class, nil, 0, fmt.Errorf("%w: can't sign NULLDATA "+
+			"transactions", ErrUnsupportedSigningClass)
 	default:
-		return nil, class, nil, 0,
-			fmt.Errorf("can't sign unknown transactions")
+		return nil, class, nil, 0, fmt.Errorf("%w: can't sign %v "+
+			"transactions", ErrUnsupportedSigningClass, class)
 	}
 }
 
@@ -382,7 +418,9 @@ sigLoop:
 		// however, assume no sigs etc are in the script since that
 		// would make the transaction nonstandard and thus not
 		// MultiSigTy, so we just need to hash the full thing.
-		hash := calcSignatureHash(pkScript, hashType, tx, idx)
+		hash := calcSignatureHash(
+			pkScript, hashType, tx, idx, BitcoinChainTag,
+		)
 
 		for _, addr := range addresses {
 			// All multisig addresses should be pubkey addresses
@@ -545,8 +583,17 @@ func SignTxOutput(chainParams *chaincfg.Params, tx *wire.MsgTx, idx int,
 	pkScript []byte, hashType SigHashType, kdb KeyDB, sdb ScriptDB,
 	previousScript []byte) ([]byte, error) {
 
+	return signTxOutput(chainParams, tx, idx, pkScript, hashType,
+		keyDBSigner{kdb: kdb}, sdb, previousScript)
+}
+
+// signTxOutput 是 SignTxOutput 和 SignTxOutputRemote 的共同实现，签名由传入的 txInSigner 生成。
+func signTxOutput(chainParams *chaincfg.Params, tx *wire.MsgTx, idx int,
+	pkScript []byte, hashType SigHashType, signer txInSigner, sdb ScriptDB,
+	previousScript []byte) ([]byte, error) {
+
 	sigScript, class, addresses, nrequired, err := sign(chainParams, tx,
-		idx, pkScript, hashType, kdb, sdb)
+		idx, pkScript, hashType, signer, sdb)
 	if err != nil {
 		return nil, err
 	}
@@ -554,7 +601,7 @@ func SignTxOutput(chainParams *chaincfg.Params, tx *wire.MsgTx, idx int,
 	if class == ScriptHashTy {
 		// TODO 保留子地址并向下传递以进行合并。
 		realSigScript, _, _, _, err := sign(chainParams, tx, idx,
-			sigScript, hashType, kdb, sdb)
+			sigScript, hashType, signer, sdb)
 		if err != nil {
 			return nil, err
 		}
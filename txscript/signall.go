@@ -0,0 +1,292 @@
+// 包含一次调用为交易的所有输入签名的函数，根据每个输入花费的脚本类型自动生成签名脚本和见证。
+
+package txscript
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/btcsuite/btcd/btcec/v2/schnorr"
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/wire"
+)
+
+// ErrUnsolvableInput 在输入花费的脚本类型不受支持、缺少所需的数据或密钥与输出不匹配而无法签名时返回。
+var ErrUnsolvableInput = errors.New("input cannot be signed")
+
+// InputSigningError 描述单个输入的签名失败。
+type InputSigningError struct {
+	// Index 是失败输入的索引。
+	Index int
+
+	// Err 是签名失败的原因。
+	Err error
+}
+
+// Error 实现 error 接口。
+func (e InputSigningError) Error() string {
+	return fmt.Sprintf("input %d: %v", e.Index, e.Err)
+}
+
+// Unwrap 返回签名失败的原因。
+func (e InputSigningError) Unwrap() error {
+	return e.Err
+}
+
+// InputSigningErrors 是 SignAllInputs 返回的按输入索引排序的签名失败列表。 可以通过 errors.As 取得，
+// errors.Is 会检查其中的每个失败原因。
+type InputSigningErrors []InputSigningError
+
+// Error 实现 error 接口。
+func (e InputSigningErrors) Error() string {
+	msgs := make([]string, len(e))
+	for i, inputErr := range e {
+		msgs[i] = inputErr.Error()
+	}
+	return fmt.Sprintf("failed to sign %d input(s): %s", len(e),
+		strings.Join(msgs, "; "))
+}
+
+// Unwrap 返回每个输入的签名失败。
+func (e InputSigningErrors) Unwrap() []error {
+	errs := make([]error, len(e))
+	for i, inputErr := range e {
+		errs[i] = inputErr
+	}
+	return errs
+}
+
+// witnessSigner 使用 KeyDB 中的私钥按 BIP0143 实现 txInSigner，使 sign 中的脚本构造逻辑可以用于见证脚本。
+type witnessSigner struct {
+	kdb       KeyDB
+	sigHashes *TxSigHashes
+	amt       int64
+}
+
+// signTxIn 实现 txInSigner 接口。
+func (s witnessSigner) signTxIn(tx *wire.MsgTx, idx int, subScript []byte,
+	hashType SigHashType, addr btcutil.Address) ([]byte, []byte, error) {
+
+	key, compressed, err := s.kdb.GetKey(addr)
+	if err != nil {
+		return nil, nil, err
+	}
+	sig, err := RawTxInWitnessSignature(tx, s.sigHashes, idx, s.amt,
+		subScript, hashType, key)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if compressed {
+		return sig, key.PubKey().SerializeCompressed(), nil
+	}
+	return sig, key.PubKey().SerializeUncompressed(), nil
+}
+
+// SignAllInputs 为 tx 的每个输入签名，输入花费的输出由 prevOutFetcher 提供。 根据每个输出的脚本类型生成签名脚本和见证：
+//
+//   - P2PK、P2PKH、裸多重签名和普通 P2SH 与 SignTxOutput 相同，结果与输入已有的签名脚本合并
+//   - P2WPKH 和 P2WSH 按 BIP0143 签名，见证脚本通过 sdb 按 P2WSH 地址查找，支持 P2PK、P2PKH 和多重签名见证脚本
+//   - 兑换脚本为 P2WPKH 或 P2WSH 的 P2SH 输出，签名脚本只推送兑换脚本，见证与原生见证输出相同
+//   - P2TR 按 BIP0086 使用密钥路径签名，kdb 必须为输出地址返回未调整的内部私钥
+//
+// 密钥通过 kdb 查找，P2SH 兑换脚本和 P2WSH 见证脚本通过 sdb 查找。 opts 只用于 taproot 签名。
+//
+// 所有输入都签名成功时返回 nil。 否则返回 InputSigningErrors，其中包含每个失败的输入，其他输入仍然会被签名。
+// 由于 taproot 签名哈希承诺了所有被花费的输出，只要有输入的前一输出无法获取，就不会签名任何输入，返回的错误列出所有缺少前一输出的输入。
+// 需要在签名之前知道每个输入缺少哪些密钥或脚本时，使用 Solvability。
+func SignAllInputs(chainParams *chaincfg.Params, tx *wire.MsgTx,
+	prevOutFetcher PrevOutputFetcher, kdb KeyDB, sdb ScriptDB,
+	hashType SigHashType, opts ...TaprootSignOption) error {
+
+	var inputErrs InputSigningErrors
+	prevOuts := make([]*wire.TxOut, len(tx.TxIn))
+	for idx, txIn := range tx.TxIn {
+		prevOuts[idx] = prevOutFetcher.FetchPrevOutput(txIn.PreviousOutPoint)
+		if prevOuts[idx] == nil {
+			inputErrs = append(inputErrs, InputSigningError{
+				Index: idx,
+				Err: fmt.Errorf("%w: previous output %v not found",
+					ErrUnsolvableInput, txIn.PreviousOutPoint),
+			})
+		}
+	}
+	if len(inputErrs) != 0 {
+		return inputErrs
+	}
+
+	sigHashes := NewTxSigHashes(tx, prevOutFetcher)
+	for idx := range tx.TxIn {
+		err := signInput(chainParams, tx, idx, prevOuts[idx], sigHashes,
+			hashType, kdb, sdb, opts)
+		if err != nil {
+			inputErrs = append(inputErrs, InputSigningError{
+				Index: idx,
+				Err:   err,
+			})
+		}
+	}
+	if len(inputErrs) != 0 {
+		return inputErrs
+	}
+	return nil
+}
+
+// signInput 为 tx 的输入 idx 签名，并设置其签名脚本和见证。
+func signInput(chainParams *chaincfg.Params, tx *wire.MsgTx, idx int,
+	prevOut *wire.TxOut, sigHashes *TxSigHashes, hashType SigHashType,
+	kdb KeyDB, sdb ScriptDB, opts []TaprootSignOption) error {
+
+	txIn := tx.TxIn[idx]
+	class := GetScriptClass(prevOut.PkScript)
+	switch class {
+	case WitnessV0PubKeyHashTy, WitnessV0ScriptHashTy, WitnessV1TaprootTy:
+		witness, err := signWitnessProgram(chainParams, tx, idx,
+			prevOut.PkScript, prevOut.Value, sigHashes, hashType, kdb,
+			sdb, opts)
+		if err != nil {
+			return err
+		}
+		txIn.SignatureScript = nil
+		txIn.Witness = witness
+		return nil
+
+	case ScriptHashTy:
+		_, addresses, _, err := ExtractPkScriptAddrs(prevOut.PkScript,
+			chainParams)
+		if err != nil {
+			return err
+		}
+		redeemScript, err := sdb.GetScript(addresses[0])
+		if err != nil {
+			return err
+		}
+
+		// Only version 0 witness programs may be nested in P2SH, anything
+		// else is signed as a regular redeem script.
+		if IsPayToWitnessPubKeyHash(redeemScript) ||
+			IsPayToWitnessScriptHash(redeemScript) {
+
+			witness, err := signWitnessProgram(chainParams, tx, idx,
+				redeemScript, prevOut.Value, sigHashes, hashType, kdb,
+				sdb, opts)
+			if err != nil {
+				return err
+			}
+			sigScript, err := NewScriptBuilder().AddData(redeemScript).
+				Script()
+			if err != nil {
+				return err
+			}
+			txIn.SignatureScript = sigScript
+			txIn.Witness = witness
+			return nil
+		}
+		fallthrough
+
+	case PubKeyTy, PubKeyHashTy, MultiSigTy:
+		sigScript, err := signTxOutput(chainParams, tx, idx,
+			prevOut.PkScript, hashType, keyDBSigner{kdb: kdb}, sdb,
+			txIn.SignatureScript)
+		if err != nil {
+			return err
+		}
+		txIn.SignatureScript = sigScript
+		return nil
+
+	default:
+		return fmt.Errorf("%w: %w: %v", ErrUnsolvableInput,
+			ErrUnsupportedSigningClass, class)
+	}
+}
+
+// signWitnessProgram 返回花费见证程序 program 的见证。 program 可以是输出脚本本身，也可以是 P2SH 兑换脚本。
+func signWitnessProgram(chainParams *chaincfg.Params, tx *wire.MsgTx, idx int,
+	program []byte, amt int64, sigHashes *TxSigHashes, hashType SigHashType,
+	kdb KeyDB, sdb ScriptDB, opts []TaprootSignOption) (wire.TxWitness, error) {
+
+	class, addresses, _, err := ExtractPkScriptAddrs(program, chainParams)
+	if err != nil {
+		return nil, err
+	}
+	if len(addresses) != 1 {
+		return nil, fmt.Errorf("%w: unable to extract witness program "+
+			"address", ErrUnsolvableInput)
+	}
+
+	signer := witnessSigner{kdb: kdb, sigHashes: sigHashes, amt: amt}
+	switch class {
+	case WitnessV0PubKeyHashTy:
+		// The sighash calculation expands a P2WPKH program into the
+		// implied P2PKH script itself.
+		sig, pkData, err := signer.signTxIn(tx, idx, program, hashType,
+			addresses[0])
+		if err != nil {
+			return nil, err
+		}
+		return wire.TxWitness{sig, pkData}, nil
+
+	case WitnessV0ScriptHashTy:
+		witnessScript, err := sdb.GetScript(addresses[0])
+		if err != nil {
+			return nil, err
+		}
+		scriptHash := sha256.Sum256(witnessScript)
+		if !bytes.Equal(scriptHash[:], program[2:]) {
+			return nil, fmt.Errorf("%w: %w: witness script does not "+
+				"match witness program", ErrUnsolvableInput,
+				ErrScriptHashMismatch)
+		}
+
+		switch scriptClass := GetScriptClass(witnessScript); scriptClass {
+		case PubKeyTy, PubKeyHashTy, MultiSigTy:
+		default:
+			return nil, fmt.Errorf("%w: %w: witness script class %v",
+				ErrUnsolvableInput, ErrUnsupportedSigningClass,
+				scriptClass)
+		}
+
+		sigScript, scriptClass, _, nRequired, err := sign(chainParams, tx,
+			idx, witnessScript, hashType, signer, sdb)
+		if err != nil {
+			return nil, err
+		}
+		items, err := PushedData(sigScript)
+		if err != nil {
+			return nil, err
+		}
+
+		// Unlike signature scripts, a partially signed witness can't be
+		// merged later on, so report it instead of silently returning a
+		// witness that fails validation. The first item is the dummy
+		// element consumed by OP_CHECKMULTISIG.
+		if scriptClass == MultiSigTy && len(items)-1 < nRequired {
+			return nil, fmt.Errorf("%w: have %d of %d signatures",
+				ErrMultisigIncomplete, len(items)-1, nRequired)
+		}
+
+		return append(wire.TxWitness(items), witnessScript), nil
+
+	case WitnessV1TaprootTy:
+		key, _, err := kdb.GetKey(addresses[0])
+		if err != nil {
+			return nil, err
+		}
+		outputKey := ComputeTaprootKeyNoScript(key.PubKey())
+		if !bytes.Equal(schnorr.SerializePubKey(outputKey), program[2:]) {
+			return nil, fmt.Errorf("%w: %w: key does not match "+
+				"BIP0086 output key", ErrUnsolvableInput,
+				ErrSigningKeyMismatch)
+		}
+		return TaprootWitnessSignature(tx, sigHashes, idx, amt, program,
+			hashType, key, opts...)
+
+	default:
+		return nil, fmt.Errorf("%w: %w: witness program class %v",
+			ErrUnsolvableInput, ErrUnsupportedSigningClass, class)
+	}
+}
@@ -0,0 +1,237 @@
+package txscript
+
+import (
+	"crypto/sha256"
+	"errors"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcec/v2/schnorr"
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/stretchr/testify/require"
+)
+
+// TestSignAllInputs 确保 SignAllInputs 为各种脚本类型的输入生成可以通过验证的签名脚本和见证，并按索引报告无法签名的输入。
+func TestSignAllInputs(t *testing.T) {
+	t.Parallel()
+
+	params := &chaincfg.TestNet3Params
+	keys := make(map[string]addressToKey)
+	scripts := make(map[string][]byte)
+
+	newKey := func() *btcec.PrivateKey {
+		key, err := btcec.NewPrivateKey()
+		require.NoError(t, err)
+		return key
+	}
+	pubKeyAddr := func(key *btcec.PrivateKey) *btcutil.AddressPubKey {
+		addr, err := btcutil.NewAddressPubKey(
+			key.PubKey().SerializeCompressed(), params,
+		)
+		require.NoError(t, err)
+		keys[addr.EncodeAddress()] = addressToKey{key, true}
+		return addr
+	}
+	payTo := func(addr btcutil.Address) []byte {
+		pkScript, err := PayToAddrScript(addr)
+		require.NoError(t, err)
+		return pkScript
+	}
+
+	// P2PKH.
+	p2pkhKey := newKey()
+	p2pkhAddr, err := btcutil.NewAddressPubKeyHash(
+		btcutil.Hash160(p2pkhKey.PubKey().SerializeCompressed()), params,
+	)
+	require.NoError(t, err)
+	keys[p2pkhAddr.EncodeAddress()] = addressToKey{p2pkhKey, true}
+
+	// P2WPKH.
+	p2wpkhKey := newKey()
+	p2wpkhAddr, err := btcutil.NewAddressWitnessPubKeyHash(
+		btcutil.Hash160(p2wpkhKey.PubKey().SerializeCompressed()), params,
+	)
+	require.NoError(t, err)
+	keys[p2wpkhAddr.EncodeAddress()] = addressToKey{p2wpkhKey, true}
+
+	// P2SH-P2WPKH.
+	nestedKey := newKey()
+	nestedProgramAddr, err := btcutil.NewAddressWitnessPubKeyHash(
+		btcutil.Hash160(nestedKey.PubKey().SerializeCompressed()), params,
+	)
+	require.NoError(t, err)
+	keys[nestedProgramAddr.EncodeAddress()] = addressToKey{nestedKey, true}
+	nestedProgram := payTo(nestedProgramAddr)
+	nestedAddr, err := btcutil.NewAddressScriptHash(nestedProgram, params)
+	require.NoError(t, err)
+	scripts[nestedAddr.EncodeAddress()] = nestedProgram
+
+	// P2WSH 2-of-3 多重签名，只持有其中两个密钥。
+	msKey1, msKey2 := newKey(), newKey()
+	msAddr3, err := btcutil.NewAddressPubKey(
+		newKey().PubKey().SerializeCompressed(), params,
+	)
+	require.NoError(t, err)
+	witnessScript, err := MultiSigScript([]*btcutil.AddressPubKey{
+		pubKeyAddr(msKey1), msAddr3, pubKeyAddr(msKey2),
+	}, 2)
+	require.NoError(t, err)
+	scriptHash := sha256.Sum256(witnessScript)
+	p2wshAddr, err := btcutil.NewAddressWitnessScriptHash(
+		scriptHash[:], params,
+	)
+	require.NoError(t, err)
+	scripts[p2wshAddr.EncodeAddress()] = witnessScript
+
+	// P2TR BIP0086 密钥路径。
+	trKey := newKey()
+	trAddr, err := btcutil.NewAddressTaproot(
+		schnorr.SerializePubKey(ComputeTaprootKeyNoScript(trKey.PubKey())),
+		params,
+	)
+	require.NoError(t, err)
+	keys[trAddr.EncodeAddress()] = addressToKey{trKey, true}
+
+	pkScripts := [][]byte{
+		payTo(p2pkhAddr),
+		payTo(p2wpkhAddr),
+		payTo(nestedAddr),
+		payTo(p2wshAddr),
+		payTo(trAddr),
+	}
+
+	tx := wire.NewMsgTx(2)
+	prevOuts := NewMultiPrevOutFetcher(nil)
+	for i, pkScript := range pkScripts {
+		op := wire.OutPoint{Index: uint32(i)}
+		tx.AddTxIn(&wire.TxIn{PreviousOutPoint: op})
+		prevOuts.AddPrevOut(op, wire.NewTxOut(int64(i+1)*1e6, pkScript))
+	}
+	tx.AddTxOut(wire.NewTxOut(1e6, pkScripts[0]))
+
+	err = SignAllInputs(
+		params, tx, prevOuts, mkGetKey(keys), mkGetScript(scripts),
+		SigHashAll,
+	)
+	require.NoError(t, err)
+
+	// 嵌套的见证输出的签名脚本只推送兑换脚本，原生见证输出没有签名脚本。
+	require.Empty(t, tx.TxIn[1].SignatureScript)
+	require.Len(t, tx.TxIn[2].Witness, 2)
+	require.Len(t, tx.TxIn[3].Witness, 4)
+	require.Len(t, tx.TxIn[4].Witness, 1)
+
+	sigHashes := NewTxSigHashes(tx, prevOuts)
+	for i, pkScript := range pkScripts {
+		vm, err := NewEngine(
+			pkScript, tx, i, StandardVerifyFlags, nil, sigHashes,
+			int64(i+1)*1e6, prevOuts,
+		)
+		require.NoError(t, err)
+		require.NoError(t, vm.Execute(), "input %d", i)
+	}
+
+	// 添加一个无法签名的输出类型和一个没有密钥的输出，其余输入仍然必须被签名。
+	nullData, err := NullDataScript([]byte("data"))
+	require.NoError(t, err)
+	unknownAddr, err := btcutil.NewAddressWitnessPubKeyHash(
+		btcutil.Hash160(newKey().PubKey().SerializeCompressed()), params,
+	)
+	require.NoError(t, err)
+	for i, pkScript := range [][]byte{nullData, payTo(unknownAddr)} {
+		op := wire.OutPoint{Index: uint32(len(pkScripts) + i)}
+		tx.AddTxIn(&wire.TxIn{PreviousOutPoint: op})
+		prevOuts.AddPrevOut(op, wire.NewTxOut(1e6, pkScript))
+	}
+	for _, txIn := range tx.TxIn {
+		txIn.SignatureScript = nil
+		txIn.Witness = nil
+	}
+
+	err = SignAllInputs(
+		params, tx, prevOuts, mkGetKey(keys), mkGetScript(scripts),
+		SigHashAll,
+	)
+	var inputErrs InputSigningErrors
+	require.True(t, errors.As(err, &inputErrs))
+	require.Len(t, inputErrs, 2)
+	require.Equal(t, 5, inputErrs[0].Index)
+	require.ErrorIs(t, inputErrs[0], ErrUnsolvableInput)
+	require.Equal(t, 6, inputErrs[1].Index)
+	require.ErrorIs(t, err, ErrUnsolvableInput)
+	require.NotEmpty(t, tx.TxIn[0].SignatureScript)
+	require.NotEmpty(t, tx.TxIn[4].Witness)
+
+	// 缺少前一输出时不会签名任何输入。
+	tx.AddTxIn(&wire.TxIn{PreviousOutPoint: wire.OutPoint{Index: 100}})
+	tx.TxIn[0].SignatureScript = nil
+	err = SignAllInputs(
+		params, tx, prevOuts, mkGetKey(keys), mkGetScript(scripts),
+		SigHashAll,
+	)
+	require.True(t, errors.As(err, &inputErrs))
+	require.Len(t, inputErrs, 1)
+	require.Equal(t, 7, inputErrs[0].Index)
+	require.ErrorIs(t, err, ErrUnsolvableInput)
+	require.Empty(t, tx.TxIn[0].SignatureScript)
+}
+
+// TestSignAllInputsIncompleteMultisig 确保无法满足阈值的 P2WSH 多重签名输入被报告为未完成，而不是生成无效的见证。
+func TestSignAllInputsIncompleteMultisig(t *testing.T) {
+	t.Parallel()
+
+	params := &chaincfg.TestNet3Params
+	key1, err := btcec.NewPrivateKey()
+	require.NoError(t, err)
+	key2, err := btcec.NewPrivateKey()
+	require.NoError(t, err)
+
+	addr1, err := btcutil.NewAddressPubKey(
+		key1.PubKey().SerializeCompressed(), params,
+	)
+	require.NoError(t, err)
+	addr2, err := btcutil.NewAddressPubKey(
+		key2.PubKey().SerializeCompressed(), params,
+	)
+	require.NoError(t, err)
+	witnessScript, err := MultiSigScript(
+		[]*btcutil.AddressPubKey{addr1, addr2}, 2,
+	)
+	require.NoError(t, err)
+	scriptHash := sha256.Sum256(witnessScript)
+	pkScript, err := payToWitnessScriptHashScript(scriptHash[:])
+	require.NoError(t, err)
+	p2wshAddr, err := btcutil.NewAddressWitnessScriptHash(
+		scriptHash[:], params,
+	)
+	require.NoError(t, err)
+
+	tx := wire.NewMsgTx(2)
+	tx.AddTxIn(&wire.TxIn{})
+	tx.AddTxOut(wire.NewTxOut(1e6, pkScript))
+	prevOuts := NewCannedPrevOutputFetcher(pkScript, 2e6)
+
+	keys := map[string]addressToKey{
+		addr1.EncodeAddress(): {key1, true},
+	}
+	scripts := map[string][]byte{
+		p2wshAddr.EncodeAddress(): witnessScript,
+	}
+	err = SignAllInputs(
+		params, tx, prevOuts, mkGetKey(keys), mkGetScript(scripts),
+		SigHashAll,
+	)
+	require.ErrorIs(t, err, ErrMultisigIncomplete)
+	require.Empty(t, tx.TxIn[0].Witness)
+
+	// 见证脚本与见证程序不匹配时必须报错。
+	scripts[p2wshAddr.EncodeAddress()] = []byte{OP_TRUE}
+	keys[addr2.EncodeAddress()] = addressToKey{key2, true}
+	err = SignAllInputs(
+		params, tx, prevOuts, mkGetKey(keys), mkGetScript(scripts),
+		SigHashAll,
+	)
+	require.ErrorIs(t, err, ErrUnsolvableInput)
+}
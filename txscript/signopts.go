@@ -0,0 +1,167 @@
+// 包含 taproot 签名的 nonce 生成选项，控制 BIP0340 签名使用的辅助随机数。
+
+package txscript
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcec/v2/schnorr"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+)
+
+// ErrInvalidSigningNonce 在无法为 taproot 签名生成有效 nonce 时返回。
+var ErrInvalidSigningNonce = errors.New("invalid signing nonce")
+
+// taprootSignOptions 保存 taproot 签名的 nonce 生成配置。
+type taprootSignOptions struct {
+	// auxRand 是固定的 BIP0340 辅助随机数，为 nil 时从 auxReader 读取。
+	auxRand *[32]byte
+
+	// auxReader 是辅助随机数的来源，默认为 crypto/rand。
+	auxReader io.Reader
+
+	// rfc6979 表示使用 RFC6979 派生 nonce，而不是 BIP0340 的辅助随机数方案。
+	rfc6979 bool
+
+	// chainTag 是被签署的签名哈希提交的链域分隔标签。
+	chainTag ChainTag
+
+	// annex 是被签署的签名哈希提交的附件，为 nil 时签名哈希不提交附件。
+	annex []byte
+}
+
+// TaprootSignOption 是修改 taproot 签名 nonce 生成方式的函数选项。
+type TaprootSignOption func(*taprootSignOptions)
+
+// WithAuxRand 使用固定的 32 字节辅助随机数签名。 相同的私钥、消息和辅助随机数总是生成相同的签名，
+// 因此适用于可复现的测试和测试向量，但在生产环境中重复使用同一辅助随机数会失去其对侧信道攻击的保护。
+func WithAuxRand(aux [32]byte) TaprootSignOption {
+	return func(o *taprootSignOptions) {
+		o.auxRand = &aux
+	}
+}
+
+// WithAuxRandReader 从调用方提供的熵源读取每个签名的 32 字节辅助随机数，例如硬件随机数生成器。
+func WithAuxRandReader(r io.Reader) TaprootSignOption {
+	return func(o *taprootSignOptions) {
+		o.auxReader = r
+	}
+}
+
+// WithRFC6979Nonce 使用 RFC6979 派生的确定性 nonce 签名，即在引入辅助随机数选项之前的行为。
+// 该方案不是 BIP0340 指定的 nonce 生成方式，生成的签名仍然有效，但无法通过 TaprootSigningNonce 审计。
+func WithRFC6979Nonce() TaprootSignOption {
+	return func(o *taprootSignOptions) {
+		o.rfc6979 = true
+	}
+}
+
+// defaultTaprootSignOptions 返回默认配置：每个签名从 crypto/rand 读取辅助随机数。
+func defaultTaprootSignOptions() *taprootSignOptions {
+	return &taprootSignOptions{
+		auxReader: rand.Reader,
+	}
+}
+
+// signingSigHashOptions 返回选项指定的计算被签署的签名哈希时使用的选项，即链域分隔标签和附件。
+// 附件不以 TaprootAnnexTag 开头时返回错误，因为这样的附件无法出现在见证中。
+func signingSigHashOptions(opts []TaprootSignOption) ([]TaprootSigHashOption,
+	error) {
+
+	o := defaultTaprootSignOptions()
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	sigHashOpts := []TaprootSigHashOption{WithTaprootChainTag(o.chainTag)}
+	if o.annex != nil {
+		if len(o.annex) == 0 || o.annex[0] != TaprootAnnexTag {
+			return nil, fmt.Errorf("%w: missing annex tag",
+				ErrMalformedAnnex)
+		}
+		sigHashOpts = append(sigHashOpts, WithAnnex(o.annex))
+	}
+	return sigHashOpts, nil
+}
+
+// signingAnnex 返回选项指定的被签署的签名哈希提交的附件，没有时返回 nil。
+func signingAnnex(opts []TaprootSignOption) []byte {
+	o := defaultTaprootSignOptions()
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o.annex
+}
+
+// schnorrSign 按照选项对 32 字节的消息哈希进行签名。
+func schnorrSign(privKey *btcec.PrivateKey, hash []byte,
+	opts []TaprootSignOption) (*schnorr.Signature, error) {
+
+	o := defaultTaprootSignOptions()
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	if o.rfc6979 {
+		return schnorr.Sign(privKey, hash)
+	}
+
+	var aux [32]byte
+	if o.auxRand != nil {
+		aux = *o.auxRand
+	} else if _, err := io.ReadFull(o.auxReader, aux[:]); err != nil {
+		return nil, fmt.Errorf("%w: unable to read auxiliary "+
+			"randomness: %v", ErrInvalidSigningNonce, err)
+	}
+
+	return schnorr.Sign(privKey, hash, schnorr.CustomNonce(aux))
+}
+
+// TaprootSigningNonce 返回 BIP0340 为私钥、32 字节消息哈希和辅助随机数派生的 nonce 标量 k'，
+// 与 WithAuxRand 签名时使用的 nonce 相同，因此审计方可以独立验证签名的 nonce 点 R = k'*G 的 x 坐标等于签名的前 32 字节。
+// 对于 taproot 密钥路径花费，privKey 必须是经过 TweakTaprootPrivKey 调整后的私钥。
+func TaprootSigningNonce(privKey *btcec.PrivateKey, hash []byte,
+	aux [32]byte) (*btcec.ModNScalar, error) {
+
+	if len(hash) != 32 {
+		return nil, fmt.Errorf("%w: message hash must be 32 bytes, got %d",
+			ErrInvalidSigningNonce, len(hash))
+	}
+
+	// d = private key, negated if P = d*G has an odd y coordinate.
+	d := privKey.Key
+	if d.IsZero() {
+		return nil, fmt.Errorf("%w: private key is zero",
+			ErrInvalidSigningNonce)
+	}
+	pubKey := privKey.PubKey()
+	if pubKey.SerializeCompressed()[0] == 0x03 {
+		d.Negate()
+	}
+
+	// t = bytes(d) xor tagged_hash("BIP0340/aux", a)
+	var t [32]byte
+	d.PutBytes(&t)
+	auxHash := chainhash.TaggedHash(chainhash.TagBIP0340Aux, aux[:])
+	for i := range t {
+		t[i] ^= auxHash[i]
+	}
+
+	// k' = tagged_hash("BIP0340/nonce", t || P || m) mod n
+	nonceHash := chainhash.TaggedHash(
+		chainhash.TagBIP0340Nonce, t[:], schnorr.SerializePubKey(pubKey),
+		hash,
+	)
+	var k btcec.ModNScalar
+	k.SetBytes((*[32]byte)(nonceHash))
+	if k.IsZero() {
+		return nil, fmt.Errorf("%w: generated nonce is zero",
+			ErrInvalidSigningNonce)
+	}
+
+	return &k, nil
+}
@@ -0,0 +1,149 @@
+package txscript
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcec/v2/schnorr"
+	"github.com/stretchr/testify/require"
+)
+
+// TestSchnorrSignAuxRand 确保固定辅助随机数生成 BIP0340 测试向量中的签名，
+// 并且 TaprootSigningNonce 返回的 nonce 与签名的 nonce 点一致。
+func TestSchnorrSignAuxRand(t *testing.T) {
+	t.Parallel()
+
+	mustHex := func(s string) []byte {
+		b, err := hex.DecodeString(s)
+		require.NoError(t, err)
+		return b
+	}
+
+	// BIP0340 测试向量 1。
+	privKey, _ := btcec.PrivKeyFromBytes(mustHex(
+		"B7E151628AED2A6ABF7158809CF4F3C762E7160F38B4DA56A784D9045190CFEF",
+	))
+	msg := mustHex(
+		"243F6A8885A308D313198A2E03707344A4093822299F31D0082EFA98EC4E6C89",
+	)
+	var aux [32]byte
+	aux[31] = 1
+	want := mustHex(
+		"6896BD60EEAE296DB48A229FF71DFE071BDE413E6D43F917DC8DCF8C78DE3341" +
+			"8906D11AC976ABCCB20B091292BFF4EA897EFCB639EA871CFA95F6DE339E4B0A",
+	)
+
+	sig, err := schnorrSign(privKey, msg, []TaprootSignOption{WithAuxRand(aux)})
+	require.NoError(t, err)
+	require.Equal(t, want, sig.Serialize())
+
+	// 从读取器获取相同的辅助随机数会生成相同的签名。
+	sig, err = schnorrSign(privKey, msg, []TaprootSignOption{
+		WithAuxRandReader(bytes.NewReader(aux[:])),
+	})
+	require.NoError(t, err)
+	require.Equal(t, want, sig.Serialize())
+
+	k, err := TaprootSigningNonce(privKey, msg, aux)
+	require.NoError(t, err)
+	var r btcec.JacobianPoint
+	btcec.ScalarBaseMultNonConst(k, &r)
+	r.ToAffine()
+	rBytes := r.X.Bytes()
+	require.Equal(t, want[:32], rBytes[:])
+
+	// 默认使用新的随机数，因此两次签名不同但都有效。
+	sig1, err := schnorrSign(privKey, msg, nil)
+	require.NoError(t, err)
+	sig2, err := schnorrSign(privKey, msg, nil)
+	require.NoError(t, err)
+	require.NotEqual(t, sig1.Serialize(), sig2.Serialize())
+	require.True(t, sig1.Verify(msg, privKey.PubKey()))
+	require.True(t, sig2.Verify(msg, privKey.PubKey()))
+
+	// RFC6979 nonce 是确定性的。
+	sig1, err = schnorrSign(privKey, msg, []TaprootSignOption{WithRFC6979Nonce()})
+	require.NoError(t, err)
+	sig2, err = schnorrSign(privKey, msg, []TaprootSignOption{WithRFC6979Nonce()})
+	require.NoError(t, err)
+	require.Equal(t, sig1.Serialize(), sig2.Serialize())
+
+	// 熵源不足或出错时返回错误。
+	_, err = schnorrSign(privKey, msg, []TaprootSignOption{
+		WithAuxRandReader(bytes.NewReader(aux[:16])),
+	})
+	require.ErrorIs(t, err, ErrInvalidSigningNonce)
+
+	_, err = TaprootSigningNonce(privKey, msg[:31], aux)
+	require.ErrorIs(t, err, ErrInvalidSigningNonce)
+}
+
+// TestTaprootSignatureAuxRand 确保密钥路径和脚本路径签名函数遵循辅助随机数选项，生成的签名可以通过脚本引擎验证。
+func TestTaprootSignatureAuxRand(t *testing.T) {
+	t.Parallel()
+
+	privKey, err := btcec.NewPrivateKey()
+	require.NoError(t, err)
+	outputKey := ComputeTaprootKeyNoScript(privKey.PubKey())
+	pkScript, err := PayToTaprootScript(outputKey)
+	require.NoError(t, err)
+
+	const amt = 1e8
+	prevFetcher := NewCannedPrevOutputFetcher(pkScript, amt)
+	tx := createSpendingTx(nil, nil, pkScript, amt)
+	sigHashes := NewTxSigHashes(tx, prevFetcher)
+
+	var aux [32]byte
+	aux[0] = 0xaa
+
+	witness1, err := TaprootWitnessSignature(tx, sigHashes, 0, amt, pkScript,
+		SigHashDefault, privKey, WithAuxRand(aux))
+	require.NoError(t, err)
+	witness2, err := TaprootWitnessSignature(tx, sigHashes, 0, amt, pkScript,
+		SigHashDefault, privKey, WithAuxRand(aux))
+	require.NoError(t, err)
+	require.Equal(t, witness1, witness2)
+
+	// 签名的 nonce 可以使用调整后的私钥独立重新计算。
+	sigHash, err := CalcTaprootSignatureHash(sigHashes, SigHashDefault, tx, 0,
+		prevFetcher)
+	require.NoError(t, err)
+	k, err := TaprootSigningNonce(TweakTaprootPrivKey(*privKey, []byte{}),
+		sigHash, aux)
+	require.NoError(t, err)
+	var r btcec.JacobianPoint
+	btcec.ScalarBaseMultNonConst(k, &r)
+	r.ToAffine()
+	rBytes := r.X.Bytes()
+	require.Equal(t, rBytes[:], []byte(witness1[0][:32]))
+
+	tx.TxIn[0].Witness = witness1
+	vm, err := NewEngine(pkScript, tx, 0, StandardVerifyFlags, nil,
+		sigHashes, amt, prevFetcher)
+	require.NoError(t, err)
+	require.NoError(t, vm.Execute())
+
+	leaf := NewBaseTapLeaf([]byte{OP_TRUE})
+	sig1, err := RawTxInTapscriptSignature(tx, sigHashes, 0, amt, pkScript,
+		leaf, SigHashAll, privKey, WithAuxRand(aux))
+	require.NoError(t, err)
+	sig2, err := RawTxInTapscriptSignature(tx, sigHashes, 0, amt, pkScript,
+		leaf, SigHashAll, privKey, WithAuxRand(aux))
+	require.NoError(t, err)
+	require.Equal(t, sig1, sig2)
+	require.Len(t, sig1, schnorr.SignatureSize+1)
+
+	_, err = RawTxInTapscriptSignature(tx, sigHashes, 0, amt, pkScript,
+		leaf, SigHashAll, privKey, WithAuxRandReader(errReader{}))
+	require.ErrorIs(t, err, ErrInvalidSigningNonce)
+}
+
+// errReader 是总是返回错误的读取器。
+type errReader struct{}
+
+func (errReader) Read([]byte) (int, error) {
+	return 0, errors.New("entropy source unavailable")
+}
@@ -0,0 +1,144 @@
+// 包含按操作码分类的签名操作计数，以及 tapscript 签名操作预算的模拟，使手续费和权重策略可以使用准确的签名操作数。
+
+package txscript
+
+// SigOpCounts 是 CountSigOpsDetailed 按操作码分类的签名操作计数。
+type SigOpCounts struct {
+	// CheckSig 是 OP_CHECKSIG 的数量。
+	CheckSig int
+
+	// CheckSigVerify 是 OP_CHECKSIGVERIFY 的数量。
+	CheckSigVerify int
+
+	// CheckMultiSig 是 OP_CHECKMULTISIG 的数量。
+	CheckMultiSig int
+
+	// CheckMultiSigVerify 是 OP_CHECKMULTISIGVERIFY 的数量。
+	CheckMultiSigVerify int
+
+	// MultiSigKeys 是紧跟在 OP_1 到 OP_16 之后的多重签名操作码的公钥数之和，即可以准确计数的多重签名操作。
+	MultiSigKeys int
+
+	// MultiSigUnknown 是公钥数无法从前一个操作码得知的多重签名操作码数量，精确计数时每个按 MaxPubKeysPerMultiSig 计算。
+	// 注意与共识规则一致，前一个操作码为 OP_0 时也计入此项。
+	MultiSigUnknown int
+
+	// CheckSigAdd 是 OP_CHECKSIGADD 的数量。 该操作码只在 tapscript 中是签名操作。
+	CheckSigAdd int
+}
+
+// multiSigOps 返回多重签名操作码的总数。
+func (c *SigOpCounts) multiSigOps() int {
+	return c.CheckMultiSig + c.CheckMultiSigVerify
+}
+
+// Legacy 返回按 GetSigOpCount 的规则计算的签名操作数，每个多重签名操作码按 MaxPubKeysPerMultiSig 计算。
+func (c *SigOpCounts) Legacy() int {
+	return c.CheckSig + c.CheckSigVerify +
+		c.multiSigOps()*MaxPubKeysPerMultiSig
+}
+
+// Precise 返回按 P2SH 和见证脚本的精确规则计算的签名操作数，可以准确计数的多重签名操作按公钥数计算。
+func (c *SigOpCounts) Precise() int {
+	return c.CheckSig + c.CheckSigVerify + c.MultiSigKeys +
+		c.MultiSigUnknown*MaxPubKeysPerMultiSig
+}
+
+// Tapscript 返回脚本作为 tapscript 执行时最多消耗预算的签名操作数，即 OP_CHECKSIG、OP_CHECKSIGVERIFY 和
+// OP_CHECKSIGADD 的数量。 多重签名操作码在 tapscript 中被禁用，因此不计入。
+func (c *SigOpCounts) Tapscript() int {
+	return c.CheckSig + c.CheckSigVerify + c.CheckSigAdd
+}
+
+// total 返回 countSigOpsV0 使用的签名操作数。
+func (c *SigOpCounts) total(precise bool) int {
+	if precise {
+		return c.Precise()
+	}
+	return c.Legacy()
+}
+
+// CountSigOpsDetailed 返回给定版本的脚本中按操作码分类的签名操作计数。 版本必须是 0 或通过 RegisterScriptVersion 注册的版本。
+//
+// 与 countSigOpsV0 相同，计数在第一个解析错误处停止，此时返回错误之前的计数和解析错误。
+func CountSigOpsDetailed(script []byte, version uint16) (SigOpCounts, error) {
+	var counts SigOpCounts
+	tokenizer := MakeScriptTokenizer(version, script)
+	prevOp := byte(OP_INVALIDOPCODE)
+	for tokenizer.Next() {
+		op := tokenizer.Opcode()
+		switch op {
+		case OP_CHECKSIG:
+			counts.CheckSig++
+
+		case OP_CHECKSIGVERIFY:
+			counts.CheckSigVerify++
+
+		case OP_CHECKSIGADD:
+			counts.CheckSigAdd++
+
+		case OP_CHECKMULTISIG, OP_CHECKMULTISIGVERIFY:
+			if op == OP_CHECKMULTISIG {
+				counts.CheckMultiSig++
+			} else {
+				counts.CheckMultiSigVerify++
+			}
+
+			// Note that OP_0 is treated as unknown here despite it being
+			// a valid small integer in order to match the consensus
+			// rules, see countSigOpsV0.
+			if prevOp >= OP_1 && prevOp <= OP_16 {
+				counts.MultiSigKeys += AsSmallInt(prevOp)
+			} else {
+				counts.MultiSigUnknown++
+			}
+
+		default:
+			// Not a sigop.
+		}
+
+		prevOp = op
+	}
+
+	return counts, tokenizer.Err()
+}
+
+// TapscriptSigOpBudget 是 SimulateTapscriptSigOpBudget 的结果。
+type TapscriptSigOpBudget struct {
+	// Budget 是 BIP0342 规定的初始预算，即 50 加上输入见证的序列化大小。
+	Budget int
+
+	// SigOps 是脚本最多执行的签名操作数，假设每个签名操作都被执行且签名非空。
+	SigOps int
+
+	// Remaining 是执行所有签名操作后剩余的预算，为负数时表示预算不足。
+	Remaining int
+}
+
+// WithinBudget 返回脚本的所有签名操作是否都在预算之内，即在最坏情况下也不会因 ErrTaprootMaxSigOps 失败。
+func (b *TapscriptSigOpBudget) WithinBudget() bool {
+	return b.Remaining >= 0
+}
+
+// MaxSigOps 返回预算最多允许的签名操作数。
+func (b *TapscriptSigOpBudget) MaxSigOps() int {
+	return b.Budget / sigOpsDelta
+}
+
+// SimulateTapscriptSigOpBudget 模拟以序列化大小为 witnessSize 的见证花费 tapscript 叶子时的签名操作预算。
+// witnessSize 应当是整个输入见证的序列化大小，即 wire.TxWitness.SerializeSize 的返回值，与引擎使用的大小相同。
+//
+// 由于 tapscript 中没有循环，脚本中的每个签名操作最多执行一次，因此模拟结果是一个上界：
+// 未执行的分支和空签名不消耗预算。 脚本无法解析时返回解析错误之前的结果和解析错误。
+func SimulateTapscriptSigOpBudget(script []byte,
+	witnessSize int) (TapscriptSigOpBudget, error) {
+
+	counts, err := CountSigOpsDetailed(script, 0)
+	budget := sigOpsDelta + witnessSize
+	sigOps := counts.Tapscript()
+	return TapscriptSigOpBudget{
+		Budget:    budget,
+		SigOps:    sigOps,
+		Remaining: budget - sigOps*sigOpsDelta,
+	}, err
+}
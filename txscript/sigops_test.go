@@ -0,0 +1,118 @@
+package txscript
+
+import (
+	"testing"
+
+	"github.com/btcsuite/btcd/wire"
+	"github.com/stretchr/testify/require"
+)
+
+// TestCountSigOpsDetailed 确保按操作码分类的计数正确，并且与 countSigOpsV0 的结果一致。
+func TestCountSigOpsDetailed(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		script   string
+		counts   SigOpCounts
+		legacy   int
+		precise  int
+		parseErr bool
+	}{{
+		name:   "empty",
+		script: "",
+	}, {
+		name:    "checksig",
+		script:  "DUP HASH160 DATA_1 0x01 EQUALVERIFY CHECKSIG",
+		counts:  SigOpCounts{CheckSig: 1},
+		legacy:  1,
+		precise: 1,
+	}, {
+		name:    "2-of-3 multisig",
+		script:  "2 DATA_1 0x01 DATA_1 0x02 DATA_1 0x03 3 CHECKMULTISIG",
+		counts:  SigOpCounts{CheckMultiSig: 1, MultiSigKeys: 3},
+		legacy:  20,
+		precise: 3,
+	}, {
+		name:    "multisig without small int",
+		script:  "DUP CHECKMULTISIGVERIFY",
+		counts:  SigOpCounts{CheckMultiSigVerify: 1, MultiSigUnknown: 1},
+		legacy:  20,
+		precise: 20,
+	}, {
+		name:    "multisig after OP_0",
+		script:  "0 CHECKMULTISIG",
+		counts:  SigOpCounts{CheckMultiSig: 1, MultiSigUnknown: 1},
+		legacy:  20,
+		precise: 20,
+	}, {
+		name:   "mixed",
+		script: "CHECKSIGVERIFY CHECKSIGADD 16 CHECKMULTISIGVERIFY CHECKSIG",
+		counts: SigOpCounts{
+			CheckSig:            1,
+			CheckSigVerify:      1,
+			CheckSigAdd:         1,
+			CheckMultiSigVerify: 1,
+			MultiSigKeys:        16,
+		},
+		legacy:  22,
+		precise: 18,
+	}, {
+		name:     "parse failure",
+		script:   "CHECKSIG CHECKSIG DATA_2 0x00",
+		counts:   SigOpCounts{CheckSig: 2},
+		legacy:   2,
+		precise:  2,
+		parseErr: true,
+	}}
+
+	for _, test := range tests {
+		script := mustParseShortForm(test.script)
+		counts, err := CountSigOpsDetailed(script, 0)
+		if test.parseErr {
+			require.Error(t, err, test.name)
+		} else {
+			require.NoError(t, err, test.name)
+		}
+		require.Equal(t, test.counts, counts, test.name)
+		require.Equal(t, test.legacy, counts.Legacy(), test.name)
+		require.Equal(t, test.precise, counts.Precise(), test.name)
+		require.Equal(t, test.legacy, countSigOpsV0(script, false),
+			test.name)
+		require.Equal(t, test.precise, countSigOpsV0(script, true),
+			test.name)
+	}
+
+	// 未注册的脚本版本无法解析。
+	_, err := CountSigOpsDetailed(mustParseShortForm("CHECKSIG"), 0xfffe)
+	require.True(t, IsErrorCode(err, ErrUnsupportedScriptVersion))
+}
+
+// TestSimulateTapscriptSigOpBudget 确保模拟的预算与引擎执行 tapscript 时的预算一致。
+func TestSimulateTapscriptSigOpBudget(t *testing.T) {
+	t.Parallel()
+
+	script := mustParseShortForm("CHECKSIG CHECKSIGADD CHECKSIGVERIFY " +
+		"2 CHECKMULTISIG")
+
+	budget, err := SimulateTapscriptSigOpBudget(script, 100)
+	require.NoError(t, err)
+	require.Equal(t, 150, budget.Budget)
+	require.Equal(t, 3, budget.SigOps)
+	require.Equal(t, 0, budget.Remaining)
+	require.Equal(t, 3, budget.MaxSigOps())
+	require.True(t, budget.WithinBudget())
+
+	budget, err = SimulateTapscriptSigOpBudget(script, 99)
+	require.NoError(t, err)
+	require.Equal(t, -1, budget.Remaining)
+	require.Equal(t, 2, budget.MaxSigOps())
+	require.False(t, budget.WithinBudget())
+
+	witness := wire.TxWitness{make([]byte, 64), script, make([]byte, 33)}
+	budget, err = SimulateTapscriptSigOpBudget(
+		script, witness.SerializeSize(),
+	)
+	require.NoError(t, err)
+	require.Equal(t, sigOpsDelta+witness.SerializeSize(), budget.Budget)
+}
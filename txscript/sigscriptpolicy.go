@@ -0,0 +1,178 @@
+// 包含签名脚本（scriptSig）的标准性检查，与输出端的脚本分类互补，供内存池在准入时报告具体的违规原因。
+
+package txscript
+
+import (
+	"fmt"
+)
+
+const (
+	// MaxStandardSigScriptSize 是标准交易中签名脚本的最大字节数。 该值足以容纳 15-of-15 的 P2SH 多重签名兑换脚本及其签名。
+	MaxStandardSigScriptSize = 1650
+
+	// MaxStandardP2SHSigOps 是标准交易中 P2SH 兑换脚本允许的最大签名操作数。
+	MaxStandardP2SHSigOps = 15
+
+	// MaxStandardWitnessScriptSize 是标准交易中 P2WSH 见证脚本的最大字节数。
+	MaxStandardWitnessScriptSize = 3600
+)
+
+// SigScriptViolationKind 表示签名脚本违反的标准性规则。
+type SigScriptViolationKind uint8
+
+const (
+	// SigScriptTooLarge 表示签名脚本超过 MaxStandardSigScriptSize。
+	SigScriptTooLarge SigScriptViolationKind = iota
+
+	// SigScriptUnparseable 表示签名脚本无法解析。
+	SigScriptUnparseable
+
+	// SigScriptNotPushOnly 表示签名脚本包含数据推送以外的操作码。
+	SigScriptNotPushOnly
+
+	// SigScriptNonMinimalPush 表示数据推送没有使用最短的编码。
+	SigScriptNonMinimalPush
+
+	// SigScriptMissingRedeemScript 表示花费 P2SH 输出的签名脚本没有推送兑换脚本。
+	SigScriptMissingRedeemScript
+
+	// SigScriptRedeemScriptTooLarge 表示 P2SH 兑换脚本超过了可推送的最大元素大小。
+	SigScriptRedeemScriptTooLarge
+
+	// SigScriptRedeemScriptTooManySigOps 表示 P2SH 兑换脚本的签名操作数超过 MaxStandardP2SHSigOps。
+	SigScriptRedeemScriptTooManySigOps
+)
+
+// sigScriptViolationNames 包含每种违规的名称。
+var sigScriptViolationNames = []string{
+	SigScriptTooLarge:                  "SigScriptTooLarge",
+	SigScriptUnparseable:               "SigScriptUnparseable",
+	SigScriptNotPushOnly:               "SigScriptNotPushOnly",
+	SigScriptNonMinimalPush:            "SigScriptNonMinimalPush",
+	SigScriptMissingRedeemScript:       "SigScriptMissingRedeemScript",
+	SigScriptRedeemScriptTooLarge:      "SigScriptRedeemScriptTooLarge",
+	SigScriptRedeemScriptTooManySigOps: "SigScriptRedeemScriptTooManySigOps",
+}
+
+// String 返回违规种类的名称。
+func (k SigScriptViolationKind) String() string {
+	if int(k) >= len(sigScriptViolationNames) {
+		return fmt.Sprintf("SigScriptViolationKind(%d)", uint8(k))
+	}
+	return sigScriptViolationNames[k]
+}
+
+// SigScriptViolation 描述签名脚本的一处标准性违规。
+type SigScriptViolation struct {
+	// Kind 是违反的规则。
+	Kind SigScriptViolationKind
+
+	// Offset 是违规操作码在签名脚本中的字节偏移量，违规与具体操作码无关时为 -1。
+	Offset int
+
+	// Description 是违规的可读描述。
+	Description string
+}
+
+// Error 实现 error 接口，使单个违规可以直接作为错误返回。
+func (v SigScriptViolation) Error() string {
+	if v.Offset < 0 {
+		return fmt.Sprintf("%v: %s", v.Kind, v.Description)
+	}
+	return fmt.Sprintf("%v at offset %d: %s", v.Kind, v.Offset,
+		v.Description)
+}
+
+// CheckSigScriptStandard 检查花费 pkScript 的签名脚本是否是标准的，并按发现顺序返回所有违规，标准时返回 nil。 检查的规则包括：
+//
+//   - 签名脚本不超过 MaxStandardSigScriptSize 字节
+//   - 签名脚本可以解析并且只包含数据推送
+//   - 每个数据推送都使用最短的编码（与 ScriptVerifyMinimalData 相同）
+//   - 花费 P2SH 输出时，兑换脚本不超过最大元素大小，并且签名操作数不超过 MaxStandardP2SHSigOps
+//
+// 与 ScriptVerifySigPushOnly 等引擎标志只给出第一个失败不同，调用者可以得到全部违规及其位置。
+func CheckSigScriptStandard(sigScript, pkScript []byte) []SigScriptViolation {
+	var violations []SigScriptViolation
+	if len(sigScript) > MaxStandardSigScriptSize {
+		violations = append(violations, SigScriptViolation{
+			Kind:   SigScriptTooLarge,
+			Offset: -1,
+			Description: fmt.Sprintf("signature script size %d exceeds "+
+				"max standard size %d", len(sigScript),
+				MaxStandardSigScriptSize),
+		})
+	}
+
+	const scriptVersion = 0
+	var lastPush []byte
+	pushOnly := true
+	tokenizer := MakeScriptTokenizer(scriptVersion, sigScript)
+	for offset := 0; tokenizer.Next(); offset = int(tokenizer.ByteIndex()) {
+		op := tokenizer.Opcode()
+		if op > OP_16 {
+			pushOnly = false
+			violations = append(violations, SigScriptViolation{
+				Kind:   SigScriptNotPushOnly,
+				Offset: offset,
+				Description: fmt.Sprintf("opcode %s is not a data push",
+					opcodeArray[op].name),
+			})
+			continue
+		}
+
+		data := tokenizer.Data()
+		if err := checkMinimalDataPush(&opcodeArray[op], data); err != nil {
+			violations = append(violations, SigScriptViolation{
+				Kind:        SigScriptNonMinimalPush,
+				Offset:      offset,
+				Description: err.Error(),
+			})
+		}
+		lastPush = data
+	}
+	if err := tokenizer.Err(); err != nil {
+		return append(violations, SigScriptViolation{
+			Kind:        SigScriptUnparseable,
+			Offset:      int(tokenizer.ByteIndex()),
+			Description: err.Error(),
+		})
+	}
+
+	// The redeem script can only be identified when the signature script
+	// is push only, as the engine would reject it otherwise anyway.
+	if !isScriptHashScript(pkScript) || !pushOnly {
+		return violations
+	}
+	if tokenizer.OpcodePosition() < 0 {
+		return append(violations, SigScriptViolation{
+			Kind:        SigScriptMissingRedeemScript,
+			Offset:      -1,
+			Description: "empty signature script spending a P2SH output",
+		})
+	}
+
+	if len(lastPush) > MaxScriptElementSize {
+		violations = append(violations, SigScriptViolation{
+			Kind:   SigScriptRedeemScriptTooLarge,
+			Offset: -1,
+			Description: fmt.Sprintf("redeem script size %d exceeds max "+
+				"element size %d", len(lastPush), MaxScriptElementSize),
+		})
+	}
+	if sigOps := countSigOpsV0(lastPush, true); sigOps > MaxStandardP2SHSigOps {
+		violations = append(violations, SigScriptViolation{
+			Kind:   SigScriptRedeemScriptTooManySigOps,
+			Offset: -1,
+			Description: fmt.Sprintf("redeem script has %d signature "+
+				"operations, more than the standard max of %d", sigOps,
+				MaxStandardP2SHSigOps),
+		})
+	}
+
+	return violations
+}
+
+// IsStandardScriptSig 返回花费 pkScript 的签名脚本是否是标准的。 需要违规详情时使用 CheckSigScriptStandard。
+func IsStandardScriptSig(sigScript, pkScript []byte) bool {
+	return len(CheckSigScriptStandard(sigScript, pkScript)) == 0
+}
@@ -0,0 +1,112 @@
+package txscript
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/stretchr/testify/require"
+)
+
+// TestCheckSigScriptStandard 确保签名脚本的每种标准性违规都被报告，并带有正确的位置。
+func TestCheckSigScriptStandard(t *testing.T) {
+	t.Parallel()
+
+	p2pkh := mustParseShortForm("DUP HASH160 DATA_20 0x" +
+		"433ec2ac1ffa1b7b7d027f564529c57197f9ae88 EQUALVERIFY CHECKSIG")
+	p2shFor := func(redeemScript []byte) []byte {
+		pkScript, err := payToScriptHashScript(btcutil.Hash160(redeemScript))
+		require.NoError(t, err)
+		return pkScript
+	}
+	pushScript := func(data ...[]byte) []byte {
+		builder := NewScriptBuilder()
+		for _, d := range data {
+			builder.AddFullData(d)
+		}
+		script, err := builder.Script()
+		require.NoError(t, err)
+		return script
+	}
+
+	// 16 个 CHECKSIG 超过了 P2SH 的签名操作限制。
+	manySigOps := bytes.Repeat([]byte{OP_CHECKSIG}, MaxStandardP2SHSigOps+1)
+	largeRedeem := bytes.Repeat([]byte{OP_NOP}, MaxScriptElementSize+1)
+
+	tests := []struct {
+		name      string
+		sigScript []byte
+		pkScript  []byte
+		want      []SigScriptViolationKind
+		offsets   []int
+	}{{
+		name:      "standard p2pkh",
+		sigScript: pushScript(make([]byte, 72), make([]byte, 33)),
+		pkScript:  p2pkh,
+	}, {
+		name:      "standard p2sh",
+		sigScript: pushScript([]byte{OP_TRUE}),
+		pkScript:  p2shFor([]byte{OP_TRUE}),
+	}, {
+		name:      "not push only",
+		sigScript: mustParseShortForm("DATA_1 0x20 NOP 0 DUP"),
+		pkScript:  p2pkh,
+		want: []SigScriptViolationKind{SigScriptNotPushOnly,
+			SigScriptNotPushOnly},
+		offsets: []int{2, 4},
+	}, {
+		name:      "non-minimal push",
+		sigScript: mustParseShortForm("0 DATA_1 0x05"),
+		pkScript:  p2pkh,
+		want:      []SigScriptViolationKind{SigScriptNonMinimalPush},
+		offsets:   []int{1},
+	}, {
+		name:      "unparseable",
+		sigScript: mustParseShortForm("0 DATA_2 0x01"),
+		pkScript:  p2pkh,
+		want:      []SigScriptViolationKind{SigScriptUnparseable},
+		offsets:   []int{1},
+	}, {
+		name: "too large",
+		sigScript: pushScript(make([]byte, 520), make([]byte, 520),
+			make([]byte, 520), make([]byte, 100)),
+		pkScript: p2pkh,
+		want:     []SigScriptViolationKind{SigScriptTooLarge},
+		offsets:  []int{-1},
+	}, {
+		name:     "missing redeem script",
+		pkScript: p2shFor([]byte{OP_TRUE}),
+		want:     []SigScriptViolationKind{SigScriptMissingRedeemScript},
+		offsets:  []int{-1},
+	}, {
+		name:      "redeem script sigops",
+		sigScript: pushScript(manySigOps),
+		pkScript:  p2shFor(manySigOps),
+		want: []SigScriptViolationKind{
+			SigScriptRedeemScriptTooManySigOps},
+		offsets: []int{-1},
+	}, {
+		name:      "redeem script too large",
+		sigScript: pushScript(largeRedeem),
+		pkScript:  p2shFor(largeRedeem),
+		want:      []SigScriptViolationKind{SigScriptRedeemScriptTooLarge},
+		offsets:   []int{-1},
+	}}
+
+	for _, test := range tests {
+		violations := CheckSigScriptStandard(test.sigScript, test.pkScript)
+		var kinds []SigScriptViolationKind
+		var offsets []int
+		for _, v := range violations {
+			kinds = append(kinds, v.Kind)
+			offsets = append(offsets, v.Offset)
+			require.NotEmpty(t, v.Error(), test.name)
+		}
+		require.Equal(t, test.want, kinds, test.name)
+		require.Equal(t, test.offsets, offsets, test.name)
+		require.Equal(t, len(test.want) == 0,
+			IsStandardScriptSig(test.sigScript, test.pkScript), test.name)
+	}
+
+	require.Equal(t, "SigScriptNotPushOnly", SigScriptNotPushOnly.String())
+}
@@ -23,6 +23,23 @@ type signatureVerifier interface {
 	Verify() bool
 }
 
+// deferrableSigVerifier is a signatureVerifier that can split the sighash
+// computation, which depends on the current engine state, from the actual
+// signature check, which doesn't. This allows the signature check to be handed
+// off to an AsyncSigVerifier.
+type deferrableSigVerifier interface {
+	signatureVerifier
+
+	// calcSigHash computes the sighash the signature commits to. False is
+	// returned if the sighash can't be computed, in which case Verify must
+	// be used instead.
+	calcSigHash() ([]byte, bool)
+
+	// verifySig returns true if the signature is valid for the passed
+	// sighash.
+	verifySig(sigHash []byte) bool
+}
+
 // baseSigVerifier is used to verify signatures for the _base_ system, meaning
 // ECDSA signatures encoded in DER or BER encoding.
 type baseSigVerifier struct {
@@ -134,12 +151,14 @@ func (b *baseSigVerifier) verifySig(sigHash []byte) bool {
 		copy(sigHashBytes[:], sigHash[:])
 
 		valid = b.vm.sigCache.Exists(sigHashBytes, b.sigBytes, b.pkBytes)
-		if !valid && b.sig.Verify(sigHash, b.pubKey) {
+		if !valid && b.vm.verifier().VerifyECDSA(
+			b.sig, sigHash, b.pubKey) {
+
 			b.vm.sigCache.Add(sigHashBytes, b.sigBytes, b.pkBytes)
 			valid = true
 		}
 	} else {
-		valid = b.sig.Verify(sigHash, b.pubKey)
+		valid = b.vm.verifier().VerifyECDSA(b.sig, sigHash, b.pubKey)
 	}
 
 	return valid
@@ -150,15 +169,23 @@ func (b *baseSigVerifier) verifySig(sigHash []byte) bool {
 //
 // NOTE: This is part of the baseSigVerifier interface.
 func (b *baseSigVerifier) Verify() bool {
+	sigHash, _ := b.calcSigHash()
+	return b.verifySig(sigHash)
+}
+
+// calcSigHash computes the sighash the signature commits to.
+//
+// NOTE: This is part of the deferrableSigVerifier interface.
+func (b *baseSigVerifier) calcSigHash() ([]byte, bool) {
 	// Remove the signature since there is no way for a signature
 	// to sign itself.
 	subScript := removeOpcodeByData(b.subScript, b.fullSigBytes)
 
 	sigHash := calcSignatureHash(
-		subScript, b.hashType, &b.vm.tx, b.vm.txIdx,
+		subScript, b.hashType, b.vm.tx, b.vm.txIdx, b.vm.chainTag,
 	)
 
-	return b.verifySig(sigHash)
+	return sigHash, true
 }
 
 // A compile-time assertion to ensure baseSigVerifier implements the
@@ -191,25 +218,37 @@ func newBaseSegwitSigVerifier(pkBytes, fullSigBytes []byte,
 //
 // NOTE: This is part of the baseSigVerifier interface.
 func (s *baseSegwitSigVerifier) Verify() bool {
+	sigHash, ok := s.calcSigHash()
+	if !ok {
+		return false
+	}
+
+	return s.verifySig(sigHash)
+}
+
+// calcSigHash computes the BIP 143 sighash the signature commits to.
+//
+// NOTE: This is part of the deferrableSigVerifier interface.
+func (s *baseSegwitSigVerifier) calcSigHash() ([]byte, bool) {
 	var sigHashes *TxSigHashes
 	if s.vm.hashCache != nil {
 		sigHashes = s.vm.hashCache
 	} else {
-		sigHashes = NewTxSigHashes(&s.vm.tx, s.vm.prevOutFetcher)
+		sigHashes = NewTxSigHashes(s.vm.tx, s.vm.prevOutFetcher)
 	}
 
 	sigHash, err := calcWitnessSignatureHashRaw(
-		s.subScript, sigHashes, s.hashType, &s.vm.tx, s.vm.txIdx,
-		s.vm.inputAmount,
+		s.subScript, sigHashes, s.hashType, s.vm.tx, s.vm.txIdx,
+		s.vm.inputAmount, s.vm.chainTag,
 	)
 	if err != nil {
 		// TODO(roasbeef): this doesn't need to return an error, should
 		// instead be further up the stack? this only returns an error
 		// if the input index is greater than the number of inputs
-		return false
+		return nil, false
 	}
 
-	return s.verifySig(sigHash)
+	return sigHash, true
 }
 
 // A compile-time assertion to ensure baseSegwitSigVerifier implements the
@@ -237,6 +276,13 @@ type taprootSigVerifier struct {
 	annex []byte
 
 	prevOuts PrevOutputFetcher
+
+	// backend performs the cryptographic verification, the default
+	// backend is used when it's nil.
+	backend VerifierBackend
+
+	// chainTag selects the tagged hash of the sighash.
+	chainTag ChainTag
 }
 
 // parseTaprootSigAndPubKey attempts to parse the public key and signature for
@@ -332,7 +378,10 @@ func (t *taprootSigVerifier) verifySig(sigHash []byte) bool {
 	// included in the sigCcahe and is valid or not (if one was passed in).
 	cacheKey, _ := chainhash.NewHash(sigHash)
 	if t.sigCache != nil {
-		if t.sigCache.Exists(*cacheKey, t.fullSigBytes, t.pkBytes) {
+		if t.sigCache.ExistsWithType(
+			SigTypeSchnorr, *cacheKey, t.fullSigBytes, t.pkBytes,
+		) {
+
 			return true
 		}
 	}
@@ -340,11 +389,16 @@ func (t *taprootSigVerifier) verifySig(sigHash []byte) bool {
 	// If we didn't find the entry in the cache, then we'll perform full
 	// verification as normal, adding the entry to the cache if it's found
 	// to be valid.
-	sigValid := t.sig.Verify(sigHash, t.pubKey)
+	sigValid := verifierOrDefault(t.backend).VerifySchnorr(
+		t.sig, sigHash, t.pubKey,
+	)
 	if sigValid {
 		if t.sigCache != nil {
 			// The sig is valid, so we'll add it to the cache.
-			t.sigCache.Add(*cacheKey, t.fullSigBytes, t.pkBytes)
+			t.sigCache.AddWithType(
+				SigTypeSchnorr, *cacheKey, t.fullSigBytes,
+				t.pkBytes,
+			)
 		}
 
 		return true
@@ -359,7 +413,7 @@ func (t *taprootSigVerifier) verifySig(sigHash []byte) bool {
 //
 // NOTE: This is part of the baseSigVerifier interface.
 func (t *taprootSigVerifier) Verify() bool {
-	var opts []TaprootSigHashOption
+	opts := []TaprootSigHashOption{WithTaprootChainTag(t.chainTag)}
 	if t.annex != nil {
 		opts = append(opts, WithAnnex(t.annex))
 	}
@@ -406,12 +460,14 @@ func newBaseTapscriptSigVerifier(pkBytes, rawSig []byte,
 	// as normal.
 	case 32:
 		baseTaprootVerifier, err := newTaprootSigVerifier(
-			pkBytes, rawSig, &vm.tx, vm.txIdx, vm.prevOutFetcher,
+			pkBytes, rawSig, vm.tx, vm.txIdx, vm.prevOutFetcher,
 			vm.sigCache, vm.hashCache, vm.taprootCtx.annex,
 		)
 		if err != nil {
 			return nil, err
 		}
+		baseTaprootVerifier.backend = vm.verifierBackend
+		baseTaprootVerifier.chainTag = vm.chainTag
 
 		return &baseTapscriptSigVerifier{
 			taprootSigVerifier: baseTaprootVerifier,
@@ -424,11 +480,7 @@ func newBaseTapscriptSigVerifier(pkBytes, rawSig []byte,
 		// However, if the flag preventing usage of unknown key types
 		// is active, then we'll return that error.
 		if vm.hasFlag(ScriptVerifyDiscourageUpgradeablePubkeyType) {
-			str := fmt.Sprintf("pubkey of length %v was used",
-				len(pkBytes))
-			return nil, scriptError(
-				ErrDiscourageUpgradeablePubKeyType, str,
-			)
+			return nil, upgradeablePubKeyTypeError(pkBytes)
 		}
 
 		return &baseTapscriptSigVerifier{
@@ -449,7 +501,25 @@ func (b *baseTapscriptSigVerifier) Verify() bool {
 		return true
 	}
 
-	var opts []TaprootSigHashOption
+	sigHash, ok := b.calcSigHash()
+	if !ok {
+		return false
+	}
+
+	return b.verifySig(sigHash)
+}
+
+// calcSigHash computes the tapscript sighash the signature commits to. False
+// is returned for unknown public key types, as there is no signature to
+// check.
+//
+// NOTE: This is part of the deferrableSigVerifier interface.
+func (b *baseTapscriptSigVerifier) calcSigHash() ([]byte, bool) {
+	if b.pubKey == nil {
+		return nil, false
+	}
+
+	opts := []TaprootSigHashOption{WithTaprootChainTag(b.chainTag)}
 	opts = append(opts, WithBaseTapscriptVersion(
 		b.vm.taprootCtx.codeSepPos, b.vm.taprootCtx.tapLeafHash[:],
 	))
@@ -466,12 +536,20 @@ func (b *baseTapscriptSigVerifier) Verify() bool {
 	)
 	if err != nil {
 		// TODO(roasbeef): propagate the error here?
-		return false
+		return nil, false
 	}
 
-	return b.verifySig(sigHash)
+	return sigHash, true
 }
 
 // A compile-time assertion to ensure baseTapscriptSigVerifier implements the
 // signatureVerifier interface.
 var _ signatureVerifier = (*baseTapscriptSigVerifier)(nil)
+
+// A compile-time assertion to ensure the script level verifiers implement the
+// deferrableSigVerifier interface.
+var (
+	_ deferrableSigVerifier = (*baseSigVerifier)(nil)
+	_ deferrableSigVerifier = (*baseSegwitSigVerifier)(nil)
+	_ deferrableSigVerifier = (*baseTapscriptSigVerifier)(nil)
+)
@@ -0,0 +1,194 @@
+// 包含脚本模拟执行支持，允许在不构造真实交易输入的情况下，使用预先填充的数据堆栈执行单个脚本。
+
+package txscript
+
+import (
+	"crypto/sha256"
+	"fmt"
+
+	"github.com/btcsuite/btcd/wire"
+)
+
+// ErrInvalidSimulation 在传递给 NewSimulationEngine 的参数无效时返回。
+var ErrInvalidSimulation = fmt.Errorf("invalid simulation parameters")
+
+// SimulationParams 描述一次模拟执行：在给定的初始数据堆栈上执行单个脚本，例如兑换脚本、见证脚本或 tapscript 叶子。
+type SimulationParams struct {
+	// Script 是要执行的脚本。
+	Script []byte
+
+	// Stack 是执行前的数据堆栈，最后一个元素位于栈顶，与见证堆栈的顺序相同。
+	Stack [][]byte
+
+	// SigVersion 决定脚本的执行规则：SigVersionBase 按传统脚本执行，SigVersionWitnessV0 按 P2WSH 见证脚本执行，
+	// SigVersionTapscript 按 BIP0342 tapscript 叶子执行。 不支持 SigVersionTaproot，因为密钥路径花费不执行脚本。
+	SigVersion SigVersion
+
+	// Flags 是执行时使用的脚本标志。
+	Flags ScriptFlags
+
+	// Tx 是可选的花费交易，仅在脚本包含签名检查时需要。 为 nil 时使用只有一个输入和一个输出的占位交易，TxIdx 必须为零。
+	Tx *wire.MsgTx
+
+	// TxIdx 是 Tx 中被模拟的输入索引。
+	TxIdx int
+
+	// InputAmount 是被花费的输出金额，用于计算见证签名哈希。
+	InputAmount int64
+
+	// PrevOutFetcher 是可选的先前输出获取器。 为 nil 时，所有输入都被视为花费一个公钥脚本为空、金额为 InputAmount 的输出。
+	PrevOutFetcher PrevOutputFetcher
+
+	// SigCache 和 HashCache 是可选的缓存，与 NewEngine 的同名参数含义相同。
+	SigCache  *SigCache
+	HashCache *TxSigHashes
+}
+
+// NewSimulationEngine 返回一个新的脚本引擎，该引擎在 params.Stack 描述的数据堆栈上执行 params.Script。
+// 与 NewEngine 不同，脚本不需要由真实交易输入的签名脚本、公钥脚本或见证引出，
+// 因此可以在构造交易之前检查兑换脚本或 tapscript 叶子能否被候选见证元素满足。
+//
+// 见证版本相关的检查（如初始元素大小、tapscript 的初始堆栈大小和 OP_SUCCESS 处理）与引擎在验证对应见证程序时应用的检查相同，
+// 但不会检查脚本与任何承诺（如见证程序哈希或 taproot 默克尔根）是否匹配。
+func NewSimulationEngine(params SimulationParams, opts ...EngineOpt) (*Engine, error) {
+	cfg := defaultEngineConfig()
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	if err := cfg.limits.Validate(); err != nil {
+		return nil, err
+	}
+
+	// 一旦有引擎被创建，链特定的脚本类别注册表即不可再修改。
+	freezeScriptClassRegistry()
+	freezeWitnessHandlerRegistry()
+	freezeScriptVersionRegistry()
+
+	tx := params.Tx
+	if tx == nil {
+		tx = simulationTx()
+	}
+	if params.TxIdx < 0 || params.TxIdx >= len(tx.TxIn) {
+		str := fmt.Sprintf("transaction input index %d is negative or "+
+			">= %d", params.TxIdx, len(tx.TxIn))
+		return nil, scriptError(ErrInvalidIndex, str)
+	}
+	prevOutFetcher := params.PrevOutFetcher
+	if prevOutFetcher == nil {
+		prevOutFetcher = NewCannedPrevOutputFetcher(nil, params.InputAmount)
+	}
+
+	vm := Engine{
+		flags:          params.Flags,
+		sigCache:       params.SigCache,
+		hashCache:      params.HashCache,
+		inputAmount:    params.InputAmount,
+		prevOutFetcher: prevOutFetcher,
+		limits:         cfg.limits,
+		coverage:       cfg.coverage,
+
+		asyncSigVerifier: cfg.asyncSigVerifier,
+		stats:            cfg.stats,
+		verifierBackend:  cfg.verifierBackend,
+		replayLog:        cfg.replayLog,
+		chainTag:         cfg.chainTag,
+	}
+	if vm.hasFlag(ScriptVerifyCleanStack) && (!vm.hasFlag(ScriptBip16) &&
+		!vm.hasFlag(ScriptVerifyWitness)) {
+		return nil, scriptError(ErrInvalidFlags,
+			"invalid flags combination")
+	}
+
+	script := params.Script
+	if len(script) > vm.limits.MaxScriptSize {
+		str := fmt.Sprintf("script size %d is larger than max allowed "+
+			"size %d", len(script), vm.limits.MaxScriptSize)
+		return nil, scriptError(ErrScriptTooBig, str)
+	}
+
+	vm.scripts = [][]byte{script}
+	vm.SetStack(params.Stack)
+	if vm.hasFlag(ScriptVerifyMinimalData) {
+		vm.dstack.verifyMinimalData = true
+		vm.astack.verifyMinimalData = true
+	}
+
+	switch params.SigVersion {
+	case SigVersionBase:
+
+	case SigVersionWitnessV0:
+		// 模拟 P2WSH 花费，使签名检查使用 BIP0143 签名哈希，并在结束时要求干净堆栈。
+		witnessHash := sha256.Sum256(script)
+		vm.witnessVersion = BaseSegwitWitnessVersion
+		vm.witnessProgram = witnessHash[:]
+
+	case SigVersionTapscript:
+		// 签名操作预算基于完整的见证大小，即初始堆栈、脚本和一个不含默克尔路径的控制块。
+		witness := make(wire.TxWitness, 0, len(params.Stack)+2)
+		witness = append(witness, params.Stack...)
+		witness = append(witness, script, make([]byte, ControlBlockBaseSize))
+
+		vm.witnessVersion = TaprootWitnessVersion
+		vm.witnessProgram = make([]byte, payToTaprootDataSize)
+		vm.taprootCtx = newTaprootExecutionCtx(
+			int32(witness.SerializeSize()),
+		)
+		vm.taprootCtx.tapLeafHash = NewBaseTapLeaf(script).TapHash()
+
+		if vm.scriptHasOpSuccess(script) {
+			if vm.hasFlag(ScriptVerifyDiscourageOpSuccess) {
+				errStr := fmt.Sprintf("script contains " +
+					"OP_SUCCESS op code")
+				return nil, scriptError(ErrDiscourageOpSuccess, errStr)
+			}
+
+			// 与真实花费一样，包含 OP_SUCCESS 的脚本无条件成功，不会被执行。
+			vm.taprootCtx.mustSucceed = true
+		}
+
+	default:
+		return nil, fmt.Errorf("%w: unsupported signature version %v",
+			ErrInvalidSimulation, params.SigVersion)
+	}
+
+	// 包含 OP_SUCCESS 的 tapscript 可能无法解析，因此只检查将被执行的脚本。
+	if vm.taprootCtx == nil || !vm.taprootCtx.mustSucceed {
+		const scriptVersion = 0
+		if err := checkScriptParses(scriptVersion, script); err != nil {
+			return nil, err
+		}
+	}
+
+	// 与 verifyWitnessProgram 相同，见证脚本的初始堆栈必须满足大小限制。
+	if vm.witnessProgram != nil {
+		if err := vm.checkWitnessStack(params.Stack); err != nil {
+			return nil, err
+		}
+	}
+
+	// 空脚本或无条件成功的脚本没有任何可执行操作，因此将程序计数器直接移到脚本之后。
+	if len(script) == 0 || (vm.taprootCtx != nil && vm.taprootCtx.mustSucceed) {
+		vm.scriptIdx++
+	}
+
+	if cfg.pooledStack {
+		vm.usePooledStackMemory()
+	}
+
+	vm.tokenizer = MakeScriptTokenizer(vm.version, script)
+	vm.tx = tx
+	vm.txIdx = params.TxIdx
+
+	return &vm, nil
+}
+
+// simulationTx 返回一个只有一个输入和一个输出的占位交易，供未提供交易的模拟执行使用。
+func simulationTx() *wire.MsgTx {
+	tx := wire.NewMsgTx(wire.TxVersion)
+	tx.AddTxIn(&wire.TxIn{
+		PreviousOutPoint: wire.OutPoint{Index: 0},
+		Sequence:         wire.MaxTxInSequenceNum,
+	})
+	tx.AddTxOut(&wire.TxOut{})
+	return tx
+}
@@ -0,0 +1,175 @@
+package txscript
+
+import (
+	"testing"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcec/v2/schnorr"
+	"github.com/stretchr/testify/require"
+)
+
+// TestSimulationEngineBase 确保模拟引擎使用注入的堆栈执行脚本，并正确报告成功和失败。
+func TestSimulationEngineBase(t *testing.T) {
+	t.Parallel()
+
+	script := mustParseShortForm("ADD 5 NUMEQUAL")
+
+	vm, err := NewSimulationEngine(SimulationParams{
+		Script: script,
+		Stack:  [][]byte{{2}, {3}},
+	})
+	require.NoError(t, err)
+	require.NoError(t, vm.Execute())
+
+	vm, err = NewSimulationEngine(SimulationParams{
+		Script: script,
+		Stack:  [][]byte{{2}, {2}},
+	})
+	require.NoError(t, err)
+	require.True(t, IsErrorCode(vm.Execute(), ErrEvalFalse))
+
+	// 空脚本直接对注入的堆栈求值。
+	vm, err = NewSimulationEngine(SimulationParams{Stack: [][]byte{{1}}})
+	require.NoError(t, err)
+	require.NoError(t, vm.Execute())
+
+	// 非最小编码的推送在启用 MinimalData 时被拒绝。
+	vm, err = NewSimulationEngine(SimulationParams{
+		Script: mustParseShortForm("ADD 5 NUMEQUAL"),
+		Stack:  [][]byte{{2, 0}, {3}},
+		Flags:  ScriptVerifyMinimalData,
+	})
+	require.NoError(t, err)
+	require.True(t, IsErrorCode(vm.Execute(), ErrMinimalData))
+
+	_, err = NewSimulationEngine(SimulationParams{
+		Script:     script,
+		SigVersion: SigVersionTaproot,
+	})
+	require.ErrorIs(t, err, ErrInvalidSimulation)
+
+	_, err = NewSimulationEngine(SimulationParams{
+		Script: script,
+		TxIdx:  1,
+	})
+	require.True(t, IsErrorCode(err, ErrInvalidIndex))
+
+	_, err = NewSimulationEngine(SimulationParams{
+		Script: []byte{OP_DATA_2, 0x01},
+	})
+	require.True(t, IsErrorCode(err, ErrMalformedPush))
+}
+
+// TestSimulationEngineWitnessV0 确保模拟的见证脚本在结束时要求干净堆栈，并应用见证元素大小限制。
+func TestSimulationEngineWitnessV0(t *testing.T) {
+	t.Parallel()
+
+	script := mustParseShortForm("DROP 1")
+
+	vm, err := NewSimulationEngine(SimulationParams{
+		Script:     script,
+		Stack:      [][]byte{{1}, {1}},
+		SigVersion: SigVersionBase,
+	})
+	require.NoError(t, err)
+	require.NoError(t, vm.Execute())
+
+	vm, err = NewSimulationEngine(SimulationParams{
+		Script:     script,
+		Stack:      [][]byte{{1}, {1}},
+		SigVersion: SigVersionWitnessV0,
+	})
+	require.NoError(t, err)
+	require.True(t, IsErrorCode(vm.Execute(), ErrEvalFalse))
+
+	_, err = NewSimulationEngine(SimulationParams{
+		Script:     script,
+		Stack:      [][]byte{make([]byte, MaxScriptElementSize+1)},
+		SigVersion: SigVersionWitnessV0,
+	})
+	require.True(t, IsErrorCode(err, ErrElementTooBig))
+}
+
+// TestSimulationEngineTapscript 确保 tapscript 叶子可以针对真实交易模拟执行，签名检查使用叶子哈希，
+// 并且 OP_SUCCESS 的处理与真实花费相同。
+func TestSimulationEngineTapscript(t *testing.T) {
+	t.Parallel()
+
+	privKey, err := btcec.NewPrivateKey()
+	require.NoError(t, err)
+	pubKey := schnorr.SerializePubKey(privKey.PubKey())
+
+	leafScript, err := NewScriptBuilder().AddData(pubKey).
+		AddOp(OP_CHECKSIG).Script()
+	require.NoError(t, err)
+	tapLeaf := NewBaseTapLeaf(leafScript)
+
+	tapHash := tapLeaf.TapHash()
+	outputKey := ComputeTaprootOutputKey(
+		privKey.PubKey(), tapHash[:],
+	)
+	pkScript, err := PayToTaprootScript(outputKey)
+	require.NoError(t, err)
+
+	const amount = 50000
+	tx := createSpendingTx(nil, nil, pkScript, amount)
+	fetcher := NewCannedPrevOutputFetcher(pkScript, amount)
+	sigHashes := NewTxSigHashes(tx, fetcher)
+
+	sig, err := RawTxInTapscriptSignature(
+		tx, sigHashes, 0, amount, pkScript, tapLeaf, SigHashDefault,
+		privKey,
+	)
+	require.NoError(t, err)
+
+	params := SimulationParams{
+		Script:         leafScript,
+		Stack:          [][]byte{sig},
+		SigVersion:     SigVersionTapscript,
+		Flags:          StandardVerifyFlags,
+		Tx:             tx,
+		InputAmount:    amount,
+		PrevOutFetcher: fetcher,
+		HashCache:      sigHashes,
+	}
+	vm, err := NewSimulationEngine(params)
+	require.NoError(t, err)
+	require.NoError(t, vm.Execute())
+
+	// 篡改后的签名使执行失败。
+	badSig := append([]byte(nil), sig...)
+	badSig[63] ^= 0x01
+	params.Stack = [][]byte{badSig}
+	vm, err = NewSimulationEngine(params)
+	require.NoError(t, err)
+	require.True(t, IsErrorCode(vm.Execute(), ErrNullFail))
+
+	// 空签名使 CHECKSIG 返回 false，清洁堆栈检查随后失败。
+	params.Stack = [][]byte{nil}
+	vm, err = NewSimulationEngine(params)
+	require.NoError(t, err)
+	require.Error(t, vm.Execute())
+
+	// 包含 OP_SUCCESS 的脚本无条件成功，除非策略标志禁止。
+	opSuccess := []byte{OP_RETURN, 0x50}
+	vm, err = NewSimulationEngine(SimulationParams{
+		Script:     opSuccess,
+		SigVersion: SigVersionTapscript,
+	})
+	require.NoError(t, err)
+	require.NoError(t, vm.Execute())
+
+	_, err = NewSimulationEngine(SimulationParams{
+		Script:     opSuccess,
+		SigVersion: SigVersionTapscript,
+		Flags:      ScriptVerifyDiscourageOpSuccess,
+	})
+	require.True(t, IsErrorCode(err, ErrDiscourageOpSuccess))
+
+	_, err = NewSimulationEngine(SimulationParams{
+		Script:     leafScript,
+		Stack:      make([][]byte, MaxStackSize+1),
+		SigVersion: SigVersionTapscript,
+	})
+	require.True(t, IsErrorCode(err, ErrStackOverflow))
+}
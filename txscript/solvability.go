@@ -0,0 +1,239 @@
+// 包含输入可解性分析，在签名之前报告钱包缺少哪些密钥或脚本、哪些数据与输出不匹配，以及脚本类型是否受支持，
+// 使钱包界面可以准确告诉用户签名缺少什么。
+
+package txscript
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/btcsuite/btcd/btcec/v2/schnorr"
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/wire"
+)
+
+var (
+	// ErrMissingSigningKey 在 KeyDB 无法提供满足签名阈值所需的私钥时返回。
+	ErrMissingSigningKey = errors.New("missing signing key")
+
+	// ErrSigningKeyMismatch 在 KeyDB 为地址返回的私钥与输出承诺的公钥不匹配时返回。
+	ErrSigningKeyMismatch = errors.New("signing key does not match address")
+
+	// ErrMissingScript 在 ScriptDB 无法提供 P2SH 兑换脚本或 P2WSH 见证脚本时返回。
+	ErrMissingScript = errors.New("missing redeem or witness script")
+
+	// ErrUnsupportedSigningClass 在输出或其兑换脚本、见证脚本的类型无法被签名时返回。
+	ErrUnsupportedSigningClass = errors.New("unsupported script class for " +
+		"signing")
+)
+
+// SolvabilityReport 描述钱包能否为一个输入签名，以及不能签名时缺少什么。
+type SolvabilityReport struct {
+	// Class 是被花费输出的公钥脚本类别。
+	Class ScriptClass
+
+	// ScriptClass 是实际执行的兑换脚本或见证脚本的类别。 对于嵌套在 P2SH 中的 P2WSH，它是见证脚本的类别；
+	// 不需要脚本或脚本缺失时为 NonStandardTy。
+	ScriptClass ScriptClass
+
+	// RequiredSigs 是需要的签名数。
+	RequiredSigs int
+
+	// Keys 是 KeyDB 能够提供匹配私钥的地址。
+	Keys []btcutil.Address
+
+	// MissingKeys 是 KeyDB 无法提供私钥的地址。 对于多重签名，即使列表非空，只要 Keys 达到 RequiredSigs 输入仍然可解。
+	MissingKeys []btcutil.Address
+
+	// MismatchedKeys 是 KeyDB 返回的私钥与地址不匹配的地址。
+	MismatchedKeys []btcutil.Address
+
+	// MissingScripts 是 ScriptDB 无法提供脚本的 P2SH 或 P2WSH 地址。
+	MissingScripts []btcutil.Address
+
+	// MismatchedScripts 是 ScriptDB 返回的脚本与地址承诺的哈希不匹配的地址。
+	MismatchedScripts []btcutil.Address
+
+	// Unsupported 表示输出或其脚本的类型无法被签名，此时 UnsupportedClass 是该类型。
+	Unsupported      bool
+	UnsupportedClass ScriptClass
+}
+
+// Solvable 返回钱包是否拥有为输入签名所需的全部密钥和脚本。
+func (r *SolvabilityReport) Solvable() bool {
+	return !r.Unsupported && len(r.MissingScripts) == 0 &&
+		len(r.MismatchedScripts) == 0 && len(r.Keys) >= r.RequiredSigs
+}
+
+// Err 在输入可解时返回 nil。 否则返回包装了 ErrUnsolvableInput 以及 ErrUnsupportedSigningClass、ErrScriptHashMismatch、
+// ErrMissingScript、ErrSigningKeyMismatch 和 ErrMissingSigningKey 中每个适用原因的错误，可以通过 errors.Is 检查。
+func (r *SolvabilityReport) Err() error {
+	if r.Solvable() {
+		return nil
+	}
+
+	var errs []error
+	if r.Unsupported {
+		errs = append(errs, fmt.Errorf("%w: %v", ErrUnsupportedSigningClass,
+			r.UnsupportedClass))
+	}
+	if len(r.MismatchedScripts) != 0 {
+		errs = append(errs, fmt.Errorf("%w: %s", ErrScriptHashMismatch,
+			joinAddresses(r.MismatchedScripts)))
+	}
+	if len(r.MissingScripts) != 0 {
+		errs = append(errs, fmt.Errorf("%w: %s", ErrMissingScript,
+			joinAddresses(r.MissingScripts)))
+	}
+	if len(r.MismatchedKeys) != 0 {
+		errs = append(errs, fmt.Errorf("%w: %s", ErrSigningKeyMismatch,
+			joinAddresses(r.MismatchedKeys)))
+	}
+	if len(r.Keys) < r.RequiredSigs {
+		errs = append(errs, fmt.Errorf("%w: have %d of %d keys, missing %s",
+			ErrMissingSigningKey, len(r.Keys), r.RequiredSigs,
+			joinAddresses(r.MissingKeys)))
+	}
+	return fmt.Errorf("%w: %w", ErrUnsolvableInput, errors.Join(errs...))
+}
+
+// joinAddresses 返回以逗号分隔的地址编码。
+func joinAddresses(addrs []btcutil.Address) string {
+	encoded := make([]string, len(addrs))
+	for i, addr := range addrs {
+		encoded[i] = addr.EncodeAddress()
+	}
+	return strings.Join(encoded, ", ")
+}
+
+// Solvability 报告能否使用 kdb 中的私钥和 sdb 中的脚本为 tx 的输入 idx 签名，该输入花费 pkScript。 支持的输出类型与 SignAllInputs 相同。
+//
+// 与签名函数只返回第一个失败原因不同，报告列出所有缺少或不匹配的密钥和脚本。 分析不会生成签名，
+// 但会检查 KeyDB 返回的私钥是否与地址匹配，以及 ScriptDB 返回的脚本是否与地址承诺的哈希匹配。 idx 超出范围时返回错误。
+func Solvability(chainParams *chaincfg.Params, tx *wire.MsgTx, idx int,
+	pkScript []byte, kdb KeyDB, sdb ScriptDB) (*SolvabilityReport, error) {
+
+	if idx < 0 || idx >= len(tx.TxIn) {
+		return nil, fmt.Errorf("input index %d out of range for %d inputs",
+			idx, len(tx.TxIn))
+	}
+
+	r := &SolvabilityReport{
+		Class:       GetScriptClass(pkScript),
+		ScriptClass: NonStandardTy,
+	}
+	r.solve(chainParams, pkScript, r.Class, kdb, sdb)
+	return r, nil
+}
+
+// solve 检查为 script 签名所需的密钥和脚本并记录到报告中。 class 是 script 的类别，它可以是输出脚本、
+// P2SH 兑换脚本或 P2WSH 见证脚本。
+func (r *SolvabilityReport) solve(chainParams *chaincfg.Params, script []byte,
+	class ScriptClass, kdb KeyDB, sdb ScriptDB) {
+
+	_, addresses, nRequired, err := ExtractPkScriptAddrs(script, chainParams)
+	if err != nil || len(addresses) == 0 {
+		r.Unsupported = true
+		r.UnsupportedClass = class
+		return
+	}
+
+	switch class {
+	case PubKeyTy, PubKeyHashTy, WitnessV0PubKeyHashTy, WitnessV1TaprootTy:
+		r.RequiredSigs = 1
+		r.checkKey(kdb, addresses[0], class, script)
+
+	case MultiSigTy:
+		r.RequiredSigs = nRequired
+		for _, addr := range addresses {
+			r.checkKey(kdb, addr, class, script)
+		}
+
+	case ScriptHashTy, WitnessV0ScriptHashTy:
+		subScript, err := sdb.GetScript(addresses[0])
+		if err != nil {
+			r.MissingScripts = append(r.MissingScripts, addresses[0])
+			return
+		}
+
+		var matches bool
+		if class == ScriptHashTy {
+			matches = bytes.Equal(hash160(subScript), script[2:22])
+		} else {
+			hash := sha256.Sum256(subScript)
+			matches = bytes.Equal(hash[:], script[2:])
+		}
+		if !matches {
+			r.MismatchedScripts = append(r.MismatchedScripts,
+				addresses[0])
+			return
+		}
+
+		// P2SH may nest a version 0 witness program, while a witness
+		// script can't nest anything.
+		subClass := GetScriptClass(subScript)
+		switch {
+		case subClass == PubKeyTy, subClass == PubKeyHashTy,
+			subClass == MultiSigTy:
+
+			r.ScriptClass = subClass
+
+		case class == ScriptHashTy &&
+			(subClass == WitnessV0PubKeyHashTy ||
+				subClass == WitnessV0ScriptHashTy):
+
+		default:
+			r.Unsupported = true
+			r.UnsupportedClass = subClass
+			return
+		}
+		r.solve(chainParams, subScript, subClass, kdb, sdb)
+
+	default:
+		r.Unsupported = true
+		r.UnsupportedClass = class
+	}
+}
+
+// checkKey 查找 addr 的私钥并检查它是否与类别为 class 的脚本 script 承诺的公钥匹配。
+func (r *SolvabilityReport) checkKey(kdb KeyDB, addr btcutil.Address,
+	class ScriptClass, script []byte) {
+
+	key, compressed, err := kdb.GetKey(addr)
+	if err != nil || key == nil {
+		r.MissingKeys = append(r.MissingKeys, addr)
+		return
+	}
+
+	pubKey := key.PubKey()
+	serialized := pubKey.SerializeUncompressed()
+	if compressed {
+		serialized = pubKey.SerializeCompressed()
+	}
+
+	var matches bool
+	switch class {
+	case PubKeyTy, MultiSigTy:
+		addrPubKey, ok := addr.(*btcutil.AddressPubKey)
+		matches = ok && addrPubKey.PubKey().IsEqual(pubKey)
+
+	case PubKeyHashTy, WitnessV0PubKeyHashTy:
+		matches = bytes.Equal(hash160(serialized), addr.ScriptAddress())
+
+	case WitnessV1TaprootTy:
+		// Only BIP0086 key path spends are supported, so the key must
+		// be the untweaked internal key of the output.
+		outputKey := ComputeTaprootKeyNoScript(pubKey)
+		matches = bytes.Equal(schnorr.SerializePubKey(outputKey),
+			script[2:])
+	}
+	if !matches {
+		r.MismatchedKeys = append(r.MismatchedKeys, addr)
+		return
+	}
+	r.Keys = append(r.Keys, addr)
+}
@@ -0,0 +1,148 @@
+package txscript
+
+import (
+	"crypto/sha256"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcec/v2/schnorr"
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/stretchr/testify/require"
+)
+
+// TestSolvability 确保可解性报告准确列出缺少或不匹配的密钥和脚本以及不受支持的脚本类型，并且报告的错误可以按原因检查。
+func TestSolvability(t *testing.T) {
+	t.Parallel()
+
+	params := &chaincfg.TestNet3Params
+	newKey := func() *btcec.PrivateKey {
+		key, err := btcec.NewPrivateKey()
+		require.NoError(t, err)
+		return key
+	}
+	pubKeyAddr := func(key *btcec.PrivateKey) *btcutil.AddressPubKey {
+		addr, err := btcutil.NewAddressPubKey(
+			key.PubKey().SerializeCompressed(), params,
+		)
+		require.NoError(t, err)
+		return addr
+	}
+	payTo := func(addr btcutil.Address) []byte {
+		pkScript, err := PayToAddrScript(addr)
+		require.NoError(t, err)
+		return pkScript
+	}
+
+	tx := wire.NewMsgTx(2)
+	tx.AddTxIn(&wire.TxIn{})
+	solve := func(pkScript []byte, keys map[string]addressToKey,
+		scripts map[string][]byte) *SolvabilityReport {
+
+		r, err := Solvability(params, tx, 0, pkScript, mkGetKey(keys),
+			mkGetScript(scripts))
+		require.NoError(t, err)
+		return r
+	}
+
+	// P2PKH：持有、缺少和不匹配的密钥。
+	pkhKey := newKey()
+	pkhAddr, err := btcutil.NewAddressPubKeyHash(
+		btcutil.Hash160(pkhKey.PubKey().SerializeCompressed()), params,
+	)
+	require.NoError(t, err)
+	p2pkh := payTo(pkhAddr)
+
+	r := solve(p2pkh, map[string]addressToKey{
+		pkhAddr.EncodeAddress(): {pkhKey, true},
+	}, nil)
+	require.True(t, r.Solvable())
+	require.NoError(t, r.Err())
+	require.Equal(t, PubKeyHashTy, r.Class)
+	require.Equal(t, []btcutil.Address{pkhAddr}, r.Keys)
+
+	r = solve(p2pkh, nil, nil)
+	require.False(t, r.Solvable())
+	require.Equal(t, []btcutil.Address{pkhAddr}, r.MissingKeys)
+	require.ErrorIs(t, r.Err(), ErrUnsolvableInput)
+	require.ErrorIs(t, r.Err(), ErrMissingSigningKey)
+	require.Contains(t, r.Err().Error(), pkhAddr.EncodeAddress())
+
+	r = solve(p2pkh, map[string]addressToKey{
+		pkhAddr.EncodeAddress(): {pkhKey, false},
+	}, nil)
+	require.Equal(t, []btcutil.Address{pkhAddr}, r.MismatchedKeys)
+	require.ErrorIs(t, r.Err(), ErrSigningKeyMismatch)
+
+	// P2WSH 2-of-3 多重签名，持有其中两个密钥时可解。
+	msKeys := []*btcec.PrivateKey{newKey(), newKey(), newKey()}
+	msAddrs := []*btcutil.AddressPubKey{
+		pubKeyAddr(msKeys[0]), pubKeyAddr(msKeys[1]), pubKeyAddr(msKeys[2]),
+	}
+	witnessScript, err := MultiSigScript(msAddrs, 2)
+	require.NoError(t, err)
+	scriptHash := sha256.Sum256(witnessScript)
+	p2wshAddr, err := btcutil.NewAddressWitnessScriptHash(
+		scriptHash[:], params,
+	)
+	require.NoError(t, err)
+	p2wsh := payTo(p2wshAddr)
+
+	keys := map[string]addressToKey{
+		msAddrs[0].EncodeAddress(): {msKeys[0], true},
+		msAddrs[2].EncodeAddress(): {msKeys[2], true},
+	}
+	scripts := map[string][]byte{p2wshAddr.EncodeAddress(): witnessScript}
+	r = solve(p2wsh, keys, scripts)
+	require.True(t, r.Solvable())
+	require.Equal(t, MultiSigTy, r.ScriptClass)
+	require.Equal(t, 2, r.RequiredSigs)
+	require.Len(t, r.Keys, 2)
+	require.Equal(t, []btcutil.Address{msAddrs[1]}, r.MissingKeys)
+
+	r = solve(p2wsh, keys, nil)
+	require.Equal(t, []btcutil.Address{p2wshAddr}, r.MissingScripts)
+	require.ErrorIs(t, r.Err(), ErrMissingScript)
+
+	// 嵌套在 P2SH 中的 P2WSH，只持有一个密钥。
+	p2shAddr, err := btcutil.NewAddressScriptHash(p2wsh, params)
+	require.NoError(t, err)
+	scripts[p2shAddr.EncodeAddress()] = p2wsh
+	delete(keys, msAddrs[2].EncodeAddress())
+	r = solve(payTo(p2shAddr), keys, scripts)
+	require.Equal(t, ScriptHashTy, r.Class)
+	require.Equal(t, MultiSigTy, r.ScriptClass)
+	require.Len(t, r.Keys, 1)
+	require.Len(t, r.MissingKeys, 2)
+	require.ErrorIs(t, r.Err(), ErrMissingSigningKey)
+	require.NotErrorIs(t, r.Err(), ErrMissingScript)
+
+	// 脚本与地址承诺的哈希不匹配。
+	scripts[p2shAddr.EncodeAddress()] = witnessScript
+	r = solve(payTo(p2shAddr), keys, scripts)
+	require.Equal(t, []btcutil.Address{p2shAddr}, r.MismatchedScripts)
+	require.ErrorIs(t, r.Err(), ErrScriptHashMismatch)
+
+	// P2TR 密钥路径。
+	trKey := newKey()
+	trAddr, err := btcutil.NewAddressTaproot(schnorr.SerializePubKey(
+		ComputeTaprootKeyNoScript(trKey.PubKey())), params,
+	)
+	require.NoError(t, err)
+	r = solve(payTo(trAddr), map[string]addressToKey{
+		trAddr.EncodeAddress(): {trKey, true},
+	}, nil)
+	require.True(t, r.Solvable())
+
+	// 不受支持的脚本类型。
+	nullData, err := NullDataScript([]byte("data"))
+	require.NoError(t, err)
+	r = solve(nullData, nil, nil)
+	require.True(t, r.Unsupported)
+	require.Equal(t, NullDataTy, r.UnsupportedClass)
+	require.ErrorIs(t, r.Err(), ErrUnsupportedSigningClass)
+
+	_, err = Solvability(params, tx, 1, p2pkh, mkGetKey(nil), mkGetScript(nil))
+	require.Error(t, err)
+}
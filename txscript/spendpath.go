@@ -0,0 +1,248 @@
+// 包含 taproot 输出花费路径的成本比较，根据钱包可用的签名者和原像，计算密钥路径和每个可满足叶子的精确见证大小及手续费，
+// 使钱包可以确定性地选择最便宜的路径。
+
+package txscript
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcec/v2/schnorr"
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/wire"
+)
+
+// ErrNoSpendPath 在可用的签名者和原像无法满足任何花费路径时返回。
+var ErrNoSpendPath = errors.New("no satisfiable spend path")
+
+// witnessScaleFactor 是见证数据相对于其他交易数据的权重折扣（BIP0141）。
+const witnessScaleFactor = 4
+
+// SpendCredentials 描述钱包花费 taproot 输出时可以提供的签名和原像。
+type SpendCredentials struct {
+	// Signers 是钱包可以签名的 32 字节 x-only 公钥。 内部密钥在其中时密钥路径可用。
+	Signers [][]byte
+
+	// Preimages 是钱包知道的哈希原像。
+	Preimages [][]byte
+
+	// HashType 是签名使用的签名哈希类型。 SigHashDefault 的签名为 64 字节，其他类型为 65 字节。
+	HashType SigHashType
+}
+
+// canSign 返回钱包是否可以为 x-only 公钥签名。
+func (c *SpendCredentials) canSign(pubKey []byte) bool {
+	for _, signer := range c.Signers {
+		if bytes.Equal(signer, pubKey) {
+			return true
+		}
+	}
+	return false
+}
+
+// sigSize 返回签名的字节数。
+func (c *SpendCredentials) sigSize() int {
+	if c.HashType == SigHashDefault {
+		return schnorr.SignatureSize
+	}
+	return schnorr.SignatureSize + 1
+}
+
+// SpendPathCost 是一条可满足花费路径的成本。
+type SpendPathCost struct {
+	// KeyPath 表示该路径是密钥路径。
+	KeyPath bool
+
+	// LeafIndex 是叶子在 IndexedTapScriptTree.LeafMerkleProofs 中的索引，密钥路径为 -1。
+	LeafIndex int
+
+	// Leaf 是花费的叶子，密钥路径为零值。
+	Leaf TapLeaf
+
+	// Signers 是该路径需要签名的 x-only 公钥，按签名在见证中的顺序排列。
+	Signers [][]byte
+
+	// Preimages 是该路径需要出示的原像。
+	Preimages [][]byte
+
+	// TimeLocks 是叶子施加的时间锁，花费交易必须满足它们。
+	TimeLocks []TimeLock
+
+	// WitnessSize 是输入见证的精确序列化大小，包括元素数量，即 wire.TxWitness.SerializeSize 的返回值。
+	WitnessSize int
+
+	// Fee 是见证数据按给定费率需要支付的手续费。 花费同一输出的所有路径的非见证数据相同，因此比较见证手续费即可比较路径。
+	Fee btcutil.Amount
+}
+
+// satisfyTapscriptLeaf 尝试使用 creds 满足叶子脚本，返回见证中叶子脚本之前的元素（签名使用占位数据）、签名者、原像和时间锁。
+// 支持的叶子形式为可选的 <n> CHECKLOCKTIMEVERIFY|CHECKSEQUENCEVERIFY DROP 前缀之后跟随以下之一：
+//
+//   - <key> CHECKSIG
+//   - multi_a 叶子（ParseMultiALeaf）
+//   - 哈希锁脚本或带公钥的哈希锁脚本（ParseHashLockScript），包括 tapscript HTLC 的赎回叶子
+func satisfyTapscriptLeaf(script []byte,
+	creds *SpendCredentials) (*SpendPathCost, [][]byte, bool) {
+
+	const scriptVersion = 0
+	var path SpendPathCost
+	sig := make([]byte, creds.sigSize())
+
+	// Strip any leading timelocks, which only constrain the spending
+	// transaction and add nothing to the witness.
+	body := script
+	for {
+		var tokens []requirementToken
+		tokenizer := MakeScriptTokenizer(scriptVersion, body)
+		for len(tokens) < 3 && tokenizer.Next() {
+			tokens = append(tokens, requirementToken{
+				op:   tokenizer.Opcode(),
+				data: tokenizer.Data(),
+			})
+		}
+		if len(tokens) < 3 || tokens[2].op != OP_DROP ||
+			(tokens[1].op != OP_CHECKLOCKTIMEVERIFY &&
+				tokens[1].op != OP_CHECKSEQUENCEVERIFY) {
+
+			break
+		}
+		value, ok := requirementNum(tokens, 0)
+		if !ok {
+			return nil, nil, false
+		}
+		path.TimeLocks = append(path.TimeLocks, TimeLock{
+			Relative: tokens[1].op == OP_CHECKSEQUENCEVERIFY,
+			Value:    value,
+		})
+		body = body[tokenizer.ByteIndex():]
+	}
+
+	// <key> CHECKSIG.
+	if len(body) == 34 && body[0] == OP_DATA_32 && body[33] == OP_CHECKSIG {
+		key := body[1:33]
+		if !creds.canSign(key) {
+			return nil, nil, false
+		}
+		path.Signers = [][]byte{key}
+		return &path, [][]byte{sig}, true
+	}
+
+	// multi_a: the first key's signature is on top of the stack, so the
+	// witness lists the keys in reverse with empty items for non-signers.
+	// Every signature has the same size, so any threshold subset costs the
+	// same and the first available keys are used.
+	if multiA, err := ParseMultiALeaf(body); err == nil {
+		items := make([][]byte, len(multiA.PubKeys))
+		for i, key := range multiA.PubKeys {
+			if len(path.Signers) == multiA.Threshold || !creds.canSign(key) {
+				continue
+			}
+			path.Signers = append(path.Signers, key)
+			items[len(items)-1-i] = sig
+		}
+		if len(path.Signers) < multiA.Threshold {
+			return nil, nil, false
+		}
+		return &path, items, true
+	}
+
+	if hashLock, err := ParseHashLockScript(body); err == nil {
+		var preimage []byte
+		for _, p := range creds.Preimages {
+			if hashLock.Matches(p) {
+				preimage = p
+				break
+			}
+		}
+		if preimage == nil {
+			return nil, nil, false
+		}
+		path.Preimages = [][]byte{preimage}
+		if hashLock.PubKey == nil {
+			return &path, [][]byte{preimage}, true
+		}
+		if len(hashLock.PubKey) != schnorr.PubKeyBytesLen ||
+			!creds.canSign(hashLock.PubKey) {
+
+			return nil, nil, false
+		}
+		path.Signers = [][]byte{hashLock.PubKey}
+		return &path, [][]byte{sig, preimage}, true
+	}
+
+	return nil, nil, false
+}
+
+// witnessFee 返回按 feeRate（每 1000 虚拟字节的聪数）为 witnessSize 字节的见证数据支付的手续费，向上取整。
+func witnessFee(witnessSize int, feeRate btcutil.Amount) btcutil.Amount {
+	const kiloWeight = 1000 * witnessScaleFactor
+	return (btcutil.Amount(witnessSize)*feeRate + kiloWeight - 1) / kiloWeight
+}
+
+// CompareSpendPaths 计算 creds 可以满足的每条花费路径的见证大小和手续费，并按成本从低到高返回。 tree 为 nil 时只考虑密钥路径。
+// feeRate 以每 1000 虚拟字节的聪数表示。
+//
+// 内部密钥在 creds.Signers 中时密钥路径可用，其见证只包含一个签名。 叶子的见证包括满足叶子的元素、叶子脚本和控制块，
+// 支持的叶子形式见 satisfyTapscriptLeaf 的说明，其他叶子被视为不可满足。
+//
+// 成本相同的路径中密钥路径排在最前，叶子按其在 tree.LeafMerkleProofs 中的索引排列，因此结果是确定的。
+// 没有可满足的路径时返回 ErrNoSpendPath。
+func CompareSpendPaths(internalKey *btcec.PublicKey,
+	tree *IndexedTapScriptTree, creds *SpendCredentials,
+	feeRate btcutil.Amount) ([]SpendPathCost, error) {
+
+	if feeRate < 0 {
+		return nil, fmt.Errorf("negative fee rate %v", feeRate)
+	}
+
+	var paths []SpendPathCost
+	internalKeyBytes := schnorr.SerializePubKey(internalKey)
+	if creds.canSign(internalKeyBytes) {
+		witness := wire.TxWitness{make([]byte, creds.sigSize())}
+		paths = append(paths, SpendPathCost{
+			KeyPath:     true,
+			LeafIndex:   -1,
+			Signers:     [][]byte{internalKeyBytes},
+			WitnessSize: witness.SerializeSize(),
+		})
+	}
+
+	if tree != nil {
+		for i, proof := range tree.LeafMerkleProofs {
+			if proof.LeafVersion != BaseLeafVersion {
+				continue
+			}
+			path, items, ok := satisfyTapscriptLeaf(proof.Script, creds)
+			if !ok {
+				continue
+			}
+
+			ctrlBlockSize := ControlBlockBaseSize + len(proof.InclusionProof)
+			witness := append(
+				wire.TxWitness(items), proof.Script,
+				make([]byte, ctrlBlockSize),
+			)
+			path.LeafIndex = i
+			path.Leaf = proof.TapLeaf
+			path.WitnessSize = witness.SerializeSize()
+			paths = append(paths, *path)
+		}
+	}
+
+	if len(paths) == 0 {
+		return nil, ErrNoSpendPath
+	}
+	for i := range paths {
+		paths[i].Fee = witnessFee(paths[i].WitnessSize, feeRate)
+	}
+
+	// The key path has index -1 and the leaves are appended in index
+	// order, so a stable sort on size alone keeps ties deterministic.
+	sort.SliceStable(paths, func(i, j int) bool {
+		return paths[i].WitnessSize < paths[j].WitnessSize
+	})
+	return paths, nil
+}
@@ -0,0 +1,148 @@
+package txscript
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcec/v2/schnorr"
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/stretchr/testify/require"
+)
+
+// TestCompareSpendPaths 确保花费路径的见证大小与实际签名的见证一致，并且路径按成本排序。
+func TestCompareSpendPaths(t *testing.T) {
+	t.Parallel()
+
+	var privKeys []*btcec.PrivateKey
+	var xOnly [][]byte
+	for i := 0; i < 4; i++ {
+		privKey, err := btcec.NewPrivateKey()
+		require.NoError(t, err)
+		privKeys = append(privKeys, privKey)
+		xOnly = append(xOnly, schnorr.SerializePubKey(privKey.PubKey()))
+	}
+	internalKey := privKeys[0]
+	preimage := bytes.Repeat([]byte{0x07}, 32)
+
+	// 叶子 0：2-of-3 multi_a；叶子 1 和 2：HTLC 赎回和退款；叶子 3：未知形式。
+	multiA, err := NewMultiADescriptor(2, []*btcec.PublicKey{
+		privKeys[1].PubKey(), privKeys[2].PubKey(), privKeys[3].PubKey(),
+	}, false)
+	require.NoError(t, err)
+	multiALeaf, err := multiA.Leaf()
+	require.NoError(t, err)
+	redeemLeaf, refundLeaf, err := BuildTapscriptHTLCLeaves(
+		privKeys[1].PubKey(), privKeys[2].PubKey(),
+		sha256.Sum256(preimage), 500,
+	)
+	require.NoError(t, err)
+	unknownLeaf := NewBaseTapLeaf(mustParseShortForm("1"))
+	tree := AssembleTaprootScriptTree(
+		multiALeaf, redeemLeaf, refundLeaf, unknownLeaf,
+	)
+
+	creds := &SpendCredentials{
+		Signers:   [][]byte{xOnly[1], xOnly[3], xOnly[2]},
+		Preimages: [][]byte{{0x01}, preimage},
+		HashType:  SigHashDefault,
+	}
+	paths, err := CompareSpendPaths(
+		internalKey.PubKey(), tree, creds, 2000,
+	)
+	require.NoError(t, err)
+	require.Len(t, paths, 3)
+
+	// 退款叶子最短，其次是赎回叶子和 multi_a 叶子。
+	require.Equal(t, 2, paths[0].LeafIndex)
+	require.Equal(t, []TimeLock{{Value: 500}}, paths[0].TimeLocks)
+	require.Equal(t, [][]byte{xOnly[2]}, paths[0].Signers)
+	require.Equal(t, 1, paths[1].LeafIndex)
+	require.Equal(t, [][]byte{preimage}, paths[1].Preimages)
+	require.Equal(t, 0, paths[2].LeafIndex)
+	require.Equal(t, [][]byte{xOnly[1], xOnly[2]}, paths[2].Signers)
+	for i := 1; i < len(paths); i++ {
+		require.LessOrEqual(t, paths[i-1].WitnessSize, paths[i].WitnessSize)
+		require.LessOrEqual(t, paths[i-1].Fee, paths[i].Fee)
+	}
+	require.Equal(t, btcutil.Amount((paths[2].WitnessSize*2000+3999)/4000),
+		paths[2].Fee)
+
+	// 使用真实签名构造见证并验证，确保估计的大小精确。
+	rootHash := tree.RootNode.TapHash()
+	outputKey := ComputeTaprootOutputKey(internalKey.PubKey(), rootHash[:])
+	pkScript, err := PayToTaprootScript(outputKey)
+	require.NoError(t, err)
+	const amt = 1e6
+	prevFetcher := NewCannedPrevOutputFetcher(pkScript, amt)
+	tx := createSpendingTx(nil, nil, pkScript, amt)
+	tx.LockTime = 500
+	tx.TxIn[0].Sequence = 0
+	sigHashes := NewTxSigHashes(tx, prevFetcher)
+
+	signerKeys := map[string]*btcec.PrivateKey{}
+	for i, key := range xOnly {
+		signerKeys[string(key)] = privKeys[i]
+	}
+	for _, path := range paths {
+		proof := tree.LeafMerkleProofs[path.LeafIndex]
+		ctrlBlock := proof.ToControlBlock(internalKey.PubKey())
+		ctrlBytes, err := ctrlBlock.ToBytes()
+		require.NoError(t, err)
+
+		var sigs [][]byte
+		for _, signer := range path.Signers {
+			sig, err := RawTxInTapscriptSignature(
+				tx, sigHashes, 0, amt, pkScript, proof.TapLeaf,
+				SigHashDefault, signerKeys[string(signer)],
+			)
+			require.NoError(t, err)
+			sigs = append(sigs, sig)
+		}
+
+		var witness wire.TxWitness
+		switch path.LeafIndex {
+		case 0:
+			witness = wire.TxWitness{nil, sigs[1], sigs[0]}
+		case 1:
+			witness = wire.TxWitness{sigs[0], preimage}
+		case 2:
+			witness = wire.TxWitness{sigs[0]}
+		}
+		witness = append(witness, proof.Script, ctrlBytes)
+		require.Equal(t, path.WitnessSize, witness.SerializeSize(),
+			"leaf %d", path.LeafIndex)
+
+		tx.TxIn[0].Witness = witness
+		vm, err := NewEngine(pkScript, tx, 0, StandardVerifyFlags, nil,
+			sigHashes, amt, prevFetcher)
+		require.NoError(t, err)
+		require.NoError(t, vm.Execute(), "leaf %d", path.LeafIndex)
+	}
+
+	// 内部密钥可用时密钥路径最便宜。
+	creds.Signers = append(creds.Signers, xOnly[0])
+	paths, err = CompareSpendPaths(internalKey.PubKey(), tree, creds, 2000)
+	require.NoError(t, err)
+	require.True(t, paths[0].KeyPath)
+	require.Equal(t, -1, paths[0].LeafIndex)
+	require.Equal(t, 1+1+schnorr.SignatureSize, paths[0].WitnessSize)
+
+	// 非默认签名哈希类型的签名多一个字节。
+	creds.HashType = SigHashAll
+	allPaths, err := CompareSpendPaths(
+		internalKey.PubKey(), tree, creds, 2000,
+	)
+	require.NoError(t, err)
+	require.Equal(t, paths[0].WitnessSize+1, allPaths[0].WitnessSize)
+
+	// 没有可满足的路径。
+	_, err = CompareSpendPaths(internalKey.PubKey(), tree,
+		&SpendCredentials{Signers: [][]byte{xOnly[3]}}, 1000)
+	require.ErrorIs(t, err, ErrNoSpendPath)
+	_, err = CompareSpendPaths(internalKey.PubKey(), nil,
+		&SpendCredentials{}, 1000)
+	require.ErrorIs(t, err, ErrNoSpendPath)
+}
@@ -5,8 +5,74 @@ package txscript
 import (
 	"encoding/hex"
 	"fmt"
+	"sync"
 )
 
+const (
+	// stackArenaChunkSize 是堆栈内存池每次分配的块大小。
+	stackArenaChunkSize = 4096
+
+	// maxStackArenaAlloc 是从内存池分配的最大元素大小，更大的元素直接从堆上分配，以避免浪费块中的剩余空间。
+	maxStackArenaAlloc = 128
+
+	// maxPooledStackArenaChunks 是引擎完成时保留以供复用的最大块数，避免少数异常脚本使池中的内存持续增长。
+	maxPooledStackArenaChunks = 16
+)
+
+// stackArenaPool 缓存已释放的堆栈内存池。
+var stackArenaPool = sync.Pool{
+	New: func() interface{} {
+		return new(stackArena)
+	},
+}
+
+// stackArena 是单个引擎在执行期间为其计算产生的堆栈元素分配内存的区域。
+//
+// 堆栈元素是不可变的并且可以被多个位置共享（例如 DUP、PICK 以及保存的 P2SH 堆栈），
+// 因此元素被弹出时无法确定其内存是否仍被引用。 区域中的内存只在引擎完成执行时整体释放，而不是在弹出时逐个释放。
+type stackArena struct {
+	chunks [][]byte
+	next   int
+	free   []byte
+}
+
+// getStackArena 从池中返回一个空的内存区域。
+func getStackArena() *stackArena {
+	return stackArenaPool.Get().(*stackArena)
+}
+
+// alloc 返回长度为 n 的字节切片。 切片的容量等于其长度，因此追加操作不会覆盖相邻的元素。
+// 返回的内存可能包含之前执行遗留的数据，调用者必须完整写入。
+func (a *stackArena) alloc(n int) []byte {
+	if n > maxStackArenaAlloc {
+		return make([]byte, n)
+	}
+
+	if len(a.free) < n {
+		if a.next < len(a.chunks) {
+			a.free = a.chunks[a.next]
+		} else {
+			a.free = make([]byte, stackArenaChunkSize)
+			a.chunks = append(a.chunks, a.free)
+		}
+		a.next++
+	}
+
+	b := a.free[:n:n]
+	a.free = a.free[n:]
+	return b
+}
+
+// release 将内存区域归还到池中。 调用后，之前从该区域分配的所有切片都不得再被使用。
+func (a *stackArena) release() {
+	if len(a.chunks) > maxPooledStackArenaChunks {
+		a.chunks = a.chunks[:maxPooledStackArenaChunks]
+	}
+	a.next = 0
+	a.free = nil
+	stackArenaPool.Put(a)
+}
+
 // asBool 获取字节数组的布尔值。
 func asBool(t []byte) bool {
 	for i := range t {
@@ -34,6 +100,12 @@ func fromBool(v bool) []byte {
 type stack struct {
 	stk               [][]byte
 	verifyMinimalData bool
+
+	// arena 在启用 WithPooledStackMemory 时为计算产生的元素提供内存，否则为 nil。
+	arena *stackArena
+
+	// pushedBytes 是推入堆栈的元素的累计字节数，用于统计扩展操作码产生的数据量。
+	pushedBytes int
 }
 
 // Depth 返回堆栈上的项目数。
@@ -45,21 +117,52 @@ func (s *stack) Depth() int32 {
 //
 // 堆栈转换: [... x1 x2] -> [... x1 x2 data]
 func (s *stack) PushByteArray(so []byte) {
+	s.pushedBytes += len(so)
 	s.stk = append(s.stk, so)
 }
 
-// PushInt 将提供的 scriptNum 转换为合适的字节数组，然后将其推入堆栈顶部。
+// alloc 返回长度为 n 的字节切片，启用内存池时从内存池分配。
+func (s *stack) alloc(n int) []byte {
+	if s.arena == nil {
+		return make([]byte, n)
+	}
+	return s.arena.alloc(n)
+}
+
+// pushCopy 将给定数据的副本添加到堆栈顶部。 调用者可以在返回后复用 data。
+//
+// 堆栈转换: [... x1 x2] -> [... x1 x2 data]
+func (s *stack) pushCopy(data []byte) {
+	b := s.alloc(len(data))
+	copy(b, data)
+	s.PushByteArray(b)
+}
+
+// PushInt 将提供的 ScriptNum 转换为合适的字节数组，然后将其推入堆栈顶部。
 //
 // 堆栈转换: [... x1 x2] -> [... x1 x2 int]
-func (s *stack) PushInt(val scriptNum) {
-	s.PushByteArray(val.Bytes())
+func (s *stack) PushInt(val ScriptNum) {
+	if val == 0 || s.arena == nil {
+		s.PushByteArray(val.Bytes())
+		return
+	}
+
+	var buf [9]byte
+	s.pushCopy(val.appendBytes(buf[:0]))
 }
 
 // PushBool 将提供的布尔值转换为合适的字节数组，然后将其推入堆栈顶部。
 //
 // 堆栈转换: [... x1 x2] -> [... x1 x2 bool]
 func (s *stack) PushBool(val bool) {
-	s.PushByteArray(fromBool(val))
+	if !val || s.arena == nil {
+		s.PushByteArray(fromBool(val))
+		return
+	}
+
+	b := s.alloc(1)
+	b[0] = 1
+	s.PushByteArray(b)
 }
 
 // PopByteArray 将值从堆栈顶部弹出并返回。
@@ -73,7 +176,7 @@ func (s *stack) PopByteArray() ([]byte, error) {
 // 转换为脚本 num 的行为强制执行对解释为数字的数据强加的共识规则。
 //
 // 堆栈转换: [... x1 x2 x3] -> [... x1 x2]
-func (s *stack) PopInt() (scriptNum, error) {
+func (s *stack) PopInt() (ScriptNum, error) {
 	so, err := s.PopByteArray()
 	if err != nil {
 		return 0, err
@@ -108,7 +211,7 @@ func (s *stack) PeekByteArray(idx int32) ([]byte, error) {
 
 // PeekInt 将堆栈中的第 N 个项目作为脚本编号返回，而不将其删除。
 // 转换为脚本 num 的行为强制执行对解释为数字的数据强加的共识规则。
-func (s *stack) PeekInt(idx int32) (scriptNum, error) {
+func (s *stack) PeekInt(idx int32) (ScriptNum, error) {
 	so, err := s.PeekByteArray(idx)
 	if err != nil {
 		return 0, err
@@ -129,6 +232,10 @@ func (s *stack) PeekBool(idx int32) (bool, error) {
 
 // nipN 是一个内部函数，用于删除堆栈中的第 n 项并返回它。
 //
+// stk 被用作双端队列：被删除的元素靠近栈顶时其上方的元素下移，靠近栈底时其下方的元素上移并将切片的起点后移一位，
+// 因此每次删除只移动较少的一侧且不分配内存，频繁 ROLL 深处元素的脚本（例如编译后的 miniscript）不会随堆栈深度平方退化。
+// 起点后移留下的容量在下次 append 扩容时回收。
+//
 // 堆栈转换:
 // nipN(0): [... x1 x2 x3] -> [... x1 x2]
 // nipN(1): [... x1 x2 x3] -> [... x1 x3]
@@ -141,17 +248,18 @@ func (s *stack) nipN(idx int32) ([]byte, error) {
 		return nil, scriptError(ErrInvalidStackOperation, str)
 	}
 
-	so := s.stk[sz-idx-1]
-	if idx == 0 {
-		s.stk = s.stk[:sz-1]
-	} else if idx == sz-1 {
-		s1 := make([][]byte, sz-1)
-		copy(s1, s.stk[1:])
-		s.stk = s1
+	// Clear the vacated slot in both cases so the backing array doesn't
+	// keep removed elements alive.
+	pos := sz - idx - 1
+	so := s.stk[pos]
+	if pos < idx {
+		copy(s.stk[1:pos+1], s.stk[:pos])
+		s.stk[0] = nil
+		s.stk = s.stk[1:]
 	} else {
-		s1 := s.stk[sz-idx : sz]
-		s.stk = s.stk[:sz-idx-1]
-		s.stk = append(s.stk, s1...)
+		copy(s.stk[pos:], s.stk[pos+1:])
+		s.stk[sz-1] = nil
+		s.stk = s.stk[:sz-1]
 	}
 	return so, nil
 }
@@ -336,6 +444,16 @@ func (s *stack) RollN(n int32) error {
 	return nil
 }
 
+// detach 将堆栈中的所有元素复制到堆上并停止使用内存池，以便在内存池被释放后堆栈内容仍然有效。
+func (s *stack) detach() {
+	for i, so := range s.stk {
+		if so != nil {
+			s.stk[i] = append([]byte{}, so...)
+		}
+	}
+	s.arena = nil
+}
+
 // String 以可读格式返回堆栈。
 func (s *stack) String() string {
 	var result string
@@ -5,8 +5,11 @@ package txscript
 import (
 	"bytes"
 	"fmt"
+	"math/rand"
 	"reflect"
 	"testing"
+
+	"github.com/stretchr/testify/require"
 )
 
 // tstCheckScriptError 确保两个传递的错误的类型相同（要么都是 nil，要么都是 Error 类型），并且当不为 nil 时，它们的错误代码匹配。
@@ -314,7 +317,7 @@ func TestStack(t *testing.T) {
 			"PushInt 0",
 			nil,
 			func(s *stack) error {
-				s.PushInt(scriptNum(0))
+				s.PushInt(ScriptNum(0))
 				return nil
 			},
 			nil,
@@ -324,7 +327,7 @@ func TestStack(t *testing.T) {
 			"PushInt 1",
 			nil,
 			func(s *stack) error {
-				s.PushInt(scriptNum(1))
+				s.PushInt(ScriptNum(1))
 				return nil
 			},
 			nil,
@@ -334,7 +337,7 @@ func TestStack(t *testing.T) {
 			"PushInt -1",
 			nil,
 			func(s *stack) error {
-				s.PushInt(scriptNum(-1))
+				s.PushInt(ScriptNum(-1))
 				return nil
 			},
 			nil,
@@ -344,7 +347,7 @@ func TestStack(t *testing.T) {
 			"PushInt two bytes",
 			nil,
 			func(s *stack) error {
-				s.PushInt(scriptNum(256))
+				s.PushInt(ScriptNum(256))
 				return nil
 			},
 			nil,
@@ -356,7 +359,7 @@ func TestStack(t *testing.T) {
 			nil,
 			func(s *stack) error {
 				// 这将设置高位
-				s.PushInt(scriptNum(128))
+				s.PushInt(ScriptNum(128))
 				return nil
 			},
 			nil,
@@ -478,7 +481,7 @@ func TestStack(t *testing.T) {
 			"PushInt PopBool",
 			nil,
 			func(s *stack) error {
-				s.PushInt(scriptNum(1))
+				s.PushInt(ScriptNum(1))
 				val, err := s.PopBool()
 				if err != nil {
 					return err
@@ -496,7 +499,7 @@ func TestStack(t *testing.T) {
 			"PushInt PopBool 2",
 			nil,
 			func(s *stack) error {
-				s.PushInt(scriptNum(0))
+				s.PushInt(ScriptNum(0))
 				val, err := s.PopBool()
 				if err != nil {
 					return err
@@ -863,7 +866,7 @@ func TestStack(t *testing.T) {
 			"pop int",
 			nil,
 			func(s *stack) error {
-				s.PushInt(scriptNum(1))
+				s.PushInt(ScriptNum(1))
 				// Peek int 在其他方面经过了很好的测试，
 				// 只是检查它是否有效。
 				val, err := s.PopInt()
@@ -935,3 +938,102 @@ func TestStack(t *testing.T) {
 		}
 	}
 }
+
+// TestStackArena 确保内存池分配的元素互不重叠，并且释放后的内存池可以被复用。
+func TestStackArena(t *testing.T) {
+	t.Parallel()
+
+	arena := new(stackArena)
+	a := arena.alloc(3)
+	b := arena.alloc(2)
+	copy(a, []byte{1, 2, 3})
+	copy(b, []byte{4, 5})
+
+	// 追加到元素不能覆盖相邻的元素。
+	require.Equal(t, 3, cap(a))
+	_ = append(a, 0xff)
+	require.Equal(t, []byte{4, 5}, b)
+
+	// 大元素直接从堆上分配，并且跨块的分配会使用新块。
+	require.Len(t, arena.alloc(maxStackArenaAlloc+1), maxStackArenaAlloc+1)
+	require.Len(t, arena.chunks, 1)
+	for i := 0; i < stackArenaChunkSize/maxStackArenaAlloc; i++ {
+		arena.alloc(maxStackArenaAlloc)
+	}
+	require.Len(t, arena.chunks, 2)
+
+	arena.release()
+	require.Zero(t, arena.next)
+	require.Nil(t, arena.free)
+	require.Len(t, arena.chunks, 2)
+}
+
+// TestPooledStackMemory 确保启用内存池的引擎与普通引擎的执行结果相同，
+// 并且 Execute 返回后的堆栈内容不会因为其他引擎复用内存池而被修改。
+func TestPooledStackMemory(t *testing.T) {
+	t.Parallel()
+
+	// 执行后在堆栈上留下计算产生的布尔值、哈希摘要和数字。
+	run := func(script string, opts ...EngineOpt) *Engine {
+		vm, err := NewSimulationEngine(SimulationParams{
+			Script: mustParseShortForm(script),
+		}, opts...)
+		require.NoError(t, err)
+		require.NoError(t, vm.Execute())
+		return vm
+	}
+	const script = "2 3 ADD 5 NUMEQUAL DATA_1 0x61 SHA256 DATA_1 0x61 " +
+		"HASH256 DUP SIZE 1"
+
+	plain := run(script)
+	pooled := run(script, WithPooledStackMemory())
+	require.Nil(t, pooled.dstack.arena)
+	require.Nil(t, pooled.astack.arena)
+	require.Len(t, pooled.GetStack(), 5)
+	require.Equal(t, plain.GetStack(), pooled.GetStack())
+
+	// 内存池被其他引擎复用后，之前引擎的最终堆栈保持不变。
+	final := plain.GetStack()
+	for i := 0; i < 8; i++ {
+		run("7 9 ADD 16 NUMEQUAL DATA_1 0x62 SHA256 DATA_1 0x62 "+
+			"HASH256 DUP SIZE 1", WithPooledStackMemory())
+	}
+	require.Equal(t, final, pooled.GetStack())
+}
+
+// TestStackNipDeque 确保在栈底和栈中间交替删除元素并推入新元素后，堆栈的内容与逐个复制的参照实现一致。
+func TestStackNipDeque(t *testing.T) {
+	t.Parallel()
+
+	rng := rand.New(rand.NewSource(1))
+	var s stack
+	var model [][]byte
+	for i := 0; i < 2000; i++ {
+		if len(model) == 0 || rng.Intn(3) == 0 {
+			item := []byte{byte(i), byte(i >> 8)}
+			s.PushByteArray(item)
+			model = append(model, item)
+			continue
+		}
+
+		// Favour the bottom of the stack, which is what ROLL on a deep
+		// stack removes.
+		idx := int32(len(model) - 1)
+		if rng.Intn(2) == 0 {
+			idx = rng.Int31n(int32(len(model)))
+		}
+		pos := len(model) - int(idx) - 1
+		item := model[pos]
+		model = append(model[:pos:pos], model[pos+1:]...)
+		if rng.Intn(2) == 0 {
+			require.NoError(t, s.RollN(idx))
+			model = append(model, item)
+		} else {
+			require.NoError(t, s.NipN(idx))
+		}
+		require.Equal(t, model, getStack(&s))
+	}
+
+	_, err := s.nipN(s.Depth())
+	require.True(t, IsErrorCode(err, ErrInvalidStackOperation))
+}
@@ -0,0 +1,247 @@
+// 包含用于断言引擎中间状态的简单堆栈检查语言，使合约测试套件可以在 Execute 或 Step 之后用一行表达式检查堆栈，
+// 例如 vm.Expect("depth=2 top=0x01 alt_empty")，而不必手动检查 GetStack 的结果。
+
+package txscript
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+var (
+	// ErrInvalidStackAssertion 在堆栈断言表达式无法解析时返回。
+	ErrInvalidStackAssertion = errors.New("invalid stack assertion")
+
+	// ErrStackAssertionFailed 在引擎的状态不满足堆栈断言时返回。
+	ErrStackAssertionFailed = errors.New("stack assertion failed")
+)
+
+// stackValue 是断言中与堆栈元素比较的值。
+type stackValue struct {
+	text string
+
+	// 以下三种形式只有一种有效：bytes 不为 nil 时按字节比较，isBool 为 true 时按布尔值比较，否则按脚本数字比较。
+	bytes  []byte
+	isBool bool
+	b      bool
+	num    int64
+}
+
+// matches 返回堆栈元素是否等于该值。
+func (v *stackValue) matches(item []byte) bool {
+	switch {
+	case v.bytes != nil:
+		return bytes.Equal(item, v.bytes)
+	case v.isBool:
+		return asBool(item) == v.b
+	}
+	const maxNumLen = 8
+	n, err := MakeScriptNum(item, false, maxNumLen)
+	return err == nil && int64(n) == v.num
+}
+
+// parseStackValue 解析断言中的值：以 0x 开头的十六进制字节，true 或 false，或十进制的脚本数字。
+func parseStackValue(text string) (*stackValue, error) {
+	v := &stackValue{text: text}
+	switch {
+	case strings.HasPrefix(text, "0x"):
+		b, err := hex.DecodeString(text[2:])
+		if err != nil {
+			return nil, err
+		}
+		v.bytes = append([]byte{}, b...)
+
+	case text == "true", text == "false":
+		v.isBool = true
+		v.b = text == "true"
+
+	default:
+		n, err := strconv.ParseInt(text, 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		v.num = n
+	}
+	return v, nil
+}
+
+// stackCheck 是断言中的一项检查。 check 返回检查失败时的描述，成功时返回空字符串。
+type stackCheck struct {
+	term  string
+	check func(vm *Engine) string
+}
+
+// StackAssertion 是解析后的堆栈断言，可以对多个引擎或同一引擎的多个步骤重复检查。
+//
+// 表达式由空白分隔的项组成，所有项都满足时断言成立：
+//
+//   - depth=N、alt_depth=N：数据堆栈或备用堆栈的深度
+//   - empty、alt_empty：数据堆栈或备用堆栈为空
+//   - top=V、alt_top=V：数据堆栈或备用堆栈的栈顶元素
+//   - stack[i]=V、alt[i]=V：从栈顶数起（栈顶为 0）的第 i 个元素
+//   - script=N、op=N：程序计数器所在的脚本序号和操作码序号，与 DisasmPC 的输出相同
+//
+// 值 V 可以是以 0x 开头的十六进制字节（"0x" 表示空元素）、按脚本布尔值比较的 true 或 false，
+// 或按脚本数字比较的十进制整数，因此 top=1 与 0x01 匹配，top=0 与空元素匹配。
+type StackAssertion struct {
+	expr   string
+	checks []stackCheck
+}
+
+// ParseStackAssertion 解析堆栈断言表达式，语法见 StackAssertion。 表达式无法解析时返回 ErrInvalidStackAssertion。
+func ParseStackAssertion(expr string) (*StackAssertion, error) {
+	a := &StackAssertion{expr: expr}
+	for _, term := range strings.Fields(expr) {
+		check, err := parseStackCheck(term)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %q: %v", ErrInvalidStackAssertion,
+				term, err)
+		}
+		a.checks = append(a.checks, stackCheck{term: term, check: check})
+	}
+	if len(a.checks) == 0 {
+		return nil, fmt.Errorf("%w: empty expression",
+			ErrInvalidStackAssertion)
+	}
+	return a, nil
+}
+
+// parseStackCheck 解析断言中的一项。
+func parseStackCheck(term string) (func(vm *Engine) string, error) {
+	switch term {
+	case "empty":
+		return depthCheck(false, 0), nil
+	case "alt_empty":
+		return depthCheck(true, 0), nil
+	}
+
+	key, value, ok := strings.Cut(term, "=")
+	if !ok {
+		return nil, errors.New("expected key=value")
+	}
+
+	switch key {
+	case "depth", "alt_depth", "script", "op":
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 0 {
+			return nil, fmt.Errorf("invalid count %q", value)
+		}
+		switch key {
+		case "depth":
+			return depthCheck(false, n), nil
+		case "alt_depth":
+			return depthCheck(true, n), nil
+		case "script":
+			return func(vm *Engine) string {
+				if vm.scriptIdx != n {
+					return fmt.Sprintf("script is %d", vm.scriptIdx)
+				}
+				return ""
+			}, nil
+		default:
+			return func(vm *Engine) string {
+				if vm.opcodeIdx != n {
+					return fmt.Sprintf("op is %d", vm.opcodeIdx)
+				}
+				return ""
+			}, nil
+		}
+	}
+
+	v, err := parseStackValue(value)
+	if err != nil {
+		return nil, fmt.Errorf("invalid value %q", value)
+	}
+	switch key {
+	case "top":
+		return itemCheck(false, 0, v), nil
+	case "alt_top":
+		return itemCheck(true, 0, v), nil
+	}
+
+	var alt bool
+	var idxStr string
+	switch {
+	case strings.HasPrefix(key, "stack[") && strings.HasSuffix(key, "]"):
+		idxStr = key[len("stack[") : len(key)-1]
+	case strings.HasPrefix(key, "alt[") && strings.HasSuffix(key, "]"):
+		alt = true
+		idxStr = key[len("alt[") : len(key)-1]
+	default:
+		return nil, fmt.Errorf("unknown key %q", key)
+	}
+	idx, err := strconv.Atoi(idxStr)
+	if err != nil || idx < 0 {
+		return nil, fmt.Errorf("invalid index %q", idxStr)
+	}
+	return itemCheck(alt, idx, v), nil
+}
+
+// assertionStack 返回断言检查的堆栈。
+func assertionStack(vm *Engine, alt bool) *stack {
+	if alt {
+		return &vm.astack
+	}
+	return &vm.dstack
+}
+
+// depthCheck 返回检查堆栈深度的函数。
+func depthCheck(alt bool, depth int) func(vm *Engine) string {
+	return func(vm *Engine) string {
+		if got := int(assertionStack(vm, alt).Depth()); got != depth {
+			return fmt.Sprintf("depth is %d", got)
+		}
+		return ""
+	}
+}
+
+// itemCheck 返回检查从栈顶数起第 idx 个元素的函数。
+func itemCheck(alt bool, idx int, v *stackValue) func(vm *Engine) string {
+	return func(vm *Engine) string {
+		item, err := assertionStack(vm, alt).PeekByteArray(int32(idx))
+		if err != nil {
+			return "no such item"
+		}
+		if !v.matches(item) {
+			return fmt.Sprintf("item is 0x%x", item)
+		}
+		return ""
+	}
+}
+
+// String 返回断言的表达式。
+func (a *StackAssertion) String() string {
+	return a.expr
+}
+
+// Check 对引擎的当前状态检查断言，所有项都满足时返回 nil，否则返回列出所有不满足的项的 ErrStackAssertionFailed 错误。
+// 引擎执行失败后堆栈的状态未定义，检查的结果也没有意义。
+func (a *StackAssertion) Check(vm *Engine) error {
+	var failures []string
+	for _, c := range a.checks {
+		if reason := c.check(vm); reason != "" {
+			failures = append(failures, fmt.Sprintf("%s (%s)", c.term,
+				reason))
+		}
+	}
+	if len(failures) == 0 {
+		return nil
+	}
+	return fmt.Errorf("%w: %s", ErrStackAssertionFailed,
+		strings.Join(failures, ", "))
+}
+
+// Expect 解析 expr 并对引擎的当前状态进行检查，是 ParseStackAssertion 和 StackAssertion.Check 的简写。
+// 可以在 Step 之间调用以检查中间状态，也可以在 Execute 之后检查最终状态。 注意 Execute 成功结束时会清除备用堆栈，
+// 并且在使用 WithPooledStackMemory 时释放堆栈内存，此时只应检查深度。
+func (vm *Engine) Expect(expr string) error {
+	a, err := ParseStackAssertion(expr)
+	if err != nil {
+		return err
+	}
+	return a.Check(vm)
+}
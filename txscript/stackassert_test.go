@@ -0,0 +1,79 @@
+package txscript
+
+import (
+	"testing"
+
+	"github.com/btcsuite/btcd/wire"
+	"github.com/stretchr/testify/require"
+)
+
+// TestStackAssertion 确保在逐步执行脚本时断言能够检查数据堆栈、备用堆栈和程序计数器的中间状态，
+// 并且失败的断言列出所有不满足的项。
+func TestStackAssertion(t *testing.T) {
+	t.Parallel()
+
+	var tx wire.MsgTx
+	tx.AddTxIn(&wire.TxIn{})
+	pkScript := mustParseShortForm("1 0x02 0x0102 TOALTSTACK 0 DROP")
+	vm, err := NewEngine(pkScript, &tx, 0, 0, nil, nil, 0, nil)
+	require.NoError(t, err)
+
+	require.NoError(t, vm.Expect("empty alt_empty depth=0"))
+
+	steps := []string{
+		"depth=1 top=1 top=0x01 top=true alt_empty script=1 op=1",
+		"depth=2 top=0x0102 stack[1]=1 alt_empty",
+		"depth=1 top=1 alt_depth=1 alt_top=0x0102 alt[0]=513",
+		"depth=2 top=0 top=0x top=false stack[1]=0x01",
+		// 脚本执行完成后备用堆栈被清除。
+		"depth=1 top=1 alt_empty",
+	}
+	for i, expr := range steps {
+		done, err := vm.Step()
+		require.NoError(t, err)
+		require.Equal(t, i == len(steps)-1, done)
+		require.NoError(t, vm.Expect(expr), "step %d", i)
+	}
+
+	// 所有不满足的项都出现在错误中。
+	err = vm.Expect("depth=3 top=0x02 stack[4]=1 alt_depth=1 op=5")
+	require.ErrorIs(t, err, ErrStackAssertionFailed)
+	for _, term := range []string{
+		"depth=3 (depth is 1)", "top=0x02 (item is 0x01)",
+		"stack[4]=1 (no such item)", "alt_depth=1 (depth is 0)",
+		"op=5 (op is 0)",
+	} {
+		require.Contains(t, err.Error(), term)
+	}
+
+	// 解析后的断言可以重复使用。
+	a, err := ParseStackAssertion("depth=1  top=true")
+	require.NoError(t, err)
+	require.Equal(t, "depth=1  top=true", a.String())
+	require.NoError(t, a.Check(vm))
+}
+
+// TestParseStackAssertionErrors 确保无法解析的表达式返回 ErrInvalidStackAssertion。
+func TestParseStackAssertionErrors(t *testing.T) {
+	t.Parallel()
+
+	tests := []string{
+		"",
+		"   ",
+		"full",
+		"depth",
+		"depth=-1",
+		"depth=x",
+		"top=0xzz",
+		"top=maybe",
+		"stack[x]=1",
+		"stack[-1]=1",
+		"stack[1=1",
+		"bottom=1",
+		"depth=1 alt_top=",
+	}
+	for _, test := range tests {
+		_, err := ParseStackAssertion(test)
+		require.ErrorIs(t, err, ErrInvalidStackAssertion, "%q", test)
+	}
+}
@@ -3,8 +3,11 @@
 package txscript
 
 import (
+	"bytes"
 	"fmt"
+	"sort"
 
+	"github.com/btcsuite/btcd/btcec/v2"
 	"github.com/btcsuite/btcd/btcutil"
 	"github.com/btcsuite/btcd/chaincfg"
 	"github.com/btcsuite/btcd/wire"
@@ -74,7 +77,10 @@ var scriptClassToName = []string{
 
 // String 通过返回枚举脚本类的名称来实现 Stringer 接口。如果枚举无效，则返回 "Invalid"（无效）。
 func (t ScriptClass) String() string {
-	if int(t) > len(scriptClassToName) || int(t) < 0 {
+	if custom := lookupCustomScriptClass(t); custom != nil {
+		return custom.Name
+	}
+	if int(t) >= len(scriptClassToName) || int(t) < 0 {
 		return "Invalid"
 	}
 	return scriptClassToName[t]
@@ -132,13 +138,11 @@ func isPubKeyScript(script []byte) bool {
 }
 
 // extractPubKeyHash 从传递的脚本中提取公钥哈希值，如果它 是标准的支付到公钥哈希脚本。 否则将返回 nil。
+// 长度在访问任何字节之前检查，因此任意输入都不会导致越界。
 func extractPubKeyHash(script []byte) []byte {
 	// A pay-to-pubkey-hash script is of the form:
 	//  OP_DUP OP_HASH160 <20-byte hash> OP_EQUALVERIFY OP_CHECKSIG
 
-	fmt.Printf("\nscript:\t\t%d\n", len(script))
-	fmt.Printf("script[3:23]:\t%x\n", script[3:23])
-	fmt.Printf("script[24]:\t%d\n\n", script[24])
 	if len(script) == 25 &&
 		script[0] == OP_DUP &&
 		script[1] == OP_HASH160 &&
@@ -399,6 +403,18 @@ func extractWitnessProgramInfo(script []byte) (int, []byte, bool) {
 	return version, program, valid
 }
 
+// extractWitnessUnknownProgram 在传递的脚本是版本未知的有效见证程序时返回其版本和程序，否则程序为 nil。
+// 版本 0 的程序只有 20 和 32 字节两种有效形式，因此不属于未知版本；32 字节的版本 1 程序是 taproot 输出。
+func extractWitnessUnknownProgram(script []byte) (int, []byte) {
+	version, program, valid := extractWitnessProgramInfo(script)
+	if !valid || version == BaseSegwitWitnessVersion ||
+		isWitnessTaprootScript(script) {
+
+		return 0, nil
+	}
+	return version, program
+}
+
 // 如果传递的脚本是见证程序，isWitnessProgramScript 返回 true，否则返回 false。
 // 见证程序必须遵守以下约束：必须有两个弹出窗口（程序版本和程序本身），
 // 第一个操作码必须是小整数（0-16），推送数据必须是规范数据，最后，推送数据的大小必须在 2 到 40 字节之间。
@@ -436,10 +452,18 @@ func extractAnnex(witness [][]byte) ([]byte, error) {
 	return lastElement, nil
 }
 
-// isNullDataScript 返回传递的脚本是否为标准空数据脚本。
+// isNullDataScript 返回传递的脚本是否为按包级默认限制的标准空数据脚本。
 //
 // 注意：此函数仅对版本 0 的脚本有效。 对于其他版本的脚本，它总是返回 false。
 func isNullDataScript(scriptVersion uint16, script []byte) bool {
+	maxDataCarrierSize := DefaultChainLimits().MaxDataCarrierSize
+	return isNullDataScriptSize(scriptVersion, script, maxDataCarrierSize)
+}
+
+// isNullDataScriptSize 返回传递的脚本是否为推送的数据不超过 maxDataCarrierSize 字节的标准空数据脚本。
+func isNullDataScriptSize(scriptVersion uint16, script []byte,
+	maxDataCarrierSize int) bool {
+
 	// The only currently supported script version is 0.
 	if scriptVersion != 0 {
 		return false
@@ -466,12 +490,20 @@ func isNullDataScript(scriptVersion uint16, script []byte) bool {
 	tokenizer := MakeScriptTokenizer(scriptVersion, script[1:])
 	return tokenizer.Next() && tokenizer.Done() &&
 		(IsSmallInt(tokenizer.Opcode()) || tokenizer.Opcode() <= OP_PUSHDATA4) &&
-		len(tokenizer.Data()) <= MaxDataCarrierSize
+		len(tokenizer.Data()) <= maxDataCarrierSize
 }
 
 // scriptType 返回从已知标准类型中检查的脚本类型。如果脚本是 segwit v0 或更早版本的脚本，
 // 版本版本应为 0；segwit v1（taproot）版本的脚本，版本版本应为 1。
 func typeOfScript(scriptVersion uint16, script []byte) ScriptClass {
+	maxDataCarrierSize := DefaultChainLimits().MaxDataCarrierSize
+	return typeOfScriptSize(scriptVersion, script, maxDataCarrierSize)
+}
+
+// typeOfScriptSize 与 typeOfScript 相同，但空数据脚本推送的数据不能超过 maxDataCarrierSize 字节。
+func typeOfScriptSize(scriptVersion uint16, script []byte,
+	maxDataCarrierSize int) ScriptClass {
+
 	switch scriptVersion {
 	case BaseSegwitWitnessVersion:
 		switch {
@@ -487,9 +519,26 @@ func typeOfScript(scriptVersion uint16, script []byte) ScriptClass {
 			return WitnessV0ScriptHashTy
 		case isMultisigScript(scriptVersion, script):
 			return MultiSigTy
-		case isNullDataScript(scriptVersion, script):
+		case isNullDataScriptSize(scriptVersion, script, maxDataCarrierSize):
 			return NullDataTy
 		}
+
+		// Taproot outputs are recognized as version 1 below, they take
+		// precedence over registered templates like the other builtins.
+		if isWitnessTaprootScript(script) {
+			break
+		}
+
+		// 所有内置模板都不匹配时，再尝试链特定的已注册模板。
+		if custom := matchCustomScriptClass(script); custom != nil {
+			return custom.class
+		}
+
+		// Programs of future witness versions are reserved for soft
+		// forks and are anyone-can-spend until then.
+		if _, program := extractWitnessUnknownProgram(script); program != nil {
+			return WitnessUnknownTy
+		}
 	case TaprootWitnessVersion:
 		switch {
 		case isWitnessTaprootScript(script):
@@ -503,15 +552,25 @@ func typeOfScript(scriptVersion uint16, script []byte) ScriptClass {
 // GetScriptClass 返回所传递脚本的类。
 // 当脚本无法解析时，将返回 NonStandardTy。
 func GetScriptClass(script []byte) ScriptClass {
+	limits := DefaultChainLimits()
+	return limits.GetScriptClass(script)
+}
+
+// GetScriptClass 与包级函数 GetScriptClass 相同，但按 l 而不是包级默认限制识别空数据脚本。
+func (l *ChainLimits) GetScriptClass(script []byte) ScriptClass {
 	const scriptVersionSegWit = 0
-	classSegWit := typeOfScript(scriptVersionSegWit, script)
+	classSegWit := typeOfScriptSize(
+		scriptVersionSegWit, script, l.MaxDataCarrierSize,
+	)
 
 	if classSegWit != NonStandardTy {
 		return classSegWit
 	}
 
 	const scriptVersionTaproot = 1
-	return typeOfScript(scriptVersionTaproot, script)
+	return typeOfScriptSize(
+		scriptVersionTaproot, script, l.MaxDataCarrierSize,
+	)
 }
 
 // NewScriptClass 返回与作为参数提供的字符串名称相对应的 ScriptClass。
@@ -523,6 +582,12 @@ func NewScriptClass(name string) (*ScriptClass, error) {
 			return &value, nil
 		}
 	}
+	for _, custom := range registeredScriptClasses() {
+		if custom.Name == name {
+			value := custom.class
+			return &value, nil
+		}
+	}
 
 	return nil, fmt.Errorf("%w: %s", ErrUnsupportedScriptType, name)
 }
@@ -566,10 +631,15 @@ func expectedInputs(script []byte, class ScriptClass) int {
 		return AsSmallInt(script[0]) + 1
 
 	case NullDataTy:
-		fallthrough
-	default:
 		return -1
 	}
+
+	if custom := lookupCustomScriptClass(class); custom != nil &&
+		custom.ExpectedInputs != nil {
+
+		return custom.ExpectedInputs(script)
+	}
+	return -1
 }
 
 // ScriptInfo 包含脚本对的信息，该信息由 CalcScriptInfo.
@@ -794,6 +864,12 @@ func PayToAddrScript(addr btcutil.Address) ([]byte, error) {
 				nilAddrErrStr)
 		}
 		return payToWitnessTaprootScript(addr.ScriptAddress())
+	case *WitnessAddress:
+		if addr == nil {
+			return nil, scriptError(ErrUnsupportedAddress,
+				nilAddrErrStr)
+		}
+		return PayToWitnessProgramScript(addr.Version(), addr.Program())
 	}
 
 	str := fmt.Sprintf("unable to generate payment script for unsupported "+
@@ -802,15 +878,22 @@ func PayToAddrScript(addr btcutil.Address) ([]byte, error) {
 }
 
 // NullDataScript 会创建一个可证明可裁剪的脚本，该脚本包含 OP_RETURN，后面跟传入的数据。
-// 如果传递的数据长度超过 MaxDataCarrierSize，将返回错误代码为 ErrTooMuchNullData 的错误信息。
+// 如果传递的数据长度超过包级默认限制中的 MaxDataCarrierSize，将返回错误代码为 ErrTooMuchNullData 的错误信息。
 func NullDataScript(data []byte) ([]byte, error) {
-	if len(data) > MaxDataCarrierSize {
+	limits := DefaultChainLimits()
+	return limits.NullDataScript(data)
+}
+
+// NullDataScript 与包级函数 NullDataScript 相同，但按 l 而不是包级默认限制检查数据和脚本的大小。
+func (l *ChainLimits) NullDataScript(data []byte) ([]byte, error) {
+	if len(data) > l.MaxDataCarrierSize {
 		str := fmt.Sprintf("data size %d is larger than max "+
-			"allowed size %d", len(data), MaxDataCarrierSize)
+			"allowed size %d", len(data), l.MaxDataCarrierSize)
 		return nil, scriptError(ErrTooMuchNullData, str)
 	}
 
-	return NewScriptBuilder().AddOp(OP_RETURN).AddData(data).Script()
+	return NewScriptBuilder(WithBuilderChainLimits(*l)).AddOp(OP_RETURN).
+		AddData(data).Script()
 }
 
 // MultiSigScript 返回多签名赎回的有效脚本，其中
@@ -834,6 +917,58 @@ func MultiSigScript(pubkeys []*btcutil.AddressPubKey, nrequired int) ([]byte, er
 	return builder.Script()
 }
 
+// SortedMultiSigScript 返回按 BIP0067 排序公钥的多重签名脚本，公钥按序列化字节的字典序升序排列，
+// 因此使用相同公钥的各方无论公钥的传入顺序如何都会得到相同的脚本和地址。 BIP0067 只允许压缩公钥，传入未压缩公钥时返回错误。
+func SortedMultiSigScript(pubkeys []*btcutil.AddressPubKey, nrequired int) ([]byte, error) {
+	keys := make([][]byte, 0, len(pubkeys))
+	for _, key := range pubkeys {
+		serialized := key.ScriptAddress()
+		if len(serialized) != btcec.PubKeyBytesLenCompressed {
+			str := fmt.Sprintf("BIP0067 requires compressed public keys, "+
+				"got %d byte key %x", len(serialized), serialized)
+			return nil, scriptError(ErrPubKeyType, str)
+		}
+		keys = append(keys, serialized)
+	}
+	sortPubKeys(keys)
+
+	sorted := make([]*btcutil.AddressPubKey, 0, len(pubkeys))
+	for _, key := range keys {
+		for _, addr := range pubkeys {
+			if bytes.Equal(addr.ScriptAddress(), key) {
+				sorted = append(sorted, addr)
+				break
+			}
+		}
+	}
+	return MultiSigScript(sorted, nrequired)
+}
+
+// IsSortedMultiSig 返回 script 是否是公钥全部为压缩公钥并按 BIP0067 排序的标准多重签名脚本。
+func IsSortedMultiSig(script []byte) bool {
+	const scriptVersion = 0
+	details := extractMultisigScriptDetails(scriptVersion, script, true)
+	if !details.valid {
+		return false
+	}
+	for i, key := range details.pubKeys {
+		if len(key) != btcec.PubKeyBytesLenCompressed {
+			return false
+		}
+		if i > 0 && bytes.Compare(details.pubKeys[i-1], key) > 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// sortPubKeys 按序列化字节的字典序原地升序排列公钥。
+func sortPubKeys(keys [][]byte) {
+	sort.Slice(keys, func(i, j int) bool {
+		return bytes.Compare(keys[i], keys[j]) < 0
+	})
+}
+
 // PushedData 返回一个字节片数组，其中包含在传递的脚本中找到的任何推送数据。 这包括 OP_0，但不包括 OP_1 - OP_16。
 func PushedData(script []byte) ([][]byte, error) {
 	const scriptVersion = 0
@@ -948,10 +1083,133 @@ func ExtractPkScriptAddrs(pkScript []byte,
 		return WitnessV1TaprootTy, addrs, 1, nil
 	}
 
+	// Check for chain-specific registered templates.
+	if custom := matchCustomScriptClass(pkScript); custom != nil {
+		if custom.ExtractAddrs == nil {
+			return custom.class, nil, 0, nil
+		}
+		addrs, reqSigs := custom.ExtractAddrs(pkScript, chainParams)
+		return custom.class, addrs, reqSigs, nil
+	}
+
+	// The spending rules of future witness versions are undefined, so the
+	// number of required signatures is unknown.
+	if version, program := extractWitnessUnknownProgram(pkScript); program != nil {
+		var addrs []btcutil.Address
+		addr, err := NewWitnessAddress(version, program, chainParams)
+		if err == nil {
+			addrs = append(addrs, addr)
+		}
+		return WitnessUnknownTy, addrs, 0, nil
+	}
+
 	// If none of the above passed, then the address must be non-standard.
 	return NonStandardTy, nil, 0, nil
 }
 
+// StandardScriptData 是 ExtractStandardData 从公钥脚本中提取的数据，Class 决定哪些字段有效：
+//
+//   - PubKeyTy：PubKey
+//   - PubKeyHashTy、ScriptHashTy：Hash（20 字节）
+//   - WitnessV0PubKeyHashTy、WitnessV0ScriptHashTy：Hash、WitnessVersion 和 WitnessProgram
+//   - WitnessV1TaprootTy：TaprootKey（32 字节的 x-only 公钥）、WitnessVersion 和 WitnessProgram
+//   - WitnessUnknownTy：WitnessVersion 和 WitnessProgram
+//   - MultiSigTy：RequiredSigs 和 PubKeys
+//   - NullDataTy：NullData（可能为空）
+//
+// 其他字段为零值。 所有字节切片都引用传入的脚本而不是副本，调用者在使用期间不得修改脚本。
+type StandardScriptData struct {
+	Class ScriptClass
+
+	PubKey     []byte
+	Hash       []byte
+	TaprootKey []byte
+
+	WitnessVersion int
+	WitnessProgram []byte
+
+	RequiredSigs int
+	PubKeys      [][]byte
+
+	NullData []byte
+}
+
+// ExtractStandardData 识别公钥脚本的标准类别并返回该类别的数据字段，供索引器等调用者直接使用，
+// 而无需像 ExtractPkScriptAddrs 那样转换为地址。 任意字节都可以安全传入，无法识别的脚本返回 NonStandardTy。
+//
+// 类别的优先级与 GetScriptClass 相同：内置模板优先，其次是链特定的已注册模板，最后才是版本未知的见证程序，
+// 因此已注册模板匹配的未来见证程序返回该模板的类别。 链特定的已注册模板只返回其类别。
+func ExtractStandardData(script []byte) StandardScriptData {
+	const scriptVersion = 0
+
+	if data := extractPubKey(script); data != nil {
+		return StandardScriptData{Class: PubKeyTy, PubKey: data}
+	}
+	if hash := extractPubKeyHash(script); hash != nil {
+		return StandardScriptData{Class: PubKeyHashTy, Hash: hash}
+	}
+	if hash := extractScriptHash(script); hash != nil {
+		return StandardScriptData{Class: ScriptHashTy, Hash: hash}
+	}
+
+	version, program, isWitness := extractWitnessProgramInfo(script)
+	if isWitness {
+		data := StandardScriptData{
+			WitnessVersion: version,
+			WitnessProgram: program,
+		}
+		switch {
+		case extractWitnessPubKeyHash(script) != nil:
+			data.Class = WitnessV0PubKeyHashTy
+			data.Hash = program
+			return data
+		case extractWitnessV0ScriptHash(script) != nil:
+			data.Class = WitnessV0ScriptHashTy
+			data.Hash = program
+			return data
+		case extractWitnessV1KeyBytes(script) != nil:
+			data.Class = WitnessV1TaprootTy
+			data.TaprootKey = program
+			return data
+		}
+	}
+
+	details := extractMultisigScriptDetails(scriptVersion, script, true)
+	if details.valid {
+		return StandardScriptData{
+			Class:        MultiSigTy,
+			RequiredSigs: details.requiredSigs,
+			PubKeys:      details.pubKeys,
+		}
+	}
+
+	if isNullDataScript(scriptVersion, script) {
+		data := StandardScriptData{Class: NullDataTy}
+		if len(script) > 1 {
+			tokenizer := MakeScriptTokenizer(scriptVersion, script[1:])
+			tokenizer.Next()
+			data.NullData = tokenizer.Data()
+		}
+		return data
+	}
+
+	if custom := matchCustomScriptClass(script); custom != nil {
+		return StandardScriptData{Class: custom.class}
+	}
+
+	// Version 0 programs of any other length are invalid rather than
+	// reserved for future upgrades.
+	if isWitness && version != BaseSegwitWitnessVersion {
+		return StandardScriptData{
+			Class:          WitnessUnknownTy,
+			WitnessVersion: version,
+			WitnessProgram: program,
+		}
+	}
+
+	return StandardScriptData{Class: NonStandardTy}
+}
+
 // AtomicSwapDataPushes 包含原子交换合约中的数据推送。
 type AtomicSwapDataPushes struct {
 	RecipientHash160 [20]byte
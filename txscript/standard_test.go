@@ -860,6 +860,80 @@ func TestMultiSigScript(t *testing.T) {
 	}
 }
 
+// TestSortedMultiSigScript 确保 SortedMultiSigScript 按 BIP0067 测试向量排序公钥，结果与传入顺序无关，
+// 拒绝未压缩公钥，并且 IsSortedMultiSig 能识别排序后的脚本。
+func TestSortedMultiSigScript(t *testing.T) {
+	t.Parallel()
+
+	newAddr := func(pubKey string) *btcutil.AddressPubKey {
+		addr, err := btcutil.NewAddressPubKey(hexToBytes(pubKey),
+			&chaincfg.MainNetParams)
+		if err != nil {
+			t.Fatalf("Unable to create pubkey address: %v", err)
+		}
+		return addr
+	}
+
+	// BIP0067 测试向量 1。
+	key1 := newAddr("02ff12471208c14bd580709cb2358d98975247d8765f92bc25e" +
+		"ab3b2763ed605f8")
+	key2 := newAddr("02fe6f0a5a297eb38c391581c4413e084773ea23954d93f7753" +
+		"db7dc0adc188b2f")
+	expected := hexToBytes("522102fe6f0a5a297eb38c391581c4413e084773ea23954" +
+		"d93f7753db7dc0adc188b2f2102ff12471208c14bd580709cb2358d98975247d8" +
+		"765f92bc25eab3b2763ed605f852ae")
+
+	for _, keys := range [][]*btcutil.AddressPubKey{
+		{key1, key2}, {key2, key1},
+	} {
+		script, err := SortedMultiSigScript(keys, 2)
+		if err != nil {
+			t.Fatalf("SortedMultiSigScript: unexpected error: %v", err)
+		}
+		if !bytes.Equal(script, expected) {
+			t.Fatalf("SortedMultiSigScript: got %x, want %x", script,
+				expected)
+		}
+		if !IsSortedMultiSig(script) {
+			t.Fatalf("IsSortedMultiSig: sorted script %x not recognized",
+				script)
+		}
+	}
+
+	unsorted, err := MultiSigScript([]*btcutil.AddressPubKey{key1, key2}, 2)
+	if err != nil {
+		t.Fatalf("MultiSigScript: unexpected error: %v", err)
+	}
+	if IsSortedMultiSig(unsorted) {
+		t.Fatalf("IsSortedMultiSig: unsorted script %x recognized",
+			unsorted)
+	}
+	if IsSortedMultiSig(mustParseShortForm("DUP HASH160 DATA_20 0x00" +
+		"00000000000000000000000000000000000000 EQUALVERIFY CHECKSIG")) {
+
+		t.Fatal("IsSortedMultiSig: non-multisig script recognized")
+	}
+
+	uncompressed := newAddr("0411db93e1dcdb8a016b49840f8c53bc1eb68a382e97b" +
+		"1482ecad7b148a6909a5cb2e0eaddfb84ccf9744464f82e160bfa9b8b64f9d4c0" +
+		"3f999b8643f656b412a3")
+	_, err = SortedMultiSigScript([]*btcutil.AddressPubKey{
+		key1, uncompressed,
+	}, 1)
+	if !IsErrorCode(err, ErrPubKeyType) {
+		t.Fatalf("SortedMultiSigScript: got %v, want ErrPubKeyType", err)
+	}
+	unsortedUncompressed, err := MultiSigScript([]*btcutil.AddressPubKey{
+		key2, uncompressed,
+	}, 1)
+	if err != nil {
+		t.Fatalf("MultiSigScript: unexpected error: %v", err)
+	}
+	if IsSortedMultiSig(unsortedUncompressed) {
+		t.Fatal("IsSortedMultiSig: script with uncompressed key recognized")
+	}
+}
+
 // TestCalcMultiSigStats 确保 CalcMutliSigStats 函数返回预期的错误。
 func TestCalcMultiSigStats(t *testing.T) {
 	t.Parallel()
@@ -1280,3 +1354,128 @@ func TestNewScriptClass(t *testing.T) {
 		})
 	}
 }
+
+// TestExtractStandardData 确保 ExtractStandardData 为每种标准脚本类别返回正确的字段，并且对任意截断的脚本都不会崩溃。
+func TestExtractStandardData(t *testing.T) {
+	t.Parallel()
+
+	pubKey := hexToBytes("02192d74d0cb94344c9569c2e77901573d8d7903c3ebec" +
+		"3a957724895dca52c6b4")
+	hash20 := hexToBytes("433ec2ac1ffa1b7b7d027f564529c57197f9ae88")
+	hash32 := hexToBytes("9f2c4c4de1a6f4a0ea4ff8e8ffbc0ce1e9ec3efa6d0b" +
+		"a4e0e9b2e8ae1fa9b8b7")
+
+	tests := []struct {
+		name   string
+		script []byte
+		want   StandardScriptData
+	}{{
+		name:   "p2pk",
+		script: append(append([]byte{OP_DATA_33}, pubKey...), OP_CHECKSIG),
+		want:   StandardScriptData{Class: PubKeyTy, PubKey: pubKey},
+	}, {
+		name: "p2pkh",
+		script: append(append([]byte{OP_DUP, OP_HASH160, OP_DATA_20},
+			hash20...), OP_EQUALVERIFY, OP_CHECKSIG),
+		want: StandardScriptData{Class: PubKeyHashTy, Hash: hash20},
+	}, {
+		name: "p2sh",
+		script: append(append([]byte{OP_HASH160, OP_DATA_20}, hash20...),
+			OP_EQUAL),
+		want: StandardScriptData{Class: ScriptHashTy, Hash: hash20},
+	}, {
+		name:   "p2wpkh",
+		script: append([]byte{OP_0, OP_DATA_20}, hash20...),
+		want: StandardScriptData{
+			Class:          WitnessV0PubKeyHashTy,
+			Hash:           hash20,
+			WitnessProgram: hash20,
+		},
+	}, {
+		name:   "p2wsh",
+		script: append([]byte{OP_0, OP_DATA_32}, hash32...),
+		want: StandardScriptData{
+			Class:          WitnessV0ScriptHashTy,
+			Hash:           hash32,
+			WitnessProgram: hash32,
+		},
+	}, {
+		name:   "p2tr",
+		script: append([]byte{OP_1, OP_DATA_32}, hash32...),
+		want: StandardScriptData{
+			Class:          WitnessV1TaprootTy,
+			TaprootKey:     hash32,
+			WitnessVersion: 1,
+			WitnessProgram: hash32,
+		},
+	}, {
+		name:   "witness v2",
+		script: append([]byte{OP_2, OP_DATA_20}, hash20...),
+		want: StandardScriptData{
+			Class:          WitnessUnknownTy,
+			WitnessVersion: 2,
+			WitnessProgram: hash20,
+		},
+	}, {
+		name:   "witness v0 with invalid program length",
+		script: []byte{OP_0, OP_DATA_2, 0x01, 0x02},
+		want:   StandardScriptData{Class: NonStandardTy},
+	}, {
+		name: "1-of-1 multisig",
+		script: append(append([]byte{OP_1, OP_DATA_33}, pubKey...), OP_1,
+			OP_CHECKMULTISIG),
+		want: StandardScriptData{
+			Class:        MultiSigTy,
+			RequiredSigs: 1,
+			PubKeys:      [][]byte{pubKey},
+		},
+	}, {
+		name:   "bare null data",
+		script: []byte{OP_RETURN},
+		want:   StandardScriptData{Class: NullDataTy},
+	}, {
+		name:   "null data",
+		script: []byte{OP_RETURN, OP_DATA_2, 0x01, 0x02},
+		want: StandardScriptData{
+			Class:    NullDataTy,
+			NullData: []byte{0x01, 0x02},
+		},
+	}, {
+		name:   "nonstandard",
+		script: []byte{OP_TRUE},
+		want:   StandardScriptData{Class: NonStandardTy},
+	}}
+
+	for _, test := range tests {
+		got := ExtractStandardData(test.script)
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%s: unexpected data -- got %+v, want %+v",
+				test.name, got, test.want)
+			continue
+		}
+		if test.want.Class != WitnessUnknownTy &&
+			got.Class != GetScriptClass(test.script) {
+
+			t.Errorf("%s: class %v does not match GetScriptClass",
+				test.name, got.Class)
+		}
+
+		// 截断的脚本都不是标准脚本，并且不能导致越界访问。
+		for i := 0; i < len(test.script)-1; i++ {
+			truncated := test.script[:i]
+			if test.want.Class == NullDataTy ||
+				test.want.Class == NonStandardTy {
+
+				continue
+			}
+			if got := ExtractStandardData(truncated); got.Class ==
+				test.want.Class {
+
+				t.Errorf("%s: truncated script %x classified as %v",
+					test.name, truncated, got.Class)
+			}
+			_, _, _, _ = ExtractPkScriptAddrs(truncated,
+				&chaincfg.MainNetParams)
+		}
+	}
+}
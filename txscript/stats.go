@@ -0,0 +1,67 @@
+// 包含脚本执行统计，便于节点运营者分析哪些脚本的执行成本较高。
+
+package txscript
+
+// ExecutionStats 记录引擎执行脚本时的资源使用情况。 通过 WithExecutionStats 传给 NewEngine 后，
+// 引擎在每一步执行时更新其中的计数器。 同一个 ExecutionStats 可以依次传给多个引擎以累计统计，
+// 但不能同时被多个正在执行的引擎使用。
+type ExecutionStats struct {
+	// MaxStackDepth 是数据堆栈达到的最大深度。
+	MaxStackDepth int
+
+	// MaxAltStackDepth 是备用堆栈达到的最大深度。
+	MaxAltStackDepth int
+
+	// OpsExecuted 是实际执行的操作码数，不包括位于未执行分支中的操作码。
+	OpsExecuted int
+
+	// OpcodeHistogram 按操作码值记录每个操作码被执行的次数。
+	OpcodeHistogram [256]int
+
+	// SigOps 是执行的签名验证次数。 对于 OP_CHECKMULTISIG，每次将签名与公钥进行比对都计为一次。
+	SigOps int
+
+	// BytesHashed 是哈希操作码输入到哈希函数中的字节数。 对于 OP_HASH160 和 OP_HASH256，第二轮哈希的 32 字节也计算在内。
+	BytesHashed int
+}
+
+// WithExecutionStats 使引擎在执行期间更新传入的统计信息。
+func WithExecutionStats(stats *ExecutionStats) EngineOpt {
+	return func(cfg *engineConfig) {
+		cfg.stats = stats
+	}
+}
+
+// recordStep 记录一次成功执行的步骤。 executing 表示执行前该操作码是否位于执行分支中。
+func (s *ExecutionStats) recordStep(vm *Engine, op byte, executing bool) {
+	if executing {
+		s.OpsExecuted++
+		s.OpcodeHistogram[op]++
+	}
+	if depth := int(vm.dstack.Depth()); depth > s.MaxStackDepth {
+		s.MaxStackDepth = depth
+	}
+	if depth := int(vm.astack.Depth()); depth > s.MaxAltStackDepth {
+		s.MaxAltStackDepth = depth
+	}
+}
+
+// recordSigOp 在启用统计时记录一次签名验证，并在指定了成本模型时累计其成本。
+func (vm *Engine) recordSigOp() {
+	if vm.stats != nil {
+		vm.stats.SigOps++
+	}
+	if vm.costModel != nil {
+		vm.cost += vm.costModel.SigOpCost
+	}
+}
+
+// recordHashed 在启用统计时记录输入到哈希函数中的字节数，并在指定了成本模型时累计其成本。
+func (vm *Engine) recordHashed(n int) {
+	if vm.stats != nil {
+		vm.stats.BytesHashed += n
+	}
+	if vm.costModel != nil {
+		vm.cost += vm.costModel.HashByteCost * uint64(n)
+	}
+}
@@ -0,0 +1,64 @@
+package txscript
+
+import (
+	"testing"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/stretchr/testify/require"
+)
+
+// TestExecutionStats 确保引擎正确记录堆栈高水位、操作码直方图、签名验证次数和哈希字节数。
+func TestExecutionStats(t *testing.T) {
+	t.Parallel()
+
+	script := mustParseShortForm("1 TOALTSTACK 2 TOALTSTACK FROMALTSTACK " +
+		"FROMALTSTACK ADD 3 NUMEQUAL IF DATA_2 0x0102 SHA256 DROP 1 " +
+		"ELSE 0 ENDIF")
+	tx := createSpendingTx(nil, nil, script, 0)
+
+	var stats ExecutionStats
+	vm, err := NewEngine(script, tx, 0, 0, nil, nil, 0, nil,
+		WithExecutionStats(&stats))
+	require.NoError(t, err)
+	require.NoError(t, vm.Execute())
+
+	// 除 ELSE 分支中的 0 之外的所有操作码都被执行。
+	require.Equal(t, 16, stats.OpsExecuted)
+	require.Equal(t, 2, stats.OpcodeHistogram[OP_TOALTSTACK])
+	require.Equal(t, 0, stats.OpcodeHistogram[OP_0])
+	require.Equal(t, 2, stats.MaxAltStackDepth)
+	require.Equal(t, 2, stats.MaxStackDepth)
+	require.Equal(t, 2, stats.BytesHashed)
+	require.Zero(t, stats.SigOps)
+
+	// 传统 P2PKH 花费：一次签名验证，HASH160 对 33 字节公钥及其 32 字节 SHA256 结果进行哈希。
+	privKey, err := btcec.NewPrivateKey()
+	require.NoError(t, err)
+	addr, err := btcutil.NewAddressPubKeyHash(
+		btcutil.Hash160(privKey.PubKey().SerializeCompressed()),
+		&chaincfg.MainNetParams,
+	)
+	require.NoError(t, err)
+	p2pkh, err := PayToAddrScript(addr)
+	require.NoError(t, err)
+
+	tx = createSpendingTx(nil, nil, p2pkh, 0)
+	sigScript, err := SignatureScript(tx, 0, p2pkh, SigHashAll, privKey, true)
+	require.NoError(t, err)
+	tx.TxIn[0].SignatureScript = sigScript
+
+	stats = ExecutionStats{}
+	vm, err = NewEngine(p2pkh, tx, 0, StandardVerifyFlags, nil, nil, 0,
+		nil, WithExecutionStats(&stats))
+	require.NoError(t, err)
+	require.NoError(t, vm.Execute())
+
+	require.Equal(t, 7, stats.OpsExecuted)
+	require.Equal(t, 1, stats.OpcodeHistogram[OP_CHECKSIG])
+	require.Equal(t, 1, stats.SigOps)
+	require.Equal(t, 33+32, stats.BytesHashed)
+	require.Equal(t, 4, stats.MaxStackDepth)
+	require.Zero(t, stats.MaxAltStackDepth)
+}
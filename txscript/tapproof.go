@@ -0,0 +1,161 @@
+// 包含 taproot 脚本包含证明的导出和独立验证，使轻客户端无需构造引擎或模拟花费，就能确认某个脚本确实被承诺在 P2TR 输出中，
+// 例如在签署合约之前核对合约条款。
+
+package txscript
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcec/v2/schnorr"
+	"github.com/btcsuite/btcd/wire"
+)
+
+// ErrMalformedTapInclusionProof 在包含证明无法解析，或证明的输出密钥、公钥脚本格式错误时返回。
+var ErrMalformedTapInclusionProof = errors.New("malformed tap inclusion proof")
+
+// TapInclusionProof 是脚本被承诺在 P2TR 输出中的紧凑证明：输出密钥、花费该脚本时使用的控制块以及脚本本身。
+// 叶子版本取自控制块。
+type TapInclusionProof struct {
+	// OutputKey 是 P2TR 输出中 32 字节的 x-only 输出密钥。
+	OutputKey []byte
+
+	// ControlBlock 包含内部密钥、输出密钥的 y 坐标奇偶性、叶子版本和梅克尔路径。
+	ControlBlock ControlBlock
+
+	// Script 是被证明包含在输出中的脚本。
+	Script []byte
+}
+
+// NewTapInclusionProof 根据内部密钥和 TapscriptProof（例如 IndexedTapScriptTree.LeafMerkleProofs 中的证明）
+// 返回包含证明。
+func NewTapInclusionProof(internalKey *btcec.PublicKey,
+	proof *TapscriptProof) *TapInclusionProof {
+
+	rootHash := proof.RootNode.TapHash()
+	outputKey := ComputeTaprootOutputKey(internalKey, rootHash[:])
+	return &TapInclusionProof{
+		OutputKey:    schnorr.SerializePubKey(outputKey),
+		ControlBlock: proof.ToControlBlock(internalKey),
+		Script:       proof.TapLeaf.Script,
+	}
+}
+
+// InclusionProof 返回叶子在树当前状态下的包含证明。
+func (t *TapScriptTree) InclusionProof(id TapLeafID) (*TapInclusionProof,
+	error) {
+
+	ctrlBlock, err := t.ControlBlock(id)
+	if err != nil {
+		return nil, err
+	}
+	leaf, _ := t.Leaf(id)
+	return &TapInclusionProof{
+		OutputKey:    schnorr.SerializePubKey(t.OutputKey()),
+		ControlBlock: *ctrlBlock,
+		Script:       leaf.Script,
+	}, nil
+}
+
+// Leaf 返回被证明的叶子。
+func (p *TapInclusionProof) Leaf() TapLeaf {
+	return NewTapLeaf(p.ControlBlock.LeafVersion, p.Script)
+}
+
+// PkScript 返回证明所针对的 P2TR 公钥脚本。
+func (p *TapInclusionProof) PkScript() ([]byte, error) {
+	return payToWitnessTaprootScript(p.OutputKey)
+}
+
+// Verify 检查 Script 和控制块是否承诺在 OutputKey 中，包括输出密钥的 y 坐标奇偶性。 它只进行哈希和一次点运算，
+// 不执行脚本。
+func (p *TapInclusionProof) Verify() error {
+	if len(p.OutputKey) != schnorr.PubKeyBytesLen {
+		return fmt.Errorf("%w: output key is %d bytes",
+			ErrMalformedTapInclusionProof, len(p.OutputKey))
+	}
+	if _, err := schnorr.ParsePubKey(p.OutputKey); err != nil {
+		return fmt.Errorf("%w: %v", ErrMalformedTapInclusionProof, err)
+	}
+
+	// Round-trip the control block so that proofs built by hand are held
+	// to the same size rules as a witness.
+	rawCtrlBlock, err := p.ControlBlock.ToBytes()
+	if err != nil {
+		return err
+	}
+	ctrlBlock, err := ParseControlBlock(rawCtrlBlock)
+	if err != nil {
+		return err
+	}
+
+	return VerifyTaprootLeafCommitment(ctrlBlock, p.OutputKey, p.Script)
+}
+
+// VerifyTapInclusionProof 检查 proof 是否证明其脚本包含在公钥脚本 pkScript 中，pkScript 必须是 P2TR 脚本。
+func VerifyTapInclusionProof(pkScript []byte, proof *TapInclusionProof) error {
+	if !IsPayToTaproot(pkScript) {
+		return fmt.Errorf("%w: public key script is not a taproot "+
+			"output", ErrMalformedTapInclusionProof)
+	}
+	if !bytes.Equal(pkScript[2:], proof.OutputKey) {
+		return scriptError(ErrTaprootMerkleProofInvalid,
+			"proof is for a different output key")
+	}
+	return proof.Verify()
+}
+
+// Bytes 返回证明的序列化形式：32 字节的输出密钥，后跟带变长长度前缀的控制块和脚本。
+func (p *TapInclusionProof) Bytes() ([]byte, error) {
+	rawCtrlBlock, err := p.ControlBlock.ToBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	buf.Write(p.OutputKey)
+	if err := wire.WriteVarBytes(&buf, 0, rawCtrlBlock); err != nil {
+		return nil, err
+	}
+	if err := wire.WriteVarBytes(&buf, 0, p.Script); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// ParseTapInclusionProof 解析 Bytes 返回的序列化证明。 它只检查格式，调用者需要调用 Verify 或 VerifyTapInclusionProof。
+func ParseTapInclusionProof(b []byte) (*TapInclusionProof, error) {
+	if len(b) < schnorr.PubKeyBytesLen {
+		return nil, fmt.Errorf("%w: %d bytes is too short",
+			ErrMalformedTapInclusionProof, len(b))
+	}
+	outputKey := append([]byte(nil), b[:schnorr.PubKeyBytesLen]...)
+
+	r := bytes.NewReader(b[schnorr.PubKeyBytesLen:])
+	rawCtrlBlock, err := wire.ReadVarBytes(
+		r, 0, ControlBlockMaxSize, "control block",
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrMalformedTapInclusionProof, err)
+	}
+	script, err := wire.ReadVarBytes(r, 0, wire.MaxBlockPayload, "script")
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrMalformedTapInclusionProof, err)
+	}
+	if r.Len() != 0 {
+		return nil, fmt.Errorf("%w: %d trailing bytes",
+			ErrMalformedTapInclusionProof, r.Len())
+	}
+
+	ctrlBlock, err := ParseControlBlock(rawCtrlBlock)
+	if err != nil {
+		return nil, err
+	}
+	return &TapInclusionProof{
+		OutputKey:    outputKey,
+		ControlBlock: *ctrlBlock,
+		Script:       script,
+	}, nil
+}
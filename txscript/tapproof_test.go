@@ -0,0 +1,136 @@
+package txscript
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/stretchr/testify/require"
+)
+
+// tapProofTestTree 返回包含三个叶子的树以及各叶子的标识符。
+func tapProofTestTree(t *testing.T) (*TapScriptTree, []TapLeafID) {
+	t.Helper()
+
+	internalKey, err := btcec.NewPrivateKey()
+	require.NoError(t, err)
+	var leaves []TapLeaf
+	for i := byte(0); i < 3; i++ {
+		script := []byte{OP_DATA_1, i, OP_DROP, OP_TRUE}
+		leaves = append(leaves, NewBaseTapLeaf(script))
+	}
+	return NewTapScriptTree(internalKey.PubKey(), leaves...)
+}
+
+// TestTapInclusionProof 测试从树导出的每个叶子的证明都能通过验证，并且序列化后能够还原。
+func TestTapInclusionProof(t *testing.T) {
+	t.Parallel()
+
+	tree, ids := tapProofTestTree(t)
+	pkScript, err := PayToTaprootScript(tree.OutputKey())
+	require.NoError(t, err)
+
+	for _, id := range ids {
+		proof, err := tree.InclusionProof(id)
+		require.NoError(t, err)
+		require.NoError(t, VerifyTapInclusionProof(pkScript, proof))
+
+		leaf, _ := tree.Leaf(id)
+		require.Equal(t, leaf.TapHash(), proof.Leaf().TapHash())
+
+		gotPkScript, err := proof.PkScript()
+		require.NoError(t, err)
+		require.Equal(t, pkScript, gotPkScript)
+
+		raw, err := proof.Bytes()
+		require.NoError(t, err)
+		parsed, err := ParseTapInclusionProof(raw)
+		require.NoError(t, err)
+		require.NoError(t, VerifyTapInclusionProof(pkScript, parsed))
+		require.Equal(t, proof.Script, parsed.Script)
+	}
+
+	_, err = tree.InclusionProof(TapLeafID(100))
+	require.ErrorIs(t, err, ErrUnknownTapLeaf)
+}
+
+// TestTapInclusionProofFromIndexedTree 测试由 AssembleTaprootScriptTree 的证明构造的包含证明与树的输出密钥一致。
+func TestTapInclusionProofFromIndexedTree(t *testing.T) {
+	t.Parallel()
+
+	internalKey, err := btcec.NewPrivateKey()
+	require.NoError(t, err)
+	leaves := []TapLeaf{
+		NewBaseTapLeaf([]byte{OP_1}),
+		NewBaseTapLeaf([]byte{OP_2}),
+	}
+	tree := AssembleTaprootScriptTree(leaves...)
+	rootHash := tree.RootNode.TapHash()
+	pkScript, err := PayToTaprootScript(
+		ComputeTaprootOutputKey(internalKey.PubKey(), rootHash[:]),
+	)
+	require.NoError(t, err)
+
+	for i := range tree.LeafMerkleProofs {
+		proof := NewTapInclusionProof(
+			internalKey.PubKey(), &tree.LeafMerkleProofs[i],
+		)
+		require.NoError(t, VerifyTapInclusionProof(pkScript, proof))
+	}
+}
+
+// TestTapInclusionProofInvalid 测试被篡改或格式错误的证明被拒绝。
+func TestTapInclusionProofInvalid(t *testing.T) {
+	t.Parallel()
+
+	tree, ids := tapProofTestTree(t)
+	pkScript, err := PayToTaprootScript(tree.OutputKey())
+	require.NoError(t, err)
+	proof, err := tree.InclusionProof(ids[1])
+	require.NoError(t, err)
+
+	// A different script isn't committed to by the same path.
+	tampered := *proof
+	tampered.Script = []byte{OP_2}
+	require.True(t, IsErrorCode(
+		VerifyTapInclusionProof(pkScript, &tampered),
+		ErrTaprootMerkleProofInvalid,
+	))
+
+	// A flipped parity bit is caught even though the x-only key matches.
+	tampered = *proof
+	tampered.ControlBlock.OutputKeyYIsOdd = !proof.ControlBlock.OutputKeyYIsOdd
+	require.True(t, IsErrorCode(
+		VerifyTapInclusionProof(pkScript, &tampered),
+		ErrTaprootOutputKeyParityMismatch,
+	))
+
+	// The proof doesn't open a different output.
+	other, _ := tapProofTestTree(t)
+	otherPkScript, err := PayToTaprootScript(other.OutputKey())
+	require.NoError(t, err)
+	require.True(t, IsErrorCode(
+		VerifyTapInclusionProof(otherPkScript, proof),
+		ErrTaprootMerkleProofInvalid,
+	))
+
+	err = VerifyTapInclusionProof([]byte{OP_TRUE}, proof)
+	require.ErrorIs(t, err, ErrMalformedTapInclusionProof)
+
+	raw, err := proof.Bytes()
+	require.NoError(t, err)
+	for _, b := range [][]byte{raw[:20], raw[:len(raw)-1], append(raw, 0)} {
+		_, err := ParseTapInclusionProof(b)
+		require.True(t, errors.Is(err, ErrMalformedTapInclusionProof),
+			"%x: %v", b, err)
+	}
+
+	// A control block with a partial merkle node is rejected by the
+	// control block parser.
+	badCtrl := append(bytes.Clone(raw[:32]), ControlBlockBaseSize+1)
+	badCtrl = append(badCtrl, make([]byte, ControlBlockBaseSize+1)...)
+	badCtrl = append(badCtrl, 0)
+	_, err = ParseTapInclusionProof(badCtrl)
+	require.Error(t, err)
+}
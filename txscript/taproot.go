@@ -48,17 +48,25 @@ func VerifyTaprootKeySpend(witnessProgram []byte, rawSig []byte, tx *wire.MsgTx,
 	inputIndex int, prevOuts PrevOutputFetcher, hashCache *TxSigHashes,
 	sigCache *SigCache) error {
 
+	return verifyTaprootKeySpend(
+		witnessProgram, rawSig, tx, inputIndex, prevOuts, hashCache,
+		sigCache, BtcecVerifier{}, BitcoinChainTag,
+	)
+}
+
+// verifyTaprootKeySpend 与 VerifyTaprootKeySpend 相同，但使用 backend 验证签名，并且签名哈希提交到 chainTag。
+func verifyTaprootKeySpend(witnessProgram []byte, rawSig []byte,
+	tx *wire.MsgTx, inputIndex int, prevOuts PrevOutputFetcher,
+	hashCache *TxSigHashes, sigCache *SigCache, backend VerifierBackend,
+	chainTag ChainTag) error {
+
 	// First, we'll need to extract the public key from the witness
 	// program.
 	rawKey := witnessProgram
 
 	// Extract the annex if it exists, so we can compute the proper proper
 	// sighash below.
-	var annex []byte
-	witness := tx.TxIn[inputIndex].Witness
-	if isAnnexedWitness(witness) {
-		annex, _ = extractAnnex(witness)
-	}
+	annex := WitnessAnnex(tx.TxIn[inputIndex].Witness)
 
 	// Now that we have the public key, we can create a new top-level
 	// keyspend verifier that'll handle all the sighash and schnorr
@@ -70,6 +78,8 @@ func VerifyTaprootKeySpend(witnessProgram []byte, rawSig []byte, tx *wire.MsgTx,
 	if err != nil {
 		return err
 	}
+	keySpendVerifier.backend = backend
+	keySpendVerifier.chainTag = chainTag
 
 	valid := keySpendVerifier.Verify()
 	if valid {
@@ -154,7 +164,7 @@ func (c *ControlBlock) RootHash(revealedScript []byte) []byte {
 		leafOffset := 32 * nodeOffset
 		nextNode := c.InclusionProof[leafOffset : leafOffset+32]
 
-		merkleAccumulator = tapBranchHash(merkleAccumulator[:], nextNode)
+		merkleAccumulator = TapBranchHash(merkleAccumulator[:], nextNode)
 	}
 
 	return merkleAccumulator[:]
@@ -230,16 +240,15 @@ func ComputeTaprootOutputKey(pubKey *btcec.PublicKey,
 
 	// First, we'll compute the tap tweak hash that commits to the internal
 	// key and the merkle script root.
-	tapTweakHash := chainhash.TaggedHash(
-		chainhash.TagTapTweak, schnorr.SerializePubKey(internalKey),
-		scriptRoot,
+	tapTweakHash := TapTweakHash(
+		schnorr.SerializePubKey(internalKey), scriptRoot,
 	)
 
 	// With the tap tweek computed,  we'll need to convert the merkle root
 	// into something in the domain we can manipulate: a scalar value mod
 	// N.
 	var tweakScalar btcec.ModNScalar
-	tweakScalar.SetBytes((*[32]byte)(tapTweakHash))
+	tweakScalar.SetBytes((*[32]byte)(&tapTweakHash))
 
 	// Next, we'll need to convert the internal key to jacobian coordinates
 	// as the routines we need only operate on this type.
@@ -260,6 +269,14 @@ func ComputeTaprootOutputKey(pubKey *btcec.PublicKey,
 	return btcec.NewPublicKey(&taprootKey.X, &taprootKey.Y)
 }
 
+// TapTweakHash 返回 32 字节 x-only 内部密钥和脚本树根哈希的调整哈希：h_taptweak(internalKey || scriptRoot)。
+// 只能通过密钥路径花费的输出使用空的 scriptRoot。
+func TapTweakHash(internalKey, scriptRoot []byte) chainhash.Hash {
+	return *chainhash.TaggedHash(
+		chainhash.TagTapTweak, internalKey, scriptRoot,
+	)
+}
+
 // ComputeTaprootKeyNoScript 在给定内部密钥的情况下计算顶层分根输出密钥，
 // 并希望输出密钥的唯一使用方式是使用 keypend 路径。这对正常的钱包操作非常有用，因为 不需要其他额外支出条件。
 func ComputeTaprootKeyNoScript(internalKey *btcec.PublicKey) *btcec.PublicKey {
@@ -288,14 +305,12 @@ func TweakTaprootPrivKey(privKey btcec.PrivateKey,
 	// key and the merkle script root. We'll snip off the extra parity byte
 	// from the compressed serialization and use that directly.
 	schnorrKeyBytes := pubKeyBytes[1:]
-	tapTweakHash := chainhash.TaggedHash(
-		chainhash.TagTapTweak, schnorrKeyBytes, scriptRoot,
-	)
+	tapTweakHash := TapTweakHash(schnorrKeyBytes, scriptRoot)
 
 	// Map the private key to a ModNScalar which is needed to perform
 	// operation mod the curve order.
 	var tweakScalar btcec.ModNScalar
-	tweakScalar.SetBytes((*[32]byte)(tapTweakHash))
+	tweakScalar.SetBytes((*[32]byte)(&tapTweakHash))
 
 	// Now that we have the private key in its may negated form, we'll add
 	// the script root as a tweak. As we're using a ModNScalar all
@@ -400,18 +415,52 @@ func NewTapLeaf(leafVersion TapscriptLeafVersion, script []byte) TapLeaf {
 func (t TapLeaf) TapHash() chainhash.Hash {
 	// TODO(roasbeef): cache these and the branch due to the recursive
 	// call, so memoize
+	return TapLeafHash(t.LeafVersion, t.Script)
+}
+
+// TapLeafHash 返回叶子版本和脚本的叶子哈希：h_tapleaf(leafVersion || compactSize(script) || script)。
+// 不使用 TapLeaf 构造脚本树的实现（例如硬件钱包）可以直接使用它计算叶子哈希。
+func TapLeafHash(leafVersion TapscriptLeafVersion,
+	script []byte) chainhash.Hash {
 
 	// The leaf encoding is: leafVersion || compactSizeof(script) ||
 	// script, where compactSizeof returns the compact size needed to
 	// encode the value.
 	var leafEncoding bytes.Buffer
 
-	_ = leafEncoding.WriteByte(byte(t.LeafVersion))
-	_ = wire.WriteVarBytes(&leafEncoding, 0, t.Script)
+	_ = leafEncoding.WriteByte(byte(leafVersion))
+	_ = wire.WriteVarBytes(&leafEncoding, 0, script)
 
 	return *chainhash.TaggedHash(chainhash.TagTapLeaf, leafEncoding.Bytes())
 }
 
+// TapscriptRootHash 从叶子哈希和包含证明（控制块中叶子之后的 32 字节节点哈希序列）计算脚本树的根哈希。
+// 包含证明超过 ControlBlockMaxNodeCount 个节点时返回 ErrControlBlockTooLarge，长度不是 32 的倍数时返回
+// ErrControlBlockInvalidLength。 外部构造的脚本树可以用它验证包含证明，再将根哈希传给 ComputeTaprootOutputKey。
+func TapscriptRootHash(leafHash chainhash.Hash,
+	inclusionProof []byte) (chainhash.Hash, error) {
+
+	switch {
+	case len(inclusionProof) > ControlBlockNodeSize*ControlBlockMaxNodeCount:
+		str := fmt.Sprintf("inclusion proof has more than %d nodes: %d "+
+			"bytes", ControlBlockMaxNodeCount, len(inclusionProof))
+		return chainhash.Hash{}, scriptError(ErrControlBlockTooLarge, str)
+
+	case len(inclusionProof)%ControlBlockNodeSize != 0:
+		str := fmt.Sprintf("inclusion proof is not a multiple of 32: %d",
+			len(inclusionProof))
+		return chainhash.Hash{}, scriptError(
+			ErrControlBlockInvalidLength, str,
+		)
+	}
+
+	root := leafHash
+	for i := 0; i < len(inclusionProof); i += ControlBlockNodeSize {
+		root = TapBranchHash(root[:], inclusionProof[i:i+ControlBlockNodeSize])
+	}
+	return root, nil
+}
+
 // TapBranch 表示 tapscript 树中的一个内部分支。左边或右边的节点可以是另一个分支、叶子或两者的组合。
 type TapBranch struct {
 	// leftNode is the left node, this cannot be nil.
@@ -445,11 +494,12 @@ func (t TapBranch) Right() TapNode {
 func (t TapBranch) TapHash() chainhash.Hash {
 	leftHash := t.leftNode.TapHash()
 	rightHash := t.rightNode.TapHash()
-	return tapBranchHash(leftHash[:], rightHash[:])
+	return TapBranchHash(leftHash[:], rightHash[:])
 }
 
-// tapBranchHash 获取左右节点的原始分接散列，并将其散列成一个分支。具体请参阅 TapBranch 方法。
-func tapBranchHash(l, r []byte) chainhash.Hash {
+// TapBranchHash 获取左右节点的 32 字节哈希，并将其散列成一个分支：h_tapbranch(min(l, r) || max(l, r))。
+// 不使用 TapNode 构造脚本树的实现可以直接使用它计算分支哈希。
+func TapBranchHash(l, r []byte) chainhash.Hash {
 	if bytes.Compare(l[:], r[:]) > 0 {
 		l, r = r, l
 	}
@@ -0,0 +1,552 @@
+// 包含 BIP-371 中定义的 taproot PSBT 键值对的编码和解码辅助函数。
+
+package txscript
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+
+	"github.com/btcsuite/btcd/btcec/v2/schnorr"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/wire"
+)
+
+// PsbtKeyType 是 PSBT 键值对中键的类型字节。
+type PsbtKeyType uint8
+
+// BIP-371 为 taproot 定义的 PSBT 输入和输出键类型。
+const (
+	PsbtInTapKeySig          PsbtKeyType = 0x13
+	PsbtInTapScriptSig       PsbtKeyType = 0x14
+	PsbtInTapLeafScript      PsbtKeyType = 0x15
+	PsbtInTapBip32Derivation PsbtKeyType = 0x16
+	PsbtInTapInternalKey     PsbtKeyType = 0x17
+	PsbtInTapMerkleRoot      PsbtKeyType = 0x18
+
+	PsbtOutTapInternalKey     PsbtKeyType = 0x05
+	PsbtOutTapTree            PsbtKeyType = 0x06
+	PsbtOutTapBip32Derivation PsbtKeyType = 0x07
+)
+
+var (
+	// ErrInvalidTaprootPsbtField 在 taproot PSBT 键值对的键或值格式不正确时返回。
+	ErrInvalidTaprootPsbtField = fmt.Errorf("invalid taproot psbt field")
+)
+
+// PsbtKeyValue 是一个未序列化的 PSBT 键值对。 完整的键由类型字节和 KeyData 组成。
+type PsbtKeyValue struct {
+	KeyType PsbtKeyType
+	KeyData []byte
+	Value   []byte
+}
+
+// Serialize 按照 BIP-174 的格式将键值对写入 w：<键长度> <键类型> <键数据> <值长度> <值>，其中长度均为 compact size 编码。
+func (kv *PsbtKeyValue) Serialize(w io.Writer) error {
+	err := wire.WriteVarInt(w, 0, uint64(1+len(kv.KeyData)))
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write([]byte{byte(kv.KeyType)}); err != nil {
+		return err
+	}
+	if _, err := w.Write(kv.KeyData); err != nil {
+		return err
+	}
+	return wire.WriteVarBytes(w, 0, kv.Value)
+}
+
+// DeserializePsbtKeyValue 从 r 中读取一个按照 BIP-174 格式序列化的键值对。
+func DeserializePsbtKeyValue(r io.Reader) (*PsbtKeyValue, error) {
+	key, err := wire.ReadVarBytes(r, 0, ControlBlockMaxSize+1, "psbt key")
+	if err != nil {
+		return nil, err
+	}
+	if len(key) == 0 {
+		return nil, fmt.Errorf("%w: empty key", ErrInvalidTaprootPsbtField)
+	}
+	value, err := wire.ReadVarBytes(
+		r, 0, wire.MaxMessagePayload, "psbt value",
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &PsbtKeyValue{
+		KeyType: PsbtKeyType(key[0]),
+		KeyData: key[1:],
+		Value:   value,
+	}, nil
+}
+
+// TaprootBip32Derivation 是 taproot 输入或输出中某个 x-only 公钥的 BIP-32 派生信息，以及使用该公钥的叶子哈希列表。
+type TaprootBip32Derivation struct {
+	// XOnlyPubKey 是 32 字节的 x-only 公钥。
+	XOnlyPubKey []byte
+
+	// LeafHashes 是使用该公钥的所有 tapscript 叶子的哈希值。 仅用于密钥路径时为空。
+	LeafHashes []chainhash.Hash
+
+	// MasterKeyFingerprint 是主密钥的指纹，按小端字节序解释。
+	MasterKeyFingerprint uint32
+
+	// Bip32Path 是从主密钥开始的派生路径。
+	Bip32Path []uint32
+}
+
+// TaprootScriptSpendSig 是某个 x-only 公钥对某个 tapscript 叶子的签名。
+type TaprootScriptSpendSig struct {
+	XOnlyPubKey []byte
+	LeafHash    chainhash.Hash
+	Signature   []byte
+}
+
+// TaprootTapLeafScript 是某个叶子脚本及其花费所需的控制块。
+type TaprootTapLeafScript struct {
+	ControlBlock []byte
+	Script       []byte
+	LeafVersion  TapscriptLeafVersion
+}
+
+// TapTreeLeaf 是 PSBT_OUT_TAP_TREE 中的一个叶子，包含其在树中的深度。
+type TapTreeLeaf struct {
+	Depth uint8
+	TapLeaf
+}
+
+// checkXOnlyPubKey 确保传入的字节是一个有效的 x-only 公钥。
+func checkXOnlyPubKey(key []byte) error {
+	if len(key) != schnorr.PubKeyBytesLen {
+		return fmt.Errorf("%w: x-only key must be %d bytes, got %d",
+			ErrInvalidTaprootPsbtField, schnorr.PubKeyBytesLen, len(key))
+	}
+	if _, err := schnorr.ParsePubKey(key); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidTaprootPsbtField, err)
+	}
+	return nil
+}
+
+// checkTaprootPsbtSig 确保传入的字节是一个长度正确的 schnorr 签名（可带 sighash 字节）。
+func checkTaprootPsbtSig(sig []byte) error {
+	if len(sig) != schnorr.SignatureSize &&
+		len(sig) != schnorr.SignatureSize+1 {
+
+		return fmt.Errorf("%w: signature must be %d or %d bytes, got %d",
+			ErrInvalidTaprootPsbtField, schnorr.SignatureSize,
+			schnorr.SignatureSize+1, len(sig))
+	}
+	return nil
+}
+
+// expectKeyType 确保键值对的类型与预期一致。
+func expectKeyType(kv *PsbtKeyValue, keyTypes ...PsbtKeyType) error {
+	for _, keyType := range keyTypes {
+		if kv.KeyType == keyType {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: unexpected key type 0x%02x",
+		ErrInvalidTaprootPsbtField, byte(kv.KeyType))
+}
+
+// EncodeTapKeySig 编码 PSBT_IN_TAP_KEY_SIG 字段。
+func EncodeTapKeySig(sig []byte) (*PsbtKeyValue, error) {
+	if err := checkTaprootPsbtSig(sig); err != nil {
+		return nil, err
+	}
+	return &PsbtKeyValue{KeyType: PsbtInTapKeySig, Value: sig}, nil
+}
+
+// DecodeTapKeySig 解码 PSBT_IN_TAP_KEY_SIG 字段并返回签名。
+func DecodeTapKeySig(kv *PsbtKeyValue) ([]byte, error) {
+	if err := expectKeyType(kv, PsbtInTapKeySig); err != nil {
+		return nil, err
+	}
+	if len(kv.KeyData) != 0 {
+		return nil, fmt.Errorf("%w: unexpected key data",
+			ErrInvalidTaprootPsbtField)
+	}
+	if err := checkTaprootPsbtSig(kv.Value); err != nil {
+		return nil, err
+	}
+	return kv.Value, nil
+}
+
+// EncodeTapScriptSig 编码 PSBT_IN_TAP_SCRIPT_SIG 字段。
+func EncodeTapScriptSig(sig *TaprootScriptSpendSig) (*PsbtKeyValue, error) {
+	if err := checkXOnlyPubKey(sig.XOnlyPubKey); err != nil {
+		return nil, err
+	}
+	if err := checkTaprootPsbtSig(sig.Signature); err != nil {
+		return nil, err
+	}
+
+	keyData := make([]byte, 0, schnorr.PubKeyBytesLen+chainhash.HashSize)
+	keyData = append(keyData, sig.XOnlyPubKey...)
+	keyData = append(keyData, sig.LeafHash[:]...)
+	return &PsbtKeyValue{
+		KeyType: PsbtInTapScriptSig,
+		KeyData: keyData,
+		Value:   sig.Signature,
+	}, nil
+}
+
+// DecodeTapScriptSig 解码 PSBT_IN_TAP_SCRIPT_SIG 字段。
+func DecodeTapScriptSig(kv *PsbtKeyValue) (*TaprootScriptSpendSig, error) {
+	if err := expectKeyType(kv, PsbtInTapScriptSig); err != nil {
+		return nil, err
+	}
+	if len(kv.KeyData) != schnorr.PubKeyBytesLen+chainhash.HashSize {
+		return nil, fmt.Errorf("%w: script sig key must be %d bytes, "+
+			"got %d", ErrInvalidTaprootPsbtField,
+			schnorr.PubKeyBytesLen+chainhash.HashSize, len(kv.KeyData))
+	}
+	xOnlyKey := kv.KeyData[:schnorr.PubKeyBytesLen]
+	if err := checkXOnlyPubKey(xOnlyKey); err != nil {
+		return nil, err
+	}
+	if err := checkTaprootPsbtSig(kv.Value); err != nil {
+		return nil, err
+	}
+
+	sig := &TaprootScriptSpendSig{
+		XOnlyPubKey: xOnlyKey,
+		Signature:   kv.Value,
+	}
+	copy(sig.LeafHash[:], kv.KeyData[schnorr.PubKeyBytesLen:])
+	return sig, nil
+}
+
+// EncodeTapLeafScript 编码 PSBT_IN_TAP_LEAF_SCRIPT 字段。 控制块必须能够被解析。
+func EncodeTapLeafScript(leaf *TaprootTapLeafScript) (*PsbtKeyValue, error) {
+	if _, err := ParseControlBlock(leaf.ControlBlock); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidTaprootPsbtField, err)
+	}
+
+	value := make([]byte, 0, len(leaf.Script)+1)
+	value = append(value, leaf.Script...)
+	value = append(value, byte(leaf.LeafVersion))
+	return &PsbtKeyValue{
+		KeyType: PsbtInTapLeafScript,
+		KeyData: leaf.ControlBlock,
+		Value:   value,
+	}, nil
+}
+
+// DecodeTapLeafScript 解码 PSBT_IN_TAP_LEAF_SCRIPT 字段。 值中的叶子版本必须与控制块中的叶子版本一致。
+func DecodeTapLeafScript(kv *PsbtKeyValue) (*TaprootTapLeafScript, error) {
+	if err := expectKeyType(kv, PsbtInTapLeafScript); err != nil {
+		return nil, err
+	}
+	ctrlBlock, err := ParseControlBlock(kv.KeyData)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidTaprootPsbtField, err)
+	}
+	if len(kv.Value) < 1 {
+		return nil, fmt.Errorf("%w: missing leaf version",
+			ErrInvalidTaprootPsbtField)
+	}
+
+	leafVersion := TapscriptLeafVersion(kv.Value[len(kv.Value)-1])
+	if leafVersion != ctrlBlock.LeafVersion {
+		return nil, fmt.Errorf("%w: leaf version %#x does not match "+
+			"control block leaf version %#x", ErrInvalidTaprootPsbtField,
+			leafVersion, ctrlBlock.LeafVersion)
+	}
+
+	return &TaprootTapLeafScript{
+		ControlBlock: kv.KeyData,
+		Script:       kv.Value[:len(kv.Value)-1],
+		LeafVersion:  leafVersion,
+	}, nil
+}
+
+// encodeTapBip32Derivation 编码输入或输出中的 taproot BIP-32 派生字段。
+func encodeTapBip32Derivation(keyType PsbtKeyType,
+	d *TaprootBip32Derivation) (*PsbtKeyValue, error) {
+
+	if err := checkXOnlyPubKey(d.XOnlyPubKey); err != nil {
+		return nil, err
+	}
+
+	var b bytes.Buffer
+	if err := wire.WriteVarInt(&b, 0, uint64(len(d.LeafHashes))); err != nil {
+		return nil, err
+	}
+	for _, leafHash := range d.LeafHashes {
+		b.Write(leafHash[:])
+	}
+
+	var scratch [4]byte
+	binary.LittleEndian.PutUint32(scratch[:], d.MasterKeyFingerprint)
+	b.Write(scratch[:])
+	for _, index := range d.Bip32Path {
+		binary.LittleEndian.PutUint32(scratch[:], index)
+		b.Write(scratch[:])
+	}
+
+	return &PsbtKeyValue{
+		KeyType: keyType,
+		KeyData: d.XOnlyPubKey,
+		Value:   b.Bytes(),
+	}, nil
+}
+
+// EncodeTapBip32Derivation 编码 PSBT_IN_TAP_BIP32_DERIVATION 字段。
+func EncodeTapBip32Derivation(d *TaprootBip32Derivation) (*PsbtKeyValue, error) {
+	return encodeTapBip32Derivation(PsbtInTapBip32Derivation, d)
+}
+
+// EncodeTapOutputBip32Derivation 编码 PSBT_OUT_TAP_BIP32_DERIVATION 字段。
+func EncodeTapOutputBip32Derivation(
+	d *TaprootBip32Derivation) (*PsbtKeyValue, error) {
+
+	return encodeTapBip32Derivation(PsbtOutTapBip32Derivation, d)
+}
+
+// DecodeTapBip32Derivation 解码输入（PSBT_IN_TAP_BIP32_DERIVATION）或输出（PSBT_OUT_TAP_BIP32_DERIVATION）中的 taproot BIP-32 派生字段。
+func DecodeTapBip32Derivation(kv *PsbtKeyValue) (*TaprootBip32Derivation, error) {
+	err := expectKeyType(
+		kv, PsbtInTapBip32Derivation, PsbtOutTapBip32Derivation,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkXOnlyPubKey(kv.KeyData); err != nil {
+		return nil, err
+	}
+
+	r := bytes.NewReader(kv.Value)
+	numHashes, err := wire.ReadVarInt(r, 0)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidTaprootPsbtField, err)
+	}
+	if numHashes > uint64(r.Len()/chainhash.HashSize) {
+		return nil, fmt.Errorf("%w: too many leaf hashes: %d",
+			ErrInvalidTaprootPsbtField, numHashes)
+	}
+
+	d := &TaprootBip32Derivation{
+		XOnlyPubKey: kv.KeyData,
+		LeafHashes:  make([]chainhash.Hash, numHashes),
+	}
+	for i := range d.LeafHashes {
+		if _, err := io.ReadFull(r, d.LeafHashes[i][:]); err != nil {
+			return nil, fmt.Errorf("%w: %v",
+				ErrInvalidTaprootPsbtField, err)
+		}
+	}
+
+	// 剩余的部分是 4 字节的指纹和若干个 4 字节的路径索引。
+	rest := kv.Value[len(kv.Value)-r.Len():]
+	if len(rest) < 4 || len(rest)%4 != 0 {
+		return nil, fmt.Errorf("%w: invalid derivation path length %d",
+			ErrInvalidTaprootPsbtField, len(rest))
+	}
+	d.MasterKeyFingerprint = binary.LittleEndian.Uint32(rest[:4])
+	for i := 4; i < len(rest); i += 4 {
+		d.Bip32Path = append(
+			d.Bip32Path, binary.LittleEndian.Uint32(rest[i:i+4]),
+		)
+	}
+
+	return d, nil
+}
+
+// encodeTapXOnlyValue 编码一个无键数据、值为 x-only 公钥的字段。
+func encodeTapXOnlyValue(keyType PsbtKeyType, key []byte) (*PsbtKeyValue, error) {
+	if err := checkXOnlyPubKey(key); err != nil {
+		return nil, err
+	}
+	return &PsbtKeyValue{KeyType: keyType, Value: key}, nil
+}
+
+// EncodeTapInternalKey 编码 PSBT_IN_TAP_INTERNAL_KEY 字段。
+func EncodeTapInternalKey(xOnlyKey []byte) (*PsbtKeyValue, error) {
+	return encodeTapXOnlyValue(PsbtInTapInternalKey, xOnlyKey)
+}
+
+// EncodeTapOutputInternalKey 编码 PSBT_OUT_TAP_INTERNAL_KEY 字段。
+func EncodeTapOutputInternalKey(xOnlyKey []byte) (*PsbtKeyValue, error) {
+	return encodeTapXOnlyValue(PsbtOutTapInternalKey, xOnlyKey)
+}
+
+// DecodeTapInternalKey 解码输入（PSBT_IN_TAP_INTERNAL_KEY）或输出（PSBT_OUT_TAP_INTERNAL_KEY）中的内部密钥字段，并返回 x-only 公钥。
+func DecodeTapInternalKey(kv *PsbtKeyValue) ([]byte, error) {
+	err := expectKeyType(kv, PsbtInTapInternalKey, PsbtOutTapInternalKey)
+	if err != nil {
+		return nil, err
+	}
+	if len(kv.KeyData) != 0 {
+		return nil, fmt.Errorf("%w: unexpected key data",
+			ErrInvalidTaprootPsbtField)
+	}
+	if err := checkXOnlyPubKey(kv.Value); err != nil {
+		return nil, err
+	}
+	return kv.Value, nil
+}
+
+// EncodeTapMerkleRoot 编码 PSBT_IN_TAP_MERKLE_ROOT 字段。
+func EncodeTapMerkleRoot(root chainhash.Hash) *PsbtKeyValue {
+	return &PsbtKeyValue{
+		KeyType: PsbtInTapMerkleRoot,
+		Value:   append([]byte(nil), root[:]...),
+	}
+}
+
+// DecodeTapMerkleRoot 解码 PSBT_IN_TAP_MERKLE_ROOT 字段。
+func DecodeTapMerkleRoot(kv *PsbtKeyValue) (chainhash.Hash, error) {
+	var root chainhash.Hash
+	if err := expectKeyType(kv, PsbtInTapMerkleRoot); err != nil {
+		return root, err
+	}
+	if len(kv.KeyData) != 0 || len(kv.Value) != chainhash.HashSize {
+		return root, fmt.Errorf("%w: merkle root must be %d bytes",
+			ErrInvalidTaprootPsbtField, chainhash.HashSize)
+	}
+	copy(root[:], kv.Value)
+	return root, nil
+}
+
+// TapTreeLeaves 按深度优先、从左到右的顺序返回以 root 为根的 tapscript 树中的所有叶子及其深度，这正是 PSBT_OUT_TAP_TREE 要求的顺序。
+func TapTreeLeaves(root TapNode) ([]TapTreeLeaf, error) {
+	var leaves []TapTreeLeaf
+	var walk func(node TapNode, depth int) error
+	walk = func(node TapNode, depth int) error {
+		if depth > ControlBlockMaxNodeCount {
+			return fmt.Errorf("%w: tree depth exceeds %d",
+				ErrInvalidTaprootPsbtField, ControlBlockMaxNodeCount)
+		}
+
+		if node.Left() == nil && node.Right() == nil {
+			leaf, ok := node.(TapLeaf)
+			if !ok {
+				return fmt.Errorf("%w: unsupported leaf node %T",
+					ErrInvalidTaprootPsbtField, node)
+			}
+			leaves = append(leaves, TapTreeLeaf{
+				Depth:   uint8(depth),
+				TapLeaf: leaf,
+			})
+			return nil
+		}
+
+		if err := walk(node.Left(), depth+1); err != nil {
+			return err
+		}
+		return walk(node.Right(), depth+1)
+	}
+
+	if err := walk(root, 0); err != nil {
+		return nil, err
+	}
+	return leaves, nil
+}
+
+// EncodeTapTree 编码 PSBT_OUT_TAP_TREE 字段。 传入的叶子必须按深度优先顺序排列，并且能够组成一棵完整的二叉树。
+func EncodeTapTree(leaves []TapTreeLeaf) (*PsbtKeyValue, error) {
+	if _, err := TapTreeFromLeaves(leaves); err != nil {
+		return nil, err
+	}
+
+	var b bytes.Buffer
+	for _, leaf := range leaves {
+		b.WriteByte(leaf.Depth)
+		b.WriteByte(byte(leaf.LeafVersion))
+		if err := wire.WriteVarBytes(&b, 0, leaf.Script); err != nil {
+			return nil, err
+		}
+	}
+
+	return &PsbtKeyValue{KeyType: PsbtOutTapTree, Value: b.Bytes()}, nil
+}
+
+// DecodeTapTree 解码 PSBT_OUT_TAP_TREE 字段，并验证叶子能够组成一棵完整的二叉树。
+func DecodeTapTree(kv *PsbtKeyValue) ([]TapTreeLeaf, error) {
+	if err := expectKeyType(kv, PsbtOutTapTree); err != nil {
+		return nil, err
+	}
+	if len(kv.KeyData) != 0 {
+		return nil, fmt.Errorf("%w: unexpected key data",
+			ErrInvalidTaprootPsbtField)
+	}
+
+	var leaves []TapTreeLeaf
+	r := bytes.NewReader(kv.Value)
+	for r.Len() > 0 {
+		var header [2]byte
+		if _, err := io.ReadFull(r, header[:]); err != nil {
+			return nil, fmt.Errorf("%w: %v",
+				ErrInvalidTaprootPsbtField, err)
+		}
+		script, err := wire.ReadVarBytes(
+			r, 0, uint32(len(kv.Value)), "tap leaf script",
+		)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v",
+				ErrInvalidTaprootPsbtField, err)
+		}
+
+		leaves = append(leaves, TapTreeLeaf{
+			Depth: header[0],
+			TapLeaf: NewTapLeaf(
+				TapscriptLeafVersion(header[1]), script,
+			),
+		})
+	}
+
+	if _, err := TapTreeFromLeaves(leaves); err != nil {
+		return nil, err
+	}
+	return leaves, nil
+}
+
+// TapTreeFromLeaves 根据按深度优先顺序排列的叶子及其深度重建 tapscript 树，并返回根节点。
+// 如果叶子无法组成一棵完整的二叉树，则返回错误。
+func TapTreeFromLeaves(leaves []TapTreeLeaf) (TapNode, error) {
+	if len(leaves) == 0 {
+		return nil, fmt.Errorf("%w: tap tree has no leaves",
+			ErrInvalidTaprootPsbtField)
+	}
+
+	// 维护一个 (节点, 深度) 栈。 每压入一个叶子后，只要栈顶两个节点深度相同，就将它们合并为深度减一的分支。
+	type stackEntry struct {
+		node  TapNode
+		depth int
+	}
+	var stack []stackEntry
+	for i, leaf := range leaves {
+		if leaf.Depth > ControlBlockMaxNodeCount {
+			return nil, fmt.Errorf("%w: leaf %d depth %d exceeds %d",
+				ErrInvalidTaprootPsbtField, i, leaf.Depth,
+				ControlBlockMaxNodeCount)
+		}
+
+		stack = append(stack, stackEntry{leaf.TapLeaf, int(leaf.Depth)})
+		for len(stack) >= 2 {
+			right := stack[len(stack)-1]
+			left := stack[len(stack)-2]
+			if left.depth != right.depth {
+				break
+			}
+			if right.depth == 0 {
+				return nil, fmt.Errorf("%w: leaf %d is beyond "+
+					"the tree root", ErrInvalidTaprootPsbtField, i)
+			}
+
+			stack = stack[:len(stack)-2]
+			stack = append(stack, stackEntry{
+				node:  NewTapBranch(left.node, right.node),
+				depth: right.depth - 1,
+			})
+		}
+	}
+
+	if len(stack) != 1 || stack[0].depth != 0 {
+		return nil, fmt.Errorf("%w: leaves do not form a complete "+
+			"binary tree", ErrInvalidTaprootPsbtField)
+	}
+	return stack[0].node, nil
+}
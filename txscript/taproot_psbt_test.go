@@ -0,0 +1,272 @@
+package txscript
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcec/v2/schnorr"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+)
+
+// TestTaprootPsbtFieldsRoundTrip 确保所有 taproot PSBT 字段在编码、序列化、反序列化和解码之后保持不变。
+func TestTaprootPsbtFieldsRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	privKey, err := btcec.NewPrivateKey()
+	if err != nil {
+		t.Fatalf("unable to generate key: %v", err)
+	}
+	xOnlyKey := schnorr.SerializePubKey(privKey.PubKey())
+	sig := bytes.Repeat([]byte{0x01}, schnorr.SignatureSize)
+	sigWithType := append(append([]byte(nil), sig...), byte(SigHashAll))
+
+	leafA := NewBaseTapLeaf([]byte{OP_TRUE})
+	leafB := NewBaseTapLeaf([]byte{OP_DROP, OP_TRUE})
+	leafC := NewBaseTapLeaf([]byte{OP_2DROP, OP_TRUE})
+	tree := AssembleTaprootScriptTree(leafA, leafB, leafC)
+	ctrlBlock := tree.LeafMerkleProofs[0].ToControlBlock(privKey.PubKey())
+	ctrlBlockBytes, err := ctrlBlock.ToBytes()
+	if err != nil {
+		t.Fatalf("unable to serialize control block: %v", err)
+	}
+
+	// serializeRoundTrip 将键值对序列化后再反序列化。
+	serializeRoundTrip := func(kv *PsbtKeyValue) *PsbtKeyValue {
+		var b bytes.Buffer
+		if err := kv.Serialize(&b); err != nil {
+			t.Fatalf("unable to serialize: %v", err)
+		}
+		decoded, err := DeserializePsbtKeyValue(&b)
+		if err != nil {
+			t.Fatalf("unable to deserialize: %v", err)
+		}
+		if b.Len() != 0 {
+			t.Fatalf("%d trailing bytes", b.Len())
+		}
+		return decoded
+	}
+
+	// Key spend signature.
+	kv, err := EncodeTapKeySig(sigWithType)
+	if err != nil {
+		t.Fatalf("EncodeTapKeySig: %v", err)
+	}
+	gotSig, err := DecodeTapKeySig(serializeRoundTrip(kv))
+	if err != nil || !bytes.Equal(gotSig, sigWithType) {
+		t.Fatalf("DecodeTapKeySig: got %x (err %v)", gotSig, err)
+	}
+
+	// Script spend signature.
+	scriptSig := &TaprootScriptSpendSig{
+		XOnlyPubKey: xOnlyKey,
+		LeafHash:    leafA.TapHash(),
+		Signature:   sig,
+	}
+	kv, err = EncodeTapScriptSig(scriptSig)
+	if err != nil {
+		t.Fatalf("EncodeTapScriptSig: %v", err)
+	}
+	gotScriptSig, err := DecodeTapScriptSig(serializeRoundTrip(kv))
+	if err != nil || !reflect.DeepEqual(gotScriptSig, scriptSig) {
+		t.Fatalf("DecodeTapScriptSig: got %v (err %v)", gotScriptSig,
+			err)
+	}
+
+	// Leaf script.
+	leafScript := &TaprootTapLeafScript{
+		ControlBlock: ctrlBlockBytes,
+		Script:       leafA.Script,
+		LeafVersion:  leafA.LeafVersion,
+	}
+	kv, err = EncodeTapLeafScript(leafScript)
+	if err != nil {
+		t.Fatalf("EncodeTapLeafScript: %v", err)
+	}
+	gotLeafScript, err := DecodeTapLeafScript(serializeRoundTrip(kv))
+	if err != nil || !reflect.DeepEqual(gotLeafScript, leafScript) {
+		t.Fatalf("DecodeTapLeafScript: got %v (err %v)", gotLeafScript,
+			err)
+	}
+
+	// BIP-32 derivation for both inputs and outputs.
+	derivation := &TaprootBip32Derivation{
+		XOnlyPubKey:          xOnlyKey,
+		LeafHashes:           []chainhash.Hash{leafA.TapHash()},
+		MasterKeyFingerprint: 0xdeadbeef,
+		Bip32Path:            []uint32{86 + 0x80000000, 0x80000000, 0},
+	}
+	for _, encode := range []func(*TaprootBip32Derivation) (*PsbtKeyValue,
+		error){EncodeTapBip32Derivation, EncodeTapOutputBip32Derivation} {
+
+		kv, err = encode(derivation)
+		if err != nil {
+			t.Fatalf("encode derivation: %v", err)
+		}
+		got, err := DecodeTapBip32Derivation(serializeRoundTrip(kv))
+		if err != nil || !reflect.DeepEqual(got, derivation) {
+			t.Fatalf("DecodeTapBip32Derivation: got %v (err %v)", got,
+				err)
+		}
+	}
+
+	// Internal keys.
+	for _, encode := range []func([]byte) (*PsbtKeyValue, error){
+		EncodeTapInternalKey, EncodeTapOutputInternalKey} {
+
+		kv, err = encode(xOnlyKey)
+		if err != nil {
+			t.Fatalf("encode internal key: %v", err)
+		}
+		got, err := DecodeTapInternalKey(serializeRoundTrip(kv))
+		if err != nil || !bytes.Equal(got, xOnlyKey) {
+			t.Fatalf("DecodeTapInternalKey: got %x (err %v)", got, err)
+		}
+	}
+
+	// Merkle root.
+	root := tree.RootNode.TapHash()
+	gotRoot, err := DecodeTapMerkleRoot(
+		serializeRoundTrip(EncodeTapMerkleRoot(root)),
+	)
+	if err != nil || gotRoot != root {
+		t.Fatalf("DecodeTapMerkleRoot: got %v (err %v)", gotRoot, err)
+	}
+
+	// Tap tree.
+	leaves, err := TapTreeLeaves(tree.RootNode)
+	if err != nil {
+		t.Fatalf("TapTreeLeaves: %v", err)
+	}
+	kv, err = EncodeTapTree(leaves)
+	if err != nil {
+		t.Fatalf("EncodeTapTree: %v", err)
+	}
+	gotLeaves, err := DecodeTapTree(serializeRoundTrip(kv))
+	if err != nil || !reflect.DeepEqual(gotLeaves, leaves) {
+		t.Fatalf("DecodeTapTree: got %v (err %v)", gotLeaves, err)
+	}
+	rebuilt, err := TapTreeFromLeaves(gotLeaves)
+	if err != nil {
+		t.Fatalf("TapTreeFromLeaves: %v", err)
+	}
+	if rebuilt.TapHash() != root {
+		t.Fatalf("rebuilt root mismatch: got %v, want %v",
+			rebuilt.TapHash(), root)
+	}
+}
+
+// TestTaprootPsbtFieldsInvalid 确保格式错误的 taproot PSBT 字段会被拒绝。
+func TestTaprootPsbtFieldsInvalid(t *testing.T) {
+	t.Parallel()
+
+	leaf := NewBaseTapLeaf([]byte{OP_TRUE})
+	tests := []struct {
+		name   string
+		decode func() error
+	}{{
+		name: "key sig wrong length",
+		decode: func() error {
+			_, err := DecodeTapKeySig(&PsbtKeyValue{
+				KeyType: PsbtInTapKeySig,
+				Value:   make([]byte, 63),
+			})
+			return err
+		},
+	}, {
+		name: "key sig wrong type",
+		decode: func() error {
+			_, err := DecodeTapKeySig(&PsbtKeyValue{
+				KeyType: PsbtInTapScriptSig,
+				Value:   make([]byte, 64),
+			})
+			return err
+		},
+	}, {
+		name: "script sig short key",
+		decode: func() error {
+			_, err := DecodeTapScriptSig(&PsbtKeyValue{
+				KeyType: PsbtInTapScriptSig,
+				KeyData: make([]byte, 32),
+				Value:   make([]byte, 64),
+			})
+			return err
+		},
+	}, {
+		name: "internal key not on curve",
+		decode: func() error {
+			_, err := DecodeTapInternalKey(&PsbtKeyValue{
+				KeyType: PsbtInTapInternalKey,
+				Value:   bytes.Repeat([]byte{0xff}, 32),
+			})
+			return err
+		},
+	}, {
+		name: "merkle root short",
+		decode: func() error {
+			_, err := DecodeTapMerkleRoot(&PsbtKeyValue{
+				KeyType: PsbtInTapMerkleRoot,
+				Value:   make([]byte, 31),
+			})
+			return err
+		},
+	}, {
+		name: "tap tree incomplete",
+		decode: func() error {
+			_, err := TapTreeFromLeaves([]TapTreeLeaf{
+				{Depth: 1, TapLeaf: leaf},
+			})
+			return err
+		},
+	}, {
+		name: "tap tree beyond root",
+		decode: func() error {
+			_, err := TapTreeFromLeaves([]TapTreeLeaf{
+				{Depth: 0, TapLeaf: leaf},
+				{Depth: 0, TapLeaf: leaf},
+			})
+			return err
+		},
+	}, {
+		name: "tap tree truncated",
+		decode: func() error {
+			_, err := DecodeTapTree(&PsbtKeyValue{
+				KeyType: PsbtOutTapTree,
+				Value:   []byte{0x00, 0xc0, 0x05, OP_TRUE},
+			})
+			return err
+		},
+	}, {
+		name: "derivation bad path",
+		decode: func() error {
+			key := schnorr.SerializePubKey(
+				ComputeTaprootKeyNoScript(mustNewPubKey(t)),
+			)
+			_, err := DecodeTapBip32Derivation(&PsbtKeyValue{
+				KeyType: PsbtInTapBip32Derivation,
+				KeyData: key,
+				Value:   []byte{0x00, 0x01, 0x02, 0x03, 0x04, 0x05},
+			})
+			return err
+		},
+	}}
+
+	for _, test := range tests {
+		err := test.decode()
+		if !errors.Is(err, ErrInvalidTaprootPsbtField) {
+			t.Errorf("%s: expected ErrInvalidTaprootPsbtField, got %v",
+				test.name, err)
+		}
+	}
+}
+
+// mustNewPubKey 返回一个随机公钥。
+func mustNewPubKey(t *testing.T) *btcec.PublicKey {
+	privKey, err := btcec.NewPrivateKey()
+	if err != nil {
+		t.Fatalf("unable to generate key: %v", err)
+	}
+	return privKey.PubKey()
+}
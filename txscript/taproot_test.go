@@ -372,3 +372,69 @@ func TestTapscriptCommitmentVerification(t *testing.T) {
 		})
 	}
 }
+
+// TestTapHashFunctions 确保独立的哈希函数与脚本树类型的方法结果一致，并与 BIP0341 的测试向量相符。
+func TestTapHashFunctions(t *testing.T) {
+	t.Parallel()
+
+	// BIP0341 钱包测试向量中单叶子脚本树的叶子哈希。
+	script, _ := hex.DecodeString("20d85a959b0290bf19bb89ed43c916be83547" +
+		"5d013da4b362117393e25a48229b8ac")
+	leafHash := TapLeafHash(BaseLeafVersion, script)
+	require.Equal(t, "5b75adecf53548f3ec6ad7d78383bf84cc57b55a3127c72b9a"+
+		"2481752dd88b21", hex.EncodeToString(leafHash[:]))
+	require.Equal(t, NewBaseTapLeaf(script).TapHash(), leafHash)
+
+	leaves := []TapLeaf{
+		NewBaseTapLeaf([]byte{OP_TRUE}),
+		NewBaseTapLeaf([]byte{OP_2}),
+		NewTapLeaf(0xc2, []byte{OP_3}),
+	}
+	tree := AssembleTaprootScriptTree(leaves...)
+	rootHash := tree.RootNode.TapHash()
+
+	// 分支哈希与左右顺序无关。
+	l, r := leaves[0].TapHash(), leaves[1].TapHash()
+	require.Equal(t, TapBranchHash(l[:], r[:]), TapBranchHash(r[:], l[:]))
+	require.Equal(t, NewTapBranch(leaves[0], leaves[1]).TapHash(),
+		TapBranchHash(l[:], r[:]))
+
+	// 每个叶子的包含证明都能还原根哈希。
+	for _, proof := range tree.LeafMerkleProofs {
+		leafHash := TapLeafHash(proof.LeafVersion, proof.Script)
+		root, err := TapscriptRootHash(leafHash, proof.InclusionProof)
+		require.NoError(t, err)
+		require.Equal(t, rootHash, root)
+	}
+
+	// 没有包含证明时根哈希就是叶子哈希。
+	root, err := TapscriptRootHash(leafHash, nil)
+	require.NoError(t, err)
+	require.Equal(t, leafHash, root)
+
+	_, err = TapscriptRootHash(leafHash, make([]byte, 33))
+	require.True(t, IsErrorCode(err, ErrControlBlockInvalidLength))
+	_, err = TapscriptRootHash(
+		leafHash, make([]byte, 32*(ControlBlockMaxNodeCount+1)),
+	)
+	require.True(t, IsErrorCode(err, ErrControlBlockTooLarge))
+
+	// 调整哈希与输出密钥的计算一致。
+	privKey, err := btcec.NewPrivateKey()
+	require.NoError(t, err)
+	internalKey := schnorr.SerializePubKey(privKey.PubKey())
+	tweak := TapTweakHash(internalKey, rootHash[:])
+	var tweakScalar btcec.ModNScalar
+	tweakScalar.SetBytes((*[32]byte)(&tweak))
+	var tweakPoint, outputPoint, internalPoint btcec.JacobianPoint
+	parsed, err := schnorr.ParsePubKey(internalKey)
+	require.NoError(t, err)
+	parsed.AsJacobian(&internalPoint)
+	btcec.ScalarBaseMultNonConst(&tweakScalar, &tweakPoint)
+	btcec.AddNonConst(&internalPoint, &tweakPoint, &outputPoint)
+	outputPoint.ToAffine()
+	require.Equal(t,
+		btcec.NewPublicKey(&outputPoint.X, &outputPoint.Y),
+		ComputeTaprootOutputKey(privKey.PubKey(), rootHash[:]),
+	)
+}
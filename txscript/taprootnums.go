@@ -0,0 +1,93 @@
+// 包含构建和验证只能通过密钥路径花费或只能通过脚本路径花费的 taproot 输出的辅助函数。 只能通过脚本路径花费的输出使用
+// BIP0341 中的标准 NUMS（nothing up my sleeve）点作为内部密钥，没有人知道该点的离散对数，因此密钥路径被禁用。
+
+package txscript
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"fmt"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcec/v2/schnorr"
+)
+
+// ErrTaprootCommitmentMismatch 在 taproot 输出没有提交到预期的内部密钥和脚本树时返回。
+var ErrTaprootCommitmentMismatch = errors.New("taproot commitment mismatch")
+
+// NUMSKeyBytes 是 BIP0341 中的标准 NUMS 点 H 的 x-only 编码，即生成元 G 的未压缩编码的 SHA256 哈希作为 x 坐标的点。
+var NUMSKeyBytes = []byte{
+	0x50, 0x92, 0x9b, 0x74, 0xc1, 0xa0, 0x49, 0x54,
+	0xb7, 0x8b, 0x4b, 0x60, 0x35, 0xe9, 0x7a, 0x5e,
+	0x07, 0x8a, 0x5a, 0x0f, 0x28, 0xec, 0x96, 0xd5,
+	0x47, 0xbf, 0xee, 0x9a, 0xce, 0x80, 0x3a, 0xc0,
+}
+
+// NUMSKey 返回 BIP0341 中的标准 NUMS 点 H。
+func NUMSKey() *btcec.PublicKey {
+	key, err := schnorr.ParsePubKey(NUMSKeyBytes)
+	if err != nil {
+		panic(fmt.Sprintf("invalid NUMS key %x: %v",
+			NUMSKeyBytes, err))
+	}
+	return key
+}
+
+// IsNUMSKey 返回公钥的 x 坐标是否为标准 NUMS 点 H。 使用 H + rG 形式隐藏的 NUMS 点无法被识别。
+func IsNUMSKey(key *btcec.PublicKey) bool {
+	return key != nil && bytes.Equal(schnorr.SerializePubKey(key), NUMSKeyBytes)
+}
+
+// taprootOutputKey 返回 taproot 输出脚本中的输出密钥，脚本不是 taproot 输出时返回错误。
+func taprootOutputKey(pkScript []byte) ([]byte, error) {
+	outputKey := extractWitnessV1KeyBytes(pkScript)
+	if outputKey == nil {
+		return nil, fmt.Errorf("%w: script %s is not a taproot output",
+			ErrTaprootCommitmentMismatch, hex.EncodeToString(pkScript))
+	}
+	return outputKey, nil
+}
+
+// verifyTaprootCommitment 验证 taproot 输出脚本的输出密钥是否由内部密钥和脚本根计算得到。
+func verifyTaprootCommitment(pkScript []byte, internalKey *btcec.PublicKey,
+	scriptRoot []byte) error {
+
+	outputKey, err := taprootOutputKey(pkScript)
+	if err != nil {
+		return err
+	}
+	expected := ComputeTaprootOutputKey(internalKey, scriptRoot)
+	if !bytes.Equal(outputKey, schnorr.SerializePubKey(expected)) {
+		return fmt.Errorf("%w: output key %x does not commit to "+
+			"internal key %x and script root %x",
+			ErrTaprootCommitmentMismatch, outputKey,
+			schnorr.SerializePubKey(internalKey), scriptRoot)
+	}
+	return nil
+}
+
+// PayToTaprootKeySpendOnlyScript 返回只能通过密钥路径花费的 taproot 输出脚本，其输出密钥按 BIP0086 提交到空的脚本根。
+func PayToTaprootKeySpendOnlyScript(internalKey *btcec.PublicKey) ([]byte, error) {
+	return PayToTaprootScript(ComputeTaprootKeyNoScript(internalKey))
+}
+
+// VerifyTaprootKeySpendOnly 验证 taproot 输出脚本是否由内部密钥生成且不提交到任何脚本路径。
+// 验证失败时返回 ErrTaprootCommitmentMismatch。
+func VerifyTaprootKeySpendOnly(pkScript []byte,
+	internalKey *btcec.PublicKey) error {
+
+	return verifyTaprootCommitment(pkScript, internalKey, []byte{})
+}
+
+// PayToTaprootScriptPathOnlyScript 返回以 NUMS 点为内部密钥、提交到脚本根的 taproot 输出脚本，
+// 该输出只能通过脚本路径花费。
+func PayToTaprootScriptPathOnlyScript(scriptRoot []byte) ([]byte, error) {
+	return PayToTaprootScript(ComputeTaprootOutputKey(NUMSKey(), scriptRoot))
+}
+
+// VerifyTaprootScriptPathOnly 验证 taproot 输出脚本是否以 NUMS 点为内部密钥并提交到脚本根，即其密钥路径被禁用。
+// 验证失败时返回 ErrTaprootCommitmentMismatch。
+func VerifyTaprootScriptPathOnly(pkScript []byte, scriptRoot []byte) error {
+	return verifyTaprootCommitment(pkScript, NUMSKey(), scriptRoot)
+}
@@ -0,0 +1,106 @@
+package txscript
+
+import (
+	"crypto/sha256"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/stretchr/testify/require"
+)
+
+// TestNUMSKey 确保 NUMS 点是 BIP0341 中由生成元推导的点，并且可以被识别。
+func TestNUMSKey(t *testing.T) {
+	t.Parallel()
+
+	// H 的 x 坐标是生成元未压缩编码的 SHA256 哈希。
+	params := btcec.S256().Params()
+	var g [65]byte
+	g[0] = 0x04
+	params.Gx.FillBytes(g[1:33])
+	params.Gy.FillBytes(g[33:])
+	h := sha256.Sum256(g[:])
+	require.Equal(t, h[:], NUMSKeyBytes)
+
+	require.True(t, IsNUMSKey(NUMSKey()))
+	require.False(t, IsNUMSKey(nil))
+
+	privKey, err := btcec.NewPrivateKey()
+	require.NoError(t, err)
+	require.False(t, IsNUMSKey(privKey.PubKey()))
+}
+
+// TestTaprootKeySpendOnly 确保只能通过密钥路径花费的输出被验证为不提交到脚本路径。
+func TestTaprootKeySpendOnly(t *testing.T) {
+	t.Parallel()
+
+	privKey, err := btcec.NewPrivateKey()
+	require.NoError(t, err)
+	internalKey := privKey.PubKey()
+
+	pkScript, err := PayToTaprootKeySpendOnlyScript(internalKey)
+	require.NoError(t, err)
+	require.NoError(t, VerifyTaprootKeySpendOnly(pkScript, internalKey))
+
+	// 提交到脚本树的输出不是只能通过密钥路径花费的输出。
+	leaf := NewBaseTapLeaf([]byte{OP_TRUE})
+	root := AssembleTaprootScriptTree(leaf).RootNode.TapHash()
+	scriptOutput, err := PayToTaprootScript(
+		ComputeTaprootOutputKey(internalKey, root[:]),
+	)
+	require.NoError(t, err)
+	err = VerifyTaprootKeySpendOnly(scriptOutput, internalKey)
+	require.ErrorIs(t, err, ErrTaprootCommitmentMismatch)
+
+	// 其他内部密钥或非 taproot 脚本同样被拒绝。
+	otherKey, err := btcec.NewPrivateKey()
+	require.NoError(t, err)
+	err = VerifyTaprootKeySpendOnly(pkScript, otherKey.PubKey())
+	require.ErrorIs(t, err, ErrTaprootCommitmentMismatch)
+	err = VerifyTaprootKeySpendOnly(pkScript[1:], internalKey)
+	require.ErrorIs(t, err, ErrTaprootCommitmentMismatch)
+}
+
+// TestTaprootScriptPathOnly 确保以 NUMS 点为内部密钥的输出可以被验证，并且可以通过脚本路径花费。
+func TestTaprootScriptPathOnly(t *testing.T) {
+	t.Parallel()
+
+	leaf := NewBaseTapLeaf([]byte{OP_TRUE})
+	tree := AssembleTaprootScriptTree(leaf)
+	root := tree.RootNode.TapHash()
+
+	pkScript, err := PayToTaprootScriptPathOnlyScript(root[:])
+	require.NoError(t, err)
+	require.NoError(t, VerifyTaprootScriptPathOnly(pkScript, root[:]))
+
+	other := NewBaseTapLeaf([]byte{OP_FALSE}).TapHash()
+	err = VerifyTaprootScriptPathOnly(pkScript, other[:])
+	require.ErrorIs(t, err, ErrTaprootCommitmentMismatch)
+
+	// 使用普通内部密钥的输出不是只能通过脚本路径花费的输出。
+	privKey, err := btcec.NewPrivateKey()
+	require.NoError(t, err)
+	keyOutput, err := PayToTaprootScript(
+		ComputeTaprootOutputKey(privKey.PubKey(), root[:]),
+	)
+	require.NoError(t, err)
+	err = VerifyTaprootScriptPathOnly(keyOutput, root[:])
+	require.ErrorIs(t, err, ErrTaprootCommitmentMismatch)
+
+	// 输出可以通过脚本路径花费，控制块中的内部密钥是 NUMS 点。
+	ctrlBlock := tree.LeafMerkleProofs[0].ToControlBlock(NUMSKey())
+	ctrlBlockBytes, err := ctrlBlock.ToBytes()
+	require.NoError(t, err)
+	parsed, err := ParseControlBlock(ctrlBlockBytes)
+	require.NoError(t, err)
+	require.True(t, IsNUMSKey(parsed.InternalKey))
+
+	witness := [][]byte{leaf.Script, ctrlBlockBytes}
+	tx := createSpendingTx(witness, nil, pkScript, 1000)
+	prevOuts := NewCannedPrevOutputFetcher(pkScript, 1000)
+	vm, err := NewEngine(
+		pkScript, tx, 0, StandardVerifyFlags|ScriptVerifyTaproot, nil,
+		NewTxSigHashes(tx, prevOuts), 1000, prevOuts,
+	)
+	require.NoError(t, err)
+	require.NoError(t, vm.Execute())
+}
@@ -0,0 +1,90 @@
+// 包含 taproot 输出密钥奇偶性和调整值的辅助函数。 钱包在更新脚本树之后经常用旧的默克尔根或错误的奇偶位构造花费，
+// 这些函数用于重新计算输出密钥 Y 坐标的奇偶性、检查控制块的奇偶位，以及恢复调整值并推导与输出密钥匹配的私钥。
+
+package txscript
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcec/v2/schnorr"
+	secp "github.com/decred/dcrd/dcrec/secp256k1/v4"
+)
+
+// ErrTaprootOutputKeyMismatch 在内部密钥和默克尔根推导出的输出密钥与预期的输出密钥不一致时返回，
+// 通常意味着使用了过期的脚本树或错误的内部密钥。
+var ErrTaprootOutputKeyMismatch = errors.New("taproot output key mismatch")
+
+// TaprootOutputKeyYIsOdd 返回由内部密钥和脚本树默克尔根承诺的输出密钥的 Y 坐标是否为奇数，即花费脚本路径时控制块中应设置的奇偶位。
+// 只能通过密钥路径花费的输出使用空的 merkleRoot。
+func TaprootOutputKeyYIsOdd(internalKey *btcec.PublicKey,
+	merkleRoot []byte) bool {
+
+	outputKey := ComputeTaprootOutputKey(internalKey, merkleRoot)
+	return outputKey.SerializeCompressed()[0] ==
+		secp.PubKeyFormatCompressedOdd
+}
+
+// VerifyControlBlockParity 检查控制块的奇偶位与由其内部密钥和 revealedScript 所在脚本树的默克尔根推导出的输出密钥是否一致。
+// 不一致时返回 ErrTaprootOutputKeyParityMismatch 错误。 与 VerifyTaprootLeafCommitment 不同，
+// 该函数不需要见证程序，因此可以在构造见证之前检查控制块。
+func VerifyControlBlockParity(controlBlock *ControlBlock,
+	revealedScript []byte) error {
+
+	rootHash := controlBlock.RootHash(revealedScript)
+	yIsOdd := TaprootOutputKeyYIsOdd(controlBlock.InternalKey, rootHash)
+	if controlBlock.OutputKeyYIsOdd != yIsOdd {
+		str := fmt.Sprintf("control block y is odd: %v, derived "+
+			"parity is odd: %v", controlBlock.OutputKeyYIsOdd, yIsOdd)
+		return scriptError(ErrTaprootOutputKeyParityMismatch, str)
+	}
+	return nil
+}
+
+// RecoverTaprootTweak 返回内部密钥和默克尔根的调整值 t = h_tapTweak(internalKey || merkleRoot)，
+// 并检查 internalKey + t*G 的 x 坐标与 outputKey 一致，outputKey 可以是 32 字节的见证程序或 33 字节的压缩公钥。
+// 不一致时返回 ErrTaprootOutputKeyMismatch 错误。 按照 BIP0341，调整值不小于曲线阶时同样返回错误。
+func RecoverTaprootTweak(internalKey *btcec.PublicKey, merkleRoot,
+	outputKey []byte) (*btcec.ModNScalar, error) {
+
+	switch len(outputKey) {
+	case schnorr.PubKeyBytesLen:
+	case btcec.PubKeyBytesLenCompressed:
+		outputKey = outputKey[1:]
+	default:
+		return nil, fmt.Errorf("%w: invalid output key length %d",
+			ErrTaprootOutputKeyMismatch, len(outputKey))
+	}
+
+	tapTweakHash := TapTweakHash(
+		schnorr.SerializePubKey(internalKey), merkleRoot,
+	)
+	var tweak btcec.ModNScalar
+	if overflow := tweak.SetBytes((*[32]byte)(&tapTweakHash)); overflow != 0 {
+		return nil, fmt.Errorf("%w: tweak exceeds the curve order",
+			ErrTaprootOutputKeyMismatch)
+	}
+
+	derived := ComputeTaprootOutputKey(internalKey, merkleRoot)
+	if string(schnorr.SerializePubKey(derived)) != string(outputKey) {
+		return nil, fmt.Errorf("%w: derived %x, expected %x",
+			ErrTaprootOutputKeyMismatch,
+			schnorr.SerializePubKey(derived), outputKey)
+	}
+
+	return &tweak, nil
+}
+
+// TaprootKeySpendPrivKey 返回用于花费 outputKey 密钥路径的私钥，即 TweakTaprootPrivKey(privKey, merkleRoot)，
+// 并检查其公钥与 outputKey 一致。 脚本树更新后使用旧的默克尔根会返回 ErrTaprootOutputKeyMismatch 错误，
+// 而不是产生无效的签名。 返回的私钥已按 BIP0340 的要求处理奇偶性，可直接用于 schnorr 签名。
+func TaprootKeySpendPrivKey(privKey *btcec.PrivateKey, merkleRoot,
+	outputKey []byte) (*btcec.PrivateKey, error) {
+
+	_, err := RecoverTaprootTweak(privKey.PubKey(), merkleRoot, outputKey)
+	if err != nil {
+		return nil, err
+	}
+	return TweakTaprootPrivKey(*privKey, merkleRoot), nil
+}
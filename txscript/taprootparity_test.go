@@ -0,0 +1,88 @@
+package txscript
+
+import (
+	"testing"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcec/v2/schnorr"
+	"github.com/stretchr/testify/require"
+)
+
+// TestTaprootParityAndTweakRecovery 确保重新计算的奇偶性与控制块一致，翻转奇偶位的控制块被拒绝，
+// 并且恢复的调整值和私钥与输出密钥匹配，而过期的默克尔根被拒绝。
+func TestTaprootParityAndTweakRecovery(t *testing.T) {
+	t.Parallel()
+
+	for i := 0; i < 20; i++ {
+		privKey, err := btcec.NewPrivateKey()
+		require.NoError(t, err)
+		internalKey := privKey.PubKey()
+
+		oldTree := AssembleTaprootScriptTree(
+			NewBaseTapLeaf([]byte{OP_1}), NewBaseTapLeaf([]byte{OP_2}),
+		)
+		oldRoot := oldTree.RootNode.TapHash()
+		tree := AssembleTaprootScriptTree(
+			NewBaseTapLeaf([]byte{OP_1}), NewBaseTapLeaf([]byte{OP_2}),
+			NewBaseTapLeaf([]byte{OP_3}),
+		)
+		root := tree.RootNode.TapHash()
+		outputKey := ComputeTaprootOutputKey(internalKey, root[:])
+
+		yIsOdd := TaprootOutputKeyYIsOdd(internalKey, root[:])
+		require.Equal(t, outputKey.SerializeCompressed()[0] == 0x03, yIsOdd)
+
+		for _, proof := range tree.LeafMerkleProofs {
+			ctrlBlock := proof.ToControlBlock(internalKey)
+			require.Equal(t, yIsOdd, ctrlBlock.OutputKeyYIsOdd)
+			require.NoError(t, VerifyControlBlockParity(
+				&ctrlBlock, proof.TapLeaf.Script,
+			))
+
+			ctrlBlock.OutputKeyYIsOdd = !ctrlBlock.OutputKeyYIsOdd
+			err := VerifyControlBlockParity(
+				&ctrlBlock, proof.TapLeaf.Script,
+			)
+			require.True(t, IsErrorCode(
+				err, ErrTaprootOutputKeyParityMismatch,
+			))
+		}
+
+		// 恢复的调整值满足 outputKey = internalKey + t*G。
+		witnessProgram := schnorr.SerializePubKey(outputKey)
+		tweak, err := RecoverTaprootTweak(
+			internalKey, root[:], witnessProgram,
+		)
+		require.NoError(t, err)
+		var tweakPoint, internalPoint, derived btcec.JacobianPoint
+		btcec.ScalarBaseMultNonConst(tweak, &tweakPoint)
+		evenKey, err := schnorr.ParsePubKey(
+			schnorr.SerializePubKey(internalKey),
+		)
+		require.NoError(t, err)
+		evenKey.AsJacobian(&internalPoint)
+		btcec.AddNonConst(&internalPoint, &tweakPoint, &derived)
+		derived.ToAffine()
+		require.True(t, btcec.NewPublicKey(&derived.X, &derived.Y).
+			IsEqual(outputKey))
+
+		_, err = RecoverTaprootTweak(
+			internalKey, root[:], outputKey.SerializeCompressed(),
+		)
+		require.NoError(t, err)
+
+		// 推导出的私钥可以签署密钥路径花费。
+		spendKey, err := TaprootKeySpendPrivKey(
+			privKey, root[:], witnessProgram,
+		)
+		require.NoError(t, err)
+		require.Equal(t, witnessProgram,
+			schnorr.SerializePubKey(spendKey.PubKey()))
+
+		// 脚本树更新之前的默克尔根不再匹配。
+		_, err = TaprootKeySpendPrivKey(privKey, oldRoot[:], witnessProgram)
+		require.ErrorIs(t, err, ErrTaprootOutputKeyMismatch)
+		_, err = RecoverTaprootTweak(internalKey, root[:], witnessProgram[1:])
+		require.ErrorIs(t, err, ErrTaprootOutputKeyMismatch)
+	}
+}
@@ -0,0 +1,322 @@
+// 包含可编辑的 tapscript 树，支持增删和替换叶子，并在每次修改后只重新计算受影响路径上的哈希。
+
+package txscript
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	secp "github.com/decred/dcrd/dcrec/secp256k1/v4"
+)
+
+// ErrUnknownTapLeaf 在叶子标识符不属于树时返回。
+var ErrUnknownTapLeaf = errors.New("unknown tap leaf")
+
+// TapLeafID 是 TapScriptTree 中叶子的稳定标识符。 它在叶子被添加时分配，在树的其他部分被修改或叶子被替换后保持不变，
+// 并且在叶子被移除后不会被重用。
+type TapLeafID uint64
+
+// tapTreeNode 是 TapScriptTree 中的节点。 叶子节点的 left 和 right 为 nil。
+type tapTreeNode struct {
+	parent      *tapTreeNode
+	left, right *tapTreeNode
+
+	// leaf 和 id 只对叶子节点有效。
+	leaf TapLeaf
+	id   TapLeafID
+
+	// hash 是节点的缓存 tap 哈希。
+	hash chainhash.Hash
+}
+
+// isLeaf 返回节点是否为叶子节点。
+func (n *tapTreeNode) isLeaf() bool {
+	return n.left == nil
+}
+
+// rehash 根据叶子或子节点重新计算节点的哈希。
+func (n *tapTreeNode) rehash() {
+	if n.isLeaf() {
+		n.hash = n.leaf.TapHash()
+		return
+	}
+	n.hash = TapBranchHash(n.left.hash[:], n.right.hash[:])
+}
+
+// sibling 返回节点的兄弟节点，根节点返回 nil。
+func (n *tapTreeNode) sibling() *tapTreeNode {
+	switch {
+	case n.parent == nil:
+		return nil
+	case n.parent.left == n:
+		return n.parent.right
+	default:
+		return n.parent.left
+	}
+}
+
+// toTapNode 返回以该节点为根的不可变子树。
+func (n *tapTreeNode) toTapNode() TapNode {
+	if n.isLeaf() {
+		return n.leaf
+	}
+	return NewTapBranch(n.left.toTapNode(), n.right.toTapNode())
+}
+
+// TapScriptTree 是可编辑的 tapscript 树。 与 AssembleTaprootScriptTree 返回的 IndexedTapScriptTree 不同，
+// 叶子通过稳定的 TapLeafID 而不是位置或哈希引用，因此轮换花费条件的钱包在修改树之后仍然知道每个控制块对应哪个叶子。
+//
+// 每次修改只重新计算从被修改位置到根的路径上的哈希；包含证明和控制块在请求时沿叶子到根的路径生成。
+// TapScriptTree 不是并发安全的。
+type TapScriptTree struct {
+	internalKey *btcec.PublicKey
+	root        *tapTreeNode
+	leaves      map[TapLeafID]*tapTreeNode
+	nextID      TapLeafID
+}
+
+// NewTapScriptTree 使用内部密钥和初始叶子创建可编辑的 tapscript 树，并按叶子的顺序返回它们的标识符。
+// 树的形状与 AssembleTaprootScriptTree 为相同叶子构建的树相同，因此两者具有相同的默克尔根。 叶子可以为空。
+func NewTapScriptTree(internalKey *btcec.PublicKey,
+	leaves ...TapLeaf) (*TapScriptTree, []TapLeafID) {
+
+	t := &TapScriptTree{
+		internalKey: internalKey,
+		leaves:      make(map[TapLeafID]*tapTreeNode, len(leaves)),
+	}
+	if len(leaves) == 0 {
+		return t, nil
+	}
+
+	ids := make([]TapLeafID, len(leaves))
+	for i := range leaves {
+		ids[i] = t.newID()
+	}
+
+	// The assembled tree does not keep the leaves in input order, so map
+	// each leaf back to its input position by hash. Identical leaves are
+	// assigned in input order.
+	positions := make(map[chainhash.Hash][]int, len(leaves))
+	for i, leaf := range leaves {
+		h := leaf.TapHash()
+		positions[h] = append(positions[h], i)
+	}
+
+	var build func(node TapNode, parent *tapTreeNode) *tapTreeNode
+	build = func(node TapNode, parent *tapTreeNode) *tapTreeNode {
+		n := &tapTreeNode{parent: parent}
+		if node.Left() == nil && node.Right() == nil {
+			h := node.TapHash()
+			idx := positions[h][0]
+			positions[h] = positions[h][1:]
+
+			n.leaf = leaves[idx]
+			n.id = ids[idx]
+			n.hash = h
+			t.leaves[n.id] = n
+			return n
+		}
+
+		n.left = build(node.Left(), n)
+		n.right = build(node.Right(), n)
+		n.rehash()
+		return n
+	}
+	t.root = build(AssembleTaprootScriptTree(leaves...).RootNode, nil)
+
+	return t, ids
+}
+
+// newID 分配一个新的叶子标识符。
+func (t *TapScriptTree) newID() TapLeafID {
+	id := t.nextID
+	t.nextID++
+	return id
+}
+
+// rehashFrom 重新计算从 n 到根路径上所有节点的哈希。
+func (t *TapScriptTree) rehashFrom(n *tapTreeNode) {
+	for ; n != nil; n = n.parent {
+		n.rehash()
+	}
+}
+
+// depth 返回节点到根的距离。
+func (n *tapTreeNode) depth() int {
+	var d int
+	for p := n.parent; p != nil; p = p.parent {
+		d++
+	}
+	return d
+}
+
+// AddLeaf 向树中添加叶子并返回其标识符。 新叶子与深度最小的现有叶子组成新的分支，以使树保持平衡，
+// 因此只有该叶子及其祖先的包含证明会改变。
+func (t *TapScriptTree) AddLeaf(leaf TapLeaf) TapLeafID {
+	n := &tapTreeNode{leaf: leaf, id: t.newID()}
+	n.hash = leaf.TapHash()
+	t.leaves[n.id] = n
+
+	if t.root == nil {
+		t.root = n
+		return n.id
+	}
+
+	// Pair the new leaf with the shallowest existing leaf, preferring the
+	// oldest one on ties so that the result is deterministic.
+	var target *tapTreeNode
+	targetDepth := -1
+	for _, existing := range t.leaves {
+		if existing == n {
+			continue
+		}
+		d := existing.depth()
+		if target == nil || d < targetDepth ||
+			(d == targetDepth && existing.id < target.id) {
+
+			target, targetDepth = existing, d
+		}
+	}
+
+	branch := &tapTreeNode{parent: target.parent, left: target, right: n}
+	switch {
+	case target.parent == nil:
+		t.root = branch
+	case target.parent.left == target:
+		target.parent.left = branch
+	default:
+		target.parent.right = branch
+	}
+	target.parent = branch
+	n.parent = branch
+	t.rehashFrom(branch)
+
+	return n.id
+}
+
+// RemoveLeaf 从树中移除叶子。 其兄弟节点取代它们的父分支，因此只有兄弟子树中叶子的包含证明变短，
+// 其他叶子的证明只有路径上的哈希改变。 移除最后一个叶子后树为空，输出密钥只承诺内部密钥。
+func (t *TapScriptTree) RemoveLeaf(id TapLeafID) error {
+	n, ok := t.leaves[id]
+	if !ok {
+		return fmt.Errorf("%w: %d", ErrUnknownTapLeaf, id)
+	}
+	delete(t.leaves, id)
+
+	parent := n.parent
+	if parent == nil {
+		t.root = nil
+		return nil
+	}
+
+	sibling := n.sibling()
+	sibling.parent = parent.parent
+	switch {
+	case parent.parent == nil:
+		t.root = sibling
+	case parent.parent.left == parent:
+		parent.parent.left = sibling
+	default:
+		parent.parent.right = sibling
+	}
+	t.rehashFrom(sibling.parent)
+
+	return nil
+}
+
+// ReplaceLeaf 将叶子替换为新的脚本和叶子版本，保留其标识符和在树中的位置。
+func (t *TapScriptTree) ReplaceLeaf(id TapLeafID, leaf TapLeaf) error {
+	n, ok := t.leaves[id]
+	if !ok {
+		return fmt.Errorf("%w: %d", ErrUnknownTapLeaf, id)
+	}
+	n.leaf = leaf
+	t.rehashFrom(n)
+	return nil
+}
+
+// Leaf 返回标识符对应的叶子。
+func (t *TapScriptTree) Leaf(id TapLeafID) (TapLeaf, bool) {
+	n, ok := t.leaves[id]
+	if !ok {
+		return TapLeaf{}, false
+	}
+	return n.leaf, true
+}
+
+// NumLeaves 返回树中叶子的数量。
+func (t *TapScriptTree) NumLeaves() int {
+	return len(t.leaves)
+}
+
+// InternalKey 返回树的内部密钥。
+func (t *TapScriptTree) InternalKey() *btcec.PublicKey {
+	return t.internalKey
+}
+
+// RootHash 返回树的默克尔根，树为空时返回 nil。
+func (t *TapScriptTree) RootHash() []byte {
+	if t.root == nil {
+		return nil
+	}
+	h := t.root.hash
+	return h[:]
+}
+
+// RootNode 返回树当前状态的不可变快照，树为空时返回 nil。
+func (t *TapScriptTree) RootNode() TapNode {
+	if t.root == nil {
+		return nil
+	}
+	return t.root.toTapNode()
+}
+
+// OutputKey 返回承诺内部密钥和当前默克尔根的 taproot 输出密钥。 树为空时返回只承诺内部密钥的输出密钥（BIP0086）。
+func (t *TapScriptTree) OutputKey() *btcec.PublicKey {
+	if t.root == nil {
+		return ComputeTaprootKeyNoScript(t.internalKey)
+	}
+	return ComputeTaprootOutputKey(t.internalKey, t.RootHash())
+}
+
+// inclusionProof 返回从叶子到根的兄弟节点哈希。
+func (t *TapScriptTree) inclusionProof(n *tapTreeNode) []byte {
+	proof := make([]byte, 0, n.depth()*chainhash.HashSize)
+	for ; n.parent != nil; n = n.parent {
+		sibling := n.sibling()
+		proof = append(proof, sibling.hash[:]...)
+	}
+	return proof
+}
+
+// Proof 返回叶子在当前树中的包含证明。 RootNode 是树当前状态的快照，因此之后对树的修改不会影响返回的证明。
+func (t *TapScriptTree) Proof(id TapLeafID) (*TapscriptProof, error) {
+	n, ok := t.leaves[id]
+	if !ok {
+		return nil, fmt.Errorf("%w: %d", ErrUnknownTapLeaf, id)
+	}
+	return &TapscriptProof{
+		TapLeaf:        n.leaf,
+		RootNode:       t.RootNode(),
+		InclusionProof: t.inclusionProof(n),
+	}, nil
+}
+
+// ControlBlock 返回通过脚本路径花费该叶子所需的控制块。 与 Proof 不同，它不需要构建树的快照。
+func (t *TapScriptTree) ControlBlock(id TapLeafID) (*ControlBlock, error) {
+	n, ok := t.leaves[id]
+	if !ok {
+		return nil, fmt.Errorf("%w: %d", ErrUnknownTapLeaf, id)
+	}
+
+	outputKey := t.OutputKey()
+	return &ControlBlock{
+		InternalKey: t.internalKey,
+		OutputKeyYIsOdd: outputKey.SerializeCompressed()[0] ==
+			secp.PubKeyFormatCompressedOdd,
+		LeafVersion:    n.leaf.LeafVersion,
+		InclusionProof: t.inclusionProof(n),
+	}, nil
+}
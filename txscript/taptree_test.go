@@ -0,0 +1,152 @@
+package txscript
+
+import (
+	"testing"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcec/v2/schnorr"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/stretchr/testify/require"
+)
+
+// testTapLeaf 返回一个可以无需签名花费的 tapscript 叶子，不同的 i 生成不同的叶子。
+func testTapLeaf(t *testing.T, i int64) TapLeaf {
+	script, err := NewScriptBuilder().AddInt64(i).AddOp(OP_DROP).
+		AddOp(OP_TRUE).Script()
+	require.NoError(t, err)
+	return NewBaseTapLeaf(script)
+}
+
+// requireTapTreeValid 确保树中每个叶子的控制块都能打开树的输出密钥，并且可以通过脚本引擎花费。
+func requireTapTreeValid(t *testing.T, tree *TapScriptTree, ids []TapLeafID) {
+	t.Helper()
+
+	require.Equal(t, len(ids), tree.NumLeaves())
+	if len(ids) > 0 {
+		snapshot := tree.RootNode().TapHash()
+		require.Equal(t, snapshot[:], tree.RootHash())
+	}
+
+	outputKey := tree.OutputKey()
+	pkScript, err := PayToTaprootScript(outputKey)
+	require.NoError(t, err)
+	for _, id := range ids {
+		leaf, ok := tree.Leaf(id)
+		require.True(t, ok)
+
+		ctrl, err := tree.ControlBlock(id)
+		require.NoError(t, err)
+		require.NoError(t, VerifyTaprootLeafCommitment(ctrl,
+			schnorr.SerializePubKey(outputKey), leaf.Script))
+
+		proof, err := tree.Proof(id)
+		require.NoError(t, err)
+		proofCtrl := proof.ToControlBlock(tree.InternalKey())
+		require.Equal(t, *ctrl, proofCtrl)
+
+		ctrlBytes, err := ctrl.ToBytes()
+		require.NoError(t, err)
+		witness := wire.TxWitness{leaf.Script, ctrlBytes}
+		tx := createSpendingTx(witness, nil, pkScript, 0)
+		prevFetcher := NewCannedPrevOutputFetcher(pkScript, 0)
+		vm, err := NewEngine(pkScript, tx, 0, StandardVerifyFlags, nil,
+			NewTxSigHashes(tx, prevFetcher), 0, prevFetcher)
+		require.NoError(t, err)
+		require.NoError(t, vm.Execute())
+	}
+}
+
+// TestTapScriptTreeAssemble 确保可编辑树与 AssembleTaprootScriptTree 为相同叶子构建的树具有相同的根，并按输入顺序分配标识符。
+func TestTapScriptTreeAssemble(t *testing.T) {
+	t.Parallel()
+
+	internalKey, err := btcec.NewPrivateKey()
+	require.NoError(t, err)
+
+	for n := 1; n <= 9; n++ {
+		leaves := make([]TapLeaf, n)
+		for i := range leaves {
+			leaves[i] = testTapLeaf(t, int64(i))
+		}
+
+		tree, ids := NewTapScriptTree(internalKey.PubKey(), leaves...)
+		require.Len(t, ids, n)
+		for i, id := range ids {
+			leaf, ok := tree.Leaf(id)
+			require.True(t, ok)
+			require.Equal(t, leaves[i], leaf)
+		}
+
+		assembled := AssembleTaprootScriptTree(leaves...)
+		rootHash := assembled.RootNode.TapHash()
+		require.Equal(t, rootHash[:], tree.RootHash())
+
+		requireTapTreeValid(t, tree, ids)
+	}
+}
+
+// TestTapScriptTreeEdit 确保添加、移除和替换叶子后所有叶子的控制块仍然有效，并且叶子标识符保持稳定。
+func TestTapScriptTreeEdit(t *testing.T) {
+	t.Parallel()
+
+	internalKey, err := btcec.NewPrivateKey()
+	require.NoError(t, err)
+
+	tree, ids := NewTapScriptTree(internalKey.PubKey())
+	require.Nil(t, tree.RootHash())
+	require.Nil(t, tree.RootNode())
+	require.True(t, tree.OutputKey().IsEqual(
+		ComputeTaprootKeyNoScript(internalKey.PubKey())))
+
+	for i := 0; i < 6; i++ {
+		ids = append(ids, tree.AddLeaf(testTapLeaf(t, int64(i))))
+		requireTapTreeValid(t, tree, ids)
+	}
+
+	// 替换叶子保留其标识符，并改变输出密钥。
+	before := tree.OutputKey()
+	replacement := testTapLeaf(t, 100)
+	require.NoError(t, tree.ReplaceLeaf(ids[2], replacement))
+	leaf, ok := tree.Leaf(ids[2])
+	require.True(t, ok)
+	require.Equal(t, replacement, leaf)
+	require.False(t, before.IsEqual(tree.OutputKey()))
+	requireTapTreeValid(t, tree, ids)
+
+	// 移除叶子后，其余叶子的标识符仍然指向相同的叶子。
+	require.NoError(t, tree.RemoveLeaf(ids[0]))
+	removed := ids[0]
+	ids = ids[1:]
+	requireTapTreeValid(t, tree, ids)
+	_, ok = tree.Leaf(removed)
+	require.False(t, ok)
+	leaf, ok = tree.Leaf(ids[0])
+	require.True(t, ok)
+	require.Equal(t, testTapLeaf(t, 1), leaf)
+
+	for _, err := range []error{
+		tree.RemoveLeaf(removed),
+		tree.ReplaceLeaf(removed, replacement),
+	} {
+		require.ErrorIs(t, err, ErrUnknownTapLeaf)
+	}
+	_, err = tree.ControlBlock(removed)
+	require.ErrorIs(t, err, ErrUnknownTapLeaf)
+	_, err = tree.Proof(removed)
+	require.ErrorIs(t, err, ErrUnknownTapLeaf)
+
+	// 新叶子不会重用已移除叶子的标识符。
+	added := tree.AddLeaf(testTapLeaf(t, 200))
+	require.NotEqual(t, removed, added)
+	ids = append(ids, added)
+	requireTapTreeValid(t, tree, ids)
+
+	for len(ids) > 0 {
+		require.NoError(t, tree.RemoveLeaf(ids[len(ids)-1]))
+		ids = ids[:len(ids)-1]
+		requireTapTreeValid(t, tree, ids)
+	}
+	require.Nil(t, tree.RootHash())
+	require.True(t, tree.OutputKey().IsEqual(
+		ComputeTaprootKeyNoScript(internalKey.PubKey())))
+}
@@ -0,0 +1,319 @@
+// 包含 taproot 脚本树与描述符 tr(KEY,TREE)（BIP0386）嵌套形式之间的转换，例如 tr(KEY,{pk(A),{pk(B),pk(C)}})，
+// 使组装好的脚本树可以作为文本备份并恢复，恢复后的树与原树的形状、叶子版本和默克尔根完全相同。
+
+package txscript
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcec/v2/schnorr"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+)
+
+// ErrInvalidTapTreeDescriptor 在 tr() 描述符无法解析或脚本树无法表示为描述符时返回。
+var ErrInvalidTapTreeDescriptor = errors.New("invalid tap tree descriptor")
+
+// TapTreeDescriptor 返回附加校验和的描述符 tr(internalKey,TREE)#checksum，root 为 nil 时返回 tr(internalKey)#checksum。
+// 分支写作 {LEFT,RIGHT}，叶子按以下形式写出：
+//
+//	pk(KEY)                      基础版本的 <KEY> CHECKSIG 叶子
+//	multi_a(...)                 基础版本的 multi_a 叶子，见 ParseMultiALeaf
+//	raw(HEX)                     其他基础版本的叶子
+//	rawleaf(VERSION,HEX)         非基础版本的叶子，VERSION 是两位十六进制的叶子版本
+//
+// raw 和 rawleaf 是本包的扩展，只包含 pk 和 multi_a 叶子的描述符与 BIP0386 兼容。
+func TapTreeDescriptor(internalKey *btcec.PublicKey,
+	root TapNode) (string, error) {
+
+	var buf strings.Builder
+	fmt.Fprintf(&buf, "tr(%x", schnorr.SerializePubKey(internalKey))
+	if root != nil {
+		buf.WriteByte(',')
+		if err := writeTapTreeNode(&buf, root, 0); err != nil {
+			return "", err
+		}
+	}
+	buf.WriteByte(')')
+
+	desc := buf.String()
+	checksum, err := descriptorChecksum(desc)
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrInvalidTapTreeDescriptor, err)
+	}
+	return desc + "#" + checksum, nil
+}
+
+// writeTapTreeNode 将以 node 为根的子树写入 buf。
+func writeTapTreeNode(buf *strings.Builder, node TapNode, depth int) error {
+	if depth > ControlBlockMaxNodeCount {
+		return fmt.Errorf("%w: tree depth exceeds %d",
+			ErrInvalidTapTreeDescriptor, ControlBlockMaxNodeCount)
+	}
+
+	if node.Left() != nil || node.Right() != nil {
+		buf.WriteByte('{')
+		if err := writeTapTreeNode(buf, node.Left(), depth+1); err != nil {
+			return err
+		}
+		buf.WriteByte(',')
+		if err := writeTapTreeNode(buf, node.Right(), depth+1); err != nil {
+			return err
+		}
+		buf.WriteByte('}')
+		return nil
+	}
+
+	leaf, ok := node.(TapLeaf)
+	if !ok {
+		return fmt.Errorf("%w: unsupported leaf node %T",
+			ErrInvalidTapTreeDescriptor, node)
+	}
+	if leaf.LeafVersion != BaseLeafVersion {
+		fmt.Fprintf(buf, "rawleaf(%02x,%x)", byte(leaf.LeafVersion),
+			leaf.Script)
+		return nil
+	}
+
+	script := leaf.Script
+	if len(script) == 34 && script[0] == OP_DATA_32 &&
+		script[33] == OP_CHECKSIG {
+
+		if _, err := schnorr.ParsePubKey(script[1:33]); err == nil {
+			fmt.Fprintf(buf, "pk(%x)", script[1:33])
+			return nil
+		}
+	}
+	if d, err := ParseMultiALeaf(script); err == nil {
+		buf.WriteString(d.String())
+		return nil
+	}
+	fmt.Fprintf(buf, "raw(%x)", script)
+	return nil
+}
+
+// ParseTapTreeDescriptor 解析 TapTreeDescriptor 生成的描述符或 BIP0386 的 tr() 描述符，返回内部密钥和脚本树的根节点，
+// 描述符没有脚本树时根节点为 nil。 描述符带有校验和时会验证校验和。 sortedmulti_a 叶子按排序后的公钥重建。
+func ParseTapTreeDescriptor(desc string) (*btcec.PublicKey, TapNode, error) {
+	if idx := strings.IndexByte(desc, '#'); idx >= 0 {
+		checksum, err := descriptorChecksum(desc[:idx])
+		if err != nil {
+			return nil, nil, fmt.Errorf("%w: %v",
+				ErrInvalidTapTreeDescriptor, err)
+		}
+		if desc[idx+1:] != checksum {
+			return nil, nil, fmt.Errorf("%w: checksum mismatch, "+
+				"expected %s", ErrInvalidTapTreeDescriptor, checksum)
+		}
+		desc = desc[:idx]
+	}
+
+	if !strings.HasPrefix(desc, "tr(") || !strings.HasSuffix(desc, ")") {
+		return nil, nil, fmt.Errorf("%w: %q is not a tr expression",
+			ErrInvalidTapTreeDescriptor, desc)
+	}
+	args := desc[len("tr(") : len(desc)-1]
+
+	keyExpr, treeExpr := args, ""
+	if sep := strings.IndexByte(args, ','); sep >= 0 {
+		keyExpr, treeExpr = args[:sep], args[sep+1:]
+	}
+	internalKey, err := parseDescriptorKey(keyExpr)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: %v", ErrInvalidTapTreeDescriptor,
+			err)
+	}
+	if treeExpr == "" {
+		if keyExpr != args {
+			return nil, nil, fmt.Errorf("%w: empty script tree",
+				ErrInvalidTapTreeDescriptor)
+		}
+		return internalKey, nil, nil
+	}
+
+	root, err := parseTapTreeNode(treeExpr, 0)
+	if err != nil {
+		return nil, nil, err
+	}
+	return internalKey, root, nil
+}
+
+// parseTapTreeNode 解析一个分支表达式 {LEFT,RIGHT} 或叶子表达式。
+func parseTapTreeNode(expr string, depth int) (TapNode, error) {
+	if depth > ControlBlockMaxNodeCount {
+		return nil, fmt.Errorf("%w: tree depth exceeds %d",
+			ErrInvalidTapTreeDescriptor, ControlBlockMaxNodeCount)
+	}
+
+	if !strings.HasPrefix(expr, "{") {
+		return parseTapLeafExpr(expr)
+	}
+	if !strings.HasSuffix(expr, "}") {
+		return nil, fmt.Errorf("%w: unterminated branch %q",
+			ErrInvalidTapTreeDescriptor, expr)
+	}
+
+	inner := expr[1 : len(expr)-1]
+	sep := topLevelComma(inner)
+	if sep < 0 {
+		return nil, fmt.Errorf("%w: branch %q does not have two children",
+			ErrInvalidTapTreeDescriptor, expr)
+	}
+	left, err := parseTapTreeNode(inner[:sep], depth+1)
+	if err != nil {
+		return nil, err
+	}
+	right, err := parseTapTreeNode(inner[sep+1:], depth+1)
+	if err != nil {
+		return nil, err
+	}
+	return NewTapBranch(left, right), nil
+}
+
+// topLevelComma 返回 expr 中不在任何括号或花括号内的第一个逗号的位置，括号不匹配或没有这样的逗号时返回 -1。
+func topLevelComma(expr string) int {
+	depth := 0
+	for i := 0; i < len(expr); i++ {
+		switch expr[i] {
+		case '(', '{':
+			depth++
+		case ')', '}':
+			depth--
+			if depth < 0 {
+				return -1
+			}
+		case ',':
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// parseTapLeafExpr 解析叶子表达式 pk、multi_a、sortedmulti_a、raw 或 rawleaf。
+func parseTapLeafExpr(expr string) (TapNode, error) {
+	name, args, ok := splitDescriptorCall(expr)
+	if !ok {
+		return nil, fmt.Errorf("%w: invalid leaf expression %q",
+			ErrInvalidTapTreeDescriptor, expr)
+	}
+
+	switch name {
+	case "pk":
+		key, err := parseDescriptorKey(args)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v",
+				ErrInvalidTapTreeDescriptor, err)
+		}
+		script, err := NewScriptBuilder().
+			AddData(schnorr.SerializePubKey(key)).AddOp(OP_CHECKSIG).
+			Script()
+		if err != nil {
+			return nil, err
+		}
+		return NewBaseTapLeaf(script), nil
+
+	case "multi_a", "sortedmulti_a":
+		d, err := ParseMultiADescriptor(expr)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v",
+				ErrInvalidTapTreeDescriptor, err)
+		}
+		leaf, err := d.Leaf()
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v",
+				ErrInvalidTapTreeDescriptor, err)
+		}
+		return leaf, nil
+
+	case "raw":
+		script, err := hex.DecodeString(args)
+		if err != nil {
+			return nil, fmt.Errorf("%w: invalid script %q",
+				ErrInvalidTapTreeDescriptor, args)
+		}
+		return NewBaseTapLeaf(script), nil
+
+	case "rawleaf":
+		sep := strings.IndexByte(args, ',')
+		if sep < 0 {
+			return nil, fmt.Errorf("%w: rawleaf requires a version and "+
+				"a script", ErrInvalidTapTreeDescriptor)
+		}
+		version, err := strconv.ParseUint(args[:sep], 16, 8)
+		if err != nil || sep != 2 {
+			return nil, fmt.Errorf("%w: invalid leaf version %q",
+				ErrInvalidTapTreeDescriptor, args[:sep])
+		}
+		if version&^uint64(TaprootLeafMask) != 0 {
+			return nil, fmt.Errorf("%w: leaf version %02x has the "+
+				"parity bit set", ErrInvalidTapTreeDescriptor, version)
+		}
+		script, err := hex.DecodeString(args[sep+1:])
+		if err != nil {
+			return nil, fmt.Errorf("%w: invalid script %q",
+				ErrInvalidTapTreeDescriptor, args[sep+1:])
+		}
+		return NewTapLeaf(TapscriptLeafVersion(version), script), nil
+
+	default:
+		return nil, fmt.Errorf("%w: unsupported leaf expression %s()",
+			ErrInvalidTapTreeDescriptor, name)
+	}
+}
+
+// splitDescriptorCall 将 NAME(ARGS) 形式的表达式拆分为名称和参数。
+func splitDescriptorCall(expr string) (string, string, bool) {
+	open := strings.IndexByte(expr, '(')
+	if open <= 0 || !strings.HasSuffix(expr, ")") {
+		return "", "", false
+	}
+	return expr[:open], expr[open+1 : len(expr)-1], true
+}
+
+// IndexTapScriptTree 为任意形状的脚本树计算每个叶子的包含证明，返回的树与 AssembleTaprootScriptTree 的结果具有相同的结构，
+// 叶子按深度优先、从左到右的顺序编号。 ParseTapTreeDescriptor 恢复的树可以通过它得到花费所需的控制块。
+func IndexTapScriptTree(root TapNode) *IndexedTapScriptTree {
+	tree := &IndexedTapScriptTree{
+		RootNode:       root,
+		LeafProofIndex: make(map[chainhash.Hash]int),
+	}
+
+	// siblings holds the sibling hashes from the root down to the
+	// current node, a leaf's inclusion proof lists them bottom up.
+	var walk func(node TapNode, siblings []chainhash.Hash)
+	walk = func(node TapNode, siblings []chainhash.Hash) {
+		if node.Left() != nil || node.Right() != nil {
+			n := len(siblings)
+			siblings = append(siblings, node.Right().TapHash())
+			walk(node.Left(), siblings[:n+1:n+1])
+			siblings = append(siblings[:n], node.Left().TapHash())
+			walk(node.Right(), siblings[:n+1:n+1])
+			return
+		}
+
+		leaf, ok := node.(TapLeaf)
+		if !ok {
+			return
+		}
+		proof := make([]byte, 0, len(siblings)*chainhash.HashSize)
+		for i := len(siblings) - 1; i >= 0; i-- {
+			proof = append(proof, siblings[i][:]...)
+		}
+		tree.LeafProofIndex[leaf.TapHash()] = len(tree.LeafMerkleProofs)
+		tree.LeafMerkleProofs = append(tree.LeafMerkleProofs,
+			TapscriptProof{
+				TapLeaf:        leaf,
+				RootNode:       root,
+				InclusionProof: proof,
+			})
+	}
+	walk(root, nil)
+
+	return tree
+}
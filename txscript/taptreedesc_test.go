@@ -0,0 +1,103 @@
+package txscript
+
+import (
+	"testing"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcec/v2/schnorr"
+	"github.com/stretchr/testify/require"
+)
+
+// TestTapTreeDescriptorRoundTrip 确保脚本树导出为描述符后能够恢复出形状、叶子版本和默克尔根相同的树，
+// 并且恢复的树计算的包含证明与 AssembleTaprootScriptTree 相同。
+func TestTapTreeDescriptorRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	keys := make([]*btcec.PublicKey, 3)
+	for i := range keys {
+		privKey, err := btcec.NewPrivateKey()
+		require.NoError(t, err)
+		keys[i] = privKey.PubKey()
+	}
+	internalKey := keys[0]
+
+	pkLeaf, err := NewScriptBuilder().
+		AddData(schnorr.SerializePubKey(keys[1])).AddOp(OP_CHECKSIG).
+		Script()
+	require.NoError(t, err)
+	multiA, err := NewMultiADescriptor(2, keys, false)
+	require.NoError(t, err)
+	multiALeaf, err := multiA.Leaf()
+	require.NoError(t, err)
+
+	leaves := []TapLeaf{
+		NewBaseTapLeaf(pkLeaf),
+		multiALeaf,
+		NewBaseTapLeaf([]byte{OP_TRUE}),
+		NewTapLeaf(0xc2, []byte{OP_2, OP_DROP}),
+		NewBaseTapLeaf(nil),
+	}
+	tree := AssembleTaprootScriptTree(leaves...)
+
+	desc, err := TapTreeDescriptor(internalKey, tree.RootNode)
+	require.NoError(t, err)
+	require.Contains(t, desc, "pk(")
+	require.Contains(t, desc, "multi_a(2,")
+	require.Contains(t, desc, "raw(51)")
+	require.Contains(t, desc, "rawleaf(c2,5275)")
+
+	gotKey, root, err := ParseTapTreeDescriptor(desc)
+	require.NoError(t, err)
+	require.Equal(t, schnorr.SerializePubKey(internalKey),
+		schnorr.SerializePubKey(gotKey))
+	require.Equal(t, tree.RootNode.TapHash(), root.TapHash())
+
+	// The text form is stable across a round trip.
+	again, err := TapTreeDescriptor(gotKey, root)
+	require.NoError(t, err)
+	require.Equal(t, desc, again)
+
+	indexed := IndexTapScriptTree(root)
+	require.Len(t, indexed.LeafMerkleProofs, len(leaves))
+	for _, leaf := range leaves {
+		want := tree.LeafMerkleProofs[tree.LeafProofIndex[leaf.TapHash()]]
+		got := indexed.LeafMerkleProofs[indexed.LeafProofIndex[leaf.TapHash()]]
+		require.Equal(t, want.TapLeaf.TapHash(), got.TapLeaf.TapHash())
+		require.Equal(t, want.InclusionProof, got.InclusionProof)
+
+		ctrlBlock := got.ToControlBlock(internalKey)
+		rootHash := tree.RootNode.TapHash()
+		require.Equal(t, rootHash[:], ctrlBlock.RootHash(leaf.Script))
+	}
+
+	// A key path only descriptor has no tree.
+	keyOnly, err := TapTreeDescriptor(internalKey, nil)
+	require.NoError(t, err)
+	_, root, err = ParseTapTreeDescriptor(keyOnly)
+	require.NoError(t, err)
+	require.Nil(t, root)
+}
+
+// TestParseTapTreeDescriptorErrors 确保格式错误的描述符被拒绝。
+func TestParseTapTreeDescriptorErrors(t *testing.T) {
+	t.Parallel()
+
+	const key = "79be667ef9dcbbac55a06295ce870b07029bfcdb2dce28d959f2815b" +
+		"16f81798"
+	tests := []string{
+		"sh(" + key + ")",
+		"tr(" + key + ",)",
+		"tr(" + key + ",{pk(" + key + ")})",
+		"tr(" + key + ",{pk(" + key + "),pk(" + key + ")}",
+		"tr(" + key + ",{pk(" + key + "),pk(" + key + ")}})",
+		"tr(" + key + ",pkh(" + key + "))",
+		"tr(" + key + ",raw(zz))",
+		"tr(" + key + ",rawleaf(c3,51))",
+		"tr(" + key + ",rawleaf(c,51))",
+		"tr(" + key + ")#aaaaaaaa",
+	}
+	for _, desc := range tests {
+		_, _, err := ParseTapTreeDescriptor(desc)
+		require.ErrorIs(t, err, ErrInvalidTapTreeDescriptor, desc)
+	}
+}
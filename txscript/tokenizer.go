@@ -159,6 +159,11 @@ func (t *ScriptTokenizer) Data() []byte {
 	return t.data
 }
 
+// Version 返回分词器解析脚本时使用的脚本版本。
+func (t *ScriptTokenizer) Version() uint16 {
+	return t.version
+}
+
 // Err 返回当前与标记生成器关联的任何错误。 仅当遇到解析错误时，该值才为非零。
 func (t *ScriptTokenizer) Err() error {
 	return t.err
@@ -168,9 +173,9 @@ func (t *ScriptTokenizer) Err() error {
 //
 // 有关更多详细信息，请参阅 ScriptTokenizer 的文档。
 func MakeScriptTokenizer(scriptVersion uint16, script []byte) ScriptTokenizer {
-	// 目前仅支持版本 0 脚本。
+	// 支持版本 0 脚本以及通过 RegisterScriptVersion 注册的版本，所有版本的编码相同。
 	var err error
-	if scriptVersion != 0 {
+	if scriptVersion != 0 && lookupScriptVersion(scriptVersion) == nil {
 		str := fmt.Sprintf("script version %d is not supported", scriptVersion)
 		err = scriptError(ErrUnsupportedScriptVersion, str)
 
@@ -0,0 +1,327 @@
+// 包含在运行脚本引擎之前对整个交易的脚本进行的快速健全性检查，只做不涉及密码学的结构检查，
+// 并一次返回所有输入的全部违规，而不是像引擎那样为每个输入单独构造并只报告第一个问题。
+
+package txscript
+
+import (
+	"crypto/sha256"
+	"fmt"
+
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/wire"
+)
+
+// MaxStandardTxSigOpsCost 是标准交易允许的最大签名操作成本，为区块上限 80000 的五分之一。
+const MaxStandardTxSigOpsCost = 16000
+
+// TxScriptViolationKind 表示 CheckTransactionScriptsSanity 发现的违规种类。
+type TxScriptViolationKind uint8
+
+const (
+	// TxScriptMissingPrevOut 表示无法获取输入花费的输出。
+	TxScriptMissingPrevOut TxScriptViolationKind = iota
+
+	// TxScriptTooLarge 表示签名脚本、被花费的公钥脚本、兑换脚本或见证脚本超过了大小限制。
+	TxScriptTooLarge
+
+	// TxScriptUnparseable 表示需要执行的脚本无法解析。
+	TxScriptUnparseable
+
+	// TxScriptNotPushOnly 表示花费 P2SH 或见证程序的签名脚本包含数据推送以外的操作码。
+	TxScriptNotPushOnly
+
+	// TxScriptMissingRedeemScript 表示花费 P2SH 输出的签名脚本没有推送兑换脚本。
+	TxScriptMissingRedeemScript
+
+	// TxScriptUnexpectedWitness 表示花费非见证输出的输入带有见证。
+	TxScriptUnexpectedWitness
+
+	// TxScriptMissingWitness 表示花费见证程序的输入没有见证。
+	TxScriptMissingWitness
+
+	// TxScriptWitnessMismatch 表示见证与见证程序的类型不符，例如 P2WPKH 见证的元素数量不是 2，
+	// 见证脚本与 P2WSH 程序的哈希不匹配，或 taproot 控制块的长度无效。
+	TxScriptWitnessMismatch
+
+	// TxScriptWitnessTooLarge 表示见证的元素数量或元素大小超过了限制。
+	TxScriptWitnessTooLarge
+
+	// TxScriptTooManySigOps 表示交易的签名操作成本超过 MaxStandardTxSigOpsCost。 该违规属于整个交易。
+	TxScriptTooManySigOps
+)
+
+// txScriptViolationNames 包含每种违规的名称。
+var txScriptViolationNames = []string{
+	TxScriptMissingPrevOut:      "TxScriptMissingPrevOut",
+	TxScriptTooLarge:            "TxScriptTooLarge",
+	TxScriptUnparseable:         "TxScriptUnparseable",
+	TxScriptNotPushOnly:         "TxScriptNotPushOnly",
+	TxScriptMissingRedeemScript: "TxScriptMissingRedeemScript",
+	TxScriptUnexpectedWitness:   "TxScriptUnexpectedWitness",
+	TxScriptMissingWitness:      "TxScriptMissingWitness",
+	TxScriptWitnessMismatch:     "TxScriptWitnessMismatch",
+	TxScriptWitnessTooLarge:     "TxScriptWitnessTooLarge",
+	TxScriptTooManySigOps:       "TxScriptTooManySigOps",
+}
+
+// String 返回违规种类的名称。
+func (k TxScriptViolationKind) String() string {
+	if int(k) >= len(txScriptViolationNames) {
+		return fmt.Sprintf("TxScriptViolationKind(%d)", uint8(k))
+	}
+	return txScriptViolationNames[k]
+}
+
+// TxScriptViolation 描述交易脚本的一处违规。
+type TxScriptViolation struct {
+	// Input 是违规输入的索引，违规属于整个交易时为 -1。
+	Input int
+
+	// Kind 是违规的种类。
+	Kind TxScriptViolationKind
+
+	// Description 是违规的可读描述。
+	Description string
+}
+
+// Error 实现 error 接口，使单个违规可以直接作为错误返回。
+func (v TxScriptViolation) Error() string {
+	if v.Input < 0 {
+		return fmt.Sprintf("%v: %s", v.Kind, v.Description)
+	}
+	return fmt.Sprintf("input %d: %v: %s", v.Input, v.Kind, v.Description)
+}
+
+// CheckTransactionScriptsSanity 在运行脚本引擎之前对 tx 的脚本进行快速检查，按输入顺序返回所有违规，没有违规时返回 nil。
+// 检查的内容包括：
+//
+//   - 每个输入花费的输出都可以通过 prevOutFetcher 获取
+//   - 签名脚本、公钥脚本、兑换脚本和见证脚本不超过默认的大小限制，需要执行的脚本可以解析
+//   - 花费 P2SH 和见证程序时签名脚本只包含数据推送，花费原生见证程序时签名脚本为空
+//   - 见证的存在与否以及形式与被花费的见证程序类型相符，见证元素的数量和大小不超过默认限制
+//   - 交易的签名操作成本不超过 MaxStandardTxSigOpsCost
+//
+// 检查不验证签名，也不执行脚本，没有违规并不意味着交易有效。 coinbase 交易的输入不被检查。
+func CheckTransactionScriptsSanity(tx *wire.MsgTx,
+	prevOutFetcher PrevOutputFetcher) []TxScriptViolation {
+
+	limits := DefaultChainLimits()
+	return limits.CheckTransactionScriptsSanity(tx, prevOutFetcher)
+}
+
+// CheckTransactionScriptsSanity 与包级函数 CheckTransactionScriptsSanity 相同，但按 l 而不是包级默认限制检查大小。
+func (l *ChainLimits) CheckTransactionScriptsSanity(tx *wire.MsgTx,
+	prevOutFetcher PrevOutputFetcher) []TxScriptViolation {
+
+	var violations []TxScriptViolation
+	add := func(input int, kind TxScriptViolationKind, format string,
+		args ...interface{}) {
+
+		violations = append(violations, TxScriptViolation{
+			Input:       input,
+			Kind:        kind,
+			Description: fmt.Sprintf(format, args...),
+		})
+	}
+
+	var legacySigOps, witnessSigOps int
+	for _, txOut := range tx.TxOut {
+		legacySigOps += GetSigOpCount(txOut.PkScript)
+	}
+
+	for i, txIn := range tx.TxIn {
+		outpoint := txIn.PreviousOutPoint
+		if outpoint.Index == wire.MaxPrevOutIndex &&
+			outpoint.Hash == (chainhash.Hash{}) {
+
+			continue
+		}
+
+		legacySigOps += GetSigOpCount(txIn.SignatureScript)
+		if len(txIn.SignatureScript) > l.MaxScriptSize {
+			add(i, TxScriptTooLarge, "signature script size %d exceeds "+
+				"the max of %d", len(txIn.SignatureScript),
+				l.MaxScriptSize)
+		}
+
+		prevOut := prevOutFetcher.FetchPrevOutput(outpoint)
+		if prevOut == nil {
+			add(i, TxScriptMissingPrevOut, "previous output %v not found",
+				outpoint)
+			continue
+		}
+		pkScript := prevOut.PkScript
+		if isScriptHashScript(pkScript) {
+			legacySigOps += GetPreciseSigOpCount(
+				txIn.SignatureScript, pkScript, true,
+			)
+		}
+		witnessSigOps += GetWitnessSigOpCount(
+			txIn.SignatureScript, pkScript, txIn.Witness,
+		)
+
+		checkInputSanity(txIn, pkScript, l,
+			func(kind TxScriptViolationKind, format string,
+				args ...interface{}) {
+
+				add(i, kind, format, args...)
+			})
+	}
+
+	sigOpsCost := legacySigOps*witnessScaleFactor + witnessSigOps
+	if sigOpsCost > MaxStandardTxSigOpsCost {
+		add(-1, TxScriptTooManySigOps, "signature operation cost %d "+
+			"exceeds the max of %d", sigOpsCost, MaxStandardTxSigOpsCost)
+	}
+
+	return violations
+}
+
+// checkInputSanity 检查花费 pkScript 的输入，并通过 add 报告违规。
+func checkInputSanity(txIn *wire.TxIn, pkScript []byte, limits *ChainLimits,
+	add func(TxScriptViolationKind, string, ...interface{})) {
+
+	const scriptVersion = 0
+	sigScript := txIn.SignatureScript
+	witness := txIn.Witness
+
+	if len(pkScript) > limits.MaxScriptSize {
+		add(TxScriptTooLarge, "public key script size %d exceeds the max "+
+			"of %d", len(pkScript), limits.MaxScriptSize)
+	}
+	if err := checkScriptParses(scriptVersion, sigScript); err != nil {
+		add(TxScriptUnparseable, "signature script: %v", err)
+		return
+	}
+
+	// Determine the witness program being spent, if any, along with the
+	// scripts that must be push only.
+	program := pkScript
+	switch {
+	case isWitnessProgramScript(pkScript):
+		if len(sigScript) != 0 {
+			add(TxScriptNotPushOnly, "signature script must be empty "+
+				"when spending a native witness program")
+		}
+
+	case isScriptHashScript(pkScript):
+		if !IsPushOnlyScript(sigScript) {
+			add(TxScriptNotPushOnly, "signature script spending a "+
+				"P2SH output is not push only")
+			return
+		}
+		if len(sigScript) == 0 {
+			add(TxScriptMissingRedeemScript, "empty signature script "+
+				"spending a P2SH output")
+			return
+		}
+		redeemScript := finalOpcodeData(scriptVersion, sigScript)
+		if len(redeemScript) > limits.MaxScriptElementSize {
+			add(TxScriptTooLarge, "redeem script size %d exceeds the "+
+				"max element size of %d", len(redeemScript),
+				limits.MaxScriptElementSize)
+		}
+		if err := checkScriptParses(scriptVersion, redeemScript); err != nil {
+			add(TxScriptUnparseable, "redeem script: %v", err)
+		}
+		program = redeemScript
+		if isWitnessProgramScript(redeemScript) &&
+			len(sigScript) != 1+len(redeemScript) {
+
+			add(TxScriptNotPushOnly, "signature script spending a "+
+				"nested witness program must only push the program")
+		}
+
+	default:
+		program = nil
+	}
+
+	if program == nil || !isWitnessProgramScript(program) {
+		if len(witness) != 0 {
+			add(TxScriptUnexpectedWitness, "witness provided for a "+
+				"non-witness spend")
+		}
+		return
+	}
+	if len(witness) == 0 {
+		add(TxScriptMissingWitness, "no witness provided for a witness "+
+			"program spend")
+		return
+	}
+	if len(witness) > limits.MaxWitnessStackItems {
+		add(TxScriptWitnessTooLarge, "witness has %d items, the max is %d",
+			len(witness), limits.MaxWitnessStackItems)
+		return
+	}
+
+	version, prog, _ := extractWitnessProgramInfo(program)
+	var stack [][]byte
+	switch {
+	case version == BaseSegwitWitnessVersion &&
+		len(prog) == payToWitnessPubKeyHashDataSize:
+
+		if len(witness) != 2 {
+			add(TxScriptWitnessMismatch, "P2WPKH witness has %d items, "+
+				"2 expected", len(witness))
+		}
+		stack = witness
+
+	case version == BaseSegwitWitnessVersion &&
+		len(prog) == payToWitnessScriptHashDataSize:
+
+		witnessScript := witness[len(witness)-1]
+		if len(witnessScript) > limits.MaxScriptSize {
+			add(TxScriptTooLarge, "witness script size %d exceeds the "+
+				"max of %d", len(witnessScript), limits.MaxScriptSize)
+		}
+		scriptHash := sha256.Sum256(witnessScript)
+		if string(scriptHash[:]) != string(prog) {
+			add(TxScriptWitnessMismatch, "witness script does not "+
+				"match the P2WSH program")
+		}
+		if err := checkScriptParses(scriptVersion, witnessScript); err != nil {
+			add(TxScriptUnparseable, "witness script: %v", err)
+		}
+		stack = witness[:len(witness)-1]
+
+	case version == BaseSegwitWitnessVersion:
+		add(TxScriptWitnessMismatch, "version 0 witness program has "+
+			"invalid length %d", len(prog))
+		return
+
+	case version == TaprootWitnessVersion &&
+		len(prog) == payToTaprootDataSize:
+
+		if isAnnexedWitness(witness) {
+			witness = witness[:len(witness)-1]
+		}
+		if len(witness) <= 1 {
+			// Key path spend, the signature is checked by the engine.
+			return
+		}
+
+		// The leaf script isn't parsed here since an OP_SUCCESS opcode
+		// makes the spend succeed regardless of what follows it.
+		ctrlBlock := witness[len(witness)-1]
+		if len(ctrlBlock) < ControlBlockBaseSize ||
+			len(ctrlBlock) > ControlBlockMaxSize ||
+			(len(ctrlBlock)-ControlBlockBaseSize)%ControlBlockNodeSize != 0 {
+
+			add(TxScriptWitnessMismatch, "invalid control block size %d",
+				len(ctrlBlock))
+		}
+		stack = witness[:len(witness)-2]
+
+	default:
+		// Unknown witness versions are left for future soft forks.
+		return
+	}
+
+	for j, item := range stack {
+		if len(item) > limits.MaxWitnessElementSize {
+			add(TxScriptWitnessTooLarge, "witness item %d size %d "+
+				"exceeds the max of %d", j, len(item),
+				limits.MaxWitnessElementSize)
+		}
+	}
+}
@@ -0,0 +1,123 @@
+package txscript
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"testing"
+
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/stretchr/testify/require"
+)
+
+// TestCheckTransactionScriptsSanity 确保一次检查报告所有输入的全部违规，并且结构正确的交易没有违规。
+func TestCheckTransactionScriptsSanity(t *testing.T) {
+	t.Parallel()
+
+	hash20 := make([]byte, 20)
+	p2pkh, err := payToPubKeyHashScript(hash20)
+	require.NoError(t, err)
+	p2wpkh, err := payToWitnessPubKeyHashScript(hash20)
+	require.NoError(t, err)
+	witnessScript := []byte{OP_TRUE}
+	scriptHash := sha256.Sum256(witnessScript)
+	p2wsh, err := payToWitnessScriptHashScript(scriptHash[:])
+	require.NoError(t, err)
+	p2sh, err := payToScriptHashScript(hash20)
+	require.NoError(t, err)
+	p2tr, err := payToWitnessTaprootScript(make([]byte, 32))
+	require.NoError(t, err)
+
+	sig := bytes.Repeat([]byte{0x30}, 72)
+	pubKey := bytes.Repeat([]byte{0x02}, 33)
+	p2pkhSigScript, err := NewScriptBuilder().AddData(sig).AddData(pubKey).
+		Script()
+	require.NoError(t, err)
+
+	type input struct {
+		pkScript  []byte
+		sigScript []byte
+		witness   wire.TxWitness
+	}
+	buildTx := func(inputs []input) (*wire.MsgTx, PrevOutputFetcher) {
+		tx := wire.NewMsgTx(2)
+		prevOuts := make(map[wire.OutPoint]*wire.TxOut)
+		for i, in := range inputs {
+			outpoint := wire.OutPoint{
+				Hash:  chainhash.Hash{1},
+				Index: uint32(i),
+			}
+			tx.AddTxIn(&wire.TxIn{
+				PreviousOutPoint: outpoint,
+				SignatureScript:  in.sigScript,
+				Witness:          in.witness,
+			})
+			if in.pkScript != nil {
+				prevOuts[outpoint] = wire.NewTxOut(1000, in.pkScript)
+			}
+		}
+		tx.AddTxOut(wire.NewTxOut(500, p2wpkh))
+		return tx, NewMultiPrevOutFetcher(prevOuts)
+	}
+
+	valid := []input{
+		{pkScript: p2pkh, sigScript: p2pkhSigScript},
+		{pkScript: p2wpkh, witness: wire.TxWitness{sig, pubKey}},
+		{pkScript: p2wsh, witness: wire.TxWitness{witnessScript}},
+		{pkScript: p2tr, witness: wire.TxWitness{make([]byte, 64)}},
+		{pkScript: p2tr, witness: wire.TxWitness{
+			{OP_TRUE}, make([]byte, ControlBlockBaseSize+32),
+		}},
+	}
+	tx, fetcher := buildTx(valid)
+	require.Empty(t, CheckTransactionScriptsSanity(tx, fetcher))
+
+	tx, fetcher = buildTx([]input{
+		{pkScript: p2pkh, sigScript: p2pkhSigScript,
+			witness: wire.TxWitness{sig}},
+		{pkScript: p2wpkh, witness: wire.TxWitness{sig}},
+		{pkScript: p2wsh, witness: wire.TxWitness{{OP_2}}},
+		{sigScript: p2pkhSigScript},
+		{pkScript: p2sh, sigScript: []byte{OP_NOP, OP_TRUE}},
+		{pkScript: p2wpkh, sigScript: []byte{OP_TRUE}},
+		{pkScript: p2tr, witness: wire.TxWitness{
+			{OP_TRUE}, make([]byte, ControlBlockBaseSize+1),
+		}},
+		{pkScript: p2wsh, witness: wire.TxWitness{
+			make([]byte, MaxScriptElementSize+1), witnessScript,
+		}},
+		{pkScript: p2sh},
+	})
+	violations := CheckTransactionScriptsSanity(tx, fetcher)
+
+	type result struct {
+		input int
+		kind  TxScriptViolationKind
+	}
+	var got []result
+	for _, v := range violations {
+		require.NotEmpty(t, v.Error())
+		got = append(got, result{v.Input, v.Kind})
+	}
+	require.Equal(t, []result{
+		{0, TxScriptUnexpectedWitness},
+		{1, TxScriptWitnessMismatch},
+		{2, TxScriptWitnessMismatch},
+		{3, TxScriptMissingPrevOut},
+		{4, TxScriptNotPushOnly},
+		{5, TxScriptNotPushOnly},
+		{5, TxScriptMissingWitness},
+		{6, TxScriptWitnessMismatch},
+		{7, TxScriptWitnessTooLarge},
+		{8, TxScriptMissingRedeemScript},
+	}, got)
+
+	// 签名操作成本属于整个交易。
+	tx, fetcher = buildTx(valid)
+	tx.AddTxOut(wire.NewTxOut(0, bytes.Repeat([]byte{OP_CHECKSIG},
+		MaxStandardTxSigOpsCost/witnessScaleFactor+1)))
+	violations = CheckTransactionScriptsSanity(tx, fetcher)
+	require.Len(t, violations, 1)
+	require.Equal(t, -1, violations[0].Input)
+	require.Equal(t, TxScriptTooManySigOps, violations[0].Kind)
+}
@@ -0,0 +1,84 @@
+// 包含与 wire 编码完全一致的序列化大小和权重计算，包括每个脚本、见证元素和计数前的 compact-size 前缀，
+// 使手续费估算与共识计算的权重相同，不会因为少算几个字节而在最低中继费率附近支付不足。
+
+package txscript
+
+import (
+	"fmt"
+
+	"github.com/btcsuite/btcd/wire"
+)
+
+const (
+	// txInFixedSize 是输入中除签名脚本以外的固定大小：32 字节的交易哈希、4 字节的输出索引和 4 字节的序列号。
+	txInFixedSize = 32 + 4 + 4
+
+	// txOutFixedSize 是输出中除公钥脚本以外的固定大小，即 8 字节的金额。
+	txOutFixedSize = 8
+
+	// txFixedSize 是交易中 4 字节的版本号和 4 字节的锁定时间。
+	txFixedSize = 4 + 4
+
+	// witnessMarkerFlagSize 是带见证的交易在版本号之后的标记字节和标志字节（BIP0144）。
+	witnessMarkerFlagSize = 2
+)
+
+// SerializeSizeOfScript 返回脚本在交易中序列化的字节数，即 compact-size 长度前缀与脚本本身的长度之和。
+func SerializeSizeOfScript(script []byte) int {
+	return wire.VarIntSerializeSize(uint64(len(script))) + len(script)
+}
+
+// SerializeSizeOfWitness 返回输入见证序列化的字节数，包括元素数量和每个元素的 compact-size 长度前缀。
+// 没有元素的见证在带见证的交易中仍占用 1 字节。
+func SerializeSizeOfWitness(witness wire.TxWitness) int {
+	n := wire.VarIntSerializeSize(uint64(len(witness)))
+	for _, item := range witness {
+		n += SerializeSizeOfScript(item)
+	}
+	return n
+}
+
+// Weight 返回交易按 BIP0141 计算的权重，即不含见证的大小乘以 3 再加上完整大小，与 wire 编码的结果完全一致。
+//
+// prevOuts 为 nil 时按交易当前的签名脚本和见证计算。 否则签名脚本和见证都为空的输入被视为未签名，
+// 按其花费的输出填入最大长度的占位签名后计算，ECDSA 签名按 73 字节计算，因此签名后的权重不会超过返回值。
+// 只支持 P2PK、P2PKH、P2WPKH 和 P2TR 密钥路径花费的未签名输入，其他输出或找不到被花费的输出时返回错误。
+// 任一输入带有见证时，所有输入都计入见证数据，同时计入标记字节和标志字节。
+func Weight(tx *wire.MsgTx, prevOuts PrevOutputFetcher) (int64, error) {
+	baseSize := txFixedSize + wire.VarIntSerializeSize(uint64(len(tx.TxIn))) +
+		wire.VarIntSerializeSize(uint64(len(tx.TxOut)))
+
+	var witnessSize int
+	var hasWitness bool
+	for i, txIn := range tx.TxIn {
+		sigScript, witness := txIn.SignatureScript, txIn.Witness
+		if prevOuts != nil && len(sigScript) == 0 && len(witness) == 0 {
+			prevOut := prevOuts.FetchPrevOutput(txIn.PreviousOutPoint)
+			if prevOut == nil {
+				return 0, fmt.Errorf("previous output of input %d "+
+					"not found", i)
+			}
+
+			var err error
+			sigScript, witness, err = spendPlaceholder(prevOut.PkScript)
+			if err != nil {
+				return 0, fmt.Errorf("input %d: %w", i, err)
+			}
+		}
+
+		baseSize += txInFixedSize + SerializeSizeOfScript(sigScript)
+		witnessSize += SerializeSizeOfWitness(witness)
+		if len(witness) > 0 {
+			hasWitness = true
+		}
+	}
+	for _, txOut := range tx.TxOut {
+		baseSize += txOutFixedSize + SerializeSizeOfScript(txOut.PkScript)
+	}
+
+	weight := int64(baseSize) * witnessScaleFactor
+	if hasWitness {
+		weight += int64(witnessMarkerFlagSize + witnessSize)
+	}
+	return weight, nil
+}
@@ -0,0 +1,109 @@
+package txscript
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/stretchr/testify/require"
+)
+
+// TestSerializeSizes 确保脚本和见证的大小与 wire 编码一致，包括 compact-size 前缀长度变化的边界。
+func TestSerializeSizes(t *testing.T) {
+	t.Parallel()
+
+	for _, n := range []int{0, 1, 252, 253, 0xffff, 0x10000} {
+		script := make([]byte, n)
+		var buf bytes.Buffer
+		require.NoError(t, wire.WriteVarBytes(&buf, 0, script))
+		require.Equal(t, buf.Len(), SerializeSizeOfScript(script), n)
+
+		witness := wire.TxWitness{script, nil, script[:n/2]}
+		require.Equal(t, witness.SerializeSize(),
+			SerializeSizeOfWitness(witness), n)
+	}
+	require.Equal(t, 1, SerializeSizeOfWitness(nil))
+
+	witness := make(wire.TxWitness, 253)
+	require.Equal(t, witness.SerializeSize(), SerializeSizeOfWitness(witness))
+}
+
+// TestWeight 确保交易权重与 wire 编码的结果一致，并且未签名输入的占位权重不小于签名后的权重。
+func TestWeight(t *testing.T) {
+	t.Parallel()
+
+	wireWeight := func(tx *wire.MsgTx) int64 {
+		return int64(tx.SerializeSizeStripped()*3 + tx.SerializeSize())
+	}
+
+	tx := wire.NewMsgTx(2)
+	tx.AddTxIn(&wire.TxIn{SignatureScript: make([]byte, 300)})
+	tx.AddTxOut(wire.NewTxOut(1, make([]byte, 252)))
+	weight, err := Weight(tx, nil)
+	require.NoError(t, err)
+	require.Equal(t, wireWeight(tx), weight)
+
+	// 只有一个输入带见证时，其他输入的空见证同样被计入。
+	tx.AddTxIn(&wire.TxIn{Witness: wire.TxWitness{make([]byte, 253)}})
+	for i := 0; i < 252; i++ {
+		tx.AddTxOut(wire.NewTxOut(1, nil))
+	}
+	weight, err = Weight(tx, nil)
+	require.NoError(t, err)
+	require.Equal(t, wireWeight(tx), weight)
+	require.Equal(t, weight, txWeight(tx))
+
+	// 未签名的输入按其花费的输出填入占位签名。
+	key, err := btcec.NewPrivateKey()
+	require.NoError(t, err)
+	keyHash := btcutil.Hash160(key.PubKey().SerializeCompressed())
+	p2wpkh, err := payToWitnessPubKeyHashScript(keyHash)
+	require.NoError(t, err)
+	p2pkh, err := payToPubKeyHashScript(keyHash)
+	require.NoError(t, err)
+
+	prevOuts := map[wire.OutPoint]*wire.TxOut{
+		{Hash: chainhash.Hash{1}}: wire.NewTxOut(50000, p2wpkh),
+		{Hash: chainhash.Hash{2}}: wire.NewTxOut(50000, p2pkh),
+	}
+	fetcher := NewMultiPrevOutFetcher(prevOuts)
+	unsigned := wire.NewMsgTx(2)
+	for outpoint := range prevOuts {
+		unsigned.AddTxIn(&wire.TxIn{PreviousOutPoint: outpoint})
+	}
+	unsigned.AddTxOut(wire.NewTxOut(90000, p2wpkh))
+
+	estimate, err := Weight(unsigned, fetcher)
+	require.NoError(t, err)
+	unsignedWeight, err := Weight(unsigned, nil)
+	require.NoError(t, err)
+	require.Equal(t, wireWeight(unsigned), unsignedWeight)
+	require.Greater(t, estimate, unsignedWeight)
+
+	sigHashes := NewTxSigHashes(unsigned, fetcher)
+	for i, txIn := range unsigned.TxIn {
+		prevOut := prevOuts[txIn.PreviousOutPoint]
+		if IsPayToWitnessPubKeyHash(prevOut.PkScript) {
+			txIn.Witness, err = WitnessSignature(unsigned, sigHashes, i,
+				prevOut.Value, prevOut.PkScript, SigHashAll, key, true)
+		} else {
+			txIn.SignatureScript, err = SignatureScript(unsigned, i,
+				prevOut.PkScript, SigHashAll, key, true)
+		}
+		require.NoError(t, err)
+	}
+	signed, err := Weight(unsigned, fetcher)
+	require.NoError(t, err)
+	require.Equal(t, wireWeight(unsigned), signed)
+	require.LessOrEqual(t, signed, estimate)
+
+	// 无法估算的输出和找不到的被花费输出返回错误。
+	unsigned.TxIn[0].Witness, unsigned.TxIn[0].SignatureScript = nil, nil
+	_, err = Weight(unsigned, NewCannedPrevOutputFetcher([]byte{OP_TRUE}, 0))
+	require.Error(t, err)
+	_, err = Weight(unsigned, NewMultiPrevOutFetcher(nil))
+	require.Error(t, err)
+}
@@ -0,0 +1,451 @@
+// 包含可复现的交易测试向量的导出与导入，格式与 data/tx_valid.json 和 data/tx_invalid.json 相同，
+// 用于将生产环境中发现的问题直接记录为回归测试向量。
+
+package txscript
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/wire"
+)
+
+// ErrTxTestVectorMismatch 在运行测试向量的结果与预期不符时返回。
+var ErrTxTestVectorMismatch = errors.New("transaction test vector result " +
+	"mismatch")
+
+// ErrUnknownScriptFlag 在解析或格式化标志时遇到没有名称的标志时返回。
+var ErrUnknownScriptFlag = errors.New("unknown script flag")
+
+// scriptFlagNames 将参考测试中使用的标志名称映射到对应的 ScriptFlags。
+var scriptFlagNames = []struct {
+	name string
+	flag ScriptFlags
+}{
+	{"P2SH", ScriptBip16},
+	{"STRICTENC", ScriptVerifyStrictEncoding},
+	{"DERSIG", ScriptVerifyDERSignatures},
+	{"LOW_S", ScriptVerifyLowS},
+	{"SIGPUSHONLY", ScriptVerifySigPushOnly},
+	{"MINIMALDATA", ScriptVerifyMinimalData},
+	{"NULLDUMMY", ScriptStrictMultiSig},
+	{"DISCOURAGE_UPGRADABLE_NOPS", ScriptDiscourageUpgradableNops},
+	{"CLEANSTACK", ScriptVerifyCleanStack},
+	{"CHECKLOCKTIMEVERIFY", ScriptVerifyCheckLockTimeVerify},
+	{"CHECKSEQUENCEVERIFY", ScriptVerifyCheckSequenceVerify},
+	{"WITNESS", ScriptVerifyWitness},
+	{"DISCOURAGE_UPGRADABLE_WITNESS_PROGRAM",
+		ScriptVerifyDiscourageUpgradeableWitnessProgram},
+	{"MINIMALIF", ScriptVerifyMinimalIf},
+	{"NULLFAIL", ScriptVerifyNullFail},
+	{"WITNESS_PUBKEYTYPE", ScriptVerifyWitnessPubKeyType},
+	{"TAPROOT", ScriptVerifyTaproot},
+	{"DISCOURAGE_UPGRADABLE_TAPROOT_VERSION",
+		ScriptVerifyDiscourageUpgradeableTaprootVersion},
+	{"DISCOURAGE_OP_SUCCESS", ScriptVerifyDiscourageOpSuccess},
+	{"DISCOURAGE_UPGRADABLE_PUBKEYTYPE",
+		ScriptVerifyDiscourageUpgradeablePubkeyType},
+	{"REJECT_UNKNOWN_WITNESS_VERSION",
+		ScriptVerifyRejectUnknownWitnessVersion},
+	{"REJECT_UNKNOWN_SCRIPT_VERSION",
+		ScriptVerifyRejectUnknownScriptVersion},
+	{"STRUCTURED_ANNEX", ScriptVerifyStructuredAnnex},
+	{"CHECKSIGFROMSTACK", ScriptVerifyCheckSigFromStack},
+}
+
+// ParseScriptFlags 将以逗号分隔的标志名称解析为 ScriptFlags，名称与参考测试中使用的相同，例如 "P2SH,WITNESS,TAPROOT"。
+// 名称前后的空白被忽略，"NONE" 和空名称不设置任何标志，因此可以直接解析配置文件中的值。 以 "0x" 开头的十六进制值按原样设置标志位，
+// 使 ScriptFlags.String 的输出总能被解析回来。 未知的名称返回 ErrUnknownScriptFlag。
+func ParseScriptFlags(flagStr string) (ScriptFlags, error) {
+	var flags ScriptFlags
+
+	sFlags := strings.Split(flagStr, ",")
+nextFlag:
+	for _, flag := range sFlags {
+		flag = strings.TrimSpace(flag)
+		if flag == "" || flag == "NONE" {
+			continue
+		}
+		if strings.HasPrefix(flag, "0x") {
+			bits, err := strconv.ParseUint(flag[2:], 16, 32)
+			if err != nil {
+				return flags, fmt.Errorf("%w: %s",
+					ErrUnknownScriptFlag, flag)
+			}
+			flags |= ScriptFlags(bits)
+			continue
+		}
+		for _, f := range scriptFlagNames {
+			if f.name == flag {
+				flags |= f.flag
+				continue nextFlag
+			}
+		}
+		return flags, fmt.Errorf("%w: %s", ErrUnknownScriptFlag, flag)
+	}
+	return flags, nil
+}
+
+// splitScriptFlags 按 scriptFlagNames 的顺序返回 flags 中各标志的名称，以及没有名称的剩余标志位。
+func splitScriptFlags(flags ScriptFlags) ([]string, ScriptFlags) {
+	var names []string
+	for _, f := range scriptFlagNames {
+		if flags&f.flag == f.flag {
+			names = append(names, f.name)
+			flags &^= f.flag
+		}
+	}
+	return names, flags
+}
+
+// FormatScriptFlags 将 ScriptFlags 格式化为以逗号分隔的标志名称，是 ParseScriptFlags 的逆操作。 没有任何标志时返回 "NONE"，
+// 包含没有名称的标志位时返回 ErrUnknownScriptFlag。
+func FormatScriptFlags(flags ScriptFlags) (string, error) {
+	names, unknown := splitScriptFlags(flags)
+	if unknown != 0 {
+		return "", fmt.Errorf("%w: 0x%x", ErrUnknownScriptFlag,
+			uint32(unknown))
+	}
+	if len(names) == 0 {
+		return "NONE", nil
+	}
+	return strings.Join(names, ","), nil
+}
+
+// String 返回标志的规范文本形式：按固定顺序以逗号分隔的标志名称，与 FormatScriptFlags 相同，没有任何标志时为 "NONE"。
+// 没有名称的标志位以十六进制值附在最后，例如 "P2SH,0x80000000"。 ParseScriptFlags(flags.String()) 总是返回 flags。
+func (flags ScriptFlags) String() string {
+	names, unknown := splitScriptFlags(flags)
+	if unknown != 0 {
+		names = append(names, fmt.Sprintf("0x%x", uint32(unknown)))
+	}
+	if len(names) == 0 {
+		return "NONE"
+	}
+	return strings.Join(names, ",")
+}
+
+// 将十六进制字符串解析为 [] 字节。
+func parseHex(tok string) ([]byte, error) {
+	if !strings.HasPrefix(tok, "0x") {
+		return nil, fmt.Errorf("not a hex number")
+	}
+	return hex.DecodeString(tok[2:])
+}
+
+// shortFormOps 保存操作码名称到值的映射，以供短格式解析使用。 它在这里声明，因此只需要创建一次。
+var shortFormOps map[string]byte
+
+// parseShortForm 将比特币核心参考测试中使用的字符串解析为它来自的脚本。
+//
+// 如果是临时的，用于这些测试的格式非常简单：
+//   - 除推送操作码和未知操作码以外的操作码以 OP_NAME 或仅 NAME 的形式出现
+//   - 普通数字被制成推送操作
+//   - 以 0x 开头的数字按原样插入到 []byte 中（因此 0x14 是 OP_DATA_20）
+//   - 单引号字符串作为数据推送
+//   - 其他任何内容都是错误
+func parseShortForm(script string) ([]byte, error) {
+	// 仅创建一次简短形式的操作码映射。
+	if shortFormOps == nil {
+		ops := make(map[string]byte)
+		for opcodeName, opcodeValue := range OpcodeByName {
+			if strings.Contains(opcodeName, "OP_UNKNOWN") {
+				continue
+			}
+			ops[opcodeName] = opcodeValue
+
+			// 名为 OP_# 的操作码不能去掉 OP_ 前缀，否则它们会与普通数字冲突。
+			// 此外，由于 OP_FALSE 和 OP_TRUE 分别是 OP_0 和 OP_1 的别名，因此它们具有相同的值，因此请按名称检测它们并允许它们。
+			if (opcodeName == "OP_FALSE" || opcodeName == "OP_TRUE") ||
+				(opcodeValue != OP_0 && (opcodeValue < OP_1 ||
+					opcodeValue > OP_16)) {
+
+				ops[strings.TrimPrefix(opcodeName, "OP_")] = opcodeValue
+			}
+		}
+		shortFormOps = ops
+	}
+
+	// Split 只做一个分隔符，因此将所有 \n 和制表符转换为空格。
+	script = strings.Replace(script, "\n", " ", -1)
+	script = strings.Replace(script, "\t", " ", -1)
+	tokens := strings.Split(script, " ")
+	builder := NewScriptBuilder()
+
+	for _, tok := range tokens {
+		if len(tok) == 0 {
+			continue
+		}
+		// if 解析为普通数字
+		if num, err := strconv.ParseInt(tok, 10, 64); err == nil {
+			builder.AddInt64(num)
+			continue
+		} else if bts, err := parseHex(tok); err == nil {
+			// 手动连接字节，因为测试代码故意创建太大的脚本，否则会导致构建器出错。
+			if builder.err == nil {
+				builder.script = append(builder.script, bts...)
+			}
+		} else if len(tok) >= 2 &&
+			tok[0] == '\'' && tok[len(tok)-1] == '\'' {
+			builder.AddFullData([]byte(tok[1 : len(tok)-1]))
+		} else if opcode, ok := shortFormOps[tok]; ok {
+			builder.AddOp(opcode)
+		} else {
+			return nil, fmt.Errorf("bad token %q", tok)
+		}
+
+	}
+	return builder.Script()
+}
+
+// TxTestPrevOut 是测试向量中交易输入所花费的先前输出。
+type TxTestPrevOut struct {
+	OutPoint wire.OutPoint
+	PkScript []byte
+	Amount   int64
+}
+
+// TxTestVector 是一个可复现的交易验证用例：交易、其花费的先前输出、验证标志以及预期结果。
+type TxTestVector struct {
+	// Comment 是导出时写在向量之前的可选注释。
+	Comment string
+
+	// PrevOuts 是交易各输入花费的先前输出。
+	PrevOuts []TxTestPrevOut
+
+	// Tx 是被验证的交易。
+	Tx *wire.MsgTx
+
+	// Flags 是验证时使用的脚本标志。
+	Flags ScriptFlags
+
+	// Valid 表示交易的所有输入是否都应验证成功。 有效的向量属于 tx_valid.json，无效的向量属于 tx_invalid.json。
+	Valid bool
+}
+
+// NewTxTestVector 通过 prevOutFetcher 查找交易每个输入花费的先前输出，并构造测试向量。
+func NewTxTestVector(tx *wire.MsgTx, prevOutFetcher PrevOutputFetcher,
+	flags ScriptFlags, valid bool) (*TxTestVector, error) {
+
+	v := &TxTestVector{
+		Tx:    tx,
+		Flags: flags,
+		Valid: valid,
+	}
+	for i, txIn := range tx.TxIn {
+		prevOut := prevOutFetcher.FetchPrevOutput(txIn.PreviousOutPoint)
+		if prevOut == nil {
+			return nil, fmt.Errorf("missing previous output %v for "+
+				"input %d", txIn.PreviousOutPoint, i)
+		}
+		v.PrevOuts = append(v.PrevOuts, TxTestPrevOut{
+			OutPoint: txIn.PreviousOutPoint,
+			PkScript: prevOut.PkScript,
+			Amount:   prevOut.Value,
+		})
+	}
+	return v, nil
+}
+
+// MarshalJSON 将向量编码为 tx_valid.json 和 tx_invalid.json 使用的格式：
+//
+//	[[[prevout hash, prevout index, prevout scriptPubKey, amount], ...],
+//	 serializedTransaction, verifyFlags]
+//
+// 公钥脚本以原始十六进制的短格式（0x...）写入，以确保能够无损地重新解析。
+func (v *TxTestVector) MarshalJSON() ([]byte, error) {
+	flags, err := FormatScriptFlags(v.Flags)
+	if err != nil {
+		return nil, err
+	}
+
+	var txBuf bytes.Buffer
+	if err := v.Tx.Serialize(&txBuf); err != nil {
+		return nil, err
+	}
+
+	prevOuts := make([][]interface{}, 0, len(v.PrevOuts))
+	for _, prevOut := range v.PrevOuts {
+		script := ""
+		if len(prevOut.PkScript) > 0 {
+			script = "0x" + hex.EncodeToString(prevOut.PkScript)
+		}
+		prevOuts = append(prevOuts, []interface{}{
+			prevOut.OutPoint.Hash.String(), prevOut.OutPoint.Index,
+			script, prevOut.Amount,
+		})
+	}
+
+	return json.Marshal([]interface{}{
+		prevOuts, hex.EncodeToString(txBuf.Bytes()), flags,
+	})
+}
+
+// ParseTxTestVector 解析 tx_valid.json 或 tx_invalid.json 中的单个向量。 valid 表示向量来自哪个文件。
+// 公钥脚本使用参考测试的短格式。
+func ParseTxTestVector(entry []byte, valid bool) (*TxTestVector, error) {
+	var test []json.RawMessage
+	if err := json.Unmarshal(entry, &test); err != nil {
+		return nil, err
+	}
+	if len(test) != 3 {
+		return nil, fmt.Errorf("test vector has %d elements, want 3",
+			len(test))
+	}
+
+	var (
+		inputs     [][]json.RawMessage
+		serialized string
+		flagStr    string
+	)
+	if err := json.Unmarshal(test[0], &inputs); err != nil {
+		return nil, fmt.Errorf("bad inputs: %w", err)
+	}
+	if err := json.Unmarshal(test[1], &serialized); err != nil {
+		return nil, fmt.Errorf("bad serialized transaction: %w", err)
+	}
+	if err := json.Unmarshal(test[2], &flagStr); err != nil {
+		return nil, fmt.Errorf("bad flags: %w", err)
+	}
+
+	v := &TxTestVector{Valid: valid}
+	flags, err := ParseScriptFlags(flagStr)
+	if err != nil {
+		return nil, err
+	}
+	v.Flags = flags
+
+	txBytes, err := hex.DecodeString(serialized)
+	if err != nil {
+		return nil, fmt.Errorf("bad serialized transaction: %w", err)
+	}
+	v.Tx = wire.NewMsgTx(wire.TxVersion)
+	if err := v.Tx.Deserialize(bytes.NewReader(txBytes)); err != nil {
+		return nil, fmt.Errorf("bad serialized transaction: %w", err)
+	}
+
+	for i, input := range inputs {
+		if len(input) < 3 || len(input) > 4 {
+			return nil, fmt.Errorf("input %d has %d elements", i,
+				len(input))
+		}
+
+		var (
+			hashStr string
+			index   int64
+			script  string
+			amount  int64
+		)
+		if err := json.Unmarshal(input[0], &hashStr); err != nil {
+			return nil, fmt.Errorf("input %d hash: %w", i, err)
+		}
+		if err := json.Unmarshal(input[1], &index); err != nil {
+			return nil, fmt.Errorf("input %d index: %w", i, err)
+		}
+		if err := json.Unmarshal(input[2], &script); err != nil {
+			return nil, fmt.Errorf("input %d script: %w", i, err)
+		}
+		if len(input) == 4 {
+			if err := json.Unmarshal(input[3], &amount); err != nil {
+				return nil, fmt.Errorf("input %d amount: %w", i,
+					err)
+			}
+		}
+
+		hash, err := chainhash.NewHashFromStr(hashStr)
+		if err != nil {
+			return nil, fmt.Errorf("input %d hash: %w", i, err)
+		}
+		pkScript, err := parseShortForm(script)
+		if err != nil {
+			return nil, fmt.Errorf("input %d script: %w", i, err)
+		}
+
+		// Some vectors use -1 as a shortcut for the max index.
+		v.PrevOuts = append(v.PrevOuts, TxTestPrevOut{
+			OutPoint: wire.OutPoint{
+				Hash:  *hash,
+				Index: uint32(int32(index)),
+			},
+			PkScript: pkScript,
+			Amount:   amount,
+		})
+	}
+
+	return v, nil
+}
+
+// Run 验证向量中交易的所有输入。 当结果与 Valid 一致时返回 nil，否则返回包装了 ErrTxTestVectorMismatch 的错误。
+func (v *TxTestVector) Run() error {
+	prevOutFetcher := NewMultiPrevOutFetcher(nil)
+	for _, prevOut := range v.PrevOuts {
+		prevOutFetcher.AddPrevOut(prevOut.OutPoint, &wire.TxOut{
+			Value:    prevOut.Amount,
+			PkScript: prevOut.PkScript,
+		})
+	}
+
+	var failure error
+	for i, txIn := range v.Tx.TxIn {
+		prevOut := prevOutFetcher.FetchPrevOutput(txIn.PreviousOutPoint)
+		if prevOut == nil {
+			return fmt.Errorf("missing previous output %v for input %d",
+				txIn.PreviousOutPoint, i)
+		}
+
+		vm, err := NewEngine(prevOut.PkScript, v.Tx, i, v.Flags, nil,
+			nil, prevOut.Value, prevOutFetcher)
+		if err == nil {
+			err = vm.Execute()
+		}
+		if err != nil {
+			failure = fmt.Errorf("input %d: %w", i, err)
+			break
+		}
+	}
+
+	switch {
+	case v.Valid && failure != nil:
+		return fmt.Errorf("%w: expected success, %v",
+			ErrTxTestVectorMismatch, failure)
+	case !v.Valid && failure == nil:
+		return fmt.Errorf("%w: expected failure, all inputs verified",
+			ErrTxTestVectorMismatch)
+	}
+	return nil
+}
+
+// MarshalTxTestVectors 将多个向量编码为可直接追加到 tx_valid.json 或 tx_invalid.json 的 JSON 数组，每个向量一行，
+// 带有注释的向量之前会加上一行注释。
+func MarshalTxTestVectors(vectors []*TxTestVector) ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteString("[\n")
+	for i, v := range vectors {
+		if v.Comment != "" {
+			comment, err := json.Marshal([]string{v.Comment})
+			if err != nil {
+				return nil, err
+			}
+			buf.Write(comment)
+			buf.WriteString(",\n")
+		}
+
+		entry, err := v.MarshalJSON()
+		if err != nil {
+			return nil, fmt.Errorf("vector %d: %w", i, err)
+		}
+		buf.Write(entry)
+		if i != len(vectors)-1 {
+			buf.WriteString(",")
+		}
+		buf.WriteString("\n")
+	}
+	buf.WriteString("]\n")
+	return buf.Bytes(), nil
+}
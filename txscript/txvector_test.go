@@ -0,0 +1,159 @@
+package txscript
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/stretchr/testify/require"
+)
+
+// TestTxTestVectorRoundTrip 确保 tx_valid.json 和 tx_invalid.json 中的每个向量都能被导入并按预期运行，
+// 并且重新导出后再导入的结果相同。
+func TestTxTestVectorRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	files := []struct {
+		path  string
+		valid bool
+	}{
+		{"data/tx_valid.json", true},
+		{"data/tx_invalid.json", false},
+	}
+	for _, file := range files {
+		data, err := ioutil.ReadFile(file.path)
+		require.NoError(t, err)
+
+		var entries []json.RawMessage
+		require.NoError(t, json.Unmarshal(data, &entries))
+
+		var vectors []*TxTestVector
+		for _, entry := range entries {
+			// 跳过注释。
+			var comment []string
+			if json.Unmarshal(entry, &comment) == nil {
+				continue
+			}
+
+			v, err := ParseTxTestVector(entry, file.valid)
+			require.NoError(t, err, string(entry))
+			require.NoError(t, v.Run(), string(entry))
+			vectors = append(vectors, v)
+		}
+		require.NotEmpty(t, vectors)
+		vectors[0].Comment = "exported"
+
+		exported, err := MarshalTxTestVectors(vectors)
+		require.NoError(t, err)
+		var reimported []json.RawMessage
+		require.NoError(t, json.Unmarshal(exported, &reimported))
+		require.Len(t, reimported, len(vectors)+1)
+
+		for i, entry := range reimported[1:] {
+			v, err := ParseTxTestVector(entry, file.valid)
+			require.NoError(t, err)
+			require.Equal(t, vectors[i].PrevOuts, v.PrevOuts)
+			require.Equal(t, vectors[i].Tx.TxHash(), v.Tx.TxHash())
+			require.Equal(t, vectors[i].Flags, v.Flags)
+			require.NoError(t, v.Run())
+		}
+	}
+}
+
+// TestTxTestVectorCapture 确保可以从交易和先前输出获取器捕获向量，并且结果不符时 Run 返回错误。
+func TestTxTestVectorCapture(t *testing.T) {
+	t.Parallel()
+
+	privKey, err := btcec.NewPrivateKey()
+	require.NoError(t, err)
+	addr, err := btcutil.NewAddressPubKeyHash(
+		btcutil.Hash160(privKey.PubKey().SerializeCompressed()),
+		&chaincfg.MainNetParams,
+	)
+	require.NoError(t, err)
+	pkScript, err := PayToAddrScript(addr)
+	require.NoError(t, err)
+
+	tx := createSpendingTx(nil, nil, pkScript, 0)
+	sigScript, err := SignatureScript(tx, 0, pkScript, SigHashAll, privKey,
+		true)
+	require.NoError(t, err)
+	tx.TxIn[0].SignatureScript = sigScript
+
+	fetcher := NewMultiPrevOutFetcher(nil)
+	_, err = NewTxTestVector(tx, fetcher, StandardVerifyFlags, true)
+	require.Error(t, err)
+
+	fetcher.AddPrevOut(tx.TxIn[0].PreviousOutPoint, &wire.TxOut{
+		PkScript: pkScript,
+	})
+	v, err := NewTxTestVector(tx, fetcher, StandardVerifyFlags, true)
+	require.NoError(t, err)
+	require.NoError(t, v.Run())
+
+	v.Valid = false
+	require.ErrorIs(t, v.Run(), ErrTxTestVectorMismatch)
+
+	entry, err := v.MarshalJSON()
+	require.NoError(t, err)
+	parsed, err := ParseTxTestVector(entry, true)
+	require.NoError(t, err)
+	require.Equal(t, StandardVerifyFlags, parsed.Flags)
+	require.NoError(t, parsed.Run())
+
+	_, err = FormatScriptFlags(ScriptFlags(1 << 31))
+	require.Error(t, err)
+	flags, err := FormatScriptFlags(0)
+	require.NoError(t, err)
+	require.Equal(t, "NONE", flags)
+}
+
+// TestScriptFlagsStringRoundTrip 确保每个标志都有名称，并且 String 的输出能被 ParseScriptFlags 解析回相同的标志，
+// 包括 taproot 之后加入的标志和没有名称的标志位。
+func TestScriptFlagsStringRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	var all ScriptFlags
+	for flag := ScriptBip16; flag <= ScriptVerifyCheckSigFromStack; flag <<= 1 {
+		name, err := FormatScriptFlags(flag)
+		require.NoError(t, err, "flag 0x%x has no name", uint32(flag))
+		require.Equal(t, name, flag.String())
+		all |= flag
+	}
+
+	tests := []ScriptFlags{
+		0,
+		all,
+		StandardVerifyFlags,
+		ScriptVerifyTaproot | ScriptVerifyDiscourageOpSuccess,
+		ScriptBip16 | ScriptFlags(1<<31),
+		ScriptFlags(1 << 31),
+	}
+	for _, flags := range tests {
+		str := flags.String()
+		parsed, err := ParseScriptFlags(str)
+		require.NoError(t, err, str)
+		require.Equal(t, flags, parsed, str)
+		require.Equal(t, str, parsed.String())
+	}
+
+	require.Equal(t, "NONE", ScriptFlags(0).String())
+	require.Equal(t, "P2SH,WITNESS,TAPROOT",
+		(ScriptVerifyTaproot | ScriptVerifyWitness | ScriptBip16).String())
+	require.Equal(t, "P2SH,0x80000000",
+		(ScriptBip16 | ScriptFlags(1<<31)).String())
+
+	// 名称的顺序和空白不影响解析结果。
+	flags, err := ParseScriptFlags(" TAPROOT , P2SH,WITNESS ")
+	require.NoError(t, err)
+	require.Equal(t, "P2SH,WITNESS,TAPROOT", flags.String())
+
+	_, err = ParseScriptFlags("P2SH,0xzz")
+	require.ErrorIs(t, err, ErrUnknownScriptFlag)
+	_, err = ParseScriptFlags("BOGUS")
+	require.ErrorIs(t, err, ErrUnknownScriptFlag)
+}
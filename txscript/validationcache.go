@@ -0,0 +1,203 @@
+// 包含脚本验证结果缓存，使交易从内存池进入区块时无需再次执行已经验证通过的脚本。
+
+package txscript
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"sync"
+
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/wire"
+)
+
+// ValidationCacheStats 是 ValidationCache 的命中和淘汰计数快照。
+type ValidationCacheStats struct {
+	// Entries 是缓存中当前的条目数。
+	Entries int
+
+	// Hits 和 Misses 分别是引擎查询缓存时命中和未命中的次数。
+	Hits   uint64
+	Misses uint64
+
+	// Evictions 是为腾出空间或缩小容量而淘汰的条目数。
+	Evictions uint64
+}
+
+// ValidationCache 记录已经完整执行并验证通过的输入脚本，与 Bitcoin Core 的脚本执行缓存类似。
+// 通过 WithValidationCache 传给 NewEngine 后，引擎在 Execute 时先查询缓存，命中则直接返回成功而不执行任何脚本；
+// 执行成功的结果会被加入缓存。 只有成功的结果会被缓存，因此缓存不会把无效的花费误判为有效。
+//
+// 缓存键是对以下内容加盐后的 SHA256 摘要：花费交易的见证哈希（覆盖签名脚本、见证以及交易的其余所有字段）、
+// 输入索引、被花费输出的公钥脚本和金额、脚本版本以及验证标志。 盐在创建缓存时随机生成，
+// 因此攻击者无法预先构造碰撞的键。 标志不同的验证不会共享结果，例如在内存池中使用策略标志验证通过的输入，
+// 在区块中只有使用相同标志验证时才会命中。
+//
+// 缓存满时随机淘汰已有条目。 ValidationCache 是并发安全的。
+type ValidationCache struct {
+	sync.RWMutex
+	salt       [32]byte
+	entries    map[chainhash.Hash]struct{}
+	maxEntries uint
+
+	hits      uint64
+	misses    uint64
+	evictions uint64
+}
+
+// NewValidationCache 返回一个最多保存 maxEntries 个条目的验证结果缓存。 maxEntries 为零时缓存不保存任何条目。
+func NewValidationCache(maxEntries uint) *ValidationCache {
+	c := &ValidationCache{
+		entries:    make(map[chainhash.Hash]struct{}),
+		maxEntries: maxEntries,
+	}
+
+	// A predictable salt only weakens the cache against precomputed
+	// collisions, so a failing random source is not fatal.
+	_, _ = rand.Read(c.salt[:])
+	return c
+}
+
+// Key 返回验证给定输入的缓存键。 tx 的见证哈希每次调用都会重新计算，验证同一交易的多个输入时可以改用 KeyWithHash。
+func (c *ValidationCache) Key(tx *wire.MsgTx, txIdx int, pkScript []byte,
+	amount int64, version uint16, flags ScriptFlags) chainhash.Hash {
+
+	return c.KeyWithHash(tx.WitnessHash(), txIdx, pkScript, amount,
+		version, flags)
+}
+
+// KeyWithHash 与 Key 相同，但使用调用者预先计算的交易见证哈希。
+func (c *ValidationCache) KeyWithHash(wtxid chainhash.Hash, txIdx int,
+	pkScript []byte, amount int64, version uint16,
+	flags ScriptFlags) chainhash.Hash {
+
+	var buf [4 + 8 + 2 + 4]byte
+	binary.LittleEndian.PutUint32(buf[0:4], uint32(txIdx))
+	binary.LittleEndian.PutUint64(buf[4:12], uint64(amount))
+	binary.LittleEndian.PutUint16(buf[12:14], version)
+	binary.LittleEndian.PutUint32(buf[14:18], uint32(flags))
+
+	var scriptLen [binary.MaxVarintLen64]byte
+	n := binary.PutUvarint(scriptLen[:], uint64(len(pkScript)))
+
+	h := sha256.New()
+	h.Write(c.salt[:])
+	h.Write(wtxid[:])
+	h.Write(buf[:])
+	h.Write(scriptLen[:n])
+	h.Write(pkScript)
+
+	var key chainhash.Hash
+	copy(key[:], h.Sum(nil))
+	return key
+}
+
+// Exists 返回缓存中是否存在给定的键，并更新命中计数。
+func (c *ValidationCache) Exists(key chainhash.Hash) bool {
+	c.Lock()
+	defer c.Unlock()
+
+	_, ok := c.entries[key]
+	if ok {
+		c.hits++
+	} else {
+		c.misses++
+	}
+	return ok
+}
+
+// Add 将给定的键加入缓存。 缓存已满时随机淘汰一个已有条目。
+func (c *ValidationCache) Add(key chainhash.Hash) {
+	c.Lock()
+	defer c.Unlock()
+
+	if c.maxEntries == 0 {
+		return
+	}
+	if _, ok := c.entries[key]; ok {
+		return
+	}
+	if uint(len(c.entries)+1) > c.maxEntries {
+		c.evict(uint(len(c.entries)) + 1 - c.maxEntries)
+	}
+	c.entries[key] = struct{}{}
+}
+
+// Remove 从缓存中删除给定的键，例如在区块连接后删除已经不会再次验证的输入。
+func (c *ValidationCache) Remove(key chainhash.Hash) {
+	c.Lock()
+	delete(c.entries, key)
+	c.Unlock()
+}
+
+// Purge 删除缓存中的所有条目。 计数不会被重置。
+func (c *ValidationCache) Purge() {
+	c.Lock()
+	c.evictions += uint64(len(c.entries))
+	c.entries = make(map[chainhash.Hash]struct{})
+	c.Unlock()
+}
+
+// Len 返回缓存中当前的条目数。
+func (c *ValidationCache) Len() int {
+	c.RLock()
+	defer c.RUnlock()
+
+	return len(c.entries)
+}
+
+// MaxEntries 返回缓存的容量。
+func (c *ValidationCache) MaxEntries() uint {
+	c.RLock()
+	defer c.RUnlock()
+
+	return c.maxEntries
+}
+
+// SetMaxEntries 修改缓存的容量，超出新容量的条目会被随机淘汰。
+func (c *ValidationCache) SetMaxEntries(maxEntries uint) {
+	c.Lock()
+	defer c.Unlock()
+
+	c.maxEntries = maxEntries
+	if uint(len(c.entries)) > maxEntries {
+		c.evict(uint(len(c.entries)) - maxEntries)
+	}
+}
+
+// Stats 返回缓存当前的条目数和计数快照。
+func (c *ValidationCache) Stats() ValidationCacheStats {
+	c.RLock()
+	defer c.RUnlock()
+
+	return ValidationCacheStats{
+		Entries:   len(c.entries),
+		Hits:      c.hits,
+		Misses:    c.misses,
+		Evictions: c.evictions,
+	}
+}
+
+// evict 随机删除 n 个条目。 调用者必须持有写锁。
+func (c *ValidationCache) evict(n uint) {
+	// As with SigCache, the random starting point of Go's map iteration
+	// is relied upon to choose the evicted entries. Since keys are salted
+	// an adversary cannot influence which entries are chosen.
+	for key := range c.entries {
+		if n == 0 {
+			break
+		}
+		delete(c.entries, key)
+		c.evictions++
+		n--
+	}
+}
+
+// WithValidationCache 使引擎在执行前查询验证结果缓存，并在执行成功后将结果加入缓存。
+// 缓存命中时 Execute 不执行任何脚本，因此执行统计、覆盖率记录和最终堆栈都不会被更新。
+func WithValidationCache(c *ValidationCache) EngineOpt {
+	return func(cfg *engineConfig) {
+		cfg.validationCache = c
+	}
+}
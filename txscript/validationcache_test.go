@@ -0,0 +1,110 @@
+package txscript
+
+import (
+	"testing"
+
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/stretchr/testify/require"
+)
+
+// TestValidationCache 确保引擎在缓存命中时跳过脚本执行，只缓存成功的结果，并且标志、输入金额或交易不同时不会命中。
+func TestValidationCache(t *testing.T) {
+	t.Parallel()
+
+	cache := NewValidationCache(10)
+	pkScript := mustParseShortForm("2 ADD 3 EQUAL")
+	tx := createSpendingTx(nil, mustParseShortForm("1"), pkScript, 0)
+	const flags = ScriptBip16
+
+	execute := func(flags ScriptFlags, amount int64) (ExecutionStats, error) {
+		var stats ExecutionStats
+		vm, err := NewEngine(pkScript, tx, 0, flags, nil, nil, amount,
+			nil, WithValidationCache(cache), WithExecutionStats(&stats))
+		require.NoError(t, err)
+		return stats, vm.Execute()
+	}
+
+	stats, err := execute(flags, 0)
+	require.NoError(t, err)
+	require.NotZero(t, stats.OpsExecuted)
+	require.Equal(t, 1, cache.Len())
+
+	// 第二次验证命中缓存，不执行任何操作码。
+	stats, err = execute(flags, 0)
+	require.NoError(t, err)
+	require.Zero(t, stats.OpsExecuted)
+
+	// 标志或金额不同的验证需要重新执行。
+	stats, err = execute(flags|ScriptVerifyMinimalData, 0)
+	require.NoError(t, err)
+	require.NotZero(t, stats.OpsExecuted)
+	stats, err = execute(flags, 1)
+	require.NoError(t, err)
+	require.NotZero(t, stats.OpsExecuted)
+	require.Equal(t, 3, cache.Len())
+
+	// 失败的结果不被缓存。
+	tx.TxIn[0].SignatureScript = mustParseShortForm("2")
+	for i := 0; i < 2; i++ {
+		_, err = execute(flags, 0)
+		require.True(t, IsErrorCode(err, ErrEvalFalse))
+	}
+	require.Equal(t, 3, cache.Len())
+
+	require.Equal(t, ValidationCacheStats{
+		Entries: 3,
+		Hits:    1,
+		Misses:  5,
+	}, cache.Stats())
+}
+
+// TestValidationCacheEviction 确保缓存遵守容量限制，并且可以调整容量和清空。
+func TestValidationCacheEviction(t *testing.T) {
+	t.Parallel()
+
+	keys := make([]chainhash.Hash, 8)
+	for i := range keys {
+		keys[i][0] = byte(i)
+	}
+
+	cache := NewValidationCache(5)
+	for _, key := range keys {
+		cache.Add(key)
+	}
+	require.Equal(t, 5, cache.Len())
+	require.EqualValues(t, 5, cache.MaxEntries())
+	require.EqualValues(t, 3, cache.Stats().Evictions)
+
+	// 最后加入的键总是存在。
+	require.True(t, cache.Exists(keys[7]))
+
+	cache.Remove(keys[7])
+	require.False(t, cache.Exists(keys[7]))
+	require.Equal(t, 4, cache.Len())
+
+	cache.SetMaxEntries(2)
+	require.Equal(t, 2, cache.Len())
+	require.EqualValues(t, 5, cache.Stats().Evictions)
+
+	cache.Purge()
+	require.Zero(t, cache.Len())
+
+	// 容量为零的缓存不保存任何条目。
+	cache.SetMaxEntries(0)
+	cache.Add(keys[0])
+	require.Zero(t, cache.Len())
+
+	// 不同缓存使用不同的盐，因此相同输入的键不同。
+	pkScript := mustParseShortForm("1")
+	tx := createSpendingTx(nil, nil, pkScript, 0)
+	other := NewValidationCache(1)
+	require.Equal(t,
+		cache.Key(tx, 0, pkScript, 0, 0, 0),
+		cache.KeyWithHash(tx.WitnessHash(), 0, pkScript, 0, 0, 0))
+	require.NotEqual(t,
+		cache.Key(tx, 0, pkScript, 0, 0, 0),
+		other.Key(tx, 0, pkScript, 0, 0, 0))
+	require.NotEqual(t,
+		cache.Key(tx, 0, pkScript, 0, 0, 0),
+		cache.Key(tx, 0, pkScript, 0, 0, ScriptBip16))
+}
@@ -0,0 +1,259 @@
+// 包含候选交易的脚本验证成本估计，根据签名操作数、签名哈希需要哈希的字节数和签名缓存的命中可能性估计每个交易的验证开销，
+// 使矿工可以安排验证顺序并限制组装区块模板的延迟。
+
+package txscript
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/wire"
+)
+
+const (
+	// witnessV0SigMsgBaseSize 是 BIP0143 签名消息除脚本代码之外的字节数。
+	witnessV0SigMsgBaseSize = 4 + 32 + 32 + 36 + 8 + 4 + 32 + 4 + 4
+
+	// maxTaprootSigMsgSize 是 taproot 签名哈希最多哈希的字节数，包括签名哈希纪元、BIP 341 签名消息和 BIP 342 扩展。
+	maxTaprootSigMsgSize = 1 + 206 + 37
+
+	// sigCacheLookupBytes 是签名缓存查找时大约哈希的字节数，即签名哈希、签名和公钥。
+	sigCacheLookupBytes = chainhash.HashSize + 1 + 73 + 33
+)
+
+// TxValidationCost 是一个交易的脚本验证成本估计。
+type TxValidationCost struct {
+	// Index 是交易在传入的候选交易中的索引。
+	Index int
+
+	// Hash 是交易的哈希。
+	Hash chainhash.Hash
+
+	// SigOps 是验证交易最多需要的签名验证次数。
+	SigOps int
+
+	// CachedSigOps 是预计会命中签名缓存的签名验证次数。
+	CachedSigOps int
+
+	// HashBytes 是计算签名哈希需要哈希的字节数。 传统输入的签名哈希需要哈希整个交易，因此它随签名操作数和交易大小的乘积增长。
+	HashBytes uint64
+
+	// ScriptCost 是按成本模型执行交易所有脚本的最大成本，包括每次签名验证的成本。
+	ScriptCost uint64
+
+	// Cost 是交易的总估计成本：ScriptCost 加上签名哈希的哈希成本，再减去命中签名缓存节省的签名验证成本。
+	Cost uint64
+}
+
+// ValidationCostEstimator 估计候选交易的脚本验证成本。
+type ValidationCostEstimator struct {
+	// Model 是估计使用的成本模型。
+	Model CostModel
+
+	// PrevOuts 提供候选交易花费的输出。
+	PrevOuts PrevOutputFetcher
+
+	// Seen 返回交易是否已经被验证过，例如已被内存池接受。 已验证交易的有效签名已被加入签名缓存，
+	// 因此其签名验证预计会命中缓存，只需计算签名哈希和查找缓存。 可以为 nil，此时所有签名验证都按未命中估计。
+	Seen func(txHash chainhash.Hash) bool
+}
+
+// NewValidationCostEstimator 返回使用 DefaultCostModel 的估计器。
+func NewValidationCostEstimator(prevOuts PrevOutputFetcher) *ValidationCostEstimator {
+	return &ValidationCostEstimator{
+		Model:    DefaultCostModel(),
+		PrevOuts: prevOuts,
+	}
+}
+
+// scoredScript 是输入执行的一个脚本及其初始堆栈和签名版本。
+type scoredScript struct {
+	script     []byte
+	stack      [][]byte
+	sigVersion SigVersion
+}
+
+// EstimateTx 返回交易的验证成本估计，Index 为 0。 估计按输入花费的脚本类型找出实际执行的脚本：签名脚本、公钥脚本、
+// P2SH 兑换脚本、P2WSH 见证脚本和 tapscript 叶子，与 CostModel.ScoreScript 一样是上界。 前一输出无法获取时返回错误。
+func (e *ValidationCostEstimator) EstimateTx(tx *wire.MsgTx) (TxValidationCost,
+	error) {
+
+	cost := TxValidationCost{Hash: tx.TxHash()}
+
+	// Coinbase transactions don't execute any scripts.
+	if len(tx.TxIn) == 1 &&
+		tx.TxIn[0].PreviousOutPoint.Index == wire.MaxPrevOutIndex &&
+		tx.TxIn[0].PreviousOutPoint.Hash == (chainhash.Hash{}) {
+
+		return cost, nil
+	}
+
+	var legacySigOps, taprootSigOps int
+	var v0SigMsgBytes uint64
+	for idx, txIn := range tx.TxIn {
+		prevOut := e.PrevOuts.FetchPrevOutput(txIn.PreviousOutPoint)
+		if prevOut == nil {
+			return cost, fmt.Errorf("input %d: previous output %v not "+
+				"found", idx, txIn.PreviousOutPoint)
+		}
+
+		scripts, keySpend := inputScripts(txIn, prevOut.PkScript)
+		if keySpend {
+			taprootSigOps++
+			cost.SigOps++
+			cost.ScriptCost += e.Model.SigOpCost
+		}
+		for _, s := range scripts {
+			scriptCost, err := e.Model.ScoreScript(s.script, s.stack)
+			if err != nil {
+				return cost, fmt.Errorf("input %d: %w", idx, err)
+			}
+			cost.ScriptCost += scriptCost
+
+			// The sigops of unparsable scripts were already reported
+			// by ScoreScript above.
+			counts, _ := CountSigOpsDetailed(s.script, 0)
+			switch s.sigVersion {
+			case SigVersionTapscript:
+				taprootSigOps += counts.Tapscript()
+				cost.SigOps += counts.Tapscript()
+
+			case SigVersionWitnessV0:
+				sigOps := counts.Precise()
+				v0SigMsgBytes += uint64(sigOps) * uint64(
+					witnessV0SigMsgBaseSize+
+						wire.VarIntSerializeSize(uint64(len(s.script)))+
+						len(s.script))
+				cost.SigOps += sigOps
+
+			default:
+				legacySigOps += counts.Precise()
+				cost.SigOps += counts.Precise()
+			}
+		}
+	}
+
+	// Legacy signature hashes serialize the whole transaction, while the
+	// segwit ones hash midstates computed once per transaction and a
+	// bounded message per signature.
+	strippedSize := uint64(tx.SerializeSizeStripped())
+	cost.HashBytes = uint64(legacySigOps)*strippedSize + v0SigMsgBytes +
+		uint64(taprootSigOps)*maxTaprootSigMsgSize
+	if v0SigMsgBytes != 0 || taprootSigOps != 0 {
+		cost.HashBytes += strippedSize
+	}
+
+	if e.Seen != nil && e.Seen(cost.Hash) {
+		cost.CachedSigOps = cost.SigOps
+	}
+
+	cost.Cost = cost.ScriptCost + e.Model.HashByteCost*cost.HashBytes
+	lookupCost := e.Model.HashByteCost * sigCacheLookupBytes
+	if e.Model.SigOpCost > lookupCost {
+		cost.Cost -= uint64(cost.CachedSigOps) *
+			(e.Model.SigOpCost - lookupCost)
+	}
+
+	return cost, nil
+}
+
+// inputScripts 返回花费 pkScript 的输入执行的脚本及其初始堆栈，第二个返回值表示输入是否是 taproot 密钥路径花费。
+func inputScripts(txIn *wire.TxIn, pkScript []byte) ([]scoredScript, bool) {
+	sigPushes, _ := PushedData(txIn.SignatureScript)
+	var scripts []scoredScript
+	if len(txIn.SignatureScript) != 0 {
+		scripts = append(scripts, scoredScript{
+			script: txIn.SignatureScript,
+		})
+	}
+	if len(pkScript) != 0 {
+		scripts = append(scripts, scoredScript{
+			script: pkScript,
+			stack:  sigPushes,
+		})
+	}
+
+	// The redeem script of a P2SH output is executed as well, unless it
+	// is a nested witness program.
+	program := pkScript
+	if isScriptHashScript(pkScript) && len(sigPushes) != 0 {
+		redeemScript := sigPushes[len(sigPushes)-1]
+		if !isWitnessProgramScript(redeemScript) {
+			return append(scripts, scoredScript{
+				script: redeemScript,
+				stack:  sigPushes[:len(sigPushes)-1],
+			}), false
+		}
+		program = redeemScript
+	}
+
+	witness := txIn.Witness
+	switch {
+	case isWitnessPubKeyHashScript(program):
+		// The implied P2PKH script is executed with the witness stack.
+		p2pkh, err := payToPubKeyHashScript(extractWitnessPubKeyHash(program))
+		if err == nil {
+			scripts = append(scripts, scoredScript{
+				script:     p2pkh,
+				stack:      witness,
+				sigVersion: SigVersionWitnessV0,
+			})
+		}
+
+	case isWitnessScriptHashScript(program) && len(witness) != 0:
+		scripts = append(scripts, scoredScript{
+			script:     witness[len(witness)-1],
+			stack:      witness[:len(witness)-1],
+			sigVersion: SigVersionWitnessV0,
+		})
+
+	case isWitnessTaprootScript(program):
+		if isAnnexedWitness(witness) {
+			witness = witness[:len(witness)-1]
+		}
+		if len(witness) <= 1 {
+			return scripts, true
+		}
+		scripts = append(scripts, scoredScript{
+			script:     witness[len(witness)-2],
+			stack:      witness[:len(witness)-2],
+			sigVersion: SigVersionTapscript,
+		})
+	}
+
+	return scripts, false
+}
+
+// ScheduleValidation 估计 txs 中每个交易的验证成本，并按成本从低到高排序，成本相同时保持原有顺序，
+// 使在延迟预算内可以验证尽可能多的交易。 budget 不为 0 时，累计成本超过 budget 的交易被放入第二个返回值，留待之后验证或从模板中排除。
+//
+// 脚本验证不依赖交易之间的顺序，但花费候选交易输出的交易要求 PrevOuts 能够提供这些输出。
+func (e *ValidationCostEstimator) ScheduleValidation(txs []*wire.MsgTx,
+	budget uint64) ([]TxValidationCost, []TxValidationCost, error) {
+
+	costs := make([]TxValidationCost, len(txs))
+	for i, tx := range txs {
+		cost, err := e.EstimateTx(tx)
+		if err != nil {
+			return nil, nil, fmt.Errorf("tx %v: %w", tx.TxHash(), err)
+		}
+		cost.Index = i
+		costs[i] = cost
+	}
+	sort.SliceStable(costs, func(i, j int) bool {
+		return costs[i].Cost < costs[j].Cost
+	})
+
+	if budget == 0 {
+		return costs, nil, nil
+	}
+	var total uint64
+	for i, cost := range costs {
+		total += cost.Cost
+		if total > budget {
+			return costs[:i], costs[i:], nil
+		}
+	}
+	return costs, nil, nil
+}
@@ -0,0 +1,142 @@
+package txscript
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcec/v2/schnorr"
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/stretchr/testify/require"
+)
+
+// TestValidationCostEstimator 确保验证成本估计计入每种输入执行的签名操作和签名哈希的哈希字节，命中签名缓存的交易成本更低，
+// 并且调度按成本排序并遵守预算。
+func TestValidationCostEstimator(t *testing.T) {
+	t.Parallel()
+
+	var addrKeys []*btcutil.AddressPubKey
+	for i := 0; i < 3; i++ {
+		priv, err := btcec.NewPrivateKey()
+		require.NoError(t, err)
+		addrKey, err := btcutil.NewAddressPubKey(
+			priv.PubKey().SerializeCompressed(), &chaincfg.MainNetParams,
+		)
+		require.NoError(t, err)
+		addrKeys = append(addrKeys, addrKey)
+	}
+	pubKey := addrKeys[0].ScriptAddress()
+	sig := bytes.Repeat([]byte{0x30}, 71)
+
+	p2pkh, err := payToPubKeyHashScript(hash160(pubKey))
+	require.NoError(t, err)
+	p2wpkh, err := payToWitnessPubKeyHashScript(hash160(pubKey))
+	require.NoError(t, err)
+	multiSig, err := MultiSigScript(addrKeys, 2)
+	require.NoError(t, err)
+	p2sh, err := payToScriptHashScript(hash160(multiSig))
+	require.NoError(t, err)
+	p2tr, err := payToWitnessTaprootScript(schnorr.SerializePubKey(
+		addrKeys[0].PubKey()))
+	require.NoError(t, err)
+
+	prevOuts := make(map[wire.OutPoint]*wire.TxOut)
+	newTx := func(pkScript []byte, sigScript []byte,
+		witness wire.TxWitness) *wire.MsgTx {
+
+		outPoint := wire.OutPoint{Index: uint32(len(prevOuts))}
+		prevOuts[outPoint] = wire.NewTxOut(1000, pkScript)
+		tx := wire.NewMsgTx(2)
+		tx.AddTxIn(&wire.TxIn{
+			PreviousOutPoint: outPoint,
+			SignatureScript:  sigScript,
+			Witness:          witness,
+		})
+		tx.AddTxOut(wire.NewTxOut(900, p2wpkh))
+		return tx
+	}
+
+	p2pkhSigScript, err := NewScriptBuilder().AddData(sig).AddData(pubKey).
+		Script()
+	require.NoError(t, err)
+	p2shSigScript, err := NewScriptBuilder().AddOp(OP_0).AddData(sig).
+		AddData(sig).AddData(multiSig).Script()
+	require.NoError(t, err)
+
+	legacyTx := newTx(p2pkh, p2pkhSigScript, nil)
+	p2shTx := newTx(p2sh, p2shSigScript, nil)
+	p2wpkhTx := newTx(p2wpkh, nil, wire.TxWitness{sig, pubKey})
+	p2trTx := newTx(p2tr, nil, wire.TxWitness{sig[:64]})
+
+	estimator := NewValidationCostEstimator(NewMultiPrevOutFetcher(prevOuts))
+	estimate := func(tx *wire.MsgTx) TxValidationCost {
+		cost, err := estimator.EstimateTx(tx)
+		require.NoError(t, err)
+		require.Equal(t, tx.TxHash(), cost.Hash)
+		return cost
+	}
+
+	legacy := estimate(legacyTx)
+	require.Equal(t, 1, legacy.SigOps)
+	require.Equal(t, uint64(legacyTx.SerializeSizeStripped()),
+		legacy.HashBytes)
+
+	// 多重签名兑换脚本最多需要验证其中每个公钥。
+	multi := estimate(p2shTx)
+	require.Equal(t, 3, multi.SigOps)
+	require.Equal(t, 3*uint64(p2shTx.SerializeSizeStripped()),
+		multi.HashBytes)
+	require.Greater(t, multi.Cost, legacy.Cost)
+
+	segwit := estimate(p2wpkhTx)
+	require.Equal(t, 1, segwit.SigOps)
+	require.NotZero(t, segwit.HashBytes)
+
+	taproot := estimate(p2trTx)
+	require.Equal(t, 1, taproot.SigOps)
+	require.Equal(t, uint64(p2trTx.SerializeSizeStripped())+
+		maxTaprootSigMsgSize, taproot.HashBytes)
+
+	// 已验证交易的签名预计命中缓存。
+	estimator.Seen = func(hash chainhash.Hash) bool {
+		return hash == p2shTx.TxHash()
+	}
+	cached := estimate(p2shTx)
+	require.Equal(t, 3, cached.CachedSigOps)
+	require.Less(t, cached.Cost, multi.Cost)
+	estimator.Seen = nil
+
+	// 币基交易不执行脚本。
+	coinbase := wire.NewMsgTx(1)
+	coinbase.AddTxIn(&wire.TxIn{
+		PreviousOutPoint: wire.OutPoint{Index: wire.MaxPrevOutIndex},
+	})
+	require.Zero(t, estimate(coinbase).Cost)
+
+	// 调度按成本从低到高排序。
+	txs := []*wire.MsgTx{p2shTx, legacyTx, p2trTx, p2wpkhTx}
+	scheduled, deferred, err := estimator.ScheduleValidation(txs, 0)
+	require.NoError(t, err)
+	require.Empty(t, deferred)
+	require.Len(t, scheduled, len(txs))
+	for i := 1; i < len(scheduled); i++ {
+		require.LessOrEqual(t, scheduled[i-1].Cost, scheduled[i].Cost)
+	}
+	require.Equal(t, 0, scheduled[len(scheduled)-1].Index)
+
+	// 超出预算的交易被推迟。
+	budget := scheduled[0].Cost + scheduled[1].Cost
+	scheduled, deferred, err = estimator.ScheduleValidation(txs, budget)
+	require.NoError(t, err)
+	require.Len(t, scheduled, 2)
+	require.Len(t, deferred, 2)
+
+	// 无法获取前一输出时返回错误。
+	missing := wire.NewMsgTx(2)
+	missing.AddTxIn(&wire.TxIn{PreviousOutPoint: wire.OutPoint{Index: 99}})
+	_, _, err = estimator.ScheduleValidation([]*wire.MsgTx{missing}, 0)
+	require.Error(t, err)
+}
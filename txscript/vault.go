@@ -0,0 +1,333 @@
+// 包含构建、识别和签名保险库（vault）脚本的函数。 保险库输出可以由恢复密钥立即花费，
+// 或由热密钥在相对锁定时间（CHECKSEQUENCEVERIFY）到期后花费，使所有者能够在热密钥泄露后的延迟期内转移资金。
+
+package txscript
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcec/v2/schnorr"
+	"github.com/btcsuite/btcd/wire"
+)
+
+// ErrInvalidVault 在保险库参数、脚本或花费交易不符合要求时返回。
+var ErrInvalidVault = errors.New("invalid vault")
+
+// VaultPath 表示保险库输出的花费路径。
+type VaultPath uint8
+
+const (
+	// VaultPathUnknown 表示脚本不是保险库脚本或叶子。
+	VaultPathUnknown VaultPath = iota
+
+	// VaultPathRecovery 表示由恢复密钥立即花费的路径。
+	VaultPathRecovery
+
+	// VaultPathHot 表示延迟到期后由热密钥花费的路径。
+	VaultPathHot
+)
+
+// String 返回花费路径的可读名称。
+func (p VaultPath) String() string {
+	switch p {
+	case VaultPathRecovery:
+		return "recovery"
+	case VaultPathHot:
+		return "hot"
+	default:
+		return "unknown"
+	}
+}
+
+// VaultTerms 是从保险库脚本中解析出的参数。
+type VaultTerms struct {
+	// RecoveryKey 是可以立即花费输出的恢复公钥。
+	RecoveryKey *btcec.PublicKey
+
+	// HotKey 是延迟到期后可以花费输出的热公钥。
+	HotKey *btcec.PublicKey
+
+	// Delay 是热密钥路径的相对锁定时间，按 BIP0068 编码，即可以直接作为输入序列号使用。
+	Delay uint32
+}
+
+// checkVaultDelay 确保延迟是有效的 BIP0068 相对锁定时间并且不为零。
+func checkVaultDelay(delay uint32) error {
+	const allowed = wire.SequenceLockTimeIsSeconds | wire.SequenceLockTimeMask
+	if delay&^allowed != 0 || delay&wire.SequenceLockTimeMask == 0 {
+		return fmt.Errorf("%w: delay %#x is not a non-zero relative "+
+			"lock time", ErrInvalidVault, delay)
+	}
+	return nil
+}
+
+// parseVaultDelay 解析脚本中推送的延迟，推送不是有效的相对锁定时间时返回 false。
+func parseVaultDelay(op byte, data []byte) (uint32, bool) {
+	var delay int64
+	switch {
+	case op >= OP_1 && op <= OP_16:
+		delay = int64(AsSmallInt(op))
+
+	case data != nil && isCanonicalPush(op, data):
+		// CHECKSEQUENCEVERIFY accepts the same 5-byte numbers as
+		// CHECKLOCKTIMEVERIFY.
+		num, err := MakeScriptNum(data, true, cltvMaxScriptNumLen)
+		if err != nil || num < 0 || num > 1<<32-1 {
+			return 0, false
+		}
+		delay = int64(num)
+
+	default:
+		return 0, false
+	}
+
+	if checkVaultDelay(uint32(delay)) != nil {
+		return 0, false
+	}
+	return uint32(delay), true
+}
+
+// BuildVaultScript 返回一个保险库见证脚本：
+//
+//	IF
+//	 <recoveryKey>
+//	ELSE
+//	 <delay> CHECKSEQUENCEVERIFY DROP <hotKey>
+//	ENDIF
+//	CHECKSIG
+//
+// delay 按 BIP0068 编码，可以是区块数或设置了 wire.SequenceLockTimeIsSeconds 的 512 秒间隔数。
+// 返回的脚本应当作为 P2WSH 的见证脚本使用。
+func BuildVaultScript(recoveryKey, hotKey *btcec.PublicKey,
+	delay uint32) ([]byte, error) {
+
+	if err := checkVaultDelay(delay); err != nil {
+		return nil, err
+	}
+
+	return NewScriptBuilder().
+		AddOp(OP_IF).
+		AddData(recoveryKey.SerializeCompressed()).
+		AddOp(OP_ELSE).
+		AddInt64(int64(delay)).AddOp(OP_CHECKSEQUENCEVERIFY).AddOp(OP_DROP).
+		AddData(hotKey.SerializeCompressed()).
+		AddOp(OP_ENDIF).
+		AddOp(OP_CHECKSIG).
+		Script()
+}
+
+// ParseVaultScript 解析 BuildVaultScript 生成的保险库见证脚本。 脚本不是保险库脚本时返回 ErrInvalidVault。
+func ParseVaultScript(script []byte) (*VaultTerms, error) {
+	const scriptVersion = 0
+	var ops []byte
+	var datas [][]byte
+	tokenizer := MakeScriptTokenizer(scriptVersion, script)
+	for tokenizer.Next() {
+		ops = append(ops, tokenizer.Opcode())
+		datas = append(datas, tokenizer.Data())
+	}
+
+	notVault := fmt.Errorf("%w: script is not a vault script",
+		ErrInvalidVault)
+	if tokenizer.Err() != nil || len(ops) != 9 ||
+		!bytes.Equal(ops[:3], []byte{OP_IF, OP_DATA_33, OP_ELSE}) ||
+		!bytes.Equal(ops[4:], []byte{OP_CHECKSEQUENCEVERIFY, OP_DROP,
+			OP_DATA_33, OP_ENDIF, OP_CHECKSIG}) {
+
+		return nil, notVault
+	}
+
+	delay, ok := parseVaultDelay(ops[3], datas[3])
+	if !ok {
+		return nil, notVault
+	}
+	recoveryKey, err := btcec.ParsePubKey(datas[1])
+	if err != nil {
+		return nil, fmt.Errorf("%w: recovery key: %v", ErrInvalidVault, err)
+	}
+	hotKey, err := btcec.ParsePubKey(datas[6])
+	if err != nil {
+		return nil, fmt.Errorf("%w: hot key: %v", ErrInvalidVault, err)
+	}
+
+	return &VaultTerms{
+		RecoveryKey: recoveryKey,
+		HotKey:      hotKey,
+		Delay:       delay,
+	}, nil
+}
+
+// IsVaultScript 返回传入的脚本是否是 BuildVaultScript 生成的保险库见证脚本。
+func IsVaultScript(script []byte) bool {
+	_, err := ParseVaultScript(script)
+	return err == nil
+}
+
+// BuildTapscriptVaultLeaves 返回保险库的两个 tapscript 叶子：
+//
+//	recovery: <recoveryKey> CHECKSIG
+//	hot:      <delay> CHECKSEQUENCEVERIFY DROP <hotKey> CHECKSIG
+//
+// 两个叶子通常与一个不可花费的内部密钥一起组装成 taproot 输出。
+func BuildTapscriptVaultLeaves(recoveryKey, hotKey *btcec.PublicKey,
+	delay uint32) (TapLeaf, TapLeaf, error) {
+
+	if err := checkVaultDelay(delay); err != nil {
+		return TapLeaf{}, TapLeaf{}, err
+	}
+
+	recovery, err := NewScriptBuilder().
+		AddData(schnorr.SerializePubKey(recoveryKey)).AddOp(OP_CHECKSIG).
+		Script()
+	if err != nil {
+		return TapLeaf{}, TapLeaf{}, err
+	}
+
+	hot, err := NewScriptBuilder().
+		AddInt64(int64(delay)).AddOp(OP_CHECKSEQUENCEVERIFY).AddOp(OP_DROP).
+		AddData(schnorr.SerializePubKey(hotKey)).AddOp(OP_CHECKSIG).
+		Script()
+	if err != nil {
+		return TapLeaf{}, TapLeaf{}, err
+	}
+
+	return NewBaseTapLeaf(recovery), NewBaseTapLeaf(hot), nil
+}
+
+// ClassifyTapscriptVaultLeaf 返回传入的 tapscript 叶子脚本属于哪条保险库花费路径，对于热密钥路径还返回其延迟。
+// 恢复叶子与普通的单密钥叶子形式相同，因此任何 "<key> CHECKSIG" 叶子都被识别为恢复路径。
+func ClassifyTapscriptVaultLeaf(script []byte) (VaultPath, uint32) {
+	const scriptVersion = 0
+	var ops []byte
+	var datas [][]byte
+	tokenizer := MakeScriptTokenizer(scriptVersion, script)
+	for tokenizer.Next() {
+		ops = append(ops, tokenizer.Opcode())
+		datas = append(datas, tokenizer.Data())
+	}
+	if tokenizer.Err() != nil {
+		return VaultPathUnknown, 0
+	}
+
+	if bytes.Equal(ops, []byte{OP_DATA_32, OP_CHECKSIG}) {
+		return VaultPathRecovery, 0
+	}
+
+	if len(ops) == 5 && ops[1] == OP_CHECKSEQUENCEVERIFY &&
+		ops[2] == OP_DROP && ops[3] == OP_DATA_32 &&
+		ops[4] == OP_CHECKSIG {
+
+		if delay, ok := parseVaultDelay(ops[0], datas[0]); ok {
+			return VaultPathHot, delay
+		}
+	}
+
+	return VaultPathUnknown, 0
+}
+
+// checkVaultSequence 确保花费交易的输入满足热密钥路径的相对锁定时间，否则 CHECKSEQUENCEVERIFY 必然失败。
+func checkVaultSequence(tx *wire.MsgTx, idx int, delay uint32) error {
+	if tx.Version < 2 {
+		return fmt.Errorf("%w: transaction version %d does not enable "+
+			"relative lock times", ErrInvalidVault, tx.Version)
+	}
+
+	const typeMask = wire.SequenceLockTimeIsSeconds
+	sequence := tx.TxIn[idx].Sequence
+	if sequence&wire.SequenceLockTimeDisabled != 0 ||
+		sequence&typeMask != delay&typeMask ||
+		sequence&wire.SequenceLockTimeMask < delay&wire.SequenceLockTimeMask {
+
+		return fmt.Errorf("%w: input sequence %#x does not satisfy "+
+			"delay %#x", ErrInvalidVault, sequence, delay)
+	}
+	return nil
+}
+
+// checkVaultSignIndex 确保输入索引有效。
+func checkVaultSignIndex(tx *wire.MsgTx, idx int) error {
+	if idx < 0 || idx >= len(tx.TxIn) {
+		return fmt.Errorf("%w: input index %d out of range",
+			ErrInvalidVault, idx)
+	}
+	return nil
+}
+
+// SignVaultSpend 使用 privKey 签名花费 P2WSH 保险库输出的输入，并返回完整的见证。
+// privKey 必须是所选路径对应的密钥；对于热密钥路径，交易版本和输入序列号必须已经满足延迟，否则返回 ErrInvalidVault。
+func SignVaultSpend(tx *wire.MsgTx, sigHashes *TxSigHashes, idx int,
+	amt int64, witnessScript []byte, path VaultPath, hashType SigHashType,
+	privKey *btcec.PrivateKey) (wire.TxWitness, error) {
+
+	if err := checkVaultSignIndex(tx, idx); err != nil {
+		return nil, err
+	}
+	terms, err := ParseVaultScript(witnessScript)
+	if err != nil {
+		return nil, err
+	}
+
+	var pathKey *btcec.PublicKey
+	var selector []byte
+	switch path {
+	case VaultPathRecovery:
+		pathKey, selector = terms.RecoveryKey, []byte{1}
+
+	case VaultPathHot:
+		if err := checkVaultSequence(tx, idx, terms.Delay); err != nil {
+			return nil, err
+		}
+		pathKey = terms.HotKey
+
+	default:
+		return nil, fmt.Errorf("%w: unknown spend path %v",
+			ErrInvalidVault, path)
+	}
+	if !privKey.PubKey().IsEqual(pathKey) {
+		return nil, fmt.Errorf("%w: key does not match %v path",
+			ErrInvalidVault, path)
+	}
+
+	sig, err := RawTxInWitnessSignature(tx, sigHashes, idx, amt,
+		witnessScript, hashType, privKey)
+	if err != nil {
+		return nil, err
+	}
+	return wire.TxWitness{sig, selector, witnessScript}, nil
+}
+
+// SignTapscriptVaultSpend 使用 privKey 签名通过保险库叶子花费 taproot 输出的输入，并返回完整的见证。
+// controlBlock 是该叶子的序列化控制块。 对于热密钥叶子，交易版本和输入序列号必须已经满足延迟，否则返回 ErrInvalidVault。
+func SignTapscriptVaultSpend(tx *wire.MsgTx, sigHashes *TxSigHashes, idx int,
+	amt int64, pkScript []byte, leaf TapLeaf, controlBlock []byte,
+	hashType SigHashType, privKey *btcec.PrivateKey,
+	opts ...TaprootSignOption) (wire.TxWitness, error) {
+
+	if err := checkVaultSignIndex(tx, idx); err != nil {
+		return nil, err
+	}
+
+	path, delay := ClassifyTapscriptVaultLeaf(leaf.Script)
+	switch path {
+	case VaultPathRecovery:
+
+	case VaultPathHot:
+		if err := checkVaultSequence(tx, idx, delay); err != nil {
+			return nil, err
+		}
+
+	default:
+		return nil, fmt.Errorf("%w: leaf is not a vault leaf",
+			ErrInvalidVault)
+	}
+
+	sig, err := RawTxInTapscriptSignature(tx, sigHashes, idx, amt, pkScript,
+		leaf, hashType, privKey, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return wire.TxWitness{sig, leaf.Script, controlBlock}, nil
+}
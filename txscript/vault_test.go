@@ -0,0 +1,168 @@
+package txscript
+
+import (
+	"crypto/sha256"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/stretchr/testify/require"
+)
+
+// vaultSpendingTx 返回一个花费单个输出的版本 2 交易，输入序列号设置为 sequence。
+func vaultSpendingTx(sequence uint32) *wire.MsgTx {
+	tx := wire.NewMsgTx(2)
+	tx.AddTxIn(&wire.TxIn{
+		PreviousOutPoint: wire.OutPoint{Index: 0},
+		Sequence:         sequence,
+	})
+	tx.AddTxOut(wire.NewTxOut(1e8-1000, nil))
+	return tx
+}
+
+// TestVaultScriptP2WSH 确保 P2WSH 保险库可以由恢复密钥立即花费，由热密钥在延迟到期后花费，并且签名助手拒绝无法通过验证的花费。
+func TestVaultScriptP2WSH(t *testing.T) {
+	t.Parallel()
+
+	const delay = 144
+	recoveryKey, err := btcec.NewPrivateKey()
+	require.NoError(t, err)
+	hotKey, err := btcec.NewPrivateKey()
+	require.NoError(t, err)
+
+	script, err := BuildVaultScript(recoveryKey.PubKey(), hotKey.PubKey(),
+		delay)
+	require.NoError(t, err)
+	terms, err := ParseVaultScript(script)
+	require.NoError(t, err)
+	require.True(t, terms.RecoveryKey.IsEqual(recoveryKey.PubKey()))
+	require.True(t, terms.HotKey.IsEqual(hotKey.PubKey()))
+	require.EqualValues(t, delay, terms.Delay)
+	require.False(t, IsVaultScript(script[1:]))
+
+	scriptHash := sha256.Sum256(script)
+	pkScript, err := payToWitnessScriptHashScript(scriptHash[:])
+	require.NoError(t, err)
+	const amt = 1e8
+	prevFetcher := NewCannedPrevOutputFetcher(pkScript, amt)
+
+	spend := func(tx *wire.MsgTx, path VaultPath,
+		key *btcec.PrivateKey) error {
+
+		sigHashes := NewTxSigHashes(tx, prevFetcher)
+		witness, err := SignVaultSpend(tx, sigHashes, 0, amt, script, path,
+			SigHashAll, key)
+		if err != nil {
+			return err
+		}
+		tx.TxIn[0].Witness = witness
+		vm, err := NewEngine(pkScript, tx, 0, StandardVerifyFlags, nil,
+			sigHashes, amt, prevFetcher)
+		require.NoError(t, err)
+		return vm.Execute()
+	}
+
+	require.NoError(t, spend(vaultSpendingTx(0), VaultPathRecovery,
+		recoveryKey))
+	require.NoError(t, spend(vaultSpendingTx(delay), VaultPathHot, hotKey))
+
+	// 延迟未到期、密钥与路径不符或交易版本不支持相对锁定时间时，签名助手拒绝签名。
+	err = spend(vaultSpendingTx(delay-1), VaultPathHot, hotKey)
+	require.ErrorIs(t, err, ErrInvalidVault)
+	err = spend(vaultSpendingTx(delay), VaultPathHot, recoveryKey)
+	require.ErrorIs(t, err, ErrInvalidVault)
+	err = spend(vaultSpendingTx(delay|wire.SequenceLockTimeIsSeconds),
+		VaultPathHot, hotKey)
+	require.ErrorIs(t, err, ErrInvalidVault)
+	tx := vaultSpendingTx(delay)
+	tx.Version = 1
+	require.ErrorIs(t, spend(tx, VaultPathHot, hotKey), ErrInvalidVault)
+
+	// 绕过签名助手的过早花费被引擎拒绝。
+	tx = vaultSpendingTx(delay)
+	sigHashes := NewTxSigHashes(tx, prevFetcher)
+	witness, err := SignVaultSpend(tx, sigHashes, 0, amt, script,
+		VaultPathHot, SigHashAll, hotKey)
+	require.NoError(t, err)
+	tx.TxIn[0].Sequence = delay - 1
+	tx.TxIn[0].Witness = witness
+	vm, err := NewEngine(pkScript, tx, 0, StandardVerifyFlags, nil,
+		NewTxSigHashes(tx, prevFetcher), amt, prevFetcher)
+	require.NoError(t, err)
+	require.True(t, IsErrorCode(vm.Execute(), ErrUnsatisfiedLockTime))
+
+	_, err = BuildVaultScript(recoveryKey.PubKey(), hotKey.PubKey(), 0)
+	require.ErrorIs(t, err, ErrInvalidVault)
+	_, err = BuildVaultScript(recoveryKey.PubKey(), hotKey.PubKey(),
+		wire.SequenceLockTimeDisabled|1)
+	require.ErrorIs(t, err, ErrInvalidVault)
+}
+
+// TestVaultTapscript 确保 tapscript 保险库叶子能够被识别，并且两条花费路径都能通过验证。
+func TestVaultTapscript(t *testing.T) {
+	t.Parallel()
+
+	const delay = wire.SequenceLockTimeIsSeconds | 3
+	recoveryKey, err := btcec.NewPrivateKey()
+	require.NoError(t, err)
+	hotKey, err := btcec.NewPrivateKey()
+	require.NoError(t, err)
+	internalKey, err := btcec.NewPrivateKey()
+	require.NoError(t, err)
+
+	recoveryLeaf, hotLeaf, err := BuildTapscriptVaultLeaves(
+		recoveryKey.PubKey(), hotKey.PubKey(), delay,
+	)
+	require.NoError(t, err)
+	path, _ := ClassifyTapscriptVaultLeaf(recoveryLeaf.Script)
+	require.Equal(t, VaultPathRecovery, path)
+	path, leafDelay := ClassifyTapscriptVaultLeaf(hotLeaf.Script)
+	require.Equal(t, VaultPathHot, path)
+	require.EqualValues(t, delay, leafDelay)
+	path, _ = ClassifyTapscriptVaultLeaf([]byte{OP_TRUE})
+	require.Equal(t, VaultPathUnknown, path)
+
+	tree := AssembleTaprootScriptTree(recoveryLeaf, hotLeaf)
+	rootHash := tree.RootNode.TapHash()
+	outputKey := ComputeTaprootOutputKey(internalKey.PubKey(), rootHash[:])
+	pkScript, err := PayToTaprootScript(outputKey)
+	require.NoError(t, err)
+	const amt = 1e8
+	prevFetcher := NewCannedPrevOutputFetcher(pkScript, amt)
+
+	spend := func(tx *wire.MsgTx, leaf TapLeaf,
+		key *btcec.PrivateKey) error {
+
+		idx := tree.LeafProofIndex[leaf.TapHash()]
+		ctrl := tree.LeafMerkleProofs[idx].ToControlBlock(
+			internalKey.PubKey(),
+		)
+		ctrlBytes, err := ctrl.ToBytes()
+		require.NoError(t, err)
+
+		sigHashes := NewTxSigHashes(tx, prevFetcher)
+		witness, err := SignTapscriptVaultSpend(tx, sigHashes, 0, amt,
+			pkScript, leaf, ctrlBytes, SigHashDefault, key)
+		if err != nil {
+			return err
+		}
+		tx.TxIn[0].Witness = witness
+		vm, err := NewEngine(pkScript, tx, 0, StandardVerifyFlags, nil,
+			sigHashes, amt, prevFetcher)
+		require.NoError(t, err)
+		return vm.Execute()
+	}
+
+	require.NoError(t, spend(vaultSpendingTx(0), recoveryLeaf, recoveryKey))
+	require.NoError(t, spend(vaultSpendingTx(delay), hotLeaf, hotKey))
+
+	err = spend(vaultSpendingTx(delay-1), hotLeaf, hotKey)
+	require.ErrorIs(t, err, ErrInvalidVault)
+	err = spend(vaultSpendingTx(3), hotLeaf, hotKey)
+	require.ErrorIs(t, err, ErrInvalidVault)
+
+	// 使用错误密钥签名的花费被引擎拒绝。
+	err = spend(vaultSpendingTx(0), recoveryLeaf, hotKey)
+	require.True(t, IsErrorCode(err, ErrNullFail) ||
+		IsErrorCode(err, ErrEvalFalse), err)
+}
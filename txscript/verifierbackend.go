@@ -0,0 +1,67 @@
+// 包含签名验证后端的扩展点，使部署可以在构造引擎时为 ECDSA 和 schnorr 签名验证接入优化的实现，
+// 例如批量 GLV、汇编 secp256k1 绑定或 GPU 批量验证。 默认后端使用 btcec。
+
+package txscript
+
+import (
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcec/v2/ecdsa"
+	"github.com/btcsuite/btcd/btcec/v2/schnorr"
+)
+
+// VerifierBackend 执行引擎所需的签名密码学验证。 签名编码、公钥编码和签名哈希由引擎负责检查和计算，
+// 后端只需判断签名对于给定的消息哈希和公钥是否有效。 签名缓存在后端之外查询，因此缓存命中时不会调用后端。
+//
+// 后端可能被多个引擎并发调用，实现必须是并发安全的，并且必须与 btcec 的验证结果完全一致，否则会导致共识分歧。
+type VerifierBackend interface {
+	// VerifyECDSA 返回 ECDSA 签名 sig 对于消息哈希 hash 和公钥 pubKey 是否有效。
+	VerifyECDSA(sig *ecdsa.Signature, hash []byte, pubKey *btcec.PublicKey) bool
+
+	// VerifySchnorr 返回 BIP0340 签名 sig 对于消息哈希 hash 和公钥 pubKey 是否有效。
+	VerifySchnorr(sig *schnorr.Signature, hash []byte, pubKey *btcec.PublicKey) bool
+}
+
+// BtcecVerifier 是使用 btcec 实现的默认验证后端。
+type BtcecVerifier struct{}
+
+// VerifyECDSA 使用 btcec 验证 ECDSA 签名。
+//
+// 这是 VerifierBackend 接口的一部分。
+func (BtcecVerifier) VerifyECDSA(sig *ecdsa.Signature, hash []byte,
+	pubKey *btcec.PublicKey) bool {
+
+	return sig.Verify(hash, pubKey)
+}
+
+// VerifySchnorr 使用 btcec 验证 BIP0340 签名。
+//
+// 这是 VerifierBackend 接口的一部分。
+func (BtcecVerifier) VerifySchnorr(sig *schnorr.Signature, hash []byte,
+	pubKey *btcec.PublicKey) bool {
+
+	return sig.Verify(hash, pubKey)
+}
+
+// A compile-time assertion to ensure BtcecVerifier implements the
+// VerifierBackend interface.
+var _ VerifierBackend = BtcecVerifier{}
+
+// WithVerifierBackend 指定引擎验证签名使用的后端，而不是默认的 BtcecVerifier。 传入 nil 时使用默认后端。
+func WithVerifierBackend(backend VerifierBackend) EngineOpt {
+	return func(cfg *engineConfig) {
+		cfg.verifierBackend = backend
+	}
+}
+
+// verifier 返回引擎使用的验证后端，未指定时返回默认后端。
+func (vm *Engine) verifier() VerifierBackend {
+	return verifierOrDefault(vm.verifierBackend)
+}
+
+// verifierOrDefault 在 backend 为 nil 时返回默认后端。
+func verifierOrDefault(backend VerifierBackend) VerifierBackend {
+	if backend == nil {
+		return BtcecVerifier{}
+	}
+	return backend
+}
@@ -0,0 +1,164 @@
+package txscript
+
+import (
+	"crypto/sha256"
+	"sync/atomic"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcec/v2/ecdsa"
+	"github.com/btcsuite/btcd/btcec/v2/schnorr"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/stretchr/testify/require"
+)
+
+// countingVerifier 是记录调用次数的验证后端，reject 为 true 时拒绝所有签名。
+type countingVerifier struct {
+	ecdsaCalls   atomic.Int64
+	schnorrCalls atomic.Int64
+	reject       bool
+}
+
+func (c *countingVerifier) VerifyECDSA(sig *ecdsa.Signature, hash []byte,
+	pubKey *btcec.PublicKey) bool {
+
+	c.ecdsaCalls.Add(1)
+	return !c.reject && BtcecVerifier{}.VerifyECDSA(sig, hash, pubKey)
+}
+
+func (c *countingVerifier) VerifySchnorr(sig *schnorr.Signature, hash []byte,
+	pubKey *btcec.PublicKey) bool {
+
+	c.schnorrCalls.Add(1)
+	return !c.reject && BtcecVerifier{}.VerifySchnorr(sig, hash, pubKey)
+}
+
+// multisigSpend 返回一个通过 P2WSH m-of-n CHECKMULTISIG 花费的交易及其公钥脚本。
+func multisigSpend(t testing.TB, m, n int) (*wire.MsgTx, []byte, int64) {
+	const amt = 1e8
+
+	keys := make([]*btcec.PrivateKey, n)
+	builder := NewScriptBuilder().AddInt64(int64(m))
+	for i := range keys {
+		key, err := btcec.NewPrivateKey()
+		require.NoError(t, err)
+		keys[i] = key
+		builder.AddData(key.PubKey().SerializeCompressed())
+	}
+	witnessScript, err := builder.AddInt64(int64(n)).
+		AddOp(OP_CHECKMULTISIG).Script()
+	require.NoError(t, err)
+	scriptHash := sha256.Sum256(witnessScript)
+	pkScript, err := payToWitnessScriptHashScript(scriptHash[:])
+	require.NoError(t, err)
+
+	tx := htlcSpendingTx(0)
+	sigHashes := NewTxSigHashes(tx, NewCannedPrevOutputFetcher(pkScript, amt))
+	witness := wire.TxWitness{nil}
+	for _, key := range keys[:m] {
+		sig, err := RawTxInWitnessSignature(tx, sigHashes, 0, amt,
+			witnessScript, SigHashAll, key)
+		require.NoError(t, err)
+		witness = append(witness, sig)
+	}
+	tx.TxIn[0].Witness = append(witness, witnessScript)
+
+	return tx, pkScript, amt
+}
+
+// TestVerifierBackend 确保引擎通过指定的后端验证 ECDSA 多重签名和 taproot 密钥花费，并且后端拒绝签名时执行失败。
+func TestVerifierBackend(t *testing.T) {
+	t.Parallel()
+
+	execute := func(tx *wire.MsgTx, pkScript []byte, amt int64,
+		backend VerifierBackend) error {
+
+		prevFetcher := NewCannedPrevOutputFetcher(pkScript, amt)
+		vm, err := NewEngine(pkScript, tx, 0, StandardVerifyFlags, nil,
+			NewTxSigHashes(tx, prevFetcher), amt, prevFetcher,
+			WithVerifierBackend(backend))
+		if err != nil {
+			return err
+		}
+		return vm.Execute()
+	}
+
+	// CHECKMULTISIG 依次尝试公钥，因此不匹配的公钥也会调用后端。
+	tx, pkScript, amt := multisigSpend(t, 2, 3)
+	require.NoError(t, execute(tx, pkScript, amt, nil))
+
+	backend := &countingVerifier{}
+	require.NoError(t, execute(tx, pkScript, amt, backend))
+	require.EqualValues(t, 3, backend.ecdsaCalls.Load())
+	require.Zero(t, backend.schnorrCalls.Load())
+
+	backend = &countingVerifier{reject: true}
+	require.Error(t, execute(tx, pkScript, amt, backend))
+
+	// taproot 密钥花费。
+	privKey, err := btcec.NewPrivateKey()
+	require.NoError(t, err)
+	pkScript, err = PayToTaprootScript(
+		ComputeTaprootKeyNoScript(privKey.PubKey()),
+	)
+	require.NoError(t, err)
+	tx = htlcSpendingTx(0)
+	prevFetcher := NewCannedPrevOutputFetcher(pkScript, amt)
+	tx.TxIn[0].Witness, err = TaprootWitnessSignature(tx,
+		NewTxSigHashes(tx, prevFetcher), 0, amt, pkScript, SigHashDefault,
+		privKey)
+	require.NoError(t, err)
+
+	backend = &countingVerifier{}
+	require.NoError(t, execute(tx, pkScript, amt, backend))
+	require.EqualValues(t, 1, backend.schnorrCalls.Load())
+	require.Zero(t, backend.ecdsaCalls.Load())
+
+	backend = &countingVerifier{reject: true}
+	err = execute(tx, pkScript, amt, backend)
+	require.True(t, IsErrorCode(err, ErrTaprootSigInvalid))
+}
+
+// BenchmarkVerifierBackends 在以多重签名为主的工作负载上比较各个验证后端。 要比较其他实现，将其加入 backends 即可。
+func BenchmarkVerifierBackends(b *testing.B) {
+	backends := []struct {
+		name    string
+		backend VerifierBackend
+	}{
+		{"btcec", BtcecVerifier{}},
+		{"counting", &countingVerifier{}},
+	}
+	workloads := []struct {
+		name string
+		m, n int
+	}{
+		{"2of3", 2, 3},
+		{"3of5", 3, 5},
+		{"15of15", 15, 15},
+	}
+
+	for _, workload := range workloads {
+		tx, pkScript, amt := multisigSpend(b, workload.m, workload.n)
+		prevFetcher := NewCannedPrevOutputFetcher(pkScript, amt)
+		sigHashes := NewTxSigHashes(tx, prevFetcher)
+
+		for _, backend := range backends {
+			name := workload.name + "/" + backend.name
+			b.Run(name, func(b *testing.B) {
+				b.ReportAllocs()
+				for i := 0; i < b.N; i++ {
+					vm, err := NewEngine(pkScript, tx, 0,
+						StandardVerifyFlags, nil, sigHashes, amt,
+						prevFetcher,
+						WithVerifierBackend(backend.backend))
+					if err != nil {
+						b.Fatal(err)
+					}
+					if err := vm.Execute(); err != nil {
+						b.Fatal(err)
+					}
+				}
+			})
+		}
+	}
+}
@@ -0,0 +1,180 @@
+// 包含未来见证版本（2 到 16）的处理程序注册逻辑，使新版本的验证规则可以在不修改 verifyWitnessProgram 的情况下添加。
+
+package txscript
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+
+	"github.com/btcsuite/btcd/wire"
+)
+
+const (
+	// firstCustomWitnessVersion 是可以注册处理程序的最小见证版本。 版本 0 和 1 由引擎内置处理。
+	firstCustomWitnessVersion = TaprootWitnessVersion + 1
+
+	// maxWitnessVersion 是见证程序允许的最大版本。
+	maxWitnessVersion = 16
+)
+
+var (
+	// ErrWitnessHandlerRegistryFrozen 在脚本引擎已经被使用之后尝试注册见证版本处理程序时返回。
+	ErrWitnessHandlerRegistryFrozen = fmt.Errorf("witness version handler " +
+		"registry is frozen")
+
+	// ErrInvalidWitnessHandler 在处理程序为 nil、版本超出范围或该版本已注册处理程序时返回。
+	ErrInvalidWitnessHandler = fmt.Errorf("invalid witness version handler")
+)
+
+// WitnessSpend 包含见证版本处理程序验证一次花费所需的全部信息。
+type WitnessSpend struct {
+	// Version 是被花费输出的见证版本。
+	Version int
+
+	// Program 是被花费输出的见证程序。
+	Program []byte
+
+	// Witness 是花费输入提供的见证。
+	Witness wire.TxWitness
+
+	// Tx 是正在验证的交易，TxIdx 是被验证输入的索引。
+	Tx    *wire.MsgTx
+	TxIdx int
+
+	// InputAmount 是被花费输出的金额。
+	InputAmount int64
+
+	// Flags 是引擎的脚本标志，处理程序可以据此决定新规则是否已激活。
+	Flags ScriptFlags
+
+	// PrevOutFetcher、HashCache 和 SigCache 与传递给 NewEngine 的值相同，可能为 nil。
+	PrevOutFetcher PrevOutputFetcher
+	HashCache      *TxSigHashes
+	SigCache       *SigCache
+}
+
+// WitnessVersionHandler 实现某个见证版本的验证逻辑。
+//
+// VerifyWitnessProgram 返回 nil 脚本表示该花费已被完全验证；否则引擎将以 stack 为初始堆栈继续执行返回的脚本，并按普通脚本规则判断结果。
+type WitnessVersionHandler interface {
+	VerifyWitnessProgram(spend *WitnessSpend) (script []byte,
+		stack [][]byte, err error)
+}
+
+// WitnessVersionHandlerFunc 使用函数实现 WitnessVersionHandler。
+type WitnessVersionHandlerFunc func(spend *WitnessSpend) ([]byte, [][]byte, error)
+
+// VerifyWitnessProgram 通过调用函数实现 WitnessVersionHandler。
+func (f WitnessVersionHandlerFunc) VerifyWitnessProgram(
+	spend *WitnessSpend) ([]byte, [][]byte, error) {
+
+	return f(spend)
+}
+
+// witnessHandlerTable 按见证版本索引已注册的处理程序。
+type witnessHandlerTable [maxWitnessVersion + 1]WitnessVersionHandler
+
+var (
+	// witnessHandlersMtx 用于串行化注册操作。
+	witnessHandlersMtx sync.Mutex
+
+	// witnessHandlers 保存已注册处理程序的不可变快照。
+	witnessHandlers atomic.Pointer[witnessHandlerTable]
+
+	// witnessHandlersFrozen 在第一个脚本引擎被创建时设置，此后不再接受新的注册。
+	witnessHandlersFrozen atomic.Bool
+)
+
+// RegisterWitnessVersionHandler 为版本 2 到 16 之一的见证程序注册验证逻辑。
+// 未注册处理程序的版本仍按 ScriptVerifyDiscourageUpgradeableWitnessProgram 和 ScriptVerifyRejectUnknownWitnessVersion 标志处理。
+//
+// 注册应当在程序初始化期间完成。 一旦创建了第一个脚本引擎，注册表即被冻结，之后的调用将返回 ErrWitnessHandlerRegistryFrozen。
+func RegisterWitnessVersionHandler(version int,
+	handler WitnessVersionHandler) error {
+
+	if handler == nil {
+		return fmt.Errorf("%w: nil handler", ErrInvalidWitnessHandler)
+	}
+	if version < firstCustomWitnessVersion || version > maxWitnessVersion {
+		return fmt.Errorf("%w: version %d is not in range [%d, %d]",
+			ErrInvalidWitnessHandler, version,
+			firstCustomWitnessVersion, maxWitnessVersion)
+	}
+
+	witnessHandlersMtx.Lock()
+	defer witnessHandlersMtx.Unlock()
+
+	if witnessHandlersFrozen.Load() {
+		return ErrWitnessHandlerRegistryFrozen
+	}
+
+	var updated witnessHandlerTable
+	if p := witnessHandlers.Load(); p != nil {
+		updated = *p
+	}
+	if updated[version] != nil {
+		return fmt.Errorf("%w: version %d already registered",
+			ErrInvalidWitnessHandler, version)
+	}
+	updated[version] = handler
+	witnessHandlers.Store(&updated)
+
+	return nil
+}
+
+// freezeWitnessHandlerRegistry 冻结见证版本处理程序注册表，之后的注册都会失败。
+func freezeWitnessHandlerRegistry() {
+	witnessHandlersFrozen.Store(true)
+}
+
+// lookupWitnessVersionHandler 返回为传入版本注册的处理程序，没有时返回 nil。
+func lookupWitnessVersionHandler(version int) WitnessVersionHandler {
+	p := witnessHandlers.Load()
+	if p == nil || version < 0 || version > maxWitnessVersion {
+		return nil
+	}
+	return p[version]
+}
+
+// verifyRegisteredWitnessProgram 使用已注册的处理程序验证见证程序，并根据其结果设置后续执行的脚本和堆栈。
+func (vm *Engine) verifyRegisteredWitnessProgram(
+	handler WitnessVersionHandler, witness wire.TxWitness) error {
+
+	script, stack, err := handler.VerifyWitnessProgram(&WitnessSpend{
+		Version:        vm.witnessVersion,
+		Program:        vm.witnessProgram,
+		Witness:        witness,
+		Tx:             vm.tx,
+		TxIdx:          vm.txIdx,
+		InputAmount:    vm.inputAmount,
+		Flags:          vm.flags,
+		PrevOutFetcher: vm.prevOutFetcher,
+		HashCache:      vm.hashCache,
+		SigCache:       vm.sigCache,
+	})
+	if err != nil {
+		return err
+	}
+
+	// The handler fully validated the spend, so leave a single true
+	// element on the stack to satisfy the final checks.
+	if script == nil {
+		vm.SetStack([][]byte{{1}})
+		return nil
+	}
+
+	if len(script) > vm.limits.MaxScriptSize {
+		str := fmt.Sprintf("witness version %d script size %d is "+
+			"larger than max allowed size %d", vm.witnessVersion,
+			len(script), vm.limits.MaxScriptSize)
+		return scriptError(ErrScriptTooBig, str)
+	}
+	if err := checkScriptParses(vm.version, script); err != nil {
+		return err
+	}
+
+	vm.scripts = append(vm.scripts, script)
+	vm.SetStack(stack)
+	return nil
+}
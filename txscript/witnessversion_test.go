@@ -0,0 +1,123 @@
+package txscript
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/btcsuite/btcd/wire"
+)
+
+// withCleanWitnessHandlerRegistry 在一个空的、未冻结的处理程序注册表中运行 f，并在结束后恢复原来的注册表状态。
+//
+// 注意：使用它的测试不能调用 t.Parallel，否则会与其他测试竞争全局注册表。
+func withCleanWitnessHandlerRegistry(f func()) {
+	witnessHandlersMtx.Lock()
+	saved := witnessHandlers.Load()
+	wasFrozen := witnessHandlersFrozen.Load()
+	witnessHandlers.Store(nil)
+	witnessHandlersFrozen.Store(false)
+	witnessHandlersMtx.Unlock()
+
+	defer func() {
+		witnessHandlersMtx.Lock()
+		witnessHandlers.Store(saved)
+		witnessHandlersFrozen.Store(wasFrozen)
+		witnessHandlersMtx.Unlock()
+	}()
+
+	f()
+}
+
+// TestWitnessVersionHandlers 确保已注册的见证版本处理程序会被引擎调用，并且未注册的版本按标志处理。
+func TestWitnessVersionHandlers(t *testing.T) {
+	program := bytes.Repeat([]byte{0x42}, 32)
+	v2Script := append([]byte{OP_2, OP_DATA_32}, program...)
+	v3Script := append([]byte{OP_3, OP_DATA_32}, program...)
+
+	// 版本 2 处理程序：见证为程序本身时直接通过，否则执行 OP_EQUAL 比较两者。
+	v2Handler := WitnessVersionHandlerFunc(func(spend *WitnessSpend) ([]byte,
+		[][]byte, error) {
+
+		if spend.Version != 2 {
+			t.Fatalf("unexpected version %d", spend.Version)
+		}
+		if len(spend.Witness) != 1 {
+			return nil, nil, scriptError(ErrWitnessProgramMismatch,
+				"expected a single witness item")
+		}
+		if bytes.Equal(spend.Witness[0], spend.Program) {
+			return nil, nil, nil
+		}
+		return []byte{OP_EQUAL}, [][]byte{spend.Witness[0],
+			spend.Program}, nil
+	})
+
+	const flags = ScriptBip16 | ScriptVerifyWitness
+	execute := func(pkScript []byte, witness wire.TxWitness,
+		flags ScriptFlags) error {
+
+		tx := createSpendingTx(witness, nil, pkScript, 0)
+		vm, err := NewEngine(pkScript, tx, 0, flags, nil, nil, 0, nil)
+		if err != nil {
+			return err
+		}
+		return vm.Execute()
+	}
+
+	withCleanWitnessHandlerRegistry(func() {
+		invalid := []struct {
+			version int
+			handler WitnessVersionHandler
+		}{
+			{0, v2Handler},
+			{TaprootWitnessVersion, v2Handler},
+			{maxWitnessVersion + 1, v2Handler},
+			{2, nil},
+		}
+		for _, test := range invalid {
+			err := RegisterWitnessVersionHandler(test.version, test.handler)
+			if !errors.Is(err, ErrInvalidWitnessHandler) {
+				t.Fatalf("version %d: expected ErrInvalidWitnessHandler, "+
+					"got %v", test.version, err)
+			}
+		}
+
+		if err := RegisterWitnessVersionHandler(2, v2Handler); err != nil {
+			t.Fatalf("unable to register handler: %v", err)
+		}
+		err := RegisterWitnessVersionHandler(2, v2Handler)
+		if !errors.Is(err, ErrInvalidWitnessHandler) {
+			t.Fatalf("expected duplicate registration to fail, got %v",
+				err)
+		}
+
+		if err := execute(v2Script, wire.TxWitness{program}, flags); err != nil {
+			t.Fatalf("handler spend failed: %v", err)
+		}
+		err = execute(v2Script, wire.TxWitness{{0x01}}, flags)
+		if !IsErrorCode(err, ErrEvalFalse) {
+			t.Fatalf("expected ErrEvalFalse, got %v", err)
+		}
+		err = execute(v2Script, nil, flags)
+		if !IsErrorCode(err, ErrWitnessProgramMismatch) {
+			t.Fatalf("expected ErrWitnessProgramMismatch, got %v", err)
+		}
+
+		// 未注册的版本默认任何人都可以花费，除非设置了拒绝标志。
+		if err := execute(v3Script, nil, flags); err != nil {
+			t.Fatalf("unknown version spend failed: %v", err)
+		}
+		err = execute(v3Script, nil,
+			flags|ScriptVerifyRejectUnknownWitnessVersion)
+		if !IsErrorCode(err, ErrUnknownWitnessVersion) {
+			t.Fatalf("expected ErrUnknownWitnessVersion, got %v", err)
+		}
+
+		err = RegisterWitnessVersionHandler(3, v2Handler)
+		if !errors.Is(err, ErrWitnessHandlerRegistryFrozen) {
+			t.Fatalf("expected ErrWitnessHandlerRegistryFrozen, got %v",
+				err)
+		}
+	})
+}
@@ -0,0 +1,226 @@
+// 包含 tapscript x-only 公钥的辅助函数：在压缩、未压缩和 x-only 编码之间转换，按字典序排序 x-only 公钥，
+// 以及按 BIP0342 的规则检查签名操作码使用的公钥编码。 外部工具经常把 33 字节的压缩公钥写入 tapscript 叶子，
+// 这类公钥按共识被视为未知公钥类型，签名检查总是成功，因此需要在构造叶子时发现。
+
+package txscript
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcec/v2/schnorr"
+	secp "github.com/decred/dcrd/dcrec/secp256k1/v4"
+)
+
+// ErrInvalidXOnlyKey 在公钥无法转换为 x-only 编码，或 32 字节的 x-only 公钥不在曲线上时返回。
+var ErrInvalidXOnlyKey = errors.New("invalid x-only public key")
+
+// TapscriptKeyType 是 tapscript 签名操作码按长度对公钥的分类。
+type TapscriptKeyType uint8
+
+const (
+	// TapscriptKeyEmpty 是空公钥，使签名操作码立即失败。
+	TapscriptKeyEmpty TapscriptKeyType = iota
+
+	// TapscriptKeyXOnly 是 32 字节的 BIP0340 x-only 公钥。
+	TapscriptKeyXOnly
+
+	// TapscriptKeyUpgradeable 是保留给未来软分叉的其它长度的公钥，按共识其签名检查总是成功，
+	// 设置 ScriptVerifyDiscourageUpgradeablePubkeyType 时被策略拒绝。
+	TapscriptKeyUpgradeable
+)
+
+// String 返回公钥类型的可读名称。
+func (t TapscriptKeyType) String() string {
+	switch t {
+	case TapscriptKeyEmpty:
+		return "empty"
+	case TapscriptKeyXOnly:
+		return "x-only"
+	case TapscriptKeyUpgradeable:
+		return "upgradeable"
+	default:
+		return fmt.Sprintf("TapscriptKeyType(%d)", uint8(t))
+	}
+}
+
+// ClassifyTapscriptKey 返回 tapscript 签名操作码对 pkBytes 的分类。 分类只取决于长度，32 字节的公钥是否在曲线上需要另外检查。
+func ClassifyTapscriptKey(pkBytes []byte) TapscriptKeyType {
+	switch len(pkBytes) {
+	case 0:
+		return TapscriptKeyEmpty
+	case schnorr.PubKeyBytesLen:
+		return TapscriptKeyXOnly
+	default:
+		return TapscriptKeyUpgradeable
+	}
+}
+
+// CheckTapscriptPubKey 按 tapscript 规则检查签名操作码使用的公钥：空公钥返回 ErrTaprootPubkeyIsEmpty 错误，
+// 不在曲线上的 32 字节公钥返回 ErrInvalidXOnlyKey 错误，设置 ScriptVerifyDiscourageUpgradeablePubkeyType 时其它长度的公钥返回
+// ErrDiscourageUpgradeablePubKeyType 错误。 未设置该标志时未知类型的公钥被接受，与共识规则一致。
+func CheckTapscriptPubKey(pkBytes []byte, flags ScriptFlags) error {
+	switch ClassifyTapscriptKey(pkBytes) {
+	case TapscriptKeyEmpty:
+		return scriptError(ErrTaprootPubkeyIsEmpty, "")
+
+	case TapscriptKeyXOnly:
+		if _, err := schnorr.ParsePubKey(pkBytes); err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidXOnlyKey, err)
+		}
+		return nil
+
+	default:
+		if flags&ScriptVerifyDiscourageUpgradeablePubkeyType != 0 {
+			return upgradeablePubKeyTypeError(pkBytes)
+		}
+		return nil
+	}
+}
+
+// upgradeablePubKeyTypeError 返回使用未知类型公钥时的 ErrDiscourageUpgradeablePubKeyType 错误，
+// 公钥看起来是 ECDSA 编码时在描述中提示应使用 x-only 编码。
+func upgradeablePubKeyTypeError(pkBytes []byte) error {
+	str := fmt.Sprintf("pubkey of length %v was used", len(pkBytes))
+	if isECDSAPubKeyEncoding(pkBytes) {
+		str += " (looks like an ECDSA public key, tapscript " +
+			"expects the 32-byte x-only encoding)"
+	}
+	return scriptError(ErrDiscourageUpgradeablePubKeyType, str)
+}
+
+// isECDSAPubKeyEncoding 返回 pkBytes 的长度和前缀字节是否符合压缩或未压缩的 ECDSA 公钥编码。
+func isECDSAPubKeyEncoding(pkBytes []byte) bool {
+	switch len(pkBytes) {
+	case btcec.PubKeyBytesLenCompressed:
+		return pkBytes[0] == secp.PubKeyFormatCompressedEven ||
+			pkBytes[0] == secp.PubKeyFormatCompressedOdd
+	case secp.PubKeyBytesLenUncompressed:
+		return pkBytes[0] == secp.PubKeyFormatUncompressed
+	default:
+		return false
+	}
+}
+
+// ToXOnlyPubKey 将 33 字节的压缩公钥或 65 字节的未压缩公钥转换为 32 字节的 x-only 编码。
+// 32 字节的输入被检查后原样复制返回。 公钥无效时返回 ErrInvalidXOnlyKey 错误。
+func ToXOnlyPubKey(pubKey []byte) ([]byte, error) {
+	var (
+		key *btcec.PublicKey
+		err error
+	)
+	switch len(pubKey) {
+	case schnorr.PubKeyBytesLen:
+		key, err = schnorr.ParsePubKey(pubKey)
+	case btcec.PubKeyBytesLenCompressed, secp.PubKeyBytesLenUncompressed:
+		key, err = btcec.ParsePubKey(pubKey)
+	default:
+		return nil, fmt.Errorf("%w: unsupported key length %d",
+			ErrInvalidXOnlyKey, len(pubKey))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidXOnlyKey, err)
+	}
+	return schnorr.SerializePubKey(key), nil
+}
+
+// ParseXOnlyPubKey 解析 32 字节的 x-only 公钥，返回 Y 坐标为偶数的公钥。 公钥无效时返回 ErrInvalidXOnlyKey 错误。
+func ParseXOnlyPubKey(xOnly []byte) (*btcec.PublicKey, error) {
+	if len(xOnly) != schnorr.PubKeyBytesLen {
+		return nil, fmt.Errorf("%w: x-only key must be %d bytes, got %d",
+			ErrInvalidXOnlyKey, schnorr.PubKeyBytesLen, len(xOnly))
+	}
+	key, err := schnorr.ParsePubKey(xOnly)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidXOnlyKey, err)
+	}
+	return key, nil
+}
+
+// CheckXOnlyPubKeyEncoding 检查 pubKey 是否是编码有效的 BIP0340 x-only 公钥，并返回精确的错误码：空公钥返回
+// ErrTaprootPubkeyIsEmpty 错误，长度不是 32 字节返回 ErrPubKeyType 错误，不是曲线上某点的 x 坐标返回
+// ErrXOnlyPubKeyNotOnCurve 错误。 与 CheckTapscriptPubKey 不同，其它长度的公钥总是被拒绝。
+func CheckXOnlyPubKeyEncoding(pubKey []byte) error {
+	switch len(pubKey) {
+	case 0:
+		return scriptError(ErrTaprootPubkeyIsEmpty, "")
+
+	case schnorr.PubKeyBytesLen:
+		if _, err := schnorr.ParsePubKey(pubKey); err != nil {
+			str := fmt.Sprintf("x-only public key %x: %v", pubKey, err)
+			return scriptError(ErrXOnlyPubKeyNotOnCurve, str)
+		}
+		return nil
+
+	default:
+		str := fmt.Sprintf("x-only public key must be %d bytes, got %d",
+			schnorr.PubKeyBytesLen, len(pubKey))
+		if isECDSAPubKeyEncoding(pubKey) {
+			str += " (looks like an ECDSA public key)"
+		}
+		return scriptError(ErrPubKeyType, str)
+	}
+}
+
+// XOnlyToCompressed 返回 x-only 公钥按 BIP0340 隐含的偶数 Y 坐标对应的 33 字节压缩公钥。
+// 原始公钥的 Y 坐标为奇数时结果与其不同，x-only 编码不保留奇偶性。
+func XOnlyToCompressed(xOnly []byte) ([]byte, error) {
+	key, err := ParseXOnlyPubKey(xOnly)
+	if err != nil {
+		return nil, err
+	}
+	return key.SerializeCompressed(), nil
+}
+
+// SortXOnlyPubKeys 检查每个公钥都是有效的 x-only 公钥，然后按字典序原地升序排列，与 BIP0067 对压缩公钥的排序相同。
+// 存在无效的公钥时返回 ErrInvalidXOnlyKey 错误，且不修改 keys。
+func SortXOnlyPubKeys(keys [][]byte) error {
+	for i, key := range keys {
+		if _, err := ParseXOnlyPubKey(key); err != nil {
+			return fmt.Errorf("key %d: %w", i, err)
+		}
+	}
+	sortPubKeys(keys)
+	return nil
+}
+
+// CheckTapscriptLeafKeys 检查 tapscript 叶子中紧接在 OP_CHECKSIG、OP_CHECKSIGVERIFY 或 OP_CHECKSIGADD 之前推送的公钥，
+// 并按设置 ScriptVerifyDiscourageUpgradeablePubkeyType 时的规则返回第一个不满足 CheckTapscriptPubKey 的公钥错误，
+// 使误写入叶子的压缩公钥在构造输出时被发现，而不是产生任何人可花费的叶子。 由其它操作码计算得到的公钥无法静态检查。
+func CheckTapscriptLeafKeys(script []byte) error {
+	const scriptVersion = 0
+
+	var (
+		prevData   []byte
+		prevIsPush bool
+	)
+	tokenizer := MakeScriptTokenizer(scriptVersion, script)
+	for tokenizer.Next() {
+		op := tokenizer.Opcode()
+		switch op {
+		case OP_CHECKSIG, OP_CHECKSIGVERIFY, OP_CHECKSIGADD:
+			if !prevIsPush {
+				break
+			}
+			err := CheckTapscriptPubKey(
+				prevData, ScriptVerifyDiscourageUpgradeablePubkeyType,
+			)
+			pos := tokenizer.OpcodePosition() - 1
+			var serr Error
+			switch {
+			case errors.As(err, &serr):
+				str := fmt.Sprintf("opcode %d: %s", pos,
+					serr.Description)
+				return scriptError(serr.ErrorCode, str)
+			case err != nil:
+				return fmt.Errorf("opcode %d: %w", pos, err)
+			}
+		}
+
+		// Only data pushes, including OP_0, are treated as keys.
+		prevIsPush = op <= OP_PUSHDATA4
+		prevData = tokenizer.Data()
+	}
+	return tokenizer.Err()
+}
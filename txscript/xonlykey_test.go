@@ -0,0 +1,116 @@
+package txscript
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcec/v2/schnorr"
+	"github.com/stretchr/testify/require"
+)
+
+// TestXOnlyKeyConversions 确保压缩、未压缩和 x-only 编码之间的转换一致，并且排序只接受有效的 x-only 公钥。
+func TestXOnlyKeyConversions(t *testing.T) {
+	t.Parallel()
+
+	var keys [][]byte
+	for i := 0; i < 10; i++ {
+		privKey, err := btcec.NewPrivateKey()
+		require.NoError(t, err)
+		pubKey := privKey.PubKey()
+		xOnly := schnorr.SerializePubKey(pubKey)
+
+		for _, encoded := range [][]byte{
+			pubKey.SerializeCompressed(), pubKey.SerializeUncompressed(),
+			xOnly,
+		} {
+			got, err := ToXOnlyPubKey(encoded)
+			require.NoError(t, err)
+			require.Equal(t, xOnly, got)
+		}
+
+		compressed, err := XOnlyToCompressed(xOnly)
+		require.NoError(t, err)
+		require.Equal(t, byte(0x02), compressed[0])
+		require.Equal(t, pubKey.SerializeCompressed()[1:], compressed[1:])
+
+		keys = append(keys, xOnly)
+	}
+
+	require.NoError(t, SortXOnlyPubKeys(keys))
+	for i := 1; i < len(keys); i++ {
+		require.Negative(t, bytes.Compare(keys[i-1], keys[i]))
+	}
+
+	// 无效的公钥使排序失败并且不修改输入。
+	unsorted := [][]byte{keys[1], keys[0], make([]byte, 32)}
+	err := SortXOnlyPubKeys(unsorted)
+	require.ErrorIs(t, err, ErrInvalidXOnlyKey)
+	require.Equal(t, keys[1], unsorted[0])
+
+	for _, bad := range [][]byte{nil, make([]byte, 20), make([]byte, 32),
+		append([]byte{0x02}, make([]byte, 32)...)} {
+
+		_, err := ToXOnlyPubKey(bad)
+		require.ErrorIs(t, err, ErrInvalidXOnlyKey, "%x", bad)
+	}
+	_, err = XOnlyToCompressed(keys[0][:31])
+	require.ErrorIs(t, err, ErrInvalidXOnlyKey)
+}
+
+// TestCheckTapscriptKeys 确保公钥分类和检查与 tapscript 签名操作码的规则一致，并且叶子中误用的 ECDSA 公钥被发现。
+func TestCheckTapscriptKeys(t *testing.T) {
+	t.Parallel()
+
+	privKey, err := btcec.NewPrivateKey()
+	require.NoError(t, err)
+	compressed := privKey.PubKey().SerializeCompressed()
+	xOnly := schnorr.SerializePubKey(privKey.PubKey())
+
+	require.Equal(t, TapscriptKeyEmpty, ClassifyTapscriptKey(nil))
+	require.Equal(t, TapscriptKeyXOnly, ClassifyTapscriptKey(xOnly))
+	require.Equal(t, TapscriptKeyUpgradeable,
+		ClassifyTapscriptKey(compressed))
+	require.Equal(t, "upgradeable", TapscriptKeyUpgradeable.String())
+
+	require.True(t, IsErrorCode(CheckTapscriptPubKey(nil, 0),
+		ErrTaprootPubkeyIsEmpty))
+	require.NoError(t, CheckTapscriptPubKey(xOnly, 0))
+	require.ErrorIs(t, CheckTapscriptPubKey(make([]byte, 32), 0),
+		ErrInvalidXOnlyKey)
+
+	// 未知类型的公钥只被策略拒绝。
+	require.NoError(t, CheckTapscriptPubKey(compressed, 0))
+	err = CheckTapscriptPubKey(
+		compressed, ScriptVerifyDiscourageUpgradeablePubkeyType,
+	)
+	require.True(t, IsErrorCode(err, ErrDiscourageUpgradeablePubKeyType))
+	if !LiteBuild {
+		require.Contains(t, err.Error(), "x-only")
+	}
+	err = CheckTapscriptPubKey(
+		[]byte{0x01}, ScriptVerifyDiscourageUpgradeablePubkeyType,
+	)
+	require.True(t, IsErrorCode(err, ErrDiscourageUpgradeablePubKeyType))
+	if !LiteBuild {
+		require.NotContains(t, err.Error(), "x-only")
+	}
+
+	leaf := func(key []byte) []byte {
+		script, err := NewScriptBuilder().AddData(xOnly).
+			AddOp(OP_CHECKSIG).AddData(key).AddOp(OP_CHECKSIGADD).
+			AddOp(OP_2).AddOp(OP_NUMEQUAL).Script()
+		require.NoError(t, err)
+		return script
+	}
+	require.NoError(t, CheckTapscriptLeafKeys(leaf(xOnly)))
+	err = CheckTapscriptLeafKeys(leaf(compressed))
+	require.True(t, IsErrorCode(err, ErrDiscourageUpgradeablePubKeyType))
+	if !LiteBuild {
+		require.Contains(t, err.Error(), "opcode 2")
+	}
+
+	// 不是由数据推送提供的公钥不被检查。
+	require.NoError(t, CheckTapscriptLeafKeys([]byte{OP_DUP, OP_CHECKSIG}))
+	require.Error(t, CheckTapscriptLeafKeys([]byte{OP_DATA_32}))
+}
@@ -0,0 +1,106 @@
+package bpfschain
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/qinglongcn/bpfschain/txscript"
+)
+
+// MsgTx 返回交易的 wire 形式，供 TxAcceptanceChecker 检查使用。 输入引用的交易 ID 作为外点的哈希，
+// 签名字段不是脚本，作为数据推送放入签名脚本，使签名脚本的大小计入检查；coinbase 交易的输入转换为空哈希和
+// wire.MaxPrevOutIndex；输出的值按 btcutil.NewAmount 换算为最小单位。
+func (tx *Transaction) MsgTx() (*wire.MsgTx, error) {
+	msgTx := wire.NewMsgTx(int32(tx.Version))
+	for i, in := range tx.Vin {
+		outpoint, err := in.outPoint()
+		if err != nil {
+			return nil, fmt.Errorf("输入 %d: %w", i, err)
+		}
+		var sigScript []byte
+		if len(in.Signature) != 0 {
+			sigScript, err = txscript.NewScriptBuilder().
+				AddData(in.Signature).Script()
+			if err != nil {
+				return nil, fmt.Errorf("输入 %d: %w", i, err)
+			}
+		}
+		msgTx.AddTxIn(wire.NewTxIn(&outpoint, sigScript, nil))
+	}
+	for i, out := range tx.Vout {
+		txOut, err := out.wireTxOut()
+		if err != nil {
+			return nil, fmt.Errorf("输出 %d: %w", i, err)
+		}
+		msgTx.AddTxOut(txOut)
+	}
+
+	return msgTx, nil
+}
+
+// outPoint 返回输入引用的输出的外点
+func (in *TxInput) outPoint() (wire.OutPoint, error) {
+	if len(in.ID) == 0 && in.Vout == -1 {
+		return wire.OutPoint{Index: wire.MaxPrevOutIndex}, nil
+	}
+
+	hash, err := chainhash.NewHash(in.ID)
+	if err != nil {
+		return wire.OutPoint{}, err
+	}
+	if in.Vout < 0 {
+		return wire.OutPoint{}, fmt.Errorf("无效的输出索引 %d", in.Vout)
+	}
+
+	return wire.OutPoint{Hash: *hash, Index: uint32(in.Vout)}, nil
+}
+
+// wireTxOut 返回输出的 wire 形式
+func (out *TxOutput) wireTxOut() (*wire.TxOut, error) {
+	amount, err := btcutil.NewAmount(out.Value)
+	if err != nil {
+		return nil, err
+	}
+
+	return wire.NewTxOut(int64(amount), out.PubKeyHash), nil
+}
+
+// prevOutputFetcher 返回提供 tx 的输入所花费的输出的获取器，prevTXs 是按十六进制 ID 索引的被引用交易。
+// 找不到的被引用交易或输出不加入获取器，由脚本检查报告缺少输入。
+func prevOutputFetcher(tx *Transaction,
+	prevTXs map[string]Transaction) (*txscript.MultiPrevOutFetcher, error) {
+
+	fetcher := txscript.NewMultiPrevOutFetcher(nil)
+	for i, in := range tx.Vin {
+		prevTX, exists := prevTXs[hex.EncodeToString(in.ID)]
+		if !exists || in.Vout < 0 || in.Vout >= len(prevTX.Vout) {
+			continue
+		}
+
+		outpoint, err := in.outPoint()
+		if err != nil {
+			return nil, fmt.Errorf("输入 %d: %w", i, err)
+		}
+		txOut, err := prevTX.Vout[in.Vout].wireTxOut()
+		if err != nil {
+			return nil, fmt.Errorf("输入 %d: %w", i, err)
+		}
+		fetcher.AddPrevOut(outpoint, txOut)
+	}
+
+	return fetcher, nil
+}
+
+// signatureVerifier 返回按链的签名格式验证 tx 的输入签名的 InputVerifier，prevTXs 是按十六进制 ID 索引的被引用交易。
+func signatureVerifier(tx *Transaction, prevTXs map[string]Transaction) InputVerifier {
+	return func(_ *wire.MsgTx, idx int, _ *wire.TxOut) error {
+		if !tx.verifyInput(idx, prevTXs) {
+			return errors.New("签名验证失败")
+		}
+		return nil
+	}
+}
@@ -0,0 +1,94 @@
+package bpfschain
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/qinglongcn/bpfschain/txscript"
+	"github.com/stretchr/testify/require"
+)
+
+// TestTransactionMsgTx 测试交易转换为 wire 形式时 coinbase 输入、签名字段和无效字段的处理。
+func TestTransactionMsgTx(t *testing.T) {
+	t.Parallel()
+
+	addr, err := btcutil.NewAddressPubKeyHash(
+		make([]byte, 20), &chaincfg.MainNetParams,
+	)
+	require.NoError(t, err)
+	coinbase := MinerTx(addr.EncodeAddress(), "data", 50)
+	msgTx, err := coinbase.MsgTx()
+	require.NoError(t, err)
+	require.Equal(t, wire.OutPoint{Index: wire.MaxPrevOutIndex},
+		msgTx.TxIn[0].PreviousOutPoint)
+	require.Equal(t, int64(5000000000), msgTx.TxOut[0].Value)
+
+	// 签名字段作为一次数据推送放入签名脚本。
+	sig := make([]byte, 64)
+	sig[0] = txscript.OP_PUSHDATA4
+	tx := Transaction{Vin: []TxInput{{ID: make([]byte, 32), Signature: sig}}}
+	msgTx, err = tx.MsgTx()
+	require.NoError(t, err)
+	pushes, err := txscript.PushedData(msgTx.TxIn[0].SignatureScript)
+	require.NoError(t, err)
+	require.Equal(t, [][]byte{sig}, pushes)
+
+	tx = Transaction{Vin: []TxInput{{ID: []byte{1, 2, 3}}}}
+	_, err = tx.MsgTx()
+	require.Error(t, err)
+}
+
+// TestTransactionAdmitSignature 测试内存池检查按链的签名格式验证输入，签名无效或被引用的输出不存在时交易被拒绝。
+func TestTransactionAdmitSignature(t *testing.T) {
+	t.Parallel()
+
+	opt := DefaultOptions()
+	require.NoError(t, opt.CheckAndSetOptions())
+
+	addr, err := btcutil.NewAddressPubKeyHash(
+		make([]byte, 20), &chaincfg.MainNetParams,
+	)
+	require.NoError(t, err)
+	prevTx := Transaction{
+		Vout: []TxOutput{*NewTXOutput(10, nil, addr.EncodeAddress())},
+	}
+	prevTx.ID = prevTx.Hash()
+	prevTXs := map[string]Transaction{
+		hex.EncodeToString(prevTx.ID): prevTx,
+	}
+
+	tx := Transaction{
+		Vin: []TxInput{{
+			ID:        prevTx.ID,
+			Vout:      0,
+			Signature: make([]byte, 64),
+		}},
+		Vout:    []TxOutput{*NewTXOutput(9.5, nil, addr.EncodeAddress())},
+		Version: versionTx,
+	}
+	tx.ID = tx.Hash()
+	require.False(t, tx.Verify(prevTXs))
+
+	msgTx, err := tx.MsgTx()
+	require.NoError(t, err)
+	prevOuts, err := prevOutputFetcher(&tx, prevTXs)
+	require.NoError(t, err)
+
+	checker := opt.NewTxAcceptanceChecker(prevOuts, nil, 1)
+	checker.VerifyInput = signatureVerifier(&tx, prevTXs)
+	var rejection *TxRejection
+	err = checker.AdmitTransaction(msgTx)
+	require.ErrorAs(t, err, &rejection)
+	require.Equal(t, RejectInvalidScript, rejection.Code, err.Error())
+	require.Equal(t, 0, rejection.Input)
+
+	// 找不到被花费的输出时被拒绝。
+	checker.PrevOuts = txscript.NewMultiPrevOutFetcher(nil)
+	err = checker.VerifyInputScripts(msgTx, 0)
+	require.ErrorAs(t, err, &rejection)
+	require.Equal(t, RejectMissingInputs, rejection.Code)
+	require.Equal(t, 0, rejection.Input)
+}